@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/ingest"
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// AddOptions holds parsed add command options
+type AddOptions struct {
+	ProjectID  string
+	GroupID    string
+	Title      string
+	Tags       string
+	Source     string
+	ConfigPath string
+	UseStdin   bool
+	Text       string
+	Attach     attachFlag
+	Importance float64 // 0 leaves it unset
+	// FrontMatter takes title/tags/source/createdAt from the text's YAML front matter
+	FrontMatter bool
+	// OnDuplicate is reject, merge or proceed when a highly similar note exists ("" skips the check)
+	OnDuplicate        string
+	DuplicateThreshold float64 // 0 uses the project's global.memory.duplicateThreshold
+	Model              string  // name of an additional embedder in the config's embedders ("" uses embedder)
+	RemoteOptions
+}
+
+// attachFlag collects repeated --attach values
+type attachFlag []string
+
+func (a *attachFlag) String() string {
+	return strings.Join(*a, ",")
+}
+
+func (a *attachFlag) Set(value string) error {
+	if value == "" {
+		return fmt.Errorf("attachment path must not be empty")
+	}
+	*a = append(*a, value)
+	return nil
+}
+
+// parseAddFlags parses command line arguments for add command
+func parseAddFlags(args []string) (*AddOptions, error) {
+	fs := flag.NewFlagSet("add", flag.ContinueOnError)
+	fs.SetOutput(io.Discard) // suppress default error output
+
+	opts := &AddOptions{}
+
+	// Long flags
+	fs.StringVar(&opts.ProjectID, "project", "", "Project ID/path (required)")
+	fs.StringVar(&opts.GroupID, "group", "", "Group ID (required unless the project sets a default group)")
+	fs.StringVar(&opts.Title, "title", "", "Note title")
+	fs.StringVar(&opts.Tags, "tags", "", "Tags (comma-separated)")
+	fs.StringVar(&opts.Source, "source", "", "Note source")
+	fs.StringVar(&opts.ConfigPath, "config", "", "Config file path")
+	fs.BoolVar(&opts.UseStdin, "stdin", false, "Read note text from stdin")
+	fs.Var(&opts.Attach, "attach", "File the note refers to (repeatable; its sha256 is recorded)")
+	fs.Float64Var(&opts.Importance, "importance", 0, "Importance (0-1) boosting the note in search results")
+	fs.BoolVar(&opts.FrontMatter, "front-matter", false, "Take title/tags/source/createdAt from the text's YAML front matter")
+	fs.StringVar(&opts.OnDuplicate, "on-duplicate", "", "When a similar note exists: reject, merge (tags) or proceed")
+	fs.Float64Var(&opts.DuplicateThreshold, "duplicate-threshold", 0, "Similarity (0-1) at which a note counts as a duplicate")
+	fs.StringVar(&opts.Model, "model", "", "Embed with this additional embedder from the config's embedders")
+	opts.registerFlags(fs)
+
+	// Short flags
+	fs.StringVar(&opts.ProjectID, "p", "", "Project ID/path (required)")
+	fs.StringVar(&opts.GroupID, "g", "", "Group ID (required unless the project sets a default group)")
+	fs.StringVar(&opts.ConfigPath, "c", "", "Config file path")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if err := applyFlagEnv(fs); err != nil {
+		return nil, err
+	}
+
+	// Get text from remaining args
+	opts.Text = strings.Join(fs.Args(), " ")
+
+	// Validation
+	if opts.ProjectID == "" {
+		return nil, fmt.Errorf("project ID is required (-p or --project)")
+	}
+	if !opts.UseStdin && opts.Text == "" {
+		return nil, fmt.Errorf("text is required (or use --stdin)")
+	}
+	if opts.Importance < 0 || opts.Importance > 1 {
+		return nil, fmt.Errorf("importance must be between 0 and 1")
+	}
+	switch opts.OnDuplicate {
+	case "", service.OnDuplicateReject, service.OnDuplicateMerge, service.OnDuplicateProceed:
+	default:
+		return nil, fmt.Errorf("--on-duplicate must be reject, merge or proceed")
+	}
+	if opts.DuplicateThreshold < 0 || opts.DuplicateThreshold > 1 {
+		return nil, fmt.Errorf("duplicate threshold must be between 0 and 1")
+	}
+	if opts.DuplicateThreshold > 0 && opts.OnDuplicate == "" {
+		return nil, fmt.Errorf("--duplicate-threshold requires --on-duplicate")
+	}
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	return opts, nil
+}
+
+// runAddCmd is the entry point for add command
+func runAddCmd(args []string) error {
+	opts, err := parseAddFlags(args)
+	if err != nil {
+		return err
+	}
+
+	// Read text from stdin if requested (multi-line allowed)
+	if opts.UseStdin {
+		text, err := readTextFromReader(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read text from stdin: %w", err)
+		}
+		opts.Text = text
+	}
+
+	if opts.Text == "" {
+		return fmt.Errorf("text is empty")
+	}
+
+	// Initialize services
+	ctx := context.Background()
+	noteService, cleanup, err := openNoteService(ctx, opts.ConfigPath, opts.RemoteOptions)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	// Execute add (projectId is canonicalized by NoteService)
+	resp, err := executeAddWithService(ctx, noteService, opts)
+	if errors.Is(err, service.ErrGroupIDRequired) {
+		return fmt.Errorf("group ID is required (-g or --group, defaultGroup in %s, or %s)", config.ProjectConfigFile, model.GlobalKeyDefaultGroup)
+	}
+	if err != nil {
+		return fmt.Errorf("add failed: %w", err)
+	}
+
+	switch {
+	case resp.Merged:
+		fmt.Fprintf(os.Stderr, "merged tags into similar note %s (score %.3f)\n", resp.DuplicateID, resp.DuplicateScore)
+	case resp.DuplicateID != "":
+		fmt.Fprintf(os.Stderr, "added although similar note %s exists (score %.3f)\n", resp.DuplicateID, resp.DuplicateScore)
+	}
+	fmt.Fprintln(os.Stdout, resp.ID)
+	return nil
+}
+
+// executeAddWithService adds a note using the provided NoteService
+func executeAddWithService(ctx context.Context, noteService service.NoteService, opts *AddOptions) (*service.AddNoteResponse, error) {
+	req := &service.AddNoteRequest{
+		ProjectID: opts.ProjectID,
+		GroupID:   opts.GroupID,
+		Text:      opts.Text,
+		Tags:      parseTags(opts.Tags),
+	}
+	if opts.Title != "" {
+		req.Title = &opts.Title
+	}
+	if opts.Source != "" {
+		req.Source = &opts.Source
+	}
+	if opts.Importance > 0 {
+		req.Importance = &opts.Importance
+	}
+	req.OnDuplicate = opts.OnDuplicate
+	req.Model = opts.Model
+	if opts.DuplicateThreshold > 0 {
+		req.DuplicateThreshold = &opts.DuplicateThreshold
+	}
+	if opts.FrontMatter {
+		applyFrontMatter(req)
+	}
+	for _, path := range opts.Attach {
+		attachment, err := fileAttachment(path)
+		if err != nil {
+			return nil, err
+		}
+		req.Attachments = append(req.Attachments, attachment)
+	}
+
+	return noteService.AddNote(ctx, req)
+}
+
+// applyFrontMatter moves the YAML front matter of req.Text into the request;
+// values given by flags take precedence and tags are merged
+func applyFrontMatter(req *service.AddNoteRequest) {
+	fm, body := ingest.ParseFrontMatter(strings.ReplaceAll(req.Text, "\r\n", "\n"))
+	if fm == nil {
+		return
+	}
+	req.Text = strings.TrimSpace(body)
+	if req.Title == nil && fm.Title != "" {
+		req.Title = &fm.Title
+	}
+	if req.Source == nil && fm.Source != "" {
+		req.Source = &fm.Source
+	}
+	if fm.CreatedAt != "" {
+		req.CreatedAt = &fm.CreatedAt
+	}
+	for _, tag := range fm.Tags {
+		if !slices.Contains(req.Tags, tag) {
+			req.Tags = append(req.Tags, tag)
+		}
+	}
+}
+
+// fileAttachment returns an attachment referring to path with the sha256 of its content
+func fileAttachment(path string) (model.Attachment, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return model.Attachment{}, fmt.Errorf("failed to read attachment: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return model.Attachment{Path: path, Hash: "sha256:" + hex.EncodeToString(sum[:])}, nil
+}
+
+// readTextFromReader reads the whole input as note text
+func readTextFromReader(r io.Reader) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	text := strings.TrimSpace(string(data))
+	if text == "" {
+		return "", fmt.Errorf("no input received")
+	}
+	return text, nil
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// TestParseAddFlags tests flag parsing for add command
+func TestParseAddFlags(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantTitle string
+		wantTags  string
+		wantStdin bool
+		wantText  string
+		wantErr   bool
+	}{
+		{
+			name:      "all flags",
+			args:      []string{"-p", "/test/project", "-g", "global", "--title", "Rule", "--tags", "rule,important", "--source", "cli", "note", "text"},
+			wantTitle: "Rule",
+			wantTags:  "rule,important",
+			wantText:  "note text",
+		},
+		{
+			name:      "stdin flag",
+			args:      []string{"--project", "/test/project", "--group", "global", "--stdin"},
+			wantStdin: true,
+		},
+		{
+			name:    "missing project",
+			args:    []string{"-g", "global", "text"},
+			wantErr: true,
+		},
+		{
+			// the project's default group is applied (or its absence reported) by the NoteService
+			name:     "missing group",
+			args:     []string{"-p", "/test/project", "text"},
+			wantText: "text",
+		},
+		{
+			name:     "on duplicate",
+			args:     []string{"-p", "/test/project", "-g", "global", "--on-duplicate", "merge", "--duplicate-threshold", "0.9", "text"},
+			wantText: "text",
+		},
+		{
+			name:    "invalid on duplicate",
+			args:    []string{"-p", "/test/project", "-g", "global", "--on-duplicate", "skip", "text"},
+			wantErr: true,
+		},
+		{
+			name:    "duplicate threshold without on duplicate",
+			args:    []string{"-p", "/test/project", "-g", "global", "--duplicate-threshold", "0.9", "text"},
+			wantErr: true,
+		},
+		{
+			name:    "missing text",
+			args:    []string{"-p", "/test/project", "-g", "global"},
+			wantErr: true,
+		},
+		{
+			name:    "importance out of range",
+			args:    []string{"-p", "/test/project", "--importance", "1.5", "text"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := parseAddFlags(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAddFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if opts.Title != tt.wantTitle {
+				t.Errorf("Title = %q, want %q", opts.Title, tt.wantTitle)
+			}
+			if opts.Tags != tt.wantTags {
+				t.Errorf("Tags = %q, want %q", opts.Tags, tt.wantTags)
+			}
+			if opts.UseStdin != tt.wantStdin {
+				t.Errorf("UseStdin = %v, want %v", opts.UseStdin, tt.wantStdin)
+			}
+			if opts.Text != tt.wantText {
+				t.Errorf("Text = %q, want %q", opts.Text, tt.wantText)
+			}
+		})
+	}
+}
+
+// TestParseAddFlags_ProjectDefaultGroup tests that --group may be omitted when .mcp-memory.json sets defaultGroup
+func TestParseAddFlags_ProjectDefaultGroup(t *testing.T) {
+	project := t.TempDir()
+	if err := config.SaveProjectConfig(filepath.Join(project, config.ProjectConfigFile), &config.ProjectConfig{ProjectID: project, DefaultGroup: "docs"}); err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := parseAddFlags([]string{"-p", project, "text"})
+	if err != nil {
+		t.Fatalf("parseAddFlags() error = %v", err)
+	}
+	if opts.GroupID != "" {
+		t.Errorf("GroupID = %q, want empty (applied by NoteService)", opts.GroupID)
+	}
+}
+
+// TestExecuteAdd tests the add execution logic
+func TestExecuteAdd(t *testing.T) {
+	mockService := &mockNoteService{
+		addNoteFunc: func(ctx context.Context, req *service.AddNoteRequest) (*service.AddNoteResponse, error) {
+			if req.ProjectID != "/test/project" || req.GroupID != "global" {
+				t.Errorf("unexpected project/group: %q/%q", req.ProjectID, req.GroupID)
+			}
+			if req.Title == nil || *req.Title != "Rule" {
+				t.Errorf("expected title Rule, got %v", req.Title)
+			}
+			if req.Source != nil {
+				t.Errorf("expected nil source, got %v", *req.Source)
+			}
+			if len(req.Tags) != 2 || req.Tags[0] != "a" || req.Tags[1] != "b" {
+				t.Errorf("expected tags [a b], got %v", req.Tags)
+			}
+			if req.Model != "code" {
+				t.Errorf("expected model code, got %q", req.Model)
+			}
+			return &service.AddNoteResponse{ID: "new-id"}, nil
+		},
+	}
+
+	resp, err := executeAddWithService(context.Background(), mockService, &AddOptions{
+		ProjectID: "/test/project",
+		GroupID:   "global",
+		Title:     "Rule",
+		Tags:      "a, b",
+		Text:      "text",
+		Model:     "code",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ID != "new-id" {
+		t.Errorf("expected id new-id, got %q", resp.ID)
+	}
+
+	// サービスのエラーはそのまま返す
+	mockService.addNoteFunc = func(ctx context.Context, req *service.AddNoteRequest) (*service.AddNoteResponse, error) {
+		return nil, errors.New("embed failed")
+	}
+	if _, err := executeAddWithService(context.Background(), mockService, &AddOptions{Text: "x"}); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
+
+// TestExecuteAdd_Attach tests that --attach files are sent with their sha256
+func TestExecuteAdd_Attach(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "design.md")
+	if err := os.WriteFile(path, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []model.Attachment
+	mockService := &mockNoteService{
+		addNoteFunc: func(ctx context.Context, req *service.AddNoteRequest) (*service.AddNoteResponse, error) {
+			got = req.Attachments
+			return &service.AddNoteResponse{ID: "new-id"}, nil
+		},
+	}
+	if _, err := executeAddWithService(context.Background(), mockService, &AddOptions{Text: "x", Attach: attachFlag{path}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	if len(got) != 1 || got[0].Path != path || got[0].Hash != want {
+		t.Errorf("unexpected attachments: %+v", got)
+	}
+
+	if _, err := executeAddWithService(context.Background(), mockService, &AddOptions{Text: "x", Attach: attachFlag{path + ".missing"}}); err == nil {
+		t.Error("expected error for a missing attachment, got nil")
+	}
+}
+
+// TestExecuteAdd_Importance tests that --importance is passed only when set
+func TestExecuteAdd_Importance(t *testing.T) {
+	var got *float64
+	mockService := &mockNoteService{
+		addNoteFunc: func(ctx context.Context, req *service.AddNoteRequest) (*service.AddNoteResponse, error) {
+			got = req.Importance
+			return &service.AddNoteResponse{ID: "new-id"}, nil
+		},
+	}
+	if _, err := executeAddWithService(context.Background(), mockService, &AddOptions{Text: "x", Importance: 0.9}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got == nil || *got != 0.9 {
+		t.Errorf("expected importance 0.9, got %v", got)
+	}
+	if _, err := executeAddWithService(context.Background(), mockService, &AddOptions{Text: "x"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != nil {
+		t.Errorf("expected no importance, got %v", *got)
+	}
+}
+
+// TestExecuteAdd_FrontMatter tests that --front-matter fills fields not given by flags
+func TestExecuteAdd_FrontMatter(t *testing.T) {
+	var got *service.AddNoteRequest
+	mockService := &mockNoteService{
+		addNoteFunc: func(ctx context.Context, req *service.AddNoteRequest) (*service.AddNoteResponse, error) {
+			got = req
+			return &service.AddNoteResponse{ID: "new-id"}, nil
+		},
+	}
+	text := "---\r\ntitle: From front matter\r\ntags: [go, rule]\r\nsource: docs/rule.md\r\ndate: 2024-03-01\r\n---\r\n\r\nuse tabs"
+
+	opts := &AddOptions{Text: text, Title: "From flag", Tags: "rule,style", FrontMatter: true}
+	if _, err := executeAddWithService(context.Background(), mockService, opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Text != "use tabs" {
+		t.Errorf("expected front matter to be removed, got %q", got.Text)
+	}
+	if *got.Title != "From flag" || *got.Source != "docs/rule.md" || *got.CreatedAt != "2024-03-01T00:00:00Z" {
+		t.Errorf("unexpected fields: title=%q source=%q createdAt=%q", *got.Title, *got.Source, *got.CreatedAt)
+	}
+	if want := []string{"rule", "style", "go"}; !reflect.DeepEqual(got.Tags, want) {
+		t.Errorf("expected tags %v, got %v", want, got.Tags)
+	}
+
+	// without --front-matter the text is stored as is
+	if _, err := executeAddWithService(context.Background(), mockService, &AddOptions{Text: text}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Text != text || got.Title != nil || got.CreatedAt != nil {
+		t.Errorf("expected text unchanged, got %+v", got)
+	}
+}
+
+// TestReadTextFromReader tests reading multi-line note text
+func TestReadTextFromReader(t *testing.T) {
+	text, err := readTextFromReader(strings.NewReader("line1\nline2\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if text != "line1\nline2" {
+		t.Errorf("expected multi-line text, got %q", text)
+	}
+
+	if _, err := readTextFromReader(strings.NewReader("  \n")); err == nil {
+		t.Error("expected error for empty input, got nil")
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/bootstrap"
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// defaultArchiveCheckInterval is how often serve archives notes not accessed in archive.afterDays days
+const defaultArchiveCheckInterval = 24 * time.Hour
+
+// ArchiveOptions holds parsed archive command options
+type ArchiveOptions struct {
+	ProjectID     string
+	OlderThanDays int
+	DryRun        bool
+	List          bool
+	Restore       bool
+	IDs           []string // note IDs to restore
+	Yes           bool
+	ConfigPath    string
+}
+
+// parseArchiveFlags parses command line arguments for archive command
+func parseArchiveFlags(args []string) (*ArchiveOptions, error) {
+	fs := flag.NewFlagSet("archive", flag.ContinueOnError)
+	fs.SetOutput(io.Discard) // suppress default error output
+
+	opts := &ArchiveOptions{}
+
+	// Long flags
+	fs.StringVar(&opts.ProjectID, "project", "", "Project ID/path (default: all projects)")
+	fs.IntVar(&opts.OlderThanDays, "older-than-days", 0, "Archive notes not accessed in this many days (default: archive.afterDays)")
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "Show the notes to archive without moving them")
+	fs.BoolVar(&opts.List, "list", false, "List archived notes")
+	fs.BoolVar(&opts.Restore, "restore", false, "Move the archived notes given as arguments back")
+	fs.BoolVar(&opts.Yes, "yes", false, "Do not ask for confirmation")
+	fs.StringVar(&opts.ConfigPath, "config", "", "Config file path")
+
+	// Short flags
+	fs.StringVar(&opts.ProjectID, "p", "", "Project ID/path (default: all projects)")
+	fs.BoolVar(&opts.Yes, "y", false, "Do not ask for confirmation")
+	fs.StringVar(&opts.ConfigPath, "c", "", "Config file path")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if err := applyFlagEnv(fs); err != nil {
+		return nil, err
+	}
+
+	// Validation
+	if opts.List && opts.Restore {
+		return nil, errors.New("--list and --restore cannot be used together")
+	}
+	if opts.Restore {
+		if fs.NArg() == 0 {
+			return nil, errors.New("--restore requires note IDs")
+		}
+		opts.IDs = fs.Args()
+	} else if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+	if opts.OlderThanDays < 0 {
+		return nil, fmt.Errorf("--older-than-days must be greater than 0, got %d", opts.OlderThanDays)
+	}
+
+	return opts, nil
+}
+
+// runArchiveCmd is the entry point for archive command
+func runArchiveCmd(args []string) error {
+	opts, err := parseArchiveFlags(args)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	services, cleanup, err := bootstrap.Initialize(ctx, opts.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+	defer cleanup()
+
+	if err := executeArchiveWithService(ctx, services.ArchiveService, opts, os.Stdin, os.Stdout, os.Stderr); err != nil {
+		return fmt.Errorf("archive failed: %w", err)
+	}
+	return nil
+}
+
+// executeArchiveWithService lists (--list) or restores (--restore) archived notes, or lists the notes
+// not accessed recently, asks for confirmation on in/errOut unless opts.Yes, and archives them.
+// Lists go to out; progress and prompts go to errOut.
+func executeArchiveWithService(ctx context.Context, archive service.ArchiveService, opts *ArchiveOptions, in io.Reader, out, errOut io.Writer) error {
+	switch {
+	case opts.List:
+		resp, err := archive.ListArchived(ctx, &service.ListArchivedRequest{ProjectID: opts.ProjectID})
+		if err != nil {
+			return err
+		}
+		printArchivedNotes(out, resp.Notes)
+		fmt.Fprintf(errOut, "%d archived notes in %s\n", len(resp.Notes), resp.Namespace)
+		return nil
+	case opts.Restore:
+		resp, err := archive.Restore(ctx, &service.RestoreRequest{IDs: opts.IDs})
+		if err != nil {
+			return err
+		}
+		for _, id := range resp.NotFound {
+			fmt.Fprintf(errOut, "%s: not in the archive\n", id)
+		}
+		fmt.Fprintf(errOut, "restored %d notes\n", len(resp.Restored))
+		return nil
+	}
+
+	plan, err := archive.Archive(ctx, &service.ArchiveRequest{ProjectID: opts.ProjectID, OlderThanDays: opts.OlderThanDays, DryRun: true})
+	if err != nil {
+		return err
+	}
+	printArchivedNotes(out, plan.Notes)
+	if len(plan.Notes) == 0 {
+		fmt.Fprintln(errOut, "nothing to archive")
+		return nil
+	}
+	if opts.DryRun {
+		return nil
+	}
+
+	if !opts.Yes && !confirm(in, errOut, fmt.Sprintf("Archive %d notes?", len(plan.Notes))) {
+		fmt.Fprintln(errOut, "archive cancelled")
+		return nil
+	}
+
+	resp, err := archive.Archive(ctx, &service.ArchiveRequest{ProjectID: opts.ProjectID, OlderThanDays: opts.OlderThanDays})
+	if err != nil {
+		if resp != nil {
+			fmt.Fprintf(errOut, "archived %d notes before the error; re-run to archive the rest\n", len(resp.Notes))
+		}
+		return err
+	}
+	fmt.Fprintf(errOut, "archived %d notes\n", len(resp.Notes))
+	return nil
+}
+
+// printArchivedNotes writes one line per note: projectId, id, createdAt and title
+func printArchivedNotes(out io.Writer, notes []service.ArchivedNote) {
+	for _, n := range notes {
+		title := ""
+		if n.Title != nil {
+			title = *n.Title
+		}
+		fmt.Fprintf(out, "%s %s %s %s\n", n.ProjectID, n.ID, n.CreatedAt, title)
+	}
+}
+
+// archiveCheckInterval returns archive.checkInterval, or 0 (disabled) without archive.afterDays
+func archiveCheckInterval(cfg *model.Config) time.Duration {
+	a := cfg.Archive
+	if a == nil || a.AfterDays <= 0 {
+		return 0
+	}
+	if a.CheckInterval != "" {
+		// validated when the config was loaded
+		if d, err := config.ParseDuration(a.CheckInterval); err == nil {
+			return d
+		}
+	}
+	return defaultArchiveCheckInterval
+}
+
+// watchArchive archives notes not accessed in archive.afterDays days at startup and then every
+// interval until ctx is cancelled. It does nothing if interval is 0.
+func watchArchive(ctx context.Context, archive service.ArchiveService, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	archiveStaleNotes(ctx, archive)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			archiveStaleNotes(ctx, archive)
+		}
+	}
+}
+
+// archiveStaleNotes archives notes not accessed in archive.afterDays days and logs how many moved
+func archiveStaleNotes(ctx context.Context, archive service.ArchiveService) {
+	resp, err := archive.Archive(ctx, &service.ArchiveRequest{})
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Warn("failed to archive notes", "error", err)
+		}
+		return
+	}
+	if len(resp.Notes) > 0 {
+		slog.Info("archived notes not accessed recently", "namespace", resp.Namespace, "notes", len(resp.Notes))
+	}
+}
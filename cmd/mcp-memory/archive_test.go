@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// mockArchiveService is a mock implementation of service.ArchiveService
+type mockArchiveService struct {
+	notes    []service.ArchivedNote
+	archived bool
+	restored []string
+}
+
+func (m *mockArchiveService) Archive(ctx context.Context, req *service.ArchiveRequest) (*service.ArchiveResponse, error) {
+	if !req.DryRun {
+		m.archived = true
+	}
+	return &service.ArchiveResponse{Namespace: "openai:test:3", Notes: m.notes}, nil
+}
+
+func (m *mockArchiveService) Restore(ctx context.Context, req *service.RestoreRequest) (*service.RestoreResponse, error) {
+	m.restored = req.IDs
+	return &service.RestoreResponse{Restored: req.IDs[:1], NotFound: req.IDs[1:]}, nil
+}
+
+func (m *mockArchiveService) ListArchived(ctx context.Context, req *service.ListArchivedRequest) (*service.ListArchivedResponse, error) {
+	return &service.ListArchivedResponse{Namespace: "openai:test:3", Notes: m.notes}, nil
+}
+
+// TestParseArchiveFlags tests flag parsing for archive command
+func TestParseArchiveFlags(t *testing.T) {
+	opts, err := parseArchiveFlags([]string{"-p", "/test/project", "--older-than-days", "90", "--dry-run", "-y", "-c", "config.json"})
+	if err != nil {
+		t.Fatalf("parseArchiveFlags() error = %v", err)
+	}
+	if opts.ProjectID != "/test/project" || opts.OlderThanDays != 90 || !opts.DryRun || !opts.Yes || opts.ConfigPath != "config.json" {
+		t.Errorf("parseArchiveFlags() = %+v", opts)
+	}
+
+	opts, err = parseArchiveFlags([]string{"--restore", "n1", "n2"})
+	if err != nil || !opts.Restore || strings.Join(opts.IDs, ",") != "n1,n2" {
+		t.Errorf("parseArchiveFlags(--restore) = %+v, %v", opts, err)
+	}
+
+	for _, args := range [][]string{{"extra"}, {"--restore"}, {"--list", "--restore", "n1"}, {"--older-than-days", "-1"}} {
+		if _, err := parseArchiveFlags(args); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+}
+
+// TestExecuteArchive tests confirmation, dry-run, --list and --restore
+func TestExecuteArchive(t *testing.T) {
+	title := "Old decision"
+	notes := []service.ArchivedNote{{ID: "n1", ProjectID: "/test/project", GroupID: "global", Title: &title, CreatedAt: "2024-01-15T10:00:00Z"}}
+	tests := []struct {
+		name         string
+		notes        []service.ArchivedNote
+		opts         ArchiveOptions
+		input        string
+		wantArchived bool
+	}{
+		{name: "confirmed", notes: notes, input: "y\n", wantArchived: true},
+		{name: "declined", notes: notes, input: "n\n"},
+		{name: "yes flag", notes: notes, opts: ArchiveOptions{Yes: true}, wantArchived: true},
+		{name: "dry run", notes: notes, opts: ArchiveOptions{DryRun: true, Yes: true}},
+		{name: "nothing to archive", opts: ArchiveOptions{Yes: true}},
+		{name: "list", notes: notes, opts: ArchiveOptions{List: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockArchiveService{notes: tt.notes}
+			var out, errOut bytes.Buffer
+			if err := executeArchiveWithService(context.Background(), mockService, &tt.opts, strings.NewReader(tt.input), &out, &errOut); err != nil {
+				t.Fatalf("executeArchiveWithService() error = %v", err)
+			}
+			if mockService.archived != tt.wantArchived {
+				t.Fatalf("archived = %v, want %v (output: %s)", mockService.archived, tt.wantArchived, errOut.String())
+			}
+			if len(tt.notes) > 0 && out.String() != "/test/project n1 2024-01-15T10:00:00Z Old decision\n" {
+				t.Errorf("unexpected list: %q", out.String())
+			}
+		})
+	}
+
+	mockService := &mockArchiveService{}
+	var out, errOut bytes.Buffer
+	opts := &ArchiveOptions{Restore: true, IDs: []string{"n1", "missing"}}
+	if err := executeArchiveWithService(context.Background(), mockService, opts, strings.NewReader(""), &out, &errOut); err != nil {
+		t.Fatalf("executeArchiveWithService() error = %v", err)
+	}
+	if len(mockService.restored) != 2 || !strings.Contains(errOut.String(), "missing: not in the archive") || !strings.Contains(errOut.String(), "restored 1 notes") {
+		t.Errorf("unexpected restore: %v, %s", mockService.restored, errOut.String())
+	}
+}
+
+// TestArchiveCheckInterval tests that serve archives only with archive.afterDays
+func TestArchiveCheckInterval(t *testing.T) {
+	if got := archiveCheckInterval(&model.Config{}); got != 0 {
+		t.Errorf("expected 0 (disabled) without archive, got %s", got)
+	}
+	cfg := &model.Config{Archive: &model.ArchiveConfig{AfterDays: 90}}
+	if got := archiveCheckInterval(cfg); got != defaultArchiveCheckInterval {
+		t.Errorf("expected the default interval, got %s", got)
+	}
+	cfg.Archive.CheckInterval = "6h"
+	if got := archiveCheckInterval(cfg); got != 6*time.Hour {
+		t.Errorf("expected 6h, got %s", got)
+	}
+	cfg.Archive.CheckInterval = "0"
+	if got := archiveCheckInterval(cfg); got != 0 {
+		t.Errorf("expected 0 (disabled), got %s", got)
+	}
+}
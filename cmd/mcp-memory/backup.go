@@ -0,0 +1,486 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/backup"
+	"github.com/brbranch/embedding_mcp/internal/bootstrap"
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/objstore"
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// backupDirName is the default backup directory under the data dir
+const backupDirName = "backups"
+
+// BackupOptions holds parsed backup command options
+type BackupOptions struct {
+	Output     string
+	Keep       int
+	ConfigPath string
+}
+
+// RestoreOptions holds parsed restore command options
+type RestoreOptions struct {
+	Input        string
+	SkipExisting bool
+	Overwrite    bool
+	WithConfig   bool
+	ConfigPath   string
+}
+
+// parseBackupFlags parses command line arguments for backup command
+func parseBackupFlags(args []string) (*BackupOptions, error) {
+	fs := flag.NewFlagSet("backup", flag.ContinueOnError)
+	fs.SetOutput(io.Discard) // suppress default error output
+
+	opts := &BackupOptions{}
+
+	// Long flags
+	fs.StringVar(&opts.Output, "output", "", "Output directory, .tar.zst file or s3://, gs://, az:// URL (default: <dataDir>/backups)")
+	fs.IntVar(&opts.Keep, "keep", 0, "Number of backups to keep in the output directory (0: keep all)")
+	fs.StringVar(&opts.ConfigPath, "config", "", "Config file path")
+
+	// Short flags
+	fs.StringVar(&opts.Output, "o", "", "Output directory, .tar.zst file or storage URL")
+	fs.StringVar(&opts.ConfigPath, "c", "", "Config file path")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if err := applyFlagEnv(fs); err != nil {
+		return nil, err
+	}
+
+	// Validation
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+	if opts.Keep < 0 {
+		return nil, fmt.Errorf("--keep must be >= 0")
+	}
+	if opts.Keep > 0 && strings.HasSuffix(opts.Output, backup.FileSuffix) {
+		return nil, fmt.Errorf("--keep requires an output directory")
+	}
+	if objstore.IsURL(opts.Output) {
+		if _, err := objstore.Parse(opts.Output); err != nil {
+			return nil, err
+		}
+	}
+
+	return opts, nil
+}
+
+// parseRestoreFlags parses command line arguments for restore command
+func parseRestoreFlags(args []string) (*RestoreOptions, error) {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	fs.SetOutput(io.Discard) // suppress default error output
+
+	opts := &RestoreOptions{}
+
+	// Long flags
+	fs.BoolVar(&opts.SkipExisting, "skip-existing", false, "Keep records that already exist")
+	fs.BoolVar(&opts.Overwrite, "overwrite", false, "Overwrite records that already exist")
+	fs.BoolVar(&opts.WithConfig, "with-config", false, "Also restore the archived config (paths and API key are kept)")
+	fs.StringVar(&opts.ConfigPath, "config", "", "Config file path")
+
+	// Short flags
+	fs.StringVar(&opts.ConfigPath, "c", "", "Config file path")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	// Archive path, optionally followed by more flags (restore backup.tar.zst --overwrite)
+	opts.Input = fs.Arg(0)
+	if fs.NArg() > 1 {
+		if err := fs.Parse(fs.Args()[1:]); err != nil {
+			return nil, err
+		}
+		if fs.NArg() > 0 {
+			return nil, fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+		}
+	}
+	if err := applyFlagEnv(fs); err != nil {
+		return nil, err
+	}
+
+	// Validation
+	if opts.Input == "" {
+		return nil, fmt.Errorf("backup archive is required")
+	}
+	if objstore.IsURL(opts.Input) {
+		if _, err := objstore.Parse(opts.Input); err != nil {
+			return nil, err
+		}
+	}
+	if opts.SkipExisting && opts.Overwrite {
+		return nil, fmt.Errorf("--skip-existing and --overwrite are mutually exclusive")
+	}
+
+	return opts, nil
+}
+
+// mode returns the import mode selected by the flags
+func (o *RestoreOptions) mode() service.ImportMode {
+	switch {
+	case o.SkipExisting:
+		return service.ImportModeSkipExisting
+	case o.Overwrite:
+		return service.ImportModeOverwrite
+	default:
+		return service.ImportModeFail
+	}
+}
+
+// runBackupCmd is the entry point for backup command
+func runBackupCmd(args []string) error {
+	opts, err := parseBackupFlags(args)
+	if err != nil {
+		return err
+	}
+
+	// Initialize services
+	ctx := context.Background()
+	services, cleanup, err := bootstrap.Initialize(ctx, opts.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+	defer cleanup()
+
+	b := backup.New(services.NoteService, services.ExportService)
+	if objstore.IsURL(opts.Output) {
+		return runRemoteBackup(ctx, b, opts, services.Config)
+	}
+
+	dir, path, err := backupOutputPath(opts.Output, services.Config, time.Now())
+	if err != nil {
+		return err
+	}
+
+	manifest, err := writeBackupFile(ctx, b, path, services.Config)
+	if err != nil {
+		return fmt.Errorf("backup failed: %w", err)
+	}
+	printBackupSummary(manifest)
+	fmt.Fprintln(os.Stdout, path)
+
+	if opts.Keep > 0 && dir != "" {
+		removed, err := backup.Prune(dir, opts.Keep)
+		if err != nil {
+			return err
+		}
+		for _, p := range removed {
+			fmt.Fprintf(os.Stderr, "removed old backup %s\n", p)
+		}
+	}
+	return nil
+}
+
+// printBackupSummary prints the number of backed up projects and notes to stderr
+func printBackupSummary(manifest *backup.Manifest) {
+	notes := 0
+	for _, p := range manifest.Projects {
+		notes += p.Notes
+	}
+	fmt.Fprintf(os.Stderr, "backed up %d projects (%d notes)\n", len(manifest.Projects), notes)
+}
+
+// runRemoteBackup writes the archive to a local temp file and uploads it to object storage
+func runRemoteBackup(ctx context.Context, b *backup.Backup, opts *BackupOptions, cfg *model.Config) error {
+	loc, err := objstore.Parse(opts.Output)
+	if err != nil {
+		return err
+	}
+	bucket, err := objstore.Open(ctx, loc)
+	if err != nil {
+		return err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "mcp-memory-backup-")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	prefix, key := remoteBackupKey(loc.Key, time.Now())
+	tmpPath := filepath.Join(tmpDir, path.Base(key))
+	manifest, err := writeBackupFile(ctx, b, tmpPath, cfg)
+	if err != nil {
+		return fmt.Errorf("backup failed: %w", err)
+	}
+	if err := uploadBackup(ctx, bucket, key, tmpPath); err != nil {
+		return fmt.Errorf("failed to upload backup: %w", err)
+	}
+	printBackupSummary(manifest)
+	fmt.Fprintln(os.Stdout, bucket.URL(key))
+
+	if opts.Keep > 0 {
+		removed, err := pruneRemoteBackups(ctx, bucket, prefix, opts.Keep)
+		if err != nil {
+			return err
+		}
+		for _, key := range removed {
+			fmt.Fprintf(os.Stderr, "removed old backup %s\n", bucket.URL(key))
+		}
+	}
+	return nil
+}
+
+// remoteBackupKey resolves the object key for a storage URL path.
+// A path ending in .tar.zst is used as is; otherwise it is a prefix ("directory") for a timestamped archive.
+func remoteBackupKey(urlPath string, now time.Time) (prefix, key string) {
+	if strings.HasSuffix(urlPath, backup.FileSuffix) {
+		return "", urlPath
+	}
+	if urlPath != "" && !strings.HasSuffix(urlPath, "/") {
+		urlPath += "/"
+	}
+	return urlPath, urlPath + backup.FileName(now)
+}
+
+// uploadBackup uploads the archive file to key
+func uploadBackup(ctx context.Context, bucket objstore.Bucket, key, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return bucket.Put(ctx, key, f)
+}
+
+// remoteArchives lists the backup archives directly under prefix
+func remoteArchives(ctx context.Context, bucket objstore.Bucket, prefix string) ([]string, error) {
+	objects, err := bucket.List(ctx, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+	var names []string
+	for _, o := range objects {
+		name := strings.TrimPrefix(o.Key, prefix)
+		if !strings.Contains(name, "/") && backup.IsArchiveName(name) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// pruneRemoteBackups keeps the newest keep archives under prefix and returns the deleted keys
+func pruneRemoteBackups(ctx context.Context, bucket objstore.Bucket, prefix string, keep int) ([]string, error) {
+	names, err := remoteArchives(ctx, bucket, prefix)
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for _, name := range backup.Expired(names, keep) {
+		if err := bucket.Delete(ctx, prefix+name); err != nil {
+			return removed, fmt.Errorf("failed to remove old backup: %w", err)
+		}
+		removed = append(removed, prefix+name)
+	}
+	return removed, nil
+}
+
+// downloadBackup downloads the archive at urlPath into w and returns its key.
+// If urlPath is not a .tar.zst object, the newest archive under that prefix is used.
+func downloadBackup(ctx context.Context, bucket objstore.Bucket, urlPath string, w io.Writer) (string, error) {
+	key := urlPath
+	if !strings.HasSuffix(urlPath, backup.FileSuffix) {
+		prefix, _ := remoteBackupKey(urlPath, time.Time{})
+		names, err := remoteArchives(ctx, bucket, prefix)
+		if err != nil {
+			return "", err
+		}
+		latest := backup.Expired(names, 0) // all archives, newest first
+		if len(latest) == 0 {
+			return "", fmt.Errorf("no backups found in %s", bucket.URL(prefix))
+		}
+		key = prefix + latest[0]
+	}
+
+	rc, err := bucket.Get(ctx, key)
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", bucket.URL(key), err)
+	}
+	defer rc.Close()
+	if _, err := io.Copy(w, rc); err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", bucket.URL(key), err)
+	}
+	return key, nil
+}
+
+// openRestoreInput opens the archive to restore, downloading it to a temp file for a storage URL
+func openRestoreInput(ctx context.Context, input string) (*os.File, func(), error) {
+	if !objstore.IsURL(input) {
+		f, err := os.Open(input)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open backup archive: %w", err)
+		}
+		return f, func() { f.Close() }, nil
+	}
+
+	loc, err := objstore.Parse(input)
+	if err != nil {
+		return nil, nil, err
+	}
+	bucket, err := objstore.Open(ctx, loc)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.CreateTemp("", "mcp-memory-restore-*"+backup.FileSuffix)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	cleanup := func() {
+		f.Close()
+		os.Remove(f.Name())
+	}
+	key, err := downloadBackup(ctx, bucket, loc.Key, f)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to read backup archive: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "downloaded %s\n", bucket.URL(key))
+	return f, cleanup, nil
+}
+
+// backupOutputPath resolves the archive path for --output.
+// It returns the backup directory (empty if an explicit file was given) and the archive path.
+func backupOutputPath(output string, cfg *model.Config, now time.Time) (string, string, error) {
+	if strings.HasSuffix(output, backup.FileSuffix) {
+		return "", output, nil
+	}
+
+	dir := output
+	if dir == "" {
+		dataDir, err := instanceDataDir(cfg)
+		if err != nil {
+			return "", "", err
+		}
+		dir = filepath.Join(dataDir, backupDirName)
+	}
+	return dir, filepath.Join(dir, backup.FileName(now)), nil
+}
+
+// writeBackupFile writes the archive to a temp file and renames it into place,
+// so that an interrupted scheduled run never leaves a truncated backup behind
+func writeBackupFile(ctx context.Context, b *backup.Backup, path string, cfg *model.Config) (*backup.Manifest, error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".backup-*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backup file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	manifest, err := b.Write(ctx, tmp, cfg, version)
+	if err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write backup file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return nil, fmt.Errorf("failed to write backup file: %w", err)
+	}
+	return manifest, nil
+}
+
+// runRestoreCmd is the entry point for restore command
+func runRestoreCmd(args []string) error {
+	opts, err := parseRestoreFlags(args)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	f, closeInput, err := openRestoreInput(ctx, opts.Input)
+	if err != nil {
+		return err
+	}
+	defer closeInput()
+
+	manifest, err := backup.ReadManifest(f)
+	if err != nil {
+		return err
+	}
+
+	// The config has to be in place before the store and embedder are opened
+	if opts.WithConfig {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("failed to read backup archive: %w", err)
+		}
+		if err := restoreConfig(f, opts.ConfigPath); err != nil {
+			return err
+		}
+	}
+
+	// Initialize services
+	services, cleanup, err := bootstrap.Initialize(ctx, opts.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+	defer cleanup()
+
+	if manifest.Namespace != services.Namespace {
+		fmt.Fprintf(os.Stderr, "warning: backup namespace %s differs from %s; notes will be re-embedded\n",
+			manifest.Namespace, services.Namespace)
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read backup archive: %w", err)
+	}
+	result, err := backup.New(services.NoteService, services.ExportService).Restore(ctx, f, opts.mode())
+	if result != nil {
+		for _, resp := range result.Projects {
+			fmt.Fprintf(os.Stdout, "restored %s: %d created, %d updated, %d skipped (%d re-embedded)\n",
+				resp.ProjectID, resp.Created, resp.Updated, resp.Skipped, resp.ReEmbedded)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("restore failed: %w", err)
+	}
+	return nil
+}
+
+// restoreConfig writes the archived config to the config file
+func restoreConfig(r io.Reader, configPath string) error {
+	cfg, err := backup.ReadConfig(r)
+	if errors.Is(err, backup.ErrNoConfig) {
+		fmt.Fprintln(os.Stderr, "warning: backup has no config; keeping the current config")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Replace is used on an unloaded manager so environment overrides are not persisted
+	manager, err := config.NewManager(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to create config manager: %w", err)
+	}
+	if err := manager.Replace(cfg); err != nil {
+		return fmt.Errorf("failed to restore config: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "restored config to %s\n", manager.GetConfigPath())
+	return nil
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/backup"
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/objstore"
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+func TestParseBackupFlags(t *testing.T) {
+	opts, err := parseBackupFlags([]string{"-o", "/backups", "--keep", "7"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.Output != "/backups" || opts.Keep != 7 {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+
+	errTests := []struct {
+		name string
+		args []string
+	}{
+		{"negative keep", []string{"--keep", "-1"}},
+		{"keep with file output", []string{"-o", "snap.tar.zst", "--keep", "3"}},
+		{"unexpected argument", []string{"extra"}},
+		{"storage URL without bucket", []string{"-o", "s3:///backups/"}},
+	}
+	for _, tt := range errTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseBackupFlags(tt.args); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestParseRestoreFlags(t *testing.T) {
+	opts, err := parseRestoreFlags([]string{"snap.tar.zst", "--overwrite", "--with-config"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.Input != "snap.tar.zst" || !opts.WithConfig || opts.mode() != service.ImportModeOverwrite {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+
+	if _, err := parseRestoreFlags(nil); err == nil {
+		t.Error("expected error without archive")
+	}
+	if _, err := parseRestoreFlags([]string{"--skip-existing", "--overwrite", "snap.tar.zst"}); err == nil {
+		t.Error("expected error for mutually exclusive modes")
+	}
+}
+
+func TestBackupOutputPath(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	cfg := &model.Config{Paths: model.PathsConfig{DataDir: "/data"}}
+
+	dir, path, err := backupOutputPath("", cfg, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dir != filepath.Join("/data", "backups") || path != filepath.Join(dir, "mcp-memory-20240102T030405Z.tar.zst") {
+		t.Errorf("unexpected default path: dir=%q path=%q", dir, path)
+	}
+
+	dir, path, _ = backupOutputPath("/mnt/backups", cfg, now)
+	if dir != "/mnt/backups" || !strings.HasPrefix(path, "/mnt/backups") {
+		t.Errorf("unexpected directory output: dir=%q path=%q", dir, path)
+	}
+
+	dir, path, _ = backupOutputPath("/tmp/snap.tar.zst", cfg, now)
+	if dir != "" || path != "/tmp/snap.tar.zst" {
+		t.Errorf("unexpected file output: dir=%q path=%q", dir, path)
+	}
+}
+
+// memBucket is an in-memory objstore.Bucket for testing
+type memBucket struct {
+	objects map[string][]byte
+}
+
+func newMemBucket() *memBucket {
+	return &memBucket{objects: make(map[string][]byte)}
+}
+
+func (b *memBucket) Put(ctx context.Context, key string, r io.ReadSeeker) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	b.objects[key] = data
+	return nil
+}
+
+func (b *memBucket) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	data, ok := b.objects[key]
+	if !ok {
+		return nil, objstore.ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (b *memBucket) List(ctx context.Context, prefix string) ([]objstore.Object, error) {
+	var objects []objstore.Object
+	for key, data := range b.objects {
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, objstore.Object{Key: key, Size: int64(len(data))})
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+func (b *memBucket) Delete(ctx context.Context, key string) error {
+	delete(b.objects, key)
+	return nil
+}
+
+func (b *memBucket) URL(key string) string { return "mem://bucket/" + key }
+
+func TestRemoteBackupKey(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		urlPath    string
+		wantPrefix string
+		wantKey    string
+	}{
+		{"", "", "mcp-memory-20240102T030405Z.tar.zst"},
+		{"team/mcp", "team/mcp/", "team/mcp/mcp-memory-20240102T030405Z.tar.zst"},
+		{"team/mcp/", "team/mcp/", "team/mcp/mcp-memory-20240102T030405Z.tar.zst"},
+		{"team/snapshot.tar.zst", "", "team/snapshot.tar.zst"},
+	}
+	for _, tt := range tests {
+		prefix, key := remoteBackupKey(tt.urlPath, now)
+		if prefix != tt.wantPrefix || key != tt.wantKey {
+			t.Errorf("remoteBackupKey(%q) = %q, %q, want %q, %q", tt.urlPath, prefix, key, tt.wantPrefix, tt.wantKey)
+		}
+	}
+}
+
+// TestRemoteBackups tests uploading, pruning and downloading the newest archive
+func TestRemoteBackups(t *testing.T) {
+	ctx := context.Background()
+	bucket := newMemBucket()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	file := filepath.Join(t.TempDir(), "archive.tar.zst")
+	for i := 0; i < 3; i++ {
+		if err := os.WriteFile(file, []byte{byte(i)}, 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, key := remoteBackupKey("team", base.Add(time.Duration(i)*time.Hour))
+		if err := uploadBackup(ctx, bucket, key, file); err != nil {
+			t.Fatalf("uploadBackup failed: %v", err)
+		}
+	}
+	// Objects other than archives directly under the prefix are left alone
+	bucket.objects["team/notes.txt"] = nil
+	bucket.objects["team/old/"+backup.FileName(base)] = nil
+
+	removed, err := pruneRemoteBackups(ctx, bucket, "team/", 2)
+	if err != nil {
+		t.Fatalf("pruneRemoteBackups failed: %v", err)
+	}
+	if len(removed) != 1 || removed[0] != "team/"+backup.FileName(base) {
+		t.Errorf("unexpected removed keys: %v", removed)
+	}
+	if len(bucket.objects) != 4 {
+		t.Errorf("expected 2 archives and 2 other objects, got %d", len(bucket.objects))
+	}
+
+	// A prefix restores the newest archive
+	var buf bytes.Buffer
+	key, err := downloadBackup(ctx, bucket, "team/", &buf)
+	if err != nil {
+		t.Fatalf("downloadBackup failed: %v", err)
+	}
+	if key != "team/"+backup.FileName(base.Add(2*time.Hour)) || !bytes.Equal(buf.Bytes(), []byte{2}) {
+		t.Errorf("unexpected download: key=%s data=%v", key, buf.Bytes())
+	}
+
+	// An explicit archive is downloaded as is
+	buf.Reset()
+	if _, err := downloadBackup(ctx, bucket, "team/"+backup.FileName(base.Add(time.Hour)), &buf); err != nil || !bytes.Equal(buf.Bytes(), []byte{1}) {
+		t.Errorf("unexpected download: %v, %v", buf.Bytes(), err)
+	}
+
+	if _, err := downloadBackup(ctx, bucket, "empty/", &buf); err == nil {
+		t.Error("expected an error for a prefix without backups")
+	}
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/bench"
+	"github.com/brbranch/embedding_mcp/internal/bootstrap"
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/store"
+	"github.com/google/uuid"
+)
+
+// benchStores are the store types the bench command can measure
+var benchStores = []string{"memory", model.StoreTypeSQLite, model.StoreTypeQdrant}
+
+// BenchOptions holds parsed bench command options
+type BenchOptions struct {
+	Store      string // store type (default: store.type of the config)
+	Sizes      []int  // note counts to measure at
+	Dim        int
+	Queries    int
+	Format     string
+	Baseline   string  // JSON results of an earlier run to compare against
+	Threshold  float64 // allowed p50 slowdown against the baseline (0.2 = 20%)
+	ConfigPath string
+}
+
+// parseBenchFlags parses command line arguments for bench command
+func parseBenchFlags(args []string) (*BenchOptions, error) {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	fs.SetOutput(io.Discard) // suppress default error output
+
+	opts := &BenchOptions{}
+	var notes string
+
+	// Long flags
+	fs.StringVar(&opts.Store, "store", "", "Store type: memory, sqlite, qdrant (default: store.type)")
+	fs.StringVar(&notes, "notes", "1000,10000", "Note counts to measure at (comma-separated)")
+	fs.IntVar(&opts.Dim, "dim", 384, "Vector dimension")
+	fs.IntVar(&opts.Queries, "queries", 100, "Searches/lists per note count")
+	fs.StringVar(&opts.Format, "format", "text", "Output format: text|json")
+	fs.StringVar(&opts.Baseline, "baseline", "", "JSON results of an earlier run to compare against")
+	fs.Float64Var(&opts.Threshold, "threshold", 0.2, "Allowed p50 slowdown against the baseline")
+	fs.StringVar(&opts.ConfigPath, "config", "", "Config file path")
+
+	// Short flags
+	fs.StringVar(&opts.Format, "f", "text", "Output format: text|json")
+	fs.StringVar(&opts.ConfigPath, "c", "", "Config file path")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if err := applyFlagEnv(fs); err != nil {
+		return nil, err
+	}
+
+	// Validation
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+	if opts.Store != "" && !slices.Contains(benchStores, opts.Store) {
+		return nil, fmt.Errorf("invalid store: %s (must be one of %s)", opts.Store, strings.Join(benchStores, ", "))
+	}
+	for _, s := range strings.Split(notes, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid --notes: %q must be positive integers", notes)
+		}
+		opts.Sizes = append(opts.Sizes, n)
+	}
+	if opts.Dim <= 0 {
+		return nil, fmt.Errorf("dim must be positive")
+	}
+	if opts.Queries <= 0 {
+		return nil, fmt.Errorf("queries must be positive")
+	}
+	if opts.Format != "text" && opts.Format != "json" {
+		return nil, fmt.Errorf("invalid format: %s (must be text or json)", opts.Format)
+	}
+	if opts.Threshold < 0 {
+		return nil, fmt.Errorf("threshold must not be negative")
+	}
+
+	return opts, nil
+}
+
+// runBenchCmd is the entry point for bench command
+func runBenchCmd(args []string) error {
+	opts, err := parseBenchFlags(args)
+	if err != nil {
+		return err
+	}
+
+	// Read the baseline first so a typo does not waste a long run
+	var baseline []bench.Result
+	if opts.Baseline != "" {
+		if baseline, err = readBenchResults(opts.Baseline); err != nil {
+			return err
+		}
+	}
+
+	manager, err := loadConfig(opts.ConfigPath, "")
+	if err != nil {
+		return err
+	}
+	cfg := manager.GetConfig()
+	if err := config.ResolveSecrets(cfg); err != nil {
+		return fmt.Errorf("failed to resolve secrets: %w", err)
+	}
+	if opts.Store == "" {
+		opts.Store = cfg.Store.Type
+	}
+
+	ctx := context.Background()
+	st, cleanup, err := openBenchStore(ctx, cfg, opts)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	return executeBench(ctx, st, opts, baseline, os.Stdout, os.Stderr)
+}
+
+// openBenchStore opens an empty store of opts.Store to measure in the bench:random:<dim>
+// namespace. sqlite uses a temporary database (removed by the returned cleanup); qdrant uses
+// the configured server.
+func openBenchStore(ctx context.Context, cfg *model.Config, opts *BenchOptions) (store.Store, func(), error) {
+	benchCfg := *cfg
+	benchCfg.Store.Type = opts.Store
+	benchCfg.Store.Journal = false
+	var tmpDir string
+	switch opts.Store {
+	case "memory":
+	case model.StoreTypeSQLite:
+		dir, err := os.MkdirTemp("", "mcp-memory-bench-")
+		if err != nil {
+			return nil, nil, err
+		}
+		tmpDir = dir
+		path := filepath.Join(dir, "bench.db")
+		benchCfg.Store.Path = &path
+	case model.StoreTypeQdrant:
+	default:
+		return nil, nil, fmt.Errorf("bench does not support store type %s (must be one of %s)", opts.Store, strings.Join(benchStores, ", "))
+	}
+
+	removeTmp := func() {
+		if tmpDir != "" {
+			os.RemoveAll(tmpDir)
+		}
+	}
+	st, err := bootstrap.NewStore(&benchCfg)
+	if err != nil {
+		removeTmp()
+		return nil, nil, err
+	}
+	if err := st.Initialize(ctx, config.GenerateNamespace("bench", "random", opts.Dim)); err != nil {
+		st.Close()
+		removeTmp()
+		return nil, nil, fmt.Errorf("failed to initialize store: %w", err)
+	}
+	return st, func() {
+		st.Close()
+		removeTmp()
+	}, nil
+}
+
+// executeBench measures st, writes the results to out and reports progress on progress.
+// With a baseline it returns an error if any result is slower than opts.Threshold allows.
+func executeBench(ctx context.Context, st store.Store, opts *BenchOptions, baseline []bench.Result, out, progress io.Writer) error {
+	projectID := "/mcp-memory-bench/" + uuid.NewString()
+	if opts.Store == model.StoreTypeQdrant {
+		// the collection outlives the run, so remove the notes it added
+		defer func() {
+			for _, groupID := range bench.GroupIDs() {
+				st.DeleteNotesByGroup(ctx, projectID, groupID)
+			}
+		}()
+	}
+
+	results, err := bench.Run(ctx, st, bench.Options{
+		Store:     opts.Store,
+		Sizes:     opts.Sizes,
+		Dim:       opts.Dim,
+		Queries:   opts.Queries,
+		ProjectID: projectID,
+		Seed:      1,
+		Progress: func(notes int) {
+			fmt.Fprintf(progress, "measuring %s at %d notes...\n", opts.Store, notes)
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("bench failed: %w", err)
+	}
+
+	if opts.Format == "json" {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(results); err != nil {
+			return err
+		}
+	} else {
+		printBenchResults(out, results)
+	}
+
+	if baseline == nil {
+		return nil
+	}
+	regressions := bench.Compare(baseline, results, opts.Threshold)
+	for _, r := range regressions {
+		fmt.Fprintf(progress, "regression: %s %s at %d notes: p50 %s -> %s (%.0f%% slower)\n",
+			r.Current.Store, r.Current.Op, r.Current.Notes, r.Baseline.P50, r.Current.P50, (r.Ratio-1)*100)
+	}
+	if len(regressions) > 0 {
+		return fmt.Errorf("%d results are more than %.0f%% slower than %s", len(regressions), opts.Threshold*100, opts.Baseline)
+	}
+	fmt.Fprintf(progress, "no regressions against %s\n", opts.Baseline)
+	return nil
+}
+
+// printBenchResults prints results as a table
+func printBenchResults(out io.Writer, results []bench.Result) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STORE\tNOTES\tOP\tCOUNT\tOPS/S\tP50\tP95\tP99")
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%d\t%.0f\t%s\t%s\t%s\n", r.Store, r.Notes, r.Op, r.Count, r.OpsPerSec,
+			r.P50.Round(time.Microsecond), r.P95.Round(time.Microsecond), r.P99.Round(time.Microsecond))
+	}
+	w.Flush()
+}
+
+// readBenchResults reads the JSON written by bench -f json
+func readBenchResults(path string) ([]bench.Result, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+	var results []bench.Result
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("invalid baseline %s: %w", path, err)
+	}
+	return results, nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/bench"
+	"github.com/brbranch/embedding_mcp/internal/model"
+)
+
+// TestParseBenchFlags tests bench command flag parsing and validation
+func TestParseBenchFlags(t *testing.T) {
+	opts, err := parseBenchFlags([]string{"--store", "sqlite", "--notes", "1000, 100000", "--dim", "8", "-f", "json"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.Store != "sqlite" || len(opts.Sizes) != 2 || opts.Sizes[1] != 100000 || opts.Dim != 8 || opts.Format != "json" {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+	if opts.Queries != 100 || opts.Threshold != 0.2 {
+		t.Errorf("unexpected defaults: %+v", opts)
+	}
+
+	for _, args := range [][]string{
+		{"--store", "chroma"},
+		{"--notes", "1000,abc"},
+		{"--notes", "0"},
+		{"--queries", "0"},
+		{"--format", "csv"},
+		{"--threshold", "-1"},
+		{"extra"},
+	} {
+		if _, err := parseBenchFlags(args); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+}
+
+// TestExecuteBench tests a bench run on the memory store and the comparison with a baseline
+func TestExecuteBench(t *testing.T) {
+	ctx := context.Background()
+	cfg := &model.Config{}
+	opts, err := parseBenchFlags([]string{"--store", "memory", "--notes", "20,50", "--dim", "8", "--queries", "3", "-f", "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st, cleanup, err := openBenchStore(ctx, cfg, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	var out, progress bytes.Buffer
+	if err := executeBench(ctx, st, opts, nil, &out, &progress); err != nil {
+		t.Fatal(err)
+	}
+	var results []bench.Result
+	if err := json.Unmarshal(out.Bytes(), &results); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out.String())
+	}
+	if len(results) != 8 || results[0].Store != "memory" || results[7].Notes != 50 {
+		t.Errorf("unexpected results: %+v", results)
+	}
+	if !strings.Contains(progress.String(), "measuring memory at 50 notes") {
+		t.Errorf("expected progress output, got %q", progress.String())
+	}
+
+	// a baseline 1000x faster than any real run reports every search as a regression
+	for i := range results {
+		results[i].P50 = 1
+	}
+	baselinePath := filepath.Join(t.TempDir(), "baseline.json")
+	data, _ := json.Marshal(results)
+	if err := os.WriteFile(baselinePath, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	baseline, err := readBenchResults(baselinePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts.Format = "text"
+	opts.Baseline = baselinePath
+	st, cleanup, err = openBenchStore(ctx, cfg, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	out.Reset()
+	progress.Reset()
+	if err := executeBench(ctx, st, opts, baseline, &out, &progress); err == nil || !strings.Contains(err.Error(), "slower than") {
+		t.Errorf("expected a regression error, got %v", err)
+	}
+	if !strings.Contains(out.String(), "STORE") || !strings.Contains(progress.String(), "regression: memory search at 20 notes") {
+		t.Errorf("unexpected output:\n%s\n%s", out.String(), progress.String())
+	}
+}
+
+// TestOpenBenchStore_SQLite tests that the sqlite bench store uses a temporary database
+func TestOpenBenchStore_SQLite(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "memory.db")
+	cfg := &model.Config{Store: model.StoreConfig{Type: model.StoreTypeSQLite, Path: &path}}
+	_, cleanup, err := openBenchStore(ctx, cfg, &BenchOptions{Store: model.StoreTypeSQLite, Dim: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cleanup()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected the configured database not to be created, got %v", err)
+	}
+	if *cfg.Store.Path != path {
+		t.Errorf("config was modified: %s", *cfg.Store.Path)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/brbranch/embedding_mcp/internal/bootstrap"
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/tui"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// BrowseOptions holds parsed browse command options
+type BrowseOptions struct {
+	ProjectID  string
+	GroupID    string
+	Limit      int
+	ConfigPath string
+}
+
+// parseBrowseFlags parses command line arguments for browse command
+func parseBrowseFlags(args []string) (*BrowseOptions, error) {
+	fs := flag.NewFlagSet("browse", flag.ContinueOnError)
+	fs.SetOutput(io.Discard) // suppress default error output
+
+	opts := &BrowseOptions{}
+
+	// Long flags
+	fs.StringVar(&opts.ProjectID, "project", "", "Project ID/path (optional, start with project list if omitted)")
+	fs.StringVar(&opts.GroupID, "group", "", "Group ID (optional)")
+	fs.IntVar(&opts.Limit, "limit", tui.DefaultLimit, "Number of notes to load")
+	fs.StringVar(&opts.ConfigPath, "config", "", "Config file path")
+
+	// Short flags
+	fs.StringVar(&opts.ProjectID, "p", "", "Project ID/path")
+	fs.StringVar(&opts.GroupID, "g", "", "Group ID")
+	fs.IntVar(&opts.Limit, "n", tui.DefaultLimit, "Number of notes to load")
+	fs.StringVar(&opts.ConfigPath, "c", "", "Config file path")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if err := applyFlagEnv(fs); err != nil {
+		return nil, err
+	}
+
+	// Validation
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+	if opts.Limit <= 0 {
+		return nil, fmt.Errorf("limit must be positive")
+	}
+	if opts.GroupID != "" && opts.ProjectID == "" {
+		return nil, fmt.Errorf("--group requires --project")
+	}
+
+	return opts, nil
+}
+
+// runBrowseCmd is the entry point for browse command
+func runBrowseCmd(args []string) error {
+	opts, err := parseBrowseFlags(args)
+	if err != nil {
+		return err
+	}
+
+	// Canonicalize project ID
+	projectID := opts.ProjectID
+	if projectID != "" {
+		projectID, err = config.CanonicalizeProjectID(projectID)
+		if err != nil {
+			return fmt.Errorf("failed to canonicalize project ID: %w", err)
+		}
+	}
+
+	// Initialize services
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	services, cleanup, err := bootstrap.Initialize(ctx, opts.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+	defer cleanup()
+
+	model := tui.New(ctx, services.NoteService, services.GroupService, tui.Options{
+		ProjectID: projectID,
+		GroupID:   opts.GroupID,
+		Limit:     opts.Limit,
+	})
+	if _, err := tea.NewProgram(model, tea.WithAltScreen(), tea.WithContext(ctx)).Run(); err != nil {
+		return fmt.Errorf("browse failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import "testing"
+
+// TestParseBrowseFlags tests flag parsing for browse command
+func TestParseBrowseFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		wantProject string
+		wantGroup   string
+		wantLimit   int
+		wantErr     bool
+	}{
+		{
+			name:      "defaults",
+			args:      []string{},
+			wantLimit: 50,
+		},
+		{
+			name:        "project and group",
+			args:        []string{"-p", "/test/project", "-g", "global", "-n", "20"},
+			wantProject: "/test/project",
+			wantGroup:   "global",
+			wantLimit:   20,
+		},
+		{
+			name:    "group without project",
+			args:    []string{"-g", "global"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid limit",
+			args:    []string{"--limit", "0"},
+			wantErr: true,
+		},
+		{
+			name:    "unexpected argument",
+			args:    []string{"extra"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := parseBrowseFlags(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseBrowseFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if opts.ProjectID != tt.wantProject || opts.GroupID != tt.wantGroup || opts.Limit != tt.wantLimit {
+				t.Errorf("unexpected options: %+v", opts)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/brbranch/embedding_mcp/internal/logging"
+)
+
+// envContainer enables container mode: serve defaults to the HTTP transport on all
+// interfaces and, unless a log file is configured, logs JSON to stdout
+const envContainer = "MCP_MEMORY_CONTAINER"
+
+// containerMode reports whether MCP_MEMORY_CONTAINER is set to a true value (1, true)
+func containerMode() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv(envContainer))
+	return enabled
+}
+
+// serveDefaults returns the default transport and HTTP host of serve
+func serveDefaults() (transport, host string) {
+	if containerMode() {
+		// 127.0.0.1 is unreachable from outside the container
+		return "http", "0.0.0.0"
+	}
+	return defaultTransport, "127.0.0.1"
+}
+
+// applyContainerLogging sends the logs of serve to stdout as JSON in container mode,
+// where the runtime collects stdout. Logs stay on stderr (or the log file) when
+// serve uses the stdio transport, whose protocol owns stdout.
+func applyContainerLogging(opts *logging.Options, args []string) {
+	if !containerMode() || opts.File != "" || (len(args) > 0 && args[0] != "serve") {
+		return
+	}
+	transport := peekFlag(args, "transport", "t")
+	if transport == "" {
+		transport = os.Getenv(flagEnvName("transport"))
+	}
+	if transport == "" {
+		transport, _ = serveDefaults()
+	}
+	for _, t := range strings.Split(transport, ",") {
+		if strings.TrimSpace(t) == "stdio" {
+			return
+		}
+	}
+	opts.Stdout = true
+	if opts.Format == "" {
+		opts.Format = logging.FormatJSON
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/logging"
+)
+
+func TestParseFlags_ContainerMode(t *testing.T) {
+	t.Setenv("MCP_MEMORY_TRANSPORT", "")
+	t.Setenv("MCP_MEMORY_HOST", "")
+	t.Setenv(envContainer, "true")
+	opts, err := parseFlags([]string{"serve"})
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
+	if opts.Transport != "http" || opts.Host != "0.0.0.0" {
+		t.Errorf("expected http on 0.0.0.0 in container mode, got %s on %s", opts.Transport, opts.Host)
+	}
+
+	// フラグ・環境変数の指定が優先
+	t.Setenv("MCP_MEMORY_HOST", "10.0.0.1")
+	opts, err = parseFlags([]string{"serve", "-t", "stdio,http"})
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
+	if opts.Transport != "stdio,http" || opts.Host != "10.0.0.1" {
+		t.Errorf("expected the given transport and host, got %s on %s", opts.Transport, opts.Host)
+	}
+
+	t.Setenv(envContainer, "")
+	t.Setenv("MCP_MEMORY_HOST", "")
+	opts, err = parseFlags([]string{"serve"})
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
+	if opts.Transport != defaultTransport || opts.Host != "127.0.0.1" {
+		t.Errorf("expected the usual defaults, got %s on %s", opts.Transport, opts.Host)
+	}
+}
+
+func TestApplyContainerLogging(t *testing.T) {
+	t.Setenv("MCP_MEMORY_TRANSPORT", "")
+	tests := []struct {
+		name       string
+		container  string
+		args       []string
+		opts       logging.Options
+		wantStdout bool
+		wantFormat string
+	}{
+		{"serve", "1", []string{"serve"}, logging.Options{}, true, logging.FormatJSON},
+		{"default command", "true", nil, logging.Options{}, true, logging.FormatJSON},
+		{"explicit format", "true", []string{"serve"}, logging.Options{Format: "text"}, true, "text"},
+		{"stdio transport", "true", []string{"serve", "--transport=stdio,http"}, logging.Options{}, false, ""},
+		{"log file", "true", []string{"serve"}, logging.Options{File: "/var/log/mcp-memory.log"}, false, ""},
+		{"other command", "true", []string{"search", "query"}, logging.Options{}, false, ""},
+		{"not container", "", []string{"serve", "-t", "http"}, logging.Options{}, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(envContainer, tt.container)
+			opts := tt.opts
+			applyContainerLogging(&opts, tt.args)
+			if opts.Stdout != tt.wantStdout || opts.Format != tt.wantFormat {
+				t.Errorf("got stdout=%v format=%q, want stdout=%v format=%q", opts.Stdout, opts.Format, tt.wantStdout, tt.wantFormat)
+			}
+		})
+	}
+}
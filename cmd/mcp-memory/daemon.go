@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/bootstrap"
+	"github.com/brbranch/embedding_mcp/internal/client"
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/daemon"
+	"github.com/brbranch/embedding_mcp/internal/jsonrpc"
+	"github.com/brbranch/embedding_mcp/internal/model"
+)
+
+// defaultStopTimeout is how long stop waits for the server to exit
+const defaultStopTimeout = 10 * time.Second
+
+// serverInfoTimeout bounds the memory.server_info call made by status
+const serverInfoTimeout = 2 * time.Second
+
+// StatusOptions holds parsed status command options
+type StatusOptions struct {
+	ConfigPath string
+	DataDir    string
+	Format     string
+}
+
+// StopOptions holds parsed stop command options
+type StopOptions struct {
+	ConfigPath string
+	DataDir    string
+	Timeout    time.Duration
+}
+
+// parseStatusFlags parses command line arguments for status command
+func parseStatusFlags(args []string) (*StatusOptions, error) {
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	fs.SetOutput(io.Discard) // suppress default error output
+
+	opts := &StatusOptions{}
+
+	// Long flags
+	fs.StringVar(&opts.ConfigPath, "config", "", "Config file path")
+	fs.StringVar(&opts.DataDir, "data-dir", "", "Data directory the server was started with")
+	fs.StringVar(&opts.Format, "format", "text", "Output format: text|json")
+
+	// Short flags
+	fs.StringVar(&opts.ConfigPath, "c", "", "Config file path")
+	fs.StringVar(&opts.Format, "f", "text", "Output format: text|json")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if err := applyFlagEnv(fs); err != nil {
+		return nil, err
+	}
+
+	// Validation
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+	if opts.Format != "text" && opts.Format != "json" {
+		return nil, fmt.Errorf("invalid format: %s (must be text or json)", opts.Format)
+	}
+
+	return opts, nil
+}
+
+// parseStopFlags parses command line arguments for stop command
+func parseStopFlags(args []string) (*StopOptions, error) {
+	fs := flag.NewFlagSet("stop", flag.ContinueOnError)
+	fs.SetOutput(io.Discard) // suppress default error output
+
+	opts := &StopOptions{}
+
+	// Long flags
+	fs.StringVar(&opts.ConfigPath, "config", "", "Config file path")
+	fs.StringVar(&opts.DataDir, "data-dir", "", "Data directory the server was started with")
+	fs.DurationVar(&opts.Timeout, "timeout", defaultStopTimeout, "Time to wait for the server to exit")
+
+	// Short flags
+	fs.StringVar(&opts.ConfigPath, "c", "", "Config file path")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if err := applyFlagEnv(fs); err != nil {
+		return nil, err
+	}
+
+	// Validation
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+	if opts.Timeout <= 0 {
+		return nil, fmt.Errorf("invalid timeout: %s (must be positive)", opts.Timeout)
+	}
+
+	return opts, nil
+}
+
+// runStatusCmd is the entry point for status command
+func runStatusCmd(args []string) error {
+	opts, err := parseStatusFlags(args)
+	if err != nil {
+		return err
+	}
+
+	manager, err := loadConfig(opts.ConfigPath, opts.DataDir)
+	if err != nil {
+		return err
+	}
+	dataDir, err := instanceDataDir(manager.GetConfig())
+	if err != nil {
+		return err
+	}
+
+	info, err := daemon.Status(dataDir)
+	if err != nil {
+		return err
+	}
+	server := fetchServerInfo(info)
+
+	if opts.Format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(struct {
+			*daemon.Info
+			Server *jsonrpc.ServerInfoResult `json:"server,omitempty"`
+		}{info, server})
+	}
+	fmt.Fprint(os.Stdout, formatStatusText(info, server))
+	return nil
+}
+
+// fetchServerInfo asks a server that serves HTTP for memory.server_info.
+// It returns nil if the server has no HTTP transport or does not answer in time.
+func fetchServerInfo(info *daemon.Info) *jsonrpc.ServerInfoResult {
+	if info.HTTPAddr == "" {
+		return nil
+	}
+	c, err := client.New(dialAddr(info.HTTPAddr), client.WithToken(os.Getenv(client.EnvToken)))
+	if err != nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), serverInfoTimeout)
+	defer cancel()
+	var result jsonrpc.ServerInfoResult
+	if err := c.Call(ctx, "memory.server_info", nil, &result); err != nil {
+		slog.Debug("failed to get server info", "url", c.URL(), "error", err)
+		return nil
+	}
+	return &result
+}
+
+// runStopCmd is the entry point for stop command
+func runStopCmd(args []string) error {
+	opts, err := parseStopFlags(args)
+	if err != nil {
+		return err
+	}
+
+	manager, err := loadConfig(opts.ConfigPath, opts.DataDir)
+	if err != nil {
+		return err
+	}
+	dataDir, err := instanceDataDir(manager.GetConfig())
+	if err != nil {
+		return err
+	}
+
+	info, err := daemon.Stop(dataDir, opts.Timeout)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "stopped mcp-memory (pid %d)\n", info.PID)
+	return nil
+}
+
+// formatStatusText formats the running server info (and memory.server_info, if any) for humans
+func formatStatusText(info *daemon.Info, server *jsonrpc.ServerInfoResult) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("running:    pid %d\n", info.PID))
+	sb.WriteString(fmt.Sprintf("started:    %s\n", info.StartedAt))
+	if info.Version != "" {
+		sb.WriteString(fmt.Sprintf("version:    %s\n", info.Version))
+	}
+	if len(info.Transports) > 0 {
+		sb.WriteString(fmt.Sprintf("transports: %s\n", strings.Join(info.Transports, ", ")))
+	}
+	if info.HTTPAddr != "" {
+		sb.WriteString(fmt.Sprintf("http:       %s\n", info.HTTPAddr))
+	}
+	if info.PipeName != "" {
+		sb.WriteString(fmt.Sprintf("pipe:       %s\n", info.PipeName))
+	}
+	if info.StoreType != "" {
+		store := info.StoreType
+		if info.StorePath != "" {
+			store += " (" + info.StorePath + ")"
+		}
+		sb.WriteString(fmt.Sprintf("store:      %s\n", store))
+	}
+	if info.ConfigPath != "" {
+		sb.WriteString(fmt.Sprintf("config:     %s\n", info.ConfigPath))
+	}
+	if server != nil {
+		if server.Commit != "" {
+			sb.WriteString(fmt.Sprintf("commit:     %s\n", server.Commit))
+		}
+		sb.WriteString(fmt.Sprintf("uptime:     %s\n", time.Duration(server.UptimeSeconds)*time.Second))
+		sb.WriteString(fmt.Sprintf("namespace:  %s\n", server.Namespace))
+		sb.WriteString(fmt.Sprintf("embedder:   %s / %s (dim %d)\n", server.Embedder.Provider, server.Embedder.Model, server.Embedder.Dim))
+		var features []string
+		for name, enabled := range server.Features {
+			if enabled {
+				features = append(features, name)
+			}
+		}
+		sort.Strings(features)
+		if len(features) > 0 {
+			sb.WriteString(fmt.Sprintf("features:   %s\n", strings.Join(features, ", ")))
+		}
+	}
+	return sb.String()
+}
+
+// acquireInstanceLock takes the single-instance lock for serve before the store is opened,
+// so that two servers never write to the same data dir or SQLite database
+func acquireInstanceLock(opts *Options) (*daemon.Instance, error) {
+	manager, err := loadConfig(opts.ConfigPath, opts.DataDir)
+	if err != nil {
+		return nil, err
+	}
+	cfg := manager.GetConfig()
+
+	dataDir, err := instanceDataDir(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	info := daemon.Info{
+		Version:    version,
+		Transports: opts.Transports,
+		ConfigPath: manager.GetConfigPath(),
+		StoreType:  cfg.Store.Type,
+	}
+	for _, t := range opts.Transports {
+		switch t {
+		case "http":
+			info.HTTPAddr = fmt.Sprintf("%s:%d", opts.Host, opts.Port)
+		case "pipe":
+			info.PipeName = opts.PipeName
+		}
+	}
+
+	var dbPath string
+	switch cfg.Store.Type {
+	case model.StoreTypeSQLite:
+		dbPath = bootstrap.SQLitePath(cfg)
+		info.StorePath = dbPath
+	case model.StoreTypeChroma, model.StoreTypeQdrant:
+		if cfg.Store.URL != nil {
+			info.StorePath = *cfg.Store.URL
+		}
+	}
+
+	inst, err := daemon.Acquire(dataDir, dbPath, info)
+	if err != nil {
+		return nil, fmt.Errorf("%w; stop it with 'mcp-memory stop' or pass --no-lock", err)
+	}
+	return inst, nil
+}
+
+// loadConfig loads the config file (with environment overrides) without opening the store.
+// A non-empty dataDir (--data-dir) takes precedence over the config and environment.
+func loadConfig(configPath, dataDir string) (*config.Manager, error) {
+	manager, err := config.NewManager(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create config manager: %w", err)
+	}
+	if err := manager.Load(); err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	if dataDir != "" {
+		dir, err := config.ResolveDataDir(dataDir)
+		if err != nil {
+			return nil, fmt.Errorf("invalid data dir: %w", err)
+		}
+		manager.GetConfig().Paths.DataDir = dir
+	}
+	return manager, nil
+}
+
+// instanceDataDir returns the data dir holding the pidfile
+func instanceDataDir(cfg *model.Config) (string, error) {
+	if cfg.Paths.DataDir != "" {
+		return cfg.Paths.DataDir, nil
+	}
+	return config.GetDefaultDataDir()
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/daemon"
+	"github.com/brbranch/embedding_mcp/internal/jsonrpc"
+)
+
+func TestParseStatusFlags(t *testing.T) {
+	opts, err := parseStatusFlags([]string{"-c", "/tmp/config.json", "-f", "json"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.ConfigPath != "/tmp/config.json" || opts.Format != "json" {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+
+	if _, err := parseStatusFlags([]string{"-f", "yaml"}); err == nil {
+		t.Error("expected error for invalid format")
+	}
+	if _, err := parseStatusFlags([]string{"extra"}); err == nil {
+		t.Error("expected error for unexpected argument")
+	}
+}
+
+func TestParseStopFlags(t *testing.T) {
+	opts, err := parseStopFlags(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.Timeout != defaultStopTimeout {
+		t.Errorf("expected default timeout, got %s", opts.Timeout)
+	}
+
+	opts, err = parseStopFlags([]string{"--timeout", "30s"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.Timeout != 30*time.Second {
+		t.Errorf("expected 30s, got %s", opts.Timeout)
+	}
+
+	if _, err := parseStopFlags([]string{"--timeout", "0s"}); err == nil {
+		t.Error("expected error for non-positive timeout")
+	}
+}
+
+func TestParseFlags_NoLock(t *testing.T) {
+	opts, err := parseFlags([]string{"serve", "--no-lock"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !opts.NoLock {
+		t.Error("expected NoLock to be true")
+	}
+}
+
+func TestAcquireInstanceLock(t *testing.T) {
+	dataDir := t.TempDir()
+	t.Setenv("MCP_MEMORY_DATA_DIR", dataDir)
+	t.Setenv("MCP_MEMORY_STORE_TYPE", "sqlite")
+	t.Setenv("MCP_MEMORY_CONFIG", filepath.Join(t.TempDir(), "missing.json"))
+
+	opts := &Options{Transports: []string{"stdio", "http"}, Host: "127.0.0.1", Port: 8765}
+	inst, err := acquireInstanceLock(opts)
+	if err != nil {
+		t.Fatalf("acquireInstanceLock failed: %v", err)
+	}
+	defer inst.Release()
+
+	info, err := daemon.Status(dataDir)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if info.HTTPAddr != "127.0.0.1:8765" || info.StorePath != filepath.Join(dataDir, "memory.db") {
+		t.Errorf("unexpected info: %+v", info)
+	}
+
+	_, err = acquireInstanceLock(opts)
+	if !errors.Is(err, daemon.ErrAlreadyRunning) || !strings.Contains(err.Error(), "--no-lock") {
+		t.Errorf("expected ErrAlreadyRunning with hint, got %v", err)
+	}
+}
+
+func TestFormatStatusText(t *testing.T) {
+	out := formatStatusText(&daemon.Info{
+		PID:        42,
+		StartedAt:  "2024-01-01T00:00:00Z",
+		Transports: []string{"stdio", "http"},
+		HTTPAddr:   "127.0.0.1:8765",
+		StoreType:  "sqlite",
+		StorePath:  "/data/memory.db",
+	}, nil)
+	for _, want := range []string{"pid 42", "stdio, http", "127.0.0.1:8765", "sqlite (/data/memory.db)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "namespace:") {
+		t.Errorf("expected no server info without memory.server_info, got:\n%s", out)
+	}
+
+	// with memory.server_info the namespace, embedder and enabled features are shown too
+	out = formatStatusText(&daemon.Info{PID: 42}, &jsonrpc.ServerInfoResult{
+		UptimeSeconds: 90,
+		Namespace:     "ollama:nomic-embed-text:768",
+		Embedder:      jsonrpc.ServerEmbedderResult{Provider: "ollama", Model: "nomic-embed-text", Dim: 768},
+		Features:      map[string]bool{"migrate": true, "events": true, "audit": false},
+	})
+	for _, want := range []string{"uptime:     1m30s", "ollama:nomic-embed-text:768", "ollama / nomic-embed-text (dim 768)", "features:   events, migrate\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestAcquireInstanceLock_DataDirFlag(t *testing.T) {
+	t.Setenv("MCP_MEMORY_DATA_DIR", t.TempDir())
+	t.Setenv("MCP_MEMORY_STORE_TYPE", "sqlite")
+	t.Setenv("MCP_MEMORY_CONFIG", filepath.Join(t.TempDir(), "missing.json"))
+
+	dataDir := t.TempDir()
+	inst, err := acquireInstanceLock(&Options{Transports: []string{"stdio"}, DataDir: dataDir})
+	if err != nil {
+		t.Fatalf("acquireInstanceLock failed: %v", err)
+	}
+	defer inst.Release()
+
+	info, err := daemon.Status(dataDir)
+	if err != nil {
+		t.Fatalf("expected server to be registered under --data-dir: %v", err)
+	}
+	if info.StorePath != filepath.Join(dataDir, "memory.db") {
+		t.Errorf("expected store under --data-dir, got %q", info.StorePath)
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/doctor"
+)
+
+// DoctorOptions holds parsed doctor command options
+type DoctorOptions struct {
+	ConfigPath string
+	Format     string
+	Timeout    time.Duration
+	Deep       bool // also validate every note, embedding and group in the store
+	Repair     bool // repair the problems --deep finds
+}
+
+// parseDoctorFlags parses command line arguments for doctor command
+func parseDoctorFlags(args []string) (*DoctorOptions, error) {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	fs.SetOutput(io.Discard) // suppress default error output
+
+	opts := &DoctorOptions{}
+
+	// Long flags
+	fs.StringVar(&opts.ConfigPath, "config", "", "Config file path")
+	fs.StringVar(&opts.Format, "format", "text", "Output format: text|json")
+	fs.DurationVar(&opts.Timeout, "timeout", doctor.DefaultTimeout, "Timeout for each connectivity check")
+	fs.BoolVar(&opts.Deep, "deep", false, "Validate every note, embedding and group in the store")
+	fs.BoolVar(&opts.Repair, "repair", false, "Repair the problems --deep finds")
+
+	// Short flags
+	fs.StringVar(&opts.ConfigPath, "c", "", "Config file path")
+	fs.StringVar(&opts.Format, "f", "text", "Output format: text|json")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if err := applyFlagEnv(fs); err != nil {
+		return nil, err
+	}
+
+	// Validation
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+	if opts.Format != "text" && opts.Format != "json" {
+		return nil, fmt.Errorf("invalid format: %s (must be text or json)", opts.Format)
+	}
+	if opts.Timeout <= 0 {
+		return nil, fmt.Errorf("invalid timeout: %s (must be positive)", opts.Timeout)
+	}
+	if opts.Repair && !opts.Deep {
+		return nil, fmt.Errorf("--repair requires --deep")
+	}
+
+	return opts, nil
+}
+
+// runDoctorCmd is the entry point for doctor command
+func runDoctorCmd(args []string) error {
+	opts, err := parseDoctorFlags(args)
+	if err != nil {
+		return err
+	}
+
+	doctorOpts := []doctor.Option{doctor.WithTimeout(opts.Timeout)}
+	if opts.Deep {
+		doctorOpts = append(doctorOpts, doctor.WithDeep(opts.Repair))
+	}
+	report := doctor.New(opts.ConfigPath, doctorOpts...).Run(context.Background())
+
+	var output string
+	if opts.Format == "json" {
+		output, err = formatDoctorJSONOutput(report)
+		if err != nil {
+			return err
+		}
+	} else {
+		output = formatDoctorTextOutput(report)
+	}
+	fmt.Fprint(os.Stdout, output)
+
+	if n := report.Failed(); n > 0 {
+		return fmt.Errorf("doctor found %d problem(s)", n)
+	}
+	return nil
+}
+
+// doctorStatusLabels maps check status to a fixed-width label
+var doctorStatusLabels = map[doctor.Status]string{
+	doctor.StatusOK:   "[ OK ]",
+	doctor.StatusWarn: "[WARN]",
+	doctor.StatusFail: "[FAIL]",
+	doctor.StatusSkip: "[SKIP]",
+}
+
+// formatDoctorTextOutput formats the report as human-readable text
+func formatDoctorTextOutput(report *doctor.Report) string {
+	var sb strings.Builder
+
+	if report.ConfigPath != "" {
+		sb.WriteString(fmt.Sprintf("config:    %s\n", report.ConfigPath))
+	}
+	if report.Namespace != "" {
+		sb.WriteString(fmt.Sprintf("namespace: %s\n", report.Namespace))
+	}
+	sb.WriteString("\n")
+
+	for _, res := range report.Results {
+		sb.WriteString(fmt.Sprintf("%s %-9s %s\n", doctorStatusLabels[res.Status], res.Name, res.Message))
+		if res.Fix != "" {
+			sb.WriteString(fmt.Sprintf("       fix: %s\n", res.Fix))
+		}
+	}
+
+	if len(report.Issues) > 0 {
+		sb.WriteString("\nindex issues:\n")
+		for _, issue := range report.Issues {
+			target := issue.ID
+			if target == "" {
+				target = issue.ProjectID
+			}
+			sb.WriteString(fmt.Sprintf("  %s %s: %s", issue.Kind, target, issue.Message))
+			if issue.Repaired {
+				sb.WriteString(fmt.Sprintf(" (%s)", issue.Action))
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// formatDoctorJSONOutput formats the report as JSON
+func formatDoctorJSONOutput(report *doctor.Report) (string, error) {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	return string(data) + "\n", nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/doctor"
+)
+
+// TestParseDoctorFlags tests flag parsing for doctor command
+func TestParseDoctorFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		wantFormat  string
+		wantTimeout time.Duration
+		wantErr     bool
+	}{
+		{
+			name:        "defaults",
+			args:        []string{},
+			wantFormat:  "text",
+			wantTimeout: doctor.DefaultTimeout,
+		},
+		{
+			name:        "all flags",
+			args:        []string{"-c", "/tmp/config.json", "-f", "json", "--timeout", "3s"},
+			wantFormat:  "json",
+			wantTimeout: 3 * time.Second,
+		},
+		{
+			name:    "invalid format",
+			args:    []string{"-f", "yaml"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid timeout",
+			args:    []string{"--timeout", "0s"},
+			wantErr: true,
+		},
+		{
+			name:        "deep repair",
+			args:        []string{"--deep", "--repair"},
+			wantFormat:  "text",
+			wantTimeout: doctor.DefaultTimeout,
+		},
+		{
+			name:    "repair without deep",
+			args:    []string{"--repair"},
+			wantErr: true,
+		},
+		{
+			name:    "unexpected argument",
+			args:    []string{"extra"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := parseDoctorFlags(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDoctorFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if opts.Format != tt.wantFormat {
+				t.Errorf("Format = %q, want %q", opts.Format, tt.wantFormat)
+			}
+			if opts.Timeout != tt.wantTimeout {
+				t.Errorf("Timeout = %v, want %v", opts.Timeout, tt.wantTimeout)
+			}
+		})
+	}
+}
+
+// TestFormatDoctorTextOutput tests text formatting of the report
+func TestFormatDoctorTextOutput(t *testing.T) {
+	report := &doctor.Report{
+		ConfigPath: "/tmp/config.json",
+		Namespace:  "openai:text-embedding-3-small:1536",
+		Results: []doctor.Result{
+			{Name: "config", Status: doctor.StatusOK, Message: "loaded"},
+			{Name: "embedder", Status: doctor.StatusFail, Message: "API key was rejected", Fix: "check the key"},
+		},
+		Issues: []doctor.Issue{{Kind: "missing_embedding", ID: "note-1", Message: "note has no embedding", Repaired: true, Action: "reembedded"}},
+	}
+
+	output := formatDoctorTextOutput(report)
+
+	for _, want := range []string{"namespace: openai:text-embedding-3-small:1536", "[ OK ] config", "[FAIL] embedder", "fix: check the key",
+		"missing_embedding note-1: note has no embedding (reembedded)"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output should contain %q, got:\n%s", want, output)
+		}
+	}
+}
+
+// TestFormatDoctorJSONOutput tests JSON formatting of the report
+func TestFormatDoctorJSONOutput(t *testing.T) {
+	report := &doctor.Report{
+		Results: []doctor.Result{{Name: "store", Status: doctor.StatusWarn, Message: "memory store", Fix: "use sqlite"}},
+	}
+
+	output, err := formatDoctorJSONOutput(report)
+	if err != nil {
+		t.Fatalf("formatDoctorJSONOutput() error = %v", err)
+	}
+
+	var parsed doctor.Report
+	if err := json.Unmarshal([]byte(output), &parsed); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if len(parsed.Results) != 1 || parsed.Results[0].Status != doctor.StatusWarn {
+		t.Errorf("unexpected results: %+v", parsed.Results)
+	}
+}
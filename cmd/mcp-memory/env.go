@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// envPrefix is the prefix of environment variables that provide flag defaults
+const envPrefix = "MCP_MEMORY_"
+
+// flagEnvName returns the environment variable for a flag ("pipe-name" -> MCP_MEMORY_PIPE_NAME)
+func flagEnvName(name string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// applyFlagEnv fills flags that were not given on the command line from their
+// MCP_MEMORY_* environment variables, so the precedence is flag > env > default.
+// Call it after fs.Parse. Shorthand flags share their value with the long form
+// and are covered through it.
+func applyFlagEnv(fs *flag.FlagSet) error {
+	// Flags bound to the same variable (-p / --project) share one flag.Value
+	given := make(map[flag.Value]bool)
+	fs.Visit(func(f *flag.Flag) {
+		given[f.Value] = true
+	})
+
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil || len(f.Name) == 1 || given[f.Value] {
+			return
+		}
+		name := flagEnvName(f.Name)
+		value := os.Getenv(name)
+		if value == "" {
+			return
+		}
+		if setErr := f.Value.Set(value); setErr != nil {
+			err = fmt.Errorf("invalid value %q for %s: %v", value, name, setErr)
+		}
+	})
+	return err
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFlagEnvName(t *testing.T) {
+	tests := map[string]string{
+		"transport":  "MCP_MEMORY_TRANSPORT",
+		"pipe-name":  "MCP_MEMORY_PIPE_NAME",
+		"log-format": "MCP_MEMORY_LOG_FORMAT",
+	}
+	for name, want := range tests {
+		if got := flagEnvName(name); got != want {
+			t.Errorf("flagEnvName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestParseFlags_EnvDefaults(t *testing.T) {
+	t.Setenv("MCP_MEMORY_TRANSPORT", "http")
+	t.Setenv("MCP_MEMORY_PORT", "9000")
+	t.Setenv("MCP_MEMORY_CONFIG", "/etc/mcp-memory.json")
+
+	opts, err := parseFlags([]string{"serve"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.Transport != "http" || opts.Port != 9000 || opts.ConfigPath != "/etc/mcp-memory.json" {
+		t.Errorf("expected env defaults, got %+v", opts)
+	}
+
+	// flags take precedence over env, including shorthands
+	opts, err = parseFlags([]string{"serve", "-t", "stdio", "-p", "8080"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.Transport != "stdio" || opts.Port != 8080 {
+		t.Errorf("expected flags to win over env, got transport=%q port=%d", opts.Transport, opts.Port)
+	}
+}
+
+func TestParseFlags_InvalidEnv(t *testing.T) {
+	t.Setenv("MCP_MEMORY_PORT", "abc")
+
+	_, err := parseFlags([]string{"serve"})
+	if err == nil || !strings.Contains(err.Error(), "MCP_MEMORY_PORT") {
+		t.Errorf("expected error mentioning MCP_MEMORY_PORT, got %v", err)
+	}
+
+	t.Setenv("MCP_MEMORY_PORT", "70000")
+	if _, err := parseFlags([]string{"serve"}); err == nil {
+		t.Error("expected env value to be validated like the flag")
+	}
+}
+
+func TestParseSearchFlags_EnvDefaults(t *testing.T) {
+	t.Setenv("MCP_MEMORY_PROJECT", "/env/project")
+	t.Setenv("MCP_MEMORY_TOP_K", "3")
+
+	opts, err := parseSearchFlags([]string{"query"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.ProjectID != "/env/project" || opts.TopK != 3 {
+		t.Errorf("expected env defaults, got project=%q topK=%d", opts.ProjectID, opts.TopK)
+	}
+
+	opts, err = parseSearchFlags([]string{"-p", "/flag/project", "query"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.ProjectID != "/flag/project" {
+		t.Errorf("expected flag to win over env, got %q", opts.ProjectID)
+	}
+}
+
+func TestExtractLogFlags_Env(t *testing.T) {
+	t.Setenv("MCP_MEMORY_LOG_LEVEL", "debug")
+	t.Setenv("MCP_MEMORY_LOG_FORMAT", "json")
+
+	opts, _, err := extractLogFlags([]string{"--log-level", "warn", "list"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.Level != "warn" || opts.Format != "json" {
+		t.Errorf("expected level from flag and format from env, got %+v", opts)
+	}
+
+	t.Setenv("MCP_MEMORY_LOG_MAX_SIZE", "big")
+	if _, _, err := extractLogFlags([]string{"list"}); err == nil {
+		t.Error("expected error for invalid MCP_MEMORY_LOG_MAX_SIZE")
+	}
+}
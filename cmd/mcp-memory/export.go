@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/bootstrap"
+	"github.com/brbranch/embedding_mcp/internal/service"
+	"github.com/brbranch/embedding_mcp/internal/site"
+)
+
+// Export formats
+const (
+	exportFormatJSONL = "jsonl"
+	exportFormatSite  = "site"
+)
+
+// ExportOptions holds parsed export command options
+type ExportOptions struct {
+	ProjectID         string
+	Output            string
+	IncludeEmbeddings bool
+	Format            string // jsonl or site
+	SiteFormat        string // site.FormatHTML or site.FormatMarkdown (--format site)
+	ConfigPath        string
+}
+
+// parseExportFlags parses command line arguments for export command
+func parseExportFlags(args []string) (*ExportOptions, error) {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.SetOutput(io.Discard) // suppress default error output
+
+	opts := &ExportOptions{}
+
+	// Long flags
+	fs.StringVar(&opts.ProjectID, "project", "", "Project ID/path (required)")
+	fs.StringVar(&opts.Output, "output", "-", "Output file (- for stdout)")
+	fs.BoolVar(&opts.IncludeEmbeddings, "include-embeddings", false, "Include embedding vectors")
+	fs.StringVar(&opts.Format, "format", exportFormatJSONL, "Output format: jsonl or site")
+	fs.StringVar(&opts.SiteFormat, "site-format", site.FormatHTML, "Site page format: html or markdown")
+	fs.StringVar(&opts.ConfigPath, "config", "", "Config file path")
+
+	// Short flags
+	fs.StringVar(&opts.ProjectID, "p", "", "Project ID/path (required)")
+	fs.StringVar(&opts.Output, "o", "-", "Output file (- for stdout)")
+	fs.StringVar(&opts.ConfigPath, "c", "", "Config file path")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if err := applyFlagEnv(fs); err != nil {
+		return nil, err
+	}
+
+	// Validation
+	if opts.ProjectID == "" {
+		return nil, fmt.Errorf("project ID is required (-p or --project)")
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+	if opts.Output == "" {
+		opts.Output = "-"
+	}
+	switch opts.Format {
+	case exportFormatJSONL:
+		if opts.SiteFormat != site.FormatHTML {
+			return nil, fmt.Errorf("--site-format requires --format site")
+		}
+	case exportFormatSite:
+		if opts.Output == "-" {
+			return nil, fmt.Errorf("--format site requires an output directory (-o or --output)")
+		}
+		if opts.IncludeEmbeddings {
+			return nil, fmt.Errorf("--include-embeddings cannot be used with --format site")
+		}
+		if opts.SiteFormat != site.FormatHTML && opts.SiteFormat != site.FormatMarkdown {
+			return nil, fmt.Errorf("invalid --site-format: %s (must be %s or %s)", opts.SiteFormat, site.FormatHTML, site.FormatMarkdown)
+		}
+	default:
+		return nil, fmt.Errorf("invalid --format: %s (must be %s or %s)", opts.Format, exportFormatJSONL, exportFormatSite)
+	}
+
+	return opts, nil
+}
+
+// runExportCmd is the entry point for export command
+func runExportCmd(args []string) error {
+	opts, err := parseExportFlags(args)
+	if err != nil {
+		return err
+	}
+
+	// Initialize services
+	ctx := context.Background()
+	services, cleanup, err := bootstrap.Initialize(ctx, opts.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+	defer cleanup()
+
+	if opts.Format == exportFormatSite {
+		result, err := executeExportSiteWithService(ctx, services.ExportService, opts)
+		if err != nil {
+			return fmt.Errorf("export failed: %w", err)
+		}
+		fmt.Printf("wrote %d notes in %d groups to %s (%d files", result.Notes, result.Groups, opts.Output, result.Files)
+		if result.Removed > 0 {
+			fmt.Printf(", %d stale pages removed", result.Removed)
+		}
+		fmt.Println(")")
+		return nil
+	}
+
+	var w io.Writer = os.Stdout
+	if opts.Output != "-" {
+		f, err := os.Create(opts.Output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	// Execute export (projectId is canonicalized by ExportService)
+	resp, err := executeExportWithService(ctx, services.ExportService, opts, w)
+	if err != nil {
+		return fmt.Errorf("export failed: %w", err)
+	}
+
+	// Summary goes to stderr so that stdout stays valid JSONL
+	fmt.Fprintf(os.Stderr, "exported %d notes, %d globals, %d groups from %s\n",
+		resp.Notes, resp.Globals, resp.Groups, resp.ProjectID)
+	return nil
+}
+
+// executeExportWithService writes the project export using the provided ExportService
+func executeExportWithService(ctx context.Context, exportService service.ExportService, opts *ExportOptions, w io.Writer) (*service.ExportResponse, error) {
+	return exportService.Export(ctx, w, &service.ExportRequest{
+		ProjectID:         opts.ProjectID,
+		IncludeEmbeddings: opts.IncludeEmbeddings,
+	})
+}
+
+// executeExportSiteWithService renders the project export as a static site into opts.Output
+func executeExportSiteWithService(ctx context.Context, exportService service.ExportService, opts *ExportOptions) (*site.Result, error) {
+	var buf bytes.Buffer
+	resp, err := exportService.Export(ctx, &buf, &service.ExportRequest{ProjectID: opts.ProjectID})
+	if err != nil {
+		return nil, err
+	}
+
+	s := &site.Site{ProjectID: resp.ProjectID, GeneratedAt: time.Now().UTC().Format(time.RFC3339)}
+	dec := json.NewDecoder(&buf)
+	for {
+		var record service.ExportRecord
+		if err := dec.Decode(&record); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read export: %w", err)
+		}
+		switch record.Type {
+		case service.ExportRecordGroup:
+			s.Groups = append(s.Groups, record.Group)
+		case service.ExportRecordGlobal:
+			s.Globals = append(s.Globals, record.Global)
+		case service.ExportRecordNote:
+			s.Notes = append(s.Notes, record.Note)
+		}
+	}
+	return site.Write(opts.Output, opts.SiteFormat, s)
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// mockExportService is a mock implementation of ExportService for testing
+type mockExportService struct {
+	exportFunc func(ctx context.Context, w io.Writer, req *service.ExportRequest) (*service.ExportResponse, error)
+	importFunc func(ctx context.Context, r io.Reader, req *service.ImportRequest) (*service.ImportResponse, error)
+}
+
+func (m *mockExportService) Export(ctx context.Context, w io.Writer, req *service.ExportRequest) (*service.ExportResponse, error) {
+	if m.exportFunc != nil {
+		return m.exportFunc(ctx, w, req)
+	}
+	return &service.ExportResponse{}, nil
+}
+
+func (m *mockExportService) Import(ctx context.Context, r io.Reader, req *service.ImportRequest) (*service.ImportResponse, error) {
+	if m.importFunc != nil {
+		return m.importFunc(ctx, r, req)
+	}
+	return &service.ImportResponse{}, nil
+}
+
+// TestParseExportFlags tests flag parsing for export command
+func TestParseExportFlags(t *testing.T) {
+	tests := []struct {
+		name           string
+		args           []string
+		wantOutput     string
+		wantEmbeddings bool
+		wantErr        bool
+	}{
+		{
+			name:           "all flags",
+			args:           []string{"-p", "/test/project", "-o", "memory.jsonl", "--include-embeddings"},
+			wantOutput:     "memory.jsonl",
+			wantEmbeddings: true,
+		},
+		{
+			name:       "default output is stdout",
+			args:       []string{"--project", "/test/project"},
+			wantOutput: "-",
+		},
+		{
+			name:    "missing project",
+			args:    []string{"-o", "memory.jsonl"},
+			wantErr: true,
+		},
+		{
+			name:    "unexpected argument",
+			args:    []string{"-p", "/test/project", "memory.jsonl"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := parseExportFlags(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseExportFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if opts.Output != tt.wantOutput {
+				t.Errorf("Output = %q, want %q", opts.Output, tt.wantOutput)
+			}
+			if opts.IncludeEmbeddings != tt.wantEmbeddings {
+				t.Errorf("IncludeEmbeddings = %v, want %v", opts.IncludeEmbeddings, tt.wantEmbeddings)
+			}
+		})
+	}
+}
+
+// TestExecuteExport tests that export options are passed to the service
+func TestExecuteExport(t *testing.T) {
+	mockService := &mockExportService{
+		exportFunc: func(ctx context.Context, w io.Writer, req *service.ExportRequest) (*service.ExportResponse, error) {
+			if req.ProjectID != "/test/project" {
+				t.Errorf("ProjectID = %q, want /test/project", req.ProjectID)
+			}
+			if !req.IncludeEmbeddings {
+				t.Error("expected IncludeEmbeddings to be true")
+			}
+			io.WriteString(w, `{"type":"header","version":1}`+"\n")
+			return &service.ExportResponse{ProjectID: req.ProjectID}, nil
+		},
+	}
+
+	var buf bytes.Buffer
+	opts := &ExportOptions{ProjectID: "/test/project", IncludeEmbeddings: true}
+	if _, err := executeExportWithService(context.Background(), mockService, opts, &buf); err != nil {
+		t.Fatalf("executeExportWithService() error = %v", err)
+	}
+	if buf.Len() == 0 {
+		t.Error("expected export output to be written")
+	}
+}
+
+// TestParseExportFlags_Site tests validation of --format site
+func TestParseExportFlags_Site(t *testing.T) {
+	opts, err := parseExportFlags([]string{"-p", "/test/project", "--format", "site", "--site-format", "markdown", "-o", "site"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.Format != exportFormatSite || opts.SiteFormat != "markdown" || opts.Output != "site" {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+	if opts, err := parseExportFlags([]string{"-p", "/test/project", "--format", "site", "-o", "site"}); err != nil || opts.SiteFormat != "html" {
+		t.Errorf("expected html by default, got %+v, %v", opts, err)
+	}
+
+	for _, args := range [][]string{
+		{"-p", "/test/project", "--format", "site"},
+		{"-p", "/test/project", "--format", "site", "-o", "site", "--include-embeddings"},
+		{"-p", "/test/project", "--format", "site", "-o", "site", "--site-format", "pdf"},
+		{"-p", "/test/project", "--format", "csv"},
+		{"-p", "/test/project", "--site-format", "markdown"},
+	} {
+		if _, err := parseExportFlags(args); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+}
+
+// TestExecuteExportSite tests that the exported records are rendered as a site
+func TestExecuteExportSite(t *testing.T) {
+	mockService := &mockExportService{
+		exportFunc: func(ctx context.Context, w io.Writer, req *service.ExportRequest) (*service.ExportResponse, error) {
+			if req.IncludeEmbeddings {
+				t.Error("expected IncludeEmbeddings to be false")
+			}
+			io.WriteString(w, `{"type":"header","version":1,"projectId":"/canonical/project"}
+{"type":"group","group":{"groupKey":"design","title":"Design decisions"}}
+{"type":"global","global":{"key":"global.memory.language","value":"ja"}}
+{"type":"note","note":{"id":"n1","groupId":"design","title":"Auth","text":"Use JWT.","tags":["auth"],"createdAt":"2024-03-01T00:00:00Z"}}
+`)
+			return &service.ExportResponse{ProjectID: "/canonical/project", Notes: 1, Globals: 1, Groups: 1}, nil
+		},
+	}
+
+	dir := filepath.Join(t.TempDir(), "site")
+	opts := &ExportOptions{ProjectID: "/test/project", Format: exportFormatSite, SiteFormat: "markdown", Output: dir}
+	result, err := executeExportSiteWithService(context.Background(), mockService, opts)
+	if err != nil {
+		t.Fatalf("executeExportSiteWithService() error = %v", err)
+	}
+	if result.Notes != 1 || result.Groups != 1 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	index, err := os.ReadFile(filepath.Join(dir, "index.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"# /canonical/project", "[Design decisions](groups/design.md)", "global.memory.language"} {
+		if !strings.Contains(string(index), want) {
+			t.Errorf("index.md does not contain %q:\n%s", want, index)
+		}
+	}
+	if page, err := os.ReadFile(filepath.Join(dir, "groups", "design.md")); err != nil || !strings.Contains(string(page), "## Auth") {
+		t.Errorf("unexpected group page: %s, %v", page, err)
+	}
+}
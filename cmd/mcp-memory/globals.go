@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/brbranch/embedding_mcp/internal/bootstrap"
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// GlobalsCopyOptions holds parsed globals copy command options
+type GlobalsCopyOptions struct {
+	From         string
+	To           string
+	SkipExisting bool
+	Overwrite    bool
+	ConfigPath   string
+}
+
+// parseGlobalsCopyFlags parses command line arguments for globals copy command
+func parseGlobalsCopyFlags(args []string) (*GlobalsCopyOptions, error) {
+	fs := flag.NewFlagSet("globals copy", flag.ContinueOnError)
+	fs.SetOutput(io.Discard) // suppress default error output
+
+	opts := &GlobalsCopyOptions{}
+
+	// Long flags
+	fs.StringVar(&opts.From, "from", "", "Source project ID/path (required)")
+	fs.StringVar(&opts.To, "to", "", "Target project ID/path (required)")
+	fs.BoolVar(&opts.SkipExisting, "skip-existing", false, "Keep keys that already exist in the target project")
+	fs.BoolVar(&opts.Overwrite, "overwrite", false, "Overwrite keys that already exist in the target project")
+	fs.StringVar(&opts.ConfigPath, "config", "", "Config file path")
+
+	// Short flags
+	fs.StringVar(&opts.ConfigPath, "c", "", "Config file path")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if err := applyFlagEnv(fs); err != nil {
+		return nil, err
+	}
+
+	// Validation
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+	if opts.From == "" || opts.To == "" {
+		return nil, fmt.Errorf("source and target projects are required (--from and --to)")
+	}
+	if opts.SkipExisting && opts.Overwrite {
+		return nil, fmt.Errorf("--skip-existing and --overwrite are mutually exclusive")
+	}
+
+	return opts, nil
+}
+
+// mode returns the import mode selected by the flags
+func (o *GlobalsCopyOptions) mode() service.ImportMode {
+	switch {
+	case o.SkipExisting:
+		return service.ImportModeSkipExisting
+	case o.Overwrite:
+		return service.ImportModeOverwrite
+	default:
+		return service.ImportModeFail
+	}
+}
+
+// runGlobalsCmd is the entry point for globals command
+func runGlobalsCmd(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("globals subcommand is required (copy)")
+	}
+	switch args[0] {
+	case "copy":
+		return runGlobalsCopyCmd(args[1:])
+	default:
+		return fmt.Errorf("unknown globals subcommand: %s", args[0])
+	}
+}
+
+// runGlobalsCopyCmd is the entry point for globals copy command
+func runGlobalsCopyCmd(args []string) error {
+	opts, err := parseGlobalsCopyFlags(args)
+	if err != nil {
+		return err
+	}
+
+	// Initialize services (this also applies the projectId rules from the config)
+	ctx := context.Background()
+	services, cleanup, err := bootstrap.Initialize(ctx, opts.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+	defer cleanup()
+
+	// GlobalService does not canonicalize projectId
+	for _, id := range []*string{&opts.From, &opts.To} {
+		canonical, err := config.CanonicalizeProjectID(*id)
+		if err != nil {
+			return fmt.Errorf("failed to canonicalize project ID: %w", err)
+		}
+		*id = canonical
+	}
+
+	if _, err := executeGlobalsCopyWithService(ctx, services.GlobalService, opts, os.Stdout); err != nil {
+		return fmt.Errorf("globals copy failed: %w", err)
+	}
+	return nil
+}
+
+// executeGlobalsCopyWithService exports the global config of opts.From and imports it into opts.To.
+// It returns nil if the source project has no global config.
+func executeGlobalsCopyWithService(ctx context.Context, globalService service.GlobalService, opts *GlobalsCopyOptions, out io.Writer) (*service.ImportGlobalsResponse, error) {
+	if opts.From == opts.To {
+		return nil, fmt.Errorf("source and target projects are the same: %s", opts.From)
+	}
+
+	exported, err := globalService.ExportGlobals(ctx, opts.From)
+	if err != nil {
+		return nil, err
+	}
+	if len(exported.Globals) == 0 {
+		fmt.Fprintf(out, "no global config in %s; nothing to copy\n", opts.From)
+		return nil, nil
+	}
+
+	resp, err := globalService.ImportGlobals(ctx, &service.ImportGlobalsRequest{
+		ProjectID: opts.To,
+		Globals:   exported.Globals,
+		Mode:      opts.mode(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(out, "copied global config from %s into %s: %d created, %d updated, %d skipped\n",
+		opts.From, resp.ProjectID, resp.Created, resp.Updated, resp.Skipped)
+	return resp, nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/service"
+	"github.com/brbranch/embedding_mcp/internal/store"
+)
+
+// TestParseGlobalsCopyFlags tests flag parsing for globals copy command
+func TestParseGlobalsCopyFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantMode service.ImportMode
+		wantErr  bool
+	}{
+		{
+			name:     "without mode",
+			args:     []string{"--from", "/test/src", "--to", "/test/dst"},
+			wantMode: service.ImportModeFail,
+		},
+		{
+			name:     "skip existing",
+			args:     []string{"--from", "/test/src", "--to", "/test/dst", "--skip-existing", "-c", "config.json"},
+			wantMode: service.ImportModeSkipExisting,
+		},
+		{
+			name:     "overwrite",
+			args:     []string{"--overwrite", "--from", "/test/src", "--to", "/test/dst"},
+			wantMode: service.ImportModeOverwrite,
+		},
+		{
+			name:    "missing target",
+			args:    []string{"--from", "/test/src"},
+			wantErr: true,
+		},
+		{
+			name:    "both modes",
+			args:    []string{"--from", "/test/src", "--to", "/test/dst", "--skip-existing", "--overwrite"},
+			wantErr: true,
+		},
+		{
+			name:    "extra argument",
+			args:    []string{"--from", "/test/src", "--to", "/test/dst", "extra"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := parseGlobalsCopyFlags(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseGlobalsCopyFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if opts.From != "/test/src" || opts.To != "/test/dst" || opts.mode() != tt.wantMode {
+				t.Errorf("parseGlobalsCopyFlags() = %+v, mode %q", *opts, opts.mode())
+			}
+		})
+	}
+}
+
+// TestExecuteGlobalsCopy tests copying global config between projects
+func TestExecuteGlobalsCopy(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemoryStore()
+	if err := st.Initialize(ctx, "openai:test:3"); err != nil {
+		t.Fatal(err)
+	}
+	svc := service.NewGlobalService(st, "openai:test:3")
+	for _, req := range []*service.UpsertGlobalRequest{
+		{ProjectID: "/test/src", Key: "global.project.conventions", Value: "tabs"},
+		{ProjectID: "/test/src", Key: "global.memory.autoCreateGroup", Value: true},
+		{ProjectID: "/test/dst", Key: "global.project.conventions", Value: "spaces"},
+	} {
+		if _, err := svc.UpsertGlobal(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := &GlobalsCopyOptions{From: "/test/src", To: "/test/dst"}
+	var out bytes.Buffer
+	if _, err := executeGlobalsCopyWithService(ctx, svc, opts, &out); !errors.Is(err, service.ErrImportConflict) {
+		t.Fatalf("expected ErrImportConflict, got %v", err)
+	}
+
+	opts.SkipExisting = true
+	resp, err := executeGlobalsCopyWithService(ctx, svc, opts, &out)
+	if err != nil {
+		t.Fatalf("executeGlobalsCopyWithService() error = %v", err)
+	}
+	if resp.Created != 1 || resp.Skipped != 1 {
+		t.Errorf("unexpected result: %+v (output: %s)", resp, out.String())
+	}
+
+	// 空のプロジェクトからは何もコピーしない
+	resp, err = executeGlobalsCopyWithService(ctx, svc, &GlobalsCopyOptions{From: "/test/empty", To: "/test/dst"}, &out)
+	if err != nil || resp != nil {
+		t.Errorf("expected nothing to copy, got %+v, %v", resp, err)
+	}
+
+	if _, err := executeGlobalsCopyWithService(ctx, svc, &GlobalsCopyOptions{From: "/test/src", To: "/test/src"}, &out); err == nil {
+		t.Error("expected error when copying into the same project")
+	}
+}
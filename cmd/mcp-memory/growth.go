@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// defaultNoteCheckInterval is how often serve checks the note count against store.noteThresholds
+const defaultNoteCheckInterval = time.Hour
+
+// noteCheckInterval returns store.noteThresholds.checkInterval (0 disables the check)
+func noteCheckInterval(cfg *model.Config) time.Duration {
+	if t := cfg.Store.NoteThresholds; t != nil && t.CheckInterval != "" {
+		// validated when the config was loaded
+		if d, err := config.ParseDuration(t.CheckInterval); err == nil {
+			return d
+		}
+	}
+	return defaultNoteCheckInterval
+}
+
+// watchNoteGrowth checks the note count at startup and then every interval until ctx is
+// cancelled. It does nothing if interval is 0.
+func watchNoteGrowth(ctx context.Context, stats service.StatsService, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	checkNoteGrowth(ctx, stats)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			checkNoteGrowth(ctx, stats)
+		}
+	}
+}
+
+// checkNoteGrowth logs a warning (soft threshold) or an error (hard threshold) with a
+// recommendation while the namespace holds more notes than a threshold
+func checkNoteGrowth(ctx context.Context, stats service.StatsService) {
+	resp, err := stats.Stats(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Warn("failed to check the note count", "error", err)
+		}
+		return
+	}
+	attrs := []any{"namespace", resp.Namespace, "notes", resp.Notes, "soft", resp.Soft, "hard", resp.Hard,
+		"growth", resp.Growth, "recommendation", resp.Recommendation}
+	switch resp.Level {
+	case service.NoteLevelCritical:
+		slog.Error("note count exceeded the hard threshold", attrs...)
+	case service.NoteLevelWarning:
+		slog.Warn("note count exceeded the soft threshold", attrs...)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// stubStatsService returns a fixed note count
+type stubStatsService struct {
+	resp *service.StatsResponse
+}
+
+func (s stubStatsService) Stats(ctx context.Context) (*service.StatsResponse, error) {
+	return s.resp, nil
+}
+
+// TestNoteCheckInterval tests the default and configured store.noteThresholds.checkInterval
+func TestNoteCheckInterval(t *testing.T) {
+	if got := noteCheckInterval(&model.Config{}); got != defaultNoteCheckInterval {
+		t.Errorf("expected the default interval, got %s", got)
+	}
+	cfg := &model.Config{Store: model.StoreConfig{NoteThresholds: &model.NoteThresholdsConfig{CheckInterval: "0"}}}
+	if got := noteCheckInterval(cfg); got != 0 {
+		t.Errorf("expected 0 (disabled), got %s", got)
+	}
+	cfg.Store.NoteThresholds.CheckInterval = "15m"
+	if got := noteCheckInterval(cfg); got != 15*time.Minute {
+		t.Errorf("expected 15m, got %s", got)
+	}
+}
+
+// TestCheckNoteGrowth tests that only counts above a threshold are logged
+func TestCheckNoteGrowth(t *testing.T) {
+	prev := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(prev) })
+	var buf bytes.Buffer
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	ctx := context.Background()
+	checkNoteGrowth(ctx, stubStatsService{&service.StatsResponse{Notes: 10, Soft: 5000, Level: service.NoteLevelOK}})
+	if buf.Len() != 0 {
+		t.Errorf("unexpected log below the thresholds: %s", buf.String())
+	}
+
+	checkNoteGrowth(ctx, stubStatsService{&service.StatsResponse{Namespace: "openai:test:3", Notes: 6000, Soft: 5000,
+		Level: service.NoteLevelWarning, Recommendation: "switch to the qdrant store"}})
+	out := buf.String()
+	for _, want := range []string{"level=WARN", "soft threshold", "notes=6000", "namespace=openai:test:3", "qdrant"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in log: %s", want, out)
+		}
+	}
+
+	buf.Reset()
+	checkNoteGrowth(ctx, stubStatsService{&service.StatsResponse{Notes: 25000, Soft: 5000, Hard: 20000, Level: service.NoteLevelCritical}})
+	if out := buf.String(); !strings.Contains(out, "level=ERROR") || !strings.Contains(out, "hard threshold") {
+		t.Errorf("expected an error log, got: %s", out)
+	}
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/gitcommit"
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// captureCommitTimeout bounds a capture-commit run started by the hook (embedding included)
+const captureCommitTimeout = 2 * time.Minute
+
+// HookOptions holds parsed hook install/uninstall command options
+type HookOptions struct {
+	Dir        string // directory inside the repository (default: current directory)
+	GroupID    string
+	ConfigPath string
+	Binary     string // mcp-memory binary the hook runs (default: this executable)
+	Sync       bool   // run capture-commit in the foreground instead of in the background
+}
+
+// parseHookFlags parses command line arguments for hook install (or uninstall when uninstall is true)
+func parseHookFlags(args []string, uninstall bool) (*HookOptions, error) {
+	name := "hook install"
+	if uninstall {
+		name = "hook uninstall"
+	}
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard) // suppress default error output
+
+	opts := &HookOptions{}
+
+	if !uninstall {
+		// Long flags
+		fs.StringVar(&opts.GroupID, "group", "", "Group ID for commit notes (default: the project's default group)")
+		fs.StringVar(&opts.ConfigPath, "config", "", "Config file path passed to capture-commit")
+		fs.StringVar(&opts.Binary, "binary", "", "mcp-memory binary the hook runs (default: this executable)")
+		fs.BoolVar(&opts.Sync, "sync", false, "Wait for capture-commit to finish instead of running it in the background")
+
+		// Short flags
+		fs.StringVar(&opts.GroupID, "g", "", "Group ID for commit notes")
+		fs.StringVar(&opts.ConfigPath, "c", "", "Config file path passed to capture-commit")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if err := applyFlagEnv(fs); err != nil {
+		return nil, err
+	}
+
+	opts.Dir = "."
+	switch fs.NArg() {
+	case 0:
+	case 1:
+		opts.Dir = fs.Arg(0)
+	default:
+		return nil, fmt.Errorf("unexpected argument: %s", fs.Arg(1))
+	}
+
+	// Validation
+	if opts.GroupID != "" {
+		if err := service.ValidateGroupID(opts.GroupID); err != nil {
+			return nil, fmt.Errorf("invalid group: %w", err)
+		}
+	}
+
+	return opts, nil
+}
+
+// runHookCmd is the entry point for hook command
+func runHookCmd(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("hook subcommand is required (install or uninstall)")
+	}
+	switch args[0] {
+	case "install", "uninstall":
+	default:
+		return fmt.Errorf("unknown hook subcommand: %s (must be install or uninstall)", args[0])
+	}
+	uninstall := args[0] == "uninstall"
+	opts, err := parseHookFlags(args[1:], uninstall)
+	if err != nil {
+		return err
+	}
+
+	hooksDir, err := gitcommit.HooksDir(context.Background(), opts.Dir)
+	if err != nil {
+		return fmt.Errorf("%w (run hook %s inside a git repository)", err, args[0])
+	}
+
+	if uninstall {
+		removed, err := gitcommit.UninstallHook(hooksDir)
+		if err != nil {
+			return err
+		}
+		if !removed {
+			fmt.Fprintf(os.Stdout, "no mcp-memory hook in %s\n", filepath.Join(hooksDir, gitcommit.HookName))
+			return nil
+		}
+		fmt.Fprintf(os.Stdout, "removed the mcp-memory hook from %s\n", filepath.Join(hooksDir, gitcommit.HookName))
+		return nil
+	}
+
+	block, err := hookBlock(opts)
+	if err != nil {
+		return err
+	}
+	path, err := gitcommit.InstallHook(hooksDir, block)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "installed %s (each commit is recorded with mcp-memory capture-commit)\n", path)
+	return nil
+}
+
+// hookBlock returns the hook lines running capture-commit with the install options
+func hookBlock(opts *HookOptions) (string, error) {
+	binary := opts.Binary
+	if binary == "" {
+		exe, err := os.Executable()
+		if err != nil {
+			return "", fmt.Errorf("failed to locate the mcp-memory binary (use --binary): %w", err)
+		}
+		binary = exe
+	}
+	hookArgs := []string{filepath.ToSlash(binary), "capture-commit"}
+	if opts.GroupID != "" {
+		hookArgs = append(hookArgs, "--group", opts.GroupID)
+	}
+	if opts.ConfigPath != "" {
+		configPath, err := filepath.Abs(opts.ConfigPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to get absolute path: %w", err)
+		}
+		hookArgs = append(hookArgs, "--config", filepath.ToSlash(configPath))
+	}
+	return gitcommit.HookBlock(hookArgs, !opts.Sync), nil
+}
+
+// CaptureCommitOptions holds parsed capture-commit command options
+type CaptureCommitOptions struct {
+	Rev        string // commit to record (default: HEAD)
+	Dir        string // directory inside the repository
+	ProjectID  string // default: git root of Dir
+	GroupID    string // default: the project's default group
+	Tags       string
+	MaxFiles   int
+	ConfigPath string
+	RemoteOptions
+}
+
+// parseCaptureCommitFlags parses command line arguments for capture-commit command
+func parseCaptureCommitFlags(args []string) (*CaptureCommitOptions, error) {
+	fs := flag.NewFlagSet("capture-commit", flag.ContinueOnError)
+	fs.SetOutput(io.Discard) // suppress default error output
+
+	opts := &CaptureCommitOptions{}
+
+	// Long flags
+	fs.StringVar(&opts.ProjectID, "project", "", "Project ID/path (default: git root of the current directory)")
+	fs.StringVar(&opts.GroupID, "group", "", "Group ID (default: the project's default group)")
+	fs.StringVar(&opts.Tags, "tags", "", "Tags added besides \"commit\" (comma-separated)")
+	fs.IntVar(&opts.MaxFiles, "max-files", gitcommit.DefaultMaxFiles, "Changed files listed in the note")
+	fs.StringVar(&opts.ConfigPath, "config", "", "Config file path")
+	opts.registerFlags(fs)
+
+	// Short flags
+	fs.StringVar(&opts.ProjectID, "p", "", "Project ID/path")
+	fs.StringVar(&opts.GroupID, "g", "", "Group ID")
+	fs.StringVar(&opts.ConfigPath, "c", "", "Config file path")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if err := applyFlagEnv(fs); err != nil {
+		return nil, err
+	}
+
+	opts.Dir = "."
+	opts.Rev = "HEAD"
+	switch fs.NArg() {
+	case 0:
+	case 1:
+		opts.Rev = fs.Arg(0)
+	default:
+		return nil, fmt.Errorf("unexpected argument: %s", fs.Arg(1))
+	}
+
+	// Validation
+	if opts.MaxFiles <= 0 {
+		return nil, fmt.Errorf("max-files must be positive")
+	}
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	return opts, nil
+}
+
+// runCaptureCommitCmd is the entry point for capture-commit command
+func runCaptureCommitCmd(args []string) error {
+	opts, err := parseCaptureCommitFlags(args)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), captureCommitTimeout)
+	defer cancel()
+
+	if opts.ProjectID == "" {
+		root, err := config.FindGitRoot(opts.Dir)
+		if err != nil {
+			return fmt.Errorf("%w (use -p or --project)", err)
+		}
+		opts.ProjectID = root
+	}
+	commit, err := gitcommit.Read(ctx, opts.Dir, opts.Rev)
+	if err != nil {
+		return err
+	}
+
+	noteService, cleanup, err := openNoteService(ctx, opts.ConfigPath, opts.RemoteOptions)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	resp, err := executeCaptureCommitWithService(ctx, noteService, opts, commit)
+	if errors.Is(err, service.ErrGroupIDRequired) {
+		return fmt.Errorf("group ID is required (-g or --group, defaultGroup in %s, or %s)", config.ProjectConfigFile, model.GlobalKeyDefaultGroup)
+	}
+	if err != nil {
+		return fmt.Errorf("capture-commit failed: %w", err)
+	}
+	fmt.Fprintln(os.Stdout, resp.ID)
+	return nil
+}
+
+// executeCaptureCommitWithService adds commit as a note using the provided NoteService.
+// The note is dated with the commit date so that list_recent follows the history.
+func executeCaptureCommitWithService(ctx context.Context, noteService service.NoteService, opts *CaptureCommitOptions, commit *gitcommit.Commit) (*service.AddNoteResponse, error) {
+	title := commit.Subject
+	source := "commit:" + commit.ShortHash()
+	createdAt := commit.Date.UTC().Format(time.RFC3339)
+	tags := []string{gitcommit.Tag}
+	for _, tag := range parseTags(opts.Tags) {
+		if tag != gitcommit.Tag {
+			tags = append(tags, tag)
+		}
+	}
+
+	req := &service.AddNoteRequest{
+		ProjectID: opts.ProjectID,
+		GroupID:   opts.GroupID,
+		Text:      commit.NoteText(opts.MaxFiles),
+		Tags:      tags,
+		Source:    &source,
+		CreatedAt: &createdAt,
+		Metadata:  map[string]any{"commit": commit.Hash},
+	}
+	if title != "" {
+		req.Title = &title
+	}
+	return noteService.AddNote(ctx, req)
+}
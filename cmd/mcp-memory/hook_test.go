@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/gitcommit"
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// TestParseHookFlags tests hook install/uninstall flag parsing and validation
+func TestParseHookFlags(t *testing.T) {
+	opts, err := parseHookFlags([]string{"-g", "commits", "--sync", "--binary", "/usr/local/bin/mcp-memory", "repo"}, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.GroupID != "commits" || !opts.Sync || opts.Binary != "/usr/local/bin/mcp-memory" || opts.Dir != "repo" {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+
+	for _, tc := range []struct {
+		args      []string
+		uninstall bool
+	}{
+		{[]string{"-g", "bad group!"}, false},
+		{[]string{"a", "b"}, false},
+		{[]string{"--group", "commits"}, true}, // uninstall takes no options
+	} {
+		if _, err := parseHookFlags(tc.args, tc.uninstall); err == nil {
+			t.Errorf("expected error for %v", tc.args)
+		}
+	}
+}
+
+// TestHookBlock tests the capture-commit command written to the hook
+func TestHookBlock(t *testing.T) {
+	block, err := hookBlock(&HookOptions{GroupID: "commits", Binary: "/usr/local/bin/mcp-memory"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(block, "'/usr/local/bin/mcp-memory' 'capture-commit' '--group' 'commits' </dev/null >/dev/null 2>&1 &") {
+		t.Errorf("unexpected hook block:\n%s", block)
+	}
+
+	block, err = hookBlock(&HookOptions{Binary: "mcp-memory", ConfigPath: "config.json", Sync: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(block, "&") || !strings.Contains(block, "'--config' '/") {
+		t.Errorf("expected a foreground command with an absolute config path:\n%s", block)
+	}
+}
+
+// TestParseCaptureCommitFlags tests capture-commit flag parsing and validation
+func TestParseCaptureCommitFlags(t *testing.T) {
+	opts, err := parseCaptureCommitFlags([]string{"-p", "~/project", "--tags", "release", "HEAD~1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.ProjectID != "~/project" || opts.Rev != "HEAD~1" || opts.MaxFiles != gitcommit.DefaultMaxFiles || opts.Tags != "release" {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+	if opts, _ := parseCaptureCommitFlags(nil); opts.Rev != "HEAD" || opts.ProjectID != "" {
+		t.Errorf("unexpected defaults: %+v", opts)
+	}
+
+	for _, args := range [][]string{
+		{"--max-files", "0"},
+		{"--remote", "127.0.0.1:8765", "--local"},
+		{"HEAD", "HEAD~1"},
+	} {
+		if _, err := parseCaptureCommitFlags(args); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+}
+
+// TestExecuteCaptureCommit tests the note added for a commit
+func TestExecuteCaptureCommit(t *testing.T) {
+	commit := &gitcommit.Commit{
+		Hash:    "0123456789abcdef0123456789abcdef01234567",
+		Author:  "Alice",
+		Date:    time.Date(2024, 1, 15, 19, 30, 0, 0, time.FixedZone("JST", 9*60*60)),
+		Subject: "Use JWT for sessions",
+		Files:   []gitcommit.FileChange{{Path: "auth.go", Additions: 10, Deletions: 2}},
+	}
+	mockService := &mockNoteService{
+		addNoteFunc: func(ctx context.Context, req *service.AddNoteRequest) (*service.AddNoteResponse, error) {
+			if req.ProjectID != "/repo" || req.GroupID != "" {
+				t.Errorf("unexpected project/group: %q/%q", req.ProjectID, req.GroupID)
+			}
+			if req.Title == nil || *req.Title != "Use JWT for sessions" {
+				t.Errorf("unexpected title: %v", req.Title)
+			}
+			if req.Source == nil || *req.Source != "commit:0123456789ab" {
+				t.Errorf("unexpected source: %v", req.Source)
+			}
+			if req.CreatedAt == nil || *req.CreatedAt != "2024-01-15T10:30:00Z" {
+				t.Errorf("expected the commit date in UTC, got %v", req.CreatedAt)
+			}
+			if strings.Join(req.Tags, ",") != "commit,release" {
+				t.Errorf("unexpected tags: %v", req.Tags)
+			}
+			if req.Metadata["commit"] != commit.Hash || !strings.Contains(req.Text, "auth.go (+10 -2)") {
+				t.Errorf("unexpected note: %v\n%s", req.Metadata, req.Text)
+			}
+			return &service.AddNoteResponse{ID: "note-1"}, nil
+		},
+	}
+
+	resp, err := executeCaptureCommitWithService(context.Background(), mockService, &CaptureCommitOptions{
+		ProjectID: "/repo",
+		Tags:      "commit, release",
+		MaxFiles:  gitcommit.DefaultMaxFiles,
+	}, commit)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ID != "note-1" {
+		t.Errorf("expected id note-1, got %q", resp.ID)
+	}
+}
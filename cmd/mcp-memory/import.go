@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/brbranch/embedding_mcp/internal/bootstrap"
+	"github.com/brbranch/embedding_mcp/internal/importer"
+	"github.com/brbranch/embedding_mcp/internal/ingest"
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// importFormatJSONL is the default import format (an export of this tool)
+const importFormatJSONL = "jsonl"
+
+// importDocumentFlags are the flags that only apply to --format other than jsonl
+var importDocumentFlags = []string{"group", "g", "tags", "chunk-size", "overlap", "dry-run"}
+
+// ImportOptions holds parsed import command options
+type ImportOptions struct {
+	ProjectID    string
+	Input        string
+	Format       string // jsonl or one of importer.Formats
+	SkipExisting bool
+	Overwrite    bool
+	ConfigPath   string
+
+	// Options for --format other than jsonl
+	GroupID   string // default: the format name
+	Tags      string
+	ChunkSize int
+	Overlap   int
+	DryRun    bool
+}
+
+// parseImportFlags parses command line arguments for import command
+func parseImportFlags(args []string) (*ImportOptions, error) {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.SetOutput(io.Discard) // suppress default error output
+
+	opts := &ImportOptions{}
+
+	// Long flags
+	fs.StringVar(&opts.ProjectID, "project", "", "Project ID/path (required)")
+	fs.StringVar(&opts.Format, "format", importFormatJSONL, "Input format: jsonl, notion, logseq, roam, bookmarks")
+	fs.BoolVar(&opts.SkipExisting, "skip-existing", false, "Keep existing records")
+	fs.BoolVar(&opts.Overwrite, "overwrite", false, "Overwrite existing records")
+	fs.StringVar(&opts.ConfigPath, "config", "", "Config file path")
+	fs.StringVar(&opts.GroupID, "group", "", "Group ID for imported documents (default: the format name)")
+	fs.StringVar(&opts.Tags, "tags", "", "Tags added to imported documents (comma-separated)")
+	fs.IntVar(&opts.ChunkSize, "chunk-size", ingest.DefaultChunkSize, "Maximum chunk size in characters")
+	fs.IntVar(&opts.Overlap, "overlap", ingest.DefaultChunkOverlap, "Overlap between chunks in characters")
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "Show what would be imported")
+
+	// Short flags
+	fs.StringVar(&opts.ProjectID, "p", "", "Project ID/path (required)")
+	fs.StringVar(&opts.ConfigPath, "c", "", "Config file path")
+	fs.StringVar(&opts.GroupID, "g", "", "Group ID for imported documents")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	// Input file, optionally followed by more flags (import -p proj memory.jsonl --overwrite)
+	opts.Input = fs.Arg(0)
+	if fs.NArg() > 1 {
+		if err := fs.Parse(fs.Args()[1:]); err != nil {
+			return nil, err
+		}
+		if fs.NArg() > 0 {
+			return nil, fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+		}
+	}
+	if err := applyFlagEnv(fs); err != nil {
+		return nil, err
+	}
+
+	// Validation
+	if opts.ProjectID == "" {
+		return nil, fmt.Errorf("project ID is required (-p or --project)")
+	}
+	if opts.Input == "" {
+		return nil, fmt.Errorf("input file is required (- for stdin)")
+	}
+	if opts.SkipExisting && opts.Overwrite {
+		return nil, fmt.Errorf("--skip-existing and --overwrite are mutually exclusive")
+	}
+
+	if opts.Format == importFormatJSONL {
+		var set []string
+		fs.Visit(func(f *flag.Flag) {
+			switch {
+			case len(f.Name) == 1 && slices.Contains(importDocumentFlags, f.Name):
+				set = append(set, "-"+f.Name)
+			case slices.Contains(importDocumentFlags, f.Name):
+				set = append(set, "--"+f.Name)
+			}
+		})
+		if len(set) > 0 {
+			return nil, fmt.Errorf("%s requires --format %s", strings.Join(set, ", "), strings.Join(importer.Formats, "|"))
+		}
+		return opts, nil
+	}
+
+	if !slices.Contains(importer.Formats, opts.Format) {
+		return nil, fmt.Errorf("invalid format: %s (must be %s or %s)", opts.Format, importFormatJSONL, strings.Join(importer.Formats, ", "))
+	}
+	if opts.SkipExisting || opts.Overwrite {
+		return nil, fmt.Errorf("--skip-existing and --overwrite apply only to --format jsonl (documents are always updated in place)")
+	}
+	if opts.Format == importer.FormatNotion && opts.Input == "-" {
+		return nil, fmt.Errorf("--format notion reads a zip file or directory, not stdin")
+	}
+	if opts.GroupID == "" {
+		opts.GroupID = opts.Format
+	}
+	if err := service.ValidateGroupID(opts.GroupID); err != nil {
+		return nil, fmt.Errorf("invalid group: %w", err)
+	}
+	if opts.ChunkSize <= 0 {
+		return nil, fmt.Errorf("--chunk-size must be positive")
+	}
+	if opts.Overlap < 0 || opts.Overlap >= opts.ChunkSize {
+		return nil, fmt.Errorf("--overlap must be between 0 and chunk size")
+	}
+
+	return opts, nil
+}
+
+// mode returns the import mode selected by the flags
+func (o *ImportOptions) mode() service.ImportMode {
+	switch {
+	case o.SkipExisting:
+		return service.ImportModeSkipExisting
+	case o.Overwrite:
+		return service.ImportModeOverwrite
+	default:
+		return service.ImportModeFail
+	}
+}
+
+// runImportCmd is the entry point for import command
+func runImportCmd(args []string) error {
+	opts, err := parseImportFlags(args)
+	if err != nil {
+		return err
+	}
+	if opts.Format != importFormatJSONL {
+		return runImportDocuments(opts)
+	}
+
+	var r io.Reader = os.Stdin
+	if opts.Input != "-" {
+		f, err := os.Open(opts.Input)
+		if err != nil {
+			return fmt.Errorf("failed to open input file: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	// Initialize services
+	ctx := context.Background()
+	services, cleanup, err := bootstrap.Initialize(ctx, opts.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+	defer cleanup()
+
+	// Execute import (projectId is canonicalized by ExportService)
+	resp, err := executeImportWithService(ctx, services.ExportService, opts, r)
+	if err != nil {
+		if resp != nil {
+			fmt.Fprintln(os.Stderr, describeImportFailure(resp))
+		}
+		return fmt.Errorf("import failed: %w", err)
+	}
+
+	fmt.Fprintf(os.Stdout, "imported into %s: %d created, %d updated, %d skipped (%d re-embedded)\n",
+		resp.ProjectID, resp.Created, resp.Updated, resp.Skipped, resp.ReEmbedded)
+	return nil
+}
+
+// describeImportFailure reports what a failed import left in the store and which records failed
+func describeImportFailure(resp *service.ImportResponse) string {
+	at := ""
+	if resp.Failed != "" {
+		at = " at " + resp.Failed
+	}
+	var b strings.Builder
+	switch {
+	case resp.RolledBack:
+		fmt.Fprintf(&b, "import failed%s and was rolled back: nothing was imported into %s", at, resp.ProjectID)
+	case resp.Created+resp.Updated+resp.Skipped == 0:
+		fmt.Fprintf(&b, "import failed%s: nothing was imported into %s", at, resp.ProjectID)
+	default:
+		fmt.Fprintf(&b, "import stopped%s after partially importing into %s: %d created, %d updated, %d skipped (re-run with --skip-existing to import the rest)",
+			at, resp.ProjectID, resp.Created, resp.Updated, resp.Skipped)
+	}
+	for _, item := range resp.Items {
+		if item.Status == service.ItemFailed {
+			fmt.Fprintf(&b, "\n  failed: %s: %v", item.ID, item.Err)
+		}
+	}
+	return b.String()
+}
+
+// executeImportWithService imports records using the provided ExportService
+func executeImportWithService(ctx context.Context, exportService service.ExportService, opts *ImportOptions, r io.Reader) (*service.ImportResponse, error) {
+	return exportService.Import(ctx, r, &service.ImportRequest{
+		ProjectID: opts.ProjectID,
+		Mode:      opts.mode(),
+	})
+}
+
+// runImportDocuments imports an export of another knowledge base (--format notion|logseq|roam|bookmarks)
+func runImportDocuments(opts *ImportOptions) error {
+	docs, err := importer.Read(opts.Format, opts.Input)
+	if err != nil {
+		return err
+	}
+
+	if opts.DryRun {
+		printImportPlan(os.Stdout, opts, docs)
+		return nil
+	}
+
+	// Initialize services
+	ctx := context.Background()
+	services, cleanup, err := bootstrap.Initialize(ctx, opts.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+	defer cleanup()
+
+	result, err := executeImportDocumentsWithService(ctx, services.SyncService, opts, docs)
+	if err != nil {
+		return fmt.Errorf("import failed after %d documents: %w", result.Files, err)
+	}
+
+	fmt.Fprintf(os.Stdout, "imported %d documents into group %s: %d created, %d updated, %d unchanged, %d deleted\n",
+		result.Files, opts.GroupID, result.Created, result.Updated, result.Unchanged, result.Deleted)
+	return nil
+}
+
+// printImportPlan prints the documents and chunk counts for --dry-run
+func printImportPlan(w io.Writer, opts *ImportOptions, docs []importer.Document) {
+	total := 0
+	for _, doc := range docs {
+		chunks := doc.Chunks(opts.ChunkSize, opts.Overlap)
+		fmt.Fprintf(w, "%s (%s): %d chunks\n", doc.Source, doc.Title, len(chunks))
+		total += len(chunks)
+	}
+	fmt.Fprintf(w, "would import %d chunks from %d documents into group %s\n", total, len(docs), opts.GroupID)
+}
+
+// executeImportDocumentsWithService syncs every document using the provided SyncService.
+// Like ingest, notes are keyed by the document's source, so re-importing an export updates
+// the notes in place instead of adding duplicates.
+func executeImportDocumentsWithService(ctx context.Context, syncService service.SyncService, opts *ImportOptions, docs []importer.Document) (*ingestResult, error) {
+	ingestOpts := &IngestOptions{ProjectID: opts.ProjectID, GroupID: opts.GroupID, Tags: opts.Tags}
+	result := &ingestResult{}
+	for _, doc := range docs {
+		req := buildSyncRequest(ingestOpts, doc.Source, doc.Chunks(opts.ChunkSize, opts.Overlap))
+		if doc.CreatedAt != "" {
+			for i := range req.Chunks {
+				req.Chunks[i].CreatedAt = &doc.CreatedAt
+			}
+		}
+		resp, err := syncService.Sync(ctx, req)
+		if err != nil {
+			return result, fmt.Errorf("%s: %w", doc.Source, err)
+		}
+		result.add(resp)
+	}
+	return result, nil
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/importer"
+	"github.com/brbranch/embedding_mcp/internal/ingest"
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// TestParseImportFlags tests flag parsing for import command
+func TestParseImportFlags(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantInput string
+		wantMode  service.ImportMode
+		wantErr   bool
+	}{
+		{
+			name:      "flags after file",
+			args:      []string{"-p", "/test/project", "memory.jsonl", "--skip-existing"},
+			wantInput: "memory.jsonl",
+			wantMode:  service.ImportModeSkipExisting,
+		},
+		{
+			name:      "flags before file",
+			args:      []string{"--overwrite", "--project", "/test/project", "memory.jsonl"},
+			wantInput: "memory.jsonl",
+			wantMode:  service.ImportModeOverwrite,
+		},
+		{
+			name:      "stdin without mode",
+			args:      []string{"-p", "/test/project", "-"},
+			wantInput: "-",
+			wantMode:  service.ImportModeFail,
+		},
+		{
+			name:    "missing project",
+			args:    []string{"memory.jsonl"},
+			wantErr: true,
+		},
+		{
+			name:    "missing file",
+			args:    []string{"-p", "/test/project"},
+			wantErr: true,
+		},
+		{
+			name:    "both modes",
+			args:    []string{"-p", "/test/project", "memory.jsonl", "--skip-existing", "--overwrite"},
+			wantErr: true,
+		},
+		{
+			name:    "extra argument",
+			args:    []string{"-p", "/test/project", "a.jsonl", "b.jsonl"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := parseImportFlags(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseImportFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if opts.Input != tt.wantInput {
+				t.Errorf("Input = %q, want %q", opts.Input, tt.wantInput)
+			}
+			if opts.mode() != tt.wantMode {
+				t.Errorf("mode() = %q, want %q", opts.mode(), tt.wantMode)
+			}
+		})
+	}
+}
+
+// TestExecuteImport tests that import options are passed to the service
+func TestExecuteImport(t *testing.T) {
+	mockService := &mockExportService{
+		importFunc: func(ctx context.Context, r io.Reader, req *service.ImportRequest) (*service.ImportResponse, error) {
+			if req.Mode != service.ImportModeOverwrite {
+				t.Errorf("Mode = %q, want overwrite", req.Mode)
+			}
+			data, _ := io.ReadAll(r)
+			if string(data) != "input" {
+				t.Errorf("reader content = %q, want input", data)
+			}
+			return &service.ImportResponse{Created: 1}, nil
+		},
+	}
+
+	opts := &ImportOptions{ProjectID: "/test/project", Overwrite: true}
+	resp, err := executeImportWithService(context.Background(), mockService, opts, strings.NewReader("input"))
+	if err != nil {
+		t.Fatalf("executeImportWithService() error = %v", err)
+	}
+	if resp.Created != 1 {
+		t.Errorf("Created = %d, want 1", resp.Created)
+	}
+}
+
+// TestExecuteImport_Error tests error propagation from the service
+func TestExecuteImport_Error(t *testing.T) {
+	mockService := &mockExportService{
+		importFunc: func(ctx context.Context, r io.Reader, req *service.ImportRequest) (*service.ImportResponse, error) {
+			return nil, service.ErrImportConflict
+		},
+	}
+
+	_, err := executeImportWithService(context.Background(), mockService, &ImportOptions{ProjectID: "/test/project"}, strings.NewReader(""))
+	if !errors.Is(err, service.ErrImportConflict) {
+		t.Errorf("expected ErrImportConflict, got %v", err)
+	}
+}
+
+// TestDescribeImportFailure tests the report of what a failed import left in the store
+func TestDescribeImportFailure(t *testing.T) {
+	got := describeImportFailure(&service.ImportResponse{ProjectID: "/test/project", Failed: "note n2", RolledBack: true})
+	if !strings.Contains(got, "at note n2") || !strings.Contains(got, "nothing was imported into /test/project") {
+		t.Errorf("unexpected rollback report: %s", got)
+	}
+
+	got = describeImportFailure(&service.ImportResponse{ProjectID: "/test/project", Failed: "note n2", Created: 2})
+	if !strings.Contains(got, "at note n2") || !strings.Contains(got, "2 created") || !strings.Contains(got, "--skip-existing") {
+		t.Errorf("unexpected partial import report: %s", got)
+	}
+
+	got = describeImportFailure(&service.ImportResponse{ProjectID: "/test/project", Items: []service.ItemResult{
+		{Index: 0, ID: "note n1", Status: service.ItemFailed, Err: service.ErrImportConflict},
+		{Index: 1, ID: "note n2", Status: service.ItemPending},
+		{Index: 2, ID: "note n3", Status: service.ItemFailed, Err: service.ErrImportConflict},
+	}})
+	if !strings.Contains(got, "nothing was imported") || !strings.Contains(got, "failed: note n1: record already exists") ||
+		!strings.Contains(got, "failed: note n3") || strings.Contains(got, "note n2") {
+		t.Errorf("unexpected conflict report: %s", got)
+	}
+}
+
+// TestParseImportFlags_Format tests the options of --format other than jsonl
+func TestParseImportFlags_Format(t *testing.T) {
+	opts, err := parseImportFlags([]string{"-p", "/test/project", "--format", "notion", "export.zip", "--tags", "wiki", "--dry-run"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.Format != importer.FormatNotion || opts.GroupID != "notion" || opts.Tags != "wiki" || !opts.DryRun || opts.ChunkSize != ingest.DefaultChunkSize {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+	if opts, _ := parseImportFlags([]string{"-p", "/test/project", "--format", "bookmarks", "-g", "links", "-"}); opts == nil || opts.GroupID != "links" {
+		t.Errorf("expected -g to set the group, got %+v", opts)
+	}
+
+	for _, args := range [][]string{
+		{"-p", "/test/project", "memory.jsonl", "-g", "docs"},                  // jsonl takes no document options
+		{"-p", "/test/project", "--format", "evernote", "export.enex"},         // unknown format
+		{"-p", "/test/project", "--format", "logseq", "--overwrite", "g.json"}, // modes are for jsonl
+		{"-p", "/test/project", "--format", "notion", "-"},                     // notion needs a zip or directory
+		{"-p", "/test/project", "--format", "roam", "--overlap", "2000", "g.json"},
+	} {
+		if _, err := parseImportFlags(args); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+}
+
+// TestExecuteImportDocuments tests the sync requests built from imported documents
+func TestExecuteImportDocuments(t *testing.T) {
+	docs := []importer.Document{
+		{
+			Source:    "notion:Wiki/Setup.md",
+			Title:     "Setup",
+			Tags:      []string{"notion", "onboarding"},
+			CreatedAt: "2024-03-01T00:00:00Z",
+			Sections:  []ingest.Section{{Heading: "Setup", Text: "# Setup\n\nInstall Go."}, {Heading: "Database", Text: "## Database\n\nRun migrations."}},
+		},
+		{Source: "notion:Wiki.md", Title: "Wiki", Sections: []ingest.Section{{Text: "Team handbook."}}},
+	}
+
+	var reqs []*service.SyncRequest
+	mockService := &mockSyncService{
+		syncFunc: func(ctx context.Context, req *service.SyncRequest) (*service.SyncResponse, error) {
+			reqs = append(reqs, req)
+			return &service.SyncResponse{Created: len(req.Chunks)}, nil
+		},
+	}
+	opts := &ImportOptions{ProjectID: "/test/project", GroupID: "notion", Tags: "imported", ChunkSize: 1500, Overlap: 200}
+	result, err := executeImportDocumentsWithService(context.Background(), mockService, opts, docs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Files != 2 || result.Created != 3 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	req := reqs[0]
+	if req.Source != "notion:Wiki/Setup.md" || req.GroupID != "notion" || len(req.Chunks) != 2 {
+		t.Fatalf("unexpected request: %+v", req)
+	}
+	c := req.Chunks[1]
+	if *c.Title != "Setup > Database" || strings.Join(c.Tags, ",") != "imported,notion,onboarding" {
+		t.Errorf("unexpected chunk: %+v", c)
+	}
+	if c.CreatedAt == nil || *c.CreatedAt != "2024-03-01T00:00:00Z" {
+		t.Errorf("expected the document's createdAt, got %v", c.CreatedAt)
+	}
+	if reqs[1].Chunks[0].CreatedAt != nil {
+		t.Errorf("expected no createdAt for a document without a date")
+	}
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/brbranch/embedding_mcp/internal/bootstrap"
+	"github.com/brbranch/embedding_mcp/internal/ingest"
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// IngestOptions holds parsed ingest command options
+type IngestOptions struct {
+	ProjectID  string
+	GroupID    string
+	Include    string
+	Exclude    string
+	Tags       string
+	Base       string
+	ChunkSize  int
+	Overlap    int
+	DryRun     bool
+	ConfigPath string
+	Paths      []string
+	// FrontMatter takes the title and tags of markdown files from their YAML front matter
+	FrontMatter bool
+}
+
+// ingestFile is a collected file and its chunks
+type ingestFile struct {
+	File   ingest.File
+	Chunks []ingest.Chunk
+}
+
+// ingestResult is the summary of an ingest run
+type ingestResult struct {
+	Files     int
+	Created   int
+	Updated   int
+	Unchanged int
+	Deleted   int
+}
+
+// add accumulates the counts of a synced file
+func (r *ingestResult) add(resp *service.SyncResponse) {
+	r.Files++
+	r.Created += resp.Created
+	r.Updated += resp.Updated
+	r.Unchanged += resp.Unchanged
+	r.Deleted += resp.Deleted
+}
+
+// registerIngestFlags defines the flags shared by ingest and watch commands
+func registerIngestFlags(fs *flag.FlagSet, opts *IngestOptions) {
+	// Long flags
+	fs.StringVar(&opts.ProjectID, "project", "", "Project ID/path (required)")
+	fs.StringVar(&opts.GroupID, "group", "", "Group ID (required)")
+	fs.StringVar(&opts.Include, "include", "", "Include globs (comma-separated)")
+	fs.StringVar(&opts.Exclude, "exclude", "", "Exclude globs (comma-separated)")
+	fs.StringVar(&opts.Tags, "tags", "", "Tags (comma-separated)")
+	fs.StringVar(&opts.Base, "base", "", "Base directory for relative source paths")
+	fs.IntVar(&opts.ChunkSize, "chunk-size", ingest.DefaultChunkSize, "Maximum chunk size in characters")
+	fs.IntVar(&opts.Overlap, "overlap", ingest.DefaultChunkOverlap, "Overlap between chunks in characters")
+	fs.StringVar(&opts.ConfigPath, "config", "", "Config file path")
+	fs.BoolVar(&opts.FrontMatter, "front-matter", false, "Take markdown titles and tags from YAML front matter")
+
+	// Short flags
+	fs.StringVar(&opts.ProjectID, "p", "", "Project ID/path (required)")
+	fs.StringVar(&opts.GroupID, "g", "", "Group ID (required)")
+	fs.StringVar(&opts.ConfigPath, "c", "", "Config file path")
+}
+
+// parseIngestArgs parses flags and paths; paths may be interleaved with flags
+// (the shell expands globs in place)
+func parseIngestArgs(fs *flag.FlagSet, opts *IngestOptions, args []string) error {
+	for {
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		opts.Paths = append(opts.Paths, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if err := applyFlagEnv(fs); err != nil {
+		return err
+	}
+
+	// Validation
+	if opts.ProjectID == "" {
+		return fmt.Errorf("project ID is required (-p or --project)")
+	}
+	if opts.GroupID == "" {
+		return fmt.Errorf("group ID is required (-g or --group)")
+	}
+	if len(opts.Paths) == 0 {
+		return fmt.Errorf("at least one file, directory or glob is required")
+	}
+	if opts.ChunkSize <= 0 {
+		return fmt.Errorf("--chunk-size must be positive")
+	}
+	if opts.Overlap < 0 || opts.Overlap >= opts.ChunkSize {
+		return fmt.Errorf("--overlap must be between 0 and chunk size")
+	}
+	return nil
+}
+
+// parseIngestFlags parses command line arguments for ingest command
+func parseIngestFlags(args []string) (*IngestOptions, error) {
+	fs := flag.NewFlagSet("ingest", flag.ContinueOnError)
+	fs.SetOutput(io.Discard) // suppress default error output
+
+	opts := &IngestOptions{}
+	registerIngestFlags(fs, opts)
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "Show what would be ingested")
+
+	if err := parseIngestArgs(fs, opts, args); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// runIngestCmd is the entry point for ingest command
+func runIngestCmd(args []string) error {
+	opts, err := parseIngestFlags(args)
+	if err != nil {
+		return err
+	}
+
+	files, err := collectIngestFiles(opts, os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	if opts.DryRun {
+		printIngestPlan(os.Stdout, files)
+		return nil
+	}
+
+	// Initialize services
+	ctx := context.Background()
+	services, cleanup, err := bootstrap.Initialize(ctx, opts.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+	defer cleanup()
+
+	// Execute ingest (projectId is canonicalized by SyncService)
+	result, err := executeIngestWithService(ctx, services.SyncService, opts, files)
+	if err != nil {
+		return fmt.Errorf("ingest failed after %d files: %w", result.Files, err)
+	}
+
+	fmt.Fprintf(os.Stdout, "ingested %d files: %d created, %d updated, %d unchanged, %d deleted\n",
+		result.Files, result.Created, result.Updated, result.Unchanged, result.Deleted)
+	return nil
+}
+
+// collectIngestFiles walks the paths, parses each file and splits it into chunks.
+// Binary files are reported to warn and skipped.
+func collectIngestFiles(opts *IngestOptions, warn io.Writer) ([]ingestFile, error) {
+	files, err := ingest.Collect(opts.Paths, opts.walkOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ingestFile, 0, len(files))
+	for _, f := range files {
+		chunks, err := readIngestChunks(opts, f)
+		if errors.Is(err, ingest.ErrBinary) {
+			fmt.Fprintf(warn, "skip %s: %v\n", f.RelPath, err)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(chunks) == 0 {
+			continue
+		}
+		result = append(result, ingestFile{File: f, Chunks: chunks})
+	}
+	return result, nil
+}
+
+// walkOptions returns the ingest.WalkOptions for the options
+func (o *IngestOptions) walkOptions() ingest.WalkOptions {
+	return ingest.WalkOptions{
+		Base:    o.Base,
+		Include: parseTags(o.Include),
+		Exclude: parseTags(o.Exclude),
+	}
+}
+
+// readIngestChunks reads, parses and chunks a single file
+func readIngestChunks(opts *IngestOptions, f ingest.File) ([]ingest.Chunk, error) {
+	content, err := os.ReadFile(f.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", f.Path, err)
+	}
+	doc, err := ingest.Parse(f, content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", f.RelPath, err)
+	}
+	if opts.FrontMatter && doc.FrontMatter != nil {
+		if doc.FrontMatter.Title != "" {
+			doc.Title = doc.FrontMatter.Title
+		}
+		doc.Tags = doc.FrontMatter.Tags
+	}
+	return doc.Chunks(opts.ChunkSize, opts.Overlap), nil
+}
+
+// printIngestPlan prints the files and chunk counts for --dry-run
+func printIngestPlan(w io.Writer, files []ingestFile) {
+	total := 0
+	for _, f := range files {
+		fmt.Fprintf(w, "%s (%s): %d chunks\n", f.File.RelPath, f.File.Kind, len(f.Chunks))
+		total += len(f.Chunks)
+	}
+	fmt.Fprintf(w, "would ingest %d chunks from %d files\n", total, len(files))
+}
+
+// buildSyncRequest converts the chunks of a file into a sync request (source is the relative path)
+func buildSyncRequest(opts *IngestOptions, source string, chunks []ingest.Chunk) *service.SyncRequest {
+	tags := parseTags(opts.Tags)
+
+	req := &service.SyncRequest{
+		ProjectID: opts.ProjectID,
+		GroupID:   opts.GroupID,
+		Source:    source,
+		Chunks:    make([]service.SyncChunk, 0, len(chunks)),
+	}
+	for _, c := range chunks {
+		title := c.Title
+		chunkTags := tags
+		for _, tag := range c.Tags {
+			if !slices.Contains(chunkTags, tag) {
+				chunkTags = append(slices.Clip(chunkTags), tag)
+			}
+		}
+		req.Chunks = append(req.Chunks, service.SyncChunk{
+			Title: &title,
+			Text:  c.Text,
+			Tags:  chunkTags,
+			Metadata: map[string]any{
+				"chunkIndex": c.Index,
+				"chunkCount": len(chunks),
+			},
+		})
+	}
+	return req
+}
+
+// executeIngestWithService syncs every file using the provided SyncService.
+// Re-ingesting a file updates its notes in place (note IDs are derived from path and chunk).
+func executeIngestWithService(ctx context.Context, syncService service.SyncService, opts *IngestOptions, files []ingestFile) (*ingestResult, error) {
+	result := &ingestResult{}
+	for _, f := range files {
+		resp, err := syncService.Sync(ctx, buildSyncRequest(opts, f.File.RelPath, f.Chunks))
+		if err != nil {
+			return result, fmt.Errorf("%s: %w", f.File.RelPath, err)
+		}
+		result.add(resp)
+	}
+	return result, nil
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/ingest"
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// TestParseIngestFlags tests flag parsing for ingest command
+func TestParseIngestFlags(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantPaths  []string
+		wantDryRun bool
+		wantErr    bool
+	}{
+		{
+			name:      "defaults",
+			args:      []string{"-p", "/test/project", "-g", "docs", "./docs"},
+			wantPaths: []string{"./docs"},
+		},
+		{
+			name:       "flags after expanded paths",
+			args:       []string{"-p", "/test/project", "-g", "docs", "a.md", "b.md", "--dry-run", "c.md", "--exclude", "*.txt"},
+			wantPaths:  []string{"a.md", "b.md", "c.md"},
+			wantDryRun: true,
+		},
+		{
+			name:    "missing project",
+			args:    []string{"-g", "docs", "./docs"},
+			wantErr: true,
+		},
+		{
+			name:    "missing group",
+			args:    []string{"-p", "/test/project", "./docs"},
+			wantErr: true,
+		},
+		{
+			name:    "missing paths",
+			args:    []string{"-p", "/test/project", "-g", "docs"},
+			wantErr: true,
+		},
+		{
+			name:    "overlap too large",
+			args:    []string{"-p", "/test/project", "-g", "docs", "--chunk-size", "100", "--overlap", "100", "./docs"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid chunk size",
+			args:    []string{"-p", "/test/project", "-g", "docs", "--chunk-size", "0", "./docs"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := parseIngestFlags(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseIngestFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(opts.Paths, tt.wantPaths) {
+				t.Errorf("Paths = %v, want %v", opts.Paths, tt.wantPaths)
+			}
+			if opts.DryRun != tt.wantDryRun {
+				t.Errorf("DryRun = %v, want %v", opts.DryRun, tt.wantDryRun)
+			}
+		})
+	}
+}
+
+// setupIngestDir creates a docs tree and changes into it
+func setupIngestDir(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"docs/guide.md":      "# Guide\n\nIntro.\n\n## Install\n\n" + strings.Repeat("step ", 100),
+		"docs/drafts/wip.md": "# WIP\n\ndraft",
+		"docs/logo.txt":      "a\x00b",
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+}
+
+// TestCollectIngestFiles tests walking, filtering and chunking
+func TestCollectIngestFiles(t *testing.T) {
+	setupIngestDir(t)
+
+	opts := &IngestOptions{
+		Paths:     []string{"./docs"},
+		Exclude:   "**/drafts/**",
+		ChunkSize: 200,
+		Overlap:   20,
+	}
+	var warn bytes.Buffer
+	files, err := collectIngestFiles(opts, &warn)
+	if err != nil {
+		t.Fatalf("collectIngestFiles failed: %v", err)
+	}
+
+	if len(files) != 1 || files[0].File.RelPath != "docs/guide.md" {
+		t.Fatalf("expected only docs/guide.md, got %+v", files)
+	}
+	if len(files[0].Chunks) < 3 {
+		t.Errorf("expected guide to be split into several chunks, got %d", len(files[0].Chunks))
+	}
+	if !strings.Contains(warn.String(), "skip docs/logo.txt") {
+		t.Errorf("expected binary file warning, got %q", warn.String())
+	}
+
+	var out bytes.Buffer
+	printIngestPlan(&out, files)
+	if !strings.Contains(out.String(), "docs/guide.md (markdown)") || !strings.Contains(out.String(), "from 1 files") {
+		t.Errorf("unexpected plan output: %q", out.String())
+	}
+}
+
+// mockSyncService is a mock SyncService for testing
+type mockSyncService struct {
+	syncFunc        func(ctx context.Context, req *service.SyncRequest) (*service.SyncResponse, error)
+	listSourcesFunc func(ctx context.Context, projectID, groupID string) ([]string, error)
+}
+
+func (m *mockSyncService) Sync(ctx context.Context, req *service.SyncRequest) (*service.SyncResponse, error) {
+	if m.syncFunc != nil {
+		return m.syncFunc(ctx, req)
+	}
+	return &service.SyncResponse{Created: len(req.Chunks)}, nil
+}
+
+func (m *mockSyncService) ListSources(ctx context.Context, projectID, groupID string) ([]string, error) {
+	if m.listSourcesFunc != nil {
+		return m.listSourcesFunc(ctx, projectID, groupID)
+	}
+	return nil, nil
+}
+
+// TestExecuteIngest tests the sync request building
+func TestExecuteIngest(t *testing.T) {
+	setupIngestDir(t)
+
+	opts := &IngestOptions{
+		ProjectID: "/test/project",
+		GroupID:   "docs",
+		Tags:      "docs,ingest",
+		Paths:     []string{"docs/guide.md"},
+		ChunkSize: 50,
+		Overlap:   0,
+	}
+	files, err := collectIngestFiles(opts, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("collectIngestFiles failed: %v", err)
+	}
+	chunkCount := len(files[0].Chunks)
+
+	var got []*service.SyncRequest
+	mockService := &mockSyncService{
+		syncFunc: func(ctx context.Context, req *service.SyncRequest) (*service.SyncResponse, error) {
+			got = append(got, req)
+			return &service.SyncResponse{Created: 1, Unchanged: len(req.Chunks) - 1}, nil
+		},
+	}
+
+	result, err := executeIngestWithService(context.Background(), mockService, opts, files)
+	if err != nil {
+		t.Fatalf("executeIngestWithService failed: %v", err)
+	}
+	if result.Files != 1 || result.Created != 1 || result.Unchanged != chunkCount-1 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if len(got) != 1 {
+		t.Fatalf("expected 1 sync request, got %d", len(got))
+	}
+
+	req := got[0]
+	if req.ProjectID != "/test/project" || req.GroupID != "docs" || req.Source != "docs/guide.md" {
+		t.Errorf("unexpected request: %s/%s/%s", req.ProjectID, req.GroupID, req.Source)
+	}
+	if len(req.Chunks) != chunkCount {
+		t.Fatalf("expected %d chunks, got %d", chunkCount, len(req.Chunks))
+	}
+	first, last := req.Chunks[0], req.Chunks[len(req.Chunks)-1]
+	if first.Title == nil || *first.Title != "Guide" {
+		t.Errorf("expected title Guide, got %v", first.Title)
+	}
+	if last.Title == nil || *last.Title != "Guide > Install" {
+		t.Errorf("expected title 'Guide > Install', got %v", last.Title)
+	}
+	if !reflect.DeepEqual(first.Tags, []string{"docs", "ingest"}) {
+		t.Errorf("unexpected tags: %v", first.Tags)
+	}
+	if last.Metadata["chunkIndex"] != chunkCount-1 || last.Metadata["chunkCount"] != chunkCount {
+		t.Errorf("unexpected chunk metadata: %v", last.Metadata)
+	}
+}
+
+// TestReadIngestChunks_FrontMatter tests that --front-matter sets the title and tags
+func TestReadIngestChunks_FrontMatter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "note.md")
+	content := "---\ntitle: Runbook\ntags: [ops, docs]\n---\n# Heading\n\nbody"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f := ingest.File{Path: path, RelPath: "note.md", Kind: ingest.KindMarkdown}
+
+	opts := &IngestOptions{Tags: "docs,ingest", ChunkSize: 100, FrontMatter: true}
+	chunks, err := readIngestChunks(opts, f)
+	if err != nil {
+		t.Fatalf("readIngestChunks failed: %v", err)
+	}
+	req := buildSyncRequest(opts, f.RelPath, chunks)
+	first := req.Chunks[0]
+	if *first.Title != "Runbook > Heading" {
+		t.Errorf("expected front matter title, got %q", *first.Title)
+	}
+	if want := []string{"docs", "ingest", "ops"}; !reflect.DeepEqual(first.Tags, want) {
+		t.Errorf("expected tags %v, got %v", want, first.Tags)
+	}
+
+	opts.FrontMatter = false
+	chunks, err = readIngestChunks(opts, f)
+	if err != nil {
+		t.Fatalf("readIngestChunks failed: %v", err)
+	}
+	if chunks[0].Title != "Heading" || chunks[0].Tags != nil {
+		t.Errorf("expected front matter to be ignored, got %+v", chunks[0])
+	}
+}
+
+// TestExecuteIngest_Error tests that the error is returned
+func TestExecuteIngest_Error(t *testing.T) {
+	wantErr := errors.New("embed failed")
+	mockService := &mockSyncService{
+		syncFunc: func(ctx context.Context, req *service.SyncRequest) (*service.SyncResponse, error) {
+			return nil, wantErr
+		},
+	}
+
+	opts := &IngestOptions{ProjectID: "/test/project", GroupID: "docs"}
+	files := []ingestFile{{
+		File:   ingest.File{RelPath: "a.md", Kind: ingest.KindMarkdown},
+		Chunks: []ingest.Chunk{{Title: "A", Text: "text"}},
+	}}
+
+	result, err := executeIngestWithService(context.Background(), mockService, opts, files)
+	if !errors.Is(err, wantErr) {
+		t.Errorf("expected embed error, got %v", err)
+	}
+	if result.Files != 0 {
+		t.Errorf("expected 0 files, got %d", result.Files)
+	}
+}
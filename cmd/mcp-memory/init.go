@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/brbranch/embedding_mcp/internal/bootstrap"
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+const (
+	// starterNoteTag marks the convention note created by init --with-global
+	starterNoteTag = "convention"
+	// starterNoteTitle is the title of the starter convention note
+	starterNoteTitle = "Project conventions"
+	// starterNoteText is the body of the starter convention note
+	starterNoteText = `Project-wide conventions for this repository.
+
+Record coding rules, naming, architecture decisions and review checklists in the "global" group
+so that every session can find them. Replace this note with the actual conventions.`
+)
+
+// InitOptions holds parsed init command options
+type InitOptions struct {
+	Dir              string
+	Root             string
+	GroupID          string
+	Tags             string
+	EmbedderProvider string
+	EmbedderModel    string
+	WithGlobal       bool
+	Force            bool
+	ConfigPath       string
+}
+
+// parseInitFlags parses command line arguments for init command
+func parseInitFlags(args []string) (*InitOptions, error) {
+	fs := flag.NewFlagSet("init", flag.ContinueOnError)
+	fs.SetOutput(io.Discard) // suppress default error output
+
+	opts := &InitOptions{}
+
+	// Long flags
+	fs.StringVar(&opts.Root, "root", "", "Project root (default: git root of the directory)")
+	fs.StringVar(&opts.GroupID, "group", model.GlobalGroupID, "Default group ID")
+	fs.StringVar(&opts.Tags, "tags", "", "Default tags (comma-separated)")
+	fs.StringVar(&opts.EmbedderProvider, "embedder-provider", "", "Embedder provider override: openai|ollama|local")
+	fs.StringVar(&opts.EmbedderModel, "embedder-model", "", "Embedder model override")
+	fs.BoolVar(&opts.WithGlobal, "with-global", false, "Add a starter convention note to the global group")
+	fs.BoolVar(&opts.Force, "force", false, "Overwrite an existing "+config.ProjectConfigFile)
+	fs.StringVar(&opts.ConfigPath, "config", "", "Config file path")
+
+	// Short flags
+	fs.StringVar(&opts.GroupID, "g", model.GlobalGroupID, "Default group ID")
+	fs.StringVar(&opts.ConfigPath, "c", "", "Config file path")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if err := applyFlagEnv(fs); err != nil {
+		return nil, err
+	}
+
+	// Optional directory to start git-root detection from
+	opts.Dir = "."
+	switch fs.NArg() {
+	case 0:
+	case 1:
+		opts.Dir = fs.Arg(0)
+	default:
+		return nil, fmt.Errorf("unexpected argument: %s", fs.Arg(1))
+	}
+
+	// Validation
+	if err := service.ValidateGroupID(opts.GroupID); err != nil {
+		return nil, fmt.Errorf("invalid group: %w", err)
+	}
+	switch opts.EmbedderProvider {
+	case "", model.ProviderOpenAI, model.ProviderOllama, model.ProviderLocal:
+	default:
+		return nil, fmt.Errorf("invalid embedder provider: %s (must be openai, ollama or local)", opts.EmbedderProvider)
+	}
+
+	return opts, nil
+}
+
+// runInitCmd is the entry point for init command
+func runInitCmd(args []string) error {
+	opts, err := parseInitFlags(args)
+	if err != nil {
+		return err
+	}
+
+	root, projectID, err := writeProjectConfig(opts, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	manager, err := loadConfig(opts.ConfigPath, "")
+	if err != nil {
+		return err
+	}
+	registryPath := config.ProjectRegistryPath(manager.GetConfigPath())
+	if err := config.RegisterProject(registryPath, projectID, root); err != nil {
+		return fmt.Errorf("failed to register project: %w", err)
+	}
+	fmt.Fprintf(os.Stdout, "registered %s in %s\n", projectID, registryPath)
+
+	if !opts.WithGlobal {
+		return nil
+	}
+
+	ctx := context.Background()
+	services, cleanup, err := bootstrap.Initialize(ctx, opts.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+	defer cleanup()
+
+	id, created, err := ensureStarterNote(ctx, services.NoteService, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to add starter note: %w", err)
+	}
+	if created {
+		fmt.Fprintf(os.Stdout, "added starter convention note %s to group %s\n", id, model.GlobalGroupID)
+	} else {
+		fmt.Fprintf(os.Stdout, "starter convention note already exists (%s)\n", id)
+	}
+	return nil
+}
+
+// writeProjectConfig resolves the project root and writes .mcp-memory.json there.
+// It returns the root and the canonical projectId.
+func writeProjectConfig(opts *InitOptions, w io.Writer) (string, string, error) {
+	root := opts.Root
+	if root == "" {
+		gitRoot, err := config.FindGitRoot(opts.Dir)
+		if errors.Is(err, config.ErrNoGitRoot) {
+			return "", "", fmt.Errorf("%w: %s (use --root to choose the project root)", err, opts.Dir)
+		}
+		if err != nil {
+			return "", "", err
+		}
+		root = gitRoot
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	projectID, err := config.CanonicalizeProjectID(root)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to canonicalize projectId: %w", err)
+	}
+
+	path := filepath.Join(root, config.ProjectConfigFile)
+	if _, err := os.Stat(path); err == nil && !opts.Force {
+		return "", "", fmt.Errorf("%s already exists (use --force to overwrite)", path)
+	}
+
+	projectConfig := &config.ProjectConfig{
+		ProjectID:    projectID,
+		DefaultGroup: opts.GroupID,
+		Tags:         parseTags(opts.Tags),
+	}
+	if opts.EmbedderProvider != "" || opts.EmbedderModel != "" {
+		projectConfig.Embedder = &config.ProjectEmbedderConfig{
+			Provider: opts.EmbedderProvider,
+			Model:    opts.EmbedderModel,
+		}
+	}
+	if err := config.SaveProjectConfig(path, projectConfig); err != nil {
+		return "", "", err
+	}
+
+	fmt.Fprintf(w, "wrote %s (projectId: %s)\n", path, projectID)
+	return root, projectID, nil
+}
+
+// ensureStarterNote adds the starter convention note to the global group unless one exists.
+// It returns the note ID and whether it was created.
+func ensureStarterNote(ctx context.Context, noteService service.NoteService, projectID string) (string, bool, error) {
+	groupID := model.GlobalGroupID
+	limit := 1
+	existing, err := noteService.ListRecent(ctx, &service.ListRecentRequest{
+		ProjectID: projectID,
+		GroupID:   &groupID,
+		Limit:     &limit,
+		Tags:      []string{starterNoteTag},
+	})
+	if err != nil {
+		return "", false, err
+	}
+	if len(existing.Items) > 0 {
+		return existing.Items[0].ID, false, nil
+	}
+
+	title := starterNoteTitle
+	resp, err := noteService.AddNote(ctx, &service.AddNoteRequest{
+		ProjectID: projectID,
+		GroupID:   groupID,
+		Title:     &title,
+		Text:      starterNoteText,
+		Tags:      []string{starterNoteTag},
+	})
+	if err != nil {
+		return "", false, err
+	}
+	return resp.ID, true, nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+func TestParseInitFlags(t *testing.T) {
+	opts, err := parseInitFlags(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.Dir != "." || opts.GroupID != "global" || opts.WithGlobal || opts.Force {
+		t.Errorf("unexpected defaults: %+v", opts)
+	}
+
+	opts, err = parseInitFlags([]string{"-g", "docs", "--tags", "a,b", "--embedder-provider", "ollama", "--embedder-model", "nomic-embed-text", "--with-global", "./sub"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.Dir != "./sub" || opts.GroupID != "docs" || opts.Tags != "a,b" || opts.EmbedderProvider != "ollama" || !opts.WithGlobal {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+
+	errTests := []struct {
+		name string
+		args []string
+	}{
+		{"invalid group", []string{"-g", "bad group"}},
+		{"invalid provider", []string{"--embedder-provider", "cohere"}},
+		{"too many args", []string{"a", "b"}},
+	}
+	for _, tt := range errTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseInitFlags(tt.args); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestWriteProjectConfig(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(root, "pkg")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := &InitOptions{Dir: sub, GroupID: "docs", Tags: "backend", EmbedderModel: "text-embedding-3-large"}
+	var buf bytes.Buffer
+	gotRoot, projectID, err := writeProjectConfig(opts, &buf)
+	if err != nil {
+		t.Fatalf("writeProjectConfig failed: %v", err)
+	}
+	wantID, _ := config.CanonicalizeProjectID(root)
+	if gotRoot != root || projectID != wantID {
+		t.Errorf("root=%q projectID=%q, want %q %q", gotRoot, projectID, root, wantID)
+	}
+
+	cfg, err := config.LoadProjectConfig(filepath.Join(root, config.ProjectConfigFile))
+	if err != nil {
+		t.Fatalf("LoadProjectConfig failed: %v", err)
+	}
+	if cfg.ProjectID != wantID || cfg.DefaultGroup != "docs" || len(cfg.Tags) != 1 || cfg.Embedder == nil || cfg.Embedder.Model != "text-embedding-3-large" {
+		t.Errorf("unexpected project config: %+v", cfg)
+	}
+
+	// existing file requires --force
+	if _, _, err := writeProjectConfig(opts, &buf); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("expected error mentioning --force, got %v", err)
+	}
+	opts.Force = true
+	if _, _, err := writeProjectConfig(opts, &buf); err != nil {
+		t.Errorf("unexpected error with --force: %v", err)
+	}
+}
+
+func TestWriteProjectConfig_NoGitRoot(t *testing.T) {
+	dir := t.TempDir()
+	var buf bytes.Buffer
+
+	if _, _, err := writeProjectConfig(&InitOptions{Dir: dir, GroupID: "global"}, &buf); err == nil || !strings.Contains(err.Error(), "--root") {
+		t.Errorf("expected error mentioning --root, got %v", err)
+	}
+
+	root, _, err := writeProjectConfig(&InitOptions{Dir: ".", Root: dir, GroupID: "global"}, &buf)
+	if err != nil {
+		t.Fatalf("unexpected error with --root: %v", err)
+	}
+	if root != dir {
+		t.Errorf("expected root %q, got %q", dir, root)
+	}
+}
+
+func TestEnsureStarterNote(t *testing.T) {
+	var added []*service.AddNoteRequest
+	existing := []service.ListRecentItem{}
+	mock := &mockNoteService{
+		listRecentFunc: func(ctx context.Context, req *service.ListRecentRequest) (*service.ListRecentResponse, error) {
+			if *req.GroupID != "global" || len(req.Tags) != 1 || req.Tags[0] != starterNoteTag {
+				t.Errorf("unexpected list request: %+v", req)
+			}
+			return &service.ListRecentResponse{Items: existing}, nil
+		},
+		addNoteFunc: func(ctx context.Context, req *service.AddNoteRequest) (*service.AddNoteResponse, error) {
+			added = append(added, req)
+			return &service.AddNoteResponse{ID: "new-id"}, nil
+		},
+	}
+
+	id, created, err := ensureStarterNote(context.Background(), mock, "/proj")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !created || id != "new-id" || len(added) != 1 || added[0].GroupID != "global" || *added[0].Title != starterNoteTitle {
+		t.Errorf("expected starter note to be added, got id=%q created=%v added=%+v", id, created, added)
+	}
+
+	existing = []service.ListRecentItem{{ID: "old-id"}}
+	id, created, err = ensureStarterNote(context.Background(), mock, "/proj")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if created || id != "old-id" || len(added) != 1 {
+		t.Errorf("expected existing note to be kept, got id=%q created=%v", id, created)
+	}
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/install"
+)
+
+// defaultServerName is the key of the mcp-memory entry in MCP client configs
+const defaultServerName = "mcp-memory"
+
+// InstallOptions holds parsed install/uninstall command options
+type InstallOptions struct {
+	Target     install.Target
+	Name       string
+	Scope      string
+	File       string
+	Binary     string
+	ConfigPath string
+	DataDir    string
+	Env        envFlag
+	Force      bool
+	DryRun     bool
+}
+
+// envFlag collects repeated --env KEY=VALUE flags
+type envFlag map[string]string
+
+func (e *envFlag) String() string {
+	if e == nil || *e == nil {
+		return ""
+	}
+	keys := make([]string, 0, len(*e))
+	for k := range *e {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+(*e)[k])
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (e *envFlag) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("invalid env %q: must be KEY=VALUE", value)
+	}
+	if *e == nil {
+		*e = envFlag{}
+	}
+	(*e)[key] = val
+	return nil
+}
+
+// parseInstallFlags parses command line arguments for install (or uninstall when uninstall is true)
+func parseInstallFlags(args []string, uninstall bool) (*InstallOptions, error) {
+	name := "install"
+	if uninstall {
+		name = "uninstall"
+	}
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard) // suppress default error output
+
+	opts := &InstallOptions{}
+
+	// Long flags
+	fs.StringVar(&opts.Name, "name", defaultServerName, "Server name in the client config")
+	fs.StringVar(&opts.Scope, "scope", string(install.ScopeUser), "Scope for claude-code/cursor: user|project")
+	fs.StringVar(&opts.File, "file", "", "Client config file (overrides the default location)")
+	if !uninstall {
+		fs.StringVar(&opts.Binary, "binary", "", "mcp-memory binary path (default: this executable)")
+		fs.StringVar(&opts.ConfigPath, "config", "", "Config file passed to serve")
+		fs.StringVar(&opts.DataDir, "data-dir", "", "Data directory passed to serve")
+		fs.Var(&opts.Env, "env", "Environment variable for the server (KEY=VALUE, repeatable)")
+		fs.BoolVar(&opts.Force, "force", false, "Replace an existing entry with the same name")
+		fs.BoolVar(&opts.DryRun, "dry-run", false, "Print the entry without writing")
+
+		// Short flags
+		fs.StringVar(&opts.ConfigPath, "c", "", "Config file passed to serve")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	// Target, optionally followed by more flags (install claude-code --scope project)
+	target := fs.Arg(0)
+	if fs.NArg() > 1 {
+		if err := fs.Parse(fs.Args()[1:]); err != nil {
+			return nil, err
+		}
+		if fs.NArg() > 0 {
+			return nil, fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+		}
+	}
+	if err := applyFlagEnv(fs); err != nil {
+		return nil, err
+	}
+
+	// Validation
+	if target == "" {
+		return nil, fmt.Errorf("target is required: claude-desktop, claude-code, cursor or mcp-json")
+	}
+	t, err := install.ParseTarget(target)
+	if err != nil {
+		return nil, err
+	}
+	opts.Target = t
+	if t == install.TargetMCPJSON && opts.File == "" {
+		return nil, fmt.Errorf("--file is required for mcp-json")
+	}
+	switch install.Scope(opts.Scope) {
+	case install.ScopeUser, install.ScopeProject:
+	default:
+		return nil, fmt.Errorf("invalid scope: %s (must be user or project)", opts.Scope)
+	}
+	if opts.Name == "" {
+		return nil, fmt.Errorf("--name must not be empty")
+	}
+
+	return opts, nil
+}
+
+// runInstallCmd is the entry point for install command
+func runInstallCmd(args []string) error {
+	opts, err := parseInstallFlags(args, false)
+	if err != nil {
+		return err
+	}
+
+	path, err := clientConfigPath(opts)
+	if err != nil {
+		return err
+	}
+	entry, err := buildInstallEntry(opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.DryRun {
+		data, err := json.MarshalIndent(map[string]any{opts.Name: entry}, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stdout, "# %s\n%s\n", path, data)
+		return nil
+	}
+
+	result, err := install.Install(path, opts.Name, entry, opts.Force)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "%s %q in %s\n", result, opts.Name, path)
+	if result != install.ResultUnchanged {
+		fmt.Fprintf(os.Stdout, "restart %s to load the server\n", opts.Target)
+	}
+	return nil
+}
+
+// runUninstallCmd is the entry point for uninstall command
+func runUninstallCmd(args []string) error {
+	opts, err := parseInstallFlags(args, true)
+	if err != nil {
+		return err
+	}
+
+	path, err := clientConfigPath(opts)
+	if err != nil {
+		return err
+	}
+	removed, err := install.Uninstall(path, opts.Name)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		fmt.Fprintf(os.Stdout, "%q is not registered in %s\n", opts.Name, path)
+		return nil
+	}
+	fmt.Fprintf(os.Stdout, "removed %q from %s\n", opts.Name, path)
+	return nil
+}
+
+// clientConfigPath returns --file or the default config location of the target
+func clientConfigPath(opts *InstallOptions) (string, error) {
+	if opts.File != "" {
+		return filepath.Abs(opts.File)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current directory: %w", err)
+	}
+	return install.ConfigPath(opts.Target, install.Scope(opts.Scope), cwd)
+}
+
+// buildInstallEntry builds the server entry with absolute paths, since MCP clients
+// start the server from an unrelated working directory
+func buildInstallEntry(opts *InstallOptions) (*install.Entry, error) {
+	binary := opts.Binary
+	if binary == "" {
+		exe, err := os.Executable()
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate mcp-memory binary (use --binary): %w", err)
+		}
+		if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+			exe = resolved
+		}
+		binary = exe
+	}
+	binary, err := filepath.Abs(binary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	args := []string{"serve"}
+	if opts.ConfigPath != "" {
+		p, err := filepath.Abs(opts.ConfigPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get absolute path: %w", err)
+		}
+		args = append(args, "-c", p)
+	}
+	if opts.DataDir != "" {
+		p, err := filepath.Abs(opts.DataDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get absolute path: %w", err)
+		}
+		args = append(args, "--data-dir", p)
+	}
+	// --profile / MCP_MEMORY_PROFILE given to install carries over to serve
+	if profile := os.Getenv(config.EnvProfile); profile != "" {
+		args = append(args, "--profile", profile)
+	}
+
+	return install.NewEntry(opts.Target, binary, args, opts.Env), nil
+}
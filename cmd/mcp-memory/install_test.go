@@ -0,0 +1,83 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/install"
+)
+
+func TestParseInstallFlags(t *testing.T) {
+	opts, err := parseInstallFlags([]string{"claude-code", "--scope", "project", "--env", "A=1", "--env", "B=x=y", "-c", "cfg.json"}, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.Target != install.TargetClaudeCode || opts.Scope != "project" || opts.Name != defaultServerName || opts.ConfigPath != "cfg.json" {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+	if !reflect.DeepEqual(map[string]string(opts.Env), map[string]string{"A": "1", "B": "x=y"}) {
+		t.Errorf("unexpected env: %v", opts.Env)
+	}
+
+	errTests := []struct {
+		name      string
+		args      []string
+		uninstall bool
+	}{
+		{"missing target", nil, false},
+		{"unknown target", []string{"vscode"}, false},
+		{"mcp-json without file", []string{"mcp-json"}, false},
+		{"invalid scope", []string{"cursor", "--scope", "global"}, false},
+		{"invalid env", []string{"cursor", "--env", "NOVALUE"}, false},
+		{"install-only flag on uninstall", []string{"cursor", "--force"}, true},
+	}
+	for _, tt := range errTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseInstallFlags(tt.args, tt.uninstall); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestBuildInstallEntry(t *testing.T) {
+	t.Setenv("MCP_MEMORY_PROFILE", "")
+	dir := t.TempDir()
+	opts := &InstallOptions{
+		Target:     install.TargetClaudeDesktop,
+		Binary:     filepath.Join(dir, "mcp-memory"),
+		ConfigPath: filepath.Join(dir, "config.json"),
+		DataDir:    filepath.Join(dir, "data"),
+		Env:        envFlag{"OPENAI_API_KEY": "sk-test"},
+	}
+
+	entry, err := buildInstallEntry(opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"serve", "-c", filepath.Join(dir, "config.json"), "--data-dir", filepath.Join(dir, "data")}
+	if entry.Command != opts.Binary || !reflect.DeepEqual(entry.Args, want) || entry.Env["OPENAI_API_KEY"] != "sk-test" || entry.Type != "" {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+
+	// default binary is the running executable
+	entry, err = buildInstallEntry(&InstallOptions{Target: install.TargetClaudeCode})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !filepath.IsAbs(entry.Command) || entry.Type != "stdio" || !reflect.DeepEqual(entry.Args, []string{"serve"}) {
+		t.Errorf("unexpected default entry: %+v", entry)
+	}
+}
+
+func TestBuildInstallEntry_Profile(t *testing.T) {
+	t.Setenv("MCP_MEMORY_PROFILE", "work")
+	entry, err := buildInstallEntry(&InstallOptions{Target: install.TargetClaudeCode, Binary: "/usr/local/bin/mcp-memory"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"serve", "--profile", "work"}; !reflect.DeepEqual(entry.Args, want) {
+		t.Errorf("args = %v, want %v", entry.Args, want)
+	}
+}
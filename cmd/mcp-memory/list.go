@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/service"
+	"github.com/brbranch/embedding_mcp/internal/textutil"
+)
+
+// ListOptions holds parsed list command options
+type ListOptions struct {
+	ProjectID  string
+	GroupID    string
+	Limit      int
+	Tags       string
+	Format     string
+	ConfigPath string
+	// IncludeDescendants also lists the descendant groups of GroupID (linked by parentGroupId)
+	IncludeDescendants bool
+	// Cursor continues from the nextCursor of a previous list
+	Cursor string
+	RemoteOptions
+}
+
+// ListJSONOutput represents the JSON output format of list command
+type ListJSONOutput struct {
+	Items            []ListJSONItem `json:"items"`
+	Total            int            `json:"total"`
+	TotalApproximate bool           `json:"totalApproximate,omitempty"`
+	NextCursor       string         `json:"nextCursor,omitempty"`
+}
+
+// ListJSONItem represents a single note in JSON output of list command
+type ListJSONItem struct {
+	ID        string   `json:"id"`
+	GroupID   string   `json:"groupId"`
+	Title     string   `json:"title,omitempty"`
+	Text      string   `json:"text"`
+	Tags      []string `json:"tags,omitempty"`
+	CreatedAt string   `json:"createdAt"`
+	UpdatedAt string   `json:"updatedAt,omitempty"`
+}
+
+// parseListFlags parses command line arguments for list command
+func parseListFlags(args []string) (*ListOptions, error) {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	fs.SetOutput(io.Discard) // suppress default error output
+
+	opts := &ListOptions{}
+
+	// Long flags
+	fs.StringVar(&opts.ProjectID, "project", "", "Project ID/path (required)")
+	fs.StringVar(&opts.GroupID, "group", "", "Group ID (optional)")
+	fs.IntVar(&opts.Limit, "limit", 10, "Number of notes")
+	fs.StringVar(&opts.Tags, "tags", "", "Tag filter (comma-separated)")
+	fs.StringVar(&opts.Format, "format", "text", "Output format: text|json")
+	fs.StringVar(&opts.ConfigPath, "config", "", "Config file path")
+	fs.BoolVar(&opts.IncludeDescendants, "include-descendants", false, "Also list the descendant groups of --group")
+	fs.StringVar(&opts.Cursor, "cursor", "", "Continue from the next page cursor of a previous list")
+	opts.registerFlags(fs)
+
+	// Short flags
+	fs.StringVar(&opts.ProjectID, "p", "", "Project ID/path (required)")
+	fs.StringVar(&opts.GroupID, "g", "", "Group ID (optional)")
+	fs.IntVar(&opts.Limit, "n", 10, "Number of notes")
+	fs.StringVar(&opts.Format, "f", "text", "Output format: text|json")
+	fs.StringVar(&opts.ConfigPath, "c", "", "Config file path")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if err := applyFlagEnv(fs); err != nil {
+		return nil, err
+	}
+
+	// Validation
+	if opts.ProjectID == "" {
+		return nil, fmt.Errorf("project ID is required (-p or --project)")
+	}
+	if opts.Limit <= 0 {
+		return nil, fmt.Errorf("limit must be greater than 0")
+	}
+	if opts.Format != "text" && opts.Format != "json" {
+		return nil, fmt.Errorf("invalid format: %s (must be text or json)", opts.Format)
+	}
+	if opts.IncludeDescendants && opts.GroupID == "" {
+		return nil, fmt.Errorf("--include-descendants requires --group")
+	}
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	return opts, nil
+}
+
+// runListCmd is the entry point for list command
+func runListCmd(args []string) error {
+	opts, err := parseListFlags(args)
+	if err != nil {
+		return err
+	}
+
+	// Initialize services
+	ctx := context.Background()
+	noteService, cleanup, err := openNoteService(ctx, opts.ConfigPath, opts.RemoteOptions)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	// Canonicalize project ID
+	canonicalProjectID, err := config.CanonicalizeProjectID(opts.ProjectID)
+	if err != nil {
+		return fmt.Errorf("failed to canonicalize projectId: %w", err)
+	}
+
+	resp, err := executeListWithService(ctx, noteService, buildListRequest(opts, canonicalProjectID))
+	if err != nil {
+		return fmt.Errorf("list failed: %w", err)
+	}
+
+	// Output results
+	switch opts.Format {
+	case "json":
+		if err := formatListJSONOutput(os.Stdout, resp); err != nil {
+			return fmt.Errorf("failed to format output: %w", err)
+		}
+	default:
+		formatListTextOutput(os.Stdout, resp)
+	}
+
+	return nil
+}
+
+// buildListRequest converts the options to a service request for the canonical project ID
+func buildListRequest(opts *ListOptions, projectID string) *service.ListRecentRequest {
+	limit := opts.Limit
+	req := &service.ListRecentRequest{
+		ProjectID:          projectID,
+		Limit:              &limit,
+		Tags:               parseTags(opts.Tags),
+		IncludeDescendants: opts.IncludeDescendants,
+		Cursor:             opts.Cursor,
+	}
+	if opts.GroupID != "" {
+		req.GroupID = &opts.GroupID
+	}
+	return req
+}
+
+// executeListWithService lists recent notes using the provided NoteService
+func executeListWithService(ctx context.Context, noteService service.NoteService, req *service.ListRecentRequest) (*service.ListRecentResponse, error) {
+	return noteService.ListRecent(ctx, req)
+}
+
+// formatListTextOutput outputs notes in human-readable text format,
+// followed by the cursor of the next page if there are more notes
+func formatListTextOutput(w io.Writer, resp *service.ListRecentResponse) {
+	items := resp.Items
+	if len(items) == 0 {
+		fmt.Fprintln(w, "No notes found.")
+		return
+	}
+
+	for i, item := range items {
+		title := "(no title)"
+		if item.Title != nil && *item.Title != "" {
+			title = *item.Title
+		}
+
+		fmt.Fprintf(w, "[%d] %s (%s)\n", i+1, title, item.CreatedAt)
+		fmt.Fprintf(w, "    %s\n", textutil.Truncate(item.Text, 60))
+		fmt.Fprintf(w, "    id: %s  group: %s\n", item.ID, item.GroupID)
+		if len(item.Tags) > 0 {
+			fmt.Fprintf(w, "    tags: %s\n", strings.Join(item.Tags, ", "))
+		}
+
+		fmt.Fprintln(w)
+	}
+
+	if resp.NextCursor != "" {
+		total := fmt.Sprint(resp.Total)
+		if resp.TotalApproximate {
+			total = "about " + total
+		}
+		fmt.Fprintf(w, "Showing %d of %s notes. Next page: --cursor %s\n", len(items), total, resp.NextCursor)
+	}
+}
+
+// formatListJSONOutput outputs notes in JSON format
+func formatListJSONOutput(w io.Writer, resp *service.ListRecentResponse) error {
+	output := ListJSONOutput{
+		Items:            make([]ListJSONItem, 0, len(resp.Items)),
+		Total:            resp.Total,
+		TotalApproximate: resp.TotalApproximate,
+		NextCursor:       resp.NextCursor,
+	}
+
+	for _, item := range resp.Items {
+		title := ""
+		if item.Title != nil {
+			title = *item.Title
+		}
+
+		output.Items = append(output.Items, ListJSONItem{
+			ID:        item.ID,
+			GroupID:   item.GroupID,
+			Title:     title,
+			Text:      item.Text,
+			Tags:      item.Tags,
+			CreatedAt: item.CreatedAt,
+			UpdatedAt: item.UpdatedAt,
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(output)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// TestParseListFlags tests flag parsing for list command
+func TestParseListFlags(t *testing.T) {
+	opts, err := parseListFlags([]string{"-p", "/test/project", "-g", "global", "-n", "20", "--tags", "rule", "-f", "json", "--cursor", "abc"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.ProjectID != "/test/project" || opts.GroupID != "global" {
+		t.Errorf("unexpected project/group: %q/%q", opts.ProjectID, opts.GroupID)
+	}
+	if opts.Limit != 20 {
+		t.Errorf("expected limit 20, got %d", opts.Limit)
+	}
+	if opts.Tags != "rule" || opts.Format != "json" {
+		t.Errorf("unexpected tags/format: %q/%q", opts.Tags, opts.Format)
+	}
+	if req := buildListRequest(opts, "/test/project"); req.Cursor != "abc" {
+		t.Errorf("expected the cursor in the request, got %q", req.Cursor)
+	}
+
+	// デフォルト値
+	opts, err = parseListFlags([]string{"--project", "/test/project"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.Limit != 10 || opts.Format != "text" {
+		t.Errorf("expected defaults limit=10 format=text, got %d/%s", opts.Limit, opts.Format)
+	}
+
+	// バリデーション
+	for _, args := range [][]string{
+		{"-g", "global"},
+		{"-p", "/test/project", "-n", "0"},
+		{"-p", "/test/project", "-f", "xml"},
+		{"-p", "/test/project", "--include-descendants"},
+	} {
+		if _, err := parseListFlags(args); err == nil {
+			t.Errorf("expected error for args %v, got nil", args)
+		}
+	}
+}
+
+// TestExecuteList tests the list execution logic
+func TestExecuteList(t *testing.T) {
+	mockService := &mockNoteService{
+		listRecentFunc: func(ctx context.Context, req *service.ListRecentRequest) (*service.ListRecentResponse, error) {
+			if req.ProjectID != "/test/project" {
+				t.Errorf("expected project /test/project, got %q", req.ProjectID)
+			}
+			if req.Limit == nil || *req.Limit != 3 {
+				t.Errorf("expected limit 3, got %v", req.Limit)
+			}
+			if req.GroupID != nil {
+				t.Errorf("expected nil group, got %v", *req.GroupID)
+			}
+			return &service.ListRecentResponse{Items: []service.ListRecentItem{{ID: "n1", Text: "hello"}}}, nil
+		},
+	}
+
+	resp, err := executeListWithService(context.Background(), mockService, buildListRequest(&ListOptions{Limit: 3}, "/test/project"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Items) != 1 || resp.Items[0].ID != "n1" {
+		t.Errorf("unexpected items: %+v", resp.Items)
+	}
+}
+
+// TestFormatListOutput tests text and JSON output of list command
+func TestFormatListOutput(t *testing.T) {
+	title := "Rule"
+	items := []service.ListRecentItem{
+		{ID: "n1", GroupID: "global", Title: &title, Text: "use table driven tests", Tags: []string{"rule"}, CreatedAt: "2024-01-15T10:30:00Z"},
+		{ID: "n2", GroupID: "research", Text: "no title note", CreatedAt: "2024-01-14T10:30:00Z"},
+	}
+
+	resp := &service.ListRecentResponse{Items: items, Total: 5, NextCursor: "abc"}
+
+	var buf bytes.Buffer
+	formatListTextOutput(&buf, resp)
+	out := buf.String()
+	for _, want := range []string{"[1] Rule (2024-01-15T10:30:00Z)", "id: n1  group: global", "tags: rule", "[2] (no title)", "Showing 2 of 5 notes. Next page: --cursor abc"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	formatListTextOutput(&buf, &service.ListRecentResponse{})
+	if !strings.Contains(buf.String(), "No notes found.") {
+		t.Errorf("expected empty message, got %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := formatListJSONOutput(&buf, resp); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var parsed ListJSONOutput
+	if err := json.Unmarshal(buf.Bytes(), &parsed); err != nil {
+		t.Fatalf("failed to parse JSON output: %v", err)
+	}
+	if len(parsed.Items) != 2 || parsed.Items[0].Title != "Rule" || parsed.Items[1].GroupID != "research" || parsed.Total != 5 || parsed.NextCursor != "abc" {
+		t.Errorf("unexpected JSON output: %+v", parsed)
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/logging"
+)
+
+// logFlagNames lists the global logging flags accepted by every command
+var logFlagNames = map[string]bool{
+	"log-level":       true,
+	"log-format":      true,
+	"log-file":        true,
+	"log-max-size":    true,
+	"log-max-backups": true,
+}
+
+// explicitLogLevel is the level given by --log-level or MCP_MEMORY_LOG_LEVEL.
+// When set it takes precedence over logging.level in the config file.
+var explicitLogLevel string
+
+// extractLogFlags removes the global logging flags from args and returns them
+// together with the remaining arguments. The flags may appear anywhere
+// (before or after the subcommand) until a "--" terminator. Flags that are
+// not given fall back to their MCP_MEMORY_LOG_* environment variables.
+func extractLogFlags(args []string) (logging.Options, []string, error) {
+	var opts logging.Options
+	rest := make([]string, 0, len(args))
+	given := make(map[string]bool)
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+
+		name, value, hasValue, ok := splitLogFlag(arg)
+		if !ok {
+			rest = append(rest, arg)
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return opts, nil, fmt.Errorf("flag needs an argument: --%s", name)
+			}
+			i++
+			value = args[i]
+		}
+		if err := setLogOption(&opts, name, value); err != nil {
+			return opts, nil, err
+		}
+		given[name] = true
+	}
+
+	for name := range logFlagNames {
+		if given[name] {
+			continue
+		}
+		if value := os.Getenv(flagEnvName(name)); value != "" {
+			if err := setLogOption(&opts, name, value); err != nil {
+				return opts, nil, fmt.Errorf("%s: %w", flagEnvName(name), err)
+			}
+		}
+	}
+
+	if err := opts.Validate(); err != nil {
+		return opts, nil, err
+	}
+	return opts, rest, nil
+}
+
+// splitLogFlag reports whether arg is a logging flag (-name, --name, --name=value)
+func splitLogFlag(arg string) (name, value string, hasValue, ok bool) {
+	if !strings.HasPrefix(arg, "-") {
+		return "", "", false, false
+	}
+	name = strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+	if i := strings.IndexByte(name, '='); i >= 0 {
+		name, value, hasValue = name[:i], name[i+1:], true
+	}
+	if !logFlagNames[name] {
+		return "", "", false, false
+	}
+	return name, value, hasValue, true
+}
+
+// setLogOption stores a single logging flag value into opts
+func setLogOption(opts *logging.Options, name, value string) error {
+	switch name {
+	case "log-level":
+		opts.Level = value
+	case "log-format":
+		opts.Format = value
+	case "log-file":
+		opts.File = value
+	case "log-max-size", "log-max-backups":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid value %q for --%s: must be a non-negative integer", value, name)
+		}
+		if name == "log-max-size" {
+			opts.MaxSizeMB = n
+		} else {
+			opts.MaxBackups = n
+		}
+	}
+	return nil
+}
+
+// applyConfigLogging fills the logging options not given by flags or environment
+// variables from the logging section of the config selected by -c/--config.
+// A config that fails to load is ignored here; the command reports it itself.
+func applyConfigLogging(opts *logging.Options, args []string) {
+	if opts.Level != "" && opts.Format != "" && opts.File != "" {
+		return
+	}
+	manager, err := loadConfig(peekFlag(args, "config", "c"), peekFlag(args, "data-dir"))
+	if err != nil {
+		return
+	}
+	cfg := manager.GetConfig().Logging
+	if opts.Level == "" {
+		opts.Level = cfg.Level
+	}
+	if opts.Format == "" {
+		opts.Format = cfg.Format
+	}
+	if opts.File == "" {
+		opts.File = cfg.File
+	}
+}
+
+// resolveLogFile resolves a relative --log-file against the data dir: --data-dir if
+// present in args, otherwise paths.dataDir of the config selected by -c/--config
+// (after environment overrides). Absolute paths and "~/" paths are used as-is.
+func resolveLogFile(file string, args []string) (string, error) {
+	if file == "" {
+		return "", nil
+	}
+	expanded, err := config.ExpandTilde(file)
+	if err != nil {
+		return "", err
+	}
+	if filepath.IsAbs(expanded) {
+		return expanded, nil
+	}
+
+	manager, err := loadConfig(peekFlag(args, "config", "c"), peekFlag(args, "data-dir"))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(manager.GetConfig().Paths.DataDir, expanded), nil
+}
+
+// peekFlag returns the value of the first of names (-name value, --name=value)
+// found in args before a "--" terminator, without parsing the command's flags
+func peekFlag(args []string, names ...string) string {
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--" {
+			break
+		}
+		if !strings.HasPrefix(args[i], "-") {
+			continue
+		}
+		name := strings.TrimPrefix(strings.TrimPrefix(args[i], "-"), "-")
+		value, hasValue := "", false
+		if j := strings.IndexByte(name, '='); j >= 0 {
+			name, value, hasValue = name[:j], name[j+1:], true
+		}
+		for _, n := range names {
+			if name != n {
+				continue
+			}
+			if hasValue {
+				return value
+			}
+			if i+1 < len(args) {
+				return args[i+1]
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/logging"
+)
+
+func TestExtractLogFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantOpts logging.Options
+		wantRest []string
+	}{
+		{
+			name:     "no log flags",
+			args:     []string{"search", "-p", "/proj", "query"},
+			wantRest: []string{"search", "-p", "/proj", "query"},
+		},
+		{
+			name:     "before subcommand",
+			args:     []string{"--log-level", "debug", "--log-format=json", "serve", "-t", "http"},
+			wantOpts: logging.Options{Level: "debug", Format: "json"},
+			wantRest: []string{"serve", "-t", "http"},
+		},
+		{
+			name:     "after subcommand",
+			args:     []string{"list", "-p", "/proj", "-log-file", "/tmp/mcp.log", "--log-max-size=5", "--log-max-backups", "2"},
+			wantOpts: logging.Options{File: "/tmp/mcp.log", MaxSizeMB: 5, MaxBackups: 2},
+			wantRest: []string{"list", "-p", "/proj"},
+		},
+		{
+			name:     "stops at terminator",
+			args:     []string{"search", "-p", "/proj", "--", "--log-level"},
+			wantRest: []string{"search", "-p", "/proj", "--", "--log-level"},
+		},
+		{
+			name:     "only log flags",
+			args:     []string{"--log-level=warn"},
+			wantOpts: logging.Options{Level: "warn"},
+			wantRest: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, rest, err := extractLogFlags(tt.args)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if opts != tt.wantOpts {
+				t.Errorf("opts = %+v, want %+v", opts, tt.wantOpts)
+			}
+			if !reflect.DeepEqual(rest, tt.wantRest) {
+				t.Errorf("rest = %v, want %v", rest, tt.wantRest)
+			}
+		})
+	}
+}
+
+func TestExtractLogFlags_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"missing value", []string{"serve", "--log-level"}},
+		{"invalid level", []string{"--log-level", "trace"}},
+		{"invalid format", []string{"--log-format=xml"}},
+		{"invalid max size", []string{"--log-max-size", "big"}},
+		{"negative backups", []string{"--log-max-backups=-1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := extractLogFlags(tt.args); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestPeekFlag(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"separate value", []string{"serve", "--data-dir", "/data"}, "/data"},
+		{"equals value", []string{"serve", "-data-dir=/data"}, "/data"},
+		{"shorthand", []string{"list", "-c", "/cfg.json"}, "/cfg.json"},
+		{"missing", []string{"serve", "-t", "http"}, ""},
+		{"after terminator", []string{"search", "--", "--data-dir", "/data"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := peekFlag(tt.args, "data-dir", "c"); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestResolveLogFile(t *testing.T) {
+	dataDir := t.TempDir()
+	t.Setenv("MCP_MEMORY_CONFIG", filepath.Join(t.TempDir(), "missing.json"))
+	t.Setenv("MCP_MEMORY_DATA_DIR", dataDir)
+
+	abs := filepath.Join(t.TempDir(), "mcp.log")
+	if got, err := resolveLogFile(abs, nil); err != nil || got != abs {
+		t.Errorf("absolute path: got %q (err=%v)", got, err)
+	}
+	if got, err := resolveLogFile("logs/mcp.log", []string{"list"}); err != nil || got != filepath.Join(dataDir, "logs", "mcp.log") {
+		t.Errorf("relative path: got %q (err=%v)", got, err)
+	}
+
+	flagDir := t.TempDir()
+	if got, err := resolveLogFile("mcp.log", []string{"serve", "--data-dir", flagDir}); err != nil || got != filepath.Join(flagDir, "mcp.log") {
+		t.Errorf("--data-dir: got %q (err=%v)", got, err)
+	}
+	if got, _ := resolveLogFile("", nil); got != "" {
+		t.Errorf("empty path: got %q", got)
+	}
+}
+
+func TestApplyConfigLogging(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"embedder":{"provider":"ollama","model":"nomic-embed-text"},"logging":{"level":"debug","format":"json","file":"logs/mcp.log"}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// フラグ・環境変数の指定が優先される
+	opts := logging.Options{Level: "warn"}
+	applyConfigLogging(&opts, []string{"serve", "-c", configPath})
+	want := logging.Options{Level: "warn", Format: "json", File: "logs/mcp.log"}
+	if !reflect.DeepEqual(opts, want) {
+		t.Errorf("expected %+v, got %+v", want, opts)
+	}
+
+	// 読み込めない設定は無視する
+	opts = logging.Options{}
+	if err := os.WriteFile(configPath, []byte(`{"logging":`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	applyConfigLogging(&opts, []string{"serve", "-c", configPath})
+	if opts != (logging.Options{}) {
+		t.Errorf("expected options to be unchanged, got %+v", opts)
+	}
+}
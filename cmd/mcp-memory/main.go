@@ -4,60 +4,185 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/signal"
+	"path/filepath"
+	rtdebug "runtime/debug"
+	"strings"
 	"syscall"
+	"time"
 
+	"github.com/brbranch/embedding_mcp/internal/audit"
 	"github.com/brbranch/embedding_mcp/internal/bootstrap"
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/debug"
 	"github.com/brbranch/embedding_mcp/internal/jsonrpc"
+	"github.com/brbranch/embedding_mcp/internal/logging"
 	"github.com/brbranch/embedding_mcp/internal/transport/http"
+	"github.com/brbranch/embedding_mcp/internal/transport/pipe"
 	"github.com/brbranch/embedding_mcp/internal/transport/stdio"
+	"github.com/brbranch/embedding_mcp/internal/webhook"
 )
 
 // ビルド時変数（-ldflags で変更可能）
 var (
 	defaultTransport = "stdio"
 	version          = "dev"
+	commit           = "" // 未設定ならGoのビルド情報（vcs.revision）を使う
 )
 
+// webhookDrainTimeout は停止時に送信待ちのWebhookを送り切るまで待つ上限
+const webhookDrainTimeout = 10 * time.Second
+
+// defaultShutdownTimeout は停止時に処理中のHTTPリクエストの完了を待つ上限のデフォルト
+const defaultShutdownTimeout = 30 * time.Second
+
 // Options はCLI引数オプション
 type Options struct {
 	Transport  string
 	Host       string
 	Port       int
 	ConfigPath string
+	DataDir    string
+	Framing    string
+	PipeName   string
+	PipeSDDL   string
+	Debug      bool
+	DebugAddr  string
+	NoLock     bool
+	Reload     time.Duration // config file polling interval (0 disables; SIGHUP still reloads)
+	Transports []string      // Transportをカンマ区切りで分割したもの（重複除去済み）
+	// SelfTest は起動時の自己診断（"" で無効、selfTestWarn / selfTestFail）
+	SelfTest string
+	// ShutdownDelay・ShutdownTimeout は停止要求を受けたHTTP transportの終了の仕方（http.Config）
+	ShutdownDelay   time.Duration
+	ShutdownTimeout time.Duration
 }
 
 func main() {
+	// ログ系フラグはサブコマンドの前後どちらでも受け付けるため先に取り除く
+	logOpts, args, err := extractLogFlags(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	// --profileも同様にサブコマンドの前後どちらでも受け付ける（設定の読み込みより先に反映）
+	profile, args, err := extractProfileFlag(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := selectProfile(profile); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	// フラグ・環境変数で指定のないログ設定は設定ファイルのloggingを使う
+	// （logging.levelはホットリロードで変更できるよう明示の指定とは区別する）
+	explicitLogLevel = logOpts.Level
+	applyConfigLogging(&logOpts, args)
+	// コンテナモードのserveはログをJSONでstdoutに出す（stdio transportを使う場合を除く）
+	applyContainerLogging(&logOpts, args)
+	// 相対パスの--log-fileはデータディレクトリ基準で解決する
+	if logOpts.File, err = resolveLogFile(logOpts.File, args); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	closeLog, err := logging.Setup(logOpts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	code := dispatch(args)
+	closeLog()
+	os.Exit(code)
+}
+
+// dispatch はサブコマンドを実行し終了コードを返す
+func dispatch(args []string) int {
 	var err error
 
 	// 引数なしの場合はserveをデフォルト実行
-	if len(os.Args) < 2 {
+	if len(args) < 1 {
 		err = run([]string{})
 	} else {
-		switch os.Args[1] {
+		switch args[0] {
 		case "serve":
 			// 既存の run() フローを使用
-			err = run(os.Args[1:])
+			err = run(args)
 		case "search":
-			err = runSearchCmd(os.Args[2:])
+			err = runSearchCmd(args[1:])
+		case "add":
+			err = runAddCmd(args[1:])
+		case "list":
+			err = runListCmd(args[1:])
+		case "ingest":
+			err = runIngestCmd(args[1:])
+		case "watch":
+			err = runWatchCmd(args[1:])
+		case "vault":
+			err = runVaultCmd(args[1:])
+		case "browse":
+			err = runBrowseCmd(args[1:])
+		case "export":
+			err = runExportCmd(args[1:])
+		case "import":
+			err = runImportCmd(args[1:])
+		case "sync":
+			err = runSyncCmd(args[1:])
+		case "globals":
+			err = runGlobalsCmd(args[1:])
+		case "migrate":
+			err = runMigrateCmd(args[1:])
+		case "merge-projects":
+			err = runMergeProjectsCmd(args[1:])
+		case "repair-timestamps":
+			err = runRepairTimestampsCmd(args[1:])
+		case "archive":
+			err = runArchiveCmd(args[1:])
+		case "doctor":
+			err = runDoctorCmd(args[1:])
+		case "bench":
+			err = runBenchCmd(args[1:])
+		case "init":
+			err = runInitCmd(args[1:])
+		case "backup":
+			err = runBackupCmd(args[1:])
+		case "restore":
+			err = runRestoreCmd(args[1:])
+		case "install":
+			err = runInstallCmd(args[1:])
+		case "uninstall":
+			err = runUninstallCmd(args[1:])
+		case "hook":
+			err = runHookCmd(args[1:])
+		case "capture-commit":
+			err = runCaptureCommitCmd(args[1:])
+		case "status":
+			err = runStatusCmd(args[1:])
+		case "stop":
+			err = runStopCmd(args[1:])
+		case "service":
+			err = runServiceCmd(args[1:])
 		case "version", "-v", "--version":
 			printVersion()
-			return
+			return 0
 		case "help", "-h", "--help":
 			printUsage()
-			return
+			return 0
 		default:
-			fmt.Fprintf(os.Stderr, "unknown command: %s\n\n", os.Args[1])
+			fmt.Fprintf(os.Stderr, "unknown command: %s\n\n", args[0])
 			printUsage()
-			os.Exit(1)
+			return 1
 		}
 	}
 
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
-		os.Exit(1)
+		return 1
 	}
+	return 0
 }
 
 // printUsage prints the usage information
@@ -68,39 +193,419 @@ Usage:
   mcp-memory <command> [options]
 
 Commands:
-  serve     Start the MCP server (stdio or HTTP)
+  serve     Start the MCP server (stdio, HTTP or named pipe)
   search    Search notes (oneshot command)
+  add       Add a note (oneshot command)
+  list      List recent notes (oneshot command)
+  ingest    Chunk and add files (markdown, text, code) as notes
+  watch     Keep ingested notes in sync with files (polling)
+  vault     Sync notes both ways with a markdown vault (e.g. Obsidian)
+  browse    Browse projects, groups and notes in an interactive terminal UI
+  export    Export a project to JSONL (notes, globals, groups), or as a static site (--format site)
+  import    Import a JSONL export, or a Notion/Logseq/Roam export or bookmarks (--format)
+  sync      Exchange notes, groups and globals of projects with another mcp-memory server
+  globals   Copy global config (global.* keys) between projects (globals copy)
+  migrate   Re-embed notes of a previous embedder's namespace into the current one
+  merge-projects
+            Merge projects split by projectId canonicalization (after changing projectId rules)
+  repair-timestamps
+            Rewrite note createdAt values stored in other formats as UTC RFC3339
+  archive   Move notes not accessed recently to the compressed archive, or list/restore archived notes
+  doctor    Diagnose config, store, embedder and dimension settings
+  bench     Measure add/search/list latency of a store at growing note counts
+  init      Set up a project (.mcp-memory.json at the git root)
+  backup    Write a full snapshot (all projects + config) to a tar/zstd archive
+  restore   Restore a backup archive into the configured store
+  install   Register mcp-memory in an MCP client (claude-desktop, claude-code, cursor, mcp-json)
+  uninstall Remove mcp-memory from an MCP client config
+  hook      Install or remove the git post-commit hook recording commits (hook install|uninstall)
+  capture-commit
+            Add a commit's message and changed files as a note
+  status    Show the server running on the configured data dir
+  stop      Stop the server running on the configured data dir
+  service   Run the HTTP server at login or boot via systemd, launchd or Windows services
+            (service install|uninstall|start|stop)
   version   Print version information
   help      Print this help message
 
+Global Options (accepted before or after any command):
+  --profile string         Config profile to use (env: MCP_MEMORY_PROFILE)
+  --log-level string       Log level: debug, info, warn, error (default: info)
+  --log-format string      Log format: text, json (default: text)
+  --log-file string        Write logs to a file instead of stderr (relative to the data dir; logs go to
+                           stdout only in container mode)
+  --log-max-size int       Rotate the log file when it exceeds this size in MB (default: 10)
+  --log-max-backups int    Number of rotated log files to keep (default: 3)
+
+Remote mode:
+  search, add and list forward to the HTTP server running on the same data dir
+  (found via its pidfile) instead of opening the store themselves; use --local to opt out.
+
+Environment:
+  Every option can also be set as MCP_MEMORY_<OPTION> (e.g. MCP_MEMORY_TRANSPORT,
+  MCP_MEMORY_LOG_LEVEL). Config values can be overridden with MCP_MEMORY_EMBEDDER_*,
+  MCP_MEMORY_STORE_*, MCP_MEMORY_DATA_DIR, MCP_MEMORY_CORS_ORIGINS and MCP_MEMORY_CONFIG;
+  MCP_MEMORY_CONFIG_JSON holds a whole config as JSON and replaces the config file.
+  Precedence: flag > environment > config file > default.
+  MCP_MEMORY_CONTAINER=true (container mode) makes serve default to "-t http --host 0.0.0.0"
+  and log JSON to stdout unless a log file is set or the stdio transport is used.
+
 Serve Options:
-  -t, --transport string   Transport type: stdio, http (default: stdio)
+  -t, --transport string   Transport type: stdio, http, pipe, or several as "stdio,http" (default: stdio)
   --host string            HTTP host (default: 127.0.0.1)
   -p, --port int           HTTP port (default: 8765)
   -c, --config string      Config file path
+  --data-dir string        Data directory for the DB, pidfile, backups and debug dumps
+                           (default: paths.dataDir, else $XDG_DATA_HOME/mcp-memory,
+                           ~/Library/Application Support/mcp-memory or %APPDATA%\mcp-memory)
+  --pipe-name string       Named pipe name on Windows (default: \\.\pipe\mcp-memory)
+  --pipe-sddl string       Named pipe ACL in SDDL format (default: OS default)
+  --debug                  Enable pprof endpoints and SIGUSR1 goroutine/heap dumps
+  --debug-addr string      pprof listen address (default: 127.0.0.1:6060)
+  --framing string         stdio/pipe framing: auto, newline, content-length (default: auto)
+  --no-lock                Skip the single-instance lock (allow several servers on one data dir / SQLite DB)
+  --reload-interval dur    Config file polling interval for hot reload; 0 disables polling,
+                           SIGHUP still reloads (default: 2s)
+  --self-test string       On startup, embed a fixed sentence and add/search/delete a temporary note;
+                           on failure "warn" logs a warning and "fail" exits (default: off)
+  --shutdown-delay dur     On SIGTERM, keep serving HTTP with /readyz returning 503 for this long
+                           before closing the listener (default: 0)
+  --shutdown-timeout dur   Time to wait for in-flight HTTP requests on shutdown; 0 waits indefinitely
+                           (default: 30s)
 
 Search Options:
   -p, --project string     Project ID/path (required)
   -g, --group string       Group ID (optional, search all groups if omitted)
   -k, --top-k int          Number of results (default: 5)
   --tags string            Tag filter (comma-separated)
-  -f, --format string      Output format: text, json (default: text)
+  -f, --format string      Output format: text, json, markdown, csv (default: text)
   -c, --config string      Config file path
   --stdin                  Read query from stdin
+  --since string           Only notes created at/after: RFC3339, YYYY-MM-DD or duration ago (24h, 7d)
+  --until string           Only notes created before (same formats as --since)
+  --min-score float        Drop results scoring below this value (0-1)
+  --language string        Only notes detected as this language when added (ja, en, zh, ko, ru)
+  --include-descendants    Also search the descendant groups of --group (parentGroupId)
+  --group-by string        Bucket results by groupId (group): each group gets up to
+                           --group-top-k results, -k caps the total
+  --group-top-k int        Results per group with --group-by (default: 2)
+  --include-archived       Also search archived notes (marked [archived] in text output)
+  --also-projects string   Also search these projects (comma-separated IDs/paths); results are
+                           merged by score (cannot be used with --group-by)
+  --remote string          Forward to a running server (URL or host:port; default: auto-detect)
+  --local                  Do not forward to a running server
+
+Add Options:
+  -p, --project string     Project ID/path (required)
+  -g, --group string       Group ID (required unless .mcp-memory.json sets defaultGroup)
+  --title string           Note title
+  --tags string            Tags (comma-separated)
+  --source string          Note source
+  -c, --config string      Config file path
+  --stdin                  Read note text from stdin (multi-line)
+  --attach string          File the note refers to (repeatable; its sha256 is recorded)
+  --importance float       Importance (0-1) boosting the note in search results
+  --front-matter           Take title/tags/source/createdAt from the text's YAML front matter
+                           (flags take precedence; tags are merged)
+  --on-duplicate string    When a similar note exists in the project/group: reject (fail),
+                           merge (add the tags to it) or proceed (add anyway)
+  --duplicate-threshold float
+                           Similarity (0-1) at which a note counts as a duplicate
+                           (default: global.memory.duplicateThreshold or 0.95)
+  --model string           Embed with this additional embedder from the config's embedders
+                           (the note is stored in its namespace)
+  --remote string          Forward to a running server (URL or host:port; default: auto-detect)
+  --local                  Do not forward to a running server
+
+List Options:
+  -p, --project string     Project ID/path (required)
+  -g, --group string       Group ID (optional, list all groups if omitted)
+  -n, --limit int          Number of notes (default: 10)
+  --tags string            Tag filter (comma-separated)
+  --include-descendants    Also list the descendant groups of --group (parentGroupId)
+  --cursor string          Continue from the next page cursor printed by a previous list
+  -f, --format string      Output format: text, json (default: text)
+  -c, --config string      Config file path
+  --remote string          Forward to a running server (URL or host:port; default: auto-detect)
+  --local                  Do not forward to a running server
+
+Ingest Options:
+  -p, --project string     Project ID/path (required)
+  -g, --group string       Group ID (required)
+  --include string         Include globs, ** allowed (comma-separated; default: known text/code extensions)
+  --exclude string         Exclude globs, ** allowed (comma-separated)
+  --tags string            Tags added to every chunk (comma-separated)
+  --base string            Base directory for relative source paths (default: current directory)
+  --chunk-size int         Maximum chunk size in characters (default: 1500)
+  --overlap int            Overlap between chunks in characters (default: 200)
+  --front-matter           Take markdown titles and tags from YAML front matter
+  --dry-run                Show files and chunk counts without adding notes
+  -c, --config string      Config file path
+
+Watch Options:
+  (same as Ingest Options, except --dry-run)
+  --interval duration      Polling interval (default: 2s)
+  --no-prune               Keep notes of files removed before watch started
+
+Vault Options (vault <dir>):
+  -p, --project string     Project ID/path (required)
+  -g, --group string       Only sync notes of this group (default: all groups)
+  --prefer string          Side that wins when a note and its file both changed: vault or memory (default: skip)
+  --no-delete              Restore files deleted from the vault instead of deleting their notes
+  --watch                  Keep syncing until interrupted
+  --interval duration      Polling interval with --watch (default: 2s)
+  -c, --config string      Config file path
+  --remote string          Forward to a running server (URL or host:port; default: auto-detect)
+  --local                  Do not forward to a running server
+  (notes are written as <group>/<title>.md with id/title/group/tags in the front matter)
+
+Browse Options:
+  -p, --project string     Project ID/path (optional, start with project list if omitted)
+  -g, --group string       Group ID (optional, requires --project)
+  -n, --limit int          Number of notes to load (default: 50)
+  -c, --config string      Config file path
+  (keys: ↑/↓ move, enter open, / search, g group, p pin, d delete, r reload, esc back, q quit)
+
+Export Options:
+  -p, --project string     Project ID/path (required)
+  -o, --output string      Output file, - for stdout (default: -)
+  --include-embeddings     Include embedding vectors (reused on import if the embedder matches)
+  --format string          Output format: jsonl, site (default: jsonl)
+  --site-format string     --format site: page format, html or markdown (default: html)
+  -c, --config string      Config file path
+  (with --format site, -o is the output directory: index and groups/<groupKey> pages)
+
+Import Options:
+  -p, --project string     Target project ID/path (required)
+  --skip-existing          Keep records that already exist
+  --overwrite              Overwrite records that already exist
+  -c, --config string      Config file path
+  (without --skip-existing/--overwrite, import fails if any record already exists)
+  --format string          Input format: jsonl, notion, logseq, roam, bookmarks (default: jsonl)
+  -g, --group string       --format other than jsonl: group ID (default: the format name)
+  --tags string            --format other than jsonl: tags added to the notes (comma-separated)
+  --chunk-size int         --format other than jsonl: maximum chunk size in characters (default: 1500)
+  --overlap int            --format other than jsonl: overlap between chunks (default: 200)
+  --dry-run                --format other than jsonl: show what would be imported
+
+Sync Options:
+  -p, --project string     Project to sync, LOCAL or LOCAL=REMOTE projectId (repeatable, comma-separated)
+  --remote string          Server to sync with (URL or host:port, required)
+  --pull-only              Only copy newer records from the remote server
+  --push-only              Only copy newer records to the remote server
+  --dry-run                Show what would be copied
+  -c, --config string      Config file path
+  (the record with the later updatedAt wins; deletions are not synced)
+
+Globals Copy Options (globals copy):
+  --from string            Source project ID/path (required)
+  --to string              Target project ID/path (required)
+  --skip-existing          Keep keys that already exist in the target project
+  --overwrite              Overwrite keys that already exist (the previous values stay in the history)
+  -c, --config string      Config file path
+  (without --skip-existing/--overwrite, the copy fails if any key already exists)
+
+Migrate Options:
+  --from string            Namespace to migrate from, provider:model:dim (default: previousEmbedder)
+  -p, --project string     Project ID/path (default: all projects)
+  -y, --yes                Do not ask for confirmation
+  -c, --config string      Config file path
+  (records already in the current namespace are skipped, so an interrupted migration can be re-run)
+
+Merge-projects Options:
+  --dry-run                Show the merges without changing anything
+  -y, --yes                Do not ask for confirmation
+  -c, --config string      Config file path
+  (groups/globals whose key already exists in the target project are left under the old ID)
+
+Repair-timestamps Options:
+  -p, --project string     Project ID/path (default: all projects)
+  --dry-run                Show the repairs without changing anything
+  -y, --yes                Do not ask for confirmation
+  -c, --config string      Config file path
+  (values without an offset are read as UTC; updatedAt of a repaired note becomes the repair time)
+
+Archive Options:
+  -p, --project string     Project ID/path (default: all projects)
+  --older-than-days int    Archive notes not searched, read or updated in this many days
+                           (default: archive.afterDays of the config)
+  --dry-run                Show the notes to archive without moving them
+  --list                   List archived notes instead
+  --restore                Move the archived notes given as arguments back (archive --restore <id>...)
+  -y, --yes                Do not ask for confirmation
+  -c, --config string      Config file path
+
+Doctor Options:
+  -c, --config string      Config file path
+  -f, --format string      Output format: text, json (default: text)
+  --timeout duration       Timeout for each connectivity check (default: 10s)
+  --deep                   Also validate every note, embedding and group in the store
+  --repair                 Repair what --deep finds (re-embed, quarantine broken notes
+                           to <dataDir>/quarantine, clear dangling parent groups)
+
+Bench Options:
+  --store string           Store to measure: memory, sqlite, qdrant (default: store.type)
+                           (sqlite uses a temporary database; qdrant uses a bench:random:<dim>
+                           collection on the configured server and removes its notes afterwards)
+  --notes string           Note counts to measure at, comma-separated (default: 1000,10000)
+  --dim int                Vector dimension of the random notes and queries (default: 384)
+  --queries int            Searches and lists per note count (default: 100)
+  -f, --format string      Output format: text, json (default: text)
+  --baseline string        JSON output of an earlier run; fail if p50 got slower than --threshold
+  --threshold float        Allowed p50 slowdown against --baseline (default: 0.2 = 20%)
+  -c, --config string      Config file path
+
+Init Options (init [dir], dir defaults to the current directory):
+  --root string            Project root (default: git root of dir)
+  -g, --group string       Default group ID (default: global)
+  --tags string            Default tags (comma-separated)
+  --embedder-provider      Embedder provider override: openai, ollama, local
+  --embedder-model         Embedder model override
+  --with-global            Add a starter convention note to the global group
+  --force                  Overwrite an existing .mcp-memory.json
+  -c, --config string      Config file path
+
+Backup Options:
+  -o, --output string      Output directory, .tar.zst file, or s3://, gs://, az:// URL (default: <dataDir>/backups)
+  --keep int               Keep only the newest N backups in the output directory (default: 0, keep all)
+  -c, --config string      Config file path
+  (storage credentials come from the standard AWS, Google Cloud and Azure environment variables and files)
+
+Restore Options (restore <archive>, archive: file, storage URL, or storage prefix for the newest backup):
+  --skip-existing          Keep records that already exist
+  --overwrite              Overwrite records that already exist
+  --with-config            Also restore the archived config (local paths and API key are kept)
+  -c, --config string      Config file path
+
+Install Options (install <target>, target: claude-desktop, claude-code, cursor, mcp-json):
+  --name string            Server name in the client config (default: mcp-memory)
+  --scope string           claude-code/cursor: user or project (default: user)
+  --file string            Client config file (required for mcp-json)
+  --binary string          mcp-memory binary path (default: this executable)
+  -c, --config string      Config file passed to serve
+  --data-dir string        Data directory passed to serve
+  --env KEY=VALUE          Environment variable for the server (repeatable)
+  --force                  Replace an existing entry with the same name
+  --dry-run                Print the entry without writing
+  (uninstall accepts --name, --scope and --file)
+
+Hook Options (hook install|uninstall [dir], dir defaults to the current directory):
+  -g, --group string       Group ID for commit notes (default: the project's default group)
+  --binary string          mcp-memory binary the hook runs (default: this executable)
+  -c, --config string      Config file passed to capture-commit
+  --sync                   Wait for capture-commit instead of running it in the background
+  (the hook honors core.hooksPath and is appended to an existing shell hook;
+  uninstall removes only the mcp-memory lines)
+
+Capture-commit Options (capture-commit [rev], rev defaults to HEAD):
+  -p, --project string     Project ID/path (default: git root of the current directory)
+  -g, --group string       Group ID (default: the project's default group)
+  --tags string            Tags added besides "commit" (comma-separated)
+  --max-files int          Changed files listed in the note (default: 50)
+  -c, --config string      Config file path
+  --remote string          Forward to a running server (URL or host:port; default: auto-detect)
+  --local                  Do not forward to a running server
+
+Status Options:
+  -c, --config string      Config file path
+  --data-dir string        Data directory the server was started with
+  -f, --format string      Output format: text, json (default: text)
+
+Stop Options:
+  -c, --config string      Config file path
+  --data-dir string        Data directory the server was started with
+  --timeout duration       Time to wait for the server to exit (default: 10s)
+
+Service Options (service install|uninstall|start|stop):
+  --system                 System-wide service started at boot (default: per-user service started at login)
+  --name string            Service name (default: mcp-memory)
+  --host string            HTTP host passed to serve (install)
+  --port int               HTTP port passed to serve (install)
+  --binary string          mcp-memory binary path (install; default: this executable)
+  -c, --config string      Config file passed to serve (install; default: the default config path)
+  --data-dir string        Data directory passed to serve (install; default: the data dir of the config)
+  --env KEY=VALUE          Environment variable for the server (install; repeatable)
+  --user string            User running a --system service on Linux/macOS (install; default: SUDO_USER)
+  --force                  Replace an existing service with a different definition (install)
+  --dry-run                Print the service definition without registering it (install)
 
 Examples:
   mcp-memory serve
   mcp-memory serve -t http -p 8080
+  mcp-memory serve -t stdio,http
+  mcp-memory --log-level debug --log-file ~/.local-mcp-memory/mcp-memory.log serve
+  mcp-memory --profile offline serve
   mcp-memory search -p /path/to/project "search query"
   mcp-memory search -p ~/project -g global -k 10 "query"
-  echo "query" | mcp-memory search -p /path/to/project --stdin`)
+  echo "query" | mcp-memory search -p /path/to/project --stdin
+  mcp-memory search --remote 127.0.0.1:8765 -p ~/project "query"
+  mcp-memory add -p ~/project -g global --tags rule "note text"
+  mcp-memory list -p ~/project -g global -n 20
+  mcp-memory ingest -p ~/project -g docs './docs/**/*.md' --exclude '**/drafts/**'
+  mcp-memory watch -p ~/project -g docs ./docs
+  mcp-memory vault -p ~/project --watch ~/Obsidian/project-memory
+  mcp-memory browse -p ~/project
+  mcp-memory export -p ~/project -o memory.jsonl --include-embeddings
+  mcp-memory export -p ~/project --format site -o ./memory-site
+  mcp-memory import -p ~/project memory.jsonl --skip-existing
+  mcp-memory import -p ~/project --format notion Export-1234.zip
+  mcp-memory sync --remote https://team-memory:8765 -p ~/src/app=/srv/projects/app
+  mcp-memory globals copy --from ~/project --to ~/new-project --skip-existing
+  mcp-memory doctor
+  mcp-memory bench --store sqlite --notes 1000,10000,100000 -f json > baseline.json
+  mcp-memory bench --store sqlite --baseline baseline.json
+  mcp-memory init --with-global
+  mcp-memory backup --keep 7
+  mcp-memory backup -o s3://team-backups/mcp-memory/ --keep 14
+  mcp-memory restore ~/.local-mcp-memory/backups/mcp-memory-20240102T030405Z.tar.zst --skip-existing
+  mcp-memory restore gs://team-backups/mcp-memory/ --skip-existing
+  mcp-memory install claude-code
+  mcp-memory install claude-desktop --env OPENAI_API_KEY=sk-...
+  mcp-memory hook install -g commits
+  mcp-memory status
+  mcp-memory stop
+  mcp-memory service install --port 8765 --env OPENAI_API_KEY=sk-...
+  sudo mcp-memory service install --system`)
 }
 
 // printVersion prints the version information
 func printVersion() {
+	if c := buildCommit(); c != "" {
+		fmt.Printf("mcp-memory version %s (%s)\n", version, c)
+		return
+	}
 	fmt.Printf("mcp-memory version %s\n", version)
 }
 
+// buildCommit returns the commit the binary was built from: the commit ldflags variable,
+// or the VCS revision recorded by the Go toolchain ("-dirty" if the tree was modified)
+func buildCommit() string {
+	if commit != "" {
+		return commit
+	}
+	info, ok := rtdebug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	var revision string
+	var modified bool
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.modified":
+			modified = s.Value == "true"
+		}
+	}
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+	if revision != "" && modified {
+		revision += "-dirty"
+	}
+	return revision
+}
+
 // run は実際の処理を行う（テスト容易性のため分離）
 func run(args []string) error {
 	opts, err := parseFlags(args)
@@ -108,6 +613,11 @@ func run(args []string) error {
 		return err
 	}
 
+	// Windowsのサービスとして起動された場合は、サービスの停止要求でserveを終了する
+	if handled, err := runAsService(opts); handled {
+		return err
+	}
+
 	ctx, cancel := setupSignalHandler()
 	defer cancel()
 
@@ -119,13 +629,26 @@ func parseFlags(args []string) (*Options, error) {
 	fs := flag.NewFlagSet("mcp-memory", flag.ContinueOnError)
 
 	opts := &Options{}
-	fs.StringVar(&opts.Transport, "transport", defaultTransport, "Transport type: stdio, http")
-	fs.StringVar(&opts.Transport, "t", defaultTransport, "Transport type (shorthand)")
-	fs.StringVar(&opts.Host, "host", "127.0.0.1", "HTTP host")
+	// コンテナモードではHTTP transportを全インターフェースで待ち受ける
+	transport, host := serveDefaults()
+	fs.StringVar(&opts.Transport, "transport", transport, "Transport type: stdio, http, pipe (comma-separated for multiple)")
+	fs.StringVar(&opts.Transport, "t", transport, "Transport type (shorthand)")
+	fs.StringVar(&opts.Host, "host", host, "HTTP host")
 	fs.IntVar(&opts.Port, "port", 8765, "HTTP port")
 	fs.IntVar(&opts.Port, "p", 8765, "HTTP port (shorthand)")
 	fs.StringVar(&opts.ConfigPath, "config", "", "Config file path")
 	fs.StringVar(&opts.ConfigPath, "c", "", "Config file path (shorthand)")
+	fs.StringVar(&opts.DataDir, "data-dir", "", "Data directory (default: paths.dataDir or the platform data dir)")
+	fs.StringVar(&opts.PipeName, "pipe-name", pipe.DefaultName, "Named pipe name (Windows)")
+	fs.StringVar(&opts.PipeSDDL, "pipe-sddl", "", "Named pipe ACL in SDDL format (Windows)")
+	fs.BoolVar(&opts.Debug, "debug", false, "Enable pprof endpoints and SIGUSR1 dumps")
+	fs.StringVar(&opts.DebugAddr, "debug-addr", debug.DefaultAddr, "pprof listen address (with --debug)")
+	fs.StringVar(&opts.Framing, "framing", string(stdio.FramingAuto), "stdio framing: auto, newline, content-length")
+	fs.BoolVar(&opts.NoLock, "no-lock", false, "Allow another server on the same data dir / SQLite database")
+	fs.DurationVar(&opts.Reload, "reload-interval", defaultReloadInterval, "Config file polling interval for hot reload (0 disables polling)")
+	fs.StringVar(&opts.SelfTest, "self-test", "", "Run a self-test on startup: warn or fail")
+	fs.DurationVar(&opts.ShutdownDelay, "shutdown-delay", 0, "Keep serving HTTP with /readyz failing for this long after SIGTERM")
+	fs.DurationVar(&opts.ShutdownTimeout, "shutdown-timeout", defaultShutdownTimeout, "Time to wait for in-flight HTTP requests on shutdown (0 waits indefinitely)")
 
 	// 空配列の場合はserveをデフォルトとして扱う
 	// serveサブコマンド確認（引数なしまたは"serve"で始まる場合のみ許可）
@@ -142,18 +665,53 @@ func parseFlags(args []string) (*Options, error) {
 	if err := fs.Parse(flagArgs); err != nil {
 		return nil, err
 	}
+	if err := applyFlagEnv(fs); err != nil {
+		return nil, err
+	}
 
 	// バリデーション
-	if opts.Transport != "stdio" && opts.Transport != "http" {
-		return nil, fmt.Errorf("invalid transport: %s (must be stdio or http)", opts.Transport)
+	transports, err := parseTransports(opts.Transport)
+	if err != nil {
+		return nil, err
 	}
+	opts.Transports = transports
 	if opts.Port < 1 || opts.Port > 65535 {
 		return nil, fmt.Errorf("invalid port: %d (must be 1-65535)", opts.Port)
 	}
+	if _, err := stdio.ParseFraming(opts.Framing); err != nil {
+		return nil, err
+	}
+	if opts.Reload < 0 {
+		return nil, fmt.Errorf("invalid reload interval: %s (must not be negative)", opts.Reload)
+	}
+	if opts.ShutdownDelay < 0 || opts.ShutdownTimeout < 0 {
+		return nil, fmt.Errorf("invalid shutdown delay or timeout (must not be negative)")
+	}
+	if opts.SelfTest != "" && opts.SelfTest != selfTestWarn && opts.SelfTest != selfTestFail {
+		return nil, fmt.Errorf("invalid self-test mode: %s (must be warn or fail)", opts.SelfTest)
+	}
 
 	return opts, nil
 }
 
+// parseTransports はカンマ区切りのtransport指定を分割して検証する
+func parseTransports(value string) ([]string, error) {
+	var transports []string
+	seen := make(map[string]bool)
+	for _, t := range strings.Split(value, ",") {
+		t = strings.TrimSpace(t)
+		if t != "stdio" && t != "http" && t != "pipe" {
+			return nil, fmt.Errorf("invalid transport: %s (must be stdio, http or pipe)", t)
+		}
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		transports = append(transports, t)
+	}
+	return transports, nil
+}
+
 // setupSignalHandler はSIGINT/SIGTERMを受けてcontextをキャンセルする
 func setupSignalHandler() (context.Context, context.CancelFunc) {
 	ctx, cancel := context.WithCancel(context.Background())
@@ -171,30 +729,211 @@ func setupSignalHandler() (context.Context, context.CancelFunc) {
 
 // runServe はserveコマンドを実行
 func runServe(ctx context.Context, opts *Options) error {
+	// 二重起動防止（storeを開く前にdataDirとSQLite DBのロックを取得）
+	if !opts.NoLock {
+		inst, err := acquireInstanceLock(opts)
+		if err != nil {
+			return err
+		}
+		defer inst.Release()
+	}
+
 	// bootstrap.Initializeを使用して共通初期化ロジックを実行
-	services, cleanup, err := bootstrap.Initialize(ctx, opts.ConfigPath)
+	services, cleanup, err := bootstrap.Initialize(ctx, opts.ConfigPath, bootstrap.WithDataDir(opts.DataDir))
 	if err != nil {
 		return err
 	}
-	defer cleanup()
+	if err := applyConfigLogLevel(services.Config.Logging.Level); err != nil {
+		cleanup()
+		return fmt.Errorf("logging.level: %w", err)
+	}
+
+	// 起動時の自己診断（失敗時は--self-testに従い警告のみ、または起動を中止）
+	if opts.SelfTest != "" {
+		if err := runSelfTest(ctx, services.SelfTestService, opts.SelfTest); err != nil {
+			cleanup()
+			return err
+		}
+	}
+
+	// デバッグ機能（pprof + SIGUSR1ダンプ）
+	if opts.Debug {
+		startDebug(ctx, opts.DebugAddr, filepath.Join(services.Config.Paths.DataDir, "debug"))
+	}
 
 	// JSON-RPC Handler初期化
 	handler := jsonrpc.New(services.NoteService, services.ConfigService, services.GlobalService, services.GroupService)
 
-	// transport起動
-	switch opts.Transport {
-	case "stdio":
-		server := stdio.New(handler)
-		return server.Run(ctx)
-	case "http":
-		// HTTP設定（CORS含む）
-		httpConfig := http.Config{
-			Addr: fmt.Sprintf("%s:%d", opts.Host, opts.Port),
+	// 設定ファイルの変更（SIGHUP・ポーリング）を実行中に反映する
+	reloader, err := newConfigReloader(opts, handler, services, cleanup)
+	if err != nil {
+		cleanup()
+		return err
+	}
+	defer reloader.close()
+
+	// 変更操作の監査ログ（audit.file）
+	if cfg := services.Config.Audit; cfg != nil && cfg.File != "" {
+		path, err := config.ResolveDataPath(services.Config.Paths.DataDir, cfg.File)
+		if err != nil {
+			return fmt.Errorf("audit.file: %w", err)
+		}
+		auditLog, closeAudit, err := audit.Open(path)
+		if err != nil {
+			return err
+		}
+		defer closeAudit()
+		handler.SetAuditLogger(auditLog)
+	}
+
+	// ノート・グループの変更を送るWebhook（webhooks）
+	if hooks := services.Config.Webhooks; len(hooks) > 0 {
+		dispatcher := webhook.New(hooks)
+		defer func() {
+			// 送信待ちのイベントを送り切る（応答しないWebhookで停止が遅れないよう上限を設ける）
+			ctx, cancel := context.WithTimeout(context.Background(), webhookDrainTimeout)
+			defer cancel()
+			if err := dispatcher.Close(ctx); err != nil {
+				slog.Warn("some webhook events were not delivered", "error", err)
+			}
+		}()
+		handler.SetWebhooks(dispatcher)
+	}
+
+	// transport起動（複数指定時は同じhandler/storeを共有して並行に起動）
+	transports := opts.Transports
+	if len(transports) == 0 {
+		transports = []string{opts.Transport}
+	}
+
+	// memory.server_info で返す情報
+	handler.SetServerInfo(jsonrpc.ServerInfo{
+		Version:    version,
+		Commit:     buildCommit(),
+		Transports: transports,
+		Namespace:  reloader.namespace,
+	})
+
+	servers := make([]server, 0, len(transports))
+	for _, t := range transports {
+		switch t {
+		case "stdio":
+			framing, err := stdio.ParseFraming(opts.Framing)
+			if err != nil {
+				return err
+			}
+			// 標準入力の読み込みはキャンセルできないため、停止要求では読み込みを待たずに戻す
+			servers = append(servers, detachedServer{stdio.New(handler, stdio.WithFraming(framing))})
+		case "http":
+			authenticator, err := newAuthenticator(*services.Config)
+			if err != nil {
+				return err
+			}
+			// HTTP設定（CORS・認証含む）
+			httpConfig := http.Config{
+				Addr:            fmt.Sprintf("%s:%d", opts.Host, opts.Port),
+				CORSOrigins:     services.Config.TransportDefaults.CORSOrigins,
+				ShutdownDelay:   opts.ShutdownDelay,
+				ShutdownTimeout: opts.ShutdownTimeout,
+				Auth:            authenticator,
+			}
+			httpServer := http.New(handler, httpConfig)
+			reloader.addHTTPServer(httpServer)
+			servers = append(servers, httpServer)
+		case "pipe":
+			framing, err := stdio.ParseFraming(opts.Framing)
+			if err != nil {
+				return err
+			}
+			servers = append(servers, pipe.New(handler, pipe.Config{
+				Name:    opts.PipeName,
+				SDDL:    opts.PipeSDDL,
+				Framing: framing,
+			}))
+		default:
+			return fmt.Errorf("unknown transport: %s", t)
+		}
+	}
+
+	reloadCtx, stopReload := context.WithCancel(ctx)
+	defer stopReload()
+	go reloader.run(reloadCtx, opts.Reload)
+	// ノート数の閾値（store.noteThresholds）の定期確認
+	go watchNoteGrowth(reloadCtx, reloaderStatsService{reloader}, noteCheckInterval(services.Config))
+	// 最終アクセスの古いノートのアーカイブ（archive）
+	go watchArchive(reloadCtx, reloaderArchiveService{reloader}, archiveCheckInterval(services.Config))
+
+	return runServers(ctx, servers)
+}
+
+// startDebug はpprofサーバーとSIGUSR1ダンプを起動する
+// pprofサーバーの失敗はtransportを止めずログに出力するのみ
+func startDebug(ctx context.Context, addr, dumpDir string) {
+	go func() {
+		if err := debug.NewServer(addr).Run(ctx); err != nil {
+			slog.Error("debug: pprof server error", "error", err)
 		}
-		// 将来的に設定ファイルからCORSOrigins読み込み予定
-		server := http.New(handler, httpConfig)
-		return server.Run(ctx)
-	default:
-		return fmt.Errorf("unknown transport: %s", opts.Transport)
+	}()
+	debug.WatchDumpSignal(ctx, dumpDir)
+	slog.Info("debug: pprof enabled", "url", "http://"+addr+"/debug/pprof/", "dumpDir", dumpDir)
+}
+
+// server はtransportサーバーの共通インターフェース
+type server interface {
+	Run(ctx context.Context) error
+}
+
+// detachedServer はcontextのキャンセルで戻らないサーバー（標準入力を読むstdio）を、
+// キャンセルされた時点で戻るようにする（サーバー自体は読み込みが終わるまで残る）
+type detachedServer struct {
+	server
+}
+
+// Run はサーバーの終了かcontextのキャンセルまで待つ（キャンセルはエラーとしない）
+func (d detachedServer) Run(ctx context.Context) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- d.server.Run(ctx)
+	}()
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+// runServers は複数のサーバーを並行に起動する
+// いずれかが終了（stdioのEOF含む）した時点で残りも停止し、最初のエラーを返す
+func runServers(ctx context.Context, servers []server) error {
+	if len(servers) == 1 {
+		return servers[0].Run(ctx)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errCh := make(chan error, len(servers))
+	for _, s := range servers {
+		go func(s server) {
+			errCh <- s.Run(ctx)
+		}(s)
+	}
+
+	// 最初に終了したサーバーの結果を採用し、残りを停止
+	first := <-errCh
+	if first != nil && first != context.Canceled {
+		// 残りのサーバーの停止を待つ間も原因が分かるよう先に出力する
+		slog.Error("server stopped; shutting down the other transports", "error", first)
+	}
+	cancel()
+	for i := 1; i < len(servers); i++ {
+		<-errCh
+	}
+
+	if first == context.Canceled {
+		// 停止要求による終了はエラーとしない
+		return nil
 	}
+	return first
 }
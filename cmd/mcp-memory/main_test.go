@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"context"
+	"errors"
 	"os"
 	"syscall"
 	"testing"
@@ -99,6 +100,41 @@ func TestParseFlags_ConfigPath(t *testing.T) {
 	}
 }
 
+// TestParseFlags_DataDir はdata-dir指定をテスト
+func TestParseFlags_DataDir(t *testing.T) {
+	opts, err := parseFlags([]string{"serve", "--data-dir", "/path/to/data"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if opts.DataDir != "/path/to/data" {
+		t.Errorf("expected data dir /path/to/data, got %s", opts.DataDir)
+	}
+}
+
+// TestParseFlags_ReloadInterval は--reload-intervalのデフォルト値と検証をテスト
+func TestParseFlags_ReloadInterval(t *testing.T) {
+	opts, err := parseFlags([]string{"serve"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.Reload != defaultReloadInterval {
+		t.Errorf("expected default reload interval %s, got %s", defaultReloadInterval, opts.Reload)
+	}
+
+	opts, err = parseFlags([]string{"serve", "--reload-interval", "0"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.Reload != 0 {
+		t.Errorf("expected reload interval 0, got %s", opts.Reload)
+	}
+
+	if _, err := parseFlags([]string{"serve", "--reload-interval", "-1s"}); err == nil {
+		t.Error("expected error for negative reload interval, got nil")
+	}
+}
+
 // TestParseFlags_InvalidTransport は不正なtransportでエラーを返すことをテスト
 func TestParseFlags_InvalidTransport(t *testing.T) {
 	args := []string{"serve", "--transport", "unknown"}
@@ -107,7 +143,7 @@ func TestParseFlags_InvalidTransport(t *testing.T) {
 		t.Fatal("expected error, got nil")
 	}
 
-	expectedMsg := "invalid transport: unknown (must be stdio or http)"
+	expectedMsg := "invalid transport: unknown (must be stdio, http or pipe)"
 	if err.Error() != expectedMsg {
 		t.Errorf("expected error message '%s', got '%s'", expectedMsg, err.Error())
 	}
@@ -139,6 +175,154 @@ func TestParseFlags_InvalidPort(t *testing.T) {
 	}
 }
 
+// TestParseFlags_Framing は--framingオプションをテスト
+func TestParseFlags_Framing(t *testing.T) {
+	opts, err := parseFlags([]string{"serve", "--framing", "content-length"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.Framing != "content-length" {
+		t.Errorf("expected framing content-length, got %s", opts.Framing)
+	}
+
+	// 不正な値はエラー
+	if _, err := parseFlags([]string{"serve", "--framing", "xml"}); err == nil {
+		t.Error("expected error for invalid framing, got nil")
+	}
+}
+
+// TestParseFlags_MultipleTransports はカンマ区切りのtransport指定をテスト
+func TestParseFlags_MultipleTransports(t *testing.T) {
+	opts, err := parseFlags([]string{"serve", "-t", "stdio, http,stdio"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(opts.Transports) != 2 || opts.Transports[0] != "stdio" || opts.Transports[1] != "http" {
+		t.Errorf("expected transports [stdio http], got %v", opts.Transports)
+	}
+
+	// 1つでも不正な値があればエラー
+	_, err = parseFlags([]string{"serve", "-t", "stdio,grpc"})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	expectedMsg := "invalid transport: grpc (must be stdio, http or pipe)"
+	if err.Error() != expectedMsg {
+		t.Errorf("expected error message '%s', got '%s'", expectedMsg, err.Error())
+	}
+}
+
+// fakeServer はrunServersテスト用のサーバー
+type fakeServer struct {
+	err     error
+	stopped chan struct{}
+}
+
+// Run はerrが設定されていれば即座に返し、なければcontextキャンセルまで待機する
+func (s *fakeServer) Run(ctx context.Context) error {
+	defer close(s.stopped)
+	if s.err != nil {
+		return s.err
+	}
+	<-ctx.Done()
+	return nil
+}
+
+// TestRunServers_StopsAllWhenOneExits はいずれかの終了で全サーバーが停止することをテスト
+func TestRunServers_StopsAllWhenOneExits(t *testing.T) {
+	wantErr := errors.New("listen failed")
+	failing := &fakeServer{err: wantErr, stopped: make(chan struct{})}
+	running := &fakeServer{stopped: make(chan struct{})}
+
+	err := runServers(context.Background(), []server{running, failing})
+	if err != wantErr {
+		t.Errorf("expected %v, got %v", wantErr, err)
+	}
+
+	select {
+	case <-running.stopped:
+	case <-time.After(1 * time.Second):
+		t.Fatal("running server was not stopped")
+	}
+}
+
+// blockingServer はcontextのキャンセルを無視して読み込みを待ち続けるサーバー（stdio相当）
+type blockingServer struct {
+	release chan struct{}
+}
+
+func (s *blockingServer) Run(ctx context.Context) error {
+	<-s.release
+	return nil
+}
+
+// TestRunServers_StdioDoesNotBlockFailure は他のtransportの起動失敗時に、標準入力の読み込みを待たずに戻ることをテスト
+func TestRunServers_StdioDoesNotBlockFailure(t *testing.T) {
+	wantErr := errors.New("listen tcp 127.0.0.1:7777: bind: address already in use")
+	stdin := &blockingServer{release: make(chan struct{})}
+	defer close(stdin.release)
+	failing := &fakeServer{err: wantErr, stopped: make(chan struct{})}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- runServers(context.Background(), []server{detachedServer{stdin}, failing})
+	}()
+
+	select {
+	case err := <-done:
+		if err != wantErr {
+			t.Errorf("expected %v, got %v", wantErr, err)
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatal("runServers waited for the stdio read")
+	}
+}
+
+// TestRunServers_ContextCancel はcontextキャンセルで全サーバーが正常停止することをテスト
+func TestRunServers_ContextCancel(t *testing.T) {
+	a := &fakeServer{stopped: make(chan struct{})}
+	b := &fakeServer{stopped: make(chan struct{})}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- runServers(ctx, []server{a, b})
+	}()
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected nil error, got %v", err)
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatal("timeout waiting for servers to stop")
+	}
+}
+
+// TestParseFlags_Debug は--debug, --debug-addrオプションをテスト
+func TestParseFlags_Debug(t *testing.T) {
+	opts, err := parseFlags([]string{"serve"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.Debug {
+		t.Error("expected debug to be disabled by default")
+	}
+
+	opts, err = parseFlags([]string{"serve", "--debug", "--debug-addr", "127.0.0.1:7070"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !opts.Debug {
+		t.Error("expected debug to be enabled")
+	}
+	if opts.DebugAddr != "127.0.0.1:7070" {
+		t.Errorf("expected debug addr 127.0.0.1:7070, got %s", opts.DebugAddr)
+	}
+}
+
 // TestParseFlags_InvalidSubcommand は不正なサブコマンドでエラーを返すことをテスト
 func TestParseFlags_InvalidSubcommand(t *testing.T) {
 	// "unknown" is an invalid subcommand; empty args should default to serve (no error)
@@ -395,7 +579,7 @@ func TestParseFlags_Validation_TableDriven(t *testing.T) {
 			name:        "invalid transport",
 			args:        []string{"serve", "--transport", "grpc"},
 			expectError: true,
-			errorMsg:    "invalid transport: grpc (must be stdio or http)",
+			errorMsg:    "invalid transport: grpc (must be stdio, http or pipe)",
 		},
 		{
 			name:        "port too low",
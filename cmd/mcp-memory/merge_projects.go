@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/brbranch/embedding_mcp/internal/bootstrap"
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// MergeProjectsOptions holds parsed merge-projects command options
+type MergeProjectsOptions struct {
+	DryRun     bool
+	Yes        bool
+	ConfigPath string
+}
+
+// parseMergeProjectsFlags parses command line arguments for merge-projects command
+func parseMergeProjectsFlags(args []string) (*MergeProjectsOptions, error) {
+	fs := flag.NewFlagSet("merge-projects", flag.ContinueOnError)
+	fs.SetOutput(io.Discard) // suppress default error output
+
+	opts := &MergeProjectsOptions{}
+
+	// Long flags
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "Show the merges without changing anything")
+	fs.BoolVar(&opts.Yes, "yes", false, "Do not ask for confirmation")
+	fs.StringVar(&opts.ConfigPath, "config", "", "Config file path")
+
+	// Short flags
+	fs.BoolVar(&opts.Yes, "y", false, "Do not ask for confirmation")
+	fs.StringVar(&opts.ConfigPath, "c", "", "Config file path")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if err := applyFlagEnv(fs); err != nil {
+		return nil, err
+	}
+
+	// Validation
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+
+	return opts, nil
+}
+
+// runMergeProjectsCmd is the entry point for merge-projects command
+func runMergeProjectsCmd(args []string) error {
+	opts, err := parseMergeProjectsFlags(args)
+	if err != nil {
+		return err
+	}
+
+	// Initialize services (this also applies the projectId rules from the config)
+	ctx := context.Background()
+	services, cleanup, err := bootstrap.Initialize(ctx, opts.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+	defer cleanup()
+
+	if _, err := executeMergeProjectsWithService(ctx, services.MergeService, opts, os.Stdin, os.Stderr); err != nil {
+		return fmt.Errorf("merge-projects failed: %w", err)
+	}
+	return nil
+}
+
+// executeMergeProjectsWithService lists the projects whose stored ID differs from its canonical
+// form, asks for confirmation on in/out unless opts.Yes, and merges them. It returns nil if there
+// was nothing to merge, opts.DryRun is set or the user declined.
+func executeMergeProjectsWithService(ctx context.Context, mergeService service.MergeService, opts *MergeProjectsOptions, in io.Reader, out io.Writer) (*service.MergeProjectsResponse, error) {
+	plan, err := mergeService.MergeProjects(ctx, &service.MergeProjectsRequest{DryRun: true})
+	if err != nil {
+		return nil, err
+	}
+	if len(plan.Merges) == 0 {
+		fmt.Fprintln(out, "all project IDs are canonical; nothing to merge")
+		return nil, nil
+	}
+	printMerges(out, plan)
+	if opts.DryRun {
+		return nil, nil
+	}
+
+	if !opts.Yes && !confirm(in, out, fmt.Sprintf("Merge %d projects?", len(plan.Merges))) {
+		fmt.Fprintln(out, "merge cancelled")
+		return nil, nil
+	}
+
+	resp, err := mergeService.MergeProjects(ctx, &service.MergeProjectsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(out, "merged %d projects\n", len(resp.Merges))
+	return resp, nil
+}
+
+// printMerges writes one line per project merge
+func printMerges(out io.Writer, resp *service.MergeProjectsResponse) {
+	for _, m := range resp.Merges {
+		fmt.Fprintf(out, "%s -> %s: %d notes, %d groups, %d globals\n", m.From, m.To, m.Notes, m.Groups, m.Globals)
+	}
+	if resp.Skipped > 0 {
+		fmt.Fprintf(out, "%d groups/globals already exist in the target project and stay under the old ID\n", resp.Skipped)
+	}
+}
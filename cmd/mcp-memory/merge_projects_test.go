@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// mockMergeService is a mock implementation of service.MergeService
+type mockMergeService struct {
+	merges []service.ProjectMerge
+	merged bool
+}
+
+func (m *mockMergeService) MergeProjects(ctx context.Context, req *service.MergeProjectsRequest) (*service.MergeProjectsResponse, error) {
+	if !req.DryRun {
+		m.merged = true
+	}
+	return &service.MergeProjectsResponse{Merges: m.merges}, nil
+}
+
+// TestParseMergeProjectsFlags tests flag parsing for merge-projects command
+func TestParseMergeProjectsFlags(t *testing.T) {
+	opts, err := parseMergeProjectsFlags([]string{"--dry-run", "-y", "-c", "config.json"})
+	if err != nil {
+		t.Fatalf("parseMergeProjectsFlags() error = %v", err)
+	}
+	want := MergeProjectsOptions{DryRun: true, Yes: true, ConfigPath: "config.json"}
+	if *opts != want {
+		t.Errorf("parseMergeProjectsFlags() = %+v, want %+v", *opts, want)
+	}
+	if _, err := parseMergeProjectsFlags([]string{"/test/project"}); err == nil {
+		t.Error("expected error for extra argument")
+	}
+}
+
+// TestExecuteMergeProjects tests confirmation and dry-run
+func TestExecuteMergeProjects(t *testing.T) {
+	merges := []service.ProjectMerge{{From: "/Test/Project", To: "/test/project", Notes: 2}}
+	tests := []struct {
+		name       string
+		merges     []service.ProjectMerge
+		opts       MergeProjectsOptions
+		input      string
+		wantMerged bool
+	}{
+		{name: "confirmed", merges: merges, input: "y\n", wantMerged: true},
+		{name: "declined", merges: merges, input: "n\n"},
+		{name: "yes flag", merges: merges, opts: MergeProjectsOptions{Yes: true}, wantMerged: true},
+		{name: "dry run", merges: merges, opts: MergeProjectsOptions{DryRun: true, Yes: true}},
+		{name: "nothing to merge", opts: MergeProjectsOptions{Yes: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockMergeService{merges: tt.merges}
+			var out bytes.Buffer
+			resp, err := executeMergeProjectsWithService(context.Background(), mockService, &tt.opts, strings.NewReader(tt.input), &out)
+			if err != nil {
+				t.Fatalf("executeMergeProjectsWithService() error = %v", err)
+			}
+			if (resp != nil) != tt.wantMerged || mockService.merged != tt.wantMerged {
+				t.Fatalf("merged = %v, want %v (output: %s)", mockService.merged, tt.wantMerged, out.String())
+			}
+			if len(tt.merges) > 0 && !strings.Contains(out.String(), "/Test/Project -> /test/project: 2 notes") {
+				t.Errorf("expected the plan in the output, got %s", out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/brbranch/embedding_mcp/internal/bootstrap"
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// MigrateOptions holds parsed migrate command options
+type MigrateOptions struct {
+	From       string
+	ProjectID  string
+	Yes        bool
+	ConfigPath string
+}
+
+// parseMigrateFlags parses command line arguments for migrate command
+func parseMigrateFlags(args []string) (*MigrateOptions, error) {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	fs.SetOutput(io.Discard) // suppress default error output
+
+	opts := &MigrateOptions{}
+
+	// Long flags
+	fs.StringVar(&opts.From, "from", "", "Namespace to migrate from (provider:model:dim)")
+	fs.StringVar(&opts.ProjectID, "project", "", "Project ID/path (default: all projects)")
+	fs.BoolVar(&opts.Yes, "yes", false, "Do not ask for confirmation")
+	fs.StringVar(&opts.ConfigPath, "config", "", "Config file path")
+
+	// Short flags
+	fs.StringVar(&opts.ProjectID, "p", "", "Project ID/path (default: all projects)")
+	fs.BoolVar(&opts.Yes, "y", false, "Do not ask for confirmation")
+	fs.StringVar(&opts.ConfigPath, "c", "", "Config file path")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if err := applyFlagEnv(fs); err != nil {
+		return nil, err
+	}
+
+	// Validation
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+	if opts.From != "" {
+		if _, _, _, err := config.ParseNamespace(opts.From); err != nil {
+			return nil, fmt.Errorf("invalid --from: %w", err)
+		}
+	}
+
+	return opts, nil
+}
+
+// runMigrateCmd is the entry point for migrate command
+func runMigrateCmd(args []string) error {
+	opts, err := parseMigrateFlags(args)
+	if err != nil {
+		return err
+	}
+
+	// Initialize services
+	ctx := context.Background()
+	services, cleanup, err := bootstrap.Initialize(ctx, opts.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+	defer cleanup()
+
+	// Default to the previous embedder's namespace
+	fromPrevious := false
+	if opts.From == "" {
+		prev := services.Config.PreviousEmbedder
+		if prev == nil {
+			return fmt.Errorf("no namespace to migrate from: pass --from provider:model:dim or set previousEmbedder in %s", services.Config.Paths.ConfigPath)
+		}
+		opts.From = config.GenerateNamespace(prev.Provider, prev.Model, prev.Dim)
+		fromPrevious = true
+	}
+
+	resp, err := executeMigrateWithService(ctx, services.MigrateService, opts, services.Namespace, os.Stdin, os.Stderr)
+	if err != nil {
+		return fmt.Errorf("migrate failed: %w", err)
+	}
+	if resp != nil && fromPrevious {
+		fmt.Fprintf(os.Stderr, "remove previousEmbedder from %s to stop reading %s\n", services.Config.Paths.ConfigPath, opts.From)
+	}
+	return nil
+}
+
+// executeMigrateWithService counts the notes in opts.From, asks for confirmation on in/out
+// unless opts.Yes, and migrates them into namespace. It returns nil if there was nothing
+// to migrate or the user declined.
+func executeMigrateWithService(ctx context.Context, migrateService service.MigrateService, opts *MigrateOptions, namespace string, in io.Reader, out io.Writer) (*service.MigrateResponse, error) {
+	if opts.From == namespace {
+		return nil, fmt.Errorf("%w: %s", service.ErrSameNamespace, namespace)
+	}
+
+	n, err := migrateService.CountNotes(ctx, opts.From)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		fmt.Fprintf(out, "no notes in %s; nothing to migrate\n", opts.From)
+		return nil, nil
+	}
+
+	if !opts.Yes {
+		scope := "all projects"
+		if opts.ProjectID != "" {
+			scope = opts.ProjectID
+		}
+		question := fmt.Sprintf("%s holds %d notes. Re-embed them (%s) into %s?", opts.From, n, scope, namespace)
+		if !confirm(in, out, question) {
+			fmt.Fprintln(out, "migration cancelled")
+			return nil, nil
+		}
+	}
+
+	resp, err := migrateService.Migrate(ctx, &service.MigrateRequest{From: opts.From, ProjectID: opts.ProjectID})
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(out, "migrated %d projects from %s into %s: %d created, %d skipped (already migrated), %d re-embedded\n",
+		resp.Projects, resp.From, resp.Namespace, resp.Created, resp.Skipped, resp.ReEmbedded)
+	return resp, nil
+}
+
+// confirm asks question on out and reports whether the answer read from in is yes
+func confirm(in io.Reader, out io.Writer, question string) bool {
+	fmt.Fprintf(out, "%s [y/N] ", question)
+	line, _ := bufio.NewReader(in).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
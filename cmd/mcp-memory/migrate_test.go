@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// mockMigrateService is a mock implementation of service.MigrateService
+type mockMigrateService struct {
+	count    int
+	migrated *service.MigrateRequest
+}
+
+func (m *mockMigrateService) CountNotes(ctx context.Context, namespace string) (int, error) {
+	return m.count, nil
+}
+
+func (m *mockMigrateService) Migrate(ctx context.Context, req *service.MigrateRequest) (*service.MigrateResponse, error) {
+	m.migrated = req
+	return &service.MigrateResponse{From: req.From, Namespace: "openai:text-embedding-3-large:0", Projects: 1, Created: m.count, ReEmbedded: m.count}, nil
+}
+
+// TestParseMigrateFlags tests flag parsing for migrate command
+func TestParseMigrateFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    MigrateOptions
+		wantErr bool
+	}{
+		{
+			name: "no flags",
+			args: []string{},
+			want: MigrateOptions{},
+		},
+		{
+			name: "all flags",
+			args: []string{"--from", "openai:text-embedding-3-small:0", "-p", "/test/project", "-y", "-c", "config.json"},
+			want: MigrateOptions{From: "openai:text-embedding-3-small:0", ProjectID: "/test/project", Yes: true, ConfigPath: "config.json"},
+		},
+		{
+			name:    "invalid namespace",
+			args:    []string{"--from", "openai"},
+			wantErr: true,
+		},
+		{
+			name:    "extra argument",
+			args:    []string{"openai:text-embedding-3-small:0"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := parseMigrateFlags(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseMigrateFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if *opts != tt.want {
+				t.Errorf("parseMigrateFlags() = %+v, want %+v", *opts, tt.want)
+			}
+		})
+	}
+}
+
+// TestExecuteMigrate tests confirmation and the migrate request
+func TestExecuteMigrate(t *testing.T) {
+	const namespace = "openai:text-embedding-3-large:0"
+	tests := []struct {
+		name         string
+		count        int
+		yes          bool
+		input        string
+		wantMigrated bool
+	}{
+		{name: "confirmed", count: 3, input: "y\n", wantMigrated: true},
+		{name: "declined", count: 3, input: "\n"},
+		{name: "yes flag", count: 3, yes: true, wantMigrated: true},
+		{name: "no notes", count: 0, yes: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockMigrateService{count: tt.count}
+			opts := &MigrateOptions{From: "openai:text-embedding-3-small:0", ProjectID: "/test/project", Yes: tt.yes}
+			var out bytes.Buffer
+			resp, err := executeMigrateWithService(context.Background(), mockService, opts, namespace, strings.NewReader(tt.input), &out)
+			if err != nil {
+				t.Fatalf("executeMigrateWithService() error = %v", err)
+			}
+			if (resp != nil) != tt.wantMigrated || (mockService.migrated != nil) != tt.wantMigrated {
+				t.Fatalf("migrated = %v, want %v (output: %s)", mockService.migrated, tt.wantMigrated, out.String())
+			}
+			if tt.wantMigrated && mockService.migrated.ProjectID != "/test/project" {
+				t.Errorf("ProjectID = %q, want /test/project", mockService.migrated.ProjectID)
+			}
+		})
+	}
+}
+
+// TestExecuteMigrate_SameNamespace tests that migrating into the same namespace is rejected
+func TestExecuteMigrate_SameNamespace(t *testing.T) {
+	opts := &MigrateOptions{From: "openai:text-embedding-3-small:0", Yes: true}
+	_, err := executeMigrateWithService(context.Background(), &mockMigrateService{count: 1}, opts, opts.From, strings.NewReader(""), &bytes.Buffer{})
+	if !errors.Is(err, service.ErrSameNamespace) {
+		t.Errorf("expected ErrSameNamespace, got %v", err)
+	}
+}
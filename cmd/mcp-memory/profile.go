@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/brbranch/embedding_mcp/internal/config"
+)
+
+// extractProfileFlag removes the global --profile flag from args and returns its value
+// together with the remaining arguments. Like the logging flags it may appear before or
+// after the subcommand, until a "--" terminator.
+func extractProfileFlag(args []string) (string, []string, error) {
+	var profile string
+	rest := make([]string, 0, len(args))
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+
+		name := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+		value, hasValue := "", false
+		if j := strings.IndexByte(name, '='); j >= 0 {
+			name, value, hasValue = name[:j], name[j+1:], true
+		}
+		if !strings.HasPrefix(arg, "-") || name != "profile" {
+			rest = append(rest, arg)
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return "", nil, fmt.Errorf("flag needs an argument: --profile")
+			}
+			i++
+			value = args[i]
+		}
+		if value == "" {
+			return "", nil, fmt.Errorf("--profile must not be empty")
+		}
+		profile = value
+	}
+	return profile, rest, nil
+}
+
+// selectProfile makes every config load in this process use profile.
+// config.Manager reads the profile from MCP_MEMORY_PROFILE, so --profile is passed on
+// through the environment (and thus takes precedence over an inherited value).
+func selectProfile(profile string) error {
+	if profile == "" {
+		return nil
+	}
+	return os.Setenv(config.EnvProfile, profile)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractProfileFlag(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		wantProfile string
+		wantRest    []string
+	}{
+		{"none", []string{"serve", "-t", "http"}, "", []string{"serve", "-t", "http"}},
+		{"before command", []string{"--profile", "work", "serve"}, "work", []string{"serve"}},
+		{"after command", []string{"list", "-p", "/proj", "-profile=offline"}, "offline", []string{"list", "-p", "/proj"}},
+		{"after terminator", []string{"search", "--", "--profile", "work"}, "", []string{"search", "--", "--profile", "work"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, rest, err := extractProfileFlag(tt.args)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if profile != tt.wantProfile {
+				t.Errorf("profile = %q, want %q", profile, tt.wantProfile)
+			}
+			if !reflect.DeepEqual(rest, tt.wantRest) {
+				t.Errorf("rest = %v, want %v", rest, tt.wantRest)
+			}
+		})
+	}
+}
+
+func TestExtractProfileFlag_Errors(t *testing.T) {
+	for _, args := range [][]string{{"serve", "--profile"}, {"--profile=", "serve"}} {
+		if _, _, err := extractProfileFlag(args); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+}
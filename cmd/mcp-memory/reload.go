@@ -0,0 +1,384 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/auth"
+	"github.com/brbranch/embedding_mcp/internal/bootstrap"
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/jsonrpc"
+	"github.com/brbranch/embedding_mcp/internal/logging"
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/service"
+	"github.com/brbranch/embedding_mcp/internal/transport/http"
+)
+
+// defaultReloadInterval is how often serve checks the config file for changes
+const defaultReloadInterval = 2 * time.Second
+
+// configReloader applies config file changes to a running server. Log level, CORS
+// origins and HTTP access tokens are applied in place. Embedder, store and encryption key changes re-initialize the services
+// and swap them into the handler once in-flight requests finish. Changes to the data dir,
+// store type or SQLite database need a restart, since the instance lock covers them.
+// It also re-initializes the services when memory.set_config changes the embedder, and
+// serves memory.migrate and memory.stats with whichever services are current.
+type configReloader struct {
+	opts    *Options
+	path    string
+	handler *jsonrpc.Handler
+	servers []*http.Server
+
+	mu       sync.Mutex
+	loaded   model.Config // config as last applied (before the embedder fixes dim at runtime)
+	modTime  time.Time
+	size     int64
+	services *bootstrap.Services
+	cleanup  func()
+}
+
+// newConfigReloader takes ownership of services and cleanup (released by close)
+// and registers itself as the handler's set_config reinitializer and migrate, stats and export service
+func newConfigReloader(opts *Options, handler *jsonrpc.Handler, services *bootstrap.Services, cleanup func()) (*configReloader, error) {
+	manager, err := loadConfig(opts.ConfigPath, opts.DataDir)
+	if err != nil {
+		return nil, err
+	}
+	r := &configReloader{
+		opts:     opts,
+		path:     manager.GetConfigPath(),
+		handler:  handler,
+		loaded:   *manager.GetConfig(),
+		services: services,
+		cleanup:  cleanup,
+	}
+	r.modTime, r.size = r.stat()
+	handler.SetReinitializer(r.reinitEmbedder)
+	handler.SetMigrateService(reloaderMigrateService{r})
+	handler.SetStatsService(reloaderStatsService{r})
+	handler.SetExportService(reloaderExportService{r})
+	handler.SetArchiveService(reloaderArchiveService{r})
+	handler.SetRepairService(reloaderRepairService{r})
+	return r, nil
+}
+
+// addHTTPServer registers an HTTP server whose CORS origins and access tokens follow the config
+func (r *configReloader) addHTTPServer(s *http.Server) {
+	r.servers = append(r.servers, s)
+}
+
+// close releases the current services
+func (r *configReloader) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cleanup()
+}
+
+// namespace returns the namespace the current services use
+func (r *configReloader) namespace() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.services.Namespace
+}
+
+// stat returns the config file's modification time and size (zero if it does not exist)
+func (r *configReloader) stat() (time.Time, int64) {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return time.Time{}, 0
+	}
+	return info.ModTime(), info.Size()
+}
+
+// run reloads the config on SIGHUP and, if interval > 0, whenever the file changes,
+// until ctx is cancelled. Reload errors are logged and the current config is kept.
+func (r *configReloader) run(ctx context.Context, interval time.Duration) {
+	sigCh := make(chan os.Signal, 1)
+	if sigs := reloadSignals(); len(sigs) > 0 {
+		signal.Notify(sigCh, sigs...)
+		defer signal.Stop(sigCh)
+	}
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sigCh:
+			slog.Info("config: reload requested by signal", "path", r.path)
+		case <-tick:
+			modTime, size := r.stat()
+			if modTime.Equal(r.modTime) && size == r.size {
+				continue
+			}
+			slog.Info("config: file changed", "path", r.path)
+		}
+		r.modTime, r.size = r.stat()
+		if err := r.reload(ctx); err != nil {
+			slog.Error("config: reload failed; keeping the current config", "error", err)
+		}
+	}
+}
+
+// reload loads the config file and applies what changed
+func (r *configReloader) reload(ctx context.Context) error {
+	manager, err := loadConfig(r.opts.ConfigPath, r.opts.DataDir)
+	if err != nil {
+		return err
+	}
+	if err := r.apply(ctx, *manager.GetConfig()); err != nil {
+		return err
+	}
+	// outside r.mu: a set_config request in flight may be waiting for it in reinitEmbedder
+	r.handler.ApplyPendingServices()
+	return nil
+}
+
+// apply applies next and, if the embedder or store changed, schedules the new services
+func (r *configReloader) apply(ctx context.Context, next model.Config) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if ctx.Err() != nil {
+		// serve is shutting down (close may already have released the services)
+		return nil
+	}
+
+	if err := applyConfigLogLevel(next.Logging.Level); err != nil {
+		return fmt.Errorf("logging.level: %w", err)
+	}
+	if len(r.servers) > 0 {
+		authenticator, err := newAuthenticator(next)
+		if err != nil {
+			return err
+		}
+		for _, s := range r.servers {
+			s.SetCORSOrigins(next.TransportDefaults.CORSOrigins)
+			s.SetAuthenticator(authenticator)
+		}
+	}
+
+	if reason := restartRequired(&r.loaded, &next); reason != "" {
+		slog.Warn("config: restart the server to apply embedder and store changes", "changed", reason, "path", r.path)
+		return nil
+	}
+	if reflect.DeepEqual(r.loaded.Embedder, next.Embedder) && reflect.DeepEqual(r.loaded.Store, next.Store) &&
+		reflect.DeepEqual(r.loaded.Encryption, next.Encryption) {
+		r.loaded = next
+		slog.Info("config: reloaded", "path", r.path)
+		return nil
+	}
+
+	services, cleanup, err := bootstrap.Initialize(ctx, r.path, bootstrap.WithDataDir(r.services.Config.Paths.DataDir))
+	if err != nil {
+		return fmt.Errorf("failed to re-initialize services: %w", err)
+	}
+	// the old services are closed once in-flight requests finish
+	r.handler.ReplaceServices(services.NoteService, services.ConfigService, services.GlobalService, services.GroupService, r.cleanup)
+	prevNamespace := r.services.Namespace
+	r.services, r.cleanup, r.loaded = services, cleanup, next
+	slog.Info("config: reloaded and re-initialized services", "path", r.path, "namespace", services.Namespace)
+	if prevNamespace != services.Namespace {
+		warnUnmigrated(ctx, services, prevNamespace)
+	}
+	return nil
+}
+
+// warnUnmigrated logs a hint when the namespace the server switched away from still holds notes
+func warnUnmigrated(ctx context.Context, services *bootstrap.Services, prevNamespace string) {
+	n, err := services.MigrateService.CountNotes(ctx, prevNamespace)
+	if err != nil || n == 0 {
+		return
+	}
+	slog.Warn("config: the previous namespace still holds notes; run `mcp-memory migrate --from` or call memory.migrate to re-embed them",
+		"previousNamespace", prevNamespace, "notes", n, "namespace", services.Namespace)
+}
+
+// reinitEmbedder re-initializes the services with an embedder changed by memory.set_config
+// (a jsonrpc.Reinitializer) and returns the namespaces in use before and after.
+// The swap is applied before the set_config response is sent.
+// If the namespace changes, dualRead makes the old embedder the previous embedder so its
+// notes stay readable until migrated; otherwise the previous embedder is dropped.
+// The config file is not written, so the change lasts until restart or until a reload
+// picks up an embedder change in the file.
+func (r *configReloader) reinitEmbedder(ctx context.Context, emb *model.EmbedderConfig, dualRead bool) (string, string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// The embedder records the detected dim in the config, but the services keep the
+	// namespace they were created with; stay in it unless the provider or model changes.
+	prevNamespace := r.services.Namespace
+	cur := r.services.Config.Embedder
+	if _, _, dim, err := config.ParseNamespace(prevNamespace); err == nil {
+		cur.Dim = dim
+	}
+	next := *emb
+	if next.Provider == cur.Provider && next.Model == cur.Model {
+		next.Dim = cur.Dim
+	}
+
+	previous := r.services.Config.PreviousEmbedder
+	if config.GenerateNamespace(next.Provider, next.Model, next.Dim) != prevNamespace {
+		previous = nil
+		if dualRead {
+			previous = &cur
+		}
+	}
+	services, cleanup, err := bootstrap.Initialize(ctx, r.path, bootstrap.WithDataDir(r.services.Config.Paths.DataDir),
+		bootstrap.WithEmbedder(&next), bootstrap.WithPreviousEmbedder(previous))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to re-initialize services: %w", err)
+	}
+	r.handler.ReplaceServices(services.NoteService, services.ConfigService, services.GlobalService, services.GroupService, r.cleanup)
+	r.services, r.cleanup = services, cleanup
+	slog.Info("config: embedder changed by set_config; re-initialized services", "namespace", services.Namespace)
+	return prevNamespace, services.Namespace, nil
+}
+
+// reloaderMigrateService is the handler's service.MigrateService; it migrates into the
+// services current at the time of the call, so it keeps working across reloads
+type reloaderMigrateService struct {
+	r *configReloader
+}
+
+// current returns the MigrateService of the current services
+func (m reloaderMigrateService) current() service.MigrateService {
+	m.r.mu.Lock()
+	defer m.r.mu.Unlock()
+	return m.r.services.MigrateService
+}
+
+func (m reloaderMigrateService) CountNotes(ctx context.Context, namespace string) (int, error) {
+	return m.current().CountNotes(ctx, namespace)
+}
+
+func (m reloaderMigrateService) Migrate(ctx context.Context, req *service.MigrateRequest) (*service.MigrateResponse, error) {
+	return m.current().Migrate(ctx, req)
+}
+
+// reloaderStatsService is the handler's service.StatsService; like reloaderMigrateService
+// it reports the namespace of the services current at the time of the call
+type reloaderStatsService struct {
+	r *configReloader
+}
+
+func (s reloaderStatsService) Stats(ctx context.Context) (*service.StatsResponse, error) {
+	s.r.mu.Lock()
+	stats := s.r.services.StatsService
+	s.r.mu.Unlock()
+	return stats.Stats(ctx)
+}
+
+// reloaderExportService is the handler's service.ExportService for GET /export; like
+// reloaderMigrateService it uses the services current at the time of the call
+type reloaderExportService struct {
+	r *configReloader
+}
+
+// current returns the ExportService of the current services
+func (e reloaderExportService) current() service.ExportService {
+	e.r.mu.Lock()
+	defer e.r.mu.Unlock()
+	return e.r.services.ExportService
+}
+
+func (e reloaderExportService) Export(ctx context.Context, w io.Writer, req *service.ExportRequest) (*service.ExportResponse, error) {
+	return e.current().Export(ctx, w, req)
+}
+
+func (e reloaderExportService) Import(ctx context.Context, r io.Reader, req *service.ImportRequest) (*service.ImportResponse, error) {
+	return e.current().Import(ctx, r, req)
+}
+
+// reloaderArchiveService is the handler's service.ArchiveService; like reloaderMigrateService
+// it uses the services current at the time of the call
+type reloaderArchiveService struct {
+	r *configReloader
+}
+
+// current returns the ArchiveService of the current services
+func (a reloaderArchiveService) current() service.ArchiveService {
+	a.r.mu.Lock()
+	defer a.r.mu.Unlock()
+	return a.r.services.ArchiveService
+}
+
+func (a reloaderArchiveService) Archive(ctx context.Context, req *service.ArchiveRequest) (*service.ArchiveResponse, error) {
+	return a.current().Archive(ctx, req)
+}
+
+func (a reloaderArchiveService) Restore(ctx context.Context, req *service.RestoreRequest) (*service.RestoreResponse, error) {
+	return a.current().Restore(ctx, req)
+}
+
+func (a reloaderArchiveService) ListArchived(ctx context.Context, req *service.ListArchivedRequest) (*service.ListArchivedResponse, error) {
+	return a.current().ListArchived(ctx, req)
+}
+
+// reloaderRepairService is the handler's service.RepairService; like reloaderMigrateService
+// it uses the services current at the time of the call
+type reloaderRepairService struct {
+	r *configReloader
+}
+
+// current returns the RepairService of the current services
+func (a reloaderRepairService) current() service.RepairService {
+	a.r.mu.Lock()
+	defer a.r.mu.Unlock()
+	return a.r.services.RepairService
+}
+
+func (a reloaderRepairService) RepairTimestamps(ctx context.Context, req *service.RepairTimestampsRequest) (*service.RepairTimestampsResponse, error) {
+	return a.current().RepairTimestamps(ctx, req)
+}
+
+func (a reloaderRepairService) Validate(ctx context.Context, req *service.ValidateRequest) (*service.ValidateResponse, error) {
+	return a.current().Validate(ctx, req)
+}
+
+// restartRequired returns the setting that cannot change while running, or "" if none did
+func restartRequired(prev, next *model.Config) string {
+	switch {
+	case prev.Paths.DataDir != next.Paths.DataDir:
+		return "paths.dataDir"
+	case prev.Store.Type != next.Store.Type:
+		return "store.type"
+	case next.Store.Type == model.StoreTypeSQLite && bootstrap.SQLitePath(prev) != bootstrap.SQLitePath(next):
+		return "store.path"
+	}
+	return ""
+}
+
+// newAuthenticator returns the HTTP authenticator for cfg.auth (nil if not configured),
+// resolving auth.apiKeyFrom without modifying cfg
+func newAuthenticator(cfg model.Config) (*auth.Authenticator, error) {
+	if cfg.Auth != nil {
+		a := *cfg.Auth
+		cfg.Auth = &a
+	}
+	if err := config.ResolveAuthSecret(&cfg); err != nil {
+		return nil, err
+	}
+	return auth.New(cfg.Auth), nil
+}
+
+// applyConfigLogLevel applies logging.level unless --log-level / MCP_MEMORY_LOG_LEVEL was given
+// (an empty level means info)
+func applyConfigLogLevel(level string) error {
+	if explicitLogLevel != "" {
+		return nil
+	}
+	return logging.SetLevel(level)
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	stdhttp "net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/bootstrap"
+	"github.com/brbranch/embedding_mcp/internal/jsonrpc"
+	"github.com/brbranch/embedding_mcp/internal/logging"
+	"github.com/brbranch/embedding_mcp/internal/model"
+)
+
+// writeReloadConfig writes a config using the in-memory store and local embedder
+func writeReloadConfig(t *testing.T, path, extra string) {
+	t.Helper()
+	data := `{"embedder": {"provider": "local", "model": "mock"}, "store": {"type": "memory"}` + extra + `}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConfigReloader_Reload(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	writeReloadConfig(t, path, `, "paths": {"dataDir": "`+filepath.ToSlash(dir)+`"}`)
+	prev := slog.Default()
+	closeLog, err := logging.Setup(logging.Options{File: filepath.Join(dir, "mcp-memory.log")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		closeLog()
+		slog.SetDefault(prev)
+	})
+
+	opts := &Options{ConfigPath: path}
+	services, cleanup, err := bootstrap.Initialize(ctx, path)
+	if err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	handler := jsonrpc.New(services.NoteService, services.ConfigService, services.GlobalService, services.GroupService)
+	r, err := newConfigReloader(opts, handler, services, cleanup)
+	if err != nil {
+		t.Fatalf("newConfigReloader failed: %v", err)
+	}
+	defer r.close()
+	firstNamespace := r.services.Namespace
+
+	// log level only: services are kept
+	writeReloadConfig(t, path, `, "paths": {"dataDir": "`+filepath.ToSlash(dir)+`"}, "logging": {"level": "debug"}`)
+	if err := r.reload(ctx); err != nil {
+		t.Fatalf("reload failed: %v", err)
+	}
+	if !slog.Default().Enabled(ctx, slog.LevelDebug) {
+		t.Error("expected debug level after reload")
+	}
+	if r.services != services {
+		t.Error("services should not be re-initialized for a log level change")
+	}
+
+	// embedder change: services are re-initialized with the new namespace
+	writeReloadConfig(t, path, `, "paths": {"dataDir": "`+filepath.ToSlash(dir)+`"}, "embedder": {"provider": "local", "model": "mock-2"}`)
+	if err := r.reload(ctx); err != nil {
+		t.Fatalf("reload failed: %v", err)
+	}
+	if r.services.Namespace == firstNamespace || r.services.Namespace != "local:mock-2:0" {
+		t.Errorf("expected namespace local:mock-2:0, got %q", r.services.Namespace)
+	}
+
+	// data dir change needs a restart: services are kept
+	current := r.services
+	writeReloadConfig(t, path, `, "paths": {"dataDir": "`+filepath.ToSlash(t.TempDir())+`"}, "embedder": {"provider": "local", "model": "mock-3"}`)
+	if err := r.reload(ctx); err != nil {
+		t.Fatalf("reload failed: %v", err)
+	}
+	if r.services != current {
+		t.Error("services should be kept when the data dir changes")
+	}
+
+	// invalid config: error, services are kept
+	if err := os.WriteFile(path, []byte("{invalid"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.reload(ctx); err == nil {
+		t.Error("expected error for invalid config")
+	}
+}
+
+func TestConfigReloader_ReinitEmbedder(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	writeReloadConfig(t, path, `, "paths": {"dataDir": "`+filepath.ToSlash(dir)+`"}`)
+
+	services, cleanup, err := bootstrap.Initialize(ctx, path)
+	if err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	handler := jsonrpc.New(services.NoteService, services.ConfigService, services.GlobalService, services.GroupService)
+	r, err := newConfigReloader(&Options{ConfigPath: path}, handler, services, cleanup)
+	if err != nil {
+		t.Fatalf("newConfigReloader failed: %v", err)
+	}
+	defer r.close()
+
+	call := func(method string, params any) map[string]any {
+		t.Helper()
+		req, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+		var resp map[string]any
+		if err := json.Unmarshal(handler.Handle(ctx, req), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp["error"] != nil {
+			t.Fatalf("%s failed: %v", method, resp["error"])
+		}
+		return resp["result"].(map[string]any)
+	}
+
+	result := call("memory.set_config", map[string]any{"embedder": map[string]any{"model": "mock-2"}})
+	if result["effectiveNamespace"] != "local:mock-2:0" || result["previousNamespace"] != "local:mock:0" || result["reembedRequired"] != true {
+		t.Errorf("unexpected set_config result: %v", result)
+	}
+	if r.services.Namespace != "local:mock-2:0" {
+		t.Errorf("expected services for local:mock-2:0, got %q", r.services.Namespace)
+	}
+	// 以降のリクエストは新しいサービスで処理される
+	if ns := call("memory.list_recent", map[string]any{"projectId": dir})["namespace"]; ns != "local:mock-2:0" {
+		t.Errorf("expected list_recent in local:mock-2:0, got %v", ns)
+	}
+	if m := call("memory.get_config", nil)["embedder"].(map[string]any)["model"]; m != "mock-2" {
+		t.Errorf("expected get_config to report mock-2, got %v", m)
+	}
+
+	// 設定ファイルは変わらないため、リロードしてもset_configの変更は維持される
+	if err := r.reload(ctx); err != nil {
+		t.Fatalf("reload failed: %v", err)
+	}
+	if r.services.Namespace != "local:mock-2:0" {
+		t.Errorf("expected set_config change to survive a reload, got %q", r.services.Namespace)
+	}
+
+	// 不正なproviderは設定もサービスも変えずにエラー
+	req, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 2, "method": "memory.set_config",
+		"params": map[string]any{"embedder": map[string]any{"provider": "cohere"}}})
+	var resp map[string]any
+	if err := json.Unmarshal(handler.Handle(ctx, req), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp["error"] == nil {
+		t.Error("expected error for an unknown provider")
+	}
+	if r.services.Namespace != "local:mock-2:0" {
+		t.Errorf("services should be kept after a failed set_config, got %q", r.services.Namespace)
+	}
+}
+
+func TestConfigReloader_DualReadAndMigrate(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	// OpenAI互換のテスト用サーバー（入力ごとに固定のベクトルを返す）
+	srv := httptest.NewServer(stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, req *stdhttp.Request) {
+		var body struct {
+			Input any `json:"input"`
+		}
+		json.NewDecoder(req.Body).Decode(&body)
+		n := 1
+		if inputs, ok := body.Input.([]any); ok {
+			n = len(inputs)
+		}
+		data := make([]map[string]any, n)
+		for i := range data {
+			data[i] = map[string]any{"embedding": []float32{0.1, 0.2, 0.3}, "index": i}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": data})
+	}))
+	defer srv.Close()
+	data := `{"embedder": {"provider": "openai", "model": "mock", "baseUrl": "` + srv.URL + `", "apiKey": "sk-test"}, "store": {"type": "sqlite"}, "paths": {"dataDir": "` + filepath.ToSlash(dir) + `"}}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	services, cleanup, err := bootstrap.Initialize(ctx, path)
+	if err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	handler := jsonrpc.New(services.NoteService, services.ConfigService, services.GlobalService, services.GroupService)
+	r, err := newConfigReloader(&Options{ConfigPath: path}, handler, services, cleanup)
+	if err != nil {
+		t.Fatalf("newConfigReloader failed: %v", err)
+	}
+	defer r.close()
+
+	call := func(method string, params any) map[string]any {
+		t.Helper()
+		req, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+		var resp map[string]any
+		if err := json.Unmarshal(handler.Handle(ctx, req), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp["error"] != nil {
+			t.Fatalf("%s failed: %v", method, resp["error"])
+		}
+		return resp["result"].(map[string]any)
+	}
+
+	id := call("memory.add_note", map[string]any{"projectId": dir, "groupId": "global", "text": "before the switch"})["id"]
+
+	result := call("memory.set_config", map[string]any{"embedder": map[string]any{"model": "mock-2"}, "dualRead": true})
+	if result["previousNoteCount"] != float64(1) || result["dualRead"] != true {
+		t.Errorf("unexpected set_config result: %v", result)
+	}
+	// 移行前でも変更前のnamespaceのノートが読める
+	items := call("memory.list_recent", map[string]any{"projectId": dir})["items"].([]any)
+	if len(items) != 1 || items[0].(map[string]any)["namespace"] != "openai:mock:0" {
+		t.Errorf("expected the note from the previous namespace, got %v", items)
+	}
+	if prev, ok := call("memory.get_config", nil)["previousEmbedder"].(map[string]any); !ok || prev["model"] != "mock" {
+		t.Errorf("expected previousEmbedder in get_config, got %v", prev)
+	}
+
+	result = call("memory.migrate", map[string]any{})
+	if result["from"] != "openai:mock:0" || result["namespace"] != "openai:mock-2:0" || result["reEmbedded"] != float64(1) {
+		t.Errorf("unexpected migrate result: %v", result)
+	}
+	note := call("memory.get", map[string]any{"id": id})
+	if note["namespace"] != "openai:mock-2:0" {
+		t.Errorf("expected the migrated note in openai:mock-2:0, got %v", note["namespace"])
+	}
+	if items := call("memory.list_recent", map[string]any{"projectId": dir})["items"].([]any); len(items) != 1 {
+		t.Errorf("expected the migrated note once, got %v", items)
+	}
+}
+
+func TestRestartRequired(t *testing.T) {
+	dbPath := "other.db"
+	base := model.Config{
+		Store: model.StoreConfig{Type: model.StoreTypeSQLite},
+		Paths: model.PathsConfig{DataDir: "/data"},
+	}
+
+	tests := []struct {
+		name   string
+		modify func(c *model.Config)
+		want   string
+	}{
+		{name: "unchanged", modify: func(c *model.Config) {}, want: ""},
+		{name: "embedder", modify: func(c *model.Config) { c.Embedder.Model = "other" }, want: ""},
+		{name: "data dir", modify: func(c *model.Config) { c.Paths.DataDir = "/other" }, want: "paths.dataDir"},
+		{name: "store type", modify: func(c *model.Config) { c.Store.Type = model.StoreTypeQdrant }, want: "store.type"},
+		{name: "sqlite path", modify: func(c *model.Config) { c.Store.Path = &dbPath }, want: "store.path"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := base
+			tt.modify(&next)
+			if got := restartRequired(&base, &next); got != tt.want {
+				t.Errorf("restartRequired() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// reloadSignals returns the signals that make serve reload its config
+func reloadSignals() []os.Signal {
+	return []os.Signal{syscall.SIGHUP}
+}
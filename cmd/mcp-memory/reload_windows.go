@@ -0,0 +1,10 @@
+//go:build windows
+
+package main
+
+import "os"
+
+// reloadSignals returns nil on Windows, which has no SIGHUP (the config file is polled instead)
+func reloadSignals() []os.Signal {
+	return nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+
+	"github.com/brbranch/embedding_mcp/internal/bootstrap"
+	"github.com/brbranch/embedding_mcp/internal/client"
+	"github.com/brbranch/embedding_mcp/internal/daemon"
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// RemoteOptions selects between a running server and in-process services for oneshot commands
+type RemoteOptions struct {
+	Remote string // server URL (--remote)
+	Local  bool   // never forward, even if a server is running (--local)
+}
+
+// registerFlags adds --remote and --local to fs
+func (o *RemoteOptions) registerFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.Remote, "remote", "", "Forward to a running server (URL or host:port)")
+	fs.BoolVar(&o.Local, "local", false, "Use in-process services even if a server is running")
+}
+
+// validate checks that --remote and --local are not combined
+func (o *RemoteOptions) validate() error {
+	if o.Remote != "" && o.Local {
+		return fmt.Errorf("--remote and --local are mutually exclusive")
+	}
+	return nil
+}
+
+// openNoteService returns the NoteService for a oneshot command. Calls are forwarded as
+// JSON-RPC to --remote, or to the HTTP server registered in the data dir's pidfile unless
+// --local is given, so that the CLI shares the server's embedder and store instead of
+// opening the SQLite database a second time. Otherwise services are initialized in-process.
+func openNoteService(ctx context.Context, configPath string, opts RemoteOptions) (service.NoteService, func(), error) {
+	remote := opts.Remote
+	if remote == "" && !opts.Local {
+		remote = detectRemote(configPath)
+	}
+
+	if remote != "" {
+		c, err := client.New(remote, client.WithToken(os.Getenv(client.EnvToken)))
+		if err != nil {
+			return nil, nil, err
+		}
+		slog.Debug("forwarding to running server", "url", c.URL())
+		return c.NoteService(), func() {}, nil
+	}
+
+	services, cleanup, err := bootstrap.Initialize(ctx, configPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize: %w", err)
+	}
+	return services.NoteService, cleanup, nil
+}
+
+// detectRemote returns the HTTP address of the server running on the configured data dir,
+// or "" if none is running or it does not serve HTTP
+func detectRemote(configPath string) string {
+	manager, err := loadConfig(configPath, "")
+	if err != nil {
+		return ""
+	}
+	dataDir, err := instanceDataDir(manager.GetConfig())
+	if err != nil {
+		return ""
+	}
+	info, err := daemon.Status(dataDir)
+	if err != nil || info.HTTPAddr == "" {
+		return ""
+	}
+	return dialAddr(info.HTTPAddr)
+}
+
+// dialAddr turns a listen address into one the CLI can connect to
+// (wildcard hosts such as 0.0.0.0 are replaced with loopback)
+func dialAddr(listenAddr string) string {
+	host, port, err := net.SplitHostPort(listenAddr)
+	if err != nil {
+		return listenAddr
+	}
+	switch host {
+	case "", "0.0.0.0":
+		host = "127.0.0.1"
+	case "::":
+		host = "::1"
+	}
+	return net.JoinHostPort(host, port)
+}
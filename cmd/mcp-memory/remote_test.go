@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"path/filepath"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/client"
+	"github.com/brbranch/embedding_mcp/internal/daemon"
+)
+
+func TestRemoteOptions_Flags(t *testing.T) {
+	var opts RemoteOptions
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	opts.registerFlags(fs)
+	if err := fs.Parse([]string{"--remote", "127.0.0.1:8765"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.Remote != "127.0.0.1:8765" || opts.validate() != nil {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+
+	opts.Local = true
+	if err := opts.validate(); err == nil {
+		t.Error("expected error for --remote with --local")
+	}
+
+	if _, err := parseSearchFlags([]string{"-p", "/proj", "--remote", "h:1", "--local", "q"}); err == nil {
+		t.Error("expected search to reject --remote with --local")
+	}
+}
+
+func TestDialAddr(t *testing.T) {
+	tests := map[string]string{
+		"127.0.0.1:8765": "127.0.0.1:8765",
+		"0.0.0.0:8765":   "127.0.0.1:8765",
+		"[::]:8765":      "[::1]:8765",
+		":8765":          "127.0.0.1:8765",
+	}
+	for in, want := range tests {
+		if got := dialAddr(in); got != want {
+			t.Errorf("dialAddr(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestDetectRemote(t *testing.T) {
+	dataDir := t.TempDir()
+	t.Setenv("MCP_MEMORY_DATA_DIR", dataDir)
+	t.Setenv("MCP_MEMORY_CONFIG", filepath.Join(t.TempDir(), "missing.json"))
+
+	if got := detectRemote(""); got != "" {
+		t.Errorf("expected no remote without a running server, got %q", got)
+	}
+
+	inst, err := daemon.Acquire(dataDir, "", daemon.Info{Transports: []string{"stdio"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := detectRemote(""); got != "" {
+		t.Errorf("expected no remote for a stdio-only server, got %q", got)
+	}
+	inst.Release()
+
+	inst, err = daemon.Acquire(dataDir, "", daemon.Info{Transports: []string{"http"}, HTTPAddr: "0.0.0.0:9876"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer inst.Release()
+	if got := detectRemote(""); got != "127.0.0.1:9876" {
+		t.Errorf("expected detected remote 127.0.0.1:9876, got %q", got)
+	}
+}
+
+func TestOpenNoteService_Local(t *testing.T) {
+	dataDir := t.TempDir()
+	t.Setenv("MCP_MEMORY_DATA_DIR", dataDir)
+	t.Setenv("MCP_MEMORY_CONFIG", filepath.Join(t.TempDir(), "missing.json"))
+	t.Setenv("MCP_MEMORY_STORE_TYPE", "memory")
+	t.Setenv("MCP_MEMORY_EMBEDDER_PROVIDER", "local")
+
+	inst, err := daemon.Acquire(dataDir, "", daemon.Info{Transports: []string{"http"}, HTTPAddr: "127.0.0.1:9876"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer inst.Release()
+
+	svc, cleanup, err := openNoteService(context.Background(), "", RemoteOptions{Local: true})
+	if err != nil {
+		t.Fatalf("openNoteService failed: %v", err)
+	}
+	defer cleanup()
+	if _, err := svc.ListProjects(context.Background()); err != nil {
+		t.Errorf("expected in-process service with --local, got %v", err)
+	}
+}
+
+func TestOpenNoteService_Remote(t *testing.T) {
+	t.Setenv("MCP_MEMORY_CONFIG", filepath.Join(t.TempDir(), "missing.json"))
+
+	svc, cleanup, err := openNoteService(context.Background(), "", RemoteOptions{Remote: "127.0.0.1:9876"})
+	if err != nil {
+		t.Fatalf("openNoteService failed: %v", err)
+	}
+	defer cleanup()
+
+	// the JSON-RPC client does not support ListProjects, unlike the in-process service
+	if _, err := svc.ListProjects(context.Background()); !errors.Is(err, client.ErrNotSupported) {
+		t.Errorf("expected remote client, got %v", err)
+	}
+
+	if _, _, err := openNoteService(context.Background(), "", RemoteOptions{Remote: "ftp://host"}); err == nil {
+		t.Error("expected error for invalid remote url")
+	}
+}
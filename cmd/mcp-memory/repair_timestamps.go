@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/brbranch/embedding_mcp/internal/bootstrap"
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// RepairTimestampsOptions holds parsed repair-timestamps command options
+type RepairTimestampsOptions struct {
+	ProjectID  string
+	DryRun     bool
+	Yes        bool
+	ConfigPath string
+}
+
+// parseRepairTimestampsFlags parses command line arguments for repair-timestamps command
+func parseRepairTimestampsFlags(args []string) (*RepairTimestampsOptions, error) {
+	fs := flag.NewFlagSet("repair-timestamps", flag.ContinueOnError)
+	fs.SetOutput(io.Discard) // suppress default error output
+
+	opts := &RepairTimestampsOptions{}
+
+	// Long flags
+	fs.StringVar(&opts.ProjectID, "project", "", "Project ID/path (default: all projects)")
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "Show the repairs without changing anything")
+	fs.BoolVar(&opts.Yes, "yes", false, "Do not ask for confirmation")
+	fs.StringVar(&opts.ConfigPath, "config", "", "Config file path")
+
+	// Short flags
+	fs.StringVar(&opts.ProjectID, "p", "", "Project ID/path (default: all projects)")
+	fs.BoolVar(&opts.Yes, "y", false, "Do not ask for confirmation")
+	fs.StringVar(&opts.ConfigPath, "c", "", "Config file path")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if err := applyFlagEnv(fs); err != nil {
+		return nil, err
+	}
+
+	// Validation
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+
+	return opts, nil
+}
+
+// runRepairTimestampsCmd is the entry point for repair-timestamps command
+func runRepairTimestampsCmd(args []string) error {
+	opts, err := parseRepairTimestampsFlags(args)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	services, cleanup, err := bootstrap.Initialize(ctx, opts.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+	defer cleanup()
+
+	if _, err := executeRepairTimestampsWithService(ctx, services.RepairService, opts, os.Stdin, os.Stderr); err != nil {
+		return fmt.Errorf("repair-timestamps failed: %w", err)
+	}
+	return nil
+}
+
+// executeRepairTimestampsWithService lists the notes whose createdAt is not UTC RFC3339, asks for
+// confirmation on in/out unless opts.Yes, and rewrites them. It returns nil if there was nothing
+// to repair, opts.DryRun is set or the user declined.
+func executeRepairTimestampsWithService(ctx context.Context, repairService service.RepairService, opts *RepairTimestampsOptions, in io.Reader, out io.Writer) (*service.RepairTimestampsResponse, error) {
+	plan, err := repairService.RepairTimestamps(ctx, &service.RepairTimestampsRequest{ProjectID: opts.ProjectID, DryRun: true})
+	if err != nil {
+		return nil, err
+	}
+	printRepairs(out, plan)
+	if len(plan.Repairs) == 0 {
+		fmt.Fprintf(out, "checked %d notes; nothing to repair\n", plan.Scanned)
+		return nil, nil
+	}
+	if opts.DryRun {
+		return nil, nil
+	}
+
+	if !opts.Yes && !confirm(in, out, fmt.Sprintf("Repair createdAt of %d notes?", len(plan.Repairs))) {
+		fmt.Fprintln(out, "repair cancelled")
+		return nil, nil
+	}
+
+	resp, err := repairService.RepairTimestamps(ctx, &service.RepairTimestampsRequest{ProjectID: opts.ProjectID})
+	if err != nil {
+		if resp != nil {
+			fmt.Fprintf(out, "repaired %d notes before the error; re-run to repair the rest\n", len(resp.Repairs))
+		}
+		return nil, err
+	}
+	fmt.Fprintf(out, "repaired %d notes\n", len(resp.Repairs))
+	return resp, nil
+}
+
+// printRepairs writes one line per note to repair and per unparsable createdAt
+func printRepairs(out io.Writer, resp *service.RepairTimestampsResponse) {
+	for _, r := range resp.Repairs {
+		fmt.Fprintf(out, "%s %s: %s -> %s\n", r.ProjectID, r.ID, r.From, r.To)
+	}
+	for _, r := range resp.Invalid {
+		fmt.Fprintf(out, "%s %s: cannot parse createdAt %q; left as is\n", r.ProjectID, r.ID, r.From)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// mockRepairService is a mock implementation of service.RepairService
+type mockRepairService struct {
+	repairs  []service.TimestampRepair
+	repaired bool
+}
+
+func (m *mockRepairService) RepairTimestamps(ctx context.Context, req *service.RepairTimestampsRequest) (*service.RepairTimestampsResponse, error) {
+	if !req.DryRun {
+		m.repaired = true
+	}
+	invalid := []service.TimestampRepair{{ID: "bad", ProjectID: "/test/project", From: "yesterday"}}
+	return &service.RepairTimestampsResponse{Scanned: 3, Repairs: m.repairs, Invalid: invalid}, nil
+}
+
+func (m *mockRepairService) Validate(ctx context.Context, req *service.ValidateRequest) (*service.ValidateResponse, error) {
+	return &service.ValidateResponse{}, nil
+}
+
+// TestParseRepairTimestampsFlags tests flag parsing for repair-timestamps command
+func TestParseRepairTimestampsFlags(t *testing.T) {
+	opts, err := parseRepairTimestampsFlags([]string{"-p", "/test/project", "--dry-run", "-y", "-c", "config.json"})
+	if err != nil {
+		t.Fatalf("parseRepairTimestampsFlags() error = %v", err)
+	}
+	want := RepairTimestampsOptions{ProjectID: "/test/project", DryRun: true, Yes: true, ConfigPath: "config.json"}
+	if *opts != want {
+		t.Errorf("parseRepairTimestampsFlags() = %+v, want %+v", *opts, want)
+	}
+	if _, err := parseRepairTimestampsFlags([]string{"extra"}); err == nil {
+		t.Error("expected error for extra argument")
+	}
+}
+
+// TestExecuteRepairTimestamps tests confirmation and dry-run
+func TestExecuteRepairTimestamps(t *testing.T) {
+	repairs := []service.TimestampRepair{{ID: "n1", ProjectID: "/test/project", From: "2024-01-15T19:00:00+09:00", To: "2024-01-15T10:00:00Z"}}
+	tests := []struct {
+		name         string
+		repairs      []service.TimestampRepair
+		opts         RepairTimestampsOptions
+		input        string
+		wantRepaired bool
+	}{
+		{name: "confirmed", repairs: repairs, input: "y\n", wantRepaired: true},
+		{name: "declined", repairs: repairs, input: "n\n"},
+		{name: "yes flag", repairs: repairs, opts: RepairTimestampsOptions{Yes: true}, wantRepaired: true},
+		{name: "dry run", repairs: repairs, opts: RepairTimestampsOptions{DryRun: true, Yes: true}},
+		{name: "nothing to repair", opts: RepairTimestampsOptions{Yes: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockRepairService{repairs: tt.repairs}
+			var out bytes.Buffer
+			resp, err := executeRepairTimestampsWithService(context.Background(), mockService, &tt.opts, strings.NewReader(tt.input), &out)
+			if err != nil {
+				t.Fatalf("executeRepairTimestampsWithService() error = %v", err)
+			}
+			if (resp != nil) != tt.wantRepaired || mockService.repaired != tt.wantRepaired {
+				t.Fatalf("repaired = %v, want %v (output: %s)", mockService.repaired, tt.wantRepaired, out.String())
+			}
+			if !strings.Contains(out.String(), `bad: cannot parse createdAt "yesterday"`) {
+				t.Errorf("expected the unparsable createdAt in the output, got %s", out.String())
+			}
+			if len(tt.repairs) > 0 && !strings.Contains(out.String(), "/test/project n1: 2024-01-15T19:00:00+09:00 -> 2024-01-15T10:00:00Z") {
+				t.Errorf("expected the plan in the output, got %s", out.String())
+			}
+		})
+	}
+}
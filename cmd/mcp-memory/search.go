@@ -3,16 +3,19 @@ package main
 import (
 	"bufio"
 	"context"
+	"encoding/csv"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
-	"github.com/brbranch/embedding_mcp/internal/bootstrap"
 	"github.com/brbranch/embedding_mcp/internal/config"
 	"github.com/brbranch/embedding_mcp/internal/service"
+	"github.com/brbranch/embedding_mcp/internal/textutil"
 )
 
 // SearchOptions holds parsed search command options
@@ -25,6 +28,17 @@ type SearchOptions struct {
 	ConfigPath string
 	UseStdin   bool
 	Query      string
+	Since      string  // RFC3339 UTC (normalized from --since)
+	Until      string  // RFC3339 UTC (normalized from --until)
+	MinScore   float64 // 0 disables the filter
+	Language   string  // detected language (metadata.language); empty disables the filter
+	// IncludeDescendants also searches the descendant groups of GroupID (linked by parentGroupId)
+	IncludeDescendants bool
+	GroupBy            string // "group" buckets the results by groupId; empty disables it
+	GroupTopK          int    // results per groupId with GroupBy; 0 uses the server default
+	IncludeArchived    bool   // also search archived notes
+	AlsoProjects       string // additional projects to search across (comma-separated)
+	RemoteOptions
 }
 
 // JSONOutput represents the JSON output format
@@ -34,11 +48,14 @@ type JSONOutput struct {
 
 // JSONResult represents a single result in JSON output
 type JSONResult struct {
-	ID    string   `json:"id"`
-	Title string   `json:"title,omitempty"`
-	Text  string   `json:"text"`
-	Score float64  `json:"score"`
-	Tags  []string `json:"tags,omitempty"`
+	ID      string   `json:"id"`
+	GroupID string   `json:"groupId,omitempty"`
+	Title   string   `json:"title,omitempty"`
+	Text    string   `json:"text"`
+	Score   float64  `json:"score"`
+	Tags    []string `json:"tags,omitempty"`
+	// Archived marks archived notes (--include-archived)
+	Archived bool `json:"archived,omitempty"`
 }
 
 // parseSearchFlags parses command line arguments for search command
@@ -53,20 +70,33 @@ func parseSearchFlags(args []string) (*SearchOptions, error) {
 	fs.StringVar(&opts.GroupID, "group", "", "Group ID (optional)")
 	fs.IntVar(&opts.TopK, "top-k", 5, "Number of results")
 	fs.StringVar(&opts.Tags, "tags", "", "Tag filter (comma-separated)")
-	fs.StringVar(&opts.Format, "format", "text", "Output format: text|json")
+	fs.StringVar(&opts.Format, "format", "text", "Output format: text|json|markdown|csv")
 	fs.StringVar(&opts.ConfigPath, "config", "", "Config file path")
 	fs.BoolVar(&opts.UseStdin, "stdin", false, "Read query from stdin")
+	fs.StringVar(&opts.Since, "since", "", "Only notes created at or after this time")
+	fs.StringVar(&opts.Until, "until", "", "Only notes created before this time")
+	fs.Float64Var(&opts.MinScore, "min-score", 0, "Minimum score (0-1)")
+	fs.StringVar(&opts.Language, "language", "", "Only notes detected as this language (ja, en, ...)")
+	fs.BoolVar(&opts.IncludeDescendants, "include-descendants", false, "Also search the descendant groups of --group")
+	fs.StringVar(&opts.GroupBy, "group-by", "", "Bucket results by: group")
+	fs.IntVar(&opts.GroupTopK, "group-top-k", 0, "Results per group with --group-by (default: 2)")
+	fs.BoolVar(&opts.IncludeArchived, "include-archived", false, "Also search archived notes")
+	fs.StringVar(&opts.AlsoProjects, "also-projects", "", "Additional project IDs/paths to search across (comma-separated)")
+	opts.registerFlags(fs)
 
 	// Short flags
 	fs.StringVar(&opts.ProjectID, "p", "", "Project ID/path (required)")
 	fs.StringVar(&opts.GroupID, "g", "", "Group ID (optional)")
 	fs.IntVar(&opts.TopK, "k", 5, "Number of results")
-	fs.StringVar(&opts.Format, "f", "text", "Output format: text|json")
+	fs.StringVar(&opts.Format, "f", "text", "Output format: text|json|markdown|csv")
 	fs.StringVar(&opts.ConfigPath, "c", "", "Config file path")
 
 	if err := fs.Parse(args); err != nil {
 		return nil, err
 	}
+	if err := applyFlagEnv(fs); err != nil {
+		return nil, err
+	}
 
 	// Set default format if not specified
 	if opts.Format == "" {
@@ -91,13 +121,83 @@ func parseSearchFlags(args []string) (*SearchOptions, error) {
 	}
 
 	// Validate format
-	if opts.Format != "text" && opts.Format != "json" {
-		return nil, fmt.Errorf("invalid format: %s (must be text or json)", opts.Format)
+	switch opts.Format {
+	case "text", "json", "markdown", "csv":
+	default:
+		return nil, fmt.Errorf("invalid format: %s (must be text, json, markdown or csv)", opts.Format)
+	}
+
+	// Validate time range and min-score
+	now := time.Now()
+	var err error
+	if opts.Since, err = parseTimeFlag(opts.Since, now); err != nil {
+		return nil, fmt.Errorf("invalid --since: %w", err)
+	}
+	if opts.Until, err = parseTimeFlag(opts.Until, now); err != nil {
+		return nil, fmt.Errorf("invalid --until: %w", err)
+	}
+	if opts.Since != "" && opts.Until != "" && opts.Since >= opts.Until {
+		return nil, fmt.Errorf("--since must be before --until")
+	}
+	if opts.MinScore < 0 || opts.MinScore > 1 {
+		return nil, fmt.Errorf("min-score must be between 0 and 1")
+	}
+	if opts.IncludeDescendants && opts.GroupID == "" {
+		return nil, fmt.Errorf("--include-descendants requires --group")
+	}
+	if opts.GroupBy != "" && opts.GroupBy != service.SearchGroupByGroup {
+		return nil, fmt.Errorf("invalid --group-by: %s (must be group)", opts.GroupBy)
+	}
+	if opts.GroupTopK < 0 {
+		return nil, fmt.Errorf("group-top-k must be greater than 0")
+	}
+	if opts.GroupTopK > 0 && opts.GroupBy == "" {
+		return nil, fmt.Errorf("--group-top-k requires --group-by")
+	}
+	if opts.AlsoProjects != "" && opts.GroupBy != "" {
+		return nil, fmt.Errorf("--also-projects cannot be used with --group-by")
+	}
+	if err := opts.validate(); err != nil {
+		return nil, err
 	}
 
 	return opts, nil
 }
 
+// parseTimeFlag normalizes a --since/--until value to RFC3339 UTC.
+// Accepted forms: RFC3339 ("2024-01-02T15:04:05Z"), a date ("2024-01-02", UTC midnight)
+// or a duration before now ("36h", "7d").
+func parseTimeFlag(value string, now time.Time) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC().Format(time.RFC3339), nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t.UTC().Format(time.RFC3339), nil
+	}
+
+	var d time.Duration
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return "", fmt.Errorf("%q is not a time (RFC3339, YYYY-MM-DD or duration like 24h, 7d)", value)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return "", fmt.Errorf("%q is not a time (RFC3339, YYYY-MM-DD or duration like 24h, 7d)", value)
+		}
+		d = parsed
+	}
+	if d < 0 {
+		return "", fmt.Errorf("duration must not be negative: %s", value)
+	}
+	return now.Add(-d).UTC().Format(time.RFC3339), nil
+}
+
 // runSearchCmd is the entry point for search command
 func runSearchCmd(args []string) error {
 	opts, err := parseSearchFlags(args)
@@ -120,9 +220,9 @@ func runSearchCmd(args []string) error {
 
 	// Initialize services
 	ctx := context.Background()
-	services, cleanup, err := bootstrap.Initialize(ctx, opts.ConfigPath)
+	noteService, cleanup, err := openNoteService(ctx, opts.ConfigPath, opts.RemoteOptions)
 	if err != nil {
-		return fmt.Errorf("failed to initialize: %w", err)
+		return err
 	}
 	defer cleanup()
 
@@ -132,17 +232,17 @@ func runSearchCmd(args []string) error {
 		return fmt.Errorf("failed to canonicalize projectId: %w", err)
 	}
 
-	// Parse tags
-	tags := parseTags(opts.Tags)
-
-	// Prepare group ID pointer
-	var groupID *string
-	if opts.GroupID != "" {
-		groupID = &opts.GroupID
+	req := buildSearchRequest(opts, canonicalProjectID)
+	for _, projectID := range parseTags(opts.AlsoProjects) {
+		canonical, err := config.CanonicalizeProjectID(projectID)
+		if err != nil {
+			return fmt.Errorf("failed to canonicalize projectId: %w", err)
+		}
+		req.ProjectIDs = append(req.ProjectIDs, canonical)
 	}
 
 	// Execute search
-	results, err := executeSearchWithService(ctx, services.NoteService, canonicalProjectID, groupID, opts.Query, opts.TopK, tags)
+	results, err := executeSearchWithService(ctx, noteService, req)
 	if err != nil {
 		return fmt.Errorf("search failed: %w", err)
 	}
@@ -153,23 +253,58 @@ func runSearchCmd(args []string) error {
 		if err := formatJSONOutput(os.Stdout, results); err != nil {
 			return fmt.Errorf("failed to format output: %w", err)
 		}
+	case "markdown":
+		formatMarkdownOutput(os.Stdout, results)
+	case "csv":
+		if err := formatCSVOutput(os.Stdout, results); err != nil {
+			return fmt.Errorf("failed to format output: %w", err)
+		}
 	default:
-		formatTextOutput(os.Stdout, results)
+		if opts.GroupBy != "" {
+			formatGroupedTextOutput(os.Stdout, results)
+		} else {
+			formatTextOutput(os.Stdout, results)
+		}
 	}
 
 	return nil
 }
 
-// executeSearchWithService executes search using the provided NoteService
-func executeSearchWithService(ctx context.Context, noteService service.NoteService, projectID string, groupID *string, query string, topK int, tags []string) ([]service.SearchResult, error) {
+// buildSearchRequest converts the options to a service request for the canonical project ID
+func buildSearchRequest(opts *SearchOptions, projectID string) *service.SearchRequest {
+	topK := opts.TopK
 	req := &service.SearchRequest{
-		ProjectID: projectID,
-		GroupID:   groupID,
-		Query:     query,
-		TopK:      &topK,
-		Tags:      tags,
+		ProjectID:          projectID,
+		Query:              opts.Query,
+		TopK:               &topK,
+		Tags:               parseTags(opts.Tags),
+		IncludeDescendants: opts.IncludeDescendants,
+		GroupBy:            opts.GroupBy,
+		IncludeArchived:    opts.IncludeArchived,
+	}
+	if opts.GroupID != "" {
+		req.GroupID = &opts.GroupID
+	}
+	if opts.GroupTopK > 0 {
+		req.GroupTopK = &opts.GroupTopK
+	}
+	if opts.Since != "" {
+		req.Since = &opts.Since
 	}
+	if opts.Until != "" {
+		req.Until = &opts.Until
+	}
+	if opts.MinScore > 0 {
+		req.MinScore = &opts.MinScore
+	}
+	if opts.Language != "" {
+		req.Language = &opts.Language
+	}
+	return req
+}
 
+// executeSearchWithService executes search using the provided NoteService
+func executeSearchWithService(ctx context.Context, noteService service.NoteService, req *service.SearchRequest) ([]service.SearchResult, error) {
 	resp, err := noteService.Search(ctx, req)
 	if err != nil {
 		return nil, err
@@ -221,10 +356,14 @@ func formatTextOutput(w io.Writer, results []service.SearchResult) {
 			title = *r.Title
 		}
 
+		if r.Archived {
+			title += " [archived]"
+		}
+
 		fmt.Fprintf(w, "[%d] %s (score: %.2f)\n", i+1, title, r.Score)
 
 		// Truncated text content
-		text := truncateText(r.Text, 60)
+		text := textutil.Truncate(r.Text, 60)
 		fmt.Fprintf(w, "    %s\n", text)
 
 		// Tags
@@ -236,6 +375,25 @@ func formatTextOutput(w io.Writer, results []service.SearchResult) {
 	}
 }
 
+// formatGroupedTextOutput outputs --group-by group results with a heading per groupId
+func formatGroupedTextOutput(w io.Writer, results []service.SearchResult) {
+	if len(results) == 0 {
+		fmt.Fprintln(w, "No results found.")
+		return
+	}
+
+	// results of the same groupId are consecutive
+	for start := 0; start < len(results); {
+		end := start + 1
+		for end < len(results) && results[end].GroupID == results[start].GroupID {
+			end++
+		}
+		fmt.Fprintf(w, "== %s ==\n", results[start].GroupID)
+		formatTextOutput(w, results[start:end])
+		start = end
+	}
+}
+
 // formatJSONOutput outputs results in JSON format
 func formatJSONOutput(w io.Writer, results []service.SearchResult) error {
 	output := JSONOutput{
@@ -249,11 +407,13 @@ func formatJSONOutput(w io.Writer, results []service.SearchResult) error {
 		}
 
 		output.Results = append(output.Results, JSONResult{
-			ID:    r.ID,
-			Title: title,
-			Text:  r.Text,
-			Score: r.Score,
-			Tags:  r.Tags,
+			ID:       r.ID,
+			GroupID:  r.GroupID,
+			Title:    title,
+			Text:     r.Text,
+			Score:    r.Score,
+			Tags:     r.Tags,
+			Archived: r.Archived,
 		})
 	}
 
@@ -262,10 +422,61 @@ func formatJSONOutput(w io.Writer, results []service.SearchResult) error {
 	return encoder.Encode(output)
 }
 
-// truncateText truncates text to maxLen and adds "..." if truncated
-func truncateText(text string, maxLen int) string {
-	if text == "" || len(text) <= maxLen {
-		return text
+// formatMarkdownOutput outputs results as a Markdown table for pasting into issues
+func formatMarkdownOutput(w io.Writer, results []service.SearchResult) {
+	if len(results) == 0 {
+		fmt.Fprintln(w, "_No results found._")
+		return
 	}
-	return text[:maxLen] + " ..."
+
+	fmt.Fprintln(w, "| # | Title | Score | Text | Tags |")
+	fmt.Fprintln(w, "|---|-------|-------|------|------|")
+	for i, r := range results {
+		title := ""
+		if r.Title != nil {
+			title = *r.Title
+		}
+		fmt.Fprintf(w, "| %d | %s | %.2f | %s | %s |\n",
+			i+1,
+			escapeMarkdownCell(title),
+			r.Score,
+			escapeMarkdownCell(r.Text),
+			escapeMarkdownCell(strings.Join(r.Tags, ", ")),
+		)
+	}
+}
+
+// escapeMarkdownCell makes text safe to place in a single Markdown table cell
+func escapeMarkdownCell(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
+
+// formatCSVOutput outputs results as CSV with a header row
+func formatCSVOutput(w io.Writer, results []service.SearchResult) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"id", "title", "score", "tags", "text"}); err != nil {
+		return err
+	}
+
+	for _, r := range results {
+		title := ""
+		if r.Title != nil {
+			title = *r.Title
+		}
+		record := []string{
+			r.ID,
+			title,
+			strconv.FormatFloat(r.Score, 'f', 4, 64),
+			strings.Join(r.Tags, ","),
+			r.Text,
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
 }
@@ -3,11 +3,13 @@ package main
 import (
 	"bytes"
 	"context"
+	"encoding/csv"
 	"encoding/json"
 	"io"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/brbranch/embedding_mcp/internal/service"
 )
@@ -210,6 +212,79 @@ func TestFormatJSONOutput(t *testing.T) {
 	}
 }
 
+// TestFormatMarkdownOutput tests Markdown table output
+func TestFormatMarkdownOutput(t *testing.T) {
+	title := "A | B"
+	results := []service.SearchResult{
+		{
+			ID:    "id1",
+			Title: &title,
+			Text:  "line1\nline2",
+			Tags:  []string{"tag1", "tag2"},
+			Score: 0.92,
+		},
+	}
+
+	var buf bytes.Buffer
+	formatMarkdownOutput(&buf, results)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+
+	if len(lines) != 3 {
+		t.Fatalf("expected header, separator and 1 row, got %d lines:\n%s", len(lines), buf.String())
+	}
+	want := `| 1 | A \| B | 0.92 | line1<br>line2 | tag1, tag2 |`
+	if lines[2] != want {
+		t.Errorf("row = %q, want %q", lines[2], want)
+	}
+
+	buf.Reset()
+	formatMarkdownOutput(&buf, nil)
+	if !strings.Contains(buf.String(), "No results found.") {
+		t.Errorf("expected empty message, got %q", buf.String())
+	}
+}
+
+// TestFormatCSVOutput tests CSV output
+func TestFormatCSVOutput(t *testing.T) {
+	title := "Test Title"
+	results := []service.SearchResult{
+		{
+			ID:    "id1",
+			Title: &title,
+			Text:  "text, with \"quotes\"",
+			Tags:  []string{"tag1", "tag2"},
+			Score: 0.92,
+		},
+		{
+			ID:    "id2",
+			Text:  "plain",
+			Score: 0.5,
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := formatCSVOutput(&buf, results); err != nil {
+		t.Fatalf("formatCSVOutput failed: %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to parse CSV output: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected header and 2 rows, got %d", len(records))
+	}
+	if strings.Join(records[0], ",") != "id,title,score,tags,text" {
+		t.Errorf("unexpected header: %v", records[0])
+	}
+	if records[1][0] != "id1" || records[1][1] != "Test Title" || records[1][2] != "0.9200" || records[1][3] != "tag1,tag2" || records[1][4] != `text, with "quotes"` {
+		t.Errorf("unexpected row: %v", records[1])
+	}
+	if records[2][1] != "" || records[2][3] != "" {
+		t.Errorf("expected empty title and tags, got %v", records[2])
+	}
+}
+
 // TestReadQueryFromStdin tests reading query from stdin
 func TestReadQueryFromStdin(t *testing.T) {
 	// Save original stdin
@@ -241,10 +316,24 @@ func TestReadQueryFromStdin(t *testing.T) {
 
 // mockNoteService is a mock implementation for testing
 type mockNoteService struct {
-	searchFunc func(ctx context.Context, req *service.SearchRequest) (*service.SearchResponse, error)
+	searchFunc       func(ctx context.Context, req *service.SearchRequest) (*service.SearchResponse, error)
+	addNoteFunc      func(ctx context.Context, req *service.AddNoteRequest) (*service.AddNoteResponse, error)
+	addNotesFunc     func(ctx context.Context, req *service.AddNotesRequest) (*service.AddNotesResponse, error)
+	listRecentFunc   func(ctx context.Context, req *service.ListRecentRequest) (*service.ListRecentResponse, error)
+	listProjectsFunc func(ctx context.Context) (*service.ListProjectsResponse, error)
 }
 
 func (m *mockNoteService) AddNote(ctx context.Context, req *service.AddNoteRequest) (*service.AddNoteResponse, error) {
+	if m.addNoteFunc != nil {
+		return m.addNoteFunc(ctx, req)
+	}
+	return nil, nil
+}
+
+func (m *mockNoteService) AddNotes(ctx context.Context, req *service.AddNotesRequest) (*service.AddNotesResponse, error) {
+	if m.addNotesFunc != nil {
+		return m.addNotesFunc(ctx, req)
+	}
 	return nil, nil
 }
 
@@ -262,6 +351,10 @@ func (m *mockNoteService) Get(ctx context.Context, id string) (*service.GetRespo
 	return nil, nil
 }
 
+func (m *mockNoteService) GetDocument(ctx context.Context, id string) (*service.GetResponse, error) {
+	return nil, nil
+}
+
 func (m *mockNoteService) Update(ctx context.Context, req *service.UpdateRequest) error {
 	return nil
 }
@@ -271,6 +364,16 @@ func (m *mockNoteService) Delete(ctx context.Context, id string) error {
 }
 
 func (m *mockNoteService) ListRecent(ctx context.Context, req *service.ListRecentRequest) (*service.ListRecentResponse, error) {
+	if m.listRecentFunc != nil {
+		return m.listRecentFunc(ctx, req)
+	}
+	return nil, nil
+}
+
+func (m *mockNoteService) ListProjects(ctx context.Context) (*service.ListProjectsResponse, error) {
+	if m.listProjectsFunc != nil {
+		return m.listProjectsFunc(ctx)
+	}
 	return nil, nil
 }
 
@@ -303,7 +406,8 @@ func TestExecuteSearch(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	results, err := executeSearchWithService(ctx, mockService, "/test/canonical/project", nil, "test query", 5, nil)
+	opts := &SearchOptions{TopK: 5, Query: "test query"}
+	results, err := executeSearchWithService(ctx, mockService, buildSearchRequest(opts, "/test/canonical/project"))
 	if err != nil {
 		t.Fatalf("executeSearchWithService failed: %v", err)
 	}
@@ -341,29 +445,6 @@ func TestParseTags(t *testing.T) {
 	}
 }
 
-// TestTruncateText tests text truncation
-func TestTruncateText(t *testing.T) {
-	tests := []struct {
-		input  string
-		maxLen int
-		want   string
-	}{
-		{"short text", 100, "short text"},
-		{"this is a very long text that should be truncated", 20, "this is a very long  ..."},
-		{"exactly twenty chars", 20, "exactly twenty chars"},
-		{"", 10, ""},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.input, func(t *testing.T) {
-			got := truncateText(tt.input, tt.maxLen)
-			if got != tt.want {
-				t.Errorf("truncateText(%q, %d) = %q, want %q", tt.input, tt.maxLen, got, tt.want)
-			}
-		})
-	}
-}
-
 // Dummy test to ensure the file compiles
 func TestSearchCompiles(t *testing.T) {
 	// This test just ensures the search.go file compiles correctly
@@ -427,13 +508,13 @@ func TestParseSearchFlags_FormatValidation(t *testing.T) {
 			name:    "invalid format",
 			args:    []string{"-p", "/test/project", "-f", "yaml", "query"},
 			wantErr: true,
-			errMsg:  "invalid format: yaml (must be text or json)",
+			errMsg:  "invalid format: yaml (must be text, json, markdown or csv)",
 		},
 		{
 			name:    "another invalid format",
 			args:    []string{"-p", "/test/project", "--format", "xml", "query"},
 			wantErr: true,
-			errMsg:  "invalid format: xml (must be text or json)",
+			errMsg:  "invalid format: xml (must be text, json, markdown or csv)",
 		},
 		{
 			name:    "valid text format",
@@ -445,6 +526,16 @@ func TestParseSearchFlags_FormatValidation(t *testing.T) {
 			args:    []string{"-p", "/test/project", "-f", "json", "query"},
 			wantErr: false,
 		},
+		{
+			name:    "valid markdown format",
+			args:    []string{"-p", "/test/project", "-f", "markdown", "query"},
+			wantErr: false,
+		},
+		{
+			name:    "valid csv format",
+			args:    []string{"-p", "/test/project", "--format", "csv", "query"},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -464,3 +555,145 @@ func TestParseSearchFlags_FormatValidation(t *testing.T) {
 		})
 	}
 }
+
+// TestParseSearchFlags_TimeRangeAndMinScore tests --since, --until and --min-score
+func TestParseSearchFlags_TimeRangeAndMinScore(t *testing.T) {
+	opts, err := parseSearchFlags([]string{"-p", "/test/project", "--since", "2024-01-02", "--until", "2024-02-01T09:00:00+09:00", "--min-score", "0.75", "query"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.Since != "2024-01-02T00:00:00Z" {
+		t.Errorf("Since = %q", opts.Since)
+	}
+	if opts.Until != "2024-02-01T00:00:00Z" {
+		t.Errorf("Until = %q (expected conversion to UTC)", opts.Until)
+	}
+	if opts.MinScore != 0.75 {
+		t.Errorf("MinScore = %v", opts.MinScore)
+	}
+
+	errTests := []struct {
+		name   string
+		args   []string
+		errMsg string
+	}{
+		{"invalid since", []string{"-p", "/p", "--since", "yesterday", "q"}, "invalid --since"},
+		{"invalid until", []string{"-p", "/p", "--until", "2024-13-01", "q"}, "invalid --until"},
+		{"since after until", []string{"-p", "/p", "--since", "2024-02-01", "--until", "2024-01-01", "q"}, "--since must be before --until"},
+		{"min-score too large", []string{"-p", "/p", "--min-score", "1.5", "q"}, "min-score must be between 0 and 1"},
+		{"negative min-score", []string{"-p", "/p", "--min-score", "-0.1", "q"}, "min-score must be between 0 and 1"},
+	}
+	for _, tt := range errTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseSearchFlags(tt.args)
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("expected error containing %q, got %v", tt.errMsg, err)
+			}
+		})
+	}
+}
+
+// TestParseTimeFlag tests relative and absolute time values
+func TestParseTimeFlag(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", ""},
+		{"2024-03-01T08:30:00Z", "2024-03-01T08:30:00Z"},
+		{"2024-03-01", "2024-03-01T00:00:00Z"},
+		{"36h", "2024-03-09T00:00:00Z"},
+		{"7d", "2024-03-03T12:00:00Z"},
+	}
+	for _, tt := range tests {
+		got, err := parseTimeFlag(tt.in, now)
+		if err != nil {
+			t.Errorf("parseTimeFlag(%q): unexpected error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseTimeFlag(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"-2h", "xd", "soon"} {
+		if _, err := parseTimeFlag(in, now); err == nil {
+			t.Errorf("parseTimeFlag(%q): expected error", in)
+		}
+	}
+}
+
+// TestBuildSearchRequest tests that optional filters are only set when given
+func TestBuildSearchRequest(t *testing.T) {
+	req := buildSearchRequest(&SearchOptions{TopK: 3, Query: "q"}, "/proj")
+	if req.GroupID != nil || req.Since != nil || req.Until != nil || req.MinScore != nil || req.Tags != nil || req.Language != nil {
+		t.Errorf("expected no filters, got %+v", req)
+	}
+
+	req = buildSearchRequest(&SearchOptions{
+		TopK: 3, Query: "q", GroupID: "g1", Tags: "a,b",
+		Since: "2024-01-01T00:00:00Z", Until: "2024-02-01T00:00:00Z", MinScore: 0.5, Language: "ja",
+		IncludeDescendants: true, GroupBy: "group", GroupTopK: 4,
+	}, "/proj")
+	if req.ProjectID != "/proj" || *req.TopK != 3 || *req.GroupID != "g1" || len(req.Tags) != 2 {
+		t.Errorf("unexpected request: %+v", req)
+	}
+	if *req.Since != "2024-01-01T00:00:00Z" || *req.Until != "2024-02-01T00:00:00Z" || *req.MinScore != 0.5 {
+		t.Errorf("unexpected filters: since=%v until=%v minScore=%v", *req.Since, *req.Until, *req.MinScore)
+	}
+	if *req.Language != "ja" || !req.IncludeDescendants {
+		t.Errorf("unexpected language/descendants filter: %v/%v", *req.Language, req.IncludeDescendants)
+	}
+	if req.GroupBy != "group" || req.GroupTopK == nil || *req.GroupTopK != 4 {
+		t.Errorf("unexpected groupBy/groupTopK: %q/%v", req.GroupBy, req.GroupTopK)
+	}
+}
+
+// TestParseSearchFlags_GroupBy tests --group-by and --group-top-k
+func TestParseSearchFlags_GroupBy(t *testing.T) {
+	opts, err := parseSearchFlags([]string{"-p", "/proj", "--group-by", "group", "--group-top-k", "3", "q"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.GroupBy != "group" || opts.GroupTopK != 3 {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+
+	for _, args := range [][]string{
+		{"-p", "/proj", "--group-by", "tag", "q"},
+		{"-p", "/proj", "--group-top-k", "3", "q"},
+		{"-p", "/proj", "--group-by", "group", "--group-top-k", "-1", "q"},
+		{"-p", "/proj", "--group-by", "group", "--also-projects", "/other", "q"},
+	} {
+		if _, err := parseSearchFlags(args); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+}
+
+// TestFormatGroupedTextOutput tests the per-group headings of --group-by output
+func TestFormatGroupedTextOutput(t *testing.T) {
+	results := []service.SearchResult{
+		{ID: "id1", GroupID: "noisy", Text: "first", Score: 0.95},
+		{ID: "id2", GroupID: "noisy", Text: "second", Score: 0.94},
+		{ID: "id3", GroupID: "auth", Text: "third", Score: 0.8},
+	}
+
+	var buf bytes.Buffer
+	formatGroupedTextOutput(&buf, results)
+	output := buf.String()
+	noisy, auth := strings.Index(output, "== noisy =="), strings.Index(output, "== auth ==")
+	if noisy < 0 || auth < noisy || strings.Count(output, "== noisy ==") != 1 {
+		t.Errorf("expected one heading per group in order, got:\n%s", output)
+	}
+	if !strings.Contains(output[auth:], "[1]") {
+		t.Errorf("expected numbering to restart per group, got:\n%s", output)
+	}
+
+	buf.Reset()
+	formatGroupedTextOutput(&buf, nil)
+	if !strings.Contains(buf.String(), "No results found.") {
+		t.Errorf("expected empty message, got %q", buf.String())
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// Self-test modes for serve --self-test
+const (
+	selfTestWarn = "warn"
+	selfTestFail = "fail"
+)
+
+// selfTestTimeout bounds the whole startup self-test
+const selfTestTimeout = 30 * time.Second
+
+// runSelfTest runs the startup self-test and logs the latency of each step.
+// On failure it returns an error in fail mode and only logs a warning in warn mode.
+func runSelfTest(ctx context.Context, selfTest service.SelfTestService, mode string) error {
+	ctx, cancel := context.WithTimeout(ctx, selfTestTimeout)
+	defer cancel()
+
+	resp, err := selfTest.SelfTest(ctx)
+	if err != nil {
+		if mode == selfTestFail {
+			return fmt.Errorf("self-test failed: %w", err)
+		}
+		slog.Warn("self-test failed", "error", err)
+		return nil
+	}
+	slog.Info("self-test passed", "namespace", resp.Namespace, "dimension", resp.Dimension,
+		"embed", resp.Embed, "add", resp.Add, "search", resp.Search, "delete", resp.Delete)
+	return nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// stubSelfTestService returns a fixed self-test result
+type stubSelfTestService struct {
+	err error
+}
+
+func (s stubSelfTestService) SelfTest(ctx context.Context) (*service.SelfTestResponse, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &service.SelfTestResponse{Namespace: "openai:test:3", Dimension: 3}, nil
+}
+
+// TestRunSelfTest tests the warn and fail modes of serve --self-test
+func TestRunSelfTest(t *testing.T) {
+	ctx := context.Background()
+	if err := runSelfTest(ctx, stubSelfTestService{}, selfTestFail); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	failing := stubSelfTestService{err: service.ErrDimensionMismatch}
+	if err := runSelfTest(ctx, failing, selfTestWarn); err != nil {
+		t.Errorf("warn mode should not fail, got %v", err)
+	}
+	if err := runSelfTest(ctx, failing, selfTestFail); !errors.Is(err, service.ErrDimensionMismatch) {
+		t.Errorf("expected ErrDimensionMismatch in fail mode, got %v", err)
+	}
+}
+
+// TestParseFlags_SelfTest tests the --self-test option
+func TestParseFlags_SelfTest(t *testing.T) {
+	opts, err := parseFlags([]string{"serve", "--self-test", "fail"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.SelfTest != selfTestFail {
+		t.Errorf("expected self-test fail, got %q", opts.SelfTest)
+	}
+
+	if _, err := parseFlags([]string{"serve", "--self-test", "always"}); err == nil {
+		t.Error("expected error for an invalid self-test mode")
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/sysservice"
+)
+
+// serviceLogFile is the log file (relative to the data dir) used where the service
+// manager does not keep stderr (launchd, Windows services)
+const serviceLogFile = "mcp-memory.log"
+
+// ServiceOptions holds parsed service install|uninstall|start|stop command options
+type ServiceOptions struct {
+	Action     string // install, uninstall, start or stop
+	Name       string
+	System     bool // register system-wide (start at boot) instead of for the current user (start at login)
+	Host       string
+	Port       int
+	Binary     string
+	ConfigPath string
+	DataDir    string
+	Env        envFlag
+	User       string
+	Force      bool
+	DryRun     bool
+}
+
+// parseServiceFlags parses command line arguments for the service subcommand action
+func parseServiceFlags(action string, args []string) (*ServiceOptions, error) {
+	switch action {
+	case "install", "uninstall", "start", "stop":
+	default:
+		return nil, fmt.Errorf("unknown service subcommand: %s (must be install, uninstall, start or stop)", action)
+	}
+	fs := flag.NewFlagSet("service "+action, flag.ContinueOnError)
+	fs.SetOutput(io.Discard) // suppress default error output
+
+	opts := &ServiceOptions{Action: action}
+
+	// Long flags
+	fs.StringVar(&opts.Name, "name", sysservice.DefaultName, "Service name")
+	fs.BoolVar(&opts.System, "system", false, "System-wide service started at boot (default: per-user service started at login)")
+	if action == "install" {
+		fs.StringVar(&opts.Host, "host", "", "HTTP host passed to serve")
+		fs.IntVar(&opts.Port, "port", 0, "HTTP port passed to serve")
+		fs.StringVar(&opts.Binary, "binary", "", "mcp-memory binary path (default: this executable)")
+		fs.StringVar(&opts.ConfigPath, "config", "", "Config file passed to serve (default: the default config path)")
+		fs.StringVar(&opts.DataDir, "data-dir", "", "Data directory passed to serve (default: the data dir of the config)")
+		fs.Var(&opts.Env, "env", "Environment variable for the server (KEY=VALUE, repeatable)")
+		fs.StringVar(&opts.User, "user", "", "User running a --system service (default: SUDO_USER)")
+		fs.BoolVar(&opts.Force, "force", false, "Replace an existing service with a different definition")
+		fs.BoolVar(&opts.DryRun, "dry-run", false, "Print the service definition without registering it")
+
+		// Short flags
+		fs.StringVar(&opts.ConfigPath, "c", "", "Config file passed to serve")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if err := applyFlagEnv(fs); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+
+	// Validation
+	if opts.Name == "" {
+		return nil, fmt.Errorf("--name must not be empty")
+	}
+	if opts.Port < 0 || opts.Port > 65535 {
+		return nil, fmt.Errorf("invalid port: %d", opts.Port)
+	}
+	if opts.User != "" && !opts.System {
+		return nil, fmt.Errorf("--user requires --system")
+	}
+
+	return opts, nil
+}
+
+// runServiceCmd is the entry point for service command
+func runServiceCmd(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("service subcommand is required (install, uninstall, start or stop)")
+	}
+	opts, err := parseServiceFlags(args[0], args[1:])
+	if err != nil {
+		return err
+	}
+
+	manager, err := sysservice.New(opts.System)
+	if err != nil {
+		return err
+	}
+	if opts.Action == "install" {
+		return installService(manager, opts)
+	}
+
+	switch opts.Action {
+	case "uninstall":
+		err = manager.Uninstall(opts.Name)
+	case "start":
+		err = manager.Start(opts.Name)
+	case "stop":
+		err = manager.Stop(opts.Name)
+	}
+	if errors.Is(err, sysservice.ErrNotInstalled) {
+		return fmt.Errorf("%w (run service install first)", err)
+	}
+	if err != nil {
+		return err
+	}
+	switch opts.Action {
+	case "uninstall":
+		fmt.Fprintf(os.Stdout, "removed %s service (%s)\n", opts.Name, manager.Kind())
+	case "start":
+		fmt.Fprintf(os.Stdout, "started %s service\n", opts.Name)
+	case "stop":
+		fmt.Fprintf(os.Stdout, "stopped %s service (it starts again at the next %s)\n", opts.Name, serviceTrigger(opts.System))
+	}
+	return nil
+}
+
+// installService registers (or with --dry-run prints) the service definition
+func installService(manager *sysservice.Manager, opts *ServiceOptions) error {
+	def, err := buildServiceDefinition(opts, manager.Kind())
+	if err != nil {
+		return err
+	}
+
+	location := manager.Path(def.Name)
+	if location == "" {
+		location = "Windows service " + def.Name
+	}
+	if opts.DryRun {
+		data, err := manager.Render(def)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stdout, "# %s\n%s", location, data)
+		return nil
+	}
+
+	result, err := manager.Install(def, opts.Force)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "%s %s service (%s) in %s\n", result, def.Name, manager.Kind(), location)
+	fmt.Fprintf(os.Stdout, "the HTTP server starts at %s\n", serviceTrigger(opts.System))
+	return nil
+}
+
+// serviceTrigger describes when the service starts
+func serviceTrigger(system bool) string {
+	if system {
+		return "boot"
+	}
+	return "login"
+}
+
+// buildServiceDefinition builds the service running serve -t http with absolute paths,
+// since the service manager starts it from an unrelated working directory (and, for
+// --system, as another user whose default config and data dir differ)
+func buildServiceDefinition(opts *ServiceOptions, kind sysservice.Kind) (*sysservice.Definition, error) {
+	binary := opts.Binary
+	if binary == "" {
+		exe, err := os.Executable()
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate mcp-memory binary (use --binary): %w", err)
+		}
+		if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+			exe = resolved
+		}
+		binary = exe
+	}
+	binary, err := filepath.Abs(binary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	configPath := opts.ConfigPath
+	if configPath == "" {
+		if configPath, err = config.GetDefaultConfigPath(); err != nil {
+			return nil, err
+		}
+	}
+	if configPath, err = filepath.Abs(configPath); err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	manager, err := loadConfig(configPath, opts.DataDir)
+	if err != nil {
+		return nil, err
+	}
+	cfg := manager.GetConfig()
+	dataDir, err := instanceDataDir(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if dataDir, err = filepath.Abs(dataDir); err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	args := []string{"serve", "-t", "http"}
+	if opts.Host != "" {
+		args = append(args, "--host", opts.Host)
+	}
+	if opts.Port != 0 {
+		args = append(args, "--port", strconv.Itoa(opts.Port))
+	}
+	args = append(args, "-c", configPath, "--data-dir", dataDir)
+	// --profile / MCP_MEMORY_PROFILE given to service install carries over to serve
+	if profile := os.Getenv(config.EnvProfile); profile != "" {
+		args = append(args, "--profile", profile)
+	}
+	// launchd and Windows services discard stderr, so log to the data dir unless logging.file is set
+	if kind != sysservice.KindSystemd && cfg.Logging.File == "" {
+		args = append(args, "--log-file", filepath.Join(dataDir, serviceLogFile))
+	}
+
+	def := &sysservice.Definition{
+		Name:   opts.Name,
+		Binary: binary,
+		Args:   args,
+		Env:    opts.Env,
+	}
+	if opts.System {
+		def.User = opts.User
+		if def.User == "" {
+			// sudo mcp-memory service install --system runs the server as the invoking user
+			def.User = os.Getenv("SUDO_USER")
+		}
+	}
+	return def, nil
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package main
+
+// runAsService reports false: only Windows starts the server through a service control
+// manager API (systemd and launchd send SIGTERM, handled by setupSignalHandler)
+func runAsService(opts *Options) (handled bool, err error) {
+	return false, nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/sysservice"
+)
+
+func TestParseServiceFlags(t *testing.T) {
+	opts, err := parseServiceFlags("install", []string{"--system", "--port", "9000", "-c", "config.json", "--env", "A=1", "--env", "B=2", "--user", "alice", "--force"})
+	if err != nil {
+		t.Fatalf("parseServiceFlags() error = %v", err)
+	}
+	if !opts.System || opts.Port != 9000 || opts.ConfigPath != "config.json" || opts.User != "alice" || !opts.Force || opts.Name != sysservice.DefaultName {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+	if opts.Env["A"] != "1" || opts.Env["B"] != "2" {
+		t.Errorf("Env = %v", opts.Env)
+	}
+
+	if opts, err := parseServiceFlags("stop", []string{"--name", "memory-work"}); err != nil || opts.Name != "memory-work" {
+		t.Errorf("parseServiceFlags(stop) = %+v, %v", opts, err)
+	}
+
+	for _, tt := range []struct {
+		action string
+		args   []string
+	}{
+		{"restart", nil},
+		{"start", []string{"--port", "9000"}},
+		{"install", []string{"--port", "70000"}},
+		{"install", []string{"--user", "alice"}},
+		{"install", []string{"--name", ""}},
+		{"uninstall", []string{"extra"}},
+	} {
+		if _, err := parseServiceFlags(tt.action, tt.args); err == nil {
+			t.Errorf("expected error for %s %v", tt.action, tt.args)
+		}
+	}
+}
+
+func TestBuildServiceDefinition(t *testing.T) {
+	t.Setenv("MCP_MEMORY_PROFILE", "")
+	t.Setenv("SUDO_USER", "alice")
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"embedder": {"provider": "ollama", "model": "nomic-embed-text"}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	dataDir := filepath.Join(dir, "data")
+
+	opts := &ServiceOptions{Name: "mcp-memory", Port: 9000, Binary: "/usr/local/bin/mcp-memory", ConfigPath: configPath, DataDir: dataDir, Env: envFlag{"A": "1"}}
+	def, err := buildServiceDefinition(opts, sysservice.KindSystemd)
+	if err != nil {
+		t.Fatalf("buildServiceDefinition() error = %v", err)
+	}
+	wantArgs := []string{"serve", "-t", "http", "--port", "9000", "-c", configPath, "--data-dir", dataDir}
+	if !reflect.DeepEqual(def.Args, wantArgs) {
+		t.Errorf("Args = %q, want %q", def.Args, wantArgs)
+	}
+	if def.Binary != "/usr/local/bin/mcp-memory" || def.User != "" || def.Env["A"] != "1" {
+		t.Errorf("unexpected definition: %+v", def)
+	}
+
+	// launchdはstderrを残さないためデータディレクトリにログを書く
+	def, err = buildServiceDefinition(opts, sysservice.KindLaunchd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantArgs = append(wantArgs, "--log-file", filepath.Join(dataDir, serviceLogFile))
+	if !reflect.DeepEqual(def.Args, wantArgs) {
+		t.Errorf("Args = %q, want %q", def.Args, wantArgs)
+	}
+
+	// --systemはsudoしたユーザーで実行する
+	opts.System = true
+	if def, _ := buildServiceDefinition(opts, sysservice.KindSystemd); def.User != "alice" {
+		t.Errorf("User = %q, want alice", def.User)
+	}
+	opts.User = "mcp"
+	if def, _ := buildServiceDefinition(opts, sysservice.KindSystemd); def.User != "mcp" {
+		t.Errorf("User = %q, want mcp", def.User)
+	}
+}
+
+func TestBuildServiceDefinition_Profile(t *testing.T) {
+	t.Setenv("MCP_MEMORY_PROFILE", "work")
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+	configJSON := `{"embedder": {"provider": "ollama", "model": "nomic-embed-text"}, "profiles": {"work": {"embedder": {"provider": "openai", "model": "text-embedding-3-small"}}}}`
+	if err := os.WriteFile(configPath, []byte(configJSON), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	opts := &ServiceOptions{Name: "mcp-memory", Binary: "/usr/local/bin/mcp-memory", ConfigPath: configPath, DataDir: dir}
+	def, err := buildServiceDefinition(opts, sysservice.KindSystemd)
+	if err != nil {
+		t.Fatalf("buildServiceDefinition() error = %v", err)
+	}
+	if n := len(def.Args); n < 2 || def.Args[n-2] != "--profile" || def.Args[n-1] != "work" {
+		t.Errorf("expected --profile work at the end, got %q", def.Args)
+	}
+}
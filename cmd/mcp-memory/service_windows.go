@@ -0,0 +1,62 @@
+//go:build windows
+
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"golang.org/x/sys/windows/svc"
+)
+
+// runAsService runs serve under the Windows service control manager when the process was
+// started as a service (service install), so that a stop request shuts the server down
+// gracefully. handled is false when started from a console.
+func runAsService(opts *Options) (handled bool, err error) {
+	isService, err := svc.IsWindowsService()
+	if err != nil || !isService {
+		return false, nil
+	}
+	s := &windowsService{opts: opts}
+	if err := svc.Run("", s); err != nil {
+		return true, err
+	}
+	return true, s.err
+}
+
+// windowsService implements svc.Handler around runServe
+type windowsService struct {
+	opts *Options
+	err  error // error returned by runServe
+}
+
+func (s *windowsService) Execute(args []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.StartPending}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- runServe(ctx, s.opts) }()
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+
+	for {
+		select {
+		case s.err = <-done:
+			if s.err != nil {
+				// a service-specific exit code lets the recovery actions restart the service
+				slog.Error("server exited", "error", s.err)
+				return true, 1
+			}
+			return false, 0
+		case req := <-requests:
+			switch req.Cmd {
+			case svc.Interrogate:
+				status <- req.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending}
+				cancel()
+				s.err = <-done
+				return false, 0
+			}
+		}
+	}
+}
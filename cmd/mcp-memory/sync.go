@@ -0,0 +1,427 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/bootstrap"
+	"github.com/brbranch/embedding_mcp/internal/client"
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// syncTimeout bounds each export/import request to the remote server
+// (exports with embeddings of large projects take longer than client.DefaultTimeout)
+const syncTimeout = 10 * time.Minute
+
+// syncProject is a project to sync and its projectId on the remote server
+type syncProject struct {
+	Local  string
+	Remote string // "" means the local projectId (canonicalized)
+}
+
+// syncProjectFlag collects repeated -p values (LOCAL or LOCAL=REMOTE, comma-separated)
+type syncProjectFlag []syncProject
+
+func (p *syncProjectFlag) String() string {
+	var parts []string
+	for _, project := range *p {
+		if project.Remote == "" {
+			parts = append(parts, project.Local)
+		} else {
+			parts = append(parts, project.Local+"="+project.Remote)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func (p *syncProjectFlag) Set(value string) error {
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		local, remote, mapped := strings.Cut(part, "=")
+		if local == "" || (mapped && remote == "") {
+			return fmt.Errorf("invalid project: %q (expected LOCAL or LOCAL=REMOTE)", part)
+		}
+		*p = append(*p, syncProject{Local: local, Remote: remote})
+	}
+	return nil
+}
+
+// SyncOptions holds parsed sync command options
+type SyncOptions struct {
+	Projects   syncProjectFlag
+	Remote     string // remote server URL (--remote)
+	PullOnly   bool   // only copy records from the remote server
+	PushOnly   bool   // only copy records to the remote server
+	DryRun     bool
+	ConfigPath string
+}
+
+// parseSyncFlags parses command line arguments for sync command
+func parseSyncFlags(args []string) (*SyncOptions, error) {
+	fs := flag.NewFlagSet("sync", flag.ContinueOnError)
+	fs.SetOutput(io.Discard) // suppress default error output
+
+	opts := &SyncOptions{}
+
+	// Long flags
+	fs.Var(&opts.Projects, "project", "Project to sync: LOCAL or LOCAL=REMOTE (repeatable, comma-separated)")
+	fs.StringVar(&opts.Remote, "remote", "", "Server to sync with (URL or host:port, required)")
+	fs.BoolVar(&opts.PullOnly, "pull-only", false, "Only copy newer records from the remote server")
+	fs.BoolVar(&opts.PushOnly, "push-only", false, "Only copy newer records to the remote server")
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "Show what would be copied")
+	fs.StringVar(&opts.ConfigPath, "config", "", "Config file path")
+
+	// Short flags
+	fs.Var(&opts.Projects, "p", "Project to sync: LOCAL or LOCAL=REMOTE (repeatable, comma-separated)")
+	fs.StringVar(&opts.ConfigPath, "c", "", "Config file path")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if err := applyFlagEnv(fs); err != nil {
+		return nil, err
+	}
+
+	// Validation
+	if len(opts.Projects) == 0 {
+		return nil, fmt.Errorf("project ID is required (-p or --project)")
+	}
+	if opts.Remote == "" {
+		return nil, fmt.Errorf("--remote is required")
+	}
+	if opts.PullOnly && opts.PushOnly {
+		return nil, fmt.Errorf("--pull-only and --push-only are mutually exclusive")
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+
+	return opts, nil
+}
+
+// runSyncCmd is the entry point for sync command
+func runSyncCmd(args []string) error {
+	opts, err := parseSyncFlags(args)
+	if err != nil {
+		return err
+	}
+
+	remote, err := client.New(opts.Remote, client.WithHTTPClient(&http.Client{Timeout: syncTimeout}), client.WithToken(os.Getenv(client.EnvToken)))
+	if err != nil {
+		return err
+	}
+
+	// Initialize services
+	ctx := context.Background()
+	services, cleanup, err := bootstrap.Initialize(ctx, opts.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+	defer cleanup()
+
+	for _, project := range opts.Projects {
+		if err := executeSyncWithService(ctx, services.ExportService, remote, project, opts, os.Stdout); err != nil {
+			if errors.Is(err, client.ErrNotSupported) {
+				err = fmt.Errorf("%w (the remote server must be a version serving /export and /import over HTTP)", err)
+			}
+			return fmt.Errorf("sync of %s failed: %w", project.Local, err)
+		}
+	}
+	return nil
+}
+
+// syncRemote is the remote side of a sync (implemented by *client.Client)
+type syncRemote interface {
+	Export(ctx context.Context, w io.Writer, projectID string, includeEmbeddings bool) error
+	Import(ctx context.Context, r io.Reader, projectID string, mode service.ImportMode) (*service.ImportResponse, error)
+}
+
+// syncExport is a decoded export of one side
+type syncExport struct {
+	header  *service.ExportRecord
+	records []*service.ExportRecord
+}
+
+// executeSyncWithService exchanges the groups, globals and notes of one project with the remote
+// server. Records are matched by groupKey, key and note ID; the side whose record has the later
+// updatedAt (createdAt for notes never updated) wins unless both hold the same content, and records
+// missing on one side are copied.
+// Deletions are not propagated: a record deleted on one side is copied back from the other.
+func executeSyncWithService(ctx context.Context, exportService service.ExportService, remote syncRemote, project syncProject, opts *SyncOptions, w io.Writer) error {
+	local, err := exportForSync(func(buf io.Writer) error {
+		_, err := exportService.Export(ctx, buf, &service.ExportRequest{ProjectID: project.Local, IncludeEmbeddings: true})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("local export failed: %w", err)
+	}
+	localID := local.header.ProjectID
+	remoteID := project.Remote
+	if remoteID == "" {
+		remoteID = localID
+	}
+	theirs, err := exportForSync(func(buf io.Writer) error {
+		return remote.Export(ctx, buf, remoteID, true)
+	})
+	if err != nil {
+		return fmt.Errorf("remote export failed: %w", err)
+	}
+
+	pull, push, err := planSync(local.records, theirs.records)
+	if err != nil {
+		return err
+	}
+	if opts.PushOnly {
+		pull = nil
+	}
+	if opts.PullOnly {
+		push = nil
+	}
+
+	target := localID
+	if remoteID != localID {
+		target = localID + " <-> " + theirs.header.ProjectID
+	}
+	if opts.DryRun {
+		fmt.Fprintf(w, "%s: would pull %s; would push %s\n", target, describeSyncRecords(pull), describeSyncRecords(push))
+		for _, record := range pull {
+			fmt.Fprintf(w, "  pull %s\n", describeSyncRecord(record))
+		}
+		for _, record := range push {
+			fmt.Fprintf(w, "  push %s\n", describeSyncRecord(record))
+		}
+		return nil
+	}
+
+	if len(pull) > 0 {
+		data, err := encodeSyncRecords(theirs.header, pull)
+		if err != nil {
+			return err
+		}
+		resp, err := exportService.Import(ctx, bytes.NewReader(data), &service.ImportRequest{ProjectID: localID, Mode: service.ImportModeOverwrite})
+		if err != nil {
+			if resp != nil {
+				fmt.Fprintln(os.Stderr, describeImportFailure(resp))
+			}
+			return fmt.Errorf("pull failed: %w", err)
+		}
+	}
+	if len(push) > 0 {
+		data, err := encodeSyncRecords(local.header, push)
+		if err != nil {
+			return err
+		}
+		if _, err := remote.Import(ctx, bytes.NewReader(data), remoteID, service.ImportModeOverwrite); err != nil {
+			return fmt.Errorf("push failed: %w", err)
+		}
+	}
+	fmt.Fprintf(w, "%s: pulled %s; pushed %s\n", target, describeSyncRecords(pull), describeSyncRecords(push))
+	return nil
+}
+
+// exportForSync decodes the JSONL written by export
+func exportForSync(export func(w io.Writer) error) (*syncExport, error) {
+	var buf bytes.Buffer
+	if err := export(&buf); err != nil {
+		return nil, err
+	}
+	result := &syncExport{}
+	dec := json.NewDecoder(&buf)
+	for {
+		var record service.ExportRecord
+		if err := dec.Decode(&record); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid export: %w", err)
+		}
+		switch {
+		case record.Type == service.ExportRecordHeader:
+			result.header = &record
+		case record.Group != nil || record.Global != nil || record.Note != nil:
+			result.records = append(result.records, &record)
+		}
+	}
+	if result.header == nil {
+		return nil, fmt.Errorf("invalid export: missing header")
+	}
+	return result, nil
+}
+
+// planSync returns the records to copy from remote to local (pull) and from local to remote (push)
+func planSync(local, remote []*service.ExportRecord) (pull, push []*service.ExportRecord, err error) {
+	theirs := make(map[string]*service.ExportRecord, len(remote))
+	for _, record := range remote {
+		theirs[syncRecordKey(record)] = record
+	}
+	seen := make(map[string]bool, len(local))
+	for _, record := range local {
+		key := syncRecordKey(record)
+		seen[key] = true
+		other, ok := theirs[key]
+		if !ok {
+			push = append(push, record)
+			continue
+		}
+		// an overwritten record gets the import time as updatedAt, so equal content means already synced
+		same, err := sameSyncContent(record, other)
+		if err != nil {
+			return nil, nil, err
+		}
+		if same {
+			continue
+		}
+		ours, err := syncRecordTime(record)
+		if err != nil {
+			return nil, nil, fmt.Errorf("local %s: %w", syncRecordName(record), err)
+		}
+		their, err := syncRecordTime(other)
+		if err != nil {
+			return nil, nil, fmt.Errorf("remote %s: %w", syncRecordName(other), err)
+		}
+		switch {
+		case ours.After(their):
+			push = append(push, record)
+		case their.After(ours):
+			pull = append(pull, other)
+		}
+	}
+	for _, record := range remote {
+		if !seen[syncRecordKey(record)] {
+			pull = append(pull, record)
+		}
+	}
+	return pull, push, nil
+}
+
+// syncRecordKey identifies a record across instances (the same identity as import uses)
+func syncRecordKey(record *service.ExportRecord) string {
+	switch {
+	case record.Group != nil:
+		return "group:" + record.Group.GroupKey
+	case record.Global != nil:
+		return "global:" + record.Global.Key
+	default:
+		return "note:" + record.Note.ID
+	}
+}
+
+// sameSyncContent reports whether two records hold the same data, ignoring what differs between
+// instances by design: ids of groups and globals, projectId, updatedAt and the global history
+func sameSyncContent(a, b *service.ExportRecord) (bool, error) {
+	x, err := syncContent(a)
+	if err != nil {
+		return false, err
+	}
+	y, err := syncContent(b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(x, y), nil
+}
+
+// syncContent encodes the data of a record compared by sameSyncContent
+func syncContent(record *service.ExportRecord) ([]byte, error) {
+	switch {
+	case record.Group != nil:
+		group := *record.Group
+		group.ID, group.ProjectID, group.UpdatedAt = "", "", time.Time{}
+		group.CreatedAt = group.CreatedAt.Truncate(time.Second)
+		return json.Marshal(&group)
+	case record.Global != nil:
+		global := *record.Global
+		global.ID, global.ProjectID, global.UpdatedAt, global.UpdatedBy, global.History = "", "", nil, "", nil
+		return json.Marshal(&global)
+	default:
+		note := *record.Note
+		note.ProjectID, note.UpdatedAt = "", nil
+		if note.Tags == nil {
+			note.Tags = []string{}
+		}
+		return json.Marshal(&note)
+	}
+}
+
+// syncRecordTime returns when the record was last changed, truncated to seconds
+// (the SQLite store keeps seconds only, so finer times would never compare equal after a sync)
+func syncRecordTime(record *service.ExportRecord) (time.Time, error) {
+	var value *string
+	switch {
+	case record.Group != nil:
+		return record.Group.UpdatedAt.Truncate(time.Second), nil
+	case record.Global != nil:
+		value = record.Global.UpdatedAt
+	default:
+		value = record.Note.UpdatedAt
+		if value == nil {
+			value = record.Note.CreatedAt
+		}
+	}
+	if value == nil {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, *value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q: %w", *value, err)
+	}
+	return t.Truncate(time.Second), nil
+}
+
+// encodeSyncRecords writes header and records as export JSONL
+func encodeSyncRecords(header *service.ExportRecord, records []*service.ExportRecord) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	if err := enc.Encode(header); err != nil {
+		return nil, fmt.Errorf("failed to encode header: %w", err)
+	}
+	for _, record := range records {
+		if err := enc.Encode(record); err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", syncRecordName(record), err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// describeSyncRecords counts records by type ("2 notes, 0 groups, 1 globals")
+func describeSyncRecords(records []*service.ExportRecord) string {
+	var notes, groups, globals int
+	for _, record := range records {
+		switch {
+		case record.Group != nil:
+			groups++
+		case record.Global != nil:
+			globals++
+		default:
+			notes++
+		}
+	}
+	return fmt.Sprintf("%d notes, %d groups, %d globals", notes, groups, globals)
+}
+
+// describeSyncRecord describes a record for --dry-run
+func describeSyncRecord(record *service.ExportRecord) string {
+	if record.Note != nil && record.Note.Title != nil && *record.Note.Title != "" {
+		return fmt.Sprintf("%s (%s)", syncRecordName(record), *record.Note.Title)
+	}
+	return syncRecordName(record)
+}
+
+// syncRecordName identifies a record in messages ("note <id>", "group <groupKey>", "global <key>")
+func syncRecordName(record *service.ExportRecord) string {
+	switch {
+	case record.Group != nil:
+		return "group " + record.Group.GroupKey
+	case record.Global != nil:
+		return "global " + record.Global.Key
+	default:
+		return "note " + record.Note.ID
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/service"
+	"github.com/brbranch/embedding_mcp/internal/store"
+)
+
+func TestParseSyncFlags(t *testing.T) {
+	opts, err := parseSyncFlags([]string{"-p", "/a,/b=/srv/b", "--project", "/c", "--remote", "https://team-memory:8765", "--pull-only", "--dry-run"})
+	if err != nil {
+		t.Fatalf("parseSyncFlags() error = %v", err)
+	}
+	want := []syncProject{{Local: "/a"}, {Local: "/b", Remote: "/srv/b"}, {Local: "/c"}}
+	if len(opts.Projects) != len(want) {
+		t.Fatalf("Projects = %+v, want %+v", opts.Projects, want)
+	}
+	for i := range want {
+		if opts.Projects[i] != want[i] {
+			t.Errorf("Projects[%d] = %+v, want %+v", i, opts.Projects[i], want[i])
+		}
+	}
+	if opts.Remote != "https://team-memory:8765" || !opts.PullOnly || opts.PushOnly || !opts.DryRun {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+
+	for _, args := range [][]string{
+		{"--remote", "127.0.0.1:8765"},
+		{"-p", "/a"},
+		{"-p", "/a=", "--remote", "127.0.0.1:8765"},
+		{"-p", "=/a", "--remote", "127.0.0.1:8765"},
+		{"-p", "/a", "--remote", "127.0.0.1:8765", "--pull-only", "--push-only"},
+		{"-p", "/a", "--remote", "127.0.0.1:8765", "extra"},
+	} {
+		if _, err := parseSyncFlags(args); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+}
+
+// serviceRemote is a syncRemote backed by an ExportService (the other instance)
+type serviceRemote struct {
+	service.ExportService
+}
+
+func (r *serviceRemote) Export(ctx context.Context, w io.Writer, projectID string, includeEmbeddings bool) error {
+	_, err := r.ExportService.Export(ctx, w, &service.ExportRequest{ProjectID: projectID, IncludeEmbeddings: includeEmbeddings})
+	return err
+}
+
+func (r *serviceRemote) Import(ctx context.Context, rd io.Reader, projectID string, mode service.ImportMode) (*service.ImportResponse, error) {
+	return r.ExportService.Import(ctx, rd, &service.ImportRequest{ProjectID: projectID, Mode: mode})
+}
+
+// newSyncInstance returns the store and ExportService of one mcp-memory instance
+func newSyncInstance(t *testing.T, emb *countingEmbedder) (store.Store, service.ExportService) {
+	t.Helper()
+	st := store.NewMemoryStore()
+	if err := st.Initialize(context.Background(), "test:sync:3"); err != nil {
+		t.Fatal(err)
+	}
+	return st, service.NewExportService(emb, st, "test:sync:3")
+}
+
+func addSyncNote(t *testing.T, st store.Store, projectID, id, text, updatedAt string) {
+	t.Helper()
+	createdAt := "2024-01-01T00:00:00Z"
+	note := &model.Note{ID: id, ProjectID: projectID, GroupID: "design", Text: text, Tags: []string{}, CreatedAt: &createdAt}
+	if updatedAt != "" {
+		note.UpdatedAt = &updatedAt
+	}
+	if err := st.AddNote(context.Background(), note, []float32{1, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExecuteSync(t *testing.T) {
+	ctx := context.Background()
+	emb := &countingEmbedder{}
+	localStore, local := newSyncInstance(t, emb)
+	remoteStore, remoteService := newSyncInstance(t, emb)
+	remote := &serviceRemote{remoteService}
+
+	addSyncNote(t, localStore, "/laptop/app", "only-local", "local note", "")
+	addSyncNote(t, remoteStore, "/srv/app", "only-remote", "remote note", "")
+	addSyncNote(t, localStore, "/laptop/app", "local-newer", "edited on the laptop", "2024-03-02T00:00:00Z")
+	addSyncNote(t, remoteStore, "/srv/app", "local-newer", "old", "2024-03-01T00:00:00Z")
+	addSyncNote(t, localStore, "/laptop/app", "remote-newer", "old", "2024-03-01T00:00:00Z")
+	addSyncNote(t, remoteStore, "/srv/app", "remote-newer", "edited by the team", "2024-03-02T00:00:00Z")
+	addSyncNote(t, localStore, "/laptop/app", "same", "same", "2024-03-01T00:00:00Z")
+	addSyncNote(t, remoteStore, "/srv/app", "same", "same", "2024-03-01T00:00:00Z")
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	if err := remoteStore.AddGroup(ctx, &model.Group{ID: "g1", ProjectID: "/srv/app", GroupKey: "design", Title: "Design", CreatedAt: now, UpdatedAt: now}); err != nil {
+		t.Fatal(err)
+	}
+	older, newer := "2024-03-01T00:00:00Z", "2024-03-02T00:00:00Z"
+	localStore.UpsertGlobal(ctx, &model.GlobalConfig{ID: "gl1", ProjectID: "/laptop/app", Key: "global.memory.language", Value: "en", UpdatedAt: &newer})
+	remoteStore.UpsertGlobal(ctx, &model.GlobalConfig{ID: "gl2", ProjectID: "/srv/app", Key: "global.memory.language", Value: "ja", UpdatedAt: &older})
+
+	project := syncProject{Local: "/laptop/app", Remote: "/srv/app"}
+
+	// --dry-run は何も書き込まない
+	var out bytes.Buffer
+	if err := executeSyncWithService(ctx, local, remote, project, &SyncOptions{DryRun: true}, &out); err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	for _, want := range []string{
+		"/laptop/app <-> /srv/app: would pull 2 notes, 1 groups, 0 globals; would push 2 notes, 0 groups, 1 globals",
+		"  pull note remote-newer", "  pull group design", "  push note only-local", "  push global global.memory.language",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("dry run output does not contain %q:\n%s", want, out.String())
+		}
+	}
+	if _, err := localStore.Get(ctx, "only-remote"); err == nil {
+		t.Error("expected --dry-run not to pull")
+	}
+
+	out.Reset()
+	if err := executeSyncWithService(ctx, local, remote, project, &SyncOptions{}, &out); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "pulled 2 notes, 1 groups, 0 globals; pushed 2 notes, 0 groups, 1 globals") {
+		t.Errorf("unexpected output: %s", out.String())
+	}
+	for _, tt := range []struct {
+		st       store.Store
+		id, text string
+	}{
+		{localStore, "only-remote", "remote note"},
+		{localStore, "remote-newer", "edited by the team"},
+		{remoteStore, "only-local", "local note"},
+		{remoteStore, "local-newer", "edited on the laptop"},
+	} {
+		note, err := tt.st.Get(ctx, tt.id)
+		if err != nil || note.Text != tt.text {
+			t.Errorf("note %s = %+v, %v, want text %q", tt.id, note, err, tt.text)
+		}
+	}
+	if note, _ := remoteStore.Get(ctx, "only-local"); note == nil || note.ProjectID != "/srv/app" {
+		t.Errorf("expected the pushed note in the remote project, got %+v", note)
+	}
+	if global, _, _ := remoteStore.GetGlobal(ctx, "/srv/app", "global.memory.language"); global == nil || global.Value != "en" {
+		t.Errorf("expected the newer global to be pushed, got %+v", global)
+	}
+	if _, err := localStore.GetGroupByKey(ctx, "/laptop/app", "design"); err != nil {
+		t.Errorf("expected the group to be pulled: %v", err)
+	}
+	if emb.calls != 0 {
+		t.Errorf("expected embeddings to be reused, embedded %d texts", emb.calls)
+	}
+
+	// 同期済みなら何もしない（上書きした側のupdatedAtが新しくなっても送り返さない）
+	out.Reset()
+	if err := executeSyncWithService(ctx, local, remote, project, &SyncOptions{}, &out); err != nil {
+		t.Fatalf("second sync failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "pulled 0 notes, 0 groups, 0 globals; pushed 0 notes, 0 groups, 0 globals") {
+		t.Errorf("expected nothing to sync, got: %s", out.String())
+	}
+
+	// --pull-only は送らない
+	addSyncNote(t, localStore, "/laptop/app", "new-local", "new", "")
+	out.Reset()
+	if err := executeSyncWithService(ctx, local, remote, project, &SyncOptions{PullOnly: true}, &out); err != nil {
+		t.Fatalf("pull-only sync failed: %v", err)
+	}
+	if _, err := remoteStore.Get(ctx, "new-local"); err == nil {
+		t.Error("expected --pull-only not to push")
+	}
+}
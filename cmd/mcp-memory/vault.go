@@ -0,0 +1,556 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/service"
+	"github.com/brbranch/embedding_mcp/internal/vault"
+)
+
+// vaultMaxNotes is the maximum number of notes listed per sync
+const vaultMaxNotes = 100000
+
+// Conflict resolutions of vault command (--prefer)
+const (
+	vaultPreferVault  = "vault"
+	vaultPreferMemory = "memory"
+)
+
+// VaultOptions holds parsed vault command options
+type VaultOptions struct {
+	Dir        string // markdown vault directory
+	ProjectID  string
+	GroupID    string // only sync notes of this group (default: all groups)
+	Prefer     string // side that wins when both changed (default: report and skip)
+	NoDelete   bool   // restore files deleted from the vault instead of deleting their notes
+	Watch      bool
+	Interval   time.Duration
+	ConfigPath string
+	RemoteOptions
+}
+
+// parseVaultFlags parses command line arguments for vault command
+func parseVaultFlags(args []string) (*VaultOptions, error) {
+	fs := flag.NewFlagSet("vault", flag.ContinueOnError)
+	fs.SetOutput(io.Discard) // suppress default error output
+
+	opts := &VaultOptions{}
+
+	// Long flags
+	fs.StringVar(&opts.ProjectID, "project", "", "Project ID/path (required)")
+	fs.StringVar(&opts.GroupID, "group", "", "Only sync notes of this group (default: all groups)")
+	fs.StringVar(&opts.Prefer, "prefer", "", "Side that wins when a note and its file both changed: vault or memory (default: skip)")
+	fs.BoolVar(&opts.NoDelete, "no-delete", false, "Restore files deleted from the vault instead of deleting their notes")
+	fs.BoolVar(&opts.Watch, "watch", false, "Keep syncing until interrupted")
+	fs.DurationVar(&opts.Interval, "interval", defaultWatchInterval, "Polling interval with --watch")
+	fs.StringVar(&opts.ConfigPath, "config", "", "Config file path")
+	opts.registerFlags(fs)
+
+	// Short flags
+	fs.StringVar(&opts.ProjectID, "p", "", "Project ID/path (required)")
+	fs.StringVar(&opts.GroupID, "g", "", "Only sync notes of this group")
+	fs.StringVar(&opts.ConfigPath, "c", "", "Config file path")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if err := applyFlagEnv(fs); err != nil {
+		return nil, err
+	}
+
+	switch fs.NArg() {
+	case 0:
+		return nil, fmt.Errorf("vault directory is required")
+	case 1:
+		opts.Dir = fs.Arg(0)
+	default:
+		return nil, fmt.Errorf("unexpected argument: %s", fs.Arg(1))
+	}
+
+	// Validation
+	if opts.ProjectID == "" {
+		return nil, fmt.Errorf("project ID is required (-p or --project)")
+	}
+	if opts.GroupID != "" {
+		if err := service.ValidateGroupID(opts.GroupID); err != nil {
+			return nil, fmt.Errorf("invalid group: %w", err)
+		}
+	}
+	switch opts.Prefer {
+	case "", vaultPreferVault, vaultPreferMemory:
+	default:
+		return nil, fmt.Errorf("invalid prefer: %s (must be vault or memory)", opts.Prefer)
+	}
+	if opts.Interval <= 0 {
+		return nil, fmt.Errorf("--interval must be positive")
+	}
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	return opts, nil
+}
+
+// runVaultCmd is the entry point for vault command
+func runVaultCmd(args []string) error {
+	opts, err := parseVaultFlags(args)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := setupSignalHandler()
+	defer cancel()
+
+	noteService, cleanup, err := openNoteService(ctx, opts.ConfigPath, opts.RemoteOptions)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	s, err := newVaultSyncer(opts, noteService, slog.Default())
+	if err != nil {
+		return err
+	}
+	if opts.Watch {
+		return s.run(ctx)
+	}
+	result, err := s.sync(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "written %d, applied %d, created %d, deleted notes %d, deleted files %d, conflicts %d\n",
+		result.Written, result.Applied, result.Created, result.DeletedNotes, result.DeletedFiles, result.Conflicts)
+	if result.Conflicts > 0 {
+		fmt.Fprintln(os.Stdout, "conflicting notes were skipped (rerun with --prefer vault or --prefer memory)")
+	}
+	return nil
+}
+
+// vaultSyncResult counts the changes of a sync
+type vaultSyncResult struct {
+	Written      int // files written from notes
+	Applied      int // notes updated from edited files
+	Created      int // notes added from new files
+	DeletedNotes int // notes whose files were deleted
+	DeletedFiles int // files whose notes were deleted
+	Conflicts    int // notes skipped because both sides changed
+}
+
+func (r *vaultSyncResult) changed() bool {
+	return r.Written+r.Applied+r.Created+r.DeletedNotes+r.DeletedFiles+r.Conflicts > 0
+}
+
+// vaultSyncer keeps the notes of a project in sync with a directory of markdown files.
+// Notes are written as <group>/<title>.md with the note ID in the front matter, and the
+// state file records the note's updatedAt and the file's hash at the last sync so that
+// each side's changes can be told apart.
+type vaultSyncer struct {
+	opts      *VaultOptions
+	notes     service.NoteService
+	log       *slog.Logger
+	dir       string // absolute vault directory
+	projectID string // canonical project ID recorded in the state file
+}
+
+// newVaultSyncer creates a vaultSyncer, creating the vault directory if needed
+func newVaultSyncer(opts *VaultOptions, noteService service.NoteService, log *slog.Logger) (*vaultSyncer, error) {
+	dir, err := filepath.Abs(opts.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve vault directory: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create vault directory: %w", err)
+	}
+	projectID, err := config.CanonicalizeProjectID(opts.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to canonicalize projectId: %w", err)
+	}
+	return &vaultSyncer{opts: opts, notes: noteService, log: log, dir: dir, projectID: projectID}, nil
+}
+
+// run syncs until ctx is canceled
+func (s *vaultSyncer) run(ctx context.Context) error {
+	if _, err := s.sync(ctx); err != nil {
+		return err
+	}
+	s.log.Info("watching vault", "dir", s.dir, "interval", s.opts.Interval)
+
+	ticker := time.NewTicker(s.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if _, err := s.sync(ctx); err != nil {
+				s.log.Error("vault sync failed", "error", err)
+			}
+		}
+	}
+}
+
+// vaultRun holds the state of a single sync
+type vaultRun struct {
+	*vaultSyncer
+	state  *vault.State
+	taken  map[string]bool // file paths in use (including files written during the run)
+	result vaultSyncResult
+}
+
+// sync compares the notes, the files and the last synced state, and applies each side's changes
+// to the other. Per-note errors are logged and the note is retried on the next sync.
+func (s *vaultSyncer) sync(ctx context.Context) (*vaultSyncResult, error) {
+	state, err := vault.LoadState(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	if state.ProjectID != "" && state.ProjectID != s.projectID {
+		return nil, fmt.Errorf("vault %s is synced with project %s", s.dir, state.ProjectID)
+	}
+	state.ProjectID = s.projectID
+
+	notes, err := s.listNotes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	files, err := vault.Collect(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &vaultRun{vaultSyncer: s, state: state, taken: make(map[string]bool, len(files))}
+
+	// Files carrying a note ID. A copied file with the same ID is treated as a new note,
+	// keeping the one at the synced path.
+	byID := make(map[string]*vault.File)
+	var newFiles []*vault.File
+	for i := range files {
+		f := &files[i]
+		r.taken[f.Path] = true
+		id := vault.Parse(f.Data).ID
+		if id == "" {
+			newFiles = append(newFiles, f)
+			continue
+		}
+		if prev, ok := byID[id]; ok {
+			if f.Path == state.Notes[id].Path {
+				byID[id], f = f, prev
+			}
+			newFiles = append(newFiles, f)
+			continue
+		}
+		byID[id] = f
+	}
+
+	seen := make(map[string]bool, len(notes))
+	for i := range notes {
+		note := &notes[i]
+		seen[note.ID] = true
+		if err := r.syncNote(ctx, note, byID[note.ID]); err != nil {
+			s.log.Error("vault sync failed", "id", note.ID, "error", err)
+		}
+	}
+
+	// Files of unknown notes (written by hand or copied from elsewhere)
+	for id, f := range byID {
+		if !seen[id] {
+			if _, tracked := state.Notes[id]; !tracked {
+				newFiles = append(newFiles, f)
+			}
+		}
+	}
+
+	// Notes deleted from the memory (or moved out of --group)
+	for id, entry := range state.Notes {
+		if seen[id] {
+			continue
+		}
+		if err := r.syncDeletedNote(ctx, id, entry, byID[id]); err != nil {
+			s.log.Error("vault sync failed", "id", id, "error", err)
+		}
+	}
+	slices.SortFunc(newFiles, func(a, b *vault.File) int { return strings.Compare(a.Path, b.Path) })
+	for _, f := range newFiles {
+		if err := r.addFile(ctx, f); err != nil {
+			s.log.Error("vault sync failed", "file", f.Path, "error", err)
+		}
+	}
+
+	if err := state.Save(s.dir); err != nil {
+		return nil, err
+	}
+	if r.result.changed() {
+		s.log.Info("vault synced", "written", r.result.Written, "applied", r.result.Applied, "created", r.result.Created,
+			"deletedNotes", r.result.DeletedNotes, "deletedFiles", r.result.DeletedFiles, "conflicts", r.result.Conflicts)
+	}
+	return &r.result, nil
+}
+
+// listNotes returns the notes to sync (chunks of ingested documents are left to ingest/watch)
+func (s *vaultSyncer) listNotes(ctx context.Context) ([]service.ListRecentItem, error) {
+	limit := vaultMaxNotes
+	req := &service.ListRecentRequest{ProjectID: s.opts.ProjectID, Limit: &limit}
+	if s.opts.GroupID != "" {
+		req.GroupID = &s.opts.GroupID
+	}
+	resp, err := s.notes.ListRecent(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notes: %w", err)
+	}
+	if len(resp.Items) == vaultMaxNotes {
+		s.log.Warn("too many notes; only the most recent ones are synced", "limit", vaultMaxNotes)
+	}
+	notes := make([]service.ListRecentItem, 0, len(resp.Items))
+	for _, item := range resp.Items {
+		if item.ParentID == nil && item.ChunkIndex == nil {
+			notes = append(notes, item)
+		}
+	}
+	return notes, nil
+}
+
+// syncNote syncs a note with its file (nil if the vault has no file for it)
+func (r *vaultRun) syncNote(ctx context.Context, note *service.ListRecentItem, f *vault.File) error {
+	entry, tracked := r.state.Notes[note.ID]
+	switch {
+	case !tracked && f == nil:
+		// New note
+		return r.writeNote(note, "")
+	case !tracked:
+		// A file exists but was never synced (e.g. the state file was removed)
+		if sameNote(note, vault.Parse(f.Data)) {
+			r.track(note.ID, f.Path, note.UpdatedAt, f.Data)
+			return nil
+		}
+		return r.resolve(ctx, note, f)
+	case f == nil:
+		// File deleted from the vault
+		if r.opts.NoDelete || note.UpdatedAt != entry.UpdatedAt {
+			return r.writeNote(note, entry.Path)
+		}
+		if err := r.notes.Delete(ctx, note.ID); err != nil {
+			return err
+		}
+		delete(r.state.Notes, note.ID)
+		r.result.DeletedNotes++
+		return nil
+	}
+
+	noteChanged := note.UpdatedAt != entry.UpdatedAt
+	fileChanged := vault.Hash(f.Data) != entry.Hash
+	switch {
+	case noteChanged && fileChanged:
+		return r.resolve(ctx, note, f)
+	case noteChanged:
+		return r.writeNote(note, f.Path)
+	case fileChanged:
+		return r.applyFile(ctx, note, f)
+	}
+	if f.Path != entry.Path {
+		// Moved or renamed in the vault
+		entry.Path = f.Path
+		r.state.Notes[note.ID] = entry
+	}
+	return nil
+}
+
+// syncDeletedNote handles a synced note that is no longer listed
+func (r *vaultRun) syncDeletedNote(ctx context.Context, id string, entry vault.Entry, f *vault.File) error {
+	switch {
+	case f == nil:
+	case vault.Hash(f.Data) == entry.Hash:
+		if err := vault.RemoveFile(r.dir, f.Path); err != nil {
+			return err
+		}
+		r.result.DeletedFiles++
+	case r.opts.Prefer == vaultPreferVault:
+		// Edited in the vault: add it back as a new note
+		delete(r.state.Notes, id)
+		return r.addFile(ctx, f)
+	case r.opts.Prefer == vaultPreferMemory:
+		if err := vault.RemoveFile(r.dir, f.Path); err != nil {
+			return err
+		}
+		r.result.DeletedFiles++
+	default:
+		r.result.Conflicts++
+		r.log.Warn("note was deleted but its file was edited", "id", id, "file", f.Path)
+		return nil
+	}
+	delete(r.state.Notes, id)
+	return nil
+}
+
+// resolve handles a note and a file that both changed since the last sync
+func (r *vaultRun) resolve(ctx context.Context, note *service.ListRecentItem, f *vault.File) error {
+	switch r.opts.Prefer {
+	case vaultPreferVault:
+		return r.applyFile(ctx, note, f)
+	case vaultPreferMemory:
+		return r.writeNote(note, f.Path)
+	}
+	r.result.Conflicts++
+	r.log.Warn("note and file both changed", "id", note.ID, "file", f.Path)
+	return nil
+}
+
+// writeNote writes note to rel (a new path under its group if empty)
+func (r *vaultRun) writeNote(note *service.ListRecentItem, rel string) error {
+	n := vaultNote(note)
+	if rel == "" {
+		rel = vault.NotePath(n, func(p string) bool { return r.taken[p] })
+	}
+	data := vault.Render(n)
+	if err := vault.WriteFile(r.dir, rel, data); err != nil {
+		return err
+	}
+	r.taken[rel] = true
+	r.track(note.ID, rel, note.UpdatedAt, data)
+	r.result.Written++
+	return nil
+}
+
+// applyFile updates note with the edited file (the text is re-embedded by Update)
+func (r *vaultRun) applyFile(ctx context.Context, note *service.ListRecentItem, f *vault.File) error {
+	edited := vault.Parse(f.Data)
+	var patch service.NotePatch
+	changed := false
+	if edited.Text != strings.TrimSpace(note.Text) {
+		patch.Text = &edited.Text
+		changed = true
+	}
+	if edited.Title != derefString(note.Title) {
+		patch.Title = &edited.Title
+		changed = true
+	}
+	if !slices.Equal(edited.Tags, note.Tags) && (len(edited.Tags) > 0 || len(note.Tags) > 0) {
+		tags := edited.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		patch.Tags = &tags
+		changed = true
+	}
+	if edited.Source != derefString(note.Source) {
+		patch.Source = &edited.Source
+		changed = true
+	}
+	if edited.GroupID != "" && edited.GroupID != note.GroupID {
+		patch.GroupID = &edited.GroupID
+		changed = true
+	}
+
+	updatedAt := note.UpdatedAt
+	if changed {
+		if err := r.notes.Update(ctx, &service.UpdateRequest{ID: note.ID, Patch: patch, IfUpdatedAt: &note.UpdatedAt}); err != nil {
+			return err
+		}
+		got, err := r.notes.Get(ctx, note.ID)
+		if err != nil {
+			return err
+		}
+		updatedAt = got.UpdatedAt
+		r.result.Applied++
+	}
+	r.track(note.ID, f.Path, updatedAt, f.Data)
+	return nil
+}
+
+// addFile adds a file without a known note ID as a new note and writes the ID back to the file
+func (r *vaultRun) addFile(ctx context.Context, f *vault.File) error {
+	n := vault.Parse(f.Data)
+	if n.Text == "" {
+		return nil // empty file (e.g. just created in the editor); added once it has text
+	}
+	if n.Title == "" {
+		n.Title = strings.TrimSuffix(path.Base(f.Path), path.Ext(f.Path))
+	}
+	if n.GroupID == "" {
+		n.GroupID = r.opts.GroupID
+		if dir, _, ok := strings.Cut(f.Path, "/"); ok && service.ValidateGroupID(dir) == nil {
+			n.GroupID = dir
+		}
+	}
+
+	req := &service.AddNoteRequest{
+		ProjectID: r.opts.ProjectID,
+		GroupID:   n.GroupID,
+		Title:     &n.Title,
+		Text:      n.Text,
+		Tags:      n.Tags,
+	}
+	if n.Source != "" {
+		req.Source = &n.Source
+	}
+	if n.CreatedAt != "" {
+		req.CreatedAt = &n.CreatedAt
+	}
+	resp, err := r.notes.AddNote(ctx, req)
+	if err != nil {
+		return err
+	}
+	got, err := r.notes.Get(ctx, resp.ID)
+	if err != nil {
+		return err
+	}
+	note := &service.ListRecentItem{
+		ID:        got.ID,
+		GroupID:   got.GroupID,
+		Title:     got.Title,
+		Text:      n.Text, // keep the file's formatting
+		Tags:      got.Tags,
+		Source:    got.Source,
+		CreatedAt: got.CreatedAt,
+		UpdatedAt: got.UpdatedAt,
+	}
+	if err := r.writeNote(note, f.Path); err != nil {
+		return err
+	}
+	r.result.Written--
+	r.result.Created++
+	return nil
+}
+
+// track records the synced state of a note
+func (r *vaultRun) track(id, rel, updatedAt string, data []byte) {
+	r.state.Notes[id] = vault.Entry{Path: rel, UpdatedAt: updatedAt, Hash: vault.Hash(data)}
+}
+
+// vaultNote converts a listed note to its markdown representation
+func vaultNote(note *service.ListRecentItem) *vault.Note {
+	return &vault.Note{
+		ID:        note.ID,
+		Title:     derefString(note.Title),
+		GroupID:   note.GroupID,
+		Tags:      note.Tags,
+		Source:    derefString(note.Source),
+		CreatedAt: note.CreatedAt,
+		Text:      note.Text,
+	}
+}
+
+// sameNote reports whether the file has the same content as the note
+func sameNote(note *service.ListRecentItem, n *vault.Note) bool {
+	return n.Text == strings.TrimSpace(note.Text) && n.Title == derefString(note.Title) &&
+		(n.GroupID == "" || n.GroupID == note.GroupID) && slices.Equal(n.Tags, note.Tags) &&
+		n.Source == derefString(note.Source)
+}
+
+// derefString returns the pointed string, or "" for nil
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
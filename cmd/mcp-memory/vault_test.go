@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/service"
+	"github.com/brbranch/embedding_mcp/internal/store"
+	"github.com/brbranch/embedding_mcp/internal/vault"
+)
+
+// TestParseVaultFlags tests vault flag parsing and validation
+func TestParseVaultFlags(t *testing.T) {
+	opts, err := parseVaultFlags([]string{"-p", "/test/project", "-g", "design", "--prefer", "vault", "--watch", "--interval", "5s", "notes"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.Dir != "notes" || opts.GroupID != "design" || opts.Prefer != vaultPreferVault || !opts.Watch || opts.Interval != 5*time.Second {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+
+	for _, args := range [][]string{
+		{"-p", "/test/project"},
+		{"notes"},
+		{"-p", "/test/project", "a", "b"},
+		{"-p", "/test/project", "--prefer", "newest", "notes"},
+		{"-p", "/test/project", "-g", "bad group!", "notes"},
+		{"-p", "/test/project", "--interval", "0s", "notes"},
+	} {
+		if _, err := parseVaultFlags(args); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+}
+
+// countingEmbedder returns a fixed vector and counts the embedded texts
+type countingEmbedder struct{ calls int }
+
+func (e *countingEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	e.calls++
+	return []float32{1, 0, 0}, nil
+}
+
+func (e *countingEmbedder) GetDimension() int { return 3 }
+
+func newTestVault(t *testing.T, opts *VaultOptions) (*vaultSyncer, service.NoteService, *countingEmbedder) {
+	t.Helper()
+	ctx := context.Background()
+	st := store.NewMemoryStore()
+	if err := st.Initialize(ctx, "test:vault:3"); err != nil {
+		t.Fatal(err)
+	}
+	emb := &countingEmbedder{}
+	notes := service.NewNoteService(emb, st, "test:vault:3")
+	opts.Dir = t.TempDir()
+	opts.ProjectID = "/test/project"
+	s, err := newVaultSyncer(opts, notes, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, notes, emb
+}
+
+func syncVault(t *testing.T, s *vaultSyncer) *vaultSyncResult {
+	t.Helper()
+	result, err := s.sync(context.Background())
+	if err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	return result
+}
+
+func readVaultFile(t *testing.T, s *vaultSyncer, rel string) *vault.Note {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(s.dir, filepath.FromSlash(rel)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return vault.Parse(data)
+}
+
+func writeVaultFile(t *testing.T, s *vaultSyncer, rel, content string) {
+	t.Helper()
+	if err := vault.WriteFile(s.dir, rel, []byte(content)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestVaultSync tests both directions: notes written as files and edited files applied to notes
+func TestVaultSync(t *testing.T) {
+	ctx := context.Background()
+	s, notes, emb := newTestVault(t, &VaultOptions{})
+
+	title := "Auth design"
+	added, err := notes.AddNote(ctx, &service.AddNoteRequest{ProjectID: "/test/project", GroupID: "design", Title: &title, Text: "Use JWT.", Tags: []string{"auth"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Notes are written as <group>/<title>.md
+	if r := syncVault(t, s); r.Written != 1 {
+		t.Fatalf("expected 1 written file, got %+v", r)
+	}
+	if n := readVaultFile(t, s, "design/Auth design.md"); n.ID != added.ID || n.Text != "Use JWT." || n.GroupID != "design" {
+		t.Errorf("unexpected file: %+v", n)
+	}
+	if r := syncVault(t, s); r.changed() {
+		t.Errorf("expected no changes, got %+v", r)
+	}
+
+	// Editing the file updates and re-embeds the note
+	embedded := emb.calls
+	writeVaultFile(t, s, "design/Auth design.md", "---\nid: "+added.ID+"\ntitle: Auth design\ngroup: design\ntags: [auth, jwt]\n---\n\nUse JWT with refresh tokens.\n")
+	if r := syncVault(t, s); r.Applied != 1 || r.Written != 0 {
+		t.Fatalf("expected 1 applied file, got %+v", r)
+	}
+	got, err := notes.Get(ctx, added.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Text != "Use JWT with refresh tokens." || strings.Join(got.Tags, ",") != "auth,jwt" {
+		t.Errorf("unexpected note: %+v", got)
+	}
+	if emb.calls == embedded {
+		t.Error("expected the edited text to be re-embedded")
+	}
+	if r := syncVault(t, s); r.changed() {
+		t.Errorf("expected no changes after applying the file, got %+v", r)
+	}
+
+	// Updating the note rewrites the file
+	time.Sleep(2 * time.Millisecond)
+	text := "Use JWT with short-lived refresh tokens."
+	if err := notes.Update(ctx, &service.UpdateRequest{ID: added.ID, Patch: service.NotePatch{Text: &text}}); err != nil {
+		t.Fatal(err)
+	}
+	if r := syncVault(t, s); r.Written != 1 {
+		t.Fatalf("expected 1 written file, got %+v", r)
+	}
+	if n := readVaultFile(t, s, "design/Auth design.md"); n.Text != text {
+		t.Errorf("unexpected file text: %q", n.Text)
+	}
+
+	// A new file becomes a note of the directory's group, and the ID is written back
+	writeVaultFile(t, s, "design/Session.md", "Sessions expire after 30 minutes.\n")
+	if r := syncVault(t, s); r.Created != 1 || r.Written != 0 {
+		t.Fatalf("expected 1 created note, got %+v", r)
+	}
+	created := readVaultFile(t, s, "design/Session.md")
+	got, err = notes.Get(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("expected the note to be added: %v", err)
+	}
+	if got.GroupID != "design" || derefString(got.Title) != "Session" || got.Text != "Sessions expire after 30 minutes." {
+		t.Errorf("unexpected note: %+v", got)
+	}
+
+	// Deleting the file deletes the note, and deleting the note deletes the file
+	if err := os.Remove(filepath.Join(s.dir, "design", "Session.md")); err != nil {
+		t.Fatal(err)
+	}
+	if err := notes.Delete(ctx, added.ID); err != nil {
+		t.Fatal(err)
+	}
+	if r := syncVault(t, s); r.DeletedNotes != 1 || r.DeletedFiles != 1 {
+		t.Fatalf("expected 1 deleted note and file, got %+v", r)
+	}
+	if _, err := notes.Get(ctx, created.ID); err == nil {
+		t.Error("expected the note of the deleted file to be deleted")
+	}
+	if _, err := os.Stat(filepath.Join(s.dir, "design", "Auth design.md")); !os.IsNotExist(err) {
+		t.Errorf("expected the file of the deleted note to be removed, got %v", err)
+	}
+}
+
+// TestVaultSync_Conflict tests that a note changed on both sides is skipped unless --prefer is given
+func TestVaultSync_Conflict(t *testing.T) {
+	ctx := context.Background()
+	s, notes, _ := newTestVault(t, &VaultOptions{})
+
+	title := "Auth"
+	added, err := notes.AddNote(ctx, &service.AddNoteRequest{ProjectID: "/test/project", GroupID: "design", Title: &title, Text: "v1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	syncVault(t, s)
+
+	time.Sleep(2 * time.Millisecond)
+	text := "v2 from the agent"
+	if err := notes.Update(ctx, &service.UpdateRequest{ID: added.ID, Patch: service.NotePatch{Text: &text}}); err != nil {
+		t.Fatal(err)
+	}
+	writeVaultFile(t, s, "design/Auth.md", "---\nid: "+added.ID+"\ntitle: Auth\n---\nv2 from a human\n")
+
+	if r := syncVault(t, s); r.Conflicts != 1 || r.Applied+r.Written != 0 {
+		t.Fatalf("expected 1 conflict, got %+v", r)
+	}
+	if n := readVaultFile(t, s, "design/Auth.md"); n.Text != "v2 from a human" {
+		t.Errorf("expected the file to be kept, got %q", n.Text)
+	}
+
+	s.opts.Prefer = vaultPreferVault
+	if r := syncVault(t, s); r.Applied != 1 {
+		t.Fatalf("expected the file to win, got %+v", r)
+	}
+	if got, _ := notes.Get(ctx, added.ID); got.Text != "v2 from a human" {
+		t.Errorf("unexpected note text: %q", got.Text)
+	}
+}
+
+// TestVaultSync_NoDelete tests that a deleted file is restored with --no-delete
+func TestVaultSync_NoDelete(t *testing.T) {
+	ctx := context.Background()
+	s, notes, _ := newTestVault(t, &VaultOptions{NoDelete: true})
+
+	added, err := notes.AddNote(ctx, &service.AddNoteRequest{ProjectID: "/test/project", GroupID: "design", Text: "keep me"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	syncVault(t, s)
+	rel := loadVaultState(t, s).Notes[added.ID].Path
+	if err := os.Remove(filepath.Join(s.dir, filepath.FromSlash(rel))); err != nil {
+		t.Fatal(err)
+	}
+
+	if r := syncVault(t, s); r.Written != 1 || r.DeletedNotes != 0 {
+		t.Fatalf("expected the file to be restored, got %+v", r)
+	}
+	if _, err := notes.Get(ctx, added.ID); err != nil {
+		t.Errorf("expected the note to be kept: %v", err)
+	}
+}
+
+// TestVaultSync_OtherProject tests that a vault synced with another project is rejected
+func TestVaultSync_OtherProject(t *testing.T) {
+	s, _, _ := newTestVault(t, &VaultOptions{})
+	syncVault(t, s)
+
+	other := *s
+	other.projectID = "/test/other"
+	if _, err := other.sync(context.Background()); err == nil {
+		t.Error("expected an error for a vault of another project")
+	}
+}
+
+func loadVaultState(t *testing.T, s *vaultSyncer) *vault.State {
+	t.Helper()
+	st, err := vault.LoadState(s.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return st
+}
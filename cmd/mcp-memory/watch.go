@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/bootstrap"
+	"github.com/brbranch/embedding_mcp/internal/ingest"
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// defaultWatchInterval is the default polling interval of watch command
+const defaultWatchInterval = 2 * time.Second
+
+// WatchOptions holds parsed watch command options
+type WatchOptions struct {
+	IngestOptions
+	Interval time.Duration
+	NoPrune  bool
+}
+
+// parseWatchFlags parses command line arguments for watch command
+func parseWatchFlags(args []string) (*WatchOptions, error) {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	fs.SetOutput(io.Discard) // suppress default error output
+
+	opts := &WatchOptions{}
+	registerIngestFlags(fs, &opts.IngestOptions)
+	fs.DurationVar(&opts.Interval, "interval", defaultWatchInterval, "Polling interval")
+	fs.BoolVar(&opts.NoPrune, "no-prune", false, "Keep notes of files removed before watch started")
+
+	if err := parseIngestArgs(fs, &opts.IngestOptions, args); err != nil {
+		return nil, err
+	}
+	if opts.Interval <= 0 {
+		return nil, fmt.Errorf("--interval must be positive")
+	}
+	return opts, nil
+}
+
+// runWatchCmd is the entry point for watch command
+func runWatchCmd(args []string) error {
+	opts, err := parseWatchFlags(args)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := setupSignalHandler()
+	defer cancel()
+
+	// Initialize services
+	services, cleanup, err := bootstrap.Initialize(ctx, opts.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+	defer cleanup()
+
+	w, err := newWatcher(opts, services.SyncService, slog.Default())
+	if err != nil {
+		return err
+	}
+	return w.run(ctx)
+}
+
+// fileState is the last synced state of a watched file
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// watcher polls the watched paths and keeps notes in sync with the files
+type watcher struct {
+	opts  *WatchOptions
+	sync  service.SyncService
+	log   *slog.Logger
+	roots []string             // watched roots relative to base (slash separated)
+	state map[string]fileState // keyed by RelPath
+}
+
+// newWatcher creates a watcher for the options
+func newWatcher(opts *WatchOptions, syncService service.SyncService, log *slog.Logger) (*watcher, error) {
+	base := opts.Base
+	if base == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get working directory: %w", err)
+		}
+		base = wd
+	}
+	base, err := filepath.Abs(base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve base directory: %w", err)
+	}
+
+	w := &watcher{
+		opts:  opts,
+		sync:  syncService,
+		log:   log,
+		state: make(map[string]fileState),
+	}
+	for _, p := range opts.Paths {
+		root, err := filepath.Abs(ingest.Root(p))
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", p, err)
+		}
+		rel, err := filepath.Rel(base, root)
+		if err != nil {
+			rel = root
+		}
+		w.roots = append(w.roots, filepath.ToSlash(rel))
+	}
+	return w, nil
+}
+
+// run performs the initial sync and then polls until ctx is canceled
+func (w *watcher) run(ctx context.Context) error {
+	if err := w.scan(ctx); err != nil {
+		return err
+	}
+	if !w.opts.NoPrune {
+		if err := w.prune(ctx); err != nil {
+			return err
+		}
+	}
+	w.log.Info("watching files", "files", len(w.state), "interval", w.opts.Interval)
+
+	ticker := time.NewTicker(w.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := w.scan(ctx); err != nil {
+				w.log.Error("scan failed", "error", err)
+			}
+		}
+	}
+}
+
+// scan collects the watched files and syncs the ones that were added, changed or removed.
+// Per-file errors are logged and the file is retried on the next scan.
+func (w *watcher) scan(ctx context.Context) error {
+	files, err := ingest.Collect(w.opts.Paths, w.opts.walkOptions())
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(files))
+	for _, f := range files {
+		seen[f.RelPath] = true
+		info, err := os.Stat(f.Path)
+		if err != nil {
+			// removed while scanning; handled on the next scan
+			continue
+		}
+		st := fileState{modTime: info.ModTime(), size: info.Size()}
+		if old, ok := w.state[f.RelPath]; ok && old == st {
+			continue
+		}
+
+		chunks, err := readIngestChunks(&w.opts.IngestOptions, f)
+		if errors.Is(err, ingest.ErrBinary) {
+			w.log.Warn("skip file", "source", f.RelPath, "error", err)
+			chunks, err = nil, nil
+		}
+		if err == nil {
+			err = w.syncFile(ctx, f.RelPath, chunks)
+		}
+		if err != nil {
+			w.log.Error("sync failed", "source", f.RelPath, "error", err)
+			continue
+		}
+		w.state[f.RelPath] = st
+	}
+
+	// Files that disappeared (deleted, renamed or excluded)
+	for rel := range w.state {
+		if seen[rel] {
+			continue
+		}
+		if err := w.syncFile(ctx, rel, nil); err != nil {
+			w.log.Error("sync failed", "source", rel, "error", err)
+			continue
+		}
+		delete(w.state, rel)
+	}
+	return nil
+}
+
+// prune deletes notes of sources under the watched roots that are no longer collected
+// (files removed while watch was not running)
+func (w *watcher) prune(ctx context.Context) error {
+	sources, err := w.sync.ListSources(ctx, w.opts.ProjectID, w.opts.GroupID)
+	if err != nil {
+		return fmt.Errorf("failed to list synced sources: %w", err)
+	}
+	for _, source := range sources {
+		if _, ok := w.state[source]; ok || !w.inScope(source) {
+			continue
+		}
+		if err := w.syncFile(ctx, source, nil); err != nil {
+			return fmt.Errorf("failed to prune %s: %w", source, err)
+		}
+	}
+	return nil
+}
+
+// inScope reports whether the source is under one of the watched roots
+func (w *watcher) inScope(source string) bool {
+	for _, root := range w.roots {
+		if root == "." || source == root || strings.HasPrefix(source, root+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// syncFile syncs the notes of a single file and logs the changes
+func (w *watcher) syncFile(ctx context.Context, source string, chunks []ingest.Chunk) error {
+	resp, err := w.sync.Sync(ctx, buildSyncRequest(&w.opts.IngestOptions, source, chunks))
+	if err != nil {
+		return err
+	}
+	if resp.Created+resp.Updated+resp.Deleted > 0 {
+		w.log.Info("synced", "source", source, "created", resp.Created, "updated", resp.Updated,
+			"unchanged", resp.Unchanged, "deleted", resp.Deleted)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// TestParseWatchFlags tests flag parsing for watch command
+func TestParseWatchFlags(t *testing.T) {
+	opts, err := parseWatchFlags([]string{"-p", "/test/project", "-g", "docs", "./docs", "--interval", "5s", "--exclude", "*.txt"})
+	if err != nil {
+		t.Fatalf("parseWatchFlags failed: %v", err)
+	}
+	if opts.Interval != 5*time.Second {
+		t.Errorf("Interval = %v, want 5s", opts.Interval)
+	}
+	if !reflect.DeepEqual(opts.Paths, []string{"./docs"}) || opts.Exclude != "*.txt" {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+
+	if _, err := parseWatchFlags([]string{"-p", "/test/project", "-g", "docs", "--interval", "0s", "./docs"}); err == nil {
+		t.Error("expected error for zero interval")
+	}
+	if _, err := parseWatchFlags([]string{"-p", "/test/project", "-g", "docs"}); err == nil {
+		t.Error("expected error for missing paths")
+	}
+}
+
+// recordingSyncService records the chunk count of every Sync call by source
+type recordingSyncService struct {
+	mockSyncService
+	calls map[string][]int
+}
+
+func newRecordingSyncService(sources ...string) *recordingSyncService {
+	r := &recordingSyncService{calls: make(map[string][]int)}
+	r.syncFunc = func(ctx context.Context, req *service.SyncRequest) (*service.SyncResponse, error) {
+		r.calls[req.Source] = append(r.calls[req.Source], len(req.Chunks))
+		return &service.SyncResponse{Created: len(req.Chunks)}, nil
+	}
+	r.listSourcesFunc = func(ctx context.Context, projectID, groupID string) ([]string, error) {
+		return sources, nil
+	}
+	return r
+}
+
+func (r *recordingSyncService) sources() []string {
+	var s []string
+	for k := range r.calls {
+		s = append(s, k)
+	}
+	sort.Strings(s)
+	return s
+}
+
+func newTestWatcher(t *testing.T, syncService service.SyncService) *watcher {
+	t.Helper()
+	opts := &WatchOptions{
+		IngestOptions: IngestOptions{
+			ProjectID: "/test/project",
+			GroupID:   "docs",
+			Paths:     []string{"docs"},
+			ChunkSize: 100,
+			Overlap:   10,
+		},
+		Interval: time.Second,
+	}
+	w, err := newWatcher(opts, syncService, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatalf("newWatcher failed: %v", err)
+	}
+	return w
+}
+
+// TestWatcher_Scan tests that only added, changed and removed files are synced
+func TestWatcher_Scan(t *testing.T) {
+	setupIngestDir(t)
+	rec := newRecordingSyncService()
+	w := newTestWatcher(t, rec)
+	ctx := context.Background()
+
+	// initial scan syncs every file (binary files are synced with no chunks)
+	if err := w.scan(ctx); err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	if want := []string{"docs/drafts/wip.md", "docs/guide.md", "docs/logo.txt"}; !reflect.DeepEqual(rec.sources(), want) {
+		t.Fatalf("expected %v synced, got %v", want, rec.sources())
+	}
+	if rec.calls["docs/logo.txt"][0] != 0 {
+		t.Errorf("expected binary file to be synced with no chunks")
+	}
+
+	// nothing changed
+	if err := w.scan(ctx); err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	if len(rec.calls["docs/guide.md"]) != 1 {
+		t.Errorf("expected unchanged file not to be synced again")
+	}
+
+	// change and remove
+	if err := os.WriteFile(filepath.Join("docs", "guide.md"), []byte("# Guide\n\nrewritten"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join("docs", "drafts", "wip.md")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.scan(ctx); err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	if got := rec.calls["docs/guide.md"]; len(got) != 2 || got[1] != 1 {
+		t.Errorf("expected changed file to be re-synced with 1 chunk, got %v", got)
+	}
+	if got := rec.calls["docs/drafts/wip.md"]; len(got) != 2 || got[1] != 0 {
+		t.Errorf("expected removed file to be synced with no chunks, got %v", got)
+	}
+	if _, ok := w.state["docs/drafts/wip.md"]; ok {
+		t.Error("expected removed file to be forgotten")
+	}
+}
+
+// TestWatcher_Prune tests that stale sources under the watched roots are removed
+func TestWatcher_Prune(t *testing.T) {
+	setupIngestDir(t)
+	rec := newRecordingSyncService("docs/guide.md", "docs/old.md", "other/keep.md")
+	w := newTestWatcher(t, rec)
+	ctx := context.Background()
+
+	if err := w.scan(ctx); err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	if err := w.prune(ctx); err != nil {
+		t.Fatalf("prune failed: %v", err)
+	}
+
+	if got := rec.calls["docs/old.md"]; len(got) != 1 || got[0] != 0 {
+		t.Errorf("expected docs/old.md to be pruned, got %v", got)
+	}
+	if _, ok := rec.calls["other/keep.md"]; ok {
+		t.Error("expected source outside watched roots to be kept")
+	}
+	if got := rec.calls["docs/guide.md"]; len(got) != 1 {
+		t.Errorf("expected existing file not to be pruned, got %v", got)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/brbranch/embedding_mcp/internal/config"
 	"github.com/brbranch/embedding_mcp/internal/jsonrpc"
 	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/textutil"
 )
 
 // TestE2E_ProjectID_TildeExpansion はprojectIDの~展開を検証
@@ -485,7 +486,7 @@ func formatTextOutputE2E(buf *bytes.Buffer, results []SearchResultItem) {
 		buf.WriteString(floatToString(r.Score))
 		buf.WriteString(")\n")
 		buf.WriteString("    ")
-		buf.WriteString(truncateTextE2E(r.Text, 60))
+		buf.WriteString(textutil.Truncate(r.Text, 60))
 		buf.WriteString("\n")
 
 		if len(r.Tags) > 0 {
@@ -523,14 +524,6 @@ func formatJSONOutputE2E(buf *bytes.Buffer, results []SearchResultItem) error {
 	return encoder.Encode(output)
 }
 
-// truncateTextE2E truncates text for display
-func truncateTextE2E(text string, maxLen int) string {
-	if text == "" || len(text) <= maxLen {
-		return text
-	}
-	return text[:maxLen] + " ..."
-}
-
 // intToString converts int to string without importing strconv
 func intToString(n int) string {
 	if n == 0 {
@@ -79,6 +79,10 @@ func (m *mockConfigService) SetConfig(ctx context.Context, req *service.SetConfi
 	}, nil
 }
 
+func (m *mockConfigService) AddProjectAlias(ctx context.Context, req *service.AddProjectAliasRequest) (*service.AddProjectAliasResponse, error) {
+	return &service.AddProjectAliasResponse{Path: req.Path, ProjectID: req.ProjectID}, nil
+}
+
 // setupTestHandler はテスト用のHandlerを構築
 func setupTestHandler(t *testing.T) *jsonrpc.Handler {
 	t.Helper()
@@ -95,9 +95,9 @@ func TestE2E_MCP_FullFlow(t *testing.T) {
 		result := resp.Result.(map[string]any)
 		tools := result["tools"].([]any)
 
-		// 15個のツールがあることを確認
-		if len(tools) != 15 {
-			t.Errorf("expected 15 tools, got %d", len(tools))
+		// 27個のツールがあることを確認
+		if len(tools) != 27 {
+			t.Errorf("expected 27 tools, got %d", len(tools))
 		}
 
 		// 必要なツールが存在することを確認
@@ -115,20 +115,32 @@ func TestE2E_MCP_FullFlow(t *testing.T) {
 
 		expectedTools := []string{
 			"memory_add_note",
+			"memory_add_notes",
 			"memory_search",
 			"memory_get",
 			"memory_update",
 			"memory_delete",
 			"memory_list_recent",
 			"memory_get_config",
+			"memory_server_info",
+			"memory_stats",
 			"memory_set_config",
+			"memory_migrate",
+			"memory_add_project_alias",
 			"memory_upsert_global",
 			"memory_get_global",
+			"memory_get_global_history",
+			"memory_export_globals",
+			"memory_import_globals",
 			"memory_group_create",
 			"memory_group_get",
 			"memory_group_update",
 			"memory_group_delete",
+			"memory_group_rename",
 			"memory_group_list",
+			"memory_archive",
+			"memory_restore",
+			"memory_list_archived",
 		}
 
 		for _, expected := range expectedTools {
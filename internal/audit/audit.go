@@ -0,0 +1,69 @@
+// Package audit records mutating memory operations to an append-only JSONL file.
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Entry は監査ログの1件（JSONLの1行）
+type Entry struct {
+	Time      string `json:"time"`                // 記録した時刻（RFC3339、UTC）
+	Method    string `json:"method"`              // memory.* のメソッド名（MCPのtools/callも内部のメソッド名で記録する。HTTPの /import は "/import"）
+	Client    string `json:"client,omitempty"`    // クライアント（"name/version"）
+	Session   string `json:"session,omitempty"`   // セッションID（stdio/pipeは接続ごと、HTTPはリクエストごと）
+	Token     string `json:"token,omitempty"`     // HTTPでプロジェクトを限定したトークンの名前（auth.tokens[].name）
+	ProjectID string `json:"projectId,omitempty"` // 対象のプロジェクト（分かる場合のみ）
+	ID        string `json:"id,omitempty"`        // 対象のノート・グローバル設定・グループのID
+	Key       string `json:"key,omitempty"`       // グローバル設定のkey、またはグループのgroupKey
+	Notes     int    `json:"notes,omitempty"`     // 一緒に変更・削除したノートの数（group_delete・group_rename）
+}
+
+// Logger は監査ログをJSONLで追記する（並行に呼び出してよい）
+// 監査のためローテーションはしない
+type Logger struct {
+	mu  sync.Mutex
+	w   io.Writer
+	now func() time.Time
+}
+
+// New はwへ書き込むLoggerを生成する
+func New(w io.Writer) *Logger {
+	return &Logger{w: w, now: time.Now}
+}
+
+// Open はpathを追記モードで開き、Loggerとファイルを閉じる関数を返す（親ディレクトリは自動作成）
+func Open(path string) (*Logger, func() error, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, nil, fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return New(f), f.Close, nil
+}
+
+// Record はeを1行追記する（Timeが空なら現在時刻を使う）
+func (l *Logger) Record(e Entry) error {
+	if e.Time == "" {
+		e.Time = l.now().UTC().Format(time.RFC3339)
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.w.Write(line); err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	return nil
+}
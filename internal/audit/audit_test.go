@@ -0,0 +1,66 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestLogger_Record はファイルへの追記と時刻の補完をテスト
+func TestLogger_Record(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "audit.jsonl")
+
+	// 開き直しても前の記録を残して追記する
+	for _, e := range []Entry{
+		{Method: "memory.add_note", Client: "claude-code/1.0.0", ProjectID: "/test/project", ID: "note-1"},
+		{Method: "memory.delete", ID: "note-1"},
+	} {
+		l, closeFn, err := Open(path)
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		l.now = func() time.Time { return time.Date(2024, 1, 15, 19, 30, 0, 0, time.FixedZone("JST", 9*60*60)) }
+		if err := l.Record(e); err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+		if err := closeFn(); err != nil {
+			t.Fatalf("close failed: %v", err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("line is not JSON: %v", err)
+		}
+		entries = append(entries, e)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Time != "2024-01-15T10:30:00Z" || entries[0].Client != "claude-code/1.0.0" || entries[0].ID != "note-1" {
+		t.Errorf("unexpected first entry: %+v", entries[0])
+	}
+	if entries[1].Method != "memory.delete" {
+		t.Errorf("unexpected second entry: %+v", entries[1])
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("expected mode 0600, got %o", perm)
+	}
+}
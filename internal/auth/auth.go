@@ -0,0 +1,124 @@
+// Package auth authenticates HTTP requests with the API key and project-scoped access tokens.
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/model"
+)
+
+// ErrForbidden はトークンで使えないプロジェクト・操作へのアクセス
+var ErrForbidden = errors.New("access denied for this token")
+
+// Scope はプロジェクトを限定したトークンで使えるプロジェクトと操作
+// nilのScope（APIキー・stdio・pipe）は制限なしを表す
+type Scope struct {
+	Name       string   // 設定のname（監査ログ・ログ用）
+	ReadOnly   bool     // 検索・取得・一覧のみ
+	projectIDs []string // 指定されたprojectIdと正規化後のprojectId
+}
+
+// AllowsProject はprojectIDのプロジェクトを使えるかを返す（projectIDは正規化して照合する）
+func (s *Scope) AllowsProject(projectID string) bool {
+	if s == nil {
+		return true
+	}
+	if projectID == "" {
+		return false
+	}
+	if slices.Contains(s.projectIDs, projectID) {
+		return true
+	}
+	canonical, err := config.CanonicalizeProjectID(projectID)
+	return err == nil && slices.Contains(s.projectIDs, canonical)
+}
+
+// Check はprojectIDのプロジェクトを使えない、または書き込み（write）を許可されていない場合にErrForbiddenを返す
+func (s *Scope) Check(projectID string, write bool) error {
+	if s == nil {
+		return nil
+	}
+	if write && s.ReadOnly {
+		return fmt.Errorf("%w: token %q is read-only", ErrForbidden, s.Name)
+	}
+	if !s.AllowsProject(projectID) {
+		if projectID == "" {
+			return fmt.Errorf("%w: token %q requires projectId", ErrForbidden, s.Name)
+		}
+		return fmt.Errorf("%w: token %q cannot access project %s", ErrForbidden, s.Name, projectID)
+	}
+	return nil
+}
+
+// token は設定の1つのトークン
+type token struct {
+	secret []byte
+	scope  *Scope // APIキーならnil
+}
+
+// Authenticator はAuthorizationヘッダーのトークンを検証する
+type Authenticator struct {
+	tokens []token
+}
+
+// New はauthの設定からAuthenticatorを生成する（設定はconfig.Validateで検証済み、apiKeyFromは解決済みであること）
+// authがnil、またはキーもトークンもない場合はnil（認証しない）を返す
+func New(a *model.AuthConfig) *Authenticator {
+	if a == nil {
+		return nil
+	}
+	auth := &Authenticator{}
+	if a.APIKey != nil && *a.APIKey != "" {
+		auth.tokens = append(auth.tokens, token{secret: []byte(*a.APIKey)})
+	}
+	for i, t := range a.Tokens {
+		scope := &Scope{Name: t.Name, ReadOnly: t.Access != model.AccessReadWrite}
+		if scope.Name == "" {
+			scope.Name = fmt.Sprintf("token[%d]", i)
+		}
+		// ノートのprojectIdは正規化済みのため、設定のprojectIdも正規化して照合する
+		for _, id := range t.ProjectIDs {
+			scope.projectIDs = append(scope.projectIDs, id)
+			if canonical, err := config.CanonicalizeProjectID(id); err == nil && canonical != id {
+				scope.projectIDs = append(scope.projectIDs, canonical)
+			}
+		}
+		auth.tokens = append(auth.tokens, token{secret: []byte(t.Token), scope: scope})
+	}
+	if len(auth.tokens) == 0 {
+		return nil
+	}
+	return auth
+}
+
+// Authenticate はtokenに一致するScope（APIキーならnil）と、一致したかを返す
+// 比較にかかる時間から一致した位置が分からないよう、すべてのトークンと比較する
+func (a *Authenticator) Authenticate(tok string) (*Scope, bool) {
+	var scope *Scope
+	matched := false
+	for _, t := range a.tokens {
+		if subtle.ConstantTimeCompare(t.secret, []byte(tok)) == 1 {
+			scope, matched = t.scope, true
+		}
+	}
+	return scope, matched
+}
+
+// contextKey はcontextに格納する際のキー
+type contextKey struct{}
+
+// NewContext はScopeを格納したcontextを返す
+func NewContext(ctx context.Context, s *Scope) context.Context {
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// FromContext はcontextからScopeを取得する（未設定・APIキーならnil）
+func FromContext(ctx context.Context) *Scope {
+	s, _ := ctx.Value(contextKey{}).(*Scope)
+	return s
+}
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+)
+
+// TestNew_Disabled はキーもトークンもなければ認証しないことをテスト
+func TestNew_Disabled(t *testing.T) {
+	empty := ""
+	for _, a := range []*model.AuthConfig{nil, {}, {APIKey: &empty}} {
+		if got := New(a); got != nil {
+			t.Errorf("New(%+v) = %+v, want nil", a, got)
+		}
+	}
+}
+
+// TestAuthenticator_Authenticate はAPIキー・トークンの照合をテスト
+func TestAuthenticator_Authenticate(t *testing.T) {
+	apiKey := "admin-secret"
+	a := New(&model.AuthConfig{APIKey: &apiKey, Tokens: []model.AccessTokenConfig{
+		{Name: "team-a", Token: "a-secret", ProjectIDs: []string{"github.com/org/a"}, Access: model.AccessReadWrite},
+		{Token: "b-secret", ProjectIDs: []string{"github.com/org/b"}},
+	}})
+
+	scope, ok := a.Authenticate("admin-secret")
+	if !ok || scope != nil {
+		t.Errorf("expected the API key to have no scope, got %+v, %v", scope, ok)
+	}
+	scope, ok = a.Authenticate("a-secret")
+	if !ok || scope.Name != "team-a" || scope.ReadOnly {
+		t.Errorf("unexpected scope: %+v, %v", scope, ok)
+	}
+	scope, ok = a.Authenticate("b-secret")
+	if !ok || scope.Name != "token[1]" || !scope.ReadOnly {
+		t.Errorf("expected a read-only token by default, got %+v, %v", scope, ok)
+	}
+	for _, tok := range []string{"", "a-secre", "a-secret2"} {
+		if _, ok := a.Authenticate(tok); ok {
+			t.Errorf("expected %q to be rejected", tok)
+		}
+	}
+}
+
+// TestScope_Check はプロジェクトと書き込みの制限をテスト
+func TestScope_Check(t *testing.T) {
+	a := New(&model.AuthConfig{Tokens: []model.AccessTokenConfig{
+		{Name: "rw", Token: "rw", ProjectIDs: []string{"github.com/org/a"}, Access: model.AccessReadWrite},
+		{Name: "ro", Token: "ro", ProjectIDs: []string{"github.com/org/a"}},
+	}})
+	rw, _ := a.Authenticate("rw")
+	ro, _ := a.Authenticate("ro")
+
+	tests := []struct {
+		name      string
+		scope     *Scope
+		projectID string
+		write     bool
+		wantErr   bool
+	}{
+		{name: "unrestricted", scope: nil, projectID: "", write: true},
+		{name: "read", scope: ro, projectID: "github.com/org/a"},
+		{name: "write", scope: rw, projectID: "github.com/org/a", write: true},
+		{name: "read-only write", scope: ro, projectID: "github.com/org/a", write: true, wantErr: true},
+		{name: "other project", scope: rw, projectID: "github.com/org/b", wantErr: true},
+		{name: "no project", scope: rw, projectID: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.scope.Check(tt.projectID, tt.write)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrForbidden) {
+				t.Errorf("expected ErrForbidden, got %v", err)
+			}
+		})
+	}
+}
+
+// TestContext はcontextへの格納をテスト
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	if FromContext(ctx) != nil {
+		t.Error("expected nil scope without NewContext")
+	}
+	scope := &Scope{Name: "team-a"}
+	if got := FromContext(NewContext(ctx, scope)); got != scope {
+		t.Errorf("FromContext() = %+v, want %+v", got, scope)
+	}
+}
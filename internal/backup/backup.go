@@ -0,0 +1,369 @@
+// Package backup writes and restores full snapshots (all projects + config) as tar/zstd archives.
+package backup
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/service"
+	"github.com/klauspost/compress/zstd"
+)
+
+// FormatVersion はバックアップアーカイブの形式バージョン
+const FormatVersion = 1
+
+// アーカイブ内のエントリ名
+const (
+	configEntry   = "config.json"
+	manifestEntry = "manifest.json"
+	projectsDir   = "projects/"
+)
+
+// ファイル名の形式（mcp-memory-20240102T150405Z.tar.zst）
+const (
+	filePrefix = "mcp-memory-"
+	FileSuffix = ".tar.zst"
+	timeLayout = "20060102T150405Z"
+)
+
+var (
+	// ErrInvalidArchive はアーカイブの形式が不正
+	ErrInvalidArchive = errors.New("invalid backup archive")
+	// ErrNoConfig はアーカイブに設定ファイルが含まれていない
+	ErrNoConfig = errors.New("backup archive has no config")
+)
+
+// Manifest はアーカイブの内容一覧（アーカイブの最後に格納）
+type Manifest struct {
+	Version    int            `json:"version"`
+	CreatedAt  string         `json:"createdAt"`
+	AppVersion string         `json:"appVersion,omitempty"`
+	Namespace  string         `json:"namespace"`
+	Projects   []ProjectEntry `json:"projects"`
+}
+
+// ProjectEntry はプロジェクト1件分のエントリ
+type ProjectEntry struct {
+	ProjectID string `json:"projectId"`
+	File      string `json:"file"`
+	Notes     int    `json:"notes"`
+	Globals   int    `json:"globals"`
+	Groups    int    `json:"groups"`
+}
+
+// RestoreResult はリストア結果（プロジェクトごとのインポート結果）
+type RestoreResult struct {
+	Projects []*service.ImportResponse
+}
+
+// Backup はストア非依存のバックアップ/リストアを行う
+// 各プロジェクトはExportServiceのJSONL（埋め込み付き）として格納するため、
+// 同じembedder設定（namespace）であればストアの種類を変えても再埋め込みは発生しない
+type Backup struct {
+	notes   service.NoteService
+	exports service.ExportService
+}
+
+// New は新しいBackupを作成
+func New(notes service.NoteService, exports service.ExportService) *Backup {
+	return &Backup{notes: notes, exports: exports}
+}
+
+// FileName はcreatedAtに対応するアーカイブ名を返す
+func FileName(createdAt time.Time) string {
+	return filePrefix + createdAt.UTC().Format(timeLayout) + FileSuffix
+}
+
+// Write は全プロジェクトと設定（APIキーは除く）をw にtar/zstdで書き出す
+func (b *Backup) Write(ctx context.Context, w io.Writer, cfg *model.Config, appVersion string) (*Manifest, error) {
+	projects, err := b.notes.ListProjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	zw, err := zstd.NewWriter(w)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create zstd writer: %w", err)
+	}
+	tw := tar.NewWriter(zw)
+	now := time.Now().UTC()
+
+	manifest := &Manifest{
+		Version:    FormatVersion,
+		CreatedAt:  now.Format(time.RFC3339),
+		AppVersion: appVersion,
+		Namespace:  projects.Namespace,
+		Projects:   make([]ProjectEntry, 0, len(projects.Projects)),
+	}
+
+	if cfg != nil {
+		if err := writeJSONEntry(tw, configEntry, redactConfig(cfg), now); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, p := range projects.Projects {
+		// tarヘッダーにサイズが必要なため1プロジェクトずつバッファする
+		var buf bytes.Buffer
+		resp, err := b.exports.Export(ctx, &buf, &service.ExportRequest{
+			ProjectID:         p.ProjectID,
+			IncludeEmbeddings: true,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to export %s: %w", p.ProjectID, err)
+		}
+
+		name := fmt.Sprintf("%s%04d.jsonl", projectsDir, i+1)
+		if err := writeEntry(tw, name, buf.Bytes(), now); err != nil {
+			return nil, err
+		}
+		manifest.Projects = append(manifest.Projects, ProjectEntry{
+			ProjectID: resp.ProjectID,
+			File:      name,
+			Notes:     resp.Notes,
+			Globals:   resp.Globals,
+			Groups:    resp.Groups,
+		})
+	}
+
+	if err := writeJSONEntry(tw, manifestEntry, manifest, now); err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close tar: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close zstd writer: %w", err)
+	}
+	return manifest, nil
+}
+
+// Restore はアーカイブ内の全プロジェクトを元のprojectIdにインポートする
+// 設定ファイルは書き戻さない（ReadConfigで取り出して呼び出し側が扱う）
+func (b *Backup) Restore(ctx context.Context, r io.Reader, mode service.ImportMode) (*RestoreResult, error) {
+	result := &RestoreResult{}
+	err := walk(r, func(name string, data []byte) error {
+		if !strings.HasPrefix(name, projectsDir) {
+			return nil
+		}
+		projectID, err := exportProjectID(data)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidArchive, name, err)
+		}
+		resp, err := b.exports.Import(ctx, bytes.NewReader(data), &service.ImportRequest{
+			ProjectID: projectID,
+			Mode:      mode,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to restore %s: %w", projectID, err)
+		}
+		result.Projects = append(result.Projects, resp)
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// ReadConfig はアーカイブに含まれる設定を返す
+func ReadConfig(r io.Reader) (*model.Config, error) {
+	var cfg *model.Config
+	err := walk(r, func(name string, data []byte) error {
+		if name != configEntry {
+			return nil
+		}
+		cfg = &model.Config{}
+		if err := json.Unmarshal(data, cfg); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidArchive, name, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		return nil, ErrNoConfig
+	}
+	return cfg, nil
+}
+
+// ReadManifest はアーカイブのmanifestを返す
+func ReadManifest(r io.Reader) (*Manifest, error) {
+	var manifest *Manifest
+	err := walk(r, func(name string, data []byte) error {
+		if name != manifestEntry {
+			return nil
+		}
+		manifest = &Manifest{}
+		if err := json.Unmarshal(data, manifest); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidArchive, name, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if manifest == nil {
+		return nil, fmt.Errorf("%w: missing %s", ErrInvalidArchive, manifestEntry)
+	}
+	if manifest.Version > FormatVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidArchive, manifest.Version)
+	}
+	return manifest, nil
+}
+
+// Prune はdir内のバックアップを新しい順にkeep件残して削除し、削除したパスを返す
+func Prune(dir string, keep int) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup directory: %w", err)
+	}
+
+	var names []string
+	for _, e := range entries {
+		if e.Type().IsRegular() && IsArchiveName(e.Name()) {
+			names = append(names, e.Name())
+		}
+	}
+
+	var removed []string
+	for _, name := range Expired(names, keep) {
+		p := filepath.Join(dir, name)
+		if err := os.Remove(p); err != nil {
+			return removed, fmt.Errorf("failed to remove old backup: %w", err)
+		}
+		removed = append(removed, p)
+	}
+	return removed, nil
+}
+
+// IsArchiveName はnameがFileNameの形式のアーカイブ名か
+func IsArchiveName(name string) bool {
+	return strings.HasPrefix(name, filePrefix) && strings.HasSuffix(name, FileSuffix)
+}
+
+// Expired はアーカイブ名のうち、新しい順にkeep件を残したときに削除するものを新しい順に返す
+// ファイル名のタイムスタンプは辞書順 = 時刻順
+func Expired(names []string, keep int) []string {
+	if len(names) <= keep {
+		return nil
+	}
+	sorted := append([]string(nil), names...)
+	sort.Sort(sort.Reverse(sort.StringSlice(sorted)))
+	return sorted[keep:]
+}
+
+// walk はtar/zstdアーカイブの各ファイルエントリについてfnを呼ぶ
+func walk(r io.Reader, fn func(name string, data []byte) error) error {
+	zr, err := zstd.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	defer zr.Close()
+
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidArchive, hdr.Name, err)
+		}
+		if err := fn(path.Clean(hdr.Name), data); err != nil {
+			return err
+		}
+	}
+}
+
+// exportProjectID はエクスポートJSONLのheaderからprojectIdを取り出す
+func exportProjectID(data []byte) (string, error) {
+	line, err := bufio.NewReader(bytes.NewReader(data)).ReadBytes('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	var header service.ExportRecord
+	if err := json.Unmarshal(line, &header); err != nil {
+		return "", err
+	}
+	if header.Type != service.ExportRecordHeader || header.ProjectID == "" {
+		return "", errors.New("missing export header")
+	}
+	return header.ProjectID, nil
+}
+
+// redactConfig はAPIキー・Webhookの署名鍵・auth（トークンを含む）・encryption（鍵を含む）を除いた設定のコピーを返す
+func redactConfig(cfg *model.Config) *model.Config {
+	c := *cfg
+	c.Embedder.APIKey = nil
+	c.Store.APIKey = nil
+	c.Auth = nil
+	c.Encryption = nil
+	if cfg.PreviousEmbedder != nil {
+		prev := *cfg.PreviousEmbedder
+		prev.APIKey = nil
+		c.PreviousEmbedder = &prev
+	}
+	if cfg.Embedders != nil {
+		c.Embedders = make(map[string]model.EmbedderConfig, len(cfg.Embedders))
+		for name, e := range cfg.Embedders {
+			e.APIKey = nil
+			c.Embedders[name] = e
+		}
+	}
+	if cfg.Webhooks != nil {
+		c.Webhooks = make([]model.WebhookConfig, len(cfg.Webhooks))
+		for i, w := range cfg.Webhooks {
+			w.Secret = ""
+			c.Webhooks[i] = w
+		}
+	}
+	return &c
+}
+
+// writeJSONEntry はvをJSONとしてアーカイブに書き込む
+func writeJSONEntry(tw *tar.Writer, name string, v any, modTime time.Time) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", name, err)
+	}
+	return writeEntry(tw, name, data, modTime)
+}
+
+// writeEntry はファイルエントリを1件書き込む
+func writeEntry(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: modTime,
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
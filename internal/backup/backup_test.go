@@ -0,0 +1,212 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/service"
+	"github.com/brbranch/embedding_mcp/internal/store"
+)
+
+const testNamespace = "openai:test:3"
+
+// countingEmbedder は埋め込み生成回数を数えるテスト用Embedder
+type countingEmbedder struct {
+	calls int
+}
+
+func (e *countingEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	e.calls++
+	return []float32{0.1, 0.2, 0.3}, nil
+}
+
+func (e *countingEmbedder) GetDimension() int {
+	return 3
+}
+
+// newTestBackup はメモリストア上のBackupとサービスを作成
+func newTestBackup(t *testing.T, emb *countingEmbedder) (*Backup, service.NoteService, service.GroupService, service.GlobalService) {
+	t.Helper()
+	st := store.NewMemoryStore()
+	if err := st.Initialize(context.Background(), testNamespace); err != nil {
+		t.Fatal(err)
+	}
+	notes := service.NewNoteService(emb, st, testNamespace)
+	b := New(notes, service.NewExportService(emb, st, testNamespace))
+	return b, notes, service.NewGroupService(st, testNamespace), service.NewGlobalService(st, testNamespace)
+}
+
+// TestWriteAndRestore は全プロジェクトを再埋め込みなしで復元できることをテスト
+func TestWriteAndRestore(t *testing.T) {
+	ctx := context.Background()
+	srcEmb := &countingEmbedder{}
+	src, notes, groups, globals := newTestBackup(t, srcEmb)
+
+	for _, p := range []string{"/proj/a", "/proj/b"} {
+		if _, err := notes.AddNote(ctx, &service.AddNoteRequest{ProjectID: p, GroupID: "global", Text: "note in " + p}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := groups.CreateGroup(ctx, &service.CreateGroupRequest{ProjectID: "/proj/a", GroupKey: "docs", Title: "Docs"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := globals.UpsertGlobal(ctx, &service.UpsertGlobalRequest{ProjectID: "/proj/b", Key: "global.project.conventions", Value: "tabs"}); err != nil {
+		t.Fatal(err)
+	}
+
+	apiKey := "secret"
+	cfg := &model.Config{
+		Embedder:   model.EmbedderConfig{Provider: "openai", Model: "m", APIKey: &apiKey},
+		Webhooks:   []model.WebhookConfig{{URL: "https://example.com/hook", Secret: "hook-secret"}},
+		Auth:       &model.AuthConfig{Tokens: []model.AccessTokenConfig{{Token: "token-secret", ProjectIDs: []string{"/proj/a"}}}},
+		Encryption: &model.EncryptionConfig{Keys: []model.EncryptionKeyConfig{{ID: "k1", Key: &apiKey}}},
+	}
+
+	var archive bytes.Buffer
+	manifest, err := src.Write(ctx, &archive, cfg, "test")
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if len(manifest.Projects) != 2 || manifest.Namespace != testNamespace {
+		t.Fatalf("unexpected manifest: %+v", manifest)
+	}
+
+	// manifest・configを読み出せる（APIキーは含まない）
+	readManifest, err := ReadManifest(bytes.NewReader(archive.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadManifest failed: %v", err)
+	}
+	if readManifest.Projects[0].ProjectID != "/proj/a" || readManifest.Projects[0].Groups != 1 {
+		t.Errorf("unexpected manifest entry: %+v", readManifest.Projects[0])
+	}
+	readCfg, err := ReadConfig(bytes.NewReader(archive.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadConfig failed: %v", err)
+	}
+	if readCfg.Embedder.Model != "m" || readCfg.Embedder.APIKey != nil {
+		t.Errorf("unexpected config: %+v", readCfg.Embedder)
+	}
+	if len(readCfg.Webhooks) != 1 || readCfg.Webhooks[0].URL != "https://example.com/hook" || readCfg.Webhooks[0].Secret != "" {
+		t.Errorf("unexpected webhooks: %+v", readCfg.Webhooks)
+	}
+	if readCfg.Auth != nil || readCfg.Encryption != nil {
+		t.Errorf("expected auth and encryption to be excluded, got %+v, %+v", readCfg.Auth, readCfg.Encryption)
+	}
+	if *cfg.Embedder.APIKey != "secret" || cfg.Webhooks[0].Secret != "hook-secret" {
+		t.Error("Write must not modify the given config")
+	}
+
+	// 別ストアへ復元
+	dstEmb := &countingEmbedder{}
+	dst, dstNotes, dstGroups, _ := newTestBackup(t, dstEmb)
+	result, err := dst.Restore(ctx, bytes.NewReader(archive.Bytes()), service.ImportModeFail)
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if len(result.Projects) != 2 {
+		t.Fatalf("expected 2 restored projects, got %d", len(result.Projects))
+	}
+	if dstEmb.calls != 0 {
+		t.Errorf("expected no re-embedding, got %d calls", dstEmb.calls)
+	}
+
+	projects, err := dstNotes.ListProjects(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(projects.Projects) != 2 || projects.Projects[1].NoteCount != 1 {
+		t.Errorf("unexpected restored projects: %+v", projects.Projects)
+	}
+	listed, err := dstGroups.ListGroups(ctx, "/proj/a")
+	if err != nil || len(listed.Groups) != 1 {
+		t.Errorf("expected restored group, got %+v (err=%v)", listed, err)
+	}
+
+	// 再度の復元は衝突、skip-existingなら成功
+	if _, err := dst.Restore(ctx, bytes.NewReader(archive.Bytes()), service.ImportModeFail); !errors.Is(err, service.ErrImportConflict) {
+		t.Errorf("expected ErrImportConflict, got %v", err)
+	}
+	if _, err := dst.Restore(ctx, bytes.NewReader(archive.Bytes()), service.ImportModeSkipExisting); err != nil {
+		t.Errorf("unexpected error with skip-existing: %v", err)
+	}
+}
+
+// TestReadConfig_NoConfig は設定なしのアーカイブでErrNoConfigを返すことをテスト
+func TestReadConfig_NoConfig(t *testing.T) {
+	b, _, _, _ := newTestBackup(t, &countingEmbedder{})
+	var archive bytes.Buffer
+	if _, err := b.Write(context.Background(), &archive, nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadConfig(&archive); !errors.Is(err, ErrNoConfig) {
+		t.Errorf("expected ErrNoConfig, got %v", err)
+	}
+}
+
+// TestRestore_InvalidArchive は不正な入力でErrInvalidArchiveを返すことをテスト
+func TestRestore_InvalidArchive(t *testing.T) {
+	b, _, _, _ := newTestBackup(t, &countingEmbedder{})
+	_, err := b.Restore(context.Background(), bytes.NewReader([]byte("not an archive")), service.ImportModeFail)
+	if !errors.Is(err, ErrInvalidArchive) {
+		t.Errorf("expected ErrInvalidArchive, got %v", err)
+	}
+}
+
+// TestPrune は新しい順にkeep件だけ残すことをテスト
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		name := FileName(base.Add(time.Duration(i) * time.Hour))
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 対象外のファイルは残す
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := Prune(dir, 2)
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if len(removed) != 2 || filepath.Base(removed[1]) != FileName(base) {
+		t.Errorf("unexpected removed files: %v", removed)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 3 {
+		t.Errorf("expected 2 backups + 1 other file, got %d entries", len(entries))
+	}
+}
+
+// TestExpired は新しい順にkeep件を残した残りを返すことをテスト
+func TestExpired(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	names := []string{FileName(base.Add(time.Hour)), FileName(base), FileName(base.Add(2 * time.Hour))}
+	got := Expired(names, 1)
+	if len(got) != 2 || got[0] != FileName(base.Add(time.Hour)) || got[1] != FileName(base) {
+		t.Errorf("unexpected expired names: %v", got)
+	}
+	if got := Expired(names, 3); got != nil {
+		t.Errorf("expected nothing to expire, got %v", got)
+	}
+	if !IsArchiveName(FileName(base)) || IsArchiveName("notes.txt") {
+		t.Error("unexpected IsArchiveName result")
+	}
+}
+
+// TestFileName はタイムスタンプ付きファイル名をテスト
+func TestFileName(t *testing.T) {
+	got := FileName(time.Date(2024, 1, 2, 15, 4, 5, 0, time.FixedZone("JST", 9*3600)))
+	if got != "mcp-memory-20240102T060405Z.tar.zst" {
+		t.Errorf("unexpected file name: %s", got)
+	}
+}
@@ -0,0 +1,203 @@
+// Package bench measures the performance of mcp-memory stores (add, search and list at growing note counts).
+package bench
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/store"
+	"github.com/google/uuid"
+)
+
+// 計測する操作
+const (
+	OpAdd         = "add"          // AddNote
+	OpSearch      = "search"       // プロジェクト全体の検索（topK 10）
+	OpSearchGroup = "search_group" // グループを指定した検索（topK 10）
+	OpListRecent  = "list_recent"  // プロジェクト全体の最近のノート（limit 10）
+)
+
+// groupIDs はノートを振り分けるグループ（search_groupは1グループ分のノートを対象にする）
+var groupIDs = func() []string {
+	ids := make([]string, 10)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("bench-%d", i)
+	}
+	return ids
+}()
+
+// Options は計測の設定
+type Options struct {
+	Store     string // 結果に記録するストア名（memory・sqlite・qdrantなど）
+	Sizes     []int  // 計測するノート数（小さい順に、前のサイズまでのノートに追加していく）
+	Dim       int    // ベクトルの次元数
+	Queries   int    // サイズごとの検索・一覧の回数
+	ProjectID string // ノートを追加するプロジェクト（既存のノートと混ざらないものを指定する）
+	Seed      uint64 // ベクトルの乱数のシード（同じシードなら同じノート・クエリになる）
+
+	// Progress はサイズごとの計測を始める前に呼ぶ（nilなら何もしない）
+	Progress func(notes int)
+}
+
+// Result は1サイズ・1操作の計測結果
+type Result struct {
+	Store     string        `json:"store"`     // ストア名（Options.Store）
+	Notes     int           `json:"notes"`     // 計測時点のノート数
+	Op        string        `json:"op"`        // 操作（OpAddなど）
+	Count     int           `json:"count"`     // 計測した回数（addはこのサイズまでに追加した件数）
+	OpsPerSec float64       `json:"opsPerSec"` // 1秒あたりの回数
+	P50       time.Duration `json:"p50"`       // 所要時間の中央値（ナノ秒）
+	P95       time.Duration `json:"p95"`
+	P99       time.Duration `json:"p99"`
+}
+
+// Run はstにopts.Sizesの件数までノートを追加しながら、サイズごとに各操作を計測する
+// stは初期化済みであること。追加したノートは削除しないため、呼び出し側で
+// DeleteNotesByGroup（グループはGroupIDsが返す）などで片付ける
+func Run(ctx context.Context, st store.Store, opts Options) ([]Result, error) {
+	if opts.Dim <= 0 || opts.Queries <= 0 {
+		return nil, fmt.Errorf("dim and queries must be positive")
+	}
+	sizes := slices.Clone(opts.Sizes)
+	slices.Sort(sizes)
+	sizes = slices.Compact(sizes)
+
+	r := rand.New(rand.NewPCG(opts.Seed, opts.Seed+1))
+	var results []Result
+	added := 0
+	for _, size := range sizes {
+		if size <= 0 {
+			return nil, fmt.Errorf("note count must be positive, got %d", size)
+		}
+		if opts.Progress != nil {
+			opts.Progress(size)
+		}
+
+		var latencies []time.Duration
+		for ; added < size; added++ {
+			note := &model.Note{
+				ID:        uuid.NewString(),
+				ProjectID: opts.ProjectID,
+				GroupID:   groupIDs[added%len(groupIDs)],
+				Text:      fmt.Sprintf("benchmark note %d", added),
+				Tags:      []string{},
+			}
+			embedding := RandomVector(r, opts.Dim)
+			start := time.Now()
+			if err := st.AddNote(ctx, note, embedding); err != nil {
+				return nil, fmt.Errorf("add: %w", err)
+			}
+			latencies = append(latencies, time.Since(start))
+		}
+		results = append(results, summarize(opts.Store, size, OpAdd, latencies))
+
+		ops := []struct {
+			name string
+			run  func(query []float32) error
+		}{
+			{OpSearch, func(query []float32) error {
+				_, err := st.Search(ctx, query, store.SearchOptions{ProjectID: opts.ProjectID, TopK: 10})
+				return err
+			}},
+			{OpSearchGroup, func(query []float32) error {
+				groupID := groupIDs[0]
+				_, err := st.Search(ctx, query, store.SearchOptions{ProjectID: opts.ProjectID, GroupID: &groupID, TopK: 10})
+				return err
+			}},
+			{OpListRecent, func([]float32) error {
+				_, err := st.ListRecent(ctx, store.ListOptions{ProjectID: opts.ProjectID, Limit: 10})
+				return err
+			}},
+		}
+		for _, op := range ops {
+			latencies := make([]time.Duration, 0, opts.Queries)
+			for range opts.Queries {
+				query := RandomVector(r, opts.Dim)
+				start := time.Now()
+				if err := op.run(query); err != nil {
+					return nil, fmt.Errorf("%s: %w", op.name, err)
+				}
+				latencies = append(latencies, time.Since(start))
+			}
+			results = append(results, summarize(opts.Store, size, op.name, latencies))
+		}
+	}
+	return results, nil
+}
+
+// GroupIDs はRunがノートを振り分けるグループ
+func GroupIDs() []string {
+	return slices.Clone(groupIDs)
+}
+
+// RandomVector は各要素が[-1, 1)の乱数のベクトルを返す
+func RandomVector(r *rand.Rand, dim int) []float32 {
+	v := make([]float32, dim)
+	for i := range v {
+		v[i] = r.Float32()*2 - 1
+	}
+	return v
+}
+
+// summarize はlatenciesを集計する
+func summarize(storeName string, notes int, op string, latencies []time.Duration) Result {
+	res := Result{Store: storeName, Notes: notes, Op: op, Count: len(latencies)}
+	if len(latencies) == 0 {
+		return res
+	}
+	var total time.Duration
+	for _, d := range latencies {
+		total += d
+	}
+	if total > 0 {
+		res.OpsPerSec = float64(len(latencies)) / total.Seconds()
+	}
+	slices.Sort(latencies)
+	res.P50 = percentile(latencies, 50)
+	res.P95 = percentile(latencies, 95)
+	res.P99 = percentile(latencies, 99)
+	return res
+}
+
+// percentile は昇順に並んだsortedのp%点を返す（最近傍順位法）
+func percentile(sorted []time.Duration, p int) time.Duration {
+	i := (len(sorted)*p + 99) / 100
+	return sorted[max(i-1, 0)]
+}
+
+// Regression は基準より遅くなった計測結果
+type Regression struct {
+	Baseline Result
+	Current  Result
+	Ratio    float64 // Current.P50 / Baseline.P50
+}
+
+// Compare はcurrentのうち、同じストア・ノート数・操作のbaselineよりp50がthreshold（0.2なら20%）を超えて遅いものを返す
+// baselineにない組み合わせは比較しない
+func Compare(baseline, current []Result, threshold float64) []Regression {
+	type key struct {
+		store string
+		notes int
+		op    string
+	}
+	base := make(map[key]Result, len(baseline))
+	for _, r := range baseline {
+		base[key{r.Store, r.Notes, r.Op}] = r
+	}
+	var regressions []Regression
+	for _, cur := range current {
+		b, ok := base[key{cur.Store, cur.Notes, cur.Op}]
+		if !ok || b.P50 <= 0 {
+			continue
+		}
+		ratio := float64(cur.P50) / float64(b.P50)
+		if ratio > 1+threshold {
+			regressions = append(regressions, Regression{Baseline: b, Current: cur, Ratio: ratio})
+		}
+	}
+	return regressions
+}
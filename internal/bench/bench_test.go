@@ -0,0 +1,218 @@
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/store"
+	"github.com/google/uuid"
+)
+
+// TestRun はサイズごとにノートを追加しながら各操作を計測することをテスト
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemoryStore()
+	if err := st.Initialize(ctx, "bench:random:8"); err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	var progress []int
+	results, err := Run(ctx, st, Options{
+		Store:     "memory",
+		Sizes:     []int{50, 20, 50},
+		Dim:       8,
+		Queries:   5,
+		ProjectID: "/bench",
+		Progress:  func(notes int) { progress = append(progress, notes) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// サイズは並べ替えて重複を除き、前のサイズまでのノートに追加していく
+	if len(progress) != 2 || progress[0] != 20 || progress[1] != 50 {
+		t.Errorf("unexpected progress: %v", progress)
+	}
+	if len(results) != 8 {
+		t.Fatalf("expected 8 results, got %d", len(results))
+	}
+	want := []struct {
+		notes int
+		op    string
+		count int
+	}{
+		{20, OpAdd, 20}, {20, OpSearch, 5}, {20, OpSearchGroup, 5}, {20, OpListRecent, 5},
+		{50, OpAdd, 30}, {50, OpSearch, 5}, {50, OpSearchGroup, 5}, {50, OpListRecent, 5},
+	}
+	for i, w := range want {
+		r := results[i]
+		if r.Store != "memory" || r.Notes != w.notes || r.Op != w.op || r.Count != w.count {
+			t.Errorf("result %d: expected %d %s x%d, got %+v", i, w.notes, w.op, w.count, r)
+		}
+		if r.P50 > r.P95 || r.P95 > r.P99 {
+			t.Errorf("result %d: percentiles out of order: %+v", i, r)
+		}
+	}
+
+	notes, err := st.ListNotes(ctx, "/bench")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 50 {
+		t.Errorf("expected 50 notes, got %d", len(notes))
+	}
+
+	if _, err := Run(ctx, st, Options{Sizes: []int{0}, Dim: 8, Queries: 1, ProjectID: "/bench"}); err == nil {
+		t.Error("expected error for a zero note count")
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := make([]time.Duration, 100)
+	for i := range sorted {
+		sorted[i] = time.Duration(i + 1)
+	}
+	for p, want := range map[int]time.Duration{50: 50, 95: 95, 99: 99} {
+		if got := percentile(sorted, p); got != want {
+			t.Errorf("p%d: expected %d, got %d", p, want, got)
+		}
+	}
+	if got := percentile(sorted[:1], 99); got != 1 {
+		t.Errorf("expected the only value, got %d", got)
+	}
+}
+
+// TestCompare はp50がthresholdを超えて遅くなった結果だけを返すことをテスト
+func TestCompare(t *testing.T) {
+	baseline := []Result{
+		{Store: "sqlite", Notes: 1000, Op: OpSearch, P50: 100},
+		{Store: "sqlite", Notes: 1000, Op: OpAdd, P50: 100},
+		{Store: "memory", Notes: 1000, Op: OpSearch, P50: 10},
+	}
+	current := []Result{
+		{Store: "sqlite", Notes: 1000, Op: OpSearch, P50: 130},  // 30%遅い
+		{Store: "sqlite", Notes: 1000, Op: OpAdd, P50: 115},     // 閾値以内
+		{Store: "memory", Notes: 1000, Op: OpSearch, P50: 5},    // 速くなった
+		{Store: "sqlite", Notes: 10000, Op: OpSearch, P50: 900}, // baselineにない
+	}
+	regressions := Compare(baseline, current, 0.2)
+	if len(regressions) != 1 {
+		t.Fatalf("expected 1 regression, got %+v", regressions)
+	}
+	if r := regressions[0]; r.Current.Op != OpSearch || r.Current.Store != "sqlite" || r.Ratio != 1.3 {
+		t.Errorf("unexpected regression: %+v", r)
+	}
+}
+
+// benchDim はBenchmarkStoresのベクトルの次元数
+const benchDim = 384
+
+// BenchmarkStores は各ストアで1k・10k・100k件のノートに対するadd・search・list_recentを計測する
+// （100k件は -short で省略。qdrantはQDRANT_URL（デフォルト http://localhost:6333）に接続できなければ省略）
+//
+//	go test -run xxx -bench Stores -benchtime 100x ./internal/bench/
+func BenchmarkStores(b *testing.B) {
+	backends := []struct {
+		name string
+		open func(b *testing.B) store.Store
+	}{
+		{"memory", func(b *testing.B) store.Store { return store.NewMemoryStore() }},
+		{"sqlite", func(b *testing.B) store.Store {
+			st, err := store.NewSQLiteStore(filepath.Join(b.TempDir(), "bench.db"))
+			if err != nil {
+				b.Fatal(err)
+			}
+			return st
+		}},
+		{"qdrant", func(b *testing.B) store.Store {
+			url := os.Getenv("QDRANT_URL")
+			if url == "" {
+				url = "http://localhost:6333"
+			}
+			st, err := store.NewQdrantStore(url)
+			if errors.Is(err, store.ErrConnectionFailed) {
+				b.Skip("Qdrant is not available, skipping benchmark")
+			}
+			if err != nil {
+				b.Fatal(err)
+			}
+			return st
+		}},
+	}
+	sizes := []int{1000, 10000, 100000}
+	if testing.Short() {
+		sizes = sizes[:2]
+	}
+
+	for _, backend := range backends {
+		b.Run(backend.name, func(b *testing.B) {
+			ctx := context.Background()
+			st := backend.open(b)
+			defer st.Close()
+			if err := st.Initialize(ctx, fmt.Sprintf("bench:random:%d", benchDim)); err != nil {
+				b.Fatal(err)
+			}
+			projectID := "/bench/" + uuid.NewString()
+			defer func() {
+				for _, groupID := range groupIDs {
+					st.DeleteNotesByGroup(ctx, projectID, groupID)
+				}
+			}()
+
+			r := rand.New(rand.NewPCG(1, 2))
+			added := 0
+			addNote := func() error {
+				note := &model.Note{ID: uuid.NewString(), ProjectID: projectID, GroupID: groupIDs[added%len(groupIDs)], Text: "benchmark note", Tags: []string{}}
+				added++
+				return st.AddNote(ctx, note, RandomVector(r, benchDim))
+			}
+			groupID := groupIDs[0]
+			query := RandomVector(r, benchDim)
+
+			for _, size := range sizes {
+				for added < size {
+					if err := addNote(); err != nil {
+						b.Fatal(err)
+					}
+				}
+				b.Run(fmt.Sprintf("%d/search", size), func(b *testing.B) {
+					for b.Loop() {
+						if _, err := st.Search(ctx, query, store.SearchOptions{ProjectID: projectID, TopK: 10}); err != nil {
+							b.Fatal(err)
+						}
+					}
+				})
+				b.Run(fmt.Sprintf("%d/search_group", size), func(b *testing.B) {
+					for b.Loop() {
+						if _, err := st.Search(ctx, query, store.SearchOptions{ProjectID: projectID, GroupID: &groupID, TopK: 10}); err != nil {
+							b.Fatal(err)
+						}
+					}
+				})
+				b.Run(fmt.Sprintf("%d/list_recent", size), func(b *testing.B) {
+					for b.Loop() {
+						if _, err := st.ListRecent(ctx, store.ListOptions{ProjectID: projectID, Limit: 10}); err != nil {
+							b.Fatal(err)
+						}
+					}
+				})
+				// addは最後に計測する（追加した分だけ次のサイズまでに追加するノートが減る）
+				b.Run(fmt.Sprintf("%d/add", size), func(b *testing.B) {
+					for b.Loop() {
+						if err := addNote(); err != nil {
+							b.Fatal(err)
+						}
+					}
+				})
+			}
+		})
+	}
+}
@@ -4,8 +4,10 @@ package bootstrap
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/brbranch/embedding_mcp/internal/config"
 	"github.com/brbranch/embedding_mcp/internal/embedder"
@@ -16,16 +18,64 @@ import (
 
 // Services は初期化されたサービス群を保持
 type Services struct {
-	NoteService   service.NoteService
-	ConfigService service.ConfigService
-	GlobalService service.GlobalService
-	GroupService  service.GroupService
-	Config        *model.Config
-	Namespace     string
+	NoteService    service.NoteService
+	ConfigService  service.ConfigService
+	GlobalService  service.GlobalService
+	GroupService   service.GroupService
+	ExportService  service.ExportService
+	SyncService    service.SyncService
+	MigrateService service.MigrateService
+	MergeService   service.MergeService
+	RepairService  service.RepairService
+	StatsService   service.StatsService // memory.stats・/health・serveのノート数の定期確認用
+	ArchiveService service.ArchiveService
+	// SelfTestService はserveの --self-test で使う
+	SelfTestService service.SelfTestService
+	Config          *model.Config
+	Namespace       string
+}
+
+// Option はInitializeのオプション
+type Option func(*options)
+
+type options struct {
+	dataDir     string
+	embedder    *model.EmbedderConfig
+	previous    *model.EmbedderConfig
+	setPrevious bool
+}
+
+// WithDataDir は設定ファイル・環境変数より優先するデータディレクトリを指定する（serve --data-dir）
+func WithDataDir(dir string) Option {
+	return func(o *options) {
+		o.dataDir = dir
+	}
+}
+
+// WithEmbedder は設定ファイルのembedder設定の代わりにcfgを使う（memory.set_configによる変更用）
+// 設定ファイルは変更しない
+func WithEmbedder(cfg *model.EmbedderConfig) Option {
+	return func(o *options) {
+		o.embedder = cfg
+	}
+}
+
+// WithPreviousEmbedder は設定ファイルのpreviousEmbedderの代わりにcfg（nilなら移行期間なし）を使う
+// （memory.set_configによる変更用）。設定ファイルは変更しない
+func WithPreviousEmbedder(cfg *model.EmbedderConfig) Option {
+	return func(o *options) {
+		o.previous = cfg
+		o.setPrevious = true
+	}
 }
 
 // Initialize は設定を読み込み、必要なサービスを初期化する
-func Initialize(ctx context.Context, configPath string) (*Services, func(), error) {
+func Initialize(ctx context.Context, configPath string, opts ...Option) (*Services, func(), error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
 	// 設定マネージャーの作成
 	configManager, err := config.NewManager(configPath)
 	if err != nil {
@@ -38,76 +88,397 @@ func Initialize(ctx context.Context, configPath string) (*Services, func(), erro
 	}
 
 	cfg := configManager.GetConfig()
+	if o.dataDir != "" {
+		dataDir, err := config.ResolveDataDir(o.dataDir)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid data dir: %w", err)
+		}
+		cfg.Paths.DataDir = dataDir
+	}
+	if o.setPrevious {
+		cfg.PreviousEmbedder = o.previous
+	}
+	if o.embedder != nil || o.setPrevious {
+		if o.embedder != nil {
+			cfg.Embedder = *o.embedder
+		}
+		if err := config.Validate(cfg); err != nil {
+			return nil, nil, err
+		}
+	}
+	// apiKeyFrom（キーチェーン）からAPIキーを取得
+	if err := config.ResolveSecrets(cfg); err != nil {
+		return nil, nil, fmt.Errorf("failed to resolve secrets: %w", err)
+	}
+
+	// projectIdの正規化ルール（CanonicalizeProjectIDはプロセス全体で共有）
+	registryPath := config.ProjectRegistryPath(configManager.GetConfigPath())
+	aliases, err := config.LoadProjectAliases(registryPath, cfg.ProjectAliases)
+	if err != nil {
+		slog.Warn("failed to load project aliases", "path", registryPath, "error", err)
+		aliases = cfg.ProjectAliases
+	}
+	config.SetCanonicalizeRules(config.RulesFromConfig(cfg.ProjectID).WithAliases(aliases))
+	// ノートIDの生成ルール・大きさの上限・タグの正規化ルール（プロセス全体で共有）
+	service.SetNoteIDConfig(cfg.NoteID)
+	service.SetNoteLimits(service.NoteLimitsFromConfig(cfg.Limits))
+	service.SetTagRules(cfg.Tags)
 
 	// namespace生成
 	namespace := config.GenerateNamespace(cfg.Embedder.Provider, cfg.Embedder.Model, cfg.Embedder.Dim)
 
 	// 1. Embedder初期化
-	emb, err := embedder.NewEmbedder(&cfg.Embedder, os.Getenv("OPENAI_API_KEY"), configManager)
+	emb, err := newEmbedder(cfg, &cfg.Embedder, configManager)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create embedder: %w", err)
+		return nil, nil, err
 	}
 
 	// 2. Store初期化
-	var st store.Store
+	st, err := NewStore(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 3. Store初期化（namespace設定）
+	if err := st.Initialize(ctx, namespace); err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize store: %w", err)
+	}
+
+	// 4. Services初期化
+	// アーカイブしたノートはnamespaceごとにdataDir/archiveのコールドストアに置く（encryptionがあればストアと同じ鍵で暗号化する）
+	keys, err := encryptionKeys(cfg)
+	if err != nil {
+		st.Close()
+		return nil, nil, err
+	}
+	archive, err := store.NewEncryptedColdStore(filepath.Join(cfg.Paths.DataDir, "archive"), namespace, keys)
+	if err != nil {
+		st.Close()
+		return nil, nil, err
+	}
+	// embedderの移行期間中は変更前のnamespaceも読み出す
+	var baseNoteService service.NoteService = service.NewNoteServiceWithArchive(emb, st, archive, namespace)
+	closePrevious := func() {}
+	if prev := cfg.PreviousEmbedder; prev != nil && config.GenerateNamespace(prev.Provider, prev.Model, prev.Dim) != namespace {
+		prevService, closer, err := openRoute(ctx, cfg, prev)
+		if err != nil {
+			st.Close()
+			return nil, nil, fmt.Errorf("previousEmbedder: %w", err)
+		}
+		baseNoteService = newDualReadNoteService(baseNoteService, prevService)
+		closePrevious = closer
+	}
+	// embeddersがあればadd_noteのmodelで振り分け、検索・一覧は全てのnamespaceをまとめる
+	var models map[string]service.NoteService
+	closeModels := func() {}
+	if len(cfg.Embedders) > 0 {
+		models, closeModels, err = openModels(ctx, cfg, namespace)
+		if err != nil {
+			closePrevious()
+			st.Close()
+			return nil, nil, err
+		}
+		baseNoteService = newModelNoteService(baseNoteService, models)
+	}
+	// NoteServiceはプロジェクトローカル設定（.mcp-memory.json）を適用するラッパー経由で提供する
+	// プロジェクトのembedder上書きの振り分け先でもembeddersのmodelを使えるようにする
+	overlay := newOverlayNoteService(baseNoteService, cfg.Embedder, config.NewProjectOverlays(),
+		func(ctx context.Context, embCfg *model.EmbedderConfig) (service.NoteService, func(), error) {
+			svc, closer, err := openRoute(ctx, cfg, embCfg)
+			if err != nil || models == nil {
+				return svc, closer, err
+			}
+			return newModelNoteService(svc, models), closer, nil
+		})
+	overlay.preload(ctx, registryPath)
+	var noteService service.NoteService = overlay
+	configService := service.NewConfigService(configManager)
+	globalService := service.NewGlobalService(st, namespace)
+	groupService := service.NewGroupService(st, namespace)
+	exportService := service.NewExportService(emb, st, namespace)
+	syncService := service.NewSyncService(emb, st, namespace)
+	migrateService := service.NewMigrateService(emb, st, namespace, func(ctx context.Context, ns string) (store.Store, error) {
+		return openStore(ctx, cfg, ns)
+	})
+	mergeService := service.NewMergeService(st, namespace)
+	// Validateで修復できないノートはdataDir/quarantineに移す
+	repairService := service.NewRepairService(emb, st, store.NewColdStore(filepath.Join(cfg.Paths.DataDir, "quarantine"), namespace), namespace)
+	selfTestService := service.NewSelfTestService(emb, st, namespace)
+	statsService := service.NewStatsService(st, namespace, cfg.Store.Type, service.NoteThresholdsFromConfig(cfg.Store.NoteThresholds))
+	var afterDays int
+	if cfg.Archive != nil {
+		afterDays = cfg.Archive.AfterDays
+	}
+	archiveService := service.NewArchiveService(st, archive, namespace, afterDays)
+
+	cleanup := func() {
+		overlay.close()
+		closeModels()
+		closePrevious()
+		st.Close()
+	}
+
+	return &Services{
+		NoteService:     noteService,
+		ConfigService:   configService,
+		GlobalService:   globalService,
+		GroupService:    groupService,
+		ExportService:   exportService,
+		SyncService:     syncService,
+		MigrateService:  migrateService,
+		MergeService:    mergeService,
+		RepairService:   repairService,
+		SelfTestService: selfTestService,
+		StatsService:    statsService,
+		ArchiveService:  archiveService,
+		Config:          cfg,
+		Namespace:       namespace,
+	}, cleanup, nil
+}
+
+// openRoute はプロジェクトのembedder上書き用に、embCfgのnamespaceで別のStoreとNoteServiceを作成する
+// 次元数はグローバル設定に保存しない（DimUpdaterなし）
+func openRoute(ctx context.Context, cfg *model.Config, embCfg *model.EmbedderConfig) (service.NoteService, func(), error) {
+	namespace := config.GenerateNamespace(embCfg.Provider, embCfg.Model, embCfg.Dim)
+	emb, err := newEmbedder(cfg, embCfg, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	st, err := openStore(ctx, cfg, namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+	return service.NewNoteService(emb, st, namespace), func() { st.Close() }, nil
+}
+
+// openStore は設定のStoreをnamespaceで作成・初期化する
+func openStore(ctx context.Context, cfg *model.Config, namespace string) (store.Store, error) {
+	st, err := NewStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := st.Initialize(ctx, namespace); err != nil {
+		st.Close()
+		return nil, fmt.Errorf("failed to initialize store: %w", err)
+	}
+	return st, nil
+}
+
+// newEmbedder はembCfgのEmbedderを作成する
+// embedder.coalesceWindowがあれば並行する埋め込みをまとめ、logging.slowThresholdがあれば遅い埋め込みを警告する
+func newEmbedder(cfg *model.Config, embCfg *model.EmbedderConfig, dimUpdater embedder.DimUpdater) (embedder.Embedder, error) {
+	emb, err := embedder.NewEmbedder(embCfg, os.Getenv("OPENAI_API_KEY"), dimUpdater)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedder: %w", err)
+	}
+	if embCfg.CoalesceWindow != "" {
+		window, _ := config.ParseDuration(embCfg.CoalesceWindow)
+		emb = embedder.NewCoalescingEmbedder(emb, window)
+	}
+	namespace := config.GenerateNamespace(embCfg.Provider, embCfg.Model, embCfg.Dim)
+	return embedder.NewSlowLogEmbedder(emb, slowThreshold(cfg), namespace), nil
+}
+
+// slowThreshold はlogging.slowThresholdを返す（未設定なら0。値は設定の読み込み時に検証済み）
+func slowThreshold(cfg *model.Config) time.Duration {
+	if cfg.Logging.SlowThreshold == "" {
+		return 0
+	}
+	d, _ := config.ParseDuration(cfg.Logging.SlowThreshold)
+	return d
+}
+
+// NewStore は設定に応じたStoreを作成する（Initializeは呼び出し側で行う）
+// encryptionがあればノートを暗号化し、store.cacheSizeのキャッシュと、logging.slowThresholdがあれば遅い操作を警告するStoreでラップする
+// （キャッシュがストアの存在確認・復号を省けるよう、キャッシュを暗号化の外側、警告の内側にする）
+func NewStore(cfg *model.Config) (store.Store, error) {
+	keys, err := encryptionKeys(cfg)
+	if err != nil {
+		return nil, err
+	}
+	st, err := newStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := store.NewEncryptedStore(st, keys)
+	if err != nil {
+		st.Close()
+		return nil, err
+	}
+	st = store.NewCachedStore(encrypted, noteCacheSize(cfg))
+	return store.NewSlowLogStore(st, slowThreshold(cfg)), nil
+}
+
+// encryptionKeys はencryption.keysの鍵を返す（未設定ならnil。keyFromはResolveSecretsで解決済みであること）
+func encryptionKeys(cfg *model.Config) ([]store.EncryptionKey, error) {
+	if cfg.Encryption == nil {
+		return nil, nil
+	}
+	keys := make([]store.EncryptionKey, 0, len(cfg.Encryption.Keys))
+	for i, k := range cfg.Encryption.Keys {
+		if k.Key == nil || *k.Key == "" {
+			return nil, fmt.Errorf("encryption.keys[%d].key: not set", i)
+		}
+		key, err := config.ParseEncryptionKey(*k.Key)
+		if err != nil {
+			return nil, fmt.Errorf("encryption.keys[%d].key: %w", i, err)
+		}
+		keys = append(keys, store.EncryptionKey{ID: k.ID, Key: key})
+	}
+	return keys, nil
+}
+
+// noteCacheSize はstore.cacheSizeを返す（省略時はstore.DefaultNoteCacheSize。ノートをメモリに持つmemoryストアは0）
+func noteCacheSize(cfg *model.Config) int {
+	if cfg.Store.Type != model.StoreTypeSQLite && cfg.Store.Type != model.StoreTypeQdrant {
+		return 0
+	}
+	if cfg.Store.CacheSize == nil {
+		return store.DefaultNoteCacheSize
+	}
+	return *cfg.Store.CacheSize
+}
+
+// newStore は設定のstore.typeのStoreを作成する
+func newStore(cfg *model.Config) (store.Store, error) {
+	conn, err := parseConnection(cfg.Store.Connection)
+	if err != nil {
+		return nil, err
+	}
+
 	switch cfg.Store.Type {
 	case "chroma":
 		url := "http://localhost:8000"
 		if cfg.Store.URL != nil && *cfg.Store.URL != "" {
 			url = *cfg.Store.URL
 		}
-		st, err = store.NewChromaStore(url)
+		var opts []store.ChromaOption
+		if conn.requestTimeout > 0 {
+			opts = append(opts, store.WithChromaTimeout(conn.requestTimeout))
+		}
+		st, err := store.NewChromaStore(url, opts...)
 		if err != nil {
-			return nil, nil, fmt.Errorf("failed to create store: %w", err)
+			return nil, fmt.Errorf("failed to create store: %w", err)
 		}
+		return st, nil
 	case "sqlite":
-		// SQLiteのDBパスを決定
-		dbPath := cfg.Paths.DataDir + "/memory.db"
-		if cfg.Store.Path != nil && *cfg.Store.Path != "" {
-			dbPath = *cfg.Store.Path
-		}
+		dbPath := SQLitePath(cfg)
 		// DBファイルの親ディレクトリを作成
 		if err := config.EnsureDir(filepath.Dir(dbPath)); err != nil {
-			return nil, nil, fmt.Errorf("failed to create data directory: %w", err)
+			return nil, fmt.Errorf("failed to create data directory: %w", err)
+		}
+		var opts []store.SQLiteOption
+		if conn.busyTimeout > 0 {
+			opts = append(opts, store.WithSQLiteBusyTimeout(conn.busyTimeout))
 		}
-		st, err = store.NewSQLiteStore(dbPath)
+		if conn.poolSize > 0 {
+			opts = append(opts, store.WithSQLitePoolSize(conn.poolSize))
+		}
+		if cfg.Store.GlobalsScope == model.GlobalsScopeShared {
+			opts = append(opts, store.WithSQLiteSharedGlobals())
+		}
+		st, err := store.NewSQLiteStore(dbPath, opts...)
 		if err != nil {
-			return nil, nil, fmt.Errorf("failed to create sqlite store: %w", err)
+			return nil, fmt.Errorf("failed to create sqlite store: %w", err)
 		}
+		return st, nil
 	case "qdrant":
 		url := "http://localhost:6333"
 		if cfg.Store.URL != nil && *cfg.Store.URL != "" {
 			url = *cfg.Store.URL
 		}
-		st, err = store.NewQdrantStore(url)
+		var opts []store.QdrantOption
+		if cfg.Store.APIKey != nil && *cfg.Store.APIKey != "" {
+			opts = append(opts, store.WithQdrantAPIKey(*cfg.Store.APIKey))
+		}
+		if conn.connectTimeout > 0 {
+			opts = append(opts, store.WithQdrantConnectTimeout(conn.connectTimeout))
+		}
+		if conn.requestTimeout > 0 {
+			opts = append(opts, store.WithQdrantRequestTimeout(conn.requestTimeout))
+		}
+		if conn.keepAliveSet {
+			opts = append(opts, store.WithQdrantKeepAlive(conn.keepAlive))
+		}
+		if conn.keepAliveTimeout > 0 {
+			opts = append(opts, store.WithQdrantKeepAliveTimeout(conn.keepAliveTimeout))
+		}
+		if conn.poolSize > 0 {
+			opts = append(opts, store.WithQdrantPoolSize(conn.poolSize))
+		}
+		if conn.maxRetries > 0 {
+			opts = append(opts, store.WithQdrantMaxRetries(conn.maxRetries))
+		}
+		if cfg.Store.GlobalsScope == model.GlobalsScopeShared {
+			opts = append(opts, store.WithQdrantSharedGlobals())
+		}
+		st, err := store.NewQdrantStore(url, opts...)
 		if err != nil {
-			return nil, nil, fmt.Errorf("failed to create qdrant store: %w", err)
+			return nil, fmt.Errorf("failed to create qdrant store: %w", err)
 		}
+		return st, nil
 	default:
-		st = store.NewMemoryStore()
-	}
-
-	// 3. Store初期化（namespace設定）
-	if err := st.Initialize(ctx, namespace); err != nil {
-		return nil, nil, fmt.Errorf("failed to initialize store: %w", err)
+		var opts []store.MemoryOption
+		if cfg.Store.Journal {
+			opts = append(opts, store.WithMemoryJournal(filepath.Join(cfg.Paths.DataDir, "journal")))
+		}
+		return store.NewMemoryStore(opts...), nil
 	}
+}
 
-	// 4. Services初期化
-	noteService := service.NewNoteService(emb, st, namespace)
-	configService := service.NewConfigService(configManager)
-	globalService := service.NewGlobalService(st, namespace)
-	groupService := service.NewGroupService(st, namespace)
+// connectionSettings はstore.connectionを解析した値（未指定は0）
+type connectionSettings struct {
+	connectTimeout   time.Duration
+	requestTimeout   time.Duration
+	keepAlive        time.Duration
+	keepAliveTimeout time.Duration
+	keepAliveSet     bool // keepAliveが指定されたか（"0"は無効化）
+	busyTimeout      time.Duration
+	poolSize         int
+	maxRetries       int
+}
 
-	cleanup := func() {
-		st.Close()
+// parseConnection はstore.connectionの時間を解析する
+func parseConnection(c *model.StoreConnectionConfig) (*connectionSettings, error) {
+	s := &connectionSettings{}
+	if c == nil {
+		return s, nil
+	}
+	durations := []struct {
+		key   string
+		value string
+		dst   *time.Duration
+	}{
+		{"connectTimeout", c.ConnectTimeout, &s.connectTimeout},
+		{"requestTimeout", c.RequestTimeout, &s.requestTimeout},
+		{"keepAlive", c.KeepAlive, &s.keepAlive},
+		{"keepAliveTimeout", c.KeepAliveTimeout, &s.keepAliveTimeout},
+		{"busyTimeout", c.BusyTimeout, &s.busyTimeout},
 	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		v, err := config.ParseDuration(d.value)
+		if err != nil {
+			return nil, fmt.Errorf("store.connection.%s: %w", d.key, err)
+		}
+		*d.dst = v
+	}
+	s.keepAliveSet = c.KeepAlive != ""
+	s.poolSize, s.maxRetries = c.PoolSize, c.MaxRetries
+	return s, nil
+}
 
-	return &Services{
-		NoteService:   noteService,
-		ConfigService: configService,
-		GlobalService: globalService,
-		GroupService:  groupService,
-		Config:        cfg,
-		Namespace:     namespace,
-	}, cleanup, nil
+// SQLitePath はSQLiteのDBパスを返す（store.path > dataDir/memory.db）
+// store.pathが相対パスの場合はdataDirからの相対として扱う
+func SQLitePath(cfg *model.Config) string {
+	if cfg.Store.Path != nil && *cfg.Store.Path != "" {
+		path, err := config.ResolveDataPath(cfg.Paths.DataDir, *cfg.Store.Path)
+		if err != nil {
+			return *cfg.Store.Path
+		}
+		return path
+	}
+	return filepath.Join(cfg.Paths.DataDir, "memory.db")
 }
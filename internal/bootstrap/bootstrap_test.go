@@ -4,7 +4,12 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/store"
 )
 
 func TestInitialize_WithValidConfig(t *testing.T) {
@@ -37,6 +42,12 @@ func TestInitialize_WithValidConfig(t *testing.T) {
 	if services.NoteService == nil {
 		t.Error("expected NoteService to be non-nil")
 	}
+	if services.ExportService == nil {
+		t.Error("expected ExportService to be non-nil")
+	}
+	if services.SyncService == nil {
+		t.Error("expected SyncService to be non-nil")
+	}
 	if services.Config == nil {
 		t.Error("expected Config to be non-nil")
 	}
@@ -54,3 +65,150 @@ func TestInitialize_WithNonExistentConfigPath(t *testing.T) {
 	// ただし、OpenAI API keyが必要なためスキップ
 	t.Skip("Skipping - requires OPENAI_API_KEY environment variable")
 }
+
+func TestInitialize_WithDataDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.json")
+	configContent := `{
+		"embedder": {"provider": "local", "model": "mock"},
+		"store": {"type": "memory"},
+		"paths": {"dataDir": "/from/config"}
+	}`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	dataDir := filepath.Join(tmpDir, "data")
+	services, cleanup, err := Initialize(context.Background(), configPath, WithDataDir(dataDir))
+	if err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer cleanup()
+
+	if services.Config.Paths.DataDir != dataDir {
+		t.Errorf("expected data dir %q, got %q", dataDir, services.Config.Paths.DataDir)
+	}
+}
+
+func TestSQLitePath(t *testing.T) {
+	dataDir := filepath.Join(t.TempDir(), "data")
+	abs := filepath.Join(t.TempDir(), "other.db")
+	rel := filepath.Join("dbs", "memory.db")
+
+	tests := []struct {
+		name   string
+		path   *string
+		expect string
+	}{
+		{"default", nil, filepath.Join(dataDir, "memory.db")},
+		{"absolute", &abs, abs},
+		{"relative to data dir", &rel, filepath.Join(dataDir, "dbs", "memory.db")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &model.Config{
+				Store: model.StoreConfig{Type: model.StoreTypeSQLite, Path: tt.path},
+				Paths: model.PathsConfig{DataDir: dataDir},
+			}
+			if got := SQLitePath(cfg); got != tt.expect {
+				t.Errorf("expected %q, got %q", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestParseConnection(t *testing.T) {
+	conn, err := parseConnection(&model.StoreConnectionConfig{
+		ConnectTimeout: "10s", KeepAlive: "0", BusyTimeout: "500ms", PoolSize: 2, MaxRetries: 3,
+	})
+	if err != nil {
+		t.Fatalf("parseConnection failed: %v", err)
+	}
+	if conn.connectTimeout != 10*time.Second || conn.busyTimeout != 500*time.Millisecond || conn.poolSize != 2 || conn.maxRetries != 3 {
+		t.Errorf("unexpected settings: %+v", conn)
+	}
+	// "0"はkeepaliveの無効化として区別する
+	if !conn.keepAliveSet || conn.keepAlive != 0 {
+		t.Errorf("expected keepAlive to be disabled, got %+v", conn)
+	}
+
+	if conn, err := parseConnection(nil); err != nil || *conn != (connectionSettings{}) {
+		t.Errorf("expected zero settings for nil, got %+v, %v", conn, err)
+	}
+	if _, err := parseConnection(&model.StoreConnectionConfig{RequestTimeout: "30"}); err == nil || !strings.Contains(err.Error(), "store.connection.requestTimeout") {
+		t.Errorf("expected an error with the config path, got %v", err)
+	}
+}
+
+func TestNoteCacheSize(t *testing.T) {
+	zero, size := 0, 10
+	tests := []struct {
+		store model.StoreConfig
+		want  int
+	}{
+		{model.StoreConfig{Type: model.StoreTypeQdrant}, store.DefaultNoteCacheSize},
+		{model.StoreConfig{Type: model.StoreTypeSQLite, CacheSize: &size}, 10},
+		{model.StoreConfig{Type: model.StoreTypeQdrant, CacheSize: &zero}, 0},
+		{model.StoreConfig{Type: "memory", CacheSize: &size}, 0},
+	}
+	for _, tt := range tests {
+		if got := noteCacheSize(&model.Config{Store: tt.store}); got != tt.want {
+			t.Errorf("noteCacheSize(%+v) = %d, want %d", tt.store, got, tt.want)
+		}
+	}
+}
+
+func TestNewStore_SQLiteConnection(t *testing.T) {
+	cfg := &model.Config{
+		Store: model.StoreConfig{Type: model.StoreTypeSQLite, Connection: &model.StoreConnectionConfig{BusyTimeout: "3s", PoolSize: 1}},
+		Paths: model.PathsConfig{DataDir: t.TempDir()},
+	}
+	st, err := NewStore(cfg)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	defer st.Close()
+	if err := st.Initialize(context.Background(), "test:model:3"); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+}
+
+// TestNewStore_Encryption はencryptionがあればストアにノートを暗号化して保存することをテスト
+func TestNewStore_Encryption(t *testing.T) {
+	ctx := context.Background()
+	key := "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	cfg := &model.Config{
+		Store:      model.StoreConfig{Type: model.StoreTypeSQLite},
+		Paths:      model.PathsConfig{DataDir: t.TempDir()},
+		Encryption: &model.EncryptionConfig{Keys: []model.EncryptionKeyConfig{{ID: "k1", Key: &key}}},
+	}
+	st, err := openStore(ctx, cfg, "test:model:3")
+	if err != nil {
+		t.Fatalf("openStore failed: %v", err)
+	}
+	note := &model.Note{ID: "note-1", ProjectID: "/proj", GroupID: "global", Text: "secret", Tags: []string{}}
+	if err := st.AddNote(ctx, note, []float32{1, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := st.Get(ctx, "note-1"); err != nil || got.Text != "secret" {
+		t.Errorf("unexpected note: %+v, %v", got, err)
+	}
+	st.Close()
+
+	plain := *cfg
+	plain.Encryption = nil
+	raw, err := openStore(ctx, &plain, "test:model:3")
+	if err != nil {
+		t.Fatalf("openStore failed: %v", err)
+	}
+	defer raw.Close()
+	if got, err := raw.Get(ctx, "note-1"); err != nil || !strings.HasPrefix(got.Text, "mcpenc:v1:k1:") {
+		t.Errorf("expected ciphertext in the store, got %+v, %v", got, err)
+	}
+
+	// keyFromが解決されていなければエラー
+	cfg.Encryption.Keys[0] = model.EncryptionKeyConfig{ID: "k1", KeyFrom: "keychain:mcp-memory"}
+	if _, err := NewStore(cfg); err == nil || !strings.Contains(err.Error(), "encryption.keys[0].key") {
+		t.Errorf("expected an error for the unresolved key, got %v", err)
+	}
+}
@@ -0,0 +1,232 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/service"
+	"github.com/brbranch/embedding_mcp/internal/store"
+)
+
+// dualReadNoteService はembedderの移行期間中（previousEmbedder設定時）に、
+// 変更前のnamespaceのノートも読み出すNoteService
+//   - add_note: 現在のnamespaceに追加する
+//   - search/list_recent: 両方の結果をまとめる（移行済みで現在のnamespaceにあるノートは変更前の側を除く）
+//   - get/update: 現在のnamespaceから探し、なければ変更前のnamespaceを探す
+//   - delete: 移行済みのノートが再び現れないよう両方から削除する
+//
+// 変更前のnamespaceの検索は変更前のembedderでクエリを埋め込む。失敗した場合は現在のnamespaceの結果のみ返す
+type dualReadNoteService struct {
+	current  service.NoteService
+	previous service.NoteService
+}
+
+func newDualReadNoteService(current, previous service.NoteService) *dualReadNoteService {
+	return &dualReadNoteService{current: current, previous: previous}
+}
+
+// AddNote は現在のnamespaceにノートを追加する
+func (s *dualReadNoteService) AddNote(ctx context.Context, req *service.AddNoteRequest) (*service.AddNoteResponse, error) {
+	return s.current.AddNote(ctx, req)
+}
+
+// AddNotes は現在のnamespaceにノートを一括追加する
+func (s *dualReadNoteService) AddNotes(ctx context.Context, req *service.AddNotesRequest) (*service.AddNotesResponse, error) {
+	return s.current.AddNotes(ctx, req)
+}
+
+// migrated はidのノートが現在のnamespaceにあるか（移行済みか）を返す
+func (s *dualReadNoteService) migrated(ctx context.Context, id string) bool {
+	_, err := s.current.Get(ctx, id)
+	return err == nil
+}
+
+// Search は両方のnamespaceで検索し、スコア順にまとめてtopK件を返す
+// スコアはどちらも0-1に正規化されているが、モデルが異なるため厳密には比較できない
+func (s *dualReadNoteService) Search(ctx context.Context, req *service.SearchRequest) (*service.SearchResponse, error) {
+	resp, err := s.current.Search(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	prev, err := s.previous.Search(ctx, req)
+	if err != nil {
+		slog.Warn("failed to search the previous namespace", "error", err)
+		return resp, nil
+	}
+
+	seen := make(map[string]bool, len(resp.Results))
+	for _, r := range resp.Results {
+		seen[r.ID] = true
+	}
+	for _, r := range prev.Results {
+		if !seen[r.ID] && !s.migrated(ctx, r.ID) {
+			resp.Results = append(resp.Results, r)
+		}
+	}
+	rankMerged(resp, req)
+	return resp, nil
+}
+
+// rankMerged は複数のnamespaceからまとめたresp.Resultsをスコア順に並べてtopK件にする
+// groupBy指定時はまとめた結果をgroupIdごとに分け直す
+func rankMerged(resp *service.SearchResponse, req *service.SearchRequest) {
+	topK := 5
+	if req.TopK != nil {
+		topK = *req.TopK
+	}
+	if req.GroupBy == service.SearchGroupByGroup {
+		groupTopK := service.DefaultGroupTopK
+		if req.GroupTopK != nil {
+			groupTopK = *req.GroupTopK
+		}
+		resp.Results, resp.Groups = service.GroupSearchResults(resp.Results, groupTopK, topK)
+		return
+	}
+	sort.SliceStable(resp.Results, func(i, j int) bool {
+		return resp.Results[i].Score > resp.Results[j].Score
+	})
+	if topK >= 0 && len(resp.Results) > topK {
+		resp.Results = resp.Results[:topK]
+	}
+}
+
+// ListRecent は両方のnamespaceの最新一覧をcreatedAt（sortBy指定時はupdatedAt）降順にまとめてlimit件を返す
+// cursorは両方のnamespaceに同じものを渡す（どちらも同じ並び順のため、まとめた一覧の続きになる）
+// totalは両方の件数の合計で、移行済みのノートを重複して数えうるため概数とする
+func (s *dualReadNoteService) ListRecent(ctx context.Context, req *service.ListRecentRequest) (*service.ListRecentResponse, error) {
+	resp, err := s.current.ListRecent(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	prev, err := s.previous.ListRecent(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Total += prev.Total
+	resp.TotalApproximate = resp.TotalApproximate || prev.TotalApproximate || prev.Total > 0
+	more := resp.NextCursor != "" || prev.NextCursor != ""
+
+	seen := make(map[string]bool, len(resp.Items))
+	for _, item := range resp.Items {
+		seen[item.ID] = true
+	}
+	for _, item := range prev.Items {
+		if !seen[item.ID] && !s.migrated(ctx, item.ID) {
+			resp.Items = append(resp.Items, item)
+		}
+	}
+	pageMerged(resp, req, more)
+	return resp, nil
+}
+
+// pageMerged は複数のnamespaceからまとめたresp.Itemsを一覧の並び順に並べてlimit件にし、nextCursorを付け直す
+// moreはいずれかのnamespaceに続きがあるか
+func pageMerged(resp *service.ListRecentResponse, req *service.ListRecentRequest, more bool) {
+	// createdAt・updatedAtはRFC3339（UTC）のため時刻として比較する（小数秒の有無で文字列比較はずれる）
+	key := func(item service.ListRecentItem) string {
+		if req.SortBy == store.SortByUpdatedAt {
+			return item.UpdatedAt
+		}
+		return item.CreatedAt
+	}
+	// 同時刻はID降順（Storeの並び順と同じ）
+	sort.SliceStable(resp.Items, func(i, j int) bool {
+		ti, _ := time.Parse(time.RFC3339, key(resp.Items[i]))
+		tj, _ := time.Parse(time.RFC3339, key(resp.Items[j]))
+		if ti.Equal(tj) {
+			return resp.Items[i].ID > resp.Items[j].ID
+		}
+		return ti.After(tj)
+	})
+	limit := 10
+	if req.Limit != nil {
+		limit = *req.Limit
+	}
+	if limit >= 0 && len(resp.Items) > limit {
+		resp.Items = resp.Items[:limit]
+		more = true
+	}
+	resp.NextCursor = ""
+	if more && len(resp.Items) > 0 {
+		resp.NextCursor = service.ListRecentCursor(&resp.Items[len(resp.Items)-1], req.SortBy)
+	}
+}
+
+// Get は現在のnamespace、変更前のnamespaceの順にノートを探す
+func (s *dualReadNoteService) Get(ctx context.Context, id string) (*service.GetResponse, error) {
+	resp, err := s.current.Get(ctx, id)
+	if errors.Is(err, service.ErrNoteNotFound) {
+		return s.previous.Get(ctx, id)
+	}
+	return resp, err
+}
+
+// GetDocument は現在のnamespace、変更前のnamespaceの順に文書を探す
+func (s *dualReadNoteService) GetDocument(ctx context.Context, id string) (*service.GetResponse, error) {
+	resp, err := s.current.GetDocument(ctx, id)
+	if errors.Is(err, service.ErrNoteNotFound) {
+		return s.previous.GetDocument(ctx, id)
+	}
+	return resp, err
+}
+
+// Update はノートを更新する（未移行のノートは変更前のnamespaceで更新する）
+func (s *dualReadNoteService) Update(ctx context.Context, req *service.UpdateRequest) error {
+	err := s.current.Update(ctx, req)
+	if errors.Is(err, service.ErrNoteNotFound) {
+		return s.previous.Update(ctx, req)
+	}
+	return err
+}
+
+// Delete は両方のnamespaceからノートを削除する（どちらにもなければErrNoteNotFound）
+func (s *dualReadNoteService) Delete(ctx context.Context, id string) error {
+	err := s.current.Delete(ctx, id)
+	if err != nil && !errors.Is(err, service.ErrNoteNotFound) {
+		return err
+	}
+	prevErr := s.previous.Delete(ctx, id)
+	if prevErr != nil && !errors.Is(prevErr, service.ErrNoteNotFound) {
+		return prevErr
+	}
+	if err != nil && prevErr != nil {
+		return err
+	}
+	return nil
+}
+
+// ListProjects は両方のnamespaceのプロジェクト一覧をまとめて返す
+// 移行済みのノートは両方で数えるため、noteCountは目安
+func (s *dualReadNoteService) ListProjects(ctx context.Context) (*service.ListProjectsResponse, error) {
+	resp, err := s.current.ListProjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+	prev, err := s.previous.ListProjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mergeProjects(resp, prev)
+	return resp, nil
+}
+
+// mergeProjects はothersのプロジェクト一覧をrespにまとめ、projectIdごとにnoteCountを合計する
+func mergeProjects(resp *service.ListProjectsResponse, others ...*service.ListProjectsResponse) {
+	counts := make(map[string]int)
+	for _, r := range append([]*service.ListProjectsResponse{resp}, others...) {
+		for _, p := range r.Projects {
+			counts[p.ProjectID] += p.NoteCount
+		}
+	}
+	resp.Projects = resp.Projects[:0]
+	for id, n := range counts {
+		resp.Projects = append(resp.Projects, service.ProjectItem{ProjectID: id, NoteCount: n})
+	}
+	sort.Slice(resp.Projects, func(i, j int) bool {
+		return resp.Projects[i].ProjectID < resp.Projects[j].ProjectID
+	})
+}
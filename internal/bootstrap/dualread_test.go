@@ -0,0 +1,140 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/service"
+	"github.com/brbranch/embedding_mcp/internal/store"
+)
+
+// newTestDualRead は現在・変更前のnamespaceにノートを入れたdualReadNoteServiceを作成する
+//   - current: "new"、移行済みの"both"
+//   - previous: "old"、"both"
+func newTestDualRead(t *testing.T) *dualReadNoteService {
+	t.Helper()
+	ctx := context.Background()
+	open := func(namespace string, notes map[string]string) service.NoteService {
+		st := store.NewMemoryStore()
+		if err := st.Initialize(ctx, namespace); err != nil {
+			t.Fatal(err)
+		}
+		for id, createdAt := range notes {
+			note := &model.Note{ID: id, ProjectID: "/test/project", GroupID: "global", Text: id, Tags: []string{}, CreatedAt: &createdAt}
+			if err := st.AddNote(ctx, note, []float32{0.1, 0.2, 0.3}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return service.NewNoteService(stubEmbedder{}, st, namespace)
+	}
+	return newDualReadNoteService(
+		open("new:m:3", map[string]string{"new": "2024-01-03T00:00:00Z", "both": "2024-01-01T00:00:00Z"}),
+		open("old:m:3", map[string]string{"old": "2024-01-02T00:00:00Z", "both": "2024-01-01T00:00:00Z"}),
+	)
+}
+
+func TestDualReadNoteService_Read(t *testing.T) {
+	ctx := context.Background()
+	s := newTestDualRead(t)
+
+	search, err := s.Search(ctx, &service.SearchRequest{ProjectID: "/test/project", Query: "q"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if search.Namespace != "new:m:3" || len(search.Results) != 3 {
+		t.Errorf("expected 3 results without duplicates, got %s %+v", search.Namespace, search.Results)
+	}
+	topK := 2
+	if search, _ := s.Search(ctx, &service.SearchRequest{ProjectID: "/test/project", Query: "q", TopK: &topK}); len(search.Results) != 2 {
+		t.Errorf("expected topK results, got %d", len(search.Results))
+	}
+	// groupByは両方のnamespaceをまとめた結果をgroupIdごとに分け直す
+	groupTopK := 1
+	search, err = s.Search(ctx, &service.SearchRequest{ProjectID: "/test/project", Query: "q", GroupBy: service.SearchGroupByGroup, GroupTopK: &groupTopK})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(search.Results) != 1 || len(search.Groups) != 1 || search.Groups[0].GroupID != "global" || search.Groups[0].Count != 1 {
+		t.Errorf("expected 1 result in the global group, got %+v %+v", search.Results, search.Groups)
+	}
+
+	list, err := s.ListRecent(ctx, &service.ListRecentRequest{ProjectID: "/test/project"})
+	if err != nil {
+		t.Fatalf("ListRecent failed: %v", err)
+	}
+	var ids []string
+	for _, item := range list.Items {
+		ids = append(ids, item.ID+"@"+item.Namespace)
+	}
+	want := []string{"new@new:m:3", "old@old:m:3", "both@new:m:3"}
+	if len(ids) != len(want) || ids[0] != want[0] || ids[1] != want[1] || ids[2] != want[2] {
+		t.Errorf("expected %v, got %v", want, ids)
+	}
+	// 移行済みの"both"を両方で数えるため、totalは概数
+	if list.Total != 4 || !list.TotalApproximate || list.NextCursor != "" {
+		t.Errorf("unexpected total/cursor: %d %v %q", list.Total, list.TotalApproximate, list.NextCursor)
+	}
+
+	// cursorで両方のnamespaceをまとめた一覧の続きを取得する
+	limit := 1
+	ids = nil
+	req := &service.ListRecentRequest{ProjectID: "/test/project", Limit: &limit}
+	for range 5 {
+		page, err := s.ListRecent(ctx, req)
+		if err != nil {
+			t.Fatalf("ListRecent failed: %v", err)
+		}
+		for _, item := range page.Items {
+			ids = append(ids, item.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		req.Cursor = page.NextCursor
+	}
+	if len(ids) != 3 || ids[0] != "new" || ids[1] != "old" || ids[2] != "both" {
+		t.Errorf("expected paged [new old both], got %v", ids)
+	}
+
+	got, err := s.Get(ctx, "old")
+	if err != nil || got.Namespace != "old:m:3" {
+		t.Errorf("expected note from the previous namespace, got %+v, %v", got, err)
+	}
+
+	projects, err := s.ListProjects(ctx)
+	if err != nil {
+		t.Fatalf("ListProjects failed: %v", err)
+	}
+	if len(projects.Projects) != 1 || projects.Projects[0].NoteCount != 4 {
+		t.Errorf("unexpected projects: %+v", projects.Projects)
+	}
+}
+
+func TestDualReadNoteService_Write(t *testing.T) {
+	ctx := context.Background()
+	s := newTestDualRead(t)
+
+	resp, err := s.AddNote(ctx, &service.AddNoteRequest{ProjectID: "/test/project", GroupID: "global", Text: "added"})
+	if err != nil {
+		t.Fatalf("AddNote failed: %v", err)
+	}
+	if resp.Namespace != "new:m:3" {
+		t.Errorf("expected the note in the current namespace, got %s", resp.Namespace)
+	}
+
+	// 移行済みのノートは両方から削除され、再び現れない
+	if err := s.Delete(ctx, "both"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := s.Get(ctx, "both"); !errors.Is(err, service.ErrNoteNotFound) {
+		t.Errorf("expected ErrNoteNotFound, got %v", err)
+	}
+	if err := s.Delete(ctx, "old"); err != nil {
+		t.Errorf("Delete of an unmigrated note failed: %v", err)
+	}
+	if err := s.Delete(ctx, "missing"); !errors.Is(err, service.ErrNoteNotFound) {
+		t.Errorf("expected ErrNoteNotFound, got %v", err)
+	}
+}
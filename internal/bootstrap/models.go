@@ -0,0 +1,288 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// modelNoteService は設定のembeddersで名前を付けた追加のembedderにノートを振り分けるNoteService
+//   - add_note/add_notes: modelの名前のembedderのnamespaceに追加する（空ならbase）
+//   - search/list_recent/list_projects: baseと全ての追加のnamespaceの結果をまとめる
+//   - get/update/delete: base、追加のnamespace（名前順）の順にノートを探す
+//
+// 追加のnamespaceの検索はそれぞれのembedderでクエリを埋め込む。スコアはどれも0-1に正規化されているが、
+// モデルが異なるため厳密には比較できない。追加のnamespaceの検索に失敗した場合はその結果を除いて返す
+type modelNoteService struct {
+	base   service.NoteService
+	models map[string]service.NoteService // nilはbaseと同じnamespace
+	routes []service.NoteService          // 追加のnamespace（名前順、重複なし）
+}
+
+func newModelNoteService(base service.NoteService, models map[string]service.NoteService) *modelNoteService {
+	names := make([]string, 0, len(models))
+	for name := range models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	s := &modelNoteService{base: base, models: models}
+	seen := make(map[service.NoteService]bool)
+	for _, name := range names {
+		if svc := models[name]; svc != nil && !seen[svc] {
+			seen[svc] = true
+			s.routes = append(s.routes, svc)
+		}
+	}
+	return s
+}
+
+// openModels は設定のembeddersのNoteServiceを作成する（同じnamespaceになる名前は1つを共有する）
+// embedderと同じnamespaceになる名前はnil（baseを使う）にする
+func openModels(ctx context.Context, cfg *model.Config, namespace string) (map[string]service.NoteService, func(), error) {
+	names := make([]string, 0, len(cfg.Embedders))
+	for name := range cfg.Embedders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	models := make(map[string]service.NoteService, len(names))
+	byNamespace := make(map[string]service.NoteService)
+	var closers []func()
+	closeAll := func() {
+		for _, c := range closers {
+			c()
+		}
+	}
+	for _, name := range names {
+		embCfg := cfg.Embedders[name]
+		ns := config.GenerateNamespace(embCfg.Provider, embCfg.Model, embCfg.Dim)
+		if ns == namespace {
+			models[name] = nil
+			continue
+		}
+		if svc, ok := byNamespace[ns]; ok {
+			models[name] = svc
+			continue
+		}
+		svc, closer, err := openRoute(ctx, cfg, &embCfg)
+		if err != nil {
+			closeAll()
+			return nil, nil, fmt.Errorf("embedders.%s: %w", name, err)
+		}
+		models[name], byNamespace[ns] = svc, svc
+		closers = append(closers, closer)
+	}
+	return models, closeAll, nil
+}
+
+// route はmodelの名前の振り分け先を返す（req.Modelを空にしたコピーを渡す）
+func (s *modelNoteService) route(req *service.AddNoteRequest) (*service.AddNoteRequest, service.NoteService, error) {
+	if req.Model == "" {
+		return req, s.base, nil
+	}
+	svc, ok := s.models[req.Model]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %q", service.ErrUnknownModel, req.Model)
+	}
+	if svc == nil {
+		svc = s.base
+	}
+	routed := *req
+	routed.Model = ""
+	return &routed, svc, nil
+}
+
+// all はbaseと追加のnamespaceの振り分け先を返す
+func (s *modelNoteService) all() []service.NoteService {
+	return append([]service.NoteService{s.base}, s.routes...)
+}
+
+// AddNote はmodelの名前のnamespaceにノートを追加する
+func (s *modelNoteService) AddNote(ctx context.Context, req *service.AddNoteRequest) (*service.AddNoteResponse, error) {
+	routed, svc, err := s.route(req)
+	if err != nil {
+		return nil, err
+	}
+	return svc.AddNote(ctx, routed)
+}
+
+// AddNotes はmodelの名前のnamespaceごとにまとめてノートを追加する
+func (s *modelNoteService) AddNotes(ctx context.Context, req *service.AddNotesRequest) (*service.AddNotesResponse, error) {
+	notes := make([]service.AddNoteRequest, len(req.Notes))
+	svcs := make([]service.NoteService, len(req.Notes))
+	items := make([]service.ItemResult, len(req.Notes))
+	var firstErr error
+	single := true
+	for i := range req.Notes {
+		items[i] = service.ItemResult{Index: i, Status: service.ItemPending}
+		routed, svc, err := s.route(&req.Notes[i])
+		if err != nil {
+			items[i].Status, items[i].Err = service.ItemFailed, err
+			if firstErr == nil {
+				firstErr = fmt.Errorf("notes[%d]: %w", i, err)
+			}
+			continue
+		}
+		notes[i], svcs[i] = *routed, svc
+		single = single && svc == svcs[0]
+	}
+	if firstErr != nil {
+		return &service.AddNotesResponse{Items: items}, firstErr
+	}
+	if len(svcs) == 0 || single {
+		svc := s.base
+		if len(svcs) > 0 {
+			svc = svcs[0]
+		}
+		return svc.AddNotes(ctx, &service.AddNotesRequest{Notes: notes})
+	}
+	return addNotesRouted(ctx, notes, svcs, items)
+}
+
+// fanOutAll はsvcsにfnを並行して呼び出し、それぞれの結果とエラーをsvcsの順に返す
+func fanOutAll[T any](svcs []service.NoteService, fn func(service.NoteService) (T, error)) ([]T, []error) {
+	results := make([]T, len(svcs))
+	errs := make([]error, len(svcs))
+	var wg sync.WaitGroup
+	for i, svc := range svcs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = fn(svc)
+		}()
+	}
+	wg.Wait()
+	return results, errs
+}
+
+// Search は全てのnamespaceで検索し、スコア順にまとめてtopK件を返す
+func (s *modelNoteService) Search(ctx context.Context, req *service.SearchRequest) (*service.SearchResponse, error) {
+	if len(s.routes) == 0 {
+		return s.base.Search(ctx, req)
+	}
+	results, errs := fanOutAll(s.all(), func(svc service.NoteService) (*service.SearchResponse, error) {
+		return svc.Search(ctx, req)
+	})
+	if errs[0] != nil {
+		return nil, errs[0]
+	}
+	resp := results[0]
+	seen := make(map[string]bool, len(resp.Results))
+	for _, r := range resp.Results {
+		seen[r.ID] = true
+	}
+	for i, r := range results[1:] {
+		if err := errs[i+1]; err != nil {
+			slog.Warn("failed to search a model namespace", "error", err)
+			continue
+		}
+		for _, result := range r.Results {
+			if !seen[result.ID] {
+				seen[result.ID] = true
+				resp.Results = append(resp.Results, result)
+			}
+		}
+	}
+	rankMerged(resp, req)
+	return resp, nil
+}
+
+// ListRecent は全てのnamespaceの最新一覧を並び順にまとめてlimit件を返す
+// cursorは全てのnamespaceに同じものを渡す（同じ並び順のため、まとめた一覧の続きになる）
+func (s *modelNoteService) ListRecent(ctx context.Context, req *service.ListRecentRequest) (*service.ListRecentResponse, error) {
+	if len(s.routes) == 0 {
+		return s.base.ListRecent(ctx, req)
+	}
+	results, errs := fanOutAll(s.all(), func(svc service.NoteService) (*service.ListRecentResponse, error) {
+		return svc.ListRecent(ctx, req)
+	})
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	resp := results[0]
+	more := resp.NextCursor != ""
+	for _, r := range results[1:] {
+		resp.Items = append(resp.Items, r.Items...)
+		resp.Total += r.Total
+		resp.TotalApproximate = resp.TotalApproximate || r.TotalApproximate
+		more = more || r.NextCursor != ""
+	}
+	pageMerged(resp, req, more)
+	return resp, nil
+}
+
+// findNote はノートが見つかるまでbase、追加のnamespaceの順にfnを呼び出す
+func (s *modelNoteService) findNote(fn func(service.NoteService) error) error {
+	var err error
+	for _, svc := range s.all() {
+		err = fn(svc)
+		if !errors.Is(err, service.ErrNoteNotFound) {
+			return err
+		}
+	}
+	return err
+}
+
+// Get はノートを取得する
+func (s *modelNoteService) Get(ctx context.Context, id string) (*service.GetResponse, error) {
+	var resp *service.GetResponse
+	err := s.findNote(func(svc service.NoteService) error {
+		var err error
+		resp, err = svc.Get(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetDocument はノートが属する文書を取得する
+func (s *modelNoteService) GetDocument(ctx context.Context, id string) (*service.GetResponse, error) {
+	var resp *service.GetResponse
+	err := s.findNote(func(svc service.NoteService) error {
+		var err error
+		resp, err = svc.GetDocument(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Update はノートのあるnamespaceで更新する（本文の変更はそのnamespaceのembedderで埋め込み直す）
+func (s *modelNoteService) Update(ctx context.Context, req *service.UpdateRequest) error {
+	return s.findNote(func(svc service.NoteService) error {
+		return svc.Update(ctx, req)
+	})
+}
+
+// Delete はノートのあるnamespaceから削除する
+func (s *modelNoteService) Delete(ctx context.Context, id string) error {
+	return s.findNote(func(svc service.NoteService) error {
+		return svc.Delete(ctx, id)
+	})
+}
+
+// ListProjects は全てのnamespaceのプロジェクト一覧をまとめて返す（namespaceはbase）
+func (s *modelNoteService) ListProjects(ctx context.Context) (*service.ListProjectsResponse, error) {
+	if len(s.routes) == 0 {
+		return s.base.ListProjects(ctx)
+	}
+	results, errs := fanOutAll(s.all(), func(svc service.NoteService) (*service.ListProjectsResponse, error) {
+		return svc.ListProjects(ctx)
+	})
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	mergeProjects(results[0], results[1:]...)
+	return results[0], nil
+}
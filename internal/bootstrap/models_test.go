@@ -0,0 +1,121 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/service"
+	"github.com/brbranch/embedding_mcp/internal/store"
+)
+
+// newTestModels はbase（"base:m:3"）と追加のembedder "code"（"code:m:3"）のmodelNoteServiceを作成する
+// "same"はbaseと同じnamespaceになる名前
+func newTestModels(t *testing.T) *modelNoteService {
+	t.Helper()
+	ctx := context.Background()
+	open := func(namespace string) service.NoteService {
+		st := store.NewMemoryStore()
+		if err := st.Initialize(ctx, namespace); err != nil {
+			t.Fatal(err)
+		}
+		return service.NewNoteService(stubEmbedder{}, st, namespace)
+	}
+	code := open("code:m:3")
+	return newModelNoteService(open("base:m:3"), map[string]service.NoteService{"code": code, "snippets": code, "same": nil})
+}
+
+func TestModelNoteService_AddNote(t *testing.T) {
+	ctx := context.Background()
+	s := newTestModels(t)
+	if len(s.routes) != 1 {
+		t.Fatalf("expected names sharing a namespace to share a route, got %d", len(s.routes))
+	}
+
+	for model, want := range map[string]string{"": "base:m:3", "code": "code:m:3", "same": "base:m:3"} {
+		resp, err := s.AddNote(ctx, &service.AddNoteRequest{ProjectID: "/test/project", GroupID: "global", Text: "note", Model: model})
+		if err != nil {
+			t.Fatalf("AddNote(%q) failed: %v", model, err)
+		}
+		if resp.Namespace != want {
+			t.Errorf("AddNote(%q): expected namespace %s, got %s", model, want, resp.Namespace)
+		}
+	}
+	_, err := s.AddNote(ctx, &service.AddNoteRequest{ProjectID: "/test/project", GroupID: "global", Text: "note", Model: "unknown"})
+	if !errors.Is(err, service.ErrUnknownModel) {
+		t.Errorf("expected ErrUnknownModel, got %v", err)
+	}
+
+	// 1回の一括追加で複数のnamespaceに振り分ける
+	resp, err := s.AddNotes(ctx, &service.AddNotesRequest{Notes: []service.AddNoteRequest{
+		{ProjectID: "/test/project", GroupID: "global", Text: "a"},
+		{ProjectID: "/test/project", GroupID: "global", Text: "b", Model: "code"},
+		{ProjectID: "/test/project", GroupID: "global", Text: "c"},
+	}})
+	if err != nil {
+		t.Fatalf("AddNotes failed: %v", err)
+	}
+	if len(resp.Results) != 3 || resp.Results[0].Namespace != "base:m:3" || resp.Results[1].Namespace != "code:m:3" || resp.Results[2].Namespace != "base:m:3" {
+		t.Errorf("unexpected results: %+v", resp.Results)
+	}
+	for _, item := range resp.Items {
+		if item.Status != service.ItemOK {
+			t.Errorf("expected all items ok, got %+v", resp.Items)
+		}
+	}
+}
+
+func TestModelNoteService_Read(t *testing.T) {
+	ctx := context.Background()
+	s := newTestModels(t)
+	base, err := s.AddNote(ctx, &service.AddNoteRequest{ProjectID: "/test/project", GroupID: "global", Text: "prose"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := s.AddNote(ctx, &service.AddNoteRequest{ProjectID: "/test/project", GroupID: "global", Text: "func main() {}", Model: "code"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	search, err := s.Search(ctx, &service.SearchRequest{ProjectID: "/test/project", Query: "q"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if search.Namespace != "base:m:3" || len(search.Results) != 2 {
+		t.Errorf("expected results from both namespaces, got %s %+v", search.Namespace, search.Results)
+	}
+
+	list, err := s.ListRecent(ctx, &service.ListRecentRequest{ProjectID: "/test/project"})
+	if err != nil {
+		t.Fatalf("ListRecent failed: %v", err)
+	}
+	if len(list.Items) != 2 || list.Total != 2 || list.TotalApproximate {
+		t.Errorf("unexpected list: %+v", list)
+	}
+
+	got, err := s.Get(ctx, code.ID)
+	if err != nil || got.Namespace != "code:m:3" {
+		t.Errorf("expected note from the model namespace, got %+v, %v", got, err)
+	}
+	text := "func run() {}"
+	if err := s.Update(ctx, &service.UpdateRequest{ID: code.ID, Patch: service.NotePatch{Text: &text}}); err != nil {
+		t.Errorf("Update failed: %v", err)
+	}
+
+	projects, err := s.ListProjects(ctx)
+	if err != nil {
+		t.Fatalf("ListProjects failed: %v", err)
+	}
+	if len(projects.Projects) != 1 || projects.Projects[0].NoteCount != 2 {
+		t.Errorf("unexpected projects: %+v", projects.Projects)
+	}
+
+	for _, id := range []string{base.ID, code.ID} {
+		if err := s.Delete(ctx, id); err != nil {
+			t.Errorf("Delete(%s) failed: %v", id, err)
+		}
+	}
+	if err := s.Delete(ctx, code.ID); !errors.Is(err, service.ErrNoteNotFound) {
+		t.Errorf("expected ErrNoteNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,392 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sync"
+
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// routeOpener はembedder設定に対応するNoteServiceと終了処理を作成する
+type routeOpener func(ctx context.Context, cfg *model.EmbedderConfig) (service.NoteService, func(), error)
+
+// overlayNoteService はプロジェクトローカル設定（.mcp-memory.json）を適用するNoteService
+//   - add_note: groupId省略時はdefaultGroup、tagsにはプロジェクトのtagsを追加し、requiredTagsが揃っているか検証
+//   - embedderの上書きがあるプロジェクトは、そのembedderのnamespaceのNoteServiceに振り分ける
+//
+// ID指定の操作（get/update/delete）はbaseから順に、作成済みの振り分け先を探す
+type overlayNoteService struct {
+	base     service.NoteService
+	embedder model.EmbedderConfig
+	overlays *config.ProjectOverlays
+	open     routeOpener
+
+	mu      sync.Mutex
+	routes  map[string]service.NoteService // key: provider:model
+	order   []service.NoteService          // 作成順
+	closers []func()
+}
+
+func newOverlayNoteService(base service.NoteService, emb model.EmbedderConfig, overlays *config.ProjectOverlays, open routeOpener) *overlayNoteService {
+	return &overlayNoteService{
+		base:     base,
+		embedder: emb,
+		overlays: overlays,
+		open:     open,
+		routes:   make(map[string]service.NoteService),
+	}
+}
+
+// preload はprojects.jsonに登録済みのプロジェクトの振り分け先を作成する
+// 再起動直後でもID指定の操作が上書きnamespaceのノートを見つけられるようにするため
+func (s *overlayNoteService) preload(ctx context.Context, registryPath string) {
+	reg, err := config.LoadProjectRegistry(registryPath)
+	if err != nil {
+		slog.Warn("failed to load project registry", "path", registryPath, "error", err)
+		return
+	}
+	// projectIdはgitリモートのIDなどパスでない場合があるため、.mcp-memory.jsonはrootから探す
+	for _, p := range reg.Projects {
+		if _, _, err := s.resolve(ctx, p.Root); err != nil {
+			slog.Warn("failed to apply project config", "projectId", p.ProjectID, "error", err)
+		}
+	}
+}
+
+// close は作成した振り分け先を終了する
+func (s *overlayNoteService) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.closers {
+		c()
+	}
+	s.closers = nil
+}
+
+// resolve はprojectIdのプロジェクトローカル設定と振り分け先を返す（設定がなければnil, base）
+func (s *overlayNoteService) resolve(ctx context.Context, projectID string) (service.NoteService, *config.ProjectConfig, error) {
+	if projectID == "" {
+		return s.base, nil, nil
+	}
+	canonical, err := config.CanonicalizeProjectID(projectID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to canonicalize projectId: %w", err)
+	}
+	// エイリアスの対応先・gitリモートのIDが直接指定された場合はディレクトリがないため設定を探さない
+	if canonical == projectID && !filepath.IsAbs(projectID) {
+		return s.base, nil, nil
+	}
+	pc, err := s.overlays.Lookup(projectID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid project config: %w", err)
+	}
+	if pc == nil {
+		return s.base, nil, nil
+	}
+	cfg := overlayEmbedderConfig(&s.embedder, pc.Embedder)
+	if cfg == nil {
+		return s.base, pc, nil
+	}
+	svc, err := s.route(ctx, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return svc, pc, nil
+}
+
+// route はembedder設定の振り分け先を返す（未作成なら作成する）
+func (s *overlayNoteService) route(ctx context.Context, cfg *model.EmbedderConfig) (service.NoteService, error) {
+	key := cfg.Provider + ":" + cfg.Model
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if svc, ok := s.routes[key]; ok {
+		return svc, nil
+	}
+	svc, closer, err := s.open(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open project embedder %s: %w", key, err)
+	}
+	s.routes[key] = svc
+	s.order = append(s.order, svc)
+	s.closers = append(s.closers, closer)
+	return svc, nil
+}
+
+// all はbaseと作成済みの振り分け先を返す
+func (s *overlayNoteService) all() []service.NoteService {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]service.NoteService{s.base}, s.order...)
+}
+
+// overlayEmbedderConfig はプロジェクトのembedder上書きを適用した設定を返す
+// 上書きがない、またはグローバル設定と同じ場合はnilを返す
+func overlayEmbedderConfig(base *model.EmbedderConfig, o *config.ProjectEmbedderConfig) *model.EmbedderConfig {
+	if o == nil {
+		return nil
+	}
+	cfg := *base
+	if o.Provider != "" && o.Provider != base.Provider {
+		// providerが変わる場合、接続先・APIキーは引き継がない
+		cfg.Provider = o.Provider
+		cfg.Model = ""
+		cfg.BaseURL = nil
+		cfg.APIKey = nil
+		cfg.APIKeyFrom = ""
+	}
+	if o.Model != "" {
+		cfg.Model = o.Model
+	}
+	if cfg.Provider == base.Provider && cfg.Model == base.Model {
+		return nil
+	}
+	// 次元数はモデルごとに異なるため引き継がない
+	cfg.Dim = 0
+	return &cfg
+}
+
+// applyOverlay はadd_noteのリクエストにプロジェクトローカル設定を適用し、振り分け先を返す
+func (s *overlayNoteService) applyOverlay(ctx context.Context, req *service.AddNoteRequest) (*service.AddNoteRequest, service.NoteService, error) {
+	svc, pc, err := s.resolve(ctx, req.ProjectID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if pc == nil {
+		return req, svc, nil
+	}
+	applied := *req
+	if applied.GroupID == "" {
+		applied.GroupID = pc.DefaultGroup
+	}
+	applied.Tags = config.MergeTags(applied.Tags, pc.Tags)
+	if err := service.CheckRequiredTags(applied.Tags, pc.RequiredTags); err != nil {
+		return nil, nil, err
+	}
+	return &applied, svc, nil
+}
+
+// AddNote はプロジェクトローカル設定を適用してノートを追加する
+func (s *overlayNoteService) AddNote(ctx context.Context, req *service.AddNoteRequest) (*service.AddNoteResponse, error) {
+	applied, svc, err := s.applyOverlay(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return svc.AddNote(ctx, applied)
+}
+
+// AddNotes はプロジェクトローカル設定を適用し、振り分け先ごとにまとめてノートを追加する
+func (s *overlayNoteService) AddNotes(ctx context.Context, req *service.AddNotesRequest) (*service.AddNotesResponse, error) {
+	notes := make([]service.AddNoteRequest, len(req.Notes))
+	svcs := make([]service.NoteService, len(req.Notes))
+	items := make([]service.ItemResult, len(req.Notes))
+	var firstErr error
+	for i := range req.Notes {
+		items[i] = service.ItemResult{Index: i, Status: service.ItemPending}
+		applied, svc, err := s.applyOverlay(ctx, &req.Notes[i])
+		if err != nil {
+			items[i].Status, items[i].Err = service.ItemFailed, err
+			if firstErr == nil {
+				firstErr = fmt.Errorf("notes[%d]: %w", i, err)
+			}
+			continue
+		}
+		notes[i], svcs[i] = *applied, svc
+	}
+	if firstErr != nil {
+		return &service.AddNotesResponse{Items: items}, firstErr
+	}
+
+	// 振り分け先が1つなら（通常はこちら）そのまま一括追加
+	single := true
+	for _, svc := range svcs {
+		if svc != s.base {
+			single = false
+			break
+		}
+	}
+	if single {
+		return s.base.AddNotes(ctx, &service.AddNotesRequest{Notes: notes})
+	}
+	return addNotesRouted(ctx, notes, svcs, items)
+}
+
+// addNotesRouted はnotes[i]をsvcs[i]に、振り分け先ごとにまとめて追加する（itemsは全項目pendingで渡す）
+// 失敗した振り分け先で止め、それまでに追加したノートはokのまま返す
+func addNotesRouted(ctx context.Context, notes []service.AddNoteRequest, svcs []service.NoteService, items []service.ItemResult) (*service.AddNotesResponse, error) {
+	resp := &service.AddNotesResponse{Results: make([]service.AddNoteResponse, len(notes)), Items: items}
+	done := make([]bool, len(notes))
+	for i := range notes {
+		if done[i] {
+			continue
+		}
+		var indexes []int
+		var batch []service.AddNoteRequest
+		for j := i; j < len(notes); j++ {
+			if !done[j] && svcs[j] == svcs[i] {
+				indexes = append(indexes, j)
+				batch = append(batch, notes[j])
+				done[j] = true
+			}
+		}
+		r, err := svcs[i].AddNotes(ctx, &service.AddNotesRequest{Notes: batch})
+		if r != nil {
+			// 項目の位置を元のリクエストに合わせる（前の振り分け先に追加した分はokのまま）
+			for k, item := range r.Items {
+				item.Index = indexes[k]
+				resp.Items[indexes[k]] = item
+			}
+			for k := range r.Results {
+				resp.Results[indexes[k]] = r.Results[k]
+			}
+		}
+		if err != nil {
+			// Resultsは追加済みのノートだけにする
+			var added []service.AddNoteResponse
+			for j, item := range resp.Items {
+				if item.Status == service.ItemOK {
+					added = append(added, resp.Results[j])
+				}
+			}
+			resp.Results = added
+			return resp, err
+		}
+		if resp.Namespace == "" {
+			resp.Namespace = r.Namespace
+		}
+	}
+	return resp, nil
+}
+
+// Search はprojectIdの振り分け先で検索する
+// projectIds指定で振り分け先が分かれる場合は、振り分け先ごとのプロジェクトをまとめて並行して検索し、スコア順にまとめる
+func (s *overlayNoteService) Search(ctx context.Context, req *service.SearchRequest) (*service.SearchResponse, error) {
+	var svcs []service.NoteService
+	subs := make(map[service.NoteService]*service.SearchRequest)
+	for _, projectID := range service.SearchProjectIDs(req) {
+		svc, _, err := s.resolve(ctx, projectID)
+		if err != nil {
+			return nil, err
+		}
+		sub, ok := subs[svc]
+		if !ok {
+			sub = &service.SearchRequest{}
+			*sub = *req
+			sub.ProjectID = ""
+			sub.ProjectIDs = nil
+			subs[svc] = sub
+			svcs = append(svcs, svc)
+		}
+		sub.ProjectIDs = append(sub.ProjectIDs, projectID)
+	}
+	switch len(svcs) {
+	case 0:
+		return s.base.Search(ctx, req)
+	case 1:
+		return svcs[0].Search(ctx, req)
+	}
+
+	results, errs := fanOutAll(svcs, func(svc service.NoteService) (*service.SearchResponse, error) {
+		return svc.Search(ctx, subs[svc])
+	})
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	resp := results[0]
+	for _, r := range results[1:] {
+		resp.Results = append(resp.Results, r.Results...)
+	}
+	rankMerged(resp, req)
+	return resp, nil
+}
+
+// ListRecent はprojectIdの振り分け先で最新一覧を取得する
+func (s *overlayNoteService) ListRecent(ctx context.Context, req *service.ListRecentRequest) (*service.ListRecentResponse, error) {
+	svc, _, err := s.resolve(ctx, req.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	return svc.ListRecent(ctx, req)
+}
+
+// findNote はノートが見つかるまでbase、振り分け先の順にfnを呼び出す
+func (s *overlayNoteService) findNote(fn func(service.NoteService) error) error {
+	var err error
+	for _, svc := range s.all() {
+		err = fn(svc)
+		if !errors.Is(err, service.ErrNoteNotFound) {
+			return err
+		}
+	}
+	return err
+}
+
+// Get はノートを取得する
+func (s *overlayNoteService) Get(ctx context.Context, id string) (*service.GetResponse, error) {
+	var resp *service.GetResponse
+	err := s.findNote(func(svc service.NoteService) error {
+		var err error
+		resp, err = svc.Get(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetDocument はノートが属する文書を取得する
+func (s *overlayNoteService) GetDocument(ctx context.Context, id string) (*service.GetResponse, error) {
+	var resp *service.GetResponse
+	err := s.findNote(func(svc service.NoteService) error {
+		var err error
+		resp, err = svc.GetDocument(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Update はノートを更新する
+func (s *overlayNoteService) Update(ctx context.Context, req *service.UpdateRequest) error {
+	return s.findNote(func(svc service.NoteService) error {
+		return svc.Update(ctx, req)
+	})
+}
+
+// Delete はノートを削除する
+func (s *overlayNoteService) Delete(ctx context.Context, id string) error {
+	return s.findNote(func(svc service.NoteService) error {
+		return svc.Delete(ctx, id)
+	})
+}
+
+// ListProjects はbaseと振り分け先のプロジェクト一覧をまとめて返す（namespaceはbase）
+func (s *overlayNoteService) ListProjects(ctx context.Context) (*service.ListProjectsResponse, error) {
+	svcs := s.all()
+	resp, err := svcs[0].ListProjects(ctx)
+	if err != nil || len(svcs) == 1 {
+		return resp, err
+	}
+
+	others := make([]*service.ListProjectsResponse, 0, len(svcs)-1)
+	for _, svc := range svcs[1:] {
+		r, err := svc.ListProjects(ctx)
+		if err != nil {
+			return nil, err
+		}
+		others = append(others, r)
+	}
+	mergeProjects(resp, others...)
+	return resp, nil
+}
@@ -0,0 +1,239 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/service"
+	"github.com/brbranch/embedding_mcp/internal/store"
+)
+
+// stubEmbedder は固定ベクトルを返すテスト用Embedder
+type stubEmbedder struct{}
+
+func (stubEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	return []float32{0.1, 0.2, 0.3}, nil
+}
+
+func (stubEmbedder) GetDimension() int {
+	return 3
+}
+
+// newTestOverlay はメモリストアのbaseと、embedder上書き用の振り分け先を作るoverlayNoteServiceを作成する
+func newTestOverlay(t *testing.T) (*overlayNoteService, *[]string) {
+	t.Helper()
+	ctx := context.Background()
+	newService := func(namespace string) service.NoteService {
+		st := store.NewMemoryStore()
+		if err := st.Initialize(ctx, namespace); err != nil {
+			t.Fatal(err)
+		}
+		return service.NewNoteService(stubEmbedder{}, st, namespace)
+	}
+
+	var opened []string
+	base := model.EmbedderConfig{Provider: "openai", Model: "text-embedding-3-small", Dim: 1536}
+	s := newOverlayNoteService(newService("openai:text-embedding-3-small:1536"), base, config.NewProjectOverlays(),
+		func(ctx context.Context, cfg *model.EmbedderConfig) (service.NoteService, func(), error) {
+			namespace := config.GenerateNamespace(cfg.Provider, cfg.Model, cfg.Dim)
+			opened = append(opened, namespace)
+			return newService(namespace), func() {}, nil
+		})
+	t.Cleanup(s.close)
+	return s, &opened
+}
+
+// writeOverlay はprojectのルートに.mcp-memory.jsonを書き込む
+func writeOverlay(t *testing.T, root string, cfg *config.ProjectConfig) {
+	t.Helper()
+	if err := config.SaveProjectConfig(filepath.Join(root, config.ProjectConfigFile), cfg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestOverlayNoteService_AddNoteDefaults はdefaultGroupとtagsが適用されることをテスト
+func TestOverlayNoteService_AddNoteDefaults(t *testing.T) {
+	ctx := context.Background()
+	s, opened := newTestOverlay(t)
+	project := t.TempDir()
+	writeOverlay(t, project, &config.ProjectConfig{ProjectID: project, DefaultGroup: "docs", Tags: []string{"backend"}})
+
+	resp, err := s.AddNote(ctx, &service.AddNoteRequest{ProjectID: project, Text: "hello", Tags: []string{"api", "backend"}})
+	if err != nil {
+		t.Fatalf("AddNote failed: %v", err)
+	}
+	note, err := s.Get(ctx, resp.ID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if note.GroupID != "docs" {
+		t.Errorf("expected groupId docs, got %q", note.GroupID)
+	}
+	if len(note.Tags) != 2 || note.Tags[0] != "api" || note.Tags[1] != "backend" {
+		t.Errorf("unexpected tags: %v", note.Tags)
+	}
+	if len(*opened) != 0 {
+		t.Errorf("expected no embedder route, got %v", *opened)
+	}
+
+	// 明示したgroupIdが優先される
+	resp, err = s.AddNote(ctx, &service.AddNoteRequest{ProjectID: project, GroupID: "global", Text: "hi"})
+	if err != nil {
+		t.Fatalf("AddNote failed: %v", err)
+	}
+	if note, _ := s.Get(ctx, resp.ID); note.GroupID != "global" {
+		t.Errorf("expected groupId global, got %q", note.GroupID)
+	}
+
+	// 設定のないプロジェクトはそのまま
+	if _, err := s.AddNote(ctx, &service.AddNoteRequest{ProjectID: t.TempDir(), Text: "x"}); !errors.Is(err, service.ErrGroupIDRequired) {
+		t.Errorf("expected ErrGroupIDRequired, got %v", err)
+	}
+}
+
+// TestOverlayNoteService_RequiredTags はrequiredTagsが足りない場合にエラーになることをテスト
+func TestOverlayNoteService_RequiredTags(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestOverlay(t)
+	project := t.TempDir()
+	writeOverlay(t, project, &config.ProjectConfig{ProjectID: project, DefaultGroup: "docs", Tags: []string{"backend"}, RequiredTags: []string{"backend", "area"}})
+
+	if _, err := s.AddNote(ctx, &service.AddNoteRequest{ProjectID: project, Text: "hello"}); !errors.Is(err, service.ErrMissingRequiredTags) {
+		t.Errorf("expected ErrMissingRequiredTags, got %v", err)
+	}
+	if _, err := s.AddNote(ctx, &service.AddNoteRequest{ProjectID: project, Text: "hello", Tags: []string{"area"}}); err != nil {
+		t.Errorf("AddNote failed: %v", err)
+	}
+}
+
+// TestOverlayNoteService_ProjectAlias はエイリアスのあるプロジェクトでも設定が適用され、
+// エイリアス・対応先のどちらで検索・一覧しても書き込んだノートが返ることをテスト
+func TestOverlayNoteService_ProjectAlias(t *testing.T) {
+	ctx := context.Background()
+	project := t.TempDir()
+	config.SetCanonicalizeRules(config.DefaultCanonicalizeRules.WithAliases(map[string]string{project: "acme/app"}))
+	t.Cleanup(func() { config.SetCanonicalizeRules(config.DefaultCanonicalizeRules) })
+	s, _ := newTestOverlay(t)
+	writeOverlay(t, project, &config.ProjectConfig{ProjectID: "acme/app", DefaultGroup: "docs"})
+
+	resp, err := s.AddNote(ctx, &service.AddNoteRequest{ProjectID: project, Text: "hello"})
+	if err != nil {
+		t.Fatalf("AddNote failed: %v", err)
+	}
+	if note, _ := s.Get(ctx, resp.ID); note == nil || note.GroupID != "docs" || note.ProjectID != "acme/app" {
+		t.Fatalf("expected the overlay and alias to be applied, got %+v", note)
+	}
+
+	for _, projectID := range []string{project, "acme/app"} {
+		searchResp, err := s.Search(ctx, &service.SearchRequest{ProjectID: projectID, Query: "hello"})
+		if err != nil {
+			t.Fatalf("Search(%s) failed: %v", projectID, err)
+		}
+		if len(searchResp.Results) != 1 || searchResp.Results[0].ID != resp.ID {
+			t.Errorf("Search(%s): unexpected results %+v", projectID, searchResp.Results)
+		}
+		listResp, err := s.ListRecent(ctx, &service.ListRecentRequest{ProjectID: projectID})
+		if err != nil {
+			t.Fatalf("ListRecent(%s) failed: %v", projectID, err)
+		}
+		if len(listResp.Items) != 1 || listResp.Items[0].ID != resp.ID {
+			t.Errorf("ListRecent(%s): unexpected items %+v", projectID, listResp.Items)
+		}
+	}
+}
+
+// TestOverlayNoteService_EmbedderRoute はembedder上書きのあるプロジェクトが別namespaceに振り分けられることをテスト
+func TestOverlayNoteService_EmbedderRoute(t *testing.T) {
+	ctx := context.Background()
+	s, opened := newTestOverlay(t)
+	plain := t.TempDir()
+	routed := t.TempDir()
+	writeOverlay(t, routed, &config.ProjectConfig{
+		ProjectID:    routed,
+		DefaultGroup: "global",
+		Embedder:     &config.ProjectEmbedderConfig{Provider: "ollama", Model: "nomic-embed-text"},
+	})
+
+	resp, err := s.AddNotes(ctx, &service.AddNotesRequest{Notes: []service.AddNoteRequest{
+		{ProjectID: routed, Text: "a"},
+		{ProjectID: plain, GroupID: "global", Text: "b"},
+		{ProjectID: routed, Text: "c"},
+	}})
+	if err != nil {
+		t.Fatalf("AddNotes failed: %v", err)
+	}
+	const routedNS = "ollama:nomic-embed-text:0"
+	if len(*opened) != 1 || (*opened)[0] != routedNS {
+		t.Fatalf("expected one route %s, got %v", routedNS, *opened)
+	}
+	wantNS := []string{routedNS, "openai:text-embedding-3-small:1536", routedNS}
+	for i, r := range resp.Results {
+		if r.Namespace != wantNS[i] {
+			t.Errorf("results[%d]: expected namespace %s, got %s", i, wantNS[i], r.Namespace)
+		}
+	}
+
+	search, err := s.Search(ctx, &service.SearchRequest{ProjectID: routed, Query: "a"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if search.Namespace != routedNS || len(search.Results) != 2 {
+		t.Errorf("unexpected search response: %s, %d results", search.Namespace, len(search.Results))
+	}
+
+	// projectIds指定時は振り分け先ごとに検索してまとめる
+	search, err = s.Search(ctx, &service.SearchRequest{ProjectID: plain, ProjectIDs: []string{routed}, Query: "a"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(search.Results) != 3 {
+		t.Errorf("expected results from both namespaces, got %+v", search.Results)
+	}
+
+	// ID指定の操作は振り分け先のノートも見つける
+	if _, err := s.Get(ctx, resp.Results[0].ID); err != nil {
+		t.Errorf("Get failed: %v", err)
+	}
+	if err := s.Delete(ctx, resp.Results[2].ID); err != nil {
+		t.Errorf("Delete failed: %v", err)
+	}
+	if _, err := s.Get(ctx, resp.Results[2].ID); !errors.Is(err, service.ErrNoteNotFound) {
+		t.Errorf("expected ErrNoteNotFound, got %v", err)
+	}
+
+	projects, err := s.ListProjects(ctx)
+	if err != nil {
+		t.Fatalf("ListProjects failed: %v", err)
+	}
+	if len(projects.Projects) != 2 {
+		t.Errorf("expected 2 projects, got %+v", projects.Projects)
+	}
+}
+
+// TestOverlayEmbedderConfig はembedder上書きの解決をテスト
+func TestOverlayEmbedderConfig(t *testing.T) {
+	url := "https://example.com/v1"
+	key := "sk-test"
+	base := &model.EmbedderConfig{Provider: "openai", Model: "text-embedding-3-small", Dim: 1536, BaseURL: &url, APIKey: &key}
+
+	if got := overlayEmbedderConfig(base, nil); got != nil {
+		t.Errorf("expected nil without override, got %+v", got)
+	}
+	if got := overlayEmbedderConfig(base, &config.ProjectEmbedderConfig{Model: "text-embedding-3-small"}); got != nil {
+		t.Errorf("expected nil for same model, got %+v", got)
+	}
+
+	got := overlayEmbedderConfig(base, &config.ProjectEmbedderConfig{Model: "text-embedding-3-large"})
+	if got == nil || got.Provider != "openai" || got.Model != "text-embedding-3-large" || got.Dim != 0 || got.APIKey == nil {
+		t.Errorf("unexpected model override: %+v", got)
+	}
+
+	got = overlayEmbedderConfig(base, &config.ProjectEmbedderConfig{Provider: "ollama", Model: "nomic-embed-text"})
+	if got == nil || got.Provider != "ollama" || got.BaseURL != nil || got.APIKey != nil {
+		t.Errorf("unexpected provider override: %+v", got)
+	}
+}
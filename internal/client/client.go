@@ -0,0 +1,180 @@
+// Package client forwards JSON-RPC calls to a running mcp-memory HTTP server.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+	httptransport "github.com/brbranch/embedding_mcp/internal/transport/http"
+)
+
+// DefaultTimeout は1リクエストあたりのデフォルトタイムアウト
+const DefaultTimeout = 60 * time.Second
+
+// ClientName はサーバーのセッションに渡すクライアント識別子
+const ClientName = "mcp-memory-cli"
+
+// EnvToken はサーバーへ送るトークン（サーバーのauth.apiKeyまたはauth.tokens[].token）の環境変数
+const EnvToken = "MCP_MEMORY_TOKEN"
+
+// ErrNotSupported はサーバーに対応するメソッドがない操作
+var ErrNotSupported = errors.New("not supported by remote server")
+
+// Error はサーバーが返したJSON-RPCエラー
+type Error struct {
+	Code    int
+	Message string
+	Data    json.RawMessage // エラーの追加情報（なければnil）
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("remote error %d: %s", e.Code, e.Message)
+}
+
+// Client はHTTP経由でJSON-RPCを送信するクライアント
+type Client struct {
+	url        string
+	httpClient *http.Client
+	token      string // Authorization: Bearerで送るトークン（空なら送らない）
+	nextID     atomic.Int64
+}
+
+// Option はClientのオプション
+type Option func(*Client)
+
+// WithHTTPClient は使用するhttp.Clientを指定する
+func WithHTTPClient(c *http.Client) Option {
+	return func(cl *Client) {
+		cl.httpClient = c
+	}
+}
+
+// WithToken はサーバーのauth.apiKeyまたはauth.tokens[].tokenを送る（空なら送らない）
+func WithToken(token string) Option {
+	return func(cl *Client) {
+		cl.token = token
+	}
+}
+
+// New はrawURL宛てのClientを作成する
+// スキーム省略時はhttp://、パス省略時は/rpc を補う（"127.0.0.1:8765" も可）
+func New(rawURL string, opts ...Option) (*Client, error) {
+	endpoint, err := NormalizeURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{
+		url:        endpoint,
+		httpClient: &http.Client{Timeout: DefaultTimeout},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// URL は送信先のエンドポイントを返す
+func (c *Client) URL() string {
+	return c.url
+}
+
+// NormalizeURL はサーバーのアドレスをJSON-RPCエンドポイントのURLにする
+func NormalizeURL(rawURL string) (string, error) {
+	if rawURL == "" {
+		return "", errors.New("remote url is empty")
+	}
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "http://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid remote url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("invalid remote url: unsupported scheme %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("invalid remote url: missing host")
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/rpc"
+	}
+	return u.String(), nil
+}
+
+// setHeaders はクライアント識別子とトークンのヘッダーを設定する
+func (c *Client) setHeaders(req *http.Request) {
+	req.Header.Set(httptransport.HeaderClient, ClientName)
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+}
+
+// Call はmethodをparams付きで呼び出し、結果をresultにデコードする（resultがnilなら捨てる）
+func (c *Client) Call(ctx context.Context, method string, params, result any) error {
+	body, err := json.Marshal(&model.Request{
+		JSONRPC: "2.0",
+		ID:      c.nextID.Add(1),
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	c.setHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach %s: %w", c.url, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("%s returned %s (set %s to the server's token)", c.url, resp.Status, EnvToken)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", c.url, resp.Status)
+	}
+
+	var envelope struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int             `json:"code"`
+			Message string          `json:"message"`
+			Data    json.RawMessage `json:"data"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	if envelope.Error != nil {
+		return &Error{Code: envelope.Error.Code, Message: envelope.Error.Message, Data: envelope.Error.Data}
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(envelope.Result, result); err != nil {
+		return fmt.Errorf("invalid result: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,286 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/jsonrpc"
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/service"
+	"github.com/brbranch/embedding_mcp/internal/store"
+)
+
+const testNamespace = "openai:test:3"
+
+// stubEmbedder は固定ベクトルを返すテスト用Embedder
+type stubEmbedder struct{}
+
+func (stubEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	return []float32{0.1, 0.2, 0.3}, nil
+}
+
+func (stubEmbedder) GetDimension() int {
+	return 3
+}
+
+// newTestServer はメモリストアのJSON-RPCハンドラーを持つHTTPサーバーを起動する
+func newTestServer(t *testing.T) (*httptest.Server, *[]string) {
+	t.Helper()
+	st := store.NewMemoryStore()
+	if err := st.Initialize(context.Background(), testNamespace); err != nil {
+		t.Fatal(err)
+	}
+	handler := jsonrpc.New(
+		service.NewNoteService(stubEmbedder{}, st, testNamespace),
+		service.NewConfigService(config.NewManagerWithConfig(&model.Config{})),
+		service.NewGlobalService(st, testNamespace),
+		service.NewGroupService(st, testNamespace),
+	)
+
+	var clients []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rpc" {
+			http.NotFound(w, r)
+			return
+		}
+		clients = append(clients, r.Header.Get("X-Mcp-Client"))
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(handler.Handle(r.Context(), body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &clients
+}
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"127.0.0.1:8765", "http://127.0.0.1:8765/rpc"},
+		{"http://localhost:8765/", "http://localhost:8765/rpc"},
+		{"https://memory.example.com/api/rpc", "https://memory.example.com/api/rpc"},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeURL(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("NormalizeURL(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "ftp://host", "http://"} {
+		if _, err := NormalizeURL(in); err == nil {
+			t.Errorf("NormalizeURL(%q): expected error", in)
+		}
+	}
+}
+
+func TestNoteService_RoundTrip(t *testing.T) {
+	srv, clients := newTestServer(t)
+	c, err := New(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notes := c.NoteService()
+	ctx := context.Background()
+
+	title := "Rule"
+	added, err := notes.AddNote(ctx, &service.AddNoteRequest{ProjectID: "/proj", GroupID: "global", Title: &title, Text: "use tabs", Tags: []string{"style"}})
+	if err != nil {
+		t.Fatalf("AddNote failed: %v", err)
+	}
+	if added.ID == "" || added.Namespace != testNamespace {
+		t.Errorf("unexpected add response: %+v", added)
+	}
+
+	// 類似ノートの拒否はDuplicateNoteErrorとして返る
+	_, err = notes.AddNote(ctx, &service.AddNoteRequest{ProjectID: "/proj", GroupID: "global", Text: "use tabs", OnDuplicate: service.OnDuplicateReject})
+	var dupErr *service.DuplicateNoteError
+	if !errors.As(err, &dupErr) || dupErr.ID != added.ID {
+		t.Errorf("expected DuplicateNoteError for %s, got %v", added.ID, err)
+	}
+
+	topK := 5
+	found, err := notes.Search(ctx, &service.SearchRequest{ProjectID: "/proj", Query: "tabs", TopK: &topK})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(found.Results) != 1 || found.Results[0].ID != added.ID || *found.Results[0].Title != "Rule" {
+		t.Errorf("unexpected search results: %+v", found.Results)
+	}
+
+	limit := 10
+	recent, err := notes.ListRecent(ctx, &service.ListRecentRequest{ProjectID: "/proj", Limit: &limit})
+	if err != nil {
+		t.Fatalf("ListRecent failed: %v", err)
+	}
+	if len(recent.Items) != 1 || recent.Items[0].Tags[0] != "style" {
+		t.Errorf("unexpected list results: %+v", recent.Items)
+	}
+
+	empty := ""
+	if err := notes.Update(ctx, &service.UpdateRequest{ID: added.ID, Patch: service.NotePatch{Title: &empty}}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	got, err := notes.Get(ctx, added.ID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Title != nil && *got.Title != "" {
+		t.Errorf("expected title to be cleared, got %q", *got.Title)
+	}
+	if got.UpdatedAt == "" || got.UpdatedAt == recent.Items[0].UpdatedAt {
+		t.Errorf("expected updatedAt to change, got %q", got.UpdatedAt)
+	}
+
+	// 古いupdatedAtを指定した更新は失敗する
+	stale := recent.Items[0].UpdatedAt
+	if err := notes.Update(ctx, &service.UpdateRequest{ID: added.ID, Patch: service.NotePatch{Title: &title}, IfUpdatedAt: &stale}); !errors.Is(err, service.ErrNoteConflict) {
+		t.Errorf("expected ErrNoteConflict, got %v", err)
+	}
+
+	if err := notes.Delete(ctx, added.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := notes.Get(ctx, added.ID); !errors.Is(err, service.ErrNoteNotFound) {
+		t.Errorf("expected ErrNoteNotFound, got %v", err)
+	}
+
+	if (*clients)[0] != ClientName {
+		t.Errorf("expected client header %q, got %q", ClientName, (*clients)[0])
+	}
+}
+
+func TestCall_RPCError(t *testing.T) {
+	srv, _ := newTestServer(t)
+	c, err := New(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Call(context.Background(), "memory.unknown", nil, nil)
+	var rpcErr *Error
+	if !errors.As(err, &rpcErr) || rpcErr.Code != model.ErrCodeMethodNotFound {
+		t.Errorf("expected method not found error, got %v", err)
+	}
+
+	if _, err := c.NoteService().ListProjects(context.Background()); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("expected ErrNotSupported, got %v", err)
+	}
+}
+
+func TestCall_HTTPError(t *testing.T) {
+	srv, _ := newTestServer(t)
+	c, err := New(srv.URL + "/other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Call(context.Background(), "memory.get", &jsonrpc.GetParams{ID: "x"}, nil); err == nil {
+		t.Error("expected error for non-200 response")
+	}
+}
+
+// TestCall_Token はWithTokenのトークンをAuthorizationヘッダーで送ることをテスト
+func TestCall_Token(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("Authorization")
+		if got != "Bearer secret" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{}}`))
+	}))
+	defer srv.Close()
+
+	c, err := New(srv.URL, WithToken("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Call(context.Background(), "memory.server_info", nil, nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	c, err = New(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.Call(context.Background(), "memory.server_info", nil, nil)
+	if err == nil || !strings.Contains(err.Error(), EnvToken) || got != "" {
+		t.Errorf("expected an error mentioning %s without a token, got %v (header %q)", EnvToken, err, got)
+	}
+}
+
+// TestExportImport はExport・Importが /export・/import を使うことをテスト
+func TestExportImport(t *testing.T) {
+	st := store.NewMemoryStore()
+	if err := st.Initialize(context.Background(), testNamespace); err != nil {
+		t.Fatal(err)
+	}
+	handler := jsonrpc.New(
+		service.NewNoteService(stubEmbedder{}, st, testNamespace),
+		service.NewConfigService(config.NewManagerWithConfig(&model.Config{})),
+		service.NewGlobalService(st, testNamespace),
+		service.NewGroupService(st, testNamespace),
+	)
+	handler.SetExportService(service.NewExportService(stubEmbedder{}, st, testNamespace))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		projectID := r.URL.Query().Get("projectId")
+		switch r.URL.Path {
+		case "/team/export":
+			handler.Export(r.Context(), w, projectID, r.URL.Query().Get("embeddings") == "true")
+		case "/team/import":
+			result, err := handler.Import(r.Context(), r.Body, projectID, r.URL.Query().Get("mode"))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+				return
+			}
+			w.Write(result)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	c, err := New(srv.URL + "/team/rpc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	data := `{"type":"header","version":1,"namespace":"` + testNamespace + `"}
+{"type":"note","note":{"id":"n1","projectId":"/other","groupId":"g","text":"hello","tags":[]},"embedding":[1,0,0]}
+`
+	resp, err := c.Import(ctx, strings.NewReader(data), "/test/project", service.ImportModeOverwrite)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if resp.ProjectID != "/test/project" || resp.Created != 1 || resp.ReEmbedded != 0 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	var buf bytes.Buffer
+	if err := c.Export(ctx, &buf, "/test/project", true); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"id":"n1"`) || !strings.Contains(buf.String(), `"embedding":[1,0,0]`) {
+		t.Errorf("unexpected export: %s", buf.String())
+	}
+
+	// 取り込みのエラーは応答の本文を含める
+	if _, err := c.Import(ctx, strings.NewReader("not json"), "/test/project", service.ImportModeOverwrite); err == nil || !strings.Contains(err.Error(), "invalid import record") {
+		t.Errorf("expected the server's error, got %v", err)
+	}
+
+	// /export を公開していないサーバー
+	c, _ = New(srv.URL)
+	if err := c.Export(ctx, &buf, "/test/project", false); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("expected ErrNotSupported, got %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// Export はサーバーの /export からprojectIDのJSONL（exportコマンドと同じ形式）を取得してwに書き出す
+// サーバーが /export を公開していなければErrNotSupportedを返す
+func (c *Client) Export(ctx context.Context, w io.Writer, projectID string, includeEmbeddings bool) error {
+	query := url.Values{"projectId": {projectID}}
+	if includeEmbeddings {
+		query.Set("embeddings", "true")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoint("export", query), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("failed to read export: %w", err)
+	}
+	return nil
+}
+
+// Import はrのJSONL（exportコマンドと同じ形式）をサーバーの /import に送り、projectIDに取り込む
+// サーバーが /import を公開していなければErrNotSupportedを返す
+func (c *Client) Import(ctx context.Context, r io.Reader, projectID string, mode service.ImportMode) (*service.ImportResponse, error) {
+	query := url.Values{"projectId": {projectID}}
+	if mode != service.ImportModeFail {
+		query.Set("mode", string(mode))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint("import", query), r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Namespace  string `json:"namespace"`
+		ProjectID  string `json:"projectId"`
+		Created    int    `json:"created"`
+		Updated    int    `json:"updated"`
+		Skipped    int    `json:"skipped"`
+		ReEmbedded int    `json:"reEmbedded"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid result: %w", err)
+	}
+	return &service.ImportResponse{
+		Namespace:  result.Namespace,
+		ProjectID:  result.ProjectID,
+		Created:    result.Created,
+		Updated:    result.Updated,
+		Skipped:    result.Skipped,
+		ReEmbedded: result.ReEmbedded,
+	}, nil
+}
+
+// endpoint は /rpc と同じ階層のnameのURLを返す（"http://host/rpc" なら "http://host/name"）
+func (c *Client) endpoint(name string, query url.Values) string {
+	u, err := url.Parse(c.url)
+	if err != nil {
+		return c.url
+	}
+	u.Path = path.Join(path.Dir(u.Path), name)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// do はreqを送り、200以外ならエラーにする（404はErrNotSupported、それ以外は応答の本文を含める）
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	c.setHeaders(req)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach %s: %w", req.URL.Redacted(), err)
+	}
+	if resp.StatusCode == http.StatusOK {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%s: %w", req.URL.Path, ErrNotSupported)
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if msg := strings.TrimSpace(string(body)); msg != "" {
+		return nil, fmt.Errorf("%s returned %s: %s", req.URL.Path, resp.Status, msg)
+	}
+	return nil, fmt.Errorf("%s returned %s", req.URL.Path, resp.Status)
+}
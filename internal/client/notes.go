@@ -0,0 +1,277 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/brbranch/embedding_mcp/internal/jsonrpc"
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// noteService はservice.NoteServiceをmemory.*メソッドの呼び出しで実装する
+type noteService struct {
+	c *Client
+}
+
+// NoteService はサーバー上のNoteServiceを返す
+// ListProjectsはJSON-RPCメソッドがないためErrNotSupportedを返す
+func (c *Client) NoteService() service.NoteService {
+	return &noteService{c: c}
+}
+
+// AddNote は memory.add_note を呼び出す
+func (s *noteService) AddNote(ctx context.Context, req *service.AddNoteRequest) (*service.AddNoteResponse, error) {
+	var result jsonrpc.AddNoteResult
+	err := s.c.Call(ctx, "memory.add_note", &jsonrpc.AddNoteParams{
+		ProjectID:          req.ProjectID,
+		GroupID:            req.GroupID,
+		Title:              req.Title,
+		Text:               req.Text,
+		Tags:               req.Tags,
+		Source:             req.Source,
+		CreatedAt:          req.CreatedAt,
+		Metadata:           req.Metadata,
+		Attachments:        req.Attachments,
+		Importance:         req.Importance,
+		OnDuplicate:        req.OnDuplicate,
+		DuplicateThreshold: req.DuplicateThreshold,
+		Model:              req.Model,
+	}, &result)
+	if err != nil {
+		return nil, duplicateError(err)
+	}
+	return &service.AddNoteResponse{
+		ID:                 result.ID,
+		Namespace:          result.Namespace,
+		CanonicalProjectID: result.CanonicalProjectID,
+		DuplicateID:        result.DuplicateID,
+		DuplicateScore:     result.DuplicateScore,
+		Merged:             result.Merged,
+	}, nil
+}
+
+// AddNotes はノートを1件ずつ memory.add_note で追加する
+func (s *noteService) AddNotes(ctx context.Context, req *service.AddNotesRequest) (*service.AddNotesResponse, error) {
+	resp := &service.AddNotesResponse{Results: make([]service.AddNoteResponse, 0, len(req.Notes))}
+	for i := range req.Notes {
+		r, err := s.AddNote(ctx, &req.Notes[i])
+		if err != nil {
+			return nil, fmt.Errorf("note %d: %w", i, err)
+		}
+		resp.Namespace = r.Namespace
+		resp.Results = append(resp.Results, *r)
+	}
+	return resp, nil
+}
+
+// Search は memory.search を呼び出す
+func (s *noteService) Search(ctx context.Context, req *service.SearchRequest) (*service.SearchResponse, error) {
+	var result jsonrpc.SearchResult
+	err := s.c.Call(ctx, "memory.search", &jsonrpc.SearchParams{
+		ProjectID:          req.ProjectID,
+		ProjectIDs:         req.ProjectIDs,
+		GroupID:            req.GroupID,
+		Query:              req.Query,
+		TopK:               req.TopK,
+		Tags:               req.Tags,
+		Since:              req.Since,
+		Until:              req.Until,
+		MinScore:           req.MinScore,
+		CollapseByParent:   req.CollapseByParent,
+		Language:           req.Language,
+		IncludeDescendants: req.IncludeDescendants,
+		GroupBy:            req.GroupBy,
+		GroupTopK:          req.GroupTopK,
+		IncludeArchived:    req.IncludeArchived,
+	}, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &service.SearchResponse{
+		Namespace: result.Namespace,
+		Results:   make([]service.SearchResult, 0, len(result.Results)),
+	}
+	for _, g := range result.Groups {
+		resp.Groups = append(resp.Groups, service.SearchGroup{GroupID: g.GroupID, Count: g.Count, TopScore: g.TopScore})
+	}
+	for _, r := range result.Results {
+		resp.Results = append(resp.Results, service.SearchResult{
+			ID:          r.ID,
+			ProjectID:   r.ProjectID,
+			GroupID:     r.GroupID,
+			Title:       r.Title,
+			Text:        r.Text,
+			Tags:        r.Tags,
+			Source:      r.Source,
+			CreatedAt:   r.CreatedAt,
+			UpdatedAt:   r.UpdatedAt,
+			Score:       r.Score,
+			Metadata:    r.Metadata,
+			Attachments: r.Attachments,
+			ParentID:    r.ParentID,
+			ChunkIndex:  r.ChunkIndex,
+			Importance:  r.Importance,
+			Archived:    r.Archived,
+		})
+	}
+	return resp, nil
+}
+
+// Get は memory.get を呼び出す
+func (s *noteService) Get(ctx context.Context, id string) (*service.GetResponse, error) {
+	return s.get(ctx, &jsonrpc.GetParams{ID: id})
+}
+
+// GetDocument は memory.get をreassemble付きで呼び出す
+func (s *noteService) GetDocument(ctx context.Context, id string) (*service.GetResponse, error) {
+	return s.get(ctx, &jsonrpc.GetParams{ID: id, Reassemble: true})
+}
+
+func (s *noteService) get(ctx context.Context, params *jsonrpc.GetParams) (*service.GetResponse, error) {
+	var result jsonrpc.NoteResult
+	if err := s.c.Call(ctx, "memory.get", params, &result); err != nil {
+		return nil, noteError(err)
+	}
+	return &service.GetResponse{
+		ID:          result.ID,
+		ProjectID:   result.ProjectID,
+		GroupID:     result.GroupID,
+		Title:       result.Title,
+		Text:        result.Text,
+		Tags:        result.Tags,
+		Source:      result.Source,
+		CreatedAt:   result.CreatedAt,
+		UpdatedAt:   result.UpdatedAt,
+		Namespace:   result.Namespace,
+		Metadata:    result.Metadata,
+		Attachments: result.Attachments,
+		ParentID:    result.ParentID,
+		ChunkIndex:  result.ChunkIndex,
+		Importance:  result.Importance,
+	}, nil
+}
+
+// Update は memory.update を呼び出す
+// NotePatchの空文字列・空mapはJSON-RPCのnull（クリア）として送る
+func (s *noteService) Update(ctx context.Context, req *service.UpdateRequest) error {
+	patch := jsonrpc.PatchParams{
+		Title:       nullableString(req.Patch.Title),
+		Text:        req.Patch.Text,
+		Tags:        req.Patch.Tags,
+		Source:      nullableString(req.Patch.Source),
+		GroupID:     req.Patch.GroupID,
+		Attachments: req.Patch.Attachments,
+		Importance:  req.Patch.Importance,
+	}
+	if req.Patch.Metadata != nil {
+		if len(*req.Patch.Metadata) == 0 {
+			patch.Metadata = json.RawMessage("null")
+		} else {
+			data, err := json.Marshal(*req.Patch.Metadata)
+			if err != nil {
+				return fmt.Errorf("failed to encode metadata: %w", err)
+			}
+			patch.Metadata = data
+		}
+	}
+
+	err := s.c.Call(ctx, "memory.update", &jsonrpc.UpdateParams{ID: req.ID, Patch: patch, IfUpdatedAt: req.IfUpdatedAt}, nil)
+	return noteError(err)
+}
+
+// Delete は memory.delete を呼び出す
+func (s *noteService) Delete(ctx context.Context, id string) error {
+	return noteError(s.c.Call(ctx, "memory.delete", &jsonrpc.DeleteParams{ID: id}, nil))
+}
+
+// ListRecent は memory.list_recent を呼び出す
+func (s *noteService) ListRecent(ctx context.Context, req *service.ListRecentRequest) (*service.ListRecentResponse, error) {
+	var result jsonrpc.ListRecentResult
+	err := s.c.Call(ctx, "memory.list_recent", &jsonrpc.ListRecentParams{
+		ProjectID:          req.ProjectID,
+		GroupID:            req.GroupID,
+		Limit:              req.Limit,
+		Tags:               req.Tags,
+		SortBy:             req.SortBy,
+		IncludeDescendants: req.IncludeDescendants,
+		Cursor:             req.Cursor,
+	}, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &service.ListRecentResponse{
+		Namespace:        result.Namespace,
+		Items:            make([]service.ListRecentItem, 0, len(result.Items)),
+		Total:            result.Total,
+		TotalApproximate: result.TotalApproximate,
+		NextCursor:       result.NextCursor,
+	}
+	for _, r := range result.Items {
+		resp.Items = append(resp.Items, service.ListRecentItem{
+			ID:          r.ID,
+			ProjectID:   r.ProjectID,
+			GroupID:     r.GroupID,
+			Title:       r.Title,
+			Text:        r.Text,
+			Tags:        r.Tags,
+			Source:      r.Source,
+			CreatedAt:   r.CreatedAt,
+			UpdatedAt:   r.UpdatedAt,
+			Namespace:   r.Namespace,
+			Metadata:    r.Metadata,
+			Attachments: r.Attachments,
+			ParentID:    r.ParentID,
+			ChunkIndex:  r.ChunkIndex,
+			Importance:  r.Importance,
+		})
+	}
+	return resp, nil
+}
+
+// ListProjects はJSON-RPCメソッドがないため未対応
+func (s *noteService) ListProjects(ctx context.Context) (*service.ListProjectsResponse, error) {
+	return nil, fmt.Errorf("list projects: %w", ErrNotSupported)
+}
+
+// noteError はnot found・conflictエラーをservice.ErrNoteNotFound・service.ErrNoteConflictに変換する
+func noteError(err error) error {
+	var rpcErr *Error
+	if errors.As(err, &rpcErr) {
+		switch rpcErr.Code {
+		case model.ErrCodeNotFound:
+			return fmt.Errorf("%w: %s", service.ErrNoteNotFound, rpcErr.Message)
+		case model.ErrCodeConflict:
+			return fmt.Errorf("%w: %s", service.ErrNoteConflict, rpcErr.Message)
+		}
+	}
+	return err
+}
+
+// duplicateError はonDuplicate=rejectのconflictエラーをservice.DuplicateNoteErrorに変換する
+func duplicateError(err error) error {
+	var rpcErr *Error
+	if errors.As(err, &rpcErr) && rpcErr.Code == model.ErrCodeConflict && rpcErr.Data != nil {
+		var data jsonrpc.DuplicateNoteData
+		if json.Unmarshal(rpcErr.Data, &data) == nil && data.DuplicateID != "" {
+			return &service.DuplicateNoteError{ID: data.DuplicateID, Score: data.Score}
+		}
+	}
+	return err
+}
+
+// nullableString はパッチ値をJSONに変換する（nil: 未指定、空文字列: null）
+func nullableString(v *string) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	if *v == "" {
+		return json.RawMessage("null")
+	}
+	data, _ := json.Marshal(*v)
+	return data
+}
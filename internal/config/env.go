@@ -1,23 +1,109 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/brbranch/embedding_mcp/internal/model"
 )
 
 // 環境変数名の定数
+// 優先順位: CLIフラグ > 環境変数 > 設定ファイル > デフォルト値
 const (
 	EnvOpenAIAPIKey = "OPENAI_API_KEY"
+
+	EnvConfigPath       = "MCP_MEMORY_CONFIG"
+	EnvConfigJSON       = "MCP_MEMORY_CONFIG_JSON"
+	EnvProfile          = "MCP_MEMORY_PROFILE"
+	EnvTransport        = "MCP_MEMORY_TRANSPORT"
+	EnvCORSOrigins      = "MCP_MEMORY_CORS_ORIGINS"
+	EnvEmbedderProvider = "MCP_MEMORY_EMBEDDER_PROVIDER"
+	EnvEmbedderModel    = "MCP_MEMORY_EMBEDDER_MODEL"
+	EnvEmbedderDim      = "MCP_MEMORY_EMBEDDER_DIM"
+	EnvEmbedderBaseURL  = "MCP_MEMORY_EMBEDDER_BASE_URL"
+	EnvEmbedderAPIKey   = "MCP_MEMORY_EMBEDDER_API_KEY"
+	EnvStoreType        = "MCP_MEMORY_STORE_TYPE"
+	EnvStorePath        = "MCP_MEMORY_STORE_PATH"
+	EnvStoreURL         = "MCP_MEMORY_STORE_URL"
+	EnvStoreAPIKey      = "MCP_MEMORY_STORE_API_KEY"
+	EnvDataDir          = "MCP_MEMORY_DATA_DIR"
+	EnvAuthAPIKey       = "MCP_MEMORY_AUTH_API_KEY"
+	EnvEncryptionKey    = "MCP_MEMORY_ENCRYPTION_KEY"
 )
 
 // ApplyEnvOverrides は環境変数による設定上書きを適用する
-// config を直接変更する
-func ApplyEnvOverrides(config *model.Config) {
-	// OpenAI APIキーの環境変数上書き
+// config を直接変更する。空文字の環境変数は未設定として扱う
+func ApplyEnvOverrides(config *model.Config) error {
+	if v := os.Getenv(EnvTransport); v != "" {
+		config.TransportDefaults.DefaultTransport = v
+	}
+	// カンマ区切りのオリジン一覧
+	if v := os.Getenv(EnvCORSOrigins); v != "" {
+		var origins []string
+		for _, origin := range strings.Split(v, ",") {
+			if origin = strings.TrimSpace(origin); origin != "" {
+				origins = append(origins, origin)
+			}
+		}
+		config.TransportDefaults.CORSOrigins = origins
+	}
+
+	if v := os.Getenv(EnvEmbedderProvider); v != "" {
+		config.Embedder.Provider = v
+	}
+	if v := os.Getenv(EnvEmbedderModel); v != "" {
+		config.Embedder.Model = v
+	}
+	if v := os.Getenv(EnvEmbedderDim); v != "" {
+		dim, err := strconv.Atoi(v)
+		if err != nil || dim < 0 {
+			return fmt.Errorf("invalid %s=%q: must be a non-negative integer", EnvEmbedderDim, v)
+		}
+		config.Embedder.Dim = dim
+	}
+	if v := os.Getenv(EnvEmbedderBaseURL); v != "" {
+		config.Embedder.BaseURL = &v
+	}
+	// OpenAI APIキーの環境変数上書き（OPENAI_API_KEYを優先）
+	if v := os.Getenv(EnvEmbedderAPIKey); v != "" {
+		config.Embedder.APIKey = &v
+	}
 	if apiKey := os.Getenv(EnvOpenAIAPIKey); apiKey != "" {
 		config.Embedder.APIKey = &apiKey
 	}
+
+	if v := os.Getenv(EnvStoreType); v != "" {
+		config.Store.Type = v
+	}
+	if v := os.Getenv(EnvStorePath); v != "" {
+		config.Store.Path = &v
+	}
+	if v := os.Getenv(EnvStoreURL); v != "" {
+		config.Store.URL = &v
+	}
+	if v := os.Getenv(EnvStoreAPIKey); v != "" {
+		config.Store.APIKey = &v
+	}
+	if v := os.Getenv(EnvDataDir); v != "" {
+		config.Paths.DataDir = v
+	}
+	if v := os.Getenv(EnvAuthAPIKey); v != "" {
+		if config.Auth == nil {
+			config.Auth = &model.AuthConfig{}
+		}
+		config.Auth.APIKey = &v
+	}
+	// 暗号化の鍵（先頭の鍵を上書きする。encryption未設定なら"default"の鍵を追加する）
+	if v := os.Getenv(EnvEncryptionKey); v != "" {
+		if config.Encryption == nil || len(config.Encryption.Keys) == 0 {
+			config.Encryption = &model.EncryptionConfig{Keys: []model.EncryptionKeyConfig{{ID: "default"}}}
+		}
+		config.Encryption.Keys[0].Key = &v
+	}
+
+	return nil
 }
 
 // GetOpenAIAPIKey は環境変数からOpenAI APIキーを取得する
@@ -27,6 +113,9 @@ func GetOpenAIAPIKey(config *model.Config) string {
 	if apiKey := os.Getenv(EnvOpenAIAPIKey); apiKey != "" {
 		return apiKey
 	}
+	if apiKey := os.Getenv(EnvEmbedderAPIKey); apiKey != "" {
+		return apiKey
+	}
 	// 設定ファイルの値
 	if config.Embedder.APIKey != nil {
 		return *config.Embedder.APIKey
@@ -117,3 +117,78 @@ func TestGetOpenAIAPIKey_Empty(t *testing.T) {
 		t.Errorf("expected empty string, got %q", key)
 	}
 }
+
+// TestApplyEnvOverrides_AllFields はMCP_MEMORY_*環境変数で各設定が上書きされることをテスト
+func TestApplyEnvOverrides_AllFields(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("MCP_MEMORY_TRANSPORT", "http")
+	t.Setenv("MCP_MEMORY_CORS_ORIGINS", "https://a.example, https://b.example,")
+	t.Setenv("MCP_MEMORY_EMBEDDER_PROVIDER", "ollama")
+	t.Setenv("MCP_MEMORY_EMBEDDER_MODEL", "nomic-embed-text")
+	t.Setenv("MCP_MEMORY_EMBEDDER_DIM", "768")
+	t.Setenv("MCP_MEMORY_EMBEDDER_BASE_URL", "http://ollama:11434")
+	t.Setenv("MCP_MEMORY_EMBEDDER_API_KEY", "mcp-key")
+	t.Setenv("MCP_MEMORY_STORE_TYPE", "qdrant")
+	t.Setenv("MCP_MEMORY_STORE_PATH", "/data/memory.db")
+	t.Setenv("MCP_MEMORY_STORE_URL", "http://qdrant:6333")
+	t.Setenv("MCP_MEMORY_DATA_DIR", "/data")
+	t.Setenv("MCP_MEMORY_AUTH_API_KEY", "admin-key")
+	t.Setenv("MCP_MEMORY_ENCRYPTION_KEY", "enc-key")
+
+	cfg := DefaultConfig("/config.json", "/home/data")
+	if err := ApplyEnvOverrides(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.TransportDefaults.DefaultTransport != "http" {
+		t.Errorf("transport = %q", cfg.TransportDefaults.DefaultTransport)
+	}
+	if origins := cfg.TransportDefaults.CORSOrigins; len(origins) != 2 || origins[0] != "https://a.example" || origins[1] != "https://b.example" {
+		t.Errorf("corsOrigins = %q", origins)
+	}
+	if cfg.Embedder.Provider != "ollama" || cfg.Embedder.Model != "nomic-embed-text" || cfg.Embedder.Dim != 768 {
+		t.Errorf("embedder = %+v", cfg.Embedder)
+	}
+	if cfg.Embedder.BaseURL == nil || *cfg.Embedder.BaseURL != "http://ollama:11434" {
+		t.Errorf("baseUrl = %v", cfg.Embedder.BaseURL)
+	}
+	if cfg.Embedder.APIKey == nil || *cfg.Embedder.APIKey != "mcp-key" {
+		t.Errorf("apiKey = %v", cfg.Embedder.APIKey)
+	}
+	if cfg.Store.Type != "qdrant" || *cfg.Store.Path != "/data/memory.db" || *cfg.Store.URL != "http://qdrant:6333" {
+		t.Errorf("store = %+v", cfg.Store)
+	}
+	if cfg.Paths.DataDir != "/data" {
+		t.Errorf("dataDir = %q", cfg.Paths.DataDir)
+	}
+	if cfg.Auth == nil || cfg.Auth.APIKey == nil || *cfg.Auth.APIKey != "admin-key" {
+		t.Errorf("auth = %+v", cfg.Auth)
+	}
+	if cfg.Encryption == nil || len(cfg.Encryption.Keys) != 1 || cfg.Encryption.Keys[0].ID != "default" || *cfg.Encryption.Keys[0].Key != "enc-key" {
+		t.Errorf("encryption = %+v", cfg.Encryption)
+	}
+}
+
+// TestApplyEnvOverrides_OpenAIAPIKeyPriority はOPENAI_API_KEYがMCP_MEMORY_EMBEDDER_API_KEYより優先されることをテスト
+func TestApplyEnvOverrides_OpenAIAPIKeyPriority(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "openai-key")
+	t.Setenv("MCP_MEMORY_EMBEDDER_API_KEY", "mcp-key")
+
+	cfg := DefaultConfig("/config.json", "/data")
+	if err := ApplyEnvOverrides(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *cfg.Embedder.APIKey != "openai-key" {
+		t.Errorf("expected apiKey 'openai-key', got %q", *cfg.Embedder.APIKey)
+	}
+}
+
+// TestApplyEnvOverrides_InvalidDim は不正な次元指定でエラーになることをテスト
+func TestApplyEnvOverrides_InvalidDim(t *testing.T) {
+	t.Setenv("MCP_MEMORY_EMBEDDER_DIM", "large")
+
+	cfg := DefaultConfig("/config.json", "/data")
+	if err := ApplyEnvOverrides(cfg); err == nil {
+		t.Fatal("expected error for invalid dim")
+	}
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrMissingEnv は設定値が参照する環境変数が未設定
+var ErrMissingEnv = errors.New("environment variable not set")
+
+// ExpandEnv はs中の ${VAR} を環境変数の値に展開する
+//   - ${VAR:-default} はVARが未設定または空の場合にdefaultを使う
+//   - $${ は展開せず ${ として残す（エスケープ）
+//   - ${ を含まない $ はそのまま（APIキー等に含まれ得るため $VAR 形式は展開しない）
+//
+// 未設定の変数を既定値なしで参照した場合はErrMissingEnvを返す
+func ExpandEnv(s string, lookup func(string) (string, bool)) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if strings.HasPrefix(s[i:], "$${") {
+			b.WriteString("${")
+			i += 3
+			continue
+		}
+		if !strings.HasPrefix(s[i:], "${") {
+			b.WriteByte(s[i])
+			i++
+			continue
+		}
+
+		end := strings.IndexByte(s[i+2:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated variable reference in %q", s)
+		}
+		expr := s[i+2 : i+2+end]
+		name, def, hasDefault := strings.Cut(expr, ":-")
+		if !validEnvName(name) {
+			return "", fmt.Errorf("invalid variable reference ${%s}", expr)
+		}
+
+		v, ok := lookup(name)
+		switch {
+		case hasDefault && v == "":
+			v = def
+		case !ok:
+			return "", fmt.Errorf("%w: %s", ErrMissingEnv, name)
+		}
+		b.WriteString(v)
+		i += 2 + end + 1
+	}
+	return b.String(), nil
+}
+
+// validEnvName は環境変数名として有効か（英数字と_、先頭は数字以外）
+func validEnvName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		switch {
+		case c == '_', c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// expandConfigEnv は設定ファイルのJSON中の全文字列値について ${VAR} を展開する
+// エラーにはどの設定値か（例: embedder.apiKey）を含める
+func expandConfigEnv(data []byte) ([]byte, error) {
+	if !bytes.Contains(data, []byte("${")) {
+		return data, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber() // 数値を丸めずに書き戻す
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	expanded, err := expandValue(doc, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand config file: %w", err)
+	}
+	return json.Marshal(expanded)
+}
+
+// expandValue はvを再帰的にたどり、文字列値を展開する
+func expandValue(v any, path string) (any, error) {
+	switch t := v.(type) {
+	case string:
+		s, err := ExpandEnv(t, os.LookupEnv)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return s, nil
+	case map[string]any:
+		// エラーが決定的になるようキー順に処理
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := k
+			if path != "" {
+				child = path + "." + k
+			}
+			e, err := expandValue(t[k], child)
+			if err != nil {
+				return nil, err
+			}
+			t[k] = e
+		}
+		return t, nil
+	case []any:
+		for i := range t {
+			e, err := expandValue(t[i], path+"["+strconv.Itoa(i)+"]")
+			if err != nil {
+				return nil, err
+			}
+			t[i] = e
+		}
+		return t, nil
+	default:
+		return v, nil
+	}
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestExpandEnv は ${VAR} 展開の各形式をテスト
+func TestExpandEnv(t *testing.T) {
+	env := map[string]string{"KEY": "secret", "EMPTY": "", "HOST": "db"}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr error
+	}{
+		{name: "no reference", in: "plain $value", want: "plain $value"},
+		{name: "whole value", in: "${KEY}", want: "secret"},
+		{name: "embedded", in: "http://${HOST}:6333", want: "http://db:6333"},
+		{name: "empty but set", in: "${EMPTY}", want: ""},
+		{name: "default when unset", in: "${MISSING:-fallback}", want: "fallback"},
+		{name: "default when empty", in: "${EMPTY:-fallback}", want: "fallback"},
+		{name: "default ignored when set", in: "${KEY:-fallback}", want: "secret"},
+		{name: "escaped", in: "$${KEY}", want: "${KEY}"},
+		{name: "missing", in: "${MISSING}", wantErr: ErrMissingEnv},
+		{name: "unterminated", in: "${KEY", wantErr: errAny},
+		{name: "invalid name", in: "${1KEY}", wantErr: errAny},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandEnv(tt.in, lookup)
+			if tt.wantErr != nil {
+				if err == nil {
+					t.Fatalf("expected error, got %q", got)
+				}
+				if tt.wantErr != errAny && !errors.Is(err, tt.wantErr) {
+					t.Errorf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// errAny は種類を問わずエラーを期待することを示す
+var errAny = errors.New("any error")
+
+// TestExpandConfigEnv はネストした値・配列を展開し、参照がなければそのまま返すことをテスト
+func TestExpandConfigEnv(t *testing.T) {
+	t.Setenv("TEST_MCP_TAG", "x")
+
+	got, err := expandConfigEnv([]byte(`{"a":{"b":["${TEST_MCP_TAG}",1]},"n":12345678901234567}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(got) != `{"a":{"b":["x",1]},"n":12345678901234567}` {
+		t.Errorf("unexpected result: %s", got)
+	}
+
+	plain := []byte(`{"a": 1}`)
+	if got, err := expandConfigEnv(plain); err != nil || string(got) != string(plain) {
+		t.Errorf("expected input unchanged, got %s, %v", got, err)
+	}
+}
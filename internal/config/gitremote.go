@@ -0,0 +1,131 @@
+package config
+
+import (
+	"bufio"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// gitRemoteProjectID はdirを含むgitリポジトリのリモートURLから求めたIDを返す（enabledがfalseなら何もしない）
+// リポジトリ外・リモートがない・ローカルパスのリモートの場合はfalse
+func gitRemoteProjectID(dir string, enabled bool) (string, bool) {
+	if !enabled {
+		return "", false
+	}
+	root, err := FindGitRoot(dir)
+	if err != nil {
+		return "", false
+	}
+	remote := readGitRemote(root)
+	if remote == "" {
+		return "", false
+	}
+	id := NormalizeGitRemote(remote)
+	return id, id != ""
+}
+
+// NormalizeGitRemote はgitのリモートURLを "host/org/repo" の形に正規化する
+//   - git@github.com:org/repo.git、https://user@github.com/org/repo.git、ssh://git@github.com:22/org/repo
+//     はいずれも github.com/org/repo
+//   - ホストは小文字にし、ポート・ユーザー・末尾の ".git" と "/" は除く
+//
+// ローカルパス（/srv/repo.git、file://）など、マシン間で共通にならないものは空文字を返す
+func NormalizeGitRemote(remote string) string {
+	remote = strings.TrimSpace(remote)
+	var host, path string
+	if strings.Contains(remote, "://") {
+		u, err := url.Parse(remote)
+		if err != nil || u.Scheme == "file" || u.Hostname() == "" {
+			return ""
+		}
+		host, path = u.Hostname(), u.Path
+	} else {
+		// scp形式（[user@]host:path）
+		i := strings.Index(remote, ":")
+		if i <= 0 || strings.ContainsAny(remote[:i], "/\\") {
+			return ""
+		}
+		host, path = remote[:i], remote[i+1:]
+		if at := strings.LastIndex(host, "@"); at >= 0 {
+			host = host[at+1:]
+		}
+	}
+
+	path = strings.Trim(path, "/")
+	path = strings.TrimSuffix(path, ".git")
+	if host == "" || path == "" {
+		return ""
+	}
+	return strings.ToLower(host) + "/" + path
+}
+
+// isGitRemoteID はidが正規化済みのgitリモートのID（host/org/repo）に見えるか
+// 相対パスと区別するため、先頭の要素に "." を含み、カレントディレクトリに存在しないものに限る
+func isGitRemoteID(id string) bool {
+	if filepath.IsAbs(id) || strings.HasPrefix(id, "~") || strings.HasPrefix(id, ".") {
+		return false
+	}
+	parts := strings.Split(id, "/")
+	if len(parts) < 2 || !strings.Contains(parts[0], ".") || NormalizeGitRemote(parts[0]+":"+strings.Join(parts[1:], "/")) != id {
+		return false
+	}
+	_, err := os.Stat(id)
+	return os.IsNotExist(err)
+}
+
+// readGitRemote はgitルートの設定からoriginのURL（なければ最初のリモートのURL）を読む
+// worktree・サブモジュール（.gitがファイル）の場合はgitdir・commondirをたどる
+func readGitRemote(root string) string {
+	gitDir := filepath.Join(root, ".git")
+	if data, err := os.ReadFile(gitDir); err == nil {
+		// .gitファイル: "gitdir: <path>"
+		dir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+		if !ok {
+			return ""
+		}
+		gitDir = strings.TrimSpace(dir)
+		if !filepath.IsAbs(gitDir) {
+			gitDir = filepath.Join(root, gitDir)
+		}
+		if common, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+			dir := strings.TrimSpace(string(common))
+			if !filepath.IsAbs(dir) {
+				dir = filepath.Join(gitDir, dir)
+			}
+			gitDir = dir
+		}
+	}
+
+	f, err := os.Open(filepath.Join(gitDir, "config"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	var first, origin, section string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			section = strings.Trim(line, "[]")
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || !strings.HasPrefix(section, "remote ") || strings.TrimSpace(key) != "url" {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if section == `remote "origin"` && origin == "" {
+			origin = value
+		}
+		if first == "" {
+			first = value
+		}
+	}
+	if origin != "" {
+		return origin
+	}
+	return first
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNormalizeGitRemote(t *testing.T) {
+	tests := []struct {
+		remote string
+		want   string
+	}{
+		{"git@github.com:org/repo.git", "github.com/org/repo"},
+		{"https://github.com/org/repo.git", "github.com/org/repo"},
+		{"https://user@GitHub.com/org/repo/", "github.com/org/repo"},
+		{"ssh://git@github.com:22/org/repo", "github.com/org/repo"},
+		{"git@gitlab.example.com:group/sub/repo.git", "gitlab.example.com/group/sub/repo"},
+		{"/srv/git/repo.git", ""},
+		{"file:///srv/git/repo.git", ""},
+		{"../repo", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeGitRemote(tt.remote); got != tt.want {
+			t.Errorf("NormalizeGitRemote(%q) = %q, want %q", tt.remote, got, tt.want)
+		}
+	}
+}
+
+// writeGitConfig はdir/.gitにリモートを設定したgitの設定ファイルを作成する
+func writeGitConfig(t *testing.T, dir, config string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".git", "config"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestCanonicalizeProjectIDWith_GitRemote はgitリモートからprojectIdを求めることをテスト
+func TestCanonicalizeProjectIDWith_GitRemote(t *testing.T) {
+	tmpDir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to resolve temp dir: %v", err)
+	}
+	repo := filepath.Join(tmpDir, "repo")
+	writeGitConfig(t, repo, "[core]\n\tbare = false\n[remote \"upstream\"]\n\turl = git@github.com:upstream/repo.git\n[remote \"origin\"]\n\turl = https://github.com/org/repo.git\n")
+	sub := filepath.Join(repo, "pkg")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// worktree（.gitファイル → gitdir → commondir）
+	worktree := filepath.Join(tmpDir, "worktree")
+	wtGitDir := filepath.Join(repo, ".git", "worktrees", "wt")
+	if err := os.MkdirAll(wtGitDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(wtGitDir, "commondir"), []byte("../..\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(worktree, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(worktree, ".git"), []byte("gitdir: "+wtGitDir+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// リモートのないリポジトリ
+	local := filepath.Join(tmpDir, "local")
+	writeGitConfig(t, local, "[core]\n\tbare = false\n")
+
+	rules := CanonicalizeRules{ResolveSymlinks: true, GitRemote: true}
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"origin", repo, "github.com/org/repo"},
+		{"subdirectory", sub, "github.com/org/repo"},
+		{"worktree", worktree, "github.com/org/repo"},
+		{"no remote", local, local},
+		{"outside repository", tmpDir, tmpDir},
+		{"already a remote ID", "github.com/org/repo", "github.com/org/repo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CanonicalizeProjectIDWith(tt.input, rules)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	// 無効なら従来どおりパス
+	if got, _ := CanonicalizeProjectIDWith(repo, DefaultCanonicalizeRules); got != repo {
+		t.Errorf("expected %q without gitRemote, got %q", repo, got)
+	}
+}
@@ -15,12 +15,17 @@ type Manager struct {
 	mu         sync.RWMutex
 	config     *model.Config
 	configPath string
+	profile    string // 適用するプロファイル名（MCP_MEMORY_PROFILE、空なら適用しない）
 }
 
 // NewManager は新しいManagerを作成する
-// configPathが空文字の場合、デフォルトパス（~/.local-mcp-memory/config.json）を使用
+// configPathが空文字の場合、MCP_MEMORY_CONFIG、デフォルトパス（~/.local-mcp-memory/config.json）の順に使用
+// MCP_MEMORY_CONFIG_JSONが設定されていれば、Loadは設定ファイルの代わりにその内容を読み込む
 func NewManager(configPath string) (*Manager, error) {
-	// configPathが空の場合はデフォルトパスを使用
+	// configPathが空の場合は環境変数、なければデフォルトパスを使用
+	if configPath == "" {
+		configPath = os.Getenv(EnvConfigPath)
+	}
 	if configPath == "" {
 		defaultPath, err := GetDefaultConfigPath()
 		if err != nil {
@@ -41,31 +46,83 @@ func NewManager(configPath string) (*Manager, error) {
 	return &Manager{
 		config:     config,
 		configPath: configPath,
+		profile:    os.Getenv(EnvProfile),
 	}, nil
 }
 
 // Load は設定ファイルを読み込む
 // ファイルが存在しない場合はデフォルト設定を使用（エラーなし）
+// 設定値中の ${VAR} / ${VAR:-default} は環境変数で展開する（未設定の変数はエラー）
+// プロファイルが選択されていればその内容で置き換え、
+// いずれの場合も最後に環境変数（MCP_MEMORY_*）による上書きを適用する
+// 未知のキーや不正な値（Validate）はどの設定かを示すエラーにする
 func (m *Manager) Load() error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	// ファイルが存在しない場合はデフォルト設定を使う
-	if _, err := os.Stat(m.configPath); os.IsNotExist(err) {
-		// デフォルト設定は既に設定されているのでそのまま返す
-		return nil
+	// 設定ファイルを置かない環境（コンテナ等）向けに、環境変数のJSONをファイルの代わりに使う
+	source := m.configPath
+	data := []byte(os.Getenv(EnvConfigJSON))
+	if len(data) > 0 {
+		source = EnvConfigJSON
+	} else {
+		// ファイルが存在しない場合はデフォルト設定を使う
+		if _, err := os.Stat(m.configPath); os.IsNotExist(err) {
+			// デフォルト設定は既に設定されているのでプロファイルと環境変数のみ適用
+			if err := ApplyProfile(m.config, m.profile); err != nil {
+				return err
+			}
+			if err := ApplyEnvOverrides(m.config); err != nil {
+				return err
+			}
+			return Validate(m.config)
+		}
+
+		// ファイルを読み込み
+		var err error
+		data, err = os.ReadFile(m.configPath)
+		if err != nil {
+			return fmt.Errorf("failed to read config file: %w", err)
+		}
 	}
 
-	// ファイルを読み込み
-	data, err := os.ReadFile(m.configPath)
+	// 設定値中の ${VAR} を環境変数で展開（APIキー等をファイルに書かずに済むように）
+	data, err := expandConfigEnv(data)
 	if err != nil {
-		return fmt.Errorf("failed to read config file: %w", err)
+		return err
+	}
+
+	// 未知のキー（typo等）は無視せずエラーにする
+	if err := checkUnknownKeys(data); err != nil {
+		return fmt.Errorf("%s: %w", source, err)
 	}
 
 	// JSONをパース
 	var config model.Config
 	if err := json.Unmarshal(data, &config); err != nil {
-		return fmt.Errorf("failed to parse config file: %w", err)
+		return fmt.Errorf("failed to parse %s: %w", source, err)
+	}
+	// ファイルの値（全プロファイルを含む）を検証
+	if err := Validate(&config); err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+	if err := ApplyProfile(&config, m.profile); err != nil {
+		return err
+	}
+	// dataDir未指定の場合はOS標準のデフォルトを使う
+	if config.Paths.DataDir == "" {
+		dataDir, err := GetDefaultDataDir()
+		if err != nil {
+			return fmt.Errorf("failed to get default data dir: %w", err)
+		}
+		config.Paths.DataDir = dataDir
+	}
+	if err := ApplyEnvOverrides(&config); err != nil {
+		return err
+	}
+	// 環境変数で上書きした値を検証
+	if err := Validate(&config); err != nil {
+		return err
 	}
 
 	m.config = &config
@@ -112,6 +169,11 @@ func (m *Manager) GetConfig() *model.Config {
 	return m.config
 }
 
+// Profile は適用するプロファイル名を返す（未選択なら空）
+func (m *Manager) Profile() string {
+	return m.profile
+}
+
 // GetConfigPath は設定ファイルパスを返す
 func (m *Manager) GetConfigPath() string {
 	return m.configPath
@@ -152,6 +214,33 @@ func (m *Manager) UpdateDim(dim int) error {
 	return nil
 }
 
+// Replace は設定をcfgで置き換えて保存する（バックアップからの復元用）
+// paths（このマシン上のパス）と設定ファイルに保存済みのapiKey（embedder・store）・auth・encryptionは維持する
+// 環境変数による上書きを保存しないよう、Loadを呼ぶ前のManagerで使用する
+func (m *Manager) Replace(cfg *model.Config) error {
+	m.mu.Lock()
+	current := m.config
+	if data, err := os.ReadFile(m.configPath); err == nil {
+		var fileConfig model.Config
+		if err := json.Unmarshal(data, &fileConfig); err != nil {
+			m.mu.Unlock()
+			return fmt.Errorf("failed to parse config file: %w", err)
+		}
+		current = &fileConfig
+	}
+
+	next := *cfg
+	next.Paths = current.Paths
+	next.Embedder.APIKey = current.Embedder.APIKey
+	next.Store.APIKey = current.Store.APIKey
+	next.Auth = current.Auth
+	next.Encryption = current.Encryption
+	m.config = &next
+	m.mu.Unlock()
+
+	return m.Save()
+}
+
 // NewManagerWithConfig は指定した設定でManagerを作成する（テスト用）
 func NewManagerWithConfig(cfg *model.Config) *Manager {
 	return &Manager{
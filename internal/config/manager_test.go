@@ -1,8 +1,10 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/brbranch/embedding_mcp/internal/model"
@@ -132,6 +134,198 @@ func TestManager_Load_Exist(t *testing.T) {
 	}
 }
 
+// TestManager_Load_EnvOverrides は環境変数が設定ファイルの値より優先されることをテスト
+func TestManager_Load_EnvOverrides(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.json")
+
+	configJSON := `{
+		"embedder": {"provider": "openai", "model": "text-embedding-3-small"},
+		"store": {"type": "sqlite"}
+	}`
+	if err := os.WriteFile(configPath, []byte(configJSON), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	t.Setenv("MCP_MEMORY_CONFIG", configPath)
+	t.Setenv("MCP_MEMORY_EMBEDDER_MODEL", "text-embedding-3-large")
+
+	mgr, err := NewManager("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mgr.GetConfigPath() != configPath {
+		t.Errorf("expected config path from MCP_MEMORY_CONFIG, got %q", mgr.GetConfigPath())
+	}
+	if err := mgr.Load(); err != nil {
+		t.Fatalf("unexpected error on load: %v", err)
+	}
+
+	cfg := mgr.GetConfig()
+	if cfg.Embedder.Model != "text-embedding-3-large" {
+		t.Errorf("expected model from env, got %q", cfg.Embedder.Model)
+	}
+	if cfg.Store.Type != "sqlite" {
+		t.Errorf("expected store type from file, got %q", cfg.Store.Type)
+	}
+}
+
+// TestManager_Load_Profile はMCP_MEMORY_PROFILEで選択したプロファイルが適用され、
+// 環境変数による上書きがその後に適用されることをテスト
+func TestManager_Load_Profile(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.json")
+
+	configJSON := `{
+		"embedder": {"provider": "openai", "model": "text-embedding-3-small"},
+		"store": {"type": "chroma"},
+		"profiles": {
+			"offline": {
+				"embedder": {"provider": "ollama", "model": "nomic-embed-text"},
+				"store": {"type": "sqlite"}
+			}
+		}
+	}`
+	if err := os.WriteFile(configPath, []byte(configJSON), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	t.Setenv("MCP_MEMORY_PROFILE", "offline")
+	t.Setenv("MCP_MEMORY_EMBEDDER_MODEL", "mxbai-embed-large")
+
+	mgr, err := NewManager(configPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mgr.Profile() != "offline" {
+		t.Errorf("expected profile offline, got %q", mgr.Profile())
+	}
+	if err := mgr.Load(); err != nil {
+		t.Fatalf("unexpected error on load: %v", err)
+	}
+
+	cfg := mgr.GetConfig()
+	if cfg.Embedder.Provider != "ollama" || cfg.Embedder.Model != "mxbai-embed-large" {
+		t.Errorf("unexpected embedder: %+v", cfg.Embedder)
+	}
+	if cfg.Store.Type != "sqlite" {
+		t.Errorf("expected store type from profile, got %q", cfg.Store.Type)
+	}
+
+	// 存在しないプロファイルはエラー
+	t.Setenv("MCP_MEMORY_PROFILE", "work")
+	mgr, err = NewManager(configPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mgr.Load(); !errors.Is(err, ErrUnknownProfile) {
+		t.Errorf("expected ErrUnknownProfile, got %v", err)
+	}
+}
+
+// TestManager_Load_ExpandEnv は設定値中の ${VAR} が環境変数で展開されることをテスト
+func TestManager_Load_ExpandEnv(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.json")
+
+	configJSON := `{
+		"embedder": {"provider": "openai", "model": "text-embedding-3-small", "dim": 1536, "apiKey": "${TEST_MCP_API_KEY}"},
+		"store": {"type": "qdrant", "url": "${TEST_MCP_QDRANT_URL:-http://localhost:6333}"}
+	}`
+	if err := os.WriteFile(configPath, []byte(configJSON), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	t.Setenv("TEST_MCP_API_KEY", "sk-from-env")
+
+	mgr, err := NewManager(configPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mgr.Load(); err != nil {
+		t.Fatalf("unexpected error on load: %v", err)
+	}
+
+	cfg := mgr.GetConfig()
+	if cfg.Embedder.APIKey == nil || *cfg.Embedder.APIKey != "sk-from-env" {
+		t.Errorf("expected apiKey from env, got %v", cfg.Embedder.APIKey)
+	}
+	if cfg.Store.URL == nil || *cfg.Store.URL != "http://localhost:6333" {
+		t.Errorf("expected default store url, got %v", cfg.Store.URL)
+	}
+	if cfg.Embedder.Dim != 1536 {
+		t.Errorf("expected dim 1536, got %d", cfg.Embedder.Dim)
+	}
+}
+
+// TestManager_Load_ExpandEnvMissing は未設定の環境変数を参照するとエラーになることをテスト
+func TestManager_Load_ExpandEnvMissing(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.json")
+
+	configJSON := `{"embedder": {"provider": "openai", "apiKey": "${TEST_MCP_UNSET_KEY}"}}`
+	if err := os.WriteFile(configPath, []byte(configJSON), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	t.Setenv("TEST_MCP_UNSET_KEY", "") // テスト終了時に元の状態へ戻す
+	os.Unsetenv("TEST_MCP_UNSET_KEY")
+
+	mgr, err := NewManager(configPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = mgr.Load()
+	if !errors.Is(err, ErrMissingEnv) {
+		t.Fatalf("expected ErrMissingEnv, got %v", err)
+	}
+	for _, want := range []string{"embedder.apiKey", "TEST_MCP_UNSET_KEY"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to mention %q, got %v", want, err)
+		}
+	}
+}
+
+// TestManager_Load_ConfigJSON はMCP_MEMORY_CONFIG_JSONが設定ファイルの代わりに読み込まれることをテスト
+func TestManager_Load_ConfigJSON(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"embedder": {"provider": "openai", "model": "text-embedding-3-small"}}`), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	t.Setenv("TEST_MCP_QDRANT_URL", "http://qdrant:6333")
+	t.Setenv("MCP_MEMORY_EMBEDDER_MODEL", "mxbai-embed-large")
+	t.Setenv("MCP_MEMORY_CONFIG_JSON", `{
+		"embedder": {"provider": "ollama", "model": "nomic-embed-text"},
+		"store": {"type": "qdrant", "url": "${TEST_MCP_QDRANT_URL}"},
+		"paths": {"dataDir": "/data"},
+		"tags": {"normalize": true}
+	}`)
+
+	mgr, err := NewManager(configPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mgr.Load(); err != nil {
+		t.Fatalf("unexpected error on load: %v", err)
+	}
+	cfg := mgr.GetConfig()
+	// 個別の環境変数による上書きはJSONの後に適用する
+	if cfg.Embedder.Provider != "ollama" || cfg.Embedder.Model != "mxbai-embed-large" {
+		t.Errorf("embedder = %+v", cfg.Embedder)
+	}
+	if cfg.Store.URL == nil || *cfg.Store.URL != "http://qdrant:6333" {
+		t.Errorf("expected the expanded store url, got %v", cfg.Store.URL)
+	}
+	if cfg.Paths.DataDir != "/data" || cfg.Tags == nil || !cfg.Tags.Normalize {
+		t.Errorf("unexpected config: paths=%+v tags=%+v", cfg.Paths, cfg.Tags)
+	}
+
+	// 不正な内容は環境変数名を示すエラーにする
+	t.Setenv("MCP_MEMORY_CONFIG_JSON", `{"embeder": {}}`)
+	mgr, _ = NewManager(configPath)
+	if err := mgr.Load(); err == nil || !strings.Contains(err.Error(), "MCP_MEMORY_CONFIG_JSON") {
+		t.Errorf("expected an error mentioning MCP_MEMORY_CONFIG_JSON, got %v", err)
+	}
+}
+
 // TestManager_Load_Invalid は不正なJSONでエラーになることをテスト
 func TestManager_Load_Invalid(t *testing.T) {
 	tmpDir := t.TempDir()
@@ -367,3 +561,55 @@ func TestDefaultConfig(t *testing.T) {
 		t.Errorf("expected data dir %q, got %q", dataDir, cfg.Paths.DataDir)
 	}
 }
+
+// TestManager_Replace はpaths・保存済みapiKey・authを維持して設定を置き換えることをテスト
+func TestManager_Replace(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.json")
+	dataDir := filepath.Join(tmpDir, "data")
+
+	configJSON := `{
+		"embedder": {"provider": "openai", "model": "text-embedding-3-small", "apiKey": "file-key"},
+		"store": {"type": "sqlite"},
+		"paths": {"configPath": "` + configPath + `", "dataDir": "` + dataDir + `"},
+		"auth": {"tokens": [{"token": "file-token", "projectIds": ["/proj"]}]}
+	}`
+	if err := os.WriteFile(configPath, []byte(configJSON), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	mgr, err := NewManager(configPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	restored := &model.Config{
+		Embedder: model.EmbedderConfig{Provider: "ollama", Model: "nomic-embed-text", Dim: 768},
+		Store:    model.StoreConfig{Type: "qdrant"},
+		Paths:    model.PathsConfig{ConfigPath: "/other/config.json", DataDir: "/other/data"},
+	}
+	if err := mgr.Replace(restored); err != nil {
+		t.Fatalf("Replace failed: %v", err)
+	}
+
+	loaded, err := NewManager(configPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Setenv("OPENAI_API_KEY", "")
+	if err := loaded.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	cfg := loaded.GetConfig()
+	if cfg.Embedder.Provider != "ollama" || cfg.Embedder.Dim != 768 || cfg.Store.Type != "qdrant" {
+		t.Errorf("expected restored embedder/store, got %+v %+v", cfg.Embedder, cfg.Store)
+	}
+	if cfg.Paths.DataDir != dataDir {
+		t.Errorf("expected dataDir to be kept, got %q", cfg.Paths.DataDir)
+	}
+	if cfg.Embedder.APIKey == nil || *cfg.Embedder.APIKey != "file-key" {
+		t.Errorf("expected apiKey to be kept, got %v", cfg.Embedder.APIKey)
+	}
+	if cfg.Auth == nil || len(cfg.Auth.Tokens) != 1 || cfg.Auth.Tokens[0].Token != "file-token" {
+		t.Errorf("expected auth to be kept, got %+v", cfg.Auth)
+	}
+}
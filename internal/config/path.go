@@ -4,7 +4,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
 )
 
 const (
@@ -12,15 +16,126 @@ const (
 	DefaultConfigDir = ".local-mcp-memory"
 	// DefaultConfigFile はデフォルトの設定ファイル名
 	DefaultConfigFile = "config.json"
-	// DefaultDataSubDir はデフォルトのデータサブディレクトリ名
+	// DefaultDataSubDir はデフォルトのデータサブディレクトリ名（旧デフォルト ~/.local-mcp-memory/data）
 	DefaultDataSubDir = "data"
+	// AppDirName はOS標準のデータディレクトリ配下に作るディレクトリ名
+	AppDirName = "mcp-memory"
+)
+
+// CanonicalizeRules はprojectIdの正規化ルール（設定のprojectIdセクション）
+type CanonicalizeRules struct {
+	// CaseInsensitive はパスを小文字に揃える（大文字小文字を区別しないファイルシステムで
+	// 同じプロジェクトが別のprojectIdに分かれるのを防ぐ）
+	CaseInsensitive bool
+	// ResolveSymlinks はシンボリックリンクを解決する（/var と /private/var など）
+	ResolveSymlinks bool
+	// GitRemote はgitリポジトリ内のパスをリモートURLから求めたID（github.com/org/repo）にする
+	GitRemote bool
+	// Aliases は正規化済みパスから論理的なprojectIdへの対応（projectAliases・memory.add_project_alias）
+	Aliases map[string]string
+}
+
+// WithAliases はaliases（パス → projectId）を追加したルールを返す
+// パスはrで正規化する（正規化できないものはそのまま使う）。projectIdは変換しない
+func (r CanonicalizeRules) WithAliases(aliases map[string]string) CanonicalizeRules {
+	if len(aliases) == 0 {
+		return r
+	}
+	base := r
+	base.Aliases = nil
+	merged := make(map[string]string, len(r.Aliases)+len(aliases))
+	for path, projectID := range r.Aliases {
+		merged[path] = projectID
+	}
+	for path, projectID := range aliases {
+		if canonical, err := CanonicalizeProjectIDWith(path, base); err == nil {
+			path = canonical
+		}
+		merged[path] = projectID
+	}
+	r.Aliases = merged
+	return r
+}
+
+// isAliasTarget はprojectIDがいずれかのエイリアスの対応先か
+func (r CanonicalizeRules) isAliasTarget(projectID string) bool {
+	for _, target := range r.Aliases {
+		if target == projectID {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultCanonicalizeRules はデフォルトの正規化ルール
+var DefaultCanonicalizeRules = CanonicalizeRules{ResolveSymlinks: true}
+
+var (
+	canonicalizeMu    sync.RWMutex
+	canonicalizeRules = DefaultCanonicalizeRules
 )
 
-// CanonicalizeProjectID はprojectIdを正規化する
-// 1. "~" をホームディレクトリに展開
-// 2. 絶対パス化（filepath.Abs）
-// 3. シンボリックリンク解決（filepath.EvalSymlinks）※失敗時はAbsまで
+// SetCanonicalizeRules はCanonicalizeProjectIDが使うルールを設定する（起動・設定の再読み込み時）
+func SetCanonicalizeRules(r CanonicalizeRules) {
+	canonicalizeMu.Lock()
+	defer canonicalizeMu.Unlock()
+	canonicalizeRules = r
+}
+
+// CurrentCanonicalizeRules はCanonicalizeProjectIDが使うルールを返す
+func CurrentCanonicalizeRules() CanonicalizeRules {
+	canonicalizeMu.RLock()
+	defer canonicalizeMu.RUnlock()
+	return canonicalizeRules
+}
+
+// RulesFromConfig は設定のprojectIdセクションから正規化ルールを作る（未設定ならデフォルト）
+func RulesFromConfig(cfg *model.ProjectIDConfig) CanonicalizeRules {
+	r := DefaultCanonicalizeRules
+	if cfg == nil {
+		return r
+	}
+	r.CaseInsensitive = cfg.CaseInsensitive
+	r.GitRemote = cfg.GitRemote
+	if cfg.ResolveSymlinks != nil {
+		r.ResolveSymlinks = *cfg.ResolveSymlinks
+	}
+	return r
+}
+
+// CanonicalizeProjectID はprojectIdを現在のルール（SetCanonicalizeRules）で正規化する
 func CanonicalizeProjectID(projectID string) (string, error) {
+	return CanonicalizeProjectIDWith(projectID, CurrentCanonicalizeRules())
+}
+
+// CanonicalizePath はパスを現在のルールで正規化する（エイリアス・gitリモートは適用しない）
+// プロジェクトのディレクトリ（.mcp-memory.jsonの置き場所）を求める場合に使う
+func CanonicalizePath(path string) (string, error) {
+	r := CurrentCanonicalizeRules()
+	r.Aliases = nil
+	r.GitRemote = false
+	return CanonicalizeProjectIDWith(path, r)
+}
+
+// CanonicalizeProjectIDWith はprojectIdをルールに従って正規化する
+//  1. "~" をホームディレクトリに展開
+//  2. 絶対パス化（filepath.Abs。末尾の区切り文字や "." / ".." も取り除かれる）
+//  3. シンボリックリンク解決（ResolveSymlinks）。パスがまだ存在しない場合は存在する親まで解決し、
+//     リンク切れなどで解決できない場合は2.まで
+//  4. gitリモートのID（GitRemote）。gitリポジトリ内ならリポジトリのリモートURLから求めたIDにし、5.は行わない
+//     （リポジトリ外・リモートがない場合はパスのまま）
+//  5. 小文字化（CaseInsensitive）
+//  6. エイリアスの適用（Aliases）。エイリアスの対応先のprojectIdはそのまま返す
+func CanonicalizeProjectIDWith(projectID string, r CanonicalizeRules) (string, error) {
+	// 対応先は "github.com/org/repo" のようなパスでないIDの場合もあるため変換しない
+	if r.isAliasTarget(projectID) {
+		return projectID, nil
+	}
+	// 正規化済みのgitリモートのIDはそのまま
+	if r.GitRemote && isGitRemoteID(projectID) {
+		return projectID, nil
+	}
+
 	// 1. "~" をホームに展開
 	expanded, err := ExpandTilde(projectID)
 	if err != nil {
@@ -28,21 +143,50 @@ func CanonicalizeProjectID(projectID string) (string, error) {
 	}
 
 	// 2. 絶対パス化
-	abs, err := filepath.Abs(expanded)
+	canonical, err := filepath.Abs(expanded)
 	if err != nil {
 		return "", fmt.Errorf("failed to get absolute path: %w", err)
 	}
 
-	// 3. シンボリックリンク解決（失敗時はAbsまで）
-	canonical, err := filepath.EvalSymlinks(abs)
-	if err != nil {
-		// EvalSymlinks が失敗した場合は Abs の結果を使用
-		return abs, nil
+	// 3. シンボリックリンク解決
+	if r.ResolveSymlinks {
+		canonical = evalSymlinks(canonical)
+	}
+
+	// 4. gitリモートのID、5. 小文字化
+	if id, ok := gitRemoteProjectID(canonical, r.GitRemote); ok {
+		canonical = id
+	} else if r.CaseInsensitive {
+		canonical = strings.ToLower(canonical)
 	}
 
+	// 6. エイリアスの適用
+	if target, ok := r.Aliases[canonical]; ok {
+		return target, nil
+	}
 	return canonical, nil
 }
 
+// evalSymlinks はpathのシンボリックリンクを解決する
+// pathが存在しない場合は存在する最も深い親まで解決して残りを連結し、解決できなければpathを返す
+func evalSymlinks(path string) string {
+	rest := ""
+	for p := path; ; {
+		if resolved, err := filepath.EvalSymlinks(p); err == nil {
+			return filepath.Join(resolved, rest)
+		}
+		if _, err := os.Lstat(p); err == nil {
+			return path // 存在するが解決できない（リンク切れなど）
+		}
+		parent := filepath.Dir(p)
+		if parent == p {
+			return path
+		}
+		rest = filepath.Join(filepath.Base(p), rest)
+		p = parent
+	}
+}
+
 // ExpandTilde は"~"をホームディレクトリに展開する
 // "~/" で始まる場合のみ展開し、それ以外はそのまま返す
 func ExpandTilde(path string) (string, error) {
@@ -78,13 +222,67 @@ func GetDefaultConfigPath() (string, error) {
 }
 
 // GetDefaultDataDir はデフォルトのデータディレクトリを返す
-// ~/.local-mcp-memory/data
+// 旧デフォルト（~/.local-mcp-memory/data）が既に存在する場合は互換性のためそれを使い、
+// なければOS標準の場所を使う
+//   - Linux等: $XDG_DATA_HOME/mcp-memory（未設定なら ~/.local/share/mcp-memory）
+//   - macOS: ~/Library/Application Support/mcp-memory
+//   - Windows: %APPDATA%\mcp-memory
 func GetDefaultDataDir() (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", fmt.Errorf("failed to get home directory: %w", err)
 	}
-	return filepath.Join(home, DefaultConfigDir, DefaultDataSubDir), nil
+
+	legacy := filepath.Join(home, DefaultConfigDir, DefaultDataSubDir)
+	if info, err := os.Stat(legacy); err == nil && info.IsDir() {
+		return legacy, nil
+	}
+	return platformDataDir(runtime.GOOS, home, os.Getenv), nil
+}
+
+// platformDataDir はOSごとの標準データディレクトリを返す
+func platformDataDir(goos, home string, getenv func(string) string) string {
+	switch goos {
+	case "windows":
+		if appData := getenv("APPDATA"); appData != "" {
+			return filepath.Join(appData, AppDirName)
+		}
+		return filepath.Join(home, "AppData", "Roaming", AppDirName)
+	case "darwin":
+		return filepath.Join(home, "Library", "Application Support", AppDirName)
+	default:
+		// XDG Base Directory仕様: 相対パスは無効として無視する
+		if xdg := getenv("XDG_DATA_HOME"); xdg != "" && filepath.IsAbs(xdg) {
+			return filepath.Join(xdg, AppDirName)
+		}
+		return filepath.Join(home, ".local", "share", AppDirName)
+	}
+}
+
+// ResolveDataDir は--data-dirなどで指定されたディレクトリを絶対パスにする（"~"も展開）
+func ResolveDataDir(dir string) (string, error) {
+	expanded, err := ExpandTilde(dir)
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(expanded)
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	return abs, nil
+}
+
+// ResolveDataPath はdataDir配下のパスを解決する
+// 絶対パス（"~"展開後）はそのまま、相対パスはdataDirからの相対として扱う
+func ResolveDataPath(dataDir, path string) (string, error) {
+	expanded, err := ExpandTilde(path)
+	if err != nil {
+		return "", err
+	}
+	if filepath.IsAbs(expanded) {
+		return expanded, nil
+	}
+	return filepath.Join(dataDir, expanded), nil
 }
 
 // EnsureDir はディレクトリが存在することを確認し、なければ作成する
@@ -3,7 +3,10 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
 )
 
 // TestCanonicalizeProjectID_TildeExpand はチルダ展開が正しく行われることをテスト
@@ -148,6 +151,101 @@ func TestCanonicalizeProjectID_AlreadyAbsolute(t *testing.T) {
 	}
 }
 
+// TestCanonicalizeProjectIDWith は正規化ルールごとの結果をテスト
+func TestCanonicalizeProjectIDWith(t *testing.T) {
+	tmpDir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to resolve temp dir: %v", err)
+	}
+	realDir := filepath.Join(tmpDir, "Real")
+	if err := os.Mkdir(realDir, 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	linkDir := filepath.Join(tmpDir, "link")
+	if err := os.Symlink(realDir, linkDir); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+	noSymlinks := false
+
+	tests := []struct {
+		name  string
+		input string
+		cfg   *model.ProjectIDConfig
+		want  string
+	}{
+		{"default resolves symlinks", linkDir, nil, realDir},
+		{"trailing slash", realDir + string(filepath.Separator), nil, realDir},
+		{"not yet created", filepath.Join(linkDir, "sub", "dir"), nil, filepath.Join(realDir, "sub", "dir")},
+		{"symlinks disabled", linkDir, &model.ProjectIDConfig{ResolveSymlinks: &noSymlinks}, linkDir},
+		{"case insensitive", linkDir, &model.ProjectIDConfig{CaseInsensitive: true}, strings.ToLower(realDir)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CanonicalizeProjectIDWith(tt.input, RulesFromConfig(tt.cfg))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestCanonicalizeProjectIDWith_Aliases はエイリアスのパスが対応先のprojectIdになることをテスト
+func TestCanonicalizeProjectIDWith_Aliases(t *testing.T) {
+	tmpDir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to resolve temp dir: %v", err)
+	}
+	checkout := filepath.Join(tmpDir, "ci", "repo")
+	rules := DefaultCanonicalizeRules.WithAliases(map[string]string{
+		checkout + string(filepath.Separator): "github.com/org/repo",
+	})
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{checkout, "github.com/org/repo"},
+		{"github.com/org/repo", "github.com/org/repo"}, // 対応先はそのまま
+		{filepath.Join(checkout, "sub"), filepath.Join(checkout, "sub")},
+	}
+	for _, tt := range tests {
+		got, err := CanonicalizeProjectIDWith(tt.input, rules)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.input, tt.want, got)
+		}
+	}
+
+	SetCanonicalizeRules(rules)
+	t.Cleanup(func() { SetCanonicalizeRules(DefaultCanonicalizeRules) })
+	if got, _ := CanonicalizePath(checkout); got != checkout {
+		t.Errorf("CanonicalizePath: expected %q, got %q", checkout, got)
+	}
+}
+
+// TestSetCanonicalizeRules はCanonicalizeProjectIDが設定したルールを使うことをテスト
+func TestSetCanonicalizeRules(t *testing.T) {
+	t.Cleanup(func() { SetCanonicalizeRules(DefaultCanonicalizeRules) })
+
+	SetCanonicalizeRules(CanonicalizeRules{CaseInsensitive: true})
+	got, err := CanonicalizeProjectID("/Test/Project")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	abs, err := filepath.Abs("/Test/Project")
+	if err != nil {
+		t.Fatalf("failed to get absolute path: %v", err)
+	}
+	if want := strings.ToLower(abs); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
 // TestExpandTilde_Valid は有効なチルダパスが展開されることをテスト
 func TestExpandTilde_Valid(t *testing.T) {
 	path := "~/test/path"
@@ -241,30 +339,80 @@ func TestGetDefaultConfigPath(t *testing.T) {
 	}
 }
 
-// TestGetDefaultDataDir はデフォルトデータディレクトリが取得できることをテスト
+// TestGetDefaultDataDir は旧デフォルトがなければOS標準の場所を返すことをテスト
 func TestGetDefaultDataDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("XDG_DATA_HOME", "")
+
 	dir, err := GetDefaultDataDir()
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-
-	if dir == "" {
-		t.Error("expected non-empty data dir")
+	if !filepath.IsAbs(dir) || filepath.Base(dir) != AppDirName {
+		t.Errorf("expected absolute platform data dir, got %q", dir)
 	}
+}
 
-	// ~/.local-mcp-memory/data の形式であることを確認
-	if !filepath.IsAbs(dir) {
-		t.Errorf("expected absolute path, got %q", dir)
+// TestGetDefaultDataDir_Legacy は旧デフォルト（~/.local-mcp-memory/data）が存在すればそれを使うことをテスト
+func TestGetDefaultDataDir_Legacy(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	legacy := filepath.Join(home, ".local-mcp-memory", "data")
+	if err := os.MkdirAll(legacy, 0755); err != nil {
+		t.Fatal(err)
 	}
 
-	home, err := os.UserHomeDir()
+	dir, err := GetDefaultDataDir()
 	if err != nil {
-		t.Fatalf("failed to get home dir: %v", err)
+		t.Fatalf("unexpected error: %v", err)
 	}
+	if dir != legacy {
+		t.Errorf("expected %q, got %q", legacy, dir)
+	}
+}
 
-	expectedDir := filepath.Join(home, ".local-mcp-memory", "data")
+// TestPlatformDataDir はOSごとのデータディレクトリをテスト
+func TestPlatformDataDir(t *testing.T) {
+	home := filepath.Join("/home", "u")
+	env := func(vars map[string]string) func(string) string {
+		return func(key string) string { return vars[key] }
+	}
+
+	tests := []struct {
+		name   string
+		goos   string
+		vars   map[string]string
+		expect string
+	}{
+		{"linux default", "linux", nil, filepath.Join(home, ".local", "share", "mcp-memory")},
+		{"linux xdg", "linux", map[string]string{"XDG_DATA_HOME": "/xdg"}, filepath.Join("/xdg", "mcp-memory")},
+		{"linux relative xdg ignored", "linux", map[string]string{"XDG_DATA_HOME": "rel"}, filepath.Join(home, ".local", "share", "mcp-memory")},
+		{"darwin", "darwin", nil, filepath.Join(home, "Library", "Application Support", "mcp-memory")},
+		{"windows appdata", "windows", map[string]string{"APPDATA": "/appdata"}, filepath.Join("/appdata", "mcp-memory")},
+		{"windows fallback", "windows", nil, filepath.Join(home, "AppData", "Roaming", "mcp-memory")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := platformDataDir(tt.goos, home, env(tt.vars)); got != tt.expect {
+				t.Errorf("expected %q, got %q", tt.expect, got)
+			}
+		})
+	}
+}
 
-	if dir != expectedDir {
-		t.Errorf("expected %q, got %q", expectedDir, dir)
+// TestResolveDataPath は相対パスがdataDir基準で解決されることをテスト
+func TestResolveDataPath(t *testing.T) {
+	dataDir := filepath.Join("/data")
+	abs := filepath.Join("/var", "memory.db")
+
+	if got, _ := ResolveDataPath(dataDir, "memory.db"); got != filepath.Join(dataDir, "memory.db") {
+		t.Errorf("relative path: got %q", got)
+	}
+	if got, _ := ResolveDataPath(dataDir, abs); got != abs {
+		t.Errorf("absolute path: got %q", got)
 	}
 }
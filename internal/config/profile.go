@@ -0,0 +1,53 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+)
+
+// ErrUnknownProfile は設定ファイルにないプロファイルが選択された
+var ErrUnknownProfile = errors.New("unknown profile")
+
+// ApplyProfile はcfg.Profiles[name]の内容でcfgを置き換える（nameが空なら何もしない）
+// embedder・storeは指定されたセクションを丸ごと置き換え、dataDirはpaths.dataDirを置き換える
+func ApplyProfile(cfg *model.Config, name string) error {
+	if name == "" {
+		return nil
+	}
+	profile, ok := cfg.Profiles[name]
+	if !ok {
+		return fmt.Errorf("%w: %s (available: %s)", ErrUnknownProfile, name, profileNames(cfg))
+	}
+
+	if profile.Embedder != nil {
+		cfg.Embedder = *profile.Embedder
+	}
+	if profile.Store != nil {
+		cfg.Store = *profile.Store
+	}
+	if profile.DataDir != "" {
+		dataDir, err := ResolveDataDir(profile.DataDir)
+		if err != nil {
+			return fmt.Errorf("profiles.%s.dataDir: %w", name, err)
+		}
+		cfg.Paths.DataDir = dataDir
+	}
+	return nil
+}
+
+// profileNames はプロファイル名を昇順のカンマ区切りで返す（なければ "none"）
+func profileNames(cfg *model.Config) string {
+	if len(cfg.Profiles) == 0 {
+		return "none"
+	}
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
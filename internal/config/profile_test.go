@@ -0,0 +1,74 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+)
+
+// TestApplyProfile はプロファイルのembedder・store・dataDirで置き換えられることをテスト
+func TestApplyProfile(t *testing.T) {
+	url := "https://api.example.com/v1"
+	dataDir := filepath.Join(t.TempDir(), "offline")
+	cfg := &model.Config{
+		Embedder: model.EmbedderConfig{Provider: "openai", Model: "text-embedding-3-small", Dim: 1536, BaseURL: &url},
+		Store:    model.StoreConfig{Type: "chroma"},
+		Paths:    model.PathsConfig{DataDir: "/data"},
+		Profiles: map[string]model.Profile{
+			"offline": {
+				Embedder: &model.EmbedderConfig{Provider: "ollama", Model: "nomic-embed-text"},
+				Store:    &model.StoreConfig{Type: "sqlite"},
+				DataDir:  dataDir,
+			},
+			"work": {Embedder: &model.EmbedderConfig{Provider: "openai", Model: "text-embedding-3-large"}},
+		},
+	}
+
+	if err := ApplyProfile(cfg, "offline"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// セクション単位で置き換えるため、元のbaseURL・dimは残らない
+	if cfg.Embedder.Provider != "ollama" || cfg.Embedder.Model != "nomic-embed-text" || cfg.Embedder.BaseURL != nil || cfg.Embedder.Dim != 0 {
+		t.Errorf("unexpected embedder: %+v", cfg.Embedder)
+	}
+	if cfg.Store.Type != "sqlite" {
+		t.Errorf("expected store type sqlite, got %q", cfg.Store.Type)
+	}
+	if cfg.Paths.DataDir != dataDir {
+		t.Errorf("expected dataDir %q, got %q", dataDir, cfg.Paths.DataDir)
+	}
+
+	// 指定のないセクションはそのまま
+	cfg.Store.Type = "chroma"
+	cfg.Paths.DataDir = "/data"
+	if err := ApplyProfile(cfg, "work"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Embedder.Model != "text-embedding-3-large" || cfg.Store.Type != "chroma" || cfg.Paths.DataDir != "/data" {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+
+	// 空の名前は何もしない
+	if err := ApplyProfile(cfg, ""); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// TestApplyProfile_Unknown は存在しないプロファイルでエラーになることをテスト
+func TestApplyProfile_Unknown(t *testing.T) {
+	cfg := &model.Config{Profiles: map[string]model.Profile{"work": {}, "personal": {}}}
+	err := ApplyProfile(cfg, "offline")
+	if !errors.Is(err, ErrUnknownProfile) {
+		t.Fatalf("expected ErrUnknownProfile, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "available: personal, work") {
+		t.Errorf("expected available profiles in error, got %v", err)
+	}
+
+	if err := ApplyProfile(&model.Config{}, "work"); !errors.Is(err, ErrUnknownProfile) {
+		t.Errorf("expected ErrUnknownProfile without profiles, got %v", err)
+	}
+}
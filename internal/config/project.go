@@ -0,0 +1,293 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// ProjectConfigFile はプロジェクトローカル設定ファイル名（gitルートに配置）
+	ProjectConfigFile = ".mcp-memory.json"
+	// ProjectRegistryFile は登録済みプロジェクト一覧のファイル名（設定ファイルと同じディレクトリ）
+	ProjectRegistryFile = "projects.json"
+)
+
+// ErrNoGitRoot はgitリポジトリ外で検出を試みた場合のエラー
+var ErrNoGitRoot = errors.New("not inside a git repository")
+
+// ProjectConfig はプロジェクトローカル設定（.mcp-memory.json）
+type ProjectConfig struct {
+	ProjectID    string                 `json:"projectId"`
+	DefaultGroup string                 `json:"defaultGroup,omitempty"`
+	Tags         []string               `json:"tags,omitempty"`
+	RequiredTags []string               `json:"requiredTags,omitempty"` // add_noteで必須のタグ（tagsで自動付与されるものは満たす）
+	Embedder     *ProjectEmbedderConfig `json:"embedder,omitempty"`
+}
+
+// ProjectEmbedderConfig はプロジェクト単位のembedder上書き
+// リポジトリにコミットされ得るためAPIキーは持たない
+type ProjectEmbedderConfig struct {
+	Provider string `json:"provider,omitempty"`
+	Model    string `json:"model,omitempty"`
+}
+
+// ProjectRegistry は登録済みプロジェクト一覧（projects.json）
+type ProjectRegistry struct {
+	Projects []RegisteredProject `json:"projects"`
+	Aliases  []ProjectAlias      `json:"aliases,omitempty"`
+}
+
+// RegisteredProject はプロジェクトルートと正規化済みprojectIdの対応
+type RegisteredProject struct {
+	ProjectID    string `json:"projectId"`
+	Root         string `json:"root"`
+	RegisteredAt string `json:"registeredAt"`
+}
+
+// ProjectAlias は別の場所のチェックアウト（CI・worktreeなど）のパスと論理的なprojectIdの対応
+// （memory.add_project_aliasで登録）
+type ProjectAlias struct {
+	Path      string `json:"path"` // 正規化済みパス
+	ProjectID string `json:"projectId"`
+	AddedAt   string `json:"addedAt"`
+}
+
+// FindGitRoot はdirから親方向に.git（ディレクトリまたはworktreeのファイル）を探し、gitルートを返す
+func FindGitRoot(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(abs, ".git")); err == nil {
+			return abs, nil
+		}
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			return "", ErrNoGitRoot
+		}
+		abs = parent
+	}
+}
+
+// LoadProjectConfig はプロジェクトローカル設定を読み込む
+func LoadProjectConfig(path string) (*ProjectConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg ProjectConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// ProjectOverlays はprojectIdごとのプロジェクトローカル設定（<projectId>/.mcp-memory.json）を解決する
+// 読み込んだ内容は更新時刻・サイズでキャッシュし、ファイルが変更されたら読み直す
+type ProjectOverlays struct {
+	mu      sync.Mutex
+	entries map[string]overlayEntry
+}
+
+type overlayEntry struct {
+	modTime time.Time
+	size    int64
+	cfg     *ProjectConfig
+}
+
+// NewProjectOverlays はProjectOverlaysを作成する
+func NewProjectOverlays() *ProjectOverlays {
+	return &ProjectOverlays{entries: make(map[string]overlayEntry)}
+}
+
+// Lookup はprojectIdを正規化し、プロジェクトルートの.mcp-memory.jsonを返す
+// エイリアスは適用しない（エイリアスのパスのチェックアウトにある.mcp-memory.jsonを使う）
+// ファイルがない場合はnil, nilを返す
+func (o *ProjectOverlays) Lookup(projectID string) (*ProjectConfig, error) {
+	canonical, err := CanonicalizePath(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to canonicalize projectId: %w", err)
+	}
+	path := filepath.Join(canonical, ProjectConfigFile)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		delete(o.entries, canonical)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	if e, ok := o.entries[canonical]; ok && e.modTime.Equal(info.ModTime()) && e.size == info.Size() {
+		return e.cfg, nil
+	}
+
+	cfg, err := LoadProjectConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	o.entries[canonical] = overlayEntry{modTime: info.ModTime(), size: info.Size(), cfg: cfg}
+	return cfg, nil
+}
+
+// MergeTags はtagsの後ろにextraのうち未登場のものを追加する
+func MergeTags(tags, extra []string) []string {
+	if len(extra) == 0 {
+		return tags
+	}
+	seen := make(map[string]bool, len(tags)+len(extra))
+	merged := make([]string, 0, len(tags)+len(extra))
+	for _, list := range [][]string{tags, extra} {
+		for _, tag := range list {
+			if !seen[tag] {
+				seen[tag] = true
+				merged = append(merged, tag)
+			}
+		}
+	}
+	return merged
+}
+
+// SaveProjectConfig はプロジェクトローカル設定を書き込む
+func SaveProjectConfig(path string, cfg *ProjectConfig) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal project config: %w", err)
+	}
+	return writeFileAtomic(path, append(data, '\n'))
+}
+
+// ProjectRegistryPath は設定ファイルパスに対応するprojects.jsonのパスを返す
+func ProjectRegistryPath(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), ProjectRegistryFile)
+}
+
+// LoadProjectRegistry は登録済みプロジェクト一覧を読み込む（ファイルがなければ空）
+func LoadProjectRegistry(path string) (*ProjectRegistry, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &ProjectRegistry{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read project registry: %w", err)
+	}
+	var reg ProjectRegistry
+	if err := json.Unmarshal(data, &reg); err != nil {
+		return nil, fmt.Errorf("failed to parse project registry: %w", err)
+	}
+	return &reg, nil
+}
+
+// RegisterProject はrootとprojectIdの対応をprojects.jsonに登録する
+// 同じrootが登録済みの場合はprojectIdを更新する
+func RegisterProject(path, projectID, root string) error {
+	reg, err := LoadProjectRegistry(path)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	found := false
+	for i := range reg.Projects {
+		if reg.Projects[i].Root == root {
+			reg.Projects[i].ProjectID = projectID
+			reg.Projects[i].RegisteredAt = now
+			found = true
+		}
+	}
+	if !found {
+		reg.Projects = append(reg.Projects, RegisteredProject{
+			ProjectID:    projectID,
+			Root:         root,
+			RegisteredAt: now,
+		})
+	}
+	sort.Slice(reg.Projects, func(i, j int) bool {
+		return reg.Projects[i].Root < reg.Projects[j].Root
+	})
+
+	return saveProjectRegistry(path, reg)
+}
+
+// AddProjectAlias はaliasPath（正規化済み）とprojectIdの対応をprojects.jsonに登録する
+// 同じパスが登録済みの場合はprojectIdを更新する
+func AddProjectAlias(path, aliasPath, projectID string) error {
+	reg, err := LoadProjectRegistry(path)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	found := false
+	for i := range reg.Aliases {
+		if reg.Aliases[i].Path == aliasPath {
+			reg.Aliases[i].ProjectID = projectID
+			reg.Aliases[i].AddedAt = now
+			found = true
+		}
+	}
+	if !found {
+		reg.Aliases = append(reg.Aliases, ProjectAlias{
+			Path:      aliasPath,
+			ProjectID: projectID,
+			AddedAt:   now,
+		})
+	}
+	sort.Slice(reg.Aliases, func(i, j int) bool {
+		return reg.Aliases[i].Path < reg.Aliases[j].Path
+	})
+
+	return saveProjectRegistry(path, reg)
+}
+
+// LoadProjectAliases はprojects.jsonのエイリアスに設定ファイルのprojectAliases（優先）を重ねて返す
+func LoadProjectAliases(registryPath string, configAliases map[string]string) (map[string]string, error) {
+	reg, err := LoadProjectRegistry(registryPath)
+	if err != nil {
+		return nil, err
+	}
+	aliases := make(map[string]string, len(reg.Aliases)+len(configAliases))
+	for _, a := range reg.Aliases {
+		aliases[a.Path] = a.ProjectID
+	}
+	for path, projectID := range configAliases {
+		aliases[path] = projectID
+	}
+	return aliases, nil
+}
+
+// saveProjectRegistry はprojects.jsonを書き込む
+func saveProjectRegistry(path string, reg *ProjectRegistry) error {
+	if err := EnsureDir(filepath.Dir(path)); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(reg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal project registry: %w", err)
+	}
+	return writeFileAtomic(path, data)
+}
+
+// writeFileAtomic は一時ファイル経由でファイルを置き換える
+func writeFileAtomic(path string, data []byte) error {
+	tmpFile := path + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := os.Rename(tmpFile, path); err != nil {
+		os.Remove(tmpFile)
+		return fmt.Errorf("failed to rename file: %w", err)
+	}
+	return nil
+}
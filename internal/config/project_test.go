@@ -0,0 +1,207 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestFindGitRoot はサブディレクトリからgitルートを検出できることをテスト
+func TestFindGitRoot(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := FindGitRoot(sub)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != root {
+		t.Errorf("expected %q, got %q", root, got)
+	}
+}
+
+// TestFindGitRoot_Worktree は.gitがファイル（worktree/submodule）の場合も検出できることをテスト
+func TestFindGitRoot_Worktree(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, ".git"), []byte("gitdir: /elsewhere\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := FindGitRoot(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != root {
+		t.Errorf("expected %q, got %q", root, got)
+	}
+}
+
+// TestFindGitRoot_NotFound はgitリポジトリ外でErrNoGitRootを返すことをテスト
+func TestFindGitRoot_NotFound(t *testing.T) {
+	if _, err := FindGitRoot(t.TempDir()); !errors.Is(err, ErrNoGitRoot) {
+		t.Errorf("expected ErrNoGitRoot, got %v", err)
+	}
+}
+
+// TestProjectConfig_SaveAndLoad はプロジェクトローカル設定の保存と読み込みをテスト
+func TestProjectConfig_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ProjectConfigFile)
+	cfg := &ProjectConfig{
+		ProjectID:    "/work/repo",
+		DefaultGroup: "docs",
+		Tags:         []string{"backend"},
+		Embedder:     &ProjectEmbedderConfig{Provider: "ollama", Model: "nomic-embed-text"},
+	}
+
+	if err := SaveProjectConfig(path, cfg); err != nil {
+		t.Fatalf("SaveProjectConfig failed: %v", err)
+	}
+	loaded, err := LoadProjectConfig(path)
+	if err != nil {
+		t.Fatalf("LoadProjectConfig failed: %v", err)
+	}
+	if loaded.ProjectID != "/work/repo" || loaded.DefaultGroup != "docs" || len(loaded.Tags) != 1 {
+		t.Errorf("unexpected config: %+v", loaded)
+	}
+	if loaded.Embedder == nil || loaded.Embedder.Model != "nomic-embed-text" {
+		t.Errorf("unexpected embedder: %+v", loaded.Embedder)
+	}
+}
+
+// TestRegisterProject は登録と同一rootの更新をテスト
+func TestRegisterProject(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", ProjectRegistryFile)
+
+	if err := RegisterProject(path, "/b", "/b"); err != nil {
+		t.Fatalf("RegisterProject failed: %v", err)
+	}
+	if err := RegisterProject(path, "/real/a", "/a"); err != nil {
+		t.Fatalf("RegisterProject failed: %v", err)
+	}
+	if err := RegisterProject(path, "/real/b", "/b"); err != nil {
+		t.Fatalf("RegisterProject failed: %v", err)
+	}
+
+	reg, err := LoadProjectRegistry(path)
+	if err != nil {
+		t.Fatalf("LoadProjectRegistry failed: %v", err)
+	}
+	if len(reg.Projects) != 2 {
+		t.Fatalf("expected 2 projects, got %+v", reg.Projects)
+	}
+	if reg.Projects[0].Root != "/a" || reg.Projects[1].ProjectID != "/real/b" {
+		t.Errorf("unexpected registry: %+v", reg.Projects)
+	}
+}
+
+func TestAddProjectAlias(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ProjectRegistryFile)
+	if err := RegisterProject(path, "/real/a", "/a"); err != nil {
+		t.Fatalf("RegisterProject failed: %v", err)
+	}
+
+	if err := AddProjectAlias(path, "/ci/repo", "/laptop/repo"); err != nil {
+		t.Fatalf("AddProjectAlias failed: %v", err)
+	}
+	if err := AddProjectAlias(path, "/ci/repo", "github.com/org/repo"); err != nil {
+		t.Fatalf("AddProjectAlias failed: %v", err)
+	}
+
+	reg, err := LoadProjectRegistry(path)
+	if err != nil {
+		t.Fatalf("LoadProjectRegistry failed: %v", err)
+	}
+	if len(reg.Projects) != 1 || len(reg.Aliases) != 1 || reg.Aliases[0].ProjectID != "github.com/org/repo" {
+		t.Errorf("unexpected registry: %+v", reg)
+	}
+
+	// 設定ファイルのprojectAliasesが優先する
+	aliases, err := LoadProjectAliases(path, map[string]string{"/ci/repo": "/laptop/repo", "/worktree": "/laptop/repo"})
+	if err != nil {
+		t.Fatalf("LoadProjectAliases failed: %v", err)
+	}
+	if len(aliases) != 2 || aliases["/ci/repo"] != "/laptop/repo" {
+		t.Errorf("unexpected aliases: %v", aliases)
+	}
+}
+
+// TestProjectRegistryPath は設定ファイルと同じディレクトリを使うことをテスト
+func TestProjectRegistryPath(t *testing.T) {
+	got := ProjectRegistryPath(filepath.Join("/home", "u", ".local-mcp-memory", "config.json"))
+	want := filepath.Join("/home", "u", ".local-mcp-memory", ProjectRegistryFile)
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+// TestProjectOverlays_Lookup はprojectIdのディレクトリの.mcp-memory.jsonを解決し、変更時に読み直すことをテスト
+func TestProjectOverlays_Lookup(t *testing.T) {
+	root := t.TempDir()
+	overlays := NewProjectOverlays()
+
+	cfg, err := overlays.Lookup(root)
+	if err != nil || cfg != nil {
+		t.Fatalf("expected nil config without file, got %+v, %v", cfg, err)
+	}
+
+	path := filepath.Join(root, ProjectConfigFile)
+	if err := SaveProjectConfig(path, &ProjectConfig{ProjectID: root, DefaultGroup: "docs"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = overlays.Lookup(root + string(filepath.Separator) + ".")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg == nil || cfg.DefaultGroup != "docs" {
+		t.Fatalf("expected defaultGroup docs, got %+v", cfg)
+	}
+
+	if err := SaveProjectConfig(path, &ProjectConfig{ProjectID: root, DefaultGroup: "research", Tags: []string{"x"}}); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = overlays.Lookup(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.DefaultGroup != "research" {
+		t.Errorf("expected reloaded defaultGroup research, got %q", cfg.DefaultGroup)
+	}
+
+	if err := os.WriteFile(path, []byte("{invalid"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := overlays.Lookup(root); err == nil {
+		t.Error("expected error for invalid project config")
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err := overlays.Lookup(root); err != nil || cfg != nil {
+		t.Errorf("expected nil config after removal, got %+v, %v", cfg, err)
+	}
+}
+
+// TestMergeTags は重複を除いて後ろに追加することをテスト
+func TestMergeTags(t *testing.T) {
+	got := MergeTags([]string{"a", "b"}, []string{"b", "c", "c"})
+	want := []string{"a", "b", "c"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+	if got := MergeTags(nil, nil); got != nil {
+		t.Errorf("expected nil, got %v", got)
+	}
+}
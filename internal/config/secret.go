@@ -0,0 +1,102 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/brbranch/embedding_mcp/internal/keychain"
+	"github.com/brbranch/embedding_mcp/internal/model"
+)
+
+// secretSchemeKeychain はOSのキーチェーンを表すapiKeyFromの接頭辞
+const secretSchemeKeychain = "keychain:"
+
+// keychainGet はキーチェーンから秘密情報を取得する（テストで差し替える）
+var keychainGet = keychain.Get
+
+// ParseSecretRef はapiKeyFromの値（"keychain:<name>"）を検証し、キーチェーン上の名前を返す
+func ParseSecretRef(ref string) (string, error) {
+	name, ok := strings.CutPrefix(ref, secretSchemeKeychain)
+	if !ok {
+		return "", fmt.Errorf("unsupported secret source %q (must be keychain:<name>)", ref)
+	}
+	if name == "" {
+		return "", fmt.Errorf("missing name in %q (must be keychain:<name>)", ref)
+	}
+	return name, nil
+}
+
+// ResolveSecrets はembedder・store・previousEmbedder・embedders・authのapiKeyFromからAPIキーを取得してapiKeyに設定する
+// encryption.keysのkeyFromも同様にkeyに設定する
+// apiKey（設定ファイルまたは環境変数）が既に設定されている場合はそちらを優先し、キーチェーンは参照しない
+// キーチェーンの確認ダイアログ等を避けるため、Loadでは行わずサービス初期化時に呼ぶ
+func ResolveSecrets(cfg *model.Config) error {
+	if err := resolveSecret(&cfg.Embedder.APIKey, cfg.Embedder.APIKeyFrom); err != nil {
+		return fmt.Errorf("embedder.apiKeyFrom: %w", err)
+	}
+	if err := resolveSecret(&cfg.Store.APIKey, cfg.Store.APIKeyFrom); err != nil {
+		return fmt.Errorf("store.apiKeyFrom: %w", err)
+	}
+	if p := cfg.PreviousEmbedder; p != nil {
+		if err := resolveSecret(&p.APIKey, p.APIKeyFrom); err != nil {
+			return fmt.Errorf("previousEmbedder.apiKeyFrom: %w", err)
+		}
+	}
+	for name, e := range cfg.Embedders {
+		if err := resolveSecret(&e.APIKey, e.APIKeyFrom); err != nil {
+			return fmt.Errorf("embedders.%s.apiKeyFrom: %w", name, err)
+		}
+		cfg.Embedders[name] = e
+	}
+	if enc := cfg.Encryption; enc != nil {
+		for i := range enc.Keys {
+			if err := resolveSecret(&enc.Keys[i].Key, enc.Keys[i].KeyFrom); err != nil {
+				return fmt.Errorf("encryption.keys[%d].keyFrom: %w", i, err)
+			}
+		}
+	}
+	return ResolveAuthSecret(cfg)
+}
+
+// encryptionKeySize はencryption.keysの鍵の長さ（AES-256）
+const encryptionKeySize = 32
+
+// ParseEncryptionKey はencryption.keysのkey（base64）を鍵のバイト列にする
+func ParseEncryptionKey(key string) ([]byte, error) {
+	b, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("key must be base64: %w", err)
+	}
+	if len(b) != encryptionKeySize {
+		return nil, fmt.Errorf("key must be %d bytes (got %d; generate one with `openssl rand -base64 32`)", encryptionKeySize, len(b))
+	}
+	return b, nil
+}
+
+// ResolveAuthSecret はauth.apiKeyFromからAPIキーを取得してauth.apiKeyに設定する（設定の再読み込み用）
+func ResolveAuthSecret(cfg *model.Config) error {
+	if a := cfg.Auth; a != nil {
+		if err := resolveSecret(&a.APIKey, a.APIKeyFrom); err != nil {
+			return fmt.Errorf("auth.apiKeyFrom: %w", err)
+		}
+	}
+	return nil
+}
+
+// resolveSecret はkeyが未設定ならrefから取得して設定する
+func resolveSecret(key **string, ref string) error {
+	if ref == "" || (*key != nil && **key != "") {
+		return nil
+	}
+	name, err := ParseSecretRef(ref)
+	if err != nil {
+		return err
+	}
+	secret, err := keychainGet(name)
+	if err != nil {
+		return err
+	}
+	*key = &secret
+	return nil
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/keychain"
+	"github.com/brbranch/embedding_mcp/internal/model"
+)
+
+// stubKeychain はkeychainGetをsecretsから返す関数に差し替え、参照された名前を記録する
+func stubKeychain(t *testing.T, secrets map[string]string) *[]string {
+	t.Helper()
+	var looked []string
+	orig := keychainGet
+	keychainGet = func(name string) (string, error) {
+		looked = append(looked, name)
+		if s, ok := secrets[name]; ok {
+			return s, nil
+		}
+		return "", keychain.ErrNotFound
+	}
+	t.Cleanup(func() { keychainGet = orig })
+	return &looked
+}
+
+func TestParseSecretRef(t *testing.T) {
+	if name, err := ParseSecretRef("keychain:openai"); err != nil || name != "openai" {
+		t.Errorf("ParseSecretRef() = %q, %v", name, err)
+	}
+	for _, ref := range []string{"keychain:", "env:OPENAI_API_KEY", "openai"} {
+		if _, err := ParseSecretRef(ref); err == nil {
+			t.Errorf("expected error for %q", ref)
+		}
+	}
+}
+
+// TestResolveSecrets はapiKeyFromからembedder・storeのAPIキーが設定されることをテスト
+func TestResolveSecrets(t *testing.T) {
+	looked := stubKeychain(t, map[string]string{"openai": "sk-keychain", "qdrant": "qd-keychain"})
+	cfg := &model.Config{
+		Embedder: model.EmbedderConfig{Provider: "openai", Model: "m", APIKeyFrom: "keychain:openai"},
+		Store:    model.StoreConfig{Type: "qdrant", APIKeyFrom: "keychain:qdrant"},
+	}
+	if err := ResolveSecrets(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Embedder.APIKey == nil || *cfg.Embedder.APIKey != "sk-keychain" {
+		t.Errorf("unexpected embedder apiKey: %v", cfg.Embedder.APIKey)
+	}
+	if cfg.Store.APIKey == nil || *cfg.Store.APIKey != "qd-keychain" {
+		t.Errorf("unexpected store apiKey: %v", cfg.Store.APIKey)
+	}
+	if len(*looked) != 2 {
+		t.Errorf("expected 2 lookups, got %v", *looked)
+	}
+}
+
+// TestResolveSecrets_APIKeyWins は設定済みのapiKey（ファイル・環境変数）があればキーチェーンを参照しないことをテスト
+func TestResolveSecrets_APIKeyWins(t *testing.T) {
+	looked := stubKeychain(t, map[string]string{"openai": "sk-keychain"})
+	key := "sk-env"
+	cfg := &model.Config{Embedder: model.EmbedderConfig{Provider: "openai", Model: "m", APIKey: &key, APIKeyFrom: "keychain:openai"}}
+	if err := ResolveSecrets(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *cfg.Embedder.APIKey != "sk-env" || len(*looked) != 0 {
+		t.Errorf("expected apiKey to be kept without lookup, got %q (lookups %v)", *cfg.Embedder.APIKey, *looked)
+	}
+}
+
+// TestResolveSecrets_EncryptionKeys はencryption.keysのkeyFromから鍵が設定されることをテスト
+func TestResolveSecrets_EncryptionKeys(t *testing.T) {
+	stubKeychain(t, map[string]string{"mcp-memory-2024": "keychain-key"})
+	inline := "inline-key"
+	cfg := &model.Config{Encryption: &model.EncryptionConfig{Keys: []model.EncryptionKeyConfig{
+		{ID: "2024", KeyFrom: "keychain:mcp-memory-2024"},
+		{ID: "2023", Key: &inline},
+	}}}
+	if err := ResolveSecrets(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if keys := cfg.Encryption.Keys; keys[0].Key == nil || *keys[0].Key != "keychain-key" || *keys[1].Key != "inline-key" {
+		t.Errorf("unexpected keys: %+v", keys)
+	}
+
+	cfg.Encryption.Keys = []model.EncryptionKeyConfig{{ID: "2025", KeyFrom: "keychain:missing"}}
+	if err := ResolveSecrets(cfg); err == nil || err.Error() != "encryption.keys[0].keyFrom: secret not found in keychain" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestParseEncryptionKey(t *testing.T) {
+	key, err := ParseEncryptionKey("MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+	if err != nil || string(key) != "0123456789abcdef0123456789abcdef" {
+		t.Errorf("ParseEncryptionKey() = %q, %v", key, err)
+	}
+	for _, s := range []string{"", "not base64!", "MDEyMzQ1Njc4OWFiY2RlZg=="} {
+		if _, err := ParseEncryptionKey(s); err == nil {
+			t.Errorf("expected error for %q", s)
+		}
+	}
+}
+
+// TestResolveSecrets_NotFound はキーチェーンにない場合に設定のパス付きでエラーになることをテスト
+func TestResolveSecrets_NotFound(t *testing.T) {
+	stubKeychain(t, nil)
+	cfg := &model.Config{Store: model.StoreConfig{Type: "qdrant", APIKeyFrom: "keychain:qdrant"}}
+	err := ResolveSecrets(cfg)
+	if !errors.Is(err, keychain.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if got := err.Error(); got != "store.apiKeyFrom: secret not found in keychain" {
+		t.Errorf("unexpected error message: %s", got)
+	}
+}
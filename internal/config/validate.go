@@ -0,0 +1,603 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/logging"
+	"github.com/brbranch/embedding_mcp/internal/model"
+)
+
+// openAIModelDims はOpenAIの既知モデルが返す埋め込みの次元数
+// （dimensionsパラメータは送らないため、dimはこの値と一致する必要がある）
+var openAIModelDims = map[string]int{
+	"text-embedding-3-small": 1536,
+	"text-embedding-3-large": 3072,
+	"text-embedding-ada-002": 1536,
+}
+
+// maxCoalesceWindow はembedder.coalesceWindowの上限
+const maxCoalesceWindow = time.Second
+
+// FieldError は1つの設定値の問題（Pathは embedder.dim のような設定のパス）
+type FieldError struct {
+	Path    string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// ValidationError は設定の検証で見つかった問題の一覧
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.Error()
+	}
+	return "invalid config: " + strings.Join(msgs, "; ")
+}
+
+// validator は問題を集める
+type validator struct {
+	errs []*FieldError
+}
+
+func (v *validator) addf(path, format string, args ...any) {
+	v.errs = append(v.errs, &FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// err は問題があれば*ValidationErrorを返す
+func (v *validator) err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Errors: v.errs}
+}
+
+// Validate は設定値を検証し、問題があればすべてを*ValidationErrorにまとめて返す
+// （provider/model/dimの組み合わせ、URLの形式・ポート番号、列挙値、プロファイルの内容）
+func Validate(cfg *model.Config) error {
+	v := &validator{}
+
+	switch cfg.TransportDefaults.DefaultTransport {
+	case "", model.TransportStdio, model.TransportHTTP, "pipe":
+	default:
+		v.addf("transportDefaults.defaultTransport", "unknown transport %q (must be stdio, http or pipe)", cfg.TransportDefaults.DefaultTransport)
+	}
+	for i, origin := range cfg.TransportDefaults.CORSOrigins {
+		if origin == "*" {
+			continue
+		}
+		if msg := checkURL(origin); msg != "" {
+			v.addf(fmt.Sprintf("transportDefaults.corsOrigins[%d]", i), "%s", msg)
+		} else if u, _ := url.Parse(origin); strings.TrimSuffix(u.Path, "/") != "" || u.RawQuery != "" {
+			v.addf(fmt.Sprintf("transportDefaults.corsOrigins[%d]", i), "origin must be scheme://host[:port] without a path, got %q", origin)
+		}
+	}
+
+	validateEmbedder(v, "embedder", &cfg.Embedder)
+	validateStore(v, "store", &cfg.Store)
+	if cfg.PreviousEmbedder != nil {
+		validateEmbedder(v, "previousEmbedder", cfg.PreviousEmbedder)
+	}
+	embedders := make([]string, 0, len(cfg.Embedders))
+	for name := range cfg.Embedders {
+		embedders = append(embedders, name)
+	}
+	sort.Strings(embedders)
+	for _, name := range embedders {
+		if name == "" {
+			v.addf("embedders", "name must not be empty")
+			continue
+		}
+		e := cfg.Embedders[name]
+		validateEmbedder(v, "embedders."+name, &e)
+	}
+
+	aliases := make([]string, 0, len(cfg.ProjectAliases))
+	for path := range cfg.ProjectAliases {
+		aliases = append(aliases, path)
+	}
+	sort.Strings(aliases)
+	for _, path := range aliases {
+		if path == "" {
+			v.addf("projectAliases", "path must not be empty")
+		} else if cfg.ProjectAliases[path] == "" {
+			v.addf("projectAliases."+path, "projectId must not be empty")
+		}
+	}
+
+	if cfg.Tags != nil {
+		from := make([]string, 0, len(cfg.Tags.Aliases))
+		for tag := range cfg.Tags.Aliases {
+			from = append(from, tag)
+		}
+		sort.Strings(from)
+		for _, tag := range from {
+			if tag == "" {
+				v.addf("tags.aliases", "alias must not be empty")
+			} else if cfg.Tags.Aliases[tag] == "" {
+				v.addf("tags.aliases."+tag, "tag must not be empty")
+			}
+		}
+	}
+
+	if id := cfg.NoteID; id != nil {
+		switch id.Scheme {
+		case "", model.NoteIDSchemeUUID, model.NoteIDSchemeUUIDv7, model.NoteIDSchemeULID:
+		default:
+			v.addf("noteId.scheme", "unknown scheme %q (must be uuid, uuidv7 or ulid)", id.Scheme)
+		}
+		switch id.Prefix {
+		case "", model.NoteIDPrefixProject, model.NoteIDPrefixGroup, model.NoteIDPrefixProjectGroup:
+		default:
+			v.addf("noteId.prefix", "unknown prefix %q (must be project, group or project-group)", id.Prefix)
+		}
+	}
+
+	for i := range cfg.Webhooks {
+		validateWebhook(v, fmt.Sprintf("webhooks[%d]", i), &cfg.Webhooks[i])
+	}
+
+	if a := cfg.Archive; a != nil {
+		if a.AfterDays <= 0 {
+			v.addf("archive.afterDays", "must be greater than 0, got %d", a.AfterDays)
+		}
+		if a.CheckInterval != "" {
+			if _, err := ParseDuration(a.CheckInterval); err != nil {
+				v.addf("archive.checkInterval", "%v", err)
+			}
+		}
+	}
+
+	if cfg.Auth != nil {
+		validateAuth(v, cfg.Auth)
+	}
+	if cfg.Encryption != nil {
+		validateEncryption(v, cfg.Encryption)
+	}
+
+	if _, err := logging.ParseLevel(cfg.Logging.Level); err != nil {
+		v.addf("logging.level", "unknown level %q (must be debug, info, warn or error)", cfg.Logging.Level)
+	}
+	if err := logging.ValidateFormat(cfg.Logging.Format); err != nil {
+		v.addf("logging.format", "unknown format %q (must be text or json)", cfg.Logging.Format)
+	}
+	if cfg.Logging.SlowThreshold != "" {
+		if _, err := ParseDuration(cfg.Logging.SlowThreshold); err != nil {
+			v.addf("logging.slowThreshold", "%v", err)
+		}
+	}
+
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := cfg.Profiles[name]
+		if p.Embedder != nil {
+			validateEmbedder(v, "profiles."+name+".embedder", p.Embedder)
+		}
+		if p.Store != nil {
+			validateStore(v, "profiles."+name+".store", p.Store)
+		}
+	}
+
+	return v.err()
+}
+
+// validateWebhook はwebhooksの1件を検証する
+func validateWebhook(v *validator, path string, w *model.WebhookConfig) {
+	if w.URL == "" {
+		v.addf(path+".url", "url is required")
+	} else if msg := checkURL(w.URL); msg != "" {
+		v.addf(path+".url", "%s", msg)
+	}
+	switch w.Format {
+	case "", model.WebhookFormatJSON, model.WebhookFormatSlack:
+	default:
+		v.addf(path+".format", "unknown format %q (must be json or slack)", w.Format)
+	}
+	for i, ev := range w.Events {
+		if !slices.Contains(model.WebhookEvents, ev) {
+			v.addf(fmt.Sprintf("%s.events[%d]", path, i), "unknown event %q (must be one of %s)", ev, strings.Join(model.WebhookEvents, ", "))
+		}
+	}
+	if w.Timeout != "" {
+		if d, err := ParseDuration(w.Timeout); err != nil {
+			v.addf(path+".timeout", "%v", err)
+		} else if d <= 0 {
+			v.addf(path+".timeout", "timeout must be positive")
+		}
+	}
+}
+
+// validateAuth はauthセクションを検証する（トークンは互いに・apiKeyと重複できない）
+func validateAuth(v *validator, a *model.AuthConfig) {
+	if a.APIKeyFrom != "" {
+		if _, err := ParseSecretRef(a.APIKeyFrom); err != nil {
+			v.addf("auth.apiKeyFrom", "%v", err)
+		}
+	}
+	seen := make(map[string]bool)
+	if a.APIKey != nil && *a.APIKey != "" {
+		seen[*a.APIKey] = true
+	}
+	for i, t := range a.Tokens {
+		path := fmt.Sprintf("auth.tokens[%d]", i)
+		if t.Token == "" {
+			v.addf(path+".token", "token is required")
+		} else if seen[t.Token] {
+			v.addf(path+".token", "token must be unique (same as another token or auth.apiKey)")
+		}
+		seen[t.Token] = true
+		if len(t.ProjectIDs) == 0 {
+			v.addf(path+".projectIds", "at least one projectId is required")
+		}
+		for j, id := range t.ProjectIDs {
+			if id == "" {
+				v.addf(fmt.Sprintf("%s.projectIds[%d]", path, j), "projectId must not be empty")
+			}
+		}
+		switch t.Access {
+		case "", model.AccessRead, model.AccessReadWrite:
+		default:
+			v.addf(path+".access", "unknown access %q (must be read or read-write)", t.Access)
+		}
+	}
+}
+
+// encryptionKeyIDPattern は暗号化の鍵のID（暗号文の接頭辞に記録するため区切りの":"は使えない）
+var encryptionKeyIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+// validateEncryption はencryptionセクションを検証する（keyFromの鍵は取得しないため、長さはサービス初期化時に確認する）
+func validateEncryption(v *validator, e *model.EncryptionConfig) {
+	if len(e.Keys) == 0 {
+		v.addf("encryption.keys", "at least one key is required")
+	}
+	seen := make(map[string]bool)
+	for i, k := range e.Keys {
+		path := fmt.Sprintf("encryption.keys[%d]", i)
+		switch {
+		case k.ID == "":
+			v.addf(path+".id", "id is required")
+		case !encryptionKeyIDPattern.MatchString(k.ID):
+			v.addf(path+".id", "id must match ^[a-zA-Z0-9_.-]+$, got %q", k.ID)
+		case seen[k.ID]:
+			v.addf(path+".id", "id must be unique, got %q", k.ID)
+		}
+		seen[k.ID] = true
+		if k.KeyFrom != "" {
+			if _, err := ParseSecretRef(k.KeyFrom); err != nil {
+				v.addf(path+".keyFrom", "%v", err)
+			}
+		}
+		if k.Key != nil && *k.Key != "" {
+			if _, err := ParseEncryptionKey(*k.Key); err != nil {
+				v.addf(path+".key", "%v", err)
+			}
+		} else if k.KeyFrom == "" {
+			v.addf(path+".key", "key or keyFrom is required")
+		}
+	}
+}
+
+// validateEmbedder はembedderセクションを検証する
+func validateEmbedder(v *validator, path string, e *model.EmbedderConfig) {
+	switch e.Provider {
+	case model.ProviderOpenAI, model.ProviderOllama:
+		if e.Model == "" {
+			v.addf(path+".model", "required for provider %s", e.Provider)
+		}
+	case model.ProviderLocal:
+	case "":
+		v.addf(path+".provider", "required (openai, ollama or local)")
+	default:
+		v.addf(path+".provider", "unknown provider %q (must be openai, ollama or local)", e.Provider)
+	}
+
+	if e.Dim < 0 {
+		v.addf(path+".dim", "must not be negative, got %d (use 0 to detect it from the first embedding)", e.Dim)
+	} else if want, ok := openAIModelDims[e.Model]; ok && e.Provider == model.ProviderOpenAI && e.Dim != 0 && e.Dim != want {
+		v.addf(path+".dim", "%s returns %d-dimensional embeddings, got %d (use %d, or 0 to detect it)", e.Model, want, e.Dim, want)
+	}
+
+	if e.BaseURL != nil && *e.BaseURL != "" {
+		if msg := checkURL(*e.BaseURL); msg != "" {
+			v.addf(path+".baseUrl", "%s", msg)
+		}
+	}
+	if e.APIKeyFrom != "" {
+		if _, err := ParseSecretRef(e.APIKeyFrom); err != nil {
+			v.addf(path+".apiKeyFrom", "%v", err)
+		}
+	}
+	if e.CoalesceWindow != "" {
+		if d, err := ParseDuration(e.CoalesceWindow); err != nil {
+			v.addf(path+".coalesceWindow", "%v", err)
+		} else if d > maxCoalesceWindow {
+			// 窓の長さがそのまま単発のadd_noteの遅延になる
+			v.addf(path+".coalesceWindow", "must be at most %s, got %q", maxCoalesceWindow, e.CoalesceWindow)
+		}
+	}
+}
+
+// validateStore はstoreセクションを検証する
+func validateStore(v *validator, path string, s *model.StoreConfig) {
+	switch s.Type {
+	case model.StoreTypeSQLite, model.StoreTypeQdrant, model.StoreTypeChroma, "memory", "":
+		// 空・memoryはMemoryStore
+	case model.StoreTypeFAISS:
+		v.addf(path+".type", "faiss is not supported yet (use sqlite or qdrant)")
+	default:
+		v.addf(path+".type", "unknown store type %q (must be sqlite, qdrant, chroma or memory)", s.Type)
+	}
+	switch s.GlobalsScope {
+	case model.GlobalsScopeNamespace, "":
+	case model.GlobalsScopeShared:
+		if s.Type != model.StoreTypeSQLite && s.Type != model.StoreTypeQdrant {
+			v.addf(path+".globalsScope", "shared globals require a sqlite or qdrant store")
+		}
+	default:
+		v.addf(path+".globalsScope", "unknown globals scope %q (must be namespace or shared)", s.GlobalsScope)
+	}
+	if s.Journal && s.Type != "memory" && s.Type != "" {
+		v.addf(path+".journal", "the journal is only for the memory store (other stores persist writes themselves)")
+	}
+
+	if s.URL != nil && *s.URL != "" {
+		if msg := checkURL(*s.URL); msg != "" {
+			v.addf(path+".url", "%s", msg)
+		}
+	}
+	if s.APIKeyFrom != "" {
+		if _, err := ParseSecretRef(s.APIKeyFrom); err != nil {
+			v.addf(path+".apiKeyFrom", "%v", err)
+		}
+	}
+	if s.Connection != nil {
+		validateConnection(v, path+".connection", s.Connection)
+	}
+	if s.NoteThresholds != nil {
+		validateNoteThresholds(v, path+".noteThresholds", s.NoteThresholds)
+	}
+	if s.CacheSize != nil && *s.CacheSize < 0 {
+		v.addf(path+".cacheSize", "must not be negative, got %d", *s.CacheSize)
+	}
+}
+
+// validateNoteThresholds はstore.noteThresholdsセクションを検証する
+func validateNoteThresholds(v *validator, path string, t *model.NoteThresholdsConfig) {
+	if t.Soft < 0 {
+		v.addf(path+".soft", "must not be negative")
+	}
+	if t.Hard < 0 {
+		v.addf(path+".hard", "must not be negative")
+	} else if t.Hard > 0 && t.Hard <= t.Soft {
+		v.addf(path+".hard", "must be greater than soft (%d)", t.Soft)
+	}
+	if t.CheckInterval != "" {
+		if _, err := ParseDuration(t.CheckInterval); err != nil {
+			v.addf(path+".checkInterval", "%v", err)
+		}
+	}
+}
+
+// validateConnection はstore.connectionセクションを検証する
+func validateConnection(v *validator, path string, c *model.StoreConnectionConfig) {
+	durations := []struct {
+		key   string
+		value string
+	}{
+		{"connectTimeout", c.ConnectTimeout},
+		{"requestTimeout", c.RequestTimeout},
+		{"keepAlive", c.KeepAlive},
+		{"keepAliveTimeout", c.KeepAliveTimeout},
+		{"busyTimeout", c.BusyTimeout},
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		if _, err := ParseDuration(d.value); err != nil {
+			v.addf(path+"."+d.key, "%v", err)
+		}
+	}
+	if c.PoolSize < 0 {
+		v.addf(path+".poolSize", "must not be negative, got %d", c.PoolSize)
+	}
+	if c.MaxRetries < 0 {
+		v.addf(path+".maxRetries", "must not be negative, got %d", c.MaxRetries)
+	}
+}
+
+// ParseDuration は設定の時間（"5s"・"500ms"のようなGoのduration形式、0以上）を解析する
+func ParseDuration(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q (use a value like 5s or 500ms)", s)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("must not be negative, got %q", s)
+	}
+	return d, nil
+}
+
+// checkURL はhttp(s)の絶対URLか（ポート番号は1-65535）を検証し、問題があれば理由を返す
+func checkURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return fmt.Sprintf("malformed URL %q", s)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Sprintf("URL must start with http:// or https://, got %q", s)
+	}
+	if u.Hostname() == "" {
+		return fmt.Sprintf("URL has no host: %q", s)
+	}
+	if p := u.Port(); p != "" {
+		if n, err := strconv.Atoi(p); err != nil || n < 1 || n > 65535 {
+			return fmt.Sprintf("invalid port %s in %q (must be 1-65535)", p, s)
+		}
+	} else if strings.HasSuffix(u.Host, ":") {
+		return fmt.Sprintf("empty port in %q", s)
+	}
+	return ""
+}
+
+// checkUnknownKeys は設定ファイルのJSONにmodel.Configにないキーがあれば*ValidationErrorを返す
+// （encoding/jsonと同様にキーの大文字小文字は区別しない）
+func checkUnknownKeys(data []byte) error {
+	var doc any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return fmt.Errorf("failed to parse config file: %w", err)
+	}
+	v := &validator{}
+	unknownKeys(v, doc, reflect.TypeOf(model.Config{}), "")
+	return v.err()
+}
+
+// unknownKeys はdocをtの構造と照合し、未知のキーを集める
+func unknownKeys(v *validator, doc any, t reflect.Type, path string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := doc.(map[string]any)
+		if !ok {
+			return // 型の不一致はjson.Unmarshalが報告する
+		}
+		fields := jsonFields(t)
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := joinPath(path, k)
+			f, ok := lookupField(fields, k)
+			if !ok {
+				msg := "unknown key"
+				if s := suggestKey(fields, k); s != "" {
+					msg += fmt.Sprintf(" (did you mean %q?)", s)
+				}
+				v.addf(child, "%s", msg)
+				continue
+			}
+			unknownKeys(v, obj[k], f.Type, child)
+		}
+	case reflect.Map:
+		obj, ok := doc.(map[string]any)
+		if !ok {
+			return
+		}
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			unknownKeys(v, obj[k], t.Elem(), joinPath(path, k))
+		}
+	case reflect.Slice:
+		arr, ok := doc.([]any)
+		if !ok {
+			return
+		}
+		for i, e := range arr {
+			unknownKeys(v, e, t.Elem(), path+"["+strconv.Itoa(i)+"]")
+		}
+	}
+}
+
+// jsonFields はstructのJSONキー名とフィールドの対応を返す
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f
+	}
+	return fields
+}
+
+// lookupField はキーに対応するフィールドを返す（完全一致を優先し、次に大文字小文字を無視して探す）
+func lookupField(fields map[string]reflect.StructField, key string) (reflect.StructField, bool) {
+	if f, ok := fields[key]; ok {
+		return f, true
+	}
+	for name, f := range fields {
+		if strings.EqualFold(name, key) {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// suggestKey はkeyに近い既知のキーを返す（編集距離2以下、なければ空）
+func suggestKey(fields map[string]reflect.StructField, key string) string {
+	best, bestDist := "", 3
+	for name := range fields {
+		d := editDistance(strings.ToLower(name), strings.ToLower(key))
+		if d < bestDist || (d == bestDist && name < best) {
+			best, bestDist = name, d
+		}
+	}
+	return best
+}
+
+// editDistance はaとbのレーベンシュタイン距離を返す
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// joinPath は設定のパスにキーを連結する
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
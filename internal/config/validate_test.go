@@ -0,0 +1,231 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+)
+
+// validationPaths はerrが*ValidationErrorであることを確認し、問題のある設定のパス一覧を返す
+func validationPaths(t *testing.T, err error) []string {
+	t.Helper()
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected *ValidationError, got %v", err)
+	}
+	paths := make([]string, len(verr.Errors))
+	for i, fe := range verr.Errors {
+		paths[i] = fe.Path
+	}
+	return paths
+}
+
+// TestValidate_OK はデフォルト設定・各providerの設定が通ることをテスト
+func TestValidate_OK(t *testing.T) {
+	url := "http://localhost:6333"
+	encKey := "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	tests := []*model.Config{
+		DefaultConfig("/cfg.json", "/data"),
+		{
+			TransportDefaults: model.TransportDefaults{DefaultTransport: "http", CORSOrigins: []string{"*", "http://localhost:3000"}},
+			Embedder:          model.EmbedderConfig{Provider: "openai", Model: "text-embedding-3-large", Dim: 3072, CoalesceWindow: "10ms"},
+			Store: model.StoreConfig{Type: "qdrant", URL: &url, Connection: &model.StoreConnectionConfig{
+				ConnectTimeout: "10s", RequestTimeout: "30s", KeepAlive: "0", KeepAliveTimeout: "500ms", PoolSize: 1, MaxRetries: 3,
+			}},
+			Logging: model.LoggingConfig{Level: "debug"},
+		},
+		{Embedder: model.EmbedderConfig{Provider: "openai", Model: "my-model", Dim: 3}, Store: model.StoreConfig{Type: "memory", Journal: true}},
+		{Embedder: model.EmbedderConfig{Provider: "local"}},
+		{
+			Embedder:  model.EmbedderConfig{Provider: "ollama", Model: "nomic-embed-text"},
+			Embedders: map[string]model.EmbedderConfig{"code": {Provider: "openai", Model: "text-embedding-3-small", Dim: 1536}},
+		},
+		{Embedder: model.EmbedderConfig{Provider: "local"}, Archive: &model.ArchiveConfig{AfterDays: 90, CheckInterval: "0"}},
+		{Embedder: model.EmbedderConfig{Provider: "local"}, Auth: &model.AuthConfig{APIKeyFrom: "keychain:mcp-admin", Tokens: []model.AccessTokenConfig{
+			{Name: "team-a", Token: "a-secret", ProjectIDs: []string{"github.com/org/a"}, Access: model.AccessReadWrite},
+			{Token: "b-secret", ProjectIDs: []string{"/srv/b", "/srv/c"}},
+		}}},
+		{Embedder: model.EmbedderConfig{Provider: "local"}, Encryption: &model.EncryptionConfig{Keys: []model.EncryptionKeyConfig{
+			{ID: "2024-06", KeyFrom: "keychain:mcp-memory-2024-06"},
+			{ID: "v1.old", Key: &encKey},
+		}}},
+	}
+	for i, cfg := range tests {
+		if err := Validate(cfg); err != nil {
+			t.Errorf("config %d: unexpected error: %v", i, err)
+		}
+	}
+}
+
+// TestValidate_Errors は不正な値がすべて設定のパス付きで報告されることをテスト
+func TestValidate_Errors(t *testing.T) {
+	badURL := "localhost:6333"
+	badPort := "http://localhost:70000"
+	ftp := "ftp://example.com"
+	negative := -1
+	cfg := &model.Config{
+		TransportDefaults: model.TransportDefaults{DefaultTransport: "grpc", CORSOrigins: []string{"http://localhost:3000/app"}},
+		Embedder:          model.EmbedderConfig{Provider: "openai", Model: "text-embedding-3-small", Dim: 768, BaseURL: &ftp, APIKeyFrom: "env:OPENAI_API_KEY", CoalesceWindow: "2s"},
+		Store: model.StoreConfig{Type: "qdrant", URL: &badPort, GlobalsScope: "global", Connection: &model.StoreConnectionConfig{
+			ConnectTimeout: "5", BusyTimeout: "-1s", PoolSize: -1, MaxRetries: -1,
+		}, NoteThresholds: &model.NoteThresholdsConfig{Soft: 1000, Hard: 500, CheckInterval: "hourly"}, CacheSize: &negative},
+		ProjectAliases: map[string]string{"/ci/repo": ""},
+		NoteID:         &model.NoteIDConfig{Scheme: "snowflake", Prefix: "user"},
+		Tags:           &model.TagsConfig{Aliases: map[string]string{"golang": ""}},
+		Embedders:      map[string]model.EmbedderConfig{"code": {Provider: "ollama"}, "": {Provider: "local"}},
+		Logging:        model.LoggingConfig{Level: "trace", Format: "xml", SlowThreshold: "fast"},
+		Profiles: map[string]model.Profile{
+			"work": {
+				Embedder: &model.EmbedderConfig{Provider: "cohere", Dim: -1},
+				Store:    &model.StoreConfig{Type: "faiss", URL: &badURL, GlobalsScope: model.GlobalsScopeShared, Journal: true},
+			},
+		},
+		Webhooks: []model.WebhookConfig{
+			{URL: "https://hooks.slack.com/services/T000/B000/XXX", Format: model.WebhookFormatSlack, Events: []string{model.WebhookEventNoteAdded}},
+			{URL: "hooks.example.com", Format: "xml", Events: []string{"note.created"}, Timeout: "0s"},
+		},
+		Archive: &model.ArchiveConfig{CheckInterval: "daily"},
+		Auth: &model.AuthConfig{APIKey: &ftp, APIKeyFrom: "env:ADMIN_KEY", Tokens: []model.AccessTokenConfig{
+			{Token: "ftp://example.com", ProjectIDs: []string{"/srv/a"}, Access: "admin"},
+			{ProjectIDs: []string{""}},
+		}},
+		Encryption: &model.EncryptionConfig{Keys: []model.EncryptionKeyConfig{
+			{ID: "2024:06", Key: &ftp},
+			{ID: "2024:06", KeyFrom: "env:KEY"},
+			{},
+		}},
+	}
+
+	err := Validate(cfg)
+	want := []string{
+		"transportDefaults.defaultTransport",
+		"transportDefaults.corsOrigins[0]",
+		"embedder.dim",
+		"embedder.baseUrl",
+		"embedder.apiKeyFrom",
+		"embedder.coalesceWindow",
+		"store.globalsScope",
+		"store.url",
+		"store.connection.connectTimeout",
+		"store.connection.busyTimeout",
+		"store.connection.poolSize",
+		"store.connection.maxRetries",
+		"store.noteThresholds.hard",
+		"store.noteThresholds.checkInterval",
+		"store.cacheSize",
+		"embedders",
+		"embedders.code.model",
+		"projectAliases./ci/repo",
+		"tags.aliases.golang",
+		"noteId.scheme",
+		"noteId.prefix",
+		"webhooks[1].url",
+		"webhooks[1].format",
+		"webhooks[1].events[0]",
+		"webhooks[1].timeout",
+		"archive.afterDays",
+		"archive.checkInterval",
+		"auth.apiKeyFrom",
+		"auth.tokens[0].token",
+		"auth.tokens[0].access",
+		"auth.tokens[1].token",
+		"auth.tokens[1].projectIds[0]",
+		"encryption.keys[0].id",
+		"encryption.keys[0].key",
+		"encryption.keys[1].id",
+		"encryption.keys[1].keyFrom",
+		"encryption.keys[2].id",
+		"encryption.keys[2].key",
+		"logging.level",
+		"logging.format",
+		"logging.slowThreshold",
+		"profiles.work.embedder.provider",
+		"profiles.work.embedder.dim",
+		"profiles.work.store.type",
+		"profiles.work.store.globalsScope",
+		"profiles.work.store.journal",
+		"profiles.work.store.url",
+	}
+	if got := validationPaths(t, err); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("paths = %v, want %v", got, want)
+	}
+	for _, msg := range []string{"invalid config: ", "returns 1536-dimensional embeddings", "must be 1-65535"} {
+		if !strings.Contains(err.Error(), msg) {
+			t.Errorf("expected error to contain %q, got %v", msg, err)
+		}
+	}
+
+	// provider・modelの必須チェック
+	err = Validate(&model.Config{Embedder: model.EmbedderConfig{Provider: "ollama"}})
+	if got := validationPaths(t, err); len(got) != 1 || got[0] != "embedder.model" {
+		t.Errorf("paths = %v, want [embedder.model]", got)
+	}
+	err = Validate(&model.Config{})
+	if got := validationPaths(t, err); len(got) != 1 || got[0] != "embedder.provider" {
+		t.Errorf("paths = %v, want [embedder.provider]", got)
+	}
+}
+
+// TestCheckUnknownKeys は未知のキーがパスと候補付きで報告されることをテスト
+func TestCheckUnknownKeys(t *testing.T) {
+	data := []byte(`{
+		"embedder": {"provider": "openai", "modle": "x", "apiKey": "k"},
+		"Store": {"type": "sqlite"},
+		"transportDefaults": {"corsOrigins": ["*"]},
+		"profiles": {"work": {"embedder": {"provider": "openai"}, "datadir": "/w", "stor": {}}},
+		"verbose": true
+	}`)
+
+	err := checkUnknownKeys(data)
+	want := []string{"embedder.modle", "profiles.work.stor", "verbose"}
+	if got := validationPaths(t, err); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("paths = %v, want %v", got, want)
+	}
+	for _, msg := range []string{`embedder.modle: unknown key (did you mean "model"?)`, `profiles.work.stor: unknown key (did you mean "store"?)`} {
+		if !strings.Contains(err.Error(), msg) {
+			t.Errorf("expected error to contain %q, got %v", msg, err)
+		}
+	}
+
+	if !strings.HasSuffix(err.Error(), "verbose: unknown key") {
+		t.Errorf("expected no suggestion for verbose, got %v", err)
+	}
+
+	if err := checkUnknownKeys([]byte(`{"embedder": {"provider": "openai", "model": "m"}, "paths": {"dataDir": "/d"}}`)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// TestManager_Load_Validation はLoadが不正な設定をファイルパス付きのエラーにすることをテスト
+func TestManager_Load_Validation(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"embedder": {"provider": "openai", "model": "m", "baseUrl": "api.example.com"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mgr, err := NewManager(configPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = mgr.Load()
+	if got := validationPaths(t, err); len(got) != 1 || got[0] != "embedder.baseUrl" {
+		t.Errorf("paths = %v, want [embedder.baseUrl]", got)
+	}
+	if !strings.Contains(err.Error(), configPath) {
+		t.Errorf("expected error to mention %s, got %v", configPath, err)
+	}
+
+	// 環境変数で上書きした値も検証する
+	if err := os.WriteFile(configPath, []byte(`{"embedder": {"provider": "openai", "model": "m"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("MCP_MEMORY_STORE_TYPE", "mongo")
+	err = mgr.Load()
+	if got := validationPaths(t, err); len(got) != 1 || got[0] != "store.type" {
+		t.Errorf("paths = %v, want [store.type]", got)
+	}
+}
@@ -0,0 +1,196 @@
+// Package daemon provides the single-instance lock, pidfile and stop control for mcp-memory serve.
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// PIDFileName はdataDir直下のpidfile名（ロックファイルを兼ねる）
+const PIDFileName = "mcp-memory.pid"
+
+// dbLockSuffix はSQLite DBごとのロックファイルの拡張子
+const dbLockSuffix = ".lock"
+
+var (
+	// ErrAlreadyRunning は同じdataDirまたはSQLite DBを使うサーバーが既に起動している
+	ErrAlreadyRunning = errors.New("another mcp-memory server is already running")
+	// ErrNotRunning はサーバーが起動していない
+	ErrNotRunning = errors.New("mcp-memory server is not running")
+	// errLocked はロックが他プロセスに保持されている（プラットフォーム実装が返す）
+	errLocked = errors.New("file is locked")
+)
+
+// Info はpidfileに書き込むサーバー情報
+type Info struct {
+	PID        int      `json:"pid"`
+	StartedAt  string   `json:"startedAt"`
+	Version    string   `json:"version,omitempty"`
+	Transports []string `json:"transports,omitempty"`
+	HTTPAddr   string   `json:"httpAddr,omitempty"`
+	PipeName   string   `json:"pipeName,omitempty"`
+	ConfigPath string   `json:"configPath,omitempty"`
+	StoreType  string   `json:"storeType,omitempty"`
+	StorePath  string   `json:"storePath,omitempty"`
+}
+
+// Instance は取得済みのロックを保持する（Releaseで解放）
+type Instance struct {
+	files []*os.File
+}
+
+// PIDPath はdataDirのpidfileパスを返す
+func PIDPath(dataDir string) string {
+	return filepath.Join(dataDir, PIDFileName)
+}
+
+// DBLockPath はSQLite DBのロックファイルパスを返す
+func DBLockPath(dbPath string) string {
+	return dbPath + dbLockSuffix
+}
+
+// Acquire はdataDirのpidfileと（dbPathが空でなければ）SQLite DBのロックを取得し、infoを書き込む
+// 既に他のサーバーが保持している場合は ErrAlreadyRunning をラップしたエラーを返す
+func Acquire(dataDir, dbPath string, info Info) (*Instance, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	paths := []string{PIDPath(dataDir)}
+	if dbPath != "" {
+		if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create db directory: %w", err)
+		}
+		paths = append(paths, DBLockPath(dbPath))
+	}
+
+	if info.PID == 0 {
+		info.PID = os.Getpid()
+	}
+	if info.StartedAt == "" {
+		info.StartedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal pid info: %w", err)
+	}
+
+	inst := &Instance{}
+	for _, path := range paths {
+		f, err := lockAndWrite(path, data)
+		if err != nil {
+			inst.Release()
+			if errors.Is(err, errLocked) {
+				return nil, alreadyRunning(path)
+			}
+			return nil, err
+		}
+		inst.files = append(inst.files, f)
+	}
+	return inst, nil
+}
+
+// Release はpidfileの内容を消去してロックを解放する
+// ファイル自体は削除しない（削除とロック取得が競合すると二重起動を許すため）
+func (i *Instance) Release() {
+	for _, f := range i.files {
+		f.Truncate(0)
+		unlock(f)
+		f.Close()
+	}
+	i.files = nil
+}
+
+// Status はdataDirで起動中のサーバー情報を返す
+// 起動していない（pidfileがない、またはロックが保持されていない）場合は ErrNotRunning
+func Status(dataDir string) (*Info, error) {
+	return statusOf(PIDPath(dataDir))
+}
+
+// Stop はdataDirで起動中のサーバーに停止を要求し、timeout以内の終了を待つ
+// Unix系ではSIGTERM（グレースフル停止）、WindowsではTerminateProcessを使用
+func Stop(dataDir string, timeout time.Duration) (*Info, error) {
+	info, err := Status(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := terminate(info.PID); err != nil {
+		return info, fmt.Errorf("failed to stop pid %d: %w", info.PID, err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		if _, err := Status(dataDir); errors.Is(err, ErrNotRunning) {
+			return info, nil
+		}
+		if time.Now().After(deadline) {
+			return info, fmt.Errorf("pid %d did not stop within %s", info.PID, timeout)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// lockAndWrite はpathをロックし、内容をdataで置き換える
+func lockAndWrite(path string, data []byte) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	if err := tryLock(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Truncate(0); err == nil {
+		_, err = f.WriteAt(data, 0)
+	}
+	if err != nil {
+		unlock(f)
+		f.Close()
+		return nil, fmt.Errorf("failed to write pid file: %w", err)
+	}
+	f.Sync()
+	return f, nil
+}
+
+// statusOf はロックファイルが保持されていれば書き込まれた情報を返す
+func statusOf(path string) (*Info, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if os.IsNotExist(err) {
+		return nil, ErrNotRunning
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pid file: %w", err)
+	}
+	defer f.Close()
+
+	// ロックを取得できた = 保持しているプロセスがいない（古いpidfile）
+	if err := tryLock(f); err == nil {
+		unlock(f)
+		return nil, ErrNotRunning
+	} else if !errors.Is(err, errLocked) {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pid file: %w", err)
+	}
+	var info Info
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse pid file %s: %w", path, err)
+	}
+	return &info, nil
+}
+
+// alreadyRunning は保持者の情報を含む ErrAlreadyRunning を返す
+func alreadyRunning(path string) error {
+	info, err := statusOf(path)
+	if err != nil || info.PID == 0 {
+		return fmt.Errorf("%w (lock: %s)", ErrAlreadyRunning, path)
+	}
+	return fmt.Errorf("%w (pid %d, started %s, lock: %s)", ErrAlreadyRunning, info.PID, info.StartedAt, path)
+}
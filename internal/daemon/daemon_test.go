@@ -0,0 +1,99 @@
+package daemon
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestAcquire_StatusAndRelease はロック取得中のみStatusが情報を返すことをテスト
+func TestAcquire_StatusAndRelease(t *testing.T) {
+	dataDir := t.TempDir()
+
+	if _, err := Status(dataDir); !errors.Is(err, ErrNotRunning) {
+		t.Fatalf("expected ErrNotRunning before acquire, got %v", err)
+	}
+
+	inst, err := Acquire(dataDir, "", Info{Transports: []string{"stdio"}, Version: "test"})
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+
+	info, err := Status(dataDir)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if info.PID != os.Getpid() {
+		t.Errorf("expected pid %d, got %d", os.Getpid(), info.PID)
+	}
+	if info.StartedAt == "" || info.Version != "test" || len(info.Transports) != 1 {
+		t.Errorf("unexpected info: %+v", info)
+	}
+
+	inst.Release()
+	if _, err := Status(dataDir); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("expected ErrNotRunning after release, got %v", err)
+	}
+}
+
+// TestAcquire_AlreadyRunning は同じdataDirでの二重起動を検出することをテスト
+func TestAcquire_AlreadyRunning(t *testing.T) {
+	dataDir := t.TempDir()
+
+	inst, err := Acquire(dataDir, "", Info{})
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	defer inst.Release()
+
+	_, err = Acquire(dataDir, "", Info{})
+	if !errors.Is(err, ErrAlreadyRunning) {
+		t.Fatalf("expected ErrAlreadyRunning, got %v", err)
+	}
+}
+
+// TestAcquire_SameDBDifferentDataDir は別dataDirでも同じSQLite DBなら二重起動を検出することをテスト
+func TestAcquire_SameDBDifferentDataDir(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "shared", "memory.db")
+
+	inst, err := Acquire(t.TempDir(), dbPath, Info{})
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+
+	otherDataDir := t.TempDir()
+	if _, err := Acquire(otherDataDir, dbPath, Info{}); !errors.Is(err, ErrAlreadyRunning) {
+		t.Fatalf("expected ErrAlreadyRunning, got %v", err)
+	}
+	// 失敗時は取得済みのdataDirロックも解放される
+	if _, err := Status(otherDataDir); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("expected partial lock to be released, got %v", err)
+	}
+
+	inst.Release()
+	inst, err = Acquire(otherDataDir, dbPath, Info{})
+	if err != nil {
+		t.Fatalf("Acquire after release failed: %v", err)
+	}
+	inst.Release()
+}
+
+// TestStatus_StalePIDFile はロックされていない古いpidfileを起動中と扱わないことをテスト
+func TestStatus_StalePIDFile(t *testing.T) {
+	dataDir := t.TempDir()
+	if err := os.WriteFile(PIDPath(dataDir), []byte(`{"pid": 999999}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Status(dataDir); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("expected ErrNotRunning for stale pid file, got %v", err)
+	}
+}
+
+// TestStop_NotRunning は起動していない場合にErrNotRunningを返すことをテスト
+func TestStop_NotRunning(t *testing.T) {
+	if _, err := Stop(t.TempDir(), 0); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("expected ErrNotRunning, got %v", err)
+	}
+}
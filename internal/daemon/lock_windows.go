@@ -0,0 +1,39 @@
+//go:build windows
+
+package daemon
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockOffset はロック対象のバイト位置
+// LockFileExの範囲は他プロセスから読めなくなるため、内容（先頭）と重ならない位置をロックする
+const lockOffset = 0x7fffffff
+
+// tryLock はLockFileExで排他ロックを取得する（ブロックしない）
+func tryLock(f *os.File) error {
+	ol := &windows.Overlapped{Offset: lockOffset}
+	err := windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, ol)
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return errLocked
+	}
+	return err
+}
+
+// unlock はLockFileExのロックを解放する
+func unlock(f *os.File) error {
+	ol := &windows.Overlapped{Offset: lockOffset}
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, ol)
+}
+
+// terminate はプロセスを終了する（WindowsにはSIGTERMがないためTerminateProcess）
+func terminate(pid int) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return p.Kill()
+}
@@ -0,0 +1,98 @@
+// Package debug provides pprof endpoints and on-demand profile dumps for mcp-memory.
+package debug
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"path/filepath"
+	"runtime"
+	rpprof "runtime/pprof"
+	"time"
+)
+
+// DefaultAddr はpprofエンドポイントのデフォルトlistenアドレス
+const DefaultAddr = "127.0.0.1:6060"
+
+// Server はpprofエンドポイントを公開するHTTPサーバー
+// JSON-RPC用のHTTP transportとは別のアドレスで起動する（stdio時も利用可能）
+type Server struct {
+	srv *http.Server
+}
+
+// NewServer は新しいServerを生成（addrが空ならDefaultAddr）
+func NewServer(addr string) *Server {
+	if addr == "" {
+		addr = DefaultAddr
+	}
+
+	// http.DefaultServeMuxは使わず専用のmuxに登録
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	return &Server{
+		srv: &http.Server{
+			Addr:              addr,
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+	}
+}
+
+// Run はサーバーを起動し、contextがキャンセルされるまで実行
+func (s *Server) Run(ctx context.Context) error {
+	go func() {
+		<-ctx.Done()
+		s.srv.Shutdown(context.Background())
+	}()
+
+	err := s.srv.ListenAndServe()
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+// WriteDump はgoroutineダンプとheapプロファイルをdirに書き出し、作成したファイルパスを返す
+func WriteDump(dir string, now time.Time) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create dump dir: %w", err)
+	}
+
+	stamp := now.Format("20060102-150405")
+	goroutinePath := filepath.Join(dir, fmt.Sprintf("mcp-memory-goroutine-%s.txt", stamp))
+	heapPath := filepath.Join(dir, fmt.Sprintf("mcp-memory-heap-%s.pprof", stamp))
+
+	// goroutine: スタックトレース全体をテキストで出力
+	if err := writeProfile(goroutinePath, "goroutine", 2); err != nil {
+		return nil, err
+	}
+
+	// heap: 最新の状態を反映させるためGC後に取得
+	runtime.GC()
+	if err := writeProfile(heapPath, "heap", 0); err != nil {
+		return nil, err
+	}
+
+	return []string{goroutinePath, heapPath}, nil
+}
+
+// writeProfile は指定プロファイルをファイルに書き出す
+func writeProfile(path, name string, debug int) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if err := rpprof.Lookup(name).WriteTo(f, debug); err != nil {
+		return fmt.Errorf("failed to write %s profile: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package debug
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestNewServer_DefaultAddr はAddr未設定時のデフォルト値をテスト
+func TestNewServer_DefaultAddr(t *testing.T) {
+	s := NewServer("")
+	if s.srv.Addr != DefaultAddr {
+		t.Errorf("expected default addr %s, got %s", DefaultAddr, s.srv.Addr)
+	}
+}
+
+// TestServer_PprofIndex はpprofエンドポイントが応答することをテスト
+func TestServer_PprofIndex(t *testing.T) {
+	s := NewServer("127.0.0.1:0")
+
+	req := httptest.NewRequest("GET", "/debug/pprof/", nil)
+	w := httptest.NewRecorder()
+	s.srv.Handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+}
+
+// TestWriteDump はgoroutine/heapダンプがファイルに書き出されることをテスト
+func TestWriteDump(t *testing.T) {
+	dir := t.TempDir()
+
+	paths, err := WriteDump(dir, time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("expected 2 files, got %d", len(paths))
+	}
+
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Errorf("expected file %s to exist: %v", p, err)
+			continue
+		}
+		if info.Size() == 0 {
+			t.Errorf("expected file %s to be non-empty", p)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+//go:build !windows
+
+package debug
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// WatchDumpSignal はSIGUSR1を受けるたびにWriteDumpを実行する（contextキャンセルまで）
+func WatchDumpSignal(ctx context.Context, dir string) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGUSR1)
+
+	go func() {
+		defer signal.Stop(sigCh)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-sigCh:
+				// stdoutはstdio transportが使用するためログはslog（stderrまたはログファイル）へ
+				paths, err := WriteDump(dir, time.Now())
+				if err != nil {
+					slog.Error("debug: dump failed", "error", err)
+					continue
+				}
+				slog.Info("debug: wrote dump", "paths", paths)
+			}
+		}
+	}()
+}
@@ -0,0 +1,8 @@
+//go:build windows
+
+package debug
+
+import "context"
+
+// WatchDumpSignal はWindowsではSIGUSR1がないため何もしない（pprofエンドポイントを使用）
+func WatchDumpSignal(ctx context.Context, dir string) {}
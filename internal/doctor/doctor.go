@@ -0,0 +1,472 @@
+// Package doctor provides environment diagnostics for mcp-memory.
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/bootstrap"
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/embedder"
+	"github.com/brbranch/embedding_mcp/internal/keychain"
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/service"
+	"github.com/brbranch/embedding_mcp/internal/store"
+)
+
+// Status はチェック結果の状態
+type Status string
+
+// Status定数
+const (
+	StatusOK   Status = "ok"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+	StatusSkip Status = "skip" // 前提のチェックが失敗したため未実施
+)
+
+// DefaultTimeout は接続確認・埋め込み確認1回あたりのタイムアウト
+const DefaultTimeout = 10 * time.Second
+
+// probeText は埋め込み確認に使用するテキスト
+const probeText = "mcp-memory doctor"
+
+// Result は1項目のチェック結果
+type Result struct {
+	Name    string `json:"name"`
+	Status  Status `json:"status"`
+	Message string `json:"message"`
+	Fix     string `json:"fix,omitempty"` // 対処方法（ok以外の場合）
+}
+
+// Report は全チェック結果
+type Report struct {
+	ConfigPath string   `json:"configPath"`
+	Namespace  string   `json:"namespace,omitempty"`
+	Results    []Result `json:"results"`
+	// Issues はインデックスの検証（WithDeep）で見つかった問題
+	Issues []Issue `json:"issues,omitempty"`
+}
+
+// Issue はインデックスの検証で見つかった1件の問題
+type Issue struct {
+	Kind      string `json:"kind"`
+	ProjectID string `json:"projectId,omitempty"`
+	ID        string `json:"id,omitempty"`
+	Message   string `json:"message"`
+	Repaired  bool   `json:"repaired"`
+	Action    string `json:"action,omitempty"`
+}
+
+// Failed はfailの件数を返す
+func (r *Report) Failed() int {
+	n := 0
+	for _, res := range r.Results {
+		if res.Status == StatusFail {
+			n++
+		}
+	}
+	return n
+}
+
+// Doctor は設定・ストア・Embedderの診断を行う
+type Doctor struct {
+	configPath  string
+	timeout     time.Duration
+	newStore    func(cfg *model.Config) (store.Store, error)
+	newEmbedder func(cfg *model.Config) (embedder.Embedder, error)
+	httpClient  *http.Client
+	deep        bool // ノート・埋め込み・グループの整合性も検証する
+	repair      bool // 検証で見つかった問題を修復する
+}
+
+// Option はDoctorのオプション
+type Option func(*Doctor)
+
+// WithTimeout は接続確認・埋め込み確認のタイムアウトを設定
+func WithTimeout(d time.Duration) Option {
+	return func(doc *Doctor) {
+		doc.timeout = d
+	}
+}
+
+// WithDeep はストアの全ノート・埋め込み・グループの整合性も検証する（repairなら見つかった問題を修復する）
+func WithDeep(repair bool) Option {
+	return func(doc *Doctor) {
+		doc.deep = true
+		doc.repair = repair
+	}
+}
+
+// WithStoreFactory はStoreの生成関数を差し替える（テスト用）
+func WithStoreFactory(f func(cfg *model.Config) (store.Store, error)) Option {
+	return func(doc *Doctor) {
+		doc.newStore = f
+	}
+}
+
+// WithEmbedderFactory はEmbedderの生成関数を差し替える（テスト用）
+func WithEmbedderFactory(f func(cfg *model.Config) (embedder.Embedder, error)) Option {
+	return func(doc *Doctor) {
+		doc.newEmbedder = f
+	}
+}
+
+// New はDoctorを生成する（configPathが空ならデフォルトパス）
+func New(configPath string, opts ...Option) *Doctor {
+	d := &Doctor{
+		configPath:  configPath,
+		timeout:     DefaultTimeout,
+		newStore:    bootstrap.NewStore,
+		newEmbedder: defaultEmbedder,
+		httpClient:  &http.Client{},
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// defaultEmbedder は設定からEmbedderを生成する
+// 診断で設定ファイルを書き換えないようDimUpdaterは渡さない
+func defaultEmbedder(cfg *model.Config) (embedder.Embedder, error) {
+	return embedder.NewEmbedder(&cfg.Embedder, os.Getenv(config.EnvOpenAIAPIKey), nil)
+}
+
+// Run は全項目をチェックしてReportを返す
+// config → store → embedder → dimension（WithDeepなら → index）の順に実行し、前提が失敗した項目はskipとする
+func (d *Doctor) Run(ctx context.Context) *Report {
+	report := &Report{}
+
+	manager, err := config.NewManager(d.configPath)
+	if err != nil {
+		report.add(Result{Name: "config", Status: StatusFail, Message: err.Error(),
+			Fix: "check the HOME environment variable or pass the config path with -c"})
+		report.skip(d.after("store", "embedder", "dimension")...)
+		return report
+	}
+	report.ConfigPath = manager.GetConfigPath()
+
+	cfg, res := d.checkConfig(manager)
+	report.add(res...)
+	if cfg == nil {
+		report.skip(d.after("store", "embedder", "dimension")...)
+		return report
+	}
+	report.Namespace = config.GenerateNamespace(cfg.Embedder.Provider, cfg.Embedder.Model, cfg.Embedder.Dim)
+
+	storeRes := d.checkStore(ctx, cfg, report.Namespace)
+	report.add(storeRes)
+
+	vec, res2 := d.checkEmbedder(ctx, cfg)
+	report.add(res2)
+	if vec == nil {
+		report.skip(d.after("dimension")...)
+		return report
+	}
+	report.add(checkDimension(cfg, len(vec)))
+
+	if d.deep {
+		// Chromaは未実装のため、ストアが使えない場合と同じく検証しない
+		if storeRes.Status == StatusFail || cfg.Store.Type == model.StoreTypeChroma {
+			report.skip("index")
+		} else {
+			report.add(d.checkIndex(ctx, cfg, report))
+		}
+	}
+
+	return report
+}
+
+// after はnamesにWithDeepのindexを加えて返す（skip用）
+func (d *Doctor) after(names ...string) []string {
+	if d.deep {
+		names = append(names, "index")
+	}
+	return names
+}
+
+// add は結果を追加する
+func (r *Report) add(results ...Result) {
+	r.Results = append(r.Results, results...)
+}
+
+// skip は未実施の項目を追加する
+func (r *Report) skip(names ...string) {
+	for _, name := range names {
+		r.Results = append(r.Results, Result{Name: name, Status: StatusSkip, Message: "skipped (previous check failed)"})
+	}
+}
+
+// checkConfig は設定ファイルの読み込みと値の妥当性（config.Validate）をチェックする
+// 読み込みに失敗した場合はnilの設定を返す
+func (d *Doctor) checkConfig(manager *config.Manager) (*model.Config, []Result) {
+	path := manager.GetConfigPath()
+
+	var results []Result
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		results = append(results, Result{Name: "config", Status: StatusWarn,
+			Message: fmt.Sprintf("config file not found at %s (using defaults)", path),
+			Fix:     fmt.Sprintf("create %s with embedder/store settings (see the config example in README)", path)})
+	}
+
+	if err := manager.Load(); err != nil {
+		fix := fmt.Sprintf("fix the JSON syntax in %s", path)
+		var verr *config.ValidationError
+		if errors.As(err, &verr) {
+			fix = fmt.Sprintf("fix the settings named above in %s (or the MCP_MEMORY_* environment variables that override them)", path)
+		}
+		results = append(results, Result{Name: "config", Status: StatusFail, Message: err.Error(), Fix: fix})
+		return nil, results
+	}
+	cfg := manager.GetConfig()
+	if err := config.ResolveSecrets(cfg); err != nil {
+		results = append(results, Result{Name: "config", Status: StatusFail, Message: err.Error(),
+			Fix: fmt.Sprintf("store the key in the OS keychain under service %q (see README) or set apiKey / the environment variable instead", keychain.Service)})
+		return nil, results
+	}
+
+	if len(results) == 0 {
+		results = append(results, Result{Name: "config", Status: StatusOK,
+			Message: fmt.Sprintf("loaded %s (embedder %s/%s, store %s)", path, cfg.Embedder.Provider, cfg.Embedder.Model, cfg.Store.Type)})
+	}
+	return cfg, results
+}
+
+// checkStore はストアへの接続・書き込み可否をチェックする
+func (d *Doctor) checkStore(ctx context.Context, cfg *model.Config, namespace string) Result {
+	switch cfg.Store.Type {
+	case model.StoreTypeSQLite:
+		dbPath := bootstrap.SQLitePath(cfg)
+		if err := checkWritableDir(filepath.Dir(dbPath)); err != nil {
+			return Result{Name: "store", Status: StatusFail, Message: fmt.Sprintf("sqlite: %v", err),
+				Fix: fmt.Sprintf("check write permission on %s or change store.path", filepath.Dir(dbPath))}
+		}
+	case model.StoreTypeChroma:
+		url := store.DefaultChromaURL
+		if cfg.Store.URL != nil && *cfg.Store.URL != "" {
+			url = *cfg.Store.URL
+		}
+		if err := d.pingChroma(ctx, url); err != nil {
+			return Result{Name: "store", Status: StatusFail, Message: fmt.Sprintf("chroma: %v", err),
+				Fix: fmt.Sprintf("start the Chroma server or check store.url (%s)", url)}
+		}
+		return Result{Name: "store", Status: StatusWarn, Message: fmt.Sprintf("chroma: %s is reachable, but ChromaStore is not yet implemented", url),
+			Fix: "change store.type to sqlite or qdrant"}
+	}
+
+	st, err := d.newStore(cfg)
+	if err != nil {
+		return storeFailure(cfg, err)
+	}
+	defer st.Close()
+
+	initCtx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+	if err := st.Initialize(initCtx, namespace); err != nil {
+		return storeFailure(cfg, err)
+	}
+
+	switch cfg.Store.Type {
+	case model.StoreTypeSQLite:
+		return Result{Name: "store", Status: StatusOK, Message: fmt.Sprintf("sqlite: %s is writable", bootstrap.SQLitePath(cfg))}
+	case model.StoreTypeQdrant:
+		return Result{Name: "store", Status: StatusOK, Message: "qdrant: connected and collections are ready"}
+	default:
+		return Result{Name: "store", Status: StatusWarn, Message: "memory store: data is not persisted across restarts",
+			Fix: "set store.type to sqlite to persist notes"}
+	}
+}
+
+// storeFailure はストア生成・初期化失敗時の結果を返す
+func storeFailure(cfg *model.Config, err error) Result {
+	res := Result{Name: "store", Status: StatusFail, Message: fmt.Sprintf("%s: %v", cfg.Store.Type, err)}
+	switch {
+	case cfg.Store.Type == model.StoreTypeQdrant:
+		res.Fix = "start Qdrant (docker compose up -d) and check that store.url and the gRPC port (6334) are reachable"
+	case cfg.Store.Type == model.StoreTypeSQLite && strings.Contains(err.Error(), "locked"):
+		res.Fix = "make sure no other mcp-memory process is using the same database"
+	default:
+		res.Fix = "check the store settings (type/path/url)"
+	}
+	return res
+}
+
+// checkWritableDir はディレクトリを作成し、一時ファイルを書き込めるか確認する
+func checkWritableDir(dir string) error {
+	if err := config.EnsureDir(dir); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		return fmt.Errorf("directory %s is not writable: %w", dir, err)
+	}
+	name := f.Name()
+	f.Close()
+	return os.Remove(name)
+}
+
+// pingChroma はChromaのheartbeatエンドポイントに接続できるか確認する（v2 → v1の順）
+func (d *Doctor) pingChroma(ctx context.Context, baseURL string) error {
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	var lastErr error
+	for _, path := range []string{"/api/v2/heartbeat", "/api/v1/heartbeat"} {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(baseURL, "/")+path, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := d.httpClient.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			return nil
+		}
+		lastErr = fmt.Errorf("heartbeat returned status %d", resp.StatusCode)
+	}
+	return lastErr
+}
+
+// checkEmbedder はEmbedderの生成と実際の埋め込み生成をチェックする
+// 成功した場合は生成されたベクトルを返す
+func (d *Doctor) checkEmbedder(ctx context.Context, cfg *model.Config) ([]float32, Result) {
+	provider := cfg.Embedder.Provider
+
+	emb, err := d.newEmbedder(cfg)
+	if err != nil {
+		res := Result{Name: "embedder", Status: StatusFail, Message: fmt.Sprintf("%s: %v", provider, err)}
+		if errors.Is(err, embedder.ErrAPIKeyRequired) {
+			res.Fix = "set the OPENAI_API_KEY environment variable or embedder.apiKey"
+		} else {
+			res.Fix = "check the embedder settings (provider/model/baseUrl)"
+		}
+		return nil, res
+	}
+
+	embedCtx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	vec, err := emb.Embed(embedCtx, probeText)
+	if err != nil {
+		return nil, embedderFailure(cfg, err)
+	}
+	if len(vec) == 0 {
+		return nil, Result{Name: "embedder", Status: StatusFail, Message: fmt.Sprintf("%s: empty embedding returned", provider),
+			Fix: "make sure embedder.model is an embedding model"}
+	}
+
+	return vec, Result{Name: "embedder", Status: StatusOK,
+		Message: fmt.Sprintf("%s/%s returned a %d-dim embedding", provider, cfg.Embedder.Model, len(vec))}
+}
+
+// embedderFailure は埋め込み生成失敗時の結果を返す
+func embedderFailure(cfg *model.Config, err error) Result {
+	res := Result{Name: "embedder", Status: StatusFail, Message: fmt.Sprintf("%s: %v", cfg.Embedder.Provider, err)}
+
+	var apiErr *embedder.APIError
+	switch {
+	case errors.Is(err, embedder.ErrNotImplemented):
+		res.Fix = "this provider is not implemented yet; change embedder.provider to openai"
+	case errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden):
+		res.Message = fmt.Sprintf("%s: API key was rejected (status %d)", cfg.Embedder.Provider, apiErr.StatusCode)
+		res.Fix = "check that OPENAI_API_KEY / embedder.apiKey is a valid key"
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound:
+		res.Fix = fmt.Sprintf("check that model %q exists (for ollama: ollama pull %s)", cfg.Embedder.Model, cfg.Embedder.Model)
+	case errors.Is(err, context.DeadlineExceeded):
+		res.Fix = "the embedder timed out; check the network and embedder.baseUrl"
+	case cfg.Embedder.Provider == model.ProviderOllama:
+		res.Fix = "make sure ollama serve is running and check embedder.baseUrl"
+	default:
+		res.Fix = "check the network connection and embedder.baseUrl"
+	}
+	return res
+}
+
+// checkDimension は実際の埋め込み次元と設定（namespace）の次元が一致するかチェックする
+func checkDimension(cfg *model.Config, actual int) Result {
+	namespace := config.GenerateNamespace(cfg.Embedder.Provider, cfg.Embedder.Model, cfg.Embedder.Dim)
+
+	switch {
+	case cfg.Embedder.Dim == 0:
+		return Result{Name: "dimension", Status: StatusWarn,
+			Message: fmt.Sprintf("embedder.dim is not set (namespace %s); it will be recorded as %d on first use, which moves data to a new namespace", namespace, actual),
+			Fix:     fmt.Sprintf("set embedder.dim to %d", actual)}
+	case cfg.Embedder.Dim != actual:
+		return Result{Name: "dimension", Status: StatusFail,
+			Message: fmt.Sprintf("embedder returned %d dims but embedder.dim is %d (namespace %s)", actual, cfg.Embedder.Dim, namespace),
+			Fix:     fmt.Sprintf("set embedder.dim to %d (this changes the namespace; migrate existing notes with export/import)", actual)}
+	default:
+		return Result{Name: "dimension", Status: StatusOK, Message: fmt.Sprintf("%d dims match namespace %s", actual, namespace)}
+	}
+}
+
+// checkIndex はストアの全ノート・埋め込み・グループの整合性を検証する（repairなら修復する）
+// 見つかった問題はreport.Issuesに追加する
+func (d *Doctor) checkIndex(ctx context.Context, cfg *model.Config, report *Report) Result {
+	st, err := d.newStore(cfg)
+	if err != nil {
+		return indexFailure(err)
+	}
+	defer st.Close()
+	if err := st.Initialize(ctx, report.Namespace); err != nil {
+		return indexFailure(err)
+	}
+	emb, err := d.newEmbedder(cfg)
+	if err != nil {
+		return indexFailure(err)
+	}
+
+	quarantineDir := filepath.Join(cfg.Paths.DataDir, "quarantine")
+	svc := service.NewRepairService(emb, st, store.NewColdStore(quarantineDir, report.Namespace), report.Namespace)
+	resp, err := svc.Validate(ctx, &service.ValidateRequest{Repair: d.repair})
+	if resp == nil {
+		return indexFailure(err)
+	}
+
+	remaining := 0
+	for _, issue := range resp.Issues {
+		report.Issues = append(report.Issues, Issue{Kind: issue.Kind, ProjectID: issue.ProjectID, ID: issue.ID,
+			Message: issue.Message, Repaired: issue.Repaired, Action: issue.Action})
+		if !issue.Repaired {
+			remaining++
+		}
+	}
+	if err != nil {
+		res := indexFailure(err)
+		res.Message = fmt.Sprintf("repair stopped after %d issue(s): %v", len(resp.Issues)-remaining, err)
+		return res
+	}
+
+	summary := fmt.Sprintf("checked %d notes", resp.Scanned)
+	switch {
+	case len(resp.Issues) == 0:
+		return Result{Name: "index", Status: StatusOK, Message: summary + ", no problems found"}
+	case remaining == 0:
+		return Result{Name: "index", Status: StatusOK,
+			Message: fmt.Sprintf("%s, repaired %d issue(s) (broken notes are kept in %s)", summary, len(resp.Issues), quarantineDir)}
+	case d.repair:
+		return Result{Name: "index", Status: StatusFail,
+			Message: fmt.Sprintf("%s, %d issue(s) could not be repaired", summary, remaining),
+			Fix:     "fix the issues listed above by hand (namespace_mismatch: check embedder.dim)"}
+	default:
+		return Result{Name: "index", Status: StatusFail,
+			Message: fmt.Sprintf("%s, found %d issue(s)", summary, remaining),
+			Fix:     "run mcp-memory doctor --deep --repair (broken notes are moved to " + quarantineDir + ")"}
+	}
+}
+
+// indexFailure はインデックスの検証ができなかった場合の結果を返す
+func indexFailure(err error) Result {
+	return Result{Name: "index", Status: StatusFail, Message: err.Error(),
+		Fix: "check the store and embedder results above"}
+}
@@ -0,0 +1,292 @@
+package doctor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/embedder"
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/store"
+)
+
+// fakeEmbedder はテスト用のEmbedder
+type fakeEmbedder struct {
+	vec []float32
+	err error
+}
+
+func (e *fakeEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	return e.vec, e.err
+}
+
+func (e *fakeEmbedder) GetDimension() int {
+	return len(e.vec)
+}
+
+// writeConfig はテスト用の設定ファイルを書き込む
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return path
+}
+
+// withEmbedder はfakeEmbedderを返すOption
+func withEmbedder(e embedder.Embedder) Option {
+	return WithEmbedderFactory(func(cfg *model.Config) (embedder.Embedder, error) {
+		return e, nil
+	})
+}
+
+// resultOf は指定名の最後の結果を返す
+func resultOf(t *testing.T, report *Report, name string) Result {
+	t.Helper()
+	for i := len(report.Results) - 1; i >= 0; i-- {
+		if report.Results[i].Name == name {
+			return report.Results[i]
+		}
+	}
+	t.Fatalf("result %q not found in %+v", name, report.Results)
+	return Result{}
+}
+
+func TestDoctor_AllOK(t *testing.T) {
+	dir := t.TempDir()
+	path := writeConfig(t, `{
+		"embedder": {"provider": "openai", "model": "m", "dim": 3},
+		"store": {"type": "sqlite", "path": "`+filepath.ToSlash(filepath.Join(dir, "memory.db"))+`"}
+	}`)
+
+	report := New(path, withEmbedder(&fakeEmbedder{vec: []float32{0.1, 0.2, 0.3}})).Run(context.Background())
+
+	if report.Failed() != 0 {
+		t.Fatalf("expected no failures, got %+v", report.Results)
+	}
+	for _, name := range []string{"config", "store", "embedder", "dimension"} {
+		if res := resultOf(t, report, name); res.Status != StatusOK {
+			t.Errorf("%s: expected ok, got %s (%s)", name, res.Status, res.Message)
+		}
+	}
+	if report.Namespace != "openai:m:3" {
+		t.Errorf("unexpected namespace %q", report.Namespace)
+	}
+}
+
+func TestDoctor_InvalidJSON(t *testing.T) {
+	path := writeConfig(t, `{not json`)
+
+	report := New(path).Run(context.Background())
+
+	res := resultOf(t, report, "config")
+	if res.Status != StatusFail || res.Fix == "" {
+		t.Errorf("expected config failure with fix, got %+v", res)
+	}
+	if res := resultOf(t, report, "embedder"); res.Status != StatusSkip {
+		t.Errorf("expected embedder to be skipped, got %s", res.Status)
+	}
+}
+
+func TestDoctor_InvalidValues(t *testing.T) {
+	path := writeConfig(t, `{
+		"embedder": {"provider": "cohere", "model": ""},
+		"store": {"type": "faiss"}
+	}`)
+
+	report := New(path).Run(context.Background())
+
+	res := resultOf(t, report, "config")
+	if res.Status != StatusFail {
+		t.Fatalf("expected config failure, got %+v", res)
+	}
+	if report.Failed() != 1 {
+		t.Errorf("expected exactly 1 failure, got %d", report.Failed())
+	}
+}
+
+func TestDoctor_StoreFailure(t *testing.T) {
+	path := writeConfig(t, `{
+		"embedder": {"provider": "openai", "model": "m", "dim": 3},
+		"store": {"type": "qdrant", "url": "http://127.0.0.1:1"}
+	}`)
+
+	report := New(path,
+		withEmbedder(&fakeEmbedder{vec: []float32{1, 2, 3}}),
+		WithStoreFactory(func(cfg *model.Config) (store.Store, error) {
+			return nil, store.ErrConnectionFailed
+		}),
+	).Run(context.Background())
+
+	res := resultOf(t, report, "store")
+	if res.Status != StatusFail || res.Fix == "" {
+		t.Errorf("expected store failure with fix, got %+v", res)
+	}
+	// ストアが失敗してもembedderのチェックは行う
+	if res := resultOf(t, report, "embedder"); res.Status != StatusOK {
+		t.Errorf("expected embedder ok, got %+v", res)
+	}
+}
+
+func TestDoctor_EmbedderFailures(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{name: "invalid key", err: &embedder.APIError{StatusCode: http.StatusUnauthorized, Message: "invalid"}},
+		{name: "not implemented", err: embedder.ErrNotImplemented},
+		{name: "network", err: errors.New("connection refused")},
+	}
+
+	path := writeConfig(t, `{
+		"embedder": {"provider": "openai", "model": "m", "dim": 3},
+		"store": {"type": "memory"}
+	}`)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := New(path, withEmbedder(&fakeEmbedder{err: tt.err})).Run(context.Background())
+
+			res := resultOf(t, report, "embedder")
+			if res.Status != StatusFail || res.Fix == "" {
+				t.Errorf("expected embedder failure with fix, got %+v", res)
+			}
+			if res := resultOf(t, report, "dimension"); res.Status != StatusSkip {
+				t.Errorf("expected dimension to be skipped, got %s", res.Status)
+			}
+		})
+	}
+}
+
+func TestDoctor_MissingAPIKey(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "")
+	path := writeConfig(t, `{
+		"embedder": {"provider": "openai", "model": "m", "dim": 3},
+		"store": {"type": "memory"}
+	}`)
+
+	report := New(path).Run(context.Background())
+
+	res := resultOf(t, report, "embedder")
+	if res.Status != StatusFail {
+		t.Fatalf("expected embedder failure, got %+v", res)
+	}
+	if res.Fix != "set the OPENAI_API_KEY environment variable or embedder.apiKey" {
+		t.Errorf("unexpected fix: %s", res.Fix)
+	}
+}
+
+func TestDoctor_Dimension(t *testing.T) {
+	tests := []struct {
+		name string
+		dim  string
+		want Status
+	}{
+		{name: "match", dim: "3", want: StatusOK},
+		{name: "unset", dim: "0", want: StatusWarn},
+		{name: "mismatch", dim: "1536", want: StatusFail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfig(t, `{
+				"embedder": {"provider": "openai", "model": "m", "dim": `+tt.dim+`},
+				"store": {"type": "memory"}
+			}`)
+
+			report := New(path, withEmbedder(&fakeEmbedder{vec: []float32{1, 2, 3}})).Run(context.Background())
+
+			if res := resultOf(t, report, "dimension"); res.Status != tt.want {
+				t.Errorf("expected %s, got %+v", tt.want, res)
+			}
+		})
+	}
+}
+
+func TestDoctor_ChromaPing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/heartbeat" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	path := writeConfig(t, `{
+		"embedder": {"provider": "openai", "model": "m", "dim": 3},
+		"store": {"type": "chroma", "url": "`+server.URL+`"}
+	}`)
+
+	report := New(path, withEmbedder(&fakeEmbedder{vec: []float32{1, 2, 3}})).Run(context.Background())
+
+	// 到達可能だが未実装のため警告
+	if res := resultOf(t, report, "store"); res.Status != StatusWarn {
+		t.Errorf("expected store warning, got %+v", res)
+	}
+}
+
+func TestDoctor_Deep(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "memory.db")
+	path := writeConfig(t, `{
+		"embedder": {"provider": "openai", "model": "m", "dim": 3},
+		"store": {"type": "sqlite", "path": "`+filepath.ToSlash(dbPath)+`"},
+		"paths": {"dataDir": "`+filepath.ToSlash(dir)+`"}
+	}`)
+
+	// 次元数の違う埋め込みを持つノート
+	st, err := store.NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Initialize(ctx, "openai:m:3"); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.AddNote(ctx, &model.Note{ID: "short", ProjectID: "/test/project", GroupID: "global", Text: "t", Tags: []string{}}, []float32{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	st.Close()
+	emb := withEmbedder(&fakeEmbedder{vec: []float32{0.1, 0.2, 0.3}})
+
+	// --deepなしでは検証しない
+	if report := New(path, emb).Run(ctx); len(report.Issues) != 0 || report.Results[len(report.Results)-1].Name == "index" {
+		t.Errorf("expected no index check without WithDeep, got %+v", report)
+	}
+
+	report := New(path, emb, WithDeep(false)).Run(ctx)
+	if res := resultOf(t, report, "index"); res.Status != StatusFail || res.Fix == "" {
+		t.Errorf("expected the mismatch to fail, got %+v", res)
+	}
+	if len(report.Issues) != 1 || report.Issues[0].Kind != "dimension_mismatch" || report.Issues[0].Repaired {
+		t.Errorf("unexpected issues: %+v", report.Issues)
+	}
+
+	report = New(path, emb, WithDeep(true)).Run(ctx)
+	if res := resultOf(t, report, "index"); res.Status != StatusOK || len(report.Issues) != 1 || !report.Issues[0].Repaired {
+		t.Errorf("expected the note to be repaired, got %+v, %+v", res, report.Issues)
+	}
+
+	report = New(path, emb, WithDeep(false)).Run(ctx)
+	if res := resultOf(t, report, "index"); res.Status != StatusOK || len(report.Issues) != 0 {
+		t.Errorf("expected no issues after repair, got %+v, %+v", res, report.Issues)
+	}
+}
+
+func TestDoctor_DeepSkipped(t *testing.T) {
+	path := writeConfig(t, `{"embedder": {"provider": "openai", "model": "m", "dim": 3}, "store": {"type": "memory"}}`)
+
+	report := New(path, WithDeep(false), WithEmbedderFactory(func(cfg *model.Config) (embedder.Embedder, error) {
+		return nil, errors.New("boom")
+	})).Run(context.Background())
+
+	if res := resultOf(t, report, "index"); res.Status != StatusSkip {
+		t.Errorf("expected index to be skipped, got %+v", res)
+	}
+}
@@ -0,0 +1,122 @@
+package embedder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// coalescingEmbedder は並行するEmbedを短い時間窓でまとめ、1回のEmbedBatchで埋め込むEmbedder
+// 一括取り込みや複数エージェントから同時に届いたadd_noteのAPI呼び出し回数（レート制限の消費）を減らす
+type coalescingEmbedder struct {
+	Embedder
+	batch    BatchEmbedder
+	window   time.Duration
+	maxTexts int
+
+	mu      sync.Mutex
+	pending *coalescedBatch // 時間窓の途中のバッチ（なければnil）
+}
+
+// coalescedBatch は時間窓の間に集めたテキストと、その埋め込みの結果
+type coalescedBatch struct {
+	ctx     context.Context
+	texts   []string
+	timer   *time.Timer
+	started bool
+	done    chan struct{}
+
+	// doneが閉じた後に読む（textsと同じ順序）
+	embeddings [][]float32
+	errs       []error
+}
+
+// NewCoalescingEmbedder はwindowの間に届いたEmbedをまとめて一括で埋め込むEmbedderを返す
+// windowが0以下、またはeがBatchEmbedderでなければ（まとめても呼び出し回数が減らないため）eをそのまま返す
+func NewCoalescingEmbedder(e Embedder, window time.Duration) Embedder {
+	be, ok := e.(BatchEmbedder)
+	if window <= 0 || !ok {
+		return e
+	}
+	return &coalescingEmbedder{Embedder: e, batch: be, window: window, maxTexts: DefaultOpenAIBatchSize}
+}
+
+// Embed はtextを時間窓のバッチに加え、バッチの埋め込みが終わるまで待つ
+// バッチが1リクエストの上限件数に達した場合は時間窓を待たずに埋め込む
+func (e *coalescingEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	e.mu.Lock()
+	b := e.pending
+	if b == nil {
+		// バッチは最初の呼び出し元のキャンセルに巻き込まれないようにする（値のみ引き継ぐ）
+		b = &coalescedBatch{ctx: context.WithoutCancel(ctx), done: make(chan struct{})}
+		b.timer = time.AfterFunc(e.window, func() { e.flush(b) })
+		e.pending = b
+	}
+	i := len(b.texts)
+	b.texts = append(b.texts, text)
+	full := len(b.texts) >= e.maxTexts
+	e.mu.Unlock()
+
+	if full {
+		e.flush(b)
+	}
+	select {
+	case <-b.done:
+		if err := b.errs[i]; err != nil {
+			return nil, err
+		}
+		return b.embeddings[i], nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// EmbedBatch は呼び出し元がすでにまとめているため、時間窓を待たずにそのまま一括で埋め込む
+func (e *coalescingEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	return e.batch.EmbedBatch(ctx, texts)
+}
+
+// flush はbを締め切って埋め込み、待っている呼び出し元に結果を渡す（2回目以降の呼び出しは何もしない）
+func (e *coalescingEmbedder) flush(b *coalescedBatch) {
+	e.mu.Lock()
+	if b.started {
+		e.mu.Unlock()
+		return
+	}
+	b.started = true
+	b.timer.Stop()
+	if e.pending == b {
+		e.pending = nil
+	}
+	e.mu.Unlock()
+
+	b.embeddings = make([][]float32, len(b.texts))
+	b.errs = make([]error, len(b.texts))
+	embeddings, err := e.batch.EmbedBatch(b.ctx, b.texts)
+	if err == nil && len(embeddings) != len(b.texts) {
+		err = fmt.Errorf("%w: expected %d embeddings, got %d", ErrInvalidResponse, len(b.texts), len(embeddings))
+	}
+	switch {
+	case err == nil:
+		copy(b.embeddings, embeddings)
+	case len(b.texts) > 1 && isBadRequest(err):
+		// 1件のテキスト（トークン数の超過など）のために同じバッチのほかの呼び出しまで失敗させない
+		for i, text := range b.texts {
+			b.embeddings[i], b.errs[i] = e.Embedder.Embed(b.ctx, text)
+		}
+	default:
+		for i := range b.errs {
+			b.errs[i] = err
+		}
+	}
+	close(b.done)
+}
+
+// isBadRequest はerrが入力の誤りによるAPIエラー（400）か判定する
+func isBadRequest(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest
+}
@@ -0,0 +1,168 @@
+package embedder
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingBatchEmbedder はテキストの長さを値とするベクトルを返し、一括の呼び出しを記録するテスト用BatchEmbedder
+// badTextを含む一括の埋め込みは400のAPIErrorにする
+type countingBatchEmbedder struct {
+	mu      sync.Mutex
+	batches [][]string
+	embeds  int
+	badText string
+}
+
+func (e *countingBatchEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	e.mu.Lock()
+	e.embeds++
+	e.mu.Unlock()
+	if text == e.badText {
+		return nil, &APIError{StatusCode: 400, Message: "input too long"}
+	}
+	return []float32{float32(len(text))}, nil
+}
+
+func (e *countingBatchEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	e.mu.Lock()
+	e.batches = append(e.batches, texts)
+	e.mu.Unlock()
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		if e.badText != "" && text == e.badText {
+			return nil, &APIError{StatusCode: 400, Message: "input too long"}
+		}
+		embeddings[i] = []float32{float32(len(text))}
+	}
+	return embeddings, nil
+}
+
+func (e *countingBatchEmbedder) GetDimension() int {
+	return 1
+}
+
+// embedConcurrently はtextsを並行してEmbedし、textsと同じ順序で結果を返す
+func embedConcurrently(e Embedder, texts []string) ([][]float32, []error) {
+	embeddings := make([][]float32, len(texts))
+	errs := make([]error, len(texts))
+	var wg sync.WaitGroup
+	for i, text := range texts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			embeddings[i], errs[i] = e.Embed(context.Background(), text)
+		}()
+	}
+	wg.Wait()
+	return embeddings, errs
+}
+
+// TestCoalescingEmbedder は時間窓の間の並行するEmbedが1回のEmbedBatchにまとまることをテスト
+func TestCoalescingEmbedder(t *testing.T) {
+	inner := &countingBatchEmbedder{}
+	emb := NewCoalescingEmbedder(inner, 50*time.Millisecond)
+
+	texts := []string{"a", "bb", "ccc", "dddd", "eeeee"}
+	embeddings, errs := embedConcurrently(emb, texts)
+	for i, text := range texts {
+		if errs[i] != nil {
+			t.Fatalf("Embed(%q) failed: %v", text, errs[i])
+		}
+		// 各呼び出し元には自分のテキストのベクトルが返る
+		if len(embeddings[i]) != 1 || embeddings[i][0] != float32(len(text)) {
+			t.Errorf("Embed(%q) = %v", text, embeddings[i])
+		}
+	}
+	if len(inner.batches) != 1 || len(inner.batches[0]) != len(texts) || inner.embeds != 0 {
+		t.Errorf("expected a single batch of %d texts, got %v (embeds=%d)", len(texts), inner.batches, inner.embeds)
+	}
+
+	// 明示的な一括の埋め込みは時間窓を待たない
+	inner.batches = nil
+	if embeddings, err := EmbedBatch(context.Background(), emb, []string{"x", "yy"}); err != nil || len(embeddings) != 2 {
+		t.Fatalf("EmbedBatch = %v, %v", embeddings, err)
+	}
+	if len(inner.batches) != 1 {
+		t.Errorf("expected one batch, got %v", inner.batches)
+	}
+}
+
+// TestCoalescingEmbedder_MaxTexts は上限件数に達したバッチを時間窓を待たずに埋め込むことをテスト
+func TestCoalescingEmbedder_MaxTexts(t *testing.T) {
+	inner := &countingBatchEmbedder{}
+	emb := NewCoalescingEmbedder(inner, time.Hour)
+	emb.(*coalescingEmbedder).maxTexts = 3
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		embedConcurrently(emb, []string{"a", "b", "c"})
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("a full batch waited for the window")
+	}
+	if len(inner.batches) != 1 || len(inner.batches[0]) != 3 {
+		t.Errorf("unexpected batches: %v", inner.batches)
+	}
+}
+
+// TestCoalescingEmbedder_BadRequest は入力の誤りで一括が失敗した場合に1件ずつ埋め込み直すことをテスト
+func TestCoalescingEmbedder_BadRequest(t *testing.T) {
+	inner := &countingBatchEmbedder{badText: strings.Repeat("x", 10)}
+	emb := NewCoalescingEmbedder(inner, 50*time.Millisecond)
+
+	texts := []string{"a", inner.badText, "ccc"}
+	embeddings, errs := embedConcurrently(emb, texts)
+	if errs[0] != nil || errs[2] != nil || embeddings[2][0] != 3 {
+		t.Errorf("expected the other texts to succeed: %v, %v", embeddings, errs)
+	}
+	if !errors.Is(errs[1], ErrAPIRequestFailed) {
+		t.Errorf("expected an API error for the bad text, got %v", errs[1])
+	}
+	if inner.embeds != 3 {
+		t.Errorf("expected 3 individual embeds, got %d", inner.embeds)
+	}
+}
+
+// TestCoalescingEmbedder_Cancel はキャンセルした呼び出し元だけが待つのをやめることをテスト
+func TestCoalescingEmbedder_Cancel(t *testing.T) {
+	inner := &countingBatchEmbedder{}
+	emb := NewCoalescingEmbedder(inner, 100*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := emb.Embed(ctx, "cancelled")
+		errCh <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	if err := <-errCh; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	// 同じバッチのほかの呼び出し元は影響を受けない
+	if embedding, err := emb.Embed(context.Background(), "ok"); err != nil || embedding[0] != 2 {
+		t.Errorf("Embed = %v, %v", embedding, err)
+	}
+}
+
+// TestNewCoalescingEmbedder_Disabled は無効な場合に元のEmbedderを返すことをテスト
+func TestNewCoalescingEmbedder_Disabled(t *testing.T) {
+	inner := &countingBatchEmbedder{}
+	if emb := NewCoalescingEmbedder(inner, 0); emb != Embedder(inner) {
+		t.Errorf("expected the embedder to be returned as is for a zero window")
+	}
+	// BatchEmbedderでなければまとめても呼び出し回数は減らない
+	single := sleepEmbedder{}
+	if emb := NewCoalescingEmbedder(single, time.Millisecond); emb != Embedder(single) {
+		t.Errorf("expected the embedder to be returned as is without EmbedBatch")
+	}
+}
@@ -16,6 +16,32 @@ type Embedder interface {
 	GetDimension() int
 }
 
+// BatchEmbedder は複数テキストを1リクエストで埋め込めるEmbedderが実装する任意インターフェース
+type BatchEmbedder interface {
+	// EmbedBatch はtextsと同じ順序で埋め込みベクトルを返す
+	EmbedBatch(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// EmbedBatch はeがBatchEmbedderを実装していれば一括で、そうでなければ1件ずつ埋め込む
+func EmbedBatch(ctx context.Context, e Embedder, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	if be, ok := e.(BatchEmbedder); ok {
+		return be.EmbedBatch(ctx, texts)
+	}
+
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		embedding, err := e.Embed(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		embeddings[i] = embedding
+	}
+	return embeddings, nil
+}
+
 // DimUpdater は次元数が確定した際に呼び出されるコールバック
 type DimUpdater interface {
 	UpdateDim(dim int) error
@@ -6,14 +6,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
+	"sort"
 	"sync"
 )
 
 const (
 	DefaultOpenAIBaseURL = "https://api.openai.com/v1"
 	DefaultOpenAIModel   = "text-embedding-3-small"
+
+	// DefaultOpenAIBatchSize はEmbedBatchで1リクエストに含めるテキスト数の上限
+	DefaultOpenAIBatchSize = 100
 )
 
 // OpenAIEmbedder はOpenAI APIを使用するEmbedder実装
@@ -88,7 +92,7 @@ func NewOpenAIEmbedder(apiKey string, opts ...OpenAIOption) (*OpenAIEmbedder, er
 // embeddingRequest はOpenAI APIリクエストの構造
 type embeddingRequest struct {
 	Model          string `json:"model"`
-	Input          string `json:"input"`
+	Input          any    `json:"input"` // string または []string
 	EncodingFormat string `json:"encoding_format"`
 }
 
@@ -105,10 +109,61 @@ type embeddingData struct {
 
 // Embed はテキストを埋め込みベクトルに変換
 func (e *OpenAIEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	data, err := e.request(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+
+	// embeddingが空でないかチェック
+	embedding := data[0].Embedding
+	if len(embedding) == 0 {
+		return nil, ErrEmptyEmbedding
+	}
+
+	e.updateDim(len(embedding))
+	return embedding, nil
+}
+
+// EmbedBatch は複数テキストをDefaultOpenAIBatchSize件ずつまとめて埋め込みベクトルに変換
+func (e *OpenAIEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += DefaultOpenAIBatchSize {
+		end := min(start+DefaultOpenAIBatchSize, len(texts))
+		batch := texts[start:end]
+
+		data, err := e.request(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) != len(batch) {
+			return nil, fmt.Errorf("%w: expected %d embeddings, got %d", ErrInvalidResponse, len(batch), len(data))
+		}
+
+		// APIはindex順を保証しないので並べ替える
+		sort.Slice(data, func(i, j int) bool { return data[i].Index < data[j].Index })
+		for i, d := range data {
+			if d.Index != i {
+				return nil, fmt.Errorf("%w: unexpected embedding index %d", ErrInvalidResponse, d.Index)
+			}
+			if len(d.Embedding) == 0 {
+				return nil, ErrEmptyEmbedding
+			}
+			embeddings = append(embeddings, d.Embedding)
+		}
+	}
+
+	if len(embeddings) > 0 {
+		e.updateDim(len(embeddings[0]))
+	}
+	return embeddings, nil
+}
+
+// request は/embeddingsにinputを送信し、空でないdataを返す
+func (e *OpenAIEmbedder) request(ctx context.Context, input any) ([]embeddingData, error) {
 	// リクエストボディ作成
 	reqBody := embeddingRequest{
 		Model:          e.model,
-		Input:          text,
+		Input:          input,
 		EncodingFormat: "float",
 	}
 
@@ -163,25 +218,22 @@ func (e *OpenAIEmbedder) Embed(ctx context.Context, text string) ([]float32, err
 		return nil, ErrEmptyEmbedding
 	}
 
-	// embeddingが空でないかチェック
-	embedding := embResp.Data[0].Embedding
-	if len(embedding) == 0 {
-		return nil, ErrEmptyEmbedding
-	}
+	return embResp.Data, nil
+}
 
-	// 次元を更新（初回のみ、かつdimが未設定の場合）
-	if e.dim == 0 {
-		e.dimOnce.Do(func() {
-			e.dim = len(embedding)
-			if e.dimUpdater != nil {
-				if err := e.dimUpdater.UpdateDim(e.dim); err != nil {
-					log.Printf("[WARN] failed to update dim: %v", err)
-				}
-			}
-		})
+// updateDim は次元を更新する（初回のみ、かつdimが未設定の場合）
+func (e *OpenAIEmbedder) updateDim(dim int) {
+	if e.dim != 0 {
+		return
 	}
-
-	return embedding, nil
+	e.dimOnce.Do(func() {
+		e.dim = dim
+		if e.dimUpdater != nil {
+			if err := e.dimUpdater.UpdateDim(e.dim); err != nil {
+				slog.Warn("failed to update dim", "error", err)
+			}
+		}
+	})
 }
 
 // GetDimension は次元を返す
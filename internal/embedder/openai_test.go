@@ -414,3 +414,78 @@ func TestOpenAIEmbedder_WithModel(t *testing.T) {
 		t.Errorf("expected model text-embedding-3-large, got %s", receivedModel)
 	}
 }
+
+func TestOpenAIEmbedder_EmbedBatch_Success(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		var req struct {
+			Input []string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		// index逆順で返してもリクエスト順に並ぶことを確認
+		resp := openAIResponse{Model: "text-embedding-3-small"}
+		for i := len(req.Input) - 1; i >= 0; i-- {
+			resp.Data = append(resp.Data, openAIEmbeddingData{Embedding: []float32{float32(i), 1}, Index: i})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	updater := &mockDimUpdater{}
+	emb, _ := NewOpenAIEmbedder("test-key",
+		WithBaseURL(server.URL),
+		WithHTTPClient(server.Client()),
+		WithDimUpdater(updater))
+
+	texts := make([]string, DefaultOpenAIBatchSize+5)
+	for i := range texts {
+		texts[i] = "text"
+	}
+
+	result, err := emb.EmbedBatch(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("EmbedBatch failed: %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("expected 2 requests, got %d", requests)
+	}
+	if len(result) != len(texts) {
+		t.Fatalf("expected %d embeddings, got %d", len(texts), len(result))
+	}
+	if result[1][0] != 1 || result[DefaultOpenAIBatchSize+1][0] != 1 {
+		t.Errorf("embeddings not in request order: %v, %v", result[1], result[DefaultOpenAIBatchSize+1])
+	}
+	if updater.callCount != 1 || updater.updatedDim != 2 {
+		t.Errorf("expected dim 2 updated once, got dim=%d calls=%d", updater.updatedDim, updater.callCount)
+	}
+}
+
+func TestOpenAIEmbedder_EmbedBatch_CountMismatch(t *testing.T) {
+	server := newMockOpenAIServer(successHandler([]float32{0.1}))
+	defer server.Close()
+
+	emb, _ := NewOpenAIEmbedder("test-key", WithBaseURL(server.URL), WithHTTPClient(server.Client()))
+
+	_, err := emb.EmbedBatch(context.Background(), []string{"a", "b"})
+	if !errors.Is(err, ErrInvalidResponse) {
+		t.Errorf("expected ErrInvalidResponse, got %v", err)
+	}
+}
+
+func TestOpenAIEmbedder_EmbedBatch_APIError(t *testing.T) {
+	server := newMockOpenAIServer(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	defer server.Close()
+
+	emb, _ := NewOpenAIEmbedder("test-key", WithBaseURL(server.URL), WithHTTPClient(server.Client()))
+
+	_, err := emb.EmbedBatch(context.Background(), []string{"a"})
+	if !errors.Is(err, ErrAPIRequestFailed) {
+		t.Errorf("expected ErrAPIRequestFailed, got %v", err)
+	}
+}
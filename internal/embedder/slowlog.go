@@ -0,0 +1,45 @@
+package embedder
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// slowLogEmbedder は閾値以上かかった埋め込みを警告としてログに出すEmbedder
+// ログにはテキストの長さ（一括の場合は件数）のみ出し、本文は含めない
+type slowLogEmbedder struct {
+	Embedder
+	threshold time.Duration
+	namespace string
+}
+
+// NewSlowLogEmbedder はthreshold以上かかった埋め込みをslogで警告するEmbedderを返す
+// thresholdが0以下ならeをそのまま返す。eがBatchEmbedderなら一括の埋め込みも維持する
+func NewSlowLogEmbedder(e Embedder, threshold time.Duration, namespace string) Embedder {
+	if threshold <= 0 {
+		return e
+	}
+	return &slowLogEmbedder{Embedder: e, threshold: threshold, namespace: namespace}
+}
+
+// observe はstartからの経過時間が閾値以上なら警告を出す（deferで呼ぶ）
+func (e *slowLogEmbedder) observe(method string, start time.Time, args ...any) {
+	d := time.Since(start)
+	if d < e.threshold {
+		return
+	}
+	attrs := append([]any{"method", method, "duration", d, "namespace", e.namespace}, args...)
+	slog.Warn("slow embedder call", attrs...)
+}
+
+func (e *slowLogEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	defer e.observe("Embed", time.Now(), "textLength", len(text))
+	return e.Embedder.Embed(ctx, text)
+}
+
+// EmbedBatch はラップしたEmbedderで一括（BatchEmbedderでなければ1件ずつ）埋め込む
+func (e *slowLogEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	defer e.observe("EmbedBatch", time.Now(), "texts", len(texts))
+	return EmbedBatch(ctx, e.Embedder, texts)
+}
@@ -0,0 +1,62 @@
+package embedder
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sleepEmbedder はdelayだけ待ってから固定ベクトルを返すテスト用Embedder（BatchEmbedderではない）
+type sleepEmbedder struct {
+	delay time.Duration
+}
+
+func (e sleepEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	time.Sleep(e.delay)
+	return []float32{0.1, 0.2, 0.3}, nil
+}
+
+func (e sleepEmbedder) GetDimension() int {
+	return 3
+}
+
+// TestSlowLogEmbedder は閾値以上かかった埋め込みのみ警告することをテスト
+func TestSlowLogEmbedder(t *testing.T) {
+	prev := slog.Default()
+	defer slog.SetDefault(prev)
+	var buf bytes.Buffer
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	ctx := context.Background()
+	emb := NewSlowLogEmbedder(sleepEmbedder{delay: 5 * time.Millisecond}, time.Millisecond, "openai:test:3")
+	if _, err := emb.Embed(ctx, "secret text"); err != nil {
+		t.Fatal(err)
+	}
+	// BatchEmbedderでなくても一括の埋め込みは1件ずつ行い、まとめて1回警告する
+	embeddings, err := EmbedBatch(ctx, emb, []string{"a", "b"})
+	if err != nil || len(embeddings) != 2 {
+		t.Fatalf("EmbedBatch: %d embeddings, err=%v", len(embeddings), err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"method=Embed ", "textLength=11", "method=EmbedBatch", "texts=2", "namespace=openai:test:3"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in log: %s", want, out)
+		}
+	}
+	if strings.Contains(out, "secret") {
+		t.Errorf("log must not contain the text: %s", out)
+	}
+
+	buf.Reset()
+	emb = NewSlowLogEmbedder(sleepEmbedder{}, time.Hour, "openai:test:3")
+	if _, err := emb.Embed(ctx, "fast"); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("unexpected log for a fast call: %s", buf.String())
+	}
+}
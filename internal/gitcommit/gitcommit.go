@@ -0,0 +1,185 @@
+// Package gitcommit reads git commits as notes and manages the post-commit hook that records them.
+package gitcommit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tag はコミットから作ったノートに付けるタグ
+const Tag = "commit"
+
+// DefaultMaxFiles はノートに列挙する変更ファイルの数の既定値（超えた分は件数のみ）
+const DefaultMaxFiles = 50
+
+// Commit は1件のコミット
+type Commit struct {
+	Hash      string
+	Parents   []string
+	Author    string
+	Date      time.Time // コミットした日時（committer date）
+	Subject   string    // メッセージの1行目
+	Body      string    // メッセージの2行目以降（前後の空白を除く）
+	Files     []FileChange
+	Additions int // テキストファイルの追加行数の合計
+	Deletions int // テキストファイルの削除行数の合計
+}
+
+// FileChange は1ファイルの変更（マージコミットは最初の親との差分）
+type FileChange struct {
+	Path      string
+	OldPath   string // リネーム・コピー元（それ以外は空）
+	Additions int
+	Deletions int
+	Binary    bool // バイナリファイル（行数は0）
+}
+
+// Read はdirのリポジトリのrev（"HEAD" など）のコミットを読み込む
+func Read(ctx context.Context, dir, rev string) (*Commit, error) {
+	out, err := git(ctx, dir, "log", "-1", "--format=%H%x00%P%x00%an%x00%cI%x00%B", rev, "--")
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.SplitN(string(out), "\x00", 5)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("unexpected git log output for %s", rev)
+	}
+	date, err := time.Parse(time.RFC3339, fields[3])
+	if err != nil {
+		return nil, fmt.Errorf("invalid commit date %q: %w", fields[3], err)
+	}
+	c := &Commit{
+		Hash:    fields[0],
+		Parents: strings.Fields(fields[1]),
+		Author:  fields[2],
+		Date:    date,
+	}
+	message := strings.TrimSpace(fields[4])
+	c.Subject, c.Body, _ = strings.Cut(message, "\n")
+	c.Subject, c.Body = strings.TrimSpace(c.Subject), strings.TrimSpace(c.Body)
+
+	// 最初の親（ルートコミットは空のツリー）との差分
+	base := ""
+	if len(c.Parents) > 0 {
+		base = c.Parents[0]
+	} else {
+		tree, err := git(ctx, dir, "hash-object", "-t", "tree", "--stdin")
+		if err != nil {
+			return nil, err
+		}
+		base = strings.TrimSpace(string(tree))
+	}
+	diff, err := git(ctx, dir, "diff", "--numstat", "-z", "-M", base, c.Hash, "--")
+	if err != nil {
+		return nil, err
+	}
+	if c.Files, err = parseNumstat(diff); err != nil {
+		return nil, err
+	}
+	for _, f := range c.Files {
+		c.Additions += f.Additions
+		c.Deletions += f.Deletions
+	}
+	return c, nil
+}
+
+// parseNumstat は git diff --numstat -z の出力を読む
+// 各レコードは "追加\t削除\tパス\x00"、リネームは "追加\t削除\t\x00元のパス\x00新しいパス\x00"、バイナリは行数が "-"
+func parseNumstat(out []byte) ([]FileChange, error) {
+	var files []FileChange
+	records := strings.Split(string(out), "\x00")
+	for i := 0; i < len(records); i++ {
+		if records[i] == "" {
+			continue
+		}
+		added, rest, ok1 := strings.Cut(records[i], "\t")
+		deleted, path, ok2 := strings.Cut(rest, "\t")
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("unexpected git diff output: %q", records[i])
+		}
+		f := FileChange{Path: path}
+		if path == "" {
+			if i+2 >= len(records) || records[i+2] == "" {
+				return nil, errors.New("unexpected git diff output: incomplete rename")
+			}
+			f.OldPath, f.Path = records[i+1], records[i+2]
+			i += 2
+		}
+		if added == "-" && deleted == "-" {
+			f.Binary = true
+		} else {
+			var err error
+			if f.Additions, err = strconv.Atoi(added); err != nil {
+				return nil, fmt.Errorf("unexpected git diff output: %q", records[i])
+			}
+			if f.Deletions, err = strconv.Atoi(deleted); err != nil {
+				return nil, fmt.Errorf("unexpected git diff output: %q", records[i])
+			}
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// ShortHash はハッシュの先頭12文字を返す
+func (c *Commit) ShortHash() string {
+	if len(c.Hash) > 12 {
+		return c.Hash[:12]
+	}
+	return c.Hash
+}
+
+// NoteText はコミットメッセージと変更の要約をノートの本文にする（変更ファイルはmaxFiles件まで列挙、0以下は既定値）
+func (c *Commit) NoteText(maxFiles int) string {
+	if maxFiles <= 0 {
+		maxFiles = DefaultMaxFiles
+	}
+	var b strings.Builder
+	b.WriteString(c.Subject)
+	if c.Body != "" {
+		b.WriteString("\n\n")
+		b.WriteString(c.Body)
+	}
+	fmt.Fprintf(&b, "\n\nCommit: %s\nAuthor: %s\n", c.Hash, c.Author)
+	if len(c.Parents) > 1 {
+		fmt.Fprintf(&b, "Merge: %s (changes against the first parent)\n", strings.Join(c.Parents, " "))
+	}
+	fmt.Fprintf(&b, "Changed files: %d (+%d -%d)\n", len(c.Files), c.Additions, c.Deletions)
+	for i, f := range c.Files {
+		if i == maxFiles {
+			fmt.Fprintf(&b, "  ... and %d more files\n", len(c.Files)-maxFiles)
+			break
+		}
+		path := f.Path
+		if f.OldPath != "" {
+			path = f.OldPath + " -> " + f.Path
+		}
+		if f.Binary {
+			fmt.Fprintf(&b, "  %s (binary)\n", path)
+		} else {
+			fmt.Fprintf(&b, "  %s (+%d -%d)\n", path, f.Additions, f.Deletions)
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// git はdirでgitを実行し、標準出力を返す（失敗時は標準エラー出力をエラーに含める）
+func git(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
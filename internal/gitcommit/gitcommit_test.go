@@ -0,0 +1,138 @@
+package gitcommit
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newRepo はテスト用のgitリポジトリを作る（gitがなければスキップ）
+func newRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	run(t, dir, "init", "-q")
+	run(t, dir, "config", "user.name", "Alice")
+	run(t, dir, "config", "user.email", "alice@example.com")
+	run(t, dir, "config", "commit.gpgsign", "false")
+	return dir
+}
+
+func run(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestRead はメッセージ・変更ファイル（リネーム・バイナリ）を読み込めることをテスト
+func TestRead(t *testing.T) {
+	ctx := context.Background()
+	dir := newRepo(t)
+	writeFile(t, dir, "auth.go", "package auth\n\nfunc Login() {}\n")
+	run(t, dir, "add", ".")
+	run(t, dir, "commit", "-q", "-m", "Initial commit")
+
+	// ルートコミットは空のツリーとの差分
+	c, err := Read(ctx, dir, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Subject != "Initial commit" || c.Body != "" || len(c.Parents) != 0 || len(c.Files) != 1 || c.Additions != 3 {
+		t.Errorf("unexpected root commit: %+v", c)
+	}
+
+	run(t, dir, "mv", "auth.go", "login.go")
+	writeFile(t, dir, "login.go", "package auth\n\nfunc Login() {}\n\nfunc Logout() {}\n")
+	writeFile(t, dir, "logo.png", "\x89PNG\x00\x01")
+	run(t, dir, "add", ".")
+	run(t, dir, "commit", "-q", "-m", "Split auth into login\n\nUse JWT for sessions.\n")
+
+	c, err = Read(ctx, dir, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Subject != "Split auth into login" || c.Body != "Use JWT for sessions." || c.Author != "Alice" || len(c.Parents) != 1 || c.Date.IsZero() {
+		t.Errorf("unexpected commit: %+v", c)
+	}
+	if len(c.Files) != 2 || c.Additions != 2 || c.Deletions != 0 {
+		t.Fatalf("unexpected files: %+v", c.Files)
+	}
+	for _, f := range c.Files {
+		switch f.Path {
+		case "login.go":
+			if f.OldPath != "auth.go" || f.Additions != 2 {
+				t.Errorf("unexpected rename: %+v", f)
+			}
+		case "logo.png":
+			if !f.Binary {
+				t.Errorf("expected a binary file: %+v", f)
+			}
+		default:
+			t.Errorf("unexpected file: %+v", f)
+		}
+	}
+
+	if _, err := Read(ctx, dir, "no-such-rev"); err == nil {
+		t.Error("expected an error for an unknown revision")
+	}
+}
+
+func TestNoteText(t *testing.T) {
+	c := &Commit{
+		Hash:      "0123456789abcdef0123456789abcdef01234567",
+		Author:    "Alice",
+		Subject:   "Split auth into login",
+		Body:      "Use JWT for sessions.",
+		Additions: 3,
+		Deletions: 1,
+		Files: []FileChange{
+			{Path: "login.go", OldPath: "auth.go", Additions: 3, Deletions: 1},
+			{Path: "logo.png", Binary: true},
+			{Path: "README.md"},
+		},
+	}
+	want := `Split auth into login
+
+Use JWT for sessions.
+
+Commit: 0123456789abcdef0123456789abcdef01234567
+Author: Alice
+Changed files: 3 (+3 -1)
+  auth.go -> login.go (+3 -1)
+  logo.png (binary)
+  ... and 1 more files`
+	if got := c.NoteText(2); got != want {
+		t.Errorf("NoteText =\n%s\nwant\n%s", got, want)
+	}
+	if c.ShortHash() != "0123456789ab" {
+		t.Errorf("unexpected short hash: %s", c.ShortHash())
+	}
+
+	c.Parents = []string{"aaa", "bbb"}
+	if got := c.NoteText(0); !strings.Contains(got, "Merge: aaa bbb") || !strings.Contains(got, "README.md (+0 -0)") {
+		t.Errorf("unexpected merge commit text:\n%s", got)
+	}
+}
+
+func TestParseNumstat_Invalid(t *testing.T) {
+	for _, out := range []string{"1\t2\n", "x\t1\tfile\x00", "1\t0\t\x00old\x00"} {
+		if _, err := parseNumstat([]byte(out)); err == nil {
+			t.Errorf("expected an error for %q", out)
+		}
+	}
+}
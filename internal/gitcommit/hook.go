@@ -0,0 +1,168 @@
+package gitcommit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// HookName はインストールするgitフックの名前
+const HookName = "post-commit"
+
+// フック内のmcp-memoryの部分を囲む行（既存のフックに追記・削除できるようにする）
+const (
+	hookBegin = "# >>> mcp-memory capture-commit >>>"
+	hookEnd   = "# <<< mcp-memory capture-commit <<<"
+)
+
+// ErrNotShellHook は既存のフックがシェルスクリプトでないため追記できない場合のエラー
+var ErrNotShellHook = errors.New("existing hook is not a shell script")
+
+// HooksDir はdirのリポジトリのフックのディレクトリを返す（core.hooksPath・worktreeを考慮する）
+func HooksDir(ctx context.Context, dir string) (string, error) {
+	out, err := git(ctx, dir, "rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return "", err
+	}
+	// 相対パスはdirからのパス
+	path := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	return filepath.Abs(path)
+}
+
+// HookBlock はフックに書き込むコマンドの部分（hookBegin・hookEnd で囲んだ行）を返す
+// argsはシェル用に引用符で囲む。backgroundならコミットを待たせないよう出力を捨ててバックグラウンドで実行する
+func HookBlock(args []string, background bool) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	line := strings.Join(quoted, " ")
+	if background {
+		line += " </dev/null >/dev/null 2>&1 &"
+	}
+	return hookBegin + "\n" + line + "\n" + hookEnd + "\n"
+}
+
+// InstallHook はhooksDirのpost-commitにblock（HookBlock）を書き込み、フックのパスを返す
+// フックがなければ作成し、既存のシェルスクリプトには追記する（以前のblockは置き換える）
+func InstallHook(hooksDir, block string) (string, error) {
+	path := filepath.Join(hooksDir, HookName)
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var content string
+	if len(data) == 0 {
+		content = "#!/bin/sh\n" + block
+	} else {
+		existing := removeBlock(string(data))
+		if !isShellScript(existing) {
+			return "", fmt.Errorf("%w: %s (add the capture-commit command to it manually)", ErrNotShellHook, path)
+		}
+		if !strings.HasSuffix(existing, "\n") {
+			existing += "\n"
+		}
+		content = existing + block
+	}
+
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	// 既存のファイルはWriteFileでは権限が変わらないため実行可能にする
+	if err := os.Chmod(path, 0755); err != nil {
+		return "", fmt.Errorf("failed to make %s executable: %w", path, err)
+	}
+	return path, nil
+}
+
+// UninstallHook はhooksDirのpost-commitからInstallHookで書き込んだ部分を取り除き、取り除いたかを返す
+// 残りが空（シェバンのみ）になればフックを削除する
+func UninstallHook(hooksDir string) (bool, error) {
+	path := filepath.Join(hooksDir, HookName)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	rest := removeBlock(string(data))
+	if rest == string(data) {
+		return false, nil
+	}
+
+	if isEmptyScript(rest) {
+		if err := os.Remove(path); err != nil {
+			return false, fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		return true, nil
+	}
+	if err := os.WriteFile(path, []byte(rest), 0755); err != nil {
+		return false, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// removeBlock はcontentからhookBegin〜hookEndの行を取り除く
+func removeBlock(content string) string {
+	start := strings.Index(content, hookBegin)
+	if start < 0 {
+		return content
+	}
+	end := strings.Index(content[start:], hookEnd)
+	if end < 0 {
+		return content
+	}
+	end += start + len(hookEnd)
+	if end < len(content) && content[end] == '\n' {
+		end++
+	}
+	return content[:start] + content[end:]
+}
+
+// isShellScript はcontentがshで実行できるスクリプト（シェバンがsh・bash・zsh・dash、またはシェバンなし）かを返す
+func isShellScript(content string) bool {
+	if !strings.HasPrefix(content, "#!") {
+		return true
+	}
+	first, _, _ := strings.Cut(content, "\n")
+	fields := strings.Fields(strings.TrimPrefix(first, "#!"))
+	if len(fields) == 0 {
+		return false
+	}
+	interp := filepath.Base(fields[0])
+	if interp == "env" && len(fields) > 1 {
+		interp = fields[1]
+	}
+	switch interp {
+	case "sh", "bash", "zsh", "dash":
+		return true
+	}
+	return false
+}
+
+// isEmptyScript はcontentがシェバン・空行・コメントのみかを返す
+func isEmptyScript(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return false
+		}
+	}
+	return true
+}
+
+// shellQuote はsをシェルの単一引用符で囲む
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
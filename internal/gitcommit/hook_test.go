@@ -0,0 +1,110 @@
+package gitcommit
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHookBlock(t *testing.T) {
+	got := HookBlock([]string{"/opt/my tools/mcp-memory", "capture-commit", "--group", "it's"}, true)
+	want := hookBegin + "\n'/opt/my tools/mcp-memory' 'capture-commit' '--group' 'it'\\''s' </dev/null >/dev/null 2>&1 &\n" + hookEnd + "\n"
+	if got != want {
+		t.Errorf("HookBlock =\n%s\nwant\n%s", got, want)
+	}
+	if got := HookBlock([]string{"mcp-memory"}, false); strings.Contains(got, "&") {
+		t.Errorf("expected a foreground command, got %s", got)
+	}
+}
+
+// TestInstallHook は新規作成・既存のフックへの追記・置き換え・取り除きをテスト
+func TestInstallHook(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "hooks")
+	block := HookBlock([]string{"mcp-memory", "capture-commit"}, true)
+
+	path, err := InstallHook(dir, block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "#!/bin/sh\n"+block {
+		t.Errorf("unexpected hook:\n%s", data)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm()&0100 == 0 {
+		t.Errorf("expected an executable hook, got %v %v", info.Mode(), err)
+	}
+	if removed, err := UninstallHook(dir); err != nil || !removed {
+		t.Fatalf("UninstallHook = %v, %v", removed, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected the hook to be removed, got %v", err)
+	}
+	if removed, err := UninstallHook(dir); err != nil || removed {
+		t.Errorf("UninstallHook on a missing hook = %v, %v", removed, err)
+	}
+
+	// 既存のフックには追記し、再インストールでは置き換える
+	existing := "#!/usr/bin/env bash\nmake lint"
+	if err := os.WriteFile(path, []byte(existing), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := InstallHook(dir, block); err != nil {
+		t.Fatal(err)
+	}
+	updated := HookBlock([]string{"mcp-memory", "capture-commit", "--group", "commits"}, true)
+	if _, err := InstallHook(dir, updated); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(path)
+	if string(data) != existing+"\n"+updated {
+		t.Errorf("unexpected hook:\n%s", data)
+	}
+	if removed, err := UninstallHook(dir); err != nil || !removed {
+		t.Fatalf("UninstallHook = %v, %v", removed, err)
+	}
+	data, _ = os.ReadFile(path)
+	if string(data) != existing+"\n" {
+		t.Errorf("expected the original hook to remain, got:\n%s", data)
+	}
+
+	// シェルスクリプトでないフックには追記しない
+	if err := os.WriteFile(path, []byte("#!/usr/bin/env python3\nprint('hi')\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := InstallHook(dir, block); !errors.Is(err, ErrNotShellHook) {
+		t.Errorf("expected ErrNotShellHook, got %v", err)
+	}
+}
+
+// TestHooksDir はcore.hooksPathの設定に従うことをテスト
+func TestHooksDir(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	ctx := context.Background()
+	dir := newRepo(t)
+	got, err := HooksDir(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := filepath.Abs(filepath.Join(dir, ".git", "hooks")); got != want {
+		t.Errorf("HooksDir = %s, want %s", got, want)
+	}
+
+	run(t, dir, "config", "core.hooksPath", ".githooks")
+	got, err = HooksDir(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := filepath.Abs(filepath.Join(dir, ".githooks")); got != want {
+		t.Errorf("HooksDir = %s, want %s", got, want)
+	}
+
+	if _, err := HooksDir(ctx, t.TempDir()); err == nil {
+		t.Error("expected an error outside a repository")
+	}
+}
@@ -0,0 +1,128 @@
+package importer
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// BookmarkTag はブックマークから作ったノートに付けるタグ
+const BookmarkTag = "bookmark"
+
+// bookmarkTag はNetscape Bookmark形式のタグ（"<DT>"、"</DL>"、"<A HREF=...>" など）
+var bookmarkTag = regexp.MustCompile(`<(/?)([A-Za-z][A-Za-z0-9]*)([^>]*)>`)
+
+// bookmarkAttr はタグの属性（NAME="value"）
+var bookmarkAttr = regexp.MustCompile(`([A-Za-z_][A-Za-z0-9_-]*)\s*=\s*"([^"]*)"`)
+
+// ParseBookmarks はブラウザからエクスポートしたブックマーク（Netscape Bookmark形式のHTML）を読み込む
+// ブックマークごとに1つのDocumentになる。sourceはURL、createdAtは追加日時、タグは "bookmark" と
+// TAGS属性（Firefox）で、フォルダの階層は本文に書く。javascript: などWebページでないものは取り込まない
+func ParseBookmarks(data []byte) ([]Document, error) {
+	text := string(data)
+	if !strings.Contains(strings.ToUpper(text), "<DL") {
+		return nil, fmt.Errorf("not a bookmarks HTML file (expected the Netscape Bookmark format)")
+	}
+
+	var (
+		docs    []Document
+		folders []string // 開いているDLごとのフォルダ名
+		pending string   // 直前のH3（次のDLのフォルダ名）
+		last    *Document
+		seen    = make(map[string]bool)
+	)
+	matches := bookmarkTag.FindAllStringSubmatchIndex(text, -1)
+	for i, m := range matches {
+		closing := m[3] > m[2]
+		name := strings.ToUpper(text[m[4]:m[5]])
+		attrs := text[m[6]:m[7]]
+		// 次のタグまでの文字列
+		next := len(text)
+		if i+1 < len(matches) {
+			next = matches[i+1][0]
+		}
+		inner := strings.TrimSpace(html.UnescapeString(text[m[1]:next]))
+
+		switch {
+		case name == "H3" && !closing:
+			pending = inner
+		case name == "DL" && !closing:
+			folders = append(folders, pending)
+			pending = ""
+		case name == "DL" && closing:
+			if len(folders) > 0 {
+				folders = folders[:len(folders)-1]
+			}
+		case name == "A" && !closing:
+			last = nil
+			doc := bookmarkDocument(parseBookmarkAttrs(attrs), inner, folders)
+			if doc == nil || seen[doc.Source] {
+				continue
+			}
+			seen[doc.Source] = true
+			docs = append(docs, *doc)
+			last = &docs[len(docs)-1]
+		case name == "DD" && !closing:
+			// 直前のブックマークの説明
+			if last != nil && inner != "" {
+				last.Sections[0].Text += "\n\n" + inner
+			}
+			last = nil
+		}
+	}
+	return docs, nil
+}
+
+// parseBookmarkAttrs はタグの属性を大文字の名前で返す
+func parseBookmarkAttrs(s string) map[string]string {
+	attrs := make(map[string]string)
+	for _, m := range bookmarkAttr.FindAllStringSubmatch(s, -1) {
+		attrs[strings.ToUpper(m[1])] = html.UnescapeString(m[2])
+	}
+	return attrs
+}
+
+// bookmarkDocument は1件のブックマークのDocumentを作る（Webページでなければnil）
+func bookmarkDocument(attrs map[string]string, title string, folders []string) *Document {
+	url := strings.TrimSpace(attrs["HREF"])
+	lower := strings.ToLower(url)
+	if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") {
+		return nil
+	}
+	if title == "" {
+		title = url
+	}
+
+	var folderPath []string
+	for _, f := range folders {
+		if f != "" {
+			folderPath = append(folderPath, f)
+		}
+	}
+	text := title + "\n" + url
+	if len(folderPath) > 0 {
+		text += "\nFolder: " + strings.Join(folderPath, " / ")
+	}
+
+	tags := []string{BookmarkTag}
+	for _, tag := range strings.Split(attrs["TAGS"], ",") {
+		tags = appendTag(tags, tag)
+	}
+	createdAt := ""
+	if v, err := strconv.ParseInt(attrs["ADD_DATE"], 10, 64); err == nil {
+		// 秒（Chrome・Firefox）だが、マイクロ秒で書き出すブラウザもある
+		if v > 1e14 {
+			v /= 1e6
+		}
+		createdAt = unixTime(v, false)
+	}
+	return &Document{
+		Source:    url,
+		Title:     title,
+		Tags:      tags,
+		CreatedAt: createdAt,
+		Sections:  sections(text),
+	}
+}
@@ -0,0 +1,57 @@
+package importer
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testBookmarks = `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+<DL><p>
+    <DT><H3 ADD_DATE="1700000000" PERSONAL_TOOLBAR_FOLDER="true">Bookmarks bar</H3>
+    <DL><p>
+        <DT><H3>Go &amp; tools</H3>
+        <DL><p>
+            <DT><A HREF="https://go.dev/doc/effective_go" ADD_DATE="1709251200" TAGS="go,style">Effective Go</A>
+            <DD>How to write clear, idiomatic Go code
+            <DT><A HREF="javascript:alert(1)">Bookmarklet</A>
+        </DL><p>
+        <DT><A HREF="https://example.com/" ADD_DATE="1709251200000000"></A>
+    </DL><p>
+    <DT><A HREF="https://go.dev/doc/effective_go">Effective Go (duplicate)</A>
+</DL><p>
+`
+
+func TestParseBookmarks(t *testing.T) {
+	docs, err := ParseBookmarks([]byte(testBookmarks))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 {
+		t.Fatalf("expected 2 bookmarks, got %+v", docs)
+	}
+
+	d := docs[0]
+	if d.Source != "https://go.dev/doc/effective_go" || d.Title != "Effective Go" || d.CreatedAt != "2024-03-01T00:00:00Z" {
+		t.Errorf("unexpected bookmark: %+v", d)
+	}
+	if !reflect.DeepEqual(d.Tags, []string{BookmarkTag, "go", "style"}) {
+		t.Errorf("unexpected tags: %v", d.Tags)
+	}
+	want := "Effective Go\nhttps://go.dev/doc/effective_go\nFolder: Bookmarks bar / Go & tools\n\nHow to write clear, idiomatic Go code"
+	if d.Sections[0].Text != want {
+		t.Errorf("text =\n%s\nwant\n%s", d.Sections[0].Text, want)
+	}
+
+	// タイトルがなければURL、マイクロ秒の追加日時も読む
+	d = docs[1]
+	if d.Title != "https://example.com/" || d.CreatedAt != "2024-03-01T00:00:00Z" || d.Sections[0].Text != "https://example.com/\nhttps://example.com/\nFolder: Bookmarks bar" {
+		t.Errorf("unexpected bookmark: %+v", d)
+	}
+
+	if _, err := ParseBookmarks([]byte(`{"roots": {}}`)); err == nil {
+		t.Error("expected an error for a non-HTML file")
+	}
+}
@@ -0,0 +1,41 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "bookmarks.html")
+	if err := os.WriteFile(p, []byte(testBookmarks), 0644); err != nil {
+		t.Fatal(err)
+	}
+	docs, err := Read(FormatBookmarks, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 {
+		t.Errorf("expected 2 bookmarks, got %d", len(docs))
+	}
+
+	if _, err := Read("evernote", p); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+	if _, err := Read(FormatLogseq, filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestUnixTime(t *testing.T) {
+	if got := unixTime(1709251200, false); got != "2024-03-01T00:00:00Z" {
+		t.Errorf("unixTime(seconds) = %s", got)
+	}
+	if got := unixTime(1709251200000, true); got != "2024-03-01T00:00:00Z" {
+		t.Errorf("unixTime(millis) = %s", got)
+	}
+	if got := unixTime(0, false); got != "" {
+		t.Errorf("unixTime(0) = %q", got)
+	}
+}
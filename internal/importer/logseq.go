@@ -0,0 +1,153 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// graphProperty はブロック内のプロパティ行（"tags:: a, b"）
+var graphProperty = regexp.MustCompile(`^([A-Za-z][\w-]*)::\s*(.*)$`)
+
+// graphBlock はLogseq・RoamのJSONエクスポートのページまたはブロック
+type graphBlock struct {
+	// Logseq
+	PageName   string         `json:"page-name"`
+	Content    string         `json:"content"`
+	Properties map[string]any `json:"properties"`
+	// Roam
+	Title      string `json:"title"`
+	String     string `json:"string"`
+	CreateTime int64  `json:"create-time"` // ミリ秒
+
+	Children []graphBlock `json:"children"`
+}
+
+// logseqExport はLogseqのJSONエクスポート（{"version": 1, "blocks": [ページ...]}）
+type logseqExport struct {
+	Blocks []graphBlock `json:"blocks"`
+}
+
+// ParseGraph はLogseqまたはRoamのJSONエクスポートを読み込む（ページごとに1つのDocument）
+// ブロックの階層はMarkdownの箇条書きにし、"tags::" のプロパティ（Logseqはページのプロパティも）をタグにする
+// 本文のないページ（リンクされただけのページなど）は取り込まない
+func ParseGraph(data []byte) ([]Document, error) {
+	data = bytes.TrimSpace(data)
+	var pages []graphBlock
+	format := FormatLogseq
+	switch {
+	case bytes.HasPrefix(data, []byte("[")):
+		// Roamはページの配列
+		format = FormatRoam
+		if err := json.Unmarshal(data, &pages); err != nil {
+			return nil, fmt.Errorf("failed to parse Roam JSON: %w", err)
+		}
+	case bytes.HasPrefix(data, []byte("{")):
+		var export logseqExport
+		if err := json.Unmarshal(data, &export); err != nil {
+			return nil, fmt.Errorf("failed to parse Logseq JSON: %w", err)
+		}
+		pages = export.Blocks
+	default:
+		return nil, fmt.Errorf("not a Logseq or Roam JSON export")
+	}
+
+	docs := make([]Document, 0, len(pages))
+	seen := make(map[string]bool, len(pages))
+	for _, page := range pages {
+		name := strings.TrimSpace(page.PageName)
+		if name == "" {
+			name = strings.TrimSpace(page.Title)
+		}
+		if name == "" || seen[name] {
+			continue
+		}
+
+		tags := []string{format}
+		for _, tag := range propertyTags(page.Properties["tags"]) {
+			tags = appendTag(tags, tag)
+		}
+		var b strings.Builder
+		for _, block := range page.Children {
+			writeGraphBlock(&b, block, 0, &tags)
+		}
+		secs := sections(b.String())
+		if secs == nil {
+			continue
+		}
+		seen[name] = true
+		docs = append(docs, Document{
+			Source:    format + ":" + name,
+			Title:     name,
+			Tags:      tags,
+			CreatedAt: unixTime(page.CreateTime, true),
+			Sections:  secs,
+		})
+	}
+	return docs, nil
+}
+
+// writeGraphBlock はブロックとその子を深さdepthの箇条書きとして書く
+// "key:: value" のプロパティ行は本文から除き、tagsはタグに加える
+func writeGraphBlock(b *strings.Builder, block graphBlock, depth int, tags *[]string) {
+	text := block.Content
+	if text == "" {
+		text = block.String
+	}
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if m := graphProperty.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			if strings.EqualFold(m[1], "tags") {
+				for _, tag := range propertyTags(m[2]) {
+					*tags = appendTag(*tags, tag)
+				}
+			}
+			continue
+		}
+		lines = append(lines, line)
+	}
+	body := strings.TrimSpace(strings.Join(lines, "\n"))
+
+	childDepth := depth
+	if body != "" {
+		indent := strings.Repeat("  ", depth)
+		for i, line := range strings.Split(body, "\n") {
+			if i == 0 {
+				fmt.Fprintf(b, "%s- %s\n", indent, line)
+			} else {
+				fmt.Fprintf(b, "%s  %s\n", indent, line)
+			}
+		}
+		childDepth++
+	}
+	for _, child := range block.Children {
+		writeGraphBlock(b, child, childDepth, tags)
+	}
+}
+
+// propertyTags はタグのプロパティ値（"a, [[b c]], #d" または配列）をタグにする
+func propertyTags(v any) []string {
+	var values []string
+	switch v := v.(type) {
+	case string:
+		values = strings.Split(v, ",")
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+	var tags []string
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		value = strings.TrimPrefix(value, "#")
+		value = strings.TrimSuffix(strings.TrimPrefix(value, "[["), "]]")
+		if value = strings.TrimSpace(value); value != "" {
+			tags = append(tags, value)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,64 @@
+package importer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseGraph_Logseq(t *testing.T) {
+	data := `{"version": 1, "blocks": [
+		{"page-name": "Auth design", "properties": {"tags": ["security", "[[jwt]]"]}, "children": [
+			{"content": "tags:: security, design\ntype:: decision", "children": []},
+			{"content": "Use JWT for sessions", "children": [
+				{"content": "Refresh tokens live 7 days\nrotated on use", "children": []}
+			]},
+			{"content": "", "children": [{"content": "orphan child", "children": []}]}
+		]},
+		{"page-name": "Empty page", "children": []}
+	]}`
+	docs, err := ParseGraph([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 1 {
+		t.Fatalf("expected 1 page, got %+v", docs)
+	}
+	d := docs[0]
+	if d.Source != "logseq:Auth design" || d.Title != "Auth design" || d.CreatedAt != "" {
+		t.Errorf("unexpected page: %+v", d)
+	}
+	if !reflect.DeepEqual(d.Tags, []string{FormatLogseq, "security", "jwt", "design"}) {
+		t.Errorf("unexpected tags: %v", d.Tags)
+	}
+	want := "- Use JWT for sessions\n  - Refresh tokens live 7 days\n    rotated on use\n- orphan child"
+	if d.Sections[0].Text != want {
+		t.Errorf("text =\n%s\nwant\n%s", d.Sections[0].Text, want)
+	}
+}
+
+func TestParseGraph_Roam(t *testing.T) {
+	data := `[
+		{"title": "March 1st, 2024", "create-time": 1709251200000, "children": [
+			{"string": "Tags:: [[standup]]", "uid": "a"},
+			{"string": "Decided to ship on Friday", "uid": "b", "children": [{"string": "QA signs off Thursday", "uid": "c"}]}
+		]}
+	]`
+	docs, err := ParseGraph([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 1 {
+		t.Fatalf("expected 1 page, got %+v", docs)
+	}
+	d := docs[0]
+	if d.Source != "roam:March 1st, 2024" || d.CreatedAt != "2024-03-01T00:00:00Z" || !reflect.DeepEqual(d.Tags, []string{FormatRoam, "standup"}) {
+		t.Errorf("unexpected page: %+v", d)
+	}
+	if want := "- Decided to ship on Friday\n  - QA signs off Thursday"; d.Sections[0].Text != want {
+		t.Errorf("text =\n%s\nwant\n%s", d.Sections[0].Text, want)
+	}
+
+	if _, err := ParseGraph([]byte("# not json")); err == nil {
+		t.Error("expected an error for a non-JSON file")
+	}
+}
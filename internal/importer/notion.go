@@ -0,0 +1,246 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/ingest"
+)
+
+// notionID はNotionがファイル名・ディレクトリ名の末尾に付けるページID（" 1a2b...（32桁の16進）"）
+var notionID = regexp.MustCompile(`\s+[0-9a-f]{32}$`)
+
+// notionProperty はページ先頭のプロパティ行（"Tags: a, b"）
+var notionProperty = regexp.MustCompile(`^([^:]{1,40}):\s*(.*)$`)
+
+// Notionのプロパティの日時の書式（タイムゾーンはUTCとみなす）
+var notionTimeLayouts = []string{
+	"January 2, 2006 3:04 PM",
+	"January 2, 2006",
+	"2006/01/02 15:04",
+	"2006/01/02",
+	time.RFC3339,
+	"2006-01-02",
+}
+
+// notionPage はエクスポート内のMarkdownファイル
+type notionPage struct {
+	path    string // エクスポート内のパス（スラッシュ区切り）
+	content []byte
+}
+
+// ReadNotion はNotionのMarkdownエクスポート（zip、またはzipを展開したディレクトリ）を読み込む
+// ページごとに1つのDocumentになり、CSV（データベースの一覧）や画像などの添付ファイルは取り込まない
+func ReadNotion(p string) ([]Document, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", p, err)
+	}
+
+	var pages []notionPage
+	if info.IsDir() {
+		pages, err = readNotionDir(p)
+	} else {
+		var r *zip.ReadCloser
+		if r, err = zip.OpenReader(p); err != nil {
+			return nil, fmt.Errorf("failed to open %s (expected a zip file or a directory): %w", p, err)
+		}
+		defer r.Close()
+		pages, err = readNotionZip(&r.Reader)
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(pages, func(i, j int) bool { return pages[i].path < pages[j].path })
+
+	docs := make([]Document, 0, len(pages))
+	seen := make(map[string]bool, len(pages))
+	for _, page := range pages {
+		doc, err := parseNotionPage(page.path, page.content)
+		if err != nil {
+			return nil, err
+		}
+		if doc == nil {
+			continue
+		}
+		// 同じ名前のページはIDを残したパスで区別する
+		if seen[doc.Source] {
+			doc.Source = "notion:" + page.path
+		}
+		seen[doc.Source] = true
+		docs = append(docs, *doc)
+	}
+	return docs, nil
+}
+
+// readNotionDir はディレクトリ以下のMarkdownファイルを読む
+func readNotionDir(dir string) ([]notionPage, error) {
+	var pages []notionPage
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !isMarkdown(p) {
+			return nil
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		pages = append(pages, notionPage{path: filepath.ToSlash(rel), content: content})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+	return pages, nil
+}
+
+// readNotionZip はzip内のMarkdownファイルを読む（大きなエクスポートを分割したzip内のzipも読む）
+func readNotionZip(r *zip.Reader) ([]notionPage, error) {
+	var pages []notionPage
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() || (!isMarkdown(f.Name) && !strings.EqualFold(path.Ext(f.Name), ".zip")) {
+			continue
+		}
+		content, err := readZipFile(f)
+		if err != nil {
+			return nil, err
+		}
+		if isMarkdown(f.Name) {
+			pages = append(pages, notionPage{path: f.Name, content: content})
+			continue
+		}
+		inner, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", f.Name, err)
+		}
+		innerPages, err := readNotionZip(inner)
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, innerPages...)
+	}
+	return pages, nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+	content, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+	}
+	return content, nil
+}
+
+func isMarkdown(name string) bool {
+	return strings.EqualFold(path.Ext(name), ".md")
+}
+
+// cleanNotionPath はパスの各要素からページIDを取り除く（"Wiki 1a2b.../Setup 3c4d....md" → "Wiki/Setup.md"）
+func cleanNotionPath(p string) string {
+	parts := strings.Split(p, "/")
+	for i, part := range parts {
+		ext := ""
+		if i == len(parts)-1 {
+			ext = path.Ext(part)
+			part = strings.TrimSuffix(part, ext)
+		}
+		parts[i] = notionID.ReplaceAllString(part, "") + ext
+	}
+	return strings.Join(parts, "/")
+}
+
+// parseNotionPage は1ページを読み込む（見出し以外の本文がなければnil）
+// タイトルは先頭のH1（なければファイル名）、タグとcreatedAtはH1直後のプロパティ行の
+// Tags / Created から取る（Notionはデータベースのページのプロパティをこの形式で書き出す）
+func parseNotionPage(p string, content []byte) (*Document, error) {
+	clean := cleanNotionPath(p)
+	doc, err := ingest.Parse(ingest.File{RelPath: clean, Kind: ingest.KindMarkdown}, content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", p, err)
+	}
+	if !hasBody(doc.Sections) {
+		return nil, nil
+	}
+
+	d := &Document{
+		Source:   "notion:" + clean,
+		Title:    doc.Title,
+		Tags:     []string{FormatNotion},
+		Sections: doc.Sections,
+	}
+	for key, value := range notionProperties(string(content)) {
+		switch strings.ToLower(key) {
+		case "tags", "tag":
+			for _, tag := range strings.Split(value, ",") {
+				d.Tags = appendTag(d.Tags, tag)
+			}
+		case "created", "created time", "date created":
+			for _, layout := range notionTimeLayouts {
+				if t, err := time.Parse(layout, value); err == nil {
+					d.CreatedAt = t.UTC().Format(time.RFC3339)
+					break
+				}
+			}
+		}
+	}
+	return d, nil
+}
+
+// hasBody は見出し以外の本文があるか（タイトルだけの空のページは取り込まない）
+func hasBody(secs []ingest.Section) bool {
+	for _, s := range secs {
+		rest := s.Text
+		if s.Heading != "" {
+			_, rest, _ = strings.Cut(s.Text, "\n")
+		}
+		if strings.TrimSpace(rest) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// notionProperties は先頭のH1の直後（空行1つを挟む）に続く "Key: Value" の行を返す
+func notionProperties(text string) map[string]string {
+	lines := strings.Split(strings.ReplaceAll(strings.TrimPrefix(text, "\ufeff"), "\r\n", "\n"), "\n")
+	i := 0
+	for i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+		i++
+	}
+	if i == len(lines) || !strings.HasPrefix(lines[i], "# ") {
+		return nil
+	}
+	i++
+	if i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+		i++
+	}
+	props := make(map[string]string)
+	for ; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+		m := notionProperty.FindStringSubmatch(lines[i])
+		if m == nil {
+			break
+		}
+		props[strings.TrimSpace(m[1])] = strings.TrimSpace(m[2])
+	}
+	return props
+}
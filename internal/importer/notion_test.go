@@ -0,0 +1,115 @@
+package importer
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var testNotionPages = map[string]string{
+	"Wiki 0123456789abcdef0123456789abcdef.md":                                                 "# Wiki\n\nTeam handbook.\n",
+	"Wiki 0123456789abcdef0123456789abcdef/Setup 89abcdef0123456789abcdef01234567.md":          "# Setup\n\nTags: onboarding, dev\nCreated: March 1, 2024 9:00 AM\nStatus: Done\n\nInstall Go.\n\n## Database\n\nRun migrations.\n",
+	"Wiki 0123456789abcdef0123456789abcdef/Setup 89abcdef0123456789abcdef01234567/diagram.png": "png",
+	"Wiki 0123456789abcdef0123456789abcdef/Tasks fedcba9876543210fedcba9876543210.csv":         "Name,Tags\n",
+	"Wiki 0123456789abcdef0123456789abcdef/Empty 00000000000000000000000000000000.md":          "# Empty\n",
+}
+
+func TestReadNotion(t *testing.T) {
+	dir := t.TempDir()
+	for rel, content := range testNotionPages {
+		p := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	docs, err := ReadNotion(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkNotionDocs(t, docs)
+
+	// zip（分割されたエクスポートのzip内のzipを含む）
+	zipPath := filepath.Join(t.TempDir(), "export.zip")
+	writeZip(t, zipPath, map[string][]byte{"Export-Part-1.zip": zipBytes(t, testNotionPages)})
+	docs, err = ReadNotion(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkNotionDocs(t, docs)
+
+	if _, err := ReadNotion(filepath.Join(dir, "Wiki 0123456789abcdef0123456789abcdef.md")); err == nil {
+		t.Error("expected an error for a markdown file")
+	}
+}
+
+func checkNotionDocs(t *testing.T, docs []Document) {
+	t.Helper()
+	if len(docs) != 2 {
+		t.Fatalf("expected 2 pages, got %+v", docs)
+	}
+	if docs[0].Source != "notion:Wiki.md" || docs[0].Title != "Wiki" || !reflect.DeepEqual(docs[0].Tags, []string{FormatNotion}) {
+		t.Errorf("unexpected page: %+v", docs[0])
+	}
+	d := docs[1]
+	if d.Source != "notion:Wiki/Setup.md" || d.Title != "Setup" || d.CreatedAt != "2024-03-01T09:00:00Z" {
+		t.Errorf("unexpected page: %+v", d)
+	}
+	if !reflect.DeepEqual(d.Tags, []string{FormatNotion, "onboarding", "dev"}) {
+		t.Errorf("unexpected tags: %v", d.Tags)
+	}
+	if chunks := d.Chunks(1500, 200); len(chunks) != 2 || chunks[1].Title != "Setup > Database" {
+		t.Errorf("expected the page to be split by headings, got %+v", chunks)
+	}
+}
+
+func TestCleanNotionPath(t *testing.T) {
+	got := cleanNotionPath("Wiki 0123456789abcdef0123456789abcdef/Setup guide 89abcdef0123456789abcdef01234567.md")
+	if got != "Wiki/Setup guide.md" {
+		t.Errorf("cleanNotionPath = %q", got)
+	}
+	if got := cleanNotionPath("Notes/Plain.md"); got != "Notes/Plain.md" {
+		t.Errorf("cleanNotionPath = %q", got)
+	}
+}
+
+func zipBytes(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "inner.zip")
+	contents := make(map[string][]byte, len(files))
+	for name, content := range files {
+		contents[name] = []byte(content)
+	}
+	writeZip(t, p, contents)
+	data, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func writeZip(t *testing.T, p string, files map[string][]byte) {
+	t.Helper()
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w := zip.NewWriter(f)
+	for name, content := range files {
+		fw, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fw.Write(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
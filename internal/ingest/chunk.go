@@ -0,0 +1,109 @@
+package ingest
+
+import (
+	"strings"
+	"unicode"
+)
+
+// チャンク分割のデフォルト値（文字数=rune数）
+const (
+	DefaultChunkSize    = 1500
+	DefaultChunkOverlap = 200
+)
+
+// Chunk はノート1件分に分割された本文
+type Chunk struct {
+	Index int // Document内での通し番号（0始まり）
+	Title string
+	Text  string
+	Tags  []string // DocumentのTags
+}
+
+// Chunks はDocumentの各セクションをsize文字以下のチャンクに分割する
+// タイトルはセクション見出しがあれば "Title > Heading" になる
+func (d *Document) Chunks(size, overlap int) []Chunk {
+	var chunks []Chunk
+	for _, s := range d.Sections {
+		title := d.Title
+		if s.Heading != "" && s.Heading != d.Title {
+			title = d.Title + " > " + s.Heading
+		}
+		for _, text := range SplitText(s.Text, size, overlap) {
+			chunks = append(chunks, Chunk{Index: len(chunks), Title: title, Text: text, Tags: d.Tags})
+		}
+	}
+	return chunks
+}
+
+// SplitText はtextをsize文字以下のチャンクに分割する
+// 区切りは段落・改行・空白の順に後方で探し、隣接チャンクはoverlap文字程度重複させる
+// サイズはrune単位なのでマルチバイト文字の途中で切れることはない
+func SplitText(text string, size, overlap int) []string {
+	r := []rune(strings.TrimSpace(text))
+	if len(r) == 0 {
+		return nil
+	}
+	if size <= 0 || len(r) <= size {
+		return []string{string(r)}
+	}
+	if overlap < 0 || overlap >= size {
+		overlap = 0
+	}
+
+	var chunks []string
+	for start := 0; start < len(r); {
+		end := start + size
+		if end >= len(r) {
+			if s := strings.TrimSpace(string(r[start:])); s != "" {
+				chunks = append(chunks, s)
+			}
+			break
+		}
+
+		cut := breakPoint(r, start+size/2, end)
+		if s := strings.TrimSpace(string(r[start:cut])); s != "" {
+			chunks = append(chunks, s)
+		}
+
+		next := cut - overlap
+		if next <= start {
+			next = cut
+		}
+		start = alignWordStart(r, next, cut)
+	}
+	return chunks
+}
+
+// breakPoint は(lo, end]の範囲で最も後ろにある区切り位置を返す（段落 > 改行 > 空白 > end）
+func breakPoint(r []rune, lo, end int) int {
+	for i := end; i > lo; i-- {
+		if r[i-1] == '\n' && i >= 2 && r[i-2] == '\n' {
+			return i
+		}
+	}
+	for i := end; i > lo; i-- {
+		if r[i-1] == '\n' {
+			return i
+		}
+	}
+	for i := end; i > lo; i-- {
+		if unicode.IsSpace(r[i-1]) {
+			return i
+		}
+	}
+	return end
+}
+
+// alignWordStart は重複部分が単語の途中から始まらないよう、posを次の空白の直後まで進める
+// limitまでに空白がなければposをそのまま返す
+func alignWordStart(r []rune, pos, limit int) int {
+	if pos == 0 || unicode.IsSpace(r[pos-1]) {
+		return pos
+	}
+	for i := pos; i < limit; i++ {
+		if unicode.IsSpace(r[i]) {
+			return i + 1
+		}
+	}
+	return pos
+}
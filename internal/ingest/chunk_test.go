@@ -0,0 +1,108 @@
+package ingest
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitText_Short(t *testing.T) {
+	chunks := SplitText("  short text  ", 100, 10)
+	if len(chunks) != 1 || chunks[0] != "short text" {
+		t.Errorf("unexpected chunks: %q", chunks)
+	}
+	if chunks := SplitText("   ", 100, 10); chunks != nil {
+		t.Errorf("expected no chunks, got %q", chunks)
+	}
+}
+
+func TestSplitText_ParagraphBoundary(t *testing.T) {
+	text := strings.Repeat("a", 30) + "\n\n" + strings.Repeat("b", 30) + "\n\n" + strings.Repeat("c", 30)
+	chunks := SplitText(text, 70, 0)
+
+	if len(chunks) != 2 {
+		t.Fatalf("expected 2 chunks, got %d: %q", len(chunks), chunks)
+	}
+	if chunks[0] != strings.Repeat("a", 30)+"\n\n"+strings.Repeat("b", 30) {
+		t.Errorf("expected first chunk to end at paragraph boundary, got %q", chunks[0])
+	}
+	if chunks[1] != strings.Repeat("c", 30) {
+		t.Errorf("unexpected second chunk %q", chunks[1])
+	}
+}
+
+func TestSplitText_Overlap(t *testing.T) {
+	words := make([]string, 100)
+	for i := range words {
+		words[i] = "word"
+	}
+	text := strings.Join(words, " ")
+	chunks := SplitText(text, 50, 15)
+
+	if len(chunks) < 2 {
+		t.Fatalf("expected multiple chunks, got %d", len(chunks))
+	}
+	for i, c := range chunks {
+		if utf8.RuneCountInString(c) > 50 {
+			t.Errorf("chunk %d exceeds size: %d", i, utf8.RuneCountInString(c))
+		}
+		if strings.HasPrefix(c, "ord") || strings.HasPrefix(c, "rd") || strings.HasPrefix(c, "d ") {
+			t.Errorf("chunk %d starts mid-word: %q", i, c)
+		}
+	}
+
+	// 重複により合計文字数は元の文字数より多い
+	total := 0
+	for _, c := range chunks {
+		total += len(c)
+	}
+	if total <= len(text) {
+		t.Errorf("expected overlapping chunks, total %d <= %d", total, len(text))
+	}
+}
+
+func TestSplitText_MultiByte(t *testing.T) {
+	text := strings.Repeat("日本語のテキスト", 50)
+	chunks := SplitText(text, 64, 8)
+
+	if len(chunks) < 2 {
+		t.Fatalf("expected multiple chunks, got %d", len(chunks))
+	}
+	for i, c := range chunks {
+		if !utf8.ValidString(c) {
+			t.Errorf("chunk %d is not valid UTF-8", i)
+		}
+		if utf8.RuneCountInString(c) > 64 {
+			t.Errorf("chunk %d exceeds size", i)
+		}
+	}
+	if !strings.HasSuffix(text, chunks[len(chunks)-1]) {
+		t.Error("expected last chunk to cover end of text")
+	}
+}
+
+func TestDocument_Chunks(t *testing.T) {
+	doc := &Document{
+		Title: "Guide",
+		Sections: []Section{
+			{Heading: "Guide", Text: "intro"},
+			{Heading: "Install", Text: strings.Repeat("x ", 40)},
+		},
+	}
+
+	chunks := doc.Chunks(50, 0)
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %d", len(chunks))
+	}
+	for i, c := range chunks {
+		if c.Index != i {
+			t.Errorf("expected index %d, got %d", i, c.Index)
+		}
+	}
+	if chunks[0].Title != "Guide" {
+		t.Errorf("expected title Guide, got %q", chunks[0].Title)
+	}
+	if chunks[1].Title != "Guide > Install" || chunks[2].Title != "Guide > Install" {
+		t.Errorf("expected section titles, got %q / %q", chunks[1].Title, chunks[2].Title)
+	}
+}
@@ -0,0 +1,145 @@
+package ingest
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FrontMatter はMarkdown先頭のYAMLフロントマターから読み取ったノートの属性
+type FrontMatter struct {
+	Title     string
+	Tags      []string
+	Source    string
+	CreatedAt string // RFC3339に正規化（解釈できない値はそのまま）
+	ID        string // ノートID（vault同期で書き出したファイルのみ）
+	GroupID   string // グループID（vault同期で書き出したファイルのみ）
+}
+
+// createdAtの書式（タイムゾーンのないものはUTCとみなす）
+var frontMatterTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// ParseFrontMatter は先頭の "---" で囲まれたフロントマターを解析し、属性と残りの本文を返す
+// フロントマターがなければnilとtextをそのまま返す
+// YAMLのうち title / tags / source / createdAt（created_at, date）/ id / group のスカラー値と
+// tagsの配列（"[a, b]"、"- a" の行、カンマ区切り）だけを扱い、それ以外のキーは無視する
+func ParseFrontMatter(text string) (*FrontMatter, string) {
+	front, body, ok := splitFrontMatter(text)
+	if !ok {
+		return nil, text
+	}
+
+	fm := &FrontMatter{}
+	key := ""
+	for _, line := range strings.Split(front, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		// "- item" は直前のキーの配列要素
+		if trimmed == "-" || strings.HasPrefix(trimmed, "- ") {
+			if tag := frontMatterValue(trimmed[1:]); key == "tags" && tag != "" {
+				fm.Tags = append(fm.Tags, tag)
+			}
+			continue
+		}
+		// インデントされた行（ネストしたマップ）は対象外
+		if line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+		k, v, found := strings.Cut(line, ":")
+		if !found {
+			key = ""
+			continue
+		}
+		key = strings.TrimSpace(k)
+		switch key {
+		case "title":
+			fm.Title = frontMatterValue(v)
+		case "source":
+			fm.Source = frontMatterValue(v)
+		case "id":
+			fm.ID = frontMatterValue(v)
+		case "group":
+			fm.GroupID = frontMatterValue(v)
+		case "tags":
+			fm.Tags = appendTags(fm.Tags, strings.TrimSpace(v))
+		case "createdAt", "created_at", "date":
+			fm.CreatedAt = normalizeFrontMatterTime(frontMatterValue(v))
+		}
+	}
+	return fm, body
+}
+
+// splitFrontMatter は先頭の "---" で囲まれたフロントマターと残りの本文を返す
+func splitFrontMatter(text string) (front, body string, ok bool) {
+	if !strings.HasPrefix(text, "---\n") {
+		return "", text, false
+	}
+	end := strings.Index(text[4:], "\n---")
+	if end < 0 {
+		return "", text, false
+	}
+	front = text[4 : 4+end]
+	rest := text[4+end+4:]
+	if i := strings.IndexByte(rest, '\n'); i >= 0 {
+		// "---" の後ろに続く同一行の文字は閉じ区切りとみなさない
+		if strings.TrimSpace(rest[:i]) != "" {
+			return "", text, false
+		}
+		return front, rest[i+1:], true
+	}
+	if strings.TrimSpace(rest) != "" {
+		return "", text, false
+	}
+	return front, "", true
+}
+
+// frontMatterValue はスカラー値の前後の空白・引用符と行末コメントを取り除く
+func frontMatterValue(v string) string {
+	v = strings.TrimSpace(v)
+	if len(v) >= 2 {
+		switch {
+		case v[0] == '"' && v[len(v)-1] == '"':
+			if s, err := strconv.Unquote(v); err == nil {
+				return s
+			}
+			return v[1 : len(v)-1]
+		case v[0] == '\'' && v[len(v)-1] == '\'':
+			return strings.ReplaceAll(v[1:len(v)-1], "''", "'")
+		}
+	}
+	if i := strings.Index(v, " #"); i >= 0 {
+		v = strings.TrimSpace(v[:i])
+	}
+	return v
+}
+
+// appendTags はフロー配列（"[a, b]"）またはカンマ区切りのタグを追加する
+func appendTags(tags []string, v string) []string {
+	v = strings.TrimSpace(v)
+	if strings.HasPrefix(v, "[") && strings.HasSuffix(v, "]") {
+		v = v[1 : len(v)-1]
+	}
+	for _, tag := range strings.Split(v, ",") {
+		if tag = frontMatterValue(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// normalizeFrontMatterTime は日時をUTCのRFC3339に揃える（解釈できなければそのまま返す）
+func normalizeFrontMatterTime(v string) string {
+	for _, layout := range frontMatterTimeLayouts {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t.UTC().Format(time.RFC3339)
+		}
+	}
+	return v
+}
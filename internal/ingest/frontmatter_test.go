@@ -0,0 +1,62 @@
+package ingest
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFrontMatter(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		want     *FrontMatter
+		wantBody string
+	}{
+		{
+			name:     "scalars and flow tags",
+			text:     "---\ntitle: \"Deploy: notes\"\ntags: [ops, 'release']\nsource: https://example.com/a # origin\ndate: 2024-03-01\nauthor: someone\n---\nbody\n",
+			want:     &FrontMatter{Title: "Deploy: notes", Tags: []string{"ops", "release"}, Source: "https://example.com/a", CreatedAt: "2024-03-01T00:00:00Z"},
+			wantBody: "body\n",
+		},
+		{
+			name:     "block tags and nested map",
+			text:     "---\ntags:\n  - a\n  - b\nextra:\n  title: nested\ncreatedAt: 2024-03-01T09:00:00+09:00\n---\nbody",
+			want:     &FrontMatter{Tags: []string{"a", "b"}, CreatedAt: "2024-03-01T00:00:00Z"},
+			wantBody: "body",
+		},
+		{
+			name:     "comma separated tags and unparsable date",
+			text:     "---\ntags: a, b\ncreated_at: yesterday\n---",
+			want:     &FrontMatter{Tags: []string{"a", "b"}, CreatedAt: "yesterday"},
+			wantBody: "",
+		},
+		{
+			name:     "vault note",
+			text:     "---\nid: 0b7e\ntitle: Auth\ngroup: design\n---\n\nbody\n",
+			want:     &FrontMatter{Title: "Auth", ID: "0b7e", GroupID: "design"},
+			wantBody: "\nbody\n",
+		},
+		{
+			name:     "no front matter",
+			text:     "# Title\n---\n",
+			wantBody: "# Title\n---\n",
+		},
+		{
+			name:     "unclosed",
+			text:     "---\ntitle: x\nbody",
+			wantBody: "---\ntitle: x\nbody",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, body := ParseFrontMatter(tt.text)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseFrontMatter() = %+v, want %+v", got, tt.want)
+			}
+			if body != tt.wantBody {
+				t.Errorf("body = %q, want %q", body, tt.wantBody)
+			}
+		})
+	}
+}
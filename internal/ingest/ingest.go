@@ -0,0 +1,266 @@
+// Package ingest collects files, parses them and splits their content into note-sized chunks.
+package ingest
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Kind はファイルの種類
+type Kind string
+
+const (
+	KindMarkdown Kind = "markdown"
+	KindText     Kind = "text"
+	KindCode     Kind = "code"
+)
+
+// ErrBinary はバイナリ（またはUTF-8でない）ファイルを表す
+var ErrBinary = errors.New("binary or non UTF-8 file")
+
+// 拡張子ごとの種類（include未指定時はここにある拡張子のみ取り込む）
+var kindsByExt = map[string]Kind{
+	".md":       KindMarkdown,
+	".markdown": KindMarkdown,
+	".mdx":      KindMarkdown,
+	".txt":      KindText,
+	".text":     KindText,
+	".rst":      KindText,
+	".adoc":     KindText,
+	".org":      KindText,
+	".go":       KindCode,
+	".py":       KindCode,
+	".js":       KindCode,
+	".jsx":      KindCode,
+	".ts":       KindCode,
+	".tsx":      KindCode,
+	".java":     KindCode,
+	".kt":       KindCode,
+	".rb":       KindCode,
+	".rs":       KindCode,
+	".c":        KindCode,
+	".h":        KindCode,
+	".cpp":      KindCode,
+	".hpp":      KindCode,
+	".cs":       KindCode,
+	".swift":    KindCode,
+	".php":      KindCode,
+	".sh":       KindCode,
+	".sql":      KindCode,
+	".yaml":     KindCode,
+	".yml":      KindCode,
+	".toml":     KindCode,
+	".json":     KindCode,
+}
+
+// ディレクトリ走査時に常にスキップするディレクトリ名（ドットで始まるものもスキップ）
+var skipDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+}
+
+// File は取り込み対象のファイル
+type File struct {
+	Path    string // 読み込みに使うパス
+	RelPath string // Base基準のスラッシュ区切り相対パス（noteのsourceに使う）
+	Kind    Kind
+}
+
+// WalkOptions はCollectのオプション
+type WalkOptions struct {
+	Base    string   // RelPathの基準ディレクトリ（空ならカレントディレクトリ）
+	Include []string // RelPathに対するglob（いずれかに一致したものだけ取り込む）
+	Exclude []string // RelPathに対するglob（一致したものは取り込まない）
+}
+
+// Collect はpathsで指定されたファイル・ディレクトリ・globパターンから取り込み対象を集める
+// ディレクトリは再帰的に走査し、globパターンは "**" を含めてシェル展開なしで解釈する
+// 明示的に指定したファイルはinclude/拡張子の判定を行わない（excludeは適用する）
+// 結果はRelPath順で重複を含まない
+func Collect(paths []string, opts WalkOptions) ([]File, error) {
+	base := opts.Base
+	if base == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get working directory: %w", err)
+		}
+		base = wd
+	}
+	base, err := filepath.Abs(base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve base directory: %w", err)
+	}
+
+	c := &collector{base: base, opts: opts, seen: make(map[string]bool)}
+	for _, p := range paths {
+		if err := c.add(p); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(c.files, func(i, j int) bool { return c.files[i].RelPath < c.files[j].RelPath })
+	return c.files, nil
+}
+
+type collector struct {
+	base  string
+	opts  WalkOptions
+	seen  map[string]bool
+	files []File
+}
+
+// add はpathsの1要素を処理する
+func (c *collector) add(p string) error {
+	if hasMeta(p) {
+		pattern := filepath.ToSlash(filepath.Clean(p))
+		return c.walk(Root(p), func(name string) bool {
+			return Match(pattern, filepath.ToSlash(name))
+		})
+	}
+
+	info, err := os.Stat(p)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", p, err)
+	}
+	if info.IsDir() {
+		return c.walk(p, func(string) bool { return true })
+	}
+	return c.addFile(p, true)
+}
+
+// walk はrootを再帰的に走査し、matchに一致したファイルを追加する
+func (c *collector) walk(root string, match func(name string) bool) error {
+	return filepath.WalkDir(root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name != root && (strings.HasPrefix(d.Name(), ".") || skipDirs[d.Name()]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || !match(filepath.Clean(name)) {
+			return nil
+		}
+		return c.addFile(name, false)
+	})
+}
+
+// addFile はフィルタを適用してファイルを追加する
+func (c *collector) addFile(name string, explicit bool) error {
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", name, err)
+	}
+	if c.seen[abs] {
+		return nil
+	}
+
+	rel, err := filepath.Rel(c.base, abs)
+	if err != nil {
+		rel = abs
+	}
+	rel = filepath.ToSlash(rel)
+
+	if matchAny(c.opts.Exclude, rel) {
+		return nil
+	}
+
+	kind, known := kindsByExt[strings.ToLower(filepath.Ext(name))]
+	if !explicit {
+		if len(c.opts.Include) > 0 {
+			if !matchAny(c.opts.Include, rel) {
+				return nil
+			}
+		} else if !known {
+			return nil
+		}
+	}
+	if !known {
+		kind = KindText
+	}
+
+	c.seen[abs] = true
+	c.files = append(c.files, File{Path: name, RelPath: rel, Kind: kind})
+	return nil
+}
+
+// Match はスラッシュ区切りのnameがglobパターンに一致するか判定する
+// "**" は0個以上のディレクトリに一致する
+// "/" を含まないパターンはファイル名（最後の要素）に対して判定する
+func Match(pattern, name string) bool {
+	pattern = strings.TrimPrefix(pattern, "./")
+	name = strings.TrimPrefix(name, "./")
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(name))
+		return ok
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(rest, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if Match(p, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasMeta(p string) bool {
+	return strings.ContainsAny(p, "*?[")
+}
+
+// Root はCollectに渡すパスの走査起点を返す
+// globパターンならメタ文字を含まない先頭ディレクトリ部分、それ以外はパスそのもの
+func Root(p string) string {
+	if !hasMeta(p) {
+		return filepath.Clean(p)
+	}
+	pattern := filepath.ToSlash(filepath.Clean(p))
+	segments := strings.Split(pattern, "/")
+	var root []string
+	for _, s := range segments[:len(segments)-1] {
+		if hasMeta(s) {
+			break
+		}
+		root = append(root, s)
+	}
+	if len(root) == 0 {
+		return "."
+	}
+	if root[0] == "" {
+		// 絶対パス
+		return "/" + path.Join(root[1:]...)
+	}
+	return filepath.FromSlash(path.Join(root...))
+}
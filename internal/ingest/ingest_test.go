@@ -0,0 +1,173 @@
+package ingest
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"*.md", "docs/a/readme.md", true},
+		{"*.md", "docs/a/readme.txt", false},
+		{"docs/*.md", "docs/readme.md", true},
+		{"docs/*.md", "docs/a/readme.md", false},
+		{"docs/**/*.md", "docs/readme.md", true},
+		{"docs/**/*.md", "docs/a/b/readme.md", true},
+		{"./docs/**/*.md", "docs/a/readme.md", true},
+		{"**/drafts/**", "docs/drafts/wip.md", true},
+		{"**/drafts/**", "docs/final/done.md", false},
+		{"docs/**", "docs/a/b.md", true},
+		{"src/**/*.md", "docs/a.md", false},
+	}
+
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRoot(t *testing.T) {
+	tests := map[string]string{
+		"./docs/**/*.md": "docs",
+		"docs/a/*.md":    "docs/a",
+		"*.md":           ".",
+		"**/x/*.md":      ".",
+		"docs":           "docs",
+		"./docs/a.md":    filepath.FromSlash("docs/a.md"),
+		"/abs/dir/*.txt": "/abs/dir",
+	}
+	for p, want := range tests {
+		if got := filepath.ToSlash(Root(p)); got != filepath.ToSlash(want) {
+			t.Errorf("Root(%q) = %q, want %q", p, got, want)
+		}
+	}
+}
+
+// writeFiles はbase以下にファイルを作成する
+func writeFiles(t *testing.T, base string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(base, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func relPaths(files []File) []string {
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.RelPath
+	}
+	return paths
+}
+
+func TestCollect(t *testing.T) {
+	base := t.TempDir()
+	writeFiles(t, base, map[string]string{
+		"docs/readme.md":         "# Readme",
+		"docs/guide/setup.md":    "# Setup",
+		"docs/guide/notes.txt":   "notes",
+		"docs/drafts/wip.md":     "# WIP",
+		"docs/image.png":         "png",
+		"docs/.hidden/secret.md": "# Secret",
+		"docs/node_modules/x.md": "# X",
+		"src/main.go":            "package main",
+		"src/unknown.ext":        "data",
+	})
+	t.Chdir(base)
+
+	tests := []struct {
+		name  string
+		paths []string
+		opts  WalkOptions
+		want  []string
+	}{
+		{
+			name:  "directory",
+			paths: []string{"docs"},
+			want:  []string{"docs/drafts/wip.md", "docs/guide/notes.txt", "docs/guide/setup.md", "docs/readme.md"},
+		},
+		{
+			name:  "recursive glob",
+			paths: []string{"./docs/**/*.md"},
+			want:  []string{"docs/drafts/wip.md", "docs/guide/setup.md", "docs/readme.md"},
+		},
+		{
+			name:  "exclude",
+			paths: []string{"docs"},
+			opts:  WalkOptions{Exclude: []string{"**/drafts/**", "*.txt"}},
+			want:  []string{"docs/guide/setup.md", "docs/readme.md"},
+		},
+		{
+			name:  "include",
+			paths: []string{"."},
+			opts:  WalkOptions{Include: []string{"*.go", "*.ext"}},
+			want:  []string{"src/main.go", "src/unknown.ext"},
+		},
+		{
+			name:  "explicit file and duplicates",
+			paths: []string{"src/unknown.ext", "docs/readme.md", "docs/*.md"},
+			want:  []string{"docs/readme.md", "src/unknown.ext"},
+		},
+		{
+			name:  "base",
+			paths: []string{"docs/guide"},
+			opts:  WalkOptions{Base: "docs"},
+			want:  []string{"guide/notes.txt", "guide/setup.md"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := Collect(tt.paths, tt.opts)
+			if err != nil {
+				t.Fatalf("Collect failed: %v", err)
+			}
+			if got := relPaths(files); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollect_Kind(t *testing.T) {
+	base := t.TempDir()
+	writeFiles(t, base, map[string]string{
+		"a.md":    "# A",
+		"b.txt":   "b",
+		"c.go":    "package c",
+		"d.other": "d",
+	})
+	t.Chdir(base)
+
+	files, err := Collect([]string{"a.md", "b.txt", "c.go", "d.other"}, WalkOptions{})
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+
+	want := []Kind{KindMarkdown, KindText, KindCode, KindText}
+	for i, f := range files {
+		if f.Kind != want[i] {
+			t.Errorf("%s: expected kind %s, got %s", f.RelPath, want[i], f.Kind)
+		}
+	}
+}
+
+func TestCollect_NotFound(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	if _, err := Collect([]string{"missing"}, WalkOptions{}); err == nil {
+		t.Error("expected error for missing path")
+	}
+}
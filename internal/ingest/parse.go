@@ -0,0 +1,128 @@
+package ingest
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// Section は見出し単位に分割された本文
+type Section struct {
+	Heading string // セクションの見出し（先頭セクションやテキスト・コードでは空）
+	Text    string
+}
+
+// Document はパース済みのファイル
+type Document struct {
+	Title    string
+	Kind     Kind
+	Sections []Section
+	// FrontMatter はMarkdown先頭のフロントマター（なければnil）
+	FrontMatter *FrontMatter
+	// Tags は各チャンクに付与するタグ
+	Tags []string
+}
+
+// Parse はファイル内容をDocumentに変換する
+// Markdownは先頭のH1（なければファイル名）をタイトルとし、H1/H2見出しでセクションに分割する
+// テキスト・コードはファイル名をタイトルとする1セクションになる
+func Parse(f File, content []byte) (*Document, error) {
+	if bytes.IndexByte(content, 0) >= 0 || !utf8.Valid(content) {
+		return nil, ErrBinary
+	}
+
+	text := strings.ReplaceAll(string(content), "\r\n", "\n")
+	text = strings.TrimPrefix(text, "\ufeff")
+
+	doc := &Document{
+		Title: strings.TrimSuffix(filepath.Base(f.RelPath), filepath.Ext(f.RelPath)),
+		Kind:  f.Kind,
+	}
+
+	if f.Kind != KindMarkdown {
+		if s := strings.TrimSpace(text); s != "" {
+			doc.Sections = []Section{{Text: s}}
+		}
+		return doc, nil
+	}
+
+	fm, body := ParseFrontMatter(text)
+	doc.FrontMatter = fm
+	title, sections := parseMarkdown(body)
+	if title != "" {
+		doc.Title = title
+	}
+	doc.Sections = sections
+	return doc, nil
+}
+
+// parseMarkdown は最初のH1とH1/H2単位のセクションを返す
+// フェンスドコードブロック内の "#" は見出しとして扱わない
+func parseMarkdown(text string) (string, []Section) {
+	var (
+		title    string
+		sections []Section
+		current  Section
+		body     strings.Builder
+		fence    string
+	)
+
+	flush := func() {
+		current.Text = strings.TrimSpace(body.String())
+		if current.Text != "" {
+			sections = append(sections, current)
+		}
+		body.Reset()
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+		} else if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+		} else if level, heading := parseHeading(line); level > 0 {
+			if level == 1 && title == "" {
+				title = heading
+			}
+			if level <= 2 {
+				flush()
+				current = Section{Heading: heading}
+			}
+		}
+
+		body.WriteString(line)
+		body.WriteByte('\n')
+	}
+	flush()
+
+	return title, sections
+}
+
+// parseHeading はATX見出し（"# title"）のレベルと見出し文字列を返す（見出しでなければ0）
+func parseHeading(line string) (int, string) {
+	if strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t") {
+		return 0, ""
+	}
+	line = strings.TrimSpace(line)
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 {
+		return 0, ""
+	}
+	rest := line[level:]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return 0, ""
+	}
+	heading := strings.TrimSpace(strings.TrimRight(strings.TrimSpace(rest), "#"))
+	if heading == "" {
+		return 0, ""
+	}
+	return level, heading
+}
@@ -0,0 +1,100 @@
+package ingest
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParse_Markdown(t *testing.T) {
+	content := `---
+title: ignored
+---
+intro line
+
+# Guide
+
+Overview text.
+
+## Install
+
+` + "```sh" + `
+# not a heading
+go install
+` + "```" + `
+
+### Details
+
+More details.
+
+## Usage
+Run it.
+`
+	doc, err := Parse(File{RelPath: "docs/guide.md", Kind: KindMarkdown}, []byte(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if doc.Title != "Guide" {
+		t.Errorf("expected title Guide, got %q", doc.Title)
+	}
+
+	var headings []string
+	for _, s := range doc.Sections {
+		headings = append(headings, s.Heading)
+	}
+	if want := []string{"", "Guide", "Install", "Usage"}; !reflect.DeepEqual(headings, want) {
+		t.Fatalf("expected headings %v, got %v", want, headings)
+	}
+	if doc.Sections[0].Text != "intro line" {
+		t.Errorf("expected front matter to be stripped, got %q", doc.Sections[0].Text)
+	}
+	if doc.FrontMatter == nil || doc.FrontMatter.Title != "ignored" {
+		t.Errorf("expected parsed front matter, got %+v", doc.FrontMatter)
+	}
+	if want := "## Install\n\n```sh\n# not a heading\ngo install\n```\n\n### Details\n\nMore details."; doc.Sections[2].Text != want {
+		t.Errorf("unexpected Install section: %q", doc.Sections[2].Text)
+	}
+}
+
+func TestParse_MarkdownWithoutH1(t *testing.T) {
+	doc, err := Parse(File{RelPath: "notes/todo.md", Kind: KindMarkdown}, []byte("## Later\n- item\n"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if doc.Title != "todo" {
+		t.Errorf("expected file name title, got %q", doc.Title)
+	}
+}
+
+func TestParse_Code(t *testing.T) {
+	content := "// # not a heading\r\npackage main\r\n"
+	doc, err := Parse(File{RelPath: "cmd/main.go", Kind: KindCode}, []byte(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if doc.Title != "main" {
+		t.Errorf("expected title main, got %q", doc.Title)
+	}
+	if len(doc.Sections) != 1 || doc.Sections[0].Text != "// # not a heading\npackage main" {
+		t.Errorf("unexpected sections: %+v", doc.Sections)
+	}
+}
+
+func TestParse_Binary(t *testing.T) {
+	for _, content := range [][]byte{{'a', 0, 'b'}, {0xff, 0xfe, 0xfd}} {
+		if _, err := Parse(File{RelPath: "x.txt", Kind: KindText}, content); !errors.Is(err, ErrBinary) {
+			t.Errorf("expected ErrBinary for %v, got %v", content, err)
+		}
+	}
+}
+
+func TestParse_Empty(t *testing.T) {
+	doc, err := Parse(File{RelPath: "empty.md", Kind: KindMarkdown}, []byte("\n\n"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(doc.Chunks(DefaultChunkSize, DefaultChunkOverlap)) != 0 {
+		t.Error("expected no chunks for empty document")
+	}
+}
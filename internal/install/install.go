@@ -0,0 +1,241 @@
+// Package install registers mcp-memory in MCP client configuration files.
+package install
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+)
+
+// Target はMCPクライアントの種類
+type Target string
+
+// 対応するMCPクライアント
+const (
+	TargetClaudeDesktop Target = "claude-desktop"
+	TargetClaudeCode    Target = "claude-code"
+	TargetCursor        Target = "cursor"
+	TargetMCPJSON       Target = "mcp-json" // 任意の mcp.json（--file で指定）
+)
+
+// Scope は設定の適用範囲（claude-code / cursor のみ）
+type Scope string
+
+// 設定の適用範囲
+const (
+	ScopeUser    Scope = "user"    // ユーザー全体
+	ScopeProject Scope = "project" // カレントプロジェクトのみ
+)
+
+// serversKey はサーバー定義を格納するキー（各クライアント共通）
+const serversKey = "mcpServers"
+
+var (
+	// ErrUnknownTarget は未対応のクライアント
+	ErrUnknownTarget = errors.New("unknown target")
+	// ErrEntryExists は同名の異なるエントリが登録済み
+	ErrEntryExists = errors.New("server entry already exists")
+)
+
+// Entry はMCPサーバーの起動定義
+type Entry struct {
+	Type    string            `json:"type,omitempty"`
+	Command string            `json:"command"`
+	Args    []string          `json:"args"`
+	Env     map[string]string `json:"env,omitempty"`
+}
+
+// Result はInstallの結果
+type Result string
+
+// Installの結果
+const (
+	ResultCreated   Result = "created"
+	ResultUpdated   Result = "updated"
+	ResultUnchanged Result = "unchanged"
+)
+
+// ParseTarget は文字列をTargetに変換する
+func ParseTarget(s string) (Target, error) {
+	switch t := Target(s); t {
+	case TargetClaudeDesktop, TargetClaudeCode, TargetCursor, TargetMCPJSON:
+		return t, nil
+	default:
+		return "", fmt.Errorf("%w: %s (must be claude-desktop, claude-code, cursor or mcp-json)", ErrUnknownTarget, s)
+	}
+}
+
+// NewEntry はtarget向けのエントリを作成する（Claude Codeはtype: stdioが必要）
+func NewEntry(target Target, command string, args []string, env map[string]string) *Entry {
+	e := &Entry{Command: command, Args: args, Env: env}
+	if target == TargetClaudeCode {
+		e.Type = "stdio"
+	}
+	return e
+}
+
+// ConfigPath はtargetの設定ファイルパスを返す
+// projectDirはScopeProjectの場合の基準ディレクトリ
+func ConfigPath(target Target, scope Scope, projectDir string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return configPath(target, scope, projectDir, runtime.GOOS, home, os.Getenv)
+}
+
+// configPath はOSごとの設定ファイルパスを返す
+func configPath(target Target, scope Scope, projectDir, goos, home string, getenv func(string) string) (string, error) {
+	switch target {
+	case TargetClaudeDesktop:
+		switch goos {
+		case "darwin":
+			return filepath.Join(home, "Library", "Application Support", "Claude", "claude_desktop_config.json"), nil
+		case "windows":
+			appData := getenv("APPDATA")
+			if appData == "" {
+				appData = filepath.Join(home, "AppData", "Roaming")
+			}
+			return filepath.Join(appData, "Claude", "claude_desktop_config.json"), nil
+		default:
+			configHome := getenv("XDG_CONFIG_HOME")
+			if configHome == "" || !filepath.IsAbs(configHome) {
+				configHome = filepath.Join(home, ".config")
+			}
+			return filepath.Join(configHome, "Claude", "claude_desktop_config.json"), nil
+		}
+	case TargetClaudeCode:
+		if scope == ScopeProject {
+			return filepath.Join(projectDir, ".mcp.json"), nil
+		}
+		return filepath.Join(home, ".claude.json"), nil
+	case TargetCursor:
+		if scope == ScopeProject {
+			return filepath.Join(projectDir, ".cursor", "mcp.json"), nil
+		}
+		return filepath.Join(home, ".cursor", "mcp.json"), nil
+	case TargetMCPJSON:
+		return "", errors.New("mcp-json requires --file")
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnknownTarget, target)
+	}
+}
+
+// Install はpathの設定ファイルにnameのエントリを書き込む
+// ファイルや親ディレクトリがなければ作成し、他のキー・サーバー定義は保持する
+// 同名で内容の異なるエントリがある場合はforceがなければErrEntryExistsを返す
+func Install(path, name string, entry *Entry, force bool) (Result, error) {
+	doc, servers, mode, err := load(path)
+	if err != nil {
+		return "", err
+	}
+
+	result := ResultCreated
+	if raw, ok := servers[name]; ok {
+		var existing Entry
+		if err := json.Unmarshal(raw, &existing); err == nil && reflect.DeepEqual(&existing, entry) {
+			return ResultUnchanged, nil
+		}
+		if !force {
+			return "", fmt.Errorf("%w: %q in %s (use --force to replace it)", ErrEntryExists, name, path)
+		}
+		result = ResultUpdated
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode entry: %w", err)
+	}
+	servers[name] = data
+	if err := save(path, doc, servers, mode); err != nil {
+		return "", err
+	}
+	return result, nil
+}
+
+// Uninstall はpathの設定ファイルからnameのエントリを削除する
+// 削除した場合はtrue、ファイルやエントリがない場合はfalseを返す
+func Uninstall(path, name string) (bool, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return false, nil
+	}
+	doc, servers, mode, err := load(path)
+	if err != nil {
+		return false, err
+	}
+	if _, ok := servers[name]; !ok {
+		return false, nil
+	}
+	delete(servers, name)
+	return true, save(path, doc, servers, mode)
+}
+
+// load は設定ファイルを読み込み、全体とmcpServersを返す（ファイルがなければ空）
+func load(path string) (map[string]json.RawMessage, map[string]json.RawMessage, os.FileMode, error) {
+	doc := map[string]json.RawMessage{}
+	servers := map[string]json.RawMessage{}
+	mode := os.FileMode(0644)
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return doc, servers, mode, nil
+	}
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, nil, 0, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	}
+	if raw, ok := doc[serversKey]; ok && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &servers); err != nil {
+			return nil, nil, 0, fmt.Errorf("failed to parse %s in %s: %w", serversKey, path, err)
+		}
+	}
+	return doc, servers, mode, nil
+}
+
+// save はmcpServersを差し替えて設定ファイルを書き戻す
+// 既存ファイルは <path>.bak に退避し、一時ファイル経由で置き換える
+func save(path string, doc, servers map[string]json.RawMessage, mode os.FileMode) error {
+	serversData, err := json.Marshal(servers)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", serversKey, err)
+	}
+	doc[serversKey] = serversData
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	data = append(data, '\n')
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if old, err := os.ReadFile(path); err == nil {
+		if err := os.WriteFile(path+".bak", old, mode); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", path, err)
+		}
+	}
+
+	tmpFile := path + ".tmp"
+	if err := os.WriteFile(tmpFile, data, mode); err != nil {
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := os.Rename(tmpFile, path); err != nil {
+		os.Remove(tmpFile)
+		return fmt.Errorf("failed to rename file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package install
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestConfigPath はクライアント・OSごとの設定ファイルパスをテスト
+func TestConfigPath(t *testing.T) {
+	home := filepath.Join("/home", "u")
+	project := filepath.Join("/work", "repo")
+	env := func(vars map[string]string) func(string) string {
+		return func(key string) string { return vars[key] }
+	}
+
+	tests := []struct {
+		name   string
+		target Target
+		scope  Scope
+		goos   string
+		vars   map[string]string
+		expect string
+	}{
+		{"desktop macOS", TargetClaudeDesktop, ScopeUser, "darwin", nil, filepath.Join(home, "Library", "Application Support", "Claude", "claude_desktop_config.json")},
+		{"desktop windows", TargetClaudeDesktop, ScopeUser, "windows", map[string]string{"APPDATA": "/appdata"}, filepath.Join("/appdata", "Claude", "claude_desktop_config.json")},
+		{"desktop linux", TargetClaudeDesktop, ScopeUser, "linux", nil, filepath.Join(home, ".config", "Claude", "claude_desktop_config.json")},
+		{"claude code user", TargetClaudeCode, ScopeUser, "linux", nil, filepath.Join(home, ".claude.json")},
+		{"claude code project", TargetClaudeCode, ScopeProject, "linux", nil, filepath.Join(project, ".mcp.json")},
+		{"cursor user", TargetCursor, ScopeUser, "darwin", nil, filepath.Join(home, ".cursor", "mcp.json")},
+		{"cursor project", TargetCursor, ScopeProject, "darwin", nil, filepath.Join(project, ".cursor", "mcp.json")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := configPath(tt.target, tt.scope, project, tt.goos, home, env(tt.vars))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expect {
+				t.Errorf("expected %q, got %q", tt.expect, got)
+			}
+		})
+	}
+
+	if _, err := configPath(TargetMCPJSON, ScopeUser, project, "linux", home, env(nil)); err == nil {
+		t.Error("expected error for mcp-json without --file")
+	}
+}
+
+// TestParseTarget は未対応のクライアントでエラーを返すことをテスト
+func TestParseTarget(t *testing.T) {
+	if got, err := ParseTarget("cursor"); err != nil || got != TargetCursor {
+		t.Errorf("unexpected result: %q, %v", got, err)
+	}
+	if _, err := ParseTarget("vscode"); !errors.Is(err, ErrUnknownTarget) {
+		t.Errorf("expected ErrUnknownTarget, got %v", err)
+	}
+}
+
+// TestInstallAndUninstall は他の設定を保持したまま登録・更新・削除できることをテスト
+func TestInstallAndUninstall(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "claude.json")
+	existing := `{"theme": "dark", "mcpServers": {"other": {"command": "other-server", "args": []}}}`
+	if err := os.WriteFile(path, []byte(existing), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	entry := NewEntry(TargetClaudeCode, "/usr/local/bin/mcp-memory", []string{"serve"}, nil)
+	result, err := Install(path, "mcp-memory", entry, false)
+	if err != nil || result != ResultCreated {
+		t.Fatalf("Install: result=%q err=%v", result, err)
+	}
+
+	// 同じ内容なら変更なし
+	if result, err := Install(path, "mcp-memory", entry, false); err != nil || result != ResultUnchanged {
+		t.Errorf("expected unchanged, got %q (err=%v)", result, err)
+	}
+
+	// 内容が異なる場合は--forceが必要
+	changed := NewEntry(TargetClaudeCode, "/opt/mcp-memory", []string{"serve", "-c", "/cfg.json"}, map[string]string{"MCP_MEMORY_LOG_LEVEL": "debug"})
+	if _, err := Install(path, "mcp-memory", changed, false); !errors.Is(err, ErrEntryExists) {
+		t.Errorf("expected ErrEntryExists, got %v", err)
+	}
+	if result, err := Install(path, "mcp-memory", changed, true); err != nil || result != ResultUpdated {
+		t.Errorf("expected updated, got %q (err=%v)", result, err)
+	}
+
+	var doc struct {
+		Theme      string           `json:"theme"`
+		MCPServers map[string]Entry `json:"mcpServers"`
+	}
+	data, _ := os.ReadFile(path)
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	got := doc.MCPServers["mcp-memory"]
+	if doc.Theme != "dark" || doc.MCPServers["other"].Command != "other-server" {
+		t.Errorf("other settings were not preserved: %s", data)
+	}
+	if got.Type != "stdio" || got.Command != "/opt/mcp-memory" || got.Env["MCP_MEMORY_LOG_LEVEL"] != "debug" {
+		t.Errorf("unexpected entry: %+v", got)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("expected file mode to be preserved, got %v", info.Mode().Perm())
+	}
+	if _, err := os.Stat(path + ".bak"); err != nil {
+		t.Errorf("expected backup file: %v", err)
+	}
+
+	removed, err := Uninstall(path, "mcp-memory")
+	if err != nil || !removed {
+		t.Fatalf("Uninstall: removed=%v err=%v", removed, err)
+	}
+	if removed, _ := Uninstall(path, "mcp-memory"); removed {
+		t.Error("expected second uninstall to be a no-op")
+	}
+}
+
+// TestInstall_NewFile はファイル・ディレクトリがなくても作成できることをテスト
+func TestInstall_NewFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".cursor", "mcp.json")
+	if _, err := Install(path, "mcp-memory", NewEntry(TargetCursor, "mcp-memory", []string{"serve"}, nil), false); err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+
+	var doc map[string]map[string]Entry
+	data, _ := os.ReadFile(path)
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if e := doc["mcpServers"]["mcp-memory"]; e.Command != "mcp-memory" || e.Type != "" {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+
+	if removed, err := Uninstall(filepath.Join(t.TempDir(), "missing.json"), "mcp-memory"); err != nil || removed {
+		t.Errorf("expected no-op for missing file, got removed=%v err=%v", removed, err)
+	}
+}
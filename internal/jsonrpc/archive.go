@@ -0,0 +1,131 @@
+package jsonrpc
+
+import (
+	"context"
+
+	"github.com/brbranch/embedding_mcp/internal/audit"
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// handleArchive は memory.archive を処理
+func (h *Handler) handleArchive(ctx context.Context, params any) (any, error) {
+	var p ArchiveParams
+	if err := mapParams(params, &p); err != nil {
+		return nil, err
+	}
+	if h.archive == nil {
+		return nil, errArchiveUnavailable
+	}
+
+	resp, err := h.archive.Archive(ctx, &service.ArchiveRequest{ProjectID: p.ProjectID, OlderThanDays: p.OlderThanDays, DryRun: p.DryRun})
+	if err != nil {
+		return nil, err
+	}
+	if !p.DryRun {
+		for _, n := range resp.Notes {
+			h.recordAudit(ctx, audit.Entry{Method: "memory.archive", ProjectID: n.ProjectID, ID: n.ID})
+		}
+	}
+
+	return &ArchiveResult{
+		Namespace: resp.Namespace,
+		DryRun:    p.DryRun,
+		Notes:     archivedNoteResults(resp.Notes),
+	}, nil
+}
+
+// handleRestore は memory.restore を処理
+func (h *Handler) handleRestore(ctx context.Context, params any) (any, error) {
+	var p RestoreParams
+	if err := mapParams(params, &p); err != nil {
+		return nil, err
+	}
+	if h.archive == nil {
+		return nil, errArchiveUnavailable
+	}
+
+	resp, err := h.archive.Restore(ctx, &service.RestoreRequest{IDs: p.IDs})
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range resp.Restored {
+		h.recordAudit(ctx, audit.Entry{Method: "memory.restore", ID: id})
+	}
+
+	return &RestoreResult{Restored: resp.Restored, NotFound: resp.NotFound}, nil
+}
+
+// handleListArchived は memory.list_archived を処理
+func (h *Handler) handleListArchived(ctx context.Context, params any) (any, error) {
+	var p ListArchivedParams
+	if err := mapParams(params, &p); err != nil {
+		return nil, err
+	}
+	if h.archive == nil {
+		return nil, errArchiveUnavailable
+	}
+
+	resp, err := h.archive.ListArchived(ctx, &service.ListArchivedRequest{ProjectID: p.ProjectID})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListArchivedResult{
+		Namespace: resp.Namespace,
+		Notes:     archivedNoteResults(resp.Notes),
+	}, nil
+}
+
+// archivedNoteResults はアーカイブのノートを結果の形式に変換する
+func archivedNoteResults(notes []service.ArchivedNote) []ArchivedNoteResult {
+	results := make([]ArchivedNoteResult, len(notes))
+	for i, n := range notes {
+		results[i] = ArchivedNoteResult{
+			ID:         n.ID,
+			ProjectID:  n.ProjectID,
+			GroupID:    n.GroupID,
+			Title:      n.Title,
+			CreatedAt:  n.CreatedAt,
+			ArchivedAt: n.ArchivedAt,
+		}
+	}
+	return results
+}
+
+// handleValidate は memory.validate を処理
+func (h *Handler) handleValidate(ctx context.Context, params any) (any, error) {
+	var p ValidateParams
+	if err := mapParams(params, &p); err != nil {
+		return nil, err
+	}
+	if h.repair == nil {
+		return nil, errRepairUnavailable
+	}
+
+	resp, err := h.repair.Validate(ctx, &service.ValidateRequest{ProjectID: p.ProjectID, Repair: p.Repair})
+	if resp == nil {
+		return nil, err
+	}
+	// 途中でエラーになっても、修復済みの分は記録する
+	for _, issue := range resp.Issues {
+		if issue.Repaired {
+			h.recordAudit(ctx, audit.Entry{Method: "memory.validate", ProjectID: issue.ProjectID, ID: issue.ID})
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	issues := make([]IndexIssueResult, len(resp.Issues))
+	for i, issue := range resp.Issues {
+		issues[i] = IndexIssueResult{
+			Kind:      issue.Kind,
+			ProjectID: issue.ProjectID,
+			ID:        issue.ID,
+			Message:   issue.Message,
+			Repaired:  issue.Repaired,
+			Action:    issue.Action,
+		}
+	}
+	return &ValidateResult{Namespace: resp.Namespace, Scanned: resp.Scanned, Issues: issues}, nil
+}
@@ -0,0 +1,126 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/service"
+	"github.com/brbranch/embedding_mcp/internal/store"
+)
+
+func TestHandle_Archive(t *testing.T) {
+	ctx := context.Background()
+	h := newTestHandler()
+
+	// 未設定（serve以外）ならエラー
+	errResp := parseErrorResponse(t, h.Handle(ctx, makeRequest("memory.list_archived", nil)))
+	if errResp.Error.Code != model.ErrCodeInternalError {
+		t.Errorf("expected code %d, got %d", model.ErrCodeInternalError, errResp.Error.Code)
+	}
+
+	st := store.NewMemoryStore()
+	if err := st.Initialize(ctx, "test:mock:3"); err != nil {
+		t.Fatal(err)
+	}
+	old := "2024-01-01T00:00:00Z"
+	for _, id := range []string{"note-1", "note-2"} {
+		note := &model.Note{ID: id, ProjectID: "/test/project", GroupID: "global", Text: id, Tags: []string{}, CreatedAt: &old}
+		if err := st.AddNote(ctx, note, []float32{1, 0, 0}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h.SetArchiveService(service.NewArchiveService(st, store.NewColdStore(t.TempDir(), "test:mock:3"), "test:mock:3", 0))
+
+	// olderThanDaysもarchive.afterDaysもなければinvalid params
+	errResp = parseErrorResponse(t, h.Handle(ctx, makeRequest("memory.archive", map[string]any{})))
+	if errResp.Error.Code != model.ErrCodeInvalidParams {
+		t.Errorf("expected code %d, got %d", model.ErrCodeInvalidParams, errResp.Error.Code)
+	}
+
+	var archived struct {
+		Result ArchiveResult `json:"result"`
+	}
+	if err := json.Unmarshal(h.Handle(ctx, makeRequest("memory.archive", map[string]any{"olderThanDays": 30})), &archived); err != nil {
+		t.Fatal(err)
+	}
+	if len(archived.Result.Notes) != 2 || archived.Result.Notes[0].ID != "note-1" || archived.Result.Notes[0].ArchivedAt == "" {
+		t.Errorf("unexpected result: %+v", archived.Result)
+	}
+
+	var list struct {
+		Result ListArchivedResult `json:"result"`
+	}
+	if err := json.Unmarshal(h.Handle(ctx, makeRequest("memory.list_archived", map[string]any{"projectId": "/test/project"})), &list); err != nil {
+		t.Fatal(err)
+	}
+	if list.Result.Namespace != "test:mock:3" || len(list.Result.Notes) != 2 {
+		t.Errorf("unexpected result: %+v", list.Result)
+	}
+
+	var restored struct {
+		Result RestoreResult `json:"result"`
+	}
+	if err := json.Unmarshal(h.Handle(ctx, makeRequest("memory.restore", map[string]any{"ids": []string{"note-2", "missing"}})), &restored); err != nil {
+		t.Fatal(err)
+	}
+	if len(restored.Result.Restored) != 1 || restored.Result.Restored[0] != "note-2" || len(restored.Result.NotFound) != 1 {
+		t.Errorf("unexpected result: %+v", restored.Result)
+	}
+	if _, err := st.Get(ctx, "note-2"); err != nil {
+		t.Errorf("expected note-2 to be restored: %v", err)
+	}
+
+	errResp = parseErrorResponse(t, h.Handle(ctx, makeRequest("memory.restore", map[string]any{"ids": []string{}})))
+	if errResp.Error.Code != model.ErrCodeInvalidParams {
+		t.Errorf("expected code %d, got %d", model.ErrCodeInvalidParams, errResp.Error.Code)
+	}
+}
+
+// fixedEmbedder は常に同じ埋め込みを返すテスト用のEmbedder
+type fixedEmbedder struct{}
+
+func (fixedEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	return []float32{1, 0, 0}, nil
+}
+
+func (fixedEmbedder) GetDimension() int { return 3 }
+
+func TestHandle_Validate(t *testing.T) {
+	ctx := context.Background()
+	h := newTestHandler()
+
+	// 未設定（serve以外）ならエラー
+	errResp := parseErrorResponse(t, h.Handle(ctx, makeRequest("memory.validate", nil)))
+	if errResp.Error.Code != model.ErrCodeInternalError {
+		t.Errorf("expected code %d, got %d", model.ErrCodeInternalError, errResp.Error.Code)
+	}
+
+	st := store.NewMemoryStore()
+	if err := st.Initialize(ctx, "test:mock:3"); err != nil {
+		t.Fatal(err)
+	}
+	st.AddNote(ctx, &model.Note{ID: "ok", ProjectID: "/test/project", GroupID: "global", Text: "ok", Tags: []string{}}, []float32{1, 0, 0})
+	st.AddNote(ctx, &model.Note{ID: "missing", ProjectID: "/test/project", GroupID: "global", Text: "missing", Tags: []string{}}, nil)
+	h.SetRepairService(service.NewRepairService(fixedEmbedder{}, st, nil, "test:mock:3"))
+
+	for _, repair := range []bool{false, true} {
+		var resp struct {
+			Result ValidateResult `json:"result"`
+		}
+		if err := json.Unmarshal(h.Handle(ctx, makeRequest("memory.validate", map[string]any{"repair": repair})), &resp); err != nil {
+			t.Fatal(err)
+		}
+		r := resp.Result
+		if r.Namespace != "test:mock:3" || r.Scanned != 2 || len(r.Issues) != 1 {
+			t.Fatalf("unexpected result: %+v", r)
+		}
+		if issue := r.Issues[0]; issue.Kind != "missing_embedding" || issue.ID != "missing" || issue.Repaired != repair {
+			t.Errorf("repair=%v: unexpected issue: %+v", repair, issue)
+		}
+	}
+	if embedding, _ := st.GetEmbedding(ctx, "missing"); len(embedding) != 3 {
+		t.Errorf("expected the embedding to be repaired, got %v", embedding)
+	}
+}
@@ -0,0 +1,28 @@
+package jsonrpc
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/brbranch/embedding_mcp/internal/audit"
+	"github.com/brbranch/embedding_mcp/internal/auth"
+	"github.com/brbranch/embedding_mcp/internal/session"
+)
+
+// recordAudit は成功した変更操作を監査ログに記録する（SetAuditLogger未設定なら何もしない）
+// 記録に失敗しても操作自体は成功として扱い、警告をログに出す
+func (h *Handler) recordAudit(ctx context.Context, e audit.Entry) {
+	if h.auditLog == nil {
+		return
+	}
+	if sess := session.FromContext(ctx); sess != nil {
+		e.Client = sess.Info().ClientID()
+		e.Session = sess.ID()
+	}
+	if scope := auth.FromContext(ctx); scope != nil {
+		e.Token = scope.Name
+	}
+	if err := h.auditLog.Record(e); err != nil {
+		slog.Warn("failed to write audit log", "method", e.Method, "error", err)
+	}
+}
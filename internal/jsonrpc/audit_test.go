@@ -0,0 +1,51 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/audit"
+	"github.com/brbranch/embedding_mcp/internal/session"
+)
+
+// === 監査ログテスト ===
+
+func TestHandle_AuditLog(t *testing.T) {
+	h := newTestHandler()
+	var buf bytes.Buffer
+	h.SetAuditLogger(audit.New(&buf))
+
+	sess := session.New(session.Info{ClientName: "claude-code", ClientVersion: "1.0.0"})
+	ctx := session.NewContext(context.Background(), sess)
+
+	// 参照系・失敗した操作は記録しない
+	h.Handle(ctx, makeRequest("memory.get", map[string]any{"id": "note-1"}))
+	h.Handle(ctx, makeRequest("memory.delete", map[string]any{}))
+
+	// MCPのtools/callも内部のメソッド名で記録する
+	h.Handle(ctx, makeRequest("tools/call", map[string]any{"name": "memory_delete", "arguments": map[string]any{"id": "note-1"}}))
+	h.Handle(context.Background(), makeRequest("memory.upsert_global", map[string]any{"projectId": "/test", "key": "global.project.conventions", "value": "tabs"}))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 audit entries, got %d: %q", len(lines), buf.String())
+	}
+	var entries [2]audit.Entry
+	for i, line := range lines {
+		if err := json.Unmarshal([]byte(line), &entries[i]); err != nil {
+			t.Fatalf("audit entry is not JSON: %v", err)
+		}
+	}
+
+	if e := entries[0]; e.Method != "memory.delete" || e.ID != "note-1" || e.ProjectID != "/test" ||
+		e.Client != "claude-code/1.0.0" || e.Session != sess.ID() || e.Time == "" {
+		t.Errorf("unexpected delete entry: %+v", e)
+	}
+	// セッションのないリクエストはクライアントを記録しない
+	if e := entries[1]; e.Method != "memory.upsert_global" || e.Key != "global.project.conventions" || e.Client != "" || e.Session != "" {
+		t.Errorf("unexpected upsert_global entry: %+v", e)
+	}
+}
@@ -0,0 +1,166 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/brbranch/embedding_mcp/internal/auth"
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// accessTarget はメソッドの対象のプロジェクトの求め方
+type accessTarget int
+
+const (
+	targetAny      accessTarget = iota // プロジェクトに関係しない（どのトークンでも使える）
+	targetProject                      // paramsのprojectId（省略時はセッションのデフォルト）
+	targetProjects                     // paramsのprojectIdとprojectIds（search。projectIds指定時はデフォルトを使わない）
+	targetNotes                        // paramsのnotes[].projectId（add_notes）
+	targetNote                         // paramsのidのノートのprojectId
+	targetGroup                        // paramsのidのグループのprojectId
+	targetAdmin                        // 全体に関わる（APIキーのみ）
+)
+
+// methodAccess はメソッドの権限
+type methodAccess struct {
+	target accessTarget
+	write  bool
+}
+
+// methodAccesses はプロジェクトを限定したトークンでメソッドを使う場合の権限
+// ここにないメソッドはAPIキーのみ使える（追加したメソッドを誤って公開しないため）
+var methodAccesses = map[string]methodAccess{
+	"initialize":                {target: targetAny},
+	"tools/list":                {target: targetAny},
+	"tools/call":                {target: targetAny}, // ツールの内部メソッドで判定する
+	"memory.describe":           {target: targetAny},
+	"memory.server_info":        {target: targetAny},
+	"memory.get_config":         {target: targetAdmin},
+	"memory.set_config":         {target: targetAdmin},
+	"memory.stats":              {target: targetAdmin},
+	"memory.migrate":            {target: targetAdmin},
+	"memory.restore":            {target: targetAdmin},
+	"memory.validate":           {target: targetAdmin},
+	"memory.add_project_alias":  {target: targetAdmin},
+	"memory.add_note":           {target: targetProject, write: true},
+	"memory.add_notes":          {target: targetNotes, write: true},
+	"memory.search":             {target: targetProjects},
+	"memory.get":                {target: targetNote},
+	"memory.update":             {target: targetNote, write: true},
+	"memory.delete":             {target: targetNote, write: true},
+	"memory.list_recent":        {target: targetProject},
+	"memory.archive":            {target: targetProject, write: true},
+	"memory.list_archived":      {target: targetProject},
+	"memory.upsert_global":      {target: targetProject, write: true},
+	"memory.get_global":         {target: targetProject},
+	"memory.get_global_history": {target: targetProject},
+	"memory.export_globals":     {target: targetProject},
+	"memory.import_globals":     {target: targetProject, write: true},
+	"memory.group_create":       {target: targetProject, write: true},
+	"memory.group_get":          {target: targetGroup},
+	"memory.group_update":       {target: targetGroup, write: true},
+	"memory.group_delete":       {target: targetGroup, write: true},
+	"memory.group_rename":       {target: targetGroup, write: true},
+	"memory.group_list":         {target: targetProject},
+	"memory.subscribe":          {target: targetProject},
+	"memory.unsubscribe":        {target: targetProject},
+}
+
+// authorize はcontextのトークン（auth.Scope）でmethodを使えなければauth.ErrForbiddenを返す
+// Scopeがない（APIキー・stdio・pipe）場合は制限しない
+// ID指定のメソッドはノート・グループを取得して、そのprojectIdで判定する
+func (h *Handler) authorize(ctx context.Context, method string, params any) error {
+	scope := auth.FromContext(ctx)
+	if scope == nil {
+		return nil
+	}
+
+	access, ok := methodAccesses[method]
+	if !ok || access.target == targetAdmin {
+		return fmt.Errorf("%w: %s requires the API key", auth.ErrForbidden, method)
+	}
+
+	switch access.target {
+	case targetProject:
+		var p struct {
+			ProjectID string `json:"projectId"`
+		}
+		if err := mapParams(params, &p); err != nil {
+			return err
+		}
+		return scope.Check(defaultProjectID(ctx, p.ProjectID), access.write)
+	case targetProjects:
+		var p struct {
+			ProjectID  string   `json:"projectId"`
+			ProjectIDs []string `json:"projectIds"`
+		}
+		if err := mapParams(params, &p); err != nil {
+			return err
+		}
+		if len(p.ProjectIDs) == 0 {
+			return scope.Check(defaultProjectID(ctx, p.ProjectID), access.write)
+		}
+		for _, projectID := range service.SearchProjectIDs(&service.SearchRequest{ProjectID: p.ProjectID, ProjectIDs: p.ProjectIDs}) {
+			if err := scope.Check(projectID, access.write); err != nil {
+				return err
+			}
+		}
+		return nil
+	case targetNotes:
+		var p struct {
+			Notes []struct {
+				ProjectID string `json:"projectId"`
+			} `json:"notes"`
+		}
+		if err := mapParams(params, &p); err != nil {
+			return err
+		}
+		for _, note := range p.Notes {
+			if err := scope.Check(defaultProjectID(ctx, note.ProjectID), access.write); err != nil {
+				return err
+			}
+		}
+		return nil
+	case targetNote:
+		id, err := paramsID(params)
+		if err != nil {
+			return err
+		}
+		note, err := h.noteService.Get(ctx, id)
+		if errors.Is(err, service.ErrNoteNotFound) && method == "memory.delete" {
+			// グローバル設定のIDはprojectIdが分からないため削除できない
+			return fmt.Errorf("%w: %s of global configs requires the API key", auth.ErrForbidden, method)
+		}
+		if err != nil {
+			return err
+		}
+		return scope.Check(note.ProjectID, access.write)
+	case targetGroup:
+		id, err := paramsID(params)
+		if err != nil {
+			return err
+		}
+		group, err := h.groupService.GetGroup(ctx, id)
+		if err != nil {
+			return err
+		}
+		return scope.Check(group.ProjectID, access.write)
+	}
+	// targetAny
+	return nil
+}
+
+// paramsID はparamsのidを返す（空ならerrIDRequired）
+func paramsID(params any) (string, error) {
+	var p struct {
+		ID string `json:"id"`
+	}
+	if err := mapParams(params, &p); err != nil {
+		return "", err
+	}
+	if p.ID == "" {
+		return "", errIDRequired
+	}
+	return p.ID, nil
+}
@@ -0,0 +1,104 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/auth"
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/session"
+)
+
+func TestHandle_Authorize(t *testing.T) {
+	a := auth.New(&model.AuthConfig{Tokens: []model.AccessTokenConfig{
+		{Name: "rw", Token: "rw", ProjectIDs: []string{"/test"}, Access: model.AccessReadWrite},
+		{Name: "ro", Token: "ro", ProjectIDs: []string{"/test"}},
+	}})
+	rw, _ := a.Authenticate("rw")
+	ro, _ := a.Authenticate("ro")
+
+	tests := []struct {
+		name      string
+		scope     *auth.Scope
+		method    string
+		params    any
+		forbidden bool
+	}{
+		{name: "no scope", scope: nil, method: "memory.get_config"},
+		{name: "describe", scope: ro, method: "memory.describe"},
+		{name: "search", scope: ro, method: "memory.search", params: map[string]any{"projectId": "/test", "query": "q"}},
+		{name: "search other project", scope: ro, method: "memory.search", params: map[string]any{"projectId": "/other", "query": "q"}, forbidden: true},
+		{name: "search without projectId", scope: ro, method: "memory.search", params: map[string]any{"query": "q"}, forbidden: true},
+		{name: "search projectIds", scope: ro, method: "memory.search", params: map[string]any{"projectIds": []string{"/test"}, "query": "q"}},
+		{name: "search projectIds with other project", scope: ro, method: "memory.search", params: map[string]any{"projectId": "/test", "projectIds": []string{"/other"}, "query": "q"}, forbidden: true},
+		{name: "add_note read-only", scope: ro, method: "memory.add_note", params: map[string]any{"projectId": "/test", "groupId": "global", "text": "t"}, forbidden: true},
+		{name: "add_note", scope: rw, method: "memory.add_note", params: map[string]any{"projectId": "/test", "groupId": "global", "text": "t"}},
+		{name: "add_notes mixed projects", scope: rw, method: "memory.add_notes", params: map[string]any{"notes": []map[string]any{
+			{"projectId": "/test", "groupId": "global", "text": "a"},
+			{"projectId": "/other", "groupId": "global", "text": "b"},
+		}}, forbidden: true},
+		// mockNoteServiceのノートのprojectIdは"/test"
+		{name: "get note", scope: ro, method: "memory.get", params: map[string]any{"id": "note-1"}},
+		{name: "delete note read-only", scope: ro, method: "memory.delete", params: map[string]any{"id": "note-1"}, forbidden: true},
+		{name: "delete note", scope: rw, method: "memory.delete", params: map[string]any{"id": "note-1"}},
+		// mockGroupServiceのグループはprojectIdを持たない
+		{name: "group of another project", scope: rw, method: "memory.group_get", params: map[string]any{"id": "group-1"}, forbidden: true},
+		{name: "admin method", scope: rw, method: "memory.get_config", forbidden: true},
+		{name: "archive all projects", scope: rw, method: "memory.archive", params: map[string]any{"olderThanDays": 30}, forbidden: true},
+		{name: "validate", scope: rw, method: "memory.validate", params: map[string]any{"projectId": "/test"}, forbidden: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler()
+			ctx := auth.NewContext(context.Background(), tt.scope)
+			resp := parseErrorResponse(t, h.Handle(ctx, makeRequest(tt.method, tt.params)))
+			if tt.forbidden {
+				if resp.Error.Code != model.ErrCodeForbidden {
+					t.Fatalf("expected code %d, got %+v", model.ErrCodeForbidden, resp.Error)
+				}
+			} else if resp.Error.Code == model.ErrCodeForbidden {
+				t.Fatalf("unexpected forbidden: %s", resp.Error.Message)
+			}
+		})
+	}
+}
+
+func TestHandle_Authorize_SessionDefault(t *testing.T) {
+	h := newTestHandler()
+	a := auth.New(&model.AuthConfig{Tokens: []model.AccessTokenConfig{{Token: "ro", ProjectIDs: []string{"/test"}}}})
+	scope, _ := a.Authenticate("ro")
+	ctx := auth.NewContext(context.Background(), scope)
+	ctx = session.NewContext(ctx, session.New(session.Info{DefaultProjectID: "/test"}))
+
+	// projectId省略時はセッションのデフォルトで判定する
+	resp := parseErrorResponse(t, h.Handle(ctx, makeRequest("memory.search", map[string]any{"query": "q"})))
+	if resp.Error.Code != 0 {
+		t.Errorf("unexpected error: %+v", resp.Error)
+	}
+}
+
+func TestHandle_Authorize_ToolsCall(t *testing.T) {
+	h := newTestHandler()
+	a := auth.New(&model.AuthConfig{Tokens: []model.AccessTokenConfig{{Token: "ro", ProjectIDs: []string{"/test"}}}})
+	scope, _ := a.Authenticate("ro")
+	ctx := auth.NewContext(context.Background(), scope)
+
+	// ツールの内部メソッドで判定し、エラーはcontentで返す
+	call := func(name string, args map[string]any) model.ToolsCallResult {
+		var resp struct {
+			Result model.ToolsCallResult `json:"result"`
+		}
+		if err := json.Unmarshal(h.Handle(ctx, makeRequest("tools/call", map[string]any{"name": name, "arguments": args})), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Result
+	}
+	if result := call("memory_search", map[string]any{"projectId": "/test", "query": "q"}); result.IsError {
+		t.Errorf("unexpected error: %+v", result.Content)
+	}
+	if result := call("memory_add_note", map[string]any{"projectId": "/test", "groupId": "global", "text": "t"}); !result.IsError {
+		t.Error("expected the read-only token to be denied")
+	}
+}
@@ -5,20 +5,65 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"sync"
+	"time"
 
+	"github.com/brbranch/embedding_mcp/internal/audit"
+	"github.com/brbranch/embedding_mcp/internal/auth"
 	"github.com/brbranch/embedding_mcp/internal/embedder"
 	"github.com/brbranch/embedding_mcp/internal/model"
 	"github.com/brbranch/embedding_mcp/internal/service"
+	"github.com/brbranch/embedding_mcp/internal/store"
+	"github.com/brbranch/embedding_mcp/internal/webhook"
 )
 
 // Handler はJSON-RPCリクエストを処理する
 type Handler struct {
+	// mu はサービスの差し替えと処理中のリクエストを排他する
+	mu            sync.RWMutex
 	noteService   service.NoteService
 	configService service.ConfigService
 	globalService service.GlobalService
 	groupService  service.GroupService
+
+	// pending は予約されたサービスの差し替え（ReplaceServices）
+	pendingMu sync.Mutex
+	pending   *serviceSet
+
+	reinit  Reinitializer
+	migrate service.MigrateService
+	stats   service.StatsService
+	export  service.ExportService
+	archive service.ArchiveService
+	repair  service.RepairService
+
+	// notifier はノート・グローバル設定の変更通知を購読者に配る
+	notifier broker
+	// auditLog は変更操作の監査ログ（nilなら記録しない）
+	auditLog *audit.Logger
+	// webhooks はノート・グループの変更を送るWebhook（nilなら送らない）
+	webhooks *webhook.Dispatcher
+
+	// serverInfo・startedAt はmemory.server_infoで返す
+	serverInfo ServerInfo
+	startedAt  time.Time
 }
 
+// serviceSet は差し替えるサービス一式と、差し替え後に呼ぶ終了処理
+type serviceSet struct {
+	noteService   service.NoteService
+	configService service.ConfigService
+	globalService service.GlobalService
+	groupService  service.GroupService
+	release       func()
+}
+
+// Reinitializer はset_configで変更されたembedder設定でサービスを作り直し、
+// 作り直す前と後にサービスが使っているnamespaceを返す
+// dualReadの場合、namespaceが変わるなら変更前のnamespaceも読み出すようにする
+// 作り直したサービスはReplaceServicesで差し替えを予約する。エラーの場合、設定は変更されない
+type Reinitializer func(ctx context.Context, embedder *model.EmbedderConfig, dualRead bool) (previous, current string, err error)
+
 // New は新しいHandlerを生成
 func New(
 	noteService service.NoteService,
@@ -31,6 +76,140 @@ func New(
 		configService: configService,
 		globalService: globalService,
 		groupService:  groupService,
+		startedAt:     time.Now(),
+	}
+}
+
+// SetReinitializer はset_configでembedderが変わった際にサービスを作り直す関数を設定する
+// 未設定の場合、set_configは設定の値のみを変更する
+func (h *Handler) SetReinitializer(fn Reinitializer) {
+	h.reinit = fn
+}
+
+// SetMigrateService はmemory.migrateとset_configのpreviousNoteCountに使うMigrateServiceを設定する
+// サービスの差し替え後も現在のnamespaceへ移行するよう、呼び出し側で現在のサービスに委譲すること
+// 未設定の場合、memory.migrateはエラーを返す
+func (h *Handler) SetMigrateService(s service.MigrateService) {
+	h.migrate = s
+}
+
+// SetStatsService はmemory.statsと /health に使うStatsServiceを設定する
+// サービスの差し替え後も現在のnamespaceを返すよう、呼び出し側で現在のサービスに委譲すること
+// 未設定の場合、memory.statsはエラーを返し、/health はノート数を含めない
+func (h *Handler) SetStatsService(s service.StatsService) {
+	h.stats = s
+}
+
+// SetExportService はHTTPの /export に使うExportServiceを設定する
+// サービスの差し替え後も現在のnamespaceを書き出すよう、呼び出し側で現在のサービスに委譲すること
+// 未設定の場合、/export はエラーを返す
+func (h *Handler) SetExportService(s service.ExportService) {
+	h.export = s
+}
+
+// SetArchiveService はmemory.archive・memory.restore・memory.list_archivedに使うArchiveServiceを設定する
+// サービスの差し替え後も現在のnamespaceを扱うよう、呼び出し側で現在のサービスに委譲すること
+// 未設定の場合、これらのメソッドはエラーを返す
+func (h *Handler) SetArchiveService(s service.ArchiveService) {
+	h.archive = s
+}
+
+// SetRepairService はmemory.validateに使うRepairServiceを設定する
+// サービスの差し替え後も現在のnamespaceを扱うよう、呼び出し側で現在のサービスに委譲すること
+// 未設定の場合、memory.validateはエラーを返す
+func (h *Handler) SetRepairService(s service.RepairService) {
+	h.repair = s
+}
+
+// SetAuditLogger は変更操作（add_note・update・delete・upsert_global・import_globals・group_*）を記録するLoggerを設定する
+// 未設定の場合は記録しない
+func (h *Handler) SetAuditLogger(l *audit.Logger) {
+	h.auditLog = l
+}
+
+// SetWebhooks はノート（add_note・add_notes・update・delete）・グループ（group_*）の変更を送るDispatcherを設定する
+// 未設定の場合は送らない
+func (h *Handler) SetWebhooks(d *webhook.Dispatcher) {
+	h.webhooks = d
+}
+
+// SetServerInfo はmemory.server_infoで返すバージョン・transportなどを設定する
+func (h *Handler) SetServerInfo(info ServerInfo) {
+	h.serverInfo = info
+}
+
+// SetServices はサービスを差し替える（設定のホットリロード用）
+// 処理中のリクエストの完了を待ってから差し替えるため、戻った後は古いサービスを閉じてよい
+// リクエストの処理中（handle内）から呼ぶとデッドロックするため、その場合はReplaceServicesを使う
+func (h *Handler) SetServices(
+	noteService service.NoteService,
+	configService service.ConfigService,
+	globalService service.GlobalService,
+	groupService service.GroupService,
+) {
+	h.ReplaceServices(noteService, configService, globalService, groupService, nil)
+	h.ApplyPendingServices()
+}
+
+// ReplaceServices はサービスの差し替えを予約し、差し替え後にrelease（nil可）を呼ぶ
+// 予約はリクエストの処理が終わるたびに、応答を返す前に反映される（処理中のリクエストの完了を待つ）
+// そのためリクエストの処理中から呼んでも、そのリクエストの応答より前に差し替わる
+// リクエストの外から呼んだ場合はApplyPendingServicesで反映する
+// 反映前に再度予約した場合は新しい予約で置き換え、両方のreleaseを呼ぶ
+func (h *Handler) ReplaceServices(
+	noteService service.NoteService,
+	configService service.ConfigService,
+	globalService service.GlobalService,
+	groupService service.GroupService,
+	release func(),
+) {
+	next := &serviceSet{
+		noteService:   noteService,
+		configService: configService,
+		globalService: globalService,
+		groupService:  groupService,
+		release:       release,
+	}
+
+	h.pendingMu.Lock()
+	defer h.pendingMu.Unlock()
+	if prev := h.pending; prev != nil && prev.release != nil {
+		// 反映されなかった予約のサービスも閉じる
+		next.release = func() {
+			prev.release()
+			if release != nil {
+				release()
+			}
+		}
+	}
+	h.pending = next
+}
+
+// ApplyPendingServices は予約された差し替えを反映する（処理中のリクエストの完了を待つ）
+func (h *Handler) ApplyPendingServices() {
+	h.pendingMu.Lock()
+	hasPending := h.pending != nil
+	h.pendingMu.Unlock()
+	if !hasPending {
+		return
+	}
+
+	h.mu.Lock()
+	h.pendingMu.Lock()
+	p := h.pending
+	h.pending = nil
+	h.pendingMu.Unlock()
+	if p != nil {
+		h.noteService = p.noteService
+		h.configService = p.configService
+		h.globalService = p.globalService
+		h.groupService = p.groupService
+	}
+	h.mu.Unlock()
+
+	// 他のリクエストが先に反映した場合（p == nil）も、上のLockで反映済みになっている
+	if p != nil && p.release != nil {
+		p.release()
 	}
 }
 
@@ -38,38 +217,86 @@ func New(
 // 戻り値は *model.Response または *model.ErrorResponse のJSON bytes
 // 通知（idがnilまたは未設定）の場合はnilを返す
 func (h *Handler) Handle(ctx context.Context, requestBytes []byte) []byte {
+	req, isNotification, errResp := h.parseRequest(requestBytes)
+	if req == nil {
+		return errResp
+	}
+
+	resp := h.handleRequest(ctx, req, isNotification)
+	// 処理中に予約された差し替え（set_config）を応答より前に反映する
+	h.ApplyPendingServices()
+	return resp
+}
+
+// parseRequest はリクエストをパースして検証する
+// 不正なリクエストの場合はreqをnilにし、返すべきエラーレスポンス（通知ならnil）を返す
+func (h *Handler) parseRequest(requestBytes []byte) (req *model.Request, isNotification bool, errResp []byte) {
 	// 1. パース（ID の存在を確認するため raw で）
 	var raw map[string]json.RawMessage
 	if err := json.Unmarshal(requestBytes, &raw); err != nil {
-		return h.encodeError(model.NewParseError(err.Error()))
+		return nil, false, h.encodeError(model.NewParseError(err.Error()))
 	}
 
 	// ID の有無と値を確認
 	idRaw, hasID := raw["id"]
-	isNotification := !hasID || (hasID && string(idRaw) == "null")
+	isNotification = !hasID || (hasID && string(idRaw) == "null")
+	var id any
+	if !isNotification {
+		// IDは文字列か数値のみ（判別できないIDにはnullで応答する）
+		if !validRequestID(idRaw) {
+			return nil, false, h.encodeError(model.NewInvalidRequest(nil, "id must be a string or number"))
+		}
+		// 受け取ったJSONのまま返す（float64に変換すると大きな整数や1.0の表記が変わる）
+		id = idRaw
+	}
 
 	// 構造体にパース
-	var req model.Request
-	if err := json.Unmarshal(requestBytes, &req); err != nil {
-		return h.encodeError(model.NewParseError(err.Error()))
+	req = &model.Request{}
+	if err := json.Unmarshal(requestBytes, req); err != nil {
+		if isNotification {
+			return nil, true, nil
+		}
+		return nil, false, h.encodeError(model.NewInvalidRequest(id, err.Error()))
 	}
+	req.ID = id
 
 	// 2. バージョン確認
 	if req.JSONRPC != "2.0" {
 		if isNotification {
-			return nil
+			return nil, true, nil
 		}
-		return h.encodeError(model.NewInvalidRequest(req.ID, "jsonrpc must be 2.0"))
+		return nil, false, h.encodeError(model.NewInvalidRequest(req.ID, "jsonrpc must be 2.0"))
 	}
 
 	// 3. method確認
 	if req.Method == "" {
 		if isNotification {
-			return nil
+			return nil, true, nil
 		}
-		return h.encodeError(model.NewInvalidRequest(req.ID, "method is required"))
+		return nil, false, h.encodeError(model.NewInvalidRequest(req.ID, "method is required"))
 	}
 
+	return req, isNotification, nil
+}
+
+// validRequestID はidRawがJSON-RPCのIDとして有効（文字列または数値）かを返す
+func validRequestID(idRaw json.RawMessage) bool {
+	var v any
+	if err := json.Unmarshal(idRaw, &v); err != nil {
+		return false
+	}
+	switch v.(type) {
+	case string, float64:
+		return true
+	}
+	return false
+}
+
+// handleRequest は処理中のサービスが差し替わらないようにしてリクエストを処理する
+func (h *Handler) handleRequest(ctx context.Context, req *model.Request, isNotification bool) []byte {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
 	// 4. 通知の処理（レスポンスを返さない）
 	if isNotification {
 		// 通知は処理するがレスポンスは返さない
@@ -88,8 +315,13 @@ func (h *Handler) Handle(ctx context.Context, requestBytes []byte) []byte {
 	return h.encodeResponse(model.NewResponse(req.ID, result))
 }
 
-// dispatch はメソッドに応じて適切なハンドラーを呼び出す
+// dispatch はトークンの権限を確認し、メソッドに応じて適切なハンドラーを呼び出す
 func (h *Handler) dispatch(ctx context.Context, id any, method string, params any) (any, error) {
+	// HTTPでプロジェクトを限定したトークンを使っている場合のみ制限する
+	if err := h.authorize(ctx, method, params); err != nil {
+		return nil, err
+	}
+
 	switch method {
 	// MCP 標準メソッド
 	case "initialize":
@@ -99,8 +331,12 @@ func (h *Handler) dispatch(ctx context.Context, id any, method string, params an
 	case "tools/call":
 		return h.handleToolsCall(ctx, id, params)
 	// memory.* メソッド（後方互換性のため維持）
+	case "memory.describe":
+		return h.handleDescribe(ctx, params)
 	case "memory.add_note":
 		return h.handleAddNote(ctx, params)
+	case "memory.add_notes":
+		return h.handleAddNotes(ctx, params)
 	case "memory.search":
 		return h.handleSearch(ctx, params)
 	case "memory.get":
@@ -111,12 +347,34 @@ func (h *Handler) dispatch(ctx context.Context, id any, method string, params an
 		return h.handleListRecent(ctx, params)
 	case "memory.get_config":
 		return h.handleGetConfig(ctx)
+	case "memory.server_info":
+		return h.handleServerInfo(ctx)
+	case "memory.stats":
+		return h.handleStats(ctx)
 	case "memory.set_config":
 		return h.handleSetConfig(ctx, params)
+	case "memory.migrate":
+		return h.handleMigrate(ctx, params)
+	case "memory.archive":
+		return h.handleArchive(ctx, params)
+	case "memory.restore":
+		return h.handleRestore(ctx, params)
+	case "memory.list_archived":
+		return h.handleListArchived(ctx, params)
+	case "memory.validate":
+		return h.handleValidate(ctx, params)
+	case "memory.add_project_alias":
+		return h.handleAddProjectAlias(ctx, params)
 	case "memory.upsert_global":
 		return h.handleUpsertGlobal(ctx, params)
 	case "memory.get_global":
 		return h.handleGetGlobal(ctx, params)
+	case "memory.get_global_history":
+		return h.handleGetGlobalHistory(ctx, params)
+	case "memory.export_globals":
+		return h.handleExportGlobals(ctx, params)
+	case "memory.import_globals":
+		return h.handleImportGlobals(ctx, params)
 	case "memory.delete":
 		return h.handleDelete(ctx, params)
 	case "memory.group_create":
@@ -127,8 +385,14 @@ func (h *Handler) dispatch(ctx context.Context, id any, method string, params an
 		return h.handleGroupUpdate(ctx, params)
 	case "memory.group_delete":
 		return h.handleGroupDelete(ctx, params)
+	case "memory.group_rename":
+		return h.handleGroupRename(ctx, params)
 	case "memory.group_list":
 		return h.handleGroupList(ctx, params)
+	case "memory.subscribe":
+		return h.handleSubscribe(ctx, params)
+	case "memory.unsubscribe":
+		return h.handleUnsubscribe(ctx, params)
 	default:
 		return nil, &methodNotFoundError{method: method}
 	}
@@ -143,6 +407,10 @@ func (h *Handler) mapError(id any, err error) *model.ErrorResponse {
 	}
 
 	// invalid params
+	var prefixErr *service.GroupKeyPrefixError
+	if errors.As(err, &prefixErr) {
+		return model.NewErrorResponse(id, model.ErrCodeInvalidParams, err.Error(), &GroupKeyPrefixData{GroupKey: prefixErr.GroupKey, Prefixes: prefixErr.Prefixes, Suggestions: prefixErr.Suggestions})
+	}
 	if errors.Is(err, service.ErrProjectIDRequired) ||
 		errors.Is(err, service.ErrGroupIDRequired) ||
 		errors.Is(err, service.ErrGroupKeyRequired) ||
@@ -153,7 +421,33 @@ func (h *Handler) mapError(id any, err error) *model.ErrorResponse {
 		errors.Is(err, service.ErrQueryRequired) ||
 		errors.Is(err, service.ErrIDRequired) ||
 		errors.Is(err, service.ErrInvalidTimeFormat) ||
+		errors.Is(err, service.ErrInvalidMinScore) ||
+		errors.Is(err, service.ErrNamespaceRequired) ||
+		errors.Is(err, service.ErrInvalidNamespace) ||
+		errors.Is(err, service.ErrSameNamespace) ||
+		errors.Is(err, service.ErrMissingRequiredTags) ||
+		errors.Is(err, service.ErrInvalidNotePolicy) ||
+		errors.Is(err, service.ErrPathRequired) ||
+		errors.Is(err, service.ErrInvalidSortBy) ||
+		errors.Is(err, service.ErrInvalidCursor) ||
+		errors.Is(err, service.ErrInvalidGroupBy) ||
+		errors.Is(err, service.ErrInvalidGroupTopK) ||
+		errors.Is(err, service.ErrGroupByMultiProject) ||
+		errors.Is(err, service.ErrInvalidAttachment) ||
+		errors.Is(err, service.ErrInvalidImportance) ||
+		errors.Is(err, service.ErrNoteTooLarge) ||
+		errors.Is(err, service.ErrInvalidMetadata) ||
+		errors.Is(err, service.ErrInvalidOnDuplicate) ||
+		errors.Is(err, service.ErrInvalidThreshold) ||
+		errors.Is(err, service.ErrUnknownModel) ||
+		errors.Is(err, service.ErrInvalidOlderThanDays) ||
+		errors.Is(err, service.ErrIDsRequired) ||
+		errors.Is(err, service.ErrInvalidGroupStatus) ||
+		errors.Is(err, service.ErrInvalidParentGroup) ||
+		errors.Is(err, service.ErrInvalidCascade) ||
+		errors.Is(err, service.ErrInvalidImportMode) ||
 		errors.Is(err, errKeyRequired) ||
+		errors.Is(err, errUnknownDescribeMethod) ||
 		errors.Is(err, errIDRequired) {
 		return model.NewInvalidParams(id, err.Error())
 	}
@@ -172,8 +466,12 @@ func (h *Handler) mapError(id any, err error) *model.ErrorResponse {
 		return model.NewErrorResponse(id, model.ErrCodeNotFound, "Not found", nil)
 	}
 
-	// conflict (duplicate key)
-	if errors.Is(err, service.ErrGroupKeyExists) {
+	// conflict (duplicate note / duplicate key / stale ifUpdatedAt)
+	var dupErr *service.DuplicateNoteError
+	if errors.As(err, &dupErr) {
+		return model.NewErrorResponse(id, model.ErrCodeConflict, err.Error(), &DuplicateNoteData{DuplicateID: dupErr.ID, Score: dupErr.Score})
+	}
+	if errors.Is(err, service.ErrGroupKeyExists) || errors.Is(err, service.ErrNoteConflict) || errors.Is(err, service.ErrGroupHasNotes) || errors.Is(err, service.ErrImportConflict) {
 		return model.NewErrorResponse(id, model.ErrCodeConflict, err.Error(), nil)
 	}
 
@@ -187,6 +485,16 @@ func (h *Handler) mapError(id any, err error) *model.ErrorResponse {
 		return model.NewErrorResponse(id, model.ErrCodeAPIKeyMissing, err.Error(), nil)
 	}
 
+	// forbidden（プロジェクトを限定したトークンで使えないプロジェクト・メソッド）
+	if errors.Is(err, auth.ErrForbidden) {
+		return model.NewErrorResponse(id, model.ErrCodeForbidden, err.Error(), nil)
+	}
+
+	// store unavailable（リクエストの誤りではなく一時的な障害）
+	if store.IsUnavailable(err) {
+		return model.NewErrorResponse(id, model.ErrCodeStoreUnavailable, err.Error(), nil)
+	}
+
 	// internal error
 	return model.NewInternalError(id, err.Error())
 }
@@ -216,5 +524,20 @@ var errKeyRequired = errors.New("key is required")
 // errIDRequired はID必須エラー
 var errIDRequired = errors.New("id is required")
 
+// errMigrateUnavailable はmemory.migrateを処理できない（serve以外から使用している）
+var errMigrateUnavailable = errors.New("migration is not available")
+
+// errStatsUnavailable はmemory.statsを処理できない（serve以外から使用している）
+var errStatsUnavailable = errors.New("stats are not available")
+
+// errArchiveUnavailable はmemory.archive・memory.restore・memory.list_archivedを処理できない（serve以外から使用している）
+var errArchiveUnavailable = errors.New("archive is not available")
+
+// errRepairUnavailable はmemory.validateを処理できない（serve以外から使用している）
+var errRepairUnavailable = errors.New("index validation is not available")
+
+// errNotificationsUnavailable はmemory.subscribeを処理できない（通知を送れないHTTPのPOSTから使用している）
+var errNotificationsUnavailable = errors.New("notifications are not available on this transport (use GET /events over HTTP)")
+
 // errNotFound はNot Foundエラー（Note/GlobalConfig両方で見つからない場合）
 var errNotFound = errors.New("not found")
@@ -1,23 +1,31 @@
 package jsonrpc
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/brbranch/embedding_mcp/internal/model"
 	"github.com/brbranch/embedding_mcp/internal/service"
+	"github.com/brbranch/embedding_mcp/internal/store"
 )
 
 // === モックサービス ===
 
 type mockNoteService struct {
-	addNoteFunc    func(ctx context.Context, req *service.AddNoteRequest) (*service.AddNoteResponse, error)
-	searchFunc     func(ctx context.Context, req *service.SearchRequest) (*service.SearchResponse, error)
-	getFunc        func(ctx context.Context, id string) (*service.GetResponse, error)
-	updateFunc     func(ctx context.Context, req *service.UpdateRequest) error
-	deleteFunc     func(ctx context.Context, id string) error
-	listRecentFunc func(ctx context.Context, req *service.ListRecentRequest) (*service.ListRecentResponse, error)
+	addNoteFunc      func(ctx context.Context, req *service.AddNoteRequest) (*service.AddNoteResponse, error)
+	addNotesFunc     func(ctx context.Context, req *service.AddNotesRequest) (*service.AddNotesResponse, error)
+	searchFunc       func(ctx context.Context, req *service.SearchRequest) (*service.SearchResponse, error)
+	getFunc          func(ctx context.Context, id string) (*service.GetResponse, error)
+	getDocumentFunc  func(ctx context.Context, id string) (*service.GetResponse, error)
+	updateFunc       func(ctx context.Context, req *service.UpdateRequest) error
+	deleteFunc       func(ctx context.Context, id string) error
+	listRecentFunc   func(ctx context.Context, req *service.ListRecentRequest) (*service.ListRecentResponse, error)
+	listProjectsFunc func(ctx context.Context) (*service.ListProjectsResponse, error)
 }
 
 func (m *mockNoteService) AddNote(ctx context.Context, req *service.AddNoteRequest) (*service.AddNoteResponse, error) {
@@ -27,6 +35,13 @@ func (m *mockNoteService) AddNote(ctx context.Context, req *service.AddNoteReque
 	return &service.AddNoteResponse{ID: "test-id", Namespace: "test-ns"}, nil
 }
 
+func (m *mockNoteService) AddNotes(ctx context.Context, req *service.AddNotesRequest) (*service.AddNotesResponse, error) {
+	if m.addNotesFunc != nil {
+		return m.addNotesFunc(ctx, req)
+	}
+	return &service.AddNotesResponse{Namespace: "test-ns"}, nil
+}
+
 func (m *mockNoteService) Search(ctx context.Context, req *service.SearchRequest) (*service.SearchResponse, error) {
 	if m.searchFunc != nil {
 		return m.searchFunc(ctx, req)
@@ -41,6 +56,13 @@ func (m *mockNoteService) Get(ctx context.Context, id string) (*service.GetRespo
 	return &service.GetResponse{ID: id, ProjectID: "/test", GroupID: "global"}, nil
 }
 
+func (m *mockNoteService) GetDocument(ctx context.Context, id string) (*service.GetResponse, error) {
+	if m.getDocumentFunc != nil {
+		return m.getDocumentFunc(ctx, id)
+	}
+	return m.Get(ctx, id)
+}
+
 func (m *mockNoteService) Update(ctx context.Context, req *service.UpdateRequest) error {
 	if m.updateFunc != nil {
 		return m.updateFunc(ctx, req)
@@ -62,9 +84,17 @@ func (m *mockNoteService) ListRecent(ctx context.Context, req *service.ListRecen
 	return &service.ListRecentResponse{Namespace: "test-ns", Items: []service.ListRecentItem{}}, nil
 }
 
+func (m *mockNoteService) ListProjects(ctx context.Context) (*service.ListProjectsResponse, error) {
+	if m.listProjectsFunc != nil {
+		return m.listProjectsFunc(ctx)
+	}
+	return &service.ListProjectsResponse{Namespace: "test-ns"}, nil
+}
+
 type mockConfigService struct {
 	getConfigFunc func(ctx context.Context) (*service.GetConfigResponse, error)
 	setConfigFunc func(ctx context.Context, req *service.SetConfigRequest) (*service.SetConfigResponse, error)
+	addAliasFunc  func(ctx context.Context, req *service.AddProjectAliasRequest) (*service.AddProjectAliasResponse, error)
 }
 
 func (m *mockConfigService) GetConfig(ctx context.Context) (*service.GetConfigResponse, error) {
@@ -86,9 +116,19 @@ func (m *mockConfigService) SetConfig(ctx context.Context, req *service.SetConfi
 	return &service.SetConfigResponse{OK: true, EffectiveNamespace: "openai:text-embedding-3-small:1536"}, nil
 }
 
+func (m *mockConfigService) AddProjectAlias(ctx context.Context, req *service.AddProjectAliasRequest) (*service.AddProjectAliasResponse, error) {
+	if m.addAliasFunc != nil {
+		return m.addAliasFunc(ctx, req)
+	}
+	return &service.AddProjectAliasResponse{Path: req.Path, ProjectID: req.ProjectID}, nil
+}
+
 type mockGlobalService struct {
 	upsertGlobalFunc func(ctx context.Context, req *service.UpsertGlobalRequest) (*service.UpsertGlobalResponse, error)
 	getGlobalFunc    func(ctx context.Context, projectID, key string) (*service.GetGlobalResponse, error)
+	getHistoryFunc   func(ctx context.Context, projectID, key string) (*service.GetGlobalHistoryResponse, error)
+	exportFunc       func(ctx context.Context, projectID string) (*service.ExportGlobalsResponse, error)
+	importFunc       func(ctx context.Context, req *service.ImportGlobalsRequest) (*service.ImportGlobalsResponse, error)
 	deleteByIDFunc   func(ctx context.Context, id string) error
 }
 
@@ -106,6 +146,27 @@ func (m *mockGlobalService) GetGlobal(ctx context.Context, projectID, key string
 	return &service.GetGlobalResponse{Namespace: "test-ns", Found: false}, nil
 }
 
+func (m *mockGlobalService) GetGlobalHistory(ctx context.Context, projectID, key string) (*service.GetGlobalHistoryResponse, error) {
+	if m.getHistoryFunc != nil {
+		return m.getHistoryFunc(ctx, projectID, key)
+	}
+	return &service.GetGlobalHistoryResponse{Namespace: "test-ns", Found: false}, nil
+}
+
+func (m *mockGlobalService) ExportGlobals(ctx context.Context, projectID string) (*service.ExportGlobalsResponse, error) {
+	if m.exportFunc != nil {
+		return m.exportFunc(ctx, projectID)
+	}
+	return &service.ExportGlobalsResponse{Namespace: "test-ns", ProjectID: projectID}, nil
+}
+
+func (m *mockGlobalService) ImportGlobals(ctx context.Context, req *service.ImportGlobalsRequest) (*service.ImportGlobalsResponse, error) {
+	if m.importFunc != nil {
+		return m.importFunc(ctx, req)
+	}
+	return &service.ImportGlobalsResponse{Namespace: "test-ns", ProjectID: req.ProjectID, Created: len(req.Globals)}, nil
+}
+
 func (m *mockGlobalService) DeleteByID(ctx context.Context, id string) error {
 	if m.deleteByIDFunc != nil {
 		return m.deleteByIDFunc(ctx, id)
@@ -114,7 +175,10 @@ func (m *mockGlobalService) DeleteByID(ctx context.Context, id string) error {
 }
 
 // mockGroupService はテスト用のGroupService
-type mockGroupService struct{}
+type mockGroupService struct {
+	deleteFunc func(ctx context.Context, req *service.DeleteGroupRequest) (*service.DeleteGroupResponse, error)
+	renameFunc func(ctx context.Context, req *service.RenameGroupRequest) (*service.RenameGroupResponse, error)
+}
 
 func (m *mockGroupService) CreateGroup(ctx context.Context, req *service.CreateGroupRequest) (*service.CreateGroupResponse, error) {
 	return &service.CreateGroupResponse{ID: "test-group-id", Namespace: "test-ns"}, nil
@@ -125,8 +189,17 @@ func (m *mockGroupService) GetGroup(ctx context.Context, id string) (*service.Ge
 func (m *mockGroupService) UpdateGroup(ctx context.Context, req *service.UpdateGroupRequest) error {
 	return nil
 }
-func (m *mockGroupService) DeleteGroup(ctx context.Context, id string) error {
-	return nil
+func (m *mockGroupService) DeleteGroup(ctx context.Context, req *service.DeleteGroupRequest) (*service.DeleteGroupResponse, error) {
+	if m.deleteFunc != nil {
+		return m.deleteFunc(ctx, req)
+	}
+	return &service.DeleteGroupResponse{}, nil
+}
+func (m *mockGroupService) RenameGroup(ctx context.Context, req *service.RenameGroupRequest) (*service.RenameGroupResponse, error) {
+	if m.renameFunc != nil {
+		return m.renameFunc(ctx, req)
+	}
+	return &service.RenameGroupResponse{}, nil
 }
 func (m *mockGroupService) ListGroups(ctx context.Context, projectID string) (*service.ListGroupsResponse, error) {
 	return &service.ListGroupsResponse{Namespace: "test-ns", Groups: []service.ListGroupItem{}}, nil
@@ -163,6 +236,22 @@ func parseErrorResponse(t *testing.T, data []byte) *model.ErrorResponse {
 	return &resp
 }
 
+type mockMigrateService struct {
+	counts      map[string]int
+	migrateFunc func(ctx context.Context, req *service.MigrateRequest) (*service.MigrateResponse, error)
+}
+
+func (m *mockMigrateService) CountNotes(ctx context.Context, namespace string) (int, error) {
+	return m.counts[namespace], nil
+}
+
+func (m *mockMigrateService) Migrate(ctx context.Context, req *service.MigrateRequest) (*service.MigrateResponse, error) {
+	if m.migrateFunc != nil {
+		return m.migrateFunc(ctx, req)
+	}
+	return &service.MigrateResponse{From: req.From, Namespace: "openai:text-embedding-3-small:1536"}, nil
+}
+
 func newTestHandler() *Handler {
 	return New(
 		&mockNoteService{},
@@ -206,6 +295,70 @@ func TestHandle_InvalidRequest_NoMethod(t *testing.T) {
 	}
 }
 
+// TestHandle_RequestID はリクエストのIDを受け取ったJSONのまま返すことをテスト
+func TestHandle_RequestID(t *testing.T) {
+	h := newTestHandler()
+	tests := []struct {
+		name   string
+		id     string
+		method string
+	}{
+		{"uuid string", `"6f1c2a9e-3b4d-4e5f-8a7b-9c0d1e2f3a4b"`, "memory.get_config"},
+		{"empty string", `""`, "memory.get_config"},
+		{"integer", `42`, "memory.get_config"},
+		{"integer beyond float64 precision", `9007199254740993`, "memory.get_config"},
+		{"float notation", `1.0`, "memory.get_config"},
+		{"negative", `-7`, "memory.get_config"},
+		{"string id on error", `"req-1"`, "unknown.method"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := []byte(`{"jsonrpc":"2.0","id":` + tt.id + `,"method":"` + tt.method + `"}`)
+			result := h.Handle(context.Background(), req)
+			if !bytes.Contains(result, []byte(`"id":`+tt.id+`,`)) {
+				t.Errorf("expected id %s to be echoed, got %s", tt.id, result)
+			}
+		})
+	}
+}
+
+// TestHandle_RequestID_Errors はIDがない・不正なリクエストの扱いをテスト
+func TestHandle_RequestID_Errors(t *testing.T) {
+	h := newTestHandler()
+
+	// idがない・nullのリクエストは通知として応答しない
+	for _, req := range []string{
+		`{"jsonrpc":"2.0","method":"memory.get_config"}`,
+		`{"jsonrpc":"2.0","id":null,"method":"memory.get_config"}`,
+		`{"jsonrpc":"2.0","method":1}`,
+	} {
+		if result := h.Handle(context.Background(), []byte(req)); result != nil {
+			t.Errorf("%s: expected no response, got %s", req, result)
+		}
+	}
+
+	// 文字列・数値以外のIDはnullのIDでInvalid Request
+	for _, id := range []string{`true`, `{"a":1}`, `[1]`} {
+		result := h.Handle(context.Background(), []byte(`{"jsonrpc":"2.0","id":`+id+`,"method":"memory.get_config"}`))
+		resp := parseErrorResponse(t, result)
+		if resp.Error.Code != model.ErrCodeInvalidRequest || resp.ID != nil {
+			t.Errorf("id %s: expected Invalid Request with a null id, got %s", id, result)
+		}
+	}
+
+	// パースできないJSONはnullのIDでParse error
+	result := h.Handle(context.Background(), []byte(`{"jsonrpc":"2.0","id":"abc",`))
+	if resp := parseErrorResponse(t, result); resp.Error.Code != model.ErrCodeParseError || resp.ID != nil {
+		t.Errorf("expected Parse error with a null id, got %s", result)
+	}
+
+	// 不正なフィールドはIDを返すInvalid Request
+	result = h.Handle(context.Background(), []byte(`{"jsonrpc":"2.0","id":"req-2","method":1}`))
+	if resp := parseErrorResponse(t, result); resp.Error.Code != model.ErrCodeInvalidRequest || resp.ID != "req-2" {
+		t.Errorf("expected Invalid Request with id req-2, got %s", result)
+	}
+}
+
 // === 2. ディスパッチ系テスト ===
 
 func TestHandle_MethodNotFound(t *testing.T) {
@@ -219,6 +372,27 @@ func TestHandle_MethodNotFound(t *testing.T) {
 	}
 }
 
+func TestHandle_SetServices(t *testing.T) {
+	h := newTestHandler()
+	h.SetServices(
+		&mockNoteService{
+			listRecentFunc: func(ctx context.Context, req *service.ListRecentRequest) (*service.ListRecentResponse, error) {
+				return &service.ListRecentResponse{Namespace: "reloaded-ns", Items: []service.ListRecentItem{}}, nil
+			},
+		},
+		&mockConfigService{},
+		&mockGlobalService{},
+		&mockGroupService{},
+	)
+
+	req := makeRequest("memory.list_recent", map[string]any{"projectId": "/test/project"})
+	resp := parseResponse(t, h.Handle(context.Background(), req))
+	resultMap := resp["result"].(map[string]any)
+	if resultMap["namespace"] != "reloaded-ns" {
+		t.Errorf("expected namespace from new service, got %v", resultMap["namespace"])
+	}
+}
+
 // === 3. memory.add_note テスト ===
 
 func TestHandle_AddNote_Success(t *testing.T) {
@@ -244,6 +418,261 @@ func TestHandle_AddNote_Success(t *testing.T) {
 	}
 }
 
+// TestHandle_AddNotes は一部の項目が失敗した場合にitemsで項目ごとの結果を返すことをテスト
+func TestHandle_AddNotes(t *testing.T) {
+	var captured *service.AddNotesRequest
+	noteSvc := &mockNoteService{
+		addNotesFunc: func(ctx context.Context, req *service.AddNotesRequest) (*service.AddNotesResponse, error) {
+			captured = req
+			return &service.AddNotesResponse{
+				Namespace: "test-ns",
+				Results:   []service.AddNoteResponse{{ID: "note-1", CanonicalProjectID: "/test/project"}},
+				Items: []service.ItemResult{
+					{Index: 0, ID: "note-1", Status: service.ItemOK},
+					{Index: 1, ID: "note-2", Status: service.ItemFailed, Err: fmt.Errorf("notes[1]: %w", service.ErrTextRequired)},
+					{Index: 2, ID: "note-3", Status: service.ItemPending},
+				},
+			}, service.ErrTextRequired
+		},
+	}
+	h := New(noteSvc, &mockConfigService{}, &mockGlobalService{}, &mockGroupService{})
+	params := map[string]any{"notes": []map[string]any{
+		{"projectId": "/test/project", "groupId": "global", "text": "first"},
+		{"projectId": "/test/project", "groupId": "global", "text": ""},
+		{"projectId": "/test/project", "groupId": "global", "text": "third"},
+	}}
+	resp := parseResponse(t, h.Handle(context.Background(), makeRequest("memory.add_notes", params)))
+	if resp["error"] != nil {
+		t.Fatalf("unexpected error: %v", resp["error"])
+	}
+	if len(captured.Notes) != 3 || captured.Notes[2].Text != "third" {
+		t.Errorf("unexpected request: %+v", captured)
+	}
+
+	var result AddNotesResult
+	b, _ := json.Marshal(resp["result"])
+	if err := json.Unmarshal(b, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Added != 1 || len(result.Items) != 3 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if item := result.Items[1]; item.Status != "failed" || item.Code != model.ErrCodeInvalidParams || item.Message == "" {
+		t.Errorf("expected an Invalid params failure, got %+v", item)
+	}
+	if item := result.Items[2]; item.Status != "pending" || item.Code != 0 {
+		t.Errorf("expected a pending item without an error, got %+v", item)
+	}
+
+	// 全項目に共通の失敗（failedの項目がない）はエラーとして返す
+	noteSvc.addNotesFunc = func(ctx context.Context, req *service.AddNotesRequest) (*service.AddNotesResponse, error) {
+		return &service.AddNotesResponse{Items: []service.ItemResult{{Status: service.ItemPending}}}, errors.New("embedding failed")
+	}
+	errResp := parseErrorResponse(t, h.Handle(context.Background(), makeRequest("memory.add_notes", params)))
+	if errResp.Error.Code != model.ErrCodeInternalError {
+		t.Errorf("expected an internal error, got %+v", errResp.Error)
+	}
+}
+
+// TestHandle_GroupDelete_Partial はノートの処理だけが残った場合に、エラーにせず件数と原因を返すことをテスト
+func TestHandle_GroupDelete_Partial(t *testing.T) {
+	groupSvc := &mockGroupService{
+		deleteFunc: func(ctx context.Context, req *service.DeleteGroupRequest) (*service.DeleteGroupResponse, error) {
+			return &service.DeleteGroupResponse{Notes: 3, Failed: "group"}, errors.New("failed to delete group: disk full")
+		},
+	}
+	h := New(&mockNoteService{}, &mockConfigService{}, &mockGlobalService{}, groupSvc)
+	params := map[string]any{"id": "group-1", "cascade": "move"}
+
+	resp := parseResponse(t, h.Handle(context.Background(), makeRequest("memory.group_delete", params)))
+	if resp["error"] != nil {
+		t.Fatalf("unexpected error: %v", resp["error"])
+	}
+	var result GroupDeleteResult
+	b, _ := json.Marshal(resp["result"])
+	if err := json.Unmarshal(b, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.OK || result.Notes != 3 || result.Code != model.ErrCodeInternalError || result.Message == "" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	// トランザクションで取り消した場合はエラーとして返す
+	groupSvc.deleteFunc = func(ctx context.Context, req *service.DeleteGroupRequest) (*service.DeleteGroupResponse, error) {
+		return &service.DeleteGroupResponse{Failed: "group", RolledBack: true}, errors.New("failed to delete group: disk full")
+	}
+	errResp := parseErrorResponse(t, h.Handle(context.Background(), makeRequest("memory.group_delete", params)))
+	if errResp.Error.Code != model.ErrCodeInternalError {
+		t.Errorf("expected an internal error, got %+v", errResp.Error)
+	}
+}
+
+// TestHandle_GroupRename_Partial はノートの書き換えだけが残った場合に、エラーにせず件数と原因を返すことをテスト
+func TestHandle_GroupRename_Partial(t *testing.T) {
+	groupSvc := &mockGroupService{
+		renameFunc: func(ctx context.Context, req *service.RenameGroupRequest) (*service.RenameGroupResponse, error) {
+			return &service.RenameGroupResponse{Notes: 2}, errors.New("failed to rename group: disk full")
+		},
+	}
+	h := New(&mockNoteService{}, &mockConfigService{}, &mockGlobalService{}, groupSvc)
+	params := map[string]any{"id": "group-1", "groupKey": "adr"}
+
+	resp := parseResponse(t, h.Handle(context.Background(), makeRequest("memory.group_rename", params)))
+	if resp["error"] != nil {
+		t.Fatalf("unexpected error: %v", resp["error"])
+	}
+	var result GroupRenameResult
+	b, _ := json.Marshal(resp["result"])
+	if err := json.Unmarshal(b, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.OK || result.Notes != 2 || result.Code != model.ErrCodeInternalError || result.Message == "" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	// ノートを書き換える前の失敗はエラーとして返す
+	groupSvc.renameFunc = func(ctx context.Context, req *service.RenameGroupRequest) (*service.RenameGroupResponse, error) {
+		return nil, service.ErrGroupKeyExists
+	}
+	errResp := parseErrorResponse(t, h.Handle(context.Background(), makeRequest("memory.group_rename", params)))
+	if errResp.Error.Code == 0 {
+		t.Errorf("expected an error, got %+v", errResp)
+	}
+}
+
+func TestHandle_AddNote_Attachments(t *testing.T) {
+	var captured []model.Attachment
+	h := newTestHandler()
+	h.noteService = &mockNoteService{
+		addNoteFunc: func(ctx context.Context, req *service.AddNoteRequest) (*service.AddNoteResponse, error) {
+			captured = req.Attachments
+			if err := service.ValidateAttachments(req.Attachments); err != nil {
+				return nil, err
+			}
+			return &service.AddNoteResponse{ID: "test-id", Namespace: "test-ns"}, nil
+		},
+	}
+	params := map[string]any{
+		"projectId":   "/test/project",
+		"groupId":     "global",
+		"text":        "test note",
+		"attachments": []map[string]any{{"path": "docs/design.md", "hash": "sha256:abcd"}},
+	}
+	resp := parseResponse(t, h.Handle(context.Background(), makeRequest("memory.add_note", params)))
+	if resp["error"] != nil {
+		t.Fatalf("unexpected error: %v", resp["error"])
+	}
+	if len(captured) != 1 || captured[0].Path != "docs/design.md" || captured[0].Hash != "sha256:abcd" {
+		t.Errorf("unexpected attachments: %+v", captured)
+	}
+
+	params["attachments"] = []map[string]any{{}}
+	errResp := parseErrorResponse(t, h.Handle(context.Background(), makeRequest("memory.add_note", params)))
+	if errResp.Error.Code != model.ErrCodeInvalidParams {
+		t.Errorf("expected code %d, got %d", model.ErrCodeInvalidParams, errResp.Error.Code)
+	}
+}
+
+func TestHandle_AddNote_Importance(t *testing.T) {
+	var captured *float64
+	h := newTestHandler()
+	h.noteService = &mockNoteService{
+		addNoteFunc: func(ctx context.Context, req *service.AddNoteRequest) (*service.AddNoteResponse, error) {
+			captured = req.Importance
+			if err := service.ValidateImportance(req.Importance); err != nil {
+				return nil, err
+			}
+			return &service.AddNoteResponse{ID: "test-id", Namespace: "test-ns"}, nil
+		},
+	}
+	params := map[string]any{
+		"projectId":  "/test/project",
+		"groupId":    "global",
+		"text":       "test note",
+		"importance": 0.9,
+	}
+	resp := parseResponse(t, h.Handle(context.Background(), makeRequest("memory.add_note", params)))
+	if resp["error"] != nil {
+		t.Fatalf("unexpected error: %v", resp["error"])
+	}
+	if captured == nil || *captured != 0.9 {
+		t.Errorf("unexpected importance: %v", captured)
+	}
+
+	params["importance"] = 2
+	errResp := parseErrorResponse(t, h.Handle(context.Background(), makeRequest("memory.add_note", params)))
+	if errResp.Error.Code != model.ErrCodeInvalidParams {
+		t.Errorf("expected code %d, got %d", model.ErrCodeInvalidParams, errResp.Error.Code)
+	}
+}
+
+func TestHandle_AddNote_TooLarge(t *testing.T) {
+	h := newTestHandler()
+	h.noteService = &mockNoteService{
+		addNoteFunc: func(ctx context.Context, req *service.AddNoteRequest) (*service.AddNoteResponse, error) {
+			return nil, service.CheckNoteLimits(nil, &req.Text, nil, nil)
+		},
+	}
+	params := map[string]any{
+		"projectId": "/test/project",
+		"groupId":   "global",
+		"text":      strings.Repeat("x", service.DefaultNoteLimits.MaxTextLength+1),
+	}
+	errResp := parseErrorResponse(t, h.Handle(context.Background(), makeRequest("memory.add_note", params)))
+	if errResp.Error.Code != model.ErrCodeInvalidParams || !strings.Contains(errResp.Error.Message, "exceeds size limit") {
+		t.Errorf("expected invalid params for a too large note, got %d %s", errResp.Error.Code, errResp.Error.Message)
+	}
+}
+
+func TestHandle_AddNote_Duplicate(t *testing.T) {
+	h := newTestHandler()
+	h.noteService = &mockNoteService{
+		addNoteFunc: func(ctx context.Context, req *service.AddNoteRequest) (*service.AddNoteResponse, error) {
+			if req.OnDuplicate != service.OnDuplicateReject || req.DuplicateThreshold == nil || *req.DuplicateThreshold != 0.9 {
+				t.Errorf("unexpected duplicate options: %q %v", req.OnDuplicate, req.DuplicateThreshold)
+			}
+			return nil, &service.DuplicateNoteError{ID: "existing-id", Score: 0.97}
+		},
+	}
+	params := map[string]any{
+		"projectId":          "/test/project",
+		"groupId":            "global",
+		"text":               "use tabs",
+		"onDuplicate":        "reject",
+		"duplicateThreshold": 0.9,
+	}
+	errResp := parseErrorResponse(t, h.Handle(context.Background(), makeRequest("memory.add_note", params)))
+	if errResp.Error.Code != model.ErrCodeConflict {
+		t.Fatalf("expected conflict, got %d %s", errResp.Error.Code, errResp.Error.Message)
+	}
+	data, ok := errResp.Error.Data.(map[string]any)
+	if !ok || data["duplicateId"] != "existing-id" || data["score"] != 0.97 {
+		t.Errorf("expected the duplicate in error data, got %v", errResp.Error.Data)
+	}
+}
+
+func TestHandle_AddNote_GroupKeyPrefix(t *testing.T) {
+	h := newTestHandler()
+	h.noteService = &mockNoteService{
+		addNoteFunc: func(ctx context.Context, req *service.AddNoteRequest) (*service.AddNoteResponse, error) {
+			return nil, &service.GroupKeyPrefixError{GroupKey: "login", Prefixes: []string{"feature-"}, Suggestions: []string{"feature-login"}}
+		},
+	}
+	params := map[string]any{
+		"projectId": "/test/project",
+		"groupId":   "login",
+		"text":      "note",
+	}
+	errResp := parseErrorResponse(t, h.Handle(context.Background(), makeRequest("memory.add_note", params)))
+	if errResp.Error.Code != model.ErrCodeInvalidParams {
+		t.Fatalf("expected invalid params, got %d %s", errResp.Error.Code, errResp.Error.Message)
+	}
+	data, ok := errResp.Error.Data.(map[string]any)
+	if !ok || data["groupKey"] != "login" || fmt.Sprint(data["prefixes"]) != "[feature-]" || fmt.Sprint(data["suggestions"]) != "[feature-login]" {
+		t.Errorf("expected the allowed prefixes in error data, got %v", errResp.Error.Data)
+	}
+}
+
 func TestHandle_AddNote_MissingProjectId(t *testing.T) {
 	h := newTestHandler()
 	h.noteService = &mockNoteService{
@@ -392,6 +821,57 @@ func TestHandle_Search_TopKDefault(t *testing.T) {
 	}
 }
 
+func TestHandle_Search_CollapseByParent(t *testing.T) {
+	var capturedReq *service.SearchRequest
+	h := newTestHandler()
+	h.noteService = &mockNoteService{
+		searchFunc: func(ctx context.Context, req *service.SearchRequest) (*service.SearchResponse, error) {
+			capturedReq = req
+			return &service.SearchResponse{Namespace: "test-ns", Results: []service.SearchResult{}}, nil
+		},
+	}
+	params := map[string]any{
+		"projectId":        "/test/project",
+		"query":            "test query",
+		"collapseByParent": true,
+	}
+	h.Handle(context.Background(), makeRequest("memory.search", params))
+
+	if !capturedReq.CollapseByParent {
+		t.Error("expected collapseByParent to be passed to the service")
+	}
+}
+
+func TestHandle_Search_GroupBy(t *testing.T) {
+	var capturedReq *service.SearchRequest
+	h := newTestHandler()
+	h.noteService = &mockNoteService{
+		searchFunc: func(ctx context.Context, req *service.SearchRequest) (*service.SearchResponse, error) {
+			capturedReq = req
+			return &service.SearchResponse{
+				Namespace: "test-ns",
+				Results:   []service.SearchResult{{ID: "n1", GroupID: "auth", Score: 0.9}},
+				Groups:    []service.SearchGroup{{GroupID: "auth", Count: 1, TopScore: 0.9}},
+			}, nil
+		},
+	}
+	params := map[string]any{
+		"projectId": "/test/project",
+		"query":     "test query",
+		"groupBy":   "group",
+		"groupTopK": 3,
+	}
+	resp := parseResponse(t, h.Handle(context.Background(), makeRequest("memory.search", params)))
+
+	if capturedReq.GroupBy != service.SearchGroupByGroup || capturedReq.GroupTopK == nil || *capturedReq.GroupTopK != 3 {
+		t.Errorf("expected groupBy/groupTopK to be passed to the service, got %+v", capturedReq)
+	}
+	groups, _ := resp["result"].(map[string]any)["groups"].([]any)
+	if len(groups) != 1 || groups[0].(map[string]any)["groupId"] != "auth" || groups[0].(map[string]any)["count"] != float64(1) {
+		t.Errorf("unexpected groups: %v", resp["result"])
+	}
+}
+
 func TestHandle_Search_MissingProjectId(t *testing.T) {
 	h := newTestHandler()
 	h.noteService = &mockNoteService{
@@ -492,6 +972,31 @@ func TestHandle_Get_Success(t *testing.T) {
 	}
 }
 
+func TestHandle_Get_Reassemble(t *testing.T) {
+	h := newTestHandler()
+	parentID, index := "parent-id", 0
+	h.noteService = &mockNoteService{
+		getDocumentFunc: func(ctx context.Context, id string) (*service.GetResponse, error) {
+			return &service.GetResponse{ID: parentID, Text: "chunk 0 chunk 1", ParentID: &parentID, ChunkIndex: &index}, nil
+		},
+	}
+
+	resp := parseResponse(t, h.Handle(context.Background(), makeRequest("memory.get", map[string]any{"id": "child-id", "reassemble": true})))
+	if resp["error"] != nil {
+		t.Fatalf("unexpected error: %v", resp["error"])
+	}
+	resultMap := resp["result"].(map[string]any)
+	if resultMap["text"] != "chunk 0 chunk 1" || resultMap["parentId"] != parentID || resultMap["chunkIndex"] != float64(0) {
+		t.Errorf("unexpected result: %v", resultMap)
+	}
+
+	// reassembleなしではチャンクの親子情報を含まない通常のノートを返す
+	resp = parseResponse(t, h.Handle(context.Background(), makeRequest("memory.get", map[string]any{"id": "child-id"})))
+	if resultMap := resp["result"].(map[string]any); resultMap["id"] != "child-id" || resultMap["parentId"] != nil {
+		t.Errorf("unexpected result: %v", resultMap)
+	}
+}
+
 func TestHandle_Get_MissingId(t *testing.T) {
 	h := newTestHandler()
 	h.noteService = &mockNoteService{
@@ -594,6 +1099,45 @@ func TestHandle_Update_NotFound(t *testing.T) {
 	}
 }
 
+func TestHandle_Update_IfUpdatedAt(t *testing.T) {
+	var capturedReq *service.UpdateRequest
+	h := newTestHandler()
+	h.noteService = &mockNoteService{
+		updateFunc: func(ctx context.Context, req *service.UpdateRequest) error {
+			capturedReq = req
+			if *req.IfUpdatedAt != "2024-01-01T00:00:00.000Z" {
+				return service.ErrNoteConflict
+			}
+			return nil
+		},
+		getFunc: func(ctx context.Context, id string) (*service.GetResponse, error) {
+			return &service.GetResponse{ID: id, UpdatedAt: "2024-01-02T00:00:00.000Z"}, nil
+		},
+	}
+
+	params := map[string]any{
+		"id":          "test-id",
+		"patch":       map[string]any{"text": "updated text"},
+		"ifUpdatedAt": "2024-01-01T00:00:00.000Z",
+	}
+	resp := parseResponse(t, h.Handle(context.Background(), makeRequest("memory.update", params)))
+	if resp["error"] != nil {
+		t.Fatalf("unexpected error: %v", resp["error"])
+	}
+	if capturedReq.IfUpdatedAt == nil {
+		t.Fatal("expected ifUpdatedAt to be passed to the service")
+	}
+	if updatedAt := resp["result"].(map[string]any)["updatedAt"]; updatedAt != "2024-01-02T00:00:00.000Z" {
+		t.Errorf("expected the new updatedAt in result, got %v", updatedAt)
+	}
+
+	params["ifUpdatedAt"] = "2023-12-31T00:00:00.000Z"
+	errResp := parseErrorResponse(t, h.Handle(context.Background(), makeRequest("memory.update", params)))
+	if errResp.Error.Code != model.ErrCodeConflict {
+		t.Errorf("expected code %d, got %d", model.ErrCodeConflict, errResp.Error.Code)
+	}
+}
+
 func TestHandle_Update_NullClear(t *testing.T) {
 	var capturedReq *service.UpdateRequest
 	h := newTestHandler()
@@ -671,6 +1215,41 @@ func TestHandle_ListRecent_Success(t *testing.T) {
 	}
 }
 
+func TestHandle_ListRecent_Cursor(t *testing.T) {
+	h := newTestHandler()
+	var got *service.ListRecentRequest
+	h.noteService = &mockNoteService{
+		listRecentFunc: func(ctx context.Context, req *service.ListRecentRequest) (*service.ListRecentResponse, error) {
+			got = req
+			if req.Cursor == "bad" {
+				return nil, service.ErrInvalidCursor
+			}
+			return &service.ListRecentResponse{Namespace: "test-ns", Items: []service.ListRecentItem{}, Total: 12, NextCursor: "next-page"}, nil
+		},
+	}
+
+	req := makeRequest("memory.list_recent", map[string]any{"projectId": "/test/project", "cursor": "page-2"})
+	resp := parseResponse(t, h.Handle(context.Background(), req))
+	if resp["error"] != nil {
+		t.Fatalf("unexpected error: %v", resp["error"])
+	}
+	if got.Cursor != "page-2" {
+		t.Errorf("expected the cursor to be passed, got %q", got.Cursor)
+	}
+	resultMap := resp["result"].(map[string]any)
+	if resultMap["total"] != float64(12) || resultMap["nextCursor"] != "next-page" {
+		t.Errorf("unexpected result: %v", resultMap)
+	}
+	if _, ok := resultMap["totalApproximate"]; ok {
+		t.Errorf("expected totalApproximate to be omitted: %v", resultMap)
+	}
+
+	req = makeRequest("memory.list_recent", map[string]any{"projectId": "/test/project", "cursor": "bad"})
+	if errResp := parseErrorResponse(t, h.Handle(context.Background(), req)); errResp.Error.Code != model.ErrCodeInvalidParams {
+		t.Errorf("expected code %d, got %d", model.ErrCodeInvalidParams, errResp.Error.Code)
+	}
+}
+
 func TestHandle_ListRecent_MissingProjectId(t *testing.T) {
 	h := newTestHandler()
 	h.noteService = &mockNoteService{
@@ -740,6 +1319,217 @@ func TestHandle_SetConfig_Success(t *testing.T) {
 	}
 }
 
+func TestHandle_SetConfig_Reinitialize(t *testing.T) {
+	h := newTestHandler()
+	var got *model.EmbedderConfig
+	gotDualRead := false
+	released := false
+	h.SetReinitializer(func(ctx context.Context, emb *model.EmbedderConfig, dualRead bool) (string, string, error) {
+		got, gotDualRead = emb, dualRead
+		// 処理中のリクエストから差し替えを予約する（デッドロックしないこと）
+		h.ReplaceServices(
+			&mockNoteService{
+				listRecentFunc: func(ctx context.Context, req *service.ListRecentRequest) (*service.ListRecentResponse, error) {
+					return &service.ListRecentResponse{Namespace: "openai:text-embedding-3-large:0", Items: []service.ListRecentItem{}}, nil
+				},
+			},
+			&mockConfigService{},
+			&mockGlobalService{},
+			&mockGroupService{},
+			func() { released = true },
+		)
+		return "openai:text-embedding-3-small:0", "openai:text-embedding-3-large:0", nil
+	})
+	h.configService = &mockConfigService{
+		setConfigFunc: func(ctx context.Context, req *service.SetConfigRequest) (*service.SetConfigResponse, error) {
+			return &service.SetConfigResponse{
+				OK:                 true,
+				EffectiveNamespace: "openai:text-embedding-3-large:0",
+				PreviousNamespace:  "openai:text-embedding-3-small:1536",
+				ReembedRequired:    true,
+			}, nil
+		},
+	}
+
+	h.SetMigrateService(&mockMigrateService{counts: map[string]int{"openai:text-embedding-3-small:0": 3}})
+
+	req := makeRequest("memory.set_config", map[string]any{"embedder": map[string]any{"model": "text-embedding-3-large"}, "dualRead": true})
+	resp := parseResponse(t, h.Handle(context.Background(), req))
+	resultMap := resp["result"].(map[string]any)
+	// namespaceはサービスが実際に使っているもの（設定のdimではない）
+	if resultMap["reembedRequired"] != true || resultMap["previousNamespace"] != "openai:text-embedding-3-small:0" {
+		t.Errorf("unexpected result: %v", resultMap)
+	}
+	if resultMap["previousNoteCount"] != float64(3) || resultMap["dualRead"] != true {
+		t.Errorf("expected previousNoteCount 3 and dualRead, got %v", resultMap)
+	}
+	if got == nil || got.Provider != "openai" || got.Model != "text-embedding-3-large" || got.Dim != 0 || !gotDualRead {
+		t.Errorf("unexpected embedder passed to reinitializer: %+v (dualRead %v)", got, gotDualRead)
+	}
+	// 応答を返した時点で差し替え済み
+	if !released {
+		t.Error("expected the old services to be released")
+	}
+	req = makeRequest("memory.list_recent", map[string]any{"projectId": "/test/project"})
+	resp = parseResponse(t, h.Handle(context.Background(), req))
+	if ns := resp["result"].(map[string]any)["namespace"]; ns != "openai:text-embedding-3-large:0" {
+		t.Errorf("expected namespace from re-initialized services, got %v", ns)
+	}
+}
+
+// TestHandle_StoreUnavailable はストアの一時的な障害をStore unavailableとして返すことをテスト
+func TestHandle_StoreUnavailable(t *testing.T) {
+	searchErr := fmt.Errorf("failed to search: %w", store.ErrCircuitOpen)
+	h := New(&mockNoteService{
+		searchFunc: func(ctx context.Context, req *service.SearchRequest) (*service.SearchResponse, error) {
+			return nil, searchErr
+		},
+	}, &mockConfigService{}, &mockGlobalService{}, &mockGroupService{})
+
+	req := makeRequest("memory.search", map[string]any{"projectId": "/test/project", "query": "test"})
+	resp := parseErrorResponse(t, h.Handle(context.Background(), req))
+	if resp.Error.Code != model.ErrCodeStoreUnavailable {
+		t.Errorf("expected code %d, got %d", model.ErrCodeStoreUnavailable, resp.Error.Code)
+	}
+
+	// それ以外のストアのエラーはInternal error
+	searchErr = errors.New("failed to search: corrupted index")
+	resp = parseErrorResponse(t, h.Handle(context.Background(), req))
+	if resp.Error.Code != model.ErrCodeInternalError {
+		t.Errorf("expected code %d, got %d", model.ErrCodeInternalError, resp.Error.Code)
+	}
+}
+
+func TestHandle_SetConfig_ReinitializeError(t *testing.T) {
+	h := newTestHandler()
+	h.SetReinitializer(func(ctx context.Context, emb *model.EmbedderConfig, dualRead bool) (string, string, error) {
+		return "", "", errors.New("unknown provider")
+	})
+	setCalled := false
+	h.configService = &mockConfigService{
+		setConfigFunc: func(ctx context.Context, req *service.SetConfigRequest) (*service.SetConfigResponse, error) {
+			setCalled = true
+			return &service.SetConfigResponse{OK: true}, nil
+		},
+	}
+
+	req := makeRequest("memory.set_config", map[string]any{"embedder": map[string]any{"provider": "cohere"}})
+	resp := parseErrorResponse(t, h.Handle(context.Background(), req))
+	if resp.Error.Code != model.ErrCodeInternalError {
+		t.Errorf("expected code %d, got %d", model.ErrCodeInternalError, resp.Error.Code)
+	}
+	if setCalled {
+		t.Error("config must not be changed when re-initialization fails")
+	}
+
+	// embedderが変わらない場合は作り直さない
+	req = makeRequest("memory.set_config", map[string]any{"embedder": map[string]any{"model": "text-embedding-3-small"}})
+	if resp := parseResponse(t, h.Handle(context.Background(), req)); resp["error"] != nil {
+		t.Errorf("unexpected error: %v", resp["error"])
+	}
+}
+
+func TestHandle_Migrate(t *testing.T) {
+	h := newTestHandler()
+
+	// MigrateService未設定
+	req := makeRequest("memory.migrate", map[string]any{"from": "openai:text-embedding-3-small:1536"})
+	if resp := parseErrorResponse(t, h.Handle(context.Background(), req)); resp.Error.Code != model.ErrCodeInternalError {
+		t.Errorf("expected code %d, got %d", model.ErrCodeInternalError, resp.Error.Code)
+	}
+
+	var gotReq *service.MigrateRequest
+	h.SetMigrateService(&mockMigrateService{
+		migrateFunc: func(ctx context.Context, req *service.MigrateRequest) (*service.MigrateResponse, error) {
+			gotReq = req
+			if req.From == "" {
+				return nil, service.ErrNamespaceRequired
+			}
+			return &service.MigrateResponse{From: req.From, Namespace: "openai:text-embedding-3-large:0", Projects: 1, Created: 4, Skipped: 1, ReEmbedded: 3}, nil
+		},
+	})
+
+	// previousEmbedderがなくfromも省略した場合はinvalid params
+	req = makeRequest("memory.migrate", map[string]any{})
+	if resp := parseErrorResponse(t, h.Handle(context.Background(), req)); resp.Error.Code != model.ErrCodeInvalidParams {
+		t.Errorf("expected code %d, got %d", model.ErrCodeInvalidParams, resp.Error.Code)
+	}
+
+	// fromを省略した場合はpreviousEmbedderのnamespace
+	h.configService = &mockConfigService{
+		getConfigFunc: func(ctx context.Context) (*service.GetConfigResponse, error) {
+			return &service.GetConfigResponse{
+				Embedder:         model.EmbedderConfig{Provider: "openai", Model: "text-embedding-3-large"},
+				PreviousEmbedder: &model.EmbedderConfig{Provider: "openai", Model: "text-embedding-3-small", Dim: 1536},
+			}, nil
+		},
+	}
+	req = makeRequest("memory.migrate", map[string]any{"projectId": "/test/project"})
+	resp := parseResponse(t, h.Handle(context.Background(), req))
+	if resp["error"] != nil {
+		t.Fatalf("unexpected error: %v", resp["error"])
+	}
+	if gotReq.From != "openai:text-embedding-3-small:1536" || gotReq.ProjectID != "/test/project" {
+		t.Errorf("unexpected request: %+v", gotReq)
+	}
+	result := resp["result"].(map[string]any)
+	if result["created"] != float64(4) || result["reEmbedded"] != float64(3) || result["namespace"] != "openai:text-embedding-3-large:0" {
+		t.Errorf("unexpected result: %v", result)
+	}
+
+	// get_configにもpreviousEmbedderが含まれる
+	resp = parseResponse(t, h.Handle(context.Background(), makeRequest("memory.get_config", nil)))
+	prev, ok := resp["result"].(map[string]any)["previousEmbedder"].(map[string]any)
+	if !ok || prev["model"] != "text-embedding-3-small" {
+		t.Errorf("expected previousEmbedder in get_config, got %v", resp["result"])
+	}
+}
+
+func TestHandle_AddProjectAlias(t *testing.T) {
+	h := newTestHandler()
+	h.configService = &mockConfigService{
+		addAliasFunc: func(ctx context.Context, req *service.AddProjectAliasRequest) (*service.AddProjectAliasResponse, error) {
+			if req.Path == "" {
+				return nil, service.ErrPathRequired
+			}
+			return &service.AddProjectAliasResponse{Path: "/ci/checkout", ProjectID: req.ProjectID}, nil
+		},
+	}
+
+	req := makeRequest("memory.add_project_alias", map[string]any{"path": "/ci/checkout/", "projectId": "github.com/org/repo"})
+	resp := parseResponse(t, h.Handle(context.Background(), req))
+	if resp["error"] != nil {
+		t.Fatalf("unexpected error: %v", resp["error"])
+	}
+	result := resp["result"].(map[string]any)
+	if result["ok"] != true || result["path"] != "/ci/checkout" || result["projectId"] != "github.com/org/repo" {
+		t.Errorf("unexpected result: %v", result)
+	}
+
+	req = makeRequest("memory.add_project_alias", map[string]any{"projectId": "github.com/org/repo"})
+	if resp := parseErrorResponse(t, h.Handle(context.Background(), req)); resp.Error.Code != model.ErrCodeInvalidParams {
+		t.Errorf("expected code %d, got %d", model.ErrCodeInvalidParams, resp.Error.Code)
+	}
+}
+
+func TestHandler_ReplaceServices_Merge(t *testing.T) {
+	h := newTestHandler()
+	var released []string
+	h.ReplaceServices(&mockNoteService{}, &mockConfigService{}, &mockGlobalService{}, &mockGroupService{}, func() { released = append(released, "first") })
+	h.ReplaceServices(&mockNoteService{}, &mockConfigService{}, &mockGlobalService{}, &mockGroupService{}, func() { released = append(released, "second") })
+	if len(released) != 0 {
+		t.Fatalf("released before apply: %v", released)
+	}
+	h.ApplyPendingServices()
+	if len(released) != 2 || released[0] != "first" || released[1] != "second" {
+		t.Errorf("expected both releases, got %v", released)
+	}
+	h.ApplyPendingServices() // 予約がなければ何もしない
+	if len(released) != 2 {
+		t.Errorf("unexpected release: %v", released)
+	}
+}
+
 func TestHandle_SetConfig_EmptyParams(t *testing.T) {
 	h := newTestHandler()
 	params := map[string]any{}
@@ -952,6 +1742,77 @@ func TestHandle_GetGlobal_EmptyKey(t *testing.T) {
 	}
 }
 
+func TestHandle_GetGlobalHistory(t *testing.T) {
+	updatedAt := "2024-01-15T10:30:00Z"
+	h := newTestHandler()
+	h.globalService = &mockGlobalService{
+		getHistoryFunc: func(ctx context.Context, projectID, key string) (*service.GetGlobalHistoryResponse, error) {
+			if key != "global.project.conventions" {
+				t.Errorf("unexpected key: %s", key)
+			}
+			return &service.GetGlobalHistoryResponse{
+				Namespace: "test-ns",
+				Found:     true,
+				History:   []model.GlobalConfigVersion{{Value: "tabs", UpdatedAt: &updatedAt, UpdatedBy: "alice"}},
+			}, nil
+		},
+	}
+	params := map[string]any{
+		"projectId": "/test/project",
+		"key":       "global.project.conventions",
+	}
+	resp := parseResponse(t, h.Handle(context.Background(), makeRequest("memory.get_global_history", params)))
+	if resp["error"] != nil {
+		t.Fatalf("unexpected error: %v", resp["error"])
+	}
+	history := resp["result"].(map[string]any)["history"].([]any)
+	item := history[0].(map[string]any)
+	if len(history) != 1 || item["value"] != "tabs" || item["updatedAt"] != updatedAt || item["updatedBy"] != "alice" {
+		t.Errorf("unexpected history: %v", history)
+	}
+
+	errResp := parseErrorResponse(t, h.Handle(context.Background(), makeRequest("memory.get_global_history", map[string]any{"projectId": "/test/project"})))
+	if errResp.Error.Code != model.ErrCodeInvalidParams {
+		t.Errorf("expected code %d, got %d", model.ErrCodeInvalidParams, errResp.Error.Code)
+	}
+}
+
+func TestHandle_ImportGlobals(t *testing.T) {
+	h := newTestHandler()
+	var got *service.ImportGlobalsRequest
+	h.globalService = &mockGlobalService{
+		importFunc: func(ctx context.Context, req *service.ImportGlobalsRequest) (*service.ImportGlobalsResponse, error) {
+			got = req
+			if req.Mode == service.ImportModeFail {
+				return nil, fmt.Errorf("%w: global.project.conventions", service.ErrImportConflict)
+			}
+			return &service.ImportGlobalsResponse{Namespace: "test-ns", ProjectID: req.ProjectID, Updated: 1}, nil
+		},
+	}
+	params := map[string]any{
+		"projectId": "/test/other",
+		"globals":   []any{map[string]any{"key": "global.project.conventions", "value": "tabs", "updatedBy": "alice"}},
+		"mode":      "overwrite",
+	}
+	resp := parseResponse(t, h.Handle(context.Background(), makeRequest("memory.import_globals", params)))
+	if resp["error"] != nil {
+		t.Fatalf("unexpected error: %v", resp["error"])
+	}
+	if result := resp["result"].(map[string]any); result["updated"] != float64(1) || result["projectId"] != "/test/other" {
+		t.Errorf("unexpected result: %v", result)
+	}
+	if len(got.Globals) != 1 || got.Globals[0].Value != "tabs" || got.Globals[0].UpdatedBy != "alice" || got.Mode != service.ImportModeOverwrite {
+		t.Errorf("unexpected request: %+v", got)
+	}
+
+	// 既存のkeyがあればconflict
+	delete(params, "mode")
+	errResp := parseErrorResponse(t, h.Handle(context.Background(), makeRequest("memory.import_globals", params)))
+	if errResp.Error.Code != model.ErrCodeConflict {
+		t.Errorf("expected code %d, got %d", model.ErrCodeConflict, errResp.Error.Code)
+	}
+}
+
 // === 12. 境界値テスト（空文字） ===
 
 func TestHandle_Get_EmptyId(t *testing.T) {
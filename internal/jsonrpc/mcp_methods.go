@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/session"
 )
 
 // ServerVersion はサーバーのバージョン（ビルド時に設定可能）
@@ -19,6 +20,19 @@ func (h *Handler) handleInitialize(ctx context.Context, params any) (any, error)
 		return nil, err
 	}
 
+	// クライアント情報とデフォルト値をセッションに記録
+	if sess := session.FromContext(ctx); sess != nil {
+		info := session.Info{
+			ClientName:    p.ClientInfo.Name,
+			ClientVersion: p.ClientInfo.Version,
+		}
+		if p.Session != nil {
+			info.DefaultProjectID = p.Session.ProjectID
+			info.DefaultGroupID = p.Session.GroupID
+		}
+		sess.Update(info)
+	}
+
 	return &model.InitializeResult{
 		ProtocolVersion: "2024-11-05",
 		ServerInfo: model.ServerInfo{
@@ -67,8 +81,8 @@ func (h *Handler) handleToolsCall(ctx context.Context, id any, params any) (any,
 		}, nil
 	}
 
-	// 内部メソッドを呼び出す
-	result, err := h.dispatchInternal(ctx, id, internalMethod, p.Arguments)
+	// 内部メソッドを呼び出す（トークンの権限は内部メソッドで確認する）
+	result, err := h.authorizedDispatchInternal(ctx, id, internalMethod, p.Arguments)
 	if err != nil {
 		// エラーをcontentに含める（MCP仕様）
 		return &model.ToolsCallResult{
@@ -97,11 +111,21 @@ func (h *Handler) handleToolsCall(ctx context.Context, id any, params any) (any,
 	}, nil
 }
 
+// authorizedDispatchInternal はトークンの権限を確認してから内部メソッドを呼び出す
+func (h *Handler) authorizedDispatchInternal(ctx context.Context, id any, method string, params any) (any, error) {
+	if err := h.authorize(ctx, method, params); err != nil {
+		return nil, err
+	}
+	return h.dispatchInternal(ctx, id, method, params)
+}
+
 // dispatchInternal は内部メソッドを直接呼び出す（tools/call用）
 func (h *Handler) dispatchInternal(ctx context.Context, id any, method string, params any) (any, error) {
 	switch method {
 	case "memory.add_note":
 		return h.handleAddNote(ctx, params)
+	case "memory.add_notes":
+		return h.handleAddNotes(ctx, params)
 	case "memory.search":
 		return h.handleSearch(ctx, params)
 	case "memory.get":
@@ -112,12 +136,28 @@ func (h *Handler) dispatchInternal(ctx context.Context, id any, method string, p
 		return h.handleListRecent(ctx, params)
 	case "memory.get_config":
 		return h.handleGetConfig(ctx)
+	case "memory.server_info":
+		return h.handleServerInfo(ctx)
+	case "memory.stats":
+		return h.handleStats(ctx)
 	case "memory.set_config":
 		return h.handleSetConfig(ctx, params)
+	case "memory.archive":
+		return h.handleArchive(ctx, params)
+	case "memory.restore":
+		return h.handleRestore(ctx, params)
+	case "memory.list_archived":
+		return h.handleListArchived(ctx, params)
 	case "memory.upsert_global":
 		return h.handleUpsertGlobal(ctx, params)
 	case "memory.get_global":
 		return h.handleGetGlobal(ctx, params)
+	case "memory.get_global_history":
+		return h.handleGetGlobalHistory(ctx, params)
+	case "memory.export_globals":
+		return h.handleExportGlobals(ctx, params)
+	case "memory.import_globals":
+		return h.handleImportGlobals(ctx, params)
 	case "memory.delete":
 		return h.handleDelete(ctx, params)
 	case "memory.group_create":
@@ -128,10 +168,11 @@ func (h *Handler) dispatchInternal(ctx context.Context, id any, method string, p
 		return h.handleGroupUpdate(ctx, params)
 	case "memory.group_delete":
 		return h.handleGroupDelete(ctx, params)
+	case "memory.group_rename":
+		return h.handleGroupRename(ctx, params)
 	case "memory.group_list":
 		return h.handleGroupList(ctx, params)
 	default:
 		return nil, &methodNotFoundError{method: method}
 	}
 }
-
@@ -67,27 +67,38 @@ func TestHandle_ToolsList_Success(t *testing.T) {
 	resultMap := resp["result"].(map[string]any)
 	tools := resultMap["tools"].([]any)
 
-	// 15個のツールがあることを確認
-	if len(tools) != 15 {
-		t.Errorf("expected 15 tools, got %d", len(tools))
+	// 27個のツールがあることを確認
+	if len(tools) != 27 {
+		t.Errorf("expected 27 tools, got %d", len(tools))
 	}
 
 	// ツール名を確認（ドットはアンダースコアに変換）
 	expectedTools := []string{
 		"memory_add_note",
+		"memory_add_notes",
 		"memory_search",
 		"memory_get",
 		"memory_update",
 		"memory_delete",
 		"memory_list_recent",
 		"memory_get_config",
+		"memory_server_info",
+		"memory_stats",
 		"memory_set_config",
+		"memory_migrate",
+		"memory_archive",
+		"memory_restore",
+		"memory_list_archived",
 		"memory_upsert_global",
 		"memory_get_global",
+		"memory_get_global_history",
+		"memory_export_globals",
+		"memory_import_globals",
 		"memory_group_create",
 		"memory_group_get",
 		"memory_group_update",
 		"memory_group_delete",
+		"memory_group_rename",
 		"memory_group_list",
 	}
 
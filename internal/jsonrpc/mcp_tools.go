@@ -2,6 +2,63 @@ package jsonrpc
 
 import "github.com/brbranch/embedding_mcp/internal/model"
 
+// attachmentsSchema はノートのattachments（ファイルへの参照）のスキーマ
+var attachmentsSchema = model.JSONSchema{
+	Type:        "array",
+	Description: "Files the note refers to (design docs, diffs, ...). Each entry needs a path or a content hash",
+	Items: &model.JSONSchema{
+		Type: "object",
+		Properties: map[string]model.JSONSchema{
+			"path": {
+				Type:        "string",
+				Description: "File path (relative to the project or absolute)",
+			},
+			"hash": {
+				Type:        "string",
+				Description: "Content hash as <algorithm>:<hex> (e.g. sha256:...)",
+			},
+		},
+	},
+}
+
+// importanceSchema はノートのimportance（重要度）のスキーマ
+var importanceSchema = model.JSONSchema{
+	Type:        "number",
+	Description: "Optional importance from 0 to 1 (e.g. 1 for conventions and incident postmortems). Boosts the note's search score",
+}
+
+// modelSchema はノートを埋め込む追加embedderの名前（設定のembedders）のスキーマ
+var modelSchema = model.JSONSchema{
+	Type:        "string",
+	Description: "Optional name of an additional embedder from the server's embedders config (e.g. 'code' for code snippets). The note is stored in that embedder's namespace; search covers all namespaces",
+}
+
+// groupTagsSchema・groupStatusSchema・groupMetadataSchema・groupParentSchema はグループの注釈・親子関係のスキーマ
+var (
+	groupTagsSchema = model.JSONSchema{
+		Type:        "array",
+		Description: "Optional tags for the group",
+		Items:       &model.JSONSchema{Type: "string"},
+	}
+	groupStatusSchema = model.JSONSchema{
+		Type:        "string",
+		Description: "Group status: 'active' (default) or 'done'",
+		Enum:        []string{model.GroupStatusActive, model.GroupStatusDone},
+	}
+	groupMetadataSchema = model.JSONSchema{
+		Type:        "object",
+		Description: "Optional metadata such as sprint, owner or linked ticket",
+	}
+	groupParentSchema = model.JSONSchema{
+		Type:        "string",
+		Description: "Optional groupKey of the parent group in the same project (empty string makes it top-level)",
+	}
+	includeDescendantsSchema = model.JSONSchema{
+		Type:        "boolean",
+		Description: "With groupId, also include notes of its descendant groups (linked by parentGroupId)",
+	}
+)
+
 // mcpTools はMCPプロトコルで公開するツールのリスト
 var mcpTools = []model.Tool{
 	{
@@ -45,10 +102,78 @@ var mcpTools = []model.Tool{
 					Type:        "object",
 					Description: "Optional metadata as key-value pairs",
 				},
+				"attachments": attachmentsSchema,
+				"importance":  importanceSchema,
+				"model":       modelSchema,
+				"onDuplicate": {
+					Type:        "string",
+					Description: "What to do when a highly similar note already exists in the same project/group: 'reject' fails with the existing note's ID (error data.duplicateId), 'merge' merges the tags into the existing note instead of adding, 'proceed' adds anyway and reports duplicateId. Omit to skip the check",
+					Enum:        []string{"reject", "merge", "proceed"},
+				},
+				"duplicateThreshold": {
+					Type:        "number",
+					Description: "Similarity score (0-1) at or above which a note counts as a duplicate. Defaults to global.memory.duplicateThreshold (0.95)",
+				},
 			},
 			Required: []string{"projectId", "groupId", "text"},
 		},
 	},
+	{
+		Name:        "memory_add_notes",
+		Description: "Add several notes in one batch. Returns the status of each note (ok, failed with an error code and message, or pending when not added because another note failed); retry only the notes whose status is not ok",
+		InputSchema: model.JSONSchema{
+			Type: "object",
+			Properties: map[string]model.JSONSchema{
+				"notes": {
+					Type:        "array",
+					Description: "Notes to add (same fields as memory_add_note, without duplicate detection)",
+					Items: &model.JSONSchema{
+						Type: "object",
+						Properties: map[string]model.JSONSchema{
+							"projectId": {
+								Type:        "string",
+								Description: "Project ID (usually the working directory path)",
+							},
+							"groupId": {
+								Type:        "string",
+								Description: "Group ID for categorizing notes",
+							},
+							"text": {
+								Type:        "string",
+								Description: "The note content",
+							},
+							"title": {
+								Type:        "string",
+								Description: "Optional title for the note",
+							},
+							"tags": {
+								Type:        "array",
+								Description: "Optional tags for the note",
+								Items:       &model.JSONSchema{Type: "string"},
+							},
+							"source": {
+								Type:        "string",
+								Description: "Optional source reference",
+							},
+							"createdAt": {
+								Type:        "string",
+								Description: "Optional ISO8601 timestamp for the note creation time",
+							},
+							"metadata": {
+								Type:        "object",
+								Description: "Optional metadata as key-value pairs",
+							},
+							"attachments": attachmentsSchema,
+							"importance":  importanceSchema,
+							"model":       modelSchema,
+						},
+						Required: []string{"projectId", "groupId", "text"},
+					},
+				},
+			},
+			Required: []string{"notes"},
+		},
+	},
 	{
 		Name:        "memory_search",
 		Description: "Search notes by semantic similarity",
@@ -59,6 +184,13 @@ var mcpTools = []model.Tool{
 					Type:        "string",
 					Description: "Project ID to search within",
 				},
+				"projectIds": {
+					Type:        "array",
+					Description: "Optional additional project IDs to search across; each project is searched concurrently and results are merged by score (cannot be combined with groupBy)",
+					Items: &model.JSONSchema{
+						Type: "string",
+					},
+				},
 				"query": {
 					Type:        "string",
 					Description: "Search query text",
@@ -87,6 +219,23 @@ var mcpTools = []model.Tool{
 					Type:        "string",
 					Description: "Optional ISO8601 timestamp to filter notes created before this time",
 				},
+				"minScore": {
+					Type:        "number",
+					Description: "Optional minimum similarity score (0-1) of returned results",
+				},
+				"collapseByParent": {
+					Type:        "boolean",
+					Description: "Return only the best-scoring chunk of each chunked document so one document does not take all topK slots",
+				},
+				"language": {
+					Type:        "string",
+					Description: "Optional language detected when the note was added (ja, en, zh, ko, ru; stored as metadata.language)",
+				},
+				"includeDescendants": includeDescendantsSchema,
+				"includeArchived": {
+					Type:        "boolean",
+					Description: "Also search notes moved to the archive (returned with archived: true; restore them with memory_restore)",
+				},
 			},
 			Required: []string{"projectId", "query"},
 		},
@@ -101,6 +250,10 @@ var mcpTools = []model.Tool{
 					Type:        "string",
 					Description: "The note ID",
 				},
+				"reassemble": {
+					Type:        "boolean",
+					Description: "For a chunked document, return its parent note with the text of all chunks joined in order",
+				},
 			},
 			Required: []string{"id"},
 		},
@@ -155,8 +308,14 @@ var mcpTools = []model.Tool{
 								{Type: "null"},
 							},
 						},
+						"attachments": attachmentsSchema,
+						"importance":  importanceSchema,
 					},
 				},
+				"ifUpdatedAt": {
+					Type:        "string",
+					Description: "Only update if the note's updatedAt still equals this value (from get/list/search); fails with a conflict error otherwise",
+				},
 			},
 			Required: []string{"id", "patch"},
 		},
@@ -201,6 +360,12 @@ var mcpTools = []model.Tool{
 						Type: "string",
 					},
 				},
+				"sortBy": {
+					Type:        "string",
+					Description: "Sort key, newest first (default: createdAt)",
+					Enum:        []string{"createdAt", "updatedAt"},
+				},
+				"includeDescendants": includeDescendantsSchema,
 			},
 			Required: []string{"projectId"},
 		},
@@ -213,6 +378,22 @@ var mcpTools = []model.Tool{
 			Properties: map[string]model.JSONSchema{},
 		},
 	},
+	{
+		Name:        "memory_server_info",
+		Description: "Get the server version, transports, store, namespace, embedder, uptime and enabled features",
+		InputSchema: model.JSONSchema{
+			Type:       "object",
+			Properties: map[string]model.JSONSchema{},
+		},
+	},
+	{
+		Name:        "memory_stats",
+		Description: "Get the note count of the current namespace per project and whether it exceeds the configured thresholds",
+		InputSchema: model.JSONSchema{
+			Type:       "object",
+			Properties: map[string]model.JSONSchema{},
+		},
+	},
 	{
 		Name:        "memory_set_config",
 		Description: "Update server configuration",
@@ -241,9 +422,99 @@ var mcpTools = []model.Tool{
 						},
 					},
 				},
+				"dualRead": {
+					Type:        "boolean",
+					Description: "If the namespace changes, keep reading notes of the previous namespace until they are migrated",
+				},
+			},
+		},
+	},
+	{
+		Name:        "memory_migrate",
+		Description: "Re-embed notes, groups and globals of a previous namespace into the current namespace (skips ones already migrated)",
+		InputSchema: model.JSONSchema{
+			Type: "object",
+			Properties: map[string]model.JSONSchema{
+				"from": {
+					Type:        "string",
+					Description: "Source namespace (provider:model:dim); defaults to the previous embedder's namespace",
+				},
+				"projectId": {
+					Type:        "string",
+					Description: "Migrate only this project (default: all projects)",
+				},
+			},
+		},
+	},
+	{
+		Name:        "memory_archive",
+		Description: "Move notes not accessed (searched, read or updated) for a number of days to the compressed archive, excluding them from normal search",
+		InputSchema: model.JSONSchema{
+			Type: "object",
+			Properties: map[string]model.JSONSchema{
+				"projectId": {
+					Type:        "string",
+					Description: "Archive only this project (default: all projects)",
+				},
+				"olderThanDays": {
+					Type:        "integer",
+					Description: "Archive notes not accessed in this many days (default: archive.afterDays of the config)",
+				},
+				"dryRun": {
+					Type:        "boolean",
+					Description: "Only list the notes that would be archived",
+				},
+			},
+		},
+	},
+	{
+		Name:        "memory_restore",
+		Description: "Move archived notes back so they are searchable and editable again",
+		InputSchema: model.JSONSchema{
+			Type: "object",
+			Properties: map[string]model.JSONSchema{
+				"ids": {
+					Type:        "array",
+					Description: "IDs of the archived notes",
+					Items: &model.JSONSchema{
+						Type: "string",
+					},
+				},
+			},
+			Required: []string{"ids"},
+		},
+	},
+	{
+		Name:        "memory_list_archived",
+		Description: "List archived notes (id, title, createdAt, archivedAt), oldest first",
+		InputSchema: model.JSONSchema{
+			Type: "object",
+			Properties: map[string]model.JSONSchema{
+				"projectId": {
+					Type:        "string",
+					Description: "List only this project (default: all projects)",
+				},
 			},
 		},
 	},
+	{
+		Name:        "memory_add_project_alias",
+		Description: "Map a path (CI checkout, worktree, another clone) to a logical projectId so memory follows the repository",
+		InputSchema: model.JSONSchema{
+			Type: "object",
+			Properties: map[string]model.JSONSchema{
+				"path": {
+					Type:        "string",
+					Description: "Path of the other checkout",
+				},
+				"projectId": {
+					Type:        "string",
+					Description: "Logical projectId the path belongs to (used as-is)",
+				},
+			},
+			Required: []string{"path", "projectId"},
+		},
+	},
 	{
 		Name:        "memory_upsert_global",
 		Description: "Create or update a global configuration value",
@@ -265,6 +536,10 @@ var mcpTools = []model.Tool{
 					Type:        "string",
 					Description: "Optional ISO8601 timestamp",
 				},
+				"updatedBy": {
+					Type:        "string",
+					Description: "Optional name of who made the change (kept in the history)",
+				},
 			},
 			Required: []string{"projectId", "key", "value"},
 		},
@@ -287,6 +562,78 @@ var mcpTools = []model.Tool{
 			Required: []string{"projectId", "key"},
 		},
 	},
+	{
+		Name:        "memory_get_global_history",
+		Description: "Get the previous values of a global configuration key (newest first, up to 20), to recover overwritten settings",
+		InputSchema: model.JSONSchema{
+			Type: "object",
+			Properties: map[string]model.JSONSchema{
+				"projectId": {
+					Type:        "string",
+					Description: "Project ID",
+				},
+				"key": {
+					Type:        "string",
+					Description: "Configuration key",
+				},
+			},
+			Required: []string{"projectId", "key"},
+		},
+	},
+	{
+		Name:        "memory_export_globals",
+		Description: "Export all global configuration values (global.*) of a project, e.g. to copy its conventions into another project",
+		InputSchema: model.JSONSchema{
+			Type: "object",
+			Properties: map[string]model.JSONSchema{
+				"projectId": {
+					Type:        "string",
+					Description: "Project ID",
+				},
+			},
+			Required: []string{"projectId"},
+		},
+	},
+	{
+		Name:        "memory_import_globals",
+		Description: "Import global configuration values into a project (the globals returned by memory_export_globals)",
+		InputSchema: model.JSONSchema{
+			Type: "object",
+			Properties: map[string]model.JSONSchema{
+				"projectId": {
+					Type:        "string",
+					Description: "Project ID to import into",
+				},
+				"globals": {
+					Type:        "array",
+					Description: "Global configuration values to import",
+					Items: &model.JSONSchema{
+						Type: "object",
+						Properties: map[string]model.JSONSchema{
+							"key": {
+								Type:        "string",
+								Description: "Configuration key (must start with 'global.')",
+							},
+							"value": {
+								Description: "Configuration value (any JSON type)",
+							},
+							"updatedBy": {
+								Type:        "string",
+								Description: "Who set the value",
+							},
+						},
+						Required: []string{"key", "value"},
+					},
+				},
+				"mode": {
+					Type:        "string",
+					Description: "How to handle keys that already exist (default: fail without writing anything)",
+					Enum:        []string{"skip-existing", "overwrite"},
+				},
+			},
+			Required: []string{"projectId", "globals"},
+		},
+	},
 	{
 		Name:        "memory_group_create",
 		Description: "Create a new group for organizing notes",
@@ -309,6 +656,10 @@ var mcpTools = []model.Tool{
 					Type:        "string",
 					Description: "Optional description for the group",
 				},
+				"tags":          groupTagsSchema,
+				"status":        groupStatusSchema,
+				"metadata":      groupMetadataSchema,
+				"parentGroupId": groupParentSchema,
 			},
 			Required: []string{"projectId", "groupKey", "title"},
 		},
@@ -349,6 +700,10 @@ var mcpTools = []model.Tool{
 							Type:        "string",
 							Description: "New description",
 						},
+						"tags":          groupTagsSchema,
+						"status":        groupStatusSchema,
+						"metadata":      groupMetadataSchema,
+						"parentGroupId": groupParentSchema,
 					},
 				},
 			},
@@ -365,13 +720,36 @@ var mcpTools = []model.Tool{
 					Type:        "string",
 					Description: "The group ID to delete",
 				},
+				"cascade": {
+					Type:        "string",
+					Description: "What to do with the group's notes: 'delete' them, 'move' them to global, or 'restrict' (refuse if any exist). Omit to keep them",
+					Enum:        []string{"delete", "move", "restrict"},
+				},
 			},
 			Required: []string{"id"},
 		},
 	},
+	{
+		Name:        "memory_group_rename",
+		Description: "Change a group's groupKey; notes and child groups referring to the old key are moved to the new one",
+		InputSchema: model.JSONSchema{
+			Type: "object",
+			Properties: map[string]model.JSONSchema{
+				"id": {
+					Type:        "string",
+					Description: "The group ID to rename",
+				},
+				"groupKey": {
+					Type:        "string",
+					Description: "New unique key for the group (alphanumeric, hyphen, underscore only; 'global' is reserved)",
+				},
+			},
+			Required: []string{"id", "groupKey"},
+		},
+	},
 	{
 		Name:        "memory_group_list",
-		Description: "List all groups in a project",
+		Description: "List all groups in a project with per-group note count, last note createdAt and total text length",
 		InputSchema: model.JSONSchema{
 			Type: "object",
 			Properties: map[string]model.JSONSchema{
@@ -387,19 +765,31 @@ var mcpTools = []model.Tool{
 
 // toolNameToMethod はMCPツール名から内部メソッド名へのマッピング
 var toolNameToMethod = map[string]string{
-	"memory_add_note":      "memory.add_note",
-	"memory_search":        "memory.search",
-	"memory_get":           "memory.get",
-	"memory_update":        "memory.update",
-	"memory_delete":        "memory.delete",
-	"memory_list_recent":   "memory.list_recent",
-	"memory_get_config":    "memory.get_config",
-	"memory_set_config":    "memory.set_config",
-	"memory_upsert_global": "memory.upsert_global",
-	"memory_get_global":    "memory.get_global",
-	"memory_group_create":  "memory.group_create",
-	"memory_group_get":     "memory.group_get",
-	"memory_group_update":  "memory.group_update",
-	"memory_group_delete":  "memory.group_delete",
-	"memory_group_list":    "memory.group_list",
+	"memory_add_note":           "memory.add_note",
+	"memory_add_notes":          "memory.add_notes",
+	"memory_search":             "memory.search",
+	"memory_get":                "memory.get",
+	"memory_update":             "memory.update",
+	"memory_delete":             "memory.delete",
+	"memory_list_recent":        "memory.list_recent",
+	"memory_get_config":         "memory.get_config",
+	"memory_server_info":        "memory.server_info",
+	"memory_stats":              "memory.stats",
+	"memory_set_config":         "memory.set_config",
+	"memory_migrate":            "memory.migrate",
+	"memory_archive":            "memory.archive",
+	"memory_restore":            "memory.restore",
+	"memory_list_archived":      "memory.list_archived",
+	"memory_add_project_alias":  "memory.add_project_alias",
+	"memory_upsert_global":      "memory.upsert_global",
+	"memory_get_global":         "memory.get_global",
+	"memory_get_global_history": "memory.get_global_history",
+	"memory_export_globals":     "memory.export_globals",
+	"memory_import_globals":     "memory.import_globals",
+	"memory_group_create":       "memory.group_create",
+	"memory_group_get":          "memory.group_get",
+	"memory_group_update":       "memory.group_update",
+	"memory_group_delete":       "memory.group_delete",
+	"memory_group_rename":       "memory.group_rename",
+	"memory_group_list":         "memory.group_list",
 }
@@ -3,8 +3,15 @@ package jsonrpc
 import (
 	"context"
 	"encoding/json"
+	"log/slog"
+	"reflect"
 
+	"github.com/brbranch/embedding_mcp/internal/audit"
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/model"
 	"github.com/brbranch/embedding_mcp/internal/service"
+	"github.com/brbranch/embedding_mcp/internal/session"
+	"github.com/brbranch/embedding_mcp/internal/webhook"
 )
 
 // handleAddNote は memory.add_note を処理
@@ -13,50 +20,137 @@ func (h *Handler) handleAddNote(ctx context.Context, params any) (any, error) {
 	if err := mapParams(params, &p); err != nil {
 		return nil, err
 	}
+	p.ProjectID = defaultProjectID(ctx, p.ProjectID)
+	p.GroupID = defaultGroupID(ctx, p.GroupID)
 
 	resp, err := h.noteService.AddNote(ctx, p.ToRequest())
 	if err != nil {
 		return nil, err
 	}
 
-	return map[string]any{
-		"id":                 resp.ID,
-		"namespace":          resp.Namespace,
-		"canonicalProjectId": resp.CanonicalProjectID,
+	// 類似ノートへマージした場合は既存ノートの更新
+	change, hookType := ChangeNoteAdded, model.WebhookEventNoteAdded
+	if resp.Merged {
+		change, hookType = ChangeNoteUpdated, model.WebhookEventNoteUpdated
+	}
+	h.notify(ChangeEvent{Type: change, ProjectID: resp.CanonicalProjectID, ID: resp.ID})
+	h.recordAudit(ctx, audit.Entry{Method: "memory.add_note", ProjectID: resp.CanonicalProjectID, ID: resp.ID})
+	h.sendWebhook(webhook.Event{Type: hookType, ProjectID: resp.CanonicalProjectID, GroupID: p.GroupID, ID: resp.ID, Title: derefString(p.Title)})
+
+	return &AddNoteResult{
+		ID:                 resp.ID,
+		Namespace:          resp.Namespace,
+		CanonicalProjectID: resp.CanonicalProjectID,
+		DuplicateID:        resp.DuplicateID,
+		DuplicateScore:     resp.DuplicateScore,
+		Merged:             resp.Merged,
+	}, nil
+}
+
+// handleAddNotes は memory.add_notes を処理
+// 一部の項目が失敗してもエラーにせず、itemsで項目ごとの結果を返す（全項目に共通の失敗はエラー）
+func (h *Handler) handleAddNotes(ctx context.Context, params any) (any, error) {
+	var p AddNotesParams
+	if err := mapParams(params, &p); err != nil {
+		return nil, err
+	}
+	for i := range p.Notes {
+		p.Notes[i].ProjectID = defaultProjectID(ctx, p.Notes[i].ProjectID)
+		p.Notes[i].GroupID = defaultGroupID(ctx, p.Notes[i].GroupID)
+	}
+
+	resp, err := h.noteService.AddNotes(ctx, p.ToRequest())
+	if err != nil && (resp == nil || !hasFailedItem(resp.Items)) {
+		return nil, err
+	}
+
+	// Webhookで送るgroupId・タイトルは追加したノートのリクエストから取る
+	requested := make(map[string]*AddNoteParams, len(resp.Items))
+	for _, item := range resp.Items {
+		requested[item.ID] = &p.Notes[item.Index]
+	}
+	for _, r := range resp.Results {
+		h.notify(ChangeEvent{Type: ChangeNoteAdded, ProjectID: r.CanonicalProjectID, ID: r.ID})
+		h.recordAudit(ctx, audit.Entry{Method: "memory.add_notes", ProjectID: r.CanonicalProjectID, ID: r.ID})
+		ev := webhook.Event{Type: model.WebhookEventNoteAdded, ProjectID: r.CanonicalProjectID, ID: r.ID}
+		if note := requested[r.ID]; note != nil {
+			ev.GroupID, ev.Title = note.GroupID, derefString(note.Title)
+		}
+		h.sendWebhook(ev)
+	}
+
+	items := make([]BatchItemResult, len(resp.Items))
+	for i, item := range resp.Items {
+		items[i] = BatchItemResult{Index: item.Index, ID: item.ID, Status: string(item.Status)}
+		if item.Err != nil {
+			rpcErr := h.mapError(nil, item.Err).Error
+			items[i].Code, items[i].Message = rpcErr.Code, rpcErr.Message
+		}
+	}
+	return &AddNotesResult{
+		Namespace: resp.Namespace,
+		Added:     len(resp.Results),
+		Items:     items,
 	}, nil
 }
 
+// hasFailedItem はitemsに失敗した項目があるかを返す
+func hasFailedItem(items []service.ItemResult) bool {
+	for _, item := range items {
+		if item.Status == service.ItemFailed {
+			return true
+		}
+	}
+	return false
+}
+
 // handleSearch は memory.search を処理
 func (h *Handler) handleSearch(ctx context.Context, params any) (any, error) {
 	var p SearchParams
 	if err := mapParams(params, &p); err != nil {
 		return nil, err
 	}
+	// projectIds指定時はセッションのデフォルトを検索対象に加えない
+	if len(p.ProjectIDs) == 0 {
+		p.ProjectID = defaultProjectID(ctx, p.ProjectID)
+	}
 
 	resp, err := h.noteService.Search(ctx, p.ToRequest())
 	if err != nil {
 		return nil, err
 	}
 
-	results := make([]map[string]any, len(resp.Results))
+	results := make([]SearchResultItem, len(resp.Results))
 	for i, r := range resp.Results {
-		results[i] = map[string]any{
-			"id":        r.ID,
-			"projectId": r.ProjectID,
-			"groupId":   r.GroupID,
-			"title":     r.Title,
-			"text":      r.Text,
-			"tags":      r.Tags,
-			"source":    r.Source,
-			"createdAt": r.CreatedAt,
-			"score":     r.Score,
-			"metadata":  r.Metadata,
+		results[i] = SearchResultItem{
+			ID:          r.ID,
+			ProjectID:   r.ProjectID,
+			GroupID:     r.GroupID,
+			Title:       r.Title,
+			Text:        r.Text,
+			Tags:        r.Tags,
+			Source:      r.Source,
+			CreatedAt:   r.CreatedAt,
+			UpdatedAt:   r.UpdatedAt,
+			Score:       r.Score,
+			Metadata:    r.Metadata,
+			Attachments: r.Attachments,
+			ParentID:    r.ParentID,
+			ChunkIndex:  r.ChunkIndex,
+			Importance:  r.Importance,
+			Archived:    r.Archived,
 		}
 	}
 
-	return map[string]any{
-		"namespace": resp.Namespace,
-		"results":   results,
+	var groups []SearchGroupResult
+	for _, g := range resp.Groups {
+		groups = append(groups, SearchGroupResult{GroupID: g.GroupID, Count: g.Count, TopScore: g.TopScore})
+	}
+
+	return &SearchResult{
+		Namespace: resp.Namespace,
+		Groups:    groups,
+		Results:   results,
 	}, nil
 }
 
@@ -67,22 +161,31 @@ func (h *Handler) handleGet(ctx context.Context, params any) (any, error) {
 		return nil, err
 	}
 
-	resp, err := h.noteService.Get(ctx, p.ID)
+	get := h.noteService.Get
+	if p.Reassemble {
+		get = h.noteService.GetDocument
+	}
+	resp, err := get(ctx, p.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	return map[string]any{
-		"id":        resp.ID,
-		"projectId": resp.ProjectID,
-		"groupId":   resp.GroupID,
-		"title":     resp.Title,
-		"text":      resp.Text,
-		"tags":      resp.Tags,
-		"source":    resp.Source,
-		"createdAt": resp.CreatedAt,
-		"namespace": resp.Namespace,
-		"metadata":  resp.Metadata,
+	return &NoteResult{
+		ID:          resp.ID,
+		ProjectID:   resp.ProjectID,
+		GroupID:     resp.GroupID,
+		Title:       resp.Title,
+		Text:        resp.Text,
+		Tags:        resp.Tags,
+		Source:      resp.Source,
+		CreatedAt:   resp.CreatedAt,
+		UpdatedAt:   resp.UpdatedAt,
+		Namespace:   resp.Namespace,
+		Metadata:    resp.Metadata,
+		Attachments: resp.Attachments,
+		ParentID:    resp.ParentID,
+		ChunkIndex:  resp.ChunkIndex,
+		Importance:  resp.Importance,
 	}, nil
 }
 
@@ -102,9 +205,17 @@ func (h *Handler) handleUpdate(ctx context.Context, params any) (any, error) {
 		return nil, err
 	}
 
-	return map[string]any{
-		"ok": true,
-	}, nil
+	// 更新後のupdatedAtを返す（取得できなければ省略）
+	result := &UpdateResult{OK: true}
+	entry := audit.Entry{Method: "memory.update", ID: req.ID}
+	if resp, err := h.noteService.Get(ctx, req.ID); err == nil {
+		result.UpdatedAt = resp.UpdatedAt
+		entry.ProjectID = resp.ProjectID
+		h.notify(ChangeEvent{Type: ChangeNoteUpdated, ProjectID: resp.ProjectID, ID: req.ID})
+		h.sendWebhook(webhook.Event{Type: model.WebhookEventNoteUpdated, ProjectID: resp.ProjectID, GroupID: resp.GroupID, ID: req.ID, Title: derefString(resp.Title)})
+	}
+	h.recordAudit(ctx, entry)
+	return result, nil
 }
 
 // handleListRecent は memory.list_recent を処理
@@ -113,31 +224,40 @@ func (h *Handler) handleListRecent(ctx context.Context, params any) (any, error)
 	if err := mapParams(params, &p); err != nil {
 		return nil, err
 	}
+	p.ProjectID = defaultProjectID(ctx, p.ProjectID)
 
 	resp, err := h.noteService.ListRecent(ctx, p.ToRequest())
 	if err != nil {
 		return nil, err
 	}
 
-	items := make([]map[string]any, len(resp.Items))
+	items := make([]NoteResult, len(resp.Items))
 	for i, item := range resp.Items {
-		items[i] = map[string]any{
-			"id":        item.ID,
-			"projectId": item.ProjectID,
-			"groupId":   item.GroupID,
-			"title":     item.Title,
-			"text":      item.Text,
-			"tags":      item.Tags,
-			"source":    item.Source,
-			"createdAt": item.CreatedAt,
-			"namespace": item.Namespace,
-			"metadata":  item.Metadata,
+		items[i] = NoteResult{
+			ID:          item.ID,
+			ProjectID:   item.ProjectID,
+			GroupID:     item.GroupID,
+			Title:       item.Title,
+			Text:        item.Text,
+			Tags:        item.Tags,
+			Source:      item.Source,
+			CreatedAt:   item.CreatedAt,
+			UpdatedAt:   item.UpdatedAt,
+			Namespace:   item.Namespace,
+			Metadata:    item.Metadata,
+			Attachments: item.Attachments,
+			ParentID:    item.ParentID,
+			ChunkIndex:  item.ChunkIndex,
+			Importance:  item.Importance,
 		}
 	}
 
-	return map[string]any{
-		"namespace": resp.Namespace,
-		"items":     items,
+	return &ListRecentResult{
+		Namespace:        resp.Namespace,
+		Total:            resp.Total,
+		TotalApproximate: resp.TotalApproximate,
+		NextCursor:       resp.NextCursor,
+		Items:            items,
 	}, nil
 }
 
@@ -148,25 +268,31 @@ func (h *Handler) handleGetConfig(ctx context.Context) (any, error) {
 		return nil, err
 	}
 
-	return map[string]any{
-		"transportDefaults": map[string]any{
-			"defaultTransport": resp.TransportDefaults.DefaultTransport,
+	var previous *EmbedderResult
+	if p := resp.PreviousEmbedder; p != nil {
+		previous = &EmbedderResult{Provider: p.Provider, Model: p.Model, Dim: p.Dim, BaseURL: p.BaseURL}
+	}
+
+	return &GetConfigResult{
+		TransportDefaults: TransportDefaultsResult{
+			DefaultTransport: resp.TransportDefaults.DefaultTransport,
 		},
-		"embedder": map[string]any{
-			"provider": resp.Embedder.Provider,
-			"model":    resp.Embedder.Model,
-			"dim":      resp.Embedder.Dim,
-			"baseUrl":  resp.Embedder.BaseURL,
+		Embedder: EmbedderResult{
+			Provider: resp.Embedder.Provider,
+			Model:    resp.Embedder.Model,
+			Dim:      resp.Embedder.Dim,
+			BaseURL:  resp.Embedder.BaseURL,
 		},
-		"store": map[string]any{
-			"type": resp.Store.Type,
-			"path": resp.Store.Path,
-			"url":  resp.Store.URL,
+		Store: StoreResult{
+			Type: resp.Store.Type,
+			Path: resp.Store.Path,
+			URL:  resp.Store.URL,
 		},
-		"paths": map[string]any{
-			"configPath": resp.Paths.ConfigPath,
-			"dataDir":    resp.Paths.DataDir,
+		Paths: PathsResult{
+			ConfigPath: resp.Paths.ConfigPath,
+			DataDir:    resp.Paths.DataDir,
 		},
+		PreviousEmbedder: previous,
 	}, nil
 }
 
@@ -177,14 +303,106 @@ func (h *Handler) handleSetConfig(ctx context.Context, params any) (any, error)
 		return nil, err
 	}
 
-	resp, err := h.configService.SetConfig(ctx, p.ToRequest())
+	req := p.ToRequest()
+
+	// embedderが変わる場合は、先に新しい設定でサービスを作り直す（失敗したら設定は変えない）
+	// 作り直したサービスはこのリクエストの応答より前に差し替わる
+	reinitialized, dualRead := false, false
+	var prevNamespace, namespace string
+	if h.reinit != nil && req.Embedder != nil {
+		cur, err := h.configService.GetConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+		next, dimReset := service.PatchEmbedder(&cur.Embedder, req.Embedder)
+		if !reflect.DeepEqual(*next, cur.Embedder) {
+			prevNamespace, namespace, err = h.reinit(ctx, next, p.DualRead)
+			if err != nil {
+				return nil, err
+			}
+			reinitialized, dualRead = true, p.DualRead && dimReset
+		}
+	}
+
+	resp, err := h.configService.SetConfig(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 
-	return map[string]any{
-		"ok":                 resp.OK,
-		"effectiveNamespace": resp.EffectiveNamespace,
+	result := &SetConfigResult{
+		OK:                 resp.OK,
+		EffectiveNamespace: resp.EffectiveNamespace,
+		PreviousNamespace:  resp.PreviousNamespace,
+		ReembedRequired:    resp.ReembedRequired,
+		DualRead:           dualRead,
+	}
+	if reinitialized {
+		// 設定のdimは初回の埋め込みで更新されるが、サービスは作成時のnamespaceを使い続けるため、実際のnamespaceを返す
+		result.PreviousNamespace, result.EffectiveNamespace = prevNamespace, namespace
+	}
+	// 変更前のnamespaceにノートが残っていれば、移行（memory.migrate）を促せるよう件数を返す
+	if resp.ReembedRequired && h.migrate != nil {
+		n, err := h.migrate.CountNotes(ctx, result.PreviousNamespace)
+		if err != nil {
+			slog.Warn("failed to count notes in the previous namespace", "namespace", result.PreviousNamespace, "error", err)
+		}
+		result.PreviousNoteCount = n
+	}
+	return result, nil
+}
+
+// handleMigrate は memory.migrate を処理
+// fromを省略した場合はpreviousEmbedder（移行期間中の変更前のembedder）のnamespaceから移行する
+func (h *Handler) handleMigrate(ctx context.Context, params any) (any, error) {
+	var p MigrateParams
+	if err := mapParams(params, &p); err != nil {
+		return nil, err
+	}
+	if h.migrate == nil {
+		return nil, errMigrateUnavailable
+	}
+
+	if p.From == "" {
+		cfg, err := h.configService.GetConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if prev := cfg.PreviousEmbedder; prev != nil {
+			p.From = config.GenerateNamespace(prev.Provider, prev.Model, prev.Dim)
+		}
+	}
+
+	resp, err := h.migrate.Migrate(ctx, &service.MigrateRequest{From: p.From, ProjectID: p.ProjectID})
+	if err != nil {
+		return nil, err
+	}
+
+	return &MigrateResult{
+		From:       resp.From,
+		Namespace:  resp.Namespace,
+		Projects:   resp.Projects,
+		Created:    resp.Created,
+		Skipped:    resp.Skipped,
+		ReEmbedded: resp.ReEmbedded,
+	}, nil
+}
+
+// handleAddProjectAlias は memory.add_project_alias を処理
+func (h *Handler) handleAddProjectAlias(ctx context.Context, params any) (any, error) {
+	var p AddProjectAliasParams
+	if err := mapParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	resp, err := h.configService.AddProjectAlias(ctx, &service.AddProjectAliasRequest{Path: p.Path, ProjectID: p.ProjectID})
+	if err != nil {
+		return nil, err
+	}
+
+	return &AddProjectAliasResult{
+		OK:        true,
+		Path:      resp.Path,
+		ProjectID: resp.ProjectID,
 	}, nil
 }
 
@@ -194,16 +412,19 @@ func (h *Handler) handleUpsertGlobal(ctx context.Context, params any) (any, erro
 	if err := mapParams(params, &p); err != nil {
 		return nil, err
 	}
+	p.ProjectID = defaultProjectID(ctx, p.ProjectID)
 
 	resp, err := h.globalService.UpsertGlobal(ctx, p.ToRequest())
 	if err != nil {
 		return nil, err
 	}
+	h.notify(ChangeEvent{Type: ChangeGlobalUpdated, ProjectID: p.ProjectID, ID: resp.ID, Key: p.Key})
+	h.recordAudit(ctx, audit.Entry{Method: "memory.upsert_global", ProjectID: p.ProjectID, ID: resp.ID, Key: p.Key})
 
-	return map[string]any{
-		"ok":        resp.OK,
-		"id":        resp.ID,
-		"namespace": resp.Namespace,
+	return &UpsertGlobalResult{
+		OK:        resp.OK,
+		ID:        resp.ID,
+		Namespace: resp.Namespace,
 	}, nil
 }
 
@@ -213,6 +434,7 @@ func (h *Handler) handleGetGlobal(ctx context.Context, params any) (any, error)
 	if err := mapParams(params, &p); err != nil {
 		return nil, err
 	}
+	p.ProjectID = defaultProjectID(ctx, p.ProjectID)
 
 	// key必須チェック（Handler側で実施）
 	if p.Key == "" {
@@ -224,19 +446,156 @@ func (h *Handler) handleGetGlobal(ctx context.Context, params any) (any, error)
 		return nil, err
 	}
 
-	result := map[string]any{
-		"namespace": resp.Namespace,
-		"found":     resp.Found,
+	result := &GetGlobalResult{
+		Namespace: resp.Namespace,
+		Found:     resp.Found,
 	}
 	if resp.Found {
-		result["id"] = resp.ID
-		result["value"] = resp.Value
-		result["updatedAt"] = resp.UpdatedAt
+		result.ID = resp.ID
+		result.Value = resp.Value
+		result.UpdatedAt = resp.UpdatedAt
+		result.UpdatedBy = resp.UpdatedBy
 	}
 
 	return result, nil
 }
 
+// handleGetGlobalHistory は memory.get_global_history を処理
+func (h *Handler) handleGetGlobalHistory(ctx context.Context, params any) (any, error) {
+	var p GetGlobalHistoryParams
+	if err := mapParams(params, &p); err != nil {
+		return nil, err
+	}
+	p.ProjectID = defaultProjectID(ctx, p.ProjectID)
+
+	if p.Key == "" {
+		return nil, errKeyRequired
+	}
+
+	resp, err := h.globalService.GetGlobalHistory(ctx, p.ProjectID, p.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]GlobalHistoryItem, len(resp.History))
+	for i, version := range resp.History {
+		items[i] = GlobalHistoryItem{
+			Value:     version.Value,
+			UpdatedAt: version.UpdatedAt,
+			UpdatedBy: version.UpdatedBy,
+		}
+	}
+	return &GetGlobalHistoryResult{
+		Namespace: resp.Namespace,
+		Found:     resp.Found,
+		History:   items,
+	}, nil
+}
+
+// handleExportGlobals は memory.export_globals を処理
+func (h *Handler) handleExportGlobals(ctx context.Context, params any) (any, error) {
+	var p ExportGlobalsParams
+	if err := mapParams(params, &p); err != nil {
+		return nil, err
+	}
+	p.ProjectID = defaultProjectID(ctx, p.ProjectID)
+
+	resp, err := h.globalService.ExportGlobals(ctx, p.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]GlobalEntryItem, len(resp.Globals))
+	for i, g := range resp.Globals {
+		items[i] = GlobalEntryItem{
+			Key:       g.Key,
+			Value:     g.Value,
+			UpdatedAt: g.UpdatedAt,
+			UpdatedBy: g.UpdatedBy,
+		}
+	}
+	return &ExportGlobalsResult{
+		Namespace: resp.Namespace,
+		ProjectID: resp.ProjectID,
+		Globals:   items,
+	}, nil
+}
+
+// handleImportGlobals は memory.import_globals を処理
+func (h *Handler) handleImportGlobals(ctx context.Context, params any) (any, error) {
+	var p ImportGlobalsParams
+	if err := mapParams(params, &p); err != nil {
+		return nil, err
+	}
+	p.ProjectID = defaultProjectID(ctx, p.ProjectID)
+
+	resp, err := h.globalService.ImportGlobals(ctx, p.ToRequest())
+	if err != nil {
+		return nil, err
+	}
+	if resp.Created+resp.Updated > 0 {
+		h.notify(ChangeEvent{Type: ChangeGlobalUpdated, ProjectID: resp.ProjectID})
+		h.recordAudit(ctx, audit.Entry{Method: "memory.import_globals", ProjectID: resp.ProjectID})
+	}
+
+	return &ImportGlobalsResult{
+		Namespace: resp.Namespace,
+		ProjectID: resp.ProjectID,
+		Created:   resp.Created,
+		Updated:   resp.Updated,
+		Skipped:   resp.Skipped,
+	}, nil
+}
+
+// handleSubscribe は memory.subscribe を処理
+// 購読はセッション（stdio/pipeの接続）に紐付き、接続の終了時に解除される
+func (h *Handler) handleSubscribe(ctx context.Context, params any) (any, error) {
+	var p SubscribeParams
+	if err := mapParams(params, &p); err != nil {
+		return nil, err
+	}
+	p.ProjectID = defaultProjectID(ctx, p.ProjectID)
+
+	if p.ProjectID == "" {
+		return nil, service.ErrProjectIDRequired
+	}
+	sess := session.FromContext(ctx)
+	if sess == nil || !sess.CanNotify() {
+		return nil, errNotificationsUnavailable
+	}
+
+	ch, cancel, err := h.Subscribe(p.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		for message := range ch {
+			sess.Notify(message)
+		}
+	}()
+	sess.AddSubscription(p.ProjectID, cancel)
+
+	return &OKResult{OK: true}, nil
+}
+
+// handleUnsubscribe は memory.unsubscribe を処理
+func (h *Handler) handleUnsubscribe(ctx context.Context, params any) (any, error) {
+	var p SubscribeParams
+	if err := mapParams(params, &p); err != nil {
+		return nil, err
+	}
+	p.ProjectID = defaultProjectID(ctx, p.ProjectID)
+
+	if p.ProjectID == "" {
+		return nil, service.ErrProjectIDRequired
+	}
+	if sess := session.FromContext(ctx); sess == nil || !sess.RemoveSubscription(p.ProjectID) {
+		return nil, errNotFound
+	}
+
+	return &OKResult{OK: true}, nil
+}
+
 // handleDelete は memory.delete を処理
 func (h *Handler) handleDelete(ctx context.Context, params any) (any, error) {
 	var p DeleteParams
@@ -249,17 +608,32 @@ func (h *Handler) handleDelete(ctx context.Context, params any) (any, error) {
 		return nil, errIDRequired
 	}
 
+	// 変更通知・監査ログ・Webhookのため、必要なら削除前にノートのprojectIdなどを取得しておく
+	var projectID string
+	hook := webhook.Event{Type: model.WebhookEventNoteDeleted, ID: p.ID}
+	if h.notifier.hasSubscribers() || h.auditLog != nil || h.webhooks != nil {
+		if note, err := h.noteService.Get(ctx, p.ID); err == nil {
+			projectID = note.ProjectID
+			hook.ProjectID, hook.GroupID, hook.Title = note.ProjectID, note.GroupID, derefString(note.Title)
+		}
+	}
+
 	// まずNoteを削除してみる
 	err := h.noteService.Delete(ctx, p.ID)
 	if err == nil {
-		return map[string]any{"ok": true}, nil
+		h.notify(ChangeEvent{Type: ChangeNoteDeleted, ProjectID: projectID, ID: p.ID})
+		h.recordAudit(ctx, audit.Entry{Method: "memory.delete", ProjectID: projectID, ID: p.ID})
+		h.sendWebhook(hook)
+		return &OKResult{OK: true}, nil
 	}
 
 	// NoteNotFoundの場合はGlobalConfigを試す
 	if err == service.ErrNoteNotFound {
 		err = h.globalService.DeleteByID(ctx, p.ID)
 		if err == nil {
-			return map[string]any{"ok": true}, nil
+			h.notify(ChangeEvent{Type: ChangeGlobalDeleted, ID: p.ID})
+			h.recordAudit(ctx, audit.Entry{Method: "memory.delete", ID: p.ID})
+			return &OKResult{OK: true}, nil
 		}
 		// GlobalConfigNotFoundの場合は「Not found」を返す
 		if err == service.ErrGlobalConfigNotFound {
@@ -276,15 +650,18 @@ func (h *Handler) handleGroupCreate(ctx context.Context, params any) (any, error
 	if err := mapParams(params, &p); err != nil {
 		return nil, err
 	}
+	p.ProjectID = defaultProjectID(ctx, p.ProjectID)
 
 	resp, err := h.groupService.CreateGroup(ctx, p.ToRequest())
 	if err != nil {
 		return nil, err
 	}
+	h.recordAudit(ctx, audit.Entry{Method: "memory.group_create", ProjectID: p.ProjectID, ID: resp.ID, Key: p.GroupKey})
+	h.sendWebhook(h.groupWebhookEvent(ctx, model.WebhookEventGroupCreated, resp.ID))
 
-	return map[string]any{
-		"id":        resp.ID,
-		"namespace": resp.Namespace,
+	return &GroupCreateResult{
+		ID:        resp.ID,
+		Namespace: resp.Namespace,
 	}, nil
 }
 
@@ -304,15 +681,21 @@ func (h *Handler) handleGroupGet(ctx context.Context, params any) (any, error) {
 		return nil, err
 	}
 
-	return map[string]any{
-		"id":          resp.ID,
-		"projectId":   resp.ProjectID,
-		"groupKey":    resp.GroupKey,
-		"title":       resp.Title,
-		"description": resp.Description,
-		"createdAt":   resp.CreatedAt,
-		"updatedAt":   resp.UpdatedAt,
-		"namespace":   resp.Namespace,
+	return &GroupGetResult{
+		GroupItem: GroupItem{
+			ID:            resp.ID,
+			ProjectID:     resp.ProjectID,
+			GroupKey:      resp.GroupKey,
+			Title:         resp.Title,
+			Description:   resp.Description,
+			CreatedAt:     resp.CreatedAt,
+			UpdatedAt:     resp.UpdatedAt,
+			Tags:          nonNilTags(resp.Tags),
+			Status:        resp.Status,
+			Metadata:      resp.Metadata,
+			ParentGroupID: resp.ParentGroupID,
+		},
+		Namespace: resp.Namespace,
 	}, nil
 }
 
@@ -330,10 +713,10 @@ func (h *Handler) handleGroupUpdate(ctx context.Context, params any) (any, error
 	if err := h.groupService.UpdateGroup(ctx, p.ToRequest()); err != nil {
 		return nil, err
 	}
+	h.recordAudit(ctx, audit.Entry{Method: "memory.group_update", ID: p.ID})
+	h.sendWebhook(h.groupWebhookEvent(ctx, model.WebhookEventGroupUpdated, p.ID))
 
-	return map[string]any{
-		"ok": true,
-	}, nil
+	return &OKResult{OK: true}, nil
 }
 
 // handleGroupDelete は memory.group_delete を処理
@@ -347,13 +730,51 @@ func (h *Handler) handleGroupDelete(ctx context.Context, params any) (any, error
 		return nil, errIDRequired
 	}
 
-	if err := h.groupService.DeleteGroup(ctx, p.ID); err != nil {
+	hook := h.groupWebhookEvent(ctx, model.WebhookEventGroupDeleted, p.ID)
+	resp, err := h.groupService.DeleteGroup(ctx, &service.DeleteGroupRequest{ID: p.ID, Cascade: p.Cascade})
+	if err != nil {
+		// ノートの削除・移動だけが残った場合は、その件数と原因を結果で返す（同じcascadeで再実行できる）
+		if resp == nil || resp.Notes == 0 || resp.RolledBack {
+			return nil, err
+		}
+		h.recordAudit(ctx, audit.Entry{Method: "memory.group_delete", ID: p.ID, Notes: resp.Notes})
+		rpcErr := h.mapError(nil, err).Error
+		return &GroupDeleteResult{Notes: resp.Notes, Code: rpcErr.Code, Message: rpcErr.Message}, nil
+	}
+	h.recordAudit(ctx, audit.Entry{Method: "memory.group_delete", ID: p.ID, Notes: resp.Notes})
+	hook.Notes = resp.Notes
+	h.sendWebhook(hook)
+
+	return &GroupDeleteResult{OK: true, Notes: resp.Notes}, nil
+}
+
+// handleGroupRename は memory.group_rename を処理
+func (h *Handler) handleGroupRename(ctx context.Context, params any) (any, error) {
+	var p GroupRenameParams
+	if err := mapParams(params, &p); err != nil {
 		return nil, err
 	}
 
-	return map[string]any{
-		"ok": true,
-	}, nil
+	if p.ID == "" {
+		return nil, errIDRequired
+	}
+
+	hook := h.groupWebhookEvent(ctx, model.WebhookEventGroupRenamed, p.ID)
+	resp, err := h.groupService.RenameGroup(ctx, &service.RenameGroupRequest{ID: p.ID, GroupKey: p.GroupKey})
+	if err != nil {
+		// ノートの書き換えだけが残った場合は、その件数と原因を結果で返す（同じgroupKeyで再実行できる）
+		if resp == nil || resp.Notes == 0 {
+			return nil, err
+		}
+		h.recordAudit(ctx, audit.Entry{Method: "memory.group_rename", ID: p.ID, Key: p.GroupKey, Notes: resp.Notes})
+		rpcErr := h.mapError(nil, err).Error
+		return &GroupRenameResult{Notes: resp.Notes, Code: rpcErr.Code, Message: rpcErr.Message}, nil
+	}
+	h.recordAudit(ctx, audit.Entry{Method: "memory.group_rename", ID: p.ID, Key: p.GroupKey, Notes: resp.Notes})
+	hook.NewGroupID, hook.Notes = p.GroupKey, resp.Notes
+	h.sendWebhook(hook)
+
+	return &GroupRenameResult{OK: true, Notes: resp.Notes}, nil
 }
 
 // handleGroupList は memory.group_list を処理
@@ -362,31 +783,49 @@ func (h *Handler) handleGroupList(ctx context.Context, params any) (any, error)
 	if err := mapParams(params, &p); err != nil {
 		return nil, err
 	}
+	p.ProjectID = defaultProjectID(ctx, p.ProjectID)
 
 	resp, err := h.groupService.ListGroups(ctx, p.ProjectID)
 	if err != nil {
 		return nil, err
 	}
 
-	groups := make([]map[string]any, len(resp.Groups))
+	groups := make([]GroupListItem, len(resp.Groups))
 	for i, g := range resp.Groups {
-		groups[i] = map[string]any{
-			"id":          g.ID,
-			"projectId":   g.ProjectID,
-			"groupKey":    g.GroupKey,
-			"title":       g.Title,
-			"description": g.Description,
-			"createdAt":   g.CreatedAt,
-			"updatedAt":   g.UpdatedAt,
+		groups[i] = GroupListItem{
+			GroupItem: GroupItem{
+				ID:            g.ID,
+				ProjectID:     g.ProjectID,
+				GroupKey:      g.GroupKey,
+				Title:         g.Title,
+				Description:   g.Description,
+				CreatedAt:     g.CreatedAt,
+				UpdatedAt:     g.UpdatedAt,
+				Tags:          nonNilTags(g.Tags),
+				Status:        g.Status,
+				Metadata:      g.Metadata,
+				ParentGroupID: g.ParentGroupID,
+			},
+			NoteCount:  g.NoteCount,
+			LastNoteAt: g.LastNoteAt,
+			TextLength: g.TextLength,
 		}
 	}
 
-	return map[string]any{
-		"namespace": resp.Namespace,
-		"groups":    groups,
+	return &GroupListResult{
+		Namespace: resp.Namespace,
+		Groups:    groups,
 	}, nil
 }
 
+// nonNilTags はタグのないグループでもtagsを空配列として返すためのもの
+func nonNilTags(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
 // mapParams はanyをターゲット構造体にマッピング
 func mapParams(params any, target any) error {
 	if params == nil {
@@ -0,0 +1,133 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/model"
+)
+
+// NotificationMethod はノート・グローバル設定の変更通知のメソッド名
+const NotificationMethod = "notifications/memory/changed"
+
+// 変更通知の種類（ChangeEvent.Type）
+const (
+	ChangeNoteAdded     = "note.added"
+	ChangeNoteUpdated   = "note.updated"
+	ChangeNoteDeleted   = "note.deleted"
+	ChangeGlobalUpdated = "global.updated"
+	ChangeGlobalDeleted = "global.deleted"
+)
+
+// subscriberBufferSize は購読者ごとに溜めておける通知の数（溢れた通知は捨てる）
+const subscriberBufferSize = 64
+
+// ChangeEvent は変更通知の内容（NotificationMethodのparams）
+// global.deleted はprojectIdが分からないため、projectIdなしですべての購読者に送る
+// import_globals によるglobal.updatedはkeyを省略する（プロジェクトのグローバル設定を読み直す）
+type ChangeEvent struct {
+	Type      string `json:"type"`
+	ProjectID string `json:"projectId,omitempty"`
+	ID        string `json:"id,omitempty"`
+	Key       string `json:"key,omitempty"`
+}
+
+// broker は変更通知を購読者に配る（ゼロ値で使用可能）
+type broker struct {
+	mu          sync.Mutex
+	next        int
+	subscribers map[int]*subscriber
+}
+
+// subscriber は1件の購読
+type subscriber struct {
+	projectIDs []string // 指定されたprojectIdと正規化後のprojectId
+	ch         chan []byte
+}
+
+// matches はprojectIDの通知を受け取るかを返す
+func (s *subscriber) matches(projectID string) bool {
+	if projectID == "" {
+		return true
+	}
+	for _, id := range s.projectIDs {
+		if id == projectID {
+			return true
+		}
+	}
+	return false
+}
+
+// subscribe はprojectIDsのいずれかに一致する通知を受け取るチャネルと、購読を解除する関数を返す
+// 解除するとチャネルは閉じられる
+func (b *broker) subscribe(projectIDs ...string) (<-chan []byte, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.subscribers == nil {
+		b.subscribers = make(map[int]*subscriber)
+	}
+	id := b.next
+	b.next++
+	sub := &subscriber{projectIDs: projectIDs, ch: make(chan []byte, subscriberBufferSize)}
+	b.subscribers[id] = sub
+
+	var once sync.Once
+	return sub.ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			delete(b.subscribers, id)
+			close(sub.ch)
+		})
+	}
+}
+
+// hasSubscribers は購読者がいるかを返す（通知のための追加の取得を省くのに使う）
+func (b *broker) hasSubscribers() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers) > 0
+}
+
+// publish は変更通知を一致する購読者に送る
+// 購読者の受信が追いつかない場合、その購読者への通知は捨てる（リクエストの処理を止めない）
+func (b *broker) publish(ev ChangeEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.subscribers) == 0 {
+		return
+	}
+
+	message, err := json.Marshal(model.NewNotification(NotificationMethod, ev))
+	if err != nil {
+		return
+	}
+	for _, sub := range b.subscribers {
+		if !sub.matches(ev.ProjectID) {
+			continue
+		}
+		select {
+		case sub.ch <- message:
+		default:
+		}
+	}
+}
+
+// Subscribe はprojectIDのノート・グローバル設定の変更通知（NotificationMethodのJSON）を受け取るチャネルと、
+// 購読を解除する関数を返す（HTTPの /events 用）
+func (h *Handler) Subscribe(projectID string) (<-chan []byte, func(), error) {
+	// ノートのprojectIdは正規化済み、グローバル設定は指定されたままのため両方で照合する
+	canonical, err := config.CanonicalizeProjectID(projectID)
+	if err != nil {
+		return nil, nil, err
+	}
+	ch, cancel := h.notifier.subscribe(projectID, canonical)
+	return ch, cancel, nil
+}
+
+// notify は変更通知を送る
+func (h *Handler) notify(ev ChangeEvent) {
+	h.notifier.publish(ev)
+}
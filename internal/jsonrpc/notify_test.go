@@ -0,0 +1,109 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/session"
+)
+
+// === 変更通知（memory.subscribe）テスト ===
+
+// receiveNotification は通知を1件受け取ってparamsを返す
+func receiveNotification(t *testing.T, ch <-chan []byte) ChangeEvent {
+	t.Helper()
+	select {
+	case message := <-ch:
+		var n struct {
+			Method string      `json:"method"`
+			Params ChangeEvent `json:"params"`
+		}
+		if err := json.Unmarshal(message, &n); err != nil {
+			t.Fatalf("failed to parse notification: %v", err)
+		}
+		if n.Method != NotificationMethod {
+			t.Errorf("expected method %s, got %s", NotificationMethod, n.Method)
+		}
+		return n.Params
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for notification")
+	}
+	return ChangeEvent{}
+}
+
+func TestHandle_Subscribe(t *testing.T) {
+	h := newTestHandler()
+	received := make(chan []byte, 10)
+	sess := session.New(session.Info{})
+	sess.SetNotifier(func(message []byte) { received <- message })
+	ctx := session.NewContext(context.Background(), sess)
+
+	resp := parseResponse(t, h.Handle(ctx, makeRequest("memory.subscribe", map[string]any{"projectId": "/test"})))
+	if resp["error"] != nil {
+		t.Fatalf("unexpected error: %v", resp["error"])
+	}
+
+	// 別のクライアント（セッションなし）の変更も通知される
+	h.Handle(context.Background(), makeRequest("memory.upsert_global", map[string]any{"projectId": "/test/other", "key": "global.a", "value": 1}))
+	h.Handle(context.Background(), makeRequest("memory.upsert_global", map[string]any{"projectId": "/test", "key": "global.project.conventions", "value": "tabs"}))
+	ev := receiveNotification(t, received)
+	if ev.Type != ChangeGlobalUpdated || ev.ProjectID != "/test" || ev.Key != "global.project.conventions" {
+		t.Errorf("unexpected event: %+v", ev)
+	}
+
+	h.Handle(context.Background(), makeRequest("memory.update", map[string]any{"id": "note-1", "patch": map[string]any{"text": "updated"}}))
+	if ev := receiveNotification(t, received); ev.Type != ChangeNoteUpdated || ev.ID != "note-1" {
+		t.Errorf("unexpected event: %+v", ev)
+	}
+
+	resp = parseResponse(t, h.Handle(ctx, makeRequest("memory.unsubscribe", map[string]any{"projectId": "/test"})))
+	if resp["error"] != nil {
+		t.Fatalf("unexpected error: %v", resp["error"])
+	}
+	h.Handle(context.Background(), makeRequest("memory.upsert_global", map[string]any{"projectId": "/test", "key": "global.project.conventions", "value": "spaces"}))
+	select {
+	case message := <-received:
+		t.Errorf("unexpected notification after unsubscribe: %s", message)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	errResp := parseErrorResponse(t, h.Handle(ctx, makeRequest("memory.unsubscribe", map[string]any{"projectId": "/test"})))
+	if errResp.Error.Code != model.ErrCodeNotFound {
+		t.Errorf("expected code %d, got %d", model.ErrCodeNotFound, errResp.Error.Code)
+	}
+}
+
+func TestHandle_Subscribe_NoNotifier(t *testing.T) {
+	h := newTestHandler()
+	// HTTPのPOSTと同じく通知を送れないセッション
+	ctx := session.NewContext(context.Background(), session.New(session.Info{}))
+
+	errResp := parseErrorResponse(t, h.Handle(ctx, makeRequest("memory.subscribe", map[string]any{"projectId": "/test"})))
+	if errResp.Error.Code != model.ErrCodeInternalError {
+		t.Errorf("expected code %d, got %d", model.ErrCodeInternalError, errResp.Error.Code)
+	}
+}
+
+func TestHandler_Subscribe_Delete(t *testing.T) {
+	h := newTestHandler()
+	ch, cancel, err := h.Subscribe("/test")
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	h.Handle(context.Background(), makeRequest("memory.delete", map[string]any{"id": "note-1"}))
+	if ev := receiveNotification(t, ch); ev.Type != ChangeNoteDeleted || ev.ID != "note-1" {
+		t.Errorf("unexpected event: %+v", ev)
+	}
+
+	cancel()
+	if _, ok := <-ch; ok {
+		t.Error("expected the channel to be closed after cancel")
+	}
+	// 解除後の通知・二重の解除で止まらない
+	h.notify(ChangeEvent{Type: ChangeGlobalDeleted})
+	cancel()
+}
@@ -3,44 +3,89 @@ package jsonrpc
 import (
 	"encoding/json"
 
+	"github.com/brbranch/embedding_mcp/internal/model"
 	"github.com/brbranch/embedding_mcp/internal/service"
 )
 
 // AddNoteParams は memory.add_note のパラメータ
 type AddNoteParams struct {
-	ProjectID string         `json:"projectId"`
-	GroupID   string         `json:"groupId"`
+	ProjectID string         `json:"projectId" jsonschema:"required"`
+	GroupID   string         `json:"groupId" jsonschema:"required"`
 	Title     *string        `json:"title"`
-	Text      string         `json:"text"`
+	Text      string         `json:"text" jsonschema:"required"`
 	Tags      []string       `json:"tags"`
 	Source    *string        `json:"source"`
 	CreatedAt *string        `json:"createdAt"`
 	Metadata  map[string]any `json:"metadata"`
+	// Attachments はノートが参照するファイル（パスまたは内容のハッシュ）
+	Attachments []model.Attachment `json:"attachments"`
+	// Importance は重要度（0-1）。検索時にスコアへ加点する
+	Importance *float64 `json:"importance"`
+	// OnDuplicate は類似ノートがある場合の扱い（reject/merge/proceed、省略時は検出しない）
+	OnDuplicate string `json:"onDuplicate,omitempty"`
+	// DuplicateThreshold は重複とみなす類似度（0-1、省略時はglobal.memory.duplicateThreshold）
+	DuplicateThreshold *float64 `json:"duplicateThreshold,omitempty"`
+	// Model は埋め込みに使う追加embedderの名前（設定のembedders、省略時はembedder）
+	Model string `json:"model,omitempty"`
 }
 
 // ToRequest はサービスリクエストに変換
 func (p *AddNoteParams) ToRequest() *service.AddNoteRequest {
 	return &service.AddNoteRequest{
-		ProjectID: p.ProjectID,
-		GroupID:   p.GroupID,
-		Title:     p.Title,
-		Text:      p.Text,
-		Tags:      p.Tags,
-		Source:    p.Source,
-		CreatedAt: p.CreatedAt,
-		Metadata:  p.Metadata,
+		ProjectID:          p.ProjectID,
+		GroupID:            p.GroupID,
+		Title:              p.Title,
+		Text:               p.Text,
+		Tags:               p.Tags,
+		Source:             p.Source,
+		CreatedAt:          p.CreatedAt,
+		Metadata:           p.Metadata,
+		Attachments:        p.Attachments,
+		Importance:         p.Importance,
+		OnDuplicate:        p.OnDuplicate,
+		DuplicateThreshold: p.DuplicateThreshold,
+		Model:              p.Model,
 	}
 }
 
+// AddNotesParams は memory.add_notes のパラメータ（各ノートのonDuplicate・duplicateThresholdは無視し、modelは項目ごとに振り分ける）
+type AddNotesParams struct {
+	Notes []AddNoteParams `json:"notes" jsonschema:"required"`
+}
+
+// ToRequest はサービスリクエストに変換
+func (p *AddNotesParams) ToRequest() *service.AddNotesRequest {
+	notes := make([]service.AddNoteRequest, len(p.Notes))
+	for i := range p.Notes {
+		notes[i] = *p.Notes[i].ToRequest()
+	}
+	return &service.AddNotesRequest{Notes: notes}
+}
+
 // SearchParams は memory.search のパラメータ
 type SearchParams struct {
-	ProjectID string   `json:"projectId"`
+	ProjectID string   `json:"projectId" jsonschema:"required"`
 	GroupID   *string  `json:"groupId"`
-	Query     string   `json:"query"`
+	Query     string   `json:"query" jsonschema:"required"`
 	TopK      *int     `json:"topK"`
 	Tags      []string `json:"tags"`
 	Since     *string  `json:"since"`
 	Until     *string  `json:"until"`
+	MinScore  *float64 `json:"minScore"`
+	// ProjectIDs はprojectIdに加えて検索するプロジェクト（複数プロジェクトを横断して検索し、スコア順にまとめる）
+	ProjectIDs []string `json:"projectIds,omitempty"`
+	// CollapseByParent はチャンク分割された文書を親ごとに1件にまとめる
+	CollapseByParent bool `json:"collapseByParent,omitempty"`
+	// Language は追加時に検出した言語（ja/en等）で絞り込む
+	Language *string `json:"language,omitempty"`
+	// IncludeDescendants はgroupIdの子孫グループ（parentGroupIdでつながるグループ）のノートも含める
+	IncludeDescendants bool `json:"includeDescendants,omitempty"`
+	// GroupBy に"group"を指定すると、groupIdごとにgroupTopK件までの結果をまとめて返す（topKは全体の件数）
+	GroupBy string `json:"groupBy,omitempty"`
+	// GroupTopK はgroupBy指定時のgroupIdごとの件数（default 2）
+	GroupTopK *int `json:"groupTopK,omitempty"`
+	// IncludeArchived はアーカイブに移したノートも検索する（結果のarchivedがtrueになる）
+	IncludeArchived bool `json:"includeArchived,omitempty"`
 }
 
 // ToRequest はサービスリクエストに変換
@@ -51,25 +96,36 @@ func (p *SearchParams) ToRequest() *service.SearchRequest {
 		topK = &defaultTopK
 	}
 	return &service.SearchRequest{
-		ProjectID: p.ProjectID,
-		GroupID:   p.GroupID,
-		Query:     p.Query,
-		TopK:      topK,
-		Tags:      p.Tags,
-		Since:     p.Since,
-		Until:     p.Until,
+		ProjectID:          p.ProjectID,
+		ProjectIDs:         p.ProjectIDs,
+		GroupID:            p.GroupID,
+		Query:              p.Query,
+		TopK:               topK,
+		Tags:               p.Tags,
+		Since:              p.Since,
+		Until:              p.Until,
+		MinScore:           p.MinScore,
+		CollapseByParent:   p.CollapseByParent,
+		Language:           p.Language,
+		IncludeDescendants: p.IncludeDescendants,
+		GroupBy:            p.GroupBy,
+		GroupTopK:          p.GroupTopK,
+		IncludeArchived:    p.IncludeArchived,
 	}
 }
 
 // GetParams は memory.get のパラメータ
 type GetParams struct {
-	ID string `json:"id"`
+	ID string `json:"id" jsonschema:"required"`
+	// Reassemble はチャンク分割された文書の全チャンクを連結した本文を返す
+	Reassemble bool `json:"reassemble,omitempty"`
 }
 
 // UpdateParams は memory.update のパラメータ
 type UpdateParams struct {
-	ID    string      `json:"id"`
-	Patch PatchParams `json:"patch"`
+	ID          string      `json:"id" jsonschema:"required"`
+	Patch       PatchParams `json:"patch" jsonschema:"required"`
+	IfUpdatedAt *string     `json:"ifUpdatedAt"`
 }
 
 // PatchParams は memory.update のパッチパラメータ
@@ -81,6 +137,10 @@ type PatchParams struct {
 	Source   json.RawMessage `json:"source,omitempty"`
 	GroupID  *string         `json:"groupId,omitempty"`
 	Metadata json.RawMessage `json:"metadata,omitempty"`
+	// Attachments は全体を置き換える（空配列でクリア）
+	Attachments *[]model.Attachment `json:"attachments,omitempty"`
+	// Importance は重要度を置き換える（0で加点なし）
+	Importance *float64 `json:"importance,omitempty"`
 }
 
 // ToRequest はサービスリクエストに変換
@@ -90,9 +150,11 @@ type PatchParams struct {
 // 将来的にはservice層でnullクリアを明示的にサポートする設計変更が望ましい
 func (p *UpdateParams) ToRequest() (*service.UpdateRequest, error) {
 	patch := service.NotePatch{
-		Text:    p.Patch.Text,
-		Tags:    p.Patch.Tags,
-		GroupID: p.Patch.GroupID,
+		Text:        p.Patch.Text,
+		Tags:        p.Patch.Tags,
+		GroupID:     p.Patch.GroupID,
+		Attachments: p.Patch.Attachments,
+		Importance:  p.Patch.Importance,
 	}
 
 	// Title: null か 値 か 未指定 かを判定
@@ -141,32 +203,43 @@ func (p *UpdateParams) ToRequest() (*service.UpdateRequest, error) {
 	}
 
 	return &service.UpdateRequest{
-		ID:    p.ID,
-		Patch: patch,
+		ID:          p.ID,
+		Patch:       patch,
+		IfUpdatedAt: p.IfUpdatedAt,
 	}, nil
 }
 
 // ListRecentParams は memory.list_recent のパラメータ
 type ListRecentParams struct {
-	ProjectID string   `json:"projectId"`
+	ProjectID string   `json:"projectId" jsonschema:"required"`
 	GroupID   *string  `json:"groupId"`
 	Limit     *int     `json:"limit"`
 	Tags      []string `json:"tags"`
+	SortBy    string   `json:"sortBy"`
+	// IncludeDescendants はgroupIdの子孫グループのノートも含める
+	IncludeDescendants bool `json:"includeDescendants,omitempty"`
+	// Cursor は前のページの結果のnextCursor（省略すると先頭から）
+	Cursor string `json:"cursor,omitempty"`
 }
 
 // ToRequest はサービスリクエストに変換
 func (p *ListRecentParams) ToRequest() *service.ListRecentRequest {
 	return &service.ListRecentRequest{
-		ProjectID: p.ProjectID,
-		GroupID:   p.GroupID,
-		Limit:     p.Limit,
-		Tags:      p.Tags,
+		ProjectID:          p.ProjectID,
+		GroupID:            p.GroupID,
+		Limit:              p.Limit,
+		Tags:               p.Tags,
+		SortBy:             p.SortBy,
+		IncludeDescendants: p.IncludeDescendants,
+		Cursor:             p.Cursor,
 	}
 }
 
 // SetConfigParams は memory.set_config のパラメータ
 type SetConfigParams struct {
 	Embedder *EmbedderParams `json:"embedder"`
+	// DualRead はnamespaceが変わる場合に、移行するまで変更前のnamespaceのノートも読み出す
+	DualRead bool `json:"dualRead"`
 }
 
 // EmbedderParams はembedder設定のパラメータ
@@ -194,10 +267,11 @@ func (p *SetConfigParams) ToRequest() *service.SetConfigRequest {
 
 // UpsertGlobalParams は memory.upsert_global のパラメータ
 type UpsertGlobalParams struct {
-	ProjectID string  `json:"projectId"`
-	Key       string  `json:"key"`
-	Value     any     `json:"value"`
+	ProjectID string  `json:"projectId" jsonschema:"required"`
+	Key       string  `json:"key" jsonschema:"required"`
+	Value     any     `json:"value" jsonschema:"required"`
 	UpdatedAt *string `json:"updatedAt"`
+	UpdatedBy string  `json:"updatedBy,omitempty"`
 }
 
 // ToRequest はサービスリクエストに変換
@@ -207,53 +281,114 @@ func (p *UpsertGlobalParams) ToRequest() *service.UpsertGlobalRequest {
 		Key:       p.Key,
 		Value:     p.Value,
 		UpdatedAt: p.UpdatedAt,
+		UpdatedBy: p.UpdatedBy,
 	}
 }
 
 // GetGlobalParams は memory.get_global のパラメータ
 type GetGlobalParams struct {
-	ProjectID string `json:"projectId"`
-	Key       string `json:"key"`
+	ProjectID string `json:"projectId" jsonschema:"required"`
+	Key       string `json:"key" jsonschema:"required"`
+}
+
+// GetGlobalHistoryParams は memory.get_global_history のパラメータ
+type GetGlobalHistoryParams struct {
+	ProjectID string `json:"projectId" jsonschema:"required"`
+	Key       string `json:"key" jsonschema:"required"`
+}
+
+// ExportGlobalsParams は memory.export_globals のパラメータ
+type ExportGlobalsParams struct {
+	ProjectID string `json:"projectId" jsonschema:"required"`
+}
+
+// ImportGlobalsParams は memory.import_globals のパラメータ
+// mode は "skip-existing"（既存のkeyを残す）または "overwrite"。省略時は既存のkeyがあればエラー
+type ImportGlobalsParams struct {
+	ProjectID string             `json:"projectId" jsonschema:"required"`
+	Globals   []GlobalEntryParam `json:"globals" jsonschema:"required"`
+	Mode      string             `json:"mode,omitempty"`
+}
+
+// GlobalEntryParam はインポートするグローバル設定1件
+type GlobalEntryParam struct {
+	Key       string `json:"key" jsonschema:"required"`
+	Value     any    `json:"value" jsonschema:"required"`
+	UpdatedBy string `json:"updatedBy,omitempty"`
+}
+
+// ToRequest はサービスリクエストに変換
+func (p *ImportGlobalsParams) ToRequest() *service.ImportGlobalsRequest {
+	globals := make([]service.GlobalEntry, len(p.Globals))
+	for i, g := range p.Globals {
+		globals[i] = service.GlobalEntry{Key: g.Key, Value: g.Value, UpdatedBy: g.UpdatedBy}
+	}
+	return &service.ImportGlobalsRequest{
+		ProjectID: p.ProjectID,
+		Globals:   globals,
+		Mode:      service.ImportMode(p.Mode),
+	}
+}
+
+// SubscribeParams は memory.subscribe / memory.unsubscribe のパラメータ
+type SubscribeParams struct {
+	ProjectID string `json:"projectId" jsonschema:"required"`
 }
 
 // DeleteParams は memory.delete のパラメータ
 type DeleteParams struct {
-	ID string `json:"id"`
+	ID string `json:"id" jsonschema:"required"`
 }
 
 // GroupCreateParams は memory.group_create のパラメータ
 type GroupCreateParams struct {
-	ProjectID   string `json:"projectId"`
-	GroupKey    string `json:"groupKey"`
-	Title       string `json:"title"`
+	ProjectID   string `json:"projectId" jsonschema:"required"`
+	GroupKey    string `json:"groupKey" jsonschema:"required"`
+	Title       string `json:"title" jsonschema:"required"`
 	Description string `json:"description"`
+	// Tags/Status/Metadata はスプリント・担当者・チケット等の注釈（statusの省略はactive）
+	Tags     []string       `json:"tags,omitempty"`
+	Status   string         `json:"status,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+	// ParentGroupID は親グループのgroupKey（同一プロジェクト内）
+	ParentGroupID string `json:"parentGroupId,omitempty"`
 }
 
 // ToRequest はサービスリクエストに変換
 func (p *GroupCreateParams) ToRequest() *service.CreateGroupRequest {
 	return &service.CreateGroupRequest{
-		ProjectID:   p.ProjectID,
-		GroupKey:    p.GroupKey,
-		Title:       p.Title,
-		Description: p.Description,
+		ProjectID:     p.ProjectID,
+		GroupKey:      p.GroupKey,
+		Title:         p.Title,
+		Description:   p.Description,
+		Tags:          p.Tags,
+		Status:        p.Status,
+		Metadata:      p.Metadata,
+		ParentGroupID: p.ParentGroupID,
 	}
 }
 
 // GroupGetParams は memory.group_get のパラメータ
 type GroupGetParams struct {
-	ID string `json:"id"`
+	ID string `json:"id" jsonschema:"required"`
 }
 
 // GroupUpdateParams は memory.group_update のパラメータ
 type GroupUpdateParams struct {
-	ID    string          `json:"id"`
-	Patch GroupPatchParam `json:"patch"`
+	ID    string          `json:"id" jsonschema:"required"`
+	Patch GroupPatchParam `json:"patch" jsonschema:"required"`
 }
 
 // GroupPatchParam はグループ更新のパッチパラメータ
 type GroupPatchParam struct {
 	Title       *string `json:"title,omitempty"`
 	Description *string `json:"description,omitempty"`
+	// Tags/Metadata は全体を置き換える（空配列・空オブジェクトでクリア）
+	Tags     *[]string       `json:"tags,omitempty"`
+	Status   *string         `json:"status,omitempty"`
+	Metadata *map[string]any `json:"metadata,omitempty"`
+	// ParentGroupID は親グループのgroupKey（空文字でトップレベルに戻す）
+	ParentGroupID *string `json:"parentGroupId,omitempty"`
 }
 
 // ToRequest はサービスリクエストに変換
@@ -261,18 +396,70 @@ func (p *GroupUpdateParams) ToRequest() *service.UpdateGroupRequest {
 	return &service.UpdateGroupRequest{
 		ID: p.ID,
 		Patch: service.GroupPatch{
-			Title:       p.Patch.Title,
-			Description: p.Patch.Description,
+			Title:         p.Patch.Title,
+			Description:   p.Patch.Description,
+			Tags:          p.Patch.Tags,
+			Status:        p.Patch.Status,
+			Metadata:      p.Patch.Metadata,
+			ParentGroupID: p.Patch.ParentGroupID,
 		},
 	}
 }
 
 // GroupDeleteParams は memory.group_delete のパラメータ
 type GroupDeleteParams struct {
-	ID string `json:"id"`
+	ID string `json:"id" jsonschema:"required"`
+	// Cascade はグループのノートの扱い（delete: 削除、move: globalへ移動、restrict: ノートがあれば拒否）。省略時はノートを残す
+	Cascade string `json:"cascade,omitempty"`
+}
+
+// GroupRenameParams は memory.group_rename のパラメータ
+type GroupRenameParams struct {
+	ID       string `json:"id" jsonschema:"required"`
+	GroupKey string `json:"groupKey" jsonschema:"required"`
 }
 
 // GroupListParams は memory.group_list のパラメータ
 type GroupListParams struct {
-	ProjectID string `json:"projectId"`
+	ProjectID string `json:"projectId" jsonschema:"required"`
+}
+
+// MigrateParams は memory.migrate のパラメータ
+type MigrateParams struct {
+	From      string `json:"from"`      // 空の場合はpreviousEmbedderのnamespace
+	ProjectID string `json:"projectId"` // 空の場合は全プロジェクト
+}
+
+// ArchiveParams は memory.archive のパラメータ
+type ArchiveParams struct {
+	ProjectID     string `json:"projectId"`               // 空の場合は全プロジェクト
+	OlderThanDays int    `json:"olderThanDays,omitempty"` // 省略時はarchive.afterDays
+	DryRun        bool   `json:"dryRun,omitempty"`        // 移すノートの一覧のみで変更しない
+}
+
+// RestoreParams は memory.restore のパラメータ
+type RestoreParams struct {
+	IDs []string `json:"ids" jsonschema:"required"`
+}
+
+// ListArchivedParams は memory.list_archived のパラメータ
+type ListArchivedParams struct {
+	ProjectID string `json:"projectId"` // 空の場合は全プロジェクト
+}
+
+// ValidateParams は memory.validate のパラメータ
+type ValidateParams struct {
+	ProjectID string `json:"projectId"`        // 空の場合は全プロジェクト
+	Repair    bool   `json:"repair,omitempty"` // 見つかった問題を修復する
+}
+
+// AddProjectAliasParams は memory.add_project_alias のパラメータ
+type AddProjectAliasParams struct {
+	Path      string `json:"path" jsonschema:"required"`      // エイリアスにするパス（CI・worktreeなどのチェックアウト）
+	ProjectID string `json:"projectId" jsonschema:"required"` // 対応先の論理的なprojectId
+}
+
+// DescribeParams は memory.describe のパラメータ
+type DescribeParams struct {
+	Method string `json:"method"` // 空の場合は全メソッド
 }
@@ -0,0 +1,438 @@
+package jsonrpc
+
+import "github.com/brbranch/embedding_mcp/internal/model"
+
+// AddNoteResult は memory.add_note の結果
+type AddNoteResult struct {
+	ID                 string `json:"id"`
+	Namespace          string `json:"namespace"`
+	CanonicalProjectID string `json:"canonicalProjectId"`
+	// DuplicateID/DuplicateScore は見つかった類似ノートとその類似度（onDuplicate指定時のみ）
+	DuplicateID    string  `json:"duplicateId,omitempty"`
+	DuplicateScore float64 `json:"duplicateScore,omitempty"`
+	// Merged は追加せず類似ノートにタグをマージしたか（idは既存ノートのID）
+	Merged bool `json:"merged,omitempty"`
+}
+
+// AddNotesResult は memory.add_notes の結果
+type AddNotesResult struct {
+	Namespace string            `json:"namespace"`
+	Added     int               `json:"added"`
+	Items     []BatchItemResult `json:"items"` // notesと同じ順序
+}
+
+// BatchItemResult はバッチ処理の1件分の結果
+// statusがokでない項目だけを再送すればよい（failedはcode・messageの原因を直してから）
+type BatchItemResult struct {
+	Index   int    `json:"index"`
+	ID      string `json:"id,omitempty"`      // ノートのID（検証で失敗した項目は空）
+	Status  string `json:"status"`            // ok・failed（この項目で失敗）・pending（他の項目の失敗で追加していない）
+	Code    int    `json:"code,omitempty"`    // failedの場合のエラーコード（同じ失敗をmemory.add_noteで返すエラーと同じ）
+	Message string `json:"message,omitempty"` // failedの場合のエラーメッセージ
+}
+
+// DuplicateNoteData は onDuplicate=reject で類似ノートがあった場合のエラーのdata
+type DuplicateNoteData struct {
+	DuplicateID string  `json:"duplicateId"`
+	Score       float64 `json:"score"`
+}
+
+// GroupKeyPrefixData は groupKey・groupId が global.memory.groupDefaults のプレフィックスで始まらない場合のエラーのdata
+type GroupKeyPrefixData struct {
+	GroupKey    string   `json:"groupKey"`
+	Prefixes    []string `json:"prefixes"`
+	Suggestions []string `json:"suggestions"`
+}
+
+// SearchResultItem は memory.search の結果1件
+type SearchResultItem struct {
+	ID        string         `json:"id"`
+	ProjectID string         `json:"projectId"`
+	GroupID   string         `json:"groupId"`
+	Title     *string        `json:"title"`
+	Text      string         `json:"text"`
+	Tags      []string       `json:"tags"`
+	Source    *string        `json:"source"`
+	CreatedAt string         `json:"createdAt"`
+	UpdatedAt string         `json:"updatedAt"`
+	Score     float64        `json:"score"`
+	Metadata  map[string]any `json:"metadata"`
+	// Attachments はノートが参照するファイル
+	Attachments []model.Attachment `json:"attachments,omitempty"`
+	// ParentID/ChunkIndex はチャンク分割された文書の親ノートIDとチャンク番号
+	ParentID   *string `json:"parentId,omitempty"`
+	ChunkIndex *int    `json:"chunkIndex,omitempty"`
+	// Importance は重要度（0-1）
+	Importance *float64 `json:"importance,omitempty"`
+	// Archived はアーカイブのノート（includeArchived指定時。memory.restoreで戻すまでget・updateできない）
+	Archived bool `json:"archived,omitempty"`
+}
+
+// SearchResult は memory.search の結果
+type SearchResult struct {
+	Namespace string `json:"namespace"`
+	// Groups はgroupBy指定時の、結果に含まれるgroupIdの一覧（resultsと同じ順）
+	Groups  []SearchGroupResult `json:"groups,omitempty"`
+	Results []SearchResultItem  `json:"results"`
+}
+
+// searchHead はSearchResultのresults以外の項目（項目ごとに書き出す場合の先頭部分）
+type searchHead struct {
+	Namespace string              `json:"namespace"`
+	Groups    []SearchGroupResult `json:"groups,omitempty"`
+}
+
+// SearchGroupResult はgroupBy指定時の1つのgroupIdの結果の概要
+type SearchGroupResult struct {
+	GroupID  string  `json:"groupId"`
+	Count    int     `json:"count"`
+	TopScore float64 `json:"topScore"`
+}
+
+// NoteResult は memory.get の結果（memory.list_recent の1件としても使用）
+type NoteResult struct {
+	ID        string         `json:"id"`
+	ProjectID string         `json:"projectId"`
+	GroupID   string         `json:"groupId"`
+	Title     *string        `json:"title"`
+	Text      string         `json:"text"`
+	Tags      []string       `json:"tags"`
+	Source    *string        `json:"source"`
+	CreatedAt string         `json:"createdAt"`
+	UpdatedAt string         `json:"updatedAt"`
+	Namespace string         `json:"namespace"`
+	Metadata  map[string]any `json:"metadata"`
+	// Attachments はノートが参照するファイル
+	Attachments []model.Attachment `json:"attachments,omitempty"`
+	// ParentID/ChunkIndex はチャンク分割された文書の親ノートIDとチャンク番号
+	ParentID   *string `json:"parentId,omitempty"`
+	ChunkIndex *int    `json:"chunkIndex,omitempty"`
+	// Importance は重要度（0-1）
+	Importance *float64 `json:"importance,omitempty"`
+}
+
+// ListRecentResult は memory.list_recent の結果
+type ListRecentResult struct {
+	Namespace string `json:"namespace"`
+	// Total は条件に一致するノートの件数（limit・cursorによらない）
+	Total int `json:"total"`
+	// TotalApproximate はtotalが概数（移行中の2つのnamespaceを合わせて数えた場合）ならtrue
+	TotalApproximate bool `json:"totalApproximate,omitempty"`
+	// NextCursor は次のページを取得するcursor（最後のページなら省略）
+	NextCursor string       `json:"nextCursor,omitempty"`
+	Items      []NoteResult `json:"items"`
+}
+
+// listRecentHead はListRecentResultのitems以外の項目（項目ごとに書き出す場合の先頭部分）
+type listRecentHead struct {
+	Namespace        string `json:"namespace"`
+	Total            int    `json:"total"`
+	TotalApproximate bool   `json:"totalApproximate,omitempty"`
+	NextCursor       string `json:"nextCursor,omitempty"`
+}
+
+// OKResult は成否のみを返すメソッドの結果
+type OKResult struct {
+	OK bool `json:"ok"`
+}
+
+// UpdateResult は memory.update の結果
+type UpdateResult struct {
+	OK        bool   `json:"ok"`
+	UpdatedAt string `json:"updatedAt"` // 次のifUpdatedAtに使う
+}
+
+// GroupDeleteResult は memory.group_delete の結果
+// グループの削除に失敗してもcascadeのノートの削除・移動が残った場合はエラーにせず、okをfalseにしてcode・messageで原因を返す
+type GroupDeleteResult struct {
+	OK      bool   `json:"ok"`
+	Notes   int    `json:"notes,omitempty"`   // cascadeで削除・移動したノート数
+	Code    int    `json:"code,omitempty"`    // okでない場合のJSON-RPCエラーコード
+	Message string `json:"message,omitempty"` // okでない場合のエラーメッセージ
+}
+
+// GroupRenameResult は memory.group_rename の結果
+// ノートを書き換えた後に失敗した場合はエラーにせず、okをfalseにしてcode・messageで原因を返す
+type GroupRenameResult struct {
+	OK      bool   `json:"ok"`
+	Notes   int    `json:"notes"`             // groupIdを書き換えたノート数
+	Code    int    `json:"code,omitempty"`    // okでない場合のJSON-RPCエラーコード
+	Message string `json:"message,omitempty"` // okでない場合のエラーメッセージ
+}
+
+// GetConfigResult は memory.get_config の結果
+type GetConfigResult struct {
+	TransportDefaults TransportDefaultsResult `json:"transportDefaults"`
+	Embedder          EmbedderResult          `json:"embedder"`
+	Store             StoreResult             `json:"store"`
+	Paths             PathsResult             `json:"paths"`
+	PreviousEmbedder  *EmbedderResult         `json:"previousEmbedder,omitempty"` // 移行期間中のみ
+}
+
+// ServerInfoResult は memory.server_info の結果
+type ServerInfoResult struct {
+	Name          string               `json:"name"`
+	Version       string               `json:"version"`
+	Commit        string               `json:"commit,omitempty"`
+	Transports    []string             `json:"transports"`
+	StoreType     string               `json:"storeType"`
+	Namespace     string               `json:"namespace"`
+	Embedder      ServerEmbedderResult `json:"embedder"`
+	StartedAt     string               `json:"startedAt"` // RFC3339（UTC）
+	UptimeSeconds int64                `json:"uptimeSeconds"`
+	Features      map[string]bool      `json:"features"` // 機能名→有効か（subscribe, events, migrate, embedderReload, dualRead, audit, sharedGlobals）
+}
+
+// ServerEmbedderResult はmemory.server_infoのembedder
+type ServerEmbedderResult struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	Dim      int    `json:"dim"` // 0は未確定（初回の埋め込みで決まる）
+}
+
+// StatsResult は memory.stats の結果
+type StatsResult struct {
+	Namespace      string               `json:"namespace"`
+	Notes          int                  `json:"notes"`
+	Projects       []ProjectStatsResult `json:"projects"` // ノート数の多い順
+	Thresholds     NoteThresholdsResult `json:"thresholds"`
+	Level          string               `json:"level"`                    // ok / warning（softを超えた）/ critical（hardを超えた）
+	Recommendation string               `json:"recommendation,omitempty"` // levelがok以外の場合の推奨
+	GrowthSince    string               `json:"growthSince,omitempty"`    // 増加数を比べた過去の計測の時刻（RFC3339、初回は省略）
+	Growth         int                  `json:"growth"`                   // growthSinceからの増加数
+}
+
+// ProjectStatsResult はプロジェクト1件分のノート数
+type ProjectStatsResult struct {
+	ProjectID string `json:"projectId"`
+	Notes     int    `json:"notes"`
+}
+
+// NoteThresholdsResult はノート数の閾値（hardが0なら無効）
+type NoteThresholdsResult struct {
+	Soft int `json:"soft"`
+	Hard int `json:"hard"`
+}
+
+// TransportDefaultsResult はtransport設定の結果
+type TransportDefaultsResult struct {
+	DefaultTransport string `json:"defaultTransport"`
+}
+
+// EmbedderResult はembedder設定の結果（apiKeyは返さない）
+type EmbedderResult struct {
+	Provider string  `json:"provider"`
+	Model    string  `json:"model"`
+	Dim      int     `json:"dim"`
+	BaseURL  *string `json:"baseUrl"`
+}
+
+// StoreResult はstore設定の結果
+type StoreResult struct {
+	Type string  `json:"type"`
+	Path *string `json:"path"`
+	URL  *string `json:"url"`
+}
+
+// PathsResult はパス設定の結果
+type PathsResult struct {
+	ConfigPath string `json:"configPath"`
+	DataDir    string `json:"dataDir"`
+}
+
+// SetConfigResult は memory.set_config の結果
+type SetConfigResult struct {
+	OK                 bool   `json:"ok"`
+	EffectiveNamespace string `json:"effectiveNamespace"`
+	PreviousNamespace  string `json:"previousNamespace"`
+	ReembedRequired    bool   `json:"reembedRequired"`   // 既存ノートはpreviousNamespaceに残り、再埋め込みが必要
+	PreviousNoteCount  int    `json:"previousNoteCount"` // reembedRequiredの場合、previousNamespaceのノート数
+	DualRead           bool   `json:"dualRead"`          // previousNamespaceのノートも読み出している
+}
+
+// MigrateResult は memory.migrate の結果
+type MigrateResult struct {
+	From       string `json:"from"`
+	Namespace  string `json:"namespace"`
+	Projects   int    `json:"projects"`
+	Created    int    `json:"created"`
+	Skipped    int    `json:"skipped"`
+	ReEmbedded int    `json:"reEmbedded"`
+}
+
+// ArchiveResult は memory.archive の結果
+type ArchiveResult struct {
+	Namespace string               `json:"namespace"`
+	DryRun    bool                 `json:"dryRun"`
+	Notes     []ArchivedNoteResult `json:"notes"` // 移した（dryRunなら移す）ノート
+}
+
+// ArchivedNoteResult はアーカイブの1件のノート
+type ArchivedNoteResult struct {
+	ID         string  `json:"id"`
+	ProjectID  string  `json:"projectId"`
+	GroupID    string  `json:"groupId"`
+	Title      *string `json:"title"`
+	CreatedAt  string  `json:"createdAt"`
+	ArchivedAt string  `json:"archivedAt,omitempty"` // dryRunでは空
+}
+
+// RestoreResult は memory.restore の結果
+type RestoreResult struct {
+	Restored []string `json:"restored"`
+	NotFound []string `json:"notFound"` // アーカイブになかったID
+}
+
+// ListArchivedResult は memory.list_archived の結果
+type ListArchivedResult struct {
+	Namespace string               `json:"namespace"`
+	Notes     []ArchivedNoteResult `json:"notes"` // createdAt昇順
+}
+
+// ValidateResult は memory.validate の結果
+type ValidateResult struct {
+	Namespace string             `json:"namespace"`
+	Scanned   int                `json:"scanned"` // 検証したノート数
+	Issues    []IndexIssueResult `json:"issues"`
+}
+
+// IndexIssueResult は memory.validate で見つかった1件の問題
+type IndexIssueResult struct {
+	Kind      string `json:"kind"` // invalid_note/missing_embedding/dimension_mismatch/dangling_parent/namespace_mismatch/unreadable_project
+	ProjectID string `json:"projectId,omitempty"`
+	ID        string `json:"id,omitempty"` // ノート・グループのID
+	Message   string `json:"message"`
+	Repaired  bool   `json:"repaired"`
+	Action    string `json:"action,omitempty"` // reembedded/quarantined/cleared_parent
+}
+
+// AddProjectAliasResult は memory.add_project_alias の結果
+type AddProjectAliasResult struct {
+	OK        bool   `json:"ok"`
+	Path      string `json:"path"` // 正規化済みパス
+	ProjectID string `json:"projectId"`
+}
+
+// UpsertGlobalResult は memory.upsert_global の結果
+type UpsertGlobalResult struct {
+	OK        bool   `json:"ok"`
+	ID        string `json:"id"`
+	Namespace string `json:"namespace"`
+}
+
+// GetGlobalResult は memory.get_global の結果
+// found=false の場合 id/value/updatedAt/updatedBy は省略される
+type GetGlobalResult struct {
+	Namespace string  `json:"namespace"`
+	Found     bool    `json:"found"`
+	ID        *string `json:"id,omitempty"`
+	Value     any     `json:"value,omitempty"`
+	UpdatedAt *string `json:"updatedAt,omitempty"`
+	UpdatedBy string  `json:"updatedBy,omitempty"`
+}
+
+// GetGlobalHistoryResult は memory.get_global_history の結果
+// history は上書きされる前の値（新しい順）で、現在の値は含まない
+type GetGlobalHistoryResult struct {
+	Namespace string              `json:"namespace"`
+	Found     bool                `json:"found"`
+	History   []GlobalHistoryItem `json:"history"`
+}
+
+// GlobalHistoryItem はグローバル設定の過去の値1件
+type GlobalHistoryItem struct {
+	Value     any     `json:"value"`
+	UpdatedAt *string `json:"updatedAt,omitempty"`
+	UpdatedBy string  `json:"updatedBy,omitempty"`
+}
+
+// ExportGlobalsResult は memory.export_globals の結果
+// globals はそのまま memory.import_globals に渡せる
+type ExportGlobalsResult struct {
+	Namespace string            `json:"namespace"`
+	ProjectID string            `json:"projectId"`
+	Globals   []GlobalEntryItem `json:"globals"`
+}
+
+// GlobalEntryItem はエクスポートしたグローバル設定1件
+type GlobalEntryItem struct {
+	Key       string  `json:"key"`
+	Value     any     `json:"value"`
+	UpdatedAt *string `json:"updatedAt,omitempty"`
+	UpdatedBy string  `json:"updatedBy,omitempty"`
+}
+
+// ImportGlobalsResult は memory.import_globals の結果
+type ImportGlobalsResult struct {
+	Namespace string `json:"namespace"`
+	ProjectID string `json:"projectId"`
+	Created   int    `json:"created"`
+	Updated   int    `json:"updated"`
+	Skipped   int    `json:"skipped"`
+}
+
+// ImportResult はHTTPの /import の結果
+type ImportResult struct {
+	Namespace  string `json:"namespace"`
+	ProjectID  string `json:"projectId"`
+	Created    int    `json:"created"`
+	Updated    int    `json:"updated"`
+	Skipped    int    `json:"skipped"`
+	ReEmbedded int    `json:"reEmbedded"`
+}
+
+// GroupCreateResult は memory.group_create の結果
+type GroupCreateResult struct {
+	ID        string `json:"id"`
+	Namespace string `json:"namespace"`
+}
+
+// GroupItem はグループ1件（memory.group_list の要素）
+type GroupItem struct {
+	ID          string `json:"id"`
+	ProjectID   string `json:"projectId"`
+	GroupKey    string `json:"groupKey"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	CreatedAt   string `json:"createdAt"`
+	UpdatedAt   string `json:"updatedAt"`
+	// Tags/Status/Metadata はグループの注釈（statusはactiveまたはdone）
+	Tags     []string       `json:"tags"`
+	Status   string         `json:"status"`
+	Metadata map[string]any `json:"metadata"`
+	// ParentGroupID は親グループのgroupKey（トップレベルなら空）
+	ParentGroupID string `json:"parentGroupId"`
+}
+
+// GroupGetResult は memory.group_get の結果
+type GroupGetResult struct {
+	GroupItem
+	Namespace string `json:"namespace"`
+}
+
+// GroupListResult は memory.group_list の結果
+type GroupListResult struct {
+	Namespace string          `json:"namespace"`
+	Groups    []GroupListItem `json:"groups"`
+}
+
+// GroupListItem は memory.group_list のグループ1件（ノートの統計付き）
+type GroupListItem struct {
+	GroupItem
+	NoteCount  int    `json:"noteCount"`
+	LastNoteAt string `json:"lastNoteAt,omitempty"` // 最も新しいノートのcreatedAt
+	TextLength int    `json:"textLength"`           // 本文の合計文字数
+}
+
+// MethodDescription はメソッド1件のスキーマ
+type MethodDescription struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Params      *model.JSONSchema `json:"params,omitempty"`
+	Result      model.JSONSchema  `json:"result"`
+}
+
+// DescribeResult は memory.describe の結果
+type DescribeResult struct {
+	Methods []MethodDescription `json:"methods"`
+}
@@ -0,0 +1,206 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+)
+
+// errUnknownDescribeMethod は memory.describe で未知のメソッドが指定された場合のエラー
+var errUnknownDescribeMethod = errors.New("unknown method")
+
+// methodSpec はJSON-RPCメソッドのparams/result型の定義
+// Params/Resultにはゼロ値を渡し、型情報のみを使用する（Paramsがnilの場合はparamsなし）
+type methodSpec struct {
+	Name        string
+	Description string
+	Params      any
+	Result      any
+}
+
+// methodSpecs は公開している全メソッドの定義（dispatchと同じ順序）
+var methodSpecs = []methodSpec{
+	{Name: "initialize", Description: "MCP initialize handshake", Params: model.InitializeParams{}, Result: model.InitializeResult{}},
+	{Name: "tools/list", Description: "List MCP tools", Result: model.ToolsListResult{}},
+	{Name: "tools/call", Description: "Call an MCP tool", Params: model.ToolsCallParams{}, Result: model.ToolsCallResult{}},
+	{Name: "memory.describe", Description: "Describe params/result schemas of all methods", Params: DescribeParams{}, Result: DescribeResult{}},
+	{Name: "memory.add_note", Description: "Add a note", Params: AddNoteParams{}, Result: AddNoteResult{}},
+	{Name: "memory.add_notes", Description: "Add notes in one batch and report the result of each (retry only the items whose status is not ok)", Params: AddNotesParams{}, Result: AddNotesResult{}},
+	{Name: "memory.search", Description: "Search notes by semantic similarity", Params: SearchParams{}, Result: SearchResult{}},
+	{Name: "memory.get", Description: "Get a note by ID", Params: GetParams{}, Result: NoteResult{}},
+	{Name: "memory.update", Description: "Update a note", Params: UpdateParams{}, Result: UpdateResult{}},
+	{Name: "memory.list_recent", Description: "List recent notes", Params: ListRecentParams{}, Result: ListRecentResult{}},
+	{Name: "memory.get_config", Description: "Get server configuration", Result: GetConfigResult{}},
+	{Name: "memory.server_info", Description: "Get the server version, transports, store, namespace, embedder, uptime and enabled features", Result: ServerInfoResult{}},
+	{Name: "memory.stats", Description: "Get the note count of the current namespace per project, its level against store.noteThresholds and the growth since an earlier measurement", Result: StatsResult{}},
+	{Name: "memory.set_config", Description: "Update embedder configuration", Params: SetConfigParams{}, Result: SetConfigResult{}},
+	{Name: "memory.migrate", Description: "Re-embed notes of another namespace (default: previousEmbedder) into the current one", Params: MigrateParams{}, Result: MigrateResult{}},
+	{Name: "memory.archive", Description: "Move notes not accessed in olderThanDays (default: archive.afterDays) days to the compressed cold store, excluding them from normal search", Params: ArchiveParams{}, Result: ArchiveResult{}},
+	{Name: "memory.restore", Description: "Move archived notes back to the store with their stored embeddings", Params: RestoreParams{}, Result: RestoreResult{}},
+	{Name: "memory.list_archived", Description: "List archived notes, oldest first", Params: ListArchivedParams{}, Result: ListArchivedResult{}},
+	{Name: "memory.validate", Description: "Check notes, embeddings and groups for corruption; with repair, re-embed missing or mis-sized vectors, quarantine broken notes and clear dangling parent groups", Params: ValidateParams{}, Result: ValidateResult{}},
+	{Name: "memory.add_project_alias", Description: "Map a path (another checkout of the repository) to a logical projectId", Params: AddProjectAliasParams{}, Result: AddProjectAliasResult{}},
+	{Name: "memory.upsert_global", Description: "Upsert a global config value", Params: UpsertGlobalParams{}, Result: UpsertGlobalResult{}},
+	{Name: "memory.get_global", Description: "Get a global config value", Params: GetGlobalParams{}, Result: GetGlobalResult{}},
+	{Name: "memory.get_global_history", Description: "Get the previous values of a global config key, newest first", Params: GetGlobalHistoryParams{}, Result: GetGlobalHistoryResult{}},
+	{Name: "memory.export_globals", Description: "Export all global config values of a project", Params: ExportGlobalsParams{}, Result: ExportGlobalsResult{}},
+	{Name: "memory.import_globals", Description: "Import global config values into a project (e.g. copied from another project)", Params: ImportGlobalsParams{}, Result: ImportGlobalsResult{}},
+	{Name: "memory.subscribe", Description: "Receive notifications/memory/changed when notes or global config of a project change (stdio/pipe; use GET /events over HTTP)", Params: SubscribeParams{}, Result: OKResult{}},
+	{Name: "memory.unsubscribe", Description: "Stop the notifications of memory.subscribe", Params: SubscribeParams{}, Result: OKResult{}},
+	{Name: "memory.delete", Description: "Delete a note or global config by ID", Params: DeleteParams{}, Result: OKResult{}},
+	{Name: "memory.group_create", Description: "Create a group", Params: GroupCreateParams{}, Result: GroupCreateResult{}},
+	{Name: "memory.group_get", Description: "Get a group by ID", Params: GroupGetParams{}, Result: GroupGetResult{}},
+	{Name: "memory.group_update", Description: "Update a group", Params: GroupUpdateParams{}, Result: OKResult{}},
+	{Name: "memory.group_delete", Description: "Delete a group (cascade: delete, move or restrict its notes)", Params: GroupDeleteParams{}, Result: GroupDeleteResult{}},
+	{Name: "memory.group_rename", Description: "Change a group's groupKey and move its notes to the new key", Params: GroupRenameParams{}, Result: GroupRenameResult{}},
+	{Name: "memory.group_list", Description: "List groups in a project with note statistics", Params: GroupListParams{}, Result: GroupListResult{}},
+}
+
+// handleDescribe は memory.describe を処理
+func (h *Handler) handleDescribe(ctx context.Context, params any) (any, error) {
+	var p DescribeParams
+	if err := mapParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	return describeMethods(p.Method)
+}
+
+// Describe は全メソッドのスキーマをJSONで返す（HTTP /schema 用）
+func (h *Handler) Describe() []byte {
+	result, _ := describeMethods("")
+	b, _ := json.Marshal(result)
+	return b
+}
+
+// describeMethods はメソッドのスキーマ一覧を生成する
+// methodが空の場合は全メソッド、指定時はそのメソッドのみ
+func describeMethods(method string) (*DescribeResult, error) {
+	result := &DescribeResult{Methods: []MethodDescription{}}
+	for _, spec := range methodSpecs {
+		if method != "" && spec.Name != method {
+			continue
+		}
+
+		desc := MethodDescription{
+			Name:        spec.Name,
+			Description: spec.Description,
+			Result:      SchemaOf(spec.Result),
+		}
+		if spec.Params != nil {
+			params := SchemaOf(spec.Params)
+			desc.Params = &params
+		}
+		result.Methods = append(result.Methods, desc)
+	}
+
+	if method != "" && len(result.Methods) == 0 {
+		return nil, errUnknownDescribeMethod
+	}
+
+	return result, nil
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// SchemaOf はGoの値の型からJSON Schemaを生成する
+// - フィールド名はjsonタグに従う（"-" は除外、埋め込み構造体は展開）
+// - `jsonschema:"required"` タグ付きのフィールドはrequiredに含める
+// - any / json.RawMessage は任意の値（空スキーマ）として扱う
+func SchemaOf(v any) model.JSONSchema {
+	return schemaForType(reflect.TypeOf(v), map[reflect.Type]bool{})
+}
+
+func schemaForType(t reflect.Type, visiting map[reflect.Type]bool) model.JSONSchema {
+	if t == nil {
+		return model.JSONSchema{}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return model.JSONSchema{Type: "string", Format: "date-time"}
+	case rawMessageType:
+		return model.JSONSchema{}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return model.JSONSchema{Type: "string"}
+	case reflect.Bool:
+		return model.JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return model.JSONSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return model.JSONSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		items := schemaForType(t.Elem(), visiting)
+		return model.JSONSchema{Type: "array", Items: &items}
+	case reflect.Map:
+		return model.JSONSchema{Type: "object"}
+	case reflect.Struct:
+		// 再帰型（JSONSchema自身など）は2段目以降をobjectとして打ち切る
+		if visiting[t] {
+			return model.JSONSchema{Type: "object"}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		schema := model.JSONSchema{
+			Type:       "object",
+			Properties: map[string]model.JSONSchema{},
+		}
+		addStructFields(&schema, t, visiting)
+		return schema
+	default:
+		// interface等は任意の値
+		return model.JSONSchema{}
+	}
+}
+
+// addStructFields は構造体のフィールドをスキーマのpropertiesに追加する
+func addStructFields(schema *model.JSONSchema, t reflect.Type, visiting map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		// 埋め込み構造体はencoding/jsonと同様にフィールドを展開
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				addStructFields(schema, ft, visiting)
+				continue
+			}
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		schema.Properties[name] = schemaForType(f.Type, visiting)
+		if f.Tag.Get("jsonschema") == "required" {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+)
+
+// === memory.describe テスト ===
+
+func TestHandle_Describe_AllMethods(t *testing.T) {
+	h := newTestHandler()
+	req := makeRequest("memory.describe", nil)
+	result := h.Handle(context.Background(), req)
+	resp := parseResponse(t, result)
+
+	if resp["error"] != nil {
+		t.Fatalf("unexpected error: %v", resp["error"])
+	}
+
+	methods := resp["result"].(map[string]any)["methods"].([]any)
+	if len(methods) != len(methodSpecs) {
+		t.Fatalf("expected %d methods, got %d", len(methodSpecs), len(methods))
+	}
+
+	names := make(map[string]bool)
+	for _, m := range methods {
+		names[m.(map[string]any)["name"].(string)] = true
+	}
+	for _, name := range []string{"initialize", "tools/list", "tools/call", "memory.add_note", "memory.group_list"} {
+		if !names[name] {
+			t.Errorf("expected method %s in describe result", name)
+		}
+	}
+}
+
+func TestHandle_Describe_SingleMethod(t *testing.T) {
+	h := newTestHandler()
+	req := makeRequest("memory.describe", map[string]any{"method": "memory.add_note"})
+	result := h.Handle(context.Background(), req)
+
+	var resp struct {
+		Result DescribeResult `json:"result"`
+	}
+	if err := json.Unmarshal(result, &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+
+	if len(resp.Result.Methods) != 1 {
+		t.Fatalf("expected 1 method, got %d", len(resp.Result.Methods))
+	}
+	desc := resp.Result.Methods[0]
+	if desc.Params == nil {
+		t.Fatal("expected params schema")
+	}
+
+	required := map[string]bool{}
+	for _, r := range desc.Params.Required {
+		required[r] = true
+	}
+	for _, r := range []string{"projectId", "groupId", "text"} {
+		if !required[r] {
+			t.Errorf("expected %s to be required, got %v", r, desc.Params.Required)
+		}
+	}
+	if required["title"] {
+		t.Error("expected title to be optional")
+	}
+	if desc.Params.Properties["tags"].Type != "array" || desc.Params.Properties["tags"].Items.Type != "string" {
+		t.Errorf("expected tags to be array of string, got %+v", desc.Params.Properties["tags"])
+	}
+	if desc.Result.Properties["canonicalProjectId"].Type != "string" {
+		t.Errorf("expected canonicalProjectId string in result, got %+v", desc.Result.Properties)
+	}
+}
+
+func TestHandle_Describe_NoParamsMethod(t *testing.T) {
+	result, err := describeMethods("memory.get_config")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Methods[0].Params != nil {
+		t.Errorf("expected no params schema for get_config, got %+v", result.Methods[0].Params)
+	}
+}
+
+func TestHandle_Describe_UnknownMethod(t *testing.T) {
+	h := newTestHandler()
+	req := makeRequest("memory.describe", map[string]any{"method": "memory.unknown"})
+	result := h.Handle(context.Background(), req)
+	errResp := parseErrorResponse(t, result)
+
+	if errResp.Error.Code != model.ErrCodeInvalidParams {
+		t.Errorf("expected error code %d, got %d", model.ErrCodeInvalidParams, errResp.Error.Code)
+	}
+}
+
+func TestSchemaOf_EmbeddedAndRecursive(t *testing.T) {
+	// 埋め込み構造体は展開される
+	s := SchemaOf(GroupGetResult{})
+	if _, ok := s.Properties["groupKey"]; !ok {
+		t.Errorf("expected embedded field groupKey, got %+v", s.Properties)
+	}
+	if _, ok := s.Properties["namespace"]; !ok {
+		t.Errorf("expected namespace, got %+v", s.Properties)
+	}
+
+	// 再帰型でも無限ループしない
+	s = SchemaOf(model.JSONSchema{})
+	if s.Properties["items"].Type != "object" {
+		t.Errorf("expected recursive items to be object, got %+v", s.Properties["items"])
+	}
+}
+
+func TestHandler_Describe(t *testing.T) {
+	h := newTestHandler()
+	var result DescribeResult
+	if err := json.Unmarshal(h.Describe(), &result); err != nil {
+		t.Fatalf("failed to parse describe output: %v", err)
+	}
+	if len(result.Methods) != len(methodSpecs) {
+		t.Errorf("expected %d methods, got %d", len(methodSpecs), len(result.Methods))
+	}
+}
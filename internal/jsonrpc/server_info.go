@@ -0,0 +1,75 @@
+package jsonrpc
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/session"
+)
+
+// ServerInfo はmemory.server_infoで返す、起動時に決まるサーバーの情報
+type ServerInfo struct {
+	Version    string   // 空ならServerVersion
+	Commit     string   // ビルドしたコミット（不明なら空）
+	Transports []string // 起動しているtransport
+	// Namespace はサービスが現在使っているnamespaceを返す（nilなら設定のembedderから求める）
+	// 設定のdimは初回の埋め込みで更新されるが、サービスは作成時のnamespaceを使い続けるため
+	Namespace func() string
+}
+
+// handleServerInfo は memory.server_info を処理
+func (h *Handler) handleServerInfo(ctx context.Context) (any, error) {
+	cfg, err := h.configService.GetConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	info := h.serverInfo
+	version := info.Version
+	if version == "" {
+		version = ServerVersion
+	}
+	transports := info.Transports
+	if transports == nil {
+		transports = []string{}
+	}
+	namespace := config.GenerateNamespace(cfg.Embedder.Provider, cfg.Embedder.Model, cfg.Embedder.Dim)
+	if info.Namespace != nil {
+		namespace = info.Namespace()
+	}
+
+	return &ServerInfoResult{
+		Name:       "mcp-memory",
+		Version:    version,
+		Commit:     info.Commit,
+		Transports: transports,
+		StoreType:  cfg.Store.Type,
+		Namespace:  namespace,
+		Embedder: ServerEmbedderResult{
+			Provider: cfg.Embedder.Provider,
+			Model:    cfg.Embedder.Model,
+			Dim:      cfg.Embedder.Dim,
+		},
+		StartedAt:     h.startedAt.UTC().Format(time.RFC3339),
+		UptimeSeconds: int64(time.Since(h.startedAt).Seconds()),
+		Features:      h.features(ctx, cfg.Store, cfg.PreviousEmbedder != nil),
+	}, nil
+}
+
+// features はクライアントが使える機能の有無を返す（キーは常にすべて含める）
+func (h *Handler) features(ctx context.Context, st model.StoreConfig, dualRead bool) map[string]bool {
+	sess := session.FromContext(ctx)
+	return map[string]bool{
+		"subscribe":      sess != nil && sess.CanNotify(),                  // この接続でmemory.subscribeの通知を受け取れる
+		"events":         slices.Contains(h.serverInfo.Transports, "http"), // HTTPのGET /events
+		"migrate":        h.migrate != nil,
+		"embedderReload": h.reinit != nil, // set_configのembedder変更をサービスに反映する
+		"dualRead":       dualRead,
+		"audit":          h.auditLog != nil,
+		"webhooks":       h.webhooks != nil,
+		"sharedGlobals":  st.GlobalsScope == model.GlobalsScopeShared,
+	}
+}
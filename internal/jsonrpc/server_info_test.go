@@ -0,0 +1,60 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/session"
+)
+
+// serverInfo は memory.server_info を呼び出して結果を返す
+func serverInfo(t *testing.T, ctx context.Context, h *Handler) ServerInfoResult {
+	t.Helper()
+	var resp struct {
+		Result ServerInfoResult `json:"result"`
+		Error  any              `json:"error"`
+	}
+	if err := json.Unmarshal(h.Handle(ctx, makeRequest("memory.server_info", nil)), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %v", resp.Error)
+	}
+	return resp.Result
+}
+
+func TestHandle_ServerInfo(t *testing.T) {
+	h := newTestHandler()
+
+	// 未設定ならServerVersionと設定から求めたnamespaceを返す
+	result := serverInfo(t, context.Background(), h)
+	if result.Version != ServerVersion || result.Namespace != "openai:text-embedding-3-small:1536" || result.StoreType != "chroma" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if result.Embedder.Dim != 1536 || result.StartedAt == "" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if result.Features["events"] || result.Features["migrate"] || result.Features["subscribe"] {
+		t.Errorf("unexpected features: %v", result.Features)
+	}
+
+	h.SetServerInfo(ServerInfo{
+		Version:    "1.2.3",
+		Commit:     "abc1234",
+		Transports: []string{"stdio", "http"},
+		Namespace:  func() string { return "openai:text-embedding-3-small:0" },
+	})
+	sess := session.New(session.Info{})
+	sess.SetNotifier(func([]byte) {})
+	ctx := session.NewContext(context.Background(), sess)
+
+	// 通知を送れるセッションならsubscribeが有効
+	result = serverInfo(t, ctx, h)
+	if result.Version != "1.2.3" || result.Commit != "abc1234" || result.Namespace != "openai:text-embedding-3-small:0" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if !result.Features["events"] || !result.Features["subscribe"] || result.Features["audit"] || result.Features["webhooks"] {
+		t.Errorf("unexpected features: %v", result.Features)
+	}
+}
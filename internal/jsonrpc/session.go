@@ -0,0 +1,23 @@
+package jsonrpc
+
+import (
+	"context"
+
+	"github.com/brbranch/embedding_mcp/internal/session"
+)
+
+// defaultProjectID はprojectId未指定時にセッションのデフォルト値を返す
+func defaultProjectID(ctx context.Context, projectID string) string {
+	if projectID != "" {
+		return projectID
+	}
+	return session.InfoFromContext(ctx).DefaultProjectID
+}
+
+// defaultGroupID はgroupId未指定時にセッションのデフォルト値を返す
+func defaultGroupID(ctx context.Context, groupID string) string {
+	if groupID != "" {
+		return groupID
+	}
+	return session.InfoFromContext(ctx).DefaultGroupID
+}
@@ -0,0 +1,103 @@
+package jsonrpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/service"
+	"github.com/brbranch/embedding_mcp/internal/session"
+)
+
+// === セッションのデフォルト値テスト ===
+
+func TestHandle_Initialize_SessionDefaults(t *testing.T) {
+	var gotReq *service.AddNoteRequest
+	noteSvc := &mockNoteService{
+		addNoteFunc: func(ctx context.Context, req *service.AddNoteRequest) (*service.AddNoteResponse, error) {
+			gotReq = req
+			return &service.AddNoteResponse{ID: "id", Namespace: "ns"}, nil
+		},
+	}
+	h := New(noteSvc, &mockConfigService{}, &mockGlobalService{}, &mockGroupService{})
+
+	sess := session.New(session.Info{})
+	ctx := session.NewContext(context.Background(), sess)
+
+	initReq := []byte(`{
+		"jsonrpc": "2.0",
+		"id": 1,
+		"method": "initialize",
+		"params": {
+			"protocolVersion": "2024-11-05",
+			"clientInfo": {"name": "test-agent", "version": "1.0.0"},
+			"session": {"projectId": "/session/project", "groupId": "feature-x"}
+		}
+	}`)
+	resp := parseResponse(t, h.Handle(ctx, initReq))
+	if resp["error"] != nil {
+		t.Fatalf("unexpected error: %v", resp["error"])
+	}
+
+	info := sess.Info()
+	if info.ClientID() != "test-agent/1.0.0" {
+		t.Errorf("expected client id test-agent/1.0.0, got %q", info.ClientID())
+	}
+
+	// projectId/groupIdを省略したadd_noteにデフォルト値が補完されること
+	req := makeRequest("memory.add_note", map[string]any{"text": "hello"})
+	resp = parseResponse(t, h.Handle(ctx, req))
+	if resp["error"] != nil {
+		t.Fatalf("unexpected error: %v", resp["error"])
+	}
+	if gotReq.ProjectID != "/session/project" {
+		t.Errorf("expected projectId /session/project, got %q", gotReq.ProjectID)
+	}
+	if gotReq.GroupID != "feature-x" {
+		t.Errorf("expected groupId feature-x, got %q", gotReq.GroupID)
+	}
+
+	// 明示指定はデフォルト値より優先されること
+	req = makeRequest("memory.add_note", map[string]any{"projectId": "/explicit", "groupId": "global", "text": "hello"})
+	h.Handle(ctx, req)
+	if gotReq.ProjectID != "/explicit" || gotReq.GroupID != "global" {
+		t.Errorf("expected explicit values, got projectId=%q groupId=%q", gotReq.ProjectID, gotReq.GroupID)
+	}
+}
+
+func TestHandle_SessionDefaults_ToolsCall(t *testing.T) {
+	var gotReq *service.SearchRequest
+	noteSvc := &mockNoteService{
+		searchFunc: func(ctx context.Context, req *service.SearchRequest) (*service.SearchResponse, error) {
+			gotReq = req
+			return &service.SearchResponse{Namespace: "ns", Results: []service.SearchResult{}}, nil
+		},
+	}
+	h := New(noteSvc, &mockConfigService{}, &mockGlobalService{}, &mockGroupService{})
+
+	ctx := session.NewContext(context.Background(), session.New(session.Info{DefaultProjectID: "/header/project"}))
+	req := makeRequest("tools/call", map[string]any{
+		"name":      "memory_search",
+		"arguments": map[string]any{"query": "test"},
+	})
+	h.Handle(ctx, req)
+
+	if gotReq == nil || gotReq.ProjectID != "/header/project" {
+		t.Errorf("expected projectId from session, got %+v", gotReq)
+	}
+}
+
+func TestHandle_NoSession_NoDefaults(t *testing.T) {
+	var gotReq *service.ListRecentRequest
+	noteSvc := &mockNoteService{
+		listRecentFunc: func(ctx context.Context, req *service.ListRecentRequest) (*service.ListRecentResponse, error) {
+			gotReq = req
+			return &service.ListRecentResponse{Namespace: "ns", Items: []service.ListRecentItem{}}, nil
+		},
+	}
+	h := New(noteSvc, &mockConfigService{}, &mockGlobalService{}, &mockGroupService{})
+
+	h.Handle(context.Background(), makeRequest("memory.list_recent", map[string]any{}))
+	if gotReq == nil || gotReq.ProjectID != "" {
+		t.Errorf("expected empty projectId without session, got %+v", gotReq)
+	}
+}
@@ -0,0 +1,63 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// handleStats は memory.stats を処理
+func (h *Handler) handleStats(ctx context.Context) (any, error) {
+	if h.stats == nil {
+		return nil, errStatsUnavailable
+	}
+	resp, err := h.stats.Stats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &StatsResult{
+		Namespace:      resp.Namespace,
+		Notes:          resp.Notes,
+		Projects:       make([]ProjectStatsResult, len(resp.Projects)),
+		Thresholds:     NoteThresholdsResult{Soft: resp.Soft, Hard: resp.Hard},
+		Level:          resp.Level,
+		Recommendation: resp.Recommendation,
+		Growth:         resp.Growth,
+	}
+	for i, p := range resp.Projects {
+		result.Projects[i] = ProjectStatsResult{ProjectID: p.ProjectID, Notes: p.Notes}
+	}
+	if !resp.GrowthSince.IsZero() {
+		result.GrowthSince = resp.GrowthSince.UTC().Format(time.RFC3339)
+	}
+	return result, nil
+}
+
+// healthResponse は /health の応答
+type healthResponse struct {
+	Status    string `json:"status"` // ok / warning / critical（ノート数の閾値）、error（storeに問い合わせられない）
+	Namespace string `json:"namespace,omitempty"`
+	Notes     int    `json:"notes"`
+	Soft      int    `json:"soft,omitempty"`
+	Hard      int    `json:"hard,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Health はHTTPの /health の応答を返す（http.HealthCheckerの実装）
+// storeに問い合わせられない場合のみokをfalseにする。ノート数が閾値を超えていてもサーバーは動作しているため
+func (h *Handler) Health(ctx context.Context) ([]byte, bool) {
+	resp := healthResponse{Status: "ok"}
+	ok := true
+	if h.stats != nil {
+		stats, err := h.stats.Stats(ctx)
+		if err != nil {
+			resp.Status, resp.Error, ok = "error", err.Error(), false
+		} else {
+			resp.Status, resp.Namespace, resp.Notes = stats.Level, stats.Namespace, stats.Notes
+			resp.Soft, resp.Hard = stats.Soft, stats.Hard
+		}
+	}
+	b, _ := json.Marshal(resp)
+	return b, ok
+}
@@ -0,0 +1,83 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// mockStatsService は固定の統計を返すStatsService
+type mockStatsService struct {
+	resp *service.StatsResponse
+	err  error
+}
+
+func (m *mockStatsService) Stats(ctx context.Context) (*service.StatsResponse, error) {
+	return m.resp, m.err
+}
+
+func TestHandle_Stats(t *testing.T) {
+	h := newTestHandler()
+
+	// 未設定（serve以外）ならエラー
+	errResp := parseErrorResponse(t, h.Handle(context.Background(), makeRequest("memory.stats", nil)))
+	if errResp.Error.Code != model.ErrCodeInternalError {
+		t.Errorf("expected code %d, got %d", model.ErrCodeInternalError, errResp.Error.Code)
+	}
+
+	h.SetStatsService(&mockStatsService{resp: &service.StatsResponse{
+		Namespace:      "openai:text-embedding-3-small:1536",
+		Notes:          6000,
+		Projects:       []service.ProjectStats{{ProjectID: "/test/a", Notes: 5000}, {ProjectID: "/test/b", Notes: 1000}},
+		Soft:           5000,
+		Level:          service.NoteLevelWarning,
+		Recommendation: "prune",
+		GrowthSince:    time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+		Growth:         120,
+	}})
+	var resp struct {
+		Result StatsResult `json:"result"`
+	}
+	if err := json.Unmarshal(h.Handle(context.Background(), makeRequest("memory.stats", nil)), &resp); err != nil {
+		t.Fatal(err)
+	}
+	r := resp.Result
+	if r.Notes != 6000 || r.Level != "warning" || r.Thresholds.Soft != 5000 || len(r.Projects) != 2 || r.Projects[0].ProjectID != "/test/a" {
+		t.Errorf("unexpected result: %+v", r)
+	}
+	if r.GrowthSince != "2024-01-15T00:00:00Z" || r.Growth != 120 {
+		t.Errorf("unexpected growth: %+v", r)
+	}
+}
+
+func TestHandler_Health(t *testing.T) {
+	h := newTestHandler()
+
+	// StatsService未設定なら件数なしでok
+	body, ok := h.Health(context.Background())
+	if !ok || string(body) != `{"status":"ok","notes":0}` {
+		t.Errorf("unexpected health: %s, %v", body, ok)
+	}
+
+	h.SetStatsService(&mockStatsService{resp: &service.StatsResponse{Namespace: "openai:test:3", Notes: 25000, Soft: 5000, Hard: 20000, Level: service.NoteLevelCritical}})
+	body, ok = h.Health(context.Background())
+	var health healthResponse
+	if err := json.Unmarshal(body, &health); err != nil {
+		t.Fatal(err)
+	}
+	// 閾値を超えていても動作はしている
+	if !ok || health.Status != "critical" || health.Notes != 25000 || health.Hard != 20000 {
+		t.Errorf("unexpected health: %s, %v", body, ok)
+	}
+
+	h.SetStatsService(&mockStatsService{err: errors.New("connection refused")})
+	body, ok = h.Health(context.Background())
+	if ok || json.Unmarshal(body, &health) != nil || health.Status != "error" || health.Error != "connection refused" {
+		t.Errorf("unexpected health: %s, %v", body, ok)
+	}
+}
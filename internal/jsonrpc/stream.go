@@ -0,0 +1,183 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/brbranch/embedding_mcp/internal/audit"
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// streamItems は応答を項目ごとに書き出す件数の下限（これ未満の結果はまとめてエンコードする）
+// 書き出し中も、この件数ごとにflushして受け手に送る
+const streamItems = 100
+
+// errExportUnavailable はExportServiceが未設定の場合のエラー
+var errExportUnavailable = errors.New("export is not available")
+
+// flusher はバッファした出力を受け手に送るWriter（http.ResponseWriterなど）
+type flusher interface {
+	Flush()
+}
+
+// streamResult は項目を1件ずつ書き出せる結果（memory.search・memory.list_recent）
+type streamResult interface {
+	itemCount() int
+	// encodeStream はjson.Marshalと同じJSONをwに書き出す（項目を1件書くごとにeachを呼ぶ）
+	encodeStream(w io.Writer, each func() error) error
+}
+
+func (r *SearchResult) itemCount() int { return len(r.Results) }
+
+func (r *SearchResult) encodeStream(w io.Writer, each func() error) error {
+	return encodeItems(w, searchHead{Namespace: r.Namespace, Groups: r.Groups}, "results", r.Results, each)
+}
+
+func (r *ListRecentResult) itemCount() int { return len(r.Items) }
+
+func (r *ListRecentResult) encodeStream(w io.Writer, each func() error) error {
+	head := listRecentHead{Namespace: r.Namespace, Total: r.Total, TotalApproximate: r.TotalApproximate, NextCursor: r.NextCursor}
+	return encodeItems(w, head, "items", r.Items, each)
+}
+
+// encodeItems は {<headの項目>,"<itemsKey>":[item,...]} を項目ごとにエンコードして書き出す
+// headはitems以外の項目をJSONオブジェクトにする値（結果の構造体と同じ順序の項目を持つ）
+func encodeItems[T any](w io.Writer, value any, itemsKey string, items []T, each func() error) error {
+	head, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	// 末尾の"}"を外して項目の配列を続ける
+	if _, err := w.Write(head[:len(head)-1]); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, `,"`+itemsKey+`":[`); err != nil {
+		return err
+	}
+	for i := range items {
+		if i > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		b, err := json.Marshal(&items[i])
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+		if err := each(); err != nil {
+			return err
+		}
+	}
+	_, err = io.WriteString(w, "]}")
+	return err
+}
+
+// HandleTo はHandleと同じくリクエストを処理し、レスポンスをwに書き出す（通知なら何も書かない）
+// streamItems件以上のmemory.search・memory.list_recentの結果は、レスポンス全体のJSONを組み立てずに
+// 項目ごとにエンコードし、streamItems件ごとにflushする（wがFlush()を持っていればそれも呼ぶ）
+// 書き出すJSONはHandleの戻り値と同じ
+func (h *Handler) HandleTo(ctx context.Context, requestBytes []byte, w io.Writer) error {
+	req, isNotification, errResp := h.parseRequest(requestBytes)
+	if req == nil || isNotification {
+		if req != nil {
+			h.handleRequest(ctx, req, true)
+			h.ApplyPendingServices()
+		}
+		_, err := w.Write(errResp)
+		return err
+	}
+
+	h.mu.RLock()
+	result, err := h.dispatch(ctx, req.ID, req.Method, req.Params)
+	h.mu.RUnlock()
+	h.ApplyPendingServices()
+	if err != nil {
+		_, err := w.Write(h.encodeError(h.mapError(req.ID, err)))
+		return err
+	}
+
+	sr, ok := result.(streamResult)
+	if !ok || sr.itemCount() < streamItems {
+		_, err := w.Write(h.encodeResponse(model.NewResponse(req.ID, result)))
+		return err
+	}
+	return writeStreamResponse(w, req.ID, sr)
+}
+
+// writeStreamResponse はresultを項目ごとに書き出したJSON-RPCレスポンスをwに書く
+func writeStreamResponse(w io.Writer, id any, result streamResult) error {
+	idJSON, err := json.Marshal(id)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	flush := func() error {
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		if f, ok := w.(flusher); ok {
+			f.Flush()
+		}
+		return nil
+	}
+
+	// model.Responseのフィールド順（jsonrpc・id・result）に合わせる
+	if _, err := io.WriteString(bw, `{"jsonrpc":"2.0","id":`+string(idJSON)+`,"result":`); err != nil {
+		return err
+	}
+	n := 0
+	err = result.encodeStream(bw, func() error {
+		if n++; n%streamItems == 0 {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(bw, "}"); err != nil {
+		return err
+	}
+	return flush()
+}
+
+// Export はprojectIDのグループ・グローバル設定・ノートをJSONL（exportコマンドと同じ形式）でwに書き出す
+// ExportServiceは1件ずつエンコードして書き出すため、件数が多くても全体をメモリに組み立てない
+func (h *Handler) Export(ctx context.Context, w io.Writer, projectID string, includeEmbeddings bool) error {
+	if h.export == nil {
+		return errExportUnavailable
+	}
+	_, err := h.export.Export(ctx, w, &service.ExportRequest{ProjectID: projectID, IncludeEmbeddings: includeEmbeddings})
+	return err
+}
+
+// Import はExportの形式のJSONLをprojectIDに取り込み、結果（ImportResult）のJSONを返す
+// modeはimportコマンドと同じ（"skip-existing" | "overwrite"、空なら衝突があればエラー）
+// 1件でも書き込んだ場合は監査ログに記録する（途中で失敗した場合も含む）
+func (h *Handler) Import(ctx context.Context, r io.Reader, projectID, mode string) ([]byte, error) {
+	if h.export == nil {
+		return nil, errExportUnavailable
+	}
+	resp, err := h.export.Import(ctx, r, &service.ImportRequest{ProjectID: projectID, Mode: service.ImportMode(mode)})
+	if resp != nil && resp.Created+resp.Updated > 0 {
+		h.recordAudit(ctx, audit.Entry{Method: "/import", ProjectID: resp.ProjectID})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&ImportResult{
+		Namespace:  resp.Namespace,
+		ProjectID:  resp.ProjectID,
+		Created:    resp.Created,
+		Updated:    resp.Updated,
+		Skipped:    resp.Skipped,
+		ReEmbedded: resp.ReEmbedded,
+	})
+}
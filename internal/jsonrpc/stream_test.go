@@ -0,0 +1,135 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/service"
+)
+
+// flushRecorder はFlushの回数を数えるWriter
+type flushRecorder struct {
+	bytes.Buffer
+	flushes int
+}
+
+func (f *flushRecorder) Flush() { f.flushes++ }
+
+// mockExportService はprojectIdを1行書き出すExportService
+type mockExportService struct {
+	importResp *service.ImportResponse // nilならImportはエラー
+}
+
+func (m *mockExportService) Export(ctx context.Context, w io.Writer, req *service.ExportRequest) (*service.ExportResponse, error) {
+	fmt.Fprintf(w, "{\"projectId\":%q,\"embeddings\":%v}\n", req.ProjectID, req.IncludeEmbeddings)
+	return &service.ExportResponse{ProjectID: req.ProjectID}, nil
+}
+
+func (m *mockExportService) Import(ctx context.Context, r io.Reader, req *service.ImportRequest) (*service.ImportResponse, error) {
+	if m.importResp == nil {
+		return nil, errors.New("not implemented")
+	}
+	resp := *m.importResp
+	resp.ProjectID = req.ProjectID + ":" + string(req.Mode)
+	return &resp, nil
+}
+
+// TestHandleTo はHandleToがHandleと同じJSONを書き出し、件数が多い場合は途中でflushすることをテスト
+func TestHandleTo(t *testing.T) {
+	title := "Title <b>"
+	newHandler := func(n int) *Handler {
+		return New(&mockNoteService{
+			searchFunc: func(ctx context.Context, req *service.SearchRequest) (*service.SearchResponse, error) {
+				resp := &service.SearchResponse{Namespace: "test-ns"}
+				for i := range n {
+					resp.Results = append(resp.Results, service.SearchResult{ID: fmt.Sprintf("note-%d", i), ProjectID: "/test", Title: &title, Text: "text & more", Tags: []string{"a"}, Score: 0.5})
+				}
+				return resp, nil
+			},
+			listRecentFunc: func(ctx context.Context, req *service.ListRecentRequest) (*service.ListRecentResponse, error) {
+				resp := &service.ListRecentResponse{Namespace: "test-ns", Total: n + 1, NextCursor: "next"}
+				for i := range n {
+					resp.Items = append(resp.Items, service.ListRecentItem{ID: fmt.Sprintf("note-%d", i), ProjectID: "/test", Text: "text"})
+				}
+				return resp, nil
+			},
+		}, &mockConfigService{}, &mockGlobalService{}, &mockGroupService{})
+	}
+
+	requests := map[string][]byte{
+		"search":      makeRequest("memory.search", map[string]any{"projectId": "/test", "query": "q", "topK": 1000}),
+		"list_recent": makeRequest("memory.list_recent", map[string]any{"projectId": "/test", "limit": 1000}),
+		"error":       makeRequest("memory.search", map[string]any{}),
+		"not found":   makeRequest("memory.unknown", nil),
+		"parse error": []byte("{"),
+	}
+	for _, n := range []int{3, 250} {
+		for name, req := range requests {
+			t.Run(fmt.Sprintf("%s/%d", name, n), func(t *testing.T) {
+				h := newHandler(n)
+				want := h.Handle(context.Background(), req)
+				var got flushRecorder
+				if err := h.HandleTo(context.Background(), req, &got); err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got.Bytes(), want) {
+					t.Errorf("HandleTo wrote\n%s\nwant\n%s", got.Bytes(), want)
+				}
+				// 250件なら100件ごとと最後にflushする
+				if n == 250 && (name == "search" || name == "list_recent") && got.flushes != 3 {
+					t.Errorf("expected 3 flushes, got %d", got.flushes)
+				}
+			})
+		}
+	}
+
+	// 通知には何も書かない
+	var buf bytes.Buffer
+	notification := []byte(`{"jsonrpc":"2.0","method":"memory.list_recent","params":{"projectId":"/test"}}`)
+	if err := newHandler(250).HandleTo(context.Background(), notification, &buf); err != nil || buf.Len() != 0 {
+		t.Errorf("expected no output for a notification, got %q, %v", buf.String(), err)
+	}
+}
+
+func TestHandler_Export(t *testing.T) {
+	h := newTestHandler()
+	var buf bytes.Buffer
+	if err := h.Export(context.Background(), &buf, "/test", false); !errors.Is(err, errExportUnavailable) {
+		t.Errorf("expected errExportUnavailable, got %v", err)
+	}
+
+	h.SetExportService(&mockExportService{})
+	if err := h.Export(context.Background(), &buf, "/test", true); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(buf.String()); got != `{"projectId":"/test","embeddings":true}` {
+		t.Errorf("unexpected export: %s", got)
+	}
+}
+
+func TestHandler_Import(t *testing.T) {
+	h := newTestHandler()
+	if _, err := h.Import(context.Background(), strings.NewReader(""), "/test", "overwrite"); !errors.Is(err, errExportUnavailable) {
+		t.Errorf("expected errExportUnavailable, got %v", err)
+	}
+
+	h.SetExportService(&mockExportService{importResp: &service.ImportResponse{Namespace: "ns", Created: 2, Skipped: 1}})
+	result, err := h.Import(context.Background(), strings.NewReader(""), "/test", "overwrite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"namespace":"ns","projectId":"/test:overwrite","created":2,"updated":0,"skipped":1,"reEmbedded":0}`
+	if string(result) != want {
+		t.Errorf("unexpected result: %s", result)
+	}
+
+	h.SetExportService(&mockExportService{})
+	if _, err := h.Import(context.Background(), strings.NewReader(""), "/test", ""); err == nil {
+		t.Error("expected the import error")
+	}
+}
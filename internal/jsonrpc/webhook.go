@@ -0,0 +1,37 @@
+package jsonrpc
+
+import (
+	"context"
+
+	"github.com/brbranch/embedding_mcp/internal/webhook"
+)
+
+// sendWebhook は変更をWebhookで送る（SetWebhooks未設定なら何もしない。送信は待たない）
+func (h *Handler) sendWebhook(ev webhook.Event) {
+	if h.webhooks == nil {
+		return
+	}
+	h.webhooks.Send(ev)
+}
+
+// groupWebhookEvent はグループidのWebhookのイベントを作る
+// Webhookの絞り込み・表示のため、SetWebhooks設定時のみグループを取得してprojectId・groupKey・タイトルを埋める
+// （削除・リネームでは変更前に呼ぶ）
+func (h *Handler) groupWebhookEvent(ctx context.Context, eventType, id string) webhook.Event {
+	ev := webhook.Event{Type: eventType, ID: id}
+	if h.webhooks == nil {
+		return ev
+	}
+	if group, err := h.groupService.GetGroup(ctx, id); err == nil {
+		ev.ProjectID, ev.GroupID, ev.Title = group.ProjectID, group.GroupKey, group.Title
+	}
+	return ev
+}
+
+// derefString はnilなら空文字列を返す
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
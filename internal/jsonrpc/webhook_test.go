@@ -0,0 +1,72 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/webhook"
+)
+
+// === Webhookテスト ===
+
+func TestHandle_Webhooks(t *testing.T) {
+	var mu sync.Mutex
+	var events []webhook.Event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var ev webhook.Event
+		if err := json.Unmarshal(body, &ev); err != nil {
+			t.Errorf("webhook payload is not JSON: %v", err)
+		}
+		mu.Lock()
+		events = append(events, ev)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	h := newTestHandler()
+	d := webhook.New([]model.WebhookConfig{{URL: srv.URL, Events: []string{
+		model.WebhookEventNoteAdded, model.WebhookEventNoteDeleted, model.WebhookEventGroupRenamed,
+	}}})
+	h.SetWebhooks(d)
+
+	ctx := context.Background()
+	// 参照系・失敗した操作・対象外のイベントは送らない
+	h.Handle(ctx, makeRequest("memory.get", map[string]any{"id": "note-1"}))
+	h.Handle(ctx, makeRequest("memory.delete", map[string]any{}))
+	h.Handle(ctx, makeRequest("memory.update", map[string]any{"id": "note-1", "patch": map[string]any{"text": "updated"}}))
+
+	h.Handle(ctx, makeRequest("memory.add_note", map[string]any{"projectId": "/test", "groupId": "decisions", "title": "Use JWT", "text": "auth"}))
+	// MCPのtools/callも送る
+	h.Handle(ctx, makeRequest("tools/call", map[string]any{"name": "memory_delete", "arguments": map[string]any{"id": "note-1"}}))
+	h.Handle(ctx, makeRequest("memory.group_rename", map[string]any{"id": "group-1", "groupKey": "adr"}))
+
+	closeCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := d.Close(closeCtx); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 3 {
+		t.Fatalf("expected 3 webhook events, got %+v", events)
+	}
+	if e := events[0]; e.Type != model.WebhookEventNoteAdded || e.ID != "test-id" || e.GroupID != "decisions" || e.Title != "Use JWT" || e.Time == "" {
+		t.Errorf("unexpected add_note event: %+v", e)
+	}
+	// 削除したノートのprojectId・groupIdは削除前に取得する
+	if e := events[1]; e.Type != model.WebhookEventNoteDeleted || e.ID != "note-1" || e.ProjectID != "/test" || e.GroupID != "global" {
+		t.Errorf("unexpected delete event: %+v", e)
+	}
+	if e := events[2]; e.Type != model.WebhookEventGroupRenamed || e.ID != "group-1" || e.NewGroupID != "adr" {
+		t.Errorf("unexpected group_rename event: %+v", e)
+	}
+}
@@ -0,0 +1,49 @@
+// Package keychain reads secrets from the OS credential store
+// (macOS Keychain, Windows Credential Manager, Secret Service on Linux and other Unix systems).
+package keychain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// Service はmcp-memoryの秘密情報を保存するサービス名
+//   - macOS: generic passwordのサービス名（アカウント名がname）
+//   - Windows: 汎用資格情報のターゲット名 "mcp-memory:<name>"
+//   - Secret Service: 属性 service=mcp-memory, account=<name>
+const Service = "mcp-memory"
+
+// ErrNotFound は指定した名前の秘密情報が保存されていない
+var ErrNotFound = errors.New("secret not found in keychain")
+
+// Get はnameで保存された秘密情報を取得する
+func Get(name string) (string, error) {
+	if name == "" {
+		return "", errors.New("keychain: name must not be empty")
+	}
+	secret, err := get(Service, name)
+	if err != nil {
+		return "", fmt.Errorf("keychain %q: %w", name, err)
+	}
+	return secret, nil
+}
+
+// trimSecret はコマンド出力の末尾の改行を取り除く
+func trimSecret(out []byte) string {
+	return strings.TrimRight(string(out), "\r\n")
+}
+
+// decodeCredentialBlob はWindowsの資格情報のバイト列を文字列にする
+// cmdkeyや資格情報マネージャーはUTF-16LEで保存するため、偶数長でNULを含む場合はUTF-16LEとして扱う
+func decodeCredentialBlob(b []byte) string {
+	if len(b) == 0 || len(b)%2 != 0 || !strings.ContainsRune(string(b), 0) {
+		return string(b)
+	}
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = uint16(b[2*i]) | uint16(b[2*i+1])<<8
+	}
+	return strings.TrimRight(string(utf16.Decode(u)), "\x00")
+}
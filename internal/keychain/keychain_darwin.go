@@ -0,0 +1,25 @@
+//go:build darwin
+
+package keychain
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+)
+
+// errSecItemNotFound はsecurityコマンドの項目が見つからない場合の終了コード
+const errSecItemNotFound = 44
+
+// get はsecurityコマンドでKeychainのgeneric passwordを取得する
+func get(service, account string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", service, "-a", account, "-w").Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == errSecItemNotFound {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("security find-generic-password failed: %w", err)
+	}
+	return trimSecret(out), nil
+}
@@ -0,0 +1,29 @@
+package keychain
+
+import "testing"
+
+func TestDecodeCredentialBlob(t *testing.T) {
+	tests := []struct {
+		name string
+		blob []byte
+		want string
+	}{
+		{"utf-16le", []byte{'s', 0, 'k', 0, '-', 0, '1', 0}, "sk-1"},
+		{"utf-8", []byte("sk-test"), "sk-test"},
+		{"utf-8 even length", []byte("sk-1"), "sk-1"},
+		{"empty", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decodeCredentialBlob(tt.blob); got != tt.want {
+				t.Errorf("decodeCredentialBlob() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGet_EmptyName(t *testing.T) {
+	if _, err := Get(""); err == nil {
+		t.Error("expected error")
+	}
+}
@@ -0,0 +1,32 @@
+//go:build !darwin && !windows
+
+package keychain
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// get はsecret-tool（libsecret）でSecret Serviceから取得する
+// secret-toolは見つからない場合も終了コード1を返すため、出力とstderrで判別する
+func get(service, account string) (string, error) {
+	cmd := exec.Command("secret-tool", "lookup", "service", service, "account", account)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return "", errors.New("secret-tool not found (install libsecret-tools or use apiKey / environment variables)")
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("secret-tool lookup failed: %s", msg)
+		}
+		return "", ErrNotFound
+	}
+	if len(out) == 0 {
+		return "", ErrNotFound
+	}
+	return trimSecret(out), nil
+}
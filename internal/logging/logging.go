@@ -0,0 +1,146 @@
+// Package logging configures the process-wide slog logger for mcp-memory.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// ログ形式
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// ローテーションのデフォルト値
+const (
+	DefaultMaxSizeMB  = 10
+	DefaultMaxBackups = 3
+)
+
+// Options はログ出力の設定
+type Options struct {
+	Level      string // debug, info, warn, error（空ならinfo）
+	Format     string // text, json（空ならtext）
+	File       string // 出力先ファイル（空ならstderr）
+	Stdout     bool   // Fileが空ならstderrの代わりにstdoutに出力する（stdio transportを使わない場合のみ）
+	MaxSizeMB  int    // ファイルのローテーションサイズ（MB、0以下ならDefaultMaxSizeMB）
+	MaxBackups int    // 保持する世代数（0以下ならDefaultMaxBackups）
+}
+
+// ParseLevel はレベル文字列をslog.Levelに変換する（空ならinfo）
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(s) {
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "debug":
+		return slog.LevelDebug, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("invalid log level: %s (must be debug, info, warn or error)", s)
+	}
+}
+
+// ValidateFormat はログ形式の文字列を検証する（空ならtext）
+func ValidateFormat(s string) error {
+	switch strings.ToLower(s) {
+	case "", FormatText, FormatJSON:
+		return nil
+	default:
+		return fmt.Errorf("invalid log format: %s (must be text or json)", s)
+	}
+}
+
+// Validate はLevel/Formatの値を検証する
+func (o Options) Validate() error {
+	if _, err := ParseLevel(o.Level); err != nil {
+		return err
+	}
+	return ValidateFormat(o.Format)
+}
+
+// isStdout はpathがstdoutと同じファイル（/dev/stdout やstdoutのリダイレクト先）かを返す
+func isStdout(path string) bool {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	out, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+	return os.SameFile(fi, out)
+}
+
+// level はSetupで設定したデフォルトロガーのレベル（SetLevelで実行中に変更できる）
+var level = new(slog.LevelVar)
+
+// SetLevel はSetupで設定したデフォルトロガーのレベルを変更する（設定のホットリロード用）
+func SetLevel(s string) error {
+	l, err := ParseLevel(s)
+	if err != nil {
+		return err
+	}
+	level.Set(l)
+	return nil
+}
+
+// NewHandler はOptionsのLevel/Formatに従いwへ書き込むHandlerを生成する
+func NewHandler(w io.Writer, opts Options) (slog.Handler, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	l, _ := ParseLevel(opts.Level)
+	return newHandler(w, opts.Format, l), nil
+}
+
+// newHandler はformatに従いレベルlevelerのHandlerを生成する
+func newHandler(w io.Writer, format string, leveler slog.Leveler) slog.Handler {
+	hopts := &slog.HandlerOptions{Level: leveler}
+
+	if strings.ToLower(format) == FormatJSON {
+		return slog.NewJSONHandler(w, hopts)
+	}
+	return slog.NewTextHandler(w, hopts)
+}
+
+// Setup はslogのデフォルトロガーを設定し、クローズ関数を返す
+// 出力先はstderrまたはファイル（stdoutはstdio transportが使用するため、Stdoutを指定した場合のみ使う）
+// slog.SetDefaultにより標準logパッケージの出力も同じHandlerに流れる
+func Setup(opts Options) (func() error, error) {
+	// ファイルを作成する前に検証する
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	var w io.Writer = os.Stderr
+	closeFn := func() error { return nil }
+
+	if opts.File != "" {
+		if isStdout(opts.File) {
+			return nil, fmt.Errorf("log file %s is stdout (reserved for the stdio transport)", opts.File)
+		}
+		f, err := OpenRotatingFile(opts.File, int64(opts.MaxSizeMB)*1024*1024, opts.MaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		w = f
+		closeFn = f.Close
+	} else if opts.Stdout {
+		w = os.Stdout
+	}
+
+	l, _ := ParseLevel(opts.Level)
+	level.Set(l)
+	h := newHandler(w, opts.Format, level)
+
+	// 標準logの出力はレベル情報を持たないためINFOとして扱われる
+	slog.SetDefault(slog.New(h))
+	return closeFn, nil
+}
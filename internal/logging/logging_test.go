@@ -0,0 +1,264 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestParseLevel はレベル文字列の変換をテスト
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in   string
+		want slog.Level
+	}{
+		{"", slog.LevelInfo},
+		{"info", slog.LevelInfo},
+		{"debug", slog.LevelDebug},
+		{"WARN", slog.LevelWarn},
+		{"warning", slog.LevelWarn},
+		{"error", slog.LevelError},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.in)
+		if err != nil {
+			t.Errorf("ParseLevel(%q): unexpected error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("expected error for invalid level")
+	}
+}
+
+// TestOptions_Validate は不正なformatを拒否することをテスト
+func TestOptions_Validate(t *testing.T) {
+	if err := (Options{Format: "json"}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := (Options{Format: "xml"}).Validate(); err == nil {
+		t.Error("expected error for invalid format")
+	}
+	if err := (Options{Level: "trace"}).Validate(); err == nil {
+		t.Error("expected error for invalid level")
+	}
+}
+
+// TestNewHandler_JSONAndLevel はJSON形式とレベルフィルタをテスト
+func TestNewHandler_JSONAndLevel(t *testing.T) {
+	var buf bytes.Buffer
+	h, err := NewHandler(&buf, Options{Level: "warn", Format: "json"})
+	if err != nil {
+		t.Fatalf("NewHandler failed: %v", err)
+	}
+	logger := slog.New(h)
+	logger.Info("dropped")
+	logger.Warn("kept", "key", "value")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 line, got %d: %q", len(lines), buf.String())
+	}
+	var rec map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &rec); err != nil {
+		t.Fatalf("output is not JSON: %v", err)
+	}
+	if rec["msg"] != "kept" || rec["key"] != "value" || rec["level"] != "WARN" {
+		t.Errorf("unexpected record: %v", rec)
+	}
+}
+
+// TestSetup_FileAndStdLog はファイル出力と標準logのslog経由の出力をテスト
+func TestSetup_FileAndStdLog(t *testing.T) {
+	prev := slog.Default()
+	defer slog.SetDefault(prev)
+
+	path := filepath.Join(t.TempDir(), "logs", "mcp-memory.log")
+	closeFn, err := Setup(Options{Level: "debug", File: path})
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	slog.Debug("from slog")
+	log.Printf("from log")
+	if err := closeFn(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read log file: %v", err)
+	}
+	out := string(data)
+	if !strings.Contains(out, "level=DEBUG msg=\"from slog\"") {
+		t.Errorf("missing slog record: %q", out)
+	}
+	if !strings.Contains(out, "level=INFO msg=\"from log\"") {
+		t.Errorf("missing std log record: %q", out)
+	}
+}
+
+// TestSetLevel は実行中にデフォルトロガーのレベルを変更できることをテスト
+func TestSetLevel(t *testing.T) {
+	prev := slog.Default()
+	defer slog.SetDefault(prev)
+
+	path := filepath.Join(t.TempDir(), "mcp-memory.log")
+	closeFn, err := Setup(Options{Level: "info", File: path})
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	slog.Debug("before")
+	if err := SetLevel("debug"); err != nil {
+		t.Fatalf("SetLevel failed: %v", err)
+	}
+	slog.Debug("after")
+	if err := SetLevel("loud"); err == nil {
+		t.Error("expected error for invalid level")
+	}
+	if err := closeFn(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read log file: %v", err)
+	}
+	out := string(data)
+	if strings.Contains(out, "before") || !strings.Contains(out, "after") {
+		t.Errorf("unexpected log output: %q", out)
+	}
+}
+
+// TestSetup_InvalidDoesNotCreateFile は不正な設定ではファイルを作成しないことをテスト
+func TestSetup_InvalidDoesNotCreateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mcp-memory.log")
+	if _, err := Setup(Options{Level: "loud", File: path}); err == nil {
+		t.Fatal("expected error for invalid level")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("log file should not be created, stat err: %v", err)
+	}
+}
+
+// TestRotatingFile_Rotate はサイズ上限でのローテーションと世代数の上限をテスト
+func TestRotatingFile_Rotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	r, err := OpenRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatalf("OpenRotatingFile failed: %v", err)
+	}
+	defer r.Close()
+
+	for _, s := range []string{"aaaaaaaa\n", "bbbbbbbb\n", "cccccccc\n", "dddddddd\n"} {
+		if _, err := r.Write([]byte(s)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	want := map[string]string{
+		path:        "dddddddd\n",
+		path + ".1": "cccccccc\n",
+		path + ".2": "bbbbbbbb\n",
+	}
+	for p, content := range want {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", p, err)
+		}
+		if string(data) != content {
+			t.Errorf("%s = %q, want %q", p, data, content)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("backup beyond maxBackups should not exist")
+	}
+}
+
+// TestRotatingFile_AppendsExisting は既存ファイルへの追記とサイズ引き継ぎをテスト
+func TestRotatingFile_AppendsExisting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte("old-data\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := OpenRotatingFile(path, 12, 1)
+	if err != nil {
+		t.Fatalf("OpenRotatingFile failed: %v", err)
+	}
+	if _, err := r.Write([]byte("new\n")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	r.Close()
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "new\n" {
+		t.Errorf("expected rotation because existing size counts, got %q", data)
+	}
+	backup, _ := os.ReadFile(path + ".1")
+	if string(backup) != "old-data\n" {
+		t.Errorf("backup = %q", backup)
+	}
+
+	if _, err := r.Write([]byte("x")); err == nil {
+		t.Error("expected error writing to closed file")
+	}
+}
+
+// TestSetup_RejectsStdout はstdoutへのログ出力を拒否することをテスト
+func TestSetup_RejectsStdout(t *testing.T) {
+	prev := slog.Default()
+	defer slog.SetDefault(prev)
+
+	// stdoutをファイルに向けて、そのファイルをログの出力先に指定する
+	path := filepath.Join(t.TempDir(), "stdout")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	stdout := os.Stdout
+	os.Stdout = f
+	defer func() { os.Stdout = stdout }()
+
+	if _, err := Setup(Options{File: path}); err == nil {
+		t.Fatal("expected error for a log file that is stdout")
+	}
+}
+
+// TestSetup_Stdout はStdoutを指定するとstdoutに出力することをテスト
+func TestSetup_Stdout(t *testing.T) {
+	prev := slog.Default()
+	defer slog.SetDefault(prev)
+
+	path := filepath.Join(t.TempDir(), "stdout")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	stdout := os.Stdout
+	os.Stdout = f
+	defer func() { os.Stdout = stdout }()
+
+	if _, err := Setup(Options{Format: FormatJSON, Stdout: true}); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	slog.Info("to stdout")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"msg":"to stdout"`) {
+		t.Errorf("expected a JSON record on stdout, got %q", data)
+	}
+}
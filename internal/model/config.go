@@ -2,15 +2,146 @@ package model
 
 // Config はサーバー全体の設定を表す
 type Config struct {
-	TransportDefaults TransportDefaults `json:"transportDefaults"`
-	Embedder          EmbedderConfig    `json:"embedder"`
-	Store             StoreConfig       `json:"store"`
-	Paths             PathsConfig       `json:"paths"`
+	TransportDefaults TransportDefaults  `json:"transportDefaults"`
+	Embedder          EmbedderConfig     `json:"embedder"`
+	Store             StoreConfig        `json:"store"`
+	Paths             PathsConfig        `json:"paths"`
+	Logging           LoggingConfig      `json:"logging"`
+	Profiles          map[string]Profile `json:"profiles,omitempty"` // --profile / MCP_MEMORY_PROFILE で選択
+	// PreviousEmbedder はembedder変更前の設定（移行期間中のみ）
+	// 設定されている間は、そのnamespaceのノートも検索・一覧・取得の対象にする（mcp-memory migrateで移行後に削除する）
+	PreviousEmbedder *EmbedderConfig `json:"previousEmbedder,omitempty"`
+	// ProjectID はprojectIdの正規化ルール（省略時はシンボリックリンクのみ解決）
+	ProjectID *ProjectIDConfig `json:"projectId,omitempty"`
+	// ProjectAliases はパスから論理的なprojectIdへの対応（CI・worktreeなど別の場所のチェックアウトを同じプロジェクトとして扱う）
+	ProjectAliases map[string]string `json:"projectAliases,omitempty"`
+	// NoteID はノートIDの生成ルール（省略時はランダムなUUID）
+	NoteID *NoteIDConfig `json:"noteId,omitempty"`
+	// Limits はノート1件の大きさの上限（省略時はデフォルト）
+	Limits *NoteLimitsConfig `json:"limits,omitempty"`
+	// Tags はタグの正規化ルール（省略時は指定されたまま保存する）
+	Tags *TagsConfig `json:"tags,omitempty"`
+	// Audit は変更操作の監査ログ（省略時は記録しない）
+	Audit *AuditConfig `json:"audit,omitempty"`
+	// Webhooks はノート・グループの変更を通知するWebhook（省略時は送らない）
+	Webhooks []WebhookConfig `json:"webhooks,omitempty"`
+	// Embedders はadd_noteのmodelで名前を指定して使う追加のembedder（コード片をコード向けのモデルで埋め込むなど）
+	// ノートはそれぞれのnamespaceに保存し、検索・一覧・取得はembedderと全ての追加embedderのnamespaceをまとめる
+	Embedders map[string]EmbedderConfig `json:"embedders,omitempty"`
+	// Archive は長くアクセスのないノートをアーカイブに移す設定（省略時はserveで自動では移さない。mcp-memory archiveでは移せる）
+	Archive *ArchiveConfig `json:"archive,omitempty"`
+	// Auth はHTTP transportの認証（省略時は認証しない）
+	Auth *AuthConfig `json:"auth,omitempty"`
+	// Encryption はノートの本文・タイトル・metadataをストアに保存する前に暗号化する設定（省略時は暗号化しない）
+	Encryption *EncryptionConfig `json:"encryption,omitempty"`
+}
+
+// EncryptionConfig はノートの暗号化（AES-256-GCM）の設定
+// 共有のQdrant・Chromaなどのストアには暗号文だけを保存する（埋め込みベクトル・タグ・projectIdなどは暗号化しない）
+type EncryptionConfig struct {
+	// Keys は鍵の一覧。先頭の鍵で暗号化し、残りの鍵はローテーション前に暗号化したノートの復号に使う
+	Keys []EncryptionKeyConfig `json:"keys"`
+}
+
+// EncryptionKeyConfig は暗号化の鍵1つの設定（KeyかKeyFromのいずれかが必要）
+type EncryptionKeyConfig struct {
+	ID      string  `json:"id"`                // ノートごとに記録する鍵のID（英数字・-・_・.）
+	Key     *string `json:"key,omitempty"`     // 32バイトの鍵のbase64（"${VAR}"で環境変数から読み込める）
+	KeyFrom string  `json:"keyFrom,omitempty"` // 鍵の取得元（形式はEmbedderConfig.APIKeyFromと同じ）
+}
+
+// AuthConfig はHTTP transportの認証の設定
+// 設定するとHTTPのリクエストに Authorization: Bearer <token> が必要になる（/healthz・/readyz・/health を除く）
+type AuthConfig struct {
+	// APIKey・APIKeyFromは全プロジェクト・全メソッドを使えるキー（取得元の形式はEmbedderConfig.APIKeyFromと同じ）
+	APIKey     *string `json:"apiKey,omitempty"`
+	APIKeyFrom string  `json:"apiKeyFrom,omitempty"`
+	// Tokens はプロジェクトを限定したトークン（1つのサーバーを複数のプロジェクトで共有する場合に使う）
+	Tokens []AccessTokenConfig `json:"tokens,omitempty"`
+}
+
+// AccessTokenConfig はプロジェクトを限定したトークンの設定
+// ProjectIDs以外のプロジェクト・全体に関わるメソッド（get_config・set_config・migrate・statsなど）は使えない
+type AccessTokenConfig struct {
+	Name       string   `json:"name,omitempty"`   // ログ・監査ログでの名前（省略時は"token[i]"）
+	Token      string   `json:"token"`            // Authorizationヘッダーで送る値
+	ProjectIDs []string `json:"projectIds"`       // 使えるprojectId（正規化して照合する）
+	Access     string   `json:"access,omitempty"` // AccessRead（デフォルト）| AccessReadWrite
+}
+
+// トークンの権限（AccessTokenConfig.Access）
+const (
+	AccessRead      = "read"       // 検索・取得・一覧のみ
+	AccessReadWrite = "read-write" // 追加・更新・削除も可能
+)
+
+// ArchiveConfig はアーカイブの設定
+// serveが定期的に、最終アクセス（検索・取得・更新）がAfterDays日より前のノートを
+// ベクトルインデックスを持たないコールドストア（dataDir/archive）に移す
+type ArchiveConfig struct {
+	AfterDays     int    `json:"afterDays"`               // アーカイブに移すまでの日数（1以上）
+	CheckInterval string `json:"checkInterval,omitempty"` // serveが移すノートを確認する間隔（デフォルト24h、"0"で無効）
+}
+
+// WebhookConfig はノート・グループの変更を通知するWebhookの設定（serveのJSON-RPC・MCPの変更操作が対象）
+// events・projectIds・groupIdsは省略時はすべてに一致し、指定した場合はいずれかに一致する変更のみ送る
+type WebhookConfig struct {
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret,omitempty"`     // HMAC-SHA256で本文に署名する鍵（省略時は署名しない）
+	Format     string   `json:"format,omitempty"`     // "json"（デフォルト）| "slack"（Incoming Webhookのtext形式）
+	Events     []string `json:"events,omitempty"`     // WebhookEvent*（"note.added" など）
+	ProjectIDs []string `json:"projectIds,omitempty"` // 対象のprojectId（正規化して照合する）
+	GroupIDs   []string `json:"groupIds,omitempty"`   // 対象のgroupId（グループの変更はgroupKeyで照合する）
+	Timeout    string   `json:"timeout,omitempty"`    // 1回の送信のタイムアウト（デフォルト10s）
+}
+
+// AuditConfig は監査ログの設定（serveのJSON-RPC・MCPの変更操作を記録する）
+type AuditConfig struct {
+	File string `json:"file,omitempty"` // 出力先のJSONLファイル（相対パスはdataDir基準）
+}
+
+// TagsConfig はタグの正規化ルール（add_note・update・ingestの書き込みとsearch・list_recentの絞り込みに適用する）
+// 変更しても既存のノートのタグは変わらない
+type TagsConfig struct {
+	Normalize bool              `json:"normalize,omitempty"` // 前後の空白を除き、NFKCで正規化して小文字にする
+	Aliases   map[string]string `json:"aliases,omitempty"`   // 別名から正式なタグへの対応（"golang": "go"）
+}
+
+// NoteLimitsConfig はノート1件の大きさの上限（省略・0はデフォルト、負の値は無制限）
+type NoteLimitsConfig struct {
+	MaxTextLength    int `json:"maxTextLength,omitempty"`    // 本文の文字数（デフォルト20000）
+	MaxTitleLength   int `json:"maxTitleLength,omitempty"`   // タイトルの文字数（デフォルト500）
+	MaxTags          int `json:"maxTags,omitempty"`          // タグの数（デフォルト50）
+	MaxTagLength     int `json:"maxTagLength,omitempty"`     // タグ1つの文字数（デフォルト100）
+	MaxMetadataBytes int `json:"maxMetadataBytes,omitempty"` // metadataをJSONにしたバイト数（デフォルト16384）
+}
+
+// NoteIDConfig はノートIDの生成ルール
+// 変更しても既存のノートのIDは変わらない。ingest / watchのノートIDはパスから決まるため対象外
+type NoteIDConfig struct {
+	Scheme string `json:"scheme,omitempty"` // "uuid"（デフォルト、ランダム）| "uuidv7" | "ulid"（いずれも作成時刻順に並ぶ）
+	Prefix string `json:"prefix,omitempty"` // "" | "project" | "group" | "project-group"（ログで見分けやすくする）
+}
+
+// ProjectIDConfig はprojectIdの正規化ルール
+// 変更すると既存のノートのprojectIdと一致しなくなるため、mcp-memory merge-projectsでまとめ直す
+type ProjectIDConfig struct {
+	CaseInsensitive bool  `json:"caseInsensitive,omitempty"` // 小文字に揃える（macOS・Windowsなど大文字小文字を区別しない環境向け）
+	ResolveSymlinks *bool `json:"resolveSymlinks,omitempty"` // シンボリックリンクを解決する（デフォルトtrue）
+	GitRemote       bool  `json:"gitRemote,omitempty"`       // gitリポジトリ内のパスをリモートURLから求めたID（github.com/org/repo）にする
+}
+
+// Profile は名前付きの設定セット（指定したセクションを丸ごと置き換える）
+type Profile struct {
+	Embedder *EmbedderConfig `json:"embedder,omitempty"`
+	Store    *StoreConfig    `json:"store,omitempty"`
+	DataDir  string          `json:"dataDir,omitempty"`
 }
 
 // TransportDefaults はtransportのデフォルト設定
 type TransportDefaults struct {
-	DefaultTransport string `json:"defaultTransport"` // "stdio" | "http"
+	DefaultTransport string   `json:"defaultTransport"`      // "stdio" | "http"
+	CORSOrigins      []string `json:"corsOrigins,omitempty"` // HTTP transportで許可するオリジン（空ならCORS無効）
 }
 
 // EmbedderConfig はembedder設定
@@ -20,6 +151,10 @@ type EmbedderConfig struct {
 	Dim      int     `json:"dim"`               // ベクトル次元（0は未設定）
 	BaseURL  *string `json:"baseUrl,omitempty"` // nullable、省略可
 	APIKey   *string `json:"apiKey,omitempty"`  // nullable、省略可（セキュリティ注意）
+	// APIKeyFrom はAPIキーの取得元（"keychain:<name>"）。apiKey・環境変数が未設定の場合に使う
+	APIKeyFrom string `json:"apiKeyFrom,omitempty"`
+	// CoalesceWindow は並行する埋め込みを1回の一括リクエストにまとめる時間窓（"10ms"など。空なら無効、一括に対応したproviderのみ）
+	CoalesceWindow string `json:"coalesceWindow,omitempty"`
 }
 
 // StoreConfig はvector store設定
@@ -27,6 +162,39 @@ type StoreConfig struct {
 	Type string  `json:"type"`           // "chroma" | "sqlite" | "qdrant" | "faiss"
 	Path *string `json:"path,omitempty"` // nullable（SQLite用）
 	URL  *string `json:"url,omitempty"`  // nullable（Chroma/Qdrant用）
+	// APIKey・APIKeyFromはQdrantのAPIキー（取得元の形式はEmbedderConfig.APIKeyFromと同じ）
+	APIKey     *string `json:"apiKey,omitempty"`
+	APIKeyFrom string  `json:"apiKeyFrom,omitempty"`
+	// Connection は接続の調整値（省略時はすべてデフォルト）
+	Connection *StoreConnectionConfig `json:"connection,omitempty"`
+	// GlobalsScope はGlobalConfigの保存範囲（GlobalsScopeNamespace（デフォルト）またはGlobalsScopeShared）
+	GlobalsScope string `json:"globalsScope,omitempty"`
+	// Journal はmemoryストアの変更をdataDir/journalに記録し、起動時に再生する（memoryストアのみ）
+	Journal bool `json:"journal,omitempty"`
+	// NoteThresholds はnamespaceのノート数の警告の閾値（省略時はsoftのみ5000）
+	NoteThresholds *NoteThresholdsConfig `json:"noteThresholds,omitempty"`
+	// CacheSize はIDで取得したノートをキャッシュする件数（省略時は1000、0で無効。memoryストアでは使わない）
+	CacheSize *int `json:"cacheSize,omitempty"`
+}
+
+// NoteThresholdsConfig はnamespaceのノート数の閾値
+// 超えるとmemory.stats・/healthの状態が変わり、serveが定期的にログで警告する（書き込みは拒否しない）
+type NoteThresholdsConfig struct {
+	Soft          int    `json:"soft,omitempty"`          // 超えたらwarning（0ならデフォルトの5000）
+	Hard          int    `json:"hard,omitempty"`          // 超えたらcritical（0なら無効）
+	CheckInterval string `json:"checkInterval,omitempty"` // serveがノート数を確認する間隔（デフォルト1h、"0"で無効）
+}
+
+// StoreConnectionConfig はストアへの接続の調整値
+// 時間は"5s"・"500ms"のようなGoのduration形式。省略した項目はデフォルト
+type StoreConnectionConfig struct {
+	ConnectTimeout   string `json:"connectTimeout,omitempty"`   // 接続確認のタイムアウト（Qdrant、デフォルト5s）
+	RequestTimeout   string `json:"requestTimeout,omitempty"`   // 1リクエストのタイムアウト（Qdrant gRPC・Chroma HTTP、デフォルトなし）
+	KeepAlive        string `json:"keepAlive,omitempty"`        // Qdrant gRPCのkeepalive間隔（デフォルト10s、"0"で無効）
+	KeepAliveTimeout string `json:"keepAliveTimeout,omitempty"` // keepaliveの応答待ち（デフォルト2s）
+	BusyTimeout      string `json:"busyTimeout,omitempty"`      // SQLiteのbusy_timeout（デフォルトなし）
+	PoolSize         int    `json:"poolSize,omitempty"`         // Qdrant: gRPC接続数（デフォルト3）、SQLite: 最大接続数（デフォルト無制限）
+	MaxRetries       int    `json:"maxRetries,omitempty"`       // 接続確認の再試行回数（Qdrant、デフォルト0）
 }
 
 // PathsConfig はファイルパス設定
@@ -35,6 +203,15 @@ type PathsConfig struct {
 	DataDir    string `json:"dataDir"`    // データディレクトリ
 }
 
+// LoggingConfig はログ設定（--log-* フラグ / MCP_MEMORY_LOG_* 環境変数が優先）
+type LoggingConfig struct {
+	Level  string `json:"level,omitempty"`  // debug, info, warn, error
+	Format string `json:"format,omitempty"` // text, json
+	File   string `json:"file,omitempty"`   // 出力先ファイル（空ならstderr、相対パスはdataDir基準）
+	// SlowThreshold はこの時間以上かかったembedder・storeの操作を警告する（"500ms"など、空・0なら無効）
+	SlowThreshold string `json:"slowThreshold,omitempty"`
+}
+
 // Transport定数
 const (
 	TransportStdio = "stdio"
@@ -48,6 +225,43 @@ const (
 	ProviderLocal  = "local"
 )
 
+// NoteIDスキーム定数
+const (
+	NoteIDSchemeUUID   = "uuid"
+	NoteIDSchemeUUIDv7 = "uuidv7"
+	NoteIDSchemeULID   = "ulid"
+)
+
+// NoteIDプレフィックス定数
+const (
+	NoteIDPrefixProject      = "project"
+	NoteIDPrefixGroup        = "group"
+	NoteIDPrefixProjectGroup = "project-group"
+)
+
+// Webhookのイベント種別（WebhookConfig.Events）
+const (
+	WebhookEventNoteAdded    = "note.added"
+	WebhookEventNoteUpdated  = "note.updated"
+	WebhookEventNoteDeleted  = "note.deleted"
+	WebhookEventGroupCreated = "group.created"
+	WebhookEventGroupUpdated = "group.updated"
+	WebhookEventGroupDeleted = "group.deleted"
+	WebhookEventGroupRenamed = "group.renamed"
+)
+
+// WebhookEvents はWebhookで送るイベント種別の一覧
+var WebhookEvents = []string{
+	WebhookEventNoteAdded, WebhookEventNoteUpdated, WebhookEventNoteDeleted,
+	WebhookEventGroupCreated, WebhookEventGroupUpdated, WebhookEventGroupDeleted, WebhookEventGroupRenamed,
+}
+
+// Webhookの本文の形式（WebhookConfig.Format）
+const (
+	WebhookFormatJSON  = "json"
+	WebhookFormatSlack = "slack"
+)
+
 // Store Type定数
 const (
 	StoreTypeChroma = "chroma"
@@ -55,3 +269,11 @@ const (
 	StoreTypeQdrant = "qdrant"
 	StoreTypeFAISS  = "faiss"
 )
+
+// GlobalConfigの保存範囲（StoreConfig.GlobalsScope）
+const (
+	// GlobalsScopeNamespace はembedderのnamespaceごとに保存する（embedderを変えると別の設定になる）
+	GlobalsScopeNamespace = "namespace"
+	// GlobalsScopeShared はnamespaceによらず共通に保存する（sqlite・qdrantのみ）
+	GlobalsScopeShared = "shared"
+)
@@ -13,6 +13,16 @@ type GlobalConfig struct {
 	Key       string  `json:"key"`       // "global."プレフィックス必須
 	Value     any     `json:"value"`     // 任意のJSON値
 	UpdatedAt *string `json:"updatedAt"` // ISO8601 UTC形式、nullable（nullならサーバー側で現在時刻設定）
+	// UpdatedBy は更新者（任意）、History はupsertで上書きされる前の値（新しい順、上限あり）
+	UpdatedBy string                `json:"updatedBy,omitempty"`
+	History   []GlobalConfigVersion `json:"history,omitempty"`
+}
+
+// GlobalConfigVersion はGlobalConfigの過去の値
+type GlobalConfigVersion struct {
+	Value     any     `json:"value"`
+	UpdatedAt *string `json:"updatedAt"`
+	UpdatedBy string  `json:"updatedBy,omitempty"`
 }
 
 // 標準キー定数
@@ -21,6 +31,21 @@ const (
 	GlobalKeyEmbedderModel      = "global.memory.embedder.model"
 	GlobalKeyGroupDefaults      = "global.memory.groupDefaults"
 	GlobalKeyProjectConventions = "global.project.conventions"
+
+	// ノート追加のポリシー（add_noteで適用する）
+	GlobalKeyDefaultGroup   = "global.memory.defaultGroup"   // string: groupId省略時のグループ
+	GlobalKeyTags           = "global.memory.tags"           // []string: 自動で付けるタグ
+	GlobalKeyRequiredTags   = "global.memory.requiredTags"   // []string: 必須タグ（足りなければエラー）
+	GlobalKeyMetadataSchema = "global.memory.metadataSchema" // object: metadataのJSON Schema（合わなければエラー）
+
+	// グループの自動作成（add_noteで適用する）
+	GlobalKeyAutoCreateGroup = "global.memory.autoCreateGroup" // bool: 未登録のgroupIdのグループを作成する（titleはgroupDefaultsのプレフィックスを除いたgroupKey）
+
+	// 検索のランキング（searchで適用する）
+	GlobalKeyImportanceBoost = "global.memory.importanceBoost" // number: importance 1のノートのスコアへの加点（デフォルト0.2）
+
+	// 追加時の重複検出（add_noteのonDuplicate指定時に適用する）
+	GlobalKeyDuplicateThreshold = "global.memory.duplicateThreshold" // number: 重複とみなす類似度（デフォルト0.95）
 )
 
 var globalKeyPattern = regexp.MustCompile(`^global\.[a-zA-Z0-9._-]+$`)
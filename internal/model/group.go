@@ -15,8 +15,23 @@ type Group struct {
 	Description string    `json:"description"` // 空文字列可
 	CreatedAt   time.Time `json:"createdAt"`   // UTC
 	UpdatedAt   time.Time `json:"updatedAt"`   // UTC
+	// Tags/Status/Metadata はスプリント・担当者・チケット等の注釈（旧データは空）
+	Tags     []string       `json:"tags,omitempty"`
+	Status   string         `json:"status,omitempty"` // GroupStatusActive/GroupStatusDone。空はactive扱い
+	Metadata map[string]any `json:"metadata,omitempty"`
+	// ParentGroupID は親グループのgroupKey（ノートのgroupIdと同じ形式）。空ならトップレベル
+	ParentGroupID string `json:"parentGroupId,omitempty"`
 }
 
+// GlobalGroupID は全体方針・規約用の予約グループID（グループ作成なしで利用可能）
+const GlobalGroupID = "global"
+
+// グループの状態
+const (
+	GroupStatusActive = "active"
+	GroupStatusDone   = "done"
+)
+
 var groupKeyPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
 
 // Validate はGroupのバリデーションを実行する
@@ -37,9 +52,22 @@ func (g *Group) Validate() error {
 		return fmt.Errorf("Title must not be empty")
 	}
 
+	if err := ValidateGroupStatus(g.Status); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// ValidateGroupStatus はStatusのバリデーションを実行する（空文字列はactive扱いで許可）
+func ValidateGroupStatus(status string) error {
+	switch status {
+	case "", GroupStatusActive, GroupStatusDone:
+		return nil
+	}
+	return fmt.Errorf("Status must be %q or %q, got %q", GroupStatusActive, GroupStatusDone, status)
+}
+
 // ValidateGroupKeyForCreate はGroupKeyのバリデーションを実行する
 // "global" は予約されているため登録禁止
 func ValidateGroupKeyForCreate(groupKey string) error {
@@ -47,7 +75,7 @@ func ValidateGroupKeyForCreate(groupKey string) error {
 		return fmt.Errorf("GroupKey must not be empty")
 	}
 
-	if groupKey == "global" {
+	if groupKey == GlobalGroupID {
 		return fmt.Errorf("GroupKey 'global' is reserved and cannot be used")
 	}
 
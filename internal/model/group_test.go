@@ -129,6 +129,20 @@ func TestGroup_Validate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "GroupKey must match pattern",
 		},
+		{
+			name: "invalid status",
+			group: &Group{
+				ID:        "test-id",
+				ProjectID: "/path/to/project",
+				GroupKey:  "sprint-12",
+				Title:     "Title",
+				CreatedAt: now,
+				UpdatedAt: now,
+				Status:    "archived",
+			},
+			wantErr: true,
+			errMsg:  "Status must be",
+		},
 	}
 
 	for _, tt := range tests {
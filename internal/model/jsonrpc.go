@@ -22,6 +22,13 @@ type ErrorResponse struct {
 	Error   RPCError `json:"error"`   // エラーオブジェクト
 }
 
+// Notification はJSON-RPC 2.0通知（サーバーからクライアントへ送る。応答は不要）
+type Notification struct {
+	JSONRPC string `json:"jsonrpc"`          // 常に "2.0"
+	Method  string `json:"method"`           // 通知名
+	Params  any    `json:"params,omitempty"` // 通知の内容
+}
+
 // RPCError はJSON-RPC 2.0エラーオブジェクト
 type RPCError struct {
 	Code    int    `json:"code"`           // エラーコード
@@ -45,6 +52,8 @@ const (
 	ErrCodeNotFound         = -32003 // Resource not found
 	ErrCodeProviderError    = -32004 // Embedding provider error
 	ErrCodeConflict         = -32005 // Resource conflict (e.g., duplicate key)
+	ErrCodeStoreUnavailable = -32006 // Store temporarily unavailable (retry later)
+	ErrCodeForbidden        = -32007 // Access denied for the token (project or method)
 )
 
 // NewResponse は成功レスポンスを生成
@@ -56,6 +65,15 @@ func NewResponse(id any, result any) *Response {
 	}
 }
 
+// NewNotification は通知を生成
+func NewNotification(method string, params any) *Notification {
+	return &Notification{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  params,
+	}
+}
+
 // NewErrorResponse はエラーレスポンスを生成
 func NewErrorResponse(id any, code int, message string, data any) *ErrorResponse {
 	return &ErrorResponse{
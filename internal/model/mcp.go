@@ -2,9 +2,17 @@ package model
 
 // InitializeParams は initialize メソッドのパラメータ
 type InitializeParams struct {
-	ProtocolVersion string      `json:"protocolVersion"`
-	ClientInfo      ClientInfo  `json:"clientInfo"`
+	ProtocolVersion string       `json:"protocolVersion"`
+	ClientInfo      ClientInfo   `json:"clientInfo"`
 	Capabilities    Capabilities `json:"capabilities,omitempty"`
+	// Session はセッション単位のデフォルト値（mcp-memory拡張、省略可）
+	Session *SessionParams `json:"session,omitempty"`
+}
+
+// SessionParams はセッション開始時にクライアントが宣言するデフォルト値
+type SessionParams struct {
+	ProjectID string `json:"projectId,omitempty"` // 以降のprojectId省略時に使用
+	GroupID   string `json:"groupId,omitempty"`   // 以降のadd_noteでgroupId省略時に使用
 }
 
 // ClientInfo はクライアント情報
@@ -63,10 +71,11 @@ type JSONSchema struct {
 	Required   []string              `json:"required,omitempty"`
 	Items      *JSONSchema           `json:"items,omitempty"`
 	// 追加プロパティ
-	Description string        `json:"description,omitempty"`
-	Enum        []string      `json:"enum,omitempty"`
-	Default     any           `json:"default,omitempty"`
-	OneOf       []JSONSchema  `json:"oneOf,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Enum        []string     `json:"enum,omitempty"`
+	Default     any          `json:"default,omitempty"`
+	OneOf       []JSONSchema `json:"oneOf,omitempty"`
+	Format      string       `json:"format,omitempty"`
 }
 
 // ToolsListResult は tools/list メソッドの結果
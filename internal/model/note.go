@@ -16,7 +16,35 @@ type Note struct {
 	Tags      []string       `json:"tags"`                // 空配列可
 	Source    *string        `json:"source"`              // nullable
 	CreatedAt *string        `json:"createdAt"`           // ISO8601 UTC形式、nullable（nullならサーバー側で現在時刻設定）
+	UpdatedAt *string        `json:"updatedAt,omitempty"` // ISO8601 UTC形式。Storeが追加・更新時に設定する（追加時に指定があればそのまま）
 	Metadata  map[string]any `json:"metadata,omitempty"`  // nullable（JSON null許容）、省略可
+	// Attachments はノートの根拠となるファイル（設計書・diffなど）への参照、省略可
+	Attachments []Attachment `json:"attachments,omitempty"`
+	// ParentID は長い文書をチャンク分割したときの親ノート（チャンク0）のID、省略可
+	ParentID *string `json:"parentId,omitempty"`
+	// ChunkIndex は親ノートの文書内でのチャンク番号（0始まり）、省略可
+	ChunkIndex *int `json:"chunkIndex,omitempty"`
+	// Importance はノートの重要度（0-1）。検索時にスコアへ加点する、省略可
+	Importance *float64 `json:"importance,omitempty"`
+}
+
+// Attachment はノートが参照するファイル（パスと内容のハッシュの少なくとも一方を持つ）
+type Attachment struct {
+	Path string `json:"path,omitempty"` // ファイルパス（プロジェクトからの相対パスまたは絶対パス）
+	Hash string `json:"hash,omitempty"` // 内容のハッシュ（"sha256:<hex>"形式）
+}
+
+var attachmentHashPattern = regexp.MustCompile(`^[a-z0-9]+:[0-9a-f]+$`)
+
+// Validate はAttachmentのバリデーションを実行する
+func (a Attachment) Validate() error {
+	if a.Path == "" && a.Hash == "" {
+		return fmt.Errorf("attachment must have a path or a hash")
+	}
+	if a.Hash != "" && !attachmentHashPattern.MatchString(a.Hash) {
+		return fmt.Errorf("attachment hash must be <algorithm>:<hex>, got %q", a.Hash)
+	}
+	return nil
 }
 
 var groupIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
@@ -162,3 +162,27 @@ func TestValidateGroupID_Invalid(t *testing.T) {
 		})
 	}
 }
+
+// TestAttachment_Validate はパスかハッシュの一方が必要で、ハッシュは<algorithm>:<hex>形式であることをテスト
+func TestAttachment_Validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		attachment Attachment
+		wantErr    bool
+	}{
+		{"path only", Attachment{Path: "docs/design.md"}, false},
+		{"hash only", Attachment{Hash: "sha256:2cf24dba"}, false},
+		{"path and hash", Attachment{Path: "docs/design.md", Hash: "sha256:2cf24dba"}, false},
+		{"empty", Attachment{}, true},
+		{"hash without algorithm", Attachment{Hash: "2cf24dba"}, true},
+		{"hash not hex", Attachment{Hash: "sha256:xyz"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.attachment.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
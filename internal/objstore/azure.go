@@ -0,0 +1,372 @@
+package objstore
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	azureAPIVersion    = "2021-08-06"
+	azureStorageScope  = "https://storage.azure.com/.default"
+	azureStorageAud    = "https://storage.azure.com/"
+	azureAuthorityHost = "https://login.microsoftonline.com"
+)
+
+// azureBucket はAzure Blob Storageのコンテナ
+type azureBucket struct {
+	client    *http.Client
+	account   string
+	container string
+	endpoint  string // https://<account>.blob.core.windows.net
+	// 認証はいずれか1つ
+	key   []byte     // 共有キー
+	sas   url.Values // SASトークン
+	token string     // Microsoft Entra IDのアクセストークン
+}
+
+// openAzure はBlob Storageのコンテナを開く
+// アカウントと認証情報は次の順に探す:
+// AZURE_STORAGE_CONNECTION_STRING（AccountName / AccountKey / SharedAccessSignature / BlobEndpoint）→
+// AZURE_STORAGE_ACCOUNT と AZURE_STORAGE_KEY（共有キー）または AZURE_STORAGE_SAS_TOKEN →
+// Microsoft Entra ID（AZURE_TENANT_ID / AZURE_CLIENT_ID / AZURE_CLIENT_SECRET のサービスプリンシパル、
+// なければApp Service・VMのマネージドID）
+// ワークロードID（AZURE_FEDERATED_TOKEN_FILE）・証明書（AZURE_CLIENT_CERTIFICATE_PATH）・Azure CLIのログインには対応しない
+func openAzure(ctx context.Context, client *http.Client, container string) (*azureBucket, error) {
+	b := &azureBucket{client: client, container: container}
+	if cs := os.Getenv("AZURE_STORAGE_CONNECTION_STRING"); cs != "" {
+		if err := b.applyConnectionString(cs); err != nil {
+			return nil, err
+		}
+	} else {
+		b.account = os.Getenv("AZURE_STORAGE_ACCOUNT")
+		if b.account == "" {
+			return nil, fmt.Errorf("no Azure storage account found (set AZURE_STORAGE_ACCOUNT or AZURE_STORAGE_CONNECTION_STRING)")
+		}
+		if key := os.Getenv("AZURE_STORAGE_KEY"); key != "" {
+			if err := b.setKey(key); err != nil {
+				return nil, err
+			}
+		} else if sas := os.Getenv("AZURE_STORAGE_SAS_TOKEN"); sas != "" {
+			if err := b.setSAS(sas); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if b.endpoint == "" {
+		b.endpoint = "https://" + b.account + ".blob.core.windows.net"
+	}
+	if b.key == nil && b.sas == nil {
+		token, err := azureAccessToken(ctx, client)
+		if err != nil {
+			return nil, err
+		}
+		b.token = token
+	}
+	return b, nil
+}
+
+// applyConnectionString は接続文字列からアカウント・エンドポイント・認証情報を設定する
+func (b *azureBucket) applyConnectionString(cs string) error {
+	values := make(map[string]string)
+	for _, part := range strings.Split(cs, ";") {
+		if k, v, ok := strings.Cut(strings.TrimSpace(part), "="); ok {
+			values[strings.ToLower(k)] = v
+		}
+	}
+	b.account = values["accountname"]
+	if endpoint := values["blobendpoint"]; endpoint != "" {
+		b.endpoint = strings.TrimSuffix(endpoint, "/")
+	} else if b.account != "" {
+		protocol := values["defaultendpointsprotocol"]
+		if protocol == "" {
+			protocol = "https"
+		}
+		suffix := values["endpointsuffix"]
+		if suffix == "" {
+			suffix = "core.windows.net"
+		}
+		b.endpoint = protocol + "://" + b.account + ".blob." + suffix
+	}
+	if b.endpoint == "" {
+		return fmt.Errorf("invalid AZURE_STORAGE_CONNECTION_STRING: AccountName or BlobEndpoint is required")
+	}
+	if key := values["accountkey"]; key != "" {
+		if b.account == "" {
+			return fmt.Errorf("invalid AZURE_STORAGE_CONNECTION_STRING: AccountKey requires AccountName")
+		}
+		return b.setKey(key)
+	}
+	if sas := values["sharedaccesssignature"]; sas != "" {
+		return b.setSAS(sas)
+	}
+	return nil
+}
+
+func (b *azureBucket) setKey(key string) error {
+	decoded, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return fmt.Errorf("invalid Azure storage account key: %w", err)
+	}
+	b.key = decoded
+	return nil
+}
+
+func (b *azureBucket) setSAS(sas string) error {
+	values, err := url.ParseQuery(strings.TrimPrefix(sas, "?"))
+	if err != nil {
+		return fmt.Errorf("invalid Azure SAS token: %w", err)
+	}
+	b.sas = values
+	return nil
+}
+
+// azureAccessToken はMicrosoft Entra IDのアクセストークンを取得する
+func azureAccessToken(ctx context.Context, client *http.Client) (string, error) {
+	tenant, clientID, secret := os.Getenv("AZURE_TENANT_ID"), os.Getenv("AZURE_CLIENT_ID"), os.Getenv("AZURE_CLIENT_SECRET")
+	if tenant != "" && clientID != "" && secret != "" {
+		authority := strings.TrimSuffix(envOr("AZURE_AUTHORITY_HOST", azureAuthorityHost), "/")
+		form := url.Values{
+			"grant_type":    {"client_credentials"},
+			"client_id":     {clientID},
+			"client_secret": {secret},
+			"scope":         {azureStorageScope},
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, authority+"/"+url.PathEscape(tenant)+"/oauth2/v2.0/token", strings.NewReader(form.Encode()))
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return fetchAccessToken(client, req)
+	}
+	// マネージドIDに進むとノードなど意図しないIDのトークンを使ってしまうため、エラーにする
+	if os.Getenv("AZURE_FEDERATED_TOKEN_FILE") != "" {
+		return "", fmt.Errorf("%w: workload identity (AZURE_FEDERATED_TOKEN_FILE)", ErrUnsupportedCredentials)
+	}
+	if os.Getenv("AZURE_CLIENT_CERTIFICATE_PATH") != "" {
+		return "", fmt.Errorf("%w: client certificate (AZURE_CLIENT_CERTIFICATE_PATH)", ErrUnsupportedCredentials)
+	}
+
+	token, err := azureManagedIdentityToken(ctx, client, clientID)
+	if err != nil {
+		return "", fmt.Errorf("no Azure credentials found (set AZURE_STORAGE_KEY, AZURE_STORAGE_SAS_TOKEN, a service principal or use a managed identity): %w", err)
+	}
+	return token, nil
+}
+
+// azureManagedIdentityToken はマネージドID（App ServiceのIDENTITY_ENDPOINT、なければVMのIMDS）のトークンを得る
+func azureManagedIdentityToken(ctx context.Context, client *http.Client, clientID string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, metadataTimeout)
+	defer cancel()
+
+	q := url.Values{"resource": {azureStorageAud}}
+	if clientID != "" {
+		q.Set("client_id", clientID)
+	}
+	endpoint, header, value := "http://169.254.169.254/metadata/identity/oauth2/token", "Metadata", "true"
+	q.Set("api-version", "2018-02-01")
+	if e := os.Getenv("IDENTITY_ENDPOINT"); e != "" && os.Getenv("IDENTITY_HEADER") != "" {
+		endpoint, header, value = e, "X-IDENTITY-HEADER", os.Getenv("IDENTITY_HEADER")
+		q.Set("api-version", "2019-08-01")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+q.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set(header, value)
+	return fetchAccessToken(client, req)
+}
+
+func (b *azureBucket) URL(key string) string {
+	return SchemeAzure + "://" + b.container + "/" + key
+}
+
+// newRequest はコンテナ内のpath（空ならコンテナ）へのリクエストを作る
+func (b *azureBucket) newRequest(ctx context.Context, method, key string, query url.Values, body io.Reader) (*http.Request, error) {
+	u, err := url.Parse(b.endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Azure blob endpoint %s: %w", b.endpoint, err)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + b.container
+	if key != "" {
+		u.Path += "/" + key
+	}
+	q := url.Values{}
+	for k, v := range query {
+		q[k] = v
+	}
+	for k, v := range b.sas {
+		q[k] = v
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("x-ms-version", azureAPIVersion)
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	return req, nil
+}
+
+// authorize は共有キーの署名またはアクセストークンを付ける（SASはクエリに含めている）
+func (b *azureBucket) authorize(req *http.Request) {
+	switch {
+	case b.key != nil:
+		req.Header.Set("Authorization", "SharedKey "+b.account+":"+signAzureSharedKey(req, b.account, b.key))
+	case b.token != "":
+		req.Header.Set("Authorization", "Bearer "+b.token)
+	}
+}
+
+// signAzureSharedKey はBlob Storageの共有キー認証の署名を作る
+func signAzureSharedKey(req *http.Request, account string, key []byte) string {
+	contentLength := ""
+	if req.ContentLength > 0 {
+		contentLength = strconv.FormatInt(req.ContentLength, 10)
+	}
+	h := req.Header
+	var msHeaders []string
+	for name := range h {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-ms-") {
+			msHeaders = append(msHeaders, lower+":"+strings.TrimSpace(h.Get(name)))
+		}
+	}
+	sort.Strings(msHeaders)
+
+	resource := "/" + account + req.URL.EscapedPath()
+	q := req.URL.Query()
+	names := make([]string, 0, len(q))
+	for name := range q {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return strings.ToLower(names[i]) < strings.ToLower(names[j]) })
+	for _, name := range names {
+		values := append([]string(nil), q[name]...)
+		sort.Strings(values)
+		resource += "\n" + strings.ToLower(name) + ":" + strings.Join(values, ",")
+	}
+
+	stringToSign := strings.Join([]string{
+		req.Method,
+		h.Get("Content-Encoding"),
+		h.Get("Content-Language"),
+		contentLength,
+		h.Get("Content-MD5"),
+		h.Get("Content-Type"),
+		"", // Date（x-ms-dateを使う）
+		h.Get("If-Modified-Since"),
+		h.Get("If-Match"),
+		h.Get("If-None-Match"),
+		h.Get("If-Unmodified-Since"),
+		h.Get("Range"),
+		strings.Join(msHeaders, "\n"),
+		resource,
+	}, "\n")
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (b *azureBucket) Put(ctx context.Context, key string, r io.ReadSeeker) error {
+	size, err := seekSize(r)
+	if err != nil {
+		return fmt.Errorf("failed to read upload: %w", err)
+	}
+	req, err := b.newRequest(ctx, http.MethodPut, key, nil, io.NopCloser(r))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("x-ms-blob-type", "BlockBlob")
+	b.authorize(req)
+	resp, err := do(b.client, req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (b *azureBucket) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := b.newRequest(ctx, http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	b.authorize(req)
+	resp, err := do(b.client, req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (b *azureBucket) Delete(ctx context.Context, key string) error {
+	req, err := b.newRequest(ctx, http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	b.authorize(req)
+	resp, err := do(b.client, req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// azureListResult はList Blobsのレスポンス
+type azureListResult struct {
+	Blobs []struct {
+		Name       string `xml:"Name"`
+		Properties struct {
+			ContentLength int64  `xml:"Content-Length"`
+			LastModified  string `xml:"Last-Modified"` // RFC1123
+		} `xml:"Properties"`
+	} `xml:"Blobs>Blob"`
+	NextMarker string `xml:"NextMarker"`
+}
+
+func (b *azureBucket) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	marker := ""
+	for {
+		q := url.Values{"restype": {"container"}, "comp": {"list"}, "prefix": {prefix}}
+		if marker != "" {
+			q.Set("marker", marker)
+		}
+		req, err := b.newRequest(ctx, http.MethodGet, "", q, nil)
+		if err != nil {
+			return nil, err
+		}
+		b.authorize(req)
+		data, err := readAll(b.client, req)
+		if err != nil {
+			return nil, err
+		}
+		var result azureListResult
+		if err := xml.Unmarshal(data, &result); err != nil {
+			return nil, fmt.Errorf("failed to parse Azure list response: %w", err)
+		}
+		for _, blob := range result.Blobs {
+			modified, _ := time.Parse(http.TimeFormat, blob.Properties.LastModified)
+			objects = append(objects, Object{Key: blob.Name, Size: blob.Properties.ContentLength, LastModified: modified})
+		}
+		if result.NextMarker == "" {
+			return objects, nil
+		}
+		marker = result.NextMarker
+	}
+}
@@ -0,0 +1,164 @@
+package objstore
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeAzure はBlob Storageを模したサーバー（authで認証を検証する）
+func fakeAzure(t *testing.T, container string, auth func(r *http.Request) error) (*httptest.Server, *memObjects) {
+	objects := newMemObjects()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := auth(r); err != nil {
+			t.Errorf("%s %s: %v", r.Method, r.URL.Path, err)
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.Header.Get("x-ms-version") == "" {
+			t.Error("missing x-ms-version")
+		}
+		// BlobEndpointにアカウント名のパスを含める（Azuriteと同じ形式）
+		key, ok := strings.CutPrefix(r.URL.Path, "/devaccount/"+container)
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		key = strings.TrimPrefix(key, "/")
+		q := r.URL.Query()
+		switch {
+		case r.Method == http.MethodGet && key == "" && q.Get("comp") == "list":
+			listAzure(w, r, objects)
+		case r.Method == http.MethodPut:
+			if r.Header.Get("x-ms-blob-type") != "BlockBlob" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			data, _ := io.ReadAll(r.Body)
+			objects.put(key, data)
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodGet:
+			data, ok := objects.get(key)
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(data)
+		case r.Method == http.MethodDelete:
+			objects.delete(key)
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, objects
+}
+
+// listAzure は1ページ1件でList Blobsに応答する
+func listAzure(w http.ResponseWriter, r *http.Request, objects *memObjects) {
+	keys := objects.list(r.URL.Query().Get("prefix"))
+	start, _ := strconv.Atoi(r.URL.Query().Get("marker"))
+	type blob struct {
+		Name          string
+		ContentLength int    `xml:"Properties>Content-Length"`
+		LastModified  string `xml:"Properties>Last-Modified"`
+	}
+	result := struct {
+		XMLName    xml.Name `xml:"EnumerationResults"`
+		Blobs      []blob   `xml:"Blobs>Blob"`
+		NextMarker string
+	}{}
+	if start < len(keys) {
+		data, _ := objects.get(keys[start])
+		result.Blobs = append(result.Blobs, blob{Name: keys[start], ContentLength: len(data), LastModified: "Tue, 02 Jan 2024 03:04:05 GMT"})
+	}
+	if start+1 < len(keys) {
+		result.NextMarker = strconv.Itoa(start + 1)
+	}
+	xml.NewEncoder(w).Encode(result)
+}
+
+func TestAzureBucket_SharedKey(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString([]byte("test-account-key"))
+	srv, objects := fakeAzure(t, "backups", func(r *http.Request) error {
+		want := "SharedKey devaccount:" + signAzureSharedKey(r, "devaccount", []byte("test-account-key"))
+		if got := r.Header.Get("Authorization"); got != want {
+			return fmt.Errorf("Authorization = %q, want %q", got, want)
+		}
+		return nil
+	})
+	t.Setenv("AZURE_STORAGE_CONNECTION_STRING", "DefaultEndpointsProtocol=http;AccountName=devaccount;AccountKey="+key+";BlobEndpoint="+srv.URL+"/devaccount;")
+
+	loc, _ := Parse("az://backups/team/")
+	b, err := Open(context.Background(), loc)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	testRoundTrip(t, b, objects)
+}
+
+func TestAzureBucket_SAS(t *testing.T) {
+	srv, objects := fakeAzure(t, "backups", func(r *http.Request) error {
+		if r.URL.Query().Get("sig") != "abc" || r.Header.Get("Authorization") != "" {
+			return fmt.Errorf("expected the SAS token in the query, got %s", r.URL.RawQuery)
+		}
+		return nil
+	})
+	t.Setenv("AZURE_STORAGE_CONNECTION_STRING", "BlobEndpoint="+srv.URL+"/devaccount;SharedAccessSignature=sv=2021-08-06&sp=rwdl&sig=abc")
+
+	loc, _ := Parse("az://backups/team/")
+	b, err := Open(context.Background(), loc)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	testRoundTrip(t, b, objects)
+}
+
+// TestOpenAzure_ServicePrincipal はサービスプリンシパルのトークンを使うことをテスト
+func TestOpenAzure_ServicePrincipal(t *testing.T) {
+	tokenSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.URL.Path != "/tenant/oauth2/v2.0/token" || r.Form.Get("client_secret") != "secret" || r.Form.Get("scope") != azureStorageScope {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"access_token":"entra-token","token_type":"Bearer"}`)
+	}))
+	defer tokenSrv.Close()
+	t.Setenv("AZURE_STORAGE_CONNECTION_STRING", "")
+	t.Setenv("AZURE_STORAGE_KEY", "")
+	t.Setenv("AZURE_STORAGE_SAS_TOKEN", "")
+	t.Setenv("AZURE_STORAGE_ACCOUNT", "teamaccount")
+	t.Setenv("AZURE_TENANT_ID", "tenant")
+	t.Setenv("AZURE_CLIENT_ID", "client")
+	t.Setenv("AZURE_CLIENT_SECRET", "secret")
+	t.Setenv("AZURE_AUTHORITY_HOST", tokenSrv.URL)
+
+	b, err := openAzure(context.Background(), http.DefaultClient, "backups")
+	if err != nil {
+		t.Fatalf("openAzure failed: %v", err)
+	}
+	if b.token != "entra-token" || b.endpoint != "https://teamaccount.blob.core.windows.net" {
+		t.Errorf("unexpected bucket: token=%q endpoint=%q", b.token, b.endpoint)
+	}
+
+	// ワークロードIDはマネージドIDに進まずエラーにする
+	t.Setenv("AZURE_CLIENT_SECRET", "")
+	t.Setenv("AZURE_FEDERATED_TOKEN_FILE", filepath.Join(t.TempDir(), "token"))
+	if _, err := openAzure(context.Background(), http.DefaultClient, "backups"); !errors.Is(err, ErrUnsupportedCredentials) {
+		t.Errorf("expected ErrUnsupportedCredentials, got %v", err)
+	}
+
+	t.Setenv("AZURE_STORAGE_ACCOUNT", "")
+	if _, err := openAzure(context.Background(), http.DefaultClient, "backups"); err == nil {
+		t.Error("expected an error without a storage account")
+	}
+}
@@ -0,0 +1,331 @@
+package objstore
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	gcsBaseURL      = "https://storage.googleapis.com"
+	gcsScope        = "https://www.googleapis.com/auth/devstorage.read_write"
+	googleTokenURL  = "https://oauth2.googleapis.com/token"
+	gceMetadataHost = "metadata.google.internal"
+)
+
+// gcsBucket はGoogle Cloud Storageのバケット
+type gcsBucket struct {
+	client *http.Client
+	bucket string
+	base   string // JSON APIの基点
+	token  string // 空なら認証しない（エミュレーター）
+}
+
+// openGCS はGCSのバケットを開く
+// 認証情報はApplication Default Credentialsと同じ順序で探す（ADCのうち次のものだけに対応する）:
+// GOOGLE_APPLICATION_CREDENTIALS のファイル → gcloud auth application-default login のファイル →
+// GCE・GKE・Cloud Runなどのメタデータサーバー
+// ファイルはサービスアカウントの鍵（service_account）とユーザーの認証情報（authorized_user）に対応し、
+// それ以外（external_accountなど）はErrUnsupportedCredentialsになる
+// STORAGE_EMULATOR_HOST を指定するとエミュレーターに認証なしでアクセスする
+func openGCS(ctx context.Context, client *http.Client, bucket string) (*gcsBucket, error) {
+	if host := os.Getenv("STORAGE_EMULATOR_HOST"); host != "" {
+		if !strings.Contains(host, "://") {
+			host = "http://" + host
+		}
+		return &gcsBucket{client: client, bucket: bucket, base: strings.TrimSuffix(host, "/")}, nil
+	}
+	token, err := googleAccessToken(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	return &gcsBucket{client: client, bucket: bucket, base: gcsBaseURL, token: token}, nil
+}
+
+// googleCredentialsFile はADCのJSONファイル
+type googleCredentialsFile struct {
+	Type string `json:"type"`
+	// service_account
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	PrivateKeyID string `json:"private_key_id"`
+	TokenURI     string `json:"token_uri"`
+	// authorized_user
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// googleTokenResponse はトークンエンドポイント・メタデータサーバーのレスポンス
+type googleTokenResponse struct {
+	AccessToken string `json:"access_token"`
+}
+
+// googleAccessToken はADCでアクセストークンを取得する
+func googleAccessToken(ctx context.Context, client *http.Client) (string, error) {
+	path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	if path == "" {
+		if p := googleWellKnownFile(); p != "" {
+			if _, err := os.Stat(p); err == nil {
+				path = p
+			}
+		}
+	}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read Google credentials: %w", err)
+		}
+		var f googleCredentialsFile
+		if err := json.Unmarshal(data, &f); err != nil {
+			return "", fmt.Errorf("failed to parse Google credentials %s: %w", path, err)
+		}
+		switch f.Type {
+		case "service_account":
+			return googleServiceAccountToken(ctx, client, &f, time.Now())
+		case "authorized_user":
+			return googleTokenRequest(ctx, client, googleTokenURL, url.Values{
+				"grant_type":    {"refresh_token"},
+				"client_id":     {f.ClientID},
+				"client_secret": {f.ClientSecret},
+				"refresh_token": {f.RefreshToken},
+			})
+		}
+		return "", fmt.Errorf("%w: Google credentials type %q in %s (use a service account key, gcloud auth application-default login or the metadata server)", ErrUnsupportedCredentials, f.Type, path)
+	}
+
+	token, err := googleMetadataToken(ctx, client)
+	if err != nil {
+		return "", fmt.Errorf("no Google credentials found (set GOOGLE_APPLICATION_CREDENTIALS, run gcloud auth application-default login or run on Google Cloud): %w", err)
+	}
+	return token, nil
+}
+
+// googleWellKnownFile はgcloud auth application-default loginが書き出すファイルのパス
+func googleWellKnownFile() string {
+	if dir := os.Getenv("CLOUDSDK_CONFIG"); dir != "" {
+		return filepath.Join(dir, "application_default_credentials.json")
+	}
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("APPDATA"); dir != "" {
+			return filepath.Join(dir, "gcloud", "application_default_credentials.json")
+		}
+		return ""
+	}
+	return homeFile(".config", "gcloud", "application_default_credentials.json")
+}
+
+// googleServiceAccountToken はサービスアカウントの鍵で署名したJWTをアクセストークンに交換する
+func googleServiceAccountToken(ctx context.Context, client *http.Client, f *googleCredentialsFile, now time.Time) (string, error) {
+	assertion, err := googleJWT(f, now)
+	if err != nil {
+		return "", err
+	}
+	tokenURL := f.TokenURI
+	if tokenURL == "" {
+		tokenURL = googleTokenURL
+	}
+	return googleTokenRequest(ctx, client, tokenURL, url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	})
+}
+
+// googleJWT はサービスアカウントの鍵でRS256のJWTを作る
+func googleJWT(f *googleCredentialsFile, now time.Time) (string, error) {
+	block, _ := pem.Decode([]byte(f.PrivateKey))
+	if block == nil {
+		return "", errors.New("invalid service account private key")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		if parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			return "", fmt.Errorf("invalid service account private key: %w", err)
+		}
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return "", errors.New("service account private key is not an RSA key")
+	}
+
+	aud := f.TokenURI
+	if aud == "" {
+		aud = googleTokenURL
+	}
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": f.PrivateKeyID})
+	claims, _ := json.Marshal(map[string]any{
+		"iss":   f.ClientEmail,
+		"scope": gcsScope,
+		"aud":   aud,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign service account assertion: %w", err)
+	}
+	return unsigned + "." + enc.EncodeToString(sig), nil
+}
+
+// googleTokenRequest はトークンエンドポイントにformをPOSTしてアクセストークンを得る
+func googleTokenRequest(ctx context.Context, client *http.Client, tokenURL string, form url.Values) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return fetchAccessToken(client, req)
+}
+
+// googleMetadataToken はメタデータサーバーからデフォルトのサービスアカウントのトークンを得る
+func googleMetadataToken(ctx context.Context, client *http.Client) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, metadataTimeout)
+	defer cancel()
+	host := envOr("GCE_METADATA_HOST", gceMetadataHost)
+	u := "http://" + host + "/computeMetadata/v1/instance/service-accounts/default/token?scopes=" + url.QueryEscape(gcsScope)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	return fetchAccessToken(client, req)
+}
+
+// fetchAccessToken はOAuth2のトークンレスポンス（access_token）を読む
+func fetchAccessToken(client *http.Client, req *http.Request) (string, error) {
+	data, err := readAll(client, req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get access token: %w", err)
+	}
+	var token googleTokenResponse
+	if err := json.Unmarshal(data, &token); err != nil || token.AccessToken == "" {
+		return "", fmt.Errorf("failed to get access token: unexpected response from %s", redactURL(req.URL))
+	}
+	return token.AccessToken, nil
+}
+
+func (b *gcsBucket) URL(key string) string {
+	return SchemeGCS + "://" + b.bucket + "/" + key
+}
+
+// objectURL はJSON APIのオブジェクトのURL（オブジェクト名の "/" もエスケープする）
+func (b *gcsBucket) objectURL(key string) string {
+	return b.base + "/storage/v1/b/" + url.PathEscape(b.bucket) + "/o/" + url.PathEscape(key)
+}
+
+func (b *gcsBucket) newRequest(ctx context.Context, method, u string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	if b.token != "" {
+		req.Header.Set("Authorization", "Bearer "+b.token)
+	}
+	return req, nil
+}
+
+func (b *gcsBucket) Put(ctx context.Context, key string, r io.ReadSeeker) error {
+	size, err := seekSize(r)
+	if err != nil {
+		return fmt.Errorf("failed to read upload: %w", err)
+	}
+	u := b.base + "/upload/storage/v1/b/" + url.PathEscape(b.bucket) + "/o?" + url.Values{"uploadType": {"media"}, "name": {key}}.Encode()
+	req, err := b.newRequest(ctx, http.MethodPost, u, io.NopCloser(r))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := do(b.client, req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (b *gcsBucket) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := b.newRequest(ctx, http.MethodGet, b.objectURL(key)+"?alt=media", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := do(b.client, req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (b *gcsBucket) Delete(ctx context.Context, key string) error {
+	req, err := b.newRequest(ctx, http.MethodDelete, b.objectURL(key), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := do(b.client, req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// gcsListResponse はobjects.listのレスポンス
+type gcsListResponse struct {
+	Items []struct {
+		Name    string    `json:"name"`
+		Size    string    `json:"size"` // 数値の文字列
+		Updated time.Time `json:"updated"`
+	} `json:"items"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+func (b *gcsBucket) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	pageToken := ""
+	for {
+		q := url.Values{"prefix": {prefix}}
+		if pageToken != "" {
+			q.Set("pageToken", pageToken)
+		}
+		req, err := b.newRequest(ctx, http.MethodGet, b.base+"/storage/v1/b/"+url.PathEscape(b.bucket)+"/o?"+q.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+		data, err := readAll(b.client, req)
+		if err != nil {
+			return nil, err
+		}
+		var result gcsListResponse
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, fmt.Errorf("failed to parse GCS list response: %w", err)
+		}
+		for _, item := range result.Items {
+			size, _ := strconv.ParseInt(item.Size, 10, 64)
+			objects = append(objects, Object{Key: item.Name, Size: size, LastModified: item.Updated})
+		}
+		if result.NextPageToken == "" {
+			return objects, nil
+		}
+		pageToken = result.NextPageToken
+	}
+}
@@ -0,0 +1,176 @@
+package objstore
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeGCS はGCSのJSON APIを模したサーバー
+func fakeGCS(t *testing.T, bucket, token string) (*httptest.Server, *memObjects) {
+	objects := newMemObjects()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token != "" && r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		path := r.URL.EscapedPath()
+		switch {
+		case r.Method == http.MethodPost && path == "/upload/storage/v1/b/"+bucket+"/o":
+			if r.URL.Query().Get("uploadType") != "media" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			data, _ := io.ReadAll(r.Body)
+			objects.put(r.URL.Query().Get("name"), data)
+			fmt.Fprint(w, `{}`)
+		case r.Method == http.MethodGet && path == "/storage/v1/b/"+bucket+"/o":
+			listGCS(w, r, objects)
+		case strings.HasPrefix(path, "/storage/v1/b/"+bucket+"/o/"):
+			// オブジェクト名の "/" はエスケープされて届く
+			key, _ := url.PathUnescape(strings.TrimPrefix(path, "/storage/v1/b/"+bucket+"/o/"))
+			if strings.Contains(strings.TrimPrefix(path, "/storage/v1/b/"+bucket+"/o/"), "/") {
+				t.Errorf("expected an escaped object name, got %s", path)
+			}
+			if r.Method == http.MethodDelete {
+				if !objects.delete(key) {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			data, ok := objects.get(key)
+			if !ok || r.URL.Query().Get("alt") != "media" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(data)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, objects
+}
+
+// listGCS は1ページ1件でobjects.listに応答する
+func listGCS(w http.ResponseWriter, r *http.Request, objects *memObjects) {
+	keys := objects.list(r.URL.Query().Get("prefix"))
+	start, _ := strconv.Atoi(r.URL.Query().Get("pageToken"))
+	resp := map[string]any{}
+	if start < len(keys) {
+		data, _ := objects.get(keys[start])
+		resp["items"] = []map[string]string{{"name": keys[start], "size": strconv.Itoa(len(data)), "updated": "2024-01-02T03:04:05.000Z"}}
+	}
+	if start+1 < len(keys) {
+		resp["nextPageToken"] = strconv.Itoa(start + 1)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func TestGCSBucket_Emulator(t *testing.T) {
+	srv, objects := fakeGCS(t, "team-backups", "")
+	t.Setenv("STORAGE_EMULATOR_HOST", strings.TrimPrefix(srv.URL, "http://"))
+
+	loc, _ := Parse("gs://team-backups/backups/")
+	b, err := Open(context.Background(), loc)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	testRoundTrip(t, b, objects)
+}
+
+// TestGoogleAccessToken_ServiceAccount はサービスアカウントの鍵で署名したJWTをトークンに交換することをテスト
+func TestGoogleAccessToken_ServiceAccount(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+
+	tokenSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		parts := strings.Split(r.Form.Get("assertion"), ".")
+		sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, sum[:], sig); err != nil {
+			t.Errorf("invalid assertion signature: %v", err)
+		}
+		claims, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		var c map[string]any
+		json.Unmarshal(claims, &c)
+		if c["iss"] != "backup@project.iam.gserviceaccount.com" || c["scope"] != gcsScope {
+			t.Errorf("unexpected claims: %v", c)
+		}
+		fmt.Fprint(w, `{"access_token":"ya29.test","expires_in":3600}`)
+	}))
+	defer tokenSrv.Close()
+
+	creds, _ := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"client_email":   "backup@project.iam.gserviceaccount.com",
+		"private_key":    string(pemKey),
+		"private_key_id": "kid",
+		"token_uri":      tokenSrv.URL,
+	})
+	path := filepath.Join(t.TempDir(), "sa.json")
+	os.WriteFile(path, creds, 0o600)
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", path)
+
+	token, err := googleAccessToken(context.Background(), http.DefaultClient)
+	if err != nil {
+		t.Fatalf("googleAccessToken failed: %v", err)
+	}
+	if token != "ya29.test" {
+		t.Errorf("token = %q", token)
+	}
+}
+
+// TestGoogleAccessToken_UnsupportedType はexternal_accountなど対応していない種類のファイルがエラーになることをテスト
+func TestGoogleAccessToken_UnsupportedType(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wif.json")
+	os.WriteFile(path, []byte(`{"type":"external_account","audience":"//iam.googleapis.com/projects/1/locations/global/workloadIdentityPools/p/providers/q"}`), 0o600)
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", path)
+
+	if _, err := googleAccessToken(context.Background(), http.DefaultClient); !errors.Is(err, ErrUnsupportedCredentials) {
+		t.Errorf("expected ErrUnsupportedCredentials, got %v", err)
+	}
+}
+
+// TestGoogleAccessToken_NoCredentials はADCが見つからなければエラーになることをテスト
+func TestGoogleAccessToken_NoCredentials(t *testing.T) {
+	metadata := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer metadata.Close()
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "")
+	t.Setenv("CLOUDSDK_CONFIG", t.TempDir())
+	t.Setenv("GCE_METADATA_HOST", strings.TrimPrefix(metadata.URL, "http://"))
+
+	if _, err := googleAccessToken(context.Background(), http.DefaultClient); err == nil {
+		t.Error("expected an error without credentials")
+	}
+}
@@ -0,0 +1,199 @@
+// Package objstore is a small client for object storage (Amazon S3, Google Cloud Storage and
+// Azure Blob Storage) used to push and pull backup archives. It does not link in the official
+// SDKs and only resolves a subset of their credential sources: static keys from environment
+// variables and shared files, service account and user credential files, and the cloud metadata
+// endpoints. Sources it does not implement (web identity, SSO, credential_process, assume role,
+// external accounts, workload identity) fail with ErrUnsupportedCredentials instead of silently
+// falling through to another identity.
+package objstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// URLのスキーム
+const (
+	SchemeS3    = "s3"
+	SchemeGCS   = "gs"
+	SchemeAzure = "az"
+)
+
+// ErrNotFound はオブジェクトが存在しない
+var ErrNotFound = errors.New("object not found")
+
+// ErrUnsupportedCredentials は公式SDKでは使えるが、このクライアントが対応していない認証情報が設定されている
+var ErrUnsupportedCredentials = errors.New("unsupported credential source")
+
+// Object は一覧で返すオブジェクト
+type Object struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// Bucket はバケット（Azureではコンテナ）に対する操作
+type Bucket interface {
+	// Put はrの内容をkeyに書き込む（署名・Content-Lengthのためにサイズを求めるのでSeekできること）
+	Put(ctx context.Context, key string, r io.ReadSeeker) error
+	// Get はkeyの内容を返す（存在しなければErrNotFound）
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// List はprefixで始まるオブジェクトを返す
+	List(ctx context.Context, prefix string) ([]Object, error)
+	// Delete はkeyを削除する
+	Delete(ctx context.Context, key string) error
+	// URL はkeyを表示用のURL（s3://bucket/key など）にする
+	URL(key string) string
+}
+
+// Location はオブジェクトストレージのURL（s3://bucket/key、gs://bucket/key、az://container/key）
+type Location struct {
+	Scheme string
+	Bucket string
+	Key    string // 先頭の "/" を除いたパス（空ならバケットの直下）
+}
+
+// IsURL はsがオブジェクトストレージのURLか
+func IsURL(s string) bool {
+	for _, scheme := range []string{SchemeS3, SchemeGCS, SchemeAzure} {
+		if strings.HasPrefix(s, scheme+"://") {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse はオブジェクトストレージのURLを解析する
+func Parse(raw string) (*Location, error) {
+	if !IsURL(raw) {
+		return nil, fmt.Errorf("unsupported storage URL: %s (must start with s3://, gs:// or az://)", raw)
+	}
+	scheme, rest, _ := strings.Cut(raw, "://")
+	bucket, key, _ := strings.Cut(rest, "/")
+	if bucket == "" {
+		return nil, fmt.Errorf("storage URL has no bucket: %s", raw)
+	}
+	return &Location{Scheme: scheme, Bucket: bucket, Key: key}, nil
+}
+
+// String はLocationをURLにする
+func (l *Location) String() string {
+	return l.Scheme + "://" + l.Bucket + "/" + l.Key
+}
+
+// Option はOpenのオプション
+type Option func(*options)
+
+type options struct {
+	httpClient *http.Client
+}
+
+// WithHTTPClient はHTTPクライアントを設定
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = client
+	}
+}
+
+// Open はlocのバケットを開き、認証情報を解決する
+// 認証情報は各クラウドのSDKと同じ順序で探す（s3.go・gcs.go・azure.goを参照）
+func Open(ctx context.Context, loc *Location, opts ...Option) (Bucket, error) {
+	o := &options{httpClient: http.DefaultClient}
+	for _, opt := range opts {
+		opt(o)
+	}
+	switch loc.Scheme {
+	case SchemeS3:
+		return openS3(ctx, o.httpClient, loc.Bucket)
+	case SchemeGCS:
+		return openGCS(ctx, o.httpClient, loc.Bucket)
+	case SchemeAzure:
+		return openAzure(ctx, o.httpClient, loc.Bucket)
+	}
+	return nil, fmt.Errorf("unsupported storage scheme: %s", loc.Scheme)
+}
+
+// metadataTimeout はメタデータエンドポイント（クラウド外では応答しない）を待つ時間
+const metadataTimeout = 2 * time.Second
+
+// do はリクエストを送り、2xx以外をエラーにする（404はErrNotFound）
+// 成功時はレスポンスを返すので、呼び出し側でBodyを閉じる
+func do(client *http.Client, req *http.Request) (*http.Response, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", req.Method, redactURL(req.URL), err)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, redactURL(req.URL))
+	}
+	return nil, fmt.Errorf("%s %s: status %d: %s", req.Method, redactURL(req.URL), resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// redactURL はエラーに含めるURLからクエリ（SASトークンを含むことがある）を除く
+func redactURL(u *url.URL) string {
+	c := *u
+	c.RawQuery = ""
+	return c.String()
+}
+
+// readAll はリクエストを送り、成功したレスポンスの本文を返す
+func readAll(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := do(client, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// seekSize はrの残りのサイズを求めて先頭に戻す
+func seekSize(r io.ReadSeeker) (int64, error) {
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// homeFile はホームディレクトリ以下のパスを返す（ホームがなければ空）
+func homeFile(elem ...string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(append([]string{home}, elem...)...)
+}
+
+// firstEnv は最初に設定されている環境変数の値を返す
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// envOr は環境変数nameの値（未設定ならdef）を返す
+func envOr(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,125 @@
+package objstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    Location
+		wantErr bool
+	}{
+		{raw: "s3://team-backups/mcp/", want: Location{Scheme: SchemeS3, Bucket: "team-backups", Key: "mcp/"}},
+		{raw: "gs://bucket", want: Location{Scheme: SchemeGCS, Bucket: "bucket"}},
+		{raw: "az://container/a/b.tar.zst", want: Location{Scheme: SchemeAzure, Bucket: "container", Key: "a/b.tar.zst"}},
+		{raw: "s3:///key", wantErr: true},
+		{raw: "https://example.com/a", wantErr: true},
+		{raw: "/mnt/backups", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := Parse(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && *got != tt.want {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// memObjects は各テストのフェイクサーバーが使うオブジェクトの保存先
+type memObjects struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func newMemObjects() *memObjects {
+	return &memObjects{objects: make(map[string][]byte)}
+}
+
+func (m *memObjects) put(key string, data []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[key] = data
+}
+
+func (m *memObjects) get(key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.objects[key]
+	return data, ok
+}
+
+func (m *memObjects) delete(key string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.objects[key]
+	delete(m.objects, key)
+	return ok
+}
+
+func (m *memObjects) list(prefix string) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var keys []string
+	for key := range m.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// testRoundTrip はPut・Get・List・Deleteをひととおり確認する
+func testRoundTrip(t *testing.T, b Bucket, objects *memObjects) {
+	t.Helper()
+	ctx := context.Background()
+	content := []byte("archive content")
+	for _, key := range []string{"backups/a b+1.tar.zst", "backups/b.tar.zst", "other/c.tar.zst"} {
+		if err := b.Put(ctx, key, bytes.NewReader(content)); err != nil {
+			t.Fatalf("Put(%s) failed: %v", key, err)
+		}
+	}
+	if data, ok := objects.get("backups/a b+1.tar.zst"); !ok || !bytes.Equal(data, content) {
+		t.Fatalf("expected the object to be stored with its key, got %v", objects.list(""))
+	}
+
+	rc, err := b.Get(ctx, "backups/a b+1.tar.zst")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if !bytes.Equal(data, content) {
+		t.Errorf("Get = %q, want %q", data, content)
+	}
+	if _, err := b.Get(ctx, "backups/missing.tar.zst"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	list, err := b.List(ctx, "backups/")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(list) != 2 || list[0].Key != "backups/a b+1.tar.zst" || list[0].Size != int64(len(content)) {
+		t.Errorf("unexpected list: %+v", list)
+	}
+
+	if err := b.Delete(ctx, "backups/b.tar.zst"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, ok := objects.get("backups/b.tar.zst"); ok {
+		t.Error("expected the object to be deleted")
+	}
+}
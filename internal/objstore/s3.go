@@ -0,0 +1,474 @@
+package objstore
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// emptySHA256 は空のペイロードのSHA-256
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// awsCredentials はAWSの認証情報
+type awsCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// s3Bucket はAmazon S3（およびS3互換ストレージ）のバケット
+type s3Bucket struct {
+	client *http.Client
+	bucket string
+	region string
+	base   *url.URL // オブジェクトのURLの基点（パス形式ならバケット名まで含む）
+	creds  *awsCredentials
+}
+
+// openS3 はS3のバケットを開く
+// 認証情報は次の順に探す（AWS SDKの認証情報のうちこれらだけに対応する）:
+// 環境変数（AWS_ACCESS_KEY_ID / AWS_SECRET_ACCESS_KEY / AWS_SESSION_TOKEN）→
+// 共有ファイル（~/.aws/credentials・~/.aws/config のAWS_PROFILEの静的なキー）→ コンテナ（ECS）→ EC2のインスタンスメタデータ
+// リージョンはAWS_REGION / AWS_DEFAULT_REGION / ~/.aws/config（なければus-east-1）、
+// AWS_ENDPOINT_URL_S3 / AWS_ENDPOINT_URL を指定するとS3互換ストレージ（MinIOなど）にパス形式でアクセスする
+func openS3(ctx context.Context, client *http.Client, bucket string) (*s3Bucket, error) {
+	profile := firstEnv("AWS_PROFILE", "AWS_DEFAULT_PROFILE")
+	if profile == "" {
+		profile = "default"
+	}
+	credsFile := readINI(envOr("AWS_SHARED_CREDENTIALS_FILE", homeFile(".aws", "credentials")))
+	configFile := readINI(envOr("AWS_CONFIG_FILE", homeFile(".aws", "config")))
+	// ~/.aws/config のセクションは [default] と [profile name]
+	configSection := configFile["profile "+profile]
+	if profile == "default" && configSection == nil {
+		configSection = configFile["default"]
+	}
+
+	region := firstEnv("AWS_REGION", "AWS_DEFAULT_REGION")
+	if region == "" {
+		region = configSection["region"]
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	creds, err := awsCredentialChain(ctx, client, credsFile[profile], configSection)
+	if err != nil {
+		return nil, err
+	}
+
+	var base *url.URL
+	if endpoint := firstEnv("AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL"); endpoint != "" {
+		if base, err = url.Parse(strings.TrimSuffix(endpoint, "/") + "/" + bucket); err != nil {
+			return nil, fmt.Errorf("invalid S3 endpoint %s: %w", endpoint, err)
+		}
+	} else if strings.Contains(bucket, ".") {
+		// "." を含むバケットは仮想ホスト形式だと証明書が一致しない
+		base = &url.URL{Scheme: "https", Host: "s3." + region + ".amazonaws.com", Path: "/" + bucket}
+	} else {
+		base = &url.URL{Scheme: "https", Host: bucket + ".s3." + region + ".amazonaws.com"}
+	}
+	return &s3Bucket{client: client, bucket: bucket, region: region, base: base, creds: creds}, nil
+}
+
+// awsCredentialChain は認証情報を順に探す
+func awsCredentialChain(ctx context.Context, client *http.Client, credsSection, configSection map[string]string) (*awsCredentials, error) {
+	if id, secret := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"); id != "" && secret != "" {
+		return &awsCredentials{AccessKeyID: id, SecretAccessKey: secret, SessionToken: os.Getenv("AWS_SESSION_TOKEN")}, nil
+	}
+	for _, section := range []map[string]string{credsSection, configSection} {
+		if section["aws_access_key_id"] != "" && section["aws_secret_access_key"] != "" {
+			return &awsCredentials{
+				AccessKeyID:     section["aws_access_key_id"],
+				SecretAccessKey: section["aws_secret_access_key"],
+				SessionToken:    section["aws_session_token"],
+			}, nil
+		}
+	}
+	if source := awsUnsupportedSource(credsSection, configSection); source != "" {
+		return nil, fmt.Errorf("%w: %s (set AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY or static keys in the profile)", ErrUnsupportedCredentials, source)
+	}
+	if creds, err := awsContainerCredentials(ctx, client); creds != nil || err != nil {
+		return creds, err
+	}
+	if !strings.EqualFold(os.Getenv("AWS_EC2_METADATA_DISABLED"), "true") {
+		if creds, err := awsInstanceCredentials(ctx, client); err == nil {
+			return creds, nil
+		}
+	}
+	return nil, fmt.Errorf("no AWS credentials found (set AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY, AWS_PROFILE or run on AWS with a role)")
+}
+
+// awsUnsupportedSource はAWS SDKでは使えるが対応していない認証情報の設定を返す（なければ空）
+// 見つけた場合に無視するとインスタンスプロファイルなど意図しない認証情報を使ってしまうため、エラーにする
+func awsUnsupportedSource(credsSection, configSection map[string]string) string {
+	if os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE") != "" {
+		return "web identity (AWS_WEB_IDENTITY_TOKEN_FILE)"
+	}
+	for _, section := range []map[string]string{credsSection, configSection} {
+		switch {
+		case section["credential_process"] != "":
+			return "credential_process"
+		case section["sso_session"] != "" || section["sso_start_url"] != "":
+			return "SSO profile"
+		case section["web_identity_token_file"] != "":
+			return "web identity (web_identity_token_file)"
+		case section["role_arn"] != "":
+			return "assume role (role_arn)"
+		}
+	}
+	return ""
+}
+
+// awsMetadataCredentials はコンテナ・インスタンスメタデータが返す認証情報
+type awsMetadataCredentials struct {
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	Token           string `json:"Token"`
+}
+
+// awsContainerCredentials はECSなどのコンテナの認証情報エンドポイントから取得する（設定されていなければnil）
+func awsContainerCredentials(ctx context.Context, client *http.Client) (*awsCredentials, error) {
+	endpoint := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	if rel := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); rel != "" {
+		endpoint = "http://169.254.170.2" + rel
+	}
+	if endpoint == "" {
+		return nil, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid container credentials endpoint: %w", err)
+	}
+	token := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+	if file := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read container authorization token: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	return fetchAWSMetadataCredentials(client, req)
+}
+
+// awsInstanceCredentials はEC2のインスタンスメタデータ（IMDSv2）からロールの認証情報を取得する
+func awsInstanceCredentials(ctx context.Context, client *http.Client) (*awsCredentials, error) {
+	ctx, cancel := context.WithTimeout(ctx, metadataTimeout)
+	defer cancel()
+	endpoint := strings.TrimSuffix(envOr("AWS_EC2_METADATA_SERVICE_ENDPOINT", "http://169.254.169.254"), "/")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint+"/latest/api/token", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "300")
+	token, err := readAll(client, req)
+	if err != nil {
+		return nil, err
+	}
+
+	const credsPath = "/latest/meta-data/iam/security-credentials/"
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, endpoint+credsPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", string(token))
+	role, err := readAll(client, req)
+	if err != nil {
+		return nil, err
+	}
+	name, _, _ := strings.Cut(strings.TrimSpace(string(role)), "\n")
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, endpoint+credsPath+name, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", string(token))
+	return fetchAWSMetadataCredentials(client, req)
+}
+
+func fetchAWSMetadataCredentials(client *http.Client, req *http.Request) (*awsCredentials, error) {
+	data, err := readAll(client, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AWS credentials: %w", err)
+	}
+	var c awsMetadataCredentials
+	if err := json.Unmarshal(data, &c); err != nil || c.AccessKeyID == "" {
+		return nil, fmt.Errorf("failed to get AWS credentials: unexpected response from %s", redactURL(req.URL))
+	}
+	return &awsCredentials{AccessKeyID: c.AccessKeyID, SecretAccessKey: c.SecretAccessKey, SessionToken: c.Token}, nil
+}
+
+// objectURL はkeyのURLを返す（キーの各要素をエスケープする）
+func (b *s3Bucket) objectURL(key string) *url.URL {
+	u := *b.base
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + key
+	u.RawPath = awsEscapePath(u.Path)
+	return &u
+}
+
+func (b *s3Bucket) URL(key string) string {
+	return SchemeS3 + "://" + b.bucket + "/" + key
+}
+
+func (b *s3Bucket) Put(ctx context.Context, key string, r io.ReadSeeker) error {
+	size, err := seekSize(r)
+	if err != nil {
+		return fmt.Errorf("failed to read upload: %w", err)
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return fmt.Errorf("failed to read upload: %w", err)
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read upload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, b.objectURL(key).String(), io.NopCloser(r))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	b.sign(req, hex.EncodeToString(h.Sum(nil)))
+	resp, err := do(b.client, req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (b *s3Bucket) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.objectURL(key).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	b.sign(req, emptySHA256)
+	resp, err := do(b.client, req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (b *s3Bucket) Delete(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, b.objectURL(key).String(), nil)
+	if err != nil {
+		return err
+	}
+	b.sign(req, emptySHA256)
+	resp, err := do(b.client, req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// s3ListResult はListObjectsV2のレスポンス
+type s3ListResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+func (b *s3Bucket) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	token := ""
+	for {
+		u := *b.base
+		if u.Path == "" {
+			u.Path = "/"
+		}
+		q := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+		u.RawQuery = awsCanonicalQuery(q)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		b.sign(req, emptySHA256)
+		data, err := readAll(b.client, req)
+		if err != nil {
+			return nil, err
+		}
+		var result s3ListResult
+		if err := xml.Unmarshal(data, &result); err != nil {
+			return nil, fmt.Errorf("failed to parse S3 list response: %w", err)
+		}
+		for _, c := range result.Contents {
+			objects = append(objects, Object{Key: c.Key, Size: c.Size, LastModified: c.LastModified})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// sign はリクエストにSigV4の署名を付ける
+func (b *s3Bucket) sign(req *http.Request, payloadHash string) {
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	signV4(req, b.creds, b.region, "s3", payloadHash, time.Now())
+}
+
+// signV4 はAWS Signature Version 4でreqに署名する（hostとreqのすべてのヘッダーを署名に含める）
+func signV4(req *http.Request, creds *awsCredentials, region, service, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		trimmed := make([]string, len(values))
+		for i, v := range values {
+			trimmed[i] = strings.Join(strings.Fields(v), " ")
+		}
+		headers[strings.ToLower(name)] = strings.Join(trimmed, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		awsCanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	sum := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(sum[:])
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// awsCanonicalQuery はクエリをキー順に並べ、SigV4の規則でエスケープする
+func awsCanonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), q[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, awsEscape(k)+"="+awsEscape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// awsEscapePath はパスの各要素をエスケープする（"/" は残す）
+func awsEscapePath(p string) string {
+	parts := strings.Split(p, "/")
+	for i, part := range parts {
+		parts[i] = awsEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+// awsEscape は非予約文字（A-Z a-z 0-9 - _ . ~）以外をパーセントエンコードする
+func awsEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// readINI はAWSの共有ファイル（INI形式）をセクションごとに読む（読めなければ空）
+func readINI(path string) map[string]map[string]string {
+	sections := make(map[string]map[string]string)
+	if path == "" {
+		return sections
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return sections
+	}
+	defer f.Close()
+
+	var current map[string]string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			name := strings.Join(strings.Fields(line[1:len(line)-1]), " ")
+			if sections[name] == nil {
+				sections[name] = make(map[string]string)
+			}
+			current = sections[name]
+		default:
+			if k, v, ok := strings.Cut(line, "="); ok && current != nil {
+				current[strings.ToLower(strings.TrimSpace(k))] = strings.TrimSpace(v)
+			}
+		}
+	}
+	return sections
+}
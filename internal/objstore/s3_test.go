@@ -0,0 +1,249 @@
+package objstore
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSignV4 はAWSのドキュメントの署名例と一致することをテスト
+func TestSignV4(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	creds := &awsCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	signV4(req, creds, "us-east-1", "iam", emptySHA256, time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, " +
+		"SignedHeaders=content-type;host;x-amz-date, " +
+		"Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// fakeS3 はパス形式のS3 APIを模したサーバー（署名を検証する）
+func fakeS3(t *testing.T, bucket string, creds *awsCredentials) (*httptest.Server, *memObjects) {
+	objects := newMemObjects()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := verifyV4(r, creds); err != nil {
+			t.Errorf("%s %s: %v", r.Method, r.URL, err)
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		key, ok := strings.CutPrefix(r.URL.Path, "/"+bucket)
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		key = strings.TrimPrefix(key, "/")
+		switch {
+		case r.Method == http.MethodGet && key == "":
+			listS3(w, r, objects)
+		case r.Method == http.MethodPut:
+			data, _ := io.ReadAll(r.Body)
+			objects.put(key, data)
+		case r.Method == http.MethodGet:
+			data, ok := objects.get(key)
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, "<Error><Code>NoSuchKey</Code></Error>")
+				return
+			}
+			w.Write(data)
+		case r.Method == http.MethodDelete:
+			objects.delete(key)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, objects
+}
+
+// listS3 は1ページ1件でListObjectsV2に応答する（ページングの確認のため）
+func listS3(w http.ResponseWriter, r *http.Request, objects *memObjects) {
+	q := r.URL.Query()
+	keys := objects.list(q.Get("prefix"))
+	start := 0
+	if token := q.Get("continuation-token"); token != "" {
+		fmt.Sscanf(token, "%d", &start)
+	}
+	type content struct {
+		Key  string
+		Size int
+	}
+	result := struct {
+		XMLName               xml.Name `xml:"ListBucketResult"`
+		Contents              []content
+		IsTruncated           bool
+		NextContinuationToken string `xml:",omitempty"`
+	}{}
+	if start < len(keys) {
+		data, _ := objects.get(keys[start])
+		result.Contents = append(result.Contents, content{Key: keys[start], Size: len(data)})
+	}
+	if start+1 < len(keys) {
+		result.IsTruncated = true
+		result.NextContinuationToken = fmt.Sprint(start + 1)
+	}
+	xml.NewEncoder(w).Encode(result)
+}
+
+// verifyV4 は受け取ったリクエストを署名済みヘッダーだけで署名し直し、Authorizationと比べる
+func verifyV4(r *http.Request, creds *awsCredentials) error {
+	auth := r.Header.Get("Authorization")
+	_, signed, ok := strings.Cut(auth, "SignedHeaders=")
+	if !ok {
+		return fmt.Errorf("missing SigV4 authorization: %q", auth)
+	}
+	signed, _, _ = strings.Cut(signed, ",")
+	date, err := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+	if err != nil {
+		return err
+	}
+	u := *r.URL
+	check, _ := http.NewRequest(r.Method, "http://"+r.Host+u.RequestURI(), nil)
+	for _, name := range strings.Split(signed, ";") {
+		if name != "host" && name != "x-amz-date" {
+			check.Header.Set(name, r.Header.Get(name))
+		}
+	}
+	signV4(check, creds, "eu-west-1", "s3", r.Header.Get("X-Amz-Content-Sha256"), date)
+	if got := check.Header.Get("Authorization"); got != auth {
+		return fmt.Errorf("signature mismatch:\n got %s\nwant %s", auth, got)
+	}
+	return nil
+}
+
+func TestS3Bucket(t *testing.T) {
+	creds := &awsCredentials{AccessKeyID: "AKIDTEST", SecretAccessKey: "secret", SessionToken: "session"}
+	srv, objects := fakeS3(t, "team-backups", creds)
+	t.Setenv("AWS_ENDPOINT_URL_S3", srv.URL)
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", creds.AccessKeyID)
+	t.Setenv("AWS_SECRET_ACCESS_KEY", creds.SecretAccessKey)
+	t.Setenv("AWS_SESSION_TOKEN", creds.SessionToken)
+
+	loc, _ := Parse("s3://team-backups/backups/")
+	b, err := Open(context.Background(), loc)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	testRoundTrip(t, b, objects)
+	if got := b.URL("backups/x.tar.zst"); got != "s3://team-backups/backups/x.tar.zst" {
+		t.Errorf("URL = %s", got)
+	}
+}
+
+// TestOpenS3_SharedFiles はAWS_PROFILEの認証情報・リージョンを共有ファイルから読むことをテスト
+func TestOpenS3_SharedFiles(t *testing.T) {
+	dir := t.TempDir()
+	credsPath := filepath.Join(dir, "credentials")
+	configPath := filepath.Join(dir, "config")
+	os.WriteFile(credsPath, []byte("[default]\naws_access_key_id = AKIDDEFAULT\naws_secret_access_key = s1\n\n[team]\naws_access_key_id = AKIDTEAM\naws_secret_access_key = s2\n"), 0o600)
+	os.WriteFile(configPath, []byte("[profile team]\nregion = ap-northeast-1\n"), 0o600)
+	for _, name := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_REGION", "AWS_DEFAULT_REGION", "AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL", "AWS_WEB_IDENTITY_TOKEN_FILE"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credsPath)
+	t.Setenv("AWS_CONFIG_FILE", configPath)
+	t.Setenv("AWS_PROFILE", "team")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+
+	b, err := openS3(context.Background(), http.DefaultClient, "team-backups")
+	if err != nil {
+		t.Fatalf("openS3 failed: %v", err)
+	}
+	if b.creds.AccessKeyID != "AKIDTEAM" || b.region != "ap-northeast-1" {
+		t.Errorf("unexpected credentials or region: %+v %s", b.creds, b.region)
+	}
+	if got := b.objectURL("a b/c.tar.zst").String(); got != "https://team-backups.s3.ap-northeast-1.amazonaws.com/a%20b/c.tar.zst" {
+		t.Errorf("unexpected object URL: %s", got)
+	}
+
+	// 見つからなければエラー
+	t.Setenv("AWS_PROFILE", "missing")
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "")
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", "")
+	if _, err := openS3(context.Background(), http.DefaultClient, "team-backups"); err == nil {
+		t.Error("expected an error without credentials")
+	}
+}
+
+// TestOpenS3_ContainerCredentials はコンテナの認証情報エンドポイントから取得することをテスト
+func TestOpenS3_ContainerCredentials(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "container-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"AccessKeyId":"AKIDCONTAINER","SecretAccessKey":"s","Token":"t","Expiration":"2030-01-01T00:00:00Z"}`)
+	}))
+	defer srv.Close()
+	for _, name := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "AWS_WEB_IDENTITY_TOKEN_FILE"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "none"))
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "none"))
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", srv.URL+"/creds")
+	t.Setenv("AWS_CONTAINER_AUTHORIZATION_TOKEN", "container-token")
+
+	b, err := openS3(context.Background(), http.DefaultClient, "bucket")
+	if err != nil {
+		t.Fatalf("openS3 failed: %v", err)
+	}
+	if b.creds.AccessKeyID != "AKIDCONTAINER" || b.creds.SessionToken != "t" {
+		t.Errorf("unexpected credentials: %+v", b.creds)
+	}
+}
+
+// TestOpenS3_UnsupportedCredentials は対応していない認証情報の設定がエラーになり、
+// インスタンスプロファイルなど別の認証情報に進まないことをテスト
+func TestOpenS3_UnsupportedCredentials(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config")
+	os.WriteFile(configPath, []byte("[profile process]\ncredential_process = /bin/creds\n\n[profile sso]\nsso_session = team\n\n[profile role]\nrole_arn = arn:aws:iam::123456789012:role/backup\nsource_profile = default\n"), 0o600)
+	for _, name := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "AWS_CONTAINER_CREDENTIALS_FULL_URI", "AWS_WEB_IDENTITY_TOKEN_FILE"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "none"))
+	t.Setenv("AWS_CONFIG_FILE", configPath)
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+
+	for _, profile := range []string{"process", "sso", "role"} {
+		t.Setenv("AWS_PROFILE", profile)
+		if _, err := openS3(context.Background(), http.DefaultClient, "bucket"); !errors.Is(err, ErrUnsupportedCredentials) {
+			t.Errorf("profile %s: expected ErrUnsupportedCredentials, got %v", profile, err)
+		}
+	}
+
+	t.Setenv("AWS_PROFILE", "default")
+	t.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", filepath.Join(dir, "token"))
+	if _, err := openS3(context.Background(), http.DefaultClient, "bucket"); !errors.Is(err, ErrUnsupportedCredentials) {
+		t.Errorf("web identity: expected ErrUnsupportedCredentials, got %v", err)
+	}
+	// 静的なキーがあればそちらを使う
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDENV")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "s")
+	if _, err := openS3(context.Background(), http.DefaultClient, "bucket"); err != nil {
+		t.Errorf("openS3 with static keys failed: %v", err)
+	}
+}
+
+func TestAWSEscape(t *testing.T) {
+	if got := awsEscape("a b+c/d~"); got != "a%20b%2Bc%2Fd~" {
+		t.Errorf("awsEscape = %s", got)
+	}
+	if got := awsCanonicalQuery(url.Values{"prefix": {"a b"}, "list-type": {"2"}}); got != "list-type=2&prefix=a%20b" {
+		t.Errorf("awsCanonicalQuery = %s", got)
+	}
+}
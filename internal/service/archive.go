@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/store"
+)
+
+// archiveService はArchiveServiceの実装
+type archiveService struct {
+	store     store.Store
+	archive   *store.ColdStore
+	namespace string
+	afterDays int // OlderThanDays省略時の日数（archive.afterDays、0なら省略できない）
+}
+
+// NewArchiveService はArchiveServiceの新しいインスタンスを作成
+// afterDaysはArchiveRequest.OlderThanDaysを省略した場合の日数（設定のarchive.afterDays、なければ0）
+func NewArchiveService(s store.Store, archive *store.ColdStore, namespace string, afterDays int) ArchiveService {
+	return &archiveService{store: s, archive: archive, namespace: namespace, afterDays: afterDays}
+}
+
+// Archive は最終アクセスがOlderThanDays日より前のノートを埋め込みごとアーカイブに書き込んでから、ストアから削除する
+// アーカイブへの書き込みに失敗した場合はストアのノートを残す
+func (s *archiveService) Archive(ctx context.Context, req *ArchiveRequest) (*ArchiveResponse, error) {
+	days := req.OlderThanDays
+	if days == 0 {
+		days = s.afterDays
+	}
+	if days <= 0 {
+		return nil, ErrInvalidOlderThanDays
+	}
+	projectIDs, err := s.projectIDs(ctx, req.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	before := time.Now().UTC().AddDate(0, 0, -days)
+	resp := &ArchiveResponse{Namespace: s.namespace, Notes: []ArchivedNote{}}
+	for _, projectID := range projectIDs {
+		notes, err := s.store.ListStaleNotes(ctx, projectID, before)
+		if err != nil {
+			return resp, fmt.Errorf("project %s: failed to list notes: %w", projectID, err)
+		}
+		if len(notes) == 0 {
+			continue
+		}
+		if req.DryRun {
+			for _, note := range notes {
+				resp.Notes = append(resp.Notes, archivedNote(note, ""))
+			}
+			continue
+		}
+
+		archivedAt := store.Timestamp()
+		batch := make([]store.ArchivedNote, 0, len(notes))
+		for _, note := range notes {
+			embedding, err := s.store.GetEmbedding(ctx, note.ID)
+			if err != nil {
+				return resp, fmt.Errorf("note %s: failed to get embedding: %w", note.ID, err)
+			}
+			batch = append(batch, store.ArchivedNote{Note: note, Embedding: embedding, ArchivedAt: archivedAt})
+		}
+		if err := s.archive.Put(batch); err != nil {
+			return resp, fmt.Errorf("project %s: %w", projectID, err)
+		}
+		for _, note := range notes {
+			if err := s.store.Delete(ctx, note.ID); err != nil && !errors.Is(err, store.ErrNotFound) {
+				return resp, fmt.Errorf("note %s: failed to delete archived note: %w", note.ID, err)
+			}
+			resp.Notes = append(resp.Notes, archivedNote(note, archivedAt))
+		}
+	}
+	return resp, nil
+}
+
+// Restore はアーカイブのノートを保存していた埋め込みでストアに戻し、アーカイブから削除する
+// 戻したノートは最終アクセスを現在時刻にする（次のArchiveですぐに移さない）
+// ストアに同じIDのノートがある場合はストアのノートを残し、アーカイブの分だけを削除する
+func (s *archiveService) Restore(ctx context.Context, req *RestoreRequest) (*RestoreResponse, error) {
+	if len(req.IDs) == 0 {
+		return nil, ErrIDsRequired
+	}
+	archived, err := s.archive.Get(req.IDs)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &RestoreResponse{Restored: []string{}, NotFound: []string{}}
+	found := make(map[string]bool, len(archived))
+	for _, n := range archived {
+		found[n.Note.ID] = true
+		if _, err := s.store.Get(ctx, n.Note.ID); errors.Is(err, store.ErrNotFound) {
+			if err := s.store.AddNote(ctx, n.Note, n.Embedding); err != nil {
+				return resp, fmt.Errorf("note %s: failed to restore: %w", n.Note.ID, err)
+			}
+		} else if err != nil {
+			return resp, fmt.Errorf("note %s: failed to get note: %w", n.Note.ID, err)
+		}
+		resp.Restored = append(resp.Restored, n.Note.ID)
+	}
+	for _, id := range req.IDs {
+		if !found[id] {
+			resp.NotFound = append(resp.NotFound, id)
+		}
+	}
+	if len(resp.Restored) == 0 {
+		return resp, nil
+	}
+	if err := s.store.TouchNotes(ctx, resp.Restored, store.Timestamp()); err != nil {
+		return resp, fmt.Errorf("failed to record access of restored notes: %w", err)
+	}
+	if _, err := s.archive.Remove(resp.Restored); err != nil {
+		return resp, err
+	}
+	return resp, nil
+}
+
+// ListArchived はアーカイブのノートを返す（本文・埋め込みは含めない）
+func (s *archiveService) ListArchived(ctx context.Context, req *ListArchivedRequest) (*ListArchivedResponse, error) {
+	projectID := req.ProjectID
+	if projectID != "" {
+		var err error
+		projectID, err = config.CanonicalizeProjectID(projectID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to canonicalize projectId: %w", err)
+		}
+	}
+	archived, err := s.archive.List(projectID)
+	if err != nil {
+		return nil, err
+	}
+	resp := &ListArchivedResponse{Namespace: s.namespace, Notes: make([]ArchivedNote, len(archived))}
+	for i, n := range archived {
+		resp.Notes[i] = archivedNote(n.Note, n.ArchivedAt)
+	}
+	return resp, nil
+}
+
+// projectIDs は対象のプロジェクトを返す（projectIDが空ならストアの全プロジェクト）
+func (s *archiveService) projectIDs(ctx context.Context, projectID string) ([]string, error) {
+	if projectID != "" {
+		canonical, err := config.CanonicalizeProjectID(projectID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to canonicalize projectId: %w", err)
+		}
+		return []string{canonical}, nil
+	}
+	projects, err := s.store.ListProjects(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+	projectIDs := make([]string, len(projects))
+	for i, p := range projects {
+		projectIDs[i] = p.ProjectID
+	}
+	return projectIDs, nil
+}
+
+// archivedNote はノートのArchivedNoteを返す
+func archivedNote(note *model.Note, archivedAt string) ArchivedNote {
+	createdAt := ""
+	if note.CreatedAt != nil {
+		createdAt = *note.CreatedAt
+	}
+	return ArchivedNote{
+		ID:         note.ID,
+		ProjectID:  note.ProjectID,
+		GroupID:    note.GroupID,
+		Title:      note.Title,
+		CreatedAt:  createdAt,
+		ArchivedAt: archivedAt,
+	}
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/store"
+)
+
+func TestArchiveService(t *testing.T) {
+	ctx := context.Background()
+	const namespace = "test:mock:3"
+	st := store.NewMemoryStore()
+	if err := st.Initialize(ctx, namespace); err != nil {
+		t.Fatal(err)
+	}
+	cs := store.NewColdStore(t.TempDir(), namespace)
+	notes := NewNoteServiceWithArchive(&mockEmbedder{dim: 3}, st, cs, namespace)
+	svc := NewArchiveService(st, cs, namespace, 30)
+
+	old := "2024-01-01T00:00:00Z"
+	for _, id := range []string{"old", "read"} {
+		note := &model.Note{ID: id, ProjectID: "/test/project", GroupID: "global", Text: id, Tags: []string{}, CreatedAt: &old}
+		if err := st.AddNote(ctx, note, []float32{0.1, 0.2, 0.3}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := notes.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: "global", Text: "recent"}); err != nil {
+		t.Fatal(err)
+	}
+	// 取得したノートは最終アクセスが新しくなり、アーカイブの対象から外れる
+	if _, err := notes.Get(ctx, "read"); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := svc.Archive(ctx, &ArchiveRequest{DryRun: true})
+	if err != nil {
+		t.Fatalf("Archive failed: %v", err)
+	}
+	if len(resp.Notes) != 1 || resp.Notes[0].ID != "old" || resp.Notes[0].ArchivedAt != "" {
+		t.Fatalf("unexpected dry run: %+v", resp.Notes)
+	}
+	if _, err := st.Get(ctx, "old"); err != nil {
+		t.Errorf("dry run removed the note: %v", err)
+	}
+
+	resp, err = svc.Archive(ctx, &ArchiveRequest{ProjectID: "/test/project", OlderThanDays: 30})
+	if err != nil {
+		t.Fatalf("Archive failed: %v", err)
+	}
+	if len(resp.Notes) != 1 || resp.Notes[0].ArchivedAt == "" {
+		t.Fatalf("unexpected response: %+v", resp.Notes)
+	}
+	if _, err := st.Get(ctx, "old"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("expected the archived note to be removed from the store, got %v", err)
+	}
+	list, err := svc.ListArchived(ctx, &ListArchivedRequest{ProjectID: "/test/project"})
+	if err != nil || len(list.Notes) != 1 || list.Notes[0].ID != "old" {
+		t.Errorf("ListArchived = %+v, %v", list, err)
+	}
+
+	// 通常の検索には含めず、includeArchivedで含める
+	search, err := notes.Search(ctx, &SearchRequest{ProjectID: "/test/project", Query: "q"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(search.Results) != 2 {
+		t.Errorf("expected 2 results without archived notes, got %+v", search.Results)
+	}
+	search, err = notes.Search(ctx, &SearchRequest{ProjectID: "/test/project", Query: "q", IncludeArchived: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	archived := 0
+	for _, r := range search.Results {
+		if r.Archived {
+			archived++
+			if r.ID != "old" {
+				t.Errorf("unexpected archived result: %+v", r)
+			}
+		}
+	}
+	if len(search.Results) != 3 || archived != 1 {
+		t.Errorf("expected the archived note in the results, got %+v", search.Results)
+	}
+
+	restored, err := svc.Restore(ctx, &RestoreRequest{IDs: []string{"old", "missing"}})
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if len(restored.Restored) != 1 || len(restored.NotFound) != 1 || restored.NotFound[0] != "missing" {
+		t.Errorf("unexpected response: %+v", restored)
+	}
+	if embedding, err := st.GetEmbedding(ctx, "old"); err != nil || len(embedding) != 3 {
+		t.Errorf("expected the note to be restored with its embedding, got %v, %v", embedding, err)
+	}
+	if list, _ := svc.ListArchived(ctx, &ListArchivedRequest{}); len(list.Notes) != 0 {
+		t.Errorf("expected an empty archive, got %+v", list.Notes)
+	}
+	// 戻したノートはすぐには移さない
+	if resp, _ := svc.Archive(ctx, &ArchiveRequest{OlderThanDays: 30}); len(resp.Notes) != 0 {
+		t.Errorf("expected no notes to archive, got %+v", resp.Notes)
+	}
+}
+
+// TestNoteService_TouchPrunesExpired は最終アクセスの記録からtouchInterval以上前のものを取り除くことをテスト
+// TestArchiveService_Encryption は暗号化を有効にするとアーカイブのファイルにも平文を書かないことをテスト
+func TestArchiveService_Encryption(t *testing.T) {
+	ctx := context.Background()
+	const namespace = "test:mock:3"
+	keys := []store.EncryptionKey{{ID: "k1", Key: bytes.Repeat([]byte{1}, 32)}}
+	backend := store.NewMemoryStore()
+	if err := backend.Initialize(ctx, namespace); err != nil {
+		t.Fatal(err)
+	}
+	st, err := store.NewEncryptedStore(backend, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	cs, err := store.NewEncryptedColdStore(dir, namespace, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notes := NewNoteServiceWithArchive(&mockEmbedder{dim: 3}, st, cs, namespace)
+	svc := NewArchiveService(st, cs, namespace, 30)
+
+	old := "2024-01-01T00:00:00Z"
+	title := "secret title"
+	note := &model.Note{ID: "old", ProjectID: "/test/project", GroupID: "global", Title: &title, Text: "secret text", Tags: []string{}, Metadata: map[string]any{"owner": "secret owner"}, CreatedAt: &old}
+	if err := st.AddNote(ctx, note, []float32{0.1, 0.2, 0.3}); err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := svc.Archive(ctx, &ArchiveRequest{OlderThanDays: 30}); err != nil || len(resp.Notes) != 1 {
+		t.Fatalf("Archive = %+v, %v", resp, err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.gz"))
+	if len(files) != 1 {
+		t.Fatalf("expected 1 archive file, got %v", files)
+	}
+	f, err := os.Open(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), "secret") || !strings.Contains(string(raw), "mcpenc:v1:k1:") {
+		t.Errorf("expected only ciphertext in the archive, got %s", raw)
+	}
+
+	// 読み出しは復号する
+	list, err := svc.ListArchived(ctx, &ListArchivedRequest{ProjectID: "/test/project"})
+	if err != nil || len(list.Notes) != 1 || list.Notes[0].Title == nil || *list.Notes[0].Title != title {
+		t.Errorf("ListArchived = %+v, %v", list, err)
+	}
+	search, err := notes.Search(ctx, &SearchRequest{ProjectID: "/test/project", Query: "q", IncludeArchived: true})
+	if err != nil || len(search.Results) != 1 || search.Results[0].Text != "secret text" {
+		t.Errorf("unexpected search results: %+v, %v", search, err)
+	}
+	if _, err := svc.Restore(ctx, &RestoreRequest{IDs: []string{"old"}}); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if got, err := st.Get(ctx, "old"); err != nil || got.Text != "secret text" || got.Metadata["owner"] != "secret owner" {
+		t.Errorf("unexpected restored note: %+v, %v", got, err)
+	}
+	if stored, err := backend.Get(ctx, "old"); err != nil || !strings.HasPrefix(stored.Text, "mcpenc:v1:k1:") {
+		t.Errorf("expected the restored note to be encrypted in the store, got %+v, %v", stored, err)
+	}
+}
+
+func TestNoteService_TouchPrunesExpired(t *testing.T) {
+	ctx := context.Background()
+	const namespace = "test:mock:3"
+	st := store.NewMemoryStore()
+	if err := st.Initialize(ctx, namespace); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewNoteServiceWithArchive(&mockEmbedder{dim: 3}, st, store.NewColdStore(t.TempDir(), namespace), namespace).(*noteService)
+
+	now := time.Now().UTC()
+	svc.touched["deleted"] = now.Add(-2 * touchInterval)
+	svc.touched["recent"] = now.Add(-time.Minute)
+	svc.pruned = now.Add(-touchInterval)
+	svc.touch(ctx, []string{"read"})
+	if _, ok := svc.touched["deleted"]; ok {
+		t.Error("expected the expired entry to be pruned")
+	}
+	for _, id := range []string{"recent", "read"} {
+		if _, ok := svc.touched[id]; !ok {
+			t.Errorf("expected %s to be kept", id)
+		}
+	}
+
+	// 取り除くのはtouchIntervalごと
+	svc.touched["deleted"] = now.Add(-2 * touchInterval)
+	svc.touch(ctx, []string{"read"})
+	if _, ok := svc.touched["deleted"]; !ok {
+		t.Error("expected no prune within touchInterval of the last one")
+	}
+}
+
+func TestArchiveService_Validation(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemoryStore()
+	if err := st.Initialize(ctx, "test:mock:3"); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewArchiveService(st, store.NewColdStore(t.TempDir(), "test:mock:3"), "test:mock:3", 0)
+
+	// archive.afterDaysがなければolderThanDaysは必須
+	for _, days := range []int{0, -1} {
+		if _, err := svc.Archive(ctx, &ArchiveRequest{OlderThanDays: days}); !errors.Is(err, ErrInvalidOlderThanDays) {
+			t.Errorf("olderThanDays %d: expected ErrInvalidOlderThanDays, got %v", days, err)
+		}
+	}
+	if _, err := svc.Restore(ctx, &RestoreRequest{}); !errors.Is(err, ErrIDsRequired) {
+		t.Errorf("expected ErrIDsRequired, got %v", err)
+	}
+}
@@ -0,0 +1,39 @@
+package service
+
+// ItemStatus はバッチ処理（AddNotes・Import）の1件ごとの状態
+type ItemStatus string
+
+const (
+	ItemOK      ItemStatus = "ok"      // 書き込んだ
+	ItemFailed  ItemStatus = "failed"  // この項目で失敗した（ItemResult.Errが原因）
+	ItemPending ItemStatus = "pending" // 書き込んでいない（他の項目の失敗で中断した・取り消した）。そのまま再送できる
+)
+
+// ItemResult はバッチ処理の1件分の結果（リクエスト順）
+// 呼び出し側はStatusがItemOKでない項目だけを（failedは原因を直してから）再送すればよい
+type ItemResult struct {
+	Index  int    // リクエスト内の位置（Importは入力のレコード順。いずれも0始まり）
+	ID     string // AddNotesはノートのID、Importは"note <id>"などのレコード
+	Status ItemStatus
+	Err    error // Statusがfailedの場合の原因
+}
+
+// newItemResults はn件すべてをpendingにした結果を返す
+func newItemResults(n int) []ItemResult {
+	items := make([]ItemResult, n)
+	for i := range items {
+		items[i] = ItemResult{Index: i, Status: ItemPending}
+	}
+	return items
+}
+
+// RetryItems はitemsのうち書き込めなかった（okでない）項目を返す
+func RetryItems(items []ItemResult) []ItemResult {
+	var retry []ItemResult
+	for _, item := range items {
+		if item.Status != ItemOK {
+			retry = append(retry, item)
+		}
+	}
+	return retry
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/brbranch/embedding_mcp/internal/config"
 	"github.com/brbranch/embedding_mcp/internal/model"
@@ -28,59 +29,28 @@ func (s *configService) GetConfig(ctx context.Context) (*GetConfigResponse, erro
 		Embedder:          cfg.Embedder,
 		Store:             cfg.Store,
 		Paths:             cfg.Paths,
+		PreviousEmbedder:  cfg.PreviousEmbedder,
 	}, nil
 }
 
 // SetConfig は設定を変更する（embedderのみ変更可能）
+// provider/modelが変わる場合はnamespaceが変わり、既存ノートは再埋め込みが必要になる（ReembedRequired）
 func (s *configService) SetConfig(ctx context.Context, req *SetConfigRequest) (*SetConfigResponse, error) {
+	// 現在の設定を取得
+	cfg := s.manager.GetConfig()
+	prevNamespace := config.GenerateNamespace(cfg.Embedder.Provider, cfg.Embedder.Model, cfg.Embedder.Dim)
+
 	if req.Embedder == nil {
 		// 変更がない場合は現在のnamespaceを返す
-		cfg := s.manager.GetConfig()
-		namespace := config.GenerateNamespace(cfg.Embedder.Provider, cfg.Embedder.Model, cfg.Embedder.Dim)
 		return &SetConfigResponse{
 			OK:                 true,
-			EffectiveNamespace: namespace,
+			EffectiveNamespace: prevNamespace,
+			PreviousNamespace:  prevNamespace,
 		}, nil
 	}
 
-	// 現在の設定を取得
-	cfg := s.manager.GetConfig()
-
-	// provider/model変更時はdimをリセット
-	dimReset := false
-	if req.Embedder.Provider != nil && *req.Embedder.Provider != cfg.Embedder.Provider {
-		dimReset = true
-	}
-	if req.Embedder.Model != nil && *req.Embedder.Model != cfg.Embedder.Model {
-		dimReset = true
-	}
-
 	// パッチを適用
-	updatedEmbedder := &model.EmbedderConfig{
-		Provider: cfg.Embedder.Provider,
-		Model:    cfg.Embedder.Model,
-		Dim:      cfg.Embedder.Dim,
-		BaseURL:  cfg.Embedder.BaseURL,
-		APIKey:   cfg.Embedder.APIKey,
-	}
-
-	if req.Embedder.Provider != nil {
-		updatedEmbedder.Provider = *req.Embedder.Provider
-	}
-	if req.Embedder.Model != nil {
-		updatedEmbedder.Model = *req.Embedder.Model
-	}
-	if req.Embedder.BaseURL != nil {
-		updatedEmbedder.BaseURL = req.Embedder.BaseURL
-	}
-	if req.Embedder.APIKey != nil {
-		updatedEmbedder.APIKey = req.Embedder.APIKey
-	}
-
-	// dimリセットが必要な場合
-	if dimReset {
-		updatedEmbedder.Dim = 0
-	}
+	updatedEmbedder, dimReset := PatchEmbedder(&cfg.Embedder, req.Embedder)
 
 	// 設定を更新
 	if err := s.manager.UpdateEmbedder(updatedEmbedder); err != nil {
@@ -92,7 +62,6 @@ func (s *configService) SetConfig(ctx context.Context, req *SetConfigRequest) (*
 		if err := s.manager.UpdateDim(0); err != nil {
 			return nil, err
 		}
-		updatedEmbedder.Dim = 0
 	}
 
 	// 新しいnamespaceを生成
@@ -101,5 +70,69 @@ func (s *configService) SetConfig(ctx context.Context, req *SetConfigRequest) (*
 	return &SetConfigResponse{
 		OK:                 true,
 		EffectiveNamespace: namespace,
+		PreviousNamespace:  prevNamespace,
+		ReembedRequired:    dimReset,
 	}, nil
 }
+
+// AddProjectAlias はパスを論理的なprojectIdのエイリアスとしてprojects.jsonに登録し、以降の正規化に適用する
+func (s *configService) AddProjectAlias(ctx context.Context, req *AddProjectAliasRequest) (*AddProjectAliasResponse, error) {
+	if req.Path == "" {
+		return nil, ErrPathRequired
+	}
+	if req.ProjectID == "" {
+		return nil, ErrProjectIDRequired
+	}
+
+	path, err := config.CanonicalizePath(req.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to canonicalize path: %w", err)
+	}
+	// 設定ファイルのないManager（テスト用）では保存しない
+	if configPath := s.manager.GetConfigPath(); configPath != "" {
+		if err := config.AddProjectAlias(config.ProjectRegistryPath(configPath), path, req.ProjectID); err != nil {
+			return nil, fmt.Errorf("failed to save project alias: %w", err)
+		}
+	}
+	config.SetCanonicalizeRules(config.CurrentCanonicalizeRules().WithAliases(map[string]string{path: req.ProjectID}))
+
+	return &AddProjectAliasResponse{Path: path, ProjectID: req.ProjectID}, nil
+}
+
+// PatchEmbedder はcurにpatchを適用したembedder設定を返す（curは変更しない）
+// provider/modelが変わる場合はdimを0にリセットし、dimResetにtrueを返す
+func PatchEmbedder(cur *model.EmbedderConfig, patch *EmbedderPatch) (updated *model.EmbedderConfig, dimReset bool) {
+	// provider/model変更時はdimをリセット
+	if patch.Provider != nil && *patch.Provider != cur.Provider {
+		dimReset = true
+	}
+	if patch.Model != nil && *patch.Model != cur.Model {
+		dimReset = true
+	}
+
+	updated = &model.EmbedderConfig{
+		Provider:       cur.Provider,
+		Model:          cur.Model,
+		Dim:            cur.Dim,
+		BaseURL:        cur.BaseURL,
+		APIKey:         cur.APIKey,
+		APIKeyFrom:     cur.APIKeyFrom,
+		CoalesceWindow: cur.CoalesceWindow,
+	}
+	if patch.Provider != nil {
+		updated.Provider = *patch.Provider
+	}
+	if patch.Model != nil {
+		updated.Model = *patch.Model
+	}
+	if patch.BaseURL != nil {
+		updated.BaseURL = patch.BaseURL
+	}
+	if patch.APIKey != nil {
+		updated.APIKey = patch.APIKey
+	}
+	if dimReset {
+		updated.Dim = 0
+	}
+	return updated, dimReset
+}
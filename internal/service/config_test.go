@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"errors"
+	"path/filepath"
 	"testing"
 
 	"github.com/brbranch/embedding_mcp/internal/config"
@@ -195,6 +197,93 @@ func TestConfigService_SetConfig_NamespaceChange(t *testing.T) {
 	if resp.EffectiveNamespace != expectedNS {
 		t.Errorf("expected namespace %s, got %s", expectedNS, resp.EffectiveNamespace)
 	}
+	if resp.PreviousNamespace != "openai:text-embedding-3-small:1536" {
+		t.Errorf("expected previous namespace openai:text-embedding-3-small:1536, got %s", resp.PreviousNamespace)
+	}
+	if !resp.ReembedRequired {
+		t.Error("expected reembedRequired for a model change")
+	}
+}
+
+func TestConfigService_SetConfig_BaseURLOnly(t *testing.T) {
+	cfg := &model.Config{
+		Embedder: model.EmbedderConfig{
+			Provider: "openai",
+			Model:    "text-embedding-3-small",
+			Dim:      1536,
+		},
+	}
+
+	mgr := config.NewManagerWithConfig(cfg)
+	svc := newTestConfigService(mgr)
+
+	baseURL := "https://proxy.example.com/v1"
+	resp, err := svc.SetConfig(context.Background(), &SetConfigRequest{Embedder: &EmbedderPatch{BaseURL: &baseURL}})
+	if err != nil {
+		t.Fatalf("SetConfig failed: %v", err)
+	}
+
+	// 同じモデルのままなので再埋め込みは不要
+	if resp.ReembedRequired || resp.EffectiveNamespace != resp.PreviousNamespace {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
+
+func TestConfigService_AddProjectAlias(t *testing.T) {
+	t.Cleanup(func() { config.SetCanonicalizeRules(config.DefaultCanonicalizeRules) })
+	dir := t.TempDir()
+	mgr, err := config.NewManager(filepath.Join(dir, "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := newTestConfigService(mgr)
+	checkout, err := config.CanonicalizePath(filepath.Join(dir, "ci", "repo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := svc.AddProjectAlias(context.Background(), &AddProjectAliasRequest{Path: checkout + "/", ProjectID: "github.com/org/repo"})
+	if err != nil {
+		t.Fatalf("AddProjectAlias failed: %v", err)
+	}
+	if resp.Path != checkout || resp.ProjectID != "github.com/org/repo" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	// 以降の正規化に適用され、projects.jsonに保存される
+	if got, _ := config.CanonicalizeProjectID(checkout); got != "github.com/org/repo" {
+		t.Errorf("expected the alias to apply, got %q", got)
+	}
+	reg, err := config.LoadProjectRegistry(filepath.Join(dir, config.ProjectRegistryFile))
+	if err != nil || len(reg.Aliases) != 1 || reg.Aliases[0].Path != checkout {
+		t.Errorf("expected the alias in projects.json, got %+v, %v", reg, err)
+	}
+
+	if _, err := svc.AddProjectAlias(context.Background(), &AddProjectAliasRequest{ProjectID: "x"}); !errors.Is(err, ErrPathRequired) {
+		t.Errorf("expected ErrPathRequired, got %v", err)
+	}
+	if _, err := svc.AddProjectAlias(context.Background(), &AddProjectAliasRequest{Path: checkout}); !errors.Is(err, ErrProjectIDRequired) {
+		t.Errorf("expected ErrProjectIDRequired, got %v", err)
+	}
+}
+
+func TestPatchEmbedder(t *testing.T) {
+	key := "sk-test"
+	cur := &model.EmbedderConfig{Provider: "openai", Model: "text-embedding-3-small", Dim: 1536, APIKey: &key, APIKeyFrom: "keychain:openai"}
+
+	newModel := "text-embedding-3-large"
+	updated, dimReset := PatchEmbedder(cur, &EmbedderPatch{Model: &newModel})
+	if !dimReset || updated.Model != newModel || updated.Dim != 0 || updated.APIKey != &key || updated.APIKeyFrom != "keychain:openai" {
+		t.Errorf("unexpected patch result: %+v (dimReset=%v)", updated, dimReset)
+	}
+	if cur.Model != "text-embedding-3-small" || cur.Dim != 1536 {
+		t.Errorf("current config must not be modified: %+v", cur)
+	}
+
+	sameModel := "text-embedding-3-small"
+	if updated, dimReset := PatchEmbedder(cur, &EmbedderPatch{Model: &sameModel}); dimReset || updated.Dim != 1536 {
+		t.Errorf("expected no reset for the same model: %+v (dimReset=%v)", updated, dimReset)
+	}
 }
 
 func TestConfigService_SetConfig_NilPatch(t *testing.T) {
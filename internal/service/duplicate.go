@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/store"
+)
+
+// 類似ノートが既にある場合の扱い（AddNoteRequest.OnDuplicate）
+// 空文字列なら重複検出をしない
+const (
+	OnDuplicateReject  = "reject"  // 既存ノートのIDを添えたDuplicateNoteErrorを返す
+	OnDuplicateMerge   = "merge"   // 追加せず、既存ノートにタグをマージする
+	OnDuplicateProceed = "proceed" // そのまま追加し、既存ノートをレスポンスで知らせる
+)
+
+// defaultDuplicateThreshold はglobal.memory.duplicateThreshold未設定時の、重複とみなす類似度
+const defaultDuplicateThreshold = 0.95
+
+// DuplicateNoteError はonDuplicate=rejectで類似ノートが見つかったときのエラー
+// errors.Is(err, ErrDuplicateNote)で判定できる
+type DuplicateNoteError struct {
+	ID    string  // 既存ノートのID
+	Score float64 // 類似度（0-1）
+}
+
+func (e *DuplicateNoteError) Error() string {
+	return fmt.Sprintf("%s: %s (score: %.3f)", ErrDuplicateNote, e.ID, e.Score)
+}
+
+func (e *DuplicateNoteError) Unwrap() error {
+	return ErrDuplicateNote
+}
+
+// validateOnDuplicate はonDuplicateとduplicateThresholdを検証する
+func validateOnDuplicate(req *AddNoteRequest) error {
+	switch req.OnDuplicate {
+	case "", OnDuplicateReject, OnDuplicateMerge, OnDuplicateProceed:
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidOnDuplicate, req.OnDuplicate)
+	}
+	if t := req.DuplicateThreshold; t != nil && (*t < 0 || *t > 1) {
+		return fmt.Errorf("%w: %v", ErrInvalidThreshold, *t)
+	}
+	return nil
+}
+
+// findDuplicate はnoteと同じproject/groupで最も類似したノートを探す（閾値未満ならnil）
+func (s *noteService) findDuplicate(ctx context.Context, req *AddNoteRequest, note *model.Note, embedding []float32) (*store.SearchResult, error) {
+	var threshold float64
+	if req.DuplicateThreshold != nil {
+		threshold = *req.DuplicateThreshold
+	} else {
+		var err error
+		threshold, err = loadFraction(ctx, s.store, note.ProjectID, model.GlobalKeyDuplicateThreshold, defaultDuplicateThreshold)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	results, err := s.store.Search(ctx, embedding, store.SearchOptions{
+		ProjectID: note.ProjectID,
+		GroupID:   &note.GroupID,
+		TopK:      1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for duplicates: %w", err)
+	}
+	if len(results) == 0 || results[0].Score < threshold {
+		return nil, nil
+	}
+	return &results[0], nil
+}
+
+// mergeDuplicate は既存ノートにnoteのタグをマージする（増えなければ更新しない）
+func (s *noteService) mergeDuplicate(ctx context.Context, existing, note *model.Note) error {
+	tags := config.MergeTags(existing.Tags, note.Tags)
+	if len(tags) == len(existing.Tags) {
+		return nil
+	}
+	if err := CheckNoteLimits(nil, nil, tags, nil); err != nil {
+		return err
+	}
+	existing.Tags = tags
+	if err := s.store.Update(ctx, existing, nil); err != nil {
+		return fmt.Errorf("failed to merge tags into %s: %w", existing.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/store"
+)
+
+// newDuplicateTestService は"tabs"を含む本文を同じベクトルに埋め込むNoteServiceを作成する
+func newDuplicateTestService(t *testing.T) (*noteService, store.Store, string) {
+	t.Helper()
+	memStore := store.NewMemoryStore()
+	emb := &mockEmbedder{dim: 3, embedFunc: func(ctx context.Context, text string) ([]float32, error) {
+		if text == "use tabs" || text == "use tabs for indentation" {
+			return []float32{1, 0, 0}, nil
+		}
+		return []float32{0, 1, 0}, nil
+	}}
+	svc := newTestNoteService(emb, memStore, "openai:test:3")
+	resp, err := svc.AddNote(context.Background(), &AddNoteRequest{ProjectID: "/test/project", GroupID: "global", Text: "use tabs", Tags: []string{"style"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return svc, memStore, resp.ID
+}
+
+func TestNoteService_AddNote_OnDuplicate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("reject", func(t *testing.T) {
+		svc, _, existing := newDuplicateTestService(t)
+		_, err := svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: "global", Text: "use tabs for indentation", OnDuplicate: OnDuplicateReject})
+		var dupErr *DuplicateNoteError
+		if !errors.As(err, &dupErr) || !errors.Is(err, ErrDuplicateNote) {
+			t.Fatalf("expected DuplicateNoteError, got %v", err)
+		}
+		if dupErr.ID != existing || dupErr.Score < defaultDuplicateThreshold {
+			t.Errorf("unexpected duplicate: %+v", dupErr)
+		}
+
+		// 似ていないノート・別グループは追加できる
+		if _, err := svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: "global", Text: "wrap at 100", OnDuplicate: OnDuplicateReject}); err != nil {
+			t.Errorf("expected a dissimilar note to be added, got %v", err)
+		}
+		if _, err := svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: "other", Text: "use tabs", OnDuplicate: OnDuplicateReject}); err != nil {
+			t.Errorf("expected a note in another group to be added, got %v", err)
+		}
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		svc, memStore, existing := newDuplicateTestService(t)
+		resp, err := svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: "global", Text: "use tabs for indentation", Tags: []string{"go", "style"}, OnDuplicate: OnDuplicateMerge})
+		if err != nil {
+			t.Fatalf("AddNote failed: %v", err)
+		}
+		if resp.ID != existing || !resp.Merged || resp.DuplicateID != existing {
+			t.Errorf("expected a merge into %s, got %+v", existing, resp)
+		}
+		note, err := memStore.Get(ctx, existing)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(note.Tags) != 2 || note.Tags[0] != "style" || note.Tags[1] != "go" {
+			t.Errorf("expected merged tags [style go], got %v", note.Tags)
+		}
+		if note.Text != "use tabs" {
+			t.Errorf("expected the existing text to be kept, got %q", note.Text)
+		}
+	})
+
+	t.Run("proceed", func(t *testing.T) {
+		svc, _, existing := newDuplicateTestService(t)
+		resp, err := svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: "global", Text: "use tabs for indentation", OnDuplicate: OnDuplicateProceed})
+		if err != nil {
+			t.Fatalf("AddNote failed: %v", err)
+		}
+		if resp.ID == existing || resp.Merged || resp.DuplicateID != existing {
+			t.Errorf("expected a new note reporting %s, got %+v", existing, resp)
+		}
+	})
+
+	t.Run("threshold", func(t *testing.T) {
+		svc, memStore, _ := newDuplicateTestService(t)
+		// 直交するベクトルのスコアは0.5
+		low := 0.4
+		if _, err := svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: "global", Text: "wrap at 100", OnDuplicate: OnDuplicateReject, DuplicateThreshold: &low}); !errors.Is(err, ErrDuplicateNote) {
+			t.Errorf("expected ErrDuplicateNote with a low threshold, got %v", err)
+		}
+		upsertTestGlobal(t, memStore, "/test/project", model.GlobalKeyDuplicateThreshold, 0.4)
+		if _, err := svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: "global", Text: "wrap at 100", OnDuplicate: OnDuplicateReject}); !errors.Is(err, ErrDuplicateNote) {
+			t.Errorf("expected ErrDuplicateNote with the project's threshold, got %v", err)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		svc, _, _ := newDuplicateTestService(t)
+		if _, err := svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: "global", Text: "x", OnDuplicate: "skip"}); !errors.Is(err, ErrInvalidOnDuplicate) {
+			t.Errorf("expected ErrInvalidOnDuplicate, got %v", err)
+		}
+		high := 1.5
+		if _, err := svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: "global", Text: "x", OnDuplicate: OnDuplicateReject, DuplicateThreshold: &high}); !errors.Is(err, ErrInvalidThreshold) {
+			t.Errorf("expected ErrInvalidThreshold, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,510 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/embedder"
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/store"
+	"github.com/google/uuid"
+)
+
+// exportService はExportServiceの実装
+type exportService struct {
+	embedder  embedder.Embedder
+	store     store.Store
+	namespace string
+}
+
+// NewExportService はExportServiceの新しいインスタンスを作成
+func NewExportService(emb embedder.Embedder, s store.Store, namespace string) ExportService {
+	return &exportService{
+		embedder:  emb,
+		store:     s,
+		namespace: namespace,
+	}
+}
+
+// Export はプロジェクト内のグループ・グローバル設定・ノートをJSONLで書き出す
+func (s *exportService) Export(ctx context.Context, w io.Writer, req *ExportRequest) (*ExportResponse, error) {
+	// バリデーション
+	if req.ProjectID == "" {
+		return nil, ErrProjectIDRequired
+	}
+
+	// ProjectIDを正規化
+	projectID, err := config.CanonicalizeProjectID(req.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to canonicalize projectId: %w", err)
+	}
+
+	groups, err := s.store.ListGroups(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list groups: %w", err)
+	}
+	globals, err := s.store.ListGlobals(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list globals: %w", err)
+	}
+	notes, err := s.store.ListNotes(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notes: %w", err)
+	}
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+
+	header := &ExportRecord{
+		Type:       ExportRecordHeader,
+		Version:    ExportFormatVersion,
+		Namespace:  s.namespace,
+		ProjectID:  projectID,
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	if err := enc.Encode(header); err != nil {
+		return nil, fmt.Errorf("failed to write header: %w", err)
+	}
+
+	for _, group := range groups {
+		if err := enc.Encode(&ExportRecord{Type: ExportRecordGroup, Group: group}); err != nil {
+			return nil, fmt.Errorf("failed to write group: %w", err)
+		}
+	}
+	for _, global := range globals {
+		if err := enc.Encode(&ExportRecord{Type: ExportRecordGlobal, Global: global}); err != nil {
+			return nil, fmt.Errorf("failed to write global: %w", err)
+		}
+	}
+	for _, note := range notes {
+		record := &ExportRecord{Type: ExportRecordNote, Note: note}
+		if req.IncludeEmbeddings {
+			embedding, err := s.store.GetEmbedding(ctx, note.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get embedding for note %s: %w", note.ID, err)
+			}
+			record.Embedding = embedding
+		}
+		if err := enc.Encode(record); err != nil {
+			return nil, fmt.Errorf("failed to write note: %w", err)
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to flush export: %w", err)
+	}
+
+	return &ExportResponse{
+		Namespace: s.namespace,
+		ProjectID: projectID,
+		Notes:     len(notes),
+		Globals:   len(globals),
+		Groups:    len(groups),
+	}, nil
+}
+
+// Import はExportで書き出したJSONLを読み込み、指定プロジェクトに取り込む
+// レコードのprojectIdはreq.ProjectIDに置き換える。ImportModeFailでは衝突があれば何も書き込まない
+// ストアがトランザクションに対応していれば全件を1つのトランザクションで書き込み、途中で失敗すれば何も残さない（RolledBack）
+// 対応していなければ失敗したレコードまでの書き込みが残り、respの件数とFailedで報告する
+// レコードを読めた後の失敗ではrespも返し、Itemsでレコードごとの結果を報告する
+func (s *exportService) Import(ctx context.Context, r io.Reader, req *ImportRequest) (*ImportResponse, error) {
+	// バリデーション
+	if req.ProjectID == "" {
+		return nil, ErrProjectIDRequired
+	}
+	switch req.Mode {
+	case ImportModeFail, ImportModeSkipExisting, ImportModeOverwrite:
+	default:
+		return nil, ErrInvalidImportMode
+	}
+
+	// ProjectIDを正規化
+	projectID, err := config.CanonicalizeProjectID(req.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to canonicalize projectId: %w", err)
+	}
+
+	header, records, err := readExportRecords(r)
+	if err != nil {
+		return nil, err
+	}
+	// 埋め込みはnamespace（provider:model:dim）が一致する場合のみ再利用する
+	reuseEmbeddings := header != nil && header.Namespace == s.namespace
+
+	resp := &ImportResponse{
+		Namespace: s.namespace,
+		ProjectID: projectID,
+		Items:     newItemResults(len(records)),
+	}
+	for i, record := range records {
+		resp.Items[i].ID = describeRecord(record)
+	}
+
+	// 衝突チェック（書き込み前に全件確認）
+	if req.Mode == ImportModeFail {
+		var conflicts []string
+		for i, record := range records {
+			exists, err := s.exists(ctx, projectID, record)
+			if err != nil {
+				return resp, err
+			}
+			if exists {
+				resp.Items[i].Status, resp.Items[i].Err = ItemFailed, ErrImportConflict
+				conflicts = append(conflicts, describeRecord(record))
+			}
+		}
+		if len(conflicts) > 0 {
+			return resp, fmt.Errorf("%w: %s", ErrImportConflict, strings.Join(conflicts, ", "))
+		}
+	}
+
+	// 再利用できない埋め込みは書き込みの前にまとめて生成する（トランザクション中に埋め込みAPIを待たない）
+	embeddings, generated, err := s.embedRecords(ctx, projectID, records, reuseEmbeddings, req.Mode)
+	if err != nil {
+		return resp, err
+	}
+
+	atomic, err := store.WithTx(ctx, s.store, func(tx store.Store) error {
+		txs := &exportService{embedder: s.embedder, store: tx, namespace: s.namespace}
+		for i, record := range records {
+			var err error
+			switch record.Type {
+			case ExportRecordGroup:
+				err = txs.importGroup(ctx, projectID, record.Group, req.Mode, resp)
+			case ExportRecordGlobal:
+				err = txs.importGlobal(ctx, projectID, record.Global, req.Mode, resp)
+			case ExportRecordNote:
+				err = txs.importNote(ctx, projectID, record.Note, embeddings[i], generated[i], req.Mode, resp)
+			}
+			if err != nil {
+				resp.Failed = describeRecord(record)
+				resp.Items[i].Status, resp.Items[i].Err = ItemFailed, err
+				return err
+			}
+			resp.Items[i].Status = ItemOK
+		}
+		return nil
+	})
+	if err != nil {
+		if atomic {
+			// トランザクションごと取り消したため、1件も取り込んでいない
+			for i := range resp.Items {
+				if resp.Items[i].Status == ItemOK {
+					resp.Items[i].Status = ItemPending
+				}
+			}
+			*resp = ImportResponse{Namespace: s.namespace, ProjectID: projectID, Failed: resp.Failed, RolledBack: true, Items: resp.Items}
+		}
+		return resp, err
+	}
+
+	return resp, nil
+}
+
+// embedRecords はノートのレコードごとに書き込む埋め込みを返す（recordsと同じ順序、ノート以外はnil）
+// 再利用できる埋め込みはそのまま使い、それ以外はまとめて生成する（generatedがtrue）
+// ImportModeSkipExistingでスキップするノートと本文が空のノート（取り込み時にエラーになる）は生成しない
+func (s *exportService) embedRecords(ctx context.Context, projectID string, records []*ExportRecord, reuseEmbeddings bool, mode ImportMode) ([][]float32, []bool, error) {
+	embeddings := make([][]float32, len(records))
+	generated := make([]bool, len(records))
+	var (
+		indexes []int
+		texts   []string
+	)
+	for i, record := range records {
+		if record.Type != ExportRecordNote {
+			continue
+		}
+		if reuseEmbeddings && len(record.Embedding) > 0 {
+			embeddings[i] = record.Embedding
+			continue
+		}
+		if record.Note.Text == "" {
+			continue
+		}
+		if mode == ImportModeSkipExisting {
+			exists, err := s.exists(ctx, projectID, record)
+			if err != nil {
+				return nil, nil, err
+			}
+			if exists {
+				continue
+			}
+		}
+		indexes = append(indexes, i)
+		texts = append(texts, record.Note.Text)
+	}
+
+	vectors, err := embedder.EmbedBatch(ctx, s.embedder, texts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate embeddings: %w", err)
+	}
+	for j, i := range indexes {
+		embeddings[i] = vectors[j]
+		generated[i] = true
+	}
+	return embeddings, generated, nil
+}
+
+// readExportRecords はJSONLを読み込み、headerとデータレコード（group → global → note順）を返す
+func readExportRecords(r io.Reader) (*ExportRecord, []*ExportRecord, error) {
+	var (
+		header                 *ExportRecord
+		groups, globals, notes []*ExportRecord
+	)
+
+	dec := json.NewDecoder(r)
+	for n := 1; ; n++ {
+		var record ExportRecord
+		if err := dec.Decode(&record); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, nil, fmt.Errorf("%w: record %d: %v", ErrInvalidImportRecord, n, err)
+		}
+
+		switch {
+		case record.Type == ExportRecordHeader:
+			if record.Version > ExportFormatVersion {
+				return nil, nil, fmt.Errorf("%w: %d", ErrUnsupportedExport, record.Version)
+			}
+			header = &record
+		case record.Type == ExportRecordGroup && record.Group != nil:
+			groups = append(groups, &record)
+		case record.Type == ExportRecordGlobal && record.Global != nil:
+			globals = append(globals, &record)
+		case record.Type == ExportRecordNote && record.Note != nil:
+			notes = append(notes, &record)
+		default:
+			return nil, nil, fmt.Errorf("%w: record %d: unknown or empty record of type %q", ErrInvalidImportRecord, n, record.Type)
+		}
+	}
+
+	// noteのgroupIdやglobalの参照先が先に揃うよう種別順に並べる
+	records := append(append(groups, globals...), notes...)
+	return header, records, nil
+}
+
+// describeRecord はエラーメッセージ用にレコードを識別する文字列を返す
+func describeRecord(record *ExportRecord) string {
+	switch record.Type {
+	case ExportRecordGroup:
+		return "group " + record.Group.GroupKey
+	case ExportRecordGlobal:
+		return "global " + record.Global.Key
+	default:
+		return "note " + record.Note.ID
+	}
+}
+
+// exists はインポート先プロジェクトに同じレコードが存在するかを返す
+// groupはgroupKey、globalはkey、noteはIDで同一性を判定する
+func (s *exportService) exists(ctx context.Context, projectID string, record *ExportRecord) (bool, error) {
+	switch record.Type {
+	case ExportRecordGroup:
+		existing, err := s.existingGroup(ctx, projectID, record.Group.GroupKey)
+		return existing != nil, err
+	case ExportRecordGlobal:
+		_, found, err := s.store.GetGlobal(ctx, projectID, record.Global.Key)
+		if err != nil {
+			return false, fmt.Errorf("failed to check existing global: %w", err)
+		}
+		return found, nil
+	default:
+		existing, err := s.existingNote(ctx, record.Note.ID)
+		return existing != nil && existing.ProjectID == projectID, err
+	}
+}
+
+// existingGroup はgroupKeyでグループを取得する（存在しなければnil）
+func (s *exportService) existingGroup(ctx context.Context, projectID, groupKey string) (*model.Group, error) {
+	group, err := s.store.GetGroupByKey(ctx, projectID, groupKey)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing group: %w", err)
+	}
+	return group, nil
+}
+
+// existingNote はIDでノートを取得する（存在しなければnil）
+func (s *exportService) existingNote(ctx context.Context, id string) (*model.Note, error) {
+	if id == "" {
+		return nil, nil
+	}
+	note, err := s.store.Get(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing note: %w", err)
+	}
+	return note, nil
+}
+
+// importGroup はグループを1件取り込む
+func (s *exportService) importGroup(ctx context.Context, projectID string, group *model.Group, mode ImportMode, resp *ImportResponse) error {
+	group.ProjectID = projectID
+
+	existing, err := s.existingGroup(ctx, projectID, group.GroupKey)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		if mode != ImportModeOverwrite {
+			resp.Skipped++
+			return nil
+		}
+		group.ID = existing.ID
+		if err := group.Validate(); err != nil {
+			return fmt.Errorf("%w: group %s: %v", ErrInvalidImportRecord, group.GroupKey, err)
+		}
+		if err := s.store.UpdateGroup(ctx, group); err != nil {
+			return fmt.Errorf("failed to update group: %w", err)
+		}
+		resp.Updated++
+		return nil
+	}
+
+	// 別プロジェクトで同じIDが使われている場合（同一マシン上でのコピー等）は採番し直す
+	if group.ID == "" {
+		group.ID = uuid.New().String()
+	} else if _, err := s.store.GetGroup(ctx, group.ID); err == nil {
+		group.ID = uuid.New().String()
+	} else if !errors.Is(err, store.ErrNotFound) {
+		return fmt.Errorf("failed to check existing group: %w", err)
+	}
+	if err := group.Validate(); err != nil {
+		return fmt.Errorf("%w: group %s: %v", ErrInvalidImportRecord, group.GroupKey, err)
+	}
+	if err := s.store.AddGroup(ctx, group); err != nil {
+		return fmt.Errorf("failed to add group: %w", err)
+	}
+	resp.Created++
+	return nil
+}
+
+// importGlobal はグローバル設定を1件取り込む
+func (s *exportService) importGlobal(ctx context.Context, projectID string, global *model.GlobalConfig, mode ImportMode, resp *ImportResponse) error {
+	global.ProjectID = projectID
+
+	if err := model.ValidateGlobalKey(global.Key); err != nil {
+		return fmt.Errorf("%w: global %s: %v", ErrInvalidImportRecord, global.Key, err)
+	}
+
+	existing, found, err := s.store.GetGlobal(ctx, projectID, global.Key)
+	if err != nil {
+		return fmt.Errorf("failed to check existing global: %w", err)
+	}
+	if found {
+		if mode != ImportModeOverwrite {
+			resp.Skipped++
+			return nil
+		}
+		global.ID = existing.ID
+		if err := s.store.UpsertGlobal(ctx, global); err != nil {
+			return fmt.Errorf("failed to upsert global: %w", err)
+		}
+		resp.Updated++
+		return nil
+	}
+
+	// 別プロジェクトで同じIDが使われている場合は採番し直す
+	if global.ID == "" {
+		global.ID = uuid.New().String()
+	} else if _, err := s.store.GetGlobalByID(ctx, global.ID); err == nil {
+		global.ID = uuid.New().String()
+	} else if !errors.Is(err, store.ErrNotFound) {
+		return fmt.Errorf("failed to check existing global: %w", err)
+	}
+	if err := s.store.UpsertGlobal(ctx, global); err != nil {
+		return fmt.Errorf("failed to upsert global: %w", err)
+	}
+	resp.Created++
+	return nil
+}
+
+// importNote はノートを1件取り込む（embeddingが空なら再生成する。generatedはembeddingを再生成したものか）
+func (s *exportService) importNote(ctx context.Context, projectID string, note *model.Note, embedding []float32, generated bool, mode ImportMode, resp *ImportResponse) error {
+	note.ProjectID = projectID
+	if note.Tags == nil {
+		note.Tags = []string{}
+	}
+
+	existing, err := s.existingNote(ctx, note.ID)
+	if err != nil {
+		return err
+	}
+	update := false
+	if existing != nil {
+		if existing.ProjectID == projectID {
+			if mode != ImportModeOverwrite {
+				resp.Skipped++
+				return nil
+			}
+			update = true
+		} else {
+			// 別プロジェクトで同じIDが使われている場合は採番し直す
+			note.ID = newNoteID(projectID, note.GroupID)
+		}
+	}
+	if note.ID == "" {
+		note.ID = newNoteID(projectID, note.GroupID)
+	}
+
+	// 時刻はAddNoteと同じくUTCに揃える（エクスポート元が手で編集されたものでもストア間で並び順が変わらないようにする）
+	if note.CreatedAt != nil {
+		createdAt, err := NormalizeTimestamp(*note.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("%w: note %s: createdAt: %v", ErrInvalidImportRecord, note.ID, err)
+		}
+		note.CreatedAt = &createdAt
+	}
+	if note.UpdatedAt != nil {
+		t, err := time.Parse(time.RFC3339, *note.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("%w: note %s: updatedAt: %v", ErrInvalidImportRecord, note.ID, err)
+		}
+		if !strings.HasSuffix(*note.UpdatedAt, "Z") {
+			updatedAt := store.FormatTimestamp(t)
+			note.UpdatedAt = &updatedAt
+		}
+	}
+
+	if err := note.Validate(); err != nil {
+		return fmt.Errorf("%w: note %s: %v", ErrInvalidImportRecord, note.ID, err)
+	}
+
+	if len(embedding) == 0 {
+		embedding, err = s.embedder.Embed(ctx, note.Text)
+		if err != nil {
+			return fmt.Errorf("failed to generate embedding: %w", err)
+		}
+		generated = true
+	}
+
+	if update {
+		if err := s.store.Update(ctx, note, embedding); err != nil {
+			return fmt.Errorf("failed to update note: %w", err)
+		}
+		resp.Updated++
+	} else {
+		if err := s.store.AddNote(ctx, note, embedding); err != nil {
+			return fmt.Errorf("failed to add note: %w", err)
+		}
+		resp.Created++
+	}
+	if generated {
+		resp.ReEmbedded++
+	}
+	return nil
+}
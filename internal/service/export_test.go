@@ -0,0 +1,404 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/store"
+)
+
+func setupExportTestService(t *testing.T, namespace string) (ExportService, store.Store, *mockEmbedder) {
+	t.Helper()
+
+	st := store.NewMemoryStore()
+	if err := st.Initialize(context.Background(), namespace); err != nil {
+		t.Fatalf("failed to initialize store: %v", err)
+	}
+
+	emb := &mockEmbedder{dim: 3}
+	return NewExportService(emb, st, namespace), st, emb
+}
+
+// seedExportData はエクスポート元のテストデータを投入する
+func seedExportData(t *testing.T, st store.Store, projectID string) {
+	t.Helper()
+	ctx := context.Background()
+
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	if err := st.AddGroup(ctx, &model.Group{ID: "group-1", ProjectID: projectID, GroupKey: "feature-1", Title: "Feature 1", CreatedAt: now, UpdatedAt: now}); err != nil {
+		t.Fatalf("AddGroup failed: %v", err)
+	}
+	if err := st.UpsertGlobal(ctx, &model.GlobalConfig{ID: "global-1", ProjectID: projectID, Key: "global.project.conventions", Value: "use gofmt"}); err != nil {
+		t.Fatalf("UpsertGlobal failed: %v", err)
+	}
+	createdAt := "2024-01-15T10:00:00Z"
+	if err := st.AddNote(ctx, &model.Note{ID: "note-1", ProjectID: projectID, GroupID: "feature-1", Text: "first note", Tags: []string{"a"}, CreatedAt: &createdAt}, []float32{1, 0, 0}); err != nil {
+		t.Fatalf("AddNote failed: %v", err)
+	}
+}
+
+func TestExportService_Export(t *testing.T) {
+	ctx := context.Background()
+	svc, st, _ := setupExportTestService(t, "test:mock:3")
+	seedExportData(t, st, "/test/project")
+
+	var buf bytes.Buffer
+	resp, err := svc.Export(ctx, &buf, &ExportRequest{ProjectID: "/test/project", IncludeEmbeddings: true})
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if resp.Notes != 1 || resp.Globals != 1 || resp.Groups != 1 {
+		t.Errorf("unexpected counts: %+v", resp)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected 4 lines, got %d: %s", len(lines), buf.String())
+	}
+
+	wantTypes := []string{ExportRecordHeader, ExportRecordGroup, ExportRecordGlobal, ExportRecordNote}
+	for i, line := range lines {
+		var record ExportRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("line %d is not valid JSON: %v", i+1, err)
+		}
+		if record.Type != wantTypes[i] {
+			t.Errorf("line %d: expected type %s, got %s", i+1, wantTypes[i], record.Type)
+		}
+		if record.Type == ExportRecordHeader && record.Namespace != "test:mock:3" {
+			t.Errorf("expected header namespace test:mock:3, got %s", record.Namespace)
+		}
+		if record.Type == ExportRecordNote && len(record.Embedding) != 3 {
+			t.Errorf("expected embedding with 3 dims, got %v", record.Embedding)
+		}
+	}
+}
+
+func TestExportService_Export_WithoutEmbeddings(t *testing.T) {
+	ctx := context.Background()
+	svc, st, _ := setupExportTestService(t, "test:mock:3")
+	seedExportData(t, st, "/test/project")
+
+	var buf bytes.Buffer
+	if _, err := svc.Export(ctx, &buf, &ExportRequest{ProjectID: "/test/project"}); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if strings.Contains(buf.String(), `"embedding"`) {
+		t.Errorf("expected no embeddings in output: %s", buf.String())
+	}
+}
+
+func TestExportService_Export_ProjectIDRequired(t *testing.T) {
+	svc, _, _ := setupExportTestService(t, "test:mock:3")
+
+	_, err := svc.Export(context.Background(), &bytes.Buffer{}, &ExportRequest{})
+	if !errors.Is(err, ErrProjectIDRequired) {
+		t.Errorf("expected ErrProjectIDRequired, got %v", err)
+	}
+}
+
+func TestExportService_Import_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	src, srcStore, _ := setupExportTestService(t, "test:mock:3")
+	seedExportData(t, srcStore, "/src/project")
+
+	var buf bytes.Buffer
+	if _, err := src.Export(ctx, &buf, &ExportRequest{ProjectID: "/src/project", IncludeEmbeddings: true}); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	dst, dstStore, emb := setupExportTestService(t, "test:mock:3")
+	embedCalls := 0
+	emb.embedFunc = func(ctx context.Context, text string) ([]float32, error) {
+		embedCalls++
+		return []float32{0, 1, 0}, nil
+	}
+
+	resp, err := dst.Import(ctx, &buf, &ImportRequest{ProjectID: "/dst/project"})
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if resp.Created != 3 || resp.Skipped != 0 || resp.Updated != 0 {
+		t.Errorf("unexpected counts: %+v", resp)
+	}
+	// 同じnamespaceなので埋め込みは再利用される
+	if embedCalls != 0 || resp.ReEmbedded != 0 {
+		t.Errorf("expected embeddings to be reused, got %d embed calls", embedCalls)
+	}
+
+	note, err := dstStore.Get(ctx, "note-1")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if note.ProjectID != "/dst/project" {
+		t.Errorf("expected projectId /dst/project, got %s", note.ProjectID)
+	}
+	embedding, _ := dstStore.GetEmbedding(ctx, "note-1")
+	if len(embedding) != 3 || embedding[0] != 1 {
+		t.Errorf("expected original embedding, got %v", embedding)
+	}
+	if _, err := dstStore.GetGroupByKey(ctx, "/dst/project", "feature-1"); err != nil {
+		t.Errorf("expected group to be imported: %v", err)
+	}
+	if _, found, _ := dstStore.GetGlobal(ctx, "/dst/project", "global.project.conventions"); !found {
+		t.Error("expected global to be imported")
+	}
+}
+
+func TestExportService_Import_ReEmbedOnNamespaceMismatch(t *testing.T) {
+	ctx := context.Background()
+	src, srcStore, _ := setupExportTestService(t, "openai:other-model:3")
+	seedExportData(t, srcStore, "/test/project")
+
+	var buf bytes.Buffer
+	if _, err := src.Export(ctx, &buf, &ExportRequest{ProjectID: "/test/project", IncludeEmbeddings: true}); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	dst, dstStore, _ := setupExportTestService(t, "test:mock:3")
+	resp, err := dst.Import(ctx, &buf, &ImportRequest{ProjectID: "/test/project"})
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if resp.ReEmbedded != 1 {
+		t.Errorf("expected 1 re-embedded note, got %d", resp.ReEmbedded)
+	}
+	embedding, _ := dstStore.GetEmbedding(ctx, "note-1")
+	if len(embedding) != 3 || embedding[0] != 0.1 {
+		t.Errorf("expected re-generated embedding, got %v", embedding)
+	}
+}
+
+func TestExportService_Import_Modes(t *testing.T) {
+	ctx := context.Background()
+	svc, st, _ := setupExportTestService(t, "test:mock:3")
+	seedExportData(t, st, "/test/project")
+
+	var buf bytes.Buffer
+	if _, err := svc.Export(ctx, &buf, &ExportRequest{ProjectID: "/test/project"}); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	exported := buf.String()
+
+	// 既存データを変更しておく
+	note, _ := st.Get(ctx, "note-1")
+	note.Text = "changed"
+	if err := st.Update(ctx, note, []float32{0, 0, 1}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	t.Run("default mode fails on conflict", func(t *testing.T) {
+		resp, err := svc.Import(ctx, strings.NewReader(exported), &ImportRequest{ProjectID: "/test/project"})
+		if !errors.Is(err, ErrImportConflict) {
+			t.Errorf("expected ErrImportConflict, got %v", err)
+		}
+		// 衝突したレコードはすべてfailedとして報告する
+		if retry := RetryItems(resp.Items); len(retry) != 3 || retry[0].Status != ItemFailed || !errors.Is(retry[2].Err, ErrImportConflict) {
+			t.Errorf("expected all 3 records to fail on conflict, got %+v", resp.Items)
+		}
+	})
+
+	t.Run("skip-existing keeps data", func(t *testing.T) {
+		resp, err := svc.Import(ctx, strings.NewReader(exported), &ImportRequest{ProjectID: "/test/project", Mode: ImportModeSkipExisting})
+		if err != nil {
+			t.Fatalf("Import failed: %v", err)
+		}
+		if resp.Skipped != 3 || resp.Created != 0 {
+			t.Errorf("unexpected counts: %+v", resp)
+		}
+		got, _ := st.Get(ctx, "note-1")
+		if got.Text != "changed" {
+			t.Errorf("expected existing note to be kept, got %q", got.Text)
+		}
+	})
+
+	t.Run("overwrite replaces data", func(t *testing.T) {
+		resp, err := svc.Import(ctx, strings.NewReader(exported), &ImportRequest{ProjectID: "/test/project", Mode: ImportModeOverwrite})
+		if err != nil {
+			t.Fatalf("Import failed: %v", err)
+		}
+		if resp.Updated != 3 {
+			t.Errorf("unexpected counts: %+v", resp)
+		}
+		got, _ := st.Get(ctx, "note-1")
+		if got.Text != "first note" {
+			t.Errorf("expected note to be overwritten, got %q", got.Text)
+		}
+	})
+}
+
+func TestExportService_Import_IDCollisionAcrossProjects(t *testing.T) {
+	ctx := context.Background()
+	svc, st, _ := setupExportTestService(t, "test:mock:3")
+	seedExportData(t, st, "/src/project")
+
+	var buf bytes.Buffer
+	if _, err := svc.Export(ctx, &buf, &ExportRequest{ProjectID: "/src/project"}); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	// 同じストア内の別プロジェクトへコピー
+	resp, err := svc.Import(ctx, &buf, &ImportRequest{ProjectID: "/dst/project"})
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if resp.Created != 3 {
+		t.Errorf("unexpected counts: %+v", resp)
+	}
+
+	// 元のノートは残っている
+	original, err := st.Get(ctx, "note-1")
+	if err != nil || original.ProjectID != "/src/project" {
+		t.Errorf("expected original note to be kept in /src/project, got %+v, %v", original, err)
+	}
+	copied, _ := st.ListNotes(ctx, "/dst/project")
+	if len(copied) != 1 || copied[0].ID == "note-1" {
+		t.Errorf("expected 1 copied note with a new ID, got %+v", copied)
+	}
+}
+
+func TestExportService_Import_InvalidInput(t *testing.T) {
+	ctx := context.Background()
+	svc, _, _ := setupExportTestService(t, "test:mock:3")
+
+	tests := []struct {
+		name    string
+		input   string
+		req     *ImportRequest
+		errType error
+	}{
+		{
+			name:    "missing projectId",
+			input:   "",
+			req:     &ImportRequest{},
+			errType: ErrProjectIDRequired,
+		},
+		{
+			name:    "invalid mode",
+			input:   "",
+			req:     &ImportRequest{ProjectID: "/test/project", Mode: "replace"},
+			errType: ErrInvalidImportMode,
+		},
+		{
+			name:    "invalid json",
+			input:   "{not json}\n",
+			req:     &ImportRequest{ProjectID: "/test/project"},
+			errType: ErrInvalidImportRecord,
+		},
+		{
+			name:    "unknown record type",
+			input:   `{"type":"unknown"}` + "\n",
+			req:     &ImportRequest{ProjectID: "/test/project"},
+			errType: ErrInvalidImportRecord,
+		},
+		{
+			name:    "newer format version",
+			input:   `{"type":"header","version":99}` + "\n",
+			req:     &ImportRequest{ProjectID: "/test/project"},
+			errType: ErrUnsupportedExport,
+		},
+		{
+			name:    "invalid note",
+			input:   `{"type":"note","note":{"id":"n1","groupId":"global","text":""}}` + "\n",
+			req:     &ImportRequest{ProjectID: "/test/project"},
+			errType: ErrInvalidImportRecord,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.Import(ctx, strings.NewReader(tt.input), tt.req)
+			if !errors.Is(err, tt.errType) {
+				t.Errorf("expected %v, got %v", tt.errType, err)
+			}
+		})
+	}
+}
+
+// TestExportService_Import_Failure は途中のレコードで失敗した場合の報告をテスト
+// トランザクションに対応したストア（SQLite）では全件を取り消し、対応していないストアでは失敗までの件数を返す
+func TestExportService_Import_Failure(t *testing.T) {
+	ctx := context.Background()
+	input := `{"type":"group","group":{"groupKey":"feature-1","title":"Feature 1"}}` + "\n" +
+		`{"type":"note","note":{"id":"n1","groupId":"global","text":"first"}}` + "\n" +
+		`{"type":"note","note":{"id":"n2","groupId":"global","text":""}}` + "\n"
+
+	sqlite, err := store.NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlite.Close()
+
+	tests := []struct {
+		name        string
+		store       store.Store
+		wantCreated int
+		wantBack    bool
+	}{
+		{"sqlite rolls back", sqlite, 0, true},
+		{"memory reports partial import", store.NewMemoryStore(), 2, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.store.Initialize(ctx, "test:mock:3"); err != nil {
+				t.Fatal(err)
+			}
+			svc := NewExportService(&mockEmbedder{dim: 3}, tt.store, "test:mock:3")
+
+			resp, err := svc.Import(ctx, strings.NewReader(input), &ImportRequest{ProjectID: "/test/project"})
+			if !errors.Is(err, ErrInvalidImportRecord) {
+				t.Fatalf("expected ErrInvalidImportRecord, got %v", err)
+			}
+			if resp.RolledBack != tt.wantBack || resp.Created != tt.wantCreated || resp.Failed != "note n2" {
+				t.Errorf("unexpected response: %+v", resp)
+			}
+			// 取り消した場合は失敗したレコード以外がpending、取り消していなければそれより前がok
+			first := ItemOK
+			if tt.wantBack {
+				first = ItemPending
+			}
+			want := []ItemStatus{first, first, ItemFailed}
+			if len(resp.Items) != 3 || resp.Items[2].ID != "note n2" || !errors.Is(resp.Items[2].Err, ErrInvalidImportRecord) {
+				t.Fatalf("unexpected items: %+v", resp.Items)
+			}
+			for i, item := range resp.Items {
+				if item.Status != want[i] {
+					t.Errorf("items[%d]: expected %s, got %s", i, want[i], item.Status)
+				}
+			}
+
+			notes, _ := tt.store.ListNotes(ctx, "/test/project")
+			groups, _ := tt.store.ListGroups(ctx, "/test/project")
+			if len(notes)+len(groups) != tt.wantCreated {
+				t.Errorf("expected %d records in the store, got %d notes and %d groups", tt.wantCreated, len(notes), len(groups))
+			}
+		})
+	}
+}
+
+// TestExportService_Import_NormalizesTimestamps はcreatedAtをUTCに揃え、不正な形式のレコードを拒否することをテスト
+func TestExportService_Import_NormalizesTimestamps(t *testing.T) {
+	ctx := context.Background()
+	svc, st, _ := setupExportTestService(t, "test:mock:3")
+
+	input := `{"type":"note","note":{"id":"n1","groupId":"global","text":"first","createdAt":"2024-01-15T19:00:00+09:00","updatedAt":"2024-01-16T09:00:00.5+09:00"}}` + "\n"
+	if _, err := svc.Import(ctx, strings.NewReader(input), &ImportRequest{ProjectID: "/test/project"}); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	note, _ := st.Get(ctx, "n1")
+	if *note.CreatedAt != "2024-01-15T10:00:00Z" || *note.UpdatedAt != "2024-01-16T00:00:00.500Z" {
+		t.Errorf("expected timestamps in UTC, got %s, %s", *note.CreatedAt, *note.UpdatedAt)
+	}
+
+	input = `{"type":"note","note":{"id":"n2","groupId":"global","text":"second","createdAt":"2024-01-15 10:00"}}` + "\n"
+	if _, err := svc.Import(ctx, strings.NewReader(input), &ImportRequest{ProjectID: "/test/project"}); !errors.Is(err, ErrInvalidImportRecord) {
+		t.Errorf("expected ErrInvalidImportRecord, got %v", err)
+	}
+}
@@ -11,6 +11,9 @@ import (
 	"github.com/google/uuid"
 )
 
+// MaxGlobalHistory はグローバル設定ごとに残す過去の値の件数
+const MaxGlobalHistory = 20
+
 // globalService はGlobalServiceの実装
 type globalService struct {
 	store     store.Store
@@ -68,8 +71,14 @@ func (s *globalService) UpsertGlobal(ctx context.Context, req *UpsertGlobalReque
 	}
 
 	id := uuid.New().String()
+	var history []model.GlobalConfigVersion
 	if found {
 		id = existing.ID
+		// 上書きする値を履歴の先頭に残す（古いものから捨てる）
+		history = append([]model.GlobalConfigVersion{{Value: existing.Value, UpdatedAt: existing.UpdatedAt, UpdatedBy: existing.UpdatedBy}}, existing.History...)
+		if len(history) > MaxGlobalHistory {
+			history = history[:MaxGlobalHistory]
+		}
 	}
 
 	// GlobalConfigモデルの作成
@@ -79,6 +88,8 @@ func (s *globalService) UpsertGlobal(ctx context.Context, req *UpsertGlobalReque
 		Key:       req.Key,
 		Value:     req.Value,
 		UpdatedAt: updatedAt,
+		UpdatedBy: req.UpdatedBy,
+		History:   history,
 	}
 
 	// Storeにupsert
@@ -127,9 +138,39 @@ func (s *globalService) GetGlobal(ctx context.Context, projectID, key string) (*
 		ID:        &globalConfig.ID,
 		Value:     globalConfig.Value,
 		UpdatedAt: globalConfig.UpdatedAt,
+		UpdatedBy: globalConfig.UpdatedBy,
 	}, nil
 }
 
+// GetGlobalHistory はグローバル設定の上書きされる前の値を新しい順に取得する
+func (s *globalService) GetGlobalHistory(ctx context.Context, projectID, key string) (*GetGlobalHistoryResponse, error) {
+	// バリデーション
+	if projectID == "" {
+		return nil, ErrProjectIDRequired
+	}
+
+	// keyのプレフィックス検証（空文字は not found として扱う）
+	if key != "" && !strings.HasPrefix(key, "global.") {
+		return nil, ErrInvalidGlobalKey
+	}
+
+	// Storeから取得
+	globalConfig, found, err := s.store.GetGlobal(ctx, projectID, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get global: %w", err)
+	}
+
+	resp := &GetGlobalHistoryResponse{
+		Namespace: s.namespace,
+		Found:     found,
+		History:   []model.GlobalConfigVersion{},
+	}
+	if found && len(globalConfig.History) > 0 {
+		resp.History = globalConfig.History
+	}
+	return resp, nil
+}
+
 // DeleteByID はIDでグローバル設定を削除する
 func (s *globalService) DeleteByID(ctx context.Context, id string) error {
 	// バリデーション
@@ -147,3 +188,91 @@ func (s *globalService) DeleteByID(ctx context.Context, id string) error {
 
 	return nil
 }
+
+// ExportGlobals はプロジェクトのグローバル設定（global.*）をすべて取得する
+func (s *globalService) ExportGlobals(ctx context.Context, projectID string) (*ExportGlobalsResponse, error) {
+	// バリデーション
+	if projectID == "" {
+		return nil, ErrProjectIDRequired
+	}
+
+	globals, err := s.store.ListGlobals(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list globals: %w", err)
+	}
+
+	entries := make([]GlobalEntry, len(globals))
+	for i, g := range globals {
+		entries[i] = GlobalEntry{
+			Key:       g.Key,
+			Value:     g.Value,
+			UpdatedAt: g.UpdatedAt,
+			UpdatedBy: g.UpdatedBy,
+		}
+	}
+
+	return &ExportGlobalsResponse{
+		Namespace: s.namespace,
+		ProjectID: projectID,
+		Globals:   entries,
+	}, nil
+}
+
+// ImportGlobals はグローバル設定をプロジェクトに書き込む（ExportGlobalsの結果を別プロジェクトへコピーする用途）
+// 上書きする場合はUpsertGlobalと同じく前の値を履歴に残す
+func (s *globalService) ImportGlobals(ctx context.Context, req *ImportGlobalsRequest) (*ImportGlobalsResponse, error) {
+	// バリデーション
+	if req.ProjectID == "" {
+		return nil, ErrProjectIDRequired
+	}
+	switch req.Mode {
+	case ImportModeFail, ImportModeSkipExisting, ImportModeOverwrite:
+	default:
+		return nil, ErrInvalidImportMode
+	}
+	for i, entry := range req.Globals {
+		if err := model.ValidateGlobalKey(entry.Key); err != nil {
+			return nil, fmt.Errorf("%w: globals[%d]: %v", ErrInvalidGlobalKey, i, err)
+		}
+	}
+
+	// 既にあるkeyを確認する（ImportModeFailなら書き込む前にエラー）
+	exists := make(map[string]bool)
+	var conflicts []string
+	for _, entry := range req.Globals {
+		_, found, err := s.store.GetGlobal(ctx, req.ProjectID, entry.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check existing global: %w", err)
+		}
+		if found && !exists[entry.Key] {
+			conflicts = append(conflicts, entry.Key)
+		}
+		exists[entry.Key] = found
+	}
+	if req.Mode == ImportModeFail && len(conflicts) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrImportConflict, strings.Join(conflicts, ", "))
+	}
+
+	resp := &ImportGlobalsResponse{Namespace: s.namespace, ProjectID: req.ProjectID}
+	for _, entry := range req.Globals {
+		if exists[entry.Key] && req.Mode == ImportModeSkipExisting {
+			resp.Skipped++
+			continue
+		}
+		if _, err := s.UpsertGlobal(ctx, &UpsertGlobalRequest{
+			ProjectID: req.ProjectID,
+			Key:       entry.Key,
+			Value:     entry.Value,
+			UpdatedBy: entry.UpdatedBy,
+		}); err != nil {
+			return resp, err
+		}
+		if exists[entry.Key] {
+			resp.Updated++
+		} else {
+			resp.Created++
+		}
+	}
+
+	return resp, nil
+}
@@ -3,7 +3,9 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/brbranch/embedding_mcp/internal/store"
 )
@@ -252,4 +254,128 @@ func TestGlobalService_UpsertGlobal_StandardKeys(t *testing.T) {
 			t.Errorf("UpsertGlobal failed for key %s: %v", key, err)
 		}
 	}
-}
\ No newline at end of file
+}
+
+func TestGlobalService_GetGlobalHistory(t *testing.T) {
+	ctx := context.Background()
+	memStore := store.NewMemoryStore()
+	svc := newTestGlobalService(memStore, "openai:test:3")
+
+	resp, err := svc.GetGlobalHistory(ctx, "/test/project", "global.project.conventions")
+	if err != nil {
+		t.Fatalf("GetGlobalHistory failed: %v", err)
+	}
+	if resp.Found || len(resp.History) != 0 {
+		t.Errorf("expected no history for a missing key, got %+v", resp)
+	}
+
+	for i := 0; i < MaxGlobalHistory+2; i++ {
+		updatedAt := time.Date(2024, 1, 1, 0, 0, i, 0, time.UTC).Format(time.RFC3339)
+		if _, err := svc.UpsertGlobal(ctx, &UpsertGlobalRequest{ProjectID: "/test/project", Key: "global.project.conventions", Value: fmt.Sprintf("v%d", i), UpdatedAt: &updatedAt, UpdatedBy: fmt.Sprintf("user-%d", i)}); err != nil {
+			t.Fatalf("UpsertGlobal failed: %v", err)
+		}
+	}
+
+	current, err := svc.GetGlobal(ctx, "/test/project", "global.project.conventions")
+	if err != nil {
+		t.Fatalf("GetGlobal failed: %v", err)
+	}
+	if current.Value != fmt.Sprintf("v%d", MaxGlobalHistory+1) || current.UpdatedBy != fmt.Sprintf("user-%d", MaxGlobalHistory+1) {
+		t.Errorf("unexpected current value: %v by %s", current.Value, current.UpdatedBy)
+	}
+
+	// 新しい順で上限件数まで。現在の値は含まず、最も古い値は捨てられる
+	resp, err = svc.GetGlobalHistory(ctx, "/test/project", "global.project.conventions")
+	if err != nil {
+		t.Fatalf("GetGlobalHistory failed: %v", err)
+	}
+	if !resp.Found || len(resp.History) != MaxGlobalHistory {
+		t.Fatalf("expected %d history entries, got %+v", MaxGlobalHistory, resp)
+	}
+	first, last := resp.History[0], resp.History[MaxGlobalHistory-1]
+	if first.Value != fmt.Sprintf("v%d", MaxGlobalHistory) || first.UpdatedBy != fmt.Sprintf("user-%d", MaxGlobalHistory) || *first.UpdatedAt != "2024-01-01T00:00:20Z" {
+		t.Errorf("unexpected newest history entry: %+v", first)
+	}
+	if last.Value != "v1" {
+		t.Errorf("expected the oldest entry to be dropped, got %v", last.Value)
+	}
+
+	if _, err := svc.GetGlobalHistory(ctx, "/test/project", "project.conventions"); !errors.Is(err, ErrInvalidGlobalKey) {
+		t.Errorf("expected ErrInvalidGlobalKey, got %v", err)
+	}
+}
+
+func TestGlobalService_ExportImportGlobals(t *testing.T) {
+	ctx := context.Background()
+	memStore := store.NewMemoryStore()
+	svc := newTestGlobalService(memStore, "openai:test:3")
+
+	for key, value := range map[string]any{"global.project.conventions": "tabs", "global.memory.autoCreateGroup": true} {
+		if _, err := svc.UpsertGlobal(ctx, &UpsertGlobalRequest{ProjectID: "/test/src", Key: key, Value: value, UpdatedBy: "alice"}); err != nil {
+			t.Fatalf("UpsertGlobal failed: %v", err)
+		}
+	}
+	if _, err := svc.UpsertGlobal(ctx, &UpsertGlobalRequest{ProjectID: "/test/dst", Key: "global.project.conventions", Value: "spaces"}); err != nil {
+		t.Fatalf("UpsertGlobal failed: %v", err)
+	}
+
+	exported, err := svc.ExportGlobals(ctx, "/test/src")
+	if err != nil {
+		t.Fatalf("ExportGlobals failed: %v", err)
+	}
+	if len(exported.Globals) != 2 || exported.ProjectID != "/test/src" || exported.Globals[0].UpdatedBy != "alice" {
+		t.Fatalf("unexpected export: %+v", exported)
+	}
+
+	// 既存のkeyがあれば何も書き込まずにエラー
+	req := &ImportGlobalsRequest{ProjectID: "/test/dst", Globals: exported.Globals}
+	if _, err := svc.ImportGlobals(ctx, req); !errors.Is(err, ErrImportConflict) {
+		t.Fatalf("expected ErrImportConflict, got %v", err)
+	}
+	if resp, _ := svc.GetGlobal(ctx, "/test/dst", "global.memory.autoCreateGroup"); resp.Found {
+		t.Error("expected nothing to be imported on conflict")
+	}
+
+	req.Mode = ImportModeSkipExisting
+	resp, err := svc.ImportGlobals(ctx, req)
+	if err != nil {
+		t.Fatalf("ImportGlobals failed: %v", err)
+	}
+	if resp.Created != 1 || resp.Skipped != 1 || resp.Updated != 0 {
+		t.Errorf("unexpected skip-existing result: %+v", resp)
+	}
+	if got, _ := svc.GetGlobal(ctx, "/test/dst", "global.project.conventions"); got.Value != "spaces" {
+		t.Errorf("expected the existing value to be kept, got %v", got.Value)
+	}
+
+	// 上書きした値は履歴に残る
+	req.Mode = ImportModeOverwrite
+	resp, err = svc.ImportGlobals(ctx, req)
+	if err != nil {
+		t.Fatalf("ImportGlobals failed: %v", err)
+	}
+	if resp.Updated != 2 || resp.Created != 0 {
+		t.Errorf("unexpected overwrite result: %+v", resp)
+	}
+	history, _ := svc.GetGlobalHistory(ctx, "/test/dst", "global.project.conventions")
+	if len(history.History) == 0 || history.History[len(history.History)-1].Value != "spaces" {
+		t.Errorf("expected the overwritten value in history, got %+v", history.History)
+	}
+
+	tests := []struct {
+		req  *ImportGlobalsRequest
+		want error
+	}{
+		{&ImportGlobalsRequest{}, ErrProjectIDRequired},
+		{&ImportGlobalsRequest{ProjectID: "/test/dst", Mode: "replace"}, ErrInvalidImportMode},
+		{&ImportGlobalsRequest{ProjectID: "/test/dst", Globals: []GlobalEntry{{Key: "project.conventions"}}}, ErrInvalidGlobalKey},
+	}
+	for _, tt := range tests {
+		if _, err := svc.ImportGlobals(ctx, tt.req); !errors.Is(err, tt.want) {
+			t.Errorf("expected %v, got %v", tt.want, err)
+		}
+	}
+	if _, err := svc.ExportGlobals(ctx, ""); !errors.Is(err, ErrProjectIDRequired) {
+		t.Errorf("expected ErrProjectIDRequired, got %v", err)
+	}
+}
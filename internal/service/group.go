@@ -3,6 +3,8 @@ package service
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/brbranch/embedding_mcp/internal/config"
@@ -42,6 +44,9 @@ func (s *groupService) CreateGroup(ctx context.Context, req *CreateGroupRequest)
 	if err := model.ValidateGroupKeyForCreate(req.GroupKey); err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrInvalidGroupKey, err)
 	}
+	if err := model.ValidateGroupStatus(req.Status); err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidGroupStatus, req.Status)
+	}
 
 	// ProjectIDを正規化
 	canonicalProjectID, err := config.CanonicalizeProjectID(req.ProjectID)
@@ -58,6 +63,16 @@ func (s *groupService) CreateGroup(ctx context.Context, req *CreateGroupRequest)
 	if err != store.ErrNotFound {
 		return nil, fmt.Errorf("failed to check group key uniqueness: %w", err)
 	}
+	if err := checkParentGroup(ctx, s.store, canonicalProjectID, req.GroupKey, req.ParentGroupID); err != nil {
+		return nil, err
+	}
+	prefixes, err := loadGroupPrefixes(ctx, s.store, canonicalProjectID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkGroupKeyPrefix(req.GroupKey, prefixes); err != nil {
+		return nil, err
+	}
 
 	// IDと時刻の生成
 	id := uuid.New().String()
@@ -65,13 +80,17 @@ func (s *groupService) CreateGroup(ctx context.Context, req *CreateGroupRequest)
 
 	// Groupモデルの作成
 	group := &model.Group{
-		ID:          id,
-		ProjectID:   canonicalProjectID,
-		GroupKey:    req.GroupKey,
-		Title:       req.Title,
-		Description: req.Description,
-		CreatedAt:   now,
-		UpdatedAt:   now,
+		ID:            id,
+		ProjectID:     canonicalProjectID,
+		GroupKey:      req.GroupKey,
+		Title:         req.Title,
+		Description:   req.Description,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		Tags:          NormalizeTags(req.Tags),
+		Status:        groupStatus(req.Status),
+		Metadata:      req.Metadata,
+		ParentGroupID: req.ParentGroupID,
 	}
 
 	// Storeに保存
@@ -102,14 +121,18 @@ func (s *groupService) GetGroup(ctx context.Context, id string) (*GetGroupRespon
 	}
 
 	return &GetGroupResponse{
-		ID:          group.ID,
-		ProjectID:   group.ProjectID,
-		GroupKey:    group.GroupKey,
-		Title:       group.Title,
-		Description: group.Description,
-		CreatedAt:   group.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:   group.UpdatedAt.Format(time.RFC3339),
-		Namespace:   s.namespace,
+		ID:            group.ID,
+		ProjectID:     group.ProjectID,
+		GroupKey:      group.GroupKey,
+		Title:         group.Title,
+		Description:   group.Description,
+		CreatedAt:     group.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:     group.UpdatedAt.Format(time.RFC3339),
+		Namespace:     s.namespace,
+		Tags:          group.Tags,
+		Status:        groupStatus(group.Status),
+		Metadata:      group.Metadata,
+		ParentGroupID: group.ParentGroupID,
 	}, nil
 }
 
@@ -136,6 +159,27 @@ func (s *groupService) UpdateGroup(ctx context.Context, req *UpdateGroupRequest)
 	if req.Patch.Description != nil {
 		group.Description = *req.Patch.Description
 	}
+	if req.Patch.Tags != nil {
+		group.Tags = NormalizeTags(*req.Patch.Tags)
+	}
+	if req.Patch.Status != nil {
+		if err := model.ValidateGroupStatus(*req.Patch.Status); err != nil {
+			return fmt.Errorf("%w: %q", ErrInvalidGroupStatus, *req.Patch.Status)
+		}
+		group.Status = groupStatus(*req.Patch.Status)
+	}
+	if req.Patch.Metadata != nil {
+		group.Metadata = nil
+		if len(*req.Patch.Metadata) > 0 {
+			group.Metadata = *req.Patch.Metadata
+		}
+	}
+	if req.Patch.ParentGroupID != nil {
+		if err := checkParentGroup(ctx, s.store, group.ProjectID, group.GroupKey, *req.Patch.ParentGroupID); err != nil {
+			return err
+		}
+		group.ParentGroupID = *req.Patch.ParentGroupID
+	}
 
 	// UpdatedAtを更新
 	group.UpdatedAt = time.Now().UTC()
@@ -148,22 +192,125 @@ func (s *groupService) UpdateGroup(ctx context.Context, req *UpdateGroupRequest)
 	return nil
 }
 
-// DeleteGroup はグループを削除する
-func (s *groupService) DeleteGroup(ctx context.Context, id string) error {
+// DeleteGroup はグループを削除する。グループのノートはreq.Cascadeに従って削除・移動する
+// 途中で失敗した場合もレスポンスを返し、取り消したか（RolledBack）・どこまで反映したか（Notes）を示す
+func (s *groupService) DeleteGroup(ctx context.Context, req *DeleteGroupRequest) (*DeleteGroupResponse, error) {
 	// バリデーション
-	if id == "" {
-		return ErrIDRequired
+	if req.ID == "" {
+		return nil, ErrIDRequired
+	}
+	switch req.Cascade {
+	case GroupCascadeNone, GroupCascadeDelete, GroupCascadeMove, GroupCascadeRestrict:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidCascade, req.Cascade)
 	}
 
-	// Storeから削除
-	if err := s.store.DeleteGroup(ctx, id); err != nil {
+	group, err := s.store.GetGroup(ctx, req.ID)
+	if err != nil {
 		if err == store.ErrNotFound {
-			return ErrGroupNotFound
+			return nil, ErrGroupNotFound
 		}
-		return fmt.Errorf("failed to delete group: %w", err)
+		return nil, fmt.Errorf("failed to get group: %w", err)
 	}
 
-	return nil
+	// グループのノートの処理とグループの削除（トランザクションに対応したストアでは、グループを削除できなければノートも元に戻す）
+	resp := &DeleteGroupResponse{}
+	atomic, err := store.WithTx(ctx, s.store, func(tx store.Store) error {
+		var err error
+		switch req.Cascade {
+		case GroupCascadeDelete:
+			resp.Notes, err = tx.DeleteNotesByGroup(ctx, group.ProjectID, group.GroupKey)
+			if err != nil {
+				resp.Failed = "notes"
+				return fmt.Errorf("failed to delete notes of group: %w", err)
+			}
+		case GroupCascadeMove:
+			resp.Notes, err = tx.MoveNotesToGroup(ctx, group.ProjectID, group.GroupKey, model.GlobalGroupID)
+			if err != nil {
+				resp.Failed = "notes"
+				return fmt.Errorf("failed to move notes of group: %w", err)
+			}
+		case GroupCascadeRestrict:
+			groupID := group.GroupKey
+			notes, err := tx.ListRecent(ctx, store.ListOptions{ProjectID: group.ProjectID, GroupID: &groupID, Limit: 1})
+			if err != nil {
+				return fmt.Errorf("failed to check notes of group: %w", err)
+			}
+			if len(notes) > 0 {
+				return fmt.Errorf("%w: %s", ErrGroupHasNotes, group.GroupKey)
+			}
+		}
+
+		if err := tx.DeleteGroup(ctx, req.ID); err != nil {
+			resp.Failed = "group"
+			if err == store.ErrNotFound {
+				return ErrGroupNotFound
+			}
+			return fmt.Errorf("failed to delete group: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		// トランザクションで取り消した場合はノートも元に戻っている。そうでなければresp.Notesの件数は削除・移動済み
+		if atomic {
+			*resp = DeleteGroupResponse{Failed: resp.Failed, RolledBack: true}
+		}
+		return resp, err
+	}
+
+	return resp, nil
+}
+
+// RenameGroup はグループのgroupKeyを変更し、そのグループのノートと子グループも新しいgroupKeyへ付け替える
+func (s *groupService) RenameGroup(ctx context.Context, req *RenameGroupRequest) (*RenameGroupResponse, error) {
+	// バリデーション
+	if req.ID == "" {
+		return nil, ErrIDRequired
+	}
+	if req.GroupKey == "" {
+		return nil, ErrGroupKeyRequired
+	}
+	if err := model.ValidateGroupKeyForCreate(req.GroupKey); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidGroupKey, err)
+	}
+
+	group, err := s.store.GetGroup(ctx, req.ID)
+	if err != nil {
+		if err == store.ErrNotFound {
+			return nil, ErrGroupNotFound
+		}
+		return nil, fmt.Errorf("failed to get group: %w", err)
+	}
+	if group.GroupKey == req.GroupKey {
+		return &RenameGroupResponse{}, nil
+	}
+	prefixes, err := loadGroupPrefixes(ctx, s.store, group.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkGroupKeyPrefix(req.GroupKey, prefixes); err != nil {
+		return nil, err
+	}
+
+	// 重複チェック（同一プロジェクト内でgroupKeyが一意）
+	_, err = s.store.GetGroupByKey(ctx, group.ProjectID, req.GroupKey)
+	if err == nil {
+		return nil, ErrGroupKeyExists
+	}
+	if err != store.ErrNotFound {
+		return nil, fmt.Errorf("failed to check group key uniqueness: %w", err)
+	}
+
+	// 途中で失敗した場合も、書き換え済みのノート数をレスポンスで返す
+	notes, err := s.store.RenameGroup(ctx, req.ID, req.GroupKey)
+	if err != nil {
+		if err == store.ErrNotFound {
+			return &RenameGroupResponse{Notes: notes}, ErrGroupNotFound
+		}
+		return &RenameGroupResponse{Notes: notes}, fmt.Errorf("failed to rename group: %w", err)
+	}
+
+	return &RenameGroupResponse{Notes: notes}, nil
 }
 
 // ListGroups はプロジェクト内の全グループを取得する
@@ -184,18 +331,29 @@ func (s *groupService) ListGroups(ctx context.Context, projectID string) (*ListG
 	if err != nil {
 		return nil, fmt.Errorf("failed to list groups: %w", err)
 	}
+	stats, err := s.store.GroupStats(ctx, canonicalProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group stats: %w", err)
+	}
 
 	// レスポンスの構築
 	items := make([]ListGroupItem, 0, len(groups))
 	for _, group := range groups {
 		items = append(items, ListGroupItem{
-			ID:          group.ID,
-			ProjectID:   group.ProjectID,
-			GroupKey:    group.GroupKey,
-			Title:       group.Title,
-			Description: group.Description,
-			CreatedAt:   group.CreatedAt.Format(time.RFC3339),
-			UpdatedAt:   group.UpdatedAt.Format(time.RFC3339),
+			ID:            group.ID,
+			ProjectID:     group.ProjectID,
+			GroupKey:      group.GroupKey,
+			Title:         group.Title,
+			Description:   group.Description,
+			CreatedAt:     group.CreatedAt.Format(time.RFC3339),
+			UpdatedAt:     group.UpdatedAt.Format(time.RFC3339),
+			Tags:          group.Tags,
+			Status:        groupStatus(group.Status),
+			Metadata:      group.Metadata,
+			ParentGroupID: group.ParentGroupID,
+			NoteCount:     stats[group.GroupKey].NoteCount,
+			LastNoteAt:    stats[group.GroupKey].LastNoteAt,
+			TextLength:    stats[group.GroupKey].TextLength,
 		})
 	}
 
@@ -204,3 +362,138 @@ func (s *groupService) ListGroups(ctx context.Context, projectID string) (*ListG
 		Groups:    items,
 	}, nil
 }
+
+// GroupKeyPrefixError はglobal.memory.groupDefaultsのプレフィックスで始まらないgroupKeyを指定したときのエラー
+// errors.Is(err, ErrGroupKeyPrefix)で判定できる
+type GroupKeyPrefixError struct {
+	GroupKey    string   // 指定されたgroupKey
+	Prefixes    []string // 許可されたプレフィックス
+	Suggestions []string // groupKeyにプレフィックスを付けた候補
+}
+
+func (e *GroupKeyPrefixError) Error() string {
+	return fmt.Sprintf("%s: %q (allowed prefixes: %s; e.g. %s)", ErrGroupKeyPrefix, e.GroupKey, strings.Join(e.Prefixes, ", "), strings.Join(e.Suggestions, ", "))
+}
+
+func (e *GroupKeyPrefixError) Unwrap() error {
+	return ErrGroupKeyPrefix
+}
+
+// checkGroupKeyPrefix はgroupKeyがprefixesのいずれかで始まるか検証する
+// prefixesが空（groupDefaults未設定）とglobalは常に許可する
+func checkGroupKeyPrefix(groupKey string, prefixes []string) error {
+	if len(prefixes) == 0 || groupKey == model.GlobalGroupID {
+		return nil
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(groupKey, prefix) {
+			return nil
+		}
+	}
+	suggestions := make([]string, len(prefixes))
+	for i, prefix := range prefixes {
+		suggestions[i] = prefix + groupKey
+	}
+	return &GroupKeyPrefixError{GroupKey: groupKey, Prefixes: prefixes, Suggestions: suggestions}
+}
+
+// loadGroupPrefixes はprojectIDのglobal.memory.groupDefaultsのプレフィックスを返す（未設定ならnil）
+func loadGroupPrefixes(ctx context.Context, st store.Store, projectID string) ([]string, error) {
+	key := model.GlobalKeyGroupDefaults
+	g, found, err := st.GetGlobal(ctx, projectID, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", key, err)
+	}
+	if !found {
+		return nil, nil
+	}
+	return parseGroupPrefixes(g.Value)
+}
+
+// parseGroupPrefixes はglobal.memory.groupDefaultsの値から、キーが"Prefix"で終わる文字列の値
+// （例: {"featurePrefix": "feature-"}の"feature-"）をソートして返す
+func parseGroupPrefixes(value any) ([]string, error) {
+	defaults, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s must be an object, got %v", ErrInvalidNotePolicy, model.GlobalKeyGroupDefaults, value)
+	}
+	var prefixes []string
+	for name, v := range defaults {
+		if prefix, ok := v.(string); ok && prefix != "" && strings.HasSuffix(name, "Prefix") {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	slices.Sort(prefixes)
+	return prefixes, nil
+}
+
+// defaultGroupTitle は自動作成するグループのtitleとして、groupKeyから一致する最長のプレフィックスを除いたものを返す
+// 除くと空になる場合はgroupKeyのまま
+func defaultGroupTitle(groupKey string, prefixes []string) string {
+	title := groupKey
+	for _, prefix := range prefixes {
+		if rest, ok := strings.CutPrefix(groupKey, prefix); ok && rest != "" && len(rest) < len(title) {
+			title = rest
+		}
+	}
+	return title
+}
+
+// groupStatus は空のstatus（作成時の省略・旧データ）をactiveとして返す
+func groupStatus(status string) string {
+	if status == "" {
+		return model.GroupStatusActive
+	}
+	return status
+}
+
+// checkParentGroup はparentGroupIDが同一プロジェクトの既存グループで、
+// groupKey自身やその子孫（親子関係が循環する）でないことを確認する。空ならトップレベルとして許可する
+func checkParentGroup(ctx context.Context, st store.Store, projectID, groupKey, parentGroupID string) error {
+	if parentGroupID == "" {
+		return nil
+	}
+	if parentGroupID == groupKey {
+		return fmt.Errorf("%w: a group cannot be its own parent", ErrInvalidParentGroup)
+	}
+	if _, err := st.GetGroupByKey(ctx, projectID, parentGroupID); err != nil {
+		if err == store.ErrNotFound {
+			return fmt.Errorf("%w: group %q not found", ErrInvalidParentGroup, parentGroupID)
+		}
+		return fmt.Errorf("failed to get parent group: %w", err)
+	}
+	descendants, err := descendantGroupKeys(ctx, st, projectID, groupKey)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(descendants, parentGroupID) {
+		return fmt.Errorf("%w: %q is a descendant of %q", ErrInvalidParentGroup, parentGroupID, groupKey)
+	}
+	return nil
+}
+
+// descendantGroupKeys はgroupKeyとその子孫グループのgroupKeyを返す（先頭がgroupKey）
+func descendantGroupKeys(ctx context.Context, st store.Store, projectID, groupKey string) ([]string, error) {
+	groups, err := st.ListGroups(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list groups: %w", err)
+	}
+	children := make(map[string][]string)
+	for _, group := range groups {
+		if group.ParentGroupID != "" {
+			children[group.ParentGroupID] = append(children[group.ParentGroupID], group.GroupKey)
+		}
+	}
+
+	keys := []string{groupKey}
+	seen := map[string]bool{groupKey: true}
+	for i := 0; i < len(keys); i++ {
+		for _, child := range children[keys[i]] {
+			if !seen[child] {
+				seen[child] = true
+				keys = append(keys, child)
+			}
+		}
+	}
+	return keys, nil
+}
@@ -2,8 +2,11 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"testing"
 
+	"github.com/brbranch/embedding_mcp/internal/model"
 	"github.com/brbranch/embedding_mcp/internal/store"
 )
 
@@ -238,7 +241,7 @@ func TestGroupService_DeleteGroup(t *testing.T) {
 	}
 
 	// 削除
-	err = svc.DeleteGroup(ctx, createResp.ID)
+	_, err = svc.DeleteGroup(ctx, &DeleteGroupRequest{ID: createResp.ID})
 	if err != nil {
 		t.Fatalf("DeleteGroup() failed: %v", err)
 	}
@@ -295,3 +298,365 @@ func TestGroupService_ListGroups(t *testing.T) {
 		t.Errorf("ListGroups() returned %d groups, want 2", len(resp.Groups))
 	}
 }
+
+func TestGroupService_Annotations(t *testing.T) {
+	ctx := context.Background()
+	svc, st := setupGroupTestService(t)
+
+	createResp, err := svc.CreateGroup(ctx, &CreateGroupRequest{
+		ProjectID: "/path/to/project",
+		GroupKey:  "sprint-12",
+		Title:     "Sprint 12",
+		Tags:      []string{"sprint"},
+		Metadata:  map[string]any{"owner": "alice", "ticket": "PROJ-42"},
+	})
+	if err != nil {
+		t.Fatalf("CreateGroup() failed: %v", err)
+	}
+
+	// statusの省略はactive
+	resp, err := svc.GetGroup(ctx, createResp.ID)
+	if err != nil {
+		t.Fatalf("GetGroup() failed: %v", err)
+	}
+	if resp.Status != model.GroupStatusActive || len(resp.Tags) != 1 || resp.Metadata["owner"] != "alice" {
+		t.Errorf("unexpected annotations: %+v", resp)
+	}
+
+	done := model.GroupStatusDone
+	tags := []string{"sprint", "released"}
+	empty := map[string]any{}
+	if err := svc.UpdateGroup(ctx, &UpdateGroupRequest{ID: createResp.ID, Patch: GroupPatch{Tags: &tags, Status: &done, Metadata: &empty}}); err != nil {
+		t.Fatalf("UpdateGroup() failed: %v", err)
+	}
+	list, err := svc.ListGroups(ctx, "/path/to/project")
+	if err != nil {
+		t.Fatalf("ListGroups() failed: %v", err)
+	}
+	if len(list.Groups) != 1 || list.Groups[0].Status != done || len(list.Groups[0].Tags) != 2 || list.Groups[0].Metadata != nil {
+		t.Errorf("expected the annotations to be updated, got %+v", list.Groups)
+	}
+
+	// 旧データ（statusなし）はactiveとして返す
+	group, _ := st.GetGroup(ctx, createResp.ID)
+	group.Status = ""
+	if err := st.UpdateGroup(ctx, group); err != nil {
+		t.Fatal(err)
+	}
+	if resp, _ := svc.GetGroup(ctx, createResp.ID); resp.Status != model.GroupStatusActive {
+		t.Errorf("expected a legacy group to be active, got %q", resp.Status)
+	}
+
+	invalid := "archived"
+	if err := svc.UpdateGroup(ctx, &UpdateGroupRequest{ID: createResp.ID, Patch: GroupPatch{Status: &invalid}}); !errors.Is(err, ErrInvalidGroupStatus) {
+		t.Errorf("expected ErrInvalidGroupStatus, got %v", err)
+	}
+	if _, err := svc.CreateGroup(ctx, &CreateGroupRequest{ProjectID: "/path/to/project", GroupKey: "other", Title: "Other", Status: invalid}); !errors.Is(err, ErrInvalidGroupStatus) {
+		t.Errorf("expected ErrInvalidGroupStatus, got %v", err)
+	}
+}
+
+func TestGroupService_ParentGroup(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := setupGroupTestService(t)
+
+	create := func(key, parent string) string {
+		t.Helper()
+		resp, err := svc.CreateGroup(ctx, &CreateGroupRequest{ProjectID: "/path/to/project", GroupKey: key, Title: key, ParentGroupID: parent})
+		if err != nil {
+			t.Fatalf("CreateGroup(%s) failed: %v", key, err)
+		}
+		return resp.ID
+	}
+	authID := create("feature-auth", "")
+	create("feature-auth-login", "feature-auth")
+	tokensID := create("feature-auth-tokens", "feature-auth-login")
+
+	resp, err := svc.GetGroup(ctx, tokensID)
+	if err != nil {
+		t.Fatalf("GetGroup() failed: %v", err)
+	}
+	if resp.ParentGroupID != "feature-auth-login" {
+		t.Errorf("expected parent feature-auth-login, got %q", resp.ParentGroupID)
+	}
+
+	// 存在しない親・自分自身・子孫（循環）は拒否する
+	if _, err := svc.CreateGroup(ctx, &CreateGroupRequest{ProjectID: "/path/to/project", GroupKey: "orphan", Title: "Orphan", ParentGroupID: "missing"}); !errors.Is(err, ErrInvalidParentGroup) {
+		t.Errorf("expected ErrInvalidParentGroup for a missing parent, got %v", err)
+	}
+	for _, parent := range []string{"feature-auth", "feature-auth-tokens"} {
+		if err := svc.UpdateGroup(ctx, &UpdateGroupRequest{ID: authID, Patch: GroupPatch{ParentGroupID: &parent}}); !errors.Is(err, ErrInvalidParentGroup) {
+			t.Errorf("parent %s: expected ErrInvalidParentGroup, got %v", parent, err)
+		}
+	}
+
+	// 空文字でトップレベルに戻す
+	top := ""
+	if err := svc.UpdateGroup(ctx, &UpdateGroupRequest{ID: tokensID, Patch: GroupPatch{ParentGroupID: &top}}); err != nil {
+		t.Fatalf("UpdateGroup() failed: %v", err)
+	}
+	if resp, _ := svc.GetGroup(ctx, tokensID); resp.ParentGroupID != "" {
+		t.Errorf("expected a top-level group, got parent %q", resp.ParentGroupID)
+	}
+}
+
+func TestGroupService_DeleteGroup_Cascade(t *testing.T) {
+	ctx := context.Background()
+	svc, st := setupGroupTestService(t)
+
+	// groupKeyごとにグループとノート1件を作成する
+	setup := func(key string) string {
+		t.Helper()
+		resp, err := svc.CreateGroup(ctx, &CreateGroupRequest{ProjectID: "/path/to/project", GroupKey: key, Title: key})
+		if err != nil {
+			t.Fatalf("CreateGroup(%s) failed: %v", key, err)
+		}
+		note := &model.Note{ID: key + "-note", ProjectID: "/path/to/project", GroupID: key, Text: key, Tags: []string{}}
+		if err := st.AddNote(ctx, note, []float32{0.1, 0.2, 0.3}); err != nil {
+			t.Fatal(err)
+		}
+		return resp.ID
+	}
+
+	// restrict: ノートがあれば削除しない
+	id := setup("restricted")
+	if _, err := svc.DeleteGroup(ctx, &DeleteGroupRequest{ID: id, Cascade: GroupCascadeRestrict}); !errors.Is(err, ErrGroupHasNotes) {
+		t.Errorf("expected ErrGroupHasNotes, got %v", err)
+	}
+	if _, err := svc.GetGroup(ctx, id); err != nil {
+		t.Errorf("expected the group to remain, got %v", err)
+	}
+
+	// delete: ノートも削除する
+	id = setup("deleted")
+	resp, err := svc.DeleteGroup(ctx, &DeleteGroupRequest{ID: id, Cascade: GroupCascadeDelete})
+	if err != nil {
+		t.Fatalf("DeleteGroup() failed: %v", err)
+	}
+	if resp.Notes != 1 {
+		t.Errorf("expected 1 deleted note, got %d", resp.Notes)
+	}
+	if _, err := st.Get(ctx, "deleted-note"); err != store.ErrNotFound {
+		t.Errorf("expected the note to be deleted, got %v", err)
+	}
+
+	// move: ノートをglobalへ移す
+	id = setup("moved")
+	if resp, err := svc.DeleteGroup(ctx, &DeleteGroupRequest{ID: id, Cascade: GroupCascadeMove}); err != nil || resp.Notes != 1 {
+		t.Fatalf("DeleteGroup() = %+v, %v", resp, err)
+	}
+	if note, _ := st.Get(ctx, "moved-note"); note == nil || note.GroupID != model.GlobalGroupID {
+		t.Errorf("expected the note to be moved to global, got %+v", note)
+	}
+
+	// ノートがなくなればrestrictでも削除できる
+	id = setup("emptied")
+	if _, err := st.DeleteNotesByGroup(ctx, "/path/to/project", "emptied"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.DeleteGroup(ctx, &DeleteGroupRequest{ID: id, Cascade: GroupCascadeRestrict}); err != nil {
+		t.Errorf("DeleteGroup() of an empty group failed: %v", err)
+	}
+
+	if _, err := svc.DeleteGroup(ctx, &DeleteGroupRequest{ID: id, Cascade: "orphan"}); !errors.Is(err, ErrInvalidCascade) {
+		t.Errorf("expected ErrInvalidCascade, got %v", err)
+	}
+}
+
+func TestGroupService_RenameGroup(t *testing.T) {
+	ctx := context.Background()
+	svc, st := setupGroupTestService(t)
+
+	parent, err := svc.CreateGroup(ctx, &CreateGroupRequest{ProjectID: "/path/to/project", GroupKey: "auth", Title: "Auth"})
+	if err != nil {
+		t.Fatalf("CreateGroup() failed: %v", err)
+	}
+	child, err := svc.CreateGroup(ctx, &CreateGroupRequest{ProjectID: "/path/to/project", GroupKey: "auth-login", Title: "Login", ParentGroupID: "auth"})
+	if err != nil {
+		t.Fatalf("CreateGroup() failed: %v", err)
+	}
+	if _, err := svc.CreateGroup(ctx, &CreateGroupRequest{ProjectID: "/path/to/project", GroupKey: "billing", Title: "Billing"}); err != nil {
+		t.Fatalf("CreateGroup() failed: %v", err)
+	}
+	for _, id := range []string{"n1", "n2"} {
+		note := &model.Note{ID: id, ProjectID: "/path/to/project", GroupID: "auth", Text: id, Tags: []string{}}
+		if err := st.AddNote(ctx, note, []float32{0.1, 0.2, 0.3}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resp, err := svc.RenameGroup(ctx, &RenameGroupRequest{ID: parent.ID, GroupKey: "feature-auth"})
+	if err != nil {
+		t.Fatalf("RenameGroup() failed: %v", err)
+	}
+	if resp.Notes != 2 {
+		t.Errorf("expected 2 moved notes, got %d", resp.Notes)
+	}
+	if group, _ := svc.GetGroup(ctx, parent.ID); group.GroupKey != "feature-auth" {
+		t.Errorf("expected groupKey feature-auth, got %q", group.GroupKey)
+	}
+	if note, _ := st.Get(ctx, "n1"); note.GroupID != "feature-auth" {
+		t.Errorf("expected the note to follow the group, got %q", note.GroupID)
+	}
+	if group, _ := svc.GetGroup(ctx, child.ID); group.ParentGroupID != "feature-auth" {
+		t.Errorf("expected the child to follow the parent, got %q", group.ParentGroupID)
+	}
+
+	// 既存・予約・不正なgroupKeyへの変更は拒否する
+	tests := []struct {
+		key  string
+		want error
+	}{
+		{"billing", ErrGroupKeyExists},
+		{"global", ErrInvalidGroupKey},
+		{"feature/auth", ErrInvalidGroupKey},
+		{"", ErrGroupKeyRequired},
+	}
+	for _, tt := range tests {
+		if _, err := svc.RenameGroup(ctx, &RenameGroupRequest{ID: parent.ID, GroupKey: tt.key}); !errors.Is(err, tt.want) {
+			t.Errorf("key %q: expected %v, got %v", tt.key, tt.want, err)
+		}
+	}
+	if _, err := svc.RenameGroup(ctx, &RenameGroupRequest{ID: "missing", GroupKey: "other"}); !errors.Is(err, ErrGroupNotFound) {
+		t.Errorf("expected ErrGroupNotFound, got %v", err)
+	}
+}
+
+func TestGroupService_ListGroups_Stats(t *testing.T) {
+	ctx := context.Background()
+	svc, st := setupGroupTestService(t)
+
+	for _, key := range []string{"active-feature", "abandoned"} {
+		if _, err := svc.CreateGroup(ctx, &CreateGroupRequest{ProjectID: "/path/to/project", GroupKey: key, Title: key}); err != nil {
+			t.Fatalf("CreateGroup() failed: %v", err)
+		}
+	}
+	for i, createdAt := range []string{"2024-01-01T00:00:00Z", "2024-03-01T00:00:00Z"} {
+		note := &model.Note{ID: fmt.Sprintf("n%d", i), ProjectID: "/path/to/project", GroupID: "active-feature", Text: "認証の仕様", Tags: []string{}, CreatedAt: &createdAt}
+		if err := st.AddNote(ctx, note, []float32{0.1, 0.2, 0.3}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resp, err := svc.ListGroups(ctx, "/path/to/project")
+	if err != nil {
+		t.Fatalf("ListGroups() failed: %v", err)
+	}
+	stats := make(map[string]ListGroupItem)
+	for _, g := range resp.Groups {
+		stats[g.GroupKey] = g
+	}
+	if g := stats["active-feature"]; g.NoteCount != 2 || g.LastNoteAt != "2024-03-01T00:00:00Z" || g.TextLength != 10 {
+		t.Errorf("unexpected stats for active-feature: %+v", g)
+	}
+	if g := stats["abandoned"]; g.NoteCount != 0 || g.LastNoteAt != "" || g.TextLength != 0 {
+		t.Errorf("unexpected stats for abandoned: %+v", g)
+	}
+}
+
+func TestGroupService_GroupKeyPrefix(t *testing.T) {
+	ctx := context.Background()
+	svc, st := setupGroupTestService(t)
+	legacy, err := svc.CreateGroup(ctx, &CreateGroupRequest{ProjectID: "/test/project", GroupKey: "legacy", Title: "Legacy"})
+	if err != nil {
+		t.Fatalf("CreateGroup failed: %v", err)
+	}
+	upsertTestGlobal(t, st, "/test/project", model.GlobalKeyGroupDefaults, map[string]any{"featurePrefix": "feature-", "taskPrefix": "task-"})
+
+	_, err = svc.CreateGroup(ctx, &CreateGroupRequest{ProjectID: "/test/project", GroupKey: "login", Title: "Login"})
+	var prefixErr *GroupKeyPrefixError
+	if !errors.As(err, &prefixErr) || prefixErr.GroupKey != "login" || len(prefixErr.Prefixes) != 2 {
+		t.Errorf("expected GroupKeyPrefixError, got %v", err)
+	}
+	if _, err := svc.CreateGroup(ctx, &CreateGroupRequest{ProjectID: "/test/project", GroupKey: "feature-login", Title: "Login"}); err != nil {
+		t.Errorf("CreateGroup with a prefix failed: %v", err)
+	}
+
+	// 登録済みのグループもrenameではプレフィックスに合わせる
+	if _, err := svc.RenameGroup(ctx, &RenameGroupRequest{ID: legacy.ID, GroupKey: "old"}); !errors.Is(err, ErrGroupKeyPrefix) {
+		t.Errorf("expected ErrGroupKeyPrefix on rename, got %v", err)
+	}
+	if _, err := svc.RenameGroup(ctx, &RenameGroupRequest{ID: legacy.ID, GroupKey: "task-legacy"}); err != nil {
+		t.Errorf("RenameGroup with a prefix failed: %v", err)
+	}
+
+	// プレフィックスのないgroupDefaultsは制限しない
+	upsertTestGlobal(t, st, "/test/project", model.GlobalKeyGroupDefaults, map[string]any{"owner": "alice"})
+	if _, err := svc.CreateGroup(ctx, &CreateGroupRequest{ProjectID: "/test/project", GroupKey: "misc", Title: "Misc"}); err != nil {
+		t.Errorf("CreateGroup without prefixes failed: %v", err)
+	}
+}
+
+// failingDeleteGroupStore はグループの削除に失敗するStore（トランザクションには対応しない）
+type failingDeleteGroupStore struct {
+	store.Store
+}
+
+func (s *failingDeleteGroupStore) DeleteGroup(ctx context.Context, id string) error {
+	return errors.New("disk full")
+}
+
+// TestGroupService_DeleteGroup_Partial はトランザクションに対応していないストアで
+// グループの削除に失敗した場合に、反映済みのcascadeの件数を返すことをテスト
+func TestGroupService_DeleteGroup_Partial(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemoryStore()
+	if err := st.Initialize(ctx, "test:mock:128"); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewGroupService(&failingDeleteGroupStore{Store: st}, "test:mock:128")
+
+	created, err := svc.CreateGroup(ctx, &CreateGroupRequest{ProjectID: "/path/to/project", GroupKey: "moved", Title: "Moved"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	note := &model.Note{ID: "moved-note", ProjectID: "/path/to/project", GroupID: "moved", Text: "moved", Tags: []string{}}
+	if err := st.AddNote(ctx, note, []float32{0.1, 0.2, 0.3}); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := svc.DeleteGroup(ctx, &DeleteGroupRequest{ID: created.ID, Cascade: GroupCascadeMove})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if resp == nil || resp.Notes != 1 || resp.RolledBack || resp.Failed != "group" {
+		t.Fatalf("expected 1 moved note without rollback, got %+v", resp)
+	}
+	if got, _ := st.Get(ctx, "moved-note"); got == nil || got.GroupID != model.GlobalGroupID {
+		t.Errorf("expected the note to stay moved to global, got %+v", got)
+	}
+	if _, err := svc.GetGroup(ctx, created.ID); err != nil {
+		t.Errorf("expected the group to remain, got %v", err)
+	}
+}
+
+// partialRenameStore はノートを書き換えた後にグループの更新に失敗するStore
+type partialRenameStore struct {
+	store.Store
+}
+
+func (s *partialRenameStore) RenameGroup(ctx context.Context, id, groupKey string) (int, error) {
+	return 2, errors.New("disk full")
+}
+
+// TestGroupService_RenameGroup_Partial は途中で失敗した場合も書き換え済みのノート数を返すことをテスト
+func TestGroupService_RenameGroup_Partial(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemoryStore()
+	if err := st.Initialize(ctx, "test:mock:128"); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewGroupService(&partialRenameStore{Store: st}, "test:mock:128")
+
+	created, err := svc.CreateGroup(ctx, &CreateGroupRequest{ProjectID: "/path/to/project", GroupKey: "auth", Title: "Auth"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := svc.RenameGroup(ctx, &RenameGroupRequest{ID: created.ID, GroupKey: "authn"})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if resp == nil || resp.Notes != 2 {
+		t.Errorf("expected 2 renamed notes, got %+v", resp)
+	}
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/google/uuid"
+)
+
+// noteIDSeparator はプレフィックスとIDの区切り（groupIdに使えない文字）
+const noteIDSeparator = "."
+
+// ulidEncoding はULIDのCrockford Base32
+const ulidEncoding = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+var (
+	noteIDMu     sync.RWMutex
+	noteIDConfig model.NoteIDConfig
+)
+
+// SetNoteIDConfig はノートIDの生成ルールを設定する（起動時。nilならランダムなUUID）
+func SetNoteIDConfig(cfg *model.NoteIDConfig) {
+	noteIDMu.Lock()
+	defer noteIDMu.Unlock()
+	noteIDConfig = model.NoteIDConfig{}
+	if cfg != nil {
+		noteIDConfig = *cfg
+	}
+}
+
+// newNoteID は現在のルールでノートIDを生成する
+// projectIDは正規化済みのもの（プレフィックスには末尾の要素を使う）
+func newNoteID(projectID, groupID string) string {
+	noteIDMu.RLock()
+	cfg := noteIDConfig
+	noteIDMu.RUnlock()
+
+	var id string
+	switch cfg.Scheme {
+	case model.NoteIDSchemeUUIDv7:
+		id = uuid.Must(uuid.NewV7()).String()
+	case model.NoteIDSchemeULID:
+		id = newULID(time.Now())
+	default:
+		id = uuid.New().String()
+	}
+
+	project := sanitizeIDPrefix(path.Base(strings.ReplaceAll(projectID, "\\", "/")))
+	switch cfg.Prefix {
+	case model.NoteIDPrefixProject:
+		return project + noteIDSeparator + id
+	case model.NoteIDPrefixGroup:
+		return groupID + noteIDSeparator + id
+	case model.NoteIDPrefixProjectGroup:
+		return project + noteIDSeparator + groupID + noteIDSeparator + id
+	}
+	return id
+}
+
+// newULID はtのミリ秒タイムスタンプ（48bit）と乱数（80bit）からULIDを生成する
+func newULID(t time.Time) string {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], uint64(t.UnixMilli())<<16)
+	rand.Read(b[6:])
+
+	// 128bitを5bitずつ26文字にする（先頭は2bitのみ）
+	var s [26]byte
+	hi, lo := binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:])
+	for i := 25; i >= 0; i-- {
+		s[i] = ulidEncoding[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(s[:])
+}
+
+// sanitizeIDPrefix はプロジェクト名をIDに使える文字（英数字・"_"・"-"）に置き換える
+func sanitizeIDPrefix(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		}
+		return '_'
+	}, name)
+}
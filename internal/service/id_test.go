@@ -0,0 +1,59 @@
+package service
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+)
+
+func TestNewNoteID(t *testing.T) {
+	t.Cleanup(func() { SetNoteIDConfig(nil) })
+
+	uuidPattern := `[0-9a-f]{8}-[0-9a-f]{4}-%s[0-9a-f]{3}-[0-9a-f]{4}-[0-9a-f]{12}`
+	tests := []struct {
+		cfg  *model.NoteIDConfig
+		want string
+	}{
+		{nil, strings.ReplaceAll(uuidPattern, "%s", "4")},
+		{&model.NoteIDConfig{Scheme: model.NoteIDSchemeUUIDv7}, strings.ReplaceAll(uuidPattern, "%s", "7")},
+		{&model.NoteIDConfig{Scheme: model.NoteIDSchemeULID}, `[0-7][0-9A-HJKMNP-TV-Z]{25}`},
+		{&model.NoteIDConfig{Scheme: model.NoteIDSchemeULID, Prefix: model.NoteIDPrefixProject}, `my_app\.[0-9A-Z]{26}`},
+		{&model.NoteIDConfig{Prefix: model.NoteIDPrefixGroup}, `global\.[0-9a-f-]{36}`},
+		{&model.NoteIDConfig{Scheme: model.NoteIDSchemeUUIDv7, Prefix: model.NoteIDPrefixProjectGroup}, `my_app\.global\.[0-9a-f-]{36}`},
+	}
+	for _, tt := range tests {
+		SetNoteIDConfig(tt.cfg)
+		id := newNoteID("/home/user/my app", "global")
+		if !regexp.MustCompile(`^` + tt.want + `$`).MatchString(id) {
+			t.Errorf("config %+v: id %q does not match %s", tt.cfg, id, tt.want)
+		}
+	}
+}
+
+// TestNewNoteID_Sortable はuuidv7・ulidのIDが作成時刻順に並ぶことをテスト
+func TestNewNoteID_Sortable(t *testing.T) {
+	t.Cleanup(func() { SetNoteIDConfig(nil) })
+
+	for _, scheme := range []string{model.NoteIDSchemeUUIDv7, model.NoteIDSchemeULID} {
+		SetNoteIDConfig(&model.NoteIDConfig{Scheme: scheme})
+		var ids []string
+		for range 3 {
+			ids = append(ids, newNoteID("/p", "global"))
+			time.Sleep(2 * time.Millisecond)
+		}
+		if !slices.IsSorted(ids) {
+			t.Errorf("%s: expected ids in creation order, got %v", scheme, ids)
+		}
+	}
+
+	if got := newULID(time.UnixMilli(0)); !strings.HasPrefix(got, "0000000000") {
+		t.Errorf("expected zero timestamp prefix, got %q", got)
+	}
+	if a, b := newULID(time.UnixMilli(1)), newULID(time.UnixMilli(2)); a >= b {
+		t.Errorf("expected %q < %q", a, b)
+	}
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"maps"
+	"unicode"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+)
+
+// MetadataKeyLanguage は追加時に検出した本文の言語を保存するmetadataのキー
+const MetadataKeyLanguage = "language"
+
+// latinLettersPerWord はラテン文字・キリル文字を漢字・かな1文字相当に換算する文字数
+// 英単語1語と漢字・かな1-2文字がほぼ同じ情報量になるよう重み付けする
+const latinLettersPerWord = 3
+
+// DetectLanguage は本文の文字種から言語（ja/zh/ko/ru/en）を推定する（判定できなければ空文字）
+// かなを含むCJK文字はja、かなを含まない漢字はzh、ハングルはko、キリル文字はru、ラテン文字はenとみなし、
+// 最も多い文字種を採用する。日本語の文中に英単語やコードが混ざっていてもjaになる
+func DetectLanguage(text string) string {
+	var kana, han, hangul, cyrillic, latin int
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		}
+	}
+
+	cjk := "zh"
+	if kana > 0 {
+		cjk = "ja"
+	}
+	language, best := "", 0
+	for _, c := range []struct {
+		language string
+		score    int
+	}{
+		{cjk, kana + han},
+		{"ko", hangul},
+		{"ru", (cyrillic + latinLettersPerWord - 1) / latinLettersPerWord},
+		{"en", (latin + latinLettersPerWord - 1) / latinLettersPerWord},
+	} {
+		if c.score > best {
+			language, best = c.language, c.score
+		}
+	}
+	return language
+}
+
+// withLanguage はmetadataに検出した言語を加えたコピーを返す
+// 既にlanguageが指定されている場合や判定できない場合はmetadataをそのまま返す
+func withLanguage(metadata map[string]any, text string) map[string]any {
+	if _, ok := metadata[MetadataKeyLanguage]; ok {
+		return metadata
+	}
+	language := DetectLanguage(text)
+	if language == "" {
+		return metadata
+	}
+	metadata = maps.Clone(metadata)
+	if metadata == nil {
+		metadata = make(map[string]any)
+	}
+	metadata[MetadataKeyLanguage] = language
+	return metadata
+}
+
+// noteLanguage はノートのmetadataに保存された言語を返す
+func noteLanguage(note *model.Note) string {
+	language, _ := note.Metadata[MetadataKeyLanguage].(string)
+	return language
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/store"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"テストはテーブル駆動で書く", "ja"},
+		{"Goのエラーは errors.Is で比較する（fmt.Errorfの%wでラップ）", "ja"},
+		{"Use table-driven tests for Go code", "en"},
+		{"错误处理使用标准库", "zh"},
+		{"테스트는 테이블 기반으로 작성한다", "ko"},
+		{"Используйте табличные тесты", "ru"},
+		{"123 + 456 = 579", ""},
+	}
+	for _, tt := range tests {
+		if got := DetectLanguage(tt.text); got != tt.want {
+			t.Errorf("DetectLanguage(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestNoteService_Language(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestNoteService(&mockEmbedder{dim: 3}, store.NewMemoryStore(), "openai:test:3")
+
+	add := func(text string, metadata map[string]any) string {
+		t.Helper()
+		resp, err := svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: "global", Text: text, Metadata: metadata})
+		if err != nil {
+			t.Fatalf("AddNote failed: %v", err)
+		}
+		return resp.ID
+	}
+	ja := add("テストはテーブル駆動で書く", nil)
+	en := add("Use table-driven tests", map[string]any{"topic": "test"})
+	explicit := add("Use table-driven tests", map[string]any{MetadataKeyLanguage: "ja"})
+
+	got, err := svc.Get(ctx, en)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Metadata[MetadataKeyLanguage] != "en" || got.Metadata["topic"] != "test" {
+		t.Errorf("expected detected language in metadata, got %v", got.Metadata)
+	}
+
+	language := "ja"
+	resp, err := svc.Search(ctx, &SearchRequest{ProjectID: "/test/project", Query: "test", Language: &language})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(resp.Results) != 2 || resp.Results[0].ID == en || resp.Results[1].ID == en {
+		t.Errorf("expected only Japanese notes (%s, %s), got %+v", ja, explicit, resp.Results)
+	}
+
+	// 本文を変更すると言語を検出し直す
+	text := "Tests are table-driven"
+	if err := svc.Update(ctx, &UpdateRequest{ID: ja, Patch: NotePatch{Text: &text}}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if got, _ := svc.Get(ctx, ja); got.Metadata[MetadataKeyLanguage] != "en" {
+		t.Errorf("expected language to be re-detected, got %v", got.Metadata)
+	}
+}
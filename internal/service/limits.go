@@ -0,0 +1,110 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+)
+
+// NoteLimits はノート1件の大きさの上限（0は無制限）
+type NoteLimits struct {
+	MaxTextLength    int // 本文の文字数
+	MaxTitleLength   int // タイトルの文字数
+	MaxTags          int // タグの数
+	MaxTagLength     int // タグ1つの文字数
+	MaxMetadataBytes int // metadataをJSONにしたバイト数
+}
+
+// DefaultNoteLimits はデフォルトの上限
+// 本文はOpenAIの埋め込みモデルの入力上限（8191トークン）に収まる程度にする
+var DefaultNoteLimits = NoteLimits{
+	MaxTextLength:    20000,
+	MaxTitleLength:   500,
+	MaxTags:          50,
+	MaxTagLength:     100,
+	MaxMetadataBytes: 16 * 1024,
+}
+
+var (
+	noteLimitsMu sync.RWMutex
+	noteLimits   = DefaultNoteLimits
+)
+
+// SetNoteLimits はノートの大きさの上限を設定する（起動時）
+func SetNoteLimits(l NoteLimits) {
+	noteLimitsMu.Lock()
+	defer noteLimitsMu.Unlock()
+	noteLimits = l
+}
+
+// CurrentNoteLimits は現在の上限を返す
+func CurrentNoteLimits() NoteLimits {
+	noteLimitsMu.RLock()
+	defer noteLimitsMu.RUnlock()
+	return noteLimits
+}
+
+// NoteLimitsFromConfig は設定のlimitsセクションから上限を作る
+// 省略・0の項目はデフォルト、負の値は無制限
+func NoteLimitsFromConfig(cfg *model.NoteLimitsConfig) NoteLimits {
+	l := DefaultNoteLimits
+	if cfg == nil {
+		return l
+	}
+	for _, f := range []struct {
+		dst *int
+		src int
+	}{
+		{&l.MaxTextLength, cfg.MaxTextLength},
+		{&l.MaxTitleLength, cfg.MaxTitleLength},
+		{&l.MaxTags, cfg.MaxTags},
+		{&l.MaxTagLength, cfg.MaxTagLength},
+		{&l.MaxMetadataBytes, cfg.MaxMetadataBytes},
+	} {
+		switch {
+		case f.src < 0:
+			*f.dst = 0
+		case f.src > 0:
+			*f.dst = f.src
+		}
+	}
+	return l
+}
+
+// CheckNoteLimits は指定された項目が上限を超えていないか検証する（nilの項目は検証しない）
+func CheckNoteLimits(title, text *string, tags []string, metadata map[string]any) error {
+	l := CurrentNoteLimits()
+	if text != nil && l.MaxTextLength > 0 {
+		if n := utf8.RuneCountInString(*text); n > l.MaxTextLength {
+			return fmt.Errorf("%w: text is %d characters (max %d); split it into smaller notes", ErrNoteTooLarge, n, l.MaxTextLength)
+		}
+	}
+	if title != nil && l.MaxTitleLength > 0 {
+		if n := utf8.RuneCountInString(*title); n > l.MaxTitleLength {
+			return fmt.Errorf("%w: title is %d characters (max %d)", ErrNoteTooLarge, n, l.MaxTitleLength)
+		}
+	}
+	if l.MaxTags > 0 && len(tags) > l.MaxTags {
+		return fmt.Errorf("%w: %d tags (max %d)", ErrNoteTooLarge, len(tags), l.MaxTags)
+	}
+	if l.MaxTagLength > 0 {
+		for i, tag := range tags {
+			if n := utf8.RuneCountInString(tag); n > l.MaxTagLength {
+				return fmt.Errorf("%w: tags[%d] is %d characters (max %d)", ErrNoteTooLarge, i, n, l.MaxTagLength)
+			}
+		}
+	}
+	if metadata != nil && l.MaxMetadataBytes > 0 {
+		data, err := json.Marshal(metadata)
+		if err != nil {
+			return fmt.Errorf("invalid metadata: %w", err)
+		}
+		if len(data) > l.MaxMetadataBytes {
+			return fmt.Errorf("%w: metadata is %d bytes as JSON (max %d)", ErrNoteTooLarge, len(data), l.MaxMetadataBytes)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/store"
+)
+
+func TestNoteLimitsFromConfig(t *testing.T) {
+	if got := NoteLimitsFromConfig(nil); got != DefaultNoteLimits {
+		t.Errorf("expected defaults, got %+v", got)
+	}
+	got := NoteLimitsFromConfig(&model.NoteLimitsConfig{MaxTextLength: 100, MaxTags: -1})
+	want := DefaultNoteLimits
+	want.MaxTextLength, want.MaxTags = 100, 0
+	if got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestNoteService_Limits(t *testing.T) {
+	ctx := context.Background()
+	SetNoteLimits(NoteLimits{MaxTextLength: 10, MaxTitleLength: 5, MaxTags: 2, MaxTagLength: 3, MaxMetadataBytes: 20})
+	t.Cleanup(func() { SetNoteLimits(DefaultNoteLimits) })
+	svc := newTestNoteService(&mockEmbedder{dim: 3}, store.NewMemoryStore(), "openai:test:3")
+
+	long := "toolong"
+	tests := []struct {
+		name string
+		req  AddNoteRequest
+		msg  string
+	}{
+		{"text", AddNoteRequest{Text: strings.Repeat("あ", 11)}, "text is 11 characters (max 10)"},
+		{"title", AddNoteRequest{Text: "x", Title: &long}, "title is 7 characters"},
+		{"tags count", AddNoteRequest{Text: "x", Tags: []string{"a", "b", "c"}}, "3 tags (max 2)"},
+		{"tag length", AddNoteRequest{Text: "x", Tags: []string{"a", "long"}}, "tags[1] is 4 characters"},
+		{"metadata", AddNoteRequest{Text: "x", Metadata: map[string]any{"log": strings.Repeat("x", 20)}}, "metadata is"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.ProjectID, tt.req.GroupID = "/test/project", "global"
+			_, err := svc.AddNote(ctx, &tt.req)
+			if !errors.Is(err, ErrNoteTooLarge) || !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("expected ErrNoteTooLarge with %q, got %v", tt.msg, err)
+			}
+		})
+	}
+
+	added, err := svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: "global", Text: strings.Repeat("あ", 10)})
+	if err != nil {
+		t.Fatalf("AddNote within limits failed: %v", err)
+	}
+	text := strings.Repeat("x", 11)
+	if err := svc.Update(ctx, &UpdateRequest{ID: added.ID, Patch: NotePatch{Text: &text}}); !errors.Is(err, ErrNoteTooLarge) {
+		t.Errorf("expected ErrNoteTooLarge on update, got %v", err)
+	}
+
+	// 上限を下げた後も、超えていない項目は更新できる
+	SetNoteLimits(NoteLimits{MaxTextLength: 5})
+	title := "ok"
+	if err := svc.Update(ctx, &UpdateRequest{ID: added.ID, Patch: NotePatch{Title: &title}}); err != nil {
+		t.Errorf("expected title update to succeed, got %v", err)
+	}
+}
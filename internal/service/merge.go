@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/store"
+)
+
+// mergeService はMergeServiceの実装
+type mergeService struct {
+	store     store.Store
+	namespace string
+}
+
+// NewMergeService はMergeServiceの新しいインスタンスを作成
+func NewMergeService(s store.Store, namespace string) MergeService {
+	return &mergeService{store: s, namespace: namespace}
+}
+
+// MergeProjects は保存されている各projectIdを現在の正規化ルールで正規化し直し、
+// 結果が異なるプロジェクトのノート・グループ・グローバル設定を正規化後のprojectIdへ移す
+// 統合先に同じgroupKeyのグループ・同じkeyのグローバル設定がある場合は統合先を優先し、元のprojectIdに残す
+func (s *mergeService) MergeProjects(ctx context.Context, req *MergeProjectsRequest) (*MergeProjectsResponse, error) {
+	projects, err := s.store.ListProjects(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+
+	resp := &MergeProjectsResponse{Merges: []ProjectMerge{}}
+	for _, p := range projects {
+		to, err := config.CanonicalizeProjectID(p.ProjectID)
+		if err != nil {
+			return resp, fmt.Errorf("project %s: %w", p.ProjectID, err)
+		}
+		if to == p.ProjectID {
+			continue
+		}
+		merge := ProjectMerge{From: p.ProjectID, To: to}
+		if err := s.mergeProject(ctx, &merge, req.DryRun, &resp.Skipped); err != nil {
+			return resp, fmt.Errorf("project %s: %w", p.ProjectID, err)
+		}
+		resp.Merges = append(resp.Merges, merge)
+	}
+	return resp, nil
+}
+
+// mergeProject はmerge.Fromのノート・グループ・グローバル設定をmerge.Toへ移し、件数をmergeに記録する
+func (s *mergeService) mergeProject(ctx context.Context, merge *ProjectMerge, dryRun bool, skipped *int) error {
+	notes, err := s.store.ListNotes(ctx, merge.From)
+	if err != nil {
+		return fmt.Errorf("failed to list notes: %w", err)
+	}
+	for _, note := range notes {
+		merge.Notes++
+		if dryRun {
+			continue
+		}
+		// Updateは埋め込みも置き換えるため、既存の埋め込みを引き継ぐ
+		embedding, err := s.store.GetEmbedding(ctx, note.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get embedding of note %s: %w", note.ID, err)
+		}
+		note.ProjectID = merge.To
+		if err := s.store.Update(ctx, note, embedding); err != nil {
+			return fmt.Errorf("failed to move note %s: %w", note.ID, err)
+		}
+	}
+
+	groups, err := s.store.ListGroups(ctx, merge.From)
+	if err != nil {
+		return fmt.Errorf("failed to list groups: %w", err)
+	}
+	for _, group := range groups {
+		if _, err := s.store.GetGroupByKey(ctx, merge.To, group.GroupKey); err == nil {
+			*skipped++
+			continue
+		} else if !errors.Is(err, store.ErrNotFound) {
+			return fmt.Errorf("failed to get group %s: %w", group.GroupKey, err)
+		}
+		merge.Groups++
+		if dryRun {
+			continue
+		}
+		if err := s.store.DeleteGroup(ctx, group.ID); err != nil {
+			return fmt.Errorf("failed to move group %s: %w", group.GroupKey, err)
+		}
+		group.ProjectID = merge.To
+		if err := s.store.AddGroup(ctx, group); err != nil {
+			return fmt.Errorf("failed to move group %s: %w", group.GroupKey, err)
+		}
+	}
+
+	globals, err := s.store.ListGlobals(ctx, merge.From)
+	if err != nil {
+		return fmt.Errorf("failed to list globals: %w", err)
+	}
+	for _, global := range globals {
+		if _, found, err := s.store.GetGlobal(ctx, merge.To, global.Key); err != nil {
+			return fmt.Errorf("failed to get global %s: %w", global.Key, err)
+		} else if found {
+			*skipped++
+			continue
+		}
+		merge.Globals++
+		if dryRun {
+			continue
+		}
+		if err := s.store.DeleteGlobalByID(ctx, global.ID); err != nil {
+			return fmt.Errorf("failed to move global %s: %w", global.Key, err)
+		}
+		global.ProjectID = merge.To
+		if err := s.store.UpsertGlobal(ctx, global); err != nil {
+			return fmt.Errorf("failed to move global %s: %w", global.Key, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/store"
+)
+
+func TestMergeService_MergeProjects(t *testing.T) {
+	ctx := context.Background()
+	config.SetCanonicalizeRules(config.CanonicalizeRules{CaseInsensitive: true})
+	t.Cleanup(func() { config.SetCanonicalizeRules(config.DefaultCanonicalizeRules) })
+
+	st := store.NewMemoryStore()
+	if err := st.Initialize(ctx, "test:mock:3"); err != nil {
+		t.Fatal(err)
+	}
+	// 大文字小文字の違いで分かれたプロジェクト。統合先には同じkeyのグローバル設定が既にある
+	seedExportData(t, st, "/Test/Project")
+	if err := st.UpsertGlobal(ctx, &model.GlobalConfig{ID: "global-2", ProjectID: "/test/project", Key: "global.project.conventions", Value: "kept"}); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	if err := st.AddGroup(ctx, &model.Group{ID: "group-2", ProjectID: "/test/other", GroupKey: "other", Title: "Other", CreatedAt: now, UpdatedAt: now}); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewMergeService(st, "test:mock:3")
+
+	// dry-runでは変更しない
+	resp, err := svc.MergeProjects(ctx, &MergeProjectsRequest{DryRun: true})
+	if err != nil {
+		t.Fatalf("MergeProjects failed: %v", err)
+	}
+	want := ProjectMerge{From: "/Test/Project", To: "/test/project", Notes: 1, Groups: 1}
+	if len(resp.Merges) != 1 || resp.Merges[0] != want || resp.Skipped != 1 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if note, _ := st.Get(ctx, "note-1"); note.ProjectID != "/Test/Project" {
+		t.Errorf("dry run moved the note to %s", note.ProjectID)
+	}
+
+	resp, err = svc.MergeProjects(ctx, &MergeProjectsRequest{})
+	if err != nil {
+		t.Fatalf("MergeProjects failed: %v", err)
+	}
+	if len(resp.Merges) != 1 || resp.Merges[0] != want {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	note, err := st.Get(ctx, "note-1")
+	if err != nil || note.ProjectID != "/test/project" {
+		t.Errorf("expected the note to be moved, got %+v, %v", note, err)
+	}
+	if embedding, _ := st.GetEmbedding(ctx, "note-1"); len(embedding) != 3 || embedding[0] != 1 {
+		t.Errorf("expected the embedding to be kept, got %v", embedding)
+	}
+	if _, err := st.GetGroupByKey(ctx, "/test/project", "feature-1"); err != nil {
+		t.Errorf("expected the group to be moved: %v", err)
+	}
+	if g, _, _ := st.GetGlobal(ctx, "/test/project", "global.project.conventions"); g.Value != "kept" {
+		t.Errorf("expected the target global to win, got %v", g.Value)
+	}
+
+	// 統合先を優先したグローバル設定は元のprojectIdに残り、再実行してもスキップされる
+	resp, err = svc.MergeProjects(ctx, &MergeProjectsRequest{})
+	if err != nil {
+		t.Fatalf("MergeProjects failed: %v", err)
+	}
+	if len(resp.Merges) != 1 || resp.Merges[0].Notes != 0 || resp.Merges[0].Groups != 0 || resp.Skipped != 1 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
@@ -0,0 +1,281 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// metadataSchema はglobal.memory.metadataSchemaのJSON Schema（検証に使うキーワードのみ）
+// 対応: type, properties, required, additionalProperties, items, enum,
+// minimum, maximum, minLength, maxLength, pattern, minItems, maxItems（それ以外のキーワードは無視する）
+type metadataSchema struct {
+	types                []string
+	properties           map[string]*metadataSchema
+	required             []string
+	additionalProperties *metadataSchema // nilなら制約なし
+	noAdditional         bool            // additionalProperties: false
+	items                *metadataSchema
+	enum                 []any
+	minimum, maximum     *float64
+	minLength, maxLength *int
+	minItems, maxItems   *int
+	pattern              *regexp.Regexp
+}
+
+// rawMetadataSchema はJSON Schemaをデコードする中間表現
+type rawMetadataSchema struct {
+	Type                 json.RawMessage               `json:"type"`
+	Properties           map[string]*rawMetadataSchema `json:"properties"`
+	Required             []string                      `json:"required"`
+	AdditionalProperties json.RawMessage               `json:"additionalProperties"`
+	Items                *rawMetadataSchema            `json:"items"`
+	Enum                 []any                         `json:"enum"`
+	Minimum              *float64                      `json:"minimum"`
+	Maximum              *float64                      `json:"maximum"`
+	MinLength            *int                          `json:"minLength"`
+	MaxLength            *int                          `json:"maxLength"`
+	Pattern              *string                       `json:"pattern"`
+	MinItems             *int                          `json:"minItems"`
+	MaxItems             *int                          `json:"maxItems"`
+}
+
+var schemaTypes = []string{"object", "array", "string", "number", "integer", "boolean", "null"}
+
+// parseMetadataSchema はグローバル設定の値（JSONオブジェクト）をmetadataSchemaに変換する
+func parseMetadataSchema(value any) (*metadataSchema, error) {
+	if _, ok := value.(map[string]any); !ok {
+		return nil, fmt.Errorf("must be a JSON Schema object, got %v", value)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var raw rawMetadataSchema
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	return raw.compile("")
+}
+
+// compile は中間表現を検証してmetadataSchemaにする（pathはエラー表示用）
+func (r *rawMetadataSchema) compile(path string) (*metadataSchema, error) {
+	s := &metadataSchema{
+		required:  r.Required,
+		enum:      r.Enum,
+		minimum:   r.Minimum,
+		maximum:   r.Maximum,
+		minLength: r.MinLength,
+		maxLength: r.MaxLength,
+		minItems:  r.MinItems,
+		maxItems:  r.MaxItems,
+	}
+
+	if len(r.Type) > 0 {
+		var single string
+		if err := json.Unmarshal(r.Type, &single); err == nil {
+			s.types = []string{single}
+		} else if err := json.Unmarshal(r.Type, &s.types); err != nil {
+			return nil, fmt.Errorf("%stype must be a string or an array of strings", path)
+		}
+		for _, t := range s.types {
+			if !slices.Contains(schemaTypes, t) {
+				return nil, fmt.Errorf("%stype: unknown type %q", path, t)
+			}
+		}
+	}
+
+	if r.Pattern != nil {
+		re, err := regexp.Compile(*r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%spattern: %v", path, err)
+		}
+		s.pattern = re
+	}
+
+	if len(r.Properties) > 0 {
+		s.properties = make(map[string]*metadataSchema, len(r.Properties))
+		for name, prop := range r.Properties {
+			if prop == nil {
+				return nil, fmt.Errorf("%sproperties.%s must be an object", path, name)
+			}
+			compiled, err := prop.compile(path + "properties." + name + ".")
+			if err != nil {
+				return nil, err
+			}
+			s.properties[name] = compiled
+		}
+	}
+
+	if len(r.AdditionalProperties) > 0 {
+		var allowed bool
+		if err := json.Unmarshal(r.AdditionalProperties, &allowed); err == nil {
+			s.noAdditional = !allowed
+		} else {
+			var raw rawMetadataSchema
+			if err := json.Unmarshal(r.AdditionalProperties, &raw); err != nil {
+				return nil, fmt.Errorf("%sadditionalProperties must be a boolean or an object", path)
+			}
+			if s.additionalProperties, err = raw.compile(path + "additionalProperties."); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if r.Items != nil {
+		items, err := r.Items.compile(path + "items.")
+		if err != nil {
+			return nil, err
+		}
+		s.items = items
+	}
+	return s, nil
+}
+
+// validate はmetadataがスキーマに合うか検証する（nilは空のオブジェクトとして扱う）
+// サーバーが付けるlanguageは、スキーマのpropertiesで定義していなければ検証しない
+func (s *metadataSchema) validate(metadata map[string]any) error {
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+	// Goの型（int・[]stringなど）をJSONの値に揃える
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMetadata, err)
+	}
+	var value map[string]any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMetadata, err)
+	}
+	if _, ok := s.properties[MetadataKeyLanguage]; !ok {
+		delete(value, MetadataKeyLanguage)
+	}
+	if msg := s.check(value, "metadata"); msg != "" {
+		return fmt.Errorf("%w: %s", ErrInvalidMetadata, msg)
+	}
+	return nil
+}
+
+// check はvalueを検証し、最初の不一致の説明を返す（一致すれば空文字）
+func (s *metadataSchema) check(value any, path string) string {
+	if len(s.types) > 0 && !slices.ContainsFunc(s.types, func(t string) bool { return schemaTypeMatches(t, value) }) {
+		return fmt.Sprintf("%s: expected %s, got %s", path, strings.Join(s.types, " or "), schemaTypeOf(value))
+	}
+	if len(s.enum) > 0 && !slices.ContainsFunc(s.enum, func(e any) bool { return jsonEqual(e, value) }) {
+		return fmt.Sprintf("%s: must be one of %s", path, formatEnum(s.enum))
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		for _, name := range s.required {
+			if _, ok := v[name]; !ok {
+				return fmt.Sprintf("%s.%s is required", path, name)
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			prop, ok := s.properties[name]
+			switch {
+			case ok:
+			case s.noAdditional:
+				return fmt.Sprintf("%s.%s is not allowed", path, name)
+			case s.additionalProperties != nil:
+				prop = s.additionalProperties
+			default:
+				continue
+			}
+			if msg := prop.check(v[name], path+"."+name); msg != "" {
+				return msg
+			}
+		}
+	case []any:
+		if s.minItems != nil && len(v) < *s.minItems {
+			return fmt.Sprintf("%s: must have at least %d items", path, *s.minItems)
+		}
+		if s.maxItems != nil && len(v) > *s.maxItems {
+			return fmt.Sprintf("%s: must have at most %d items", path, *s.maxItems)
+		}
+		if s.items != nil {
+			for i, item := range v {
+				if msg := s.items.check(item, fmt.Sprintf("%s[%d]", path, i)); msg != "" {
+					return msg
+				}
+			}
+		}
+	case string:
+		n := len([]rune(v))
+		if s.minLength != nil && n < *s.minLength {
+			return fmt.Sprintf("%s: must be at least %d characters", path, *s.minLength)
+		}
+		if s.maxLength != nil && n > *s.maxLength {
+			return fmt.Sprintf("%s: must be at most %d characters", path, *s.maxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			return fmt.Sprintf("%s: must match %q", path, s.pattern.String())
+		}
+	case float64:
+		if s.minimum != nil && v < *s.minimum {
+			return fmt.Sprintf("%s: must be >= %v", path, *s.minimum)
+		}
+		if s.maximum != nil && v > *s.maximum {
+			return fmt.Sprintf("%s: must be <= %v", path, *s.maximum)
+		}
+	}
+	return ""
+}
+
+// schemaTypeMatches はvalueがJSON Schemaの型tに当てはまるか
+func schemaTypeMatches(t string, value any) bool {
+	if t == "number" {
+		_, ok := value.(float64)
+		return ok
+	}
+	return schemaTypeOf(value) == t
+}
+
+// schemaTypeOf はJSONの値の型名を返す（整数値の数はinteger）
+func schemaTypeOf(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// jsonEqual はJSONの値として等しいか
+func jsonEqual(a, b any) bool {
+	x, err1 := json.Marshal(a)
+	y, err2 := json.Marshal(b)
+	return err1 == nil && err2 == nil && string(x) == string(y)
+}
+
+// formatEnum はenumの値をJSONで列挙する
+func formatEnum(values []any) string {
+	strs := make([]string, len(values))
+	for i, v := range values {
+		data, _ := json.Marshal(v)
+		strs[i] = string(data)
+	}
+	return strings.Join(strs, ", ")
+}
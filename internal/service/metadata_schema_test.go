@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/store"
+)
+
+// testMetadataSchema はticket・decision・ownerを持つスキーマ
+var testMetadataSchema = map[string]any{
+	"type":     "object",
+	"required": []any{"ticket"},
+	"properties": map[string]any{
+		"ticket":   map[string]any{"type": "string", "pattern": "^[A-Z]+-[0-9]+$"},
+		"decision": map[string]any{"enum": []any{"accepted", "rejected"}},
+		"owner":    map[string]any{"type": []any{"string", "null"}, "maxLength": 5},
+		"priority": map[string]any{"type": "integer", "minimum": 1, "maximum": 3},
+		"links":    map[string]any{"type": "array", "maxItems": 2, "items": map[string]any{"type": "string"}},
+	},
+	"additionalProperties": false,
+}
+
+func TestMetadataSchema_Validate(t *testing.T) {
+	schema, err := parseMetadataSchema(testMetadataSchema)
+	if err != nil {
+		t.Fatalf("parseMetadataSchema failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		metadata map[string]any
+		want     string // 空なら一致
+	}{
+		{"valid", map[string]any{"ticket": "ABC-1", "decision": "accepted", "owner": nil, "priority": 2, "links": []string{"a"}}, ""},
+		{"missing required", nil, "metadata.ticket is required"},
+		{"wrong type", map[string]any{"ticket": 1}, "metadata.ticket: expected string, got integer"},
+		{"pattern", map[string]any{"ticket": "abc"}, `metadata.ticket: must match "^[A-Z]+-[0-9]+$"`},
+		{"enum", map[string]any{"ticket": "A-1", "decision": "maybe"}, `metadata.decision: must be one of "accepted", "rejected"`},
+		{"maxLength", map[string]any{"ticket": "A-1", "owner": "someone"}, "metadata.owner: must be at most 5 characters"},
+		{"integer", map[string]any{"ticket": "A-1", "priority": 1.5}, "metadata.priority: expected integer, got number"},
+		{"maximum", map[string]any{"ticket": "A-1", "priority": 4}, "metadata.priority: must be <= 3"},
+		{"items", map[string]any{"ticket": "A-1", "links": []any{"a", 1}}, "metadata.links[1]: expected string, got integer"},
+		{"maxItems", map[string]any{"ticket": "A-1", "links": []any{"a", "b", "c"}}, "metadata.links: must have at most 2 items"},
+		{"additional", map[string]any{"ticket": "A-1", "extra": true}, "metadata.extra is not allowed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schema.validate(tt.metadata)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidMetadata) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected ErrInvalidMetadata with %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestParseMetadataSchema_Invalid(t *testing.T) {
+	tests := []struct {
+		value any
+		want  string
+	}{
+		{"object", "must be a JSON Schema object"},
+		{map[string]any{"type": "text"}, `type: unknown type "text"`},
+		{map[string]any{"properties": map[string]any{"a": map[string]any{"pattern": "("}}}, "properties.a.pattern"},
+		{map[string]any{"additionalProperties": "no"}, "additionalProperties must be a boolean or an object"},
+	}
+	for _, tt := range tests {
+		if _, err := parseMetadataSchema(tt.value); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parseMetadataSchema(%v): expected error containing %q, got %v", tt.value, tt.want, err)
+		}
+	}
+}
+
+func TestNoteService_MetadataSchema(t *testing.T) {
+	ctx := context.Background()
+	memStore := store.NewMemoryStore()
+	svc := newTestNoteService(&mockEmbedder{dim: 3}, memStore, "openai:test:3")
+	upsertTestGlobal(t, memStore, "/test/project", model.GlobalKeyMetadataSchema, testMetadataSchema)
+
+	if _, err := svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: "global", Text: "x"}); !errors.Is(err, ErrInvalidMetadata) {
+		t.Errorf("expected ErrInvalidMetadata without the required field, got %v", err)
+	}
+	added, err := svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: "global", Text: "x", Metadata: map[string]any{"ticket": "ABC-1"}})
+	if err != nil {
+		t.Fatalf("AddNote failed: %v", err)
+	}
+
+	// 本文だけの更新はmetadataを検証しない（検出した言語が加わっていても通る）
+	text := "y"
+	if err := svc.Update(ctx, &UpdateRequest{ID: added.ID, Patch: NotePatch{Text: &text}}); err != nil {
+		t.Errorf("Update of text failed: %v", err)
+	}
+	got, err := svc.Get(ctx, added.ID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if err := svc.Update(ctx, &UpdateRequest{ID: added.ID, Patch: NotePatch{Metadata: &got.Metadata}}); err != nil {
+		t.Errorf("expected metadata with the detected language to pass, got %v", err)
+	}
+	metadata := map[string]any{"ticket": "ABC-1", "owner": "someone"}
+	if err := svc.Update(ctx, &UpdateRequest{ID: added.ID, Patch: NotePatch{Metadata: &metadata}}); !errors.Is(err, ErrInvalidMetadata) {
+		t.Errorf("expected ErrInvalidMetadata on update, got %v", err)
+	}
+
+	upsertTestGlobal(t, memStore, "/test/project", model.GlobalKeyMetadataSchema, "strict")
+	if _, err := svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: "global", Text: "x"}); !errors.Is(err, ErrInvalidNotePolicy) {
+		t.Errorf("expected ErrInvalidNotePolicy, got %v", err)
+	}
+}
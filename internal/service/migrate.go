@@ -0,0 +1,114 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/embedder"
+	"github.com/brbranch/embedding_mcp/internal/store"
+)
+
+// StoreOpener はnamespaceを指定してStoreを開く（Initialize済みのものを返す）
+type StoreOpener func(ctx context.Context, namespace string) (store.Store, error)
+
+// migrateService はMigrateServiceの実装
+type migrateService struct {
+	export    ExportService
+	namespace string
+	open      StoreOpener
+}
+
+// NewMigrateService はMigrateServiceの新しいインスタンスを作成
+// 移行元のStoreはopenで開き、移行が終わったら閉じる
+func NewMigrateService(emb embedder.Embedder, s store.Store, namespace string, open StoreOpener) MigrateService {
+	return &migrateService{
+		export:    NewExportService(emb, s, namespace),
+		namespace: namespace,
+		open:      open,
+	}
+}
+
+// CountNotes はnamespaceのノート数（全プロジェクトの合計）を返す
+func (s *migrateService) CountNotes(ctx context.Context, namespace string) (int, error) {
+	if _, _, _, err := config.ParseNamespace(namespace); err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidNamespace, err)
+	}
+	src, err := s.open(ctx, namespace)
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	projects, err := src.ListProjects(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list projects: %w", err)
+	}
+	n := 0
+	for _, p := range projects {
+		n += p.NoteCount
+	}
+	return n, nil
+}
+
+// Migrate はreq.Fromのグループ・グローバル設定・ノートを現在のnamespaceへ取り込む
+// ノートは現在のembedderで再埋め込みする。移行先に同じID（グループはgroupKey、グローバル設定はkey）が
+// あるものはスキップするため、途中で失敗しても再実行すれば続きから移行できる。移行元は変更しない
+func (s *migrateService) Migrate(ctx context.Context, req *MigrateRequest) (*MigrateResponse, error) {
+	if req.From == "" {
+		return nil, ErrNamespaceRequired
+	}
+	if _, _, _, err := config.ParseNamespace(req.From); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidNamespace, err)
+	}
+	if req.From == s.namespace {
+		return nil, ErrSameNamespace
+	}
+
+	var projectIDs []string
+	if req.ProjectID != "" {
+		projectID, err := config.CanonicalizeProjectID(req.ProjectID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to canonicalize projectId: %w", err)
+		}
+		projectIDs = []string{projectID}
+	}
+
+	src, err := s.open(ctx, req.From)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	if projectIDs == nil {
+		projects, err := src.ListProjects(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list projects: %w", err)
+		}
+		for _, p := range projects {
+			projectIDs = append(projectIDs, p.ProjectID)
+		}
+	}
+
+	// 埋め込みは含めずに書き出し、取り込み時に再生成させる
+	srcExport := NewExportService(nil, src, req.From)
+	resp := &MigrateResponse{From: req.From, Namespace: s.namespace}
+	for _, projectID := range projectIDs {
+		var buf bytes.Buffer
+		if _, err := srcExport.Export(ctx, &buf, &ExportRequest{ProjectID: projectID}); err != nil {
+			return resp, fmt.Errorf("project %s: %w", projectID, err)
+		}
+		r, err := s.export.Import(ctx, &buf, &ImportRequest{ProjectID: projectID, Mode: ImportModeSkipExisting})
+		if r != nil {
+			resp.Created += r.Created
+			resp.Skipped += r.Skipped
+			resp.ReEmbedded += r.ReEmbedded
+		}
+		if err != nil {
+			return resp, fmt.Errorf("project %s: %w", projectID, err)
+		}
+		resp.Projects++
+	}
+	return resp, nil
+}
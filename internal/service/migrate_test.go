@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/store"
+)
+
+// nopCloseStore はCloseしても中身を消さないStore（MemoryStoreのCloseは全件削除するため）
+type nopCloseStore struct {
+	store.Store
+}
+
+func (nopCloseStore) Close() error { return nil }
+
+// setupMigrateTestService は移行元（old:mock:3）にテストデータを投入し、移行先（new:mock:3）のMigrateServiceを作成する
+func setupMigrateTestService(t *testing.T) (MigrateService, store.Store, *mockEmbedder) {
+	t.Helper()
+	ctx := context.Background()
+
+	src := store.NewMemoryStore()
+	if err := src.Initialize(ctx, "old:mock:3"); err != nil {
+		t.Fatal(err)
+	}
+	seedExportData(t, src, "/test/project")
+	if err := src.AddNote(ctx, &model.Note{ID: "note-2", ProjectID: "/test/other", GroupID: "global", Text: "second note", Tags: []string{}}, []float32{1, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := store.NewMemoryStore()
+	if err := dst.Initialize(ctx, "new:mock:3"); err != nil {
+		t.Fatal(err)
+	}
+	emb := &mockEmbedder{dim: 3}
+	open := func(ctx context.Context, namespace string) (store.Store, error) {
+		if namespace != "old:mock:3" {
+			empty := store.NewMemoryStore()
+			return empty, empty.Initialize(ctx, namespace)
+		}
+		return nopCloseStore{src}, nil
+	}
+	return NewMigrateService(emb, dst, "new:mock:3", open), dst, emb
+}
+
+func TestMigrateService_CountNotes(t *testing.T) {
+	ctx := context.Background()
+	svc, _, _ := setupMigrateTestService(t)
+
+	n, err := svc.CountNotes(ctx, "old:mock:3")
+	if err != nil {
+		t.Fatalf("CountNotes failed: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("expected 2 notes, got %d", n)
+	}
+	if n, _ := svc.CountNotes(ctx, "other:mock:3"); n != 0 {
+		t.Errorf("expected 0 notes in an unused namespace, got %d", n)
+	}
+	if _, err := svc.CountNotes(ctx, "invalid"); !errors.Is(err, ErrInvalidNamespace) {
+		t.Errorf("expected ErrInvalidNamespace, got %v", err)
+	}
+}
+
+func TestMigrateService_Migrate(t *testing.T) {
+	ctx := context.Background()
+	svc, dst, emb := setupMigrateTestService(t)
+	emb.embedFunc = func(ctx context.Context, text string) ([]float32, error) {
+		return []float32{0, 1, 0}, nil
+	}
+
+	resp, err := svc.Migrate(ctx, &MigrateRequest{From: "old:mock:3", ProjectID: "/test/project"})
+	if err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	// group・global・noteの3件
+	if resp.Projects != 1 || resp.Created != 3 || resp.ReEmbedded != 1 || resp.Namespace != "new:mock:3" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	embedding, err := dst.GetEmbedding(ctx, "note-1")
+	if err != nil {
+		t.Fatalf("migrated note not found: %v", err)
+	}
+	if embedding[1] != 1 {
+		t.Errorf("expected the note to be re-embedded, got %v", embedding)
+	}
+
+	// 全プロジェクト。移行済みのものはスキップする
+	resp, err = svc.Migrate(ctx, &MigrateRequest{From: "old:mock:3"})
+	if err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if resp.Projects != 2 || resp.Skipped != 3 || resp.Created != 1 || resp.ReEmbedded != 1 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if _, err := dst.Get(ctx, "note-2"); err != nil {
+		t.Errorf("expected /test/other to be migrated: %v", err)
+	}
+}
+
+func TestMigrateService_Migrate_InvalidRequest(t *testing.T) {
+	ctx := context.Background()
+	svc, _, _ := setupMigrateTestService(t)
+
+	tests := []struct {
+		from string
+		want error
+	}{
+		{"", ErrNamespaceRequired},
+		{"old-mock", ErrInvalidNamespace},
+		{"new:mock:3", ErrSameNamespace},
+	}
+	for _, tt := range tests {
+		if _, err := svc.Migrate(ctx, &MigrateRequest{From: tt.from}); !errors.Is(err, tt.want) {
+			t.Errorf("from %q: expected %v, got %v", tt.from, tt.want, err)
+		}
+	}
+}
@@ -1,8 +1,16 @@
 package service
 
 import (
+	"cmp"
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/brbranch/embedding_mcp/internal/config"
@@ -12,25 +20,225 @@ import (
 	"github.com/google/uuid"
 )
 
+// rerankFetchFactor はcollapseByParent・importanceで結果を絞り込む・並べ直す場合にtopKの何倍の候補を取得するか
+const rerankFetchFactor = 4
+
+// groupSearchWorkers はgroupBy=groupでgroupIdごとの検索を並行して行う数
+// groupIdが多いプロジェクトでも待ち時間がgroupId数の掛け算にならず、ストアへの同時リクエストも抑える
+const groupSearchWorkers = 4
+
+// projectSearchWorkers はprojectIds指定時にプロジェクトごとの検索を並行して行う数
+const projectSearchWorkers = 4
+
+// defaultImportanceBoost はglobal.memory.importanceBoost未設定時の、importance 1のノートのスコアへの加点
+const defaultImportanceBoost = 0.2
+
+// touchInterval は同じノートの最終アクセスを記録し直すまでの間隔（検索・取得のたびにストアへ書き込まないため）
+const touchInterval = time.Hour
+
 // noteService はNoteServiceの実装
 type noteService struct {
 	embedder  embedder.Embedder
 	store     store.Store
 	namespace string
+	updateMu  sync.Mutex // Updateの取得〜更新を直列化する（ifUpdatedAtの比較と更新の間に他の更新を挟まない）
+	groupMu   sync.Mutex // グループの自動作成の確認〜作成を直列化する（同じgroupKeyを二重に作らない）
+	// archive はアーカイブのコールドストア（nilならincludeArchivedは無視し、最終アクセスも記録しない）
+	archive *store.ColdStore
+	touchMu sync.Mutex
+	touched map[string]time.Time // ノートIDごとの最後に最終アクセスを記録した時刻
+	pruned  time.Time            // touchedから古い記録を最後に取り除いた時刻
 }
 
 // NewNoteService はNoteServiceの新しいインスタンスを作成
 func NewNoteService(emb embedder.Embedder, s store.Store, namespace string) NoteService {
+	return NewNoteServiceWithArchive(emb, s, nil, namespace)
+}
+
+// NewNoteServiceWithArchive はアーカイブのコールドストアを使うNoteServiceを作成する
+// 検索・取得したノートの最終アクセスを記録し（ArchiveServiceが古いノートを選ぶのに使う）、
+// SearchRequest.IncludeArchivedならアーカイブのノートも検索する
+func NewNoteServiceWithArchive(emb embedder.Embedder, s store.Store, archive *store.ColdStore, namespace string) NoteService {
 	return &noteService{
 		embedder:  emb,
 		store:     s,
 		namespace: namespace,
+		archive:   archive,
+		touched:   make(map[string]time.Time),
 	}
 }
 
 // AddNote はノートを追加する
 func (s *noteService) AddNote(ctx context.Context, req *AddNoteRequest) (*AddNoteResponse, error) {
+	req, policy, err := s.applyPolicy(ctx, req, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateOnDuplicate(req); err != nil {
+		return nil, err
+	}
+	note, err := buildNote(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkNoteGroup(ctx, note.ProjectID, note.GroupID, policy.groupPrefixes); err != nil {
+		return nil, err
+	}
+
+	// 埋め込み生成
+	embedding, err := s.embedder.Embed(ctx, req.Text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate embedding: %w", err)
+	}
+
+	resp := &AddNoteResponse{
+		ID:                 note.ID,
+		Namespace:          s.namespace,
+		CanonicalProjectID: note.ProjectID,
+	}
+
+	// 重複検出
+	if req.OnDuplicate != "" {
+		dup, err := s.findDuplicate(ctx, req, note, embedding)
+		if err != nil {
+			return nil, err
+		}
+		if dup != nil {
+			switch req.OnDuplicate {
+			case OnDuplicateReject:
+				return nil, &DuplicateNoteError{ID: dup.Note.ID, Score: dup.Score}
+			case OnDuplicateMerge:
+				if err := s.mergeDuplicate(ctx, dup.Note, note); err != nil {
+					return nil, err
+				}
+				resp.ID = dup.Note.ID
+				resp.Merged = true
+			}
+			resp.DuplicateID = dup.Note.ID
+			resp.DuplicateScore = dup.Score
+			if resp.Merged {
+				return resp, nil
+			}
+		}
+	}
+
+	if policy.autoCreateGroup {
+		if err := s.ensureGroup(ctx, note.ProjectID, note.GroupID, policy.groupPrefixes); err != nil {
+			return nil, err
+		}
+	}
+
+	// Storeに保存
+	if err := s.store.AddNote(ctx, note, embedding); err != nil {
+		return nil, fmt.Errorf("failed to add note to store: %w", err)
+	}
+
+	return resp, nil
+}
+
+// AddNotes は複数のノートをまとめて追加する
+// 全件のバリデーション後に埋め込みを一括生成（EmbedBatch）し、先頭から順に保存する
+// トランザクションに対応したストアでは途中で失敗すれば1件も追加しない（対応していなければResultsの分まで追加済み）
+// 失敗した場合もrespを返し、Itemsで項目ごとの結果（失敗した項目と未追加の項目）を報告する
+func (s *noteService) AddNotes(ctx context.Context, req *AddNotesRequest) (*AddNotesResponse, error) {
+	resp := &AddNotesResponse{Namespace: s.namespace, Items: newItemResults(len(req.Notes))}
+	if len(req.Notes) == 0 {
+		return resp, nil
+	}
+
+	// バリデーション（失敗した項目をすべて報告する）
+	notes := make([]*model.Note, len(req.Notes))
+	texts := make([]string, len(req.Notes))
+	policies := make(map[string]*notePolicy)
+	var firstErr error
+	for i := range req.Notes {
+		note, err := s.prepareNote(ctx, &req.Notes[i], policies)
+		if err != nil {
+			resp.Items[i].Status, resp.Items[i].Err = ItemFailed, err
+			if firstErr == nil {
+				firstErr = fmt.Errorf("notes[%d]: %w", i, err)
+			}
+			continue
+		}
+		notes[i] = note
+		texts[i] = note.Text
+		resp.Items[i].ID = note.ID
+	}
+	if firstErr != nil {
+		return resp, firstErr
+	}
+
+	// 埋め込み一括生成
+	embeddings, err := embedder.EmbedBatch(ctx, s.embedder, texts)
+	if err != nil {
+		return resp, fmt.Errorf("failed to generate embeddings: %w", err)
+	}
+
+	for _, note := range notes {
+		if policy := policies[note.ProjectID]; policy.autoCreateGroup {
+			if err := s.ensureGroup(ctx, note.ProjectID, note.GroupID, policy.groupPrefixes); err != nil {
+				return resp, err
+			}
+		}
+	}
+
+	failed := -1
+	atomic, err := store.WithTx(ctx, s.store, func(tx store.Store) error {
+		for i, note := range notes {
+			if err := tx.AddNote(ctx, note, embeddings[i]); err != nil {
+				failed = i
+				return fmt.Errorf("failed to add note to store: %w", err)
+			}
+			resp.Results = append(resp.Results, AddNoteResponse{
+				ID:                 note.ID,
+				Namespace:          s.namespace,
+				CanonicalProjectID: note.ProjectID,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		if atomic {
+			resp.Results = nil
+		}
+		for i := range resp.Results {
+			resp.Items[i].Status = ItemOK
+		}
+		if failed >= 0 {
+			resp.Items[failed].Status, resp.Items[failed].Err = ItemFailed, err
+		}
+		return resp, err
+	}
+
+	for i := range resp.Items {
+		resp.Items[i].Status = ItemOK
+	}
+	return resp, nil
+}
+
+// prepareNote はAddNotesの1件にポリシーを適用して検証し、追加するNoteを生成する
+func (s *noteService) prepareNote(ctx context.Context, req *AddNoteRequest, policies map[string]*notePolicy) (*model.Note, error) {
+	applied, policy, err := s.applyPolicy(ctx, req, policies)
+	if err != nil {
+		return nil, err
+	}
+	note, err := buildNote(applied)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkNoteGroup(ctx, note.ProjectID, note.GroupID, policy.groupPrefixes); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+// buildNote はリクエストを検証し、IDとcreatedAtを採番したNoteを生成する
+func buildNote(req *AddNoteRequest) (*model.Note, error) {
 	// バリデーション
+	// modelの振り分けはbootstrapのラッパーが行う（ここに届くのは設定にない名前）
+	if req.Model != "" {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownModel, req.Model)
+	}
 	if req.ProjectID == "" {
 		return nil, ErrProjectIDRequired
 	}
@@ -43,6 +251,15 @@ func (s *noteService) AddNote(ctx context.Context, req *AddNoteRequest) (*AddNot
 	if req.Text == "" {
 		return nil, ErrTextRequired
 	}
+	if err := ValidateAttachments(req.Attachments); err != nil {
+		return nil, err
+	}
+	if err := ValidateImportance(req.Importance); err != nil {
+		return nil, err
+	}
+	if err := CheckNoteLimits(req.Title, &req.Text, req.Tags, req.Metadata); err != nil {
+		return nil, err
+	}
 
 	// ProjectIDを正規化
 	canonicalProjectID, err := config.CanonicalizeProjectID(req.ProjectID)
@@ -50,23 +267,16 @@ func (s *noteService) AddNote(ctx context.Context, req *AddNoteRequest) (*AddNot
 		return nil, fmt.Errorf("failed to canonicalize projectId: %w", err)
 	}
 
-	// CreatedAtが指定されている場合はISO8601形式を検証
+	// IDとcreatedAtの生成（指定されている場合はISO8601形式を検証し、UTCに揃える）
+	id := newNoteID(canonicalProjectID, req.GroupID)
+	var createdAt *string
 	if req.CreatedAt != nil {
-		if _, err := time.Parse(time.RFC3339, *req.CreatedAt); err != nil {
-			return nil, fmt.Errorf("%w: %v", ErrInvalidTimeFormat, err)
+		normalized, err := NormalizeTimestamp(*req.CreatedAt)
+		if err != nil {
+			return nil, err
 		}
-	}
-
-	// 埋め込み生成
-	embedding, err := s.embedder.Embed(ctx, req.Text)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate embedding: %w", err)
-	}
-
-	// IDとcreatedAtの生成
-	id := uuid.New().String()
-	createdAt := req.CreatedAt
-	if createdAt == nil {
+		createdAt = &normalized
+	} else {
 		// RFC3339は秒までの精度なので、ナノ秒がある場合は次の秒に切り上げ
 		// これにより、テスト開始時刻（マイクロ秒を含む）より後になることを保証
 		now := time.Now().UTC()
@@ -78,34 +288,26 @@ func (s *noteService) AddNote(ctx context.Context, req *AddNoteRequest) (*AddNot
 	}
 
 	// Noteモデルの作成（正規化されたprojectIDを使用）
-	note := &model.Note{
-		ID:        id,
-		ProjectID: canonicalProjectID,
-		GroupID:   req.GroupID,
-		Title:     req.Title,
-		Text:      req.Text,
-		Tags:      req.Tags,
-		Source:    req.Source,
-		CreatedAt: createdAt,
-		Metadata:  req.Metadata,
-	}
-
-	// Storeに保存
-	if err := s.store.AddNote(ctx, note, embedding); err != nil {
-		return nil, fmt.Errorf("failed to add note to store: %w", err)
-	}
-
-	return &AddNoteResponse{
-		ID:                 id,
-		Namespace:          s.namespace,
-		CanonicalProjectID: canonicalProjectID,
+	return &model.Note{
+		ID:          id,
+		ProjectID:   canonicalProjectID,
+		GroupID:     req.GroupID,
+		Title:       req.Title,
+		Text:        req.Text,
+		Tags:        req.Tags,
+		Source:      req.Source,
+		CreatedAt:   createdAt,
+		Metadata:    withLanguage(req.Metadata, req.Text),
+		Attachments: req.Attachments,
+		Importance:  req.Importance,
 	}, nil
 }
 
 // Search は検索クエリに基づいてノートを検索する
 func (s *noteService) Search(ctx context.Context, req *SearchRequest) (*SearchResponse, error) {
 	// バリデーション
-	if req.ProjectID == "" {
+	projectIDs := SearchProjectIDs(req)
+	if len(projectIDs) == 0 {
 		return nil, ErrProjectIDRequired
 	}
 	if req.Query == "" {
@@ -117,6 +319,18 @@ func (s *noteService) Search(ctx context.Context, req *SearchRequest) (*SearchRe
 			return nil, err
 		}
 	}
+	if req.MinScore != nil && (*req.MinScore < 0 || *req.MinScore > 1) {
+		return nil, ErrInvalidMinScore
+	}
+	if req.GroupBy != "" && req.GroupBy != SearchGroupByGroup {
+		return nil, ErrInvalidGroupBy
+	}
+	if req.GroupTopK != nil && *req.GroupTopK <= 0 {
+		return nil, ErrInvalidGroupTopK
+	}
+	if len(projectIDs) > 1 && req.GroupBy != "" {
+		return nil, ErrGroupByMultiProject
+	}
 
 	// 埋め込み生成
 	embedding, err := s.embedder.Embed(ctx, req.Query)
@@ -147,48 +361,375 @@ func (s *noteService) Search(ctx context.Context, req *SearchRequest) (*SearchRe
 		until = &t
 	}
 
-	// 検索オプションの構築
-	opts := store.SearchOptions{
-		ProjectID: req.ProjectID,
-		GroupID:   req.GroupID,
+	if len(projectIDs) > 1 {
+		return s.searchProjects(ctx, embedding, projectIDs, req, topK, since, until)
+	}
+	opts, boost, err := s.searchOptions(ctx, projectIDs[0], req, topK, since, until)
+	if err != nil {
+		return nil, err
+	}
+	if req.GroupBy == SearchGroupByGroup {
+		return s.searchByGroup(ctx, embedding, opts, req, boost)
+	}
+	results, err := s.searchProject(ctx, embedding, opts, req, boost)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &SearchResponse{
+		Namespace: s.namespace,
+		Results:   results,
+	}
+	s.touchResults(ctx, resp.Results)
+	return resp, nil
+}
+
+// SearchProjectIDs はreqの検索対象のプロジェクト（ProjectIDとProjectIDs。空と重複を除く）を返す
+func SearchProjectIDs(req *SearchRequest) []string {
+	projectIDs := make([]string, 0, 1+len(req.ProjectIDs))
+	for _, projectID := range append([]string{req.ProjectID}, req.ProjectIDs...) {
+		if projectID != "" && !slices.Contains(projectIDs, projectID) {
+			projectIDs = append(projectIDs, projectID)
+		}
+	}
+	return projectIDs
+}
+
+// searchOptions はprojectIDを検索するストアの検索オプションとimportanceの加点を返す
+func (s *noteService) searchOptions(ctx context.Context, projectID string, req *SearchRequest, topK int, since, until *time.Time) (store.SearchOptions, float64, error) {
+	// 書き込み時と同じく正規化する（エイリアス・gitリモートのIDで保存されたノートを引けるように）
+	projectID, err := config.CanonicalizeProjectID(projectID)
+	if err != nil {
+		return store.SearchOptions{}, 0, fmt.Errorf("failed to canonicalize projectId: %w", err)
+	}
+	boost, err := loadImportanceBoost(ctx, s.store, projectID)
+	if err != nil {
+		return store.SearchOptions{}, 0, err
+	}
+	groupID, groupIDs, err := s.groupFilter(ctx, projectID, req.GroupID, req.IncludeDescendants)
+	if err != nil {
+		return store.SearchOptions{}, 0, err
+	}
+	return store.SearchOptions{
+		ProjectID: projectID,
+		GroupID:   groupID,
+		GroupIDs:  groupIDs,
 		TopK:      topK,
-		Tags:      req.Tags,
+		Tags:      NormalizeTags(req.Tags),
 		Since:     since,
 		Until:     until,
+	}, boost, nil
+}
+
+// searchProject はoptsの1つのプロジェクトを検索し、加点・絞り込みをしたopts.TopK件を返す
+func (s *noteService) searchProject(ctx context.Context, embedding []float32, opts store.SearchOptions, req *SearchRequest, boost float64) ([]SearchResult, error) {
+	topK := opts.TopK
+	// 親ごとにまとめる場合は同じ文書のチャンクで枠が埋まらないよう、
+	// importanceで加点する場合は加点で順位が上がるノートを取りこぼさないよう多めに取得する
+	// 言語で絞り込む場合も同様に、絞り込み後にtopK件残るよう多めに取得する
+	if req.CollapseByParent || boost > 0 || req.Language != nil {
+		opts.TopK = topK * rerankFetchFactor
+	}
+	results, err := s.searchStore(ctx, embedding, opts, req.IncludeArchived)
+	if err != nil {
+		return nil, err
+	}
+	return rankResults(results, req, boost, topK), nil
+}
+
+// searchProjects はprojectIDsのプロジェクトごとの検索を最大projectSearchWorkers件並行して行い、
+// スコア降順にまとめてtopK件を返す（プロジェクト数だけ待ち時間が積み重ならないように）
+func (s *noteService) searchProjects(ctx context.Context, embedding []float32, projectIDs []string, req *SearchRequest, topK int, since, until *time.Time) (*SearchResponse, error) {
+	perProject := make([][]SearchResult, len(projectIDs))
+	errs := make([]error, len(projectIDs))
+	sem := make(chan struct{}, projectSearchWorkers)
+	var wg sync.WaitGroup
+	for i, projectID := range projectIDs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			opts, boost, err := s.searchOptions(ctx, projectID, req, topK, since, until)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			perProject[i], errs[i] = s.searchProject(ctx, embedding, opts, req, boost)
+		}()
 	}
+	wg.Wait()
 
-	// Store検索
+	var results []SearchResult
+	seen := make(map[string]bool)
+	for i := range projectIDs {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		// エイリアスなどで同じプロジェクトに正規化された場合は同じノートが重複する
+		for _, r := range perProject[i] {
+			if !seen[r.ID] {
+				seen[r.ID] = true
+				results = append(results, r)
+			}
+		}
+	}
+	slices.SortStableFunc(results, func(a, b SearchResult) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+	if topK >= 0 && len(results) > topK {
+		results = results[:topK]
+	}
+	s.touchResults(ctx, results)
+	return &SearchResponse{
+		Namespace: s.namespace,
+		Results:   results,
+	}, nil
+}
+
+// searchStore はストアを検索する。includeArchivedならアーカイブも検索し、スコア降順にまとめてTopK件を返す
+func (s *noteService) searchStore(ctx context.Context, embedding []float32, opts store.SearchOptions, includeArchived bool) ([]store.SearchResult, error) {
 	results, err := s.store.Search(ctx, embedding, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search: %w", err)
 	}
+	if !includeArchived || s.archive == nil {
+		return results, nil
+	}
+	archived, err := s.archive.Search(embedding, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search archive: %w", err)
+	}
+	results = append(results, archived...)
+	slices.SortStableFunc(results, func(a, b store.SearchResult) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+	if opts.TopK > 0 && len(results) > opts.TopK {
+		results = results[:opts.TopK]
+	}
+	return results, nil
+}
+
+// touchResults は検索結果のうちアーカイブでないノートの最終アクセスを記録する
+func (s *noteService) touchResults(ctx context.Context, results []SearchResult) {
+	ids := make([]string, 0, len(results))
+	for _, r := range results {
+		if !r.Archived {
+			ids = append(ids, r.ID)
+		}
+	}
+	s.touch(ctx, ids)
+}
+
+// touch はノートの最終アクセスを記録する（touchInterval以内に記録したノートは省く）
+// 記録に失敗しても読み取り自体は成功させる（警告のみ）
+func (s *noteService) touch(ctx context.Context, ids []string) {
+	if s.archive == nil || len(ids) == 0 {
+		return
+	}
+	now := time.Now().UTC()
+	var due []string
+	s.touchMu.Lock()
+	// touchInterval以上前の記録は次のアクセスで記録し直すため不要。
+	// 削除・アーカイブされたノートの記録が残り続けないよう、touchIntervalごとにまとめて取り除く
+	if now.Sub(s.pruned) >= touchInterval {
+		maps.DeleteFunc(s.touched, func(_ string, last time.Time) bool {
+			return now.Sub(last) >= touchInterval
+		})
+		s.pruned = now
+	}
+	for _, id := range ids {
+		if last, ok := s.touched[id]; !ok || now.Sub(last) >= touchInterval {
+			s.touched[id] = now
+			due = append(due, id)
+		}
+	}
+	s.touchMu.Unlock()
+	if len(due) == 0 {
+		return
+	}
+	if err := s.store.TouchNotes(ctx, due, store.FormatTimestamp(now)); err != nil {
+		slog.Warn("failed to record note access", "error", err)
+	}
+}
+
+// searchByGroup はプロジェクトのgroupIdごとに検索し、groupIdごとにGroupTopK件までの結果をまとめて返す
+// 1回の検索の上位だけをまとめると、似たノートの多いgroupIdが枠を占めて他のgroupIdが結果に現れないため、
+// 絞り込み条件に一致するgroupIdそれぞれで検索する
+func (s *noteService) searchByGroup(ctx context.Context, embedding []float32, opts store.SearchOptions, req *SearchRequest, boost float64) (*SearchResponse, error) {
+	groupTopK := DefaultGroupTopK
+	if req.GroupTopK != nil {
+		groupTopK = *req.GroupTopK
+	}
+
+	stats, err := s.store.GroupStats(ctx, opts.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list groups: %w", err)
+	}
+	// アーカイブも検索する場合はアーカイブにだけ残っているgroupIdも含める
+	if req.IncludeArchived && s.archive != nil {
+		archived, err := s.archive.List(opts.ProjectID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list archive: %w", err)
+		}
+		for _, n := range archived {
+			if _, ok := stats[n.Note.GroupID]; !ok {
+				stats[n.Note.GroupID] = store.GroupStats{}
+			}
+		}
+	}
+	var groupIDs []string
+	for groupID := range stats {
+		if store.MatchesGroup(groupID, opts.GroupID, opts.GroupIDs) {
+			groupIDs = append(groupIDs, groupID)
+		}
+	}
+	slices.Sort(groupIDs)
+
+	// groupIdごとの検索は最大groupSearchWorkers件並行して行い、groupIDsの順にまとめる
+	perGroup := make([][]SearchResult, len(groupIDs))
+	errs := make([]error, len(groupIDs))
+	sem := make(chan struct{}, groupSearchWorkers)
+	var wg sync.WaitGroup
+	for i, groupID := range groupIDs {
+		groupOpts := opts
+		groupOpts.GroupID = &groupID
+		groupOpts.GroupIDs = nil
+		groupOpts.TopK = groupTopK
+		if req.CollapseByParent || boost > 0 || req.Language != nil {
+			groupOpts.TopK = groupTopK * rerankFetchFactor
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results, err := s.searchStore(ctx, embedding, groupOpts, req.IncludeArchived)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			perGroup[i] = rankResults(results, req, boost, groupTopK)
+		}()
+	}
+	wg.Wait()
+	var all []SearchResult
+	for i := range groupIDs {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		all = append(all, perGroup[i]...)
+	}
+
+	results, groups := GroupSearchResults(all, groupTopK, opts.TopK)
+	s.touchResults(ctx, results)
+	return &SearchResponse{
+		Namespace: s.namespace,
+		Results:   results,
+		Groups:    groups,
+	}, nil
+}
+
+// GroupSearchResults はresultsをgroupIdごとにgroupTopK件までにし、最も高いスコアの順にgroupIdを並べて
+// 合わせてtopK件までを返す（groupIdの中はスコア降順）
+func GroupSearchResults(results []SearchResult, groupTopK, topK int) ([]SearchResult, []SearchGroup) {
+	sorted := slices.Clone(results)
+	slices.SortStableFunc(sorted, func(a, b SearchResult) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+	var order []string
+	buckets := make(map[string][]SearchResult)
+	for _, r := range sorted {
+		if _, ok := buckets[r.GroupID]; !ok {
+			order = append(order, r.GroupID)
+		}
+		if len(buckets[r.GroupID]) < groupTopK {
+			buckets[r.GroupID] = append(buckets[r.GroupID], r)
+		}
+	}
+
+	grouped := make([]SearchResult, 0, len(sorted))
+	var groups []SearchGroup
+	for _, groupID := range order {
+		bucket := buckets[groupID]
+		if rest := topK - len(grouped); topK >= 0 && len(bucket) > rest {
+			bucket = bucket[:rest]
+		}
+		if len(bucket) == 0 {
+			break
+		}
+		grouped = append(grouped, bucket...)
+		groups = append(groups, SearchGroup{GroupID: groupID, Count: len(bucket), TopScore: bucket[0].Score})
+	}
+	return grouped, groups
+}
+
+// rankResults はストアの検索結果（スコア降順）にimportanceの加点・minScore・言語・親ごとのまとめを適用し、
+// 上位limit件を返す
+func rankResults(results []store.SearchResult, req *SearchRequest, boost float64, limit int) []SearchResult {
+	// importanceの加点はストアによらずここで適用する
+	if boost > 0 {
+		for i, r := range results {
+			if r.Note.Importance != nil {
+				results[i].Score = min(1, r.Score+boost**r.Note.Importance)
+			}
+		}
+		slices.SortStableFunc(results, func(a, b store.SearchResult) int {
+			return cmp.Compare(b.Score, a.Score)
+		})
+	}
 
 	// レスポンスの構築
 	searchResults := make([]SearchResult, 0, len(results))
+	parents := make(map[string]bool)
 	for _, r := range results {
+		// スコアは0-1に正規化済みのため全ストア共通で閾値を適用できる
+		if req.MinScore != nil && r.Score < *req.MinScore {
+			continue
+		}
+		if req.Language != nil && noteLanguage(r.Note) != *req.Language {
+			continue
+		}
+		// 結果はスコア降順なので、親ごとに最初の1件だけを残す
+		if req.CollapseByParent {
+			parent := r.Note.ID
+			if r.Note.ParentID != nil {
+				parent = *r.Note.ParentID
+			}
+			if parents[parent] {
+				continue
+			}
+			parents[parent] = true
+		}
+		if len(searchResults) == limit {
+			break
+		}
 		createdAt := ""
 		if r.Note.CreatedAt != nil {
 			createdAt = *r.Note.CreatedAt
 		}
 
 		searchResults = append(searchResults, SearchResult{
-			ID:        r.Note.ID,
-			ProjectID: r.Note.ProjectID,
-			GroupID:   r.Note.GroupID,
-			Title:     r.Note.Title,
-			Text:      r.Note.Text,
-			Tags:      r.Note.Tags,
-			Source:    r.Note.Source,
-			CreatedAt: createdAt,
-			Score:     r.Score,
-			Metadata:  r.Note.Metadata,
+			ID:          r.Note.ID,
+			ProjectID:   r.Note.ProjectID,
+			GroupID:     r.Note.GroupID,
+			Title:       r.Note.Title,
+			Text:        r.Note.Text,
+			Tags:        r.Note.Tags,
+			Source:      r.Note.Source,
+			CreatedAt:   createdAt,
+			UpdatedAt:   noteUpdatedAt(r.Note),
+			Score:       r.Score,
+			Metadata:    r.Note.Metadata,
+			Attachments: r.Note.Attachments,
+			ParentID:    r.Note.ParentID,
+			ChunkIndex:  r.Note.ChunkIndex,
+			Importance:  r.Note.Importance,
+			Archived:    r.Archived,
 		})
 	}
 
-	return &SearchResponse{
-		Namespace: s.namespace,
-		Results:   searchResults,
-	}, nil
+	return searchResults
 }
 
 // Get は指定されたIDのノートを取得する
@@ -206,6 +747,7 @@ func (s *noteService) Get(ctx context.Context, id string) (*GetResponse, error)
 		}
 		return nil, fmt.Errorf("failed to get note: %w", err)
 	}
+	s.touch(ctx, []string{note.ID})
 
 	// createdAtの取得
 	createdAt := ""
@@ -214,19 +756,65 @@ func (s *noteService) Get(ctx context.Context, id string) (*GetResponse, error)
 	}
 
 	return &GetResponse{
-		ID:        note.ID,
-		ProjectID: note.ProjectID,
-		GroupID:   note.GroupID,
-		Title:     note.Title,
-		Text:      note.Text,
-		Tags:      note.Tags,
-		Source:    note.Source,
-		CreatedAt: createdAt,
-		Namespace: s.namespace,
-		Metadata:  note.Metadata,
+		ID:          note.ID,
+		ProjectID:   note.ProjectID,
+		GroupID:     note.GroupID,
+		Title:       note.Title,
+		Text:        note.Text,
+		Tags:        note.Tags,
+		Source:      note.Source,
+		CreatedAt:   createdAt,
+		UpdatedAt:   noteUpdatedAt(note),
+		Namespace:   s.namespace,
+		Metadata:    note.Metadata,
+		Attachments: note.Attachments,
+		ParentID:    note.ParentID,
+		ChunkIndex:  note.ChunkIndex,
+		Importance:  note.Importance,
 	}, nil
 }
 
+// GetDocument は指定されたノートが属する文書を取得する
+// チャンク分割されたノートは親ノート（チャンク0）の内容で、本文を全チャンクの連結に置き換えて返す
+func (s *noteService) GetDocument(ctx context.Context, id string) (*GetResponse, error) {
+	resp, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if resp.ParentID == nil {
+		return resp, nil
+	}
+	if *resp.ParentID != resp.ID {
+		resp, err = s.Get(ctx, *resp.ParentID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get parent note: %w", err)
+		}
+	}
+
+	notes, err := s.store.ListNotes(ctx, resp.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notes: %w", err)
+	}
+	var chunks []*model.Note
+	for _, note := range notes {
+		if note.ParentID != nil && *note.ParentID == resp.ID && note.ChunkIndex != nil {
+			chunks = append(chunks, note)
+		}
+	}
+	slices.SortFunc(chunks, func(a, b *model.Note) int {
+		return *a.ChunkIndex - *b.ChunkIndex
+	})
+
+	texts := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		texts[i] = chunk.Text
+	}
+	if len(texts) > 0 {
+		resp.Text = joinChunks(texts)
+	}
+	return resp, nil
+}
+
 // Update はノートを更新する
 func (s *noteService) Update(ctx context.Context, req *UpdateRequest) error {
 	// バリデーション
@@ -234,6 +822,9 @@ func (s *noteService) Update(ctx context.Context, req *UpdateRequest) error {
 		return ErrIDRequired
 	}
 
+	s.updateMu.Lock()
+	defer s.updateMu.Unlock()
+
 	// 既存ノートを取得
 	note, err := s.store.Get(ctx, req.ID)
 	if err != nil {
@@ -243,6 +834,24 @@ func (s *noteService) Update(ctx context.Context, req *UpdateRequest) error {
 		return fmt.Errorf("failed to get note: %w", err)
 	}
 
+	// 楽観的ロック: 取得後に他で更新されていれば失敗させる
+	if req.IfUpdatedAt != nil && *req.IfUpdatedAt != noteUpdatedAt(note) {
+		return fmt.Errorf("%w: %s (updatedAt: %s)", ErrNoteConflict, req.ID, noteUpdatedAt(note))
+	}
+
+	// 変更する項目だけ上限を検証する（上限を下げる前のノートも他の項目は更新できる）
+	var patchTags []string
+	var patchMetadata map[string]any
+	if req.Patch.Tags != nil {
+		patchTags = NormalizeTags(*req.Patch.Tags)
+	}
+	if req.Patch.Metadata != nil {
+		patchMetadata = *req.Patch.Metadata
+	}
+	if err := CheckNoteLimits(req.Patch.Title, req.Patch.Text, patchTags, patchMetadata); err != nil {
+		return err
+	}
+
 	// パッチを適用
 	textChanged := false
 	if req.Patch.Title != nil {
@@ -252,8 +861,17 @@ func (s *noteService) Update(ctx context.Context, req *UpdateRequest) error {
 		note.Text = *req.Patch.Text
 		textChanged = true
 	}
+	var policy *notePolicy
+	if req.Patch.Tags != nil || req.Patch.Metadata != nil || req.Patch.GroupID != nil {
+		if policy, err = loadNotePolicy(ctx, s.store, note.ProjectID); err != nil {
+			return err
+		}
+	}
 	if req.Patch.Tags != nil {
-		note.Tags = *req.Patch.Tags
+		if err := CheckRequiredTags(patchTags, NormalizeTags(policy.requiredTags)); err != nil {
+			return err
+		}
+		note.Tags = patchTags
 	}
 	if req.Patch.Source != nil {
 		note.Source = req.Patch.Source
@@ -262,11 +880,39 @@ func (s *noteService) Update(ctx context.Context, req *UpdateRequest) error {
 		if err := ValidateGroupID(*req.Patch.GroupID); err != nil {
 			return err
 		}
+		if err := s.checkNoteGroup(ctx, note.ProjectID, *req.Patch.GroupID, policy.groupPrefixes); err != nil {
+			return err
+		}
 		note.GroupID = *req.Patch.GroupID
 	}
 	if req.Patch.Metadata != nil {
+		if policy.metadataSchema != nil {
+			if err := policy.metadataSchema.validate(*req.Patch.Metadata); err != nil {
+				return err
+			}
+		}
 		note.Metadata = *req.Patch.Metadata
 	}
+	if req.Patch.Attachments != nil {
+		if err := ValidateAttachments(*req.Patch.Attachments); err != nil {
+			return err
+		}
+		note.Attachments = *req.Patch.Attachments
+	}
+	if req.Patch.Importance != nil {
+		if err := ValidateImportance(req.Patch.Importance); err != nil {
+			return err
+		}
+		note.Importance = req.Patch.Importance
+	}
+	// text変更時は言語を検出し直す（patchのmetadataでlanguageを指定した場合はそちらを優先する）
+	if textChanged {
+		if _, ok := note.Metadata[MetadataKeyLanguage]; ok && (req.Patch.Metadata == nil || (*req.Patch.Metadata)[MetadataKeyLanguage] == nil) {
+			note.Metadata = maps.Clone(note.Metadata)
+			delete(note.Metadata, MetadataKeyLanguage)
+		}
+		note.Metadata = withLanguage(note.Metadata, note.Text)
+	}
 
 	// text変更時は再埋め込み
 	var embedding []float32
@@ -303,6 +949,19 @@ func (s *noteService) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+// groupFilter はSearch/ListRecentのgroup絞り込みを返す（projectIDは正規化済み）。
+// includeDescendantsならgroupIDの代わりに、groupIDとその子孫グループのgroupId一覧で絞り込む
+func (s *noteService) groupFilter(ctx context.Context, projectID string, groupID *string, includeDescendants bool) (*string, []string, error) {
+	if groupID == nil || !includeDescendants {
+		return groupID, nil, nil
+	}
+	groupIDs, err := descendantGroupKeys(ctx, s.store, projectID, *groupID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return nil, groupIDs, nil
+}
+
 // ListRecent は最近のノートを取得する
 func (s *noteService) ListRecent(ctx context.Context, req *ListRecentRequest) (*ListRecentResponse, error) {
 	// バリデーション
@@ -315,33 +974,67 @@ func (s *noteService) ListRecent(ctx context.Context, req *ListRecentRequest) (*
 			return nil, err
 		}
 	}
+	if req.SortBy != "" && req.SortBy != store.SortByCreatedAt && req.SortBy != store.SortByUpdatedAt {
+		return nil, ErrInvalidSortBy
+	}
+	projectID, err := config.CanonicalizeProjectID(req.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to canonicalize projectId: %w", err)
+	}
+
+	after, err := decodeListCursor(req.Cursor, req.SortBy)
+	if err != nil {
+		return nil, err
+	}
 
 	// Limitのデフォルト値
 	limit := 10
 	if req.Limit != nil {
 		limit = *req.Limit
-		// limit=0の場合は0件を返す（明示的に0件要求）
-		if limit == 0 {
-			return &ListRecentResponse{
-				Namespace: s.namespace,
-				Items:     []ListRecentItem{},
-			}, nil
-		}
+	}
+
+	groupID, groupIDs, err := s.groupFilter(ctx, projectID, req.GroupID, req.IncludeDescendants)
+	if err != nil {
+		return nil, err
 	}
 
 	// リストオプションの構築
 	opts := store.ListOptions{
-		ProjectID: req.ProjectID,
-		GroupID:   req.GroupID,
+		ProjectID: projectID,
+		GroupID:   groupID,
+		GroupIDs:  groupIDs,
 		Limit:     limit,
-		Tags:      req.Tags,
+		Tags:      NormalizeTags(req.Tags),
+		SortBy:    req.SortBy,
+		After:     after,
 	}
 
-	// Storeから取得
+	total, err := s.store.CountNotes(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count notes: %w", err)
+	}
+	// limit=0の場合は0件を返す（明示的に0件要求。件数だけを取得する）
+	if limit == 0 {
+		return &ListRecentResponse{
+			Namespace: s.namespace,
+			Items:     []ListRecentItem{},
+			Total:     total,
+		}, nil
+	}
+
+	// Storeから取得（続きがあるか判定するため1件多く取得する）
+	if limit > 0 {
+		opts.Limit = limit + 1
+	}
 	notes, err := s.store.ListRecent(ctx, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list recent notes: %w", err)
 	}
+	nextCursor := ""
+	if limit > 0 && len(notes) > limit {
+		notes = notes[:limit]
+		nextCursor = encodeListCursor(store.NewListCursor(notes[limit-1], req.SortBy), req.SortBy)
+	}
 
 	// レスポンスの構築
 	items := make([]ListRecentItem, 0, len(notes))
@@ -352,21 +1045,314 @@ func (s *noteService) ListRecent(ctx context.Context, req *ListRecentRequest) (*
 		}
 
 		items = append(items, ListRecentItem{
-			ID:        note.ID,
-			ProjectID: note.ProjectID,
-			GroupID:   note.GroupID,
-			Title:     note.Title,
-			Text:      note.Text,
-			Tags:      note.Tags,
-			Source:    note.Source,
-			CreatedAt: createdAt,
-			Namespace: s.namespace,
-			Metadata:  note.Metadata,
+			ID:          note.ID,
+			ProjectID:   note.ProjectID,
+			GroupID:     note.GroupID,
+			Title:       note.Title,
+			Text:        note.Text,
+			Tags:        note.Tags,
+			Source:      note.Source,
+			CreatedAt:   createdAt,
+			UpdatedAt:   noteUpdatedAt(note),
+			Namespace:   s.namespace,
+			Metadata:    note.Metadata,
+			Attachments: note.Attachments,
+			ParentID:    note.ParentID,
+			ChunkIndex:  note.ChunkIndex,
+			Importance:  note.Importance,
 		})
 	}
 
 	return &ListRecentResponse{
-		Namespace: s.namespace,
-		Items:     items,
+		Namespace:  s.namespace,
+		Items:      items,
+		Total:      total,
+		NextCursor: nextCursor,
 	}, nil
 }
+
+// listCursor はListRecentのCursorの内容（クライアントには不透明な文字列として渡す）
+type listCursor struct {
+	SortBy string `json:"s"`
+	Key    string `json:"k"`
+	ID     string `json:"i"`
+}
+
+// ListRecentCursor はitemの次から一覧を続けるCursorを返す（複数の一覧をまとめて返す場合用）
+func ListRecentCursor(item *ListRecentItem, sortBy string) string {
+	key := item.CreatedAt
+	if sortBy == store.SortByUpdatedAt {
+		key = item.UpdatedAt
+	}
+	return encodeListCursor(&store.ListCursor{Key: key, ID: item.ID}, sortBy)
+}
+
+// encodeListCursor はカーソルをCursorの文字列（JSONのbase64url）にする
+func encodeListCursor(c *store.ListCursor, sortBy string) string {
+	data, _ := json.Marshal(listCursor{SortBy: listSortBy(sortBy), Key: c.Key, ID: c.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeListCursor はCursorの文字列をカーソルに戻す（空ならnil）
+// 別のsortByで発行されたCursorは並び順が異なるためErrInvalidCursorにする
+func decodeListCursor(cursor, sortBy string) (*store.ListCursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c listCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	if c.SortBy != listSortBy(sortBy) {
+		return nil, fmt.Errorf("%w: issued for sortBy %s", ErrInvalidCursor, c.SortBy)
+	}
+	return &store.ListCursor{Key: c.Key, ID: c.ID}, nil
+}
+
+// listSortBy はsortByの省略をcreatedAtにする
+func listSortBy(sortBy string) string {
+	if sortBy == "" {
+		return store.SortByCreatedAt
+	}
+	return sortBy
+}
+
+// ListProjects はストア内のプロジェクト一覧を取得する（projectID昇順）
+func (s *noteService) ListProjects(ctx context.Context) (*ListProjectsResponse, error) {
+	projects, err := s.store.ListProjects(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+
+	resp := &ListProjectsResponse{
+		Namespace: s.namespace,
+		Projects:  make([]ProjectItem, 0, len(projects)),
+	}
+	for _, p := range projects {
+		resp.Projects = append(resp.Projects, ProjectItem{ProjectID: p.ProjectID, NoteCount: p.NoteCount})
+	}
+	return resp, nil
+}
+
+// noteUpdatedAt はノートのupdatedAtを返す（ない場合はcreatedAt）
+func noteUpdatedAt(note *model.Note) string {
+	if note.UpdatedAt != nil {
+		return *note.UpdatedAt
+	}
+	if note.CreatedAt != nil {
+		return *note.CreatedAt
+	}
+	return ""
+}
+
+// notePolicy はプロジェクトのノート追加ポリシー（GlobalConfigのglobal.memory.*で設定）
+type notePolicy struct {
+	defaultGroup string   // global.memory.defaultGroup
+	tags         []string // global.memory.tags
+	requiredTags []string // global.memory.requiredTags
+	// autoCreateGroup はglobal.memory.autoCreateGroup（未登録のgroupIdのグループを作成する）
+	autoCreateGroup bool
+	// groupPrefixes はglobal.memory.groupDefaultsのプレフィックス（未登録のgroupIdはいずれかで始まる必要がある）
+	groupPrefixes []string
+	// metadataSchema はglobal.memory.metadataSchema（未設定ならnil）
+	metadataSchema *metadataSchema
+}
+
+// loadNotePolicy はprojectID（正規化済み）のポリシーを読み込む（未設定の項目はゼロ値）
+func loadNotePolicy(ctx context.Context, st store.Store, projectID string) (*notePolicy, error) {
+	p := &notePolicy{}
+	for _, key := range []string{model.GlobalKeyDefaultGroup, model.GlobalKeyTags, model.GlobalKeyRequiredTags, model.GlobalKeyMetadataSchema, model.GlobalKeyAutoCreateGroup, model.GlobalKeyGroupDefaults} {
+		g, found, err := st.GetGlobal(ctx, projectID, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s: %w", key, err)
+		}
+		if !found {
+			continue
+		}
+		switch key {
+		case model.GlobalKeyDefaultGroup:
+			group, ok := g.Value.(string)
+			if !ok {
+				return nil, fmt.Errorf("%w: %s must be a string, got %v", ErrInvalidNotePolicy, key, g.Value)
+			}
+			if err := ValidateGroupID(group); err != nil {
+				return nil, fmt.Errorf("%w: %s: %v", ErrInvalidNotePolicy, key, err)
+			}
+			p.defaultGroup = group
+		case model.GlobalKeyTags:
+			if p.tags, err = policyStrings(key, g.Value); err != nil {
+				return nil, err
+			}
+		case model.GlobalKeyRequiredTags:
+			if p.requiredTags, err = policyStrings(key, g.Value); err != nil {
+				return nil, err
+			}
+		case model.GlobalKeyMetadataSchema:
+			if p.metadataSchema, err = parseMetadataSchema(g.Value); err != nil {
+				return nil, fmt.Errorf("%w: %s: %v", ErrInvalidNotePolicy, key, err)
+			}
+		case model.GlobalKeyAutoCreateGroup:
+			autoCreate, ok := g.Value.(bool)
+			if !ok {
+				return nil, fmt.Errorf("%w: %s must be a boolean, got %v", ErrInvalidNotePolicy, key, g.Value)
+			}
+			p.autoCreateGroup = autoCreate
+		case model.GlobalKeyGroupDefaults:
+			if p.groupPrefixes, err = parseGroupPrefixes(g.Value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return p, nil
+}
+
+// policyStrings はポリシーの値（JSONの文字列配列）を[]stringに変換する
+func policyStrings(key string, value any) ([]string, error) {
+	items, ok := value.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s must be an array of strings, got %v", ErrInvalidNotePolicy, key, value)
+	}
+	strs := make([]string, len(items))
+	for i, item := range items {
+		if strs[i], ok = item.(string); !ok {
+			return nil, fmt.Errorf("%w: %s must be an array of strings, got %v", ErrInvalidNotePolicy, key, value)
+		}
+	}
+	return strs, nil
+}
+
+// loadImportanceBoost はprojectIDのimportance 1のノートへの加点を読み込む（未設定ならdefaultImportanceBoost）
+func loadImportanceBoost(ctx context.Context, st store.Store, projectID string) (float64, error) {
+	return loadFraction(ctx, st, projectID, model.GlobalKeyImportanceBoost, defaultImportanceBoost)
+}
+
+// loadFraction はprojectIDのグローバル設定keyを0-1の数値として読み込む（未設定ならdef）
+func loadFraction(ctx context.Context, st store.Store, projectID, key string, def float64) (float64, error) {
+	g, found, err := st.GetGlobal(ctx, projectID, key)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get %s: %w", key, err)
+	}
+	if !found {
+		return def, nil
+	}
+	// ストアによって整数はint64で返る
+	var f float64
+	switch v := g.Value.(type) {
+	case float64:
+		f = v
+	case int64:
+		f = float64(v)
+	default:
+		return 0, fmt.Errorf("%w: %s must be a number, got %v", ErrInvalidNotePolicy, key, g.Value)
+	}
+	if f < 0 || f > 1 {
+		return 0, fmt.Errorf("%w: %s must be between 0 and 1, got %v", ErrInvalidNotePolicy, key, f)
+	}
+	return f, nil
+}
+
+// applyPolicy はadd_noteのリクエストにプロジェクトのポリシーを適用する
+// groupId省略時はdefaultGroup、tagsには自動タグを追加し、必須タグが揃っているか検証する
+// policiesは読み込んだポリシーのキャッシュ（nilならキャッシュしない）。適用したポリシーも返す
+func (s *noteService) applyPolicy(ctx context.Context, req *AddNoteRequest, policies map[string]*notePolicy) (*AddNoteRequest, *notePolicy, error) {
+	if req.ProjectID == "" {
+		return req, &notePolicy{}, nil // buildNoteでErrProjectIDRequired
+	}
+	projectID, err := config.CanonicalizeProjectID(req.ProjectID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to canonicalize projectId: %w", err)
+	}
+	policy, ok := policies[projectID]
+	if !ok {
+		if policy, err = loadNotePolicy(ctx, s.store, projectID); err != nil {
+			return nil, nil, err
+		}
+		if policies != nil {
+			policies[projectID] = policy
+		}
+	}
+
+	applied := *req
+	if applied.GroupID == "" {
+		applied.GroupID = policy.defaultGroup
+	}
+	applied.Tags = NormalizeTags(config.MergeTags(applied.Tags, policy.tags))
+	if err := CheckRequiredTags(applied.Tags, NormalizeTags(policy.requiredTags)); err != nil {
+		return nil, nil, err
+	}
+	if policy.metadataSchema != nil {
+		if err := policy.metadataSchema.validate(applied.Metadata); err != nil {
+			return nil, nil, err
+		}
+	}
+	return &applied, policy, nil
+}
+
+// checkNoteGroup はノートのgroupIdがglobal.memory.groupDefaultsのプレフィックスで始まるか検証する
+// 登録済みのグループ（プレフィックスを設定する前に作成したもの）は許可する
+func (s *noteService) checkNoteGroup(ctx context.Context, projectID, groupID string, prefixes []string) error {
+	prefixErr := checkGroupKeyPrefix(groupID, prefixes)
+	if prefixErr == nil {
+		return nil
+	}
+	if _, err := s.store.GetGroupByKey(ctx, projectID, groupID); err != store.ErrNotFound {
+		if err != nil {
+			return fmt.Errorf("failed to get group: %w", err)
+		}
+		return nil
+	}
+	return prefixErr
+}
+
+// ensureGroup はprojectID（正規化済み）にgroupKeyのグループがなければ作成する（global.memory.autoCreateGroup）
+// titleはgroupKeyからprefixes（global.memory.groupDefaultsのプレフィックス）を除いたもの
+func (s *noteService) ensureGroup(ctx context.Context, projectID, groupKey string, prefixes []string) error {
+	if groupKey == model.GlobalGroupID {
+		return nil
+	}
+
+	s.groupMu.Lock()
+	defer s.groupMu.Unlock()
+
+	_, err := s.store.GetGroupByKey(ctx, projectID, groupKey)
+	if err == nil {
+		return nil
+	}
+	if err != store.ErrNotFound {
+		return fmt.Errorf("failed to get group: %w", err)
+	}
+
+	now := time.Now().UTC()
+	group := &model.Group{
+		ID:        uuid.New().String(),
+		ProjectID: projectID,
+		GroupKey:  groupKey,
+		Title:     defaultGroupTitle(groupKey, prefixes),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Status:    model.GroupStatusActive,
+	}
+	if err := s.store.AddGroup(ctx, group); err != nil {
+		return fmt.Errorf("failed to add group to store: %w", err)
+	}
+	return nil
+}
+
+// CheckRequiredTags はtagsにrequiredのタグがすべて含まれているか検証する
+// 足りない場合はErrMissingRequiredTagsに足りないタグを付けて返す
+func CheckRequiredTags(tags, required []string) error {
+	var missing []string
+	for _, r := range required {
+		if !slices.Contains(tags, r) {
+			missing = append(missing, r)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s (required: %s)", ErrMissingRequiredTags, strings.Join(missing, ", "), strings.Join(required, ", "))
+	}
+	return nil
+}
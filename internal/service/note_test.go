@@ -3,10 +3,15 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"maps"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/brbranch/embedding_mcp/internal/config"
 	"github.com/brbranch/embedding_mcp/internal/embedder"
+	"github.com/brbranch/embedding_mcp/internal/model"
 	"github.com/brbranch/embedding_mcp/internal/store"
 )
 
@@ -126,6 +131,25 @@ func TestNoteService_AddNote_TextRequired(t *testing.T) {
 	}
 }
 
+// TestNoteService_AddNote_UnknownModel はembeddersの振り分けを通らないmodel指定を拒否することをテスト
+func TestNoteService_AddNote_UnknownModel(t *testing.T) {
+	memStore := store.NewMemoryStore()
+	emb := &mockEmbedder{dim: 3}
+	svc := newTestNoteService(emb, memStore, "openai:test:3")
+
+	req := &AddNoteRequest{
+		ProjectID: "/test/project",
+		GroupID:   "global",
+		Text:      "func main() {}",
+		Model:     "code",
+	}
+
+	_, err := svc.AddNote(context.Background(), req)
+	if !errors.Is(err, ErrUnknownModel) {
+		t.Errorf("expected ErrUnknownModel, got %v", err)
+	}
+}
+
 func TestNoteService_AddNote_CreatedAtDefault(t *testing.T) {
 	memStore := store.NewMemoryStore()
 	emb := &mockEmbedder{dim: 3}
@@ -472,6 +496,128 @@ func TestNoteService_Update_NotFound(t *testing.T) {
 	}
 }
 
+func TestNoteService_Update_IfUpdatedAt(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestNoteService(&mockEmbedder{dim: 3}, store.NewMemoryStore(), "openai:test:3")
+
+	addResp, _ := svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: "global", Text: "original text"})
+	before, _ := svc.Get(ctx, addResp.ID)
+	if before.UpdatedAt == "" {
+		t.Fatal("expected updatedAt to be set on add")
+	}
+
+	title := "first"
+	if err := svc.Update(ctx, &UpdateRequest{ID: addResp.ID, Patch: NotePatch{Title: &title}, IfUpdatedAt: &before.UpdatedAt}); err != nil {
+		t.Fatalf("Update with the current updatedAt failed: %v", err)
+	}
+	after, _ := svc.Get(ctx, addResp.ID)
+	if after.UpdatedAt == before.UpdatedAt {
+		t.Errorf("expected updatedAt to change, got %s", after.UpdatedAt)
+	}
+
+	// 古いupdatedAtでの更新は失敗し、内容は変わらない
+	title = "second"
+	err := svc.Update(ctx, &UpdateRequest{ID: addResp.ID, Patch: NotePatch{Title: &title}, IfUpdatedAt: &before.UpdatedAt})
+	if !errors.Is(err, ErrNoteConflict) {
+		t.Fatalf("expected ErrNoteConflict, got %v", err)
+	}
+	if got, _ := svc.Get(ctx, addResp.ID); *got.Title != "first" {
+		t.Errorf("expected the note to be unchanged, got %q", *got.Title)
+	}
+}
+
+func TestNoteService_Attachments(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestNoteService(&mockEmbedder{dim: 3}, store.NewMemoryStore(), "openai:test:3")
+
+	attachments := []model.Attachment{{Path: "docs/design.md", Hash: "sha256:abcd"}}
+	addResp, err := svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: "global", Text: "decision", Attachments: attachments})
+	if err != nil {
+		t.Fatalf("AddNote failed: %v", err)
+	}
+	got, _ := svc.Get(ctx, addResp.ID)
+	if len(got.Attachments) != 1 || got.Attachments[0] != attachments[0] {
+		t.Errorf("unexpected attachments: %+v", got.Attachments)
+	}
+
+	// 置き換え（空配列でクリア）
+	empty := []model.Attachment{}
+	if err := svc.Update(ctx, &UpdateRequest{ID: addResp.ID, Patch: NotePatch{Attachments: &empty}}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if got, _ := svc.Get(ctx, addResp.ID); len(got.Attachments) != 0 {
+		t.Errorf("expected attachments to be cleared, got %+v", got.Attachments)
+	}
+
+	invalid := []model.Attachment{{}}
+	if _, err := svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: "global", Text: "x", Attachments: invalid}); !errors.Is(err, ErrInvalidAttachment) {
+		t.Errorf("expected ErrInvalidAttachment on add, got %v", err)
+	}
+	if err := svc.Update(ctx, &UpdateRequest{ID: addResp.ID, Patch: NotePatch{Attachments: &invalid}}); !errors.Is(err, ErrInvalidAttachment) {
+		t.Errorf("expected ErrInvalidAttachment on update, got %v", err)
+	}
+}
+
+func TestNoteService_Importance(t *testing.T) {
+	ctx := context.Background()
+	memStore := store.NewMemoryStore()
+	vectors := map[string][]float32{
+		"q":          {1, 0, 0},
+		"routine":    {0.9, 0.436, 0},
+		"convention": {0.8, 0.6, 0},
+	}
+	svc := newTestNoteService(&mockEmbedder{dim: 3, embedFunc: func(ctx context.Context, text string) ([]float32, error) {
+		return vectors[text], nil
+	}}, memStore, "openai:test:3")
+
+	high := 1.0
+	if _, err := svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: "global", Text: "routine"}); err != nil {
+		t.Fatalf("AddNote failed: %v", err)
+	}
+	added, err := svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: "global", Text: "convention", Importance: &high})
+	if err != nil {
+		t.Fatalf("AddNote failed: %v", err)
+	}
+
+	search := func() []SearchResult {
+		t.Helper()
+		resp, err := svc.Search(ctx, &SearchRequest{ProjectID: "/test/project", Query: "q"})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		return resp.Results
+	}
+	// デフォルトの加点で重要なノートが上位になる
+	if results := search(); len(results) != 2 || results[0].ID != added.ID || *results[0].Importance != 1 {
+		t.Errorf("expected the important note first, got %+v", results)
+	}
+
+	upsertTestGlobal(t, memStore, "/test/project", model.GlobalKeyImportanceBoost, 0.0)
+	if results := search(); results[0].Text != "routine" {
+		t.Errorf("expected no boost with importanceBoost 0, got %+v", results)
+	}
+
+	upsertTestGlobal(t, memStore, "/test/project", model.GlobalKeyImportanceBoost, "high")
+	if _, err := svc.Search(ctx, &SearchRequest{ProjectID: "/test/project", Query: "q"}); !errors.Is(err, ErrInvalidNotePolicy) {
+		t.Errorf("expected ErrInvalidNotePolicy, got %v", err)
+	}
+
+	invalid := 1.5
+	if _, err := svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: "global", Text: "x", Importance: &invalid}); !errors.Is(err, ErrInvalidImportance) {
+		t.Errorf("expected ErrInvalidImportance on add, got %v", err)
+	}
+	if err := svc.Update(ctx, &UpdateRequest{ID: added.ID, Patch: NotePatch{Importance: &invalid}}); !errors.Is(err, ErrInvalidImportance) {
+		t.Errorf("expected ErrInvalidImportance on update, got %v", err)
+	}
+	low := 0.0
+	if err := svc.Update(ctx, &UpdateRequest{ID: added.ID, Patch: NotePatch{Importance: &low}}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if got, _ := svc.Get(ctx, added.ID); got.Importance == nil || *got.Importance != 0 {
+		t.Errorf("expected importance 0, got %v", got.Importance)
+	}
+}
+
 func TestNoteService_ListRecent_Success(t *testing.T) {
 	memStore := store.NewMemoryStore()
 	emb := &mockEmbedder{dim: 3}
@@ -507,6 +653,37 @@ func TestNoteService_ListRecent_Success(t *testing.T) {
 	}
 }
 
+func TestNoteService_SearchAndListRecent_ProjectAlias(t *testing.T) {
+	ctx := context.Background()
+	config.SetCanonicalizeRules(config.DefaultCanonicalizeRules.WithAliases(map[string]string{"/work/app": "acme/app"}))
+	t.Cleanup(func() { config.SetCanonicalizeRules(config.DefaultCanonicalizeRules) })
+
+	svc := newTestNoteService(&mockEmbedder{dim: 3}, store.NewMemoryStore(), "openai:test:3")
+	added, err := svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/work/app/", GroupID: "global", Text: "aliased note"})
+	if err != nil {
+		t.Fatalf("AddNote failed: %v", err)
+	}
+
+	// 書き込みと同じエイリアス（表記揺れを含む）・対応先のどちらでも引ける
+	for _, projectID := range []string{"/work/app/", "/work/app", "acme/app"} {
+		searchResp, err := svc.Search(ctx, &SearchRequest{ProjectID: projectID, Query: "aliased"})
+		if err != nil {
+			t.Fatalf("Search(%s) failed: %v", projectID, err)
+		}
+		if len(searchResp.Results) != 1 || searchResp.Results[0].ID != added.ID {
+			t.Errorf("Search(%s): expected the aliased note, got %+v", projectID, searchResp.Results)
+		}
+
+		listResp, err := svc.ListRecent(ctx, &ListRecentRequest{ProjectID: projectID})
+		if err != nil {
+			t.Fatalf("ListRecent(%s) failed: %v", projectID, err)
+		}
+		if len(listResp.Items) != 1 || listResp.Items[0].ProjectID != "acme/app" || listResp.Total != 1 {
+			t.Errorf("ListRecent(%s): expected the aliased note, got %+v (total %d)", projectID, listResp.Items, listResp.Total)
+		}
+	}
+}
+
 func TestNoteService_ListRecent_WithGroupID(t *testing.T) {
 	memStore := store.NewMemoryStore()
 	emb := &mockEmbedder{dim: 3}
@@ -544,6 +721,51 @@ func TestNoteService_ListRecent_WithGroupID(t *testing.T) {
 	}
 }
 
+func TestNoteService_IncludeDescendants(t *testing.T) {
+	ctx := context.Background()
+	memStore := store.NewMemoryStore()
+	svc := newTestNoteService(&mockEmbedder{dim: 3}, memStore, "openai:test:3")
+	groups := NewGroupService(memStore, "openai:test:3")
+
+	// feature-auth > feature-auth-login > feature-auth-tokens、feature-billingは無関係
+	for _, g := range [][2]string{{"feature-auth", ""}, {"feature-auth-login", "feature-auth"}, {"feature-auth-tokens", "feature-auth-login"}, {"feature-billing", ""}} {
+		if _, err := groups.CreateGroup(ctx, &CreateGroupRequest{ProjectID: "/test/project", GroupKey: g[0], Title: g[0], ParentGroupID: g[1]}); err != nil {
+			t.Fatalf("CreateGroup(%s) failed: %v", g[0], err)
+		}
+		if _, err := svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: g[0], Text: g[0] + " note"}); err != nil {
+			t.Fatalf("AddNote(%s) failed: %v", g[0], err)
+		}
+	}
+
+	groupID := "feature-auth"
+	list, err := svc.ListRecent(ctx, &ListRecentRequest{ProjectID: "/test/project", GroupID: &groupID, IncludeDescendants: true})
+	if err != nil {
+		t.Fatalf("ListRecent failed: %v", err)
+	}
+	if len(list.Items) != 3 {
+		t.Errorf("expected 3 notes under feature-auth, got %d", len(list.Items))
+	}
+	for _, item := range list.Items {
+		if item.GroupID == "feature-billing" {
+			t.Errorf("unexpected note from %s", item.GroupID)
+		}
+	}
+
+	search, err := svc.Search(ctx, &SearchRequest{ProjectID: "/test/project", GroupID: &groupID, Query: "note", IncludeDescendants: true})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(search.Results) != 3 {
+		t.Errorf("expected 3 results under feature-auth, got %d", len(search.Results))
+	}
+
+	// 指定しなければgroupIdのみ
+	search, _ = svc.Search(ctx, &SearchRequest{ProjectID: "/test/project", GroupID: &groupID, Query: "note"})
+	if len(search.Results) != 1 {
+		t.Errorf("expected 1 result without descendants, got %d", len(search.Results))
+	}
+}
+
 func TestNoteService_ListRecent_WithLimit(t *testing.T) {
 	memStore := store.NewMemoryStore()
 	emb := &mockEmbedder{dim: 3}
@@ -590,6 +812,41 @@ func TestNoteService_ListRecent_ProjectIDRequired(t *testing.T) {
 	}
 }
 
+func TestNoteService_ListRecent_SortBy(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestNoteService(&mockEmbedder{dim: 3}, store.NewMemoryStore(), "openai:test:3")
+
+	older, newer := "2024-01-01T00:00:00Z", "2024-01-02T00:00:00Z"
+	first, _ := svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: "global", Text: "older", CreatedAt: &older})
+	_, _ = svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: "global", Text: "newer", CreatedAt: &newer})
+	title := "touched"
+	if err := svc.Update(ctx, &UpdateRequest{ID: first.ID, Patch: NotePatch{Title: &title}}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	tests := []struct {
+		sortBy string
+		want   string
+	}{
+		{"", "newer"},
+		{"createdAt", "newer"},
+		{"updatedAt", "older"},
+	}
+	for _, tt := range tests {
+		resp, err := svc.ListRecent(ctx, &ListRecentRequest{ProjectID: "/test/project", SortBy: tt.sortBy})
+		if err != nil {
+			t.Fatalf("ListRecent failed: %v", err)
+		}
+		if len(resp.Items) != 2 || resp.Items[0].Text != tt.want {
+			t.Errorf("sortBy %q: expected %q first, got %+v", tt.sortBy, tt.want, resp.Items)
+		}
+	}
+
+	if _, err := svc.ListRecent(ctx, &ListRecentRequest{ProjectID: "/test/project", SortBy: "title"}); !errors.Is(err, ErrInvalidSortBy) {
+		t.Errorf("expected ErrInvalidSortBy, got %v", err)
+	}
+}
+
 func TestNoteService_ListRecent_LimitZero(t *testing.T) {
 	memStore := store.NewMemoryStore()
 	emb := &mockEmbedder{dim: 3}
@@ -619,6 +876,57 @@ func TestNoteService_ListRecent_LimitZero(t *testing.T) {
 	if len(resp.Items) != 0 {
 		t.Errorf("expected 0 items with limit=0, got %d", len(resp.Items))
 	}
+	// total is still reported, so limit=0 can be used to count notes
+	if resp.Total != 1 || resp.NextCursor != "" {
+		t.Errorf("expected total 1 without a cursor, got %d %q", resp.Total, resp.NextCursor)
+	}
+}
+
+func TestNoteService_ListRecent_Cursor(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestNoteService(&mockEmbedder{dim: 3}, store.NewMemoryStore(), "openai:test:3")
+
+	// 同時刻のノートがページの境目をまたぐ
+	for i, createdAt := range []string{"2024-01-01T00:00:00Z", "2024-01-02T00:00:00Z", "2024-01-02T00:00:00Z", "2024-01-03T00:00:00Z", "2024-01-04T00:00:00Z"} {
+		_, err := svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: "global", Text: fmt.Sprintf("note %d", i), CreatedAt: &createdAt})
+		if err != nil {
+			t.Fatalf("AddNote failed: %v", err)
+		}
+	}
+
+	limit := 2
+	req := &ListRecentRequest{ProjectID: "/test/project", Limit: &limit}
+	var pages [][]string
+	for {
+		resp, err := svc.ListRecent(ctx, req)
+		if err != nil {
+			t.Fatalf("ListRecent failed: %v", err)
+		}
+		if resp.Total != 5 || resp.TotalApproximate {
+			t.Errorf("expected an exact total of 5, got %d (approximate %v)", resp.Total, resp.TotalApproximate)
+		}
+		var texts []string
+		for _, item := range resp.Items {
+			texts = append(texts, item.Text)
+		}
+		pages = append(pages, texts)
+		if resp.NextCursor == "" || len(pages) > 5 {
+			break
+		}
+		req.Cursor = resp.NextCursor
+	}
+	got := fmt.Sprint(pages)
+	if got != "[[note 4 note 3] [note 1 note 2] [note 0]]" && got != "[[note 4 note 3] [note 2 note 1] [note 0]]" {
+		t.Errorf("unexpected pages: %s", got)
+	}
+
+	// 別のsortByで発行したcursor・壊れたcursorは使えない
+	if _, err := svc.ListRecent(ctx, &ListRecentRequest{ProjectID: "/test/project", Cursor: req.Cursor, SortBy: "updatedAt"}); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor for another sortBy, got %v", err)
+	}
+	if _, err := svc.ListRecent(ctx, &ListRecentRequest{ProjectID: "/test/project", Cursor: "not a cursor"}); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor, got %v", err)
+	}
 }
 
 func TestNoteService_Update_IDRequired(t *testing.T) {
@@ -735,6 +1043,206 @@ func TestNoteService_Search_WithTopK(t *testing.T) {
 	}
 }
 
+func TestNoteService_Search_MinScore(t *testing.T) {
+	memStore := store.NewMemoryStore()
+	emb := &mockEmbedder{dim: 3, embedFunc: func(ctx context.Context, text string) ([]float32, error) {
+		if strings.Contains(text, "near") {
+			return []float32{1, 0, 0}, nil
+		}
+		return []float32{0, 1, 0}, nil
+	}}
+	svc := newTestNoteService(emb, memStore, "openai:test:3")
+
+	for _, text := range []string{"near note", "far note"} {
+		if _, err := svc.AddNote(context.Background(), &AddNoteRequest{
+			ProjectID: "/test/project",
+			GroupID:   "global",
+			Text:      text,
+		}); err != nil {
+			t.Fatalf("AddNote failed: %v", err)
+		}
+	}
+
+	minScore := 0.9
+	resp, err := svc.Search(context.Background(), &SearchRequest{
+		ProjectID: "/test/project",
+		Query:     "near",
+		MinScore:  &minScore,
+	})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(resp.Results) != 1 || resp.Results[0].Text != "near note" {
+		t.Errorf("expected only the near note, got %+v", resp.Results)
+	}
+
+	invalid := 1.5
+	_, err = svc.Search(context.Background(), &SearchRequest{
+		ProjectID: "/test/project",
+		Query:     "near",
+		MinScore:  &invalid,
+	})
+	if !errors.Is(err, ErrInvalidMinScore) {
+		t.Errorf("expected ErrInvalidMinScore, got %v", err)
+	}
+}
+
+func TestNoteService_Search_GroupBy(t *testing.T) {
+	ctx := context.Background()
+	emb := &mockEmbedder{dim: 3, embedFunc: func(ctx context.Context, text string) ([]float32, error) {
+		switch {
+		case strings.HasPrefix(text, "auth"):
+			return []float32{0.9, 0.1, 0}, nil
+		case strings.HasPrefix(text, "db"):
+			return []float32{0.5, 0.5, 0}, nil
+		}
+		return []float32{1, 0, 0}, nil
+	}}
+	svc := newTestNoteService(emb, store.NewMemoryStore(), "openai:test:3")
+
+	add := func(groupID, text string) {
+		if _, err := svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: groupID, Text: text}); err != nil {
+			t.Fatalf("AddNote failed: %v", err)
+		}
+	}
+	for i := range 6 {
+		add("noisy", fmt.Sprintf("noisy %d", i))
+	}
+	add("auth", "auth token rotation")
+	add("db", "db migration")
+
+	// 通常の検索では似たノートの多いgroupIdが枠を占める
+	resp, err := svc.Search(ctx, &SearchRequest{ProjectID: "/test/project", Query: "query"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	for _, r := range resp.Results {
+		if r.GroupID != "noisy" {
+			t.Fatalf("expected only noisy results without groupBy, got %+v", resp.Results)
+		}
+	}
+
+	resp, err = svc.Search(ctx, &SearchRequest{ProjectID: "/test/project", Query: "query", GroupBy: SearchGroupByGroup})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	var got []string
+	for _, r := range resp.Results {
+		got = append(got, r.GroupID)
+	}
+	if fmt.Sprint(got) != "[noisy noisy auth db]" {
+		t.Errorf("expected 2 noisy results then auth and db, got %v", got)
+	}
+	if len(resp.Groups) != 3 || resp.Groups[0] != (SearchGroup{GroupID: "noisy", Count: 2, TopScore: resp.Results[0].Score}) || resp.Groups[2].GroupID != "db" {
+		t.Errorf("unexpected groups: %+v", resp.Groups)
+	}
+
+	// topKは全体の件数、groupTopKはgroupIdごとの件数
+	topK, groupTopK := 3, 1
+	resp, err = svc.Search(ctx, &SearchRequest{ProjectID: "/test/project", Query: "query", TopK: &topK, GroupBy: SearchGroupByGroup, GroupTopK: &groupTopK})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(resp.Results) != 3 || len(resp.Groups) != 3 {
+		t.Errorf("expected one result from each of 3 groups, got %+v", resp.Groups)
+	}
+
+	// groupIdの絞り込みは候補のgroupIdにも適用する
+	groupID := "auth"
+	resp, _ = svc.Search(ctx, &SearchRequest{ProjectID: "/test/project", GroupID: &groupID, Query: "query", GroupBy: SearchGroupByGroup})
+	if len(resp.Groups) != 1 || resp.Groups[0].GroupID != "auth" {
+		t.Errorf("expected only the auth group, got %+v", resp.Groups)
+	}
+
+	if _, err := svc.Search(ctx, &SearchRequest{ProjectID: "/test/project", Query: "query", GroupBy: "tag"}); !errors.Is(err, ErrInvalidGroupBy) {
+		t.Errorf("expected ErrInvalidGroupBy, got %v", err)
+	}
+	zero := 0
+	if _, err := svc.Search(ctx, &SearchRequest{ProjectID: "/test/project", Query: "query", GroupBy: SearchGroupByGroup, GroupTopK: &zero}); !errors.Is(err, ErrInvalidGroupTopK) {
+		t.Errorf("expected ErrInvalidGroupTopK, got %v", err)
+	}
+}
+
+// barrierSearchStore は並行してn件の検索が始まるまでSearchを返さないStore
+type barrierSearchStore struct {
+	store.Store
+	arrived chan struct{}
+	n       int
+}
+
+func (s *barrierSearchStore) Search(ctx context.Context, embedding []float32, opts store.SearchOptions) ([]store.SearchResult, error) {
+	s.arrived <- struct{}{}
+	deadline := time.After(5 * time.Second)
+	for len(s.arrived) < s.n {
+		select {
+		case <-deadline:
+			return nil, errors.New("searched sequentially")
+		case <-time.After(time.Millisecond):
+		}
+	}
+	return s.Store.Search(ctx, embedding, opts)
+}
+
+// TestNoteService_Search_GroupByConcurrent はgroupBy=groupでgroupIdごとの検索を並行して行うことをテスト
+func TestNoteService_Search_GroupByConcurrent(t *testing.T) {
+	ctx := context.Background()
+	memStore := store.NewMemoryStore()
+	svc := newTestNoteService(&mockEmbedder{dim: 3}, memStore, "openai:test:3")
+	groups := []string{"a", "b", "c"}
+	for _, groupID := range groups {
+		if _, err := svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: groupID, Text: groupID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	svc.store = &barrierSearchStore{Store: memStore, arrived: make(chan struct{}, len(groups)), n: len(groups)}
+
+	resp, err := svc.Search(ctx, &SearchRequest{ProjectID: "/test/project", Query: "query", GroupBy: SearchGroupByGroup})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(resp.Groups) != 3 || len(resp.Results) != 3 {
+		t.Errorf("expected one result from each group, got %+v", resp.Groups)
+	}
+}
+
+// TestNoteService_Search_ProjectIDs はprojectIds指定時にプロジェクトごとの検索を並行して行い、
+// スコア順にまとめることをテスト
+func TestNoteService_Search_ProjectIDs(t *testing.T) {
+	ctx := context.Background()
+	memStore := store.NewMemoryStore()
+	emb := &mockEmbedder{dim: 3, embedFunc: func(ctx context.Context, text string) ([]float32, error) {
+		switch text {
+		case "best":
+			return []float32{1, 0, 0}, nil
+		case "good":
+			return []float32{1, 1, 0}, nil
+		default:
+			return []float32{0, 0, 1}, nil
+		}
+	}}
+	svc := newTestNoteService(emb, memStore, "openai:test:3")
+	notes := map[string]string{"/test/a": "good", "/test/b": "best", "/test/c": "other", "/test/d": "best"}
+	for projectID, text := range notes {
+		if _, err := svc.AddNote(ctx, &AddNoteRequest{ProjectID: projectID, GroupID: "global", Text: text}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	svc.store = &barrierSearchStore{Store: memStore, arrived: make(chan struct{}, 3), n: 3}
+
+	topK := 2
+	resp, err := svc.Search(ctx, &SearchRequest{ProjectID: "/test/a", ProjectIDs: []string{"/test/b", "/test/c", "/test/a"}, Query: "best", TopK: &topK})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(resp.Results) != 2 || resp.Results[0].ProjectID != "/test/b" || resp.Results[1].ProjectID != "/test/a" {
+		t.Errorf("expected the best results across /test/a and /test/b, got %+v", resp.Results)
+	}
+
+	if _, err := svc.Search(ctx, &SearchRequest{ProjectIDs: []string{"/test/a", "/test/b"}, Query: "q", GroupBy: SearchGroupByGroup}); !errors.Is(err, ErrGroupByMultiProject) {
+		t.Errorf("expected ErrGroupByMultiProject, got %v", err)
+	}
+}
+
 func TestNoteService_AddNote_WithAllFields(t *testing.T) {
 	memStore := store.NewMemoryStore()
 	emb := &mockEmbedder{dim: 3}
@@ -773,4 +1281,358 @@ func TestNoteService_AddNote_WithAllFields(t *testing.T) {
 	if note.CreatedAt != "2025-01-26T12:00:00Z" {
 		t.Errorf("createdAt not saved correctly: %s", note.CreatedAt)
 	}
-}
\ No newline at end of file
+}
+
+// TestNoteService_AddNote_NormalizesCreatedAt はcreatedAtをUTCに揃えて保存し、不正な形式を拒否することをテスト
+func TestNoteService_AddNote_NormalizesCreatedAt(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestNoteService(&mockEmbedder{dim: 3}, store.NewMemoryStore(), "openai:test:3")
+
+	createdAt := "2025-01-26T21:00:00.250+09:00"
+	resp, err := svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: "global", Text: "note", CreatedAt: &createdAt})
+	if err != nil {
+		t.Fatalf("AddNote failed: %v", err)
+	}
+	if note, _ := svc.Get(ctx, resp.ID); note.CreatedAt != "2025-01-26T12:00:00Z" {
+		t.Errorf("expected createdAt in UTC, got %s", note.CreatedAt)
+	}
+
+	invalid := "2025-01-26 12:00:00"
+	if _, err := svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: "global", Text: "note", CreatedAt: &invalid}); !errors.Is(err, ErrInvalidTimeFormat) {
+		t.Errorf("expected ErrInvalidTimeFormat, got %v", err)
+	}
+}
+// mockBatchEmbedder はEmbedBatchの呼び出しを記録するテスト用Embedder
+type mockBatchEmbedder struct {
+	mockEmbedder
+	batchCalls [][]string
+}
+
+func (m *mockBatchEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	m.batchCalls = append(m.batchCalls, texts)
+	embeddings := make([][]float32, len(texts))
+	for i := range texts {
+		embeddings[i] = []float32{float32(i), 0.2, 0.3}
+	}
+	return embeddings, nil
+}
+
+func TestNoteService_AddNotes_Success(t *testing.T) {
+	memStore := store.NewMemoryStore()
+	emb := &mockBatchEmbedder{mockEmbedder: mockEmbedder{dim: 3}}
+	svc := newTestNoteService(emb, memStore, "openai:test:3")
+
+	req := &AddNotesRequest{Notes: []AddNoteRequest{
+		{ProjectID: "/test/project", GroupID: "docs", Text: "first"},
+		{ProjectID: "/test/project", GroupID: "docs", Text: "second"},
+	}}
+
+	resp, err := svc.AddNotes(context.Background(), req)
+	if err != nil {
+		t.Fatalf("AddNotes failed: %v", err)
+	}
+	if len(resp.Results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(resp.Results))
+	}
+	if len(emb.batchCalls) != 1 || len(emb.batchCalls[0]) != 2 {
+		t.Errorf("expected a single batch of 2 texts, got %v", emb.batchCalls)
+	}
+
+	for i, want := range []string{"first", "second"} {
+		note, err := memStore.Get(context.Background(), resp.Results[i].ID)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if note.Text != want {
+			t.Errorf("results[%d]: expected text %q, got %q", i, want, note.Text)
+		}
+	}
+}
+
+func TestNoteService_AddNotes_FallbackToEmbed(t *testing.T) {
+	memStore := store.NewMemoryStore()
+	var calls int
+	emb := &mockEmbedder{
+		embedFunc: func(ctx context.Context, text string) ([]float32, error) {
+			calls++
+			return []float32{0.1, 0.2, 0.3}, nil
+		},
+	}
+	svc := newTestNoteService(emb, memStore, "openai:test:3")
+
+	req := &AddNotesRequest{Notes: []AddNoteRequest{
+		{ProjectID: "/test/project", GroupID: "docs", Text: "first"},
+		{ProjectID: "/test/project", GroupID: "docs", Text: "second"},
+	}}
+
+	if _, err := svc.AddNotes(context.Background(), req); err != nil {
+		t.Fatalf("AddNotes failed: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 Embed calls, got %d", calls)
+	}
+}
+
+func TestNoteService_AddNotes_ValidationBeforeEmbed(t *testing.T) {
+	memStore := store.NewMemoryStore()
+	emb := &mockBatchEmbedder{}
+	svc := newTestNoteService(emb, memStore, "openai:test:3")
+
+	req := &AddNotesRequest{Notes: []AddNoteRequest{
+		{ProjectID: "/test/project", GroupID: "docs", Text: "first"},
+		{ProjectID: "/test/project", GroupID: "docs", Text: ""},
+	}}
+
+	resp, err := svc.AddNotes(context.Background(), req)
+	if !errors.Is(err, ErrTextRequired) {
+		t.Errorf("expected ErrTextRequired, got %v", err)
+	}
+	if len(emb.batchCalls) != 0 {
+		t.Errorf("expected no embedding calls, got %d", len(emb.batchCalls))
+	}
+	// 検証で失敗した項目はfailed、それ以外は追加していないためpending
+	if len(resp.Items) != 2 || resp.Items[0].Status != ItemPending || resp.Items[1].Status != ItemFailed || !errors.Is(resp.Items[1].Err, ErrTextRequired) {
+		t.Errorf("unexpected items: %+v", resp.Items)
+	}
+}
+
+// failingAddStore は本文がfailTextのノートの追加に失敗するStore
+type failingAddStore struct {
+	store.Store
+	failText string
+}
+
+func (s *failingAddStore) AddNote(ctx context.Context, note *model.Note, embedding []float32) error {
+	if note.Text == s.failText {
+		return errors.New("disk full")
+	}
+	return s.Store.AddNote(ctx, note, embedding)
+}
+
+// TestNoteService_AddNotes_Items は保存に失敗した場合の項目ごとの結果をテスト
+// トランザクションに対応していないストアでは、失敗した項目より前は追加済み（ok）、後ろは未追加（pending）
+func TestNoteService_AddNotes_Items(t *testing.T) {
+	ctx := context.Background()
+	memStore := store.NewMemoryStore()
+	svc := newTestNoteService(&mockEmbedder{dim: 3}, &failingAddStore{Store: memStore, failText: "second"}, "openai:test:3")
+
+	req := &AddNotesRequest{Notes: []AddNoteRequest{
+		{ProjectID: "/test/project", GroupID: "docs", Text: "first"},
+		{ProjectID: "/test/project", GroupID: "docs", Text: "second"},
+		{ProjectID: "/test/project", GroupID: "docs", Text: "third"},
+	}}
+	resp, err := svc.AddNotes(ctx, req)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	want := []ItemStatus{ItemOK, ItemFailed, ItemPending}
+	for i, item := range resp.Items {
+		if item.Index != i || item.Status != want[i] || item.ID == "" {
+			t.Errorf("items[%d]: expected %s, got %+v", i, want[i], item)
+		}
+	}
+	if resp.Items[1].Err == nil {
+		t.Error("expected the cause of the failed item")
+	}
+	if len(resp.Results) != 1 || resp.Results[0].ID != resp.Items[0].ID {
+		t.Errorf("expected only the first note in Results, got %+v", resp.Results)
+	}
+
+	retry := RetryItems(resp.Items)
+	if len(retry) != 2 || retry[0].Index != 1 || retry[1].Index != 2 {
+		t.Errorf("unexpected retry items: %+v", retry)
+	}
+	notes, _ := memStore.ListNotes(ctx, "/test/project")
+	if len(notes) != 1 {
+		t.Errorf("expected 1 note in the store, got %d", len(notes))
+	}
+}
+
+func TestNoteService_AddNotes_Empty(t *testing.T) {
+	svc := newTestNoteService(&mockEmbedder{}, store.NewMemoryStore(), "openai:test:3")
+
+	resp, err := svc.AddNotes(context.Background(), &AddNotesRequest{})
+	if err != nil {
+		t.Fatalf("AddNotes failed: %v", err)
+	}
+	if len(resp.Results) != 0 {
+		t.Errorf("expected no results, got %d", len(resp.Results))
+	}
+}
+
+func TestNoteService_ListProjects(t *testing.T) {
+	memStore := store.NewMemoryStore()
+	svc := newTestNoteService(&mockEmbedder{dim: 3}, memStore, "openai:test:3")
+	ctx := context.Background()
+
+	for _, projectID := range []string{"/b/project", "/a/project", "/b/project"} {
+		if _, err := svc.AddNote(ctx, &AddNoteRequest{ProjectID: projectID, GroupID: "global", Text: "note"}); err != nil {
+			t.Fatalf("AddNote failed: %v", err)
+		}
+	}
+
+	resp, err := svc.ListProjects(ctx)
+	if err != nil {
+		t.Fatalf("ListProjects failed: %v", err)
+	}
+	if resp.Namespace != "openai:test:3" {
+		t.Errorf("expected namespace openai:test:3, got %s", resp.Namespace)
+	}
+	if len(resp.Projects) != 2 || resp.Projects[0].ProjectID != "/a/project" || resp.Projects[1].NoteCount != 2 {
+		t.Errorf("unexpected projects: %+v", resp.Projects)
+	}
+}
+
+// upsertTestGlobal はテスト用にGlobalConfigを保存する
+func upsertTestGlobal(t *testing.T, s store.Store, projectID, key string, value any) {
+	t.Helper()
+	if err := s.UpsertGlobal(context.Background(), &model.GlobalConfig{ID: key, ProjectID: projectID, Key: key, Value: value}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNoteService_AddNote_Policy(t *testing.T) {
+	memStore := store.NewMemoryStore()
+	svc := newTestNoteService(&mockEmbedder{dim: 3}, memStore, "openai:test:3")
+	ctx := context.Background()
+	upsertTestGlobal(t, memStore, "/test/project", model.GlobalKeyDefaultGroup, "docs")
+	upsertTestGlobal(t, memStore, "/test/project", model.GlobalKeyTags, []any{"team-a"})
+	upsertTestGlobal(t, memStore, "/test/project", model.GlobalKeyRequiredTags, []any{"team-a", "area"})
+
+	// 必須タグが足りない
+	_, err := svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", Text: "note"})
+	if !errors.Is(err, ErrMissingRequiredTags) || !strings.Contains(err.Error(), "area (required: team-a, area)") {
+		t.Errorf("expected ErrMissingRequiredTags for area, got %v", err)
+	}
+
+	// defaultGroupと自動タグが適用され、自動タグは必須タグを満たす
+	resp, err := svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", Text: "note", Tags: []string{"area"}})
+	if err != nil {
+		t.Fatalf("AddNote failed: %v", err)
+	}
+	note, err := svc.Get(ctx, resp.ID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if note.GroupID != "docs" || strings.Join(note.Tags, ",") != "area,team-a" {
+		t.Errorf("unexpected group/tags: %s %v", note.GroupID, note.Tags)
+	}
+
+	// AddNotesも同じポリシー
+	_, err = svc.AddNotes(ctx, &AddNotesRequest{Notes: []AddNoteRequest{
+		{ProjectID: "/test/project", Text: "a", Tags: []string{"area"}},
+		{ProjectID: "/test/project", Text: "b"},
+	}})
+	if !errors.Is(err, ErrMissingRequiredTags) || !strings.HasPrefix(err.Error(), "notes[1]: ") {
+		t.Errorf("expected ErrMissingRequiredTags for notes[1], got %v", err)
+	}
+
+	// 更新でタグを変える場合も必須タグを検証する
+	tags := []string{"team-a"}
+	if err := svc.Update(ctx, &UpdateRequest{ID: resp.ID, Patch: NotePatch{Tags: &tags}}); !errors.Is(err, ErrMissingRequiredTags) {
+		t.Errorf("expected ErrMissingRequiredTags on update, got %v", err)
+	}
+}
+
+func TestNoteService_AddNote_InvalidPolicy(t *testing.T) {
+	memStore := store.NewMemoryStore()
+	svc := newTestNoteService(&mockEmbedder{dim: 3}, memStore, "openai:test:3")
+	upsertTestGlobal(t, memStore, "/test/project", model.GlobalKeyRequiredTags, "area")
+
+	_, err := svc.AddNote(context.Background(), &AddNoteRequest{ProjectID: "/test/project", GroupID: "global", Text: "note"})
+	if !errors.Is(err, ErrInvalidNotePolicy) || !strings.Contains(err.Error(), model.GlobalKeyRequiredTags) {
+		t.Errorf("expected ErrInvalidNotePolicy, got %v", err)
+	}
+}
+
+func TestNoteService_AddNote_AutoCreateGroup(t *testing.T) {
+	memStore := store.NewMemoryStore()
+	svc := newTestNoteService(&mockEmbedder{dim: 3}, memStore, "openai:test:3")
+	ctx := context.Background()
+
+	// 未設定ならグループは作らない
+	if _, err := svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: "feature-login", Text: "note"}); err != nil {
+		t.Fatalf("AddNote failed: %v", err)
+	}
+	if _, err := memStore.GetGroupByKey(ctx, "/test/project", "feature-login"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("expected no group without autoCreateGroup, got %v", err)
+	}
+
+	upsertTestGlobal(t, memStore, "/test/project", model.GlobalKeyAutoCreateGroup, true)
+	upsertTestGlobal(t, memStore, "/test/project", model.GlobalKeyGroupDefaults, map[string]any{"featurePrefix": "feature-", "taskPrefix": "task-"})
+	for _, groupID := range []string{"feature-login", "feature-login", "task-12", "global"} {
+		if _, err := svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: groupID, Text: "note " + groupID}); err != nil {
+			t.Fatalf("AddNote(%s) failed: %v", groupID, err)
+		}
+	}
+	if _, err := svc.AddNotes(ctx, &AddNotesRequest{Notes: []AddNoteRequest{
+		{ProjectID: "/test/project", GroupID: "task-", Text: "a"},
+		{ProjectID: "/test/project", GroupID: "task-", Text: "b"},
+	}}); err != nil {
+		t.Fatalf("AddNotes failed: %v", err)
+	}
+
+	groups, err := memStore.ListGroups(ctx, "/test/project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	titles := make(map[string]string)
+	for _, group := range groups {
+		titles[group.GroupKey] = group.Title
+	}
+	// プレフィックスを除いたtitle。除くと空になるtask-はgroupKeyのまま、globalは作らない
+	want := map[string]string{"feature-login": "login", "task-12": "12", "task-": "task-"}
+	if !maps.Equal(titles, want) {
+		t.Errorf("expected groups %v, got %v", want, titles)
+	}
+
+	upsertTestGlobal(t, memStore, "/test/project", model.GlobalKeyAutoCreateGroup, "yes")
+	if _, err := svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: "feature-login", Text: "note"}); !errors.Is(err, ErrInvalidNotePolicy) {
+		t.Errorf("expected ErrInvalidNotePolicy, got %v", err)
+	}
+}
+
+func TestNoteService_GroupKeyPrefix(t *testing.T) {
+	memStore := store.NewMemoryStore()
+	svc := newTestNoteService(&mockEmbedder{dim: 3}, memStore, "openai:test:3")
+	ctx := context.Background()
+
+	// プレフィックスを設定する前のノート・グループ
+	resp, err := svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: "misc", Text: "note"})
+	if err != nil {
+		t.Fatalf("AddNote failed: %v", err)
+	}
+	if err := memStore.AddGroup(ctx, &model.Group{ID: "g-legacy", ProjectID: "/test/project", GroupKey: "legacy", Title: "Legacy"}); err != nil {
+		t.Fatal(err)
+	}
+	upsertTestGlobal(t, memStore, "/test/project", model.GlobalKeyGroupDefaults, map[string]any{"taskPrefix": "task-", "featurePrefix": "feature-", "owner": "alice"})
+
+	_, err = svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: "login", Text: "note"})
+	var prefixErr *GroupKeyPrefixError
+	if !errors.As(err, &prefixErr) || !errors.Is(err, ErrGroupKeyPrefix) {
+		t.Fatalf("expected GroupKeyPrefixError, got %v", err)
+	}
+	if strings.Join(prefixErr.Prefixes, ",") != "feature-,task-" || strings.Join(prefixErr.Suggestions, ",") != "feature-login,task-login" {
+		t.Errorf("unexpected prefixes/suggestions: %+v", prefixErr)
+	}
+
+	// プレフィックスに一致するgroupId・global・登録済みのグループは許可する
+	for _, groupID := range []string{"feature-login", "global", "legacy"} {
+		if _, err := svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: groupID, Text: "note"}); err != nil {
+			t.Errorf("AddNote(%s) failed: %v", groupID, err)
+		}
+	}
+
+	_, err = svc.AddNotes(ctx, &AddNotesRequest{Notes: []AddNoteRequest{
+		{ProjectID: "/test/project", GroupID: "task-1", Text: "a"},
+		{ProjectID: "/test/project", GroupID: "misc", Text: "b"},
+	}})
+	if !errors.Is(err, ErrGroupKeyPrefix) || !strings.HasPrefix(err.Error(), "notes[1]: ") {
+		t.Errorf("expected ErrGroupKeyPrefix for notes[1], got %v", err)
+	}
+
+	groupID := "misc"
+	if err := svc.Update(ctx, &UpdateRequest{ID: resp.ID, Patch: NotePatch{GroupID: &groupID}}); !errors.Is(err, ErrGroupKeyPrefix) {
+		t.Errorf("expected ErrGroupKeyPrefix on update, got %v", err)
+	}
+}
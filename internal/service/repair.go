@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/embedder"
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/store"
+)
+
+// repairService はRepairServiceの実装
+type repairService struct {
+	embedder   embedder.Embedder
+	store      store.Store
+	quarantine *store.ColdStore // Validateで修復できないノートの移し先
+	namespace  string
+}
+
+// NewRepairService はRepairServiceの新しいインスタンスを作成
+// quarantineはValidateで修復できないノートを移すコールドストア（nilなら隔離せずに問題として残す）
+func NewRepairService(emb embedder.Embedder, s store.Store, quarantine *store.ColdStore, namespace string) RepairService {
+	return &repairService{embedder: emb, store: s, quarantine: quarantine, namespace: namespace}
+}
+
+// RepairTimestamps はNormalizeTimestampの形式でないcreatedAtを持つノートを探し、UTCの秒までのRFC3339に書き換える
+// オフセットのない形式はUTCとみなす。時刻として解釈できないものは書き換えずにInvalidとして返す
+// 書き換えたノートのupdatedAtは書き換えた時刻になる（埋め込みは変わらない）
+func (s *repairService) RepairTimestamps(ctx context.Context, req *RepairTimestampsRequest) (*RepairTimestampsResponse, error) {
+	var projectIDs []string
+	if req.ProjectID != "" {
+		projectID, err := config.CanonicalizeProjectID(req.ProjectID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to canonicalize projectId: %w", err)
+		}
+		projectIDs = []string{projectID}
+	} else {
+		projects, err := s.store.ListProjects(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list projects: %w", err)
+		}
+		for _, p := range projects {
+			projectIDs = append(projectIDs, p.ProjectID)
+		}
+	}
+
+	resp := &RepairTimestampsResponse{Repairs: []TimestampRepair{}, Invalid: []TimestampRepair{}}
+	for _, projectID := range projectIDs {
+		notes, err := s.store.ListNotes(ctx, projectID)
+		if err != nil {
+			return resp, fmt.Errorf("project %s: failed to list notes: %w", projectID, err)
+		}
+		for _, note := range notes {
+			resp.Scanned++
+			if note.CreatedAt == nil {
+				continue
+			}
+			repair := TimestampRepair{ID: note.ID, ProjectID: projectID, From: *note.CreatedAt}
+			t, ok := parseLegacyTimestamp(repair.From)
+			if !ok {
+				resp.Invalid = append(resp.Invalid, repair)
+				continue
+			}
+			repair.To = t.Format(time.RFC3339)
+			if repair.To == repair.From {
+				continue
+			}
+			if !req.DryRun {
+				// Updateは埋め込みも置き換えるため、既存の埋め込みを引き継ぐ
+				embedding, err := s.store.GetEmbedding(ctx, note.ID)
+				if err != nil {
+					return resp, fmt.Errorf("failed to get embedding of note %s: %w", note.ID, err)
+				}
+				note.CreatedAt = &repair.To
+				if err := s.store.Update(ctx, note, embedding); err != nil {
+					return resp, fmt.Errorf("failed to repair note %s: %w", note.ID, err)
+				}
+			}
+			resp.Repairs = append(resp.Repairs, repair)
+		}
+	}
+	return resp, nil
+}
+
+// Validate はnamespaceのノート・埋め込み・グループの整合性を検証する
+// Repairの場合、埋め込みがない・次元数が違うノートは本文から埋め込みを作り直し、
+// payloadが壊れたノートは隔離用のコールドストアに移し、削除されたグループを指すparentGroupIdは外す
+// ストアが全ノートの走査（store.RawScanner）に対応していれば、projectIdが読めない・復号できないノートも1件ずつ検証する
+// 修復の途中でエラーになった場合は、それまでの結果とエラーを返す
+func (s *repairService) Validate(ctx context.Context, req *ValidateRequest) (*ValidateResponse, error) {
+	projectIDs, err := s.projectIDs(ctx, req.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &ValidateResponse{Namespace: s.namespace, Issues: []IndexIssue{}}
+	dim := namespaceDim(s.namespace)
+	if actual := s.embedder.GetDimension(); dim > 0 && actual > 0 && actual != dim {
+		resp.Issues = append(resp.Issues, IndexIssue{Kind: IndexIssueNamespaceMismatch,
+			Message: fmt.Sprintf("embedder returns %d dims but namespace %s has %d; fix embedder.dim and migrate the notes", actual, s.namespace, dim)})
+		// 作り直しても次元数が合わないため、埋め込みは修復しない
+		dim = -1
+	}
+
+	// projectIdを指定した場合、payloadを解釈できずプロジェクトが分からないノートは対象外
+	scanned, err := store.ScanNotes(ctx, s.store, func(raw store.RawNote) error {
+		if req.ProjectID != "" && (raw.Note == nil || raw.Note.ProjectID != projectIDs[0]) {
+			return nil
+		}
+		return s.validateNote(ctx, raw, dim, req.Repair, resp)
+	})
+	if err != nil {
+		return resp, fmt.Errorf("failed to scan notes: %w", err)
+	}
+
+	for _, projectID := range projectIDs {
+		if !scanned {
+			if err := s.validateNotes(ctx, projectID, dim, req.Repair, resp); err != nil {
+				return resp, err
+			}
+		}
+		if err := s.validateGroups(ctx, projectID, req.Repair, resp); err != nil {
+			return resp, err
+		}
+	}
+	return resp, nil
+}
+
+// projectIDs はprojectIDを正規化して返す（空なら全プロジェクト）
+func (s *repairService) projectIDs(ctx context.Context, projectID string) ([]string, error) {
+	if projectID != "" {
+		canonical, err := config.CanonicalizeProjectID(projectID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to canonicalize projectId: %w", err)
+		}
+		return []string{canonical}, nil
+	}
+	projects, err := s.store.ListProjects(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+	ids := make([]string, len(projects))
+	for i, p := range projects {
+		ids[i] = p.ProjectID
+	}
+	return ids, nil
+}
+
+// validateNotes はプロジェクトのノートをListNotesで読み出して検証する（全ノートを走査できないストア用）
+func (s *repairService) validateNotes(ctx context.Context, projectID string, dim int, repair bool, resp *ValidateResponse) error {
+	notes, err := s.store.ListNotes(ctx, projectID)
+	if err != nil {
+		resp.Issues = append(resp.Issues, IndexIssue{Kind: IndexIssueUnreadableProject, ProjectID: projectID,
+			Message: fmt.Sprintf("failed to list notes: %v", err)})
+		return nil
+	}
+	for _, note := range notes {
+		embedding, err := s.store.GetEmbedding(ctx, note.ID)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			return fmt.Errorf("failed to get embedding of note %s: %w", note.ID, err)
+		}
+		if err := s.validateNote(ctx, store.RawNote{ID: note.ID, Note: note, Embedding: embedding}, dim, repair, resp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateNote はノート1件のpayloadと埋め込みを検証する（dimが0以下なら次元数は確認しない、負なら作り直さない）
+func (s *repairService) validateNote(ctx context.Context, raw store.RawNote, dim int, repair bool, resp *ValidateResponse) error {
+	resp.Scanned++
+	var projectID string
+	if raw.Note != nil {
+		projectID = raw.Note.ProjectID
+	}
+
+	invalid := raw.Err
+	if invalid == nil {
+		invalid = raw.Note.Validate()
+	}
+	if invalid != nil {
+		issue := IndexIssue{Kind: IndexIssueInvalidNote, ProjectID: projectID, ID: raw.ID, Message: invalid.Error()}
+		if repair && raw.ID != "" && s.quarantine != nil {
+			if err := s.quarantineNote(ctx, raw); err != nil {
+				return err
+			}
+			issue.Repaired, issue.Action = true, IndexRepairQuarantine
+		}
+		resp.Issues = append(resp.Issues, issue)
+		return nil
+	}
+
+	issue := IndexIssue{ProjectID: projectID, ID: raw.ID}
+	switch {
+	case len(raw.Embedding) == 0:
+		issue.Kind, issue.Message = IndexIssueMissingEmbedding, "note has no embedding"
+	case dim > 0 && len(raw.Embedding) != dim:
+		issue.Kind, issue.Message = IndexIssueDimensionMismatch, fmt.Sprintf("embedding has %d dims, namespace has %d", len(raw.Embedding), dim)
+	default:
+		return nil
+	}
+	if repair && dim >= 0 {
+		if err := s.reembed(ctx, raw.Note, dim); err != nil {
+			return err
+		}
+		issue.Repaired, issue.Action = true, IndexRepairReembed
+	}
+	resp.Issues = append(resp.Issues, issue)
+	return nil
+}
+
+// validateGroups はプロジェクトのグループのparentGroupIdが存在するグループを指しているか検証する
+func (s *repairService) validateGroups(ctx context.Context, projectID string, repair bool, resp *ValidateResponse) error {
+	groups, err := s.store.ListGroups(ctx, projectID)
+	if err != nil {
+		resp.Issues = append(resp.Issues, IndexIssue{Kind: IndexIssueUnreadableProject, ProjectID: projectID,
+			Message: fmt.Sprintf("failed to list groups: %v", err)})
+		return nil
+	}
+	keys := make(map[string]bool, len(groups))
+	for _, g := range groups {
+		keys[g.GroupKey] = true
+	}
+	for _, g := range groups {
+		if g.ParentGroupID == "" || keys[g.ParentGroupID] {
+			continue
+		}
+		issue := IndexIssue{Kind: IndexIssueDanglingParent, ProjectID: projectID, ID: g.ID,
+			Message: fmt.Sprintf("group %q has parent %q, which does not exist", g.GroupKey, g.ParentGroupID)}
+		if repair {
+			g.ParentGroupID = ""
+			g.UpdatedAt = time.Now().UTC()
+			if err := s.store.UpdateGroup(ctx, g); err != nil {
+				return fmt.Errorf("failed to repair group %s: %w", g.ID, err)
+			}
+			issue.Repaired, issue.Action = true, IndexRepairClearParent
+		}
+		resp.Issues = append(resp.Issues, issue)
+	}
+	return nil
+}
+
+// reembed はノートの本文から埋め込みを作り直して保存する（dimが正なら次元数を確認する）
+func (s *repairService) reembed(ctx context.Context, note *model.Note, dim int) error {
+	embedding, err := s.embedder.Embed(ctx, note.Text)
+	if err != nil {
+		return fmt.Errorf("failed to embed note %s: %w", note.ID, err)
+	}
+	if dim > 0 && len(embedding) != dim {
+		return fmt.Errorf("failed to repair note %s: embedder returned %d dims, namespace has %d", note.ID, len(embedding), dim)
+	}
+	if err := s.store.Update(ctx, note, embedding); err != nil {
+		return fmt.Errorf("failed to repair note %s: %w", note.ID, err)
+	}
+	return nil
+}
+
+// quarantineNote はノートを隔離用のコールドストアに移し、ストアから削除する
+// 解釈できたところまでのノート（復号できなければ暗号化されたまま）と元のpayloadを、raw.IDで残す
+func (s *repairService) quarantineNote(ctx context.Context, raw store.RawNote) error {
+	note := &model.Note{}
+	if raw.Note != nil {
+		copied := *raw.Note
+		note = &copied
+	}
+	note.ID = raw.ID
+	now := time.Now().UTC().Format(time.RFC3339)
+	if err := s.quarantine.Put([]store.ArchivedNote{{Note: note, Embedding: raw.Embedding, ArchivedAt: now, Payload: raw.Payload}}); err != nil {
+		return fmt.Errorf("failed to quarantine note %s: %w", raw.ID, err)
+	}
+	if err := s.store.Delete(ctx, raw.ID); err != nil {
+		return fmt.Errorf("failed to delete quarantined note %s: %w", raw.ID, err)
+	}
+	return nil
+}
+
+// namespaceDim はnamespace（provider:model:dim）の次元数を返す（modelが":"を含んでもよい。解釈できなければ0）
+func namespaceDim(namespace string) int {
+	i := strings.LastIndex(namespace, ":")
+	if i < 0 {
+		return 0
+	}
+	dim, err := strconv.Atoi(namespace[i+1:])
+	if err != nil {
+		return 0
+	}
+	return dim
+}
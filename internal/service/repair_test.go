@@ -0,0 +1,303 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/store"
+)
+
+func TestRepairService_RepairTimestamps(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemoryStore()
+	if err := st.Initialize(ctx, "test:mock:3"); err != nil {
+		t.Fatal(err)
+	}
+	// 以前のバージョンでそのまま保存されたcreatedAt
+	for id, createdAt := range map[string]string{
+		"offset":   "2024-01-15T19:00:00+09:00",
+		"no-zone":  "2024-01-15 10:00:00",
+		"valid":    "2024-01-15T10:00:00Z",
+		"invalid":  "yesterday",
+		"fraction": "2024-01-15T10:00:00.5Z",
+	} {
+		note := &model.Note{ID: id, ProjectID: "/test/project", GroupID: "global", Text: id, Tags: []string{}, CreatedAt: &createdAt}
+		if err := st.AddNote(ctx, note, []float32{1, 0, 0}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	other := "2024-01-15T19:00:00+09:00"
+	st.AddNote(ctx, &model.Note{ID: "other", ProjectID: "/other/project", GroupID: "global", Text: "other", Tags: []string{}, CreatedAt: &other}, []float32{1, 0, 0})
+	svc := NewRepairService(&mockEmbedder{dim: 3}, st, nil, "test:mock:3")
+
+	// dry-runでは変更しない
+	resp, err := svc.RepairTimestamps(ctx, &RepairTimestampsRequest{ProjectID: "/test/project", DryRun: true})
+	if err != nil {
+		t.Fatalf("RepairTimestamps failed: %v", err)
+	}
+	if resp.Scanned != 5 || len(resp.Repairs) != 3 || len(resp.Invalid) != 1 || resp.Invalid[0].ID != "invalid" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if note, _ := st.Get(ctx, "offset"); *note.CreatedAt != "2024-01-15T19:00:00+09:00" {
+		t.Errorf("dry run changed createdAt to %s", *note.CreatedAt)
+	}
+
+	resp, err = svc.RepairTimestamps(ctx, &RepairTimestampsRequest{})
+	if err != nil {
+		t.Fatalf("RepairTimestamps failed: %v", err)
+	}
+	if resp.Scanned != 6 || len(resp.Repairs) != 4 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	for _, id := range []string{"offset", "no-zone", "fraction", "other", "valid"} {
+		note, _ := st.Get(ctx, id)
+		if *note.CreatedAt != "2024-01-15T10:00:00Z" {
+			t.Errorf("%s: expected a normalized createdAt, got %s", id, *note.CreatedAt)
+		}
+	}
+	if embedding, _ := st.GetEmbedding(ctx, "offset"); len(embedding) != 3 || embedding[0] != 1 {
+		t.Errorf("expected the embedding to be kept, got %v", embedding)
+	}
+
+	// 修復後は対象がない
+	resp, _ = svc.RepairTimestamps(ctx, &RepairTimestampsRequest{DryRun: true})
+	if len(resp.Repairs) != 0 || len(resp.Invalid) != 1 {
+		t.Errorf("expected nothing left to repair, got %+v", resp)
+	}
+}
+
+func TestRepairService_Validate(t *testing.T) {
+	ctx := context.Background()
+	const namespace = "test:mock:3"
+	st := store.NewMemoryStore()
+	if err := st.Initialize(ctx, namespace); err != nil {
+		t.Fatal(err)
+	}
+	for id, embedding := range map[string][]float32{
+		"ok":      {1, 0, 0},
+		"missing": nil,
+		"short":   {1, 0},
+	} {
+		note := &model.Note{ID: id, ProjectID: "/test/project", GroupID: "global", Text: id, Tags: []string{}}
+		if err := st.AddNote(ctx, note, embedding); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 本文が空のノート（以前のバージョン・手作業で壊れたpayload）
+	if err := st.AddNote(ctx, &model.Note{ID: "broken", ProjectID: "/test/project", GroupID: "global", Tags: []string{}}, []float32{1, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	for _, g := range []*model.Group{
+		{ID: "g-parent", ProjectID: "/test/project", GroupKey: "parent", Title: "parent", CreatedAt: now, UpdatedAt: now},
+		{ID: "g-child", ProjectID: "/test/project", GroupKey: "child", Title: "child", ParentGroupID: "parent", CreatedAt: now, UpdatedAt: now},
+		{ID: "g-orphan", ProjectID: "/test/project", GroupKey: "orphan", Title: "orphan", ParentGroupID: "deleted", CreatedAt: now, UpdatedAt: now},
+	} {
+		if err := st.AddGroup(ctx, g); err != nil {
+			t.Fatal(err)
+		}
+	}
+	quarantine := store.NewColdStore(t.TempDir(), namespace)
+	svc := NewRepairService(&mockEmbedder{dim: 3}, st, quarantine, namespace)
+
+	kinds := func(resp *ValidateResponse) map[string]string {
+		got := map[string]string{}
+		for _, issue := range resp.Issues {
+			got[issue.ID] = issue.Kind
+			if issue.Repaired != (issue.Action != "") {
+				t.Errorf("%s: repaired=%v but action=%q", issue.ID, issue.Repaired, issue.Action)
+			}
+		}
+		return got
+	}
+
+	// repairなしでは変更しない
+	resp, err := svc.Validate(ctx, &ValidateRequest{ProjectID: "/test/project"})
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	want := map[string]string{
+		"missing":  IndexIssueMissingEmbedding,
+		"short":    IndexIssueDimensionMismatch,
+		"broken":   IndexIssueInvalidNote,
+		"g-orphan": IndexIssueDanglingParent,
+	}
+	if got := kinds(resp); resp.Scanned != 4 || len(got) != len(want) {
+		t.Fatalf("unexpected response: %+v", resp)
+	} else {
+		for id, kind := range want {
+			if got[id] != kind {
+				t.Errorf("%s: expected %s, got %q", id, kind, got[id])
+			}
+		}
+	}
+	if embedding, _ := st.GetEmbedding(ctx, "short"); len(embedding) != 2 {
+		t.Errorf("validation without repair changed the embedding to %v", embedding)
+	}
+
+	resp, err = svc.Validate(ctx, &ValidateRequest{Repair: true})
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	for _, issue := range resp.Issues {
+		if !issue.Repaired {
+			t.Errorf("expected %s to be repaired: %+v", issue.ID, issue)
+		}
+	}
+	for _, id := range []string{"missing", "short"} {
+		if embedding, _ := st.GetEmbedding(ctx, id); len(embedding) != 3 {
+			t.Errorf("%s: expected a re-embedded vector, got %v", id, embedding)
+		}
+	}
+	if _, err := st.Get(ctx, "broken"); err == nil {
+		t.Error("expected the broken note to be removed from the store")
+	}
+	if archived, err := quarantine.Get([]string{"broken"}); err != nil || len(archived) != 1 || len(archived[0].Embedding) != 3 {
+		t.Errorf("expected the broken note in quarantine, got %+v, %v", archived, err)
+	}
+	if g, _ := st.GetGroup(ctx, "g-orphan"); g.ParentGroupID != "" {
+		t.Errorf("expected the dangling parent to be cleared, got %q", g.ParentGroupID)
+	}
+	if g, _ := st.GetGroup(ctx, "g-child"); g.ParentGroupID != "parent" {
+		t.Errorf("expected a valid parent to be kept, got %q", g.ParentGroupID)
+	}
+
+	// 修復後は問題がない
+	resp, _ = svc.Validate(ctx, &ValidateRequest{})
+	if len(resp.Issues) != 0 || resp.Scanned != 3 {
+		t.Errorf("expected no issues after repair, got %+v", resp)
+	}
+}
+
+// TestRepairService_Validate_NamespaceMismatch はembedderの次元数がnamespaceと違えば埋め込みを作り直さないことをテスト
+func TestRepairService_Validate_NamespaceMismatch(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemoryStore()
+	if err := st.Initialize(ctx, "test:mock:3"); err != nil {
+		t.Fatal(err)
+	}
+	st.AddNote(ctx, &model.Note{ID: "missing", ProjectID: "/test/project", GroupID: "global", Text: "t", Tags: []string{}}, nil)
+	svc := NewRepairService(&mockEmbedder{dim: 4}, st, nil, "test:mock:3")
+
+	resp, err := svc.Validate(ctx, &ValidateRequest{Repair: true})
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if len(resp.Issues) != 2 || resp.Issues[0].Kind != IndexIssueNamespaceMismatch || resp.Issues[1].Kind != IndexIssueMissingEmbedding || resp.Issues[1].Repaired {
+		t.Errorf("unexpected issues: %+v", resp.Issues)
+	}
+}
+
+// corruptPointStore はListNotesでは読めない（projectId・idが壊れた）ポイントを1件含むStore
+// QdrantStore.ScanNotesが返すのと同じ形で、ポイントのIDと解釈できたところまでのノートを渡す
+type corruptPointStore struct {
+	store.Store
+	deleted bool
+}
+
+const corruptPointID = "7d3f6a1e-2b4c-4d5e-8f90-1a2b3c4d5e6f"
+
+func (s *corruptPointStore) ScanNotes(ctx context.Context, fn func(store.RawNote) error) error {
+	if err := s.Store.(store.RawScanner).ScanNotes(ctx, fn); err != nil {
+		return err
+	}
+	if s.deleted {
+		return nil
+	}
+	return fn(store.RawNote{
+		ID:        corruptPointID,
+		Note:      &model.Note{Text: "orphan", Tags: []string{}},
+		Embedding: []float32{1, 0, 0},
+		Payload:   map[string]any{"projectId": int64(42), "text": "orphan"},
+	})
+}
+
+func (s *corruptPointStore) Delete(ctx context.Context, id string) error {
+	if id == corruptPointID && !s.deleted {
+		s.deleted = true
+		return nil
+	}
+	return s.Store.Delete(ctx, id)
+}
+
+// TestRepairService_Validate_CorruptPayload はprojectIdで一覧できない壊れたポイントも検出・隔離することをテスト
+func TestRepairService_Validate_CorruptPayload(t *testing.T) {
+	ctx := context.Background()
+	const namespace = "test:mock:3"
+	mem := store.NewMemoryStore()
+	if err := mem.Initialize(ctx, namespace); err != nil {
+		t.Fatal(err)
+	}
+	if err := mem.AddNote(ctx, &model.Note{ID: "ok", ProjectID: "/test/project", GroupID: "global", Text: "ok", Tags: []string{}}, []float32{1, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+	st := &corruptPointStore{Store: mem}
+	quarantine := store.NewColdStore(t.TempDir(), namespace)
+	svc := NewRepairService(&mockEmbedder{dim: 3}, st, quarantine, namespace)
+
+	// projectIdを指定した場合は、プロジェクトの分からないポイントは対象外
+	resp, err := svc.Validate(ctx, &ValidateRequest{ProjectID: "/test/project"})
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if resp.Scanned != 1 || len(resp.Issues) != 0 {
+		t.Errorf("unexpected response for the project: %+v", resp)
+	}
+
+	resp, err = svc.Validate(ctx, &ValidateRequest{Repair: true})
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if resp.Scanned != 2 || len(resp.Issues) != 1 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if issue := resp.Issues[0]; issue.Kind != IndexIssueInvalidNote || issue.ID != corruptPointID || issue.Action != IndexRepairQuarantine {
+		t.Errorf("expected the corrupt point to be quarantined, got %+v", issue)
+	}
+	if !st.deleted {
+		t.Error("expected the corrupt point to be deleted from the store")
+	}
+	archived, err := quarantine.Get([]string{corruptPointID})
+	if err != nil || len(archived) != 1 || archived[0].Payload["text"] != "orphan" {
+		t.Errorf("expected the corrupt point with its payload in quarantine, got %+v, %v", archived, err)
+	}
+}
+
+// TestRepairService_Validate_Undecryptable は復号できないノートをプロジェクト全体ではなく1件の問題として返すことをテスト
+func TestRepairService_Validate_Undecryptable(t *testing.T) {
+	ctx := context.Background()
+	const namespace = "test:mock:3"
+	mem := store.NewMemoryStore()
+	if err := mem.Initialize(ctx, namespace); err != nil {
+		t.Fatal(err)
+	}
+	st, err := store.NewEncryptedStore(mem, []store.EncryptionKey{{ID: "a", Key: make([]byte, 32)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"readable", "tampered"} {
+		if err := st.AddNote(ctx, &model.Note{ID: id, ProjectID: "/test/project", GroupID: "global", Text: id, Tags: []string{}}, []float32{1, 0, 0}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 暗号文を壊す
+	tampered, _ := mem.Get(ctx, "tampered")
+	tampered.Text = "mcpenc:v1:a:AAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+	if err := mem.Update(ctx, tampered, []float32{1, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := NewRepairService(&mockEmbedder{dim: 3}, st, nil, namespace)
+	resp, err := svc.Validate(ctx, &ValidateRequest{ProjectID: "/test/project"})
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if resp.Scanned != 2 || len(resp.Issues) != 1 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if issue := resp.Issues[0]; issue.Kind != IndexIssueInvalidNote || issue.ID != "tampered" || issue.ProjectID != "/test/project" {
+		t.Errorf("expected only the tampered note to be reported, got %+v", issue)
+	}
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/embedder"
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/store"
+	"github.com/google/uuid"
+)
+
+// ErrDimensionMismatch はembedderの次元数がnamespaceの次元数と一致しない場合のエラー
+var ErrDimensionMismatch = errors.New("embedder dimension does not match the namespace")
+
+// 自己診断で追加する一時ノート（実際のプロジェクトと重ならないprojectId）
+const (
+	selfTestProjectID = "mcp-memory:self-test"
+	selfTestText      = "mcp-memory self-test"
+)
+
+// selfTestService はSelfTestServiceの実装
+type selfTestService struct {
+	embedder  embedder.Embedder
+	store     store.Store
+	namespace string
+}
+
+// NewSelfTestService はSelfTestServiceの新しいインスタンスを作成
+func NewSelfTestService(emb embedder.Embedder, s store.Store, namespace string) SelfTestService {
+	return &selfTestService{embedder: emb, store: s, namespace: namespace}
+}
+
+// SelfTest は固定の文を埋め込み、一時ノートを追加・検索・削除して各手順の所要時間を返す
+// 埋め込みの次元数がnamespaceの次元数（0なら未確定のため確認しない）と異なる場合はErrDimensionMismatchを返す
+// 途中で失敗しても追加した一時ノートは削除する
+func (s *selfTestService) SelfTest(ctx context.Context) (*SelfTestResponse, error) {
+	resp := &SelfTestResponse{Namespace: s.namespace}
+
+	start := time.Now()
+	embedding, err := s.embedder.Embed(ctx, selfTestText)
+	if err != nil {
+		return nil, fmt.Errorf("embed: %w", err)
+	}
+	resp.Embed = time.Since(start)
+	resp.Dimension = len(embedding)
+	if _, _, dim, err := config.ParseNamespace(s.namespace); err == nil && dim > 0 && dim != len(embedding) {
+		return nil, fmt.Errorf("%w: embedder returned %d dims but namespace %s expects %d (set embedder.dim to %d)",
+			ErrDimensionMismatch, len(embedding), s.namespace, dim, len(embedding))
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	note := &model.Note{
+		ID:        uuid.NewString(),
+		ProjectID: selfTestProjectID,
+		GroupID:   model.GlobalGroupID,
+		Text:      selfTestText,
+		Tags:      []string{},
+		CreatedAt: &now,
+	}
+	start = time.Now()
+	if err := s.store.AddNote(ctx, note, embedding); err != nil {
+		return nil, fmt.Errorf("add note: %w", err)
+	}
+	resp.Add = time.Since(start)
+
+	start = time.Now()
+	results, searchErr := s.store.Search(ctx, embedding, store.SearchOptions{ProjectID: selfTestProjectID, TopK: 1})
+	resp.Search = time.Since(start)
+
+	start = time.Now()
+	if err := s.store.Delete(ctx, note.ID); err != nil {
+		return nil, fmt.Errorf("delete note %s: %w", note.ID, err)
+	}
+	resp.Delete = time.Since(start)
+
+	if searchErr != nil {
+		return nil, fmt.Errorf("search: %w", searchErr)
+	}
+	if len(results) == 0 || results[0].Note.ID != note.ID {
+		return nil, fmt.Errorf("search: the added note was not found")
+	}
+	return resp, nil
+}
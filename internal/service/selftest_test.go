@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/store"
+)
+
+func TestSelfTest_Success(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewMemoryStore()
+	if err := s.Initialize(ctx, "openai:test:3"); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := NewSelfTestService(&mockEmbedder{}, s, "openai:test:3").SelfTest(ctx)
+	if err != nil {
+		t.Fatalf("SelfTest failed: %v", err)
+	}
+	if resp.Namespace != "openai:test:3" || resp.Dimension != 3 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	// 一時ノートは残らない
+	projects, err := s.ListProjects(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(projects) != 0 {
+		t.Errorf("expected no projects after the self-test, got %+v", projects)
+	}
+}
+
+func TestSelfTest_DimensionMismatch(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewMemoryStore()
+	if err := s.Initialize(ctx, "openai:text-embedding-3-small:1536"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := NewSelfTestService(&mockEmbedder{}, s, "openai:text-embedding-3-small:1536").SelfTest(ctx)
+	if !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("expected ErrDimensionMismatch, got %v", err)
+	}
+}
+
+func TestSelfTest_EmbedError(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewMemoryStore()
+	if err := s.Initialize(ctx, "openai:test:3"); err != nil {
+		t.Fatal(err)
+	}
+	embedErr := errors.New("connection refused")
+	emb := &mockEmbedder{embedFunc: func(ctx context.Context, text string) ([]float32, error) { return nil, embedErr }}
+
+	if _, err := NewSelfTestService(emb, s, "openai:test:3").SelfTest(ctx); !errors.Is(err, embedErr) {
+		t.Errorf("expected the embedder error, got %v", err)
+	}
+}
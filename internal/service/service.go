@@ -3,29 +3,40 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"regexp"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
 )
 
 // NoteService はノートのCRUD + 検索を提供
 type NoteService interface {
 	AddNote(ctx context.Context, req *AddNoteRequest) (*AddNoteResponse, error)
+	AddNotes(ctx context.Context, req *AddNotesRequest) (*AddNotesResponse, error)
 	Search(ctx context.Context, req *SearchRequest) (*SearchResponse, error)
 	Get(ctx context.Context, id string) (*GetResponse, error)
+	GetDocument(ctx context.Context, id string) (*GetResponse, error)
 	Update(ctx context.Context, req *UpdateRequest) error
 	Delete(ctx context.Context, id string) error
 	ListRecent(ctx context.Context, req *ListRecentRequest) (*ListRecentResponse, error)
+	ListProjects(ctx context.Context) (*ListProjectsResponse, error)
 }
 
 // ConfigService は設定の取得・変更を提供
 type ConfigService interface {
 	GetConfig(ctx context.Context) (*GetConfigResponse, error)
 	SetConfig(ctx context.Context, req *SetConfigRequest) (*SetConfigResponse, error)
+	AddProjectAlias(ctx context.Context, req *AddProjectAliasRequest) (*AddProjectAliasResponse, error)
 }
 
 // GlobalService はグローバル設定のUpsert/Get/Deleteを提供
 type GlobalService interface {
 	UpsertGlobal(ctx context.Context, req *UpsertGlobalRequest) (*UpsertGlobalResponse, error)
 	GetGlobal(ctx context.Context, projectID, key string) (*GetGlobalResponse, error)
+	GetGlobalHistory(ctx context.Context, projectID, key string) (*GetGlobalHistoryResponse, error)
+	ExportGlobals(ctx context.Context, projectID string) (*ExportGlobalsResponse, error)
+	ImportGlobals(ctx context.Context, req *ImportGlobalsRequest) (*ImportGlobalsResponse, error)
 	DeleteByID(ctx context.Context, id string) error
 }
 
@@ -34,10 +45,60 @@ type GroupService interface {
 	CreateGroup(ctx context.Context, req *CreateGroupRequest) (*CreateGroupResponse, error)
 	GetGroup(ctx context.Context, id string) (*GetGroupResponse, error)
 	UpdateGroup(ctx context.Context, req *UpdateGroupRequest) error
-	DeleteGroup(ctx context.Context, id string) error
+	DeleteGroup(ctx context.Context, req *DeleteGroupRequest) (*DeleteGroupResponse, error)
+	RenameGroup(ctx context.Context, req *RenameGroupRequest) (*RenameGroupResponse, error)
 	ListGroups(ctx context.Context, projectID string) (*ListGroupsResponse, error)
 }
 
+// ExportService はプロジェクト単位のエクスポート/インポート（JSONL）を提供
+type ExportService interface {
+	Export(ctx context.Context, w io.Writer, req *ExportRequest) (*ExportResponse, error)
+	Import(ctx context.Context, r io.Reader, req *ImportRequest) (*ImportResponse, error)
+}
+
+// SyncService はファイル単位でノートを同期する（ingest/watch用）
+// ノートIDはprojectId・groupId・source・チャンク番号から決まるため、同じファイルを何度同期しても重複しない
+type SyncService interface {
+	Sync(ctx context.Context, req *SyncRequest) (*SyncResponse, error)
+	ListSources(ctx context.Context, projectID, groupID string) ([]string, error)
+}
+
+// MigrateService はembedder変更前のnamespaceのノートを現在のnamespaceへ移行する
+type MigrateService interface {
+	CountNotes(ctx context.Context, namespace string) (int, error)
+	Migrate(ctx context.Context, req *MigrateRequest) (*MigrateResponse, error)
+}
+
+// MergeService はprojectIdの正規化ルール変更で別々のprojectIdに分かれたプロジェクトをまとめる
+type MergeService interface {
+	MergeProjects(ctx context.Context, req *MergeProjectsRequest) (*MergeProjectsResponse, error)
+}
+
+// RepairService は以前のバージョンなどで形式を揃えずに保存されたデータを修復する
+type RepairService interface {
+	RepairTimestamps(ctx context.Context, req *RepairTimestampsRequest) (*RepairTimestampsResponse, error)
+	// Validate はノート・埋め込み・グループの整合性を検証し、Repairなら修復する
+	Validate(ctx context.Context, req *ValidateRequest) (*ValidateResponse, error)
+}
+
+// ArchiveService は長くアクセスのないノートをアーカイブ（ベクトルインデックスを持たない圧縮したコールドストア）に移し、
+// 通常の検索の対象から外す。移したノートはRestoreで戻すか、検索のincludeArchivedで検索できる
+type ArchiveService interface {
+	Archive(ctx context.Context, req *ArchiveRequest) (*ArchiveResponse, error)
+	Restore(ctx context.Context, req *RestoreRequest) (*RestoreResponse, error)
+	ListArchived(ctx context.Context, req *ListArchivedRequest) (*ListArchivedResponse, error)
+}
+
+// SelfTestService はembedderとstoreを一通り試す（serve起動時の自己診断）
+type SelfTestService interface {
+	SelfTest(ctx context.Context) (*SelfTestResponse, error)
+}
+
+// StatsService はnamespaceのノート数と閾値（store.noteThresholds）に対する状態を返す
+type StatsService interface {
+	Stats(ctx context.Context) (*StatsResponse, error)
+}
+
 // エラー定義
 var (
 	ErrNoteNotFound         = errors.New("note not found")
@@ -51,10 +112,43 @@ var (
 	ErrInvalidGroupID       = errors.New("groupId contains invalid characters")
 	ErrInvalidGroupKey      = errors.New("groupKey contains invalid characters or is reserved")
 	ErrGroupKeyExists       = errors.New("groupKey already exists in this project")
+	ErrInvalidGroupStatus   = errors.New("status must be active or done")
+	ErrInvalidParentGroup   = errors.New("invalid parentGroupId")
+	ErrInvalidCascade       = errors.New("cascade must be delete, move or restrict")
+	ErrGroupHasNotes        = errors.New("group still has notes")
 	ErrTextRequired         = errors.New("text is required")
 	ErrQueryRequired        = errors.New("query is required")
 	ErrIDRequired           = errors.New("id is required")
 	ErrInvalidTimeFormat    = errors.New("invalid time format (expected ISO8601 UTC)")
+	ErrInvalidImportMode    = errors.New("invalid import mode (must be skip-existing or overwrite)")
+	ErrInvalidImportRecord  = errors.New("invalid import record")
+	ErrUnsupportedExport    = errors.New("unsupported export format version")
+	ErrImportConflict       = errors.New("record already exists (use skip-existing or overwrite)")
+	ErrSourceRequired       = errors.New("source is required")
+	ErrInvalidMinScore      = errors.New("minScore must be between 0 and 1")
+	ErrNamespaceRequired    = errors.New("namespace is required")
+	ErrInvalidNamespace     = errors.New("invalid namespace (expected provider:model:dim)")
+	ErrSameNamespace        = errors.New("namespace is the current namespace")
+	ErrMissingRequiredTags  = errors.New("missing required tags")
+	ErrInvalidNotePolicy    = errors.New("invalid note policy")
+	ErrPathRequired         = errors.New("path is required")
+	ErrInvalidSortBy        = errors.New("sortBy must be createdAt or updatedAt")
+	ErrInvalidCursor        = errors.New("invalid cursor")
+	ErrInvalidGroupBy       = errors.New("groupBy must be group")
+	ErrInvalidGroupTopK     = errors.New("groupTopK must be greater than 0")
+	ErrGroupByMultiProject  = errors.New("groupBy cannot be used with projectIds")
+	ErrNoteConflict         = errors.New("note has been updated since ifUpdatedAt")
+	ErrInvalidAttachment    = errors.New("invalid attachment")
+	ErrInvalidImportance    = errors.New("importance must be between 0 and 1")
+	ErrNoteTooLarge         = errors.New("note exceeds size limit")
+	ErrInvalidMetadata      = errors.New("metadata does not match the project's metadata schema")
+	ErrDuplicateNote        = errors.New("a similar note already exists")
+	ErrInvalidOnDuplicate   = errors.New("onDuplicate must be reject, merge or proceed")
+	ErrInvalidThreshold     = errors.New("duplicateThreshold must be between 0 and 1")
+	ErrGroupKeyPrefix       = errors.New("groupKey does not start with a groupDefaults prefix")
+	ErrUnknownModel         = errors.New("model is not configured in embedders")
+	ErrInvalidOlderThanDays = errors.New("olderThanDays must be greater than 0")
+	ErrIDsRequired          = errors.New("ids is required")
 )
 
 // groupIDRegex はgroupIdの文字制約を検証
@@ -71,3 +165,21 @@ func ValidateGroupID(groupID string) error {
 	}
 	return nil
 }
+
+// ValidateImportance はimportanceが0-1の範囲か検証する（nilは未指定）
+func ValidateImportance(importance *float64) error {
+	if importance != nil && (*importance < 0 || *importance > 1) {
+		return ErrInvalidImportance
+	}
+	return nil
+}
+
+// ValidateAttachments はattachmentsの各要素を検証する（パスかハッシュが必要）
+func ValidateAttachments(attachments []model.Attachment) error {
+	for i, a := range attachments {
+		if err := a.Validate(); err != nil {
+			return fmt.Errorf("%w: attachments[%d]: %v", ErrInvalidAttachment, i, err)
+		}
+	}
+	return nil
+}
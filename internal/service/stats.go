@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/store"
+)
+
+// DefaultSoftNoteThreshold はstore.noteThresholds.soft未指定時の警告の閾値
+const DefaultSoftNoteThreshold = 5000
+
+// growthWindow は増加数を比べる過去の計測の範囲
+const growthWindow = 24 * time.Hour
+
+// ノート数の閾値に対する状態
+const (
+	NoteLevelOK       = "ok"
+	NoteLevelWarning  = "warning"  // softを超えた
+	NoteLevelCritical = "critical" // hardを超えた
+)
+
+// NoteThresholds はノート数の閾値（Hardが0なら無効）
+type NoteThresholds struct {
+	Soft int
+	Hard int
+}
+
+// NoteThresholdsFromConfig はstore.noteThresholdsから閾値を求める（softの省略時はDefaultSoftNoteThreshold）
+func NoteThresholdsFromConfig(cfg *model.NoteThresholdsConfig) NoteThresholds {
+	t := NoteThresholds{Soft: DefaultSoftNoteThreshold}
+	if cfg != nil {
+		if cfg.Soft > 0 {
+			t.Soft = cfg.Soft
+		}
+		t.Hard = cfg.Hard
+	}
+	return t
+}
+
+// noteCountSample はノート数の計測値
+type noteCountSample struct {
+	at    time.Time
+	count int
+}
+
+// statsService はStatsServiceの実装
+type statsService struct {
+	store      store.Store
+	namespace  string
+	storeType  string
+	thresholds NoteThresholds
+	now        func() time.Time
+
+	// samples はgrowthWindow内の計測値（古い順）
+	mu      sync.Mutex
+	samples []noteCountSample
+}
+
+// NewStatsService はStatsServiceの新しいインスタンスを作成
+// storeTypeは閾値を超えた場合の推奨（バックエンドの変更か整理か）に使う
+func NewStatsService(s store.Store, namespace, storeType string, thresholds NoteThresholds) StatsService {
+	return &statsService{store: s, namespace: namespace, storeType: storeType, thresholds: thresholds, now: time.Now}
+}
+
+// Stats はnamespaceのノート数（プロジェクト別）と閾値に対する状態、過去の計測からの増加数を返す
+// 計測値はこのインスタンスが保持するため、増加数は同じサービスで前回呼び出した時点からのもの（最大24時間前）
+func (s *statsService) Stats(ctx context.Context) (*StatsResponse, error) {
+	projects, err := s.store.ListProjects(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count notes: %w", err)
+	}
+
+	resp := &StatsResponse{
+		Namespace: s.namespace,
+		Soft:      s.thresholds.Soft,
+		Hard:      s.thresholds.Hard,
+		Projects:  make([]ProjectStats, 0, len(projects)),
+	}
+	for _, p := range projects {
+		resp.Notes += p.NoteCount
+		resp.Projects = append(resp.Projects, ProjectStats{ProjectID: p.ProjectID, Notes: p.NoteCount})
+	}
+	sort.SliceStable(resp.Projects, func(i, j int) bool {
+		return resp.Projects[i].Notes > resp.Projects[j].Notes
+	})
+
+	switch {
+	case s.thresholds.Hard > 0 && resp.Notes >= s.thresholds.Hard:
+		resp.Level = NoteLevelCritical
+	case s.thresholds.Soft > 0 && resp.Notes >= s.thresholds.Soft:
+		resp.Level = NoteLevelWarning
+	default:
+		resp.Level = NoteLevelOK
+	}
+	if resp.Level != NoteLevelOK {
+		resp.Recommendation = s.recommendation()
+	}
+
+	resp.GrowthSince, resp.Growth = s.record(resp.Notes)
+	return resp, nil
+}
+
+// record は計測値を記録し、growthWindow内で最も古い計測の時刻とそこからの増加数を返す（初回はゼロ値）
+func (s *statsService) record(count int) (time.Time, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	i := 0
+	for i < len(s.samples) && now.Sub(s.samples[i].at) > growthWindow {
+		i++
+	}
+	s.samples = append(s.samples[i:], noteCountSample{at: now, count: count})
+	oldest := s.samples[0]
+	if len(s.samples) == 1 {
+		return time.Time{}, 0
+	}
+	return oldest.at, count - oldest.count
+}
+
+// recommendation は閾値を超えた場合の推奨を返す
+func (s *statsService) recommendation() string {
+	switch s.storeType {
+	case model.StoreTypeQdrant, model.StoreTypeChroma:
+		return "prune notes that are no longer needed (e.g. delete finished groups with memory.group_delete)"
+	default:
+		return "switch to the qdrant store for larger collections, or prune notes that are no longer needed"
+	}
+}
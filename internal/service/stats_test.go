@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/store"
+)
+
+// addStatsNotes はprojectIDにn件のノートを追加する
+func addStatsNotes(t *testing.T, s store.Store, projectID string, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		note := &model.Note{ID: fmt.Sprintf("%s-%d", projectID, i), ProjectID: projectID, GroupID: "global", Text: "note", Tags: []string{}}
+		if err := s.AddNote(context.Background(), note, []float32{1, 0, 0}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestStats_Levels(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewMemoryStore()
+	if err := s.Initialize(ctx, "openai:test:3"); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewStatsService(s, "openai:test:3", model.StoreTypeSQLite, NoteThresholds{Soft: 3, Hard: 5}).(*statsService)
+	now := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	addStatsNotes(t, s, "/test/a", 1)
+	addStatsNotes(t, s, "/test/b", 1)
+	resp, err := svc.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	if resp.Notes != 2 || resp.Level != NoteLevelOK || resp.Recommendation != "" || !resp.GrowthSince.IsZero() {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	// softを超えるとwarning、増加数は前回の計測から
+	addStatsNotes(t, s, "/test/c", 2)
+	now = now.Add(time.Hour)
+	resp, err = svc.Stats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Level != NoteLevelWarning || resp.Recommendation == "" || resp.Growth != 2 || resp.Projects[0].ProjectID != "/test/c" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	// hardを超えるとcritical。24時間より前の計測は比べない
+	addStatsNotes(t, s, "/test/d", 2)
+	now = now.Add(25 * time.Hour)
+	resp, err = svc.Stats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Level != NoteLevelCritical || resp.Notes != 6 || resp.Growth != 0 || !resp.GrowthSince.IsZero() {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
+
+func TestNoteThresholdsFromConfig(t *testing.T) {
+	if got := NoteThresholdsFromConfig(nil); got != (NoteThresholds{Soft: DefaultSoftNoteThreshold}) {
+		t.Errorf("unexpected default thresholds: %+v", got)
+	}
+	if got := NoteThresholdsFromConfig(&model.NoteThresholdsConfig{Hard: 20000}); got != (NoteThresholds{Soft: DefaultSoftNoteThreshold, Hard: 20000}) {
+		t.Errorf("unexpected thresholds: %+v", got)
+	}
+	if got := NoteThresholdsFromConfig(&model.NoteThresholdsConfig{Soft: 100, Hard: 200}); got != (NoteThresholds{Soft: 100, Hard: 200}) {
+		t.Errorf("unexpected thresholds: %+v", got)
+	}
+}
@@ -0,0 +1,315 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/embedder"
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/store"
+	"github.com/google/uuid"
+)
+
+// syncIDNamespace は同期ノートのID（UUIDv5）を導出するための名前空間
+var syncIDNamespace = uuid.NewSHA1(uuid.NameSpaceURL, []byte("https://github.com/brbranch/embedding_mcp/sync"))
+
+// SyncNoteID は同期ノートのIDを返す（projectIDは正規化済みであること）
+func SyncNoteID(projectID, groupID, source string, index int) string {
+	name := projectID + "\x00" + groupID + "\x00" + source + "\x00" + strconv.Itoa(index)
+	return uuid.NewSHA1(syncIDNamespace, []byte(name)).String()
+}
+
+// syncService はSyncServiceの実装
+type syncService struct {
+	embedder  embedder.Embedder
+	store     store.Store
+	namespace string
+}
+
+// NewSyncService はSyncServiceの新しいインスタンスを作成
+func NewSyncService(emb embedder.Embedder, s store.Store, namespace string) SyncService {
+	return &syncService{
+		embedder:  emb,
+		store:     s,
+		namespace: namespace,
+	}
+}
+
+// Sync はsourceのノートをChunksの内容に揃える
+// 本文が変わったチャンクだけを再埋め込み（一括）し、余ったチャンクは削除する
+func (s *syncService) Sync(ctx context.Context, req *SyncRequest) (*SyncResponse, error) {
+	projectID, err := s.validate(req.ProjectID, req.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Source == "" {
+		return nil, ErrSourceRequired
+	}
+	for i, c := range req.Chunks {
+		if c.Text == "" {
+			return nil, fmt.Errorf("chunks[%d]: %w", i, ErrTextRequired)
+		}
+		if err := CheckNoteLimits(c.Title, &c.Text, c.Tags, c.Metadata); err != nil {
+			return nil, fmt.Errorf("chunks[%d]: %w", i, err)
+		}
+		if c.CreatedAt != nil {
+			if _, err := NormalizeTimestamp(*c.CreatedAt); err != nil {
+				return nil, fmt.Errorf("chunks[%d]: %w", i, err)
+			}
+		}
+	}
+
+	resp := &SyncResponse{Namespace: s.namespace, ProjectID: projectID}
+	// チャンク0を親ノートとし、全チャンク（チャンク0自身を含む）にそのIDとチャンク番号を持たせる
+	parentID := SyncNoteID(projectID, req.GroupID, req.Source, 0)
+
+	// 既存ノートとの差分を計算
+	notes := make([]*model.Note, len(req.Chunks))
+	existing := make([]*model.Note, len(req.Chunks))
+	var embedIdx []int
+	var texts []string
+	for i, c := range req.Chunks {
+		id := SyncNoteID(projectID, req.GroupID, req.Source, i)
+		old, err := s.get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		source, index := req.Source, i
+		note := &model.Note{
+			ID:         id,
+			ProjectID:  projectID,
+			GroupID:    req.GroupID,
+			Title:      c.Title,
+			Text:       c.Text,
+			Tags:       NormalizeTags(c.Tags),
+			Source:     &source,
+			Metadata:   withLanguage(c.Metadata, c.Text),
+			ParentID:   &parentID,
+			ChunkIndex: &index,
+		}
+		if note.Tags == nil {
+			note.Tags = []string{}
+		}
+		if old != nil {
+			// importanceはファイルの内容ではないので既存の値を引き継ぐ
+			note.CreatedAt, note.Importance = old.CreatedAt, old.Importance
+		} else if c.CreatedAt != nil {
+			createdAt, _ := NormalizeTimestamp(*c.CreatedAt)
+			note.CreatedAt = &createdAt
+		} else {
+			now := time.Now().UTC().Format(time.RFC3339)
+			note.CreatedAt = &now
+		}
+		notes[i], existing[i] = note, old
+
+		if old == nil || old.Text != note.Text {
+			embedIdx = append(embedIdx, i)
+			texts = append(texts, note.Text)
+		}
+	}
+
+	// 埋め込み一括生成
+	embeddings := make([][]float32, len(notes))
+	if len(texts) > 0 {
+		vectors, err := embedder.EmbedBatch(ctx, s.embedder, texts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate embeddings: %w", err)
+		}
+		for j, i := range embedIdx {
+			embeddings[i] = vectors[j]
+		}
+	}
+
+	// トランザクションに対応したストアでは、途中で失敗すれば何も書き込まない（対応していなければrespの件数まで書き込み済み）
+	atomic, err := store.WithTx(ctx, s.store, func(tx store.Store) error {
+		txs := &syncService{embedder: s.embedder, store: tx, namespace: s.namespace}
+		return txs.write(ctx, req, projectID, notes, existing, embeddings, resp)
+	})
+	if err != nil {
+		if atomic {
+			resp = &SyncResponse{Namespace: s.namespace, ProjectID: projectID}
+		}
+		return resp, err
+	}
+
+	return resp, nil
+}
+
+// write はnotesを先頭から書き込み、余ったチャンクを削除する（件数をrespに数える）
+func (s *syncService) write(ctx context.Context, req *SyncRequest, projectID string, notes, existing []*model.Note, embeddings [][]float32, resp *SyncResponse) error {
+	for i, note := range notes {
+		old := existing[i]
+		switch {
+		case old == nil:
+			if err := s.store.AddNote(ctx, note, embeddings[i]); err != nil {
+				return fmt.Errorf("failed to add note: %w", err)
+			}
+			resp.Created++
+		case embeddings[i] == nil && sameNoteFields(old, note):
+			resp.Unchanged++
+		default:
+			embedding := embeddings[i]
+			if embedding == nil {
+				// 本文は同じなので既存の埋め込みを引き継ぐ
+				var err error
+				embedding, err = s.store.GetEmbedding(ctx, note.ID)
+				if err != nil {
+					return fmt.Errorf("failed to get embedding: %w", err)
+				}
+			}
+			if err := s.store.Update(ctx, note, embedding); err != nil {
+				return fmt.Errorf("failed to update note: %w", err)
+			}
+			resp.Updated++
+		}
+	}
+
+	// 余ったチャンクを後ろから削除（途中で失敗してもチャンク番号の連続性を保つ）
+	last := len(req.Chunks) - 1
+	for {
+		note, err := s.get(ctx, SyncNoteID(projectID, req.GroupID, req.Source, last+1))
+		if err != nil {
+			return err
+		}
+		if note == nil {
+			break
+		}
+		last++
+	}
+	for i := last; i >= len(req.Chunks); i-- {
+		if err := s.store.Delete(ctx, SyncNoteID(projectID, req.GroupID, req.Source, i)); err != nil {
+			return fmt.Errorf("failed to delete note: %w", err)
+		}
+		resp.Deleted++
+	}
+	return nil
+}
+
+// ListSources は同期済みのsource一覧を返す（source順）
+// 手動で追加されたノートはsourceが同じでも含まない
+func (s *syncService) ListSources(ctx context.Context, projectID, groupID string) ([]string, error) {
+	projectID, err := s.validate(projectID, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	notes, err := s.store.ListNotes(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notes: %w", err)
+	}
+
+	var sources []string
+	for _, note := range notes {
+		if note.GroupID != groupID || note.Source == nil {
+			continue
+		}
+		// チャンク0のIDと一致するノートだけを同期ノートとみなす
+		if note.ID == SyncNoteID(projectID, groupID, *note.Source, 0) {
+			sources = append(sources, *note.Source)
+		}
+	}
+	sort.Strings(sources)
+	return sources, nil
+}
+
+// validate はprojectId/groupIdを検証し、正規化済みprojectIdを返す
+func (s *syncService) validate(projectID, groupID string) (string, error) {
+	if projectID == "" {
+		return "", ErrProjectIDRequired
+	}
+	if groupID == "" {
+		return "", ErrGroupIDRequired
+	}
+	if err := ValidateGroupID(groupID); err != nil {
+		return "", err
+	}
+	canonical, err := config.CanonicalizeProjectID(projectID)
+	if err != nil {
+		return "", fmt.Errorf("failed to canonicalize projectId: %w", err)
+	}
+	return canonical, nil
+}
+
+// get はIDでノートを取得する（存在しなければnil）
+func (s *syncService) get(ctx context.Context, id string) (*model.Note, error) {
+	note, err := s.store.Get(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note: %w", err)
+	}
+	return note, nil
+}
+
+// minChunkOverlap は隣接チャンクの重複とみなす最短の文字数（偶然の一致で本文を削らないため）
+const minChunkOverlap = 10
+
+// joinChunks はチャンクの本文を順に連結する
+// 隣接チャンクの重複（前のチャンクの末尾と次のチャンクの先頭の一致）は取り除き、重複がなければ空行で区切る
+func joinChunks(texts []string) string {
+	var b strings.Builder
+	for i, text := range texts {
+		if i == 0 {
+			b.WriteString(text)
+			continue
+		}
+		if n := chunkOverlap(texts[i-1], text); n > 0 {
+			b.WriteString(text[n:])
+			continue
+		}
+		b.WriteString("\n\n")
+		b.WriteString(text)
+	}
+	return b.String()
+}
+
+// chunkOverlap はprevの末尾とnextの先頭が一致する最長のバイト数を返す（minChunkOverlap文字未満なら0）
+func chunkOverlap(prev, next string) int {
+	for n := min(len(prev), len(next)); n > 0; n-- {
+		if n < len(next) && !utf8.RuneStart(next[n]) {
+			continue
+		}
+		if strings.HasSuffix(prev, next[:n]) {
+			if utf8.RuneCountInString(next[:n]) < minChunkOverlap {
+				return 0
+			}
+			return n
+		}
+	}
+	return 0
+}
+
+// sameNoteFields は本文以外の同期対象フィールド（title/tags/metadata/親子関係）が等しいか判定する
+// metadataはストアによって数値型が変わるためJSON表現で比較する
+func sameNoteFields(a, b *model.Note) bool {
+	type fields struct {
+		Title      *string        `json:"title"`
+		Tags       []string       `json:"tags"`
+		Metadata   map[string]any `json:"metadata"`
+		ParentID   *string        `json:"parentId"`
+		ChunkIndex *int           `json:"chunkIndex"`
+	}
+	normalize := func(n *model.Note) fields {
+		f := fields{Title: n.Title, Tags: n.Tags, Metadata: n.Metadata, ParentID: n.ParentID, ChunkIndex: n.ChunkIndex}
+		if len(f.Tags) == 0 {
+			f.Tags = nil
+		}
+		if len(f.Metadata) == 0 {
+			f.Metadata = nil
+		}
+		return f
+	}
+	aj, err1 := json.Marshal(normalize(a))
+	bj, err2 := json.Marshal(normalize(b))
+	return err1 == nil && err2 == nil && string(aj) == string(bj)
+}
@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/store"
+)
+
+func setupSyncTestService(t *testing.T) (SyncService, store.Store, *mockBatchEmbedder) {
+	t.Helper()
+
+	st := store.NewMemoryStore()
+	if err := st.Initialize(context.Background(), "openai:test:3"); err != nil {
+		t.Fatalf("failed to initialize store: %v", err)
+	}
+
+	emb := &mockBatchEmbedder{mockEmbedder: mockEmbedder{dim: 3}}
+	return NewSyncService(emb, st, "openai:test:3"), st, emb
+}
+
+func syncChunks(texts ...string) []SyncChunk {
+	chunks := make([]SyncChunk, len(texts))
+	for i, text := range texts {
+		chunks[i] = SyncChunk{Text: text, Metadata: map[string]any{"chunkIndex": i, "chunkCount": len(texts)}}
+	}
+	return chunks
+}
+
+func TestSyncService_Sync_Create(t *testing.T) {
+	svc, st, emb := setupSyncTestService(t)
+	ctx := context.Background()
+
+	resp, err := svc.Sync(ctx, &SyncRequest{ProjectID: "/test/project", GroupID: "docs", Source: "docs/a.md", Chunks: syncChunks("one", "two")})
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if resp.Created != 2 || resp.Updated != 0 || resp.Deleted != 0 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if len(emb.batchCalls) != 1 {
+		t.Errorf("expected 1 batch call, got %d", len(emb.batchCalls))
+	}
+
+	note, err := st.Get(ctx, SyncNoteID("/test/project", "docs", "docs/a.md", 1))
+	if err != nil {
+		t.Fatalf("expected stable ID for chunk 1: %v", err)
+	}
+	if note.Text != "two" || note.Source == nil || *note.Source != "docs/a.md" || note.CreatedAt == nil {
+		t.Errorf("unexpected note: %+v", note)
+	}
+}
+
+// TestSyncService_Sync_CreatedAt は新規ノートだけに指定したcreatedAtを使うことをテスト
+func TestSyncService_Sync_CreatedAt(t *testing.T) {
+	svc, st, _ := setupSyncTestService(t)
+	ctx := context.Background()
+	createdAt, later := "2024-03-01T09:00:00+09:00", "2025-01-01T00:00:00Z"
+	chunks := syncChunks("one")
+	chunks[0].CreatedAt = &createdAt
+
+	if _, err := svc.Sync(ctx, &SyncRequest{ProjectID: "/test/project", GroupID: "docs", Source: "notion:a.md", Chunks: chunks}); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	id := SyncNoteID("/test/project", "docs", "notion:a.md", 0)
+	note, err := st.Get(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if note.CreatedAt == nil || *note.CreatedAt != "2024-03-01T00:00:00Z" {
+		t.Errorf("expected createdAt in UTC, got %v", note.CreatedAt)
+	}
+
+	chunks[0].Text, chunks[0].CreatedAt = "one (edited)", &later
+	if _, err := svc.Sync(ctx, &SyncRequest{ProjectID: "/test/project", GroupID: "docs", Source: "notion:a.md", Chunks: chunks}); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if note, _ := st.Get(ctx, id); *note.CreatedAt != "2024-03-01T00:00:00Z" {
+		t.Errorf("expected createdAt of an existing note to be kept, got %s", *note.CreatedAt)
+	}
+}
+
+func TestSyncService_Sync_Idempotent(t *testing.T) {
+	svc, _, emb := setupSyncTestService(t)
+	ctx := context.Background()
+	req := &SyncRequest{ProjectID: "/test/project", GroupID: "docs", Source: "docs/a.md", Chunks: syncChunks("one", "two")}
+
+	if _, err := svc.Sync(ctx, req); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	resp, err := svc.Sync(ctx, req)
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if resp.Unchanged != 2 || resp.Created != 0 || resp.Updated != 0 {
+		t.Errorf("expected all unchanged, got %+v", resp)
+	}
+	if len(emb.batchCalls) != 1 {
+		t.Errorf("expected no re-embedding, got %d batch calls", len(emb.batchCalls))
+	}
+}
+
+func TestSyncService_Sync_ChangeAndShrink(t *testing.T) {
+	svc, st, emb := setupSyncTestService(t)
+	ctx := context.Background()
+
+	if _, err := svc.Sync(ctx, &SyncRequest{ProjectID: "/test/project", GroupID: "docs", Source: "docs/a.md", Chunks: syncChunks("one", "two", "three")}); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	firstID := SyncNoteID("/test/project", "docs", "docs/a.md", 0)
+	before, _ := st.Get(ctx, firstID)
+
+	// chunk 0は本文が同じ（metadataのみ変化）、chunk 1は本文が変化、chunk 2は削除
+	resp, err := svc.Sync(ctx, &SyncRequest{ProjectID: "/test/project", GroupID: "docs", Source: "docs/a.md", Chunks: syncChunks("one", "TWO")})
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if resp.Updated != 2 || resp.Deleted != 1 || resp.Created != 0 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if got := emb.batchCalls[len(emb.batchCalls)-1]; !reflect.DeepEqual(got, []string{"TWO"}) {
+		t.Errorf("expected only changed text to be embedded, got %v", got)
+	}
+
+	after, _ := st.Get(ctx, firstID)
+	if *after.CreatedAt != *before.CreatedAt {
+		t.Errorf("expected createdAt to be preserved")
+	}
+	if embedding, _ := st.GetEmbedding(ctx, firstID); len(embedding) == 0 {
+		t.Error("expected embedding to be kept for metadata-only update")
+	}
+	if _, err := st.Get(ctx, SyncNoteID("/test/project", "docs", "docs/a.md", 2)); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("expected chunk 2 to be deleted, got %v", err)
+	}
+}
+
+func TestSyncService_Sync_RemoveAll(t *testing.T) {
+	svc, st, _ := setupSyncTestService(t)
+	ctx := context.Background()
+
+	if _, err := svc.Sync(ctx, &SyncRequest{ProjectID: "/test/project", GroupID: "docs", Source: "docs/a.md", Chunks: syncChunks("one", "two")}); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	resp, err := svc.Sync(ctx, &SyncRequest{ProjectID: "/test/project", GroupID: "docs", Source: "docs/a.md"})
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if resp.Deleted != 2 {
+		t.Errorf("expected 2 deleted, got %+v", resp)
+	}
+	notes, _ := st.ListNotes(ctx, "/test/project")
+	if len(notes) != 0 {
+		t.Errorf("expected no notes, got %d", len(notes))
+	}
+}
+
+func TestSyncService_Sync_Validation(t *testing.T) {
+	svc, _, _ := setupSyncTestService(t)
+	ctx := context.Background()
+
+	date := "2024-03-01"
+	tests := []struct {
+		name string
+		req  *SyncRequest
+		want error
+	}{
+		{"project required", &SyncRequest{GroupID: "docs", Source: "a.md"}, ErrProjectIDRequired},
+		{"group required", &SyncRequest{ProjectID: "/p", Source: "a.md"}, ErrGroupIDRequired},
+		{"invalid group", &SyncRequest{ProjectID: "/p", GroupID: "bad group", Source: "a.md"}, ErrInvalidGroupID},
+		{"source required", &SyncRequest{ProjectID: "/p", GroupID: "docs"}, ErrSourceRequired},
+		{"text required", &SyncRequest{ProjectID: "/p", GroupID: "docs", Source: "a.md", Chunks: []SyncChunk{{}}}, ErrTextRequired},
+		{"invalid createdAt", &SyncRequest{ProjectID: "/p", GroupID: "docs", Source: "a.md", Chunks: []SyncChunk{{Text: "x", CreatedAt: &date}}}, ErrInvalidTimeFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.Sync(ctx, tt.req); !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestSyncService_ListSources(t *testing.T) {
+	svc, st, _ := setupSyncTestService(t)
+	ctx := context.Background()
+
+	for _, source := range []string{"docs/b.md", "docs/a.md"} {
+		if _, err := svc.Sync(ctx, &SyncRequest{ProjectID: "/test/project", GroupID: "docs", Source: source, Chunks: syncChunks("x", "y")}); err != nil {
+			t.Fatalf("Sync failed: %v", err)
+		}
+	}
+	if _, err := svc.Sync(ctx, &SyncRequest{ProjectID: "/test/project", GroupID: "other", Source: "docs/c.md", Chunks: syncChunks("z")}); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	// 手動で追加したノートは同期対象外
+	manual := "docs/manual.md"
+	if err := st.AddNote(ctx, &model.Note{ID: "manual", ProjectID: "/test/project", GroupID: "docs", Text: "m", Source: &manual}, []float32{1, 0, 0}); err != nil {
+		t.Fatalf("AddNote failed: %v", err)
+	}
+
+	sources, err := svc.ListSources(ctx, "/test/project", "docs")
+	if err != nil {
+		t.Fatalf("ListSources failed: %v", err)
+	}
+	if want := []string{"docs/a.md", "docs/b.md"}; !reflect.DeepEqual(sources, want) {
+		t.Errorf("expected %v, got %v", want, sources)
+	}
+}
+
+func TestSyncService_Sync_ParentChunks(t *testing.T) {
+	svc, st, _ := setupSyncTestService(t)
+	ctx := context.Background()
+	notes := newTestNoteService(&mockEmbedder{dim: 3}, st, "openai:test:3")
+
+	chunks := syncChunks(
+		"The first chunk ends with a shared sentence about overlap.",
+		"a shared sentence about overlap. The second chunk follows.",
+		"A separate section.",
+	)
+	if _, err := svc.Sync(ctx, &SyncRequest{ProjectID: "/test/project", GroupID: "docs", Source: "docs/a.md", Chunks: chunks}); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if _, err := notes.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: "docs", Text: "plain"}); err != nil {
+		t.Fatalf("AddNote failed: %v", err)
+	}
+
+	parentID := SyncNoteID("/test/project", "docs", "docs/a.md", 0)
+	for i := range chunks {
+		note, err := st.Get(ctx, SyncNoteID("/test/project", "docs", "docs/a.md", i))
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if note.ParentID == nil || *note.ParentID != parentID || note.ChunkIndex == nil || *note.ChunkIndex != i {
+			t.Errorf("chunk %d: unexpected parentId/chunkIndex %v/%v", i, note.ParentID, note.ChunkIndex)
+		}
+	}
+
+	// 子チャンクから取得しても親の文書として連結した本文を返す
+	doc, err := notes.GetDocument(ctx, SyncNoteID("/test/project", "docs", "docs/a.md", 2))
+	if err != nil {
+		t.Fatalf("GetDocument failed: %v", err)
+	}
+	want := "The first chunk ends with a shared sentence about overlap. The second chunk follows.\n\nA separate section."
+	if doc.ID != parentID || doc.Text != want {
+		t.Errorf("unexpected document %s: %q", doc.ID, doc.Text)
+	}
+
+	// 同じ文書のチャンクは最もスコアの高い1件にまとめる
+	topK := 3
+	resp, err := notes.Search(ctx, &SearchRequest{ProjectID: "/test/project", Query: "q", TopK: &topK})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(resp.Results) != 3 {
+		t.Errorf("expected 3 results without collapsing, got %d", len(resp.Results))
+	}
+	resp, err = notes.Search(ctx, &SearchRequest{ProjectID: "/test/project", Query: "q", TopK: &topK, CollapseByParent: true})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(resp.Results) != 2 || resp.Results[1].ID != parentID {
+		t.Errorf("expected the plain note and the best chunk, got %+v", resp.Results)
+	}
+}
+
+func TestJoinChunks(t *testing.T) {
+	tests := []struct {
+		name  string
+		texts []string
+		want  string
+	}{
+		{"overlap", []string{"alpha beta gamma delta epsilon", "gamma delta epsilon zeta"}, "alpha beta gamma delta epsilon zeta"},
+		{"short match is not overlap", []string{"ends with the", "the next chunk"}, "ends with the\n\nthe next chunk"},
+		{"multibyte overlap", []string{"日本語の文章を分割したときの重複部分はここから始まる", "重複部分はここから始まる。続きの文章"}, "日本語の文章を分割したときの重複部分はここから始まる。続きの文章"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := joinChunks(tt.texts); got != tt.want {
+				t.Errorf("joinChunks() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
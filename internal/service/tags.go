@@ -0,0 +1,72 @@
+package service
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"golang.org/x/text/unicode/norm"
+)
+
+// tagRules はタグの正規化ルール（aliasesのキー・値は正規化済み）
+type tagRules struct {
+	normalize bool
+	aliases   map[string]string
+}
+
+var (
+	tagRulesMu      sync.RWMutex
+	currentTagRules tagRules
+)
+
+// SetTagRules はタグの正規化ルールを設定する（起動時。nilなら正規化しない）
+func SetTagRules(cfg *model.TagsConfig) {
+	r := tagRules{}
+	if cfg != nil {
+		r.normalize = cfg.Normalize
+		if len(cfg.Aliases) > 0 {
+			r.aliases = make(map[string]string, len(cfg.Aliases))
+			for from, to := range cfg.Aliases {
+				r.aliases[r.normalizeTag(from)] = r.normalizeTag(to)
+			}
+		}
+	}
+
+	tagRulesMu.Lock()
+	defer tagRulesMu.Unlock()
+	currentTagRules = r
+}
+
+// NormalizeTags は現在のルールでタグを正規化し、空になったタグと重複を除く
+// ルールが設定されていなければtagsをそのまま返す
+func NormalizeTags(tags []string) []string {
+	tagRulesMu.RLock()
+	r := currentTagRules
+	tagRulesMu.RUnlock()
+	if (!r.normalize && len(r.aliases) == 0) || tags == nil {
+		return tags
+	}
+
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = r.normalizeTag(tag)
+		if alias, ok := r.aliases[tag]; ok {
+			tag = alias
+		}
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// normalizeTag はnormalizeが有効なら前後の空白を除き、NFKCで正規化して小文字にする
+func (r tagRules) normalizeTag(tag string) string {
+	if !r.normalize {
+		return tag
+	}
+	return strings.ToLower(strings.TrimSpace(norm.NFKC.String(tag)))
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/store"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	t.Cleanup(func() { SetTagRules(nil) })
+
+	tags := []string{" Go ", "ＡＰＩ", "golang", "api", "Rule"}
+	if got := NormalizeTags(tags); !reflect.DeepEqual(got, tags) {
+		t.Errorf("expected tags unchanged without rules, got %v", got)
+	}
+
+	SetTagRules(&model.TagsConfig{Normalize: true, Aliases: map[string]string{"Golang": "Go"}})
+	if got, want := NormalizeTags(tags), []string{"go", "api", "rule"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("NormalizeTags() = %v, want %v", got, want)
+	}
+	if got := NormalizeTags(nil); got != nil {
+		t.Errorf("expected nil, got %v", got)
+	}
+
+	// 正規化なしでもエイリアスは適用する（大文字小文字は区別する）
+	SetTagRules(&model.TagsConfig{Aliases: map[string]string{"golang": "go"}})
+	if got, want := NormalizeTags([]string{"golang", "Golang", "go"}), []string{"go", "Golang"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("NormalizeTags() = %v, want %v", got, want)
+	}
+}
+
+func TestNoteService_TagRules(t *testing.T) {
+	ctx := context.Background()
+	SetTagRules(&model.TagsConfig{Normalize: true, Aliases: map[string]string{"golang": "go"}})
+	t.Cleanup(func() { SetTagRules(nil) })
+	memStore := store.NewMemoryStore()
+	svc := newTestNoteService(&mockEmbedder{dim: 3}, memStore, "openai:test:3")
+	upsertTestGlobal(t, memStore, "/test/project", model.GlobalKeyRequiredTags, []any{"Area"})
+
+	added, err := svc.AddNote(ctx, &AddNoteRequest{ProjectID: "/test/project", GroupID: "global", Text: "x", Tags: []string{"Golang", " AREA "}})
+	if err != nil {
+		t.Fatalf("AddNote failed: %v", err)
+	}
+	got, err := svc.Get(ctx, added.ID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if want := []string{"go", "area"}; !reflect.DeepEqual(got.Tags, want) {
+		t.Errorf("expected tags %v, got %v", want, got.Tags)
+	}
+
+	// 絞り込みのタグも同じルールで正規化する
+	search, err := svc.Search(ctx, &SearchRequest{ProjectID: "/test/project", Query: "x", Tags: []string{"GOLANG"}})
+	if err != nil || len(search.Results) != 1 {
+		t.Errorf("expected the note to match the aliased filter, got %+v, %v", search, err)
+	}
+	list, err := svc.ListRecent(ctx, &ListRecentRequest{ProjectID: "/test/project", Tags: []string{"Go"}})
+	if err != nil || len(list.Items) != 1 {
+		t.Errorf("expected the note in list_recent, got %+v, %v", list, err)
+	}
+
+	tags := []string{"Area", "Rule"}
+	if err := svc.Update(ctx, &UpdateRequest{ID: added.ID, Patch: NotePatch{Tags: &tags}}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if got, _ := svc.Get(ctx, added.ID); !reflect.DeepEqual(got.Tags, []string{"area", "rule"}) {
+		t.Errorf("expected normalized tags after update, got %v", got.Tags)
+	}
+}
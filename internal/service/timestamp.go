@@ -0,0 +1,38 @@
+package service
+
+import (
+	"fmt"
+	"time"
+)
+
+// NormalizeTimestamp はcreatedAtの文字列（RFC3339。オフセット・小数秒は任意）を検証し、
+// UTCの秒までのRFC3339（2024-01-15T10:00:00Z）にして返す
+// SQLiteは文字列順、Qdrantは導出したUnix時刻、メモリストアはパースした時刻で並べ・絞り込むため、
+// 保存前に形式を揃えてどのストアでも同じ順序・範囲になるようにする
+func NormalizeTimestamp(s string) (string, error) {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidTimeFormat, err)
+	}
+	return t.UTC().Format(time.RFC3339), nil
+}
+
+// legacyTimestampLayouts はNormalizeTimestampを通さずに保存された時刻として解釈する形式
+// オフセットのないものはUTCとみなす
+var legacyTimestampLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// parseLegacyTimestamp は保存済みの時刻をlegacyTimestampLayoutsのいずれかで解釈する
+func parseLegacyTimestamp(s string) (time.Time, bool) {
+	for _, layout := range legacyTimestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizeTimestamp(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"2024-01-15T10:00:00Z", "2024-01-15T10:00:00Z"},
+		{"2024-01-15T19:00:00+09:00", "2024-01-15T10:00:00Z"},
+		{"2024-01-15T10:00:00.789Z", "2024-01-15T10:00:00Z"},
+		{"2024-01-15T05:30:00-04:30", "2024-01-15T10:00:00Z"},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeTimestamp(tt.input)
+		if err != nil || got != tt.want {
+			t.Errorf("NormalizeTimestamp(%q) = %q, %v, want %q", tt.input, got, err, tt.want)
+		}
+	}
+
+	for _, input := range []string{"", "2024-01-15", "2024-01-15 10:00:00", "2024-01-15T10:00:00"} {
+		if _, err := NormalizeTimestamp(input); !errors.Is(err, ErrInvalidTimeFormat) {
+			t.Errorf("NormalizeTimestamp(%q): expected ErrInvalidTimeFormat, got %v", input, err)
+		}
+	}
+}
+
+// TestParseLegacyTimestamp はオフセットのない保存済みの時刻をUTCとみなすことをテスト
+func TestParseLegacyTimestamp(t *testing.T) {
+	for input, want := range map[string]string{
+		"2024-01-15T19:00:00+09:00": "2024-01-15T10:00:00Z",
+		"2024-01-15T10:00:00":       "2024-01-15T10:00:00Z",
+		"2024-01-15 10:00:00":       "2024-01-15T10:00:00Z",
+		"2024-01-15 10:00:00+00:00": "2024-01-15T10:00:00Z",
+		"2024-01-15":                "2024-01-15T00:00:00Z",
+	} {
+		got, ok := parseLegacyTimestamp(input)
+		if !ok || got.Format("2006-01-02T15:04:05Z07:00") != want {
+			t.Errorf("parseLegacyTimestamp(%q) = %v, %v, want %s", input, got, ok, want)
+		}
+	}
+	if _, ok := parseLegacyTimestamp("yesterday"); ok {
+		t.Error("expected an unparsable value to fail")
+	}
+}
@@ -1,6 +1,10 @@
 package service
 
-import "github.com/brbranch/embedding_mcp/internal/model"
+import (
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+)
 
 // AddNoteRequest はノート追加リクエスト
 type AddNoteRequest struct {
@@ -12,6 +16,18 @@ type AddNoteRequest struct {
 	Source    *string
 	CreatedAt *string // nullならサーバー側で設定
 	Metadata  map[string]any
+	// Attachments はノートが参照するファイル（パスまたは内容のハッシュ）
+	Attachments []model.Attachment
+	// Importance は重要度（0-1）。検索時にスコアへ加点する
+	Importance *float64
+	// OnDuplicate は同じproject/groupに類似ノートがある場合の扱い（OnDuplicate*、空なら検出しない）
+	// AddNoteのみで使い、AddNotesでは無視する
+	OnDuplicate string
+	// DuplicateThreshold は重複とみなす類似度（0-1）。nilならglobal.memory.duplicateThreshold
+	DuplicateThreshold *float64
+	// Model は埋め込みに使う追加embedderの名前（設定のembedders、空ならembedder）
+	// ノートはそのembedderのnamespaceに保存する。AddNotesでは全項目で同じ値にする
+	Model string
 }
 
 // AddNoteResponse はノート追加レスポンス
@@ -19,6 +35,23 @@ type AddNoteResponse struct {
 	ID                 string
 	Namespace          string
 	CanonicalProjectID string
+	// DuplicateID/DuplicateScore は見つかった類似ノートとその類似度（なければ空）
+	DuplicateID    string
+	DuplicateScore float64
+	// Merged は追加せず類似ノートにタグをマージしたか（IDは既存ノートのID）
+	Merged bool
+}
+
+// AddNotesRequest はノート一括追加リクエスト
+type AddNotesRequest struct {
+	Notes []AddNoteRequest
+}
+
+// AddNotesResponse はノート一括追加レスポンス（Resultsはリクエスト順）
+type AddNotesResponse struct {
+	Namespace string
+	Results   []AddNoteResponse // 追加したノート
+	Items     []ItemResult      // リクエストの全項目の結果（失敗時に再送する項目を判断する）
 }
 
 // SearchRequest は検索リクエスト
@@ -30,12 +63,42 @@ type SearchRequest struct {
 	Tags      []string // AND検索
 	Since     *string  // UTC ISO8601
 	Until     *string  // UTC ISO8601
+	MinScore  *float64 // 0-1、これ未満のスコアの結果を除外
+	// ProjectIDs はProjectIDに加えて検索するプロジェクト（複数プロジェクトを横断して検索する）
+	ProjectIDs []string
+	// CollapseByParent はチャンク分割された文書を親ごとに最もスコアの高い1件にまとめる
+	CollapseByParent bool
+	// Language は追加時に検出した言語（metadata.language）での絞り込み
+	Language *string
+	// IncludeDescendants はGroupIDの子孫グループ（parentGroupIdでつながるグループ）のノートも含める
+	IncludeDescendants bool
+	// GroupBy がSearchGroupByGroupなら、groupIdごとにGroupTopK件までの結果を集めて返す
+	GroupBy string
+	// GroupTopK はGroupBy指定時のgroupIdごとの件数の上限（default DefaultGroupTopK）
+	GroupTopK *int
+	// IncludeArchived はアーカイブ（コールドストア）に移したノートも検索する
+	IncludeArchived bool
 }
 
+// SearchGroupByGroup はgroupIdごとに結果をまとめるGroupBy
+const SearchGroupByGroup = "group"
+
+// DefaultGroupTopK はGroupBy指定時のgroupIdごとの件数の上限のデフォルト
+const DefaultGroupTopK = 2
+
 // SearchResponse は検索レスポンス
 type SearchResponse struct {
 	Namespace string
 	Results   []SearchResult
+	// Groups はGroupBy指定時の、結果に含まれるgroupIdの一覧（Resultsと同じ順）
+	Groups []SearchGroup
+}
+
+// SearchGroup はGroupBy指定時の1つのgroupIdの結果の概要
+type SearchGroup struct {
+	GroupID  string
+	Count    int     // Resultsに含まれるこのgroupIdの件数
+	TopScore float64 // このgroupIdの最も高いスコア
 }
 
 // SearchResult は検索結果の1件
@@ -48,8 +111,18 @@ type SearchResult struct {
 	Tags      []string
 	Source    *string
 	CreatedAt string
+	UpdatedAt string
 	Score     float64 // 0-1正規化
 	Metadata  map[string]any
+	// Attachments はノートが参照するファイル
+	Attachments []model.Attachment
+	// ParentID/ChunkIndex はチャンク分割された文書の親ノートIDとチャンク番号
+	ParentID   *string
+	ChunkIndex *int
+	// Importance は重要度（0-1）
+	Importance *float64
+	// Archived はアーカイブのノート（Restoreで戻すまで取得・更新できない）
+	Archived bool
 }
 
 // GetResponse はノート取得レスポンス
@@ -62,14 +135,23 @@ type GetResponse struct {
 	Tags      []string
 	Source    *string
 	CreatedAt string
+	UpdatedAt string
 	Namespace string
 	Metadata  map[string]any
+	// Attachments はノートが参照するファイル
+	Attachments []model.Attachment
+	// ParentID/ChunkIndex はチャンク分割された文書の親ノートIDとチャンク番号
+	ParentID   *string
+	ChunkIndex *int
+	// Importance は重要度（0-1）
+	Importance *float64
 }
 
 // UpdateRequest はノート更新リクエスト
 type UpdateRequest struct {
-	ID    string
-	Patch NotePatch
+	ID          string
+	Patch       NotePatch
+	IfUpdatedAt *string // 指定時は現在のupdatedAtと一致する場合のみ更新する（楽観的ロック）
 }
 
 // NotePatch はノート更新パッチ
@@ -80,6 +162,10 @@ type NotePatch struct {
 	Source   *string
 	GroupID  *string // 再埋め込み不要
 	Metadata *map[string]any
+	// Attachments は指定時に全体を置き換える（空配列でクリア）
+	Attachments *[]model.Attachment
+	// Importance は指定時に置き換える（0で加点なし）
+	Importance *float64
 }
 
 // ListRecentRequest は最近のノート取得リクエスト
@@ -88,12 +174,23 @@ type ListRecentRequest struct {
 	GroupID   *string
 	Limit     *int // default 10
 	Tags      []string
+	SortBy    string // createdAt（デフォルト）またはupdatedAt。いずれも降順
+	// IncludeDescendants はGroupIDの子孫グループのノートも含める
+	IncludeDescendants bool
+	// Cursor は前のページのNextCursor（空なら先頭から）
+	Cursor string
 }
 
 // ListRecentResponse は最近のノート取得レスポンス
 type ListRecentResponse struct {
 	Namespace string
 	Items     []ListRecentItem
+	// Total は条件に一致するノートの件数（Limit・Cursorによらない）
+	Total int
+	// TotalApproximate はTotalが概数であることを示す（移行中の2つのnamespaceを合わせて数えた場合）
+	TotalApproximate bool
+	// NextCursor は次のページを取得するCursor（続きがなければ空）
+	NextCursor string
 }
 
 // ListRecentItem は最近のノートの1件
@@ -106,8 +203,28 @@ type ListRecentItem struct {
 	Tags      []string
 	Source    *string
 	CreatedAt string
+	UpdatedAt string
 	Namespace string
 	Metadata  map[string]any
+	// Attachments はノートが参照するファイル
+	Attachments []model.Attachment
+	// ParentID/ChunkIndex はチャンク分割された文書の親ノートIDとチャンク番号
+	ParentID   *string
+	ChunkIndex *int
+	// Importance は重要度（0-1）
+	Importance *float64
+}
+
+// ListProjectsResponse はプロジェクト一覧レスポンス
+type ListProjectsResponse struct {
+	Namespace string
+	Projects  []ProjectItem
+}
+
+// ProjectItem はプロジェクト一覧の1件
+type ProjectItem struct {
+	ProjectID string
+	NoteCount int
 }
 
 // GetConfigResponse は設定取得レスポンス
@@ -116,6 +233,7 @@ type GetConfigResponse struct {
 	Embedder          model.EmbedderConfig
 	Store             model.StoreConfig
 	Paths             model.PathsConfig
+	PreviousEmbedder  *model.EmbedderConfig // 移行期間中のみ
 }
 
 // SetConfigRequest は設定変更リクエスト
@@ -135,14 +253,171 @@ type EmbedderPatch struct {
 type SetConfigResponse struct {
 	OK                 bool
 	EffectiveNamespace string
+	PreviousNamespace  string // 変更前のnamespace
+	ReembedRequired    bool   // provider/modelが変わり、既存ノートの再埋め込みが必要
+}
+
+// AddProjectAliasRequest はプロジェクトエイリアス登録リクエスト
+type AddProjectAliasRequest struct {
+	Path      string // エイリアスにするパス（正規化して登録）
+	ProjectID string // 対応先の論理的なprojectId（変換しない）
+}
+
+// AddProjectAliasResponse はプロジェクトエイリアス登録レスポンス
+type AddProjectAliasResponse struct {
+	Path      string // 正規化済みパス
+	ProjectID string
+}
+
+// MigrateRequest は別namespaceからの移行リクエスト
+type MigrateRequest struct {
+	From      string // 移行元のnamespace（provider:model:dim）
+	ProjectID string // 空なら全プロジェクト
+}
+
+// MigrateResponse は移行レスポンス
+type MigrateResponse struct {
+	From       string
+	Namespace  string // 移行先（現在のnamespace）
+	Projects   int
+	Created    int // 取り込んだノート・グループ・グローバル設定の数
+	Skipped    int // 移行先に既にあったもの
+	ReEmbedded int // 再埋め込みしたノート数
+}
+
+// MergeProjectsRequest はプロジェクト統合リクエスト
+type MergeProjectsRequest struct {
+	DryRun bool // 統合先の算出のみで変更しない
+}
+
+// MergeProjectsResponse はプロジェクト統合レスポンス
+type MergeProjectsResponse struct {
+	Merges  []ProjectMerge
+	Skipped int // 統合先に同じgroupKey・keyがあり移さなかったグループ・グローバル設定の数
+}
+
+// ProjectMerge は1つのprojectIdの統合結果
+type ProjectMerge struct {
+	From    string // 保存されているprojectId
+	To      string // 現在のルールで正規化したprojectId
+	Notes   int
+	Groups  int
+	Globals int
+}
+
+// ArchiveRequest は最終アクセスの古いノートのアーカイブリクエスト
+type ArchiveRequest struct {
+	ProjectID     string // 空なら全プロジェクト
+	OlderThanDays int    // 最終アクセス（検索・取得・更新）がこの日数より前のノートを移す（0なら設定のarchive.afterDays）
+	DryRun        bool   // 移すノートの算出のみで変更しない
 }
 
+// ArchiveResponse はアーカイブのレスポンス
+type ArchiveResponse struct {
+	Namespace string
+	Notes     []ArchivedNote // 移した（DryRunなら移す）ノート
+}
+
+// ArchivedNote はアーカイブの1件のノート
+type ArchivedNote struct {
+	ID         string
+	ProjectID  string
+	GroupID    string
+	Title      *string
+	CreatedAt  string
+	ArchivedAt string // DryRunでは空
+}
+
+// RestoreRequest はアーカイブからノートを戻すリクエスト
+type RestoreRequest struct {
+	IDs []string
+}
+
+// RestoreResponse はアーカイブからノートを戻したレスポンス
+type RestoreResponse struct {
+	Restored []string // 戻したノートのID
+	NotFound []string // アーカイブになかったID
+}
+
+// ListArchivedRequest はアーカイブの一覧リクエスト
+type ListArchivedRequest struct {
+	ProjectID string // 空なら全プロジェクト
+}
+
+// ListArchivedResponse はアーカイブの一覧レスポンス（createdAt昇順）
+type ListArchivedResponse struct {
+	Namespace string
+	Notes     []ArchivedNote
+}
+
+// RepairTimestampsRequest はノートのcreatedAtの修復リクエスト
+type RepairTimestampsRequest struct {
+	ProjectID string // 空なら全プロジェクト
+	DryRun    bool   // 修復するノートの算出のみで変更しない
+}
+
+// RepairTimestampsResponse はノートのcreatedAtの修復レスポンス
+type RepairTimestampsResponse struct {
+	Scanned int               // 確認したノート数
+	Repairs []TimestampRepair // 書き換えた（DryRunなら書き換える）ノート
+	Invalid []TimestampRepair // 時刻として解釈できず、そのままにしたノート（Toは空）
+}
+
+// TimestampRepair は1件のノートのcreatedAtの修復
+type TimestampRepair struct {
+	ID        string
+	ProjectID string
+	From      string // 保存されているcreatedAt
+	To        string // 正規化したcreatedAt
+}
+
+// ValidateRequest はインデックスの整合性の検証リクエスト
+type ValidateRequest struct {
+	ProjectID string // 空なら全プロジェクト
+	Repair    bool   // 見つかった問題を修復する（修復できないノートは隔離する）
+}
+
+// ValidateResponse はインデックスの整合性の検証レスポンス
+type ValidateResponse struct {
+	Namespace string
+	Scanned   int          // 確認したノート数
+	Issues    []IndexIssue // 見つかった問題（Repairなら修復したかを含む）
+}
+
+// IndexIssue はインデックスの不整合1件
+type IndexIssue struct {
+	Kind      string // IndexIssueInvalidNoteなど
+	ProjectID string
+	ID        string // ノートまたはグループのID（namespace全体の問題なら空）
+	Message   string
+	Repaired  bool   // 修復した
+	Action    string // 行った修復（IndexRepairReembedなど）
+}
+
+// IndexIssue.Kind
+const (
+	IndexIssueInvalidNote       = "invalid_note"       // payloadが壊れている（id・projectId・本文がない、groupIdの形式が不正、復号できない）
+	IndexIssueMissingEmbedding  = "missing_embedding"  // 埋め込みがない
+	IndexIssueDimensionMismatch = "dimension_mismatch" // 埋め込みの次元数がnamespaceと異なる
+	IndexIssueDanglingParent    = "dangling_parent"    // グループのparentGroupIdが削除されたグループを指している
+	IndexIssueNamespaceMismatch = "namespace_mismatch" // embedderの次元数がnamespaceと異なる（設定の問題のため修復しない）
+	IndexIssueUnreadableProject = "unreadable_project" // プロジェクトのノート・グループを読み出せない（修復しない）
+)
+
+// IndexIssue.Action
+const (
+	IndexRepairReembed     = "reembedded"     // 本文から埋め込みを作り直した
+	IndexRepairQuarantine  = "quarantined"    // ストアから隔離用のコールドストア（dataDir/quarantine）に移した
+	IndexRepairClearParent = "cleared_parent" // parentGroupIdを外してトップレベルにした
+)
+
 // UpsertGlobalRequest はグローバル設定upsertリクエスト
 type UpsertGlobalRequest struct {
 	ProjectID string
 	Key       string // "global." プレフィックス必須
 	Value     any
 	UpdatedAt *string
+	UpdatedBy string // 更新者（任意）。履歴に残る
 }
 
 // UpsertGlobalResponse はグローバル設定upsertレスポンス
@@ -159,6 +434,46 @@ type GetGlobalResponse struct {
 	ID        *string
 	Value     any
 	UpdatedAt *string
+	UpdatedBy string
+}
+
+// GetGlobalHistoryResponse はグローバル設定の履歴取得レスポンス
+type GetGlobalHistoryResponse struct {
+	Namespace string
+	Found     bool
+	// History は上書きされる前の値（新しい順、最大MaxGlobalHistory件）。現在の値は含まない
+	History []model.GlobalConfigVersion
+}
+
+// GlobalEntry はエクスポート・インポートするグローバル設定1件
+type GlobalEntry struct {
+	Key       string
+	Value     any
+	UpdatedAt *string // インポート時は無視（インポートした時刻になる）
+	UpdatedBy string
+}
+
+// ExportGlobalsResponse はプロジェクトのグローバル設定のエクスポートレスポンス
+type ExportGlobalsResponse struct {
+	Namespace string
+	ProjectID string
+	Globals   []GlobalEntry
+}
+
+// ImportGlobalsRequest はグローバル設定のインポートリクエスト
+type ImportGlobalsRequest struct {
+	ProjectID string // インポート先
+	Globals   []GlobalEntry
+	Mode      ImportMode // 既にあるkeyの扱い（ImportModeFailなら1件も書き込まずにエラー）
+}
+
+// ImportGlobalsResponse はグローバル設定のインポートレスポンス
+type ImportGlobalsResponse struct {
+	Namespace string
+	ProjectID string
+	Created   int
+	Updated   int // 上書きした件数（前の値は履歴に残る）
+	Skipped   int
 }
 
 // CreateGroupRequest はグループ作成リクエスト
@@ -167,6 +482,12 @@ type CreateGroupRequest struct {
 	GroupKey    string
 	Title       string
 	Description string
+	// Tags/Status/Metadata はスプリント・担当者・チケット等の注釈（Statusの省略はactive）
+	Tags     []string
+	Status   string
+	Metadata map[string]any
+	// ParentGroupID は親グループのgroupKey（同一プロジェクト内。空ならトップレベル）
+	ParentGroupID string
 }
 
 // CreateGroupResponse はグループ作成レスポンス
@@ -177,14 +498,18 @@ type CreateGroupResponse struct {
 
 // GetGroupResponse はグループ取得レスポンス
 type GetGroupResponse struct {
-	ID          string
-	ProjectID   string
-	GroupKey    string
-	Title       string
-	Description string
-	CreatedAt   string
-	UpdatedAt   string
-	Namespace   string
+	ID            string
+	ProjectID     string
+	GroupKey      string
+	Title         string
+	Description   string
+	CreatedAt     string
+	UpdatedAt     string
+	Namespace     string
+	Tags          []string
+	Status        string // 旧データもactiveとして返す
+	Metadata      map[string]any
+	ParentGroupID string
 }
 
 // UpdateGroupRequest はグループ更新リクエスト
@@ -193,10 +518,46 @@ type UpdateGroupRequest struct {
 	Patch GroupPatch
 }
 
+// グループ削除時のノートの扱い（DeleteGroupRequest.Cascade）
+const (
+	GroupCascadeNone     = ""         // グループのみ削除し、ノートはそのgroupIdのまま残す
+	GroupCascadeDelete   = "delete"   // グループのノートも削除する
+	GroupCascadeMove     = "move"     // グループのノートをglobalへ移す
+	GroupCascadeRestrict = "restrict" // グループにノートがあれば削除しない
+)
+
+// DeleteGroupRequest はグループ削除リクエスト
+type DeleteGroupRequest struct {
+	ID      string
+	Cascade string
+}
+
+// DeleteGroupResponse はグループ削除レスポンス
+type DeleteGroupResponse struct {
+	Notes      int    // cascadeで削除・移動したノート数
+	RolledBack bool   // 失敗したためトランザクションを取り消した（ノートも元のまま）
+	Failed     string // 失敗した手順（"notes"または"group"）。RolledBackでなければ、"group"の失敗ではNotes件数のノートが削除・移動済み
+}
+
+// RenameGroupRequest はグループのgroupKey変更リクエスト
+type RenameGroupRequest struct {
+	ID       string
+	GroupKey string // 新しいgroupKey
+}
+
+// RenameGroupResponse はグループのgroupKey変更レスポンス
+type RenameGroupResponse struct {
+	Notes int // groupIdを書き換えたノート数（失敗した場合も、書き換え済みのノート数）
+}
+
 // GroupPatch はグループ更新パッチ
 type GroupPatch struct {
-	Title       *string // nilは変更なし
-	Description *string // nilは変更なし
+	Title         *string // nilは変更なし
+	Description   *string // nilは変更なし
+	Tags          *[]string
+	Status        *string
+	Metadata      *map[string]any // 全体を置き換える（空mapでクリア）
+	ParentGroupID *string         // 空文字でトップレベルに戻す
 }
 
 // ListGroupsResponse はグループ一覧レスポンス
@@ -207,11 +568,149 @@ type ListGroupsResponse struct {
 
 // ListGroupItem はグループ一覧の1件
 type ListGroupItem struct {
-	ID          string
-	ProjectID   string
-	GroupKey    string
-	Title       string
-	Description string
-	CreatedAt   string
-	UpdatedAt   string
+	ID            string
+	ProjectID     string
+	GroupKey      string
+	Title         string
+	Description   string
+	CreatedAt     string
+	UpdatedAt     string
+	Tags          []string
+	Status        string
+	Metadata      map[string]any
+	ParentGroupID string
+	// NoteCount/LastNoteAt/TextLength はグループのノートの統計（LastNoteAtはノートがなければ空）
+	NoteCount  int
+	LastNoteAt string
+	TextLength int
+}
+
+// ExportFormatVersion はエクスポートファイルの形式バージョン
+const ExportFormatVersion = 1
+
+// エクスポートレコード種別
+const (
+	ExportRecordHeader = "header"
+	ExportRecordGroup  = "group"
+	ExportRecordGlobal = "global"
+	ExportRecordNote   = "note"
+)
+
+// ExportRecord はエクスポートファイル（JSONL）の1行
+// 先頭にheader、続いてgroup → global → noteの順に出力する
+type ExportRecord struct {
+	Type       string              `json:"type"`                 // "header" | "group" | "global" | "note"
+	Version    int                 `json:"version,omitempty"`    // header のみ
+	Namespace  string              `json:"namespace,omitempty"`  // header のみ（埋め込みの互換性判定に使用）
+	ProjectID  string              `json:"projectId,omitempty"`  // header のみ（エクスポート元）
+	ExportedAt string              `json:"exportedAt,omitempty"` // header のみ
+	Group      *model.Group        `json:"group,omitempty"`
+	Global     *model.GlobalConfig `json:"global,omitempty"`
+	Note       *model.Note         `json:"note,omitempty"`
+	Embedding  []float32           `json:"embedding,omitempty"` // note のみ（IncludeEmbeddings指定時）
+}
+
+// ExportRequest はエクスポートリクエスト
+type ExportRequest struct {
+	ProjectID         string
+	IncludeEmbeddings bool
+}
+
+// ExportResponse はエクスポートレスポンス
+type ExportResponse struct {
+	Namespace string
+	ProjectID string // 正規化済み
+	Notes     int
+	Globals   int
+	Groups    int
+}
+
+// ImportMode は既存データと衝突した場合の動作
+type ImportMode string
+
+// ImportMode定数
+const (
+	ImportModeFail         ImportMode = ""              // 衝突があれば何も書き込まずにエラー
+	ImportModeSkipExisting ImportMode = "skip-existing" // 既存データを残す
+	ImportModeOverwrite    ImportMode = "overwrite"     // 既存データを上書き
+)
+
+// ImportRequest はインポートリクエスト
+type ImportRequest struct {
+	ProjectID string // インポート先（レコードのprojectIdは無視してこの値に揃える）
+	Mode      ImportMode
+}
+
+// ImportResponse はインポートレスポンス
+type ImportResponse struct {
+	Namespace  string
+	ProjectID  string // 正規化済み
+	Created    int
+	Updated    int
+	Skipped    int
+	ReEmbedded int // 埋め込みを再生成したノート数
+
+	// 以下は取り込みに失敗した場合のみ設定する
+	RolledBack bool   // トランザクションを取り消したため、何も取り込んでいない（件数はすべて0）
+	Failed     string // 失敗したレコード（"note <id>"など）。RolledBackでなければ、それより前のレコードは取り込み済み
+
+	Items []ItemResult // 入力の全レコードの結果（skip-existingでスキップしたレコードもok）
+}
+
+// SyncChunk は同期するノート1件分（チャンク番号はChunks内の位置）
+type SyncChunk struct {
+	Title    *string
+	Text     string
+	Tags     []string
+	Metadata map[string]any
+	// CreatedAt は新規作成時のcreatedAt（RFC3339、nilなら現在時刻）。既存ノートのcreatedAtは変えない
+	CreatedAt *string
+}
+
+// SyncRequest はファイル同期リクエスト
+// Chunksが空の場合はsourceのノートをすべて削除する
+type SyncRequest struct {
+	ProjectID string
+	GroupID   string
+	Source    string // ファイルの相対パス
+	Chunks    []SyncChunk
+}
+
+// SyncResponse はファイル同期レスポンス
+type SyncResponse struct {
+	Namespace string
+	ProjectID string // 正規化済み
+	Created   int
+	Updated   int
+	Unchanged int
+	Deleted   int
+}
+
+// SelfTestResponse は自己診断の結果（各手順の所要時間）
+type SelfTestResponse struct {
+	Namespace string
+	Dimension int // 埋め込みの次元数
+	Embed     time.Duration
+	Add       time.Duration
+	Search    time.Duration
+	Delete    time.Duration
+}
+
+// StatsResponse はnamespaceのノート数の統計
+type StatsResponse struct {
+	Namespace      string
+	Notes          int
+	Projects       []ProjectStats // ノート数の多い順
+	Soft           int
+	Hard           int       // 0なら無効
+	Level          string    // NoteLevelOK / NoteLevelWarning / NoteLevelCritical
+	Recommendation string    // Levelがok以外の場合の推奨
+	GrowthSince    time.Time // 増加数を比べた過去の計測の時刻（ゼロなら初回の計測）
+	Growth         int       // GrowthSinceからの増加数（減った場合は負）
+}
+
+// ProjectStats はプロジェクト1件分のノート数
+type ProjectStats struct {
+	ProjectID string
+	Notes     int
 }
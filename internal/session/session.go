@@ -0,0 +1,161 @@
+// Package session provides per-connection client identity and defaults for mcp-memory.
+package session
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Info はセッションのクライアント情報とデフォルト値
+type Info struct {
+	ClientName       string // クライアント名（MCP clientInfo.name または HTTPヘッダー）
+	ClientVersion    string // クライアントバージョン
+	DefaultProjectID string // projectId省略時に使用するデフォルト値
+	DefaultGroupID   string // groupId省略時に使用するデフォルト値（add_noteのみ）
+}
+
+// ClientID はログ等でクライアントを識別する文字列を返す（"name/version" 形式）
+func (i Info) ClientID() string {
+	if i.ClientName == "" || i.ClientVersion == "" {
+		return i.ClientName
+	}
+	return i.ClientName + "/" + i.ClientVersion
+}
+
+// Session は1接続（stdio/pipe）または1リクエスト（HTTP）単位のセッション
+// 並行に処理されるリクエストから参照されるため、アクセスはロックで保護する
+type Session struct {
+	mu   sync.RWMutex
+	id   string
+	info Info
+
+	// notify はクライアントへ通知を送る関数（接続を持つstdio/pipeのみ設定される）
+	notify func(message []byte)
+	// subscriptions は購読ごとの解除関数（Closeですべて解除する）
+	subscriptions map[string]func()
+}
+
+// New は新しいSessionを生成
+func New(info Info) *Session {
+	return &Session{id: uuid.NewString(), info: info}
+}
+
+// ID はセッションを識別するランダムなIDを返す（監査ログ等で同じクライアントの別の接続を区別する）
+func (s *Session) ID() string {
+	return s.id
+}
+
+// Info は現在のセッション情報を返す
+func (s *Session) Info() Info {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.info
+}
+
+// Update は空でないフィールドのみセッション情報を上書きする
+func (s *Session) Update(info Info) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if info.ClientName != "" {
+		s.info.ClientName = info.ClientName
+	}
+	if info.ClientVersion != "" {
+		s.info.ClientVersion = info.ClientVersion
+	}
+	if info.DefaultProjectID != "" {
+		s.info.DefaultProjectID = info.DefaultProjectID
+	}
+	if info.DefaultGroupID != "" {
+		s.info.DefaultGroupID = info.DefaultGroupID
+	}
+}
+
+// SetNotifier はクライアントへ通知を送る関数を設定する（トランスポートが接続ごとに設定）
+func (s *Session) SetNotifier(fn func(message []byte)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.notify = fn
+}
+
+// CanNotify は通知を送れるか（SetNotifier済みか）を返す
+func (s *Session) CanNotify() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.notify != nil
+}
+
+// Notify はクライアントへ通知を送る（通知を送れない場合は何もしない）
+func (s *Session) Notify(message []byte) {
+	s.mu.RLock()
+	notify := s.notify
+	s.mu.RUnlock()
+	if notify != nil {
+		notify(message)
+	}
+}
+
+// AddSubscription は購読を登録する。同じkeyの購読があれば解除してから置き換える
+func (s *Session) AddSubscription(key string, cancel func()) {
+	s.mu.Lock()
+	prev := s.subscriptions[key]
+	if s.subscriptions == nil {
+		s.subscriptions = make(map[string]func())
+	}
+	s.subscriptions[key] = cancel
+	s.mu.Unlock()
+
+	if prev != nil {
+		prev()
+	}
+}
+
+// RemoveSubscription は購読を解除し、購読していたかを返す
+func (s *Session) RemoveSubscription(key string) bool {
+	s.mu.Lock()
+	cancel, ok := s.subscriptions[key]
+	delete(s.subscriptions, key)
+	s.mu.Unlock()
+
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// Close はすべての購読を解除し、以降の通知を止める（接続の終了時に呼ぶ）
+func (s *Session) Close() {
+	s.mu.Lock()
+	subscriptions := s.subscriptions
+	s.subscriptions = nil
+	s.notify = nil
+	s.mu.Unlock()
+
+	for _, cancel := range subscriptions {
+		cancel()
+	}
+}
+
+// contextKey はcontextに格納する際のキー
+type contextKey struct{}
+
+// NewContext はSessionを格納したcontextを返す
+func NewContext(ctx context.Context, s *Session) context.Context {
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// FromContext はcontextからSessionを取得する（未設定ならnil）
+func FromContext(ctx context.Context) *Session {
+	s, _ := ctx.Value(contextKey{}).(*Session)
+	return s
+}
+
+// InfoFromContext はcontextのセッション情報を返す（未設定ならゼロ値）
+func InfoFromContext(ctx context.Context) Info {
+	if s := FromContext(ctx); s != nil {
+		return s.Info()
+	}
+	return Info{}
+}
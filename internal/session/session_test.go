@@ -0,0 +1,99 @@
+package session
+
+import (
+	"context"
+	"testing"
+)
+
+// TestSession_Update は空でないフィールドのみ上書きされることをテスト
+func TestSession_Update(t *testing.T) {
+	s := New(Info{ClientName: "cli", DefaultProjectID: "/a"})
+	s.Update(Info{DefaultGroupID: "feature-x"})
+
+	info := s.Info()
+	if info.ClientName != "cli" {
+		t.Errorf("expected ClientName cli, got %q", info.ClientName)
+	}
+	if info.DefaultProjectID != "/a" {
+		t.Errorf("expected DefaultProjectID /a, got %q", info.DefaultProjectID)
+	}
+	if info.DefaultGroupID != "feature-x" {
+		t.Errorf("expected DefaultGroupID feature-x, got %q", info.DefaultGroupID)
+	}
+}
+
+// TestSession_ID はセッションごとに異なるIDが振られることをテスト
+func TestSession_ID(t *testing.T) {
+	a, b := New(Info{}), New(Info{})
+	if a.ID() == "" || a.ID() == b.ID() {
+		t.Errorf("expected distinct session IDs, got %q and %q", a.ID(), b.ID())
+	}
+}
+
+// TestInfo_ClientID はクライアント識別子の生成をテスト
+func TestInfo_ClientID(t *testing.T) {
+	tests := []struct {
+		info Info
+		want string
+	}{
+		{Info{}, ""},
+		{Info{ClientName: "claude-code"}, "claude-code"},
+		{Info{ClientName: "claude-code", ClientVersion: "1.2.0"}, "claude-code/1.2.0"},
+	}
+	for _, tt := range tests {
+		if got := tt.info.ClientID(); got != tt.want {
+			t.Errorf("ClientID() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+// TestContext はcontextへの格納と取得をテスト
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	if FromContext(ctx) != nil {
+		t.Error("expected nil session for empty context")
+	}
+	if InfoFromContext(ctx) != (Info{}) {
+		t.Error("expected zero Info for empty context")
+	}
+
+	s := New(Info{ClientName: "cli"})
+	ctx = NewContext(ctx, s)
+	if FromContext(ctx) != s {
+		t.Error("expected stored session")
+	}
+	if InfoFromContext(ctx).ClientName != "cli" {
+		t.Errorf("expected ClientName cli, got %q", InfoFromContext(ctx).ClientName)
+	}
+}
+
+// TestSession_Subscriptions は購読の置き換え・解除とCloseをテスト
+func TestSession_Subscriptions(t *testing.T) {
+	s := New(Info{})
+	if s.CanNotify() {
+		t.Error("expected no notifier by default")
+	}
+	var sent []string
+	s.SetNotifier(func(message []byte) { sent = append(sent, string(message)) })
+	s.Notify([]byte("a"))
+
+	cancelled := map[string]int{}
+	s.AddSubscription("/a", func() { cancelled["/a"]++ })
+	s.AddSubscription("/a", func() { cancelled["/a2"]++ })
+	s.AddSubscription("/b", func() { cancelled["/b"]++ })
+	if cancelled["/a"] != 1 {
+		t.Errorf("expected the replaced subscription to be cancelled, got %v", cancelled)
+	}
+	if !s.RemoveSubscription("/b") || s.RemoveSubscription("/b") {
+		t.Error("expected RemoveSubscription to report only the first removal")
+	}
+
+	s.Close()
+	s.Notify([]byte("b"))
+	if cancelled["/a2"] != 1 || cancelled["/b"] != 1 {
+		t.Errorf("expected all subscriptions to be cancelled once, got %v", cancelled)
+	}
+	if len(sent) != 1 || sent[0] != "a" {
+		t.Errorf("expected no notification after Close, got %v", sent)
+	}
+}
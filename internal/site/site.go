@@ -0,0 +1,437 @@
+// Package site renders a project's groups and notes into a static HTML or Markdown tree,
+// so that the memory can be browsed by people who don't use an MCP client.
+package site
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+)
+
+// 出力形式
+const (
+	FormatHTML     = "html"
+	FormatMarkdown = "markdown"
+)
+
+// GroupsDir はグループごとのページを置くディレクトリ
+const GroupsDir = "groups"
+
+// Site はサイトにするプロジェクトの内容
+type Site struct {
+	ProjectID   string
+	GeneratedAt string // RFC3339（ページに表示する）
+	Groups      []*model.Group
+	Globals     []*model.GlobalConfig
+	Notes       []*model.Note
+}
+
+// Result は書き出した結果
+type Result struct {
+	Files   int // 書き出したファイル数（index含む）
+	Groups  int
+	Notes   int
+	Removed int // 前回の出力から消えたグループのページ
+}
+
+// page はグループ1つのページ
+type page struct {
+	Key         string
+	Title       string
+	Description string
+	Status      string
+	Tags        []string
+	Parent      string
+	Notes       []*model.Note
+}
+
+// tagEntry はタグの一覧の1行（タグとそのノートへのリンク）
+type tagEntry struct {
+	Tag   string
+	Notes []tagNote
+}
+
+type tagNote struct {
+	GroupKey string
+	ID       string
+	Title    string
+}
+
+// Write はsiteをdirにformatで書き出す（index と groups/<groupKey>）
+// グループはgroupKey順（globalを先頭）、ノートはcreatedAtの古い順に並べる
+// 以前の出力のうち、今回書き出さなかったグループのページは削除する
+func Write(dir, format string, s *Site) (*Result, error) {
+	var ext string
+	switch format {
+	case FormatHTML:
+		ext = ".html"
+	case FormatMarkdown:
+		ext = ".md"
+	default:
+		return nil, fmt.Errorf("unknown site format: %s (must be %s or %s)", format, FormatHTML, FormatMarkdown)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, GroupsDir), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	pages := buildPages(s)
+	tags := buildTags(pages)
+	result := &Result{Groups: len(pages), Notes: len(s.Notes)}
+	written := make(map[string]bool, len(pages))
+	for _, p := range pages {
+		var content []byte
+		var err error
+		if format == FormatHTML {
+			content, err = renderGroupHTML(s, p)
+		} else {
+			content = renderGroupMarkdown(s, p)
+		}
+		if err != nil {
+			return nil, err
+		}
+		name := p.Key + ext
+		if err := writeFile(filepath.Join(dir, GroupsDir, name), content); err != nil {
+			return nil, err
+		}
+		written[name] = true
+		result.Files++
+	}
+
+	var index []byte
+	var err error
+	if format == FormatHTML {
+		index, err = renderIndexHTML(s, pages, tags)
+	} else {
+		index = renderIndexMarkdown(s, pages, tags)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFile(filepath.Join(dir, "index"+ext), index); err != nil {
+		return nil, err
+	}
+	result.Files++
+
+	// 削除・リネームされたグループのページを消す（groups/ 内の同じ拡張子のファイルのみ）
+	entries, err := os.ReadDir(filepath.Join(dir, GroupsDir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filepath.Join(dir, GroupsDir), err)
+	}
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ext || written[e.Name()] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, GroupsDir, e.Name())); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove %s: %w", e.Name(), err)
+		}
+		result.Removed++
+	}
+	return result, nil
+}
+
+func writeFile(p string, content []byte) error {
+	if err := os.WriteFile(p, content, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", p, err)
+	}
+	return nil
+}
+
+// buildPages はノートをグループごとにまとめる
+// グループのレコードがないgroupId（globalなど）もページにし、ノートのないグループもページにする
+func buildPages(s *Site) []*page {
+	byKey := make(map[string]*page)
+	for _, g := range s.Groups {
+		byKey[g.GroupKey] = &page{
+			Key:         g.GroupKey,
+			Title:       g.Title,
+			Description: g.Description,
+			Status:      g.Status,
+			Tags:        g.Tags,
+			Parent:      g.ParentGroupID,
+		}
+	}
+	for _, n := range s.Notes {
+		p, ok := byKey[n.GroupID]
+		if !ok {
+			p = &page{Key: n.GroupID, Title: n.GroupID}
+			byKey[n.GroupID] = p
+		}
+		p.Notes = append(p.Notes, n)
+	}
+
+	pages := make([]*page, 0, len(byKey))
+	for _, p := range byKey {
+		sort.SliceStable(p.Notes, func(i, j int) bool { return lessNote(p.Notes[i], p.Notes[j]) })
+		pages = append(pages, p)
+	}
+	sort.Slice(pages, func(i, j int) bool {
+		if (pages[i].Key == model.GlobalGroupID) != (pages[j].Key == model.GlobalGroupID) {
+			return pages[i].Key == model.GlobalGroupID
+		}
+		return pages[i].Key < pages[j].Key
+	})
+	return pages
+}
+
+// lessNote はcreatedAtの古い順（createdAtのないノートは最後、同じならチャンク順・ID順）
+func lessNote(a, b *model.Note) bool {
+	ca, cb := deref(a.CreatedAt), deref(b.CreatedAt)
+	if ca != cb {
+		if ca == "" || cb == "" {
+			return cb == ""
+		}
+		return ca < cb
+	}
+	ia, ib := -1, -1
+	if a.ChunkIndex != nil {
+		ia = *a.ChunkIndex
+	}
+	if b.ChunkIndex != nil {
+		ib = *b.ChunkIndex
+	}
+	if ia != ib {
+		return ia < ib
+	}
+	return a.ID < b.ID
+}
+
+// buildTags はタグごとのノートの一覧を作る（タグ名順）
+func buildTags(pages []*page) []tagEntry {
+	byTag := make(map[string][]tagNote)
+	for _, p := range pages {
+		for _, n := range p.Notes {
+			for _, tag := range n.Tags {
+				byTag[tag] = append(byTag[tag], tagNote{GroupKey: p.Key, ID: n.ID, Title: noteTitle(n)})
+			}
+		}
+	}
+	tags := make([]tagEntry, 0, len(byTag))
+	for tag, notes := range byTag {
+		tags = append(tags, tagEntry{Tag: tag, Notes: notes})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Tag < tags[j].Tag })
+	return tags
+}
+
+// noteTitle はノートの見出し（タイトルがなければ本文の1行目）
+func noteTitle(n *model.Note) string {
+	if title := strings.TrimSpace(deref(n.Title)); title != "" {
+		return title
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(n.Text), "\n")
+	if r := []rune(line); len(r) > 60 {
+		line = string(r[:60]) + "…"
+	}
+	if line == "" {
+		return n.ID
+	}
+	return line
+}
+
+// globalValue はGlobalConfigの値を表示用のJSONにする
+func globalValue(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// renderIndexMarkdown はindex.mdを作る
+func renderIndexMarkdown(s *Site, pages []*page, tags []tagEntry) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", s.ProjectID)
+	fmt.Fprintf(&b, "%d notes in %d groups. Generated at %s.\n\n", len(s.Notes), len(pages), s.GeneratedAt)
+
+	b.WriteString("## Groups\n\n")
+	for _, p := range pages {
+		fmt.Fprintf(&b, "- [%s](%s/%s.md) (%d notes)", mdEscape(p.Title), GroupsDir, p.Key, len(p.Notes))
+		if p.Description != "" {
+			fmt.Fprintf(&b, " — %s", mdEscape(firstLine(p.Description)))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(s.Globals) > 0 {
+		b.WriteString("\n## Global settings\n\n| Key | Value |\n|-----|-------|\n")
+		for _, g := range s.Globals {
+			fmt.Fprintf(&b, "| `%s` | `%s` |\n", g.Key, strings.ReplaceAll(globalValue(g.Value), "|", `\|`))
+		}
+	}
+
+	if len(tags) > 0 {
+		b.WriteString("\n## Tags\n\n")
+		for _, t := range tags {
+			links := make([]string, len(t.Notes))
+			for i, n := range t.Notes {
+				links[i] = fmt.Sprintf("[%s](%s/%s.md#note-%s)", mdEscape(n.Title), GroupsDir, n.GroupKey, n.ID)
+			}
+			fmt.Fprintf(&b, "- **%s**: %s\n", mdEscape(t.Tag), strings.Join(links, ", "))
+		}
+	}
+	return []byte(b.String())
+}
+
+// renderGroupMarkdown はグループのページを作る（ノートの本文はMarkdownとしてそのまま書く）
+func renderGroupMarkdown(s *Site, p *page) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", mdEscape(p.Title))
+	fmt.Fprintf(&b, "[← %s](../index.md)\n\n", mdEscape(s.ProjectID))
+	var meta []string
+	meta = append(meta, fmt.Sprintf("group: `%s`", p.Key))
+	if p.Status != "" {
+		meta = append(meta, "status: "+p.Status)
+	}
+	if p.Parent != "" {
+		meta = append(meta, fmt.Sprintf("parent: [%s](%s.md)", p.Parent, p.Parent))
+	}
+	if len(p.Tags) > 0 {
+		meta = append(meta, "tags: "+codeList(p.Tags))
+	}
+	b.WriteString(strings.Join(meta, " · "))
+	b.WriteString("\n\n")
+	if p.Description != "" {
+		b.WriteString(strings.TrimSpace(p.Description))
+		b.WriteString("\n\n")
+	}
+
+	for _, n := range p.Notes {
+		fmt.Fprintf(&b, "<a id=\"note-%s\"></a>\n\n## %s\n\n", n.ID, mdEscape(noteTitle(n)))
+		var meta []string
+		if createdAt := deref(n.CreatedAt); createdAt != "" {
+			meta = append(meta, createdAt)
+		}
+		if len(n.Tags) > 0 {
+			meta = append(meta, "tags: "+codeList(n.Tags))
+		}
+		if source := deref(n.Source); source != "" {
+			meta = append(meta, "source: `"+source+"`")
+		}
+		if len(meta) > 0 {
+			fmt.Fprintf(&b, "*%s*\n\n", strings.Join(meta, " · "))
+		}
+		b.WriteString(strings.TrimSpace(n.Text))
+		b.WriteString("\n\n")
+	}
+	return []byte(b.String())
+}
+
+// mdEscape はリンクテキスト・見出しでMarkdownとして解釈される記号をエスケープする
+func mdEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`, "<", `\<`, "*", `\*`, "_", `\_`, "`", "\\`", "\n", " ").Replace(s)
+}
+
+func codeList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = "`" + v + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
+
+// HTMLのページ（1ファイルで完結するようスタイルを埋め込む）
+var htmlTemplates = template.Must(template.New("site").Funcs(template.FuncMap{
+	"noteTitle":   noteTitle,
+	"deref":       deref,
+	"globalValue": globalValue,
+	"firstLine":   firstLine,
+	"groupsDir":   func() string { return GroupsDir },
+}).Parse(`
+{{define "head"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", "Hiragino Sans", sans-serif; max-width: 56rem; margin: 2rem auto; padding: 0 1rem; line-height: 1.6; color: #222; }
+a { color: #0b62c4; }
+.meta { color: #666; font-size: 0.9em; }
+.tag { display: inline-block; background: #eef2f7; border-radius: 3px; padding: 0 0.4em; margin-right: 0.3em; font-size: 0.85em; }
+.note { border-top: 1px solid #ddd; padding-top: 0.5rem; margin-top: 1.5rem; }
+.text { white-space: pre-wrap; word-wrap: break-word; }
+table { border-collapse: collapse; } td, th { border: 1px solid #ddd; padding: 0.2em 0.6em; text-align: left; }
+</style>
+</head>
+<body>
+{{end}}
+
+{{define "tags"}}{{range .}}<span class="tag">{{.}}</span>{{end}}{{end}}
+
+{{define "index"}}{{template "head" .Site.ProjectID}}
+<h1>{{.Site.ProjectID}}</h1>
+<p class="meta">{{len .Site.Notes}} notes in {{len .Pages}} groups. Generated at {{.Site.GeneratedAt}}.</p>
+<h2>Groups</h2>
+<ul>
+{{range .Pages}}<li><a href="{{groupsDir}}/{{.Key}}.html">{{.Title}}</a> <span class="meta">({{len .Notes}} notes)</span>{{with .Description}} — {{firstLine .}}{{end}}</li>
+{{end}}</ul>
+{{with .Site.Globals}}<h2>Global settings</h2>
+<table>
+<tr><th>Key</th><th>Value</th></tr>
+{{range .}}<tr><td><code>{{.Key}}</code></td><td><code>{{globalValue .Value}}</code></td></tr>
+{{end}}</table>
+{{end}}{{with .Tags}}<h2>Tags</h2>
+<ul>
+{{range .}}<li><span class="tag">{{.Tag}}</span> {{range $i, $n := .Notes}}{{if $i}}, {{end}}<a href="{{groupsDir}}/{{$n.GroupKey}}.html#note-{{$n.ID}}">{{$n.Title}}</a>{{end}}</li>
+{{end}}</ul>
+{{end}}</body>
+</html>
+{{end}}
+
+{{define "group"}}{{template "head" .Page.Title}}
+<p><a href="../index.html">← {{.Site.ProjectID}}</a></p>
+<h1>{{.Page.Title}}</h1>
+<p class="meta">group: <code>{{.Page.Key}}</code>{{with .Page.Status}} · status: {{.}}{{end}}{{with .Page.Parent}} · parent: <a href="{{.}}.html">{{.}}</a>{{end}} {{template "tags" .Page.Tags}}</p>
+{{with .Page.Description}}<p class="text">{{.}}</p>
+{{end}}{{range .Page.Notes}}<div class="note" id="note-{{.ID}}">
+<h2>{{noteTitle .}}</h2>
+<p class="meta">{{deref .CreatedAt}} {{template "tags" .Tags}}{{with deref .Source}} · source: <code>{{.}}</code>{{end}}</p>
+<div class="text">{{.Text}}</div>
+</div>
+{{end}}</body>
+</html>
+{{end}}
+`))
+
+func renderIndexHTML(s *Site, pages []*page, tags []tagEntry) ([]byte, error) {
+	var b strings.Builder
+	data := struct {
+		Site  *Site
+		Pages []*page
+		Tags  []tagEntry
+	}{s, pages, tags}
+	if err := htmlTemplates.ExecuteTemplate(&b, "index", data); err != nil {
+		return nil, fmt.Errorf("failed to render index: %w", err)
+	}
+	return []byte(b.String()), nil
+}
+
+func renderGroupHTML(s *Site, p *page) ([]byte, error) {
+	var b strings.Builder
+	data := struct {
+		Site *Site
+		Page *page
+	}{s, p}
+	if err := htmlTemplates.ExecuteTemplate(&b, "group", data); err != nil {
+		return nil, fmt.Errorf("failed to render group %s: %w", p.Key, err)
+	}
+	return []byte(b.String()), nil
+}
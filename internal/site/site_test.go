@@ -0,0 +1,138 @@
+package site
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+)
+
+func strPtr(s string) *string { return &s }
+
+func testSite() *Site {
+	return &Site{
+		ProjectID:   "/test/project",
+		GeneratedAt: "2024-03-10T00:00:00Z",
+		Groups: []*model.Group{
+			{GroupKey: "design", Title: "Design decisions", Description: "Architecture notes", Tags: []string{"arch"}},
+			{GroupKey: "empty", Title: "Empty"},
+		},
+		Globals: []*model.GlobalConfig{
+			{Key: "global.memory.embedder.provider", Value: "openai"},
+		},
+		Notes: []*model.Note{
+			{ID: "n2", GroupID: "design", Title: strPtr("Sessions"), Text: "Expire after 30 minutes.", Tags: []string{"auth"}, CreatedAt: strPtr("2024-03-02T00:00:00Z")},
+			{ID: "n1", GroupID: "design", Title: strPtr("Auth <JWT>"), Text: "Use JWT.\n<script>alert(1)</script>", Tags: []string{"auth", "security"}, Source: strPtr("docs/auth.md"), CreatedAt: strPtr("2024-03-01T00:00:00Z")},
+			{ID: "n3", GroupID: "global", Text: "Prefer small PRs.\nmore", CreatedAt: strPtr("2024-03-03T00:00:00Z")},
+		},
+	}
+}
+
+func readFile(t *testing.T, dir, rel string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(rel)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// TestWrite_Markdown はグループごとのページとindexが書き出されることをテスト
+func TestWrite_Markdown(t *testing.T) {
+	dir := t.TempDir()
+	result, err := Write(dir, FormatMarkdown, testSite())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Files != 4 || result.Groups != 3 || result.Notes != 3 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	index := readFile(t, dir, "index.md")
+	for _, want := range []string{
+		"# /test/project",
+		"- [global](groups/global.md) (1 notes)\n- [Design decisions](groups/design.md) (2 notes) — Architecture notes\n- [Empty](groups/empty.md) (0 notes)",
+		"| `global.memory.embedder.provider` | `\"openai\"` |",
+		"- **auth**: [Auth \\<JWT>](groups/design.md#note-n1), [Sessions](groups/design.md#note-n2)",
+	} {
+		if !strings.Contains(index, want) {
+			t.Errorf("index.md does not contain %q:\n%s", want, index)
+		}
+	}
+
+	// ノートはcreatedAtの古い順
+	design := readFile(t, dir, "groups/design.md")
+	first, second := strings.Index(design, "## Auth"), strings.Index(design, "## Sessions")
+	if first < 0 || second < first {
+		t.Errorf("expected notes sorted by createdAt:\n%s", design)
+	}
+	if !strings.Contains(design, "*2024-03-01T00:00:00Z · tags: `auth`, `security` · source: `docs/auth.md`*") {
+		t.Errorf("expected note metadata:\n%s", design)
+	}
+
+	// タイトルのないノートは本文の1行目を見出しにする
+	if global := readFile(t, dir, "groups/global.md"); !strings.Contains(global, "## Prefer small PRs.\n") {
+		t.Errorf("expected the first line as heading:\n%s", global)
+	}
+}
+
+// TestWrite_HTML はHTMLでノートの本文がエスケープされることをテスト
+func TestWrite_HTML(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Write(dir, FormatHTML, testSite()); err != nil {
+		t.Fatal(err)
+	}
+	design := readFile(t, dir, "groups/design.html")
+	if strings.Contains(design, "<script>") {
+		t.Errorf("expected the note text to be escaped:\n%s", design)
+	}
+	for _, want := range []string{
+		`<div class="note" id="note-n1">`,
+		"<h2>Auth &lt;JWT&gt;</h2>",
+		`<span class="tag">security</span>`,
+		`<a href="../index.html">`,
+	} {
+		if !strings.Contains(design, want) {
+			t.Errorf("design.html does not contain %q", want)
+		}
+	}
+	if index := readFile(t, dir, "index.html"); !strings.Contains(index, `<a href="groups/design.html#note-n2">Sessions</a>`) {
+		t.Errorf("expected tag links in index.html:\n%s", index)
+	}
+}
+
+// TestWrite_RemovesStalePages は前回の出力にあって今回ないグループのページを削除することをテスト
+func TestWrite_RemovesStalePages(t *testing.T) {
+	dir := t.TempDir()
+	s := testSite()
+	if _, err := Write(dir, FormatMarkdown, s); err != nil {
+		t.Fatal(err)
+	}
+	keep := filepath.Join(dir, GroupsDir, "notes.txt")
+	if err := os.WriteFile(keep, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s.Groups = s.Groups[:1]
+	result, err := Write(dir, FormatMarkdown, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Removed != 1 {
+		t.Errorf("expected 1 removed page, got %+v", result)
+	}
+	if _, err := os.Stat(filepath.Join(dir, GroupsDir, "empty.md")); !os.IsNotExist(err) {
+		t.Errorf("expected empty.md to be removed, got %v", err)
+	}
+	if _, err := os.Stat(keep); err != nil {
+		t.Errorf("expected other files to be kept: %v", err)
+	}
+}
+
+func TestWrite_UnknownFormat(t *testing.T) {
+	if _, err := Write(t.TempDir(), "pdf", testSite()); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,265 @@
+package store
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+)
+
+// ArchivedNote はコールドストアに移したノート1件（復元用に埋め込みも持つ）
+type ArchivedNote struct {
+	Note       *model.Note    `json:"note"`
+	Embedding  []float32      `json:"embedding"`
+	ArchivedAt string         `json:"archivedAt"`
+	Payload    map[string]any `json:"payload,omitempty"` // 隔離したノートの元のpayload（QdrantのScanNotesで読んだ場合のみ）
+}
+
+// ColdStore はアーカイブしたノートをnamespaceごとのgzip圧縮したJSONLファイルに保存する
+// ベクトルインデックスは持たず、検索は全件を走査する（通常の検索には含めない前提）
+// 変更はファイル全体を一時ファイルに書いてから置き換える。プロセス内の操作は直列化するが、
+// 別のプロセスと同時に変更すると片方の変更が失われうる
+type ColdStore struct {
+	path   string
+	cipher *noteCipher // nilなら暗号化しない
+}
+
+// coldStoreMu はプロセス内のColdStoreの読み書きを直列化する
+var coldStoreMu sync.Mutex
+
+// NewColdStore はdir配下のnamespaceのコールドストアを作成する（ファイルは最初の書き込みで作る）
+func NewColdStore(dir, namespace string) *ColdStore {
+	return &ColdStore{path: coldStorePath(dir, namespace)}
+}
+
+// NewEncryptedColdStore はノートの本文・タイトル・metadataをkeysで暗号化して保存するコールドストアを作成する
+// （keysが空ならNewColdStoreと同じ。鍵の扱いはNewEncryptedStoreと同じ）
+func NewEncryptedColdStore(dir, namespace string, keys []EncryptionKey) (*ColdStore, error) {
+	c := NewColdStore(dir, namespace)
+	if len(keys) == 0 {
+		return c, nil
+	}
+	cipher, err := newNoteCipher(keys)
+	if err != nil {
+		return nil, err
+	}
+	c.cipher = cipher
+	return c, nil
+}
+
+// coldStorePath はnamespaceのコールドストアのファイルパスを返す（journalPathと同じ規則でファイル名にする）
+func coldStorePath(dir, namespace string) string {
+	return journalPath(dir, namespace) + ".gz"
+}
+
+// Put はノートをアーカイブに追加する（同じIDは置き換える）
+func (c *ColdStore) Put(notes []ArchivedNote) error {
+	coldStoreMu.Lock()
+	defer coldStoreMu.Unlock()
+
+	existing, err := c.load()
+	if err != nil {
+		return err
+	}
+	replaced := make(map[string]bool, len(notes))
+	for _, n := range notes {
+		replaced[n.Note.ID] = true
+	}
+	kept := existing[:0]
+	for _, n := range existing {
+		if !replaced[n.Note.ID] {
+			kept = append(kept, n)
+		}
+	}
+	return c.save(append(kept, notes...))
+}
+
+// Get はidsのうちアーカイブにあるノートを返す（idsの順、ないIDは含めない）
+func (c *ColdStore) Get(ids []string) ([]ArchivedNote, error) {
+	coldStoreMu.Lock()
+	defer coldStoreMu.Unlock()
+
+	existing, err := c.load()
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]ArchivedNote, len(existing))
+	for _, n := range existing {
+		byID[n.Note.ID] = n
+	}
+	var notes []ArchivedNote
+	for _, id := range ids {
+		if n, ok := byID[id]; ok {
+			notes = append(notes, n)
+		}
+	}
+	return notes, nil
+}
+
+// Remove はidsのノートをアーカイブから削除し、削除した件数を返す
+func (c *ColdStore) Remove(ids []string) (int, error) {
+	coldStoreMu.Lock()
+	defer coldStoreMu.Unlock()
+
+	existing, err := c.load()
+	if err != nil {
+		return 0, err
+	}
+	remove := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		remove[id] = true
+	}
+	kept := existing[:0]
+	for _, n := range existing {
+		if !remove[n.Note.ID] {
+			kept = append(kept, n)
+		}
+	}
+	removed := len(existing) - len(kept)
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, c.save(kept)
+}
+
+// List はプロジェクトのアーカイブしたノートを返す（projectIDが空なら全プロジェクト、createdAt昇順）
+func (c *ColdStore) List(projectID string) ([]ArchivedNote, error) {
+	coldStoreMu.Lock()
+	defer coldStoreMu.Unlock()
+
+	existing, err := c.load()
+	if err != nil {
+		return nil, err
+	}
+	var notes []*model.Note
+	byID := make(map[string]ArchivedNote, len(existing))
+	for _, n := range existing {
+		if projectID == "" || n.Note.ProjectID == projectID {
+			notes = append(notes, n.Note)
+			byID[n.Note.ID] = n
+		}
+	}
+	sortByCreatedAt(notes)
+	result := make([]ArchivedNote, len(notes))
+	for i, note := range notes {
+		result[i] = byID[note.ID]
+	}
+	return result, nil
+}
+
+// Search はアーカイブしたノートを全件走査してベクトル検索する（スコアはMemoryStoreと同じ）
+func (c *ColdStore) Search(embedding []float32, opts SearchOptions) ([]SearchResult, error) {
+	coldStoreMu.Lock()
+	existing, err := c.load()
+	coldStoreMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	queryNorm := vectorNorm(embedding)
+	var results []SearchResult
+	for _, n := range existing {
+		if n.Note.ProjectID != opts.ProjectID || !matchesSearch(n.Note, opts) {
+			continue
+		}
+		distance := 2.0
+		if len(n.Embedding) == len(embedding) {
+			distance = cosineDistance(dot(embedding, n.Embedding), queryNorm, vectorNorm(n.Embedding))
+		}
+		results = append(results, SearchResult{Note: n.Note, Score: 1.0 - (distance / 2.0), Archived: true})
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if opts.TopK > 0 && len(results) > opts.TopK {
+		results = results[:opts.TopK]
+	}
+	return results, nil
+}
+
+// load はファイルの全件を読み込む（ファイルがなければ空）
+func (c *ColdStore) load() ([]ArchivedNote, error) {
+	f, err := os.Open(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive %s: %w", c.path, err)
+	}
+	defer zr.Close()
+
+	var notes []ArchivedNote
+	dec := json.NewDecoder(bufio.NewReader(zr))
+	for {
+		var n ArchivedNote
+		if err := dec.Decode(&n); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read archive %s: %w", c.path, err)
+		}
+		if n.Note == nil {
+			continue
+		}
+		if c.cipher != nil {
+			if err := c.cipher.decryptNote(n.Note); err != nil {
+				return nil, fmt.Errorf("failed to read archive %s: %w", c.path, err)
+			}
+		}
+		notes = append(notes, n)
+	}
+	return notes, nil
+}
+
+// save は全件を一時ファイルに書き、ファイルを置き換える
+func (c *ColdStore) save(notes []ArchivedNote) error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), ".archive-*")
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	zw := gzip.NewWriter(tmp)
+	enc := json.NewEncoder(zw)
+	for _, n := range notes {
+		if c.cipher != nil {
+			note, err := c.cipher.encryptNote(n.Note)
+			if err != nil {
+				tmp.Close()
+				return fmt.Errorf("failed to write archive: %w", err)
+			}
+			n.Note = note
+		}
+		if err := enc.Encode(n); err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to write archive: %w", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("failed to replace archive: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package store
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestColdStore はアーカイブの追加・取得・一覧・検索・削除をテスト
+func TestColdStore(t *testing.T) {
+	dir := t.TempDir()
+	cs := NewColdStore(dir, "openai:test:3")
+
+	if notes, err := cs.List(""); err != nil || len(notes) != 0 {
+		t.Fatalf("expected an empty archive before the first write, got %+v, %v", notes, err)
+	}
+
+	now := Timestamp()
+	err := cs.Put([]ArchivedNote{
+		{Note: newTestNoteWithCreatedAt("note-2", "/test/project", "global", "second", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)), Embedding: []float32{0, 1, 0}, ArchivedAt: now},
+		{Note: newTestNoteWithCreatedAt("note-1", "/test/project", "global", "first", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)), Embedding: []float32{1, 0, 0}, ArchivedAt: now},
+		{Note: newTestNote("note-3", "/other/project", "global", "other"), Embedding: []float32{1, 0, 0}, ArchivedAt: now},
+	})
+	if err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	// 同じIDは置き換える
+	if err := cs.Put([]ArchivedNote{{Note: newTestNoteWithCreatedAt("note-2", "/test/project", "global", "replaced", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)), Embedding: []float32{0, 1, 0}, ArchivedAt: now}}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	// 別のインスタンスでも同じファイルを読む
+	reopened := NewColdStore(dir, "openai:test:3")
+	notes, err := reopened.List("/test/project")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(notes) != 2 || notes[0].Note.ID != "note-1" || notes[1].Note.Text != "replaced" {
+		t.Errorf("unexpected notes: %+v", notes)
+	}
+	if all, _ := reopened.List(""); len(all) != 3 {
+		t.Errorf("expected 3 notes across projects, got %d", len(all))
+	}
+
+	results, err := reopened.Search([]float32{1, 0, 0}, SearchOptions{ProjectID: "/test/project", TopK: 1})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Note.ID != "note-1" || !results[0].Archived || results[0].Score < 0.99 {
+		t.Errorf("unexpected results: %+v", results)
+	}
+
+	got, err := reopened.Get([]string{"note-3", "missing", "note-1"})
+	if err != nil || len(got) != 2 || got[0].Note.ID != "note-3" || len(got[1].Embedding) != 3 {
+		t.Errorf("Get = %+v, %v", got, err)
+	}
+
+	removed, err := reopened.Remove([]string{"note-1", "missing"})
+	if err != nil || removed != 1 {
+		t.Errorf("Remove = %d, %v", removed, err)
+	}
+	if notes, _ := cs.List("/test/project"); len(notes) != 1 {
+		t.Errorf("expected 1 note after Remove, got %d", len(notes))
+	}
+
+	// 別のnamespaceは別のファイル
+	if notes, _ := NewColdStore(dir, "openai:other:3").List(""); len(notes) != 0 {
+		t.Errorf("expected no notes in another namespace, got %d", len(notes))
+	}
+}
+
+// TestColdStore_Encryption は暗号化を有効にする前にアーカイブしたノートも読み出せ、書き込むと暗号化することをテスト
+func TestColdStore_Encryption(t *testing.T) {
+	dir := t.TempDir()
+	now := Timestamp()
+	if err := NewColdStore(dir, "openai:test:3").Put([]ArchivedNote{{Note: newTestNote("note-1", "/test/project", "global", "plain"), ArchivedAt: now}}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	cs, err := NewEncryptedColdStore(dir, "openai:test:3", []EncryptionKey{testKeyA})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cs.Put([]ArchivedNote{{Note: newTestNote("note-2", "/test/project", "global", "secret"), ArchivedAt: now}}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	notes, err := cs.List("/test/project")
+	if err != nil || len(notes) != 2 || notes[0].Note.Text != "plain" || notes[1].Note.Text != "secret" {
+		t.Errorf("unexpected notes: %+v, %v", notes, err)
+	}
+
+	// 鍵がなければ暗号文のまま読める（書き込み時に以前のノートも暗号化する）
+	raw, err := NewColdStore(dir, "openai:test:3").List("")
+	if err != nil || len(raw) != 2 {
+		t.Fatalf("List = %+v, %v", raw, err)
+	}
+	for _, n := range raw {
+		if !strings.HasPrefix(n.Note.Text, "mcpenc:v1:a:") {
+			t.Errorf("expected ciphertext in the archive, got %q", n.Note.Text)
+		}
+	}
+
+	if _, err := NewEncryptedColdStore(dir, "openai:test:3", []EncryptionKey{{ID: "bad:id", Key: testKeyA.Key}}); err == nil {
+		t.Error("expected an error for an invalid key id")
+	}
+}
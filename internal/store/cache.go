@@ -0,0 +1,209 @@
+package store
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+)
+
+// DefaultNoteCacheSize はIDで取得したノートをキャッシュする件数のデフォルト
+const DefaultNoteCacheSize = 1000
+
+// existenceCheckSkipTTL はキャッシュしたノートの更新・削除で存在確認を省く期間（取得してからの時間）
+// ほかのプロセスが削除したノートをUpdateのupsertで作り直してしまう期間を、この長さまでに抑える
+const existenceCheckSkipTTL = 5 * time.Second
+
+// existingNoteWriter は存在が分かっているノートを、存在確認を省いて更新・削除できるStore
+// （存在確認が別の往復になるQdrantStoreが実装する）
+type existingNoteWriter interface {
+	updateExisting(ctx context.Context, note *model.Note, embedding []float32) error
+	deleteExisting(ctx context.Context, id string) error
+}
+
+// cachedStore はGetで取得したノートをIDごとにLRUでキャッシュするStore
+// このStoreを通した書き込み（AddNote・Update・Delete・グループ単位の変更）でキャッシュを無効化する
+// ほかのプロセスによる同じストアへの書き込みは検知しないため、このプロセスだけが書き込む前提
+// （存在確認を省くのは取得からexistenceCheckSkipTTL以内に限る）
+type cachedStore struct {
+	Store
+	size int
+	now  func() time.Time
+
+	mu    sync.Mutex
+	gen   uint64                   // 無効化のたびに増やす（取得中に無効化された結果はキャッシュしない）
+	order *list.List               // 最近使った順（先頭が最新、値は*cachedNote）
+	items map[string]*list.Element // key: note.ID
+}
+
+// cachedNote はキャッシュの1件
+type cachedNote struct {
+	id      string
+	note    *model.Note
+	fetched time.Time // ストアから取得した時刻
+}
+
+// NewCachedStore はGetの結果をsize件までキャッシュするStoreを返す（sizeが0以下ならsをそのまま返す）
+// 取得してからexistenceCheckSkipTTL以内のノートは、Update・Deleteの存在確認も省く（sが対応している場合）
+func NewCachedStore(s Store, size int) Store {
+	if size <= 0 {
+		return s
+	}
+	return &cachedStore{Store: s, size: size, now: time.Now, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+// lookup はキャッシュしたノートを返す（呼び出し側でコピーすること）
+func (s *cachedStore) lookup(id string) (*model.Note, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.items[id]
+	if !ok {
+		return nil, false
+	}
+	s.order.MoveToFront(e)
+	return e.Value.(*cachedNote).note, true
+}
+
+// recentlyFetched はidのノートがキャッシュにあり、取得してからexistenceCheckSkipTTL以内かを返す
+func (s *cachedStore) recentlyFetched(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.items[id]
+	return ok && s.now().Sub(e.Value.(*cachedNote).fetched) < existenceCheckSkipTTL
+}
+
+// generation は現在の無効化の世代を返す
+func (s *cachedStore) generation() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.gen
+}
+
+// put はgenの時点で取得したノートをキャッシュする（その後に無効化されていれば何もしない）
+func (s *cachedStore) put(note *model.Note, gen uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if gen != s.gen {
+		return
+	}
+	if e, ok := s.items[note.ID]; ok {
+		c := e.Value.(*cachedNote)
+		c.note, c.fetched = note, s.now()
+		s.order.MoveToFront(e)
+		return
+	}
+	s.items[note.ID] = s.order.PushFront(&cachedNote{id: note.ID, note: note, fetched: s.now()})
+	if s.order.Len() > s.size {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.items, oldest.Value.(*cachedNote).id)
+	}
+}
+
+// invalidate はidのノートをキャッシュから外す（書き込みの後に呼ぶ）
+func (s *cachedStore) invalidate(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gen++
+	if e, ok := s.items[id]; ok {
+		s.order.Remove(e)
+		delete(s.items, id)
+	}
+}
+
+// purge はキャッシュを空にする（複数のノートをまとめて変更した後に呼ぶ）
+func (s *cachedStore) purge() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gen++
+	s.order.Init()
+	clear(s.items)
+}
+
+func (s *cachedStore) Get(ctx context.Context, id string) (*model.Note, error) {
+	if note, ok := s.lookup(id); ok {
+		return copyNote(note), nil
+	}
+	gen := s.generation()
+	note, err := s.Store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.put(copyNote(note), gen)
+	return note, nil
+}
+
+func (s *cachedStore) AddNote(ctx context.Context, note *model.Note, embedding []float32) error {
+	defer s.invalidate(note.ID)
+	return s.Store.AddNote(ctx, note, embedding)
+}
+
+// Update はノートを取得してからexistenceCheckSkipTTL以内なら、存在確認を省いて更新する
+func (s *cachedStore) Update(ctx context.Context, note *model.Note, embedding []float32) error {
+	defer s.invalidate(note.ID)
+	if w, ok := s.Store.(existingNoteWriter); ok {
+		if s.recentlyFetched(note.ID) {
+			return w.updateExisting(ctx, note, embedding)
+		}
+	}
+	return s.Store.Update(ctx, note, embedding)
+}
+
+// Delete はノートを取得してからexistenceCheckSkipTTL以内なら、存在確認を省いて削除する
+func (s *cachedStore) Delete(ctx context.Context, id string) error {
+	defer s.invalidate(id)
+	if w, ok := s.Store.(existingNoteWriter); ok {
+		if s.recentlyFetched(id) {
+			return w.deleteExisting(ctx, id)
+		}
+	}
+	return s.Store.Delete(ctx, id)
+}
+
+func (s *cachedStore) DeleteNotesByGroup(ctx context.Context, projectID, groupID string) (int, error) {
+	defer s.purge()
+	return s.Store.DeleteNotesByGroup(ctx, projectID, groupID)
+}
+
+func (s *cachedStore) MoveNotesToGroup(ctx context.Context, projectID, fromGroupID, toGroupID string) (int, error) {
+	defer s.purge()
+	return s.Store.MoveNotesToGroup(ctx, projectID, fromGroupID, toGroupID)
+}
+
+func (s *cachedStore) RenameGroup(ctx context.Context, id, groupKey string) (int, error) {
+	defer s.purge()
+	return s.Store.RenameGroup(ctx, id, groupKey)
+}
+
+// ScanNotes はキャッシュを通さずに走査する
+func (s *cachedStore) ScanNotes(ctx context.Context, fn func(RawNote) error) error {
+	r, ok := s.Store.(RawScanner)
+	if !ok {
+		return ErrNotScannable
+	}
+	return r.ScanNotes(ctx, fn)
+}
+
+// WithTx はトランザクション内の読み書きをキャッシュを通さずに行い、終わった後にキャッシュを空にする
+// （ロールバックした書き込みをキャッシュに残さない）
+func (s *cachedStore) WithTx(ctx context.Context, fn func(tx Store) error) error {
+	t, ok := s.Store.(Transactional)
+	if !ok {
+		return ErrNotTransactional
+	}
+	defer s.purge()
+	return t.WithTx(ctx, fn)
+}
+
+// Initialize はnamespaceが変わりうるため、初期化した後にキャッシュを空にする
+func (s *cachedStore) Initialize(ctx context.Context, namespace string) error {
+	defer s.purge()
+	return s.Store.Initialize(ctx, namespace)
+}
+
+func (s *cachedStore) Close() error {
+	defer s.purge()
+	return s.Store.Close()
+}
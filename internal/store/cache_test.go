@@ -0,0 +1,187 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+)
+
+// countingStore はGetと存在確認を省いた書き込みの回数を数えるStore
+type countingStore struct {
+	*MemoryStore
+	gets     int
+	existing int
+	onGet    func() // Getの途中で呼ぶ（nilなら何もしない）
+}
+
+func (s *countingStore) Get(ctx context.Context, id string) (*model.Note, error) {
+	s.gets++
+	if s.onGet != nil {
+		s.onGet()
+	}
+	return s.MemoryStore.Get(ctx, id)
+}
+
+func (s *countingStore) updateExisting(ctx context.Context, note *model.Note, embedding []float32) error {
+	s.existing++
+	return s.MemoryStore.Update(ctx, note, embedding)
+}
+
+func (s *countingStore) deleteExisting(ctx context.Context, id string) error {
+	s.existing++
+	return s.MemoryStore.Delete(ctx, id)
+}
+
+// newCachedTestStore は3件のノートを入れたcountingStoreと、それをsize件キャッシュするStoreを返す
+func newCachedTestStore(t *testing.T, size int) (*countingStore, Store) {
+	t.Helper()
+	ctx := context.Background()
+	backend := &countingStore{MemoryStore: NewMemoryStore()}
+	st := NewCachedStore(backend, size)
+	if err := st.Initialize(ctx, "openai:test:3"); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"note-1", "note-2", "note-3"} {
+		if err := st.AddNote(ctx, newTestNote(id, "/test/project", "global", "text of "+id), []float32{1, 0, 0}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() { st.Close() })
+	return backend, st
+}
+
+func TestCachedStore_Get(t *testing.T) {
+	ctx := context.Background()
+	backend, st := newCachedTestStore(t, 2)
+
+	note, err := st.Get(ctx, "note-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 返したノートを書き換えてもキャッシュには影響しない
+	note.Text = "mutated"
+	note.Tags = append(note.Tags, "mutated")
+	cached, err := st.Get(ctx, "note-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backend.gets != 1 {
+		t.Errorf("expected the second Get to be served from the cache, got %d backend gets", backend.gets)
+	}
+	if cached.Text != "text of note-1" || len(cached.Tags) != 0 {
+		t.Errorf("cached note was modified: %+v", cached)
+	}
+
+	// 最近使っていないものから追い出す（note-2の後にnote-1を使ったため、note-3の追加でnote-2が追い出される）
+	st.Get(ctx, "note-2")
+	st.Get(ctx, "note-1")
+	st.Get(ctx, "note-3")
+	backend.gets = 0
+	st.Get(ctx, "note-1")
+	st.Get(ctx, "note-3")
+	if backend.gets != 0 {
+		t.Errorf("expected note-1 and note-3 to be cached, got %d backend gets", backend.gets)
+	}
+	st.Get(ctx, "note-2")
+	if backend.gets != 1 {
+		t.Errorf("expected note-2 to be evicted, got %d backend gets", backend.gets)
+	}
+
+	// 存在しないノートはキャッシュしない
+	if _, err := st.Get(ctx, "missing"); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+// TestCachedStore_WriteInvalidates は書き込みでキャッシュが無効化され、キャッシュ済みなら存在確認を省くことをテスト
+func TestCachedStore_WriteInvalidates(t *testing.T) {
+	ctx := context.Background()
+	backend, st := newCachedTestStore(t, 10)
+
+	note, _ := st.Get(ctx, "note-1")
+	note.Text = "updated"
+	if err := st.Update(ctx, note, []float32{0, 1, 0}); err != nil {
+		t.Fatal(err)
+	}
+	if backend.existing != 1 {
+		t.Errorf("expected the cached note to be updated without an existence check, got %d", backend.existing)
+	}
+	if got, _ := st.Get(ctx, "note-1"); got.Text != "updated" {
+		t.Errorf("expected the updated note, got %+v", got)
+	}
+
+	// キャッシュになければ通常のUpdate（存在しなければErrNotFound）
+	if err := st.Update(ctx, newTestNote("missing", "/test/project", "global", "text"), []float32{1, 0, 0}); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if backend.existing != 1 {
+		t.Errorf("expected no existence-free write for an uncached note, got %d", backend.existing)
+	}
+
+	if err := st.Delete(ctx, "note-1"); err != nil {
+		t.Fatal(err)
+	}
+	if backend.existing != 2 {
+		t.Errorf("expected the cached note to be deleted without an existence check, got %d", backend.existing)
+	}
+	if _, err := st.Get(ctx, "note-1"); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound after Delete, got %v", err)
+	}
+
+	// グループ単位の変更はキャッシュ全体を無効化する
+	st.Get(ctx, "note-2")
+	if _, err := st.MoveNotesToGroup(ctx, "/test/project", "global", "moved"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := st.Get(ctx, "note-2"); got.GroupID != "moved" {
+		t.Errorf("expected the moved note, got %+v", got)
+	}
+}
+
+// TestCachedStore_StaleSkipsNoCheck は取得から時間が経ったノートは存在確認をしてから書き込むことをテスト
+// （ほかのプロセスが削除したノートをUpdateで作り直さない）
+func TestCachedStore_StaleSkipsNoCheck(t *testing.T) {
+	ctx := context.Background()
+	backend, st := newCachedTestStore(t, 10)
+	now := time.Now()
+	st.(*cachedStore).now = func() time.Time { return now }
+
+	note, _ := st.Get(ctx, "note-1")
+	// ほかのプロセスによる削除（キャッシュは無効化されない）
+	if err := backend.MemoryStore.Delete(ctx, "note-1"); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(existenceCheckSkipTTL)
+	if err := st.Update(ctx, note, []float32{1, 0, 0}); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound for a note deleted elsewhere, got %v", err)
+	}
+	if backend.existing != 0 {
+		t.Errorf("expected an existence check for a stale cached note, got %d existence-free writes", backend.existing)
+	}
+	if _, err := backend.MemoryStore.Get(ctx, "note-1"); err != ErrNotFound {
+		t.Errorf("expected the deleted note not to be recreated, got %v", err)
+	}
+}
+
+// TestCachedStore_InvalidatedDuringGet は取得中に無効化された結果をキャッシュしないことをテスト
+func TestCachedStore_InvalidatedDuringGet(t *testing.T) {
+	ctx := context.Background()
+	backend, st := newCachedTestStore(t, 10)
+
+	backend.onGet = func() { st.(*cachedStore).invalidate("note-1") }
+	st.Get(ctx, "note-1")
+	backend.onGet = nil
+	st.Get(ctx, "note-1")
+	if backend.gets != 2 {
+		t.Errorf("expected the note fetched during an invalidation not to be cached, got %d backend gets", backend.gets)
+	}
+}
+
+func TestNewCachedStore_Disabled(t *testing.T) {
+	backend := NewMemoryStore()
+	if st := NewCachedStore(backend, 0); st != Store(backend) {
+		t.Errorf("expected the store to be returned as is, got %T", st)
+	}
+}
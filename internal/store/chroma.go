@@ -3,8 +3,11 @@ package store
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/store/naming"
 )
 
 const (
@@ -15,26 +18,44 @@ const (
 // ChromaStore はChromaを使用したStore実装（スタブ）
 // TODO: chroma-go v2 APIを使用した完全実装
 type ChromaStore struct {
-	baseURL   string
-	namespace string
+	baseURL    string
+	namespace  string
+	collection string       // namespaceのノートのコレクション名（naming.Chroma）
+	httpClient *http.Client // Chroma REST APIの呼び出しに使う
 	// 実際の実装では chroma.Client などを保持
 }
 
+// ChromaOption はChromaStoreのオプション
+type ChromaOption func(*ChromaStore)
+
+// WithChromaTimeout はHTTPリクエストのタイムアウトを設定（デフォルトなし）
+func WithChromaTimeout(d time.Duration) ChromaOption {
+	return func(s *ChromaStore) {
+		s.httpClient.Timeout = d
+	}
+}
+
 // NewChromaStore はChromaStoreを作成する
-func NewChromaStore(url string) (*ChromaStore, error) {
+func NewChromaStore(url string, opts ...ChromaOption) (*ChromaStore, error) {
 	if url == "" {
 		url = DefaultChromaURL
 	}
 
-	return &ChromaStore{
-		baseURL: url,
-	}, nil
+	s := &ChromaStore{
+		baseURL:    url,
+		httpClient: &http.Client{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
 }
 
 // Initialize はストアを初期化する
 func (s *ChromaStore) Initialize(ctx context.Context, namespace string) error {
 	s.namespace = namespace
-	// TODO: chroma-go v2 APIでコレクション作成
+	s.collection = naming.Chroma.Collection(namespace, naming.SuffixNotes)
+	// TODO: chroma-go v2 APIでs.collectionのコレクション作成
 	return fmt.Errorf("ChromaStore is not yet implemented")
 }
 
@@ -73,6 +94,41 @@ func (s *ChromaStore) ListRecent(ctx context.Context, opts ListOptions) ([]*mode
 	return nil, fmt.Errorf("ChromaStore is not yet implemented")
 }
 
+// CountNotes は一覧の条件に一致するノートの件数を返す
+func (s *ChromaStore) CountNotes(ctx context.Context, opts ListOptions) (int, error) {
+	return 0, fmt.Errorf("ChromaStore is not yet implemented")
+}
+
+// TouchNotes はノートの最終アクセスを記録する
+func (s *ChromaStore) TouchNotes(ctx context.Context, ids []string, accessedAt string) error {
+	return fmt.Errorf("ChromaStore is not yet implemented")
+}
+
+// ListStaleNotes は長くアクセスのないノートを取得する
+func (s *ChromaStore) ListStaleNotes(ctx context.Context, projectID string, before time.Time) ([]*model.Note, error) {
+	return nil, fmt.Errorf("ChromaStore is not yet implemented")
+}
+
+// DeleteNotesByGroup はプロジェクト内のgroupIDのノートを全て削除する
+func (s *ChromaStore) DeleteNotesByGroup(ctx context.Context, projectID, groupID string) (int, error) {
+	return 0, fmt.Errorf("ChromaStore is not yet implemented")
+}
+
+// MoveNotesToGroup はプロジェクト内のfromGroupIDのノートをtoGroupIDへ移す
+func (s *ChromaStore) MoveNotesToGroup(ctx context.Context, projectID, fromGroupID, toGroupID string) (int, error) {
+	return 0, fmt.Errorf("ChromaStore is not yet implemented")
+}
+
+// ListNotes はプロジェクト内の全ノートを取得する
+func (s *ChromaStore) ListNotes(ctx context.Context, projectID string) ([]*model.Note, error) {
+	return nil, fmt.Errorf("ChromaStore is not yet implemented")
+}
+
+// GetEmbedding はノートの埋め込みベクトルを取得する
+func (s *ChromaStore) GetEmbedding(ctx context.Context, id string) ([]float32, error) {
+	return nil, fmt.Errorf("ChromaStore is not yet implemented")
+}
+
 // UpsertGlobal はグローバル設定を追加/更新する
 func (s *ChromaStore) UpsertGlobal(ctx context.Context, config *model.GlobalConfig) error {
 	return fmt.Errorf("ChromaStore is not yet implemented")
@@ -93,6 +149,16 @@ func (s *ChromaStore) DeleteGlobalByID(ctx context.Context, id string) error {
 	return fmt.Errorf("ChromaStore is not yet implemented")
 }
 
+// ListGlobals はプロジェクト内の全グローバル設定を取得する
+func (s *ChromaStore) ListGlobals(ctx context.Context, projectID string) ([]*model.GlobalConfig, error) {
+	return nil, fmt.Errorf("ChromaStore is not yet implemented")
+}
+
+// ListProjects はプロジェクト一覧を取得する
+func (s *ChromaStore) ListProjects(ctx context.Context) ([]ProjectSummary, error) {
+	return nil, fmt.Errorf("ChromaStore is not yet implemented")
+}
+
 // AddGroup はグループを追加する
 func (s *ChromaStore) AddGroup(ctx context.Context, group *model.Group) error {
 	return fmt.Errorf("ChromaStore is not yet implemented")
@@ -118,6 +184,16 @@ func (s *ChromaStore) DeleteGroup(ctx context.Context, id string) error {
 	return fmt.Errorf("ChromaStore is not yet implemented")
 }
 
+// RenameGroup はグループのgroupKeyを変更する
+func (s *ChromaStore) RenameGroup(ctx context.Context, id, groupKey string) (int, error) {
+	return 0, fmt.Errorf("ChromaStore is not yet implemented")
+}
+
+// GroupStats はプロジェクト内のgroupIdごとのノートの統計を返す
+func (s *ChromaStore) GroupStats(ctx context.Context, projectID string) (map[string]GroupStats, error) {
+	return nil, fmt.Errorf("ChromaStore is not yet implemented")
+}
+
 // ListGroups はプロジェクト内の全グループを取得する
 func (s *ChromaStore) ListGroups(ctx context.Context, projectID string) ([]*model.Group, error) {
 	return nil, fmt.Errorf("ChromaStore is not yet implemented")
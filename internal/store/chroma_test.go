@@ -1,6 +1,7 @@
 package store
 
 import (
+	"reflect"
 	"testing"
 	"time"
 
@@ -905,6 +906,97 @@ func TestChromaStore_Namespace_Isolation(t *testing.T) {
 	assertErrorIs(t, err, ErrNotFound)
 }
 
+// TestChromaStore_ListNotes はプロジェクト内の全ノート取得（createdAt昇順）をテスト
+func TestChromaStore_ListNotes(t *testing.T) {
+	ctx := setupTestContext()
+	store := setupTestStore(t)
+	defer store.Close()
+
+	embedding := dummyEmbedding(1536)
+	time1 := time.Date(2024, 1, 10, 10, 0, 0, 0, time.UTC)
+	time2 := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	assertNoError(t, store.AddNote(ctx, newTestNoteWithCreatedAt("all2", testProjectID, testGroupID, "Note 2", time2), embedding))
+	assertNoError(t, store.AddNote(ctx, newTestNoteWithCreatedAt("all1", testProjectID, "other-group", "Note 1", time1), embedding))
+	assertNoError(t, store.AddNote(ctx, newTestNoteWithCreatedAt("other", "/other/project", testGroupID, "Other", time1), embedding))
+
+	notes, err := store.ListNotes(ctx, testProjectID)
+	assertNoError(t, err)
+
+	if len(notes) != 2 {
+		t.Fatalf("Expected 2 notes, got %d", len(notes))
+	}
+	if notes[0].ID != "all1" || notes[1].ID != "all2" {
+		t.Errorf("Expected [all1 all2] in createdAt ascending order, got [%s %s]", notes[0].ID, notes[1].ID)
+	}
+}
+
+// TestChromaStore_GetEmbedding は埋め込みベクトルの取得をテスト
+func TestChromaStore_GetEmbedding(t *testing.T) {
+	ctx := setupTestContext()
+	store := setupTestStore(t)
+	defer store.Close()
+
+	embedding := dummyEmbedding(8)
+	assertNoError(t, store.AddNote(ctx, newTestNote("emb1", testProjectID, testGroupID, "Note"), embedding))
+
+	got, err := store.GetEmbedding(ctx, "emb1")
+	assertNoError(t, err)
+	if len(got) != len(embedding) {
+		t.Fatalf("Expected %d dims, got %d", len(embedding), len(got))
+	}
+	for i := range embedding {
+		if got[i] != embedding[i] {
+			t.Fatalf("embedding[%d] = %f, want %f", i, got[i], embedding[i])
+		}
+	}
+
+	_, err = store.GetEmbedding(ctx, "non-existent")
+	assertErrorIs(t, err, ErrNotFound)
+}
+
+// TestChromaStore_ListGlobals はプロジェクト内の全グローバル設定取得をテスト
+func TestChromaStore_ListGlobals(t *testing.T) {
+	ctx := setupTestContext()
+	store := setupTestStore(t)
+	defer store.Close()
+
+	assertNoError(t, store.UpsertGlobal(ctx, &model.GlobalConfig{ID: "g2", ProjectID: testProjectID, Key: "global.b", Value: "b"}))
+	assertNoError(t, store.UpsertGlobal(ctx, &model.GlobalConfig{ID: "g1", ProjectID: testProjectID, Key: "global.a", Value: "a"}))
+	assertNoError(t, store.UpsertGlobal(ctx, &model.GlobalConfig{ID: "g3", ProjectID: "/other/project", Key: "global.a", Value: "x"}))
+
+	configs, err := store.ListGlobals(ctx, testProjectID)
+	assertNoError(t, err)
+
+	if len(configs) != 2 {
+		t.Fatalf("Expected 2 configs, got %d", len(configs))
+	}
+	if configs[0].Key != "global.a" || configs[1].Key != "global.b" {
+		t.Errorf("Expected keys in ascending order, got [%s %s]", configs[0].Key, configs[1].Key)
+	}
+}
+
+// TestChromaStore_ListProjects はプロジェクト一覧の取得をテスト
+func TestChromaStore_ListProjects(t *testing.T) {
+	ctx := setupTestContext()
+	store := setupTestStore(t)
+	defer store.Close()
+
+	embedding := dummyEmbedding(8)
+	assertNoError(t, store.AddNote(ctx, newTestNote("n1", "/b/project", testGroupID, "Note 1"), embedding))
+	assertNoError(t, store.AddNote(ctx, newTestNote("n2", "/b/project", testGroupID, "Note 2"), embedding))
+	assertNoError(t, store.UpsertGlobal(ctx, &model.GlobalConfig{ID: "g1", ProjectID: "/a/project", Key: "global.a", Value: "a"}))
+	assertNoError(t, store.AddGroup(ctx, &model.Group{ID: "grp1", ProjectID: "/c/project", GroupKey: "feature", Title: "Feature", CreatedAt: time.Now(), UpdatedAt: time.Now()}))
+
+	projects, err := store.ListProjects(ctx)
+	assertNoError(t, err)
+
+	want := []ProjectSummary{{"/a/project", 0}, {"/b/project", 2}, {"/c/project", 0}}
+	if !reflect.DeepEqual(projects, want) {
+		t.Errorf("Expected %v, got %v", want, projects)
+	}
+}
+
 // Helper functions
 
 // setupTestStore はテスト用のChromaStoreを初期化
@@ -0,0 +1,310 @@
+package store
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+)
+
+// EncryptionKey はノートの暗号化の鍵（AES-256）
+type EncryptionKey struct {
+	ID  string // 暗号文に記録する鍵のID（":"は使えない）
+	Key []byte // 32バイト
+}
+
+// encryptedPrefix は暗号化した値の接頭辞（"mcpenc:v1:<鍵ID>:<base64(nonce+暗号文)>"）
+const encryptedPrefix = "mcpenc:v1:"
+
+// encryptedMetadataKey は暗号化したmetadata（JSON）を格納するmetadataのキー
+const encryptedMetadataKey = "_encrypted"
+
+// ErrUnknownEncryptionKey はノートを暗号化した鍵が設定にない（ローテーションで古い鍵を外した）ことを示す
+var ErrUnknownEncryptionKey = errors.New("unknown encryption key")
+
+// encryptedStore はノートの本文・タイトル・metadataを暗号化して保存し、読み出し時に復号するStore
+// 暗号化していない値（暗号化を有効にする前のノート）はそのまま返す
+// 暗号文はノートのIDと項目名に結び付けるため、別のノート・項目に移しても復号できない
+type encryptedStore struct {
+	Store
+	*noteCipher
+}
+
+// noteCipher はノートの本文・タイトル・metadataを暗号化・復号する（encryptedStoreとColdStoreで共有する）
+type noteCipher struct {
+	keyID string                 // 暗号化に使う鍵のID（keysの先頭）
+	aeads map[string]cipher.AEAD // key: 鍵のID（復号用）
+}
+
+// NewEncryptedStore はkeysの先頭の鍵でノートを暗号化するStoreを返す（keysが空ならsをそのまま返す）
+// 残りの鍵は、以前の鍵で暗号化したノートの復号に使う
+func NewEncryptedStore(s Store, keys []EncryptionKey) (Store, error) {
+	if len(keys) == 0 {
+		return s, nil
+	}
+	c, err := newNoteCipher(keys)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptedStore{Store: s, noteCipher: c}, nil
+}
+
+// newNoteCipher はkeysの先頭の鍵で暗号化するnoteCipherを返す（keysは空でないこと）
+func newNoteCipher(keys []EncryptionKey) (*noteCipher, error) {
+	c := &noteCipher{keyID: keys[0].ID, aeads: make(map[string]cipher.AEAD, len(keys))}
+	for _, k := range keys {
+		if k.ID == "" || strings.Contains(k.ID, ":") {
+			return nil, fmt.Errorf("invalid encryption key id %q", k.ID)
+		}
+		if len(k.Key) != 32 {
+			return nil, fmt.Errorf("encryption key %s must be 32 bytes, got %d", k.ID, len(k.Key))
+		}
+		block, err := aes.NewCipher(k.Key)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %s: %w", k.ID, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %s: %w", k.ID, err)
+		}
+		c.aeads[k.ID] = aead
+	}
+	return c, nil
+}
+
+// additionalData は暗号文を結び付けるノートのIDと項目名
+func additionalData(id, field string) []byte {
+	return []byte(id + "\x00" + field)
+}
+
+// seal はノートidのfieldの値を現在の鍵で暗号化する
+func (s *noteCipher) seal(id, field, plaintext string) (string, error) {
+	aead := s.aeads[s.keyID]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), additionalData(id, field))
+	return encryptedPrefix + s.keyID + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// open はsealで暗号化した値を復号する（暗号化していない値はそのまま返す）
+func (s *noteCipher) open(id, field, value string) (string, error) {
+	rest, ok := strings.CutPrefix(value, encryptedPrefix)
+	if !ok {
+		return value, nil
+	}
+	keyID, data, _ := strings.Cut(rest, ":")
+	aead, ok := s.aeads[keyID]
+	if !ok {
+		return "", fmt.Errorf("%w %q (%s of note %s)", ErrUnknownEncryptionKey, keyID, field, id)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(data)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("failed to decrypt %s of note %s: malformed ciphertext", field, id)
+	}
+	n := aead.NonceSize()
+	plaintext, err := aead.Open(nil, sealed[:n], sealed[n:], additionalData(id, field))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt %s of note %s: %w", field, id, err)
+	}
+	return string(plaintext), nil
+}
+
+// encryptNote はnoteの本文・タイトル・metadataを暗号化したコピーを返す（noteは変更しない）
+func (s *noteCipher) encryptNote(note *model.Note) (*model.Note, error) {
+	enc := *note
+	var err error
+	if enc.Text, err = s.seal(note.ID, "text", note.Text); err != nil {
+		return nil, err
+	}
+	if note.Title != nil {
+		title, err := s.seal(note.ID, "title", *note.Title)
+		if err != nil {
+			return nil, err
+		}
+		enc.Title = &title
+	}
+	if note.Metadata != nil {
+		data, err := json.Marshal(note.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+		}
+		metadata, err := s.seal(note.ID, "metadata", string(data))
+		if err != nil {
+			return nil, err
+		}
+		enc.Metadata = map[string]any{encryptedMetadataKey: metadata}
+	}
+	return &enc, nil
+}
+
+// decryptNote はストアから取得したnoteを復号する（noteを書き換える）
+func (s *noteCipher) decryptNote(note *model.Note) error {
+	var err error
+	if note.Text, err = s.open(note.ID, "text", note.Text); err != nil {
+		return err
+	}
+	if note.Title != nil {
+		title, err := s.open(note.ID, "title", *note.Title)
+		if err != nil {
+			return err
+		}
+		note.Title = &title
+	}
+	if sealed, ok := note.Metadata[encryptedMetadataKey].(string); ok && len(note.Metadata) == 1 {
+		data, err := s.open(note.ID, "metadata", sealed)
+		if err != nil {
+			return err
+		}
+		var metadata map[string]any
+		if err := json.Unmarshal([]byte(data), &metadata); err != nil {
+			return fmt.Errorf("failed to decrypt metadata of note %s: %w", note.ID, err)
+		}
+		note.Metadata = metadata
+	}
+	return nil
+}
+
+// decryptNotes はnotesをすべて復号する
+func (s *noteCipher) decryptNotes(notes []*model.Note) error {
+	for _, note := range notes {
+		if err := s.decryptNote(note); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// write はnoteを暗号化したコピーをwriteFnで書き込み、Storeが設定したcreatedAt・updatedAtをnoteに戻す
+func (s *encryptedStore) write(note *model.Note, writeFn func(*model.Note) error) error {
+	enc, err := s.encryptNote(note)
+	if err != nil {
+		return err
+	}
+	if err := writeFn(enc); err != nil {
+		return err
+	}
+	note.CreatedAt, note.UpdatedAt = enc.CreatedAt, enc.UpdatedAt
+	return nil
+}
+
+func (s *encryptedStore) AddNote(ctx context.Context, note *model.Note, embedding []float32) error {
+	return s.write(note, func(enc *model.Note) error { return s.Store.AddNote(ctx, enc, embedding) })
+}
+
+func (s *encryptedStore) Update(ctx context.Context, note *model.Note, embedding []float32) error {
+	return s.write(note, func(enc *model.Note) error { return s.Store.Update(ctx, enc, embedding) })
+}
+
+// updateExisting はラップしているStoreが対応していれば、存在確認を省いて更新する（cachedStore用）
+func (s *encryptedStore) updateExisting(ctx context.Context, note *model.Note, embedding []float32) error {
+	w, ok := s.Store.(existingNoteWriter)
+	if !ok {
+		return s.Update(ctx, note, embedding)
+	}
+	return s.write(note, func(enc *model.Note) error { return w.updateExisting(ctx, enc, embedding) })
+}
+
+// deleteExisting はラップしているStoreが対応していれば、存在確認を省いて削除する（cachedStore用）
+func (s *encryptedStore) deleteExisting(ctx context.Context, id string) error {
+	if w, ok := s.Store.(existingNoteWriter); ok {
+		return w.deleteExisting(ctx, id)
+	}
+	return s.Store.Delete(ctx, id)
+}
+
+func (s *encryptedStore) Get(ctx context.Context, id string) (*model.Note, error) {
+	note, err := s.Store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.decryptNote(note); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+func (s *encryptedStore) Search(ctx context.Context, embedding []float32, opts SearchOptions) ([]SearchResult, error) {
+	results, err := s.Store.Search(ctx, embedding, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range results {
+		if err := s.decryptNote(r.Note); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+func (s *encryptedStore) ListRecent(ctx context.Context, opts ListOptions) ([]*model.Note, error) {
+	notes, err := s.Store.ListRecent(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.decryptNotes(notes); err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+func (s *encryptedStore) ListStaleNotes(ctx context.Context, projectID string, before time.Time) ([]*model.Note, error) {
+	notes, err := s.Store.ListStaleNotes(ctx, projectID, before)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.decryptNotes(notes); err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+func (s *encryptedStore) ListNotes(ctx context.Context, projectID string) ([]*model.Note, error) {
+	notes, err := s.Store.ListNotes(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.decryptNotes(notes); err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+// ScanNotes はノートを1件ずつ復号する。復号できないノートは暗号化されたままErrを付けて渡す
+func (s *encryptedStore) ScanNotes(ctx context.Context, fn func(RawNote) error) error {
+	r, ok := s.Store.(RawScanner)
+	if !ok {
+		return ErrNotScannable
+	}
+	return r.ScanNotes(ctx, func(raw RawNote) error {
+		if raw.Note != nil && raw.Err == nil {
+			dec := *raw.Note
+			if err := s.decryptNote(&dec); err != nil {
+				raw.Err = err
+			} else {
+				raw.Note = &dec
+			}
+		}
+		return fn(raw)
+	})
+}
+
+// WithTx はトランザクション内のStoreも暗号化する
+func (s *encryptedStore) WithTx(ctx context.Context, fn func(tx Store) error) error {
+	t, ok := s.Store.(Transactional)
+	if !ok {
+		return ErrNotTransactional
+	}
+	return t.WithTx(ctx, func(tx Store) error {
+		return fn(&encryptedStore{Store: tx, noteCipher: s.noteCipher})
+	})
+}
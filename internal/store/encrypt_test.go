@@ -0,0 +1,188 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var (
+	testKeyA = EncryptionKey{ID: "a", Key: bytes.Repeat([]byte{1}, 32)}
+	testKeyB = EncryptionKey{ID: "b", Key: bytes.Repeat([]byte{2}, 32)}
+)
+
+// newEncryptedTestStore はbackendをkeysで暗号化するStoreを返す
+func newEncryptedTestStore(t *testing.T, backend Store, keys ...EncryptionKey) Store {
+	t.Helper()
+	st, err := NewEncryptedStore(backend, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return st
+}
+
+// TestEncryptedStore_RoundTrip はバックエンドには暗号文だけを保存し、読み出し時に復号することをテスト
+func TestEncryptedStore_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	backend := NewMemoryStore()
+	if err := backend.Initialize(ctx, "openai:test:3"); err != nil {
+		t.Fatal(err)
+	}
+	st := newEncryptedTestStore(t, backend, testKeyA)
+
+	note := newTestNoteAllFields("note-1", "/test/project", "global")
+	if err := st.AddNote(ctx, note, []float32{1, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+	if note.Text != "This is a test note with all fields" || note.UpdatedAt == nil {
+		t.Errorf("expected the plaintext to be kept and updatedAt to be set, got %q, %v", note.Text, note.UpdatedAt)
+	}
+
+	stored, err := backend.Get(ctx, "note-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(stored.Text, "mcpenc:v1:a:") || !strings.HasPrefix(*stored.Title, "mcpenc:v1:a:") {
+		t.Errorf("expected ciphertext in the backend, got %q, %q", stored.Text, *stored.Title)
+	}
+	if _, ok := stored.Metadata["_encrypted"]; !ok || len(stored.Metadata) != 1 {
+		t.Errorf("expected encrypted metadata in the backend, got %v", stored.Metadata)
+	}
+	if stored.Tags[0] != "tag1" || *stored.Source != "test-source" {
+		t.Errorf("expected tags and source to be stored as is, got %v, %v", stored.Tags, *stored.Source)
+	}
+
+	got, err := st.Get(ctx, "note-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Text != note.Text || *got.Title != "Test Note Title" || got.Metadata["key1"] != "value1" || got.Metadata["nested"].(map[string]any)["inner"] != "data" {
+		t.Errorf("unexpected decrypted note: %+v", got)
+	}
+
+	results, err := st.Search(ctx, []float32{1, 0, 0}, SearchOptions{ProjectID: "/test/project", TopK: 5})
+	if err != nil || len(results) != 1 || results[0].Note.Text != note.Text {
+		t.Errorf("unexpected search results: %+v, %v", results, err)
+	}
+	notes, err := st.ListRecent(ctx, ListOptions{ProjectID: "/test/project", Limit: 10})
+	if err != nil || len(notes) != 1 || *notes[0].Title != "Test Note Title" {
+		t.Errorf("unexpected list: %+v, %v", notes, err)
+	}
+	notes, err = st.ListNotes(ctx, "/test/project")
+	if err != nil || len(notes) != 1 || notes[0].Text != note.Text {
+		t.Errorf("unexpected notes: %+v, %v", notes, err)
+	}
+}
+
+// TestEncryptedStore_Plaintext は暗号化を有効にする前のノートをそのまま読み出せることをテスト
+func TestEncryptedStore_Plaintext(t *testing.T) {
+	ctx := context.Background()
+	backend := NewMemoryStore()
+	if err := backend.Initialize(ctx, "openai:test:3"); err != nil {
+		t.Fatal(err)
+	}
+	note := newTestNoteWithTitle("note-1", "/test/project", "global", "title", "plain text")
+	note.Metadata = map[string]any{"_encrypted": true}
+	if err := backend.AddNote(ctx, note, []float32{1, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := newEncryptedTestStore(t, backend, testKeyA).Get(ctx, "note-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Text != "plain text" || *got.Title != "title" || got.Metadata["_encrypted"] != true {
+		t.Errorf("unexpected note: %+v", got)
+	}
+}
+
+// TestEncryptedStore_Rotation は以前の鍵で暗号化したノートを復号でき、新しい書き込みは先頭の鍵を使うことをテスト
+func TestEncryptedStore_Rotation(t *testing.T) {
+	ctx := context.Background()
+	backend := NewMemoryStore()
+	if err := backend.Initialize(ctx, "openai:test:3"); err != nil {
+		t.Fatal(err)
+	}
+	if err := newEncryptedTestStore(t, backend, testKeyA).AddNote(ctx, newTestNote("note-1", "/test/project", "global", "old"), []float32{1, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+
+	rotated := newEncryptedTestStore(t, backend, testKeyB, testKeyA)
+	note, err := rotated.Get(ctx, "note-1")
+	if err != nil || note.Text != "old" {
+		t.Fatalf("expected the old key to decrypt, got %+v, %v", note, err)
+	}
+	note.Text = "new"
+	if err := rotated.Update(ctx, note, []float32{1, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+	if stored, _ := backend.Get(ctx, "note-1"); !strings.HasPrefix(stored.Text, "mcpenc:v1:b:") {
+		t.Errorf("expected the note to be re-encrypted with key b, got %q", stored.Text)
+	}
+
+	if _, err := newEncryptedTestStore(t, backend, testKeyA).Get(ctx, "note-1"); !errors.Is(err, ErrUnknownEncryptionKey) {
+		t.Errorf("expected ErrUnknownEncryptionKey without key b, got %v", err)
+	}
+}
+
+// TestEncryptedStore_BoundToNote は暗号文を別のノートに移すと復号できないことをテスト
+func TestEncryptedStore_BoundToNote(t *testing.T) {
+	ctx := context.Background()
+	backend := NewMemoryStore()
+	if err := backend.Initialize(ctx, "openai:test:3"); err != nil {
+		t.Fatal(err)
+	}
+	st := newEncryptedTestStore(t, backend, testKeyA)
+	if err := st.AddNote(ctx, newTestNote("note-1", "/test/project", "global", "secret"), []float32{1, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := backend.Get(ctx, "note-1")
+	if err := backend.AddNote(ctx, newTestNote("note-2", "/test/project", "global", stored.Text), []float32{1, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.Get(ctx, "note-2"); err == nil || !strings.Contains(err.Error(), "failed to decrypt text of note note-2") {
+		t.Errorf("expected a decryption error, got %v", err)
+	}
+}
+
+// TestEncryptedStore_WithTx はトランザクション内の書き込みも暗号化することをテスト
+func TestEncryptedStore_WithTx(t *testing.T) {
+	ctx := context.Background()
+	backend, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backend.Close()
+	if err := backend.Initialize(ctx, "openai:test:3"); err != nil {
+		t.Fatal(err)
+	}
+	st := newEncryptedTestStore(t, backend, testKeyA)
+
+	atomic, err := WithTx(ctx, st, func(tx Store) error {
+		return tx.AddNote(ctx, newTestNote("note-1", "/test/project", "global", "in tx"), []float32{1, 0, 0})
+	})
+	if err != nil || !atomic {
+		t.Fatalf("WithTx() = %v, %v", atomic, err)
+	}
+	if stored, _ := backend.Get(ctx, "note-1"); !strings.HasPrefix(stored.Text, "mcpenc:v1:a:") {
+		t.Errorf("expected ciphertext in the backend, got %q", stored.Text)
+	}
+	if got, err := st.Get(ctx, "note-1"); err != nil || got.Text != "in tx" {
+		t.Errorf("unexpected note: %+v, %v", got, err)
+	}
+}
+
+func TestNewEncryptedStore_InvalidKey(t *testing.T) {
+	backend := NewMemoryStore()
+	if st, err := NewEncryptedStore(backend, nil); err != nil || st != Store(backend) {
+		t.Errorf("expected the store itself without keys, got %v, %v", st, err)
+	}
+	for _, k := range []EncryptionKey{{ID: "short", Key: []byte("0123")}, {ID: "a:b", Key: testKeyA.Key}, {Key: testKeyA.Key}} {
+		if _, err := NewEncryptedStore(backend, []EncryptionKey{k}); err == nil {
+			t.Errorf("expected error for %+v", k)
+		}
+	}
+}
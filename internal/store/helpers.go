@@ -1,7 +1,12 @@
 package store
 
 import (
-	"math"
+	"slices"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
 )
 
 // CosineSimilarity はコサイン類似度を計算する（実際はcosine distanceを返す: 0=同一、2=正反対）
@@ -9,28 +14,7 @@ func CosineSimilarity(a, b []float32) float64 {
 	if len(a) != len(b) {
 		return 2.0
 	}
-
-	var dotProduct, normA, normB float64
-	for i := range a {
-		dotProduct += float64(a[i]) * float64(b[i])
-		normA += float64(a[i]) * float64(a[i])
-		normB += float64(b[i]) * float64(b[i])
-	}
-
-	normA = math.Sqrt(normA)
-	normB = math.Sqrt(normB)
-
-	if normA == 0 || normB == 0 {
-		return 2.0
-	}
-
-	// cosine similarity: -1 to 1
-	similarity := dotProduct / (normA * normB)
-
-	// cosine distance: 0 to 2
-	distance := 1.0 - similarity
-
-	return distance
+	return cosineDistance(dot(a, b), vectorNorm(a), vectorNorm(b))
 }
 
 // ContainsAllTags はtargets内の全てのタグがtagsに含まれているかをチェックする（AND検索）
@@ -48,3 +32,67 @@ func ContainsAllTags(tags []string, targets []string) bool {
 
 	return true
 }
+
+// MatchesGroup はgroupIDがGroupID（nilは無条件）とGroupIDs（空は無条件）の両方を満たすかをチェックする
+func MatchesGroup(groupID string, want *string, wantAny []string) bool {
+	if want != nil && groupID != *want {
+		return false
+	}
+	return len(wantAny) == 0 || slices.Contains(wantAny, groupID)
+}
+
+// projectSummaries はprojectIDごとのノート件数をprojectID昇順のProjectSummaryに変換する
+func projectSummaries(counts map[string]int) []ProjectSummary {
+	summaries := make([]ProjectSummary, 0, len(counts))
+	for projectID, n := range counts {
+		summaries = append(summaries, ProjectSummary{ProjectID: projectID, NoteCount: n})
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].ProjectID < summaries[j].ProjectID
+	})
+	return summaries
+}
+
+// addGroupStats はノート1件分をgroupIdの統計に加える（集計をGoで行うストア用）
+func addGroupStats(stats map[string]GroupStats, note *model.Note) {
+	st := stats[note.GroupID]
+	st.NoteCount++
+	st.TextLength += utf8.RuneCountInString(note.Text)
+	if note.CreatedAt != nil && isLaterTimestamp(*note.CreatedAt, st.LastNoteAt) {
+		st.LastNoteAt = *note.CreatedAt
+	}
+	stats[note.GroupID] = st
+}
+
+// lastAccessTime はノートの最終アクセスの時刻（accessedAt・updatedAt・createdAtのうち最も新しいもの）を返す
+// どれも解析できない場合はfalse（ListStaleNotesの対象にしない）
+func lastAccessTime(note *model.Note, accessedAt string) (time.Time, bool) {
+	values := []string{accessedAt}
+	if note.UpdatedAt != nil {
+		values = append(values, *note.UpdatedAt)
+	}
+	if note.CreatedAt != nil {
+		values = append(values, *note.CreatedAt)
+	}
+	var last time.Time
+	found := false
+	for _, value := range values {
+		if t, err := time.Parse(time.RFC3339, value); err == nil && (!found || t.After(last)) {
+			last, found = t, true
+		}
+	}
+	return last, found
+}
+
+// isLaterTimestamp はaがbより後の時刻かをチェックする（bが空なら常にtrue、解析できなければ文字列で比較）
+func isLaterTimestamp(a, b string) bool {
+	if b == "" {
+		return true
+	}
+	ta, errA := time.Parse(time.RFC3339, a)
+	tb, errB := time.Parse(time.RFC3339, b)
+	if errA != nil || errB != nil {
+		return a > b
+	}
+	return ta.After(tb)
+}
@@ -0,0 +1,186 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+)
+
+// ジャーナルの操作
+const (
+	journalNotePut      = "note.put"
+	journalNoteDelete   = "note.delete"
+	journalNoteTouch    = "note.touch"
+	journalGlobalPut    = "global.put"
+	journalGlobalDelete = "global.delete"
+	journalGroupPut     = "group.put"
+	journalGroupDelete  = "group.delete"
+)
+
+// journalRecord は変更ジャーナルの1行（putは変更後の値全体、deleteはIDのみ、touchはIDと最終アクセス）
+type journalRecord struct {
+	Op         string              `json:"op"`
+	ID         string              `json:"id,omitempty"`
+	AccessedAt string              `json:"accessedAt,omitempty"`
+	Note       *model.Note         `json:"note,omitempty"`
+	Embedding  []float32           `json:"embedding,omitempty"`
+	Global     *model.GlobalConfig `json:"global,omitempty"`
+	Group      *model.Group        `json:"group,omitempty"`
+}
+
+// journal は追記専用の変更ジャーナル（JSONL）
+// 1回の変更の行はまとめて書き込み、応答を返す前にfsyncする
+type journal struct {
+	f    *os.File
+	path string
+}
+
+// openJournals はプロセス内で開いているジャーナルの数（パスごと）
+// 設定の再読み込みで同じnamespaceのストアが一時的に2つ開くため、ほかに開いていれば書き直さない
+var (
+	openJournalsMu sync.Mutex
+	openJournals   = make(map[string]int)
+)
+
+// journalPath はnamespaceのジャーナルのパスを返す（ファイル名に使えない文字は"_"にする）
+func journalPath(dir, namespace string) string {
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '.' {
+			return r
+		}
+		return '_'
+	}, namespace)
+	return filepath.Join(dir, name+".jsonl")
+}
+
+// openJournal はpathの記録をapplyで再生してから追記用に開く
+// プロセス内でほかに開いていなければ、再生後の状態（snapshotの記録）だけの内容に書き直して肥大化を防ぐ
+func openJournal(path string, apply func(journalRecord), snapshot func() []journalRecord) (*journal, error) {
+	openJournalsMu.Lock()
+	defer openJournalsMu.Unlock()
+
+	if err := replayJournal(path, apply); err != nil {
+		return nil, err
+	}
+	if openJournals[path] == 0 {
+		if err := writeJournal(path, snapshot()); err != nil {
+			return nil, err
+		}
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	openJournals[path]++
+	return &journal{f: f, path: path}, nil
+}
+
+// append はrecordsを追記してfsyncする
+func (j *journal) append(records ...journalRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	if err := encodeJournal(&buf, records); err != nil {
+		return err
+	}
+	if _, err := j.f.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	if err := j.f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync journal: %w", err)
+	}
+	return nil
+}
+
+// close はジャーナルを閉じる
+func (j *journal) close() error {
+	openJournalsMu.Lock()
+	defer openJournalsMu.Unlock()
+	if openJournals[j.path]--; openJournals[j.path] <= 0 {
+		delete(openJournals, j.path)
+	}
+	return j.f.Close()
+}
+
+// encodeJournal はrecordsをJSONLでwに書き込む
+func encodeJournal(w io.Writer, records []journalRecord) error {
+	enc := json.NewEncoder(w)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return fmt.Errorf("failed to encode journal record: %w", err)
+		}
+	}
+	return nil
+}
+
+// replayJournal はpathの記録を先頭から順にapplyに渡す（ファイルがなければ何もしない）
+// 書き込み中のクラッシュで途切れた最後の行は警告して捨てる。途中の行が壊れている場合はエラー
+func replayJournal(path string, apply func(journalRecord)) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	// 記録は必ず改行で終わるため、改行のない最後の行は書き込みの途中で途切れたもの
+	terminated := bytes.HasSuffix(data, []byte("\n"))
+	lines := bytes.Split(bytes.TrimSuffix(data, []byte("\n")), []byte("\n"))
+	for i, line := range lines {
+		if len(line) == 0 {
+			continue
+		}
+		var r journalRecord
+		if err := json.Unmarshal(line, &r); err != nil {
+			if i == len(lines)-1 && !terminated {
+				slog.Warn("journal: discarding a truncated last record", "path", path, "line", i+1)
+				return nil
+			}
+			return fmt.Errorf("journal %s: line %d: %w", path, i+1, err)
+		}
+		apply(r)
+	}
+	return nil
+}
+
+// writeJournal はrecordsだけを含むジャーナルでpathを置き換える（一時ファイルに書いてからrename）
+func writeJournal(path string, records []journalRecord) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create journal: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	if err := encodeJournal(w, records); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync journal: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace journal: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+)
+
+// TestMemoryStore_Journal はクローズせずに終了したストアの変更が次のInitializeで復元されることをテスト
+func TestMemoryStore_Journal(t *testing.T) {
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "journal")
+	const namespace = "openai:test:3"
+
+	st := NewMemoryStore(WithMemoryJournal(dir))
+	if err := st.Initialize(ctx, namespace); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	for _, note := range []*model.Note{
+		newTestNote("note-1", "/test/project", "feature-1", "first"),
+		newTestNote("note-2", "/test/project", "feature-1", "second"),
+		newTestNote("note-3", "/test/project", "feature-2", "third"),
+	} {
+		if err := st.AddNote(ctx, note, []float32{1, 0, 0}); err != nil {
+			t.Fatalf("AddNote failed: %v", err)
+		}
+	}
+	if err := st.Delete(ctx, "note-3"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	now := time.Now().UTC()
+	if err := st.AddGroup(ctx, &model.Group{ID: "group-1", ProjectID: "/test/project", GroupKey: "feature-1", Title: "Feature 1", CreatedAt: now, UpdatedAt: now}); err != nil {
+		t.Fatalf("AddGroup failed: %v", err)
+	}
+	if n, err := st.RenameGroup(ctx, "group-1", "feature-x"); err != nil || n != 2 {
+		t.Fatalf("RenameGroup = %d, %v", n, err)
+	}
+	if err := st.UpsertGlobal(ctx, &model.GlobalConfig{ID: "global-1", ProjectID: "/test/project", Key: "global.editor", Value: "vim"}); err != nil {
+		t.Fatalf("UpsertGlobal failed: %v", err)
+	}
+	if err := st.TouchNotes(ctx, []string{"note-2"}, "2999-01-01T00:00:00Z"); err != nil {
+		t.Fatalf("TouchNotes failed: %v", err)
+	}
+
+	// クラッシュを想定し、stを閉じずに同じdirで開き直す
+	restored := NewMemoryStore(WithMemoryJournal(dir))
+	if err := restored.Initialize(ctx, namespace); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer restored.Close()
+	defer st.Close()
+
+	notes, err := restored.ListNotes(ctx, "/test/project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 2 || notes[0].GroupID != "feature-x" || notes[1].GroupID != "feature-x" {
+		t.Errorf("unexpected notes: %+v", notes)
+	}
+	if stale, _ := restored.ListStaleNotes(ctx, "/test/project", time.Now().Add(time.Hour)); len(stale) != 1 || stale[0].ID != "note-1" {
+		t.Errorf("expected the touch of note-2 to be restored, got %+v", stale)
+	}
+	if emb, err := restored.GetEmbedding(ctx, "note-1"); err != nil || len(emb) != 3 {
+		t.Errorf("GetEmbedding = %v, %v", emb, err)
+	}
+	if group, err := restored.GetGroup(ctx, "group-1"); err != nil || group.GroupKey != "feature-x" {
+		t.Errorf("GetGroup = %+v, %v", group, err)
+	}
+	if config, ok, err := restored.GetGlobal(ctx, "/test/project", "global.editor"); err != nil || !ok || config.Value != "vim" {
+		t.Errorf("GetGlobal = %+v, %v, %v", config, ok, err)
+	}
+
+	// 別のnamespaceは別のジャーナル
+	other := NewMemoryStore(WithMemoryJournal(dir))
+	if err := other.Initialize(ctx, "openai:other:3"); err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	if notes, _ := other.ListNotes(ctx, "/test/project"); len(notes) != 0 {
+		t.Errorf("expected no notes in another namespace, got %d", len(notes))
+	}
+}
+
+// TestMemoryStore_Journal_Truncated は途切れた最後の行を捨て、途中の壊れた行はエラーにすることをテスト
+func TestMemoryStore_Journal_Truncated(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	const namespace = "openai:test:3"
+
+	st := NewMemoryStore(WithMemoryJournal(dir))
+	if err := st.Initialize(ctx, namespace); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.AddNote(ctx, newTestNote("note-1", "/test/project", "global", "kept"), []float32{1, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	path := journalPath(dir, namespace)
+	if filepath.Base(path) != "openai_test_3.jsonl" {
+		t.Errorf("unexpected journal path: %s", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	torn := string(data) + `{"op":"note.put","note":{"id":"note-2"`
+	if err := os.WriteFile(path, []byte(torn), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := NewMemoryStore(WithMemoryJournal(dir))
+	if err := restored.Initialize(ctx, namespace); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if _, err := restored.Get(ctx, "note-1"); err != nil {
+		t.Errorf("expected note-1 to be restored: %v", err)
+	}
+	if _, err := restored.Get(ctx, "note-2"); err != ErrNotFound {
+		t.Errorf("expected the truncated note to be discarded, got %v", err)
+	}
+	if err := restored.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 開き直した際に現在の状態だけに書き直されている
+	data, err = os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(data), "\n") != 1 || !strings.HasSuffix(string(data), "\n") {
+		t.Errorf("expected a compacted journal with one record, got %q", data)
+	}
+
+	if err := os.WriteFile(path, []byte("garbage\n"+string(data)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewMemoryStore(WithMemoryJournal(dir)).Initialize(ctx, namespace); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("expected an error for a corrupted record, got %v", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
 	"sort"
 	"sync"
 	"time"
@@ -12,34 +13,75 @@ import (
 )
 
 // MemoryStore はテスト用のインメモリStore実装
+// WithMemoryJournalを指定すると、変更をジャーナルに記録して次回のInitializeで復元する
+//
+// 変更はwriteMuで直列化し、muはmapを読み書きする間だけ保持する（ジャーナルのfsync中も読み取りを止めない）
+// 登録したnoteEntry・グローバル設定・グループは変更せず差し替えるため、
+// 読み取りはロック中に参照だけ集め、スコア計算やコピーはロックの外で行う
 type MemoryStore struct {
 	mu            sync.RWMutex
-	notes         map[string]*noteEntry          // key: note.ID
-	globalConfigs map[string]*model.GlobalConfig // key: projectID + key
-	groups        map[string]*model.Group        // key: group.ID
+	writeMu       sync.Mutex
+	notes         map[string]*noteEntry            // key: note.ID
+	projects      map[string]map[string]*noteEntry // key: projectID → note.ID（検索・一覧は対象プロジェクトだけ走査する）
+	globalConfigs map[string]*model.GlobalConfig   // key: projectID + key
+	groups        map[string]*model.Group          // key: group.ID
 	initialized   bool
 	namespace     string
+	journalDir    string
+	journal       *journal
+}
+
+// MemoryOption はMemoryStoreのオプション
+type MemoryOption func(*MemoryStore)
+
+// WithMemoryJournal はdir配下にnamespaceごとの変更ジャーナルを書く
+// 変更はジャーナルへの書き込み（fsync）が成功してから反映し、Initializeで再生するため、
+// 応答後にプロセスが落ちても変更は失われない
+func WithMemoryJournal(dir string) MemoryOption {
+	return func(s *MemoryStore) {
+		s.journalDir = dir
+	}
 }
 
 type noteEntry struct {
-	note      *model.Note
-	embedding []float32
+	note       *model.Note
+	embedding  []float32
+	norm       float64 // embeddingのノルム（検索ではノートごとに内積だけ計算する）
+	accessedAt string  // TouchNotesで記録した最終アクセス（なければ空）
 }
 
 // NewMemoryStore はMemoryStoreを作成する
-func NewMemoryStore() *MemoryStore {
-	return &MemoryStore{
+func NewMemoryStore(opts ...MemoryOption) *MemoryStore {
+	s := &MemoryStore{
 		notes:         make(map[string]*noteEntry),
+		projects:      make(map[string]map[string]*noteEntry),
 		globalConfigs: make(map[string]*model.GlobalConfig),
 		groups:        make(map[string]*model.Group),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // Initialize はストアを初期化する
 func (s *MemoryStore) Initialize(ctx context.Context, namespace string) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if s.journalDir != "" && s.journal == nil {
+		if err := os.MkdirAll(s.journalDir, 0700); err != nil {
+			return fmt.Errorf("failed to create journal directory: %w", err)
+		}
+		j, err := openJournal(journalPath(s.journalDir, namespace), s.applyJournal, s.journalSnapshot)
+		if err != nil {
+			return err
+		}
+		s.journal = j
+	}
+
 	s.namespace = namespace
 	s.initialized = true
 	return nil
@@ -47,59 +89,56 @@ func (s *MemoryStore) Initialize(ctx context.Context, namespace string) error {
 
 // Close はストアをクローズする
 func (s *MemoryStore) Close() error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	s.notes = make(map[string]*noteEntry)
+	s.projects = make(map[string]map[string]*noteEntry)
 	s.globalConfigs = make(map[string]*model.GlobalConfig)
 	s.groups = make(map[string]*model.Group)
 	s.initialized = false
+	if s.journal != nil {
+		err := s.journal.close()
+		s.journal = nil
+		return err
+	}
 	return nil
 }
 
 // AddNote はノートを追加する
 func (s *MemoryStore) AddNote(ctx context.Context, note *model.Note, embedding []float32) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
 
 	if !s.initialized {
 		return ErrNotInitialized
 	}
 
+	stampNewNote(note)
+
 	// ディープコピー
-	noteCopy := s.copyNote(note)
+	noteCopy := copyNote(note)
 	embeddingCopy := make([]float32, len(embedding))
 	copy(embeddingCopy, embedding)
 
-	s.notes[note.ID] = &noteEntry{
-		note:      noteCopy,
-		embedding: embeddingCopy,
-	}
-
-	return nil
+	return s.commit(journalRecord{Op: journalNotePut, Note: noteCopy, Embedding: embeddingCopy})
 }
 
 // Get はIDでノートを取得する
 func (s *MemoryStore) Get(ctx context.Context, id string) (*model.Note, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	if !s.initialized {
-		return nil, ErrNotInitialized
+	entry, err := s.entry(id)
+	if err != nil {
+		return nil, err
 	}
-
-	entry, ok := s.notes[id]
-	if !ok {
-		return nil, ErrNotFound
-	}
-
-	return s.copyNote(entry.note), nil
+	return copyNote(entry.note), nil
 }
 
 // Update はノートを更新する
 func (s *MemoryStore) Update(ctx context.Context, note *model.Note, embedding []float32) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
 
 	if !s.initialized {
 		return ErrNotInitialized
@@ -109,23 +148,20 @@ func (s *MemoryStore) Update(ctx context.Context, note *model.Note, embedding []
 		return ErrNotFound
 	}
 
+	stampUpdatedNote(note)
+
 	// ディープコピー
-	noteCopy := s.copyNote(note)
+	noteCopy := copyNote(note)
 	embeddingCopy := make([]float32, len(embedding))
 	copy(embeddingCopy, embedding)
 
-	s.notes[note.ID] = &noteEntry{
-		note:      noteCopy,
-		embedding: embeddingCopy,
-	}
-
-	return nil
+	return s.commit(journalRecord{Op: journalNotePut, Note: noteCopy, Embedding: embeddingCopy})
 }
 
 // Delete はノートを削除する
 func (s *MemoryStore) Delete(ctx context.Context, id string) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
 
 	if !s.initialized {
 		return ErrNotInitialized
@@ -135,125 +171,172 @@ func (s *MemoryStore) Delete(ctx context.Context, id string) error {
 		return ErrNotFound
 	}
 
-	delete(s.notes, id)
-	return nil
+	return s.commit(journalRecord{Op: journalNoteDelete, ID: id})
 }
 
-// Search はベクトル検索を実行する
-func (s *MemoryStore) Search(ctx context.Context, embedding []float32, opts SearchOptions) ([]SearchResult, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+// DeleteNotesByGroup はプロジェクト内のgroupIDのノートを全て削除し、件数を返す
+func (s *MemoryStore) DeleteNotesByGroup(ctx context.Context, projectID, groupID string) (int, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
 
 	if !s.initialized {
-		return nil, ErrNotInitialized
+		return 0, ErrNotInitialized
 	}
 
-	var results []SearchResult
-
-	// 全ノートをスキャン
-	for _, entry := range s.notes {
-		// projectIDフィルタ
-		if entry.note.ProjectID != opts.ProjectID {
-			continue
+	var records []journalRecord
+	for id, entry := range s.projects[projectID] {
+		if entry.note.GroupID == groupID {
+			records = append(records, journalRecord{Op: journalNoteDelete, ID: id})
 		}
+	}
+	if err := s.commit(records...); err != nil {
+		return 0, err
+	}
+	return len(records), nil
+}
 
-		// groupIDフィルタ
-		if opts.GroupID != nil && entry.note.GroupID != *opts.GroupID {
-			continue
-		}
+// MoveNotesToGroup はプロジェクト内のfromGroupIDのノートをtoGroupIDへ移し、件数を返す
+func (s *MemoryStore) MoveNotesToGroup(ctx context.Context, projectID, fromGroupID, toGroupID string) (int, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
 
-		// tagsフィルタ（AND検索）
-		if len(opts.Tags) > 0 {
-			if !ContainsAllTags(entry.note.Tags, opts.Tags) {
-				continue
-			}
+	if !s.initialized {
+		return 0, ErrNotInitialized
+	}
+
+	var records []journalRecord
+	for _, entry := range s.projects[projectID] {
+		if entry.note.GroupID == fromGroupID {
+			note := copyNote(entry.note)
+			note.GroupID = toGroupID
+			stampUpdatedNote(note)
+			records = append(records, journalRecord{Op: journalNotePut, Note: note, Embedding: entry.embedding})
 		}
+	}
+	if err := s.commit(records...); err != nil {
+		return 0, err
+	}
+	return len(records), nil
+}
 
-		// since/untilフィルタ
-		if opts.Since != nil || opts.Until != nil {
-			if entry.note.CreatedAt == nil {
-				continue
-			}
-			createdAt, err := time.Parse(time.RFC3339, *entry.note.CreatedAt)
-			if err != nil {
-				continue
-			}
+// Search はベクトル検索を実行する
+func (s *MemoryStore) Search(ctx context.Context, embedding []float32, opts SearchOptions) ([]SearchResult, error) {
+	entries, err := s.projectEntries(opts.ProjectID)
+	if err != nil {
+		return nil, err
+	}
 
-			// since <= createdAt
-			if opts.Since != nil && createdAt.Before(*opts.Since) {
-				continue
-			}
+	// ノートのコピーはtopKに残ったものだけ作る
+	type scored struct {
+		entry *noteEntry
+		score float64
+	}
+	var candidates []scored
+	queryNorm := vectorNorm(embedding)
 
-			// createdAt < until
-			if opts.Until != nil && !createdAt.Before(*opts.Until) {
-				continue
-			}
+	// プロジェクト内の全ノートをスキャン
+	for _, entry := range entries {
+		if !matchesSearch(entry.note, opts) {
+			continue
 		}
 
 		// コサイン距離を計算してスコアに変換
-		distance := CosineSimilarity(embedding, entry.embedding)
+		distance := 2.0
+		if len(entry.embedding) == len(embedding) {
+			distance = cosineDistance(dot(embedding, entry.embedding), queryNorm, entry.norm)
+		}
 		score := 1.0 - (distance / 2.0) // 0-1に正規化
 
-		results = append(results, SearchResult{
-			Note:  s.copyNote(entry.note),
-			Score: score,
-		})
+		candidates = append(candidates, scored{entry: entry, score: score})
 	}
 
 	// スコア降順でソート
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].Score > results[j].Score
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
 	})
 
 	// TopK制限
-	if opts.TopK > 0 && len(results) > opts.TopK {
-		results = results[:opts.TopK]
+	if opts.TopK > 0 && len(candidates) > opts.TopK {
+		candidates = candidates[:opts.TopK]
 	}
 
+	results := make([]SearchResult, 0, len(candidates))
+	for _, c := range candidates {
+		results = append(results, SearchResult{Note: copyNote(c.entry.note), Score: c.score})
+	}
 	return results, nil
 }
 
+// matchesSearch はノートが検索の絞り込み条件（groupId・tags・since/until）に一致するかをチェックする
+func matchesSearch(note *model.Note, opts SearchOptions) bool {
+	// groupIDフィルタ
+	if !MatchesGroup(note.GroupID, opts.GroupID, opts.GroupIDs) {
+		return false
+	}
+
+	// tagsフィルタ（AND検索）
+	if len(opts.Tags) > 0 && !ContainsAllTags(note.Tags, opts.Tags) {
+		return false
+	}
+
+	// since/untilフィルタ
+	if opts.Since != nil || opts.Until != nil {
+		if note.CreatedAt == nil {
+			return false
+		}
+		createdAt, err := time.Parse(time.RFC3339, *note.CreatedAt)
+		if err != nil {
+			return false
+		}
+
+		// since <= createdAt
+		if opts.Since != nil && createdAt.Before(*opts.Since) {
+			return false
+		}
+
+		// createdAt < until
+		if opts.Until != nil && !createdAt.Before(*opts.Until) {
+			return false
+		}
+	}
+	return true
+}
+
 // ListRecent は最新ノート一覧を取得する
 func (s *MemoryStore) ListRecent(ctx context.Context, opts ListOptions) ([]*model.Note, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	if !s.initialized {
-		return nil, ErrNotInitialized
+	entries, err := s.projectEntries(opts.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	// カーソルの位置を同じ比較で扱えるよう、ソートキーだけを持つノートにする
+	var after *model.Note
+	if opts.After != nil {
+		after = &model.Note{ID: opts.After.ID}
+		if opts.After.Key != "" {
+			after.CreatedAt = &opts.After.Key
+			after.UpdatedAt = &opts.After.Key
+		}
 	}
 
 	var notes []*model.Note
 
-	// 全ノートをスキャン
-	for _, entry := range s.notes {
-		// projectIDフィルタ
-		if entry.note.ProjectID != opts.ProjectID {
+	// プロジェクト内の全ノートをスキャン
+	for _, entry := range entries {
+		if !matchesList(entry.note, opts) {
 			continue
 		}
-
-		// groupIDフィルタ
-		if opts.GroupID != nil && entry.note.GroupID != *opts.GroupID {
+		// カーソルより前（前のページまで）のノートは除く
+		if after != nil && !listsBefore(after, entry.note, opts.SortBy) {
 			continue
 		}
 
-		// tagsフィルタ（AND検索）
-		if len(opts.Tags) > 0 {
-			if !ContainsAllTags(entry.note.Tags, opts.Tags) {
-				continue
-			}
-		}
-
-		notes = append(notes, s.copyNote(entry.note))
+		notes = append(notes, copyNote(entry.note))
 	}
 
-	// createdAt降順でソート
+	// createdAt（SortByUpdatedAtならupdatedAt）降順、同時刻はID降順でソート
 	sort.Slice(notes, func(i, j int) bool {
-		if notes[i].CreatedAt == nil || notes[j].CreatedAt == nil {
-			return false
-		}
-		ti, _ := time.Parse(time.RFC3339, *notes[i].CreatedAt)
-		tj, _ := time.Parse(time.RFC3339, *notes[j].CreatedAt)
-		return ti.After(tj)
+		return listsBefore(notes[i], notes[j], opts.SortBy)
 	})
 
 	// Limit制限
@@ -264,30 +347,201 @@ func (s *MemoryStore) ListRecent(ctx context.Context, opts ListOptions) ([]*mode
 	return notes, nil
 }
 
-// UpsertGlobal はグローバル設定を追加/更新する
-func (s *MemoryStore) UpsertGlobal(ctx context.Context, config *model.GlobalConfig) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// CountNotes は一覧の条件（projectId・groupId・tags）に一致するノートの件数を返す
+func (s *MemoryStore) CountNotes(ctx context.Context, opts ListOptions) (int, error) {
+	entries, err := s.projectEntries(opts.ProjectID)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, entry := range entries {
+		if matchesList(entry.note, opts) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// matchesList はノートがListOptionsのgroupId・tagsの条件に一致するか判定する
+func matchesList(note *model.Note, opts ListOptions) bool {
+	// groupIDフィルタ
+	if !MatchesGroup(note.GroupID, opts.GroupID, opts.GroupIDs) {
+		return false
+	}
+	// tagsフィルタ（AND検索）
+	return len(opts.Tags) == 0 || ContainsAllTags(note.Tags, opts.Tags)
+}
+
+// listsBefore はListRecentの並び（ソートキー降順、同時刻はID降順）でaがbより前か判定する
+// ソートキーのない・解析できないノートは最後に並べる
+func listsBefore(a, b *model.Note, sortBy string) bool {
+	ta, okA := noteSortTime(a, sortBy)
+	tb, okB := noteSortTime(b, sortBy)
+	if okA != okB {
+		return okA
+	}
+	if okA && !ta.Equal(tb) {
+		return ta.After(tb)
+	}
+	return a.ID > b.ID
+}
+
+// TouchNotes はidsのノートの最終アクセスを記録する（ないIDは無視する）
+func (s *MemoryStore) TouchNotes(ctx context.Context, ids []string, accessedAt string) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
 
 	if !s.initialized {
 		return ErrNotInitialized
 	}
 
-	key := s.globalKey(config.ProjectID, config.Key)
+	var records []journalRecord
+	for _, id := range ids {
+		if _, ok := s.notes[id]; ok {
+			records = append(records, journalRecord{Op: journalNoteTouch, ID: id, AccessedAt: accessedAt})
+		}
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	return s.commit(records...)
+}
 
-	// ディープコピー
-	configCopy := &model.GlobalConfig{
-		ID:        config.ID,
-		ProjectID: config.ProjectID,
-		Key:       config.Key,
-		Value:     s.copyValue(config.Value),
-		UpdatedAt: config.UpdatedAt,
+// ListStaleNotes はプロジェクト内で最終アクセスがbeforeより前のノートを取得する（createdAt昇順）
+func (s *MemoryStore) ListStaleNotes(ctx context.Context, projectID string, before time.Time) ([]*model.Note, error) {
+	entries, err := s.projectEntries(projectID)
+	if err != nil {
+		return nil, err
 	}
 
-	s.globalConfigs[key] = configCopy
+	var notes []*model.Note
+	for _, entry := range entries {
+		if t, ok := lastAccessTime(entry.note, entry.accessedAt); ok && t.Before(before) {
+			notes = append(notes, copyNote(entry.note))
+		}
+	}
+	sortByCreatedAt(notes)
+	return notes, nil
+}
+
+// ListNotes はプロジェクト内の全ノートを取得する（createdAt昇順）
+func (s *MemoryStore) ListNotes(ctx context.Context, projectID string) ([]*model.Note, error) {
+	entries, err := s.projectEntries(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	var notes []*model.Note
+	for _, entry := range entries {
+		notes = append(notes, copyNote(entry.note))
+	}
+	sortByCreatedAt(notes)
+	return notes, nil
+}
+
+// ScanNotes は全プロジェクトのノートをID順に走査する（走査する前の時点のノートを渡す）
+func (s *MemoryStore) ScanNotes(ctx context.Context, fn func(RawNote) error) error {
+	s.mu.RLock()
+	if !s.initialized {
+		s.mu.RUnlock()
+		return ErrNotInitialized
+	}
+	raws := make([]RawNote, 0, len(s.notes))
+	for _, entry := range s.notes {
+		embedding := make([]float32, len(entry.embedding))
+		copy(embedding, entry.embedding)
+		raws = append(raws, RawNote{ID: entry.note.ID, Note: copyNote(entry.note), Embedding: embedding})
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(raws, func(i, j int) bool { return raws[i].ID < raws[j].ID })
+	for _, raw := range raws {
+		if err := fn(raw); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// sortByCreatedAt はnotesをcreatedAt昇順に並べる（同時刻はID順）
+func sortByCreatedAt(notes []*model.Note) {
+	sort.Slice(notes, func(i, j int) bool {
+		var ti, tj time.Time
+		if notes[i].CreatedAt != nil {
+			ti, _ = time.Parse(time.RFC3339, *notes[i].CreatedAt)
+		}
+		if notes[j].CreatedAt != nil {
+			tj, _ = time.Parse(time.RFC3339, *notes[j].CreatedAt)
+		}
+		if ti.Equal(tj) {
+			return notes[i].ID < notes[j].ID
+		}
+		return ti.Before(tj)
+	})
+}
+
+// ListProjects はプロジェクト一覧を取得する（projectID昇順）
+func (s *MemoryStore) ListProjects(ctx context.Context) ([]ProjectSummary, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.initialized {
+		return nil, ErrNotInitialized
+	}
+
+	counts := make(map[string]int)
+	for projectID, shard := range s.projects {
+		counts[projectID] = len(shard)
+	}
+	for _, config := range s.globalConfigs {
+		counts[config.ProjectID] += 0
+	}
+	for _, group := range s.groups {
+		counts[group.ProjectID] += 0
+	}
+
+	return projectSummaries(counts), nil
+}
+
+// GroupStats はプロジェクト内のgroupIdごとのノートの統計を返す
+func (s *MemoryStore) GroupStats(ctx context.Context, projectID string) (map[string]GroupStats, error) {
+	entries, err := s.projectEntries(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make(map[string]GroupStats)
+	for _, entry := range entries {
+		addGroupStats(stats, entry.note)
+	}
+	return stats, nil
+}
+
+// GetEmbedding はノートの埋め込みベクトルを取得する
+func (s *MemoryStore) GetEmbedding(ctx context.Context, id string) ([]float32, error) {
+	entry, err := s.entry(id)
+	if err != nil {
+		return nil, err
+	}
+
+	embedding := make([]float32, len(entry.embedding))
+	copy(embedding, entry.embedding)
+	return embedding, nil
+}
+
+// UpsertGlobal はグローバル設定を追加/更新する
+func (s *MemoryStore) UpsertGlobal(ctx context.Context, config *model.GlobalConfig) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if !s.initialized {
+		return ErrNotInitialized
+	}
+
+	return s.commit(journalRecord{Op: journalGlobalPut, Global: s.copyGlobalConfig(config)})
+}
+
 // GetGlobal はグローバル設定を取得する
 func (s *MemoryStore) GetGlobal(ctx context.Context, projectID, key string) (*model.GlobalConfig, bool, error) {
 	s.mu.RLock()
@@ -303,16 +557,7 @@ func (s *MemoryStore) GetGlobal(ctx context.Context, projectID, key string) (*mo
 		return nil, false, nil
 	}
 
-	// ディープコピー
-	configCopy := &model.GlobalConfig{
-		ID:        config.ID,
-		ProjectID: config.ProjectID,
-		Key:       config.Key,
-		Value:     s.copyValue(config.Value),
-		UpdatedAt: config.UpdatedAt,
-	}
-
-	return configCopy, true, nil
+	return s.copyGlobalConfig(config), true, nil
 }
 
 // GetGlobalByID はIDでグローバル設定を取得する
@@ -327,63 +572,83 @@ func (s *MemoryStore) GetGlobalByID(ctx context.Context, id string) (*model.Glob
 	// ID で全件検索
 	for _, config := range s.globalConfigs {
 		if config.ID == id {
-			// ディープコピー
-			configCopy := &model.GlobalConfig{
-				ID:        config.ID,
-				ProjectID: config.ProjectID,
-				Key:       config.Key,
-				Value:     s.copyValue(config.Value),
-				UpdatedAt: config.UpdatedAt,
-			}
-			return configCopy, nil
+			return s.copyGlobalConfig(config), nil
 		}
 	}
 
 	return nil, ErrNotFound
 }
 
+// copyGlobalConfig はグローバル設定（履歴を含む）のディープコピーを返す
+func (s *MemoryStore) copyGlobalConfig(config *model.GlobalConfig) *model.GlobalConfig {
+	configCopy := &model.GlobalConfig{
+		ID:        config.ID,
+		ProjectID: config.ProjectID,
+		Key:       config.Key,
+		Value:     copyValue(config.Value),
+		UpdatedAt: config.UpdatedAt,
+		UpdatedBy: config.UpdatedBy,
+	}
+	for _, version := range config.History {
+		version.Value = copyValue(version.Value)
+		configCopy.History = append(configCopy.History, version)
+	}
+	return configCopy
+}
+
 // DeleteGlobalByID はIDでグローバル設定を削除する
 func (s *MemoryStore) DeleteGlobalByID(ctx context.Context, id string) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
 
 	if !s.initialized {
 		return ErrNotInitialized
 	}
 
 	// ID で検索して削除
-	for key, config := range s.globalConfigs {
+	for _, config := range s.globalConfigs {
 		if config.ID == id {
-			delete(s.globalConfigs, key)
-			return nil
+			return s.commit(journalRecord{Op: journalGlobalDelete, ID: id})
 		}
 	}
 
 	return ErrNotFound
 }
 
+// ListGlobals はプロジェクト内の全グローバル設定を取得する（key昇順）
+func (s *MemoryStore) ListGlobals(ctx context.Context, projectID string) ([]*model.GlobalConfig, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.initialized {
+		return nil, ErrNotInitialized
+	}
+
+	var configs []*model.GlobalConfig
+	for _, config := range s.globalConfigs {
+		if config.ProjectID != projectID {
+			continue
+		}
+		configs = append(configs, s.copyGlobalConfig(config))
+	}
+
+	sort.Slice(configs, func(i, j int) bool {
+		return configs[i].Key < configs[j].Key
+	})
+
+	return configs, nil
+}
+
 // AddGroup はグループを追加する
 func (s *MemoryStore) AddGroup(ctx context.Context, group *model.Group) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
 
 	if !s.initialized {
 		return ErrNotInitialized
 	}
 
-	// ディープコピー
-	groupCopy := &model.Group{
-		ID:          group.ID,
-		ProjectID:   group.ProjectID,
-		GroupKey:    group.GroupKey,
-		Title:       group.Title,
-		Description: group.Description,
-		CreatedAt:   group.CreatedAt,
-		UpdatedAt:   group.UpdatedAt,
-	}
-
-	s.groups[group.ID] = groupCopy
-	return nil
+	return s.commit(journalRecord{Op: journalGroupPut, Group: s.copyGroup(group)})
 }
 
 // GetGroup はIDでグループを取得する
@@ -400,16 +665,7 @@ func (s *MemoryStore) GetGroup(ctx context.Context, id string) (*model.Group, er
 		return nil, ErrNotFound
 	}
 
-	// ディープコピー
-	return &model.Group{
-		ID:          group.ID,
-		ProjectID:   group.ProjectID,
-		GroupKey:    group.GroupKey,
-		Title:       group.Title,
-		Description: group.Description,
-		CreatedAt:   group.CreatedAt,
-		UpdatedAt:   group.UpdatedAt,
-	}, nil
+	return s.copyGroup(group), nil
 }
 
 // GetGroupByKey はProjectIDとGroupKeyでグループを取得する
@@ -423,16 +679,7 @@ func (s *MemoryStore) GetGroupByKey(ctx context.Context, projectID, groupKey str
 
 	for _, group := range s.groups {
 		if group.ProjectID == projectID && group.GroupKey == groupKey {
-			// ディープコピー
-			return &model.Group{
-				ID:          group.ID,
-				ProjectID:   group.ProjectID,
-				GroupKey:    group.GroupKey,
-				Title:       group.Title,
-				Description: group.Description,
-				CreatedAt:   group.CreatedAt,
-				UpdatedAt:   group.UpdatedAt,
-			}, nil
+			return s.copyGroup(group), nil
 		}
 	}
 
@@ -441,8 +688,8 @@ func (s *MemoryStore) GetGroupByKey(ctx context.Context, projectID, groupKey str
 
 // UpdateGroup はグループを更新する
 func (s *MemoryStore) UpdateGroup(ctx context.Context, group *model.Group) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
 
 	if !s.initialized {
 		return ErrNotInitialized
@@ -452,24 +699,13 @@ func (s *MemoryStore) UpdateGroup(ctx context.Context, group *model.Group) error
 		return ErrNotFound
 	}
 
-	// ディープコピー
-	s.groups[group.ID] = &model.Group{
-		ID:          group.ID,
-		ProjectID:   group.ProjectID,
-		GroupKey:    group.GroupKey,
-		Title:       group.Title,
-		Description: group.Description,
-		CreatedAt:   group.CreatedAt,
-		UpdatedAt:   group.UpdatedAt,
-	}
-
-	return nil
+	return s.commit(journalRecord{Op: journalGroupPut, Group: s.copyGroup(group)})
 }
 
 // DeleteGroup はグループを削除する
 func (s *MemoryStore) DeleteGroup(ctx context.Context, id string) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
 
 	if !s.initialized {
 		return ErrNotInitialized
@@ -479,8 +715,50 @@ func (s *MemoryStore) DeleteGroup(ctx context.Context, id string) error {
 		return ErrNotFound
 	}
 
-	delete(s.groups, id)
-	return nil
+	return s.commit(journalRecord{Op: journalGroupDelete, ID: id})
+}
+
+// RenameGroup はgroupKeyを変更し、そのグループのノートのgroupId・子グループのparentGroupIdも書き換える
+func (s *MemoryStore) RenameGroup(ctx context.Context, id, groupKey string) (int, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if !s.initialized {
+		return 0, ErrNotInitialized
+	}
+
+	group, ok := s.groups[id]
+	if !ok {
+		return 0, ErrNotFound
+	}
+	oldKey := group.GroupKey
+
+	var records []journalRecord
+	for _, entry := range s.projects[group.ProjectID] {
+		if entry.note.GroupID == oldKey {
+			note := copyNote(entry.note)
+			note.GroupID = groupKey
+			stampUpdatedNote(note)
+			records = append(records, journalRecord{Op: journalNotePut, Note: note, Embedding: entry.embedding})
+		}
+	}
+	count := len(records)
+	for _, child := range s.groups {
+		if child.ProjectID == group.ProjectID && child.ParentGroupID == oldKey {
+			childCopy := s.copyGroup(child)
+			childCopy.ParentGroupID = groupKey
+			records = append(records, journalRecord{Op: journalGroupPut, Group: childCopy})
+		}
+	}
+	renamed := s.copyGroup(group)
+	renamed.GroupKey = groupKey
+	renamed.UpdatedAt = time.Now().UTC()
+	records = append(records, journalRecord{Op: journalGroupPut, Group: renamed})
+
+	if err := s.commit(records...); err != nil {
+		return 0, err
+	}
+	return count, nil
 }
 
 // ListGroups はプロジェクト内の全グループを取得する
@@ -495,16 +773,7 @@ func (s *MemoryStore) ListGroups(ctx context.Context, projectID string) ([]*mode
 	var groups []*model.Group
 	for _, group := range s.groups {
 		if group.ProjectID == projectID {
-			// ディープコピー
-			groups = append(groups, &model.Group{
-				ID:          group.ID,
-				ProjectID:   group.ProjectID,
-				GroupKey:    group.GroupKey,
-				Title:       group.Title,
-				Description: group.Description,
-				CreatedAt:   group.CreatedAt,
-				UpdatedAt:   group.UpdatedAt,
-			})
+			groups = append(groups, s.copyGroup(group))
 		}
 	}
 
@@ -518,11 +787,141 @@ func (s *MemoryStore) ListGroups(ctx context.Context, projectID string) ([]*mode
 
 // Helper methods
 
+// commit はジャーナルがあればrecordsを記録してから、メモリ上の状態に反映する（writeMuを保持して呼ぶ）
+// ジャーナルへの書き込みはmuの外で行い、反映の間だけmuを保持する
+func (s *MemoryStore) commit(records ...journalRecord) error {
+	if s.journal != nil {
+		if err := s.journal.append(records...); err != nil {
+			return err
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range records {
+		s.applyJournal(r)
+	}
+	return nil
+}
+
+// applyJournal はジャーナルの1件をメモリ上の状態に反映する（recordの値はそのまま保持する。muを保持して呼ぶ）
+func (s *MemoryStore) applyJournal(r journalRecord) {
+	switch r.Op {
+	case journalNotePut:
+		if r.Note != nil {
+			var accessedAt string
+			if prev, ok := s.notes[r.Note.ID]; ok {
+				accessedAt = prev.accessedAt
+			}
+			s.removeNote(r.Note.ID) // projectIdが変わった場合に元のプロジェクトから外す
+			entry := &noteEntry{note: r.Note, embedding: r.Embedding, norm: vectorNorm(r.Embedding), accessedAt: accessedAt}
+			s.notes[r.Note.ID] = entry
+			shard, ok := s.projects[r.Note.ProjectID]
+			if !ok {
+				shard = make(map[string]*noteEntry)
+				s.projects[r.Note.ProjectID] = shard
+			}
+			shard[r.Note.ID] = entry
+		}
+	case journalNoteDelete:
+		s.removeNote(r.ID)
+	case journalNoteTouch:
+		if prev, ok := s.notes[r.ID]; ok {
+			entry := *prev
+			entry.accessedAt = r.AccessedAt
+			s.notes[r.ID] = &entry
+			s.projects[prev.note.ProjectID][r.ID] = &entry
+		}
+	case journalGlobalPut:
+		if r.Global != nil {
+			s.globalConfigs[s.globalKey(r.Global.ProjectID, r.Global.Key)] = r.Global
+		}
+	case journalGlobalDelete:
+		for key, config := range s.globalConfigs {
+			if config.ID == r.ID {
+				delete(s.globalConfigs, key)
+			}
+		}
+	case journalGroupPut:
+		if r.Group != nil {
+			s.groups[r.Group.ID] = r.Group
+		}
+	case journalGroupDelete:
+		delete(s.groups, r.ID)
+	}
+}
+
+// removeNote はidのノートをプロジェクトの索引ごと取り除く（ノートがなくなったプロジェクトは索引から消す）
+func (s *MemoryStore) removeNote(id string) {
+	entry, ok := s.notes[id]
+	if !ok {
+		return
+	}
+	delete(s.notes, id)
+	shard := s.projects[entry.note.ProjectID]
+	delete(shard, id)
+	if len(shard) == 0 {
+		delete(s.projects, entry.note.ProjectID)
+	}
+}
+
+// projectEntries はprojectIDのノートのエントリを返す
+// エントリは変更されないため、返した後はロックの外でフィルタ・スコア計算・コピーしてよい
+func (s *MemoryStore) projectEntries(projectID string) ([]*noteEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.initialized {
+		return nil, ErrNotInitialized
+	}
+
+	shard := s.projects[projectID]
+	entries := make([]*noteEntry, 0, len(shard))
+	for _, entry := range shard {
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// entry はidのノートのエントリを返す
+func (s *MemoryStore) entry(id string) (*noteEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.initialized {
+		return nil, ErrNotInitialized
+	}
+
+	entry, ok := s.notes[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return entry, nil
+}
+
+// journalSnapshot は現在の状態を再現するジャーナルの記録を返す
+func (s *MemoryStore) journalSnapshot() []journalRecord {
+	records := make([]journalRecord, 0, len(s.notes)+len(s.globalConfigs)+len(s.groups))
+	for _, entry := range s.notes {
+		records = append(records, journalRecord{Op: journalNotePut, Note: entry.note, Embedding: entry.embedding})
+		if entry.accessedAt != "" {
+			records = append(records, journalRecord{Op: journalNoteTouch, ID: entry.note.ID, AccessedAt: entry.accessedAt})
+		}
+	}
+	for _, config := range s.globalConfigs {
+		records = append(records, journalRecord{Op: journalGlobalPut, Global: config})
+	}
+	for _, group := range s.groups {
+		records = append(records, journalRecord{Op: journalGroupPut, Group: group})
+	}
+	return records
+}
+
 func (s *MemoryStore) globalKey(projectID, key string) string {
 	return fmt.Sprintf("%s:%s", projectID, key)
 }
 
-func (s *MemoryStore) copyNote(note *model.Note) *model.Note {
+// copyNote はノートのディープコピーを返す（cachedStoreでも使う）
+func copyNote(note *model.Note) *model.Note {
 	noteCopy := &model.Note{
 		ID:        note.ID,
 		ProjectID: note.ProjectID,
@@ -548,14 +947,53 @@ func (s *MemoryStore) copyNote(note *model.Note) *model.Note {
 		noteCopy.CreatedAt = &createdAt
 	}
 
+	if note.UpdatedAt != nil {
+		updatedAt := *note.UpdatedAt
+		noteCopy.UpdatedAt = &updatedAt
+	}
+
+	if note.Attachments != nil {
+		noteCopy.Attachments = make([]model.Attachment, len(note.Attachments))
+		copy(noteCopy.Attachments, note.Attachments)
+	}
+
+	if note.ParentID != nil {
+		parentID := *note.ParentID
+		noteCopy.ParentID = &parentID
+	}
+
+	if note.ChunkIndex != nil {
+		chunkIndex := *note.ChunkIndex
+		noteCopy.ChunkIndex = &chunkIndex
+	}
+
+	if note.Importance != nil {
+		importance := *note.Importance
+		noteCopy.Importance = &importance
+	}
+
 	if note.Metadata != nil {
-		noteCopy.Metadata = s.copyValue(note.Metadata).(map[string]any)
+		noteCopy.Metadata = copyValue(note.Metadata).(map[string]any)
 	}
 
 	return noteCopy
 }
 
-func (s *MemoryStore) copyValue(v any) any {
+// copyGroup はgroupのディープコピーを返す
+func (s *MemoryStore) copyGroup(group *model.Group) *model.Group {
+	groupCopy := *group
+	if group.Tags != nil {
+		groupCopy.Tags = make([]string, len(group.Tags))
+		copy(groupCopy.Tags, group.Tags)
+	}
+	if group.Metadata != nil {
+		groupCopy.Metadata = copyValue(group.Metadata).(map[string]any)
+	}
+	return &groupCopy
+}
+
+// copyValue はJSONで表せる値のディープコピーを返す
+func copyValue(v any) any {
 	if v == nil {
 		return nil
 	}
@@ -0,0 +1,198 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestMemoryStore_ConcurrentAccess は検索・一覧・取得と変更を並行して行ってもデータが壊れないことをテスト
+// go test -race で実行するとデータ競合も検出する
+func TestMemoryStore_ConcurrentAccess(t *testing.T) {
+	ctx := context.Background()
+	st := NewMemoryStore(WithMemoryJournal(filepath.Join(t.TempDir(), "journal")))
+	if err := st.Initialize(ctx, "openai:test:3"); err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	const writers, notesPerWriter, readers = 4, 50, 8
+	var wg sync.WaitGroup
+	for w := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			projectID := fmt.Sprintf("/project-%d", w%2)
+			for i := range notesPerWriter {
+				note := newTestNote(fmt.Sprintf("note-%d-%d", w, i), projectID, "global", "text")
+				if err := st.AddNote(ctx, note, []float32{1, float32(i), 0}); err != nil {
+					t.Error(err)
+					return
+				}
+				note.Text = "updated"
+				if err := st.Update(ctx, note, []float32{0, 1, float32(i)}); err != nil {
+					t.Error(err)
+					return
+				}
+				if i%10 == 0 {
+					if _, err := st.MoveNotesToGroup(ctx, projectID, "global", "moved"); err != nil {
+						t.Error(err)
+						return
+					}
+				}
+			}
+		}()
+	}
+	stop := make(chan struct{})
+	var readersWG sync.WaitGroup
+	for r := range readers {
+		readersWG.Add(1)
+		go func() {
+			defer readersWG.Done()
+			projectID := fmt.Sprintf("/project-%d", r%2)
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				results, err := st.Search(ctx, []float32{1, 0, 0}, SearchOptions{ProjectID: projectID, TopK: 5})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				for _, result := range results {
+					// 返したノートを書き換えてもストアには影響しない
+					result.Note.Tags = append(result.Note.Tags, "mutated")
+				}
+				if _, err := st.ListRecent(ctx, ListOptions{ProjectID: projectID, Limit: 5}); err != nil {
+					t.Error(err)
+					return
+				}
+				if _, err := st.GroupStats(ctx, projectID); err != nil {
+					t.Error(err)
+					return
+				}
+				if _, err := st.ListProjects(ctx); err != nil {
+					t.Error(err)
+					return
+				}
+				if _, err := st.Get(ctx, fmt.Sprintf("note-%d-0", r%writers)); err != nil && err != ErrNotFound {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(stop)
+	readersWG.Wait()
+
+	projects, err := st.ListProjects(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(projects) != 2 || projects[0].NoteCount != writers/2*notesPerWriter || projects[1].NoteCount != writers/2*notesPerWriter {
+		t.Errorf("unexpected projects: %+v", projects)
+	}
+	notes, err := st.ListNotes(ctx, "/project-0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, note := range notes {
+		if note.Text != "updated" || len(note.Tags) != 0 {
+			t.Errorf("unexpected note: %+v", note)
+		}
+	}
+}
+
+// TestMemoryStore_ReadDuringWrite は変更（ジャーナルの書き込み）の途中でも読み取りが待たされないことをテスト
+func TestMemoryStore_ReadDuringWrite(t *testing.T) {
+	ctx := context.Background()
+	st := NewMemoryStore()
+	if err := st.Initialize(ctx, "openai:test:3"); err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	if err := st.AddNote(ctx, newTestNote("note-1", "/test/project", "global", "text"), []float32{1, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 変更中の状態を再現する
+	st.writeMu.Lock()
+	done := make(chan error, 1)
+	go func() {
+		results, err := st.Search(ctx, []float32{1, 0, 0}, SearchOptions{ProjectID: "/test/project"})
+		if err == nil && len(results) != 1 {
+			err = fmt.Errorf("expected 1 result, got %d", len(results))
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Search was blocked by a pending write")
+	}
+	st.writeMu.Unlock()
+}
+
+// TestMemoryStore_UpdateProject はprojectIdを変えた更新で、ノートが元のプロジェクトの検索・一覧から外れることをテスト
+func TestMemoryStore_UpdateProject(t *testing.T) {
+	ctx := context.Background()
+	st := NewMemoryStore()
+	if err := st.Initialize(ctx, "openai:test:3"); err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	note := newTestNote("note-1", "/project-a", "global", "text")
+	if err := st.AddNote(ctx, note, []float32{1, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+	note.ProjectID = "/project-b"
+	if err := st.Update(ctx, note, []float32{1, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+
+	if results, _ := st.Search(ctx, []float32{1, 0, 0}, SearchOptions{ProjectID: "/project-a"}); len(results) != 0 {
+		t.Errorf("expected no results in the old project, got %d", len(results))
+	}
+	if results, _ := st.Search(ctx, []float32{1, 0, 0}, SearchOptions{ProjectID: "/project-b"}); len(results) != 1 {
+		t.Errorf("expected 1 result in the new project, got %d", len(results))
+	}
+	projects, err := st.ListProjects(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(projects) != 1 || projects[0].ProjectID != "/project-b" || projects[0].NoteCount != 1 {
+		t.Errorf("unexpected projects: %+v", projects)
+	}
+}
+
+// TestMemoryStore_ListRecent_Pagination はカーソルによるページ送りと件数をテスト
+func TestMemoryStore_ListRecent_Pagination(t *testing.T) {
+	st := NewMemoryStore()
+	if err := st.Initialize(context.Background(), "openai:test:3"); err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	testListPagination(t, st, "/test/project", 3)
+}
+
+// TestMemoryStore_StaleNotes は最終アクセスの記録と古いノートの一覧をテスト
+func TestMemoryStore_StaleNotes(t *testing.T) {
+	st := NewMemoryStore()
+	if err := st.Initialize(context.Background(), "openai:test:3"); err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	testStaleNotes(t, st, "/test/project", 3)
+}
@@ -0,0 +1,71 @@
+// Package naming はnamespace（provider:model:dim）から各ストアの物理名（コレクション名）を決める
+// どのストアでも同じ規則で変換し、同じnamespaceが同じ名前になるようにする（ストアを移行しても対応が分かる）
+// SQLite・メモリストアはnamespaceを列・キーの値としてそのまま保存するため変換しない
+package naming
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// コレクションの種類ごとの接尾辞
+const (
+	SuffixNotes         = ""                // ノート
+	SuffixGlobalConfigs = "_global_configs" // GlobalConfig
+	SuffixGroups        = "_groups"         // グループ
+)
+
+// Backend はストアごとの名前の制約
+type Backend struct {
+	Name      string
+	MaxLen    int  // 名前の最大長（超える場合はnamespaceの末尾をハッシュにして切り詰める）
+	AlnumEnds bool // 先頭・末尾は英数字のみ（それ以外の文字は取り除く）
+}
+
+var (
+	// Qdrant はコレクション名に : / \ などを使えず、255文字まで
+	Qdrant = Backend{Name: "qdrant", MaxLen: 255}
+	// Chroma はコレクション名が英数字と . _ - のみ、3-63文字、先頭・末尾が英数字
+	Chroma = Backend{Name: "chroma", MaxLen: 63, AlnumEnds: true}
+)
+
+// hashLen は切り詰めた名前に付けるハッシュの長さ
+const hashLen = 8
+
+// Sanitize はnamespaceの英数字と . _ - 以外の文字を _ に置き換える
+// どのストアでも使える文字だけにするため、:（provider:model:dimの区切り）も置き換わる
+func Sanitize(namespace string) string {
+	return strings.Map(func(r rune) rune {
+		if isAlnum(r) || r == '.' || r == '_' || r == '-' {
+			return r
+		}
+		return '_'
+	}, namespace)
+}
+
+// Collection はnamespaceのsuffix（SuffixNotesなど）のコレクション名を返す
+// 長すぎる場合は、切り詰めたnamespaceと元のnamespaceのハッシュで区別できる名前にする
+func (b Backend) Collection(namespace, suffix string) string {
+	base := Sanitize(namespace)
+	if b.AlnumEnds {
+		base = strings.TrimFunc(base, func(r rune) bool { return !isAlnum(r) })
+	}
+	if b.MaxLen > 0 && len(base)+len(suffix) > b.MaxLen {
+		sum := sha256.Sum256([]byte(namespace))
+		keep := max(b.MaxLen-len(suffix)-hashLen-1, 0)
+		base = base[:keep] + "-" + hex.EncodeToString(sum[:])[:hashLen]
+	}
+	return base + suffix
+}
+
+// LegacyQdrant は以前のバージョンのQdrantのコレクション名を返す（: だけを _ に置き換え、長さは制限しない）
+// Collectionと異なる場合、既存のコレクションを探すために使う
+func LegacyQdrant(namespace, suffix string) string {
+	return strings.ReplaceAll(namespace, ":", "_") + suffix
+}
+
+// isAlnum はrがASCIIの英数字かを返す
+func isAlnum(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+}
@@ -0,0 +1,80 @@
+package naming
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		namespace string
+		want      string
+	}{
+		{"openai:text-embedding-3-small:1536", "openai_text-embedding-3-small_1536"},
+		{"ollama:nomic-embed-text:latest:768", "ollama_nomic-embed-text_latest_768"},
+		{"ollama:hf.co/user/model:Q4_K_M:1024", "ollama_hf.co_user_model_Q4_K_M_1024"},
+		{"local:モデル:3", "local_____3"},
+		{"shared", "shared"},
+	}
+	for _, tt := range tests {
+		if got := Sanitize(tt.namespace); got != tt.want {
+			t.Errorf("Sanitize(%q) = %q, want %q", tt.namespace, got, tt.want)
+		}
+	}
+}
+
+// TestCollection は同じnamespaceがストアによらず同じ名前になり、各ストアの制約を満たすことをテスト
+func TestCollection(t *testing.T) {
+	namespace := "openai:text-embedding-3-small:1536"
+	for _, suffix := range []string{SuffixNotes, SuffixGlobalConfigs, SuffixGroups} {
+		q, c := Qdrant.Collection(namespace, suffix), Chroma.Collection(namespace, suffix)
+		if q != c || q != "openai_text-embedding-3-small_1536"+suffix {
+			t.Errorf("suffix %q: expected the same name, got qdrant %q and chroma %q", suffix, q, c)
+		}
+	}
+
+	// 以前のQdrantの変換（: を _ に置き換える）と同じ名前になる
+	if got := Qdrant.Collection("ollama:nomic-embed-text:latest:768", SuffixGroups); got != "ollama_nomic-embed-text_latest_768_groups" {
+		t.Errorf("unexpected qdrant name: %s", got)
+	}
+}
+
+func TestCollection_Constraints(t *testing.T) {
+	chromaName := regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*[a-zA-Z0-9]$`)
+	long := "ollama:" + strings.Repeat("very-long-model-name/", 20) + ":768"
+	namespaces := []string{
+		"openai:text-embedding-3-small:1536",
+		long,
+		"_local:model:3_",
+	}
+	for _, ns := range namespaces {
+		for _, suffix := range []string{SuffixNotes, SuffixGlobalConfigs, SuffixGroups} {
+			if got := Chroma.Collection(ns, suffix); len(got) > Chroma.MaxLen || !chromaName.MatchString(got) {
+				t.Errorf("chroma: invalid name %q for %q", got, ns)
+			}
+			if got := Qdrant.Collection(ns, suffix); len(got) > Qdrant.MaxLen || strings.ContainsAny(got, `<>:"/\|?*`) {
+				t.Errorf("qdrant: invalid name %q for %q", got, ns)
+			}
+		}
+	}
+
+	// 切り詰めた名前は元のnamespaceのハッシュで区別する
+	other := "ollama:" + strings.Repeat("very-long-model-name/", 20) + ":1024"
+	a, b := Chroma.Collection(long, SuffixGroups), Chroma.Collection(other, SuffixGroups)
+	if a == b || !strings.HasSuffix(a, SuffixGroups) || len(a) != Chroma.MaxLen {
+		t.Errorf("expected distinct truncated names, got %q and %q", a, b)
+	}
+}
+
+// TestLegacyQdrant は以前のQdrantの名前が : だけを置き換えた名前になることをテスト
+func TestLegacyQdrant(t *testing.T) {
+	if got := LegacyQdrant("local:my model:3", SuffixGroups); got != "local_my model_3_groups" {
+		t.Errorf("unexpected legacy name: %s", got)
+	}
+	// : 以外の文字を含まないnamespaceはCollectionと同じ名前になる
+	ns := "openai:text-embedding-3-small:1536"
+	if LegacyQdrant(ns, SuffixNotes) != Qdrant.Collection(ns, SuffixNotes) {
+		t.Errorf("expected the same name for %q", ns)
+	}
+}
@@ -2,11 +2,9 @@ package store
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/binary"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/url"
 	"sort"
 	"strconv"
@@ -15,28 +13,66 @@ import (
 	"time"
 
 	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/store/naming"
+	"github.com/google/uuid"
 	"github.com/qdrant/go-client/qdrant"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
 )
 
-// sanitizeCollectionName はQdrantのコレクション名として使用できる文字列に変換する
-// Qdrantは ":" などの特殊文字をコレクション名に使用できないため
-func sanitizeCollectionName(name string) string {
-	return strings.ReplaceAll(name, ":", "_")
-}
+const (
+	// defaultQdrantConnectTimeout は接続確認のデフォルトのタイムアウト
+	defaultQdrantConnectTimeout = 5 * time.Second
+)
 
-// noteCollection はNote用コレクション名を返す
+// noteCollection はNote用コレクション名を返す（Initializeで決めた名前）
 func (s *QdrantStore) noteCollection() string {
-	return sanitizeCollectionName(s.namespace)
+	return s.noteColl
 }
 
-// globalConfigCollection はGlobalConfig用コレクション名を返す
+// globalConfigCollection はGlobalConfig用コレクション名を返す（Initializeで決めた名前）
 func (s *QdrantStore) globalConfigCollection() string {
-	return sanitizeCollectionName(s.namespace) + "_global_configs"
+	return s.globalColl
 }
 
-// groupCollection はGroup用コレクション名を返す
+// globalConfigCollectionFor はnamespaceのGlobalConfig用コレクション名を返す
+// WithQdrantSharedGlobalsの場合はnamespaceによらず共通のコレクション
+func (s *QdrantStore) globalConfigCollectionFor(namespace string) string {
+	if s.sharedGlobals {
+		namespace = SharedGlobalsNamespace
+	}
+	return naming.Qdrant.Collection(namespace, naming.SuffixGlobalConfigs)
+}
+
+// legacyGlobalConfigCollectionFor はnamespaceのGlobalConfig用コレクションの以前の名前を返す
+func (s *QdrantStore) legacyGlobalConfigCollectionFor(namespace string) string {
+	if s.sharedGlobals {
+		namespace = SharedGlobalsNamespace
+	}
+	return naming.LegacyQdrant(namespace, naming.SuffixGlobalConfigs)
+}
+
+// groupCollection はGroup用コレクション名を返す（Initializeで決めた名前）
 func (s *QdrantStore) groupCollection() string {
-	return sanitizeCollectionName(s.namespace) + "_groups"
+	return s.groupColl
+}
+
+// resolveCollection は新しい名前nameのコレクションがなく、以前の名前legacyのコレクションがあればlegacyを返す
+// 以前のバージョンで作成したコレクションのデータを使い続けるため（名前は変えない）
+func (s *QdrantStore) resolveCollection(ctx context.Context, name, legacy string) (string, error) {
+	if legacy == name {
+		return name, nil
+	}
+	exists, err := s.client.CollectionExists(ctx, name)
+	if err != nil || exists {
+		return name, err
+	}
+	// 以前の名前がQdrantで使えない名前ならエラーになる（そのコレクションはない）
+	if exists, err := s.client.CollectionExists(ctx, legacy); err == nil && exists {
+		slog.Warn("using the qdrant collection with the legacy name", "collection", legacy, "name", name)
+		return legacy, nil
+	}
+	return name, nil
 }
 
 // QdrantStore はQdrantを使用したStore実装
@@ -47,10 +83,122 @@ type QdrantStore struct {
 	vectorDim   uint64       // ベクトル次元数（namespaceから取得）
 	initialized bool
 	mu          sync.RWMutex // initializedフラグの保護
+
+	// コレクション名（Initializeで決める。以前の名前のコレクションがあればその名前）
+	noteColl   string
+	globalColl string
+	groupColl  string
+
+	// sharedGlobals はGlobalConfigをnamespaceによらず共通のコレクションに保存するか
+	sharedGlobals bool
+}
+
+// qdrantOptions はNewQdrantStoreの設定
+type qdrantOptions struct {
+	config         qdrant.Config
+	connectTimeout time.Duration
+	requestTimeout time.Duration
+	maxRetries     int
+	sharedGlobals  bool
+
+	breakerThreshold int
+	breakerCooldown  time.Duration
+}
+
+// QdrantOption はQdrantStoreのオプション
+type QdrantOption func(*qdrantOptions)
+
+// WithQdrantAPIKey はQdrantのAPIキーを設定
+func WithQdrantAPIKey(apiKey string) QdrantOption {
+	return func(o *qdrantOptions) {
+		o.config.APIKey = apiKey
+	}
+}
+
+// WithQdrantConnectTimeout は接続確認のタイムアウトを設定（デフォルト5秒）
+func WithQdrantConnectTimeout(d time.Duration) QdrantOption {
+	return func(o *qdrantOptions) {
+		o.connectTimeout = d
+	}
+}
+
+// WithQdrantRequestTimeout はgRPCリクエストごとのタイムアウトを設定
+// 呼び出し側のcontextに期限がある場合はそちらを優先する
+func WithQdrantRequestTimeout(d time.Duration) QdrantOption {
+	return func(o *qdrantOptions) {
+		o.requestTimeout = d
+	}
+}
+
+// WithQdrantKeepAlive はgRPCのkeepalive間隔を設定（デフォルト10秒、秒単位に切り上げる）
+// 0の場合はkeepaliveを無効にする
+func WithQdrantKeepAlive(interval time.Duration) QdrantOption {
+	return func(o *qdrantOptions) {
+		if interval == 0 {
+			o.config.KeepAliveTime = -1
+			return
+		}
+		o.config.KeepAliveTime = ceilSeconds(interval)
+	}
+}
+
+// WithQdrantKeepAliveTimeout はkeepaliveの応答待ちを設定（デフォルト2秒、秒単位に切り上げる）
+func WithQdrantKeepAliveTimeout(d time.Duration) QdrantOption {
+	return func(o *qdrantOptions) {
+		o.config.KeepAliveTimeout = uint(ceilSeconds(d))
+	}
+}
+
+// WithQdrantPoolSize はgRPC接続数を設定（デフォルト3）
+func WithQdrantPoolSize(n int) QdrantOption {
+	return func(o *qdrantOptions) {
+		o.config.PoolSize = uint(n)
+	}
+}
+
+// WithQdrantMaxRetries は接続確認に失敗した場合の再試行回数を設定（デフォルト0）
+func WithQdrantMaxRetries(n int) QdrantOption {
+	return func(o *qdrantOptions) {
+		o.maxRetries = n
+	}
+}
+
+// WithQdrantCircuitBreaker は接続エラーがthreshold回続いた場合に、cooldownの間リクエストをすぐ失敗させる（デフォルト5回・10秒）
+// thresholdが0以下なら無効にする
+func WithQdrantCircuitBreaker(threshold int, cooldown time.Duration) QdrantOption {
+	return func(o *qdrantOptions) {
+		o.breakerThreshold = threshold
+		o.breakerCooldown = cooldown
+	}
+}
+
+// WithQdrantSharedGlobals はGlobalConfigをnamespace（embedder）によらず共通のコレクションに保存する
+func WithQdrantSharedGlobals() QdrantOption {
+	return func(o *qdrantOptions) {
+		o.sharedGlobals = true
+	}
+}
+
+// ceilSeconds はdを秒に切り上げる（最小1秒）
+func ceilSeconds(d time.Duration) int {
+	return max(1, int((d+time.Second-1)/time.Second))
+}
+
+// requestTimeoutInterceptor は期限のないgRPCリクエストにタイムアウトを付ける
+func requestTimeoutInterceptor(timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
 }
 
 // NewQdrantStore はQdrantStoreを作成する
-func NewQdrantStore(urlStr string) (*QdrantStore, error) {
+// URLがhttpsの場合はTLSで接続する
+func NewQdrantStore(urlStr string, opts ...QdrantOption) (*QdrantStore, error) {
 	parsedURL, err := url.Parse(urlStr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse URL: %w", err)
@@ -72,26 +220,62 @@ func NewQdrantStore(urlStr string) (*QdrantStore, error) {
 		}
 	}
 
-	client, err := qdrant.NewClient(&qdrant.Config{
-		Host:                   host,
-		Port:                   port,
-		SkipCompatibilityCheck: true, // バージョンチェックをスキップ
-	})
+	o := &qdrantOptions{
+		config: qdrant.Config{
+			Host:                   host,
+			Port:                   port,
+			UseTLS:                 parsedURL.Scheme == "https",
+			SkipCompatibilityCheck: true, // バージョンチェックをスキップ
+		},
+		connectTimeout:   defaultQdrantConnectTimeout,
+		breakerThreshold: defaultQdrantBreakerThreshold,
+		breakerCooldown:  defaultQdrantBreakerCooldown,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	// Qdrantの再起動後に長く待たないよう、再接続の間隔を短くする
+	o.config.GrpcOptions = append(o.config.GrpcOptions, grpc.WithConnectParams(grpc.ConnectParams{
+		Backoff:           backoff.Config{BaseDelay: time.Second, Multiplier: 1.6, Jitter: 0.2, MaxDelay: qdrantMaxReconnectDelay},
+		MinConnectTimeout: o.connectTimeout,
+	}))
+	// 外側から順に、回路遮断・読み取りの再試行・1回ごとのタイムアウト
+	var interceptors []grpc.UnaryClientInterceptor
+	if o.breakerThreshold > 0 {
+		interceptors = append(interceptors, newCircuitBreaker(o.breakerThreshold, o.breakerCooldown).interceptor)
+	}
+	interceptors = append(interceptors, retryReadInterceptor)
+	if o.requestTimeout > 0 {
+		interceptors = append(interceptors, requestTimeoutInterceptor(o.requestTimeout))
+	}
+	o.config.GrpcOptions = append(o.config.GrpcOptions, grpc.WithChainUnaryInterceptor(interceptors...))
+	client, err := qdrant.NewClient(&o.config)
 	if err != nil {
 		return nil, ErrConnectionFailed
 	}
 
-	// 接続確認
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	if _, err := client.HealthCheck(ctx); err != nil {
-		return nil, ErrConnectionFailed
+	// 接続確認（失敗した場合はmaxRetries回まで、1秒・2秒・4秒…と間隔を空けて再試行する）
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), o.connectTimeout)
+		_, err = client.HealthCheck(ctx)
+		cancel()
+		if err == nil {
+			break
+		}
+		if attempt >= o.maxRetries {
+			client.Close()
+			return nil, ErrConnectionFailed
+		}
+		backoff := time.Second << min(attempt, 5)
+		slog.Warn("qdrant health check failed; retrying", "attempt", attempt+1, "maxAttempts", o.maxRetries+1, "backoff", backoff, "error", err)
+		time.Sleep(backoff)
 	}
 
 	return &QdrantStore{
-		client: client,
-		url:    urlStr,
+		client:        client,
+		url:           urlStr,
+		sharedGlobals: o.sharedGlobals,
 	}, nil
 }
 
@@ -117,13 +301,21 @@ func (s *QdrantStore) Initialize(ctx context.Context, namespace string) error {
 	vectorDim := parseVectorDim(namespace)
 
 	// コレクション名をサニタイズ（Qdrantは ":" を許可しない）
-	collectionName := sanitizeCollectionName(namespace)
+	collectionName, err := s.resolveCollection(ctx, naming.Qdrant.Collection(namespace, naming.SuffixNotes), naming.LegacyQdrant(namespace, naming.SuffixNotes))
+	if err != nil {
+		return fmt.Errorf("failed to check collection existence: %w", err)
+	}
 
 	// Note用コレクション存在確認
 	exists, err := s.client.CollectionExists(ctx, collectionName)
 	if err != nil {
 		return fmt.Errorf("failed to check collection existence: %w", err)
 	}
+	if exists {
+		if err := s.migrateLegacyPointIDs(ctx, collectionName); err != nil {
+			return err
+		}
+	}
 
 	// Note用コレクションが存在しない場合は作成
 	if !exists {
@@ -149,13 +341,31 @@ func (s *QdrantStore) Initialize(ctx context.Context, namespace string) error {
 		}
 	}
 
+	// updatedAtTimestampにpayload indexを作成（ListRecentのSortByUpdatedAt用）
+	// 既存のコレクションにも作成する（作成済みなら何もしない）
+	_, err = s.client.CreateFieldIndex(ctx, &qdrant.CreateFieldIndexCollection{
+		CollectionName: collectionName,
+		FieldName:      "updatedAtTimestamp",
+		FieldType:      qdrant.PtrOf(qdrant.FieldType_FieldTypeFloat),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create payload index: %w", err)
+	}
+
 	// GlobalConfig用コレクション作成
-	globalConfigCollection := collectionName + "_global_configs"
+	globalConfigCollection, err := s.resolveCollection(ctx, s.globalConfigCollectionFor(namespace), s.legacyGlobalConfigCollectionFor(namespace))
+	if err != nil {
+		return fmt.Errorf("failed to check global_configs collection existence: %w", err)
+	}
 	exists, err = s.client.CollectionExists(ctx, globalConfigCollection)
 	if err != nil {
 		return fmt.Errorf("failed to check global_configs collection existence: %w", err)
 	}
-	if !exists {
+	if exists {
+		if err := s.migrateLegacyPointIDs(ctx, globalConfigCollection); err != nil {
+			return err
+		}
+	} else {
 		err = s.client.CreateCollection(ctx, &qdrant.CreateCollection{
 			CollectionName: globalConfigCollection,
 			VectorsConfig: qdrant.NewVectorsConfig(&qdrant.VectorParams{
@@ -169,12 +379,19 @@ func (s *QdrantStore) Initialize(ctx context.Context, namespace string) error {
 	}
 
 	// Group用コレクション作成
-	groupCollection := collectionName + "_groups"
+	groupCollection, err := s.resolveCollection(ctx, naming.Qdrant.Collection(namespace, naming.SuffixGroups), naming.LegacyQdrant(namespace, naming.SuffixGroups))
+	if err != nil {
+		return fmt.Errorf("failed to check groups collection existence: %w", err)
+	}
 	exists, err = s.client.CollectionExists(ctx, groupCollection)
 	if err != nil {
 		return fmt.Errorf("failed to check groups collection existence: %w", err)
 	}
-	if !exists {
+	if exists {
+		if err := s.migrateLegacyPointIDs(ctx, groupCollection); err != nil {
+			return err
+		}
+	} else {
 		err = s.client.CreateCollection(ctx, &qdrant.CreateCollection{
 			CollectionName: groupCollection,
 			VectorsConfig: qdrant.NewVectorsConfig(&qdrant.VectorParams{
@@ -190,11 +407,24 @@ func (s *QdrantStore) Initialize(ctx context.Context, namespace string) error {
 	s.mu.Lock()
 	s.namespace = namespace
 	s.vectorDim = vectorDim
+	s.noteColl, s.globalColl, s.groupColl = collectionName, globalConfigCollection, groupCollection
 	s.initialized = true
 	s.mu.Unlock()
 	return nil
 }
 
+// migrateLegacyPointIDs は既存のコレクションの数値IDのポイントをUUIDのポイントに移し替える
+func (s *QdrantStore) migrateLegacyPointIDs(ctx context.Context, collection string) error {
+	n, err := migratePointIDs(ctx, s.client, collection)
+	if n > 0 {
+		slog.Info("migrated qdrant points to uuid point ids", "collection", collection, "points", n)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to migrate point ids of %s: %w", collection, err)
+	}
+	return nil
+}
+
 // Close はストアをクローズする
 func (s *QdrantStore) Close() error {
 	s.mu.Lock()
@@ -235,10 +465,7 @@ func (s *QdrantStore) acquireClientWithCollections() (*qdrant.Client, string, st
 	if !s.initialized || s.client == nil {
 		return nil, "", "", "", ErrNotInitialized
 	}
-	noteColl := sanitizeCollectionName(s.namespace)
-	globalColl := noteColl + "_global_configs"
-	groupColl := noteColl + "_groups"
-	return s.client, noteColl, globalColl, groupColl, nil
+	return s.client, s.noteCollection(), s.globalConfigCollection(), s.groupCollection(), nil
 }
 
 // AddNote はノートを追加する
@@ -253,6 +480,7 @@ func (s *QdrantStore) AddNote(ctx context.Context, note *model.Note, embedding [
 		now := time.Now().UTC().Format(time.RFC3339)
 		note.CreatedAt = &now
 	}
+	stampNewNote(note)
 
 	// tagsがnilの場合は空配列を設定
 	if note.Tags == nil {
@@ -268,7 +496,7 @@ func (s *QdrantStore) AddNote(ctx context.Context, note *model.Note, embedding [
 		Wait:           qdrant.PtrOf(true),
 		Points: []*qdrant.PointStruct{
 			{
-				Id:      qdrant.NewIDNum(hashID(note.ID)),
+				Id:      pointID(note.ID),
 				Vectors: qdrant.NewVectors(embedding...),
 				Payload: payload,
 			},
@@ -292,7 +520,7 @@ func (s *QdrantStore) Get(ctx context.Context, id string) (*model.Note, error) {
 	// IDでポイントを取得
 	points, err := client.Get(ctx, &qdrant.GetPoints{
 		CollectionName: noteColl,
-		Ids:            []*qdrant.PointId{qdrant.NewIDNum(hashID(id))},
+		Ids:            []*qdrant.PointId{pointID(id)},
 		WithPayload:    qdrant.NewWithPayload(true),
 	})
 
@@ -323,7 +551,7 @@ func (s *QdrantStore) Update(ctx context.Context, note *model.Note, embedding []
 	// 存在確認
 	points, err := client.Get(ctx, &qdrant.GetPoints{
 		CollectionName: noteColl,
-		Ids:            []*qdrant.PointId{qdrant.NewIDNum(hashID(note.ID))},
+		Ids:            []*qdrant.PointId{pointID(note.ID)},
 		WithPayload:    qdrant.NewWithPayload(false),
 	})
 
@@ -334,6 +562,21 @@ func (s *QdrantStore) Update(ctx context.Context, note *model.Note, embedding []
 	if len(points) == 0 {
 		return ErrNotFound
 	}
+	return s.upsertUpdated(ctx, client, noteColl, note, embedding)
+}
+
+// updateExisting は存在確認を省いてノートを更新する（cachedStoreがノートの存在を知っている場合）
+func (s *QdrantStore) updateExisting(ctx context.Context, note *model.Note, embedding []float32) error {
+	client, noteColl, _, _, err := s.acquireClientWithCollections()
+	if err != nil {
+		return err
+	}
+	return s.upsertUpdated(ctx, client, noteColl, note, embedding)
+}
+
+// upsertUpdated はupdatedAtを更新したノートで既存のポイントを上書きする
+func (s *QdrantStore) upsertUpdated(ctx context.Context, client *qdrant.Client, noteColl string, note *model.Note, embedding []float32) error {
+	stampUpdatedNote(note)
 
 	// tagsがnilの場合は空配列を設定
 	if note.Tags == nil {
@@ -344,11 +587,11 @@ func (s *QdrantStore) Update(ctx context.Context, note *model.Note, embedding []
 	payload := buildPayload(note)
 
 	// ポイントを更新（Upsertで上書き）
-	_, err = client.Upsert(ctx, &qdrant.UpsertPoints{
+	_, err := client.Upsert(ctx, &qdrant.UpsertPoints{
 		CollectionName: noteColl,
 		Points: []*qdrant.PointStruct{
 			{
-				Id:      qdrant.NewIDNum(hashID(note.ID)),
+				Id:      pointID(note.ID),
 				Vectors: qdrant.NewVectors(embedding...),
 				Payload: payload,
 			},
@@ -369,132 +612,706 @@ func (s *QdrantStore) Delete(ctx context.Context, id string) error {
 		return err
 	}
 
-	// 存在確認
+	// 存在確認
+	points, err := client.Get(ctx, &qdrant.GetPoints{
+		CollectionName: noteColl,
+		Ids:            []*qdrant.PointId{pointID(id)},
+		WithPayload:    qdrant.NewWithPayload(false),
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to check existence: %w", err)
+	}
+
+	if len(points) == 0 {
+		return ErrNotFound
+	}
+	return deletePoint(ctx, client, noteColl, id)
+}
+
+// deleteExisting は存在確認を省いてノートを削除する（cachedStoreがノートの存在を知っている場合）
+func (s *QdrantStore) deleteExisting(ctx context.Context, id string) error {
+	client, noteColl, _, _, err := s.acquireClientWithCollections()
+	if err != nil {
+		return err
+	}
+	return deletePoint(ctx, client, noteColl, id)
+}
+
+// deletePoint はノートのポイントを削除する
+func deletePoint(ctx context.Context, client *qdrant.Client, noteColl, id string) error {
+	_, err := client.Delete(ctx, &qdrant.DeletePoints{
+		CollectionName: noteColl,
+		Points:         qdrant.NewPointsSelector(pointID(id)),
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to delete point: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteNotesByGroup はプロジェクト内のgroupIDのノートを全て削除し、件数を返す
+func (s *QdrantStore) DeleteNotesByGroup(ctx context.Context, projectID, groupID string) (int, error) {
+	client, noteColl, _, _, err := s.acquireClientWithCollections()
+	if err != nil {
+		return 0, err
+	}
+
+	filter := groupNotesFilter(projectID, groupID)
+	count, err := client.Count(ctx, &qdrant.CountPoints{
+		CollectionName: noteColl,
+		Filter:         filter,
+		Exact:          qdrant.PtrOf(true),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count points: %w", err)
+	}
+	if count == 0 {
+		return 0, nil
+	}
+
+	_, err = client.Delete(ctx, &qdrant.DeletePoints{
+		CollectionName: noteColl,
+		Wait:           qdrant.PtrOf(true),
+		Points:         qdrant.NewPointsSelectorFilter(filter),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete points: %w", err)
+	}
+
+	return int(count), nil
+}
+
+// MoveNotesToGroup はプロジェクト内のfromGroupIDのノートをtoGroupIDへ移し、件数を返す
+// payloadのgroupId・updatedAtのみを書き換え、埋め込みはそのまま残す
+func (s *QdrantStore) MoveNotesToGroup(ctx context.Context, projectID, fromGroupID, toGroupID string) (int, error) {
+	client, noteColl, _, _, err := s.acquireClientWithCollections()
+	if err != nil {
+		return 0, err
+	}
+
+	filter := groupNotesFilter(projectID, fromGroupID)
+	count, err := client.Count(ctx, &qdrant.CountPoints{
+		CollectionName: noteColl,
+		Filter:         filter,
+		Exact:          qdrant.PtrOf(true),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count points: %w", err)
+	}
+	if count == 0 {
+		return 0, nil
+	}
+
+	now := Timestamp()
+	payload := map[string]*qdrant.Value{}
+	payload["groupId"], _ = qdrant.NewValue(toGroupID)
+	payload["updatedAt"], _ = qdrant.NewValue(now)
+	if t, err := time.Parse(time.RFC3339, now); err == nil {
+		payload["updatedAtTimestamp"], _ = qdrant.NewValue(float64(t.UnixMilli()) / 1000)
+	}
+	_, err = client.SetPayload(ctx, &qdrant.SetPayloadPoints{
+		CollectionName: noteColl,
+		Wait:           qdrant.PtrOf(true),
+		Payload:        payload,
+		PointsSelector: qdrant.NewPointsSelectorFilter(filter),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to move points: %w", err)
+	}
+
+	return int(count), nil
+}
+
+// groupNotesFilter はプロジェクト内のgroupIDのノートに一致するフィルタを返す
+func groupNotesFilter(projectID, groupID string) *qdrant.Filter {
+	return &qdrant.Filter{
+		Must: []*qdrant.Condition{
+			qdrant.NewMatch("projectId", projectID),
+			qdrant.NewMatch("groupId", groupID),
+		},
+	}
+}
+
+// Search はベクトル検索を実行する
+func (s *QdrantStore) Search(ctx context.Context, embedding []float32, opts SearchOptions) ([]SearchResult, error) {
+	client, noteColl, _, _, err := s.acquireClientWithCollections()
+	if err != nil {
+		return nil, err
+	}
+
+	// フィルタを構築
+	filter := buildSearchFilter(opts)
+
+	// Qdrantで検索実行
+	queryResp, err := client.Query(ctx, &qdrant.QueryPoints{
+		CollectionName: noteColl,
+		Query:          qdrant.NewQuery(embedding...),
+		Filter:         filter,
+		Limit:          qdrant.PtrOf(uint64(opts.TopK)),
+		WithPayload:    qdrant.NewWithPayload(true),
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to query points: %w", err)
+	}
+
+	// 結果を変換
+	var results []SearchResult
+	for _, point := range queryResp {
+		note, err := payloadToNote(point.Payload)
+		if err != nil {
+			slog.Warn("failed to convert payload to note in Search", "pointID", point.Id.String(), "error", err)
+			continue
+		}
+
+		// スコアを0-1に正規化 (Qdrantのcosine距離は-1〜1なので (score+1)/2)
+		score := float64((point.Score + 1.0) / 2.0)
+
+		results = append(results, SearchResult{
+			Note:  note,
+			Score: score,
+		})
+	}
+
+	// スコア降順でソート（念のため）
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+
+	return results, nil
+}
+
+// ListRecent は最新のノートをリストする
+func (s *QdrantStore) ListRecent(ctx context.Context, opts ListOptions) ([]*model.Note, error) {
+	client, noteColl, _, _, err := s.acquireClientWithCollections()
+	if err != nil {
+		return nil, err
+	}
+
+	// フィルタを構築
+	filter := buildListFilter(opts)
+
+	// Qdrant ScrollのOrderByを使用してcreatedAtTimestamp降順で取得
+	// これにより「最新N件」を正確に取得できる
+	// SortByUpdatedAtならupdatedAtTimestamp降順（OrderByのキーを持たないupdatedAt導入前のノートは含まれない）
+	orderKey := "createdAtTimestamp"
+	if opts.SortBy == SortByUpdatedAt {
+		orderKey = "updatedAtTimestamp"
+	}
+
+	// OrderByは同じ値の点の順序を決めないため、同じタイムスタンプのノートはまとめて取得してID降順に並べる
+	var notes []*model.Note
+	if opts.After != nil {
+		ts, ok := listTimestamp(opts.After.Key, opts.SortBy)
+		if !ok {
+			// ソートキーのないノートはOrderByで取得されないため、その後に続くノートもない
+			return nil, nil
+		}
+		// カーソルと同じタイムスタンプでIDが小さいノート、続いてより古いノート
+		ties, err := scrollListTies(ctx, client, noteColl, filter, orderKey, ts)
+		if err != nil {
+			return nil, err
+		}
+		for _, note := range ties {
+			if note.ID < opts.After.ID {
+				notes = append(notes, note)
+			}
+		}
+		filter = withListRange(filter, orderKey, &qdrant.Range{Lt: &ts})
+	}
+	if opts.Limit > 0 && len(notes) >= opts.Limit {
+		return notes[:opts.Limit], nil
+	}
+
+	scroll := &qdrant.ScrollPoints{
+		CollectionName: noteColl,
+		Filter:         filter,
+		WithPayload:    qdrant.NewWithPayload(true),
+		WithVectors:    qdrant.NewWithVectors(false),
+		OrderBy: &qdrant.OrderBy{
+			Key:       orderKey,
+			Direction: qdrant.PtrOf(qdrant.Direction_Desc),
+		},
+	}
+	if opts.Limit > 0 {
+		scroll.Limit = qdrant.PtrOf(uint32(opts.Limit - len(notes)))
+	}
+	scrollResp, err := client.Scroll(ctx, scroll)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scroll points: %w", err)
+	}
+
+	// payloadからNoteに変換し、タイムスタンプごとにまとめる（Scrollの結果はタイムスタンプ降順）
+	var (
+		page     []*model.Note
+		lastTS   float64
+		groupPos int // pageの中で最後のタイムスタンプのノートが始まる位置
+	)
+	for _, point := range scrollResp {
+		note, err := payloadToNote(point.Payload)
+		if err != nil {
+			slog.Warn("failed to convert payload to note in ListRecent", "pointID", point.Id.String(), "error", err)
+			continue
+		}
+		ts := point.Payload[orderKey].GetDoubleValue()
+		if len(page) == 0 || ts != lastTS {
+			sortListTies(page[groupPos:])
+			groupPos, lastTS = len(page), ts
+		}
+		page = append(page, note)
+	}
+
+	// 件数で打ち切られた場合、最後のタイムスタンプのノートはすべて取得し直してID降順の先頭から使う
+	if opts.Limit > 0 && len(scrollResp) == opts.Limit-len(notes) && len(page) > 0 {
+		ties, err := scrollListTies(ctx, client, noteColl, filter, orderKey, lastTS)
+		if err != nil {
+			return nil, err
+		}
+		page = append(page[:groupPos], ties...)
+	} else {
+		sortListTies(page[groupPos:])
+	}
+
+	notes = append(notes, page...)
+	if opts.Limit > 0 && len(notes) > opts.Limit {
+		notes = notes[:opts.Limit]
+	}
+	return notes, nil
+}
+
+// CountNotes は一覧の条件（projectId・groupId・tags）に一致するノートの件数を返す
+func (s *QdrantStore) CountNotes(ctx context.Context, opts ListOptions) (int, error) {
+	client, noteColl, _, _, err := s.acquireClientWithCollections()
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := client.Count(ctx, &qdrant.CountPoints{
+		CollectionName: noteColl,
+		Filter:         buildListFilter(opts),
+		Exact:          qdrant.PtrOf(true),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count points: %w", err)
+	}
+	return int(count), nil
+}
+
+// listTimestamp はListCursorのソートキーを、payloadのcreatedAtTimestamp・updatedAtTimestampと同じ値にする
+func listTimestamp(key, sortBy string) (float64, bool) {
+	t, err := time.Parse(time.RFC3339, key)
+	if err != nil {
+		return 0, false
+	}
+	if sortBy == SortByUpdatedAt {
+		return float64(t.UnixMilli()) / 1000, true
+	}
+	return float64(t.Unix()), true
+}
+
+// withListRange はfilterにorderKeyの範囲の条件を加えたフィルタを返す（filterは変更しない）
+func withListRange(filter *qdrant.Filter, orderKey string, r *qdrant.Range) *qdrant.Filter {
+	must := append(append([]*qdrant.Condition{}, filter.Must...), qdrant.NewRange(orderKey, r))
+	return &qdrant.Filter{Must: must}
+}
+
+// scrollListTies はorderKeyがtsのノートをすべてID降順で返す
+func scrollListTies(ctx context.Context, client *qdrant.Client, collection string, filter *qdrant.Filter, orderKey string, ts float64) ([]*model.Note, error) {
+	points, err := scrollAll(ctx, client, collection, withListRange(filter, orderKey, &qdrant.Range{Gte: &ts, Lte: &ts}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to scroll points: %w", err)
+	}
+	var notes []*model.Note
+	for _, point := range points {
+		note, err := payloadToNote(point.Payload)
+		if err != nil {
+			slog.Warn("failed to convert payload to note in ListRecent", "pointID", point.Id.String(), "error", err)
+			continue
+		}
+		notes = append(notes, note)
+	}
+	sortListTies(notes)
+	return notes, nil
+}
+
+// sortListTies は同じタイムスタンプのノートをID降順に並べる
+func sortListTies(notes []*model.Note) {
+	sort.Slice(notes, func(i, j int) bool {
+		return notes[i].ID > notes[j].ID
+	})
+}
+
+// ListNotes はプロジェクト内の全ノートを取得する（createdAt昇順）
+func (s *QdrantStore) ListNotes(ctx context.Context, projectID string) ([]*model.Note, error) {
+	client, noteColl, _, _, err := s.acquireClientWithCollections()
+	if err != nil {
+		return nil, err
+	}
+
+	filter := &qdrant.Filter{
+		Must: []*qdrant.Condition{
+			qdrant.NewMatch("projectId", projectID),
+		},
+	}
+
+	points, err := scrollAll(ctx, client, noteColl, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scroll points: %w", err)
+	}
+
+	var notes []*model.Note
+	for _, point := range points {
+		note, err := payloadToNote(point.Payload)
+		if err != nil {
+			slog.Warn("failed to convert payload to note in ListNotes", "pointID", point.Id.String(), "error", err)
+			continue
+		}
+		notes = append(notes, note)
+	}
+
+	// createdAt昇順でソート（同時刻はID順）
+	sort.Slice(notes, func(i, j int) bool {
+		var ci, cj string
+		if notes[i].CreatedAt != nil {
+			ci = *notes[i].CreatedAt
+		}
+		if notes[j].CreatedAt != nil {
+			cj = *notes[j].CreatedAt
+		}
+		if ci == cj {
+			return notes[i].ID < notes[j].ID
+		}
+		return ci < cj
+	})
+
+	return notes, nil
+}
+
+// ScanNotes はprojectIdで絞らずにコレクションの全ポイントを埋め込みとともに走査する
+// payloadのidがない・ポイントIDと合わないポイントは、ポイントのUUIDをIDにする（Deleteで削除できる）
+func (s *QdrantStore) ScanNotes(ctx context.Context, fn func(RawNote) error) error {
+	client, noteColl, _, _, err := s.acquireClientWithCollections()
+	if err != nil {
+		return err
+	}
+
+	const pageSize = uint32(1000)
+	var offset *qdrant.PointId
+	for {
+		points, next, err := client.ScrollAndOffset(ctx, &qdrant.ScrollPoints{
+			CollectionName: noteColl,
+			Limit:          qdrant.PtrOf(pageSize),
+			WithPayload:    qdrant.NewWithPayload(true),
+			WithVectors:    qdrant.NewWithVectors(true),
+			Offset:         offset,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to scroll points: %w", err)
+		}
+		for _, point := range points {
+			if err := fn(rawNoteFromPoint(point)); err != nil {
+				return err
+			}
+		}
+
+		// 次のページがなければ終了
+		if next == nil {
+			return nil
+		}
+		offset = next
+	}
+}
+
+// rawNoteFromPoint はポイントのpayloadと埋め込みをRawNoteにする
+func rawNoteFromPoint(point *qdrant.RetrievedPoint) RawNote {
+	raw := RawNote{ID: point.GetId().GetUuid(), Embedding: pointVector(point), Payload: make(map[string]any, len(point.Payload))}
+	for k, v := range point.Payload {
+		raw.Payload[k] = convertQdrantValue(v)
+	}
+	note, err := payloadToNote(point.Payload)
+	if err != nil {
+		raw.Err = err
+		return raw
+	}
+	raw.Note = note
+	if note.ID != "" && pointID(note.ID).GetUuid() == raw.ID {
+		raw.ID = note.ID
+	}
+	return raw
+}
+
+// TouchNotes はidsのノートの最終アクセスをpayloadのaccessedAtに記録する（ないIDは無視する）
+func (s *QdrantStore) TouchNotes(ctx context.Context, ids []string, accessedAt string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	client, noteColl, _, _, err := s.acquireClientWithCollections()
+	if err != nil {
+		return err
+	}
+
+	pointIDs := make([]*qdrant.PointId, len(ids))
+	for i, id := range ids {
+		pointIDs[i] = pointID(id)
+	}
+	payload := map[string]*qdrant.Value{}
+	payload["accessedAt"], _ = qdrant.NewValue(accessedAt)
+	// ID指定ではなくフィルタで選ぶ（削除済みのIDが混ざってもエラーにしない）
+	_, err = client.SetPayload(ctx, &qdrant.SetPayloadPoints{
+		CollectionName: noteColl,
+		Wait:           qdrant.PtrOf(true),
+		Payload:        payload,
+		PointsSelector: qdrant.NewPointsSelectorFilter(&qdrant.Filter{
+			Must: []*qdrant.Condition{qdrant.NewHasID(pointIDs...)},
+		}),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to touch points: %w", err)
+	}
+	return nil
+}
+
+// ListStaleNotes はプロジェクト内で最終アクセスがbeforeより前のノートを取得する（createdAt昇順）
+func (s *QdrantStore) ListStaleNotes(ctx context.Context, projectID string, before time.Time) ([]*model.Note, error) {
+	client, noteColl, _, _, err := s.acquireClientWithCollections()
+	if err != nil {
+		return nil, err
+	}
+
+	filter := &qdrant.Filter{
+		Must: []*qdrant.Condition{
+			qdrant.NewMatch("projectId", projectID),
+		},
+	}
+
+	points, err := scrollAll(ctx, client, noteColl, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scroll points: %w", err)
+	}
+
+	var notes []*model.Note
+	for _, point := range points {
+		note, err := payloadToNote(point.Payload)
+		if err != nil {
+			slog.Warn("failed to convert payload to note in ListStaleNotes", "pointID", point.Id.String(), "error", err)
+			continue
+		}
+		if t, ok := lastAccessTime(note, point.Payload["accessedAt"].GetStringValue()); ok && t.Before(before) {
+			notes = append(notes, note)
+		}
+	}
+	sortByCreatedAt(notes)
+	return notes, nil
+}
+
+// GetEmbedding はノートの埋め込みベクトルを取得する
+func (s *QdrantStore) GetEmbedding(ctx context.Context, id string) ([]float32, error) {
+	client, noteColl, _, _, err := s.acquireClientWithCollections()
+	if err != nil {
+		return nil, err
+	}
+
 	points, err := client.Get(ctx, &qdrant.GetPoints{
 		CollectionName: noteColl,
-		Ids:            []*qdrant.PointId{qdrant.NewIDNum(hashID(id))},
+		Ids:            []*qdrant.PointId{pointID(id)},
 		WithPayload:    qdrant.NewWithPayload(false),
+		WithVectors:    qdrant.NewWithVectors(true),
 	})
-
 	if err != nil {
-		return fmt.Errorf("failed to check existence: %w", err)
+		return nil, fmt.Errorf("failed to get point: %w", err)
 	}
 
 	if len(points) == 0 {
-		return ErrNotFound
-	}
-
-	// ポイントを削除
-	_, err = client.Delete(ctx, &qdrant.DeletePoints{
-		CollectionName: noteColl,
-		Points:         qdrant.NewPointsSelector(qdrant.NewIDNum(hashID(id))),
-	})
-
-	if err != nil {
-		return fmt.Errorf("failed to delete point: %w", err)
+		return nil, ErrNotFound
 	}
 
-	return nil
+	return pointVector(points[0]), nil
 }
 
-// Search はベクトル検索を実行する
-func (s *QdrantStore) Search(ctx context.Context, embedding []float32, opts SearchOptions) ([]SearchResult, error) {
-	client, noteColl, _, _, err := s.acquireClientWithCollections()
+// ListProjects はプロジェクト一覧を取得する（projectID昇順）
+func (s *QdrantStore) ListProjects(ctx context.Context) ([]ProjectSummary, error) {
+	client, noteColl, globalColl, groupColl, err := s.acquireClientWithCollections()
 	if err != nil {
 		return nil, err
 	}
 
-	// フィルタを構築
-	filter := buildSearchFilter(opts)
-
-	// Qdrantで検索実行
-	queryResp, err := client.Query(ctx, &qdrant.QueryPoints{
-		CollectionName: noteColl,
-		Query:          qdrant.NewQuery(embedding...),
-		Filter:         filter,
-		Limit:          qdrant.PtrOf(uint64(opts.TopK)),
-		WithPayload:    qdrant.NewWithPayload(true),
-	})
-
-	if err != nil {
-		return nil, fmt.Errorf("failed to query points: %w", err)
+	counts := make(map[string]int)
+	collections := []struct {
+		name   string
+		filter *qdrant.Filter
+		weight int // ノートのみ件数に数える
+	}{
+		{noteColl, nil, 1},
+		{globalColl, &qdrant.Filter{Must: []*qdrant.Condition{qdrant.NewMatch("type", "global_config")}}, 0},
+		{groupColl, &qdrant.Filter{Must: []*qdrant.Condition{qdrant.NewMatch("type", "group")}}, 0},
 	}
-
-	// 結果を変換
-	var results []SearchResult
-	for _, point := range queryResp {
-		note, err := payloadToNote(point.Payload)
+	for _, c := range collections {
+		points, err := scrollAll(ctx, client, c.name, c.filter)
 		if err != nil {
-			log.Printf("warning: failed to convert payload to note in Search: %v", err)
-			continue
+			return nil, fmt.Errorf("failed to scroll points: %w", err)
+		}
+		for _, point := range points {
+			if v, ok := point.Payload["projectId"]; ok && v.GetStringValue() != "" {
+				counts[v.GetStringValue()] += c.weight
+			}
 		}
-
-		// スコアを0-1に正規化 (Qdrantのcosine距離は-1〜1なので (score+1)/2)
-		score := float64((point.Score + 1.0) / 2.0)
-
-		results = append(results, SearchResult{
-			Note:  note,
-			Score: score,
-		})
 	}
 
-	// スコア降順でソート（念のため）
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].Score > results[j].Score
-	})
-
-	return results, nil
+	return projectSummaries(counts), nil
 }
 
-// ListRecent は最新のノートをリストする
-func (s *QdrantStore) ListRecent(ctx context.Context, opts ListOptions) ([]*model.Note, error) {
+// GroupStats はプロジェクト内のgroupIdごとのノートの統計を返す
+// Qdrantには集計クエリがないため、プロジェクトのノートをscrollして集計する
+func (s *QdrantStore) GroupStats(ctx context.Context, projectID string) (map[string]GroupStats, error) {
 	client, noteColl, _, _, err := s.acquireClientWithCollections()
 	if err != nil {
 		return nil, err
 	}
 
-	// フィルタを構築
-	filter := buildListFilter(opts)
-
-	// Qdrant ScrollのOrderByを使用してcreatedAtTimestamp降順で取得
-	// これにより「最新N件」を正確に取得できる
-	scrollResp, err := client.Scroll(ctx, &qdrant.ScrollPoints{
-		CollectionName: noteColl,
-		Filter:         filter,
-		Limit:          qdrant.PtrOf(uint32(opts.Limit)),
-		WithPayload:    qdrant.NewWithPayload(true),
-		WithVectors:    qdrant.NewWithVectors(false),
-		OrderBy: &qdrant.OrderBy{
-			Key:       "createdAtTimestamp",
-			Direction: qdrant.PtrOf(qdrant.Direction_Desc),
+	filter := &qdrant.Filter{
+		Must: []*qdrant.Condition{
+			qdrant.NewMatch("projectId", projectID),
 		},
-	})
-
+	}
+	points, err := scrollAll(ctx, client, noteColl, filter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to scroll points: %w", err)
 	}
 
-	// payloadからNoteに変換
-	var notes []*model.Note
-	for _, point := range scrollResp {
+	stats := make(map[string]GroupStats)
+	for _, point := range points {
 		note, err := payloadToNote(point.Payload)
 		if err != nil {
-			log.Printf("warning: failed to convert payload to note in ListRecent: %v", err)
+			slog.Warn("failed to convert payload to note in GroupStats", "pointID", point.Id.String(), "error", err)
 			continue
 		}
-		notes = append(notes, note)
+		addGroupStats(stats, note)
 	}
+	return stats, nil
+}
 
-	return notes, nil
+// scrollAll はフィルタに一致する全ポイントをページングで取得する（vectorなし）
+func scrollAll(ctx context.Context, client *qdrant.Client, collection string, filter *qdrant.Filter) ([]*qdrant.RetrievedPoint, error) {
+	const pageSize = uint32(1000)
+
+	var (
+		all    []*qdrant.RetrievedPoint
+		offset *qdrant.PointId
+	)
+	for {
+		points, next, err := client.ScrollAndOffset(ctx, &qdrant.ScrollPoints{
+			CollectionName: collection,
+			Filter:         filter,
+			Limit:          qdrant.PtrOf(pageSize),
+			WithPayload:    qdrant.NewWithPayload(true),
+			WithVectors:    qdrant.NewWithVectors(false),
+			Offset:         offset,
+		})
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, points...)
+
+		// 次のページがなければ終了
+		if next == nil {
+			return all, nil
+		}
+		offset = next
+	}
 }
 
 // Helper functions
 
-// hashID は文字列IDを数値IDに変換する（簡易実装）
-func hashID(id string) uint64 {
-	// SHA256ハッシュの先頭8バイトを使用して衝突耐性を向上
-	h := sha256.Sum256([]byte(id))
-	return binary.BigEndian.Uint64(h[:8])
+// pointIDNamespace は文字列IDからポイントIDのUUID（v5）を導出する名前空間
+var pointIDNamespace = uuid.MustParse("6f1c3a52-8d4e-4b7a-9c21-5e0d7f3b9a64")
+
+// pointID は文字列IDに対応するQdrantのポイントIDを返す
+// 正規形（小文字・ハイフン区切り）のUUIDはそのまま使い、それ以外（ULID・プレフィックス付きなど）は
+// ID全体からUUIDv5を導出する。元のIDはpayloadの"id"に保存する
+// （以前はSHA-256の先頭8バイトを数値IDにしていたため、異なるIDが同じポイントを上書きしうった。migratePointIDsを参照）
+func pointID(id string) *qdrant.PointId {
+	if u, err := uuid.Parse(id); err == nil && u.String() == id {
+		return qdrant.NewIDUUID(id)
+	}
+	return qdrant.NewIDUUID(uuid.NewSHA1(pointIDNamespace, []byte(id)).String())
+}
+
+// pointVector はポイントのベクトルを返す
+func pointVector(point *qdrant.RetrievedPoint) []float32 {
+	vector := point.GetVectors().GetVector()
+	if dense := vector.GetDense(); dense != nil {
+		return dense.GetData()
+	}
+	return vector.GetData()
+}
+
+// migratePointIDs は以前のバージョンが作った数値IDのポイントを、pointIDのUUIDのポイントに移し替え、移した件数を返す
+// Qdrantのscrollは数値IDをUUIDより先に返すため、数値IDがなくなったページで終える
+// 移し替えてから元のポイントを消すため、途中で失敗しても次のInitializeで続きから移し替える
+func migratePointIDs(ctx context.Context, client *qdrant.Client, collection string) (int, error) {
+	const pageSize = uint32(256)
+
+	migrated := 0
+	var offset *qdrant.PointId
+	for {
+		points, next, err := client.ScrollAndOffset(ctx, &qdrant.ScrollPoints{
+			CollectionName: collection,
+			Limit:          qdrant.PtrOf(pageSize),
+			WithPayload:    qdrant.NewWithPayload(true),
+			WithVectors:    qdrant.NewWithVectors(true),
+			Offset:         offset,
+		})
+		if err != nil {
+			return migrated, err
+		}
+
+		var (
+			upserts []*qdrant.PointStruct
+			legacy  []*qdrant.PointId
+		)
+		for _, point := range points {
+			if point.GetId().GetUuid() != "" {
+				continue
+			}
+			id := point.GetPayload()["id"].GetStringValue()
+			if id == "" {
+				slog.Warn("skipping a point without id in the payload", "collection", collection, "pointID", point.GetId().GetNum())
+				continue
+			}
+			upserts = append(upserts, &qdrant.PointStruct{
+				Id:      pointID(id),
+				Vectors: qdrant.NewVectors(pointVector(point)...),
+				Payload: point.GetPayload(),
+			})
+			legacy = append(legacy, point.GetId())
+		}
+		if len(upserts) > 0 {
+			if _, err := client.Upsert(ctx, &qdrant.UpsertPoints{
+				CollectionName: collection,
+				Wait:           qdrant.PtrOf(true),
+				Points:         upserts,
+			}); err != nil {
+				return migrated, err
+			}
+			if _, err := client.Delete(ctx, &qdrant.DeletePoints{
+				CollectionName: collection,
+				Wait:           qdrant.PtrOf(true),
+				Points:         qdrant.NewPointsSelector(legacy...),
+			}); err != nil {
+				return migrated, err
+			}
+			migrated += len(upserts)
+		}
+
+		// 数値IDのポイントはUUIDのポイントより前に並ぶ
+		if next == nil || len(points) == 0 || points[len(points)-1].GetId().GetUuid() != "" {
+			return migrated, nil
+		}
+		offset = next
+	}
 }
 
 // buildSearchFilter はSearchOptionsからQdrantのフィルタを構築する
@@ -508,6 +1325,9 @@ func buildSearchFilter(opts SearchOptions) *qdrant.Filter {
 	if opts.GroupID != nil {
 		conditions = append(conditions, qdrant.NewMatch("groupId", *opts.GroupID))
 	}
+	if len(opts.GroupIDs) > 0 {
+		conditions = append(conditions, qdrant.NewMatchKeywords("groupId", opts.GroupIDs...))
+	}
 
 	// tagsフィルタ（AND検索）
 	for _, tag := range opts.Tags {
@@ -546,6 +1366,9 @@ func buildListFilter(opts ListOptions) *qdrant.Filter {
 	if opts.GroupID != nil {
 		conditions = append(conditions, qdrant.NewMatch("groupId", *opts.GroupID))
 	}
+	if len(opts.GroupIDs) > 0 {
+		conditions = append(conditions, qdrant.NewMatchKeywords("groupId", opts.GroupIDs...))
+	}
 
 	// tagsフィルタ（AND検索）
 	for _, tag := range opts.Tags {
@@ -579,6 +1402,13 @@ func buildPayload(note *model.Note) map[string]*qdrant.Value {
 			payload["createdAtTimestamp"], _ = qdrant.NewValue(float64(t.Unix()))
 		}
 	}
+	if note.UpdatedAt != nil {
+		payload["updatedAt"], _ = qdrant.NewValue(*note.UpdatedAt)
+		// ソート用にミリ秒までのタイムスタンプも保存
+		if t, err := time.Parse(time.RFC3339, *note.UpdatedAt); err == nil {
+			payload["updatedAtTimestamp"], _ = qdrant.NewValue(float64(t.UnixMilli()) / 1000)
+		}
+	}
 
 	// tags を *qdrant.Value のリストに変換
 	tagValues := make([]*qdrant.Value, len(note.Tags))
@@ -587,6 +1417,32 @@ func buildPayload(note *model.Note) map[string]*qdrant.Value {
 	}
 	payload["tags"] = qdrant.NewValueList(&qdrant.ListValue{Values: tagValues})
 
+	// attachments を {path, hash} のリストに変換（空のフィールドは保存しない）
+	if len(note.Attachments) > 0 {
+		attachmentValues := make([]*qdrant.Value, len(note.Attachments))
+		for i, a := range note.Attachments {
+			fields := map[string]any{}
+			if a.Path != "" {
+				fields["path"] = a.Path
+			}
+			if a.Hash != "" {
+				fields["hash"] = a.Hash
+			}
+			attachmentValues[i], _ = qdrant.NewValue(fields)
+		}
+		payload["attachments"] = qdrant.NewValueList(&qdrant.ListValue{Values: attachmentValues})
+	}
+
+	if note.ParentID != nil {
+		payload["parentId"], _ = qdrant.NewValue(*note.ParentID)
+	}
+	if note.ChunkIndex != nil {
+		payload["chunkIndex"], _ = qdrant.NewValue(int64(*note.ChunkIndex))
+	}
+	if note.Importance != nil {
+		payload["importance"], _ = qdrant.NewValue(*note.Importance)
+	}
+
 	// metadata をJSON経由で変換
 	if note.Metadata != nil {
 		jsonBytes, err := json.Marshal(note.Metadata)
@@ -631,6 +1487,13 @@ func payloadToNote(payload map[string]*qdrant.Value) (*model.Note, error) {
 		createdAt := v.GetStringValue()
 		note.CreatedAt = &createdAt
 	}
+	// updatedAtのない旧データはcreatedAtとする
+	if v, ok := payload["updatedAt"]; ok && v.GetStringValue() != "" {
+		updatedAt := v.GetStringValue()
+		note.UpdatedAt = &updatedAt
+	} else {
+		note.UpdatedAt = note.CreatedAt
+	}
 
 	// tagsの取得
 	if v, ok := payload["tags"]; ok && v.GetListValue() != nil {
@@ -645,6 +1508,30 @@ func payloadToNote(payload map[string]*qdrant.Value) (*model.Note, error) {
 		note.Tags = []string{}
 	}
 
+	// attachmentsの取得
+	if v, ok := payload["attachments"]; ok && v.GetListValue() != nil {
+		for _, item := range v.GetListValue().Values {
+			fields := item.GetStructValue().GetFields()
+			note.Attachments = append(note.Attachments, model.Attachment{
+				Path: fields["path"].GetStringValue(),
+				Hash: fields["hash"].GetStringValue(),
+			})
+		}
+	}
+
+	if v, ok := payload["parentId"]; ok && v.GetStringValue() != "" {
+		parentID := v.GetStringValue()
+		note.ParentID = &parentID
+	}
+	if v, ok := payload["chunkIndex"]; ok && v != nil {
+		chunkIndex := int(v.GetIntegerValue())
+		note.ChunkIndex = &chunkIndex
+	}
+	if v, ok := payload["importance"]; ok && v != nil {
+		importance := v.GetDoubleValue()
+		note.Importance = &importance
+	}
+
 	// metadataの取得（convertQdrantValueを使用して型を正確に復元）
 	if v, ok := payload["metadata"]; ok && v != nil {
 		converted := convertQdrantValue(v)
@@ -700,6 +1587,24 @@ func (s *QdrantStore) UpsertGlobal(ctx context.Context, config *model.GlobalConf
 	}
 	payload["value"], _ = qdrant.NewValue(valueAny)
 
+	if config.UpdatedBy != "" {
+		payload["updatedBy"], _ = qdrant.NewValue(config.UpdatedBy)
+	}
+	// historyもJSON経由で{value, updatedAt, updatedBy}のリストとして保存
+	if len(config.History) > 0 {
+		jsonBytes, err := json.Marshal(config.History)
+		if err != nil {
+			return fmt.Errorf("failed to marshal history: %w", err)
+		}
+		var historyAny any
+		if err := json.Unmarshal(jsonBytes, &historyAny); err != nil {
+			return fmt.Errorf("failed to unmarshal history: %w", err)
+		}
+		if payload["history"], err = qdrant.NewValue(historyAny); err != nil {
+			return fmt.Errorf("failed to convert history: %w", err)
+		}
+	}
+
 	// ダミーベクトル（1次元）
 	dummyVector := []float32{1.0}
 
@@ -709,7 +1614,7 @@ func (s *QdrantStore) UpsertGlobal(ctx context.Context, config *model.GlobalConf
 		Wait:           qdrant.PtrOf(true),
 		Points: []*qdrant.PointStruct{
 			{
-				Id:      qdrant.NewIDNum(hashID(config.ID)),
+				Id:      pointID(config.ID),
 				Vectors: qdrant.NewVectors(dummyVector...),
 				Payload: payload,
 			},
@@ -764,6 +1669,42 @@ func (s *QdrantStore) GetGlobal(ctx context.Context, projectID, key string) (*mo
 	return config, true, nil
 }
 
+// ListGlobals はプロジェクト内の全GlobalConfigを取得する（key昇順）
+func (s *QdrantStore) ListGlobals(ctx context.Context, projectID string) ([]*model.GlobalConfig, error) {
+	client, _, globalColl, _, err := s.acquireClientWithCollections()
+	if err != nil {
+		return nil, err
+	}
+
+	filter := &qdrant.Filter{
+		Must: []*qdrant.Condition{
+			qdrant.NewMatch("projectId", projectID),
+			qdrant.NewMatch("type", "global_config"),
+		},
+	}
+
+	points, err := scrollAll(ctx, client, globalColl, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scroll global configs: %w", err)
+	}
+
+	var configs []*model.GlobalConfig
+	for _, point := range points {
+		config, err := payloadToGlobalConfig(point.Payload)
+		if err != nil {
+			slog.Warn("failed to convert payload to global config in ListGlobals", "pointID", point.Id.String(), "error", err)
+			continue
+		}
+		configs = append(configs, config)
+	}
+
+	sort.Slice(configs, func(i, j int) bool {
+		return configs[i].Key < configs[j].Key
+	})
+
+	return configs, nil
+}
+
 // GetGlobalByID はIDでGlobalConfigを取得する
 func (s *QdrantStore) GetGlobalByID(ctx context.Context, id string) (*model.GlobalConfig, error) {
 	client, _, globalColl, _, err := s.acquireClientWithCollections()
@@ -774,7 +1715,7 @@ func (s *QdrantStore) GetGlobalByID(ctx context.Context, id string) (*model.Glob
 	// IDでポイントを取得
 	points, err := client.Get(ctx, &qdrant.GetPoints{
 		CollectionName: globalColl,
-		Ids:            []*qdrant.PointId{qdrant.NewIDNum(hashID(id))},
+		Ids:            []*qdrant.PointId{pointID(id)},
 		WithPayload:    qdrant.NewWithPayload(true),
 	})
 
@@ -805,7 +1746,7 @@ func (s *QdrantStore) DeleteGlobalByID(ctx context.Context, id string) error {
 	// 存在確認
 	points, err := client.Get(ctx, &qdrant.GetPoints{
 		CollectionName: globalColl,
-		Ids:            []*qdrant.PointId{qdrant.NewIDNum(hashID(id))},
+		Ids:            []*qdrant.PointId{pointID(id)},
 		WithPayload:    qdrant.NewWithPayload(false),
 	})
 
@@ -820,7 +1761,7 @@ func (s *QdrantStore) DeleteGlobalByID(ctx context.Context, id string) error {
 	// ポイントを削除
 	_, err = client.Delete(ctx, &qdrant.DeletePoints{
 		CollectionName: globalColl,
-		Points:         qdrant.NewPointsSelector(qdrant.NewIDNum(hashID(id))),
+		Points:         qdrant.NewPointsSelector(pointID(id)),
 	})
 
 	if err != nil {
@@ -840,15 +1781,7 @@ func (s *QdrantStore) AddGroup(ctx context.Context, group *model.Group) error {
 	}
 
 	// payloadを構築
-	payload := make(map[string]*qdrant.Value)
-	payload["id"], _ = qdrant.NewValue(group.ID)
-	payload["projectId"], _ = qdrant.NewValue(group.ProjectID)
-	payload["groupKey"], _ = qdrant.NewValue(group.GroupKey)
-	payload["title"], _ = qdrant.NewValue(group.Title)
-	payload["description"], _ = qdrant.NewValue(group.Description)
-	payload["createdAt"], _ = qdrant.NewValue(group.CreatedAt.Format(time.RFC3339))
-	payload["updatedAt"], _ = qdrant.NewValue(group.UpdatedAt.Format(time.RFC3339))
-	payload["type"], _ = qdrant.NewValue("group")
+	payload := buildGroupPayload(group)
 
 	// ダミーベクトル（1次元）
 	dummyVector := []float32{1.0}
@@ -859,7 +1792,7 @@ func (s *QdrantStore) AddGroup(ctx context.Context, group *model.Group) error {
 		Wait:           qdrant.PtrOf(true),
 		Points: []*qdrant.PointStruct{
 			{
-				Id:      qdrant.NewIDNum(hashID(group.ID)),
+				Id:      pointID(group.ID),
 				Vectors: qdrant.NewVectors(dummyVector...),
 				Payload: payload,
 			},
@@ -883,7 +1816,7 @@ func (s *QdrantStore) GetGroup(ctx context.Context, id string) (*model.Group, er
 	// IDでポイントを取得
 	points, err := client.Get(ctx, &qdrant.GetPoints{
 		CollectionName: groupColl,
-		Ids:            []*qdrant.PointId{qdrant.NewIDNum(hashID(id))},
+		Ids:            []*qdrant.PointId{pointID(id)},
 		WithPayload:    qdrant.NewWithPayload(true),
 	})
 
@@ -955,7 +1888,7 @@ func (s *QdrantStore) UpdateGroup(ctx context.Context, group *model.Group) error
 	// 存在確認
 	points, err := client.Get(ctx, &qdrant.GetPoints{
 		CollectionName: groupColl,
-		Ids:            []*qdrant.PointId{qdrant.NewIDNum(hashID(group.ID))},
+		Ids:            []*qdrant.PointId{pointID(group.ID)},
 		WithPayload:    qdrant.NewWithPayload(false),
 	})
 
@@ -968,15 +1901,7 @@ func (s *QdrantStore) UpdateGroup(ctx context.Context, group *model.Group) error
 	}
 
 	// payloadを構築
-	payload := make(map[string]*qdrant.Value)
-	payload["id"], _ = qdrant.NewValue(group.ID)
-	payload["projectId"], _ = qdrant.NewValue(group.ProjectID)
-	payload["groupKey"], _ = qdrant.NewValue(group.GroupKey)
-	payload["title"], _ = qdrant.NewValue(group.Title)
-	payload["description"], _ = qdrant.NewValue(group.Description)
-	payload["createdAt"], _ = qdrant.NewValue(group.CreatedAt.Format(time.RFC3339))
-	payload["updatedAt"], _ = qdrant.NewValue(group.UpdatedAt.Format(time.RFC3339))
-	payload["type"], _ = qdrant.NewValue("group")
+	payload := buildGroupPayload(group)
 
 	// ダミーベクトル（1次元）
 	dummyVector := []float32{1.0}
@@ -987,7 +1912,7 @@ func (s *QdrantStore) UpdateGroup(ctx context.Context, group *model.Group) error
 		Wait:           qdrant.PtrOf(true),
 		Points: []*qdrant.PointStruct{
 			{
-				Id:      qdrant.NewIDNum(hashID(group.ID)),
+				Id:      pointID(group.ID),
 				Vectors: qdrant.NewVectors(dummyVector...),
 				Payload: payload,
 			},
@@ -1001,6 +1926,42 @@ func (s *QdrantStore) UpdateGroup(ctx context.Context, group *model.Group) error
 	return nil
 }
 
+// RenameGroup はgroupKeyを変更し、そのグループのノートのgroupId・子グループのparentGroupIdも書き換える
+// Qdrantにはトランザクションがないため、ノート（フィルタ指定の一括更新）・子グループ・グループの順に更新する
+func (s *QdrantStore) RenameGroup(ctx context.Context, id, groupKey string) (int, error) {
+	group, err := s.GetGroup(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	oldKey := group.GroupKey
+
+	count, err := s.MoveNotesToGroup(ctx, group.ProjectID, oldKey, groupKey)
+	if err != nil {
+		return 0, err
+	}
+
+	groups, err := s.ListGroups(ctx, group.ProjectID)
+	if err != nil {
+		return count, err
+	}
+	for _, child := range groups {
+		if child.ParentGroupID != oldKey {
+			continue
+		}
+		child.ParentGroupID = groupKey
+		if err := s.UpdateGroup(ctx, child); err != nil {
+			return count, fmt.Errorf("failed to update child group %s: %w", child.GroupKey, err)
+		}
+	}
+
+	group.GroupKey = groupKey
+	group.UpdatedAt = time.Now().UTC()
+	if err := s.UpdateGroup(ctx, group); err != nil {
+		return count, err
+	}
+	return count, nil
+}
+
 // DeleteGroup はグループを削除する
 func (s *QdrantStore) DeleteGroup(ctx context.Context, id string) error {
 	client, _, _, groupColl, err := s.acquireClientWithCollections()
@@ -1011,7 +1972,7 @@ func (s *QdrantStore) DeleteGroup(ctx context.Context, id string) error {
 	// 存在確認
 	points, err := client.Get(ctx, &qdrant.GetPoints{
 		CollectionName: groupColl,
-		Ids:            []*qdrant.PointId{qdrant.NewIDNum(hashID(id))},
+		Ids:            []*qdrant.PointId{pointID(id)},
 		WithPayload:    qdrant.NewWithPayload(false),
 	})
 
@@ -1026,7 +1987,7 @@ func (s *QdrantStore) DeleteGroup(ctx context.Context, id string) error {
 	// ポイントを削除
 	_, err = client.Delete(ctx, &qdrant.DeletePoints{
 		CollectionName: groupColl,
-		Points:         qdrant.NewPointsSelector(qdrant.NewIDNum(hashID(id))),
+		Points:         qdrant.NewPointsSelector(pointID(id)),
 	})
 
 	if err != nil {
@@ -1074,7 +2035,7 @@ func (s *QdrantStore) ListGroups(ctx context.Context, projectID string) ([]*mode
 		for _, point := range scrollResp {
 			group, err := payloadToGroup(point.Payload)
 			if err != nil {
-				log.Printf("warning: failed to convert payload to group in ListGroups: %v", err)
+				slog.Warn("failed to convert payload to group in ListGroups", "pointID", point.Id.String(), "error", err)
 				continue
 			}
 			groups = append(groups, group)
@@ -1183,9 +2144,64 @@ func payloadToGlobalConfig(payload map[string]*qdrant.Value) (*model.GlobalConfi
 		}
 	}
 
+	if v, ok := payload["updatedBy"]; ok {
+		config.UpdatedBy = v.GetStringValue()
+	}
+	if v, ok := payload["history"]; ok && v.GetListValue() != nil {
+		for _, item := range v.GetListValue().Values {
+			fields := item.GetStructValue().GetFields()
+			version := model.GlobalConfigVersion{
+				Value:     convertQdrantValue(fields["value"]),
+				UpdatedBy: fields["updatedBy"].GetStringValue(),
+			}
+			if updatedAt := fields["updatedAt"].GetStringValue(); updatedAt != "" {
+				version.UpdatedAt = &updatedAt
+			}
+			config.History = append(config.History, version)
+		}
+	}
+
 	return config, nil
 }
 
+// buildGroupPayload はGroupからQdrantのpayloadを構築する
+func buildGroupPayload(group *model.Group) map[string]*qdrant.Value {
+	payload := make(map[string]*qdrant.Value)
+	payload["id"], _ = qdrant.NewValue(group.ID)
+	payload["projectId"], _ = qdrant.NewValue(group.ProjectID)
+	payload["groupKey"], _ = qdrant.NewValue(group.GroupKey)
+	payload["title"], _ = qdrant.NewValue(group.Title)
+	payload["description"], _ = qdrant.NewValue(group.Description)
+	payload["createdAt"], _ = qdrant.NewValue(group.CreatedAt.Format(time.RFC3339))
+	payload["updatedAt"], _ = qdrant.NewValue(group.UpdatedAt.Format(time.RFC3339))
+	payload["type"], _ = qdrant.NewValue("group")
+
+	if len(group.Tags) > 0 {
+		tagValues := make([]*qdrant.Value, len(group.Tags))
+		for i, tag := range group.Tags {
+			tagValues[i], _ = qdrant.NewValue(tag)
+		}
+		payload["tags"] = qdrant.NewValueList(&qdrant.ListValue{Values: tagValues})
+	}
+	if group.Status != "" {
+		payload["status"], _ = qdrant.NewValue(group.Status)
+	}
+	if group.ParentGroupID != "" {
+		payload["parentGroupId"], _ = qdrant.NewValue(group.ParentGroupID)
+	}
+	// metadata をJSON経由で変換
+	if len(group.Metadata) > 0 {
+		if jsonBytes, err := json.Marshal(group.Metadata); err == nil {
+			var metadataMap map[string]any
+			if err := json.Unmarshal(jsonBytes, &metadataMap); err == nil {
+				payload["metadata"], _ = qdrant.NewValue(metadataMap)
+			}
+		}
+	}
+
+	return payload
+}
+
 // payloadToGroup はQdrantのpayloadからGroupを構築する
 func payloadToGroup(payload map[string]*qdrant.Value) (*model.Group, error) {
 	group := &model.Group{}
@@ -1215,6 +2231,24 @@ func payloadToGroup(payload map[string]*qdrant.Value) (*model.Group, error) {
 			group.UpdatedAt = t
 		}
 	}
+	if v, ok := payload["tags"]; ok && v.GetListValue() != nil {
+		for _, item := range v.GetListValue().Values {
+			if s := item.GetStringValue(); s != "" {
+				group.Tags = append(group.Tags, s)
+			}
+		}
+	}
+	if v, ok := payload["status"]; ok && v.GetStringValue() != "" {
+		group.Status = v.GetStringValue()
+	}
+	if v, ok := payload["parentGroupId"]; ok && v.GetStringValue() != "" {
+		group.ParentGroupID = v.GetStringValue()
+	}
+	if v, ok := payload["metadata"]; ok && v != nil {
+		if metadata, ok := convertQdrantValue(v).(map[string]any); ok {
+			group.Metadata = metadata
+		}
+	}
 
 	return group, nil
 }
@@ -0,0 +1,161 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/qdrant/go-client/qdrant"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// qdrantMaxReconnectDelay はQdrantが落ちている間の再接続の間隔の上限（gRPCのデフォルトは120秒）
+	qdrantMaxReconnectDelay = 10 * time.Second
+	// qdrantRetryDelay は読み取りを再試行するまでの待ち時間
+	qdrantRetryDelay = 200 * time.Millisecond
+
+	// defaultQdrantBreakerThreshold は回路を開くまでの連続した接続エラーの回数
+	defaultQdrantBreakerThreshold = 5
+	// defaultQdrantBreakerCooldown は回路を開いてから試しに1件通すまでの時間
+	defaultQdrantBreakerCooldown = 10 * time.Second
+)
+
+// qdrantIdempotentMethods は同じリクエストを再送しても結果が変わらない読み取りのgRPCメソッド
+// ヘルスチェックはNewQdrantStoreがmaxRetriesに従って再試行するため含めない
+var qdrantIdempotentMethods = map[string]bool{
+	qdrant.Points_Get_FullMethodName:                   true,
+	qdrant.Points_Search_FullMethodName:                true,
+	qdrant.Points_Query_FullMethodName:                 true,
+	qdrant.Points_Scroll_FullMethodName:                true,
+	qdrant.Points_Count_FullMethodName:                 true,
+	qdrant.Collections_Get_FullMethodName:              true,
+	qdrant.Collections_List_FullMethodName:             true,
+	qdrant.Collections_CollectionExists_FullMethodName: true,
+}
+
+// isQdrantUnavailable はerrがQdrantに接続できない（再起動中・応答しない）ことによるエラーか判定する
+func isQdrantUnavailable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	}
+	return false
+}
+
+// IsUnavailable はerrがストアに接続できない一時的な障害（接続失敗・回路が開いている・Qdrantが応答しない）によるものか判定する
+// リクエストの誤りではないため、時間をおいて同じリクエストを再試行できる
+func IsUnavailable(err error) bool {
+	return errors.Is(err, ErrConnectionFailed) || errors.Is(err, ErrCircuitOpen) || isQdrantUnavailable(err)
+}
+
+// retryReadInterceptor は接続エラーで失敗した読み取りを1回だけ再試行する
+// 再試行の前に再接続の待ち時間をリセットし、Qdrantの再起動後にすぐ繋ぎ直す
+func retryReadInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	if status.Code(err) != codes.Unavailable {
+		return err
+	}
+	if cc != nil {
+		cc.ResetConnectBackoff()
+	}
+	if !qdrantIdempotentMethods[method] {
+		return err
+	}
+	select {
+	case <-ctx.Done():
+		return err
+	case <-time.After(qdrantRetryDelay):
+	}
+	slog.Debug("retrying qdrant request", "method", method, "error", err)
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// circuitBreaker はQdrantへの接続エラーが続いた場合に回路を開き、cooldownの間はリクエストを送らずにすぐ失敗させる
+// cooldown後は1件だけ試しに通し（half-open）、成功すれば閉じ、失敗すれば再び開く
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	failures int       // 連続した接続エラーの回数
+	openedAt time.Time // 回路を開いた時刻（閉じていればゼロ）
+	probing  bool      // half-openで試しのリクエストを送っている
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+// allow はリクエストを送ってよいか判定する（回路が開いている場合は再開までの時間を返す）
+func (b *circuitBreaker) allow() (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openedAt.IsZero() {
+		return true, 0
+	}
+	if wait := b.cooldown - b.now().Sub(b.openedAt); wait > 0 {
+		return false, wait
+	}
+	if b.probing {
+		return false, 0
+	}
+	b.probing = true
+	return true, 0
+}
+
+// record はリクエストの結果を記録する
+func (b *circuitBreaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if !isQdrantUnavailable(err) {
+		if !b.openedAt.IsZero() {
+			slog.Info("qdrant is reachable again; circuit breaker closed")
+		}
+		b.failures = 0
+		b.openedAt = time.Time{}
+		return
+	}
+	b.failures++
+	if !b.openedAt.IsZero() {
+		// half-openの試しのリクエストが失敗した
+		b.openedAt = b.now()
+		return
+	}
+	if b.failures >= b.threshold {
+		b.openedAt = b.now()
+		slog.Warn("qdrant is unavailable; circuit breaker opened", "failures", b.failures, "cooldown", b.cooldown, "error", err)
+	}
+}
+
+// interceptor は回路が開いている間、リクエストを送らずにErrCircuitOpenを返すgRPCのインターセプター
+// ヘルスチェックは接続の確認そのものなので対象にしない
+func (b *circuitBreaker) interceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if method == qdrant.Qdrant_HealthCheck_FullMethodName {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	ok, wait := b.allow()
+	if !ok {
+		if wait > 0 {
+			return fmt.Errorf("%w (retrying in %s)", ErrCircuitOpen, wait.Round(time.Second))
+		}
+		return ErrCircuitOpen
+	}
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	// 呼び出し元のキャンセルはQdrantの状態と関係ない
+	if status.Code(err) == codes.Canceled {
+		b.mu.Lock()
+		b.probing = false
+		b.mu.Unlock()
+		return err
+	}
+	b.record(err)
+	return err
+}
@@ -0,0 +1,134 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/qdrant/go-client/qdrant"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeInvoker はerrsを順に返し、呼び出し回数を数えるgRPCのinvoker
+type fakeInvoker struct {
+	errs  []error
+	calls int
+}
+
+func (f *fakeInvoker) invoke(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+	f.calls++
+	if len(f.errs) == 0 {
+		return nil
+	}
+	err := f.errs[0]
+	f.errs = f.errs[1:]
+	return err
+}
+
+// TestRetryReadInterceptor は接続エラーで失敗した読み取りのみ1回再試行することをテスト
+func TestRetryReadInterceptor(t *testing.T) {
+	ctx := context.Background()
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+
+	inv := &fakeInvoker{errs: []error{unavailable}}
+	if err := retryReadInterceptor(ctx, qdrant.Points_Search_FullMethodName, nil, nil, nil, inv.invoke); err != nil || inv.calls != 2 {
+		t.Errorf("read: err=%v, calls=%d", err, inv.calls)
+	}
+
+	// 再試行は1回だけ
+	inv = &fakeInvoker{errs: []error{unavailable, unavailable}}
+	if err := retryReadInterceptor(ctx, qdrant.Points_Get_FullMethodName, nil, nil, nil, inv.invoke); status.Code(err) != codes.Unavailable || inv.calls != 2 {
+		t.Errorf("read: err=%v, calls=%d", err, inv.calls)
+	}
+
+	// 書き込みは届いていた場合に二重になるため再試行しない
+	inv = &fakeInvoker{errs: []error{unavailable}}
+	if err := retryReadInterceptor(ctx, qdrant.Points_Upsert_FullMethodName, nil, nil, nil, inv.invoke); err == nil || inv.calls != 1 {
+		t.Errorf("write: err=%v, calls=%d", err, inv.calls)
+	}
+
+	// 接続以外のエラーは再試行しない
+	inv = &fakeInvoker{errs: []error{status.Error(codes.NotFound, "collection not found")}}
+	if err := retryReadInterceptor(ctx, qdrant.Points_Search_FullMethodName, nil, nil, nil, inv.invoke); err == nil || inv.calls != 1 {
+		t.Errorf("not found: err=%v, calls=%d", err, inv.calls)
+	}
+}
+
+// TestCircuitBreaker は接続エラーが続くと回路を開き、cooldown後の試しのリクエストで閉じることをテスト
+func TestCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	b := newCircuitBreaker(2, 10*time.Second)
+	b.now = func() time.Time { return now }
+	call := func(inv *fakeInvoker) error {
+		return b.interceptor(ctx, qdrant.Points_Search_FullMethodName, nil, nil, nil, inv.invoke)
+	}
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+
+	inv := &fakeInvoker{errs: []error{unavailable, status.Error(codes.DeadlineExceeded, "timeout")}}
+	call(inv)
+	call(inv)
+	// 回路が開いている間は送らずに失敗する
+	if err := call(inv); !errors.Is(err, ErrCircuitOpen) || inv.calls != 2 {
+		t.Fatalf("expected ErrCircuitOpen without a request, got %v (calls=%d)", err, inv.calls)
+	}
+	// ヘルスチェックは回路によらず送る
+	if err := b.interceptor(ctx, qdrant.Qdrant_HealthCheck_FullMethodName, nil, nil, nil, inv.invoke); err != nil || inv.calls != 3 {
+		t.Errorf("health check: err=%v, calls=%d", err, inv.calls)
+	}
+
+	// cooldown後の試しのリクエストが失敗すると再び開く
+	now = now.Add(11 * time.Second)
+	inv = &fakeInvoker{errs: []error{unavailable}}
+	if err := call(inv); status.Code(err) != codes.Unavailable || inv.calls != 1 {
+		t.Fatalf("probe: err=%v, calls=%d", err, inv.calls)
+	}
+	if err := call(inv); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected the circuit to reopen, got %v", err)
+	}
+
+	// 試しのリクエストが成功すると閉じる
+	now = now.Add(11 * time.Second)
+	inv = &fakeInvoker{}
+	if err := call(inv); err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if err := call(inv); err != nil || inv.calls != 2 {
+		t.Errorf("expected the circuit to be closed: err=%v, calls=%d", err, inv.calls)
+	}
+
+	// 接続以外のエラーは数えない
+	inv = &fakeInvoker{errs: []error{status.Error(codes.NotFound, "a"), status.Error(codes.NotFound, "b"), status.Error(codes.NotFound, "c")}}
+	for range 3 {
+		call(inv)
+	}
+	if err := call(inv); err != nil || inv.calls != 4 {
+		t.Errorf("non-connection errors must not open the circuit: err=%v, calls=%d", err, inv.calls)
+	}
+}
+
+// TestIsUnavailable はラップされた接続エラーも一時的な障害と判定することをテスト
+func TestIsUnavailable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{ErrCircuitOpen, true},
+		{fmt.Errorf("failed to search: %w", ErrCircuitOpen), true},
+		{ErrConnectionFailed, true},
+		{fmt.Errorf("failed to get note: %w", status.Error(codes.Unavailable, "connection refused")), true},
+		{status.Error(codes.DeadlineExceeded, "deadline exceeded"), true},
+		{status.Error(codes.InvalidArgument, "wrong vector size"), false},
+		{ErrNotFound, false},
+		{errors.New("other"), false},
+	}
+	for _, tt := range tests {
+		if got := IsUnavailable(tt.err); got != tt.want {
+			t.Errorf("IsUnavailable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
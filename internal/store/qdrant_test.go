@@ -3,10 +3,15 @@ package store
 import (
 	"context"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/store/naming"
+	"github.com/qdrant/go-client/qdrant"
+	"google.golang.org/grpc"
 )
 
 const (
@@ -583,6 +588,297 @@ func TestQdrantStore_ListRecent_Basic(t *testing.T) {
 	}
 }
 
+// TestBuildPayload_Attachments はattachmentsがpayloadを経由して復元されることをテスト（Qdrant不要）
+func TestBuildPayload_Attachments(t *testing.T) {
+	note := newQdrantTestNote("attach-1", testQdrantProjectID, testQdrantGroupID, "Decision")
+	note.Attachments = []model.Attachment{{Path: "docs/design.md", Hash: "sha256:abcd"}, {Path: "diff.patch"}}
+
+	got, err := payloadToNote(buildPayload(note))
+	if err != nil {
+		t.Fatalf("payloadToNote failed: %v", err)
+	}
+	if len(got.Attachments) != 2 || got.Attachments[0] != note.Attachments[0] || got.Attachments[1] != note.Attachments[1] {
+		t.Errorf("unexpected attachments: %+v", got.Attachments)
+	}
+
+	note.Attachments = nil
+	if got, _ := payloadToNote(buildPayload(note)); got.Attachments != nil {
+		t.Errorf("expected no attachments, got %+v", got.Attachments)
+	}
+}
+
+func TestBuildPayload_ParentChunk(t *testing.T) {
+	note := newQdrantTestNote("chunk-1", testQdrantProjectID, testQdrantGroupID, "Second chunk")
+	parentID, index := "chunk-0", 1
+	note.ParentID, note.ChunkIndex = &parentID, &index
+
+	got, err := payloadToNote(buildPayload(note))
+	if err != nil {
+		t.Fatalf("payloadToNote failed: %v", err)
+	}
+	if got.ParentID == nil || *got.ParentID != parentID || got.ChunkIndex == nil || *got.ChunkIndex != index {
+		t.Errorf("unexpected parentId/chunkIndex: %v/%v", got.ParentID, got.ChunkIndex)
+	}
+	if got.Importance != nil {
+		t.Errorf("expected no importance, got %v", *got.Importance)
+	}
+	importance := 0.75
+	note.Importance = &importance
+	if got, _ := payloadToNote(buildPayload(note)); got.Importance == nil || *got.Importance != importance {
+		t.Errorf("unexpected importance: %v", got.Importance)
+	}
+
+	note.ParentID, note.ChunkIndex = nil, nil
+	if got, _ := payloadToNote(buildPayload(note)); got.ParentID != nil || got.ChunkIndex != nil {
+		t.Errorf("expected no parent, got %v/%v", got.ParentID, got.ChunkIndex)
+	}
+}
+
+// TestBuildGroupPayload はグループのtags・status・metadataが保存・復元されることをテスト（Qdrant不要）
+func TestBuildGroupPayload(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	group := &model.Group{
+		ID: "group-1", ProjectID: testQdrantProjectID, GroupKey: "sprint-12", Title: "Sprint 12",
+		CreatedAt: now, UpdatedAt: now,
+		Tags: []string{"sprint", "backend"}, Status: model.GroupStatusDone,
+		Metadata:      map[string]any{"owner": "alice", "ticket": "PROJ-42", "points": float64(13)},
+		ParentGroupID: "backend",
+	}
+
+	got, err := payloadToGroup(buildGroupPayload(group))
+	if err != nil {
+		t.Fatalf("payloadToGroup failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, group) {
+		t.Errorf("group mismatch:\ngot  %+v\nwant %+v", got, group)
+	}
+
+	group.Tags, group.Status, group.Metadata, group.ParentGroupID = nil, "", nil, ""
+	if got, _ := payloadToGroup(buildGroupPayload(group)); got.Tags != nil || got.Status != "" || got.Metadata != nil || got.ParentGroupID != "" {
+		t.Errorf("expected no annotations, got %+v", got)
+	}
+}
+
+// TestPointID_NoteIDSchemes はどのID形式でも決定的に異なるUUIDの点IDになり、元のIDが復元されることをテスト（Qdrant不要）
+func TestPointID_NoteIDSchemes(t *testing.T) {
+	ids := []string{
+		"0b5e0f4c-6d1a-4c8e-9a52-1f0f3b6c2d11",
+		"0B5E0F4C-6D1A-4C8E-9A52-1F0F3B6C2D11", // 正規形でないUUIDは別のIDとして扱う
+		"01927b3c-8f2e-7a61-b5d4-3c2e1f0a9b87",
+		"01J9XK3M5QZ8T2V7W4N6R0P1HS",
+		"myproject.global.01J9XK3M5QZ8T2V7W4N6R0P1HS",
+		"global:/test/project:global.memory.embedder.provider",
+	}
+	seen := make(map[string]string)
+	for _, id := range ids {
+		p := pointID(id).GetUuid()
+		if p == "" || p != pointID(id).GetUuid() {
+			t.Errorf("pointID(%q) is not a deterministic uuid: %q", id, p)
+		}
+		if other, ok := seen[p]; ok {
+			t.Errorf("pointID collision between %q and %q", id, other)
+		}
+		seen[p] = id
+
+		got, err := payloadToNote(buildPayload(newQdrantTestNote(id, testQdrantProjectID, testQdrantGroupID, "text")))
+		if err != nil || got.ID != id {
+			t.Errorf("expected id %q to round-trip, got %v (%v)", id, got, err)
+		}
+	}
+	// 正規形のUUIDはそのまま点IDにする
+	if got := pointID(ids[0]).GetUuid(); got != ids[0] {
+		t.Errorf("expected the uuid to be used as is, got %q", got)
+	}
+}
+
+// TestQdrantStore_MigratePointIDs は以前の数値IDのポイントがInitializeでUUIDのポイントに移し替えられることをテスト
+func TestQdrantStore_MigratePointIDs(t *testing.T) {
+	store := setupInitializedQdrantStore(t)
+	defer store.Close()
+	ctx := context.Background()
+
+	// 以前のバージョンと同じく、数値IDでノートとグループを書き込む
+	note := newQdrantTestNote("legacy-note", testQdrantProjectID, testQdrantGroupID, "legacy text")
+	createdAt := "2024-01-15T10:30:00Z"
+	note.CreatedAt = &createdAt
+	group := &model.Group{ID: "legacy-group", ProjectID: testQdrantProjectID, GroupKey: "legacy", Title: "Legacy"}
+	for _, p := range []struct {
+		collection string
+		point      *qdrant.PointStruct
+	}{
+		{testQdrantNamespace, &qdrant.PointStruct{Id: qdrant.NewIDNum(1), Vectors: qdrant.NewVectors(dummyQdrantEmbedding(1536)...), Payload: buildPayload(note)}},
+		{testQdrantNamespace + "_groups", &qdrant.PointStruct{Id: qdrant.NewIDNum(2), Vectors: qdrant.NewVectors(1), Payload: buildGroupPayload(group)}},
+	} {
+		if _, err := store.client.Upsert(ctx, &qdrant.UpsertPoints{CollectionName: p.collection, Wait: qdrant.PtrOf(true), Points: []*qdrant.PointStruct{p.point}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := store.Get(ctx, "legacy-note"); err != ErrNotFound {
+		t.Fatalf("expected the legacy note not to be found before the migration, got %v", err)
+	}
+
+	if err := store.Initialize(ctx, testQdrantNamespace); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.Get(ctx, "legacy-note")
+	if err != nil || got.Text != "legacy text" {
+		t.Fatalf("expected the migrated note, got %+v (%v)", got, err)
+	}
+	embedding, err := store.GetEmbedding(ctx, "legacy-note")
+	if err != nil || len(embedding) != 1536 {
+		t.Errorf("expected the embedding to be migrated, got %v (%v)", embedding, err)
+	}
+	if g, err := store.GetGroup(ctx, "legacy-group"); err != nil || g.GroupKey != "legacy" {
+		t.Errorf("expected the migrated group, got %+v (%v)", g, err)
+	}
+	notes, err := store.ListNotes(ctx, testQdrantProjectID)
+	if err != nil || len(notes) != 1 {
+		t.Errorf("expected the legacy point to be removed, got %d notes (%v)", len(notes), err)
+	}
+}
+
+// TestQdrantStore_LegacyCollectionNames は以前の名前のコレクションがあればその名前を使い続けることをテスト
+func TestQdrantStore_LegacyCollectionNames(t *testing.T) {
+	store := setupQdrantTestStore(t)
+	defer store.Close()
+	ctx := context.Background()
+
+	// 以前のバージョンは : だけを置き換えていた（+ はそのまま）
+	namespace := "test+legacy:mock:3"
+	legacy := naming.LegacyQdrant(namespace, naming.SuffixNotes)
+	for _, suffix := range []string{naming.SuffixNotes, naming.SuffixGlobalConfigs, naming.SuffixGroups} {
+		_ = store.client.DeleteCollection(ctx, naming.Qdrant.Collection(namespace, suffix))
+		_ = store.client.DeleteCollection(ctx, naming.LegacyQdrant(namespace, suffix))
+	}
+	err := store.client.CreateCollection(ctx, &qdrant.CreateCollection{
+		CollectionName: legacy,
+		VectorsConfig:  qdrant.NewVectorsConfig(&qdrant.VectorParams{Size: 3, Distance: qdrant.Distance_Cosine}),
+	})
+	if err != nil {
+		t.Skipf("qdrant does not accept the legacy name %q: %v", legacy, err)
+	}
+
+	if err := store.Initialize(ctx, namespace); err != nil {
+		t.Fatal(err)
+	}
+	if store.noteCollection() != legacy {
+		t.Errorf("expected the legacy collection %q, got %q", legacy, store.noteCollection())
+	}
+	if got := store.groupCollection(); got != naming.Qdrant.Collection(namespace, naming.SuffixGroups) {
+		t.Errorf("expected a new groups collection, got %q", got)
+	}
+}
+
+// TestQdrantStore_LongNamespace はコレクション名を切り詰めるnamespaceでもグループを操作できることをテスト
+func TestQdrantStore_LongNamespace(t *testing.T) {
+	store := setupQdrantTestStore(t)
+	defer store.Close()
+	ctx := context.Background()
+
+	// ノートのコレクション名は収まるが、_groupsを付けると255文字を超える
+	namespace := "test:" + strings.Repeat("m", 244) + ":3"
+	if err := store.Initialize(ctx, namespace); err != nil {
+		t.Fatal(err)
+	}
+	group := &model.Group{ID: "long-group", ProjectID: testQdrantProjectID, GroupKey: "long", Title: "Long"}
+	if err := store.AddGroup(ctx, group); err != nil {
+		t.Fatalf("AddGroup failed: %v", err)
+	}
+	if got, err := store.GetGroup(ctx, "long-group"); err != nil || got.GroupKey != "long" {
+		t.Errorf("expected the group, got %+v (%v)", got, err)
+	}
+}
+
+// TestQdrantStore_ScanNotes_CorruptPayload はprojectId・idが壊れたポイントもScanNotesで読め、そのIDで削除できることをテスト
+func TestQdrantStore_ScanNotes_CorruptPayload(t *testing.T) {
+	store := setupInitializedQdrantStore(t)
+	defer store.Close()
+	ctx := context.Background()
+
+	note := newQdrantTestNote("scan-ok", testQdrantProjectID, testQdrantGroupID, "ok")
+	if err := store.AddNote(ctx, note, dummyQdrantEmbedding(1536)); err != nil {
+		t.Fatal(err)
+	}
+	corruptID := "5b0c1d2e-3f4a-4b5c-8d6e-7f8091a2b3c4"
+	payload := map[string]*qdrant.Value{}
+	payload["projectId"], _ = qdrant.NewValue(int64(42))
+	payload["text"], _ = qdrant.NewValue("orphan")
+	if _, err := store.client.Upsert(ctx, &qdrant.UpsertPoints{CollectionName: store.noteCollection(), Wait: qdrant.PtrOf(true), Points: []*qdrant.PointStruct{
+		{Id: qdrant.NewIDUUID(corruptID), Vectors: qdrant.NewVectors(dummyQdrantEmbedding(1536)...), Payload: payload},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	if notes, _ := store.ListNotes(ctx, testQdrantProjectID); len(notes) != 1 {
+		t.Fatalf("expected ListNotes to skip the corrupt point, got %d notes", len(notes))
+	}
+
+	raws := map[string]RawNote{}
+	if err := store.ScanNotes(ctx, func(raw RawNote) error {
+		raws[raw.ID] = raw
+		return nil
+	}); err != nil {
+		t.Fatalf("ScanNotes failed: %v", err)
+	}
+	corrupt, ok := raws[corruptID]
+	if !ok || corrupt.Note.ProjectID != "" || corrupt.Payload["projectId"] != int64(42) || len(corrupt.Embedding) != 1536 {
+		t.Fatalf("expected the corrupt point, got %+v", raws)
+	}
+	if raw := raws["scan-ok"]; raw.Note == nil || raw.Note.Text != "ok" {
+		t.Errorf("expected the valid note by its id, got %+v", raw)
+	}
+	if err := store.Delete(ctx, corruptID); err != nil {
+		t.Errorf("failed to delete the corrupt point: %v", err)
+	}
+}
+
+// TestQdrantStore_ListRecent_SortByUpdatedAt はupdatedAt降順のソートをテスト
+func TestQdrantStore_ListRecent_SortByUpdatedAt(t *testing.T) {
+	store := setupInitializedQdrantStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	embedding := dummyQdrantEmbedding(1536)
+
+	createdAt1 := "2024-01-10T10:00:00Z"
+	createdAt2 := "2024-01-15T10:00:00Z"
+	note1 := &model.Note{ID: "sort-1", ProjectID: testQdrantProjectID, GroupID: testQdrantGroupID, Text: "Note 1", CreatedAt: &createdAt1, Tags: []string{}}
+	note2 := &model.Note{ID: "sort-2", ProjectID: testQdrantProjectID, GroupID: testQdrantGroupID, Text: "Note 2", CreatedAt: &createdAt2, Tags: []string{}}
+	store.AddNote(ctx, note1, embedding)
+	store.AddNote(ctx, note2, embedding)
+
+	note1.Text = "Note 1 updated"
+	if err := store.Update(ctx, note1, embedding); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	notes, err := store.ListRecent(ctx, ListOptions{ProjectID: testQdrantProjectID, Limit: 10, SortBy: SortByUpdatedAt})
+	if err != nil {
+		t.Fatalf("ListRecent failed: %v", err)
+	}
+	if len(notes) != 2 || notes[0].ID != "sort-1" {
+		t.Fatalf("expected the updated note first, got %d notes", len(notes))
+	}
+	if notes[0].UpdatedAt == nil || *notes[0].UpdatedAt <= createdAt2 {
+		t.Errorf("expected updatedAt to advance, got %v", notes[0].UpdatedAt)
+	}
+}
+
+// TestQdrantStore_ListRecent_Pagination はカーソルによるページ送りと件数をテスト
+func TestQdrantStore_ListRecent_Pagination(t *testing.T) {
+	store := setupInitializedQdrantStore(t)
+	defer store.Close()
+
+	testListPagination(t, store, testQdrantProjectID, 1536)
+}
+
+// TestQdrantStore_StaleNotes は最終アクセスの記録と古いノートの一覧をテスト
+func TestQdrantStore_StaleNotes(t *testing.T) {
+	store := setupInitializedQdrantStore(t)
+	defer store.Close()
+
+	testStaleNotes(t, store, testQdrantProjectID, 1536)
+}
+
 // TestQdrantStore_ListRecent_WithLimit はLimit指定をテスト
 func TestQdrantStore_ListRecent_WithLimit(t *testing.T) {
 	store := setupInitializedQdrantStore(t)
@@ -1152,11 +1448,11 @@ func TestParseVectorDim(t *testing.T) {
 		{"openai:text-embedding-3-large:3072", 3072},
 		{"ollama:nomic-embed-text:768", 768},
 		{"provider:model:256", 256},
-		{"invalid-namespace", 1536},         // デフォルト
-		{"provider:model:invalid", 1536},    // パース失敗時デフォルト
-		{"single-part", 1536},               // デフォルト
-		{"two:parts", 1536},                 // デフォルト
-		{"", 1536},                          // 空文字でデフォルト
+		{"invalid-namespace", 1536},      // デフォルト
+		{"provider:model:invalid", 1536}, // パース失敗時デフォルト
+		{"single-part", 1536},            // デフォルト
+		{"two:parts", 1536},              // デフォルト
+		{"", 1536},                       // 空文字でデフォルト
 	}
 
 	for _, tt := range tests {
@@ -1168,3 +1464,51 @@ func TestParseVectorDim(t *testing.T) {
 		})
 	}
 }
+
+// TestQdrantOptions は接続の調整値がqdrant.Configへ反映されることをテスト（サーバー不要）
+func TestQdrantOptions(t *testing.T) {
+	o := &qdrantOptions{}
+	for _, opt := range []QdrantOption{
+		WithQdrantKeepAlive(1500 * time.Millisecond),
+		WithQdrantKeepAliveTimeout(time.Second),
+		WithQdrantPoolSize(1),
+		WithQdrantMaxRetries(2),
+	} {
+		opt(o)
+	}
+	if o.config.KeepAliveTime != 2 || o.config.KeepAliveTimeout != 1 || o.config.PoolSize != 1 || o.maxRetries != 2 {
+		t.Errorf("unexpected options: %+v", o)
+	}
+
+	WithQdrantKeepAlive(0)(o)
+	if o.config.KeepAliveTime != -1 {
+		t.Errorf("expected keepalive to be disabled, got %d", o.config.KeepAliveTime)
+	}
+}
+
+// TestRequestTimeoutInterceptor は期限のないリクエストにのみタイムアウトが付くことをテスト
+func TestRequestTimeoutInterceptor(t *testing.T) {
+	interceptor := requestTimeoutInterceptor(time.Minute)
+	var deadline time.Time
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		deadline, _ = ctx.Deadline()
+		return nil
+	}
+
+	if err := interceptor(context.Background(), "/m", nil, nil, nil, invoker); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Until(deadline); d <= 0 || d > time.Minute {
+		t.Errorf("expected a deadline within a minute, got %v", d)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	want, _ := ctx.Deadline()
+	if err := interceptor(ctx, "/m", nil, nil, nil, invoker); err != nil {
+		t.Fatal(err)
+	}
+	if !deadline.Equal(want) {
+		t.Errorf("expected the caller's deadline to be kept, got %v", deadline)
+	}
+}
@@ -0,0 +1,210 @@
+package store
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+)
+
+// slowLogStore は閾値以上かかった操作を警告としてログに出すStore
+// ログにはメソッド名・所要時間・namespaceと、引数の要約（ID・projectId・件数など。本文やベクトルは含めない）を出す
+type slowLogStore struct {
+	Store
+	threshold time.Duration
+	namespace string
+}
+
+// NewSlowLogStore はthreshold以上かかった操作をslogで警告するStoreを返す（thresholdが0以下ならsをそのまま返す）
+func NewSlowLogStore(s Store, threshold time.Duration) Store {
+	if threshold <= 0 {
+		return s
+	}
+	return &slowLogStore{Store: s, threshold: threshold}
+}
+
+// observe はstartからの経過時間が閾値以上なら警告を出す（deferで呼ぶ）
+func (s *slowLogStore) observe(method string, start time.Time, args ...any) {
+	d := time.Since(start)
+	if d < s.threshold {
+		return
+	}
+	attrs := append([]any{"method", method, "duration", d, "namespace", s.namespace}, args...)
+	slog.Warn("slow store operation", attrs...)
+}
+
+// groupIDAttr はnil許容のgroupIDをログ用の文字列にする
+func groupIDAttr(groupID *string) string {
+	if groupID == nil {
+		return ""
+	}
+	return *groupID
+}
+
+func (s *slowLogStore) AddNote(ctx context.Context, note *model.Note, embedding []float32) error {
+	defer s.observe("AddNote", time.Now(), "projectId", note.ProjectID, "id", note.ID)
+	return s.Store.AddNote(ctx, note, embedding)
+}
+
+func (s *slowLogStore) Get(ctx context.Context, id string) (*model.Note, error) {
+	defer s.observe("Get", time.Now(), "id", id)
+	return s.Store.Get(ctx, id)
+}
+
+func (s *slowLogStore) Update(ctx context.Context, note *model.Note, embedding []float32) error {
+	defer s.observe("Update", time.Now(), "projectId", note.ProjectID, "id", note.ID)
+	return s.Store.Update(ctx, note, embedding)
+}
+
+func (s *slowLogStore) Delete(ctx context.Context, id string) error {
+	defer s.observe("Delete", time.Now(), "id", id)
+	return s.Store.Delete(ctx, id)
+}
+
+func (s *slowLogStore) DeleteNotesByGroup(ctx context.Context, projectID, groupID string) (int, error) {
+	defer s.observe("DeleteNotesByGroup", time.Now(), "projectId", projectID, "groupId", groupID)
+	return s.Store.DeleteNotesByGroup(ctx, projectID, groupID)
+}
+
+func (s *slowLogStore) MoveNotesToGroup(ctx context.Context, projectID, fromGroupID, toGroupID string) (int, error) {
+	defer s.observe("MoveNotesToGroup", time.Now(), "projectId", projectID, "fromGroupId", fromGroupID, "toGroupId", toGroupID)
+	return s.Store.MoveNotesToGroup(ctx, projectID, fromGroupID, toGroupID)
+}
+
+func (s *slowLogStore) Search(ctx context.Context, embedding []float32, opts SearchOptions) ([]SearchResult, error) {
+	defer s.observe("Search", time.Now(), "projectId", opts.ProjectID, "groupId", groupIDAttr(opts.GroupID),
+		"groupIds", len(opts.GroupIDs), "topK", opts.TopK, "tags", opts.Tags, "timeRange", opts.Since != nil || opts.Until != nil)
+	return s.Store.Search(ctx, embedding, opts)
+}
+
+func (s *slowLogStore) ListRecent(ctx context.Context, opts ListOptions) ([]*model.Note, error) {
+	defer s.observe("ListRecent", time.Now(), "projectId", opts.ProjectID, "groupId", groupIDAttr(opts.GroupID),
+		"groupIds", len(opts.GroupIDs), "limit", opts.Limit, "tags", opts.Tags, "sortBy", opts.SortBy, "cursor", opts.After != nil)
+	return s.Store.ListRecent(ctx, opts)
+}
+
+func (s *slowLogStore) CountNotes(ctx context.Context, opts ListOptions) (int, error) {
+	defer s.observe("CountNotes", time.Now(), "projectId", opts.ProjectID, "groupId", groupIDAttr(opts.GroupID),
+		"groupIds", len(opts.GroupIDs), "tags", opts.Tags)
+	return s.Store.CountNotes(ctx, opts)
+}
+
+func (s *slowLogStore) TouchNotes(ctx context.Context, ids []string, accessedAt string) error {
+	defer s.observe("TouchNotes", time.Now(), "ids", len(ids))
+	return s.Store.TouchNotes(ctx, ids, accessedAt)
+}
+
+func (s *slowLogStore) ListStaleNotes(ctx context.Context, projectID string, before time.Time) ([]*model.Note, error) {
+	defer s.observe("ListStaleNotes", time.Now(), "projectId", projectID)
+	return s.Store.ListStaleNotes(ctx, projectID, before)
+}
+
+func (s *slowLogStore) ListNotes(ctx context.Context, projectID string) ([]*model.Note, error) {
+	defer s.observe("ListNotes", time.Now(), "projectId", projectID)
+	return s.Store.ListNotes(ctx, projectID)
+}
+
+func (s *slowLogStore) GetEmbedding(ctx context.Context, id string) ([]float32, error) {
+	defer s.observe("GetEmbedding", time.Now(), "id", id)
+	return s.Store.GetEmbedding(ctx, id)
+}
+
+func (s *slowLogStore) ListGlobals(ctx context.Context, projectID string) ([]*model.GlobalConfig, error) {
+	defer s.observe("ListGlobals", time.Now(), "projectId", projectID)
+	return s.Store.ListGlobals(ctx, projectID)
+}
+
+func (s *slowLogStore) ListProjects(ctx context.Context) ([]ProjectSummary, error) {
+	defer s.observe("ListProjects", time.Now())
+	return s.Store.ListProjects(ctx)
+}
+
+func (s *slowLogStore) GroupStats(ctx context.Context, projectID string) (map[string]GroupStats, error) {
+	defer s.observe("GroupStats", time.Now(), "projectId", projectID)
+	return s.Store.GroupStats(ctx, projectID)
+}
+
+func (s *slowLogStore) UpsertGlobal(ctx context.Context, config *model.GlobalConfig) error {
+	defer s.observe("UpsertGlobal", time.Now(), "projectId", config.ProjectID, "key", config.Key)
+	return s.Store.UpsertGlobal(ctx, config)
+}
+
+func (s *slowLogStore) GetGlobal(ctx context.Context, projectID, key string) (*model.GlobalConfig, bool, error) {
+	defer s.observe("GetGlobal", time.Now(), "projectId", projectID, "key", key)
+	return s.Store.GetGlobal(ctx, projectID, key)
+}
+
+func (s *slowLogStore) GetGlobalByID(ctx context.Context, id string) (*model.GlobalConfig, error) {
+	defer s.observe("GetGlobalByID", time.Now(), "id", id)
+	return s.Store.GetGlobalByID(ctx, id)
+}
+
+func (s *slowLogStore) DeleteGlobalByID(ctx context.Context, id string) error {
+	defer s.observe("DeleteGlobalByID", time.Now(), "id", id)
+	return s.Store.DeleteGlobalByID(ctx, id)
+}
+
+func (s *slowLogStore) AddGroup(ctx context.Context, group *model.Group) error {
+	defer s.observe("AddGroup", time.Now(), "projectId", group.ProjectID, "groupKey", group.GroupKey)
+	return s.Store.AddGroup(ctx, group)
+}
+
+func (s *slowLogStore) GetGroup(ctx context.Context, id string) (*model.Group, error) {
+	defer s.observe("GetGroup", time.Now(), "id", id)
+	return s.Store.GetGroup(ctx, id)
+}
+
+func (s *slowLogStore) GetGroupByKey(ctx context.Context, projectID, groupKey string) (*model.Group, error) {
+	defer s.observe("GetGroupByKey", time.Now(), "projectId", projectID, "groupKey", groupKey)
+	return s.Store.GetGroupByKey(ctx, projectID, groupKey)
+}
+
+func (s *slowLogStore) UpdateGroup(ctx context.Context, group *model.Group) error {
+	defer s.observe("UpdateGroup", time.Now(), "id", group.ID)
+	return s.Store.UpdateGroup(ctx, group)
+}
+
+func (s *slowLogStore) DeleteGroup(ctx context.Context, id string) error {
+	defer s.observe("DeleteGroup", time.Now(), "id", id)
+	return s.Store.DeleteGroup(ctx, id)
+}
+
+func (s *slowLogStore) ListGroups(ctx context.Context, projectID string) ([]*model.Group, error) {
+	defer s.observe("ListGroups", time.Now(), "projectId", projectID)
+	return s.Store.ListGroups(ctx, projectID)
+}
+
+func (s *slowLogStore) RenameGroup(ctx context.Context, id, groupKey string) (int, error) {
+	defer s.observe("RenameGroup", time.Now(), "id", id, "groupKey", groupKey)
+	return s.Store.RenameGroup(ctx, id, groupKey)
+}
+
+// ScanNotes は走査全体（fnの処理を含む）の所要時間を計測する
+func (s *slowLogStore) ScanNotes(ctx context.Context, fn func(RawNote) error) error {
+	r, ok := s.Store.(RawScanner)
+	if !ok {
+		return ErrNotScannable
+	}
+	defer s.observe("ScanNotes", time.Now())
+	return r.ScanNotes(ctx, fn)
+}
+
+// WithTx はトランザクション全体の所要時間を計測し、トランザクション内の操作も同じ閾値で計測する
+func (s *slowLogStore) WithTx(ctx context.Context, fn func(tx Store) error) error {
+	t, ok := s.Store.(Transactional)
+	if !ok {
+		return ErrNotTransactional
+	}
+	defer s.observe("WithTx", time.Now())
+	return t.WithTx(ctx, func(tx Store) error {
+		return fn(&slowLogStore{Store: tx, threshold: s.threshold, namespace: s.namespace})
+	})
+}
+
+// Initialize はnamespaceを記録してから初期化する（接続確認・コレクション作成の遅さも対象にする）
+func (s *slowLogStore) Initialize(ctx context.Context, namespace string) error {
+	s.namespace = namespace
+	defer s.observe("Initialize", time.Now())
+	return s.Store.Initialize(ctx, namespace)
+}
@@ -0,0 +1,53 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// captureSlog はテスト中のslogの出力をバッファに集める
+func captureSlog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	prev := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(prev) })
+	var buf bytes.Buffer
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	return &buf
+}
+
+// TestSlowLogStore は閾値以上かかった操作のみ警告することをテスト
+func TestSlowLogStore(t *testing.T) {
+	ctx := context.Background()
+	mem := NewMemoryStore()
+	if NewSlowLogStore(mem, 0) != Store(mem) {
+		t.Error("expected the store to be returned as-is when the threshold is 0")
+	}
+
+	buf := captureSlog(t)
+	st := NewSlowLogStore(mem, time.Nanosecond)
+	if err := st.Initialize(ctx, "openai:test:3"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.ListRecent(ctx, ListOptions{ProjectID: "/test/project", Limit: 10}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{`msg="slow store operation" method=ListRecent`, "namespace=openai:test:3", "projectId=/test/project", "limit=10"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in log: %s", want, out)
+		}
+	}
+
+	buf.Reset()
+	st = NewSlowLogStore(mem, time.Hour)
+	if _, err := st.ListProjects(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("unexpected log for a fast operation: %s", buf.String())
+	}
+}
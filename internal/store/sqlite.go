@@ -5,10 +5,12 @@ import (
 	"database/sql"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -16,36 +18,196 @@ import (
 	_ "modernc.org/sqlite"
 )
 
+// sqliteTables はテーブル定義（作成順）
+// 主キーは(namespace, id)。embedderの移行で同じIDのレコードを別のnamespaceに取り込めるようにするため
+// columnsは後から追加した列（末尾に追加するため、schemaの列の並びと一致する）
+var sqliteTables = []struct {
+	name    string
+	schema  string
+	indexes string
+	columns []string
+}{
+	{
+		name: "notes",
+		schema: `
+	CREATE TABLE IF NOT EXISTS notes (
+		id TEXT NOT NULL,
+		namespace TEXT NOT NULL,
+		project_id TEXT NOT NULL,
+		group_id TEXT NOT NULL,
+		title TEXT,
+		text TEXT NOT NULL,
+		tags TEXT,
+		source TEXT,
+		created_at TEXT,
+		metadata TEXT,
+		embedding BLOB,
+		updated_at TEXT,
+		attachments TEXT,
+		parent_id TEXT,
+		chunk_index INTEGER,
+		importance REAL,
+		accessed_at TEXT,
+		embedding_norm REAL,
+		PRIMARY KEY(namespace, id)
+	);`,
+		indexes: `
+	CREATE INDEX IF NOT EXISTS idx_notes_namespace ON notes(namespace);
+	CREATE INDEX IF NOT EXISTS idx_notes_project_id ON notes(namespace, project_id);
+	CREATE INDEX IF NOT EXISTS idx_notes_group_id ON notes(namespace, group_id);
+	CREATE INDEX IF NOT EXISTS idx_notes_created_at ON notes(namespace, created_at);
+	CREATE INDEX IF NOT EXISTS idx_notes_updated_at ON notes(namespace, updated_at);
+	CREATE INDEX IF NOT EXISTS idx_notes_parent_id ON notes(namespace, parent_id);`,
+		columns: []string{"updated_at TEXT", "attachments TEXT", "parent_id TEXT", "chunk_index INTEGER", "importance REAL", "accessed_at TEXT", "embedding_norm REAL"},
+	},
+	{
+		name: "global_configs",
+		schema: `
+	CREATE TABLE IF NOT EXISTS global_configs (
+		id TEXT NOT NULL,
+		namespace TEXT NOT NULL,
+		project_id TEXT NOT NULL,
+		key TEXT NOT NULL,
+		value TEXT,
+		updated_at TEXT,
+		updated_by TEXT,
+		history TEXT,
+		PRIMARY KEY(namespace, id),
+		UNIQUE(namespace, project_id, key)
+	);`,
+		indexes: `
+	CREATE INDEX IF NOT EXISTS idx_global_configs_namespace ON global_configs(namespace);
+	CREATE INDEX IF NOT EXISTS idx_global_configs_project_key ON global_configs(namespace, project_id, key);`,
+		columns: []string{"updated_by TEXT", "history TEXT"},
+	},
+	{
+		name: "groups",
+		schema: `
+	CREATE TABLE IF NOT EXISTS groups (
+		id TEXT NOT NULL,
+		namespace TEXT NOT NULL,
+		project_id TEXT NOT NULL,
+		group_key TEXT NOT NULL,
+		title TEXT NOT NULL,
+		description TEXT,
+		created_at TEXT NOT NULL,
+		updated_at TEXT NOT NULL,
+		tags TEXT,
+		status TEXT,
+		metadata TEXT,
+		parent_group_id TEXT,
+		PRIMARY KEY(namespace, id),
+		UNIQUE(namespace, project_id, group_key)
+	);`,
+		indexes: `
+	CREATE INDEX IF NOT EXISTS idx_groups_project ON groups(namespace, project_id);`,
+		columns: []string{"tags TEXT", "status TEXT", "metadata TEXT", "parent_group_id TEXT"},
+	},
+}
+
+// SQLiteの接続のデフォルト
 const (
-	// noteCountWarningThreshold は警告を出すノート件数の閾値
-	noteCountWarningThreshold = 5000
+	// DefaultSQLiteBusyTimeout はほかの接続・プロセスが書き込み中のDBを待つ時間
+	DefaultSQLiteBusyTimeout = 5 * time.Second
+	// DefaultSQLitePoolSize は読み取り用の接続数（書き込みは常に1接続）
+	DefaultSQLitePoolSize = 4
 )
 
 // SQLiteStore はSQLiteを使用したStore実装
+// 読み取りは接続プール（db）、書き込みは1つの接続（wdb）で行い、同じプロセス内の書き込み同士がSQLITE_BUSYにならないようにする
+// よく使うクエリはプリペアドステートメントをキャッシュする（reads・writes）
+// WithTxでは、読み書きともtxで行うStoreをfnに渡す
 type SQLiteStore struct {
 	mu          sync.RWMutex
 	db          *sql.DB
+	wdb         *sql.DB
+	tx          *sql.Tx // WithTxのトランザクション（トランザクション外ならnil）
+	reads       *stmtCache
+	writes      *stmtCache
 	dbPath      string
 	namespace   string
 	initialized bool
+
+	// sharedGlobals はGlobalConfigをnamespaceによらずSharedGlobalsNamespaceに保存するか
+	sharedGlobals bool
+}
+
+// sqliteOptions はNewSQLiteStoreの設定
+type sqliteOptions struct {
+	busyTimeout   time.Duration
+	poolSize      int
+	sharedGlobals bool
+}
+
+// SQLiteOption はSQLiteStoreのオプション
+type SQLiteOption func(*sqliteOptions)
+
+// WithSQLiteBusyTimeout はロック中のDBへの書き込みを待つ時間（busy_timeout）を設定（デフォルトDefaultSQLiteBusyTimeout）
+func WithSQLiteBusyTimeout(d time.Duration) SQLiteOption {
+	return func(o *sqliteOptions) {
+		o.busyTimeout = d
+	}
+}
+
+// WithSQLitePoolSize は読み取り用の最大接続数を設定（デフォルトDefaultSQLitePoolSize）
+func WithSQLitePoolSize(n int) SQLiteOption {
+	return func(o *sqliteOptions) {
+		o.poolSize = n
+	}
+}
+
+// WithSQLiteSharedGlobals はGlobalConfigをnamespace（embedder）によらず共通に保存する
+func WithSQLiteSharedGlobals() SQLiteOption {
+	return func(o *sqliteOptions) {
+		o.sharedGlobals = true
+	}
 }
 
 // NewSQLiteStore はSQLiteStoreを作成する
-func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
-	db, err := sql.Open("sqlite", dbPath)
+func NewSQLiteStore(dbPath string, opts ...SQLiteOption) (*SQLiteStore, error) {
+	o := &sqliteOptions{busyTimeout: DefaultSQLiteBusyTimeout, poolSize: DefaultSQLitePoolSize}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	// busy_timeoutは接続ごとの設定のため、DSNで指定して全接続に適用する
+	// store.pathがクエリ付きのURI（file:x.db?mode=rwc）ならそのパラメータに追加する
+	sep := "?"
+	if strings.Contains(dbPath, "?") {
+		sep = "&"
+	}
+	dsn := fmt.Sprintf("%s%s_pragma=busy_timeout(%d)", dbPath, sep, o.busyTimeout.Milliseconds())
+	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
+	// アイドルの接続を閉じると、接続ごとに準備したステートメントも作り直しになる
+	db.SetMaxOpenConns(o.poolSize)
+	db.SetMaxIdleConns(o.poolSize)
 
-	// WALモードを有効化
-	if _, err := db.Exec("PRAGMA journal_mode=WAL"); err != nil {
+	// 書き込みのトランザクションは開始時に書き込みロックを取る（読み取りから昇格する際のSQLITE_BUSYを避ける）
+	wdb, err := sql.Open("sqlite", dsn+"&_txlock=immediate")
+	if err != nil {
 		db.Close()
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	wdb.SetMaxOpenConns(1)
+	wdb.SetMaxIdleConns(1)
+
+	// WALモードを有効化（DBファイルに記録されるため、書き込み用の接続で1回設定すればよい）
+	if _, err := wdb.Exec("PRAGMA journal_mode=WAL"); err != nil {
+		db.Close()
+		wdb.Close()
 		return nil, fmt.Errorf("failed to set WAL mode: %w", err)
 	}
 
 	return &SQLiteStore{
-		db:     db,
-		dbPath: dbPath,
+		db:            db,
+		wdb:           wdb,
+		reads:         newStmtCache(db),
+		writes:        newStmtCache(wdb),
+		dbPath:        dbPath,
+		sharedGlobals: o.sharedGlobals,
 	}, nil
 }
 
@@ -54,84 +216,205 @@ func (s *SQLiteStore) Initialize(ctx context.Context, namespace string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// notesテーブル作成
-	notesSQL := `
-	CREATE TABLE IF NOT EXISTS notes (
-		id TEXT PRIMARY KEY,
-		namespace TEXT NOT NULL,
-		project_id TEXT NOT NULL,
-		group_id TEXT NOT NULL,
-		title TEXT,
-		text TEXT NOT NULL,
-		tags TEXT,
-		source TEXT,
-		created_at TEXT,
-		metadata TEXT,
-		embedding BLOB
-	);
-	CREATE INDEX IF NOT EXISTS idx_notes_namespace ON notes(namespace);
-	CREATE INDEX IF NOT EXISTS idx_notes_project_id ON notes(namespace, project_id);
-	CREATE INDEX IF NOT EXISTS idx_notes_group_id ON notes(namespace, group_id);
-	CREATE INDEX IF NOT EXISTS idx_notes_created_at ON notes(namespace, created_at);
-	`
-
-	if _, err := s.db.ExecContext(ctx, notesSQL); err != nil {
-		return fmt.Errorf("failed to create notes table: %w", err)
+	for _, t := range sqliteTables {
+		if _, err := s.wdb.ExecContext(ctx, t.schema); err != nil {
+			return fmt.Errorf("failed to create %s table: %w", t.name, err)
+		}
+		// 主キーの移行はSELECT *でコピーするため、先に列を揃える
+		if err := s.migrateColumns(ctx, t.name, t.columns); err != nil {
+			return fmt.Errorf("failed to migrate %s columns: %w", t.name, err)
+		}
+		if err := s.migratePrimaryKey(ctx, t.name, t.schema); err != nil {
+			return fmt.Errorf("failed to migrate %s table: %w", t.name, err)
+		}
+		if _, err := s.wdb.ExecContext(ctx, t.indexes); err != nil {
+			return fmt.Errorf("failed to create %s indexes: %w", t.name, err)
+		}
+	}
+	if err := s.backfillEmbeddingNorms(ctx); err != nil {
+		return fmt.Errorf("failed to backfill embedding norms: %w", err)
 	}
 
-	// global_configsテーブル作成
-	globalSQL := `
-	CREATE TABLE IF NOT EXISTS global_configs (
-		id TEXT PRIMARY KEY,
-		namespace TEXT NOT NULL,
-		project_id TEXT NOT NULL,
-		key TEXT NOT NULL,
-		value TEXT,
-		updated_at TEXT,
-		UNIQUE(namespace, project_id, key)
-	);
-	CREATE INDEX IF NOT EXISTS idx_global_configs_namespace ON global_configs(namespace);
-	CREATE INDEX IF NOT EXISTS idx_global_configs_project_key ON global_configs(namespace, project_id, key);
-	`
+	s.namespace = namespace
+	s.initialized = true
+	return nil
+}
 
-	if _, err := s.db.ExecContext(ctx, globalSQL); err != nil {
-		return fmt.Errorf("failed to create global_configs table: %w", err)
+// globalsNamespace はGlobalConfigを保存するnamespaceを返す
+func (s *SQLiteStore) globalsNamespace() string {
+	if s.sharedGlobals {
+		return SharedGlobalsNamespace
 	}
+	return s.namespace
+}
 
-	// groupsテーブル作成
-	groupsSQL := `
-	CREATE TABLE IF NOT EXISTS groups (
-		id TEXT PRIMARY KEY,
-		namespace TEXT NOT NULL,
-		project_id TEXT NOT NULL,
-		group_key TEXT NOT NULL,
-		title TEXT NOT NULL,
-		description TEXT,
-		created_at TEXT NOT NULL,
-		updated_at TEXT NOT NULL,
-		UNIQUE(namespace, project_id, group_key)
-	);
-	CREATE INDEX IF NOT EXISTS idx_groups_project ON groups(namespace, project_id);
-	`
+// backfillEmbeddingNorms はembedding_norm列がない旧スキーマで追加したノートのノルムを求めて保存する
+func (s *SQLiteStore) backfillEmbeddingNorms(ctx context.Context) error {
+	rows, err := s.wdb.QueryContext(ctx, `SELECT namespace, id, embedding FROM notes WHERE embedding_norm IS NULL AND embedding IS NOT NULL`)
+	if err != nil {
+		return err
+	}
+	type norm struct {
+		namespace, id string
+		norm          float64
+	}
+	var norms []norm
+	for rows.Next() {
+		var n norm
+		var blob []byte
+		if err := rows.Scan(&n.namespace, &n.id, &blob); err != nil {
+			rows.Close()
+			return err
+		}
+		n.norm = vectorNorm(decodeEmbedding(blob))
+		norms = append(norms, n)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(norms) == 0 {
+		return nil
+	}
 
-	if _, err := s.db.ExecContext(ctx, groupsSQL); err != nil {
-		return fmt.Errorf("failed to create groups table: %w", err)
+	tx, err := s.wdb.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, n := range norms {
+		if _, err := tx.ExecContext(ctx, `UPDATE notes SET embedding_norm = ? WHERE namespace = ? AND id = ?`, n.norm, n.namespace, n.id); err != nil {
+			return err
+		}
 	}
+	return tx.Commit()
+}
 
-	s.namespace = namespace
-	s.initialized = true
+// migrateColumns は旧スキーマのテーブルにない列を追加する（columnsは"名前 型"）
+func (s *SQLiteStore) migrateColumns(ctx context.Context, table string, columns []string) error {
+	for _, column := range columns {
+		name, _, _ := strings.Cut(column, " ")
+		var exists int
+		if err := s.wdb.QueryRowContext(ctx, `SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, name).Scan(&exists); err != nil {
+			return err
+		}
+		if exists > 0 {
+			continue
+		}
+		if _, err := s.wdb.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s`, table, column)); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// migratePrimaryKey は主キーがidのみの旧スキーマのテーブルを(namespace, id)の主キーで作り直す
+// 列の並びは変わらないため、そのままコピーする。インデックスは呼び出し側で作り直す
+func (s *SQLiteStore) migratePrimaryKey(ctx context.Context, table, schema string) error {
+	var pkColumns int
+	if err := s.wdb.QueryRowContext(ctx, `SELECT COUNT(*) FROM pragma_table_info(?) WHERE pk > 0`, table).Scan(&pkColumns); err != nil {
+		return err
+	}
+	if pkColumns != 1 {
+		return nil
+	}
+
+	tx, err := s.wdb.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	old := table + "_old"
+	stmts := []string{
+		fmt.Sprintf(`ALTER TABLE %s RENAME TO %s`, table, old),
+		schema,
+		fmt.Sprintf(`INSERT INTO %s SELECT * FROM %s`, table, old),
+		fmt.Sprintf(`DROP TABLE %s`, old),
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
 // Close はストアをクローズする
 func (s *SQLiteStore) Close() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	s.initialized = false
-	if s.db != nil {
-		return s.db.Close()
+	if s.db == nil {
+		return nil
+	}
+	s.reads.close()
+	s.writes.close()
+	return errors.Join(s.db.Close(), s.wdb.Close())
+}
+
+// WithTx はfnの書き込みを1つのトランザクションで行う（fnがエラーを返せばすべて取り消す）
+// fnに渡すStoreは読み取りも同じトランザクションで行うため、fnの中の書き込みが見える
+// トランザクション中は書き込み用の接続を占有するため、このStoreへのほかの書き込みはコミットまで待つ
+// fnの中でWithTxを呼んだ場合は、外側のトランザクションに含める
+func (s *SQLiteStore) WithTx(ctx context.Context, fn func(tx Store) error) error {
+	if s.tx != nil {
+		return fn(sqliteTxStore{s})
+	}
+
+	s.mu.RLock()
+	initialized, namespace := s.initialized, s.namespace
+	s.mu.RUnlock()
+	if !initialized {
+		return ErrNotInitialized
+	}
+
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		stmts := newStmtCache(tx)
+		return fn(sqliteTxStore{&SQLiteStore{
+			db:            s.db,
+			wdb:           s.wdb,
+			tx:            tx,
+			reads:         stmts,
+			writes:        stmts,
+			dbPath:        s.dbPath,
+			namespace:     namespace,
+			initialized:   true,
+			sharedGlobals: s.sharedGlobals,
+		}})
+	})
+}
+
+// withTx はfnを書き込み用のトランザクションで実行する（WithTxの中ならそのトランザクションを使う）
+func (s *SQLiteStore) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	if s.tx != nil {
+		return fn(s.tx)
 	}
+	tx, err := s.wdb.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// sqliteTxStore はWithTxのfnに渡すStore（接続を共有するため、初期化・クローズはしない）
+type sqliteTxStore struct {
+	*SQLiteStore
+}
+
+func (s sqliteTxStore) Initialize(ctx context.Context, namespace string) error {
+	return errors.New("cannot initialize a store inside a transaction")
+}
+
+func (s sqliteTxStore) Close() error {
 	return nil
 }
 
@@ -149,6 +432,7 @@ func (s *SQLiteStore) AddNote(ctx context.Context, note *model.Note, embedding [
 		now := time.Now().UTC().Format(time.RFC3339)
 		note.CreatedAt = &now
 	}
+	stampNewNote(note)
 
 	tagsJSON, err := json.Marshal(note.Tags)
 	if err != nil {
@@ -163,27 +447,24 @@ func (s *SQLiteStore) AddNote(ctx context.Context, note *model.Note, embedding [
 		}
 	}
 
+	attachmentsJSON, err := encodeAttachments(note.Attachments)
+	if err != nil {
+		return err
+	}
+
 	embeddingBlob := encodeEmbedding(embedding)
 
-	_, err = s.db.ExecContext(ctx, `
-		INSERT INTO notes (id, namespace, project_id, group_id, title, text, tags, source, created_at, metadata, embedding)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	// 検索のたびにノートごとのノルムを求めないよう、書き込み時に保存する
+	_, err = s.writes.ExecContext(ctx, `
+		INSERT INTO notes (id, namespace, project_id, group_id, title, text, tags, source, created_at, metadata, embedding, updated_at, attachments, parent_id, chunk_index, importance, embedding_norm)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`, note.ID, s.namespace, note.ProjectID, note.GroupID, note.Title, note.Text,
-		string(tagsJSON), note.Source, note.CreatedAt, metadataJSON, embeddingBlob)
+		string(tagsJSON), note.Source, note.CreatedAt, metadataJSON, embeddingBlob, note.UpdatedAt, attachmentsJSON, note.ParentID, note.ChunkIndex, note.Importance, vectorNorm(embedding))
 
 	if err != nil {
 		return fmt.Errorf("failed to insert note: %w", err)
 	}
 
-	// 件数チェックと警告
-	count, _ := s.countNotes(ctx)
-	if count >= noteCountWarningThreshold {
-		slog.Warn("note count exceeded threshold",
-			"count", count,
-			"threshold", noteCountWarningThreshold,
-			"recommendation", "consider using ChromaStore for better performance")
-	}
-
 	return nil
 }
 
@@ -196,8 +477,8 @@ func (s *SQLiteStore) Get(ctx context.Context, id string) (*model.Note, error) {
 		return nil, ErrNotInitialized
 	}
 
-	row := s.db.QueryRowContext(ctx, `
-		SELECT id, project_id, group_id, title, text, tags, source, created_at, metadata
+	row := s.reads.QueryRowContext(ctx, `
+		SELECT id, project_id, group_id, title, text, tags, source, created_at, metadata, COALESCE(updated_at, created_at), attachments, parent_id, chunk_index, importance
 		FROM notes
 		WHERE id = ? AND namespace = ?
 	`, id, s.namespace)
@@ -224,7 +505,7 @@ func (s *SQLiteStore) Update(ctx context.Context, note *model.Note, embedding []
 
 	// 存在確認
 	var exists int
-	err := s.db.QueryRowContext(ctx, `
+	err := s.reads.QueryRowContext(ctx, `
 		SELECT 1 FROM notes WHERE id = ? AND namespace = ?
 	`, note.ID, s.namespace).Scan(&exists)
 	if err == sql.ErrNoRows {
@@ -233,6 +514,7 @@ func (s *SQLiteStore) Update(ctx context.Context, note *model.Note, embedding []
 	if err != nil {
 		return fmt.Errorf("failed to check note existence: %w", err)
 	}
+	stampUpdatedNote(note)
 
 	tagsJSON, err := json.Marshal(note.Tags)
 	if err != nil {
@@ -247,14 +529,19 @@ func (s *SQLiteStore) Update(ctx context.Context, note *model.Note, embedding []
 		}
 	}
 
+	attachmentsJSON, err := encodeAttachments(note.Attachments)
+	if err != nil {
+		return err
+	}
+
 	embeddingBlob := encodeEmbedding(embedding)
 
-	_, err = s.db.ExecContext(ctx, `
+	_, err = s.writes.ExecContext(ctx, `
 		UPDATE notes
-		SET project_id = ?, group_id = ?, title = ?, text = ?, tags = ?, source = ?, created_at = ?, metadata = ?, embedding = ?
+		SET project_id = ?, group_id = ?, title = ?, text = ?, tags = ?, source = ?, created_at = ?, metadata = ?, embedding = ?, updated_at = ?, attachments = ?, parent_id = ?, chunk_index = ?, importance = ?, embedding_norm = ?
 		WHERE id = ? AND namespace = ?
 	`, note.ProjectID, note.GroupID, note.Title, note.Text, string(tagsJSON),
-		note.Source, note.CreatedAt, metadataJSON, embeddingBlob, note.ID, s.namespace)
+		note.Source, note.CreatedAt, metadataJSON, embeddingBlob, note.UpdatedAt, attachmentsJSON, note.ParentID, note.ChunkIndex, note.Importance, vectorNorm(embedding), note.ID, s.namespace)
 
 	if err != nil {
 		return fmt.Errorf("failed to update note: %w", err)
@@ -272,7 +559,7 @@ func (s *SQLiteStore) Delete(ctx context.Context, id string) error {
 		return ErrNotInitialized
 	}
 
-	result, err := s.db.ExecContext(ctx, `
+	result, err := s.writes.ExecContext(ctx, `
 		DELETE FROM notes WHERE id = ? AND namespace = ?
 	`, id, s.namespace)
 	if err != nil {
@@ -291,6 +578,55 @@ func (s *SQLiteStore) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+// DeleteNotesByGroup はプロジェクト内のgroupIDのノートを全て削除し、件数を返す
+func (s *SQLiteStore) DeleteNotesByGroup(ctx context.Context, projectID, groupID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.initialized {
+		return 0, ErrNotInitialized
+	}
+
+	result, err := s.writes.ExecContext(ctx, `
+		DELETE FROM notes WHERE namespace = ? AND project_id = ? AND group_id = ?
+	`, s.namespace, projectID, groupID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete notes: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// MoveNotesToGroup はプロジェクト内のfromGroupIDのノートをtoGroupIDへ移し、件数を返す
+func (s *SQLiteStore) MoveNotesToGroup(ctx context.Context, projectID, fromGroupID, toGroupID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.initialized {
+		return 0, ErrNotInitialized
+	}
+
+	result, err := s.writes.ExecContext(ctx, `
+		UPDATE notes SET group_id = ?, updated_at = ?
+		WHERE namespace = ? AND project_id = ? AND group_id = ?
+	`, toGroupID, Timestamp(), s.namespace, projectID, fromGroupID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to move notes: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
+
 // Search はベクトル検索を実行する
 func (s *SQLiteStore) Search(ctx context.Context, embedding []float32, opts SearchOptions) ([]SearchResult, error) {
 	s.mu.RLock()
@@ -300,28 +636,34 @@ func (s *SQLiteStore) Search(ctx context.Context, embedding []float32, opts Sear
 		return nil, ErrNotInitialized
 	}
 
-	// 全件取得（namespace + projectIDフィルタ）
-	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, project_id, group_id, title, text, tags, source, created_at, metadata, embedding
+	// groupId・tags・期間はSQLで絞り込み、条件を満たす行の埋め込みだけを読み込む
+	where, args := s.noteFilter(opts.ProjectID, opts.GroupID, opts.GroupIDs, opts.Tags, opts.Since, opts.Until)
+	rows, err := s.reads.QueryContext(ctx, `
+		SELECT id, project_id, group_id, title, text, tags, source, created_at, metadata, COALESCE(updated_at, created_at), attachments, parent_id, chunk_index, importance, embedding, embedding_norm
 		FROM notes
-		WHERE namespace = ? AND project_id = ?
-	`, s.namespace, opts.ProjectID)
+		WHERE `+where, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query notes: %w", err)
 	}
 	defer rows.Close()
 
 	var results []SearchResult
+	var buf []float32
+	queryNorm := vectorNorm(embedding)
 
 	for rows.Next() {
 		var (
 			id, projectID, groupID, text string
 			title, source, createdAt     sql.NullString
+			updatedAt, attachmentsJSON   sql.NullString
+			parentID                     sql.NullString
+			chunkIndex                   sql.NullInt64
+			importance, embeddingNorm    sql.NullFloat64
 			tagsJSON, metadataJSON       sql.NullString
 			embeddingBlob                []byte
 		)
 
-		if err := rows.Scan(&id, &projectID, &groupID, &title, &text, &tagsJSON, &source, &createdAt, &metadataJSON, &embeddingBlob); err != nil {
+		if err := rows.Scan(&id, &projectID, &groupID, &title, &text, &tagsJSON, &source, &createdAt, &metadataJSON, &updatedAt, &attachmentsJSON, &parentID, &chunkIndex, &importance, &embeddingBlob, &embeddingNorm); err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
 
@@ -341,6 +683,9 @@ func (s *SQLiteStore) Search(ctx context.Context, embedding []float32, opts Sear
 		if createdAt.Valid {
 			note.CreatedAt = &createdAt.String
 		}
+		if updatedAt.Valid {
+			note.UpdatedAt = &updatedAt.String
+		}
 		if tagsJSON.Valid {
 			if err := json.Unmarshal([]byte(tagsJSON.String), &note.Tags); err != nil {
 				slog.Warn("failed to unmarshal tags in Search", "noteID", id, "error", err)
@@ -354,43 +699,23 @@ func (s *SQLiteStore) Search(ctx context.Context, embedding []float32, opts Sear
 				slog.Warn("failed to unmarshal metadata in Search", "noteID", id, "error", err)
 			}
 		}
-
-		// groupIDフィルタ
-		if opts.GroupID != nil && note.GroupID != *opts.GroupID {
-			continue
+		note.Attachments = decodeAttachments(attachmentsJSON, id, "Search")
+		note.ParentID, note.ChunkIndex = decodeChunk(parentID, chunkIndex)
+		if importance.Valid {
+			note.Importance = &importance.Float64
 		}
 
-		// tagsフィルタ（AND検索）
-		if len(opts.Tags) > 0 {
-			if !ContainsAllTags(note.Tags, opts.Tags) {
-				continue
+		// cosine類似度計算（デコード先のバッファは行をまたいで使い回す）
+		// ノートのノルムは書き込み時に保存したものを使い、行ごとの計算は内積だけにする
+		buf = decodeEmbeddingInto(buf, embeddingBlob)
+		distance := 2.0
+		if len(buf) == len(embedding) {
+			norm := embeddingNorm.Float64
+			if !embeddingNorm.Valid {
+				norm = vectorNorm(buf)
 			}
+			distance = cosineDistance(dot(embedding, buf), queryNorm, norm)
 		}
-
-		// since/untilフィルタ
-		if opts.Since != nil || opts.Until != nil {
-			if note.CreatedAt == nil {
-				continue
-			}
-			createdTime, err := time.Parse(time.RFC3339, *note.CreatedAt)
-			if err != nil {
-				continue
-			}
-
-			// since <= createdAt
-			if opts.Since != nil && createdTime.Before(*opts.Since) {
-				continue
-			}
-
-			// createdAt < until
-			if opts.Until != nil && !createdTime.Before(*opts.Until) {
-				continue
-			}
-		}
-
-		// cosine類似度計算
-		noteEmbedding := decodeEmbedding(embeddingBlob)
-		distance := CosineSimilarity(embedding, noteEmbedding)
 		score := 1.0 - (distance / 2.0) // 0-1に正規化
 
 		results = append(results, SearchResult{
@@ -425,13 +750,33 @@ func (s *SQLiteStore) ListRecent(ctx context.Context, opts ListOptions) ([]*mode
 		return nil, ErrNotInitialized
 	}
 
-	// 全件取得（namespace + projectIDフィルタ、createdAt降順）
-	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, project_id, group_id, title, text, tags, source, created_at, metadata
+	// groupId・tagsで絞り込み、createdAt降順（同時刻はID降順）で取得
+	// SortByUpdatedAtならupdatedAt降順（updatedAtのない旧データはcreatedAt）
+	orderBy := "created_at"
+	if opts.SortBy == SortByUpdatedAt {
+		orderBy = "COALESCE(updated_at, created_at)"
+	}
+	where, args := s.noteFilter(opts.ProjectID, opts.GroupID, opts.GroupIDs, opts.Tags, nil, nil)
+	// カーソルより後のノートに絞る（ORDER BYと同じく文字列で比較し、ソートキーのないノートは最後）
+	if opts.After != nil {
+		if opts.After.Key == "" {
+			where += " AND " + orderBy + " IS NULL AND id < ?"
+			args = append(args, opts.After.ID)
+		} else {
+			where += " AND (" + orderBy + " < ? OR " + orderBy + " IS NULL OR (" + orderBy + " = ? AND id < ?))"
+			args = append(args, opts.After.Key, opts.After.Key, opts.After.ID)
+		}
+	}
+	query := `
+		SELECT id, project_id, group_id, title, text, tags, source, created_at, metadata, COALESCE(updated_at, created_at), attachments, parent_id, chunk_index, importance
 		FROM notes
-		WHERE namespace = ? AND project_id = ?
-		ORDER BY created_at DESC NULLS LAST
-	`, s.namespace, opts.ProjectID)
+		WHERE ` + where + `
+		ORDER BY ` + orderBy + ` DESC NULLS LAST, id DESC`
+	if opts.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, opts.Limit)
+	}
+	rows, err := s.reads.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query notes: %w", err)
 	}
@@ -443,10 +788,14 @@ func (s *SQLiteStore) ListRecent(ctx context.Context, opts ListOptions) ([]*mode
 		var (
 			id, projectID, groupID, text string
 			title, source, createdAt     sql.NullString
+			updatedAt, attachmentsJSON   sql.NullString
+			parentID                     sql.NullString
+			chunkIndex                   sql.NullInt64
+			importance                   sql.NullFloat64
 			tagsJSON, metadataJSON       sql.NullString
 		)
 
-		if err := rows.Scan(&id, &projectID, &groupID, &title, &text, &tagsJSON, &source, &createdAt, &metadataJSON); err != nil {
+		if err := rows.Scan(&id, &projectID, &groupID, &title, &text, &tagsJSON, &source, &createdAt, &metadataJSON, &updatedAt, &attachmentsJSON, &parentID, &chunkIndex, &importance); err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
 
@@ -466,6 +815,9 @@ func (s *SQLiteStore) ListRecent(ctx context.Context, opts ListOptions) ([]*mode
 		if createdAt.Valid {
 			note.CreatedAt = &createdAt.String
 		}
+		if updatedAt.Valid {
+			note.UpdatedAt = &updatedAt.String
+		}
 		if tagsJSON.Valid {
 			if err := json.Unmarshal([]byte(tagsJSON.String), &note.Tags); err != nil {
 				slog.Warn("failed to unmarshal tags in ListRecent", "noteID", id, "error", err)
@@ -479,34 +831,264 @@ func (s *SQLiteStore) ListRecent(ctx context.Context, opts ListOptions) ([]*mode
 				slog.Warn("failed to unmarshal metadata in ListRecent", "noteID", id, "error", err)
 			}
 		}
+		note.Attachments = decodeAttachments(attachmentsJSON, id, "ListRecent")
+		note.ParentID, note.ChunkIndex = decodeChunk(parentID, chunkIndex)
+		if importance.Valid {
+			note.Importance = &importance.Float64
+		}
+
+		notes = append(notes, note)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return notes, nil
+}
+
+// CountNotes は一覧の条件（projectId・groupId・tags）に一致するノートの件数を返す
+func (s *SQLiteStore) CountNotes(ctx context.Context, opts ListOptions) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.initialized {
+		return 0, ErrNotInitialized
+	}
+
+	where, args := s.noteFilter(opts.ProjectID, opts.GroupID, opts.GroupIDs, opts.Tags, nil, nil)
+	var count int
+	if err := s.reads.QueryRowContext(ctx, `SELECT COUNT(*) FROM notes WHERE `+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count notes: %w", err)
+	}
+	return count, nil
+}
+
+// noteFilter はnotesのnamespace・projectIDとgroupId・tags・期間の条件をWHERE句とその引数にする
+// tagsはjson_eachでAND検索（JSONとして壊れたtagsはどのタグにも一致しない）
+// 期間はjulianday()で時刻として比較する（オフセット付きのcreatedAtも正しく比較でき、解析できない値は一致しない）
+func (s *SQLiteStore) noteFilter(projectID string, groupID *string, groupIDs, tags []string, since, until *time.Time) (string, []any) {
+	conds := []string{"namespace = ?", "project_id = ?"}
+	args := []any{s.namespace, projectID}
+
+	if groupID != nil {
+		conds = append(conds, "group_id = ?")
+		args = append(args, *groupID)
+	}
+	if len(groupIDs) > 0 {
+		conds = append(conds, "group_id IN (?"+strings.Repeat(", ?", len(groupIDs)-1)+")")
+		for _, id := range groupIDs {
+			args = append(args, id)
+		}
+	}
+	for _, tag := range tags {
+		conds = append(conds, "EXISTS (SELECT 1 FROM json_each(CASE WHEN json_valid(notes.tags) THEN notes.tags ELSE '[]' END) WHERE value = ?)")
+		args = append(args, tag)
+	}
+	// since <= createdAt
+	if since != nil {
+		conds = append(conds, "julianday(created_at) >= julianday(?)")
+		args = append(args, since.UTC().Format(time.RFC3339Nano))
+	}
+	// createdAt < until
+	if until != nil {
+		conds = append(conds, "julianday(created_at) < julianday(?)")
+		args = append(args, until.UTC().Format(time.RFC3339Nano))
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// ListNotes はプロジェクト内の全ノートを取得する（createdAt昇順）
+func (s *SQLiteStore) ListNotes(ctx context.Context, projectID string) ([]*model.Note, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.initialized {
+		return nil, ErrNotInitialized
+	}
+
+	rows, err := s.reads.QueryContext(ctx, `
+		SELECT id, project_id, group_id, title, text, tags, source, created_at, metadata, COALESCE(updated_at, created_at), attachments, parent_id, chunk_index, importance
+		FROM notes
+		WHERE namespace = ? AND project_id = ?
+		ORDER BY created_at ASC, id ASC
+	`, s.namespace, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notes: %w", err)
+	}
+	defer rows.Close()
+
+	var notes []*model.Note
+	for rows.Next() {
+		note, err := s.scanNote(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		notes = append(notes, note)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return notes, nil
+}
+
+// sqliteScanPageSize はScanNotesで1回に読み出すノート数
+const sqliteScanPageSize = 500
+
+// ScanNotes は全プロジェクトのノートをID順に、埋め込みとともに走査する
+// ページごとに読み出してからロックを外してfnを呼ぶため、fnの中で書き込んでもよい
+func (s *SQLiteStore) ScanNotes(ctx context.Context, fn func(RawNote) error) error {
+	after := ""
+	for {
+		raws, err := s.scanNotesPage(ctx, after)
+		if err != nil {
+			return err
+		}
+		for _, raw := range raws {
+			if err := fn(raw); err != nil {
+				return err
+			}
+		}
+		if len(raws) < sqliteScanPageSize {
+			return nil
+		}
+		after = raws[len(raws)-1].ID
+	}
+}
+
+// scanNotesPage はIDがafterより後のノートを1ページ分読み出す
+func (s *SQLiteStore) scanNotesPage(ctx context.Context, after string) ([]RawNote, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.initialized {
+		return nil, ErrNotInitialized
+	}
+
+	rows, err := s.reads.QueryContext(ctx, `
+		SELECT id, project_id, group_id, title, text, tags, source, created_at, metadata, COALESCE(updated_at, created_at), attachments, parent_id, chunk_index, importance, embedding
+		FROM notes
+		WHERE namespace = ? AND id > ?
+		ORDER BY id ASC
+		LIMIT ?
+	`, s.namespace, after, sqliteScanPageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notes: %w", err)
+	}
+	defer rows.Close()
+
+	var raws []RawNote
+	for rows.Next() {
+		var data []byte
+		note, err := s.scanNote(embeddingScanner{rows, &data})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		raws = append(raws, RawNote{ID: note.ID, Note: note, Embedding: decodeEmbedding(data)})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return raws, nil
+}
+
+// embeddingScanner はscanNoteの列の後ろのembedding列をdataに読み込む
+type embeddingScanner struct {
+	rowScanner
+	data *[]byte
+}
+
+func (r embeddingScanner) Scan(dest ...any) error {
+	return r.rowScanner.Scan(append(dest, r.data)...)
+}
 
-		// groupIDフィルタ
-		if opts.GroupID != nil && note.GroupID != *opts.GroupID {
-			continue
-		}
+// TouchNotes はidsのノートの最終アクセスを記録する（ないIDは無視する）
+func (s *SQLiteStore) TouchNotes(ctx context.Context, ids []string, accessedAt string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-		// tagsフィルタ（AND検索）
-		if len(opts.Tags) > 0 {
-			if !ContainsAllTags(note.Tags, opts.Tags) {
-				continue
-			}
-		}
+	if !s.initialized {
+		return ErrNotInitialized
+	}
 
-		notes = append(notes, note)
+	args := make([]any, 0, len(ids)+2)
+	args = append(args, accessedAt, s.namespace)
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	if _, err := s.writes.ExecContext(ctx, `
+		UPDATE notes SET accessed_at = ? WHERE namespace = ? AND id IN (`+placeholders+`)
+	`, args...); err != nil {
+		return fmt.Errorf("failed to touch notes: %w", err)
+	}
+	return nil
+}
 
-		// Limit制限
-		if opts.Limit > 0 && len(notes) >= opts.Limit {
-			break
-		}
+// ListStaleNotes はプロジェクト内で最終アクセスがbeforeより前のノートを取得する（createdAt昇順）
+// 最終アクセスはaccessed_at・updated_at・created_atのうち最も新しいもの
+// 時刻はRFC3339（UTC）の文字列として比較する（小数秒の有無で同じ秒の中はずれうる）
+func (s *SQLiteStore) ListStaleNotes(ctx context.Context, projectID string, before time.Time) ([]*model.Note, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.initialized {
+		return nil, ErrNotInitialized
+	}
+
+	rows, err := s.reads.QueryContext(ctx, `
+		SELECT id, project_id, group_id, title, text, tags, source, created_at, metadata, COALESCE(updated_at, created_at), attachments, parent_id, chunk_index, importance
+		FROM notes
+		WHERE namespace = ? AND project_id = ? AND MAX(COALESCE(accessed_at, ''), COALESCE(updated_at, ''), COALESCE(created_at, '')) < ?
+		ORDER BY created_at ASC, id ASC
+	`, s.namespace, projectID, FormatTimestamp(before))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notes: %w", err)
 	}
+	defer rows.Close()
 
+	var notes []*model.Note
+	for rows.Next() {
+		note, err := s.scanNote(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		notes = append(notes, note)
+	}
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating rows: %w", err)
 	}
-
 	return notes, nil
 }
 
+// GetEmbedding はノートの埋め込みベクトルを取得する
+func (s *SQLiteStore) GetEmbedding(ctx context.Context, id string) ([]float32, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.initialized {
+		return nil, ErrNotInitialized
+	}
+
+	var data []byte
+	err := s.reads.QueryRowContext(ctx, `
+		SELECT embedding FROM notes WHERE id = ? AND namespace = ?
+	`, id, s.namespace).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get embedding: %w", err)
+	}
+
+	return decodeEmbedding(data), nil
+}
+
 // UpsertGlobal はグローバル設定を追加/更新する
 func (s *SQLiteStore) UpsertGlobal(ctx context.Context, config *model.GlobalConfig) error {
 	s.mu.Lock()
@@ -524,15 +1106,25 @@ func (s *SQLiteStore) UpsertGlobal(ctx context.Context, config *model.GlobalConf
 			return fmt.Errorf("failed to marshal value: %w", err)
 		}
 	}
+	var historyJSON any
+	if len(config.History) > 0 {
+		b, err := json.Marshal(config.History)
+		if err != nil {
+			return fmt.Errorf("failed to marshal history: %w", err)
+		}
+		historyJSON = string(b)
+	}
 
-	_, err = s.db.ExecContext(ctx, `
-		INSERT INTO global_configs (id, namespace, project_id, key, value, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?)
+	_, err = s.writes.ExecContext(ctx, `
+		INSERT INTO global_configs (id, namespace, project_id, key, value, updated_at, updated_by, history)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(namespace, project_id, key) DO UPDATE SET
 			id = excluded.id,
 			value = excluded.value,
-			updated_at = excluded.updated_at
-	`, config.ID, s.namespace, config.ProjectID, config.Key, string(valueJSON), config.UpdatedAt)
+			updated_at = excluded.updated_at,
+			updated_by = excluded.updated_by,
+			history = excluded.history
+	`, config.ID, s.globalsNamespace(), config.ProjectID, config.Key, string(valueJSON), config.UpdatedAt, config.UpdatedBy, historyJSON)
 
 	if err != nil {
 		return fmt.Errorf("failed to upsert global config: %w", err)
@@ -550,17 +1142,11 @@ func (s *SQLiteStore) GetGlobal(ctx context.Context, projectID, key string) (*mo
 		return nil, false, ErrNotInitialized
 	}
 
-	var (
-		id, pID, k   string
-		valueJSON    sql.NullString
-		updatedAt    sql.NullString
-	)
-
-	err := s.db.QueryRowContext(ctx, `
-		SELECT id, project_id, key, value, updated_at
+	config, err := scanGlobalConfig(s.reads.QueryRowContext(ctx, `
+		SELECT id, project_id, key, value, updated_at, updated_by, history
 		FROM global_configs
 		WHERE namespace = ? AND project_id = ? AND key = ?
-	`, s.namespace, projectID, key).Scan(&id, &pID, &k, &valueJSON, &updatedAt)
+	`, s.globalsNamespace(), projectID, key))
 
 	if err == sql.ErrNoRows {
 		return nil, false, nil
@@ -569,26 +1155,6 @@ func (s *SQLiteStore) GetGlobal(ctx context.Context, projectID, key string) (*mo
 		return nil, false, fmt.Errorf("failed to get global config: %w", err)
 	}
 
-	config := &model.GlobalConfig{
-		ID:        id,
-		ProjectID: pID,
-		Key:       k,
-	}
-
-	if valueJSON.Valid && valueJSON.String != "" {
-		var value any
-		if err := json.Unmarshal([]byte(valueJSON.String), &value); err == nil {
-			config.Value = value
-		} else {
-			config.Value = valueJSON.String
-		}
-	}
-
-	if updatedAt.Valid {
-		ua := updatedAt.String
-		config.UpdatedAt = &ua
-	}
-
 	return config, true, nil
 }
 
@@ -601,17 +1167,11 @@ func (s *SQLiteStore) GetGlobalByID(ctx context.Context, id string) (*model.Glob
 		return nil, ErrNotInitialized
 	}
 
-	var (
-		gID, pID, k string
-		valueJSON   sql.NullString
-		updatedAt   sql.NullString
-	)
-
-	err := s.db.QueryRowContext(ctx, `
-		SELECT id, project_id, key, value, updated_at
+	config, err := scanGlobalConfig(s.reads.QueryRowContext(ctx, `
+		SELECT id, project_id, key, value, updated_at, updated_by, history
 		FROM global_configs
 		WHERE id = ? AND namespace = ?
-	`, id, s.namespace).Scan(&gID, &pID, &k, &valueJSON, &updatedAt)
+	`, id, s.globalsNamespace()))
 
 	if err == sql.ErrNoRows {
 		return nil, ErrNotFound
@@ -620,27 +1180,121 @@ func (s *SQLiteStore) GetGlobalByID(ctx context.Context, id string) (*model.Glob
 		return nil, fmt.Errorf("failed to get global config by id: %w", err)
 	}
 
-	config := &model.GlobalConfig{
-		ID:        gID,
-		ProjectID: pID,
-		Key:       k,
+	return config, nil
+}
+
+// ListProjects はプロジェクト一覧を取得する（projectID昇順）
+func (s *SQLiteStore) ListProjects(ctx context.Context) ([]ProjectSummary, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.initialized {
+		return nil, ErrNotInitialized
 	}
 
-	if valueJSON.Valid && valueJSON.String != "" {
-		var value any
-		if err := json.Unmarshal([]byte(valueJSON.String), &value); err == nil {
-			config.Value = value
-		} else {
-			config.Value = valueJSON.String
+	rows, err := s.reads.QueryContext(ctx, `
+		SELECT project_id, SUM(n) FROM (
+			SELECT project_id, 1 AS n FROM notes WHERE namespace = ?
+			UNION ALL
+			SELECT project_id, 0 AS n FROM global_configs WHERE namespace = ?
+			UNION ALL
+			SELECT project_id, 0 AS n FROM groups WHERE namespace = ?
+		)
+		GROUP BY project_id
+		ORDER BY project_id ASC
+	`, s.namespace, s.globalsNamespace(), s.namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query projects: %w", err)
+	}
+	defer rows.Close()
+
+	summaries := []ProjectSummary{}
+	for rows.Next() {
+		var summary ProjectSummary
+		if err := rows.Scan(&summary.ProjectID, &summary.NoteCount); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
+		summaries = append(summaries, summary)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %w", err)
 	}
 
-	if updatedAt.Valid {
-		ua := updatedAt.String
-		config.UpdatedAt = &ua
+	return summaries, nil
+}
+
+// GroupStats はプロジェクト内のgroupIdごとのノートの統計をSQLで集計して返す
+func (s *SQLiteStore) GroupStats(ctx context.Context, projectID string) (map[string]GroupStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.initialized {
+		return nil, ErrNotInitialized
 	}
 
-	return config, nil
+	rows, err := s.reads.QueryContext(ctx, `
+		SELECT group_id, COUNT(*), COALESCE(MAX(created_at), ''), COALESCE(SUM(LENGTH(text)), 0)
+		FROM notes
+		WHERE namespace = ? AND project_id = ?
+		GROUP BY group_id
+	`, s.namespace, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query group stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := make(map[string]GroupStats)
+	for rows.Next() {
+		var (
+			groupID string
+			st      GroupStats
+		)
+		if err := rows.Scan(&groupID, &st.NoteCount, &st.LastNoteAt, &st.TextLength); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		stats[groupID] = st
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+
+	return stats, nil
+}
+
+// ListGlobals はプロジェクト内の全グローバル設定を取得する（key昇順）
+func (s *SQLiteStore) ListGlobals(ctx context.Context, projectID string) ([]*model.GlobalConfig, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.initialized {
+		return nil, ErrNotInitialized
+	}
+
+	rows, err := s.reads.QueryContext(ctx, `
+		SELECT id, project_id, key, value, updated_at, updated_by, history
+		FROM global_configs
+		WHERE namespace = ? AND project_id = ?
+		ORDER BY key ASC
+	`, s.globalsNamespace(), projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query global configs: %w", err)
+	}
+	defer rows.Close()
+
+	var configs []*model.GlobalConfig
+	for rows.Next() {
+		config, err := scanGlobalConfig(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		configs = append(configs, config)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return configs, nil
 }
 
 // DeleteGlobalByID はIDでグローバル設定を削除する
@@ -652,9 +1306,9 @@ func (s *SQLiteStore) DeleteGlobalByID(ctx context.Context, id string) error {
 		return ErrNotInitialized
 	}
 
-	result, err := s.db.ExecContext(ctx, `
+	result, err := s.writes.ExecContext(ctx, `
 		DELETE FROM global_configs WHERE id = ? AND namespace = ?
-	`, id, s.namespace)
+	`, id, s.globalsNamespace())
 	if err != nil {
 		return fmt.Errorf("failed to delete global config: %w", err)
 	}
@@ -680,11 +1334,16 @@ func (s *SQLiteStore) AddGroup(ctx context.Context, group *model.Group) error {
 		return ErrNotInitialized
 	}
 
-	_, err := s.db.ExecContext(ctx, `
-		INSERT INTO groups (id, namespace, project_id, group_key, title, description, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	tagsJSON, metadataJSON, err := marshalGroupFields(group)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.writes.ExecContext(ctx, `
+		INSERT INTO groups (id, namespace, project_id, group_key, title, description, created_at, updated_at, tags, status, metadata, parent_group_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`, group.ID, s.namespace, group.ProjectID, group.GroupKey, group.Title, group.Description,
-		group.CreatedAt.Format(time.RFC3339), group.UpdatedAt.Format(time.RFC3339))
+		group.CreatedAt.Format(time.RFC3339), group.UpdatedAt.Format(time.RFC3339), tagsJSON, group.Status, metadataJSON, group.ParentGroupID)
 
 	if err != nil {
 		return fmt.Errorf("failed to insert group: %w", err)
@@ -702,8 +1361,8 @@ func (s *SQLiteStore) GetGroup(ctx context.Context, id string) (*model.Group, er
 		return nil, ErrNotInitialized
 	}
 
-	row := s.db.QueryRowContext(ctx, `
-		SELECT id, project_id, group_key, title, description, created_at, updated_at
+	row := s.reads.QueryRowContext(ctx, `
+		SELECT id, project_id, group_key, title, description, created_at, updated_at, tags, status, metadata, parent_group_id
 		FROM groups
 		WHERE id = ? AND namespace = ?
 	`, id, s.namespace)
@@ -728,8 +1387,8 @@ func (s *SQLiteStore) GetGroupByKey(ctx context.Context, projectID, groupKey str
 		return nil, ErrNotInitialized
 	}
 
-	row := s.db.QueryRowContext(ctx, `
-		SELECT id, project_id, group_key, title, description, created_at, updated_at
+	row := s.reads.QueryRowContext(ctx, `
+		SELECT id, project_id, group_key, title, description, created_at, updated_at, tags, status, metadata, parent_group_id
 		FROM groups
 		WHERE namespace = ? AND project_id = ? AND group_key = ?
 	`, s.namespace, projectID, groupKey)
@@ -754,11 +1413,16 @@ func (s *SQLiteStore) UpdateGroup(ctx context.Context, group *model.Group) error
 		return ErrNotInitialized
 	}
 
-	result, err := s.db.ExecContext(ctx, `
+	tagsJSON, metadataJSON, err := marshalGroupFields(group)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.writes.ExecContext(ctx, `
 		UPDATE groups
-		SET title = ?, description = ?, updated_at = ?
+		SET title = ?, description = ?, updated_at = ?, tags = ?, status = ?, metadata = ?, parent_group_id = ?
 		WHERE id = ? AND namespace = ?
-	`, group.Title, group.Description, group.UpdatedAt.Format(time.RFC3339), group.ID, s.namespace)
+	`, group.Title, group.Description, group.UpdatedAt.Format(time.RFC3339), tagsJSON, group.Status, metadataJSON, group.ParentGroupID, group.ID, s.namespace)
 
 	if err != nil {
 		return fmt.Errorf("failed to update group: %w", err)
@@ -776,6 +1440,57 @@ func (s *SQLiteStore) UpdateGroup(ctx context.Context, group *model.Group) error
 	return nil
 }
 
+// RenameGroup はgroupKeyを変更し、そのグループのノートのgroupId・子グループのparentGroupIdも
+// 1つのトランザクションで書き換える
+func (s *SQLiteStore) RenameGroup(ctx context.Context, id, groupKey string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.initialized {
+		return 0, ErrNotInitialized
+	}
+
+	var rowsAffected int64
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		var projectID, oldKey string
+		err := tx.QueryRowContext(ctx, `
+			SELECT project_id, group_key FROM groups WHERE id = ? AND namespace = ?
+		`, id, s.namespace).Scan(&projectID, &oldKey)
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get group: %w", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE groups SET group_key = ?, updated_at = ? WHERE id = ? AND namespace = ?
+		`, groupKey, time.Now().UTC().Format(time.RFC3339), id, s.namespace); err != nil {
+			return fmt.Errorf("failed to rename group: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE groups SET parent_group_id = ? WHERE namespace = ? AND project_id = ? AND parent_group_id = ?
+		`, groupKey, s.namespace, projectID, oldKey); err != nil {
+			return fmt.Errorf("failed to update child groups: %w", err)
+		}
+		result, err := tx.ExecContext(ctx, `
+			UPDATE notes SET group_id = ?, updated_at = ?
+			WHERE namespace = ? AND project_id = ? AND group_id = ?
+		`, groupKey, Timestamp(), s.namespace, projectID, oldKey)
+		if err != nil {
+			return fmt.Errorf("failed to move notes: %w", err)
+		}
+		if rowsAffected, err = result.RowsAffected(); err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(rowsAffected), nil
+}
+
 // DeleteGroup はグループを削除する
 func (s *SQLiteStore) DeleteGroup(ctx context.Context, id string) error {
 	s.mu.Lock()
@@ -785,7 +1500,7 @@ func (s *SQLiteStore) DeleteGroup(ctx context.Context, id string) error {
 		return ErrNotInitialized
 	}
 
-	result, err := s.db.ExecContext(ctx, `
+	result, err := s.writes.ExecContext(ctx, `
 		DELETE FROM groups WHERE id = ? AND namespace = ?
 	`, id, s.namespace)
 	if err != nil {
@@ -813,8 +1528,8 @@ func (s *SQLiteStore) ListGroups(ctx context.Context, projectID string) ([]*mode
 		return nil, ErrNotInitialized
 	}
 
-	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, project_id, group_key, title, description, created_at, updated_at
+	rows, err := s.reads.QueryContext(ctx, `
+		SELECT id, project_id, group_key, title, description, created_at, updated_at, tags, status, metadata, parent_group_id
 		FROM groups
 		WHERE namespace = ? AND project_id = ?
 		ORDER BY created_at ASC
@@ -826,32 +1541,10 @@ func (s *SQLiteStore) ListGroups(ctx context.Context, projectID string) ([]*mode
 
 	var groups []*model.Group
 	for rows.Next() {
-		var (
-			id, projectID, groupKey, title string
-			description                    sql.NullString
-			createdAt, updatedAt           string
-		)
-
-		if err := rows.Scan(&id, &projectID, &groupKey, &title, &description, &createdAt, &updatedAt); err != nil {
+		group, err := s.scanGroup(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
-
-		createdTime, _ := time.Parse(time.RFC3339, createdAt)
-		updatedTime, _ := time.Parse(time.RFC3339, updatedAt)
-
-		group := &model.Group{
-			ID:        id,
-			ProjectID: projectID,
-			GroupKey:  groupKey,
-			Title:     title,
-			CreatedAt: createdTime,
-			UpdatedAt: updatedTime,
-		}
-
-		if description.Valid {
-			group.Description = description.String
-		}
-
 		groups = append(groups, group)
 	}
 
@@ -862,14 +1555,16 @@ func (s *SQLiteStore) ListGroups(ctx context.Context, projectID string) ([]*mode
 	return groups, nil
 }
 
-func (s *SQLiteStore) scanGroup(row *sql.Row) (*model.Group, error) {
+func (s *SQLiteStore) scanGroup(row rowScanner) (*model.Group, error) {
 	var (
 		id, projectID, groupKey, title string
 		description                    sql.NullString
 		createdAt, updatedAt           string
+		tagsJSON, status, metadataJSON sql.NullString
+		parentGroupID                  sql.NullString
 	)
 
-	if err := row.Scan(&id, &projectID, &groupKey, &title, &description, &createdAt, &updatedAt); err != nil {
+	if err := row.Scan(&id, &projectID, &groupKey, &title, &description, &createdAt, &updatedAt, &tagsJSON, &status, &metadataJSON, &parentGroupID); err != nil {
 		return nil, err
 	}
 
@@ -888,28 +1583,56 @@ func (s *SQLiteStore) scanGroup(row *sql.Row) (*model.Group, error) {
 	if description.Valid {
 		group.Description = description.String
 	}
+	group.Status = status.String
+	group.ParentGroupID = parentGroupID.String
+	if tagsJSON.Valid && tagsJSON.String != "" {
+		if err := json.Unmarshal([]byte(tagsJSON.String), &group.Tags); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal group tags: %w", err)
+		}
+	}
+	if metadataJSON.Valid && metadataJSON.String != "" {
+		if err := json.Unmarshal([]byte(metadataJSON.String), &group.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal group metadata: %w", err)
+		}
+	}
 
 	return group, nil
 }
 
+// marshalGroupFields はグループのtags・metadataをJSON文字列にする（空ならNULL）
+func marshalGroupFields(group *model.Group) (tagsJSON, metadataJSON []byte, err error) {
+	if len(group.Tags) > 0 {
+		if tagsJSON, err = json.Marshal(group.Tags); err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal group tags: %w", err)
+		}
+	}
+	if len(group.Metadata) > 0 {
+		if metadataJSON, err = json.Marshal(group.Metadata); err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal group metadata: %w", err)
+		}
+	}
+	return tagsJSON, metadataJSON, nil
+}
+
 // Helper functions
 
-func (s *SQLiteStore) countNotes(ctx context.Context) (int, error) {
-	var count int
-	err := s.db.QueryRowContext(ctx, `
-		SELECT COUNT(*) FROM notes WHERE namespace = ?
-	`, s.namespace).Scan(&count)
-	return count, err
+// rowScanner は*sql.Rowと*sql.Rowsの共通インターフェース
+type rowScanner interface {
+	Scan(dest ...any) error
 }
 
-func (s *SQLiteStore) scanNote(row *sql.Row) (*model.Note, error) {
+func (s *SQLiteStore) scanNote(row rowScanner) (*model.Note, error) {
 	var (
 		id, projectID, groupID, text string
 		title, source, createdAt     sql.NullString
+		updatedAt, attachmentsJSON   sql.NullString
+		parentID                     sql.NullString
+		chunkIndex                   sql.NullInt64
+		importance                   sql.NullFloat64
 		tagsJSON, metadataJSON       sql.NullString
 	)
 
-	if err := row.Scan(&id, &projectID, &groupID, &title, &text, &tagsJSON, &source, &createdAt, &metadataJSON); err != nil {
+	if err := row.Scan(&id, &projectID, &groupID, &title, &text, &tagsJSON, &source, &createdAt, &metadataJSON, &updatedAt, &attachmentsJSON, &parentID, &chunkIndex, &importance); err != nil {
 		return nil, err
 	}
 
@@ -929,6 +1652,9 @@ func (s *SQLiteStore) scanNote(row *sql.Row) (*model.Note, error) {
 	if createdAt.Valid {
 		note.CreatedAt = &createdAt.String
 	}
+	if updatedAt.Valid {
+		note.UpdatedAt = &updatedAt.String
+	}
 	if tagsJSON.Valid {
 		if err := json.Unmarshal([]byte(tagsJSON.String), &note.Tags); err != nil {
 			slog.Warn("failed to unmarshal tags in scanNote", "noteID", id, "error", err)
@@ -942,10 +1668,91 @@ func (s *SQLiteStore) scanNote(row *sql.Row) (*model.Note, error) {
 			slog.Warn("failed to unmarshal metadata in scanNote", "noteID", id, "error", err)
 		}
 	}
+	note.Attachments = decodeAttachments(attachmentsJSON, id, "scanNote")
+	note.ParentID, note.ChunkIndex = decodeChunk(parentID, chunkIndex)
+	if importance.Valid {
+		note.Importance = &importance.Float64
+	}
 
 	return note, nil
 }
 
+// scanGlobalConfig はglobal_configsの行（id, project_id, key, value, updated_at, updated_by, history）をGlobalConfigに変換する
+func scanGlobalConfig(row rowScanner) (*model.GlobalConfig, error) {
+	var (
+		id, projectID, key     string
+		valueJSON, updatedAt   sql.NullString
+		updatedBy, historyJSON sql.NullString
+	)
+	if err := row.Scan(&id, &projectID, &key, &valueJSON, &updatedAt, &updatedBy, &historyJSON); err != nil {
+		return nil, err
+	}
+
+	config := &model.GlobalConfig{
+		ID:        id,
+		ProjectID: projectID,
+		Key:       key,
+		UpdatedBy: updatedBy.String,
+	}
+	if valueJSON.Valid && valueJSON.String != "" {
+		var value any
+		if err := json.Unmarshal([]byte(valueJSON.String), &value); err == nil {
+			config.Value = value
+		} else {
+			config.Value = valueJSON.String
+		}
+	}
+	if updatedAt.Valid {
+		ua := updatedAt.String
+		config.UpdatedAt = &ua
+	}
+	if historyJSON.Valid && historyJSON.String != "" {
+		if err := json.Unmarshal([]byte(historyJSON.String), &config.History); err != nil {
+			slog.Warn("failed to unmarshal history in scanGlobalConfig", "globalConfigID", id, "error", err)
+		}
+	}
+	return config, nil
+}
+
+// decodeChunk はparent_id/chunk_index列を変換する（NULLならnil）
+func decodeChunk(parentID sql.NullString, chunkIndex sql.NullInt64) (*string, *int) {
+	var p *string
+	var i *int
+	if parentID.Valid {
+		p = &parentID.String
+	}
+	if chunkIndex.Valid {
+		n := int(chunkIndex.Int64)
+		i = &n
+	}
+	return p, i
+}
+
+// encodeAttachments はattachmentsをJSONに変換する（空ならNULL）
+func encodeAttachments(attachments []model.Attachment) (any, error) {
+	if len(attachments) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(attachments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal attachments: %w", err)
+	}
+	return string(data), nil
+}
+
+// decodeAttachments はattachments列のJSONを変換する（失敗時は警告してnil）
+func decodeAttachments(data sql.NullString, id, caller string) []model.Attachment {
+	if !data.Valid || data.String == "" {
+		return nil
+	}
+	var attachments []model.Attachment
+	if err := json.Unmarshal([]byte(data.String), &attachments); err != nil {
+		slog.Warn("failed to unmarshal attachments in "+caller, "noteID", id, "error", err)
+		return nil
+	}
+	return attachments
+}
+
 // encodeEmbedding はfloat32配列をバイト配列に変換する
 func encodeEmbedding(embedding []float32) []byte {
 	buf := make([]byte, len(embedding)*4)
@@ -960,9 +1767,18 @@ func decodeEmbedding(data []byte) []float32 {
 	if len(data) == 0 {
 		return nil
 	}
-	embedding := make([]float32, len(data)/4)
-	for i := range embedding {
-		embedding[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[i*4:]))
+	return decodeEmbeddingInto(make([]float32, len(data)/4), data)
+}
+
+// decodeEmbeddingInto はバイト配列をbufに変換して返す（容量が足りなければ確保し直す）
+func decodeEmbeddingInto(buf []float32, data []byte) []float32 {
+	n := len(data) / 4
+	if cap(buf) < n {
+		buf = make([]float32, n)
 	}
-	return embedding
+	buf = buf[:n]
+	for i := range buf {
+		buf[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[i*4:]))
+	}
+	return buf
 }
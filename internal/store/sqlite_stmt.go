@@ -0,0 +1,82 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// maxCachedStmts はstmtCacheに保持するプリペアドステートメントの上限
+// 絞り込みの組み合わせ（groupIdsの件数など）ごとに文が変わるクエリで際限なく増えないようにする
+const maxCachedStmts = 64
+
+// sqlConn はstmtCacheがクエリを実行する先（*sql.DBまたはトランザクション中の*sql.Tx）
+type sqlConn interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// stmtCache はdbのプリペアドステートメントをクエリ文字列ごとにキャッシュする
+// 準備に失敗した場合や上限に達した場合は、準備せずにdbで直接実行する
+// dbが*sql.Txの場合、ステートメントはトランザクションの終了とともに閉じられる
+type stmtCache struct {
+	db    sqlConn
+	mu    sync.Mutex
+	stmts map[string]*sql.Stmt
+}
+
+func newStmtCache(db sqlConn) *stmtCache {
+	return &stmtCache{db: db, stmts: make(map[string]*sql.Stmt)}
+}
+
+// prepare はqueryのキャッシュ済みステートメントを返す（使えない場合はnil）
+func (c *stmtCache) prepare(ctx context.Context, query string) *sql.Stmt {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if stmt, ok := c.stmts[query]; ok {
+		return stmt
+	}
+	if len(c.stmts) >= maxCachedStmts {
+		return nil
+	}
+	stmt, err := c.db.PrepareContext(ctx, query)
+	if err != nil {
+		// 構文の誤りなどは直接実行した際のエラーとして返す
+		return nil
+	}
+	c.stmts[query] = stmt
+	return stmt
+}
+
+func (c *stmtCache) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if stmt := c.prepare(ctx, query); stmt != nil {
+		return stmt.QueryContext(ctx, args...)
+	}
+	return c.db.QueryContext(ctx, query, args...)
+}
+
+func (c *stmtCache) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	if stmt := c.prepare(ctx, query); stmt != nil {
+		return stmt.QueryRowContext(ctx, args...)
+	}
+	return c.db.QueryRowContext(ctx, query, args...)
+}
+
+func (c *stmtCache) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if stmt := c.prepare(ctx, query); stmt != nil {
+		return stmt.ExecContext(ctx, args...)
+	}
+	return c.db.ExecContext(ctx, query, args...)
+}
+
+// close はキャッシュしたステートメントを閉じる（dbは閉じない）
+func (c *stmtCache) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for query, stmt := range c.stmts {
+		stmt.Close()
+		delete(c.stmts, query)
+	}
+}
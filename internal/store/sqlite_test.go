@@ -2,8 +2,15 @@ package store
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"sync"
 	"testing"
 	"time"
 
@@ -96,6 +103,239 @@ func TestSQLiteStore_Initialize(t *testing.T) {
 	}
 }
 
+// TestSQLiteStore_SameIDAcrossNamespaces は別のnamespaceに同じIDのノートを置けることをテスト（embedderの移行用）
+func TestSQLiteStore_SameIDAcrossNamespaces(t *testing.T) {
+	ctx := context.Background()
+	_, dbPath := setupSQLiteTestStore(t)
+
+	for _, namespace := range []string{"old:model:3", "new:model:3"} {
+		store, err := NewSQLiteStore(dbPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Initialize(ctx, namespace); err != nil {
+			t.Fatalf("Initialize failed: %v", err)
+		}
+		note := newSQLiteTestNote("note-1", testSQLiteProjectID, testSQLiteGroupID, namespace)
+		if err := store.AddNote(ctx, note, dummySQLiteEmbedding(3)); err != nil {
+			t.Fatalf("AddNote in %s failed: %v", namespace, err)
+		}
+		store.Close()
+	}
+}
+
+// TestSQLiteStore_Initialize_MigratesPrimaryKey は主キーがidのみの旧スキーマを作り直し、データを引き継ぐことをテスト
+func TestSQLiteStore_Initialize_MigratesPrimaryKey(t *testing.T) {
+	ctx := context.Background()
+	store, _ := setupSQLiteTestStore(t)
+	defer store.Close()
+
+	legacy := `
+	CREATE TABLE notes (
+		id TEXT PRIMARY KEY, namespace TEXT NOT NULL, project_id TEXT NOT NULL, group_id TEXT NOT NULL,
+		title TEXT, text TEXT NOT NULL, tags TEXT, source TEXT, created_at TEXT, metadata TEXT, embedding BLOB
+	);
+	CREATE INDEX idx_notes_namespace ON notes(namespace);
+	INSERT INTO notes (id, namespace, project_id, group_id, text, tags, created_at)
+		VALUES ('note-1', 'old:model:3', '/test/project', 'global', 'legacy', '[]', '2024-01-01T00:00:00Z');
+	CREATE TABLE groups (
+		id TEXT PRIMARY KEY, namespace TEXT NOT NULL, project_id TEXT NOT NULL, group_key TEXT NOT NULL,
+		title TEXT NOT NULL, description TEXT, created_at TEXT NOT NULL, updated_at TEXT NOT NULL,
+		UNIQUE(namespace, project_id, group_key)
+	);
+	INSERT INTO groups (id, namespace, project_id, group_key, title, created_at, updated_at)
+		VALUES ('group-1', 'old:model:3', '/test/project', 'feature', 'Feature', '2024-01-01T00:00:00Z', '2024-01-01T00:00:00Z');`
+	if _, err := store.db.ExecContext(ctx, legacy); err != nil {
+		t.Fatalf("failed to create legacy schema: %v", err)
+	}
+
+	if err := store.Initialize(ctx, "old:model:3"); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	got, err := store.Get(ctx, "note-1")
+	if err != nil || got.Text != "legacy" {
+		t.Fatalf("expected the legacy note to be kept, got %+v, %v", got, err)
+	}
+	// updated_at列が追加され、値のない旧データはcreatedAtになる
+	if got.UpdatedAt == nil || *got.UpdatedAt != "2024-01-01T00:00:00Z" {
+		t.Errorf("expected updatedAt to fall back to createdAt, got %v", got.UpdatedAt)
+	}
+	// tags・status・metadata列が追加され、旧データは空になる
+	group, err := store.GetGroupByKey(ctx, "/test/project", "feature")
+	if err != nil || group.Title != "Feature" || group.Tags != nil || group.Status != "" || group.Metadata != nil {
+		t.Errorf("expected the legacy group without annotations, got %+v, %v", group, err)
+	}
+
+	if err := store.Initialize(ctx, "new:model:3"); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if err := store.AddNote(ctx, newSQLiteTestNote("note-1", testSQLiteProjectID, testSQLiteGroupID, "migrated"), dummySQLiteEmbedding(3)); err != nil {
+		t.Errorf("AddNote with the same ID in another namespace failed: %v", err)
+	}
+}
+
+// TestSQLiteStore_EmbeddingNorm は書き込み時に埋め込みのノルムを保存し、旧データのノルムを初期化時に補うことをテスト
+func TestSQLiteStore_EmbeddingNorm(t *testing.T) {
+	ctx := context.Background()
+	store, _ := setupSQLiteTestStore(t)
+	defer store.Close()
+	if err := store.Initialize(ctx, "test:model:3"); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	storedNorm := func() sql.NullFloat64 {
+		t.Helper()
+		var norm sql.NullFloat64
+		if err := store.db.QueryRowContext(ctx, `SELECT embedding_norm FROM notes WHERE id = 'note-1'`).Scan(&norm); err != nil {
+			t.Fatalf("failed to read embedding_norm: %v", err)
+		}
+		return norm
+	}
+
+	note := newSQLiteTestNote("note-1", testSQLiteProjectID, testSQLiteGroupID, "text")
+	if err := store.AddNote(ctx, note, []float32{3, 4, 0}); err != nil {
+		t.Fatalf("AddNote failed: %v", err)
+	}
+	if norm := storedNorm(); !norm.Valid || norm.Float64 != 5 {
+		t.Errorf("expected norm 5 after AddNote, got %+v", norm)
+	}
+	if err := store.Update(ctx, note, []float32{0, 2, 0}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if norm := storedNorm(); !norm.Valid || norm.Float64 != 2 {
+		t.Errorf("expected norm 2 after Update, got %+v", norm)
+	}
+
+	// ノルムのない旧データも検索でき、初期化時にノルムを補う
+	if _, err := store.db.ExecContext(ctx, `UPDATE notes SET embedding_norm = NULL`); err != nil {
+		t.Fatal(err)
+	}
+	results, err := store.Search(ctx, []float32{0, 1, 0}, SearchOptions{ProjectID: testSQLiteProjectID, TopK: 1})
+	if err != nil || len(results) != 1 || math.Abs(results[0].Score-1) > 1e-6 {
+		t.Fatalf("expected a perfect match without a stored norm, got %+v, %v", results, err)
+	}
+	if err := store.Initialize(ctx, "test:model:3"); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if norm := storedNorm(); !norm.Valid || norm.Float64 != 2 {
+		t.Errorf("expected the norm to be backfilled, got %+v", norm)
+	}
+	results, err = store.Search(ctx, []float32{0, 1, 0}, SearchOptions{ProjectID: testSQLiteProjectID, TopK: 1})
+	if err != nil || len(results) != 1 || math.Abs(results[0].Score-1) > 1e-6 {
+		t.Errorf("expected a perfect match with the stored norm, got %+v, %v", results, err)
+	}
+}
+
+// TestSQLiteStore_Options はbusy_timeoutと最大接続数が反映されることをテスト
+func TestSQLiteStore_Options(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	store, err := NewSQLiteStore(dbPath, WithSQLiteBusyTimeout(2500*time.Millisecond), WithSQLitePoolSize(2))
+	if err != nil {
+		t.Fatalf("Failed to create SQLiteStore: %v", err)
+	}
+	defer store.Close()
+
+	var busyTimeout int
+	if err := store.db.QueryRow("PRAGMA busy_timeout").Scan(&busyTimeout); err != nil {
+		t.Fatalf("failed to read busy_timeout: %v", err)
+	}
+	if busyTimeout != 2500 {
+		t.Errorf("busy_timeout = %d, want 2500", busyTimeout)
+	}
+	if n := store.db.Stats().MaxOpenConnections; n != 2 {
+		t.Errorf("MaxOpenConnections = %d, want 2", n)
+	}
+	if n := store.wdb.Stats().MaxOpenConnections; n != 1 {
+		t.Errorf("writer MaxOpenConnections = %d, want 1", n)
+	}
+
+	// 未指定ならデフォルト
+	defaults, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer defaults.Close()
+	if err := defaults.db.QueryRow("PRAGMA busy_timeout").Scan(&busyTimeout); err != nil {
+		t.Fatal(err)
+	}
+	if busyTimeout != int(DefaultSQLiteBusyTimeout.Milliseconds()) || defaults.db.Stats().MaxOpenConnections != DefaultSQLitePoolSize {
+		t.Errorf("unexpected defaults: busy_timeout=%d, MaxOpenConnections=%d", busyTimeout, defaults.db.Stats().MaxOpenConnections)
+	}
+}
+
+// TestSQLiteStore_URIWithQuery はクエリ付きのURIでも既存のパラメータとbusy_timeoutの両方を適用することをテスト
+func TestSQLiteStore_URIWithQuery(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	store, err := NewSQLiteStore("file:"+filepath.ToSlash(dbPath)+"?mode=rwc", WithSQLiteBusyTimeout(1500*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Failed to create SQLiteStore: %v", err)
+	}
+	defer store.Close()
+	if err := store.Initialize(context.Background(), "openai:test:3"); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	var busyTimeout int
+	if err := store.db.QueryRow("PRAGMA busy_timeout").Scan(&busyTimeout); err != nil {
+		t.Fatalf("failed to read busy_timeout: %v", err)
+	}
+	if busyTimeout != 1500 {
+		t.Errorf("busy_timeout = %d, want 1500", busyTimeout)
+	}
+	// mode=rwcが壊れていなければ指定したパスにDBを作成する
+	if _, err := os.Stat(dbPath); err != nil {
+		t.Errorf("expected the database at %s: %v", dbPath, err)
+	}
+}
+
+// TestSQLiteStore_ConcurrentWrites は同じDBを開いた2つのストアから並行して書き込んでもSQLITE_BUSYにならないことをテスト
+func TestSQLiteStore_ConcurrentWrites(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	var stores []*SQLiteStore
+	for _, namespace := range []string{"ns-a", "ns-b"} {
+		store, err := NewSQLiteStore(dbPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer store.Close()
+		if err := store.Initialize(ctx, namespace); err != nil {
+			t.Fatal(err)
+		}
+		stores = append(stores, store)
+	}
+
+	embedding := dummySQLiteEmbedding(8)
+	errCh := make(chan error, 100)
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			store := stores[i%2]
+			id := fmt.Sprintf("note-%d", i)
+			if err := store.AddNote(ctx, newSQLiteTestNote(id, testSQLiteProjectID, testSQLiteGroupID, "text"), embedding); err != nil {
+				errCh <- err
+				return
+			}
+			if _, err := store.Search(ctx, embedding, SearchOptions{ProjectID: testSQLiteProjectID, TopK: 5}); err != nil {
+				errCh <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errCh)
+	for err := range errCh {
+		t.Errorf("concurrent access failed: %v", err)
+	}
+
+	for _, store := range stores {
+		notes, err := store.ListNotes(ctx, testSQLiteProjectID)
+		if err != nil || len(notes) != 25 {
+			t.Errorf("ListNotes = %d notes, %v", len(notes), err)
+		}
+	}
+}
+
 // TestSQLiteStore_NotInitialized はInitialize前の操作がErrNotInitializedを返すことをテスト
 func TestSQLiteStore_NotInitialized(t *testing.T) {
 	store, _ := setupSQLiteTestStore(t)
@@ -355,6 +595,49 @@ func TestSQLiteStore_Delete_NotFound(t *testing.T) {
 	}
 }
 
+// TestSQLiteStore_NotesByGroup はグループ単位のノートの削除・移動をテスト
+func TestSQLiteStore_NotesByGroup(t *testing.T) {
+	store := setupInitializedSQLiteStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	embedding := dummySQLiteEmbedding(1536)
+
+	store.AddNote(ctx, newSQLiteTestNote("g-1", testSQLiteProjectID, "group-a", "A1"), embedding)
+	store.AddNote(ctx, newSQLiteTestNote("g-2", testSQLiteProjectID, "group-a", "A2"), embedding)
+	store.AddNote(ctx, newSQLiteTestNote("g-3", testSQLiteProjectID, "group-b", "B1"), embedding)
+	store.AddNote(ctx, newSQLiteTestNote("g-4", "/other/project", "group-a", "other"), embedding)
+
+	moved, err := store.MoveNotesToGroup(ctx, testSQLiteProjectID, "group-a", "global")
+	if err != nil {
+		t.Fatalf("MoveNotesToGroup failed: %v", err)
+	}
+	if moved != 2 {
+		t.Errorf("Expected 2 moved notes, got %d", moved)
+	}
+	note, _ := store.Get(ctx, "g-1")
+	if note.GroupID != "global" {
+		t.Errorf("Expected g-1 to be moved to global, got %s", note.GroupID)
+	}
+	if note, _ := store.Get(ctx, "g-4"); note.GroupID != "group-a" {
+		t.Errorf("Expected a note of another project to stay, got %s", note.GroupID)
+	}
+
+	deleted, err := store.DeleteNotesByGroup(ctx, testSQLiteProjectID, "group-b")
+	if err != nil {
+		t.Fatalf("DeleteNotesByGroup failed: %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("Expected 1 deleted note, got %d", deleted)
+	}
+	if _, err := store.Get(ctx, "g-3"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if deleted, _ := store.DeleteNotesByGroup(ctx, testSQLiteProjectID, "group-b"); deleted != 0 {
+		t.Errorf("Expected 0 deleted notes, got %d", deleted)
+	}
+}
+
 // TestSQLiteStore_Search_Basic は基本的なベクトル検索をテスト
 func TestSQLiteStore_Search_Basic(t *testing.T) {
 	store := setupInitializedSQLiteStore(t)
@@ -458,6 +741,16 @@ func TestSQLiteStore_Search_WithGroupIDFilter(t *testing.T) {
 	if len(results) != 2 {
 		t.Errorf("Expected 2 results with nil groupID, got %d", len(results))
 	}
+
+	// GroupIDsはいずれかに一致
+	opts.GroupIDs = []string{"group-a", "group-c"}
+	results, err = store.Search(ctx, embedding, opts)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Note.GroupID != "group-a" {
+		t.Errorf("Expected only group-a with groupIDs, got %d results", len(results))
+	}
 }
 
 // TestSQLiteStore_Search_WithTagsFilter はtagsフィルタをテスト
@@ -561,6 +854,77 @@ func TestSQLiteStore_Search_WithTimeFilter_Boundary(t *testing.T) {
 	}
 }
 
+// TestSQLiteStore_Search_SQLFilters はSQLでの絞り込み（オフセット付きの時刻・複数group・壊れたtags）をテスト
+func TestSQLiteStore_Search_SQLFilters(t *testing.T) {
+	store := setupInitializedSQLiteStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	embedding := dummySQLiteEmbedding(8)
+
+	// 2024-01-15T00:30:00Zと同じ時刻（文字列として比較するとsinceより前になる）
+	edt := "2024-01-14T20:30:00-04:00"
+	utc := "2024-01-14T23:00:00Z"
+	notes := []*model.Note{
+		{ID: "f-1", ProjectID: testSQLiteProjectID, GroupID: "a", Text: "EDT", CreatedAt: &edt, Tags: []string{"go", "db"}},
+		{ID: "f-2", ProjectID: testSQLiteProjectID, GroupID: "b", Text: "UTC", CreatedAt: &utc, Tags: []string{"go"}},
+		{ID: "f-3", ProjectID: testSQLiteProjectID, GroupID: "c", Text: "other group", CreatedAt: &edt, Tags: []string{"go", "db"}},
+	}
+	for _, note := range notes {
+		if err := store.AddNote(ctx, note, embedding); err != nil {
+			t.Fatalf("AddNote failed: %v", err)
+		}
+	}
+	// JSONとして壊れたtagsの行があってもクエリは失敗しない
+	if _, err := store.db.ExecContext(ctx, `UPDATE notes SET tags = '[broken' WHERE id = 'f-2'`); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := func(results []SearchResult) []string {
+		var ids []string
+		for _, r := range results {
+			ids = append(ids, r.Note.ID)
+		}
+		sort.Strings(ids)
+		return ids
+	}
+
+	since := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	results, err := store.Search(ctx, embedding, SearchOptions{ProjectID: testSQLiteProjectID, GroupIDs: []string{"a", "b"}, Since: &since, TopK: 5})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if got := ids(results); !slices.Equal(got, []string{"f-1"}) {
+		t.Errorf("since with an offset: got %v", got)
+	}
+
+	until := time.Date(2024, 1, 15, 0, 30, 0, 0, time.UTC)
+	results, err = store.Search(ctx, embedding, SearchOptions{ProjectID: testSQLiteProjectID, Until: &until, TopK: 5})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if got := ids(results); !slices.Equal(got, []string{"f-2"}) {
+		t.Errorf("until is exclusive: got %v", got)
+	}
+
+	results, err = store.Search(ctx, embedding, SearchOptions{ProjectID: testSQLiteProjectID, Tags: []string{"go"}, TopK: 5})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if got := ids(results); !slices.Equal(got, []string{"f-1", "f-3"}) {
+		t.Errorf("tags: got %v", got)
+	}
+
+	// Limitは絞り込んだ後の件数
+	recent, err := store.ListRecent(ctx, ListOptions{ProjectID: testSQLiteProjectID, GroupIDs: []string{"b", "c"}, Tags: []string{"db"}, Limit: 1})
+	if err != nil {
+		t.Fatalf("ListRecent failed: %v", err)
+	}
+	if len(recent) != 1 || recent[0].ID != "f-3" {
+		t.Errorf("ListRecent: got %+v", recent)
+	}
+}
+
 // TestSQLiteStore_Search_TopK はTopK制限をテスト
 func TestSQLiteStore_Search_TopK(t *testing.T) {
 	store := setupInitializedSQLiteStore(t)
@@ -733,6 +1097,190 @@ func TestSQLiteStore_ListRecent_SortOrder(t *testing.T) {
 	}
 }
 
+// TestSQLiteStore_UpdatedAt は追加・更新時にupdatedAtが設定されることをテスト
+func TestSQLiteStore_UpdatedAt(t *testing.T) {
+	store := setupInitializedSQLiteStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	embedding := dummySQLiteEmbedding(3)
+	createdAt := "2024-01-10T00:00:00Z"
+	note := &model.Note{ID: "updated-1", ProjectID: testSQLiteProjectID, GroupID: testSQLiteGroupID, Text: "Original", Tags: []string{}, CreatedAt: &createdAt}
+	if err := store.AddNote(ctx, note, embedding); err != nil {
+		t.Fatalf("AddNote failed: %v", err)
+	}
+
+	got, err := store.Get(ctx, "updated-1")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.UpdatedAt == nil || *got.UpdatedAt != createdAt {
+		t.Fatalf("expected updatedAt %s on add, got %v", createdAt, got.UpdatedAt)
+	}
+
+	got.Text = "Updated"
+	if err := store.Update(ctx, got, embedding); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	updated, _ := store.Get(ctx, "updated-1")
+	if updated.UpdatedAt == nil || *updated.UpdatedAt <= createdAt {
+		t.Errorf("expected updatedAt to advance, got %v", updated.UpdatedAt)
+	}
+	if *updated.CreatedAt != createdAt {
+		t.Errorf("expected createdAt to be kept, got %s", *updated.CreatedAt)
+	}
+}
+
+// TestSQLiteStore_Attachments はattachmentsの保存・更新をテスト
+func TestSQLiteStore_Attachments(t *testing.T) {
+	store := setupInitializedSQLiteStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	embedding := dummySQLiteEmbedding(3)
+	note := newSQLiteTestNote("attach-1", testSQLiteProjectID, testSQLiteGroupID, "Decision")
+	note.Attachments = []model.Attachment{{Path: "docs/design.md", Hash: "sha256:abcd"}, {Hash: "sha256:ef01"}}
+	if err := store.AddNote(ctx, note, embedding); err != nil {
+		t.Fatalf("AddNote failed: %v", err)
+	}
+
+	got, err := store.Get(ctx, "attach-1")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if len(got.Attachments) != 2 || got.Attachments[0] != note.Attachments[0] || got.Attachments[1] != note.Attachments[1] {
+		t.Errorf("unexpected attachments: %+v", got.Attachments)
+	}
+	results, _ := store.Search(ctx, embedding, SearchOptions{ProjectID: testSQLiteProjectID, TopK: 5})
+	if len(results) != 1 || len(results[0].Note.Attachments) != 2 {
+		t.Errorf("expected attachments in search results, got %+v", results)
+	}
+
+	// 空にするとNULLとして保存される
+	got.Attachments = nil
+	if err := store.Update(ctx, got, embedding); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	notes, _ := store.ListRecent(ctx, ListOptions{ProjectID: testSQLiteProjectID, Limit: 10})
+	if len(notes) != 1 || notes[0].Attachments != nil {
+		t.Errorf("expected attachments to be cleared, got %+v", notes)
+	}
+}
+
+func TestSQLiteStore_ParentChunk(t *testing.T) {
+	store := setupInitializedSQLiteStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	embedding := dummySQLiteEmbedding(3)
+	parentID, index := "chunk-0", 1
+	note := newSQLiteTestNote("chunk-1", testSQLiteProjectID, testSQLiteGroupID, "Second chunk")
+	note.ParentID, note.ChunkIndex = &parentID, &index
+	if err := store.AddNote(ctx, note, embedding); err != nil {
+		t.Fatalf("AddNote failed: %v", err)
+	}
+	plain := newSQLiteTestNote("plain", testSQLiteProjectID, testSQLiteGroupID, "Plain")
+	if err := store.AddNote(ctx, plain, embedding); err != nil {
+		t.Fatalf("AddNote failed: %v", err)
+	}
+
+	got, err := store.Get(ctx, "chunk-1")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.ParentID == nil || *got.ParentID != parentID || got.ChunkIndex == nil || *got.ChunkIndex != index {
+		t.Errorf("unexpected parentId/chunkIndex: %v/%v", got.ParentID, got.ChunkIndex)
+	}
+	notes, _ := store.ListNotes(ctx, testSQLiteProjectID)
+	for _, n := range notes {
+		if n.ID == "plain" && (n.ParentID != nil || n.ChunkIndex != nil) {
+			t.Errorf("expected no parent for a plain note, got %v/%v", n.ParentID, n.ChunkIndex)
+		}
+	}
+}
+
+func TestSQLiteStore_Importance(t *testing.T) {
+	store := setupInitializedSQLiteStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	embedding := dummySQLiteEmbedding(3)
+	importance := 0.8
+	note := newSQLiteTestNote("important", testSQLiteProjectID, testSQLiteGroupID, "Convention")
+	note.Importance = &importance
+	if err := store.AddNote(ctx, note, embedding); err != nil {
+		t.Fatalf("AddNote failed: %v", err)
+	}
+
+	results, _ := store.Search(ctx, embedding, SearchOptions{ProjectID: testSQLiteProjectID, TopK: 5})
+	if len(results) != 1 || results[0].Note.Importance == nil || *results[0].Note.Importance != importance {
+		t.Errorf("expected importance in search results, got %+v", results)
+	}
+
+	note.Importance = nil
+	if err := store.Update(ctx, note, embedding); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if got, _ := store.Get(ctx, "important"); got.Importance != nil {
+		t.Errorf("expected importance to be cleared, got %v", *got.Importance)
+	}
+}
+
+// TestSQLiteStore_ListRecent_SortByUpdatedAt はupdatedAt降順のソートをテスト
+func TestSQLiteStore_ListRecent_SortByUpdatedAt(t *testing.T) {
+	store := setupInitializedSQLiteStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	embedding := dummySQLiteEmbedding(3)
+	t1 := "2024-01-10T00:00:00Z"
+	t2 := "2024-01-15T00:00:00Z"
+	note1 := &model.Note{ID: "sort-1", ProjectID: testSQLiteProjectID, GroupID: testSQLiteGroupID, Text: "Old", Tags: []string{}, CreatedAt: &t1}
+	note2 := &model.Note{ID: "sort-2", ProjectID: testSQLiteProjectID, GroupID: testSQLiteGroupID, Text: "New", Tags: []string{}, CreatedAt: &t2}
+	store.AddNote(ctx, note1, embedding)
+	store.AddNote(ctx, note2, embedding)
+
+	// 古いノートを更新する
+	note1.Text = "Old but updated"
+	if err := store.Update(ctx, note1, embedding); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	tests := []struct {
+		sortBy string
+		want   []string
+	}{
+		{"", []string{"sort-2", "sort-1"}},
+		{SortByCreatedAt, []string{"sort-2", "sort-1"}},
+		{SortByUpdatedAt, []string{"sort-1", "sort-2"}},
+	}
+	for _, tt := range tests {
+		notes, err := store.ListRecent(ctx, ListOptions{ProjectID: testSQLiteProjectID, Limit: 10, SortBy: tt.sortBy})
+		if err != nil {
+			t.Fatalf("ListRecent failed: %v", err)
+		}
+		if len(notes) != 2 || notes[0].ID != tt.want[0] || notes[1].ID != tt.want[1] {
+			t.Errorf("sortBy %q: expected %v, got %d notes", tt.sortBy, tt.want, len(notes))
+		}
+	}
+}
+
+// TestSQLiteStore_ListRecent_Pagination はカーソルによるページ送りと件数をテスト
+func TestSQLiteStore_ListRecent_Pagination(t *testing.T) {
+	store := setupInitializedSQLiteStore(t)
+	defer store.Close()
+
+	testListPagination(t, store, testSQLiteProjectID, 3)
+}
+
+// TestSQLiteStore_StaleNotes は最終アクセスの記録と古いノートの一覧をテスト
+func TestSQLiteStore_StaleNotes(t *testing.T) {
+	store := setupInitializedSQLiteStore(t)
+	defer store.Close()
+
+	testStaleNotes(t, store, testSQLiteProjectID, 3)
+}
+
 // TestSQLiteStore_ListRecent_Limit はLimit制限をテスト
 func TestSQLiteStore_ListRecent_Limit(t *testing.T) {
 	store := setupInitializedSQLiteStore(t)
@@ -874,6 +1422,8 @@ func TestSQLiteStore_UpsertGlobal_Update(t *testing.T) {
 	config.Value = "updated"
 	updatedAt2 := "2024-01-16T10:30:00Z"
 	config.UpdatedAt = &updatedAt2
+	config.UpdatedBy = "bob"
+	config.History = []model.GlobalConfigVersion{{Value: map[string]any{"indent": "tabs"}, UpdatedAt: &updatedAt, UpdatedBy: "alice"}}
 	if err := store.UpsertGlobal(ctx, config); err != nil {
 		t.Fatalf("UpsertGlobal update failed: %v", err)
 	}
@@ -882,6 +1432,13 @@ func TestSQLiteStore_UpsertGlobal_Update(t *testing.T) {
 	if retrieved.Value != "updated" {
 		t.Errorf("Expected value 'updated', got '%v'", retrieved.Value)
 	}
+	if retrieved.UpdatedBy != "bob" || len(retrieved.History) != 1 {
+		t.Fatalf("expected updatedBy and history to be kept, got %+v", retrieved)
+	}
+	version := retrieved.History[0]
+	if value, _ := version.Value.(map[string]any); value["indent"] != "tabs" || *version.UpdatedAt != updatedAt || version.UpdatedBy != "alice" {
+		t.Errorf("unexpected history entry: %+v", version)
+	}
 }
 
 // TestSQLiteStore_GetGlobal_Found は存在するconfig取得をテスト
@@ -1010,6 +1567,56 @@ func TestSQLiteStore_DeleteGlobalByID_NotFound(t *testing.T) {
 	}
 }
 
+// TestSQLiteStore_SharedGlobals はnamespaceをまたいだglobal configの共有をテスト
+func TestSQLiteStore_SharedGlobals(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	// 同じDBを別のembedder（namespace）で開く
+	open := func(namespace string) *SQLiteStore {
+		t.Helper()
+		s, err := NewSQLiteStore(dbPath, WithSQLiteSharedGlobals())
+		if err != nil {
+			t.Fatalf("Failed to create SQLiteStore: %v", err)
+		}
+		if err := s.Initialize(ctx, namespace); err != nil {
+			t.Fatalf("Failed to initialize store: %v", err)
+		}
+		t.Cleanup(func() { s.Close() })
+		return s
+	}
+	oldStore := open("openai:text-embedding-3-small:1536")
+	newStore := open("openai:text-embedding-3-large:3072")
+
+	config := &model.GlobalConfig{ID: "config-1", ProjectID: testSQLiteProjectID, Key: "global.test.key", Value: "shared-value"}
+	if err := oldStore.UpsertGlobal(ctx, config); err != nil {
+		t.Fatalf("UpsertGlobal failed: %v", err)
+	}
+	if err := oldStore.AddNote(ctx, newSQLiteTestNote("note-1", testSQLiteProjectID, testSQLiteGroupID, "old note"), []float32{0.1, 0.2, 0.3}); err != nil {
+		t.Fatalf("AddNote failed: %v", err)
+	}
+
+	retrieved, found, err := newStore.GetGlobal(ctx, testSQLiteProjectID, "global.test.key")
+	if err != nil || !found {
+		t.Fatalf("GetGlobal from another namespace: found=%v, err=%v", found, err)
+	}
+	if retrieved.Value != "shared-value" {
+		t.Errorf("Expected value 'shared-value', got '%v'", retrieved.Value)
+	}
+
+	// ノートはnamespaceごとのまま
+	if _, err := newStore.Get(ctx, "note-1"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound for a note in another namespace, got %v", err)
+	}
+
+	if err := newStore.DeleteGlobalByID(ctx, "config-1"); err != nil {
+		t.Fatalf("DeleteGlobalByID failed: %v", err)
+	}
+	if _, found, _ := oldStore.GetGlobal(ctx, testSQLiteProjectID, "global.test.key"); found {
+		t.Error("Config should be deleted from every namespace")
+	}
+}
+
 // TestSQLiteStore_Close はDB接続クローズをテスト
 func TestSQLiteStore_Close(t *testing.T) {
 	store := setupInitializedSQLiteStore(t)
@@ -1105,3 +1712,339 @@ func TestSQLiteStore_Update_WithReembedding(t *testing.T) {
 		t.Errorf("Expected score close to 1.0 after re-embedding, got %f", results[0].Score)
 	}
 }
+
+// TestSQLiteStore_ListNotes はプロジェクト内の全ノート取得（createdAt昇順）をテスト
+func TestSQLiteStore_ListNotes(t *testing.T) {
+	store := setupInitializedSQLiteStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	embedding := dummySQLiteEmbedding(1536)
+
+	time1 := "2024-01-10T10:00:00Z"
+	time2 := "2024-01-15T10:00:00Z"
+	note1 := newSQLiteTestNote("all-1", testSQLiteProjectID, testSQLiteGroupID, "Note 1")
+	note1.CreatedAt = &time1
+	note2 := newSQLiteTestNote("all-2", testSQLiteProjectID, "other-group", "Note 2")
+	note2.CreatedAt = &time2
+	note3 := newSQLiteTestNote("all-3", "/other/project", testSQLiteGroupID, "Other")
+	note3.CreatedAt = &time1
+
+	store.AddNote(ctx, note2, embedding)
+	store.AddNote(ctx, note1, embedding)
+	store.AddNote(ctx, note3, embedding)
+
+	notes, err := store.ListNotes(ctx, testSQLiteProjectID)
+	if err != nil {
+		t.Fatalf("ListNotes failed: %v", err)
+	}
+
+	if len(notes) != 2 {
+		t.Fatalf("Expected 2 notes, got %d", len(notes))
+	}
+	if notes[0].ID != "all-1" || notes[1].ID != "all-2" {
+		t.Errorf("Expected [all-1 all-2], got [%s %s]", notes[0].ID, notes[1].ID)
+	}
+}
+
+// TestSQLiteStore_ScanNotes は全プロジェクトのノートを埋め込みとともに走査し、走査中に削除できることをテスト
+func TestSQLiteStore_ScanNotes(t *testing.T) {
+	store := setupInitializedSQLiteStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	for _, note := range []*model.Note{
+		newSQLiteTestNote("scan-1", testSQLiteProjectID, testSQLiteGroupID, "Note 1"),
+		newSQLiteTestNote("scan-2", "/other/project", testSQLiteGroupID, "Note 2"),
+	} {
+		if err := store.AddNote(ctx, note, dummySQLiteEmbedding(1536)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var ids []string
+	err := store.ScanNotes(ctx, func(raw RawNote) error {
+		if raw.Err != nil || raw.Note.ID != raw.ID || len(raw.Embedding) != 1536 {
+			t.Errorf("unexpected raw note: %+v", raw)
+		}
+		ids = append(ids, raw.ID)
+		return store.Delete(ctx, raw.ID)
+	})
+	if err != nil {
+		t.Fatalf("ScanNotes failed: %v", err)
+	}
+	if !reflect.DeepEqual(ids, []string{"scan-1", "scan-2"}) {
+		t.Errorf("expected both projects in id order, got %v", ids)
+	}
+	if notes, _ := store.ListNotes(ctx, testSQLiteProjectID); len(notes) != 0 {
+		t.Errorf("expected the notes to be deleted during the scan, got %d", len(notes))
+	}
+}
+
+// TestSQLiteStore_GetEmbedding は保存した埋め込みベクトルの取得をテスト
+func TestSQLiteStore_GetEmbedding(t *testing.T) {
+	store := setupInitializedSQLiteStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	embedding := dummySQLiteEmbedding(16)
+
+	store.AddNote(ctx, newSQLiteTestNote("emb-1", testSQLiteProjectID, testSQLiteGroupID, "Note"), embedding)
+
+	got, err := store.GetEmbedding(ctx, "emb-1")
+	if err != nil {
+		t.Fatalf("GetEmbedding failed: %v", err)
+	}
+	if len(got) != len(embedding) {
+		t.Fatalf("Expected %d dims, got %d", len(embedding), len(got))
+	}
+	for i := range embedding {
+		if got[i] != embedding[i] {
+			t.Fatalf("embedding[%d] = %f, want %f", i, got[i], embedding[i])
+		}
+	}
+
+	if _, err := store.GetEmbedding(ctx, "non-existent"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+// TestSQLiteStore_ListGlobals はプロジェクト内の全グローバル設定取得をテスト
+func TestSQLiteStore_ListGlobals(t *testing.T) {
+	store := setupInitializedSQLiteStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	store.UpsertGlobal(ctx, &model.GlobalConfig{ID: "lg-2", ProjectID: testSQLiteProjectID, Key: "global.b", Value: map[string]any{"x": 1.0}})
+	store.UpsertGlobal(ctx, &model.GlobalConfig{ID: "lg-1", ProjectID: testSQLiteProjectID, Key: "global.a", Value: "a"})
+	store.UpsertGlobal(ctx, &model.GlobalConfig{ID: "lg-3", ProjectID: "/other/project", Key: "global.a", Value: "x"})
+
+	configs, err := store.ListGlobals(ctx, testSQLiteProjectID)
+	if err != nil {
+		t.Fatalf("ListGlobals failed: %v", err)
+	}
+
+	if len(configs) != 2 {
+		t.Fatalf("Expected 2 configs, got %d", len(configs))
+	}
+	if configs[0].Key != "global.a" || configs[1].Key != "global.b" {
+		t.Errorf("Expected keys in ascending order, got [%s %s]", configs[0].Key, configs[1].Key)
+	}
+	if v, ok := configs[1].Value.(map[string]any); !ok || v["x"] != 1.0 {
+		t.Errorf("Expected object value, got %v", configs[1].Value)
+	}
+}
+
+func TestSQLiteStore_ListProjects(t *testing.T) {
+	store := setupInitializedSQLiteStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	embedding := dummySQLiteEmbedding(8)
+
+	if err := store.AddNote(ctx, newSQLiteTestNote("p-1", "/b/project", testSQLiteGroupID, "Note 1"), embedding); err != nil {
+		t.Fatalf("AddNote failed: %v", err)
+	}
+	if err := store.AddNote(ctx, newSQLiteTestNote("p-2", "/b/project", testSQLiteGroupID, "Note 2"), embedding); err != nil {
+		t.Fatalf("AddNote failed: %v", err)
+	}
+	if err := store.UpsertGlobal(ctx, &model.GlobalConfig{ID: "pg-1", ProjectID: "/a/project", Key: "global.a", Value: "a"}); err != nil {
+		t.Fatalf("UpsertGlobal failed: %v", err)
+	}
+	now := time.Now().UTC()
+	if err := store.AddGroup(ctx, &model.Group{ID: "pgrp-1", ProjectID: "/c/project", GroupKey: "feature", Title: "Feature", CreatedAt: now, UpdatedAt: now}); err != nil {
+		t.Fatalf("AddGroup failed: %v", err)
+	}
+
+	projects, err := store.ListProjects(ctx)
+	if err != nil {
+		t.Fatalf("ListProjects failed: %v", err)
+	}
+
+	want := []ProjectSummary{{"/a/project", 0}, {"/b/project", 2}, {"/c/project", 0}}
+	if len(projects) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, projects)
+	}
+	for i := range want {
+		if projects[i] != want[i] {
+			t.Errorf("projects[%d]: expected %v, got %v", i, want[i], projects[i])
+		}
+	}
+}
+
+// TestSQLiteStore_GroupAnnotations はグループのtags・status・metadataが保存・更新されることをテスト
+func TestSQLiteStore_GroupAnnotations(t *testing.T) {
+	store := setupInitializedSQLiteStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	group := &model.Group{
+		ID: "grp-1", ProjectID: testSQLiteProjectID, GroupKey: "sprint-12", Title: "Sprint 12", CreatedAt: now, UpdatedAt: now,
+		Tags: []string{"sprint"}, Status: model.GroupStatusActive, Metadata: map[string]any{"owner": "alice", "points": float64(13)},
+		ParentGroupID: "backend",
+	}
+	if err := store.AddGroup(ctx, group); err != nil {
+		t.Fatalf("AddGroup failed: %v", err)
+	}
+	got, err := store.GetGroup(ctx, "grp-1")
+	if err != nil {
+		t.Fatalf("GetGroup failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, group) {
+		t.Errorf("group mismatch:\ngot  %+v\nwant %+v", got, group)
+	}
+
+	group.Tags, group.Status, group.Metadata = nil, model.GroupStatusDone, nil
+	if err := store.UpdateGroup(ctx, group); err != nil {
+		t.Fatalf("UpdateGroup failed: %v", err)
+	}
+	groups, err := store.ListGroups(ctx, testSQLiteProjectID)
+	if err != nil {
+		t.Fatalf("ListGroups failed: %v", err)
+	}
+	if len(groups) != 1 || groups[0].Status != model.GroupStatusDone || groups[0].Tags != nil || groups[0].Metadata != nil {
+		t.Errorf("expected the annotations to be updated, got %+v", groups)
+	}
+}
+
+// TestSQLiteStore_RenameGroup はgroupKeyの変更とノート・子グループの付け替えをテスト
+func TestSQLiteStore_RenameGroup(t *testing.T) {
+	store := setupInitializedSQLiteStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, g := range []*model.Group{
+		{ID: "grp-1", ProjectID: testSQLiteProjectID, GroupKey: "auth", Title: "Auth", CreatedAt: now, UpdatedAt: now},
+		{ID: "grp-2", ProjectID: testSQLiteProjectID, GroupKey: "auth-login", Title: "Login", CreatedAt: now, UpdatedAt: now, ParentGroupID: "auth"},
+	} {
+		if err := store.AddGroup(ctx, g); err != nil {
+			t.Fatalf("AddGroup failed: %v", err)
+		}
+	}
+	embedding := dummySQLiteEmbedding(1536)
+	store.AddNote(ctx, newSQLiteTestNote("r-1", testSQLiteProjectID, "auth", "A"), embedding)
+	store.AddNote(ctx, newSQLiteTestNote("r-2", "/other/project", "auth", "other"), embedding)
+
+	count, err := store.RenameGroup(ctx, "grp-1", "feature-auth")
+	if err != nil {
+		t.Fatalf("RenameGroup failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 moved note, got %d", count)
+	}
+	if group, _ := store.GetGroup(ctx, "grp-1"); group.GroupKey != "feature-auth" {
+		t.Errorf("Expected groupKey feature-auth, got %s", group.GroupKey)
+	}
+	if group, _ := store.GetGroup(ctx, "grp-2"); group.ParentGroupID != "feature-auth" {
+		t.Errorf("Expected the child's parent to be renamed, got %s", group.ParentGroupID)
+	}
+	if note, _ := store.Get(ctx, "r-1"); note.GroupID != "feature-auth" {
+		t.Errorf("Expected the note to be moved, got %s", note.GroupID)
+	}
+	if note, _ := store.Get(ctx, "r-2"); note.GroupID != "auth" {
+		t.Errorf("Expected a note of another project to stay, got %s", note.GroupID)
+	}
+
+	// 同じプロジェクトの既存groupKeyへの変更はUNIQUE制約で失敗する（トランザクションごと取り消す）
+	if _, err := store.RenameGroup(ctx, "grp-2", "feature-auth"); err == nil {
+		t.Error("Expected an error for a duplicate groupKey")
+	}
+	if _, err := store.RenameGroup(ctx, "missing", "other"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+// TestSQLiteStore_GroupStats はgroupIdごとのノートの統計をテスト
+func TestSQLiteStore_GroupStats(t *testing.T) {
+	store := setupInitializedSQLiteStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	embedding := dummySQLiteEmbedding(1536)
+	for i, createdAt := range []string{"2024-01-01T00:00:00Z", "2024-03-01T00:00:00Z"} {
+		note := newSQLiteTestNote(fmt.Sprintf("s-%d", i), testSQLiteProjectID, "group-a", "認証の仕様")
+		note.CreatedAt = &createdAt
+		store.AddNote(ctx, note, embedding)
+	}
+	store.AddNote(ctx, newSQLiteTestNote("s-3", testSQLiteProjectID, "global", "abc"), embedding)
+	store.AddNote(ctx, newSQLiteTestNote("s-4", "/other/project", "group-a", "other"), embedding)
+
+	stats, err := store.GroupStats(ctx, testSQLiteProjectID)
+	if err != nil {
+		t.Fatalf("GroupStats failed: %v", err)
+	}
+	want := GroupStats{NoteCount: 2, LastNoteAt: "2024-03-01T00:00:00Z", TextLength: 10}
+	if stats["group-a"] != want {
+		t.Errorf("Expected %+v for group-a, got %+v", want, stats["group-a"])
+	}
+	if len(stats) != 2 || stats["global"].NoteCount != 1 || stats["global"].TextLength != 3 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+// TestSQLiteStore_WithTx はトランザクション内の書き込みのコミット・ロールバックをテスト
+func TestSQLiteStore_WithTx(t *testing.T) {
+	store := setupInitializedSQLiteStore(t)
+	defer store.Close()
+
+	ctx := context.Background()
+	embedding := dummySQLiteEmbedding(1536)
+	now := time.Now().UTC().Truncate(time.Second)
+	store.AddGroup(ctx, &model.Group{ID: "grp-1", ProjectID: testSQLiteProjectID, GroupKey: "auth", Title: "Auth", CreatedAt: now, UpdatedAt: now})
+
+	// fnがエラーを返せば、fnの中の書き込み（RenameGroupを含む）はすべて取り消す
+	errAbort := fmt.Errorf("abort")
+	err := store.WithTx(ctx, func(tx Store) error {
+		if err := tx.AddNote(ctx, newSQLiteTestNote("tx-1", testSQLiteProjectID, "auth", "A"), embedding); err != nil {
+			return err
+		}
+		// トランザクション内の読み取りには書き込みが見える
+		if _, err := tx.Get(ctx, "tx-1"); err != nil {
+			return err
+		}
+		if _, err := tx.RenameGroup(ctx, "grp-1", "feature-auth"); err != nil {
+			return err
+		}
+		// 入れ子のWithTxは外側のトランザクションに含める
+		return tx.(Transactional).WithTx(ctx, func(tx Store) error {
+			if err := tx.AddNote(ctx, newSQLiteTestNote("tx-2", testSQLiteProjectID, "auth", "B"), embedding); err != nil {
+				return err
+			}
+			return errAbort
+		})
+	})
+	if err != errAbort {
+		t.Fatalf("Expected the error of fn, got %v", err)
+	}
+	for _, id := range []string{"tx-1", "tx-2"} {
+		if _, err := store.Get(ctx, id); err != ErrNotFound {
+			t.Errorf("Expected %s to be rolled back, got %v", id, err)
+		}
+	}
+	if group, _ := store.GetGroup(ctx, "grp-1"); group.GroupKey != "auth" {
+		t.Errorf("Expected the rename to be rolled back, got %s", group.GroupKey)
+	}
+
+	// エラーがなければコミットする（渡したStoreのCloseは接続を閉じない）
+	err = store.WithTx(ctx, func(tx Store) error {
+		defer tx.Close()
+		if err := tx.Initialize(ctx, "other"); err == nil {
+			t.Error("Expected Initialize to fail inside a transaction")
+		}
+		return tx.AddNote(ctx, newSQLiteTestNote("tx-3", testSQLiteProjectID, "auth", "C"), embedding)
+	})
+	if err != nil {
+		t.Fatalf("WithTx failed: %v", err)
+	}
+	if _, err := store.Get(ctx, "tx-3"); err != nil {
+		t.Errorf("Expected tx-3 to be committed, got %v", err)
+	}
+
+	uninitialized, _ := setupSQLiteTestStore(t)
+	defer uninitialized.Close()
+	if err := uninitialized.WithTx(ctx, func(Store) error { return nil }); err != ErrNotInitialized {
+		t.Errorf("Expected ErrNotInitialized, got %v", err)
+	}
+}
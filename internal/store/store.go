@@ -3,6 +3,8 @@ package store
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/brbranch/embedding_mcp/internal/model"
 )
@@ -15,11 +17,33 @@ type Store interface {
 	Update(ctx context.Context, note *model.Note, embedding []float32) error
 	Delete(ctx context.Context, id string) error
 
+	// グループ削除のcascade用（プロジェクト内のgroupIDのノートを一括で削除・移動し、件数を返す）
+	DeleteNotesByGroup(ctx context.Context, projectID, groupID string) (int, error)
+	MoveNotesToGroup(ctx context.Context, projectID, fromGroupID, toGroupID string) (int, error)
+
 	// ベクトル検索
 	Search(ctx context.Context, embedding []float32, opts SearchOptions) ([]SearchResult, error)
 
 	// 最新一覧取得（createdAt降順）
 	ListRecent(ctx context.Context, opts ListOptions) ([]*model.Note, error)
+	// ListRecentの条件（projectId・groupId・tags）に一致するノートの件数（Limit・Afterは無視する）
+	CountNotes(ctx context.Context, opts ListOptions) (int, error)
+
+	// アーカイブ用（最終アクセスの記録と、長くアクセスのないノートの取得）
+	// TouchNotesはidsのノートの最終アクセスをaccessedAt（Timestampの形式）にする（updatedAtは変えない。ないIDは無視する）
+	TouchNotes(ctx context.Context, ids []string, accessedAt string) error
+	// ListStaleNotesはプロジェクト内で最終アクセス（accessedAt・updatedAt・createdAtのうち最も新しいもの）がbeforeより前のノートを返す（createdAt昇順）
+	ListStaleNotes(ctx context.Context, projectID string, before time.Time) ([]*model.Note, error)
+
+	// エクスポート用（プロジェクト内の全件取得・埋め込み取得）
+	ListNotes(ctx context.Context, projectID string) ([]*model.Note, error)
+	GetEmbedding(ctx context.Context, id string) ([]float32, error)
+	ListGlobals(ctx context.Context, projectID string) ([]*model.GlobalConfig, error)
+
+	// プロジェクト一覧（ノート・GlobalConfig・グループのいずれかを持つもの、projectID昇順）
+	ListProjects(ctx context.Context) ([]ProjectSummary, error)
+	// プロジェクト内のgroupIdごとのノートの統計（ノートのないgroupIdは含まない）
+	GroupStats(ctx context.Context, projectID string) (map[string]GroupStats, error)
 
 	// GlobalConfig操作
 	UpsertGlobal(ctx context.Context, config *model.GlobalConfig) error
@@ -34,8 +58,51 @@ type Store interface {
 	UpdateGroup(ctx context.Context, group *model.Group) error
 	DeleteGroup(ctx context.Context, id string) error
 	ListGroups(ctx context.Context, projectID string) ([]*model.Group, error)
+	// RenameGroup はgroupKeyを変更し、そのグループのノートのgroupId・子グループのparentGroupIdも書き換える（ノート数を返す）
+	// ノートを書き換えた後に失敗した場合も、書き換え済みのノート数を返す
+	RenameGroup(ctx context.Context, id, groupKey string) (int, error)
 
 	// 初期化・終了
 	Initialize(ctx context.Context, namespace string) error
 	Close() error
 }
+
+// Transactional は複数の書き込みを1つのトランザクションで行えるStore（SQLiteStoreが実装する）
+type Transactional interface {
+	// WithTx はfnにトランザクション内で操作するStoreを渡し、fnがエラーを返さなければコミット、返せばロールバックする
+	// 渡したStoreはfnの中だけで使う（Initialize・Closeは呼ばない）
+	WithTx(ctx context.Context, fn func(tx Store) error) error
+}
+
+// WithTx はsがトランザクションに対応していればfnを1つのトランザクションで実行し、対応していなければsでそのまま実行する
+// atomicは、fnが失敗した場合にfnの書き込みがすべて取り消されるか（falseなら失敗までの書き込みが残る）を返す
+func WithTx(ctx context.Context, s Store, fn func(tx Store) error) (atomic bool, err error) {
+	if t, ok := s.(Transactional); ok {
+		if err := t.WithTx(ctx, fn); !errors.Is(err, ErrNotTransactional) {
+			return true, err
+		}
+	}
+	return false, fn(s)
+}
+
+// RawScanner はprojectIdで絞らずにnamespaceの全ノートを走査できるStore（QdrantStore・SQLiteStore・MemoryStoreが実装する）
+// ListNotesと違い、payloadを解釈・復号できないノートも飛ばさずにErrを付けて渡す
+type RawScanner interface {
+	// ScanNotes は全ノートを1件ずつfnに渡す（fnがエラーを返せば中断してそのエラーを返す）
+	// fnの中で走査中のノートを更新・削除してもよい
+	ScanNotes(ctx context.Context, fn func(RawNote) error) error
+}
+
+// ScanNotes はsが対応していれば全ノートを走査する。対応していなければfnを呼ばずにscanned=falseを返す
+func ScanNotes(ctx context.Context, s Store, fn func(RawNote) error) (scanned bool, err error) {
+	r, ok := s.(RawScanner)
+	if !ok {
+		return false, nil
+	}
+	if err := r.ScanNotes(ctx, fn); errors.Is(err, ErrNotScannable) {
+		return false, nil
+	} else if err != nil {
+		return true, err
+	}
+	return true, nil
+}
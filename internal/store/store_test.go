@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
@@ -25,6 +26,95 @@ func TestStoreInterface_GlobalConfigOperations(t *testing.T) {
 	// 具体的なテストはchroma_test.goで実施
 }
 
+// testListPagination はListRecentをカーソルでページ送りすると全件を重複・欠落なく同じ順に返すことを検証する
+// 同時刻のノート（ID降順に並ぶ）がページの境目をまたぐようにし、CountNotesの件数も確認する
+func testListPagination(t *testing.T, st Store, projectID string, dim int) {
+	t.Helper()
+	ctx := context.Background()
+	embedding := make([]float32, dim)
+	embedding[0] = 1
+
+	times := []string{"2024-03-01T00:00:00Z", "2024-03-02T00:00:00Z", "2024-03-02T00:00:00Z", "2024-03-02T00:00:00Z", "2024-03-03T00:00:00Z"}
+	for i, createdAt := range times {
+		note := newTestNoteWithTags(fmt.Sprintf("page-%d", i), projectID, "global", "text", []string{"paged"})
+		note.CreatedAt = &createdAt
+		if err := st.AddNote(ctx, note, embedding); err != nil {
+			t.Fatalf("AddNote failed: %v", err)
+		}
+	}
+	other := newTestNote("page-other", projectID, "other", "text")
+	other.CreatedAt = &times[0]
+	if err := st.AddNote(ctx, other, embedding); err != nil {
+		t.Fatalf("AddNote failed: %v", err)
+	}
+
+	opts := ListOptions{ProjectID: projectID, Tags: []string{"paged"}, Limit: 2}
+	var got []string
+	for page := 0; page < 5; page++ {
+		notes, err := st.ListRecent(ctx, opts)
+		if err != nil {
+			t.Fatalf("ListRecent failed: %v", err)
+		}
+		for _, note := range notes {
+			got = append(got, note.ID)
+		}
+		if len(notes) < opts.Limit {
+			break
+		}
+		opts.After = NewListCursor(notes[len(notes)-1], opts.SortBy)
+	}
+	want := []string{"page-4", "page-3", "page-2", "page-1", "page-0"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("paged ListRecent = %v, want %v", got, want)
+	}
+
+	count, err := st.CountNotes(ctx, ListOptions{ProjectID: projectID, Tags: []string{"paged"}, Limit: 2, After: opts.After})
+	if err != nil {
+		t.Fatalf("CountNotes failed: %v", err)
+	}
+	if count != 5 {
+		t.Errorf("CountNotes = %d, want 5", count)
+	}
+	if count, _ := st.CountNotes(ctx, ListOptions{ProjectID: projectID}); count != 6 {
+		t.Errorf("CountNotes without filters = %d, want 6", count)
+	}
+}
+
+// testStaleNotes はTouchNotesで記録した最終アクセスがListStaleNotesの対象から外れることを検証する
+// 記録のないノートはupdatedAt・createdAtで判定し、他のプロジェクトのノートは含めない
+func testStaleNotes(t *testing.T, st Store, projectID string, dim int) {
+	t.Helper()
+	ctx := context.Background()
+	embedding := make([]float32, dim)
+	embedding[0] = 1
+
+	old := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, note := range []*model.Note{
+		newTestNoteWithCreatedAt("stale-1", projectID, "global", "old", old),
+		newTestNoteWithCreatedAt("stale-2", projectID, "global", "old but read", old.Add(time.Hour)),
+		newTestNoteWithCreatedAt("stale-3", projectID, "global", "new", time.Now().UTC()),
+		newTestNoteWithCreatedAt("stale-other", projectID+"-other", "global", "old", old),
+	} {
+		if err := st.AddNote(ctx, note, embedding); err != nil {
+			t.Fatalf("AddNote failed: %v", err)
+		}
+	}
+	if err := st.TouchNotes(ctx, []string{"stale-2", "missing"}, Timestamp()); err != nil {
+		t.Fatalf("TouchNotes failed: %v", err)
+	}
+
+	notes, err := st.ListStaleNotes(ctx, projectID, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("ListStaleNotes failed: %v", err)
+	}
+	if len(notes) != 1 || notes[0].ID != "stale-1" {
+		t.Errorf("expected only stale-1, got %+v", notes)
+	}
+	if notes, _ := st.ListStaleNotes(ctx, projectID, old); len(notes) != 0 {
+		t.Errorf("expected no notes before the oldest note, got %d", len(notes))
+	}
+}
+
 // Helper functions for test data
 
 // newTestNote は基本的なテスト用Noteを生成
@@ -209,3 +299,45 @@ func assertNoError(t *testing.T, err error) {
 func setupTestContext() context.Context {
 	return context.Background()
 }
+
+// TestWithTx はトランザクションに対応していないStore・ラッパーでは、fnをそのまま実行することをテスト
+func TestWithTx(t *testing.T) {
+	ctx := context.Background()
+	sqlite := setupInitializedSQLiteStore(t)
+	defer sqlite.Close()
+	memory := NewMemoryStore()
+	memory.Initialize(ctx, testSQLiteNamespace)
+
+	tests := []struct {
+		name       string
+		store      Store
+		wantAtomic bool
+	}{
+		{"sqlite", sqlite, true},
+		{"wrapped sqlite", NewSlowLogStore(NewCachedStore(sqlite, 10), time.Hour), true},
+		{"memory", memory, false},
+		{"wrapped memory", NewSlowLogStore(NewCachedStore(memory, 10), time.Hour), false},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := fmt.Sprintf("helper-%d", i)
+			atomic, err := WithTx(ctx, tt.store, func(tx Store) error {
+				if err := tx.AddNote(ctx, newSQLiteTestNote(id, testSQLiteProjectID, "global", "A"), dummySQLiteEmbedding(3)); err != nil {
+					return err
+				}
+				return fmt.Errorf("abort")
+			})
+			if err == nil || atomic != tt.wantAtomic {
+				t.Fatalf("Expected an error with atomic=%v, got %v, %v", tt.wantAtomic, atomic, err)
+			}
+			// atomicでなければ失敗までの書き込みが残る
+			_, err = tt.store.Get(ctx, id)
+			if tt.wantAtomic && err != ErrNotFound {
+				t.Errorf("Expected the note to be rolled back, got %v", err)
+			}
+			if !tt.wantAtomic && err != nil {
+				t.Errorf("Expected the note to be kept, got %v", err)
+			}
+		})
+	}
+}
@@ -11,6 +11,7 @@ import (
 type SearchOptions struct {
 	ProjectID string     // 必須
 	GroupID   *string    // nullable（nilの場合はフィルタなし）
+	GroupIDs  []string   // いずれかのgroupに一致、空/nilはフィルタなし（子グループを含める検索用）
 	TopK      int        // default: 5
 	Tags      []string   // AND検索、空/nilはフィルタなし、大小文字区別
 	Since     *time.Time // UTC、境界条件: since <= createdAt
@@ -19,16 +20,64 @@ type SearchOptions struct {
 
 // ListOptions はListRecent操作のオプション
 type ListOptions struct {
-	ProjectID string   // 必須
-	GroupID   *string  // nullable（nilの場合は全group）
-	Limit     int      // default: 10
-	Tags      []string // AND検索、空/nilはフィルタなし
+	ProjectID string      // 必須
+	GroupID   *string     // nullable（nilの場合は全group）
+	GroupIDs  []string    // いずれかのgroupに一致、空/nilはフィルタなし（子グループを含める一覧用）
+	Limit     int         // default: 10
+	Tags      []string    // AND検索、空/nilはフィルタなし
+	SortBy    string      // SortByCreatedAt（デフォルト）またはSortByUpdatedAt。いずれも降順
+	After     *ListCursor // nilなら先頭から。指定すると、このノートより後（同じソートキーならID降順）のノートを返す
 }
 
+// ListCursor はListRecentのページの続きを取得するための位置（前のページの最後のノート）
+// ListRecentはソートキー降順、同じ値ならID降順に並べる
+type ListCursor struct {
+	Key string // 最後のノートのソートキーの値（NoteSortKeyの戻り値。空ならソートキーなし）
+	ID  string // 最後のノートのID
+}
+
+// NewListCursor はnoteの次から一覧を続けるカーソルを返す
+func NewListCursor(note *model.Note, sortBy string) *ListCursor {
+	return &ListCursor{Key: NoteSortKey(note, sortBy), ID: note.ID}
+}
+
+// ListRecentのソートキー
+const (
+	SortByCreatedAt = "createdAt"
+	SortByUpdatedAt = "updatedAt"
+)
+
+// SharedGlobalsNamespace はGlobalConfigをnamespaceによらず共通に保存する場合（store.globalsScope: "shared"）の保存先
+// embedderのnamespace（provider:model:dim）と重ならない名前にする
+const SharedGlobalsNamespace = "shared"
+
 // SearchResult はベクトル検索結果の1件を表す
 type SearchResult struct {
-	Note  *model.Note
-	Score float64 // 0-1に正規化（1が最も類似）
+	Note     *model.Note
+	Score    float64 // 0-1に正規化（1が最も類似）
+	Archived bool    // ColdStoreの検索結果
+}
+
+// ProjectSummary はストア内のプロジェクト1件分の概要
+type ProjectSummary struct {
+	ProjectID string
+	NoteCount int
+}
+
+// GroupStats はプロジェクト内のgroupId1件分のノートの統計
+type GroupStats struct {
+	NoteCount  int
+	LastNoteAt string // 最も新しいノートのcreatedAt
+	TextLength int    // 本文の合計文字数
+}
+
+// RawNote はScanNotesで走査したノート1件（payloadを解釈できなかったノートも含む）
+type RawNote struct {
+	ID        string         // Deleteに渡せるID（payloadのidでポイントを特定できなければポイントのID）
+	Note      *model.Note    // 解釈したノート（Errがあれば暗号化されたまま・nilのことがある）
+	Embedding []float32      // 埋め込み（なければnil）
+	Payload   map[string]any // 元のpayload（Qdrantのみ。隔離するときに残す）
+	Err       error          // 解釈・復号できなかった理由
 }
 
 // エラー定義
@@ -36,8 +85,68 @@ var (
 	ErrNotFound         = errors.New("resource not found")
 	ErrNotInitialized   = errors.New("store not initialized")
 	ErrConnectionFailed = errors.New("failed to connect to store")
+	ErrCircuitOpen      = errors.New("store is unavailable (circuit breaker open)")
+	// ErrNotTransactional はラップしているStoreがトランザクションに対応していないことを示す（WithTxがfnを呼ぶ前に返す）
+	ErrNotTransactional = errors.New("store does not support transactions")
+	// ErrNotScannable はラップしているStoreがScanNotesに対応していないことを示す（fnを呼ぶ前に返す）
+	ErrNotScannable = errors.New("store does not support scanning all notes")
 )
 
+// Timestamp は現在時刻をupdatedAtの形式（UTC、ミリ秒まで）で返す
+// 同じ秒の中の更新も区別できるよう、createdAtより細かくする
+func Timestamp() string {
+	return FormatTimestamp(time.Now())
+}
+
+// FormatTimestamp はtをupdatedAtの形式（UTC、ミリ秒まで）にする
+func FormatTimestamp(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z07:00")
+}
+
+// stampNewNote はAddNote時のupdatedAtを設定する（指定がなければcreatedAt、それもなければ現在時刻）
+func stampNewNote(note *model.Note) {
+	if note.UpdatedAt != nil {
+		return
+	}
+	now := Timestamp()
+	if note.CreatedAt != nil {
+		now = *note.CreatedAt
+	}
+	note.UpdatedAt = &now
+}
+
+// stampUpdatedNote はUpdate時のupdatedAtを現在時刻にする
+func stampUpdatedNote(note *model.Note) {
+	now := Timestamp()
+	note.UpdatedAt = &now
+}
+
+// NoteSortKey はListRecentのソートキーの値を返す（updatedAtがなければcreatedAt、どちらもなければ空）
+func NoteSortKey(note *model.Note, sortBy string) string {
+	value := note.CreatedAt
+	if sortBy == SortByUpdatedAt && note.UpdatedAt != nil {
+		value = note.UpdatedAt
+	}
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// noteSortTime はListRecentのソートキーの時刻を返す（updatedAtがなければcreatedAt）
+// どちらもない・解析できない場合はfalse
+func noteSortTime(note *model.Note, sortBy string) (time.Time, bool) {
+	value := note.CreatedAt
+	if sortBy == SortByUpdatedAt && note.UpdatedAt != nil {
+		value = note.UpdatedAt
+	}
+	if value == nil {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, *value)
+	return t, err == nil
+}
+
 // DefaultSearchOptions はSearchOptionsのデフォルト値を返す
 func DefaultSearchOptions() SearchOptions {
 	return SearchOptions{TopK: 5}
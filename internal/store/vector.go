@@ -0,0 +1,42 @@
+package store
+
+import "math"
+
+// dot は同じ長さのベクトルa・bの内積を返す
+// amd64でAVX・FMAが使える場合はアセンブリ実装（vector_amd64.s）に置き換わる（purego タグで無効化）
+var dot = dotGeneric
+
+// dotGeneric は4つのアキュムレータに分けて展開したループで内積を計算する
+// 加算の依存関係を分けることで、CPUが複数の積和を並行して実行できる
+func dotGeneric(a, b []float32) float32 {
+	b = b[:len(a)] // 境界チェックをループの外に出す
+	var s0, s1, s2, s3 float32
+	i := 0
+	for ; i+4 <= len(a); i += 4 {
+		s0 += a[i] * b[i]
+		s1 += a[i+1] * b[i+1]
+		s2 += a[i+2] * b[i+2]
+		s3 += a[i+3] * b[i+3]
+	}
+	for ; i < len(a); i++ {
+		s0 += a[i] * b[i]
+	}
+	return (s0 + s1) + (s2 + s3)
+}
+
+// vectorNorm はvのノルム（長さ）を返す
+func vectorNorm(v []float32) float64 {
+	return math.Sqrt(float64(dot(v, v)))
+}
+
+// cosineDistance は内積とそれぞれのノルムからcosine distance（0=同一、2=正反対）を求める
+// ノルムが0の場合は2（最も遠い）とする
+// ノルムを事前に求めておけば、ノートごとの計算は内積だけで済む
+func cosineDistance(dotProduct float32, normA, normB float64) float64 {
+	if normA == 0 || normB == 0 {
+		return 2.0
+	}
+	similarity := float64(dotProduct) / (normA * normB)
+	// 丸め誤差で-1〜1をわずかに超えないようにする
+	return 1.0 - max(-1, min(1, similarity))
+}
@@ -0,0 +1,16 @@
+//go:build amd64 && !purego
+
+package store
+
+import "golang.org/x/sys/cpu"
+
+// dotAVX はAVXのFMA命令で8要素ずつ（32要素ごとに4つのアキュムレータで）内積を計算する
+//
+//go:noescape
+func dotAVX(a, b []float32) float32
+
+func init() {
+	if cpu.X86.HasAVX && cpu.X86.HasFMA {
+		dot = dotAVX
+	}
+}
@@ -0,0 +1,131 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// referenceCosineDistance は最適化前と同じfloat64の素朴なループによるcosine distance（比較用）
+func referenceCosineDistance(a, b []float32) float64 {
+	var dotProduct, normA, normB float64
+	for i := range a {
+		dotProduct += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 2.0
+	}
+	return 1.0 - dotProduct/(math.Sqrt(normA)*math.Sqrt(normB))
+}
+
+// randomVector は-1〜1の乱数でdim次元のベクトルを作る
+func randomVector(r *rand.Rand, dim int) []float32 {
+	v := make([]float32, dim)
+	for i := range v {
+		v[i] = r.Float32()*2 - 1
+	}
+	return v
+}
+
+// TestDot は内積がfloat64の素朴な計算と一致することをテスト（SIMDの端数処理を含む長さで確認）
+func TestDot(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	for _, n := range append([]int{0, 1, 3, 7, 8, 9, 31, 32, 33, 100, 1536}, r.IntN(3000)) {
+		a, b := randomVector(r, n), randomVector(r, n)
+		var want, scale float64
+		for i := range a {
+			want += float64(a[i]) * float64(b[i])
+			scale += math.Abs(float64(a[i]) * float64(b[i]))
+		}
+		for name, fn := range map[string]func(a, b []float32) float32{"dot": dot, "dotGeneric": dotGeneric} {
+			if got := float64(fn(a, b)); math.Abs(got-want) > 1e-5*(scale+1) {
+				t.Errorf("%s(len=%d) = %v, want %v", name, n, got, want)
+			}
+		}
+	}
+}
+
+// TestCosineSimilarity はcosine distanceの値と境界のケースをテスト
+func TestCosineSimilarity(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float32
+		want float64
+	}{
+		{"identical", []float32{1, 2, 3}, []float32{1, 2, 3}, 0},
+		{"scaled", []float32{1, 2, 3}, []float32{2, 4, 6}, 0},
+		{"orthogonal", []float32{1, 0}, []float32{0, 1}, 1},
+		{"opposite", []float32{1, 2}, []float32{-1, -2}, 2},
+		{"zero vector", []float32{0, 0}, []float32{1, 0}, 2},
+		{"length mismatch", []float32{1, 0}, []float32{1, 0, 0}, 2},
+		{"empty", []float32{}, []float32{}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CosineSimilarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("CosineSimilarity = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	r := rand.New(rand.NewPCG(3, 4))
+	for range 100 {
+		a, b := randomVector(r, 1536), randomVector(r, 1536)
+		if got, want := CosineSimilarity(a, b), referenceCosineDistance(a, b); math.Abs(got-want) > 1e-5 {
+			t.Fatalf("CosineSimilarity = %v, want %v", got, want)
+		}
+	}
+}
+
+func BenchmarkCosineDistance(b *testing.B) {
+	r := rand.New(rand.NewPCG(5, 6))
+	x, y := randomVector(r, 1536), randomVector(r, 1536)
+	yNorm := vectorNorm(y)
+
+	b.Run("reference", func(b *testing.B) {
+		for b.Loop() {
+			referenceCosineDistance(x, y)
+		}
+	})
+	b.Run("dotGeneric", func(b *testing.B) {
+		for b.Loop() {
+			dotGeneric(x, y)
+		}
+	})
+	b.Run("precomputedNorm", func(b *testing.B) {
+		xNorm := vectorNorm(x)
+		for b.Loop() {
+			cosineDistance(dot(x, y), xNorm, yNorm)
+		}
+	})
+}
+
+// BenchmarkMemoryStore_Search は1万件・1536次元のノートを総当たりで検索する
+func BenchmarkMemoryStore_Search(b *testing.B) {
+	const notes, dim = 10000, 1536
+	ctx := context.Background()
+	st := NewMemoryStore()
+	if err := st.Initialize(ctx, "bench:model:1536"); err != nil {
+		b.Fatal(err)
+	}
+	defer st.Close()
+
+	r := rand.New(rand.NewPCG(7, 8))
+	for i := range notes {
+		note := newTestNote(fmt.Sprintf("note-%d", i), "/bench/project", "global", "text")
+		if err := st.AddNote(ctx, note, randomVector(r, dim)); err != nil {
+			b.Fatal(err)
+		}
+	}
+	query := randomVector(r, dim)
+
+	for b.Loop() {
+		if _, err := st.Search(ctx, query, SearchOptions{ProjectID: "/bench/project", TopK: 10}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package sysservice
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+)
+
+// launchdLabel はnameのlaunchdのラベルを返す
+func launchdLabel(name string) string {
+	return launchdLabelPrefix + name
+}
+
+// launchdDomain はサービスを読み込むlaunchdのドメイン（ユーザーはgui/<uid>、システムはsystem）
+func (m *Manager) launchdDomain() string {
+	if m.system {
+		return "system"
+	}
+	return "gui/" + strconv.Itoa(m.uid)
+}
+
+// launchdTarget はnameのサービスを指すlaunchctlのサービスターゲット
+func (m *Manager) launchdTarget(name string) string {
+	return m.launchdDomain() + "/" + launchdLabel(name)
+}
+
+// renderLaunchd はlaunchdのplistを返す
+// 読み込み時（ログイン・起動時）に開始し、異常終了した場合のみ再起動する
+func renderLaunchd(def *Definition, system bool) []byte {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	b.WriteString(`<plist version="1.0">` + "\n<dict>\n")
+
+	writeKey := func(key string) {
+		b.WriteString("\t<key>")
+		xml.EscapeText(&b, []byte(key))
+		b.WriteString("</key>\n")
+	}
+	writeString := func(indent, value string) {
+		b.WriteString(indent + "<string>")
+		xml.EscapeText(&b, []byte(value))
+		b.WriteString("</string>\n")
+	}
+
+	writeKey("Label")
+	writeString("\t", launchdLabel(def.Name))
+	writeKey("ProgramArguments")
+	b.WriteString("\t<array>\n")
+	for _, arg := range append([]string{def.Binary}, def.Args...) {
+		writeString("\t\t", arg)
+	}
+	b.WriteString("\t</array>\n")
+	if len(def.Env) > 0 {
+		writeKey("EnvironmentVariables")
+		b.WriteString("\t<dict>\n")
+		for _, k := range sortedKeys(def.Env) {
+			b.WriteString("\t\t<key>")
+			xml.EscapeText(&b, []byte(k))
+			b.WriteString("</key>\n")
+			writeString("\t\t", def.Env[k])
+		}
+		b.WriteString("\t</dict>\n")
+	}
+	if system && def.User != "" {
+		writeKey("UserName")
+		writeString("\t", def.User)
+	}
+	writeKey("RunAtLoad")
+	b.WriteString("\t<true/>\n")
+	writeKey("KeepAlive")
+	b.WriteString("\t<dict>\n\t\t<key>SuccessfulExit</key>\n\t\t<false/>\n\t</dict>\n")
+	writeKey("ProcessType")
+	writeString("\t", "Background")
+	b.WriteString("</dict>\n</plist>\n")
+	return b.Bytes()
+}
+
+// loadLaunchd はplistをlaunchdに読み込む（RunAtLoadによりすぐに開始する）
+// 更新した場合は読み込み済みの古い定義を外してから読み込む
+func (m *Manager) loadLaunchd(name, path string, reload bool) error {
+	if reload {
+		m.run("launchctl", "bootout", m.launchdTarget(name))
+	} else if m.run("launchctl", "print", m.launchdTarget(name)) == nil {
+		// 読み込み済み（定義は変わっていない）
+		return nil
+	}
+	if err := m.run("launchctl", "bootstrap", m.launchdDomain(), path); err != nil {
+		return fmt.Errorf("failed to load %s: %w", path, err)
+	}
+	return nil
+}
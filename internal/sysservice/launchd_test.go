@@ -0,0 +1,54 @@
+package sysservice
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestRenderLaunchd(t *testing.T) {
+	def := &Definition{
+		Name:   "mcp-memory",
+		Binary: "/usr/local/bin/mcp-memory",
+		Args:   []string{"serve", "-t", "http", "--data-dir", "/Users/alice/R&D <memory>"},
+		Env:    map[string]string{"OPENAI_API_KEY": "sk-test"},
+		User:   "alice",
+	}
+
+	user := string(renderLaunchd(def, false))
+	for _, want := range []string{
+		"<key>Label</key>\n\t<string>com.github.brbranch.mcp-memory</string>",
+		"\t\t<string>/usr/local/bin/mcp-memory</string>\n\t\t<string>serve</string>",
+		"<string>/Users/alice/R&amp;D &lt;memory&gt;</string>",
+		"\t\t<key>OPENAI_API_KEY</key>\n\t\t<string>sk-test</string>",
+		"<key>RunAtLoad</key>\n\t<true/>",
+		"<key>SuccessfulExit</key>\n\t\t<false/>",
+	} {
+		if !strings.Contains(user, want) {
+			t.Errorf("plist does not contain %q:\n%s", want, user)
+		}
+	}
+	if strings.Contains(user, "UserName") {
+		t.Errorf("user agent must not set UserName:\n%s", user)
+	}
+	// 整形式のXMLであること
+	if err := xml.Unmarshal([]byte(user), new(struct{})); err != nil {
+		t.Errorf("plist is not valid XML: %v", err)
+	}
+
+	system := string(renderLaunchd(def, true))
+	if !strings.Contains(system, "<key>UserName</key>\n\t<string>alice</string>") {
+		t.Errorf("daemon plist does not set UserName:\n%s", system)
+	}
+}
+
+func TestManager_LaunchdTarget(t *testing.T) {
+	m, _ := newTestManager(t, "darwin", false)
+	if got := m.launchdTarget("mcp-memory"); got != "gui/501/com.github.brbranch.mcp-memory" {
+		t.Errorf("launchdTarget() = %s", got)
+	}
+	m.system = true
+	if got := m.launchdTarget("mcp-memory"); got != "system/com.github.brbranch.mcp-memory" {
+		t.Errorf("launchdTarget() = %s", got)
+	}
+}
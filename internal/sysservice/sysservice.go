@@ -0,0 +1,321 @@
+// Package sysservice registers the mcp-memory server with the OS service manager (systemd, launchd or Windows services).
+package sysservice
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Kind はサービスマネージャーの種類
+type Kind string
+
+// 対応するサービスマネージャー
+const (
+	KindSystemd Kind = "systemd" // Linux
+	KindLaunchd Kind = "launchd" // macOS
+	KindWindows Kind = "windows" // Windowsサービス
+)
+
+// DefaultName はサービス名のデフォルト
+const DefaultName = "mcp-memory"
+
+// launchdLabelPrefix はlaunchdのラベル（reverse DNS形式）の接頭辞
+const launchdLabelPrefix = "com.github.brbranch."
+
+var (
+	// ErrUnsupported はサービスマネージャーに対応していないOS
+	ErrUnsupported = errors.New("no supported service manager on this platform")
+	// ErrExists は同名の異なる定義が登録済み
+	ErrExists = errors.New("service already installed")
+	// ErrNotInstalled はサービスが登録されていない
+	ErrNotInstalled = errors.New("service is not installed")
+)
+
+// Result はInstallの結果
+type Result string
+
+// Installの結果
+const (
+	ResultCreated   Result = "created"
+	ResultUpdated   Result = "updated"
+	ResultUnchanged Result = "unchanged"
+)
+
+// Definition はサービスの起動定義
+type Definition struct {
+	Name   string            // サービス名（launchdのラベル・systemdのユニット名の元になる）
+	Binary string            // mcp-memoryの絶対パス
+	Args   []string          // Binaryに渡す引数（serve ...）
+	Env    map[string]string // 追加する環境変数
+	User   string            // システム全体のサービスを実行するユーザー（空ならroot。Windowsでは無視しLocalSystem）
+}
+
+// Manager はOSのサービスマネージャーにサービスを登録・起動・停止する
+type Manager struct {
+	kind   Kind
+	system bool   // trueならシステム全体（起動時に開始）、falseならユーザー（ログイン時に開始）
+	dir    string // 定義ファイルを置くディレクトリ（Windowsは空）
+	uid    int    // launchdのguiドメインに使うユーザーID
+
+	// run は外部コマンド（systemctl・launchctl）を実行する（テストで差し替える）
+	run func(name string, args ...string) error
+}
+
+// New は実行中のOSのサービスマネージャーを返す
+// systemがtrueならシステム全体（起動時）、falseならログインユーザー（ログイン時）に登録する
+func New(system bool) (*Manager, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return newManager(runtime.GOOS, system, home, os.Getenv)
+}
+
+// newManager はgoosのサービスマネージャーを返す
+func newManager(goos string, system bool, home string, getenv func(string) string) (*Manager, error) {
+	m := &Manager{system: system, uid: os.Getuid(), run: runCommand}
+	switch goos {
+	case "linux":
+		m.kind = KindSystemd
+		if system {
+			m.dir = "/etc/systemd/system"
+		} else {
+			configHome := getenv("XDG_CONFIG_HOME")
+			if configHome == "" || !filepath.IsAbs(configHome) {
+				configHome = filepath.Join(home, ".config")
+			}
+			m.dir = filepath.Join(configHome, "systemd", "user")
+		}
+	case "darwin":
+		m.kind = KindLaunchd
+		if system {
+			m.dir = "/Library/LaunchDaemons"
+		} else {
+			m.dir = filepath.Join(home, "Library", "LaunchAgents")
+		}
+	case "windows":
+		m.kind = KindWindows
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupported, goos)
+	}
+	return m, nil
+}
+
+// Kind はサービスマネージャーの種類を返す
+func (m *Manager) Kind() Kind {
+	return m.kind
+}
+
+// Path はnameの定義ファイルのパスを返す（Windowsはサービスの登録先がレジストリのため空）
+func (m *Manager) Path(name string) string {
+	switch m.kind {
+	case KindSystemd:
+		return filepath.Join(m.dir, name+".service")
+	case KindLaunchd:
+		return filepath.Join(m.dir, launchdLabel(name)+".plist")
+	default:
+		return ""
+	}
+}
+
+// Render はdefの定義ファイルの内容を返す（Windowsは登録する設定の説明）
+func (m *Manager) Render(def *Definition) ([]byte, error) {
+	if err := def.validate(); err != nil {
+		return nil, err
+	}
+	switch m.kind {
+	case KindSystemd:
+		return renderSystemd(def, m.system), nil
+	case KindLaunchd:
+		return renderLaunchd(def, m.system), nil
+	default:
+		return renderWindows(def), nil
+	}
+}
+
+// Install はdefを登録し、ログイン時（systemなら起動時）に開始するよう有効にして起動する
+// 同名で内容の異なる定義がある場合はforceがなければErrExistsを返す
+func (m *Manager) Install(def *Definition, force bool) (Result, error) {
+	if m.kind == KindWindows {
+		return installWindows(def, force)
+	}
+	data, err := m.Render(def)
+	if err != nil {
+		return "", err
+	}
+	path := m.Path(def.Name)
+
+	result := ResultCreated
+	existing, err := os.ReadFile(path)
+	switch {
+	case err == nil && bytes.Equal(existing, data):
+		result = ResultUnchanged
+	case err == nil:
+		if !force {
+			return "", fmt.Errorf("%w: %s (use --force to replace it)", ErrExists, path)
+		}
+		result = ResultUpdated
+	case !os.IsNotExist(err):
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if result != ResultUnchanged {
+		if err := os.MkdirAll(m.dir, 0o755); err != nil {
+			return "", fmt.Errorf("failed to create %s: %w", m.dir, err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return "", fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+
+	switch m.kind {
+	case KindSystemd:
+		err = m.enableSystemd(def.Name, result == ResultUpdated)
+	case KindLaunchd:
+		err = m.loadLaunchd(def.Name, path, result == ResultUpdated)
+	}
+	return result, err
+}
+
+// Uninstall はnameのサービスを停止して登録を削除する（登録されていなければErrNotInstalled）
+func (m *Manager) Uninstall(name string) error {
+	if m.kind == KindWindows {
+		return uninstallWindows(name)
+	}
+	path := m.Path(name)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", ErrNotInstalled, path)
+	}
+	switch m.kind {
+	case KindSystemd:
+		// 停止に失敗しても（起動していない等）登録は削除する
+		m.systemctl("disable", "--now", unitName(name))
+	case KindLaunchd:
+		m.run("launchctl", "bootout", m.launchdTarget(name))
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	if m.kind == KindSystemd {
+		return m.systemctl("daemon-reload")
+	}
+	return nil
+}
+
+// Start はnameのサービスを開始する
+func (m *Manager) Start(name string) error {
+	if err := m.checkInstalled(name); err != nil {
+		return err
+	}
+	switch m.kind {
+	case KindSystemd:
+		return m.systemctl("start", unitName(name))
+	case KindLaunchd:
+		return m.run("launchctl", "kickstart", m.launchdTarget(name))
+	default:
+		return startWindows(name)
+	}
+}
+
+// Stop はnameのサービスを停止する（次のログイン・起動時には再び開始する）
+func (m *Manager) Stop(name string) error {
+	if err := m.checkInstalled(name); err != nil {
+		return err
+	}
+	switch m.kind {
+	case KindSystemd:
+		return m.systemctl("stop", unitName(name))
+	case KindLaunchd:
+		// KeepAliveは異常終了時のみ再起動するため、SIGTERMで正常終了させれば止まったままになる
+		return m.run("launchctl", "kill", "SIGTERM", m.launchdTarget(name))
+	default:
+		return stopWindows(name)
+	}
+}
+
+// checkInstalled は定義ファイルがなければErrNotInstalledを返す（Windowsはサービスマネージャーが判定する）
+func (m *Manager) checkInstalled(name string) error {
+	path := m.Path(name)
+	if path == "" {
+		return nil
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", ErrNotInstalled, path)
+	}
+	return nil
+}
+
+// validate は定義に必要な値が揃っているか確認する
+func (d *Definition) validate() error {
+	if d.Name == "" {
+		return errors.New("service name is empty")
+	}
+	if strings.ContainsAny(d.Name, `/\ `) {
+		return fmt.Errorf("invalid service name %q", d.Name)
+	}
+	if !filepath.IsAbs(d.Binary) {
+		return fmt.Errorf("binary path must be absolute: %s", d.Binary)
+	}
+	return nil
+}
+
+// sortedEnv はEnvをキー順のKEY=VALUEで返す
+func (d *Definition) sortedEnv() []string {
+	pairs := make([]string, 0, len(d.Env))
+	for _, k := range sortedKeys(d.Env) {
+		pairs = append(pairs, k+"="+d.Env[k])
+	}
+	return pairs
+}
+
+// sortedKeys はmのキーをソートして返す
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// runCommand はコマンドを実行し、失敗したら出力をエラーに含める
+func runCommand(name string, args ...string) error {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%s %s: %w: %s", name, strings.Join(args, " "), err, msg)
+		}
+		return fmt.Errorf("%s %s: %w", name, strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+// renderWindows はWindowsサービスとして登録する設定を説明する（--dry-run用）
+func renderWindows(def *Definition) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Service:     %s (automatic start, LocalSystem)\n", def.Name)
+	fmt.Fprintf(&b, "Command:     %s\n", windowsCommandLine(def.Binary, def.Args))
+	for _, pair := range def.sortedEnv() {
+		fmt.Fprintf(&b, "Environment: %s\n", pair)
+	}
+	return []byte(b.String())
+}
+
+// windowsCommandLine は引数を空白・引用符を含む場合だけ引用符で囲んで連結する
+func windowsCommandLine(binary string, args []string) string {
+	parts := make([]string, 0, len(args)+1)
+	for _, arg := range append([]string{binary}, args...) {
+		if arg == "" || strings.ContainsAny(arg, " \t\"") {
+			arg = `"` + strings.ReplaceAll(arg, `"`, `\"`) + `"`
+		}
+		parts = append(parts, arg)
+	}
+	return strings.Join(parts, " ")
+}
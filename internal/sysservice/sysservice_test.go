@@ -0,0 +1,229 @@
+package sysservice
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeRunner は実行したコマンドを記録する（failは失敗させるコマンドの前方一致）
+type fakeRunner struct {
+	commands []string
+	fail     []string
+}
+
+func (f *fakeRunner) run(name string, args ...string) error {
+	cmd := strings.Join(append([]string{name}, args...), " ")
+	f.commands = append(f.commands, cmd)
+	for _, prefix := range f.fail {
+		if strings.HasPrefix(cmd, prefix) {
+			return errors.New("exit status 1")
+		}
+	}
+	return nil
+}
+
+// newTestManager はhomeを一時ディレクトリにしたgoosのManagerを返す
+func newTestManager(t *testing.T, goos string, system bool) (*Manager, *fakeRunner) {
+	t.Helper()
+	m, err := newManager(goos, system, t.TempDir(), func(string) string { return "" })
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner := &fakeRunner{}
+	m.run = runner.run
+	m.uid = 501
+	return m, runner
+}
+
+func testDefinition() *Definition {
+	return &Definition{
+		Name:   DefaultName,
+		Binary: "/usr/local/bin/mcp-memory",
+		Args:   []string{"serve", "-t", "http", "--port", "8765"},
+		Env:    map[string]string{"OPENAI_API_KEY": "sk-test"},
+	}
+}
+
+func TestNewManager(t *testing.T) {
+	getenv := func(key string) string {
+		if key == "XDG_CONFIG_HOME" {
+			return "/home/alice/.xdg"
+		}
+		return ""
+	}
+	tests := []struct {
+		goos     string
+		system   bool
+		wantKind Kind
+		wantPath string
+	}{
+		{"linux", false, KindSystemd, "/home/alice/.xdg/systemd/user/mcp-memory.service"},
+		{"linux", true, KindSystemd, "/etc/systemd/system/mcp-memory.service"},
+		{"darwin", false, KindLaunchd, "/home/alice/Library/LaunchAgents/com.github.brbranch.mcp-memory.plist"},
+		{"darwin", true, KindLaunchd, "/Library/LaunchDaemons/com.github.brbranch.mcp-memory.plist"},
+		{"windows", true, KindWindows, ""},
+	}
+	for _, tt := range tests {
+		m, err := newManager(tt.goos, tt.system, "/home/alice", getenv)
+		if err != nil {
+			t.Fatalf("newManager(%s) error = %v", tt.goos, err)
+		}
+		if m.Kind() != tt.wantKind {
+			t.Errorf("newManager(%s).Kind() = %s, want %s", tt.goos, m.Kind(), tt.wantKind)
+		}
+		if got := m.Path(DefaultName); got != filepath.FromSlash(tt.wantPath) {
+			t.Errorf("newManager(%s, system=%v).Path() = %s, want %s", tt.goos, tt.system, got, tt.wantPath)
+		}
+	}
+
+	// XDG_CONFIG_HOMEが未設定なら ~/.config
+	m, _ := newManager("linux", false, "/home/alice", func(string) string { return "" })
+	if got, want := m.Path(DefaultName), filepath.FromSlash("/home/alice/.config/systemd/user/mcp-memory.service"); got != want {
+		t.Errorf("Path() = %s, want %s", got, want)
+	}
+
+	if _, err := newManager("plan9", false, "/home/alice", getenv); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported, got %v", err)
+	}
+}
+
+func TestManager_InstallSystemd(t *testing.T) {
+	m, runner := newTestManager(t, "linux", false)
+	def := testDefinition()
+
+	result, err := m.Install(def, false)
+	if err != nil || result != ResultCreated {
+		t.Fatalf("Install() = %s, %v", result, err)
+	}
+	data, err := os.ReadFile(m.Path(DefaultName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `ExecStart="/usr/local/bin/mcp-memory" "serve"`) {
+		t.Errorf("unexpected unit:\n%s", data)
+	}
+	want := []string{
+		"systemctl --user daemon-reload",
+		"systemctl --user enable mcp-memory.service",
+		"systemctl --user start mcp-memory.service",
+	}
+	if strings.Join(runner.commands, "\n") != strings.Join(want, "\n") {
+		t.Errorf("commands = %q, want %q", runner.commands, want)
+	}
+
+	// 同じ定義ならunchanged
+	if result, err := m.Install(def, false); err != nil || result != ResultUnchanged {
+		t.Errorf("second Install() = %s, %v", result, err)
+	}
+
+	// 異なる定義は--forceがなければ拒否し、あれば置き換えて再起動する
+	def.Args = append(def.Args, "--host", "0.0.0.0")
+	if _, err := m.Install(def, false); !errors.Is(err, ErrExists) {
+		t.Errorf("expected ErrExists, got %v", err)
+	}
+	runner.commands = nil
+	if result, err := m.Install(def, true); err != nil || result != ResultUpdated {
+		t.Fatalf("forced Install() = %s, %v", result, err)
+	}
+	if last := runner.commands[len(runner.commands)-1]; last != "systemctl --user restart mcp-memory.service" {
+		t.Errorf("expected a restart, got %q", runner.commands)
+	}
+}
+
+func TestManager_InstallLaunchd(t *testing.T) {
+	m, runner := newTestManager(t, "darwin", false)
+	runner.fail = []string{"launchctl print"}
+
+	if result, err := m.Install(testDefinition(), false); err != nil || result != ResultCreated {
+		t.Fatalf("Install() = %s, %v", result, err)
+	}
+	want := "launchctl bootstrap gui/501 " + m.Path(DefaultName)
+	if last := runner.commands[len(runner.commands)-1]; last != want {
+		t.Errorf("commands = %q, want last %q", runner.commands, want)
+	}
+
+	// 読み込み済みで定義が同じなら読み込み直さない
+	runner.fail = nil
+	runner.commands = nil
+	if result, err := m.Install(testDefinition(), false); err != nil || result != ResultUnchanged {
+		t.Fatalf("second Install() = %s, %v", result, err)
+	}
+	if len(runner.commands) != 1 || runner.commands[0] != "launchctl print gui/501/com.github.brbranch.mcp-memory" {
+		t.Errorf("commands = %q", runner.commands)
+	}
+}
+
+func TestManager_StartStopUninstall(t *testing.T) {
+	m, runner := newTestManager(t, "linux", true)
+	// システムのユニットのディレクトリは一時ディレクトリに差し替える
+	m.dir = t.TempDir()
+
+	for _, op := range []func(string) error{m.Start, m.Stop, m.Uninstall} {
+		if err := op(DefaultName); !errors.Is(err, ErrNotInstalled) {
+			t.Errorf("expected ErrNotInstalled, got %v", err)
+		}
+	}
+	if len(runner.commands) != 0 {
+		t.Errorf("expected no commands before install, got %q", runner.commands)
+	}
+
+	if _, err := m.Install(testDefinition(), false); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	runner.commands = nil
+	if err := m.Stop(DefaultName); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Start(DefaultName); err != nil {
+		t.Fatal(err)
+	}
+	// 停止に失敗しても登録は削除する
+	runner.fail = []string{"systemctl disable"}
+	if err := m.Uninstall(DefaultName); err != nil {
+		t.Fatalf("Uninstall() error = %v", err)
+	}
+	want := []string{
+		"systemctl stop mcp-memory.service",
+		"systemctl start mcp-memory.service",
+		"systemctl disable --now mcp-memory.service",
+		"systemctl daemon-reload",
+	}
+	if strings.Join(runner.commands, "\n") != strings.Join(want, "\n") {
+		t.Errorf("commands = %q, want %q", runner.commands, want)
+	}
+	if _, err := os.Stat(m.Path(DefaultName)); !os.IsNotExist(err) {
+		t.Errorf("expected the unit to be removed, got %v", err)
+	}
+}
+
+func TestDefinition_Validate(t *testing.T) {
+	m, _ := newTestManager(t, "linux", false)
+	for _, def := range []*Definition{
+		{Name: "", Binary: "/usr/local/bin/mcp-memory"},
+		{Name: "mcp memory", Binary: "/usr/local/bin/mcp-memory"},
+		{Name: "../mcp-memory", Binary: "/usr/local/bin/mcp-memory"},
+		{Name: "mcp-memory", Binary: "mcp-memory"},
+	} {
+		if _, err := m.Render(def); err == nil {
+			t.Errorf("expected an error for %+v", def)
+		}
+	}
+}
+
+func TestRenderWindows(t *testing.T) {
+	def := testDefinition()
+	def.Binary = `C:\Program Files\mcp-memory\mcp-memory.exe`
+	got := string(renderWindows(def))
+	for _, want := range []string{
+		"Service:     mcp-memory (automatic start, LocalSystem)",
+		`Command:     "C:\Program Files\mcp-memory\mcp-memory.exe" serve -t http --port 8765`,
+		"Environment: OPENAI_API_KEY=sk-test",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("renderWindows() does not contain %q:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package sysservice
+
+import (
+	"fmt"
+	"strings"
+)
+
+// unitName はnameのsystemdユニット名を返す
+func unitName(name string) string {
+	return name + ".service"
+}
+
+// renderSystemd はsystemdのユニットファイルを返す
+// ユーザーのユニットはログイン時（default.target）、システムのユニットは起動時（multi-user.target）に開始する
+func renderSystemd(def *Definition, system bool) []byte {
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	b.WriteString("Description=mcp-memory server\n")
+	b.WriteString("After=network-online.target\n")
+	b.WriteString("Wants=network-online.target\n")
+	b.WriteString("\n[Service]\n")
+	b.WriteString("Type=simple\n")
+	args := make([]string, 0, len(def.Args)+1)
+	for _, arg := range append([]string{def.Binary}, def.Args...) {
+		args = append(args, systemdQuote(arg))
+	}
+	fmt.Fprintf(&b, "ExecStart=%s\n", strings.Join(args, " "))
+	for _, pair := range def.sortedEnv() {
+		fmt.Fprintf(&b, "Environment=%s\n", systemdQuote(pair))
+	}
+	if system && def.User != "" {
+		fmt.Fprintf(&b, "User=%s\n", def.User)
+	}
+	b.WriteString("Restart=on-failure\n")
+	b.WriteString("RestartSec=5\n")
+	b.WriteString("\n[Install]\n")
+	if system {
+		b.WriteString("WantedBy=multi-user.target\n")
+	} else {
+		b.WriteString("WantedBy=default.target\n")
+	}
+	return []byte(b.String())
+}
+
+// systemdQuote はユニットファイルの値として引用符で囲む
+// %（指定子）と$（変数展開）はsystemdに解釈されないよう重ねる
+func systemdQuote(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%", "$", "$$", "\n", `\n`).Replace(s)
+	return `"` + s + `"`
+}
+
+// systemctl はユーザーのユニットなら --user を付けてsystemctlを実行する
+func (m *Manager) systemctl(args ...string) error {
+	if !m.system {
+		args = append([]string{"--user"}, args...)
+	}
+	return m.run("systemctl", args...)
+}
+
+// enableSystemd はユニットを読み込み直して有効にし、起動する（更新した場合は再起動する）
+func (m *Manager) enableSystemd(name string, restart bool) error {
+	if err := m.systemctl("daemon-reload"); err != nil {
+		return err
+	}
+	if err := m.systemctl("enable", unitName(name)); err != nil {
+		return err
+	}
+	if restart {
+		return m.systemctl("restart", unitName(name))
+	}
+	return m.systemctl("start", unitName(name))
+}
@@ -0,0 +1,54 @@
+package sysservice
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderSystemd(t *testing.T) {
+	def := &Definition{
+		Name:   "mcp-memory",
+		Binary: "/opt/mcp memory/bin/mcp-memory",
+		Args:   []string{"serve", "-t", "http", "--data-dir", "/home/alice/100%"},
+		Env:    map[string]string{"B": `say "hi"`, "A": "$HOME"},
+		User:   "alice",
+	}
+
+	user := string(renderSystemd(def, false))
+	for _, want := range []string{
+		`ExecStart="/opt/mcp memory/bin/mcp-memory" "serve" "-t" "http" "--data-dir" "/home/alice/100%%"`,
+		"Environment=\"A=$$HOME\"\nEnvironment=\"B=say \\\"hi\\\"\"\n",
+		"Restart=on-failure",
+		"WantedBy=default.target",
+	} {
+		if !strings.Contains(user, want) {
+			t.Errorf("user unit does not contain %q:\n%s", want, user)
+		}
+	}
+	if strings.Contains(user, "User=") {
+		t.Errorf("user unit must not set User=:\n%s", user)
+	}
+
+	system := string(renderSystemd(def, true))
+	for _, want := range []string{"User=alice", "WantedBy=multi-user.target"} {
+		if !strings.Contains(system, want) {
+			t.Errorf("system unit does not contain %q:\n%s", want, system)
+		}
+	}
+}
+
+func TestSystemdQuote(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain", `"plain"`},
+		{`C:\dir`, `"C:\\dir"`},
+		{"a\nb", `"a\nb"`},
+		{"%h/$USER", `"%%h/$$USER"`},
+	}
+	for _, tt := range tests {
+		if got := systemdQuote(tt.in); got != tt.want {
+			t.Errorf("systemdQuote(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+//go:build windows
+
+package sysservice
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// windowsStopTimeout は停止を待つ時間
+const windowsStopTimeout = 30 * time.Second
+
+// installWindows はdefを自動開始のWindowsサービスとして登録して起動する（管理者権限が必要）
+// 異常終了した場合は5秒後に再起動する
+func installWindows(def *Definition, force bool) (Result, error) {
+	if err := def.validate(); err != nil {
+		return "", err
+	}
+	m, err := connectWindows()
+	if err != nil {
+		return "", err
+	}
+	defer m.Disconnect()
+
+	binaryPath := syscall.EscapeArg(def.Binary)
+	for _, arg := range def.Args {
+		binaryPath += " " + syscall.EscapeArg(arg)
+	}
+	env := def.sortedEnv()
+
+	result := ResultCreated
+	s, err := m.OpenService(def.Name)
+	if err == nil {
+		defer s.Close()
+		config, err := s.Config()
+		if err != nil {
+			return "", fmt.Errorf("failed to read service config: %w", err)
+		}
+		current, _ := readWindowsEnv(def.Name)
+		switch {
+		case config.BinaryPathName == binaryPath && config.StartType == mgr.StartAutomatic && slices.Equal(current, env):
+			result = ResultUnchanged
+		case !force:
+			return "", fmt.Errorf("%w: %s (use --force to replace it)", ErrExists, def.Name)
+		default:
+			config.BinaryPathName = binaryPath
+			config.StartType = mgr.StartAutomatic
+			if err := s.UpdateConfig(config); err != nil {
+				return "", fmt.Errorf("failed to update service: %w", err)
+			}
+			result = ResultUpdated
+		}
+	} else {
+		s, err = m.CreateService(def.Name, def.Binary, mgr.Config{
+			DisplayName: "mcp-memory server",
+			Description: "Local memory server for MCP clients",
+			StartType:   mgr.StartAutomatic,
+		}, def.Args...)
+		if err != nil {
+			return "", fmt.Errorf("failed to create service: %w", err)
+		}
+		defer s.Close()
+	}
+
+	if result != ResultUnchanged {
+		if err := writeWindowsEnv(def.Name, env); err != nil {
+			return "", err
+		}
+		if err := s.SetRecoveryActions([]mgr.RecoveryAction{{Type: mgr.ServiceRestart, Delay: 5 * time.Second}}, 86400); err != nil {
+			return "", fmt.Errorf("failed to set recovery actions: %w", err)
+		}
+	}
+	if result == ResultUpdated {
+		if err := stopService(s); err != nil {
+			return "", err
+		}
+	}
+	return result, startService(s)
+}
+
+// uninstallWindows はサービスを停止して削除する
+func uninstallWindows(name string) error {
+	m, s, err := openWindowsService(name)
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+	defer s.Close()
+	// 停止に失敗しても削除する（削除はサービスの終了後に反映される）
+	stopService(s)
+	if err := s.Delete(); err != nil {
+		return fmt.Errorf("failed to delete service: %w", err)
+	}
+	return nil
+}
+
+// startWindows はサービスを開始する
+func startWindows(name string) error {
+	m, s, err := openWindowsService(name)
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+	defer s.Close()
+	return startService(s)
+}
+
+// stopWindows はサービスを停止する
+func stopWindows(name string) error {
+	m, s, err := openWindowsService(name)
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+	defer s.Close()
+	return stopService(s)
+}
+
+// connectWindows はサービスコントロールマネージャーに接続する
+func connectWindows() (*mgr.Mgr, error) {
+	m, err := mgr.Connect()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the service manager (run as administrator): %w", err)
+	}
+	return m, nil
+}
+
+// openWindowsService はnameのサービスを開く（登録されていなければErrNotInstalled）
+func openWindowsService(name string) (*mgr.Mgr, *mgr.Service, error) {
+	m, err := connectWindows()
+	if err != nil {
+		return nil, nil, err
+	}
+	s, err := m.OpenService(name)
+	if errors.Is(err, windows.ERROR_SERVICE_DOES_NOT_EXIST) {
+		m.Disconnect()
+		return nil, nil, fmt.Errorf("%w: %s", ErrNotInstalled, name)
+	}
+	if err != nil {
+		m.Disconnect()
+		return nil, nil, fmt.Errorf("failed to open service: %w", err)
+	}
+	return m, s, nil
+}
+
+// startService はサービスを開始する（実行中なら何もしない）
+func startService(s *mgr.Service) error {
+	status, err := s.Query()
+	if err != nil {
+		return fmt.Errorf("failed to query service: %w", err)
+	}
+	if status.State == svc.Running || status.State == svc.StartPending {
+		return nil
+	}
+	if err := s.Start(); err != nil {
+		return fmt.Errorf("failed to start service: %w", err)
+	}
+	return nil
+}
+
+// stopService はサービスに停止を要求し、停止するまで待つ（停止済みなら何もしない）
+func stopService(s *mgr.Service) error {
+	status, err := s.Query()
+	if err != nil {
+		return fmt.Errorf("failed to query service: %w", err)
+	}
+	if status.State == svc.Stopped {
+		return nil
+	}
+	if status, err = s.Control(svc.Stop); err != nil {
+		return fmt.Errorf("failed to stop service: %w", err)
+	}
+	deadline := time.Now().Add(windowsStopTimeout)
+	for status.State != svc.Stopped {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("service did not stop within %s", windowsStopTimeout)
+		}
+		time.Sleep(300 * time.Millisecond)
+		if status, err = s.Query(); err != nil {
+			return fmt.Errorf("failed to query service: %w", err)
+		}
+	}
+	return nil
+}
+
+// windowsServiceKey はサービスの設定を持つレジストリキー
+func windowsServiceKey(name string) string {
+	return `SYSTEM\CurrentControlSet\Services\` + name
+}
+
+// readWindowsEnv はサービスのEnvironment値（KEY=VALUE）を読む
+func readWindowsEnv(name string) ([]string, error) {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, windowsServiceKey(name), registry.QUERY_VALUE)
+	if err != nil {
+		return nil, err
+	}
+	defer k.Close()
+	env, _, err := k.GetStringsValue("Environment")
+	if errors.Is(err, registry.ErrNotExist) {
+		return []string{}, nil
+	}
+	return env, err
+}
+
+// writeWindowsEnv はサービスのEnvironment値を書き換える（空なら削除する）
+func writeWindowsEnv(name string, env []string) error {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, windowsServiceKey(name), registry.SET_VALUE)
+	if err != nil {
+		return fmt.Errorf("failed to open service registry key: %w", err)
+	}
+	defer k.Close()
+	if len(env) == 0 {
+		if err := k.DeleteValue("Environment"); err != nil && !errors.Is(err, registry.ErrNotExist) {
+			return fmt.Errorf("failed to clear service environment: %w", err)
+		}
+		return nil
+	}
+	if err := k.SetStringsValue("Environment", env); err != nil {
+		return fmt.Errorf("failed to set service environment: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+//go:build !windows
+
+package sysservice
+
+// Windowsサービスの操作はWindows以外では使わない（NewがKindWindowsを返すのはWindowsのみ）
+
+func installWindows(def *Definition, force bool) (Result, error) {
+	return "", ErrUnsupported
+}
+
+func uninstallWindows(name string) error {
+	return ErrUnsupported
+}
+
+func startWindows(name string) error {
+	return ErrUnsupported
+}
+
+func stopWindows(name string) error {
+	return ErrUnsupported
+}
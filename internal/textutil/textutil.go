@@ -0,0 +1,26 @@
+// Package textutil はCLIの表示などで使う文字列の加工を提供する
+package textutil
+
+import "github.com/rivo/uniseg"
+
+// Ellipsis はTruncateが切り詰めた文字列の末尾に付ける印
+const Ellipsis = " ..."
+
+// Truncate はtextを先頭からmaxLen文字（書記素クラスタ単位）までに切り詰め、切り詰めた場合はEllipsisを付ける
+// バイト単位で切ると日本語などのマルチバイト文字や結合文字・絵文字の途中で切れて文字化けするため、
+// 見た目の1文字を分けずに数える
+func Truncate(text string, maxLen int) string {
+	if len(text) <= maxLen {
+		// バイト数がmaxLen以下なら文字数もmaxLen以下
+		return text
+	}
+	end := 0
+	g := uniseg.NewGraphemes(text)
+	for n := 0; n < maxLen && g.Next(); n++ {
+		_, end = g.Positions()
+	}
+	if end == len(text) {
+		return text
+	}
+	return text[:end] + Ellipsis
+}
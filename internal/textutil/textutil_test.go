@@ -0,0 +1,32 @@
+package textutil
+
+import "testing"
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		input  string
+		maxLen int
+		want   string
+	}{
+		{"short text", 100, "short text"},
+		{"this is a very long text that should be truncated", 20, "this is a very long  ..."},
+		{"exactly twenty chars", 20, "exactly twenty chars"},
+		{"", 10, ""},
+		// マルチバイト文字は文字数で数え、途中で切らない
+		{"日本語のテキストを切り詰める", 5, "日本語のテ ..."},
+		{"日本語", 3, "日本語"},
+		{"日本語", 2, "日本 ..."},
+		// 結合文字・ZWJで結合した絵文字は1文字として扱う
+		{"か\u3099きく", 1, "か\u3099 ..."},
+		{"👨‍👩‍👧家族", 1, "👨‍👩‍👧 ..."},
+		{"abc", 0, " ..."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := Truncate(tt.input, tt.maxLen); got != tt.want {
+				t.Errorf("Truncate(%q, %d) = %q, want %q", tt.input, tt.maxLen, got, tt.want)
+			}
+		})
+	}
+}
@@ -67,8 +67,8 @@ func TestCORS_Enabled(t *testing.T) {
 	if w.Header().Get("Access-Control-Allow-Methods") != "POST, OPTIONS" {
 		t.Errorf("expected methods POST, OPTIONS, got %q", w.Header().Get("Access-Control-Allow-Methods"))
 	}
-	if w.Header().Get("Access-Control-Allow-Headers") != "Content-Type" {
-		t.Errorf("expected headers Content-Type, got %q", w.Header().Get("Access-Control-Allow-Headers"))
+	if w.Header().Get("Access-Control-Allow-Headers") != "Content-Type, Authorization, X-Mcp-Client, X-Mcp-Project-Id, X-Mcp-Group-Id" {
+		t.Errorf("expected headers Content-Type, Authorization and session headers, got %q", w.Header().Get("Access-Control-Allow-Headers"))
 	}
 }
 
@@ -100,8 +100,8 @@ func TestCORS_Preflight(t *testing.T) {
 	if w.Header().Get("Access-Control-Allow-Methods") != "POST, OPTIONS" {
 		t.Errorf("expected methods POST, OPTIONS, got %q", w.Header().Get("Access-Control-Allow-Methods"))
 	}
-	if w.Header().Get("Access-Control-Allow-Headers") != "Content-Type" {
-		t.Errorf("expected headers Content-Type, got %q", w.Header().Get("Access-Control-Allow-Headers"))
+	if w.Header().Get("Access-Control-Allow-Headers") != "Content-Type, Authorization, X-Mcp-Client, X-Mcp-Project-Id, X-Mcp-Group-Id" {
+		t.Errorf("expected headers Content-Type, Authorization and session headers, got %q", w.Header().Get("Access-Control-Allow-Headers"))
 	}
 
 	// レスポンスボディは空であること
@@ -207,3 +207,33 @@ func TestCORS_VaryHeader(t *testing.T) {
 		t.Errorf("expected Vary: Origin, got %q", w.Header().Get("Vary"))
 	}
 }
+
+// TestCORS_SetCORSOrigins は実行中に許可オリジンを差し替えられることをテスト
+func TestCORS_SetCORSOrigins(t *testing.T) {
+	handler := newMockHandler()
+	handler.SetResponse("memory.get_config", map[string]any{"ok": true})
+
+	server := New(handler, Config{Addr: "127.0.0.1:0"})
+
+	request := func() string {
+		reqBody := `{"jsonrpc":"2.0","id":1,"method":"memory.get_config"}`
+		req := httptest.NewRequest("POST", "/rpc", strings.NewReader(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Origin", "http://example.com")
+		w := httptest.NewRecorder()
+		server.handleRPC(w, req)
+		return w.Header().Get("Access-Control-Allow-Origin")
+	}
+
+	if got := request(); got != "" {
+		t.Errorf("expected no CORS header, got %q", got)
+	}
+	server.SetCORSOrigins([]string{"http://example.com"})
+	if got := request(); got != "http://example.com" {
+		t.Errorf("expected CORS origin http://example.com, got %q", got)
+	}
+	server.SetCORSOrigins(nil)
+	if got := request(); got != "" {
+		t.Errorf("expected no CORS header after disabling, got %q", got)
+	}
+}
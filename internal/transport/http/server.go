@@ -3,15 +3,33 @@ package http
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/auth"
+	"github.com/brbranch/embedding_mcp/internal/session"
 )
 
 // MaxBodySize はリクエストボディの最大サイズ（1MB、stdioと統一）
 const MaxBodySize = 1024 * 1024
 
+// MaxImportBodySize は /import のリクエストボディの最大サイズ（埋め込みを含むエクスポートを受け取るため大きくする）
+const MaxImportBodySize = 512 * 1024 * 1024
+
+// セッション情報を指定するHTTPヘッダー（HTTPはステートレスのためリクエストごとに指定）
+const (
+	HeaderClient    = "X-Mcp-Client"     // クライアント識別子
+	HeaderProjectID = "X-Mcp-Project-Id" // projectId省略時のデフォルト値
+	HeaderGroupID   = "X-Mcp-Group-Id"   // groupId省略時のデフォルト値
+)
+
 // DefaultAddr はデフォルトのlistenアドレス
 const DefaultAddr = "127.0.0.1:8765"
 
@@ -20,10 +38,53 @@ type Handler interface {
 	Handle(ctx context.Context, requestBytes []byte) []byte
 }
 
+// StreamHandler はレスポンスをwに直接書き出せるHandler（実装していれば /rpc に使う）
+// 件数の多い結果をまとめて組み立てずに、書き出しながらチャンクで送る
+type StreamHandler interface {
+	HandleTo(ctx context.Context, requestBytes []byte, w io.Writer) error
+}
+
+// Exporter はプロジェクトのエクスポートを提供する（実装していれば /export でJSONLを送る）
+type Exporter interface {
+	Export(ctx context.Context, w io.Writer, projectID string, includeEmbeddings bool) error
+}
+
+// Importer はエクスポート形式のJSONLの取り込みを提供する（実装していれば /import で受け取る）
+// modeはimportコマンドと同じ（"skip-existing" | "overwrite"、空なら衝突があればエラー）。resultは結果のJSON
+type Importer interface {
+	Import(ctx context.Context, r io.Reader, projectID, mode string) (result []byte, err error)
+}
+
+// SchemaProvider はメソッドのJSON Schemaを提供する（実装していれば /schema を公開）
+type SchemaProvider interface {
+	Describe() []byte
+}
+
+// Subscriber は変更通知を提供する（実装していれば /events をServer-Sent Eventsで公開）
+// チャネルには通知（JSON-RPC notification）のJSONが届き、解除関数を呼ぶと閉じられる
+type Subscriber interface {
+	Subscribe(projectID string) (<-chan []byte, func(), error)
+}
+
+// HealthChecker はサーバーの状態を提供する（実装していれば /health を公開）
+// bodyはJSON。okがfalseなら503 Service Unavailableで返す
+type HealthChecker interface {
+	Health(ctx context.Context) (body []byte, ok bool)
+}
+
 // Config はHTTPサーバー設定
 type Config struct {
 	Addr        string   // listen address (例: "127.0.0.1:8765")
 	CORSOrigins []string // 許可するオリジンリスト、空ならCORS無効
+	// ShutdownDelay は停止要求から新しい接続の受け付けをやめるまでの時間
+	// この間は /readyz が503を返し、ロードバランサーが振り分けをやめるのを待つ（0なら待たない）
+	ShutdownDelay time.Duration
+	// ShutdownTimeout は処理中のリクエストの完了を待つ上限（超えたら切断する。0なら無制限）
+	ShutdownTimeout time.Duration
+	// Auth はAuthorization: Bearerのトークンを検証する（nilなら認証しない）
+	// 認証したトークンのScopeはcontextに格納し、/rpc はHandlerがメソッドごとに、
+	// /events・/export・/import はprojectIdで制限する。/healthz・/readyz・/health は認証しない
+	Auth *auth.Authenticator
 }
 
 // Server はHTTP JSON-RPCサーバー
@@ -31,6 +92,17 @@ type Server struct {
 	handler Handler
 	config  Config
 	srv     *http.Server
+
+	corsMu      sync.RWMutex
+	corsOrigins []string // SetCORSOriginsで実行中に変更可能
+
+	// auth はSetAuthenticatorで実行中に変更可能（nilなら認証しない）
+	auth atomic.Pointer[auth.Authenticator]
+
+	// shutdown はShutdown開始時に閉じられる（/eventsの接続を終わらせる）
+	shutdown chan struct{}
+	// draining は停止要求を受けた後trueになる（/readyzが503を返す）
+	draining atomic.Bool
 }
 
 // New は新しいServerを生成
@@ -42,36 +114,85 @@ func New(handler Handler, config Config) *Server {
 	}
 
 	s := &Server{
-		handler: handler,
-		config:  config,
+		handler:     handler,
+		config:      config,
+		corsOrigins: config.CORSOrigins,
+		shutdown:    make(chan struct{}),
 	}
+	s.auth.Store(config.Auth)
 
 	mux := http.NewServeMux()
-	mux.HandleFunc("/rpc", s.handleRPC)
+	mux.HandleFunc("/rpc", s.requireAuth(s.handleRPC))
+	mux.HandleFunc("/healthz", s.handleHealthz)
+	mux.HandleFunc("/readyz", s.handleReadyz)
+	if _, ok := handler.(SchemaProvider); ok {
+		mux.HandleFunc("/schema", s.requireAuth(s.handleSchema))
+	}
+	if _, ok := handler.(Subscriber); ok {
+		mux.HandleFunc("/events", s.requireAuth(s.handleEvents))
+	}
+	if _, ok := handler.(HealthChecker); ok {
+		mux.HandleFunc("/health", s.handleHealth)
+	}
+	if _, ok := handler.(Exporter); ok {
+		mux.HandleFunc("/export", s.requireAuth(s.handleExport))
+	}
+	if _, ok := handler.(Importer); ok {
+		mux.HandleFunc("/import", s.requireAuth(s.handleImport))
+	}
 
 	s.srv = &http.Server{
 		Addr:              addr,
 		Handler:           mux,
 		ReadHeaderTimeout: 10 * time.Second, // DoS対策
 	}
+	// Shutdownは処理中の接続の終了を待つため、続いている/eventsの接続を先に終わらせる
+	s.srv.RegisterOnShutdown(func() { close(s.shutdown) })
 
 	return s
 }
 
 // Run はサーバーを起動し、contextがキャンセルされるまで実行
+// キャンセル後は処理中のリクエストが終わるまで（ShutdownTimeoutまで）待ってから戻る
 func (s *Server) Run(ctx context.Context) error {
-	// contextキャンセル時にShutdownを呼ぶ
+	errCh := make(chan error, 1)
 	go func() {
-		<-ctx.Done()
-		s.srv.Shutdown(context.Background())
+		errCh <- s.srv.ListenAndServe()
 	}()
 
-	err := s.srv.ListenAndServe()
-	if err == http.ErrServerClosed {
-		// Graceful shutdownはエラーではない
-		return nil
+	select {
+	case err := <-errCh:
+		if err == http.ErrServerClosed {
+			// Graceful shutdownはエラーではない
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+	}
+	s.drain()
+	return nil
+}
+
+// drain は停止要求を受けたサーバーを止める
+// ShutdownDelayの間は /readyz で503を返しつつリクエストを受け付け、
+// その後は新しい接続を受け付けずに処理中のリクエストの完了を待つ
+func (s *Server) drain() {
+	s.draining.Store(true)
+	if s.config.ShutdownDelay > 0 {
+		slog.Info("http: draining before shutdown", "delay", s.config.ShutdownDelay)
+		time.Sleep(s.config.ShutdownDelay)
+	}
+
+	ctx := context.Background()
+	if s.config.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.config.ShutdownTimeout)
+		defer cancel()
+	}
+	if err := s.srv.Shutdown(ctx); err != nil {
+		slog.Warn("http: aborted requests still running at the shutdown timeout", "timeout", s.config.ShutdownTimeout, "error", err)
+		s.srv.Close()
 	}
-	return err
 }
 
 // handleRPC はJSON-RPCリクエストを処理
@@ -110,19 +231,361 @@ func (s *Server) handleRPC(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// JSON-RPC処理
-	respBytes := s.handler.Handle(r.Context(), body)
-
+	// JSON-RPC処理（ヘッダーからセッションを生成）
+	ctx := session.NewContext(r.Context(), session.New(session.Info{
+		ClientName:       r.Header.Get(HeaderClient),
+		DefaultProjectID: r.Header.Get(HeaderProjectID),
+		DefaultGroupID:   r.Header.Get(HeaderGroupID),
+	}))
 	// レスポンス送信
 	w.Header().Set("Content-Type", "application/json")
+	if sh, ok := s.handler.(StreamHandler); ok {
+		// 書き出しが始まった後はステータスを変えられないため、エラーは接続の切断として扱う
+		w.WriteHeader(http.StatusOK)
+		if err := sh.HandleTo(ctx, body, w); err != nil {
+			slog.Debug("http: failed to write response", "error", err)
+		}
+		return
+	}
+	respBytes := s.handler.Handle(ctx, body)
 	w.WriteHeader(http.StatusOK)
 	w.Write(respBytes)
 }
 
+// handleHealth はサーバーの状態を返す（監視用のためCORSは扱わない）
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	checker, ok := s.handler.(HealthChecker)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	body, healthy := checker.Health(r.Context())
+	w.Header().Set("Content-Type", "application/json")
+	if healthy {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	w.Write(body)
+}
+
+// handleHealthz はプロセスが応答できることを返す（livenessプローブ用。storeには問い合わせない）
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"status":"ok"}`))
+}
+
+// handleReadyz はリクエストを受け付けられるかを返す（readinessプローブ用）
+// 停止要求の後は503、それ以外はHealthCheckerの結果（未実装なら常に200）
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, ready := []byte(`{"status":"ok"}`), true
+	if s.draining.Load() {
+		body, ready = []byte(`{"status":"draining"}`), false
+	} else if checker, ok := s.handler.(HealthChecker); ok {
+		body, ready = checker.Health(r.Context())
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if ready {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	w.Write(body)
+}
+
+// handleSchema は全メソッドのJSON Schemaを返す
+func (s *Server) handleSchema(w http.ResponseWriter, r *http.Request) {
+	// CORS処理
+	s.handleCORS(w, r)
+
+	// Preflightリクエスト
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// GETのみ許可
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	provider, ok := s.handler.(SchemaProvider)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(provider.Describe())
+}
+
+// handleEvents はprojectIdの変更通知をServer-Sent Eventsで送り続ける
+// projectIdはクエリ（?projectId=）またはX-Mcp-Project-Idヘッダーで指定する
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	// CORS処理
+	s.handleCORS(w, r)
+
+	// Preflightリクエスト
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// GETのみ許可
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	subscriber, ok := s.handler.(Subscriber)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	projectID := r.URL.Query().Get("projectId")
+	if projectID == "" {
+		projectID = r.Header.Get(HeaderProjectID)
+	}
+	if projectID == "" {
+		http.Error(w, "projectId is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := auth.FromContext(r.Context()).Check(projectID, false); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	ch, cancel, err := subscriber.Subscribe(projectID)
+	if err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.shutdown:
+			return
+		case message, ok := <-ch:
+			if !ok {
+				return
+			}
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", message); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// handleExport はprojectIdのグループ・グローバル設定・ノートをJSONL（exportコマンドと同じ形式）で返す
+// projectIdはクエリ（?projectId=）またはX-Mcp-Project-Idヘッダーで指定し、?embeddings=trueで埋め込みも含める
+// 1件ずつ書き出してチャンクで送るため、件数が多くても応答全体をメモリに持たない
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	// CORS処理
+	s.handleCORS(w, r)
+
+	// Preflightリクエスト
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// GETのみ許可
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	exporter, ok := s.handler.(Exporter)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	projectID := r.URL.Query().Get("projectId")
+	if projectID == "" {
+		projectID = r.Header.Get(HeaderProjectID)
+	}
+	if projectID == "" {
+		http.Error(w, "projectId is required", http.StatusBadRequest)
+		return
+	}
+	if err := auth.FromContext(r.Context()).Check(projectID, false); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	includeEmbeddings := r.URL.Query().Get("embeddings") == "true"
+
+	out := &exportWriter{w: w}
+	if err := exporter.Export(r.Context(), out, projectID, includeEmbeddings); err != nil {
+		if !out.started {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// 書き出しの途中で失敗した場合は、途切れた応答で終わる（最後の行が不完全になる）
+		slog.Warn("http: export failed", "projectId", projectID, "error", err)
+	}
+}
+
+// handleImport はリクエストボディのJSONL（/export と同じ形式）をprojectIdに取り込み、結果をJSONで返す
+// projectIdは /export と同じく指定し、?mode=skip-existing|overwrite で既存データとの衝突時の動作を選ぶ
+// 取り込みに失敗した場合は422で理由を返す（ストアがトランザクションに対応していなければ途中まで取り込まれる）
+func (s *Server) handleImport(w http.ResponseWriter, r *http.Request) {
+	// CORS処理
+	s.handleCORS(w, r)
+
+	// Preflightリクエスト
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// POSTのみ許可
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	importer, ok := s.handler.(Importer)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	projectID := r.URL.Query().Get("projectId")
+	if projectID == "" {
+		projectID = r.Header.Get(HeaderProjectID)
+	}
+	if projectID == "" {
+		http.Error(w, "projectId is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := auth.FromContext(r.Context()).Check(projectID, true); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	// 取り込みのエラーからは読み込みのエラーを判別できないため、読み込みのエラーを覚えておく
+	body := &readErrRecorder{r: http.MaxBytesReader(w, r.Body, MaxImportBodySize)}
+	result, err := importer.Import(r.Context(), body, projectID, r.URL.Query().Get("mode"))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(body.err, &tooLarge) {
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		slog.Warn("http: import failed", "projectId", projectID, "error", err)
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(result)
+}
+
+// readErrRecorder はEOF以外の読み込みのエラーを記録するReader
+type readErrRecorder struct {
+	r   io.Reader
+	err error
+}
+
+func (e *readErrRecorder) Read(p []byte) (int, error) {
+	n, err := e.r.Read(p)
+	if err != nil && err != io.EOF {
+		e.err = err
+	}
+	return n, err
+}
+
+// exportWriter は最初の書き込みでヘッダーを送るWriter（書き出す前のエラーはステータスで返すため）
+type exportWriter struct {
+	w       http.ResponseWriter
+	started bool
+}
+
+func (e *exportWriter) Write(p []byte) (int, error) {
+	if !e.started {
+		e.started = true
+		e.w.Header().Set("Content-Type", "application/x-ndjson")
+		e.w.WriteHeader(http.StatusOK)
+	}
+	return e.w.Write(p)
+}
+
+// SetAuthenticator はトークンの検証を差し替える（設定のホットリロード用、nilなら認証しない）
+func (s *Server) SetAuthenticator(a *auth.Authenticator) {
+	s.auth.Store(a)
+}
+
+// requireAuth はAuthが設定されていればAuthorization: Bearerのトークンを検証し、Scopeをcontextに格納してnextを呼ぶ
+// トークンがない・一致しない場合は401を返す（CORSのPreflightは検証しない）
+func (s *Server) requireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		a := s.auth.Load()
+		if a == nil || r.Method == "OPTIONS" {
+			next(w, r)
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		scope, valid := a.Authenticate(strings.TrimSpace(token))
+		if !ok || !valid {
+			s.handleCORS(w, r)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="mcp-memory"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r.WithContext(auth.NewContext(r.Context(), scope)))
+	}
+}
+
+// SetCORSOrigins は許可するオリジンを差し替える（設定のホットリロード用、空ならCORS無効）
+func (s *Server) SetCORSOrigins(origins []string) {
+	s.corsMu.Lock()
+	defer s.corsMu.Unlock()
+	s.corsOrigins = origins
+}
+
 // handleCORS はCORSヘッダーを設定
 func (s *Server) handleCORS(w http.ResponseWriter, r *http.Request) {
+	s.corsMu.RLock()
+	origins := s.corsOrigins
+	s.corsMu.RUnlock()
+
 	// CORS無効ならスキップ
-	if len(s.config.CORSOrigins) == 0 {
+	if len(origins) == 0 {
 		return
 	}
 
@@ -133,7 +596,7 @@ func (s *Server) handleCORS(w http.ResponseWriter, r *http.Request) {
 
 	// 許可オリジンをチェック
 	allowed := false
-	for _, allowedOrigin := range s.config.CORSOrigins {
+	for _, allowedOrigin := range origins {
 		if origin == allowedOrigin {
 			allowed = true
 			break
@@ -147,6 +610,6 @@ func (s *Server) handleCORS(w http.ResponseWriter, r *http.Request) {
 	// CORSヘッダーを設定
 	w.Header().Set("Access-Control-Allow-Origin", origin)
 	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, "+HeaderClient+", "+HeaderProjectID+", "+HeaderGroupID)
 	w.Header().Add("Vary", "Origin") // 既存のVaryヘッダーを保持
 }
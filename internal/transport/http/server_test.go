@@ -1,17 +1,23 @@
 package http
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/brbranch/embedding_mcp/internal/auth"
 	"github.com/brbranch/embedding_mcp/internal/model"
+	"github.com/brbranch/embedding_mcp/internal/session"
 )
 
 // mockHandler はテスト用のJSON-RPCハンドラー
@@ -332,3 +338,573 @@ func TestServer_ReadHeaderTimeout(t *testing.T) {
 		t.Error("expected ReadHeaderTimeout to be set")
 	}
 }
+
+// schemaHandler は /schema テスト用のSchemaProvider実装
+type schemaHandler struct {
+	*mockHandler
+}
+
+func (h *schemaHandler) Describe() []byte {
+	return []byte(`{"methods":[{"name":"memory.get","result":{"type":"object"}}]}`)
+}
+
+// TestServer_Schema はSchemaProvider実装時に /schema が公開されることをテスト
+func TestServer_Schema(t *testing.T) {
+	server := New(&schemaHandler{newMockHandler()}, Config{
+		Addr: "127.0.0.1:0",
+	})
+
+	req := httptest.NewRequest("GET", "/schema", nil)
+	w := httptest.NewRecorder()
+	server.srv.Handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected Content-Type application/json, got %q", ct)
+	}
+
+	var body map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if _, ok := body["methods"]; !ok {
+		t.Error("expected methods in response")
+	}
+
+	// GET以外は405
+	req = httptest.NewRequest("POST", "/schema", nil)
+	w = httptest.NewRecorder()
+	server.srv.Handler.ServeHTTP(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405, got %d", w.Code)
+	}
+}
+
+// TestServer_SchemaNotProvided はSchemaProvider未実装時に /schema が存在しないことをテスト
+func TestServer_SchemaNotProvided(t *testing.T) {
+	server := New(newMockHandler(), Config{
+		Addr: "127.0.0.1:0",
+	})
+
+	req := httptest.NewRequest("GET", "/schema", nil)
+	w := httptest.NewRecorder()
+	server.srv.Handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+}
+
+// healthHandler はHealthCheckerを実装したモック
+type healthHandler struct {
+	*mockHandler
+	ok bool
+}
+
+func (h *healthHandler) Health(ctx context.Context) ([]byte, bool) {
+	if !h.ok {
+		return []byte(`{"status":"error"}`), false
+	}
+	return []byte(`{"status":"warning","notes":6000}`), true
+}
+
+// TestServer_Health はHealthChecker実装時に /health が公開され、異常時は503になることをテスト
+func TestServer_Health(t *testing.T) {
+	handler := &healthHandler{mockHandler: newMockHandler(), ok: true}
+	server := New(handler, Config{Addr: "127.0.0.1:0"})
+
+	req := httptest.NewRequest("GET", "/health", nil)
+	w := httptest.NewRecorder()
+	server.srv.Handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected Content-Type application/json, got %q", ct)
+	}
+	if !strings.Contains(w.Body.String(), `"status":"warning"`) {
+		t.Errorf("unexpected body: %s", w.Body.String())
+	}
+
+	handler.ok = false
+	w = httptest.NewRecorder()
+	server.srv.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/health", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", w.Code)
+	}
+
+	// HealthChecker未実装なら存在しない
+	server = New(newMockHandler(), Config{Addr: "127.0.0.1:0"})
+	w = httptest.NewRecorder()
+	server.srv.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/health", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+}
+
+// TestServer_HealthzReadyz は /healthz・/readyz が常に公開され、停止要求の後は /readyz が503になることをテスト
+func TestServer_HealthzReadyz(t *testing.T) {
+	handler := &healthHandler{mockHandler: newMockHandler(), ok: true}
+	server := New(handler, Config{Addr: "127.0.0.1:0"})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.srv.Handler.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+	if w := get("/healthz"); w.Code != http.StatusOK || w.Body.String() != `{"status":"ok"}` {
+		t.Errorf("/healthz = %d %s", w.Code, w.Body.String())
+	}
+	if w := get("/readyz"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"status":"warning"`) {
+		t.Errorf("/readyz = %d %s", w.Code, w.Body.String())
+	}
+
+	// storeに問い合わせられなければreadyではないが、livenessは保つ
+	handler.ok = false
+	if w := get("/readyz"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected /readyz 503 when unhealthy, got %d", w.Code)
+	}
+	if w := get("/healthz"); w.Code != http.StatusOK {
+		t.Errorf("expected /healthz 200 when unhealthy, got %d", w.Code)
+	}
+
+	// HealthChecker未実装でも公開する
+	server = New(newMockHandler(), Config{Addr: "127.0.0.1:0"})
+	if w := get("/readyz"); w.Code != http.StatusOK {
+		t.Errorf("expected /readyz 200 without HealthChecker, got %d", w.Code)
+	}
+	server.draining.Store(true)
+	if w := get("/readyz"); w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), "draining") {
+		t.Errorf("expected /readyz 503 while draining, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+// blockingHandler はreleaseが閉じられるまで応答しないハンドラー
+type blockingHandler struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (h *blockingHandler) Handle(ctx context.Context, requestBytes []byte) []byte {
+	close(h.started)
+	<-h.release
+	return []byte(`{"jsonrpc":"2.0","id":1,"result":{}}`)
+}
+
+// freeAddr は空いているlistenアドレスを返す
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+// TestServer_ShutdownDrain は停止要求の後、ShutdownDelayの間は/readyzが503を返し、
+// 処理中のリクエストの完了を待ってからRunが戻ることをテスト
+func TestServer_ShutdownDrain(t *testing.T) {
+	addr := freeAddr(t)
+	handler := &blockingHandler{started: make(chan struct{}), release: make(chan struct{})}
+	server := New(handler, Config{Addr: addr, ShutdownDelay: 300 * time.Millisecond, ShutdownTimeout: 5 * time.Second})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Run(ctx)
+	}()
+	base := "http://" + addr
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		resp, err := http.Get(base + "/healthz")
+		if err == nil {
+			resp.Body.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("server did not start: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	rpcDone := make(chan int, 1)
+	go func() {
+		resp, err := http.Post(base+"/rpc", "application/json", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"memory.list_recent"}`))
+		if err != nil {
+			rpcDone <- 0
+			return
+		}
+		resp.Body.Close()
+		rpcDone <- resp.StatusCode
+	}()
+	<-handler.started
+	cancel()
+
+	// ShutdownDelayの間は接続を受け付け、/readyzで503を返す
+	var code int
+	for i := 0; i < 20; i++ {
+		resp, err := http.Get(base + "/readyz")
+		if err != nil {
+			t.Fatalf("expected /readyz to be served while draining: %v", err)
+		}
+		resp.Body.Close()
+		if code = resp.StatusCode; code == http.StatusServiceUnavailable {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if code != http.StatusServiceUnavailable {
+		t.Errorf("expected /readyz 503 while draining, got %d", code)
+	}
+
+	// 処理中のリクエストが終わるまでRunは戻らない
+	time.Sleep(400 * time.Millisecond)
+	select {
+	case err := <-errCh:
+		t.Fatalf("Run returned before the in-flight request finished: %v", err)
+	default:
+	}
+	close(handler.release)
+	if code := <-rpcDone; code != http.StatusOK {
+		t.Errorf("expected the in-flight request to complete with 200, got %d", code)
+	}
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Errorf("Run() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for server to stop")
+	}
+}
+
+// sessionCaptureHandler はcontextのセッション情報を記録するハンドラー
+type sessionCaptureHandler struct {
+	info session.Info
+}
+
+func (h *sessionCaptureHandler) Handle(ctx context.Context, requestBytes []byte) []byte {
+	h.info = session.InfoFromContext(ctx)
+	return []byte(`{"jsonrpc":"2.0","id":1,"result":{}}`)
+}
+
+// TestServer_SessionHeaders はセッションヘッダーがcontextに反映されることをテスト
+func TestServer_SessionHeaders(t *testing.T) {
+	handler := &sessionCaptureHandler{}
+	server := New(handler, Config{
+		Addr: "127.0.0.1:0",
+	})
+
+	reqBody := `{"jsonrpc":"2.0","id":1,"method":"memory.search"}`
+	req := httptest.NewRequest("POST", "/rpc", strings.NewReader(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderClient, "dashboard")
+	req.Header.Set(HeaderProjectID, "/my/project")
+	req.Header.Set(HeaderGroupID, "global")
+	w := httptest.NewRecorder()
+
+	server.handleRPC(w, req)
+
+	if handler.info.ClientName != "dashboard" {
+		t.Errorf("expected client dashboard, got %q", handler.info.ClientName)
+	}
+	if handler.info.DefaultProjectID != "/my/project" {
+		t.Errorf("expected projectId /my/project, got %q", handler.info.DefaultProjectID)
+	}
+	if handler.info.DefaultGroupID != "global" {
+		t.Errorf("expected groupId global, got %q", handler.info.DefaultGroupID)
+	}
+}
+
+// eventsHandler はSubscriberを実装するテスト用ハンドラー
+type eventsHandler struct {
+	*mockHandler
+	ch chan []byte
+}
+
+func (h *eventsHandler) Subscribe(projectID string) (<-chan []byte, func(), error) {
+	return h.ch, func() {}, nil
+}
+
+// TestServer_Events はSubscriber実装時に /events で通知が送られることをテスト
+func TestServer_Events(t *testing.T) {
+	handler := &eventsHandler{mockHandler: newMockHandler(), ch: make(chan []byte, 1)}
+	server := New(handler, Config{
+		Addr: "127.0.0.1:0",
+	})
+	ts := httptest.NewServer(server.srv.Handler)
+	defer ts.Close()
+
+	// projectIdは必須
+	resp, err := http.Get(ts.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get(ts.URL + "/events?projectId=/test/project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("expected Content-Type text/event-stream, got %q", ct)
+	}
+
+	handler.ch <- []byte(`{"jsonrpc":"2.0","method":"notifications/memory/changed"}`)
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != "data: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/memory/changed\"}\n" {
+		t.Errorf("unexpected event line: %q", line)
+	}
+}
+
+// streamHandler はStreamHandlerを実装したモック（2回に分けて書き、間でflushする）
+type streamHandler struct {
+	*mockHandler
+	flushed bool
+}
+
+func (h *streamHandler) HandleTo(ctx context.Context, requestBytes []byte, w io.Writer) error {
+	io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":{"items":[1,`)
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+		h.flushed = true
+	}
+	_, err := io.WriteString(w, `2]}}`)
+	return err
+}
+
+// TestServer_StreamHandler はStreamHandler実装時に /rpc の応答を書き出しながら送ることをテスト
+func TestServer_StreamHandler(t *testing.T) {
+	handler := &streamHandler{mockHandler: newMockHandler()}
+	server := New(handler, Config{Addr: "127.0.0.1:0"})
+
+	req := httptest.NewRequest("POST", "/rpc", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"memory.list_recent"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.srv.Handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected response: %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if w.Body.String() != `{"jsonrpc":"2.0","id":1,"result":{"items":[1,2]}}` {
+		t.Errorf("unexpected body: %s", w.Body.String())
+	}
+	if !handler.flushed {
+		t.Error("expected the response writer to be flushable")
+	}
+}
+
+// exportHandler はExporterを実装したモック
+type exportHandler struct {
+	*mockHandler
+	err error
+}
+
+func (h *exportHandler) Export(ctx context.Context, w io.Writer, projectID string, includeEmbeddings bool) error {
+	if h.err != nil {
+		return h.err
+	}
+	fmt.Fprintf(w, "{\"projectId\":%q,\"embeddings\":%v}\n", projectID, includeEmbeddings)
+	return nil
+}
+
+// importHandler はImporterを実装したモック
+type importHandler struct {
+	*mockHandler
+	err error
+}
+
+func (h *importHandler) Import(ctx context.Context, r io.Reader, projectID, mode string) ([]byte, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if h.err != nil {
+		return nil, h.err
+	}
+	return []byte(fmt.Sprintf(`{"projectId":%q,"mode":%q,"bytes":%d}`, projectID, mode, len(data))), nil
+}
+
+// TestServer_Import はImporter実装時に /import がボディを取り込むことをテスト
+func TestServer_Import(t *testing.T) {
+	handler := &importHandler{mockHandler: newMockHandler()}
+	server := New(handler, Config{Addr: "127.0.0.1:0"})
+
+	w := httptest.NewRecorder()
+	server.srv.Handler.ServeHTTP(w, httptest.NewRequest("POST", "/import?projectId=/test/project&mode=overwrite", strings.NewReader("{}\n{}\n")))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("unexpected response: %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if w.Body.String() != `{"projectId":"/test/project","mode":"overwrite","bytes":6}` {
+		t.Errorf("unexpected body: %s", w.Body.String())
+	}
+
+	// 1MBを超えるボディも受け取る
+	w = httptest.NewRecorder()
+	server.srv.Handler.ServeHTTP(w, httptest.NewRequest("POST", "/import?projectId=/test/project", strings.NewReader(strings.Repeat("x", MaxBodySize+1))))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200 for a body over MaxBodySize, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	server.srv.Handler.ServeHTTP(w, httptest.NewRequest("POST", "/import", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 without projectId, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	server.srv.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/import?projectId=/test/project", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405, got %d", w.Code)
+	}
+
+	// 取り込みのエラーは422で返す
+	handler.err = errors.New("record already exists")
+	w = httptest.NewRecorder()
+	server.srv.Handler.ServeHTTP(w, httptest.NewRequest("POST", "/import?projectId=/test/project", strings.NewReader("{}")))
+	if w.Code != http.StatusUnprocessableEntity || !strings.Contains(w.Body.String(), "record already exists") {
+		t.Errorf("unexpected response: %d %s", w.Code, w.Body.String())
+	}
+
+	// Importer未実装なら存在しない
+	server = New(newMockHandler(), Config{Addr: "127.0.0.1:0"})
+	w = httptest.NewRecorder()
+	server.srv.Handler.ServeHTTP(w, httptest.NewRequest("POST", "/import?projectId=/test/project", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+}
+
+// TestServer_Export はExporter実装時に /export がJSONLを返すことをテスト
+func TestServer_Export(t *testing.T) {
+	handler := &exportHandler{mockHandler: newMockHandler()}
+	server := New(handler, Config{Addr: "127.0.0.1:0"})
+
+	w := httptest.NewRecorder()
+	server.srv.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/export?projectId=/test/project&embeddings=true", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("unexpected response: %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if w.Body.String() != "{\"projectId\":\"/test/project\",\"embeddings\":true}\n" {
+		t.Errorf("unexpected body: %s", w.Body.String())
+	}
+
+	// projectIdはヘッダーでも指定できる
+	req := httptest.NewRequest("GET", "/export", nil)
+	req.Header.Set(HeaderProjectID, "/test/header")
+	w = httptest.NewRecorder()
+	server.srv.Handler.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), `"/test/header","embeddings":false`) {
+		t.Errorf("unexpected body: %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	server.srv.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/export", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 without projectId, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	server.srv.Handler.ServeHTTP(w, httptest.NewRequest("POST", "/export?projectId=/test/project", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405, got %d", w.Code)
+	}
+
+	// 書き出す前のエラーはステータスで返す
+	handler.err = errors.New("export is not available")
+	w = httptest.NewRecorder()
+	server.srv.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/export?projectId=/test/project", nil))
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "export is not available") {
+		t.Errorf("unexpected response: %d %s", w.Code, w.Body.String())
+	}
+
+	// Exporter未実装なら存在しない
+	server = New(newMockHandler(), Config{Addr: "127.0.0.1:0"})
+	w = httptest.NewRecorder()
+	server.srv.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/export?projectId=/test/project", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+}
+
+// scopeCaptureHandler はcontextのScopeを記録し、Importerも実装するテスト用ハンドラー
+type scopeCaptureHandler struct {
+	*importHandler
+	scope *auth.Scope
+}
+
+func (h *scopeCaptureHandler) Handle(ctx context.Context, requestBytes []byte) []byte {
+	h.scope = auth.FromContext(ctx)
+	return []byte(`{"jsonrpc":"2.0","id":1,"result":{}}`)
+}
+
+// TestServer_Auth はAuth設定時にトークンを検証し、Scopeをcontextに格納することをテスト
+func TestServer_Auth(t *testing.T) {
+	apiKey := "admin-secret"
+	handler := &scopeCaptureHandler{importHandler: &importHandler{mockHandler: newMockHandler()}}
+	server := New(handler, Config{Addr: "127.0.0.1:0", CORSOrigins: []string{"http://localhost:3000"}, Auth: auth.New(&model.AuthConfig{
+		APIKey: &apiKey,
+		Tokens: []model.AccessTokenConfig{{Name: "team-a", Token: "a-secret", ProjectIDs: []string{"/test/project"}}},
+	})})
+
+	do := func(method, target, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"memory.search"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Origin", "http://localhost:3000")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		server.srv.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	// トークンがない・一致しない場合は401
+	for _, token := range []string{"", "wrong"} {
+		w := do("POST", "/rpc", token)
+		if w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("token %q: expected status 401 with WWW-Authenticate, got %d", token, w.Code)
+		}
+		if w.Header().Get("Access-Control-Allow-Origin") == "" {
+			t.Errorf("token %q: expected CORS headers on 401", token)
+		}
+	}
+	// Preflight・監視用のエンドポイントは認証しない
+	if w := do("OPTIONS", "/rpc", ""); w.Code != http.StatusOK {
+		t.Errorf("expected preflight status 200, got %d", w.Code)
+	}
+	if w := do("GET", "/healthz", ""); w.Code != http.StatusOK {
+		t.Errorf("expected /healthz status 200, got %d", w.Code)
+	}
+
+	if w := do("POST", "/rpc", "admin-secret"); w.Code != http.StatusOK || handler.scope != nil {
+		t.Errorf("expected the API key to have no scope, got %d %+v", w.Code, handler.scope)
+	}
+	if w := do("POST", "/rpc", "a-secret"); w.Code != http.StatusOK || handler.scope == nil || handler.scope.Name != "team-a" {
+		t.Errorf("expected the token's scope, got %d %+v", w.Code, handler.scope)
+	}
+
+	// /import はprojectIdと書き込みの権限で制限する（team-aは読み取り専用）
+	if w := do("POST", "/import?projectId=/test/project", "a-secret"); w.Code != http.StatusForbidden {
+		t.Errorf("expected status 403 for a read-only token, got %d", w.Code)
+	}
+	if w := do("POST", "/import?projectId=/test/project", "admin-secret"); w.Code != http.StatusOK {
+		t.Errorf("expected status 200 for the API key, got %d", w.Code)
+	}
+
+	// 実行中に無効にできる
+	server.SetAuthenticator(nil)
+	if w := do("POST", "/rpc", ""); w.Code != http.StatusOK {
+		t.Errorf("expected status 200 after disabling auth, got %d", w.Code)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !windows
+
+package pipe
+
+// listen はWindows以外では未対応
+func listen(config Config) (listener, error) {
+	return nil, ErrUnsupported
+}
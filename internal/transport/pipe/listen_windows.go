@@ -0,0 +1,128 @@
+//go:build windows
+
+package pipe
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// pipeBufferSize はパイプの入出力バッファサイズ
+const pipeBufferSize = 64 * 1024
+
+// pipeListener はnamed pipeのlistener
+type pipeListener struct {
+	name    string
+	namePtr *uint16
+	sa      *windows.SecurityAttributes
+
+	mu     sync.Mutex
+	first  bool
+	closed bool
+}
+
+// listen はnamed pipeのlistenerを生成
+func listen(config Config) (listener, error) {
+	namePtr, err := windows.UTF16PtrFromString(config.Name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pipe name: %w", err)
+	}
+
+	l := &pipeListener{
+		name:    config.Name,
+		namePtr: namePtr,
+		first:   true,
+	}
+
+	if config.SDDL != "" {
+		sd, err := windows.SecurityDescriptorFromString(config.SDDL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pipe SDDL: %w", err)
+		}
+		l.sa = &windows.SecurityAttributes{
+			SecurityDescriptor: sd,
+		}
+		l.sa.Length = uint32(unsafe.Sizeof(*l.sa))
+	}
+
+	return l, nil
+}
+
+// Accept はクライアント接続を待機する
+func (l *pipeListener) Accept() (io.ReadWriteCloser, error) {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil, os.ErrClosed
+	}
+	flags := uint32(windows.PIPE_ACCESS_DUPLEX)
+	if l.first {
+		// 同名パイプを他プロセスが先に作成していないことを保証
+		flags |= windows.FILE_FLAG_FIRST_PIPE_INSTANCE
+		l.first = false
+	}
+	l.mu.Unlock()
+
+	h, err := windows.CreateNamedPipe(
+		l.namePtr,
+		flags,
+		windows.PIPE_TYPE_BYTE|windows.PIPE_READMODE_BYTE|windows.PIPE_WAIT|windows.PIPE_REJECT_REMOTE_CLIENTS,
+		windows.PIPE_UNLIMITED_INSTANCES,
+		pipeBufferSize,
+		pipeBufferSize,
+		0,
+		l.sa,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pipe %s: %w", l.name, err)
+	}
+
+	if err := windows.ConnectNamedPipe(h, nil); err != nil && !errors.Is(err, windows.ERROR_PIPE_CONNECTED) {
+		windows.CloseHandle(h)
+		return nil, fmt.Errorf("failed to connect pipe %s: %w", l.name, err)
+	}
+
+	l.mu.Lock()
+	closed := l.closed
+	l.mu.Unlock()
+	if closed {
+		// Closeによる自己接続で解除された場合
+		windows.DisconnectNamedPipe(h)
+		windows.CloseHandle(h)
+		return nil, os.ErrClosed
+	}
+
+	return os.NewFile(uintptr(h), l.name), nil
+}
+
+// Close はlistenerを閉じ、待機中のAcceptを解除する
+func (l *pipeListener) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	l.mu.Unlock()
+
+	// ConnectNamedPipeはブロッキングのため、自身に接続して待機を解除する
+	h, err := windows.CreateFile(
+		l.namePtr,
+		windows.GENERIC_READ|windows.GENERIC_WRITE,
+		0,
+		nil,
+		windows.OPEN_EXISTING,
+		0,
+		0,
+	)
+	if err == nil {
+		windows.CloseHandle(h)
+	}
+	return nil
+}
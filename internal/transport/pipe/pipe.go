@@ -0,0 +1,110 @@
+// Package pipe implements Windows named pipe transport for mcp-memory.
+package pipe
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/brbranch/embedding_mcp/internal/transport/stdio"
+)
+
+// DefaultName はデフォルトのパイプ名
+const DefaultName = `\\.\pipe\mcp-memory`
+
+// ErrUnsupported はnamed pipeが利用できないプラットフォームで返されるエラー
+var ErrUnsupported = errors.New("named pipe transport is only supported on Windows")
+
+// Handler はJSON-RPCリクエストを処理する
+type Handler interface {
+	Handle(ctx context.Context, requestBytes []byte) []byte
+}
+
+// Config はnamed pipeサーバー設定
+type Config struct {
+	Name    string        // パイプ名（例: `\\.\pipe\mcp-memory`）、空ならDefaultName
+	SDDL    string        // パイプのACL（SDDL形式）、空ならOSのデフォルト
+	Framing stdio.Framing // メッセージ区切り方式、空ならauto
+}
+
+// Server はnamed pipe JSON-RPCサーバー
+// 接続ごとにstdioと同じプロトコル（NDJSON / Content-Length）で処理する
+type Server struct {
+	handler Handler
+	config  Config
+}
+
+// New は新しいServerを生成
+func New(handler Handler, config Config) *Server {
+	if config.Name == "" {
+		config.Name = DefaultName
+	}
+	if config.Framing == "" {
+		config.Framing = stdio.FramingAuto
+	}
+	return &Server{
+		handler: handler,
+		config:  config,
+	}
+}
+
+// listener はクライアント接続を受け付ける（プラットフォーム別に実装）
+type listener interface {
+	Accept() (io.ReadWriteCloser, error)
+	Close() error
+}
+
+// Run はサーバーを起動し、contextがキャンセルされるまで実行
+func (s *Server) Run(ctx context.Context) error {
+	l, err := listen(s.config)
+	if err != nil {
+		return err
+	}
+
+	// contextキャンセル時にlistenerを閉じてAcceptを解除
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				// Graceful shutdownはエラーではない
+				return nil
+			}
+			return err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.serveConn(ctx, conn)
+		}()
+	}
+}
+
+// serveConn は1接続分のリクエストを処理する
+func (s *Server) serveConn(ctx context.Context, conn io.ReadWriteCloser) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// contextキャンセル時に接続を閉じて読み取りを解除
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	server := stdio.New(s.handler,
+		stdio.WithReader(conn),
+		stdio.WithWriter(conn),
+		stdio.WithFraming(s.config.Framing),
+	)
+	// 接続単位のエラー（切断など）はサーバー全体を止めない
+	_ = server.Run(ctx)
+}
@@ -0,0 +1,86 @@
+package pipe
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// echoHandler はリクエストのidをそのまま返すハンドラー
+type echoHandler struct{}
+
+func (echoHandler) Handle(ctx context.Context, requestBytes []byte) []byte {
+	var req map[string]any
+	_ = json.Unmarshal(requestBytes, &req)
+	b, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": req["id"], "result": map[string]any{"ok": true}})
+	return b
+}
+
+// TestNew_Defaults はデフォルト値の設定をテスト
+func TestNew_Defaults(t *testing.T) {
+	s := New(echoHandler{}, Config{})
+	if s.config.Name != DefaultName {
+		t.Errorf("expected default name %s, got %s", DefaultName, s.config.Name)
+	}
+	if s.config.Framing != "auto" {
+		t.Errorf("expected default framing auto, got %s", s.config.Framing)
+	}
+}
+
+// TestServer_Run_Unsupported はWindows以外でErrUnsupportedを返すことをテスト
+func TestServer_Run_Unsupported(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("named pipe is supported on Windows")
+	}
+
+	err := New(echoHandler{}, Config{}).Run(context.Background())
+	if err != ErrUnsupported {
+		t.Errorf("expected ErrUnsupported, got %v", err)
+	}
+}
+
+// TestServer_ServeConn は1接続分のリクエスト処理をテスト
+func TestServer_ServeConn(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	s := New(echoHandler{}, Config{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.serveConn(ctx, server)
+		close(done)
+	}()
+
+	if _, err := client.Write([]byte(`{"jsonrpc":"2.0","id":7,"method":"memory.get_config"}` + "\n")); err != nil {
+		t.Fatalf("failed to write request: %v", err)
+	}
+
+	client.SetReadDeadline(time.Now().Add(time.Second))
+	line, err := bufio.NewReader(client).ReadString('\n')
+	if err != nil {
+		t.Fatalf("failed to read response: %v", err)
+	}
+
+	var resp map[string]any
+	if err := json.Unmarshal([]byte(line), &resp); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if resp["id"] != float64(7) {
+		t.Errorf("expected id 7, got %v", resp["id"])
+	}
+
+	// contextキャンセルで接続処理が終了すること
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("serveConn did not stop after cancel")
+	}
+}
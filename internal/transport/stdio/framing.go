@@ -0,0 +1,179 @@
+package stdio
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Framing はstdio上のメッセージ区切り方式
+type Framing string
+
+const (
+	// FramingAuto は最初のメッセージから区切り方式を自動判定する
+	FramingAuto Framing = "auto"
+	// FramingNewline は改行区切りJSON（NDJSON）
+	FramingNewline Framing = "newline"
+	// FramingContentLength はLSP形式の Content-Length ヘッダー区切り
+	FramingContentLength Framing = "content-length"
+)
+
+// contentLengthHeader はContent-Lengthヘッダー名
+const contentLengthHeader = "Content-Length"
+
+// ErrMessageTooLarge はメッセージがMaxBufferSizeを超えた場合のエラー
+var ErrMessageTooLarge = errors.New("message too large")
+
+// ParseFraming は文字列からFramingを生成（空文字はauto）
+func ParseFraming(s string) (Framing, error) {
+	switch Framing(s) {
+	case "", FramingAuto:
+		return FramingAuto, nil
+	case FramingNewline, FramingContentLength:
+		return Framing(s), nil
+	default:
+		return "", fmt.Errorf("invalid framing: %s (must be auto, newline or content-length)", s)
+	}
+}
+
+// messageReader は1メッセージずつ読み取る
+// 入力の終端ではio.EOFを返す
+type messageReader interface {
+	ReadMessage() ([]byte, error)
+}
+
+// detectFraming は先頭の1バイトから区切り方式を判定する
+// JSONは '{' か '[' で始まるため、それ以外はヘッダー付きとみなす
+func detectFraming(br *bufio.Reader) (Framing, error) {
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return "", err
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			// 先頭の空白・空行は読み飛ばす
+			br.Discard(1)
+			continue
+		case '{', '[':
+			return FramingNewline, nil
+		default:
+			return FramingContentLength, nil
+		}
+	}
+}
+
+// newMessageReader はFramingに応じたmessageReaderを生成
+func newMessageReader(br *bufio.Reader, framing Framing) messageReader {
+	if framing == FramingContentLength {
+		return &contentLengthReader{reader: br}
+	}
+
+	scanner := bufio.NewScanner(br)
+	// バッファサイズを1MBに拡張
+	buf := make([]byte, MaxBufferSize)
+	scanner.Buffer(buf, MaxBufferSize)
+	return &newlineReader{scanner: scanner}
+}
+
+// newlineReader は改行区切りのメッセージを読み取る
+type newlineReader struct {
+	scanner *bufio.Scanner
+}
+
+// ReadMessage は空行をスキップして1行読み取る
+func (r *newlineReader) ReadMessage() ([]byte, error) {
+	for r.scanner.Scan() {
+		line := r.scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		return []byte(line), nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// contentLengthReader は Content-Length ヘッダー区切りのメッセージを読み取る
+type contentLengthReader struct {
+	reader *bufio.Reader
+}
+
+// ReadMessage はヘッダーを読み取り、Content-Lengthバイトの本文を返す
+func (r *contentLengthReader) ReadMessage() ([]byte, error) {
+	length := -1
+	readHeader := false
+	for {
+		line, err := r.reader.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && !readHeader && strings.TrimSpace(line) == "" {
+				// メッセージ間での終端は正常終了
+				return nil, io.EOF
+			}
+			if err == io.EOF {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			if !readHeader {
+				// メッセージ間の空行は読み飛ばす
+				continue
+			}
+			break
+		}
+		readHeader = true
+
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid header line: %q", line)
+		}
+		// Content-Type等の他ヘッダーは無視
+		if !strings.EqualFold(strings.TrimSpace(name), contentLengthHeader) {
+			continue
+		}
+		length, err = strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || length < 0 {
+			return nil, fmt.Errorf("invalid Content-Length: %q", value)
+		}
+	}
+
+	if length < 0 {
+		return nil, fmt.Errorf("missing %s header", contentLengthHeader)
+	}
+	if length > MaxBufferSize {
+		return nil, ErrMessageTooLarge
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r.reader, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// writeMessage はFramingに応じてメッセージを書き込む
+func writeMessage(w io.Writer, framing Framing, message []byte) error {
+	if framing == FramingContentLength {
+		header := fmt.Sprintf("%s: %d\r\n\r\n", contentLengthHeader, len(message))
+		if _, err := io.WriteString(w, header); err != nil {
+			return err
+		}
+		_, err := w.Write(message)
+		return err
+	}
+
+	// レスポンスを書き込み（1行 + 改行）
+	if _, err := w.Write(message); err != nil {
+		return err
+	}
+	_, err := w.Write([]byte("\n"))
+	return err
+}
@@ -0,0 +1,143 @@
+package stdio
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+)
+
+// frame はContent-Lengthヘッダー付きのメッセージを生成
+func frame(body string) string {
+	return fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+// TestParseFraming は区切り方式のパースをテスト
+func TestParseFraming(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Framing
+		wantErr bool
+	}{
+		{"", FramingAuto, false},
+		{"auto", FramingAuto, false},
+		{"newline", FramingNewline, false},
+		{"content-length", FramingContentLength, false},
+		{"xml", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseFraming(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseFraming(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseFraming(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+// TestServer_Run_ContentLengthAutoDetect はContent-Length形式の自動判定をテスト
+func TestServer_Run_ContentLengthAutoDetect(t *testing.T) {
+	handler := newMockHandler()
+	handler.SetResponse("memory.get_config", map[string]any{"ok": true})
+
+	input := frame(`{"jsonrpc":"2.0","id":1,"method":"memory.get_config"}`) +
+		"Content-Type: application/vscode-jsonrpc; charset=utf-8\r\n" +
+		frame(`{"jsonrpc":"2.0","id":2,"method":"memory.get_config"}`)
+	var output bytes.Buffer
+
+	server := New(handler, WithReader(strings.NewReader(input)), WithWriter(&output), WithConcurrency(1))
+	if err := server.Run(context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	// レスポンスもContent-Length形式で返ること
+	reader := &contentLengthReader{reader: bufio.NewReader(&output)}
+	for _, wantID := range []float64{1, 2} {
+		body, err := reader.ReadMessage()
+		if err != nil {
+			t.Fatalf("failed to read framed response: %v", err)
+		}
+		var resp model.Response
+		if err := json.Unmarshal(body, &resp); err != nil {
+			t.Fatalf("failed to parse response: %v", err)
+		}
+		if resp.ID != wantID {
+			t.Errorf("expected id %v, got %v", wantID, resp.ID)
+		}
+	}
+	if _, err := reader.ReadMessage(); err != io.EOF {
+		t.Errorf("expected EOF after 2 responses, got %v", err)
+	}
+}
+
+// TestServer_Run_NewlineAutoDetect は改行区切りの自動判定をテスト
+func TestServer_Run_NewlineAutoDetect(t *testing.T) {
+	handler := newMockHandler()
+	handler.SetResponse("memory.get_config", map[string]any{"ok": true})
+
+	input := "\n" + `{"jsonrpc":"2.0","id":1,"method":"memory.get_config"}` + "\n"
+	var output bytes.Buffer
+
+	server := New(handler, WithReader(strings.NewReader(input)), WithWriter(&output))
+	if err := server.Run(context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if strings.HasPrefix(output.String(), contentLengthHeader) {
+		t.Errorf("expected newline framing, got %q", output.String())
+	}
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 1 {
+		t.Errorf("expected 1 line, got %d", len(lines))
+	}
+}
+
+// TestServer_Run_ForcedContentLength は明示指定時にContent-Length形式で動作することをテスト
+func TestServer_Run_ForcedContentLength(t *testing.T) {
+	handler := newMockHandler()
+	handler.SetResponse("memory.get_config", map[string]any{"ok": true})
+
+	input := frame(`{"jsonrpc":"2.0","id":1,"method":"memory.get_config"}`)
+	var output bytes.Buffer
+
+	server := New(handler, WithReader(strings.NewReader(input)), WithWriter(&output), WithFraming(FramingContentLength))
+	if err := server.Run(context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if !strings.HasPrefix(output.String(), contentLengthHeader+": ") {
+		t.Errorf("expected Content-Length framed response, got %q", output.String())
+	}
+}
+
+// TestContentLengthReader_Errors は不正なヘッダーのエラーをテスト
+func TestContentLengthReader_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"missing length", "Content-Type: application/json\r\n\r\n{}"},
+		{"invalid length", "Content-Length: abc\r\n\r\n{}"},
+		{"malformed header", "garbage\r\n\r\n{}"},
+		{"truncated body", "Content-Length: 10\r\n\r\n{}"},
+		{"too large", fmt.Sprintf("Content-Length: %d\r\n\r\n", MaxBufferSize+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := &contentLengthReader{reader: bufio.NewReader(strings.NewReader(tt.input))}
+			if _, err := reader.ReadMessage(); err == nil || err == io.EOF {
+				t.Errorf("expected error, got %v", err)
+			}
+		})
+	}
+}
@@ -6,12 +6,20 @@ import (
 	"context"
 	"io"
 	"os"
-	"strings"
+	"sync"
+
+	"github.com/brbranch/embedding_mcp/internal/session"
 )
 
-// MaxBufferSize はScannerの最大バッファサイズ（1MB）
+// MaxBufferSize は1メッセージの最大サイズ（1MB）
 const MaxBufferSize = 1024 * 1024
 
+// DefaultConcurrency は同時に処理するリクエスト数のデフォルト値
+const DefaultConcurrency = 8
+
+// readerBufferSize は入力読み取りバッファのサイズ
+const readerBufferSize = 64 * 1024
+
 // Handler はJSON-RPCリクエストを処理するインターフェース
 type Handler interface {
 	Handle(ctx context.Context, requestBytes []byte) []byte
@@ -19,9 +27,11 @@ type Handler interface {
 
 // Server はstdio JSON-RPCサーバー
 type Server struct {
-	handler Handler
-	reader  io.Reader
-	writer  io.Writer
+	handler     Handler
+	reader      io.Reader
+	writer      io.Writer
+	concurrency int
+	framing     Framing
 }
 
 // Option はサーバーオプション
@@ -41,12 +51,31 @@ func WithWriter(w io.Writer) Option {
 	}
 }
 
+// WithConcurrency は同時に処理するリクエスト数の上限を設定（1以下なら直列処理）
+func WithConcurrency(n int) Option {
+	return func(s *Server) {
+		if n < 1 {
+			n = 1
+		}
+		s.concurrency = n
+	}
+}
+
+// WithFraming はメッセージの区切り方式を設定（デフォルトはauto）
+func WithFraming(f Framing) Option {
+	return func(s *Server) {
+		s.framing = f
+	}
+}
+
 // New は新しいServerを生成
 func New(handler Handler, opts ...Option) *Server {
 	s := &Server{
-		handler: handler,
-		reader:  os.Stdin,
-		writer:  os.Stdout,
+		handler:     handler,
+		reader:      os.Stdin,
+		writer:      os.Stdout,
+		concurrency: DefaultConcurrency,
+		framing:     FramingAuto,
 	}
 	for _, opt := range opts {
 		opt(s)
@@ -55,11 +84,34 @@ func New(handler Handler, opts ...Option) *Server {
 }
 
 // Run はサーバーを起動し、contextがキャンセルされるまで実行
+// リクエストは最大concurrency件まで並行に処理し、レスポンスの書き込みのみ直列化する
+// （レスポンスは完了順に1メッセージずつ書き込まれ、クライアントはidで対応付ける）
 func (s *Server) Run(ctx context.Context) error {
-	scanner := bufio.NewScanner(s.reader)
-	// バッファサイズを1MBに拡張
-	buf := make([]byte, MaxBufferSize)
-	scanner.Buffer(buf, MaxBufferSize)
+	// 1ストリーム = 1セッション（initializeで宣言されたデフォルト値を以降のリクエストで共有）
+	sess := session.FromContext(ctx)
+	if sess == nil {
+		sess = session.New(session.Info{})
+		ctx = session.NewContext(ctx, sess)
+		// 終了時に購読（memory.subscribe）を解除する
+		defer sess.Close()
+	}
+
+	br := bufio.NewReaderSize(s.reader, readerBufferSize)
+
+	// 区切り方式の決定（autoの場合は最初の入力から判定）
+	framing := s.framing
+	if framing == FramingAuto {
+		detected, err := detectFraming(br)
+		if err != nil {
+			if err == io.EOF {
+				// 入力なしで終端: 正常終了
+				return nil
+			}
+			return err
+		}
+		framing = detected
+	}
+	reader := newMessageReader(br, framing)
 
 	// コンテキストキャンセルをチェックするチャネル
 	done := make(chan struct{})
@@ -68,6 +120,36 @@ func (s *Server) Run(ctx context.Context) error {
 		close(done)
 	}()
 
+	// 並行数を制限するセマフォ
+	sem := make(chan struct{}, s.concurrency)
+	var wg sync.WaitGroup
+	// 処理中のリクエストがすべて完了してから戻る
+	defer wg.Wait()
+
+	var (
+		writeMu  sync.Mutex
+		writeErr error
+	)
+	// write はレスポンスを1メッセージとして書き込む（goroutine間で直列化）
+	write := func(response []byte) {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+
+		if writeErr != nil {
+			return
+		}
+		writeErr = writeMessage(s.writer, framing, response)
+	}
+	// 変更通知（memory.subscribe）もレスポンスと同じストリームに書き込む
+	sess.SetNotifier(write)
+
+	// firstWriteErr は発生済みの書き込みエラーを返す
+	firstWriteErr := func() error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return writeErr
+	}
+
 	for {
 		// コンテキストキャンセルをチェック
 		select {
@@ -76,32 +158,42 @@ func (s *Server) Run(ctx context.Context) error {
 		default:
 		}
 
-		// 1行読み取り
-		if !scanner.Scan() {
-			// EOFまたはエラー
-			if err := scanner.Err(); err != nil {
+		// 書き込みエラーが発生していれば終了
+		if err := firstWriteErr(); err != nil {
+			return err
+		}
+
+		// 1メッセージ読み取り（空行はスキップ）
+		request, err := reader.ReadMessage()
+		if err != nil {
+			if err != io.EOF {
 				return err
 			}
-			// EOF: 正常終了
-			return nil
+			// EOF: 処理中のリクエストの完了を待って正常終了
+			wg.Wait()
+			return firstWriteErr()
 		}
 
-		line := scanner.Text()
-
-		// 空行はスキップ
-		if strings.TrimSpace(line) == "" {
-			continue
+		// ワーカーの空きを待つ
+		select {
+		case sem <- struct{}{}:
+		case <-done:
+			return ctx.Err()
 		}
 
-		// ハンドラーでリクエストを処理
-		response := s.handler.Handle(ctx, []byte(line))
+		wg.Add(1)
+		go func(request []byte) {
+			defer wg.Done()
+			defer func() { <-sem }()
 
-		// レスポンスを書き込み（1行 + 改行）
-		if _, err := s.writer.Write(response); err != nil {
-			return err
-		}
-		if _, err := s.writer.Write([]byte("\n")); err != nil {
-			return err
-		}
+			// ハンドラーでリクエストを処理
+			response := s.handler.Handle(ctx, request)
+
+			// 通知にはレスポンスを返さない
+			if response == nil {
+				return
+			}
+			write(response)
+		}(request)
 	}
 }
@@ -5,7 +5,9 @@ import (
 	"context"
 	"encoding/json"
 	"io"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -392,3 +394,126 @@ func TestServer_Run_WriteError(t *testing.T) {
 		t.Error("expected write error, got nil")
 	}
 }
+
+// slowHandler は指定メソッドの処理を遅延させるハンドラー
+type slowHandler struct {
+	*mockHandler
+	slowMethod string
+	delay      time.Duration
+	mu         sync.Mutex
+	active     int
+	maxActive  int
+}
+
+func (h *slowHandler) Handle(ctx context.Context, requestBytes []byte) []byte {
+	h.mu.Lock()
+	h.active++
+	if h.active > h.maxActive {
+		h.maxActive = h.active
+	}
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		h.active--
+		h.mu.Unlock()
+	}()
+
+	var req model.Request
+	if err := json.Unmarshal(requestBytes, &req); err == nil && req.Method == h.slowMethod {
+		time.Sleep(h.delay)
+	}
+	return h.mockHandler.Handle(ctx, requestBytes)
+}
+
+// TestServer_Run_ConcurrentDispatch は遅いリクエストが後続をブロックしないことをテスト
+func TestServer_Run_ConcurrentDispatch(t *testing.T) {
+	base := newMockHandler()
+	base.SetResponse("memory.search", map[string]any{"results": []any{}})
+	base.SetResponse("memory.get_config", map[string]any{"ok": true})
+	handler := &slowHandler{mockHandler: base, slowMethod: "memory.search", delay: 200 * time.Millisecond}
+
+	input := `{"jsonrpc":"2.0","id":1,"method":"memory.search"}` + "\n" +
+		`{"jsonrpc":"2.0","id":2,"method":"memory.get_config"}` + "\n"
+	var output bytes.Buffer
+
+	server := New(handler, WithReader(strings.NewReader(input)), WithWriter(&output))
+	if err := server.Run(context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+
+	// 速いリクエスト（id=2）が先に書き込まれること
+	var first model.Response
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if first.ID != float64(2) {
+		t.Errorf("expected first response id 2, got %v", first.ID)
+	}
+}
+
+// TestServer_Run_ConcurrencyLimit は並行数の上限が守られ、各レスポンスが1行で書き込まれることをテスト
+func TestServer_Run_ConcurrencyLimit(t *testing.T) {
+	base := newMockHandler()
+	base.SetResponse("memory.search", map[string]any{"results": []any{}})
+	handler := &slowHandler{mockHandler: base, slowMethod: "memory.search", delay: 20 * time.Millisecond}
+
+	var input strings.Builder
+	const n = 20
+	for i := 1; i <= n; i++ {
+		input.WriteString(`{"jsonrpc":"2.0","id":` + strconv.Itoa(i) + `,"method":"memory.search"}` + "\n")
+	}
+	var output bytes.Buffer
+
+	server := New(handler, WithReader(strings.NewReader(input.String())), WithWriter(&output), WithConcurrency(3))
+	if err := server.Run(context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if handler.maxActive > 3 {
+		t.Errorf("expected at most 3 concurrent requests, got %d", handler.maxActive)
+	}
+
+	// 全レスポンスが壊れずに1行ずつ書き込まれていること
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != n {
+		t.Fatalf("expected %d lines, got %d", n, len(lines))
+	}
+	seen := make(map[float64]bool)
+	for _, line := range lines {
+		var resp model.Response
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			t.Fatalf("failed to parse response %q: %v", line, err)
+		}
+		seen[resp.ID.(float64)] = true
+	}
+	if len(seen) != n {
+		t.Errorf("expected %d distinct ids, got %d", n, len(seen))
+	}
+}
+
+// nilHandler は常にnil（通知扱い）を返すハンドラー
+type nilHandler struct{}
+
+func (nilHandler) Handle(ctx context.Context, requestBytes []byte) []byte {
+	return nil
+}
+
+// TestServer_Run_Notification は通知に対して何も書き込まないことをテスト
+func TestServer_Run_Notification(t *testing.T) {
+	input := `{"jsonrpc":"2.0","method":"notifications/initialized"}` + "\n"
+	var output bytes.Buffer
+
+	server := New(nilHandler{}, WithReader(strings.NewReader(input)), WithWriter(&output))
+	if err := server.Run(context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if output.Len() != 0 {
+		t.Errorf("expected no output for notification, got %q", output.String())
+	}
+}
@@ -0,0 +1,758 @@
+// Package tui implements the interactive terminal browser for notes (mcp-memory browse).
+package tui
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/brbranch/embedding_mcp/internal/service"
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+)
+
+// PinnedTag はピン留めされたノートに付けるタグ
+const PinnedTag = "pinned"
+
+// DefaultLimit は一覧・検索で取得するノート数のデフォルト
+const DefaultLimit = 50
+
+// Options はブラウザの初期状態
+type Options struct {
+	ProjectID string // 正規化済み。空ならプロジェクト一覧から開始
+	GroupID   string // 空なら全グループ
+	Limit     int
+}
+
+// screen は表示中の画面
+type screen int
+
+const (
+	screenProjects screen = iota
+	screenNotes
+	screenGroups
+	screenNote
+)
+
+// noteItem は一覧に表示するノート
+type noteItem struct {
+	ID        string
+	GroupID   string
+	Title     *string
+	Text      string
+	Tags      []string
+	CreatedAt string
+	Score     *float64 // 検索結果のみ
+}
+
+func (n *noteItem) pinned() bool {
+	return slices.Contains(n.Tags, PinnedTag)
+}
+
+// 非同期処理の結果メッセージ
+type (
+	projectsLoadedMsg struct {
+		projects []service.ProjectItem
+		err      error
+	}
+	notesLoadedMsg struct {
+		items []noteItem
+		err   error
+	}
+	groupsLoadedMsg struct {
+		groups []string
+		err    error
+	}
+	noteLoadedMsg struct {
+		note *service.GetResponse
+		err  error
+	}
+	deletedMsg struct {
+		id  string
+		err error
+	}
+	pinnedMsg struct {
+		id   string
+		tags []string
+		err  error
+	}
+)
+
+// Model はbubbleteaのモデル
+type Model struct {
+	notes  service.NoteService
+	groups service.GroupService
+	ctx    context.Context
+	limit  int
+
+	screen        screen
+	width, height int
+
+	projects      []service.ProjectItem
+	projectCursor int
+
+	projectID   string
+	groupID     string // 空なら全グループ
+	groupList   []string
+	groupCursor int
+
+	items  []noteItem
+	cursor int
+	query  string // 空でなければ検索結果を表示中
+
+	searching bool
+	input     textinput.Model
+
+	detail       *service.GetResponse
+	detailOffset int
+
+	confirmDelete string // 削除確認中のノートID
+	status        string
+	loading       bool
+}
+
+// New は新しいModelを作成する
+func New(ctx context.Context, notes service.NoteService, groups service.GroupService, opts Options) *Model {
+	input := textinput.New()
+	input.Prompt = "search: "
+	input.Placeholder = "query"
+	input.Cursor.SetMode(cursor.CursorStatic)
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+
+	m := &Model{
+		notes:     notes,
+		groups:    groups,
+		ctx:       ctx,
+		limit:     limit,
+		input:     input,
+		projectID: opts.ProjectID,
+		groupID:   opts.GroupID,
+		width:     80,
+		height:    24,
+	}
+	if opts.ProjectID != "" {
+		m.screen = screenNotes
+	}
+	return m
+}
+
+// Init は初期データを読み込む
+func (m *Model) Init() tea.Cmd {
+	m.loading = true
+	if m.screen == screenNotes {
+		return m.loadNotes()
+	}
+	return m.loadProjects()
+}
+
+// Update はメッセージを処理する
+func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, nil
+
+	case projectsLoadedMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.status = "error: " + msg.err.Error()
+			return m, nil
+		}
+		m.projects = msg.projects
+		m.projectCursor = clamp(m.projectCursor, len(m.projects))
+		return m, nil
+
+	case notesLoadedMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.status = "error: " + msg.err.Error()
+			return m, nil
+		}
+		m.items = msg.items
+		m.cursor = clamp(m.cursor, len(m.items))
+		return m, nil
+
+	case groupsLoadedMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.status = "error: " + msg.err.Error()
+			return m, nil
+		}
+		m.groupList = msg.groups
+		m.groupCursor = max(slices.Index(m.groupList, m.groupID), 0)
+		m.screen = screenGroups
+		return m, nil
+
+	case noteLoadedMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.status = "error: " + msg.err.Error()
+			return m, nil
+		}
+		m.detail = msg.note
+		m.detailOffset = 0
+		m.screen = screenNote
+		return m, nil
+
+	case deletedMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.status = "delete failed: " + msg.err.Error()
+			return m, nil
+		}
+		m.items = slices.DeleteFunc(m.items, func(n noteItem) bool { return n.ID == msg.id })
+		m.cursor = clamp(m.cursor, len(m.items))
+		if m.screen == screenNote {
+			m.screen, m.detail = screenNotes, nil
+		}
+		m.status = "deleted"
+		return m, nil
+
+	case pinnedMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.status = "pin failed: " + msg.err.Error()
+			return m, nil
+		}
+		m.applyTags(msg.id, msg.tags)
+		if slices.Contains(msg.tags, PinnedTag) {
+			m.status = "pinned"
+		} else {
+			m.status = "unpinned"
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+
+	if m.searching {
+		var cmd tea.Cmd
+		m.input, cmd = m.input.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+// handleKey はキー入力を処理する
+func (m *Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	key := msg.String()
+	if key == "ctrl+c" {
+		return m, tea.Quit
+	}
+
+	// 検索クエリ入力中
+	if m.searching {
+		switch key {
+		case "enter":
+			m.searching = false
+			m.input.Blur()
+			m.query = strings.TrimSpace(m.input.Value())
+			m.cursor = 0
+			m.loading = true
+			return m, m.loadNotes()
+		case "esc":
+			m.searching = false
+			m.input.Blur()
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.input, cmd = m.input.Update(msg)
+		return m, cmd
+	}
+
+	// 削除確認中
+	if m.confirmDelete != "" {
+		id := m.confirmDelete
+		m.confirmDelete = ""
+		if key == "y" || key == "Y" {
+			m.loading = true
+			m.status = ""
+			return m, m.deleteNote(id)
+		}
+		m.status = "delete canceled"
+		return m, nil
+	}
+
+	m.status = ""
+	if key == "q" {
+		return m, tea.Quit
+	}
+
+	switch m.screen {
+	case screenProjects:
+		return m.handleProjectsKey(key)
+	case screenNotes:
+		return m.handleNotesKey(key)
+	case screenGroups:
+		return m.handleGroupsKey(key)
+	case screenNote:
+		return m.handleNoteKey(key)
+	}
+	return m, nil
+}
+
+func (m *Model) handleProjectsKey(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "up", "k":
+		m.projectCursor = clamp(m.projectCursor-1, len(m.projects))
+	case "down", "j":
+		m.projectCursor = clamp(m.projectCursor+1, len(m.projects))
+	case "r":
+		m.loading = true
+		return m, m.loadProjects()
+	case "enter":
+		if len(m.projects) == 0 {
+			return m, nil
+		}
+		m.projectID = m.projects[m.projectCursor].ProjectID
+		m.groupID, m.query, m.cursor, m.items = "", "", 0, nil
+		m.screen = screenNotes
+		m.loading = true
+		return m, m.loadNotes()
+	}
+	return m, nil
+}
+
+func (m *Model) handleNotesKey(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "up", "k":
+		m.cursor = clamp(m.cursor-1, len(m.items))
+	case "down", "j":
+		m.cursor = clamp(m.cursor+1, len(m.items))
+	case "pgup":
+		m.cursor = clamp(m.cursor-m.listHeight(), len(m.items))
+	case "pgdown":
+		m.cursor = clamp(m.cursor+m.listHeight(), len(m.items))
+	case "/":
+		m.searching = true
+		m.input.SetValue(m.query)
+		m.input.CursorEnd()
+		return m, m.input.Focus()
+	case "g":
+		m.loading = true
+		return m, m.loadGroups()
+	case "r":
+		m.loading = true
+		return m, m.loadNotes()
+	case "enter":
+		if item := m.selected(); item != nil {
+			m.loading = true
+			return m, m.loadNote(item.ID)
+		}
+	case "d":
+		if item := m.selected(); item != nil {
+			m.confirmDelete = item.ID
+		}
+	case "p":
+		if item := m.selected(); item != nil {
+			m.loading = true
+			return m, m.togglePin(item.ID, item.Tags)
+		}
+	case "esc", "backspace":
+		if m.query != "" {
+			m.query, m.cursor = "", 0
+			m.loading = true
+			return m, m.loadNotes()
+		}
+		m.screen = screenProjects
+		m.loading = true
+		return m, m.loadProjects()
+	}
+	return m, nil
+}
+
+func (m *Model) handleGroupsKey(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "up", "k":
+		m.groupCursor = clamp(m.groupCursor-1, len(m.groupList))
+	case "down", "j":
+		m.groupCursor = clamp(m.groupCursor+1, len(m.groupList))
+	case "enter":
+		if len(m.groupList) > 0 {
+			m.groupID = m.groupList[m.groupCursor]
+		}
+		m.cursor = 0
+		m.screen = screenNotes
+		m.loading = true
+		return m, m.loadNotes()
+	case "esc", "backspace":
+		m.screen = screenNotes
+	}
+	return m, nil
+}
+
+func (m *Model) handleNoteKey(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "up", "k":
+		m.detailOffset = max(m.detailOffset-1, 0)
+	case "down", "j":
+		m.detailOffset = min(m.detailOffset+1, max(len(m.detailLines())-m.bodyHeight(), 0))
+	case "pgup":
+		m.detailOffset = max(m.detailOffset-m.bodyHeight(), 0)
+	case "pgdown":
+		m.detailOffset = min(m.detailOffset+m.bodyHeight(), max(len(m.detailLines())-m.bodyHeight(), 0))
+	case "d":
+		m.confirmDelete = m.detail.ID
+	case "p":
+		m.loading = true
+		return m, m.togglePin(m.detail.ID, m.detail.Tags)
+	case "esc", "backspace":
+		m.screen, m.detail = screenNotes, nil
+	}
+	return m, nil
+}
+
+// selected はカーソル位置のノートを返す
+func (m *Model) selected() *noteItem {
+	if m.cursor < 0 || m.cursor >= len(m.items) {
+		return nil
+	}
+	return &m.items[m.cursor]
+}
+
+// applyTags はピン留め結果を一覧と詳細に反映する（一覧はピン留めを先頭に並べ直す）
+func (m *Model) applyTags(id string, tags []string) {
+	for i := range m.items {
+		if m.items[i].ID == id {
+			m.items[i].Tags = tags
+		}
+	}
+	if m.detail != nil && m.detail.ID == id {
+		m.detail.Tags = tags
+	}
+	if m.query == "" {
+		sortPinnedFirst(m.items)
+		m.cursor = max(slices.IndexFunc(m.items, func(n noteItem) bool { return n.ID == id }), 0)
+	}
+}
+
+// === 非同期処理 ===
+
+func (m *Model) loadProjects() tea.Cmd {
+	ctx, notes := m.ctx, m.notes
+	return func() tea.Msg {
+		resp, err := notes.ListProjects(ctx)
+		if err != nil {
+			return projectsLoadedMsg{err: err}
+		}
+		return projectsLoadedMsg{projects: resp.Projects}
+	}
+}
+
+func (m *Model) loadNotes() tea.Cmd {
+	ctx, notes, projectID, query, limit := m.ctx, m.notes, m.projectID, m.query, m.limit
+	var groupID *string
+	if m.groupID != "" {
+		g := m.groupID
+		groupID = &g
+	}
+
+	return func() tea.Msg {
+		if query != "" {
+			resp, err := notes.Search(ctx, &service.SearchRequest{ProjectID: projectID, GroupID: groupID, Query: query, TopK: &limit})
+			if err != nil {
+				return notesLoadedMsg{err: err}
+			}
+			items := make([]noteItem, 0, len(resp.Results))
+			for _, r := range resp.Results {
+				score := r.Score
+				items = append(items, noteItem{ID: r.ID, GroupID: r.GroupID, Title: r.Title, Text: r.Text, Tags: r.Tags, CreatedAt: r.CreatedAt, Score: &score})
+			}
+			return notesLoadedMsg{items: items}
+		}
+
+		resp, err := notes.ListRecent(ctx, &service.ListRecentRequest{ProjectID: projectID, GroupID: groupID, Limit: &limit})
+		if err != nil {
+			return notesLoadedMsg{err: err}
+		}
+		items := make([]noteItem, 0, len(resp.Items))
+		for _, r := range resp.Items {
+			items = append(items, noteItem{ID: r.ID, GroupID: r.GroupID, Title: r.Title, Text: r.Text, Tags: r.Tags, CreatedAt: r.CreatedAt})
+		}
+		sortPinnedFirst(items)
+		return notesLoadedMsg{items: items}
+	}
+}
+
+// loadGroups はグループの選択肢（全グループ・global・登録済みグループ・表示中ノートのgroupId）を読み込む
+func (m *Model) loadGroups() tea.Cmd {
+	ctx, groups, projectID := m.ctx, m.groups, m.projectID
+	seen := []string{"global"}
+	for _, item := range m.items {
+		seen = append(seen, item.GroupID)
+	}
+	if m.groupID != "" {
+		seen = append(seen, m.groupID)
+	}
+
+	return func() tea.Msg {
+		choices := seen
+		if groups != nil {
+			resp, err := groups.ListGroups(ctx, projectID)
+			if err != nil {
+				return groupsLoadedMsg{err: err}
+			}
+			for _, g := range resp.Groups {
+				choices = append(choices, g.GroupKey)
+			}
+		}
+		sort.Strings(choices)
+		return groupsLoadedMsg{groups: append([]string{""}, slices.Compact(choices)...)}
+	}
+}
+
+func (m *Model) loadNote(id string) tea.Cmd {
+	ctx, notes := m.ctx, m.notes
+	return func() tea.Msg {
+		note, err := notes.Get(ctx, id)
+		return noteLoadedMsg{note: note, err: err}
+	}
+}
+
+func (m *Model) deleteNote(id string) tea.Cmd {
+	ctx, notes := m.ctx, m.notes
+	return func() tea.Msg {
+		return deletedMsg{id: id, err: notes.Delete(ctx, id)}
+	}
+}
+
+// togglePin はPinnedTagを付け外しする
+func (m *Model) togglePin(id string, tags []string) tea.Cmd {
+	ctx, notes := m.ctx, m.notes
+	newTags := slices.DeleteFunc(slices.Clone(tags), func(t string) bool { return t == PinnedTag })
+	if len(newTags) == len(tags) {
+		newTags = append(newTags, PinnedTag)
+	}
+
+	return func() tea.Msg {
+		err := notes.Update(ctx, &service.UpdateRequest{ID: id, Patch: service.NotePatch{Tags: &newTags}})
+		return pinnedMsg{id: id, tags: newTags, err: err}
+	}
+}
+
+// === 描画 ===
+
+var (
+	headerStyle   = lipgloss.NewStyle().Bold(true)
+	selectedStyle = lipgloss.NewStyle().Reverse(true)
+	dimStyle      = lipgloss.NewStyle().Faint(true)
+	statusStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
+)
+
+// View は画面を描画する
+func (m *Model) View() string {
+	var b strings.Builder
+
+	b.WriteString(headerStyle.Render(runewidth.Truncate(m.header(), m.width, "…")))
+	b.WriteString("\n\n")
+
+	var body []string
+	switch m.screen {
+	case screenProjects:
+		body = m.projectLines()
+	case screenNotes:
+		body = m.noteLines()
+	case screenGroups:
+		body = m.groupLines()
+	case screenNote:
+		lines := m.detailLines()
+		end := min(m.detailOffset+m.bodyHeight(), len(lines))
+		body = lines[min(m.detailOffset, end):end]
+	}
+	for len(body) < m.bodyHeight() {
+		body = append(body, "")
+	}
+	b.WriteString(strings.Join(body, "\n"))
+	b.WriteString("\n\n")
+
+	switch {
+	case m.searching:
+		b.WriteString(m.input.View())
+	case m.confirmDelete != "":
+		b.WriteString(statusStyle.Render("delete this note? (y/n)"))
+	case m.status != "":
+		b.WriteString(statusStyle.Render(m.status))
+	case m.loading:
+		b.WriteString(dimStyle.Render("loading..."))
+	default:
+		b.WriteString(dimStyle.Render(runewidth.Truncate(m.help(), m.width, "…")))
+	}
+	return b.String()
+}
+
+func (m *Model) header() string {
+	switch m.screen {
+	case screenProjects:
+		return fmt.Sprintf("mcp-memory ▸ projects (%d)", len(m.projects))
+	case screenNote:
+		return "mcp-memory ▸ " + m.projectID + " ▸ note " + m.detail.ID
+	}
+
+	group := m.groupID
+	if group == "" {
+		group = "all groups"
+	}
+	h := "mcp-memory ▸ " + m.projectID + " ▸ " + group
+	if m.screen == screenNotes {
+		if m.query != "" {
+			h += fmt.Sprintf(" ▸ search %q (%d)", m.query, len(m.items))
+		} else {
+			h += fmt.Sprintf(" ▸ recent (%d)", len(m.items))
+		}
+	}
+	return h
+}
+
+func (m *Model) help() string {
+	switch m.screen {
+	case screenProjects:
+		return "↑/↓ move  enter open  r reload  q quit"
+	case screenNotes:
+		return "↑/↓ move  enter view  / search  g group  p pin  d delete  r reload  esc back  q quit"
+	case screenGroups:
+		return "↑/↓ move  enter select  esc back  q quit"
+	case screenNote:
+		return "↑/↓ scroll  p pin  d delete  esc back  q quit"
+	}
+	return ""
+}
+
+func (m *Model) projectLines() []string {
+	if len(m.projects) == 0 {
+		return []string{dimStyle.Render("no projects")}
+	}
+	lines := make([]string, 0, len(m.projects))
+	for _, p := range m.projects {
+		lines = append(lines, fmt.Sprintf("%s  (%d notes)", p.ProjectID, p.NoteCount))
+	}
+	return m.window(lines, m.projectCursor)
+}
+
+func (m *Model) noteLines() []string {
+	if len(m.items) == 0 {
+		return []string{dimStyle.Render("no notes")}
+	}
+	lines := make([]string, 0, len(m.items))
+	for _, item := range m.items {
+		mark := " "
+		if item.pinned() {
+			mark = "★"
+		}
+		title := firstLine(item.Text)
+		if item.Title != nil && *item.Title != "" {
+			title = *item.Title + " — " + firstLine(item.Text)
+		}
+		prefix := fmt.Sprintf("%s %-10s [%s] ", mark, dateOnly(item.CreatedAt), item.GroupID)
+		if item.Score != nil {
+			prefix = fmt.Sprintf("%s %.3f [%s] ", mark, *item.Score, item.GroupID)
+		}
+		lines = append(lines, prefix+title)
+	}
+	return m.window(lines, m.cursor)
+}
+
+func (m *Model) groupLines() []string {
+	lines := make([]string, 0, len(m.groupList))
+	for _, g := range m.groupList {
+		if g == "" {
+			g = "(all groups)"
+		}
+		lines = append(lines, g)
+	}
+	return m.window(lines, m.groupCursor)
+}
+
+// detailLines はノート詳細を画面幅で折り返した行を返す
+func (m *Model) detailLines() []string {
+	if m.detail == nil {
+		return nil
+	}
+	n := m.detail
+
+	var meta []string
+	if n.Title != nil && *n.Title != "" {
+		meta = append(meta, headerStyle.Render(*n.Title))
+	}
+	meta = append(meta, dimStyle.Render("group: "+n.GroupID+"  created: "+n.CreatedAt))
+	if len(n.Tags) > 0 {
+		meta = append(meta, dimStyle.Render("tags: "+strings.Join(n.Tags, ", ")))
+	}
+	if n.Source != nil && *n.Source != "" {
+		meta = append(meta, dimStyle.Render("source: "+*n.Source))
+	}
+	meta = append(meta, "")
+
+	wrapped := lipgloss.NewStyle().Width(max(m.width, 20)).Render(n.Text)
+	return append(meta, strings.Split(wrapped, "\n")...)
+}
+
+// window はカーソル行が見えるように表示範囲を切り出し、カーソル行を強調する
+func (m *Model) window(lines []string, cursor int) []string {
+	height := m.listHeight()
+	start := 0
+	if cursor >= height {
+		start = cursor - height + 1
+	}
+	end := min(start+height, len(lines))
+
+	out := make([]string, 0, end-start)
+	for i := start; i < end; i++ {
+		line := runewidth.Truncate(lines[i], m.width-2, "…")
+		if i == cursor {
+			out = append(out, selectedStyle.Render("> "+line))
+		} else {
+			out = append(out, "  "+line)
+		}
+	}
+	return out
+}
+
+// bodyHeight はヘッダーとフッターを除いた本文の行数
+func (m *Model) bodyHeight() int {
+	return max(m.height-4, 1)
+}
+
+func (m *Model) listHeight() int {
+	return m.bodyHeight()
+}
+
+// === ヘルパー ===
+
+// sortPinnedFirst はピン留めされたノートを先頭に移動する（それ以外の順序は保つ）
+func sortPinnedFirst(items []noteItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].pinned() && !items[j].pinned()
+	})
+}
+
+func clamp(i, n int) int {
+	if n == 0 {
+		return 0
+	}
+	return min(max(i, 0), n-1)
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+func dateOnly(createdAt string) string {
+	if len(createdAt) >= 10 {
+		return createdAt[:10]
+	}
+	return createdAt
+}
@@ -0,0 +1,285 @@
+package tui
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/brbranch/embedding_mcp/internal/service"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// fakeNoteService はテスト用のNoteService（使用するメソッドのみ実装）
+type fakeNoteService struct {
+	service.NoteService
+	projects []service.ProjectItem
+	notes    []service.ListRecentItem
+	searched []string
+	deleted  []string
+	updated  map[string][]string
+}
+
+func (f *fakeNoteService) ListProjects(ctx context.Context) (*service.ListProjectsResponse, error) {
+	return &service.ListProjectsResponse{Projects: f.projects}, nil
+}
+
+func (f *fakeNoteService) ListRecent(ctx context.Context, req *service.ListRecentRequest) (*service.ListRecentResponse, error) {
+	var items []service.ListRecentItem
+	for _, n := range f.notes {
+		if n.ProjectID == req.ProjectID && (req.GroupID == nil || *req.GroupID == n.GroupID) {
+			items = append(items, n)
+		}
+	}
+	return &service.ListRecentResponse{Items: items}, nil
+}
+
+func (f *fakeNoteService) Search(ctx context.Context, req *service.SearchRequest) (*service.SearchResponse, error) {
+	f.searched = append(f.searched, req.Query)
+	var results []service.SearchResult
+	for _, n := range f.notes {
+		if strings.Contains(n.Text, req.Query) {
+			results = append(results, service.SearchResult{ID: n.ID, GroupID: n.GroupID, Text: n.Text, Tags: n.Tags, Score: 0.9})
+		}
+	}
+	return &service.SearchResponse{Results: results}, nil
+}
+
+func (f *fakeNoteService) Get(ctx context.Context, id string) (*service.GetResponse, error) {
+	for _, n := range f.notes {
+		if n.ID == id {
+			return &service.GetResponse{ID: n.ID, ProjectID: n.ProjectID, GroupID: n.GroupID, Text: n.Text, Tags: n.Tags, CreatedAt: n.CreatedAt}, nil
+		}
+	}
+	return nil, service.ErrNoteNotFound
+}
+
+func (f *fakeNoteService) Delete(ctx context.Context, id string) error {
+	f.deleted = append(f.deleted, id)
+	return nil
+}
+
+func (f *fakeNoteService) GetDocument(ctx context.Context, id string) (*service.GetResponse, error) {
+	return f.Get(ctx, id)
+}
+
+func (f *fakeNoteService) Update(ctx context.Context, req *service.UpdateRequest) error {
+	if f.updated == nil {
+		f.updated = make(map[string][]string)
+	}
+	f.updated[req.ID] = *req.Patch.Tags
+	return nil
+}
+
+// fakeGroupService はテスト用のGroupService
+type fakeGroupService struct {
+	service.GroupService
+	keys []string
+}
+
+func (f *fakeGroupService) ListGroups(ctx context.Context, projectID string) (*service.ListGroupsResponse, error) {
+	resp := &service.ListGroupsResponse{}
+	for _, k := range f.keys {
+		resp.Groups = append(resp.Groups, service.ListGroupItem{GroupKey: k})
+	}
+	return resp, nil
+}
+
+func newFakeNotes() *fakeNoteService {
+	return &fakeNoteService{
+		projects: []service.ProjectItem{{ProjectID: "/a", NoteCount: 3}, {ProjectID: "/b", NoteCount: 0}},
+		notes: []service.ListRecentItem{
+			{ID: "n1", ProjectID: "/a", GroupID: "global", Text: "first note", CreatedAt: "2025-01-03T00:00:00Z"},
+			{ID: "n2", ProjectID: "/a", GroupID: "feature", Text: "second note\nmore", Tags: []string{PinnedTag}, CreatedAt: "2025-01-02T00:00:00Z"},
+			{ID: "n3", ProjectID: "/a", GroupID: "global", Text: "third", CreatedAt: "2025-01-01T00:00:00Z"},
+		},
+	}
+}
+
+// send はメッセージを処理し、返されたコマンドの結果も同期的に処理する
+func send(t *testing.T, m *Model, msg tea.Msg) {
+	t.Helper()
+	_, cmd := m.Update(msg)
+	for cmd != nil {
+		next := cmd()
+		if next == nil {
+			return
+		}
+		if _, ok := next.(tea.QuitMsg); ok {
+			return
+		}
+		_, cmd = m.Update(next)
+	}
+}
+
+func key(s string) tea.KeyMsg {
+	switch s {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case "down":
+		return tea.KeyMsg{Type: tea.KeyDown}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func typeText(t *testing.T, m *Model, s string) {
+	t.Helper()
+	for _, r := range s {
+		send(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+}
+
+func start(t *testing.T, m *Model) {
+	t.Helper()
+	cmd := m.Init()
+	if cmd != nil {
+		send(t, m, cmd())
+	}
+}
+
+func TestModel_ProjectsToNotes(t *testing.T) {
+	notes := newFakeNotes()
+	m := New(context.Background(), notes, &fakeGroupService{}, Options{})
+	start(t, m)
+
+	if m.screen != screenProjects || len(m.projects) != 2 {
+		t.Fatalf("expected projects screen with 2 projects, got screen=%v projects=%d", m.screen, len(m.projects))
+	}
+	if !strings.Contains(m.View(), "/a  (3 notes)") {
+		t.Errorf("expected project list in view:\n%s", m.View())
+	}
+
+	send(t, m, key("enter"))
+	if m.screen != screenNotes || m.projectID != "/a" {
+		t.Fatalf("expected notes screen for /a, got screen=%v project=%q", m.screen, m.projectID)
+	}
+	// ピン留めされたノートが先頭
+	if len(m.items) != 3 || m.items[0].ID != "n2" {
+		t.Fatalf("expected pinned note first, got %+v", m.items)
+	}
+	if view := m.View(); !strings.Contains(view, "★") || !strings.Contains(view, "recent (3)") {
+		t.Errorf("unexpected view:\n%s", view)
+	}
+
+	send(t, m, key("esc"))
+	if m.screen != screenProjects {
+		t.Errorf("expected back to projects, got %v", m.screen)
+	}
+}
+
+func TestModel_SearchAndClear(t *testing.T) {
+	notes := newFakeNotes()
+	m := New(context.Background(), notes, nil, Options{ProjectID: "/a"})
+	start(t, m)
+
+	send(t, m, key("/"))
+	if !m.searching {
+		t.Fatal("expected search input")
+	}
+	typeText(t, m, "second")
+	send(t, m, key("enter"))
+
+	if len(notes.searched) != 1 || notes.searched[0] != "second" {
+		t.Fatalf("expected search for 'second', got %v", notes.searched)
+	}
+	if len(m.items) != 1 || m.items[0].ID != "n2" || m.items[0].Score == nil {
+		t.Fatalf("unexpected search results: %+v", m.items)
+	}
+	if !strings.Contains(m.View(), `search "second" (1)`) {
+		t.Errorf("expected search header:\n%s", m.View())
+	}
+
+	// escで検索を解除して最近の一覧に戻る
+	send(t, m, key("esc"))
+	if m.query != "" || len(m.items) != 3 || m.screen != screenNotes {
+		t.Errorf("expected recent list after clearing search, got query=%q items=%d", m.query, len(m.items))
+	}
+}
+
+func TestModel_ViewNote(t *testing.T) {
+	m := New(context.Background(), newFakeNotes(), nil, Options{ProjectID: "/a"})
+	start(t, m)
+
+	send(t, m, key("enter"))
+	if m.screen != screenNote || m.detail == nil || m.detail.ID != "n2" {
+		t.Fatalf("expected detail of n2, got screen=%v detail=%+v", m.screen, m.detail)
+	}
+	if view := m.View(); !strings.Contains(view, "second note") || !strings.Contains(view, "more") {
+		t.Errorf("expected full text in view:\n%s", view)
+	}
+
+	send(t, m, key("esc"))
+	if m.screen != screenNotes {
+		t.Errorf("expected back to notes, got %v", m.screen)
+	}
+}
+
+func TestModel_Delete(t *testing.T) {
+	notes := newFakeNotes()
+	m := New(context.Background(), notes, nil, Options{ProjectID: "/a"})
+	start(t, m)
+
+	// nでキャンセル
+	send(t, m, key("d"))
+	send(t, m, key("n"))
+	if len(notes.deleted) != 0 {
+		t.Fatal("expected delete to be canceled")
+	}
+
+	send(t, m, key("d"))
+	if !strings.Contains(m.View(), "(y/n)") {
+		t.Errorf("expected confirmation prompt:\n%s", m.View())
+	}
+	send(t, m, key("y"))
+	if len(notes.deleted) != 1 || notes.deleted[0] != "n2" {
+		t.Fatalf("expected n2 to be deleted, got %v", notes.deleted)
+	}
+	if len(m.items) != 2 {
+		t.Errorf("expected deleted note to be removed from list, got %d", len(m.items))
+	}
+}
+
+func TestModel_TogglePin(t *testing.T) {
+	notes := newFakeNotes()
+	m := New(context.Background(), notes, nil, Options{ProjectID: "/a"})
+	start(t, m)
+
+	// n1（2番目）をピン留め
+	send(t, m, key("down"))
+	send(t, m, key("p"))
+	if tags := notes.updated["n1"]; len(tags) != 1 || tags[0] != PinnedTag {
+		t.Fatalf("expected n1 to be pinned, got %v", tags)
+	}
+	if !m.items[1].pinned() || m.items[m.cursor].ID != "n1" {
+		t.Errorf("expected cursor to follow pinned note, got cursor=%d items=%+v", m.cursor, m.items)
+	}
+
+	// 解除
+	send(t, m, key("p"))
+	if tags := notes.updated["n1"]; len(tags) != 0 {
+		t.Errorf("expected n1 to be unpinned, got %v", tags)
+	}
+}
+
+func TestModel_GroupFilter(t *testing.T) {
+	m := New(context.Background(), newFakeNotes(), &fakeGroupService{keys: []string{"design"}}, Options{ProjectID: "/a"})
+	start(t, m)
+
+	send(t, m, key("g"))
+	if m.screen != screenGroups {
+		t.Fatalf("expected groups screen, got %v", m.screen)
+	}
+	want := []string{"", "design", "feature", "global"}
+	if strings.Join(m.groupList, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected groups %v, got %v", want, m.groupList)
+	}
+
+	// feature を選択
+	send(t, m, key("down"))
+	send(t, m, key("down"))
+	send(t, m, key("enter"))
+	if m.groupID != "feature" || len(m.items) != 1 || m.items[0].ID != "n2" {
+		t.Errorf("expected feature group filter, got group=%q items=%+v", m.groupID, m.items)
+	}
+}
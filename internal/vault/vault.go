@@ -0,0 +1,258 @@
+// Package vault reads and writes notes as markdown files with YAML front matter, so that a directory
+// of notes (such as an Obsidian vault) can be kept in sync with the memory.
+package vault
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/brbranch/embedding_mcp/internal/ingest"
+)
+
+// StateFile は前回の同期の状態を保存するファイル（vaultの直下）
+const StateFile = ".mcp-memory-vault.json"
+
+// Ext はノートのファイルの拡張子
+const Ext = ".md"
+
+// maxFileNameLength はタイトルから作るファイル名の最大文字数
+const maxFileNameLength = 80
+
+// Note はmarkdownファイル1つに対応するノートの属性
+type Note struct {
+	ID        string
+	Title     string
+	GroupID   string
+	Tags      []string
+	Source    string
+	CreatedAt string
+	Text      string // 本文（前後の空白を除く）
+}
+
+// plainValue はYAMLで引用符なしに書ける値（それ以外はダブルクォートで囲む）
+var plainValue = regexp.MustCompile(`^[\p{L}\p{N}_][\p{L}\p{N} _./@+-]*$`)
+
+// Render はノートをフロントマター付きのmarkdownにする
+func Render(n *Note) []byte {
+	var b strings.Builder
+	b.WriteString("---\n")
+	writeField(&b, "id", n.ID)
+	writeField(&b, "title", n.Title)
+	writeField(&b, "group", n.GroupID)
+	if len(n.Tags) > 0 {
+		tags := make([]string, len(n.Tags))
+		for i, tag := range n.Tags {
+			tags[i] = yamlValue(tag)
+		}
+		fmt.Fprintf(&b, "tags: [%s]\n", strings.Join(tags, ", "))
+	}
+	writeField(&b, "source", n.Source)
+	writeField(&b, "createdAt", n.CreatedAt)
+	b.WriteString("---\n\n")
+	if text := strings.TrimSpace(n.Text); text != "" {
+		b.WriteString(text)
+		b.WriteString("\n")
+	}
+	return []byte(b.String())
+}
+
+// writeField は空でない値を "key: value" の行として書く
+func writeField(b *strings.Builder, key, value string) {
+	if value != "" {
+		fmt.Fprintf(b, "%s: %s\n", key, yamlValue(value))
+	}
+}
+
+// yamlValue は値をYAMLのスカラーとして書ける形にする（数値・真偽値に見えるものや記号を含むものは引用符で囲む）
+func yamlValue(v string) string {
+	if plainValue.MatchString(v) && strings.TrimSpace(v) == v {
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			switch strings.ToLower(v) {
+			case "true", "false", "yes", "no", "on", "off", "null":
+			default:
+				return v
+			}
+		}
+	}
+	return strconv.Quote(v)
+}
+
+// Parse はmarkdownを読み、フロントマターの属性と本文を返す（フロントマターがなければ全体が本文）
+func Parse(data []byte) *Note {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	fm, body := ingest.ParseFrontMatter(text)
+	n := &Note{Text: strings.TrimSpace(body)}
+	if fm != nil {
+		n.ID = fm.ID
+		n.Title = fm.Title
+		n.GroupID = fm.GroupID
+		n.Tags = fm.Tags
+		n.Source = fm.Source
+		n.CreatedAt = fm.CreatedAt
+	}
+	return n
+}
+
+// FileName はタイトルからファイル名（拡張子なし）を作る
+// ファイル名に使えない文字は "-" に置き換え、タイトルがなければIDを使う
+func FileName(title, id string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case strings.ContainsRune(`/\:*?"<>|#^[]`, r):
+			return '-'
+		case unicode.IsControl(r):
+			return ' '
+		}
+		return r
+	}, title)
+	name = strings.TrimLeft(strings.Join(strings.Fields(name), " "), ".")
+	if runes := []rune(name); len(runes) > maxFileNameLength {
+		name = strings.TrimSpace(string(runes[:maxFileNameLength]))
+	}
+	if name == "" {
+		return id
+	}
+	return name
+}
+
+// NotePath はノートを新しく書き出すときのvaultからの相対パス（"グループ/タイトル.md"）を返す
+// existsが真を返すパスは避け、ファイル名にIDの先頭を付ける
+func NotePath(n *Note, exists func(rel string) bool) string {
+	name := FileName(n.Title, n.ID)
+	rel := path.Join(n.GroupID, name+Ext)
+	if exists(rel) {
+		short := n.ID
+		if len(short) > 8 {
+			short = short[:8]
+		}
+		rel = path.Join(n.GroupID, fmt.Sprintf("%s (%s)%s", name, short, Ext))
+	}
+	return rel
+}
+
+// File はvault内のmarkdownファイル
+type File struct {
+	Path string // vaultからの相対パス（スラッシュ区切り）
+	Data []byte
+}
+
+// Collect はdir以下のmarkdownファイルをパス順に返す
+// "." で始まるファイル・ディレクトリ（.obsidian、.trash、状態ファイル）は対象外
+func Collect(dir string) ([]File, error) {
+	var files []File
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !d.Type().IsRegular() || !strings.EqualFold(filepath.Ext(p), Ext) {
+			return nil
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, File{Path: filepath.ToSlash(rel), Data: data})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault: %w", err)
+	}
+	return files, nil
+}
+
+// WriteFile はvaultからの相対パスにファイルを書く（ディレクトリがなければ作る）
+func WriteFile(dir, rel string, data []byte) error {
+	p := filepath.Join(dir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(p, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", rel, err)
+	}
+	return nil
+}
+
+// RemoveFile はvaultからの相対パスのファイルを削除する（存在しなければ何もしない）
+func RemoveFile(dir, rel string) error {
+	if err := os.Remove(filepath.Join(dir, filepath.FromSlash(rel))); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove %s: %w", rel, err)
+	}
+	return nil
+}
+
+// Hash はファイル内容のSHA-256（16進）を返す
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// State は前回の同期の状態
+type State struct {
+	ProjectID string           `json:"projectId"`
+	Notes     map[string]Entry `json:"notes"` // ノートIDごと
+}
+
+// Entry は1件のノートを前回同期したときの状態
+// ノートのupdatedAtとファイルのハッシュを比べて、どちら側が変更されたかを判断する
+type Entry struct {
+	Path      string `json:"path"`      // vaultからの相対パス（スラッシュ区切り）
+	UpdatedAt string `json:"updatedAt"` // ノートのupdatedAt
+	Hash      string `json:"hash"`      // ファイル内容のSHA-256
+}
+
+// LoadState はdirの状態ファイルを読む（なければ空の状態を返す）
+func LoadState(dir string) (*State, error) {
+	st := &State{Notes: make(map[string]Entry)}
+	data, err := os.ReadFile(filepath.Join(dir, StateFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault state: %w", err)
+	}
+	if err := json.Unmarshal(data, st); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", StateFile, err)
+	}
+	if st.Notes == nil {
+		st.Notes = make(map[string]Entry)
+	}
+	return st, nil
+}
+
+// Save は状態ファイルを書き込む（一時ファイルに書いてから置き換える）
+func (s *State) Save(dir string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(dir, StateFile+".tmp")
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write vault state: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, StateFile)); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write vault state: %w", err)
+	}
+	return nil
+}
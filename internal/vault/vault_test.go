@@ -0,0 +1,145 @@
+package vault
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestRenderParse は書き出したmarkdownを読み戻せることをテスト
+func TestRenderParse(t *testing.T) {
+	n := &Note{
+		ID:        "0b7e6c1a-2f4d-4e8a-9c3b-5d1f2a3b4c5d",
+		Title:     "Deploy: notes #1",
+		GroupID:   "design",
+		Tags:      []string{"ops", "2024", "release notes"},
+		Source:    "https://example.com/a",
+		CreatedAt: "2024-03-01T00:00:00Z",
+		Text:      "## Steps\n\n1. build\n2. deploy",
+	}
+	data := Render(n)
+	want := `---
+id: 0b7e6c1a-2f4d-4e8a-9c3b-5d1f2a3b4c5d
+title: "Deploy: notes #1"
+group: design
+tags: [ops, "2024", release notes]
+source: "https://example.com/a"
+createdAt: "2024-03-01T00:00:00Z"
+---
+
+## Steps
+
+1. build
+2. deploy
+`
+	if string(data) != want {
+		t.Errorf("Render =\n%s\nwant\n%s", data, want)
+	}
+	if got := Parse(data); !reflect.DeepEqual(got, n) {
+		t.Errorf("Parse = %+v, want %+v", got, n)
+	}
+
+	// フロントマターのないファイル（CRLF）は全体が本文
+	if got := Parse([]byte("# Memo\r\n\r\nwritten by hand\r\n")); got.ID != "" || got.Text != "# Memo\n\nwritten by hand" {
+		t.Errorf("unexpected note: %+v", got)
+	}
+}
+
+func TestFileName(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Auth design", "Auth design"},
+		{"a/b: c?", "a-b- c-"},
+		{"..hidden\nnote", "hidden note"},
+		{"", "id-1"},
+		{strings.Repeat("あ", 100), strings.Repeat("あ", maxFileNameLength)},
+	}
+	for _, tt := range tests {
+		if got := FileName(tt.title, "id-1"); got != tt.want {
+			t.Errorf("FileName(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+
+	n := &Note{ID: "0b7e6c1a-2f4d", Title: "Auth", GroupID: "design"}
+	if got := NotePath(n, func(string) bool { return false }); got != "design/Auth.md" {
+		t.Errorf("NotePath = %q", got)
+	}
+	if got := NotePath(n, func(rel string) bool { return rel == "design/Auth.md" }); got != "design/Auth (0b7e6c1a).md" {
+		t.Errorf("NotePath with a taken name = %q", got)
+	}
+}
+
+// TestCollect はmarkdown以外と "." で始まるファイル・ディレクトリを除くことをテスト
+func TestCollect(t *testing.T) {
+	dir := t.TempDir()
+	for rel, content := range map[string]string{
+		"design/Auth.md":           "auth",
+		"Inbox.MD":                 "inbox",
+		"image.png":                "png",
+		".obsidian/workspace.md":   "x",
+		".trash/old.md":            "x",
+		StateFile:                  "{}",
+		"design/.draft.md":         "x",
+		"design/nested/Session.md": "session",
+	} {
+		if err := WriteFile(dir, rel, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files, err := Collect(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	if want := []string{"Inbox.MD", "design/Auth.md", "design/nested/Session.md"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("Collect = %v, want %v", paths, want)
+	}
+
+	if err := RemoveFile(dir, "design/Auth.md"); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveFile(dir, "design/Auth.md"); err != nil {
+		t.Errorf("RemoveFile on a missing file: %v", err)
+	}
+}
+
+func TestState(t *testing.T) {
+	dir := t.TempDir()
+	st, err := LoadState(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.ProjectID != "" || len(st.Notes) != 0 {
+		t.Errorf("expected an empty state, got %+v", st)
+	}
+
+	st.ProjectID = "/repo"
+	st.Notes["n1"] = Entry{Path: "design/Auth.md", UpdatedAt: "2024-03-01T00:00:00Z", Hash: Hash([]byte("auth"))}
+	if err := st.Save(dir); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadState(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, st) {
+		t.Errorf("LoadState = %+v, want %+v", loaded, st)
+	}
+	if _, err := os.Stat(filepath.Join(dir, StateFile+".tmp")); !os.IsNotExist(err) {
+		t.Errorf("expected the temporary file to be removed, got %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, StateFile), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadState(dir); err == nil {
+		t.Error("expected an error for a broken state file")
+	}
+}
@@ -0,0 +1,270 @@
+// Package webhook posts note and group lifecycle events to configured HTTP endpoints.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/config"
+	"github.com/brbranch/embedding_mcp/internal/model"
+)
+
+// 送信するHTTPヘッダー
+const (
+	EventHeader     = "X-Mcp-Memory-Event"     // イベント種別（"note.added" など）
+	SignatureHeader = "X-Mcp-Memory-Signature" // "sha256=<本文のHMAC-SHA256（hex）>"（secret設定時のみ）
+)
+
+const (
+	defaultTimeout = 10 * time.Second
+	// queueSize はWebhookごとに溜めておけるイベントの数（溢れたイベントは捨てる）
+	queueSize = 256
+	// maxAttempts は1件のイベントを送る最大回数（接続エラー・429・5xxで再送する）
+	maxAttempts = 3
+)
+
+// Event はWebhookで送る変更（formatがjsonの場合の本文）
+// 本文・値は含めない（受け取った側で必要ならmemory.getなどで取得する）
+type Event struct {
+	Type       string `json:"type"`                 // model.WebhookEvent*
+	Time       string `json:"time"`                 // 変更した時刻（RFC3339、UTC）
+	ProjectID  string `json:"projectId,omitempty"`  // 対象のプロジェクト（正規化済み）
+	GroupID    string `json:"groupId,omitempty"`    // ノートのgroupId、またはグループのgroupKey
+	ID         string `json:"id"`                   // 対象のノート・グループのID
+	Title      string `json:"title,omitempty"`      // ノート・グループのタイトル（分かる場合のみ）
+	NewGroupID string `json:"newGroupId,omitempty"` // group.renamedの変更後のgroupKey
+	Notes      int    `json:"notes,omitempty"`      // 一緒に変更・削除したノートの数（group.deleted・group.renamed）
+}
+
+// Dispatcher は変更を設定されたWebhookへ非同期に送る（並行に呼び出してよい）
+// Webhookごとに1つのgoroutineが順に送るため、遅いWebhookが他のWebhookやリクエストの処理を止めない
+type Dispatcher struct {
+	hooks      []*hook
+	client     *http.Client
+	now        func() time.Time
+	retryDelay time.Duration
+	wg         sync.WaitGroup
+
+	// mu はSendとCloseを排他する（Close後のイベントは捨てる）
+	mu     sync.RWMutex
+	closed bool
+}
+
+// hook は1つのWebhookの設定と送信待ちのイベント
+type hook struct {
+	cfg        model.WebhookConfig
+	projectIDs []string // 指定されたprojectIdと正規化後のprojectId
+	timeout    time.Duration
+	queue      chan Event
+}
+
+// New はhooksへ送るDispatcherを生成し、送信用のgoroutineを起動する（設定はconfig.Validateで検証済みであること）
+// 使い終わったらCloseで送信待ちのイベントを送り切る
+func New(hooks []model.WebhookConfig) *Dispatcher {
+	d := &Dispatcher{client: &http.Client{}, now: time.Now, retryDelay: time.Second}
+	for _, cfg := range hooks {
+		h := &hook{cfg: cfg, timeout: defaultTimeout, queue: make(chan Event, queueSize)}
+		if cfg.Timeout != "" {
+			if t, err := config.ParseDuration(cfg.Timeout); err == nil && t > 0 {
+				h.timeout = t
+			}
+		}
+		// ノートのprojectIdは正規化済みのため、設定のprojectIdも正規化して照合する
+		for _, id := range cfg.ProjectIDs {
+			h.projectIDs = append(h.projectIDs, id)
+			if canonical, err := config.CanonicalizeProjectID(id); err == nil && canonical != id {
+				h.projectIDs = append(h.projectIDs, canonical)
+			}
+		}
+		d.hooks = append(d.hooks, h)
+	}
+	for _, h := range d.hooks {
+		d.wg.Add(1)
+		go d.run(h)
+	}
+	return d
+}
+
+// Send はevに一致するWebhookへの送信を予約する（送信を待たない）
+// Timeが空なら現在時刻を使う。送信待ちが溢れたWebhookへのイベントは捨てて警告をログに出す
+func (d *Dispatcher) Send(ev Event) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return
+	}
+	if ev.Time == "" {
+		ev.Time = d.now().UTC().Format(time.RFC3339)
+	}
+	for _, h := range d.hooks {
+		if !h.matches(ev) {
+			continue
+		}
+		select {
+		case h.queue <- ev:
+		default:
+			slog.Warn("webhook queue is full, dropping event", "url", redactURL(h.cfg.URL), "type", ev.Type, "id", ev.ID)
+		}
+	}
+}
+
+// Close は新しいイベントの受け付けをやめ、送信待ちのイベントを送り終えるまで（最大でctxの期限まで）待つ
+func (d *Dispatcher) Close(ctx context.Context) error {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		for _, h := range d.hooks {
+			close(h.queue)
+		}
+	}
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("webhook deliveries did not finish: %w", ctx.Err())
+	}
+}
+
+// matches はhの対象のイベントかを返す
+func (h *hook) matches(ev Event) bool {
+	if len(h.cfg.Events) > 0 && !slices.Contains(h.cfg.Events, ev.Type) {
+		return false
+	}
+	if len(h.projectIDs) > 0 && !slices.Contains(h.projectIDs, ev.ProjectID) {
+		return false
+	}
+	if len(h.cfg.GroupIDs) > 0 && !slices.Contains(h.cfg.GroupIDs, ev.GroupID) &&
+		(ev.NewGroupID == "" || !slices.Contains(h.cfg.GroupIDs, ev.NewGroupID)) {
+		return false
+	}
+	return true
+}
+
+// run はhの送信待ちのイベントを順に送る（queueが閉じられるまで）
+func (d *Dispatcher) run(h *hook) {
+	defer d.wg.Done()
+	for ev := range h.queue {
+		if err := d.deliver(h, ev); err != nil {
+			slog.Warn("failed to deliver webhook", "url", redactURL(h.cfg.URL), "type", ev.Type, "id", ev.ID, "error", err)
+		}
+	}
+}
+
+// deliver はevをhへ送る（接続エラー・429・5xxの場合はretryDelayずつ間隔を延ばしてmaxAttempts回まで送る）
+func (d *Dispatcher) deliver(h *hook, ev Event) error {
+	body, err := Payload(h.cfg.Format, ev)
+	if err != nil {
+		return err
+	}
+	var lastErr error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(d.retryDelay * time.Duration(attempt))
+		}
+		retry, err := d.post(h, ev.Type, body)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retry {
+			break
+		}
+	}
+	return lastErr
+}
+
+// post はbodyを1回送り、失敗した場合は再送してよいかとエラーを返す
+func (d *Dispatcher) post(h *hook, eventType string, body []byte) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, eventType)
+	if h.cfg.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(h.cfg.Secret, body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("unexpected status %s", resp.Status)
+}
+
+// Payload はevをformat（model.WebhookFormat*、空はjson）の本文にする
+func Payload(format string, ev Event) ([]byte, error) {
+	if format == model.WebhookFormatSlack {
+		return json.Marshal(map[string]string{"text": slackText(ev)})
+	}
+	return json.Marshal(ev)
+}
+
+// slackText はSlackのIncoming Webhookに投稿する1行の文章を返す
+func slackText(ev Event) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s]", ev.Type)
+	if ev.Title != "" {
+		fmt.Fprintf(&b, " %s", ev.Title)
+	}
+	fmt.Fprintf(&b, " (id: %s", ev.ID)
+	if ev.GroupID != "" {
+		fmt.Fprintf(&b, ", group: %s", ev.GroupID)
+		if ev.NewGroupID != "" {
+			fmt.Fprintf(&b, " -> %s", ev.NewGroupID)
+		}
+	}
+	if ev.ProjectID != "" {
+		fmt.Fprintf(&b, ", project: %s", ev.ProjectID)
+	}
+	b.WriteString(")")
+	return b.String()
+}
+
+// Sign はbodyのHMAC-SHA256署名をSignatureHeaderの値（"sha256=<hex>"）で返す
+// 受け取った側は同じsecretで本文から計算した値とhmac.Equalで比較する
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// redactURL はログに出すためURLのパス以降（SlackのWebhookでは鍵にあたる）を省く
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "(invalid url)"
+	}
+	if u.Path == "" || u.Path == "/" {
+		return u.Scheme + "://" + u.Host
+	}
+	return u.Scheme + "://" + u.Host + "/..."
+}
@@ -0,0 +1,190 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/brbranch/embedding_mcp/internal/model"
+)
+
+// received は受信したリクエスト
+type received struct {
+	event     string
+	signature string
+	body      []byte
+}
+
+// newReceiver はリクエストを記録し、statusesの順（尽きたら200）に応答するサーバーを返す
+func newReceiver(t *testing.T, statuses ...int) (*httptest.Server, func() []received) {
+	t.Helper()
+	var mu sync.Mutex
+	var got []received
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		got = append(got, received{event: r.Header.Get(EventHeader), signature: r.Header.Get(SignatureHeader), body: body})
+		n := len(got)
+		mu.Unlock()
+		if n <= len(statuses) {
+			w.WriteHeader(statuses[n-1])
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []received {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]received(nil), got...)
+	}
+}
+
+func closeDispatcher(t *testing.T, d *Dispatcher) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := d.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestDispatcher_Send は一致するイベントが署名付きで送られることをテスト
+func TestDispatcher_Send(t *testing.T) {
+	srv, got := newReceiver(t)
+	d := New([]model.WebhookConfig{{
+		URL:        srv.URL + "/hook",
+		Secret:     "s3cret",
+		Events:     []string{model.WebhookEventNoteAdded, model.WebhookEventGroupRenamed},
+		ProjectIDs: []string{"/repo/a"},
+		GroupIDs:   []string{"decisions"},
+	}})
+	d.now = func() time.Time { return time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC) }
+
+	d.Send(Event{Type: model.WebhookEventNoteAdded, ProjectID: "/repo/a", GroupID: "decisions", ID: "n1", Title: "Use JWT"})
+	d.Send(Event{Type: model.WebhookEventNoteDeleted, ProjectID: "/repo/a", GroupID: "decisions", ID: "n2"}) // event
+	d.Send(Event{Type: model.WebhookEventNoteAdded, ProjectID: "/repo/b", GroupID: "decisions", ID: "n3"})   // project
+	d.Send(Event{Type: model.WebhookEventNoteAdded, ProjectID: "/repo/a", GroupID: "global", ID: "n4"})      // group
+	// 変更後のgroupKeyが一致するrenameも送る
+	d.Send(Event{Type: model.WebhookEventGroupRenamed, ProjectID: "/repo/a", GroupID: "adr", NewGroupID: "decisions", ID: "g1"})
+	closeDispatcher(t, d)
+
+	reqs := got()
+	if len(reqs) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(reqs))
+	}
+	if reqs[0].event != model.WebhookEventNoteAdded || reqs[0].signature != Sign("s3cret", reqs[0].body) {
+		t.Errorf("unexpected headers: %+v", reqs[0])
+	}
+	var ev Event
+	if err := json.Unmarshal(reqs[0].body, &ev); err != nil {
+		t.Fatal(err)
+	}
+	want := Event{Type: model.WebhookEventNoteAdded, Time: "2024-01-15T10:30:00Z", ProjectID: "/repo/a", GroupID: "decisions", ID: "n1", Title: "Use JWT"}
+	if ev != want {
+		t.Errorf("payload = %+v, want %+v", ev, want)
+	}
+	if reqs[1].event != model.WebhookEventGroupRenamed {
+		t.Errorf("expected group.renamed, got %s", reqs[1].event)
+	}
+}
+
+// TestDispatcher_Retry は5xxは再送し、4xxは再送しないことをテスト
+func TestDispatcher_Retry(t *testing.T) {
+	srv, got := newReceiver(t, http.StatusServiceUnavailable, http.StatusBadGateway)
+	d := New([]model.WebhookConfig{{URL: srv.URL}})
+	d.retryDelay = time.Millisecond
+	d.Send(Event{Type: model.WebhookEventNoteUpdated, ID: "n1"})
+	closeDispatcher(t, d)
+	if reqs := got(); len(reqs) != 3 || reqs[0].signature != "" {
+		t.Errorf("expected 3 unsigned attempts, got %+v", reqs)
+	}
+
+	srv, got = newReceiver(t, http.StatusBadRequest)
+	d = New([]model.WebhookConfig{{URL: srv.URL}})
+	d.retryDelay = time.Millisecond
+	d.Send(Event{Type: model.WebhookEventNoteUpdated, ID: "n1"})
+	closeDispatcher(t, d)
+	if reqs := got(); len(reqs) != 1 {
+		t.Errorf("expected 1 attempt, got %d", len(reqs))
+	}
+}
+
+// TestDispatcher_SlowHook は遅いWebhookがSend・他のWebhookを待たせないことをテスト
+func TestDispatcher_SlowHook(t *testing.T) {
+	release := make(chan struct{})
+	var slowCalls atomic.Int32
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		slowCalls.Add(1)
+		<-release
+	}))
+	defer slow.Close()
+	fast, got := newReceiver(t)
+
+	d := New([]model.WebhookConfig{{URL: slow.URL}, {URL: fast.URL}})
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		d.Send(Event{Type: model.WebhookEventNoteAdded, ID: "n"})
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Send blocked for %s", elapsed)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(got()) < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := len(got()); n != 3 {
+		t.Errorf("expected the fast hook to receive 3 events, got %d", n)
+	}
+
+	// 送り終わらなければctxの期限で諦める
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := d.Close(ctx); err == nil {
+		t.Error("expected Close to time out")
+	}
+	close(release)
+	closeDispatcher(t, d)
+	d.Send(Event{Type: model.WebhookEventNoteAdded, ID: "after-close"}) // 捨てられる（panicしない）
+	if n := slowCalls.Load(); n != 3 {
+		t.Errorf("expected 3 requests to the slow hook, got %d", n)
+	}
+}
+
+func TestPayload_Slack(t *testing.T) {
+	body, err := Payload(model.WebhookFormatSlack, Event{
+		Type: model.WebhookEventGroupRenamed, ProjectID: "/repo/a", GroupID: "adr", NewGroupID: "decisions", ID: "g1", Title: "Decisions",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg map[string]string
+	if err := json.Unmarshal(body, &msg); err != nil {
+		t.Fatal(err)
+	}
+	want := "[group.renamed] Decisions (id: g1, group: adr -> decisions, project: /repo/a)"
+	if len(msg) != 1 || msg["text"] != want {
+		t.Errorf("unexpected payload: %s", body)
+	}
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '{"type":"note.added"}' | openssl dgst -sha256 -hmac secret
+	got := Sign("secret", []byte(`{"type":"note.added"}`))
+	if got != "sha256=f4d6a8d85d0c39671bcec1837aa916e37c3951926c16d770f1130e910c64e851" {
+		t.Errorf("unexpected signature: %s", got)
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	if got := redactURL("https://hooks.slack.com/services/T000/B000/XXX"); got != "https://hooks.slack.com/..." {
+		t.Errorf("unexpected: %s", got)
+	}
+	if got := redactURL("http://localhost:8080"); got != "http://localhost:8080" {
+		t.Errorf("unexpected: %s", got)
+	}
+}